@@ -0,0 +1,79 @@
+// Command libnld builds the core operations of nld as a C shared library,
+// so that services in other languages can call them without running the
+// nld executable:
+//
+//	go build -buildmode=c-shared -o libnld.so ./cmd/libnld
+//
+// which also writes the header libnld.h. Every function takes a request as
+// a NUL-terminated JSON string and returns a JSON response that the caller
+// must release with NLDFree:
+//
+//	char *NLDValidate(char *request);  // {"document": {...}}
+//	char *NLDRender(char *request);    // {"document": {...}, "format": "html"}
+//	char *NLDConvert(char *request);   // {"format": "markdown", "text": "..."}
+//	void NLDFree(char *response);
+//	int NLDAPIVersion(void);
+//
+// Failures are reported in the "error" field of the response rather than
+// by the return value, which is never NULL.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/colemalphrus/nld/internal/core"
+)
+
+// NLDValidate validates a document against the schema built into nld for
+// its type
+//
+//export NLDValidate
+func NLDValidate(request *C.char) *C.char {
+	return call(core.ValidateJSON, request)
+}
+
+// NLDRender renders a document as markdown, html or text
+//
+//export NLDRender
+func NLDRender(request *C.char) *C.char {
+	return call(core.RenderJSON, request)
+}
+
+// NLDConvert imports a document from another format into NLD
+//
+//export NLDConvert
+func NLDConvert(request *C.char) *C.char {
+	return call(core.ConvertJSON, request)
+}
+
+// NLDFree releases a response returned by the other functions
+//
+//export NLDFree
+func NLDFree(response *C.char) {
+	C.free(unsafe.Pointer(response))
+}
+
+// NLDAPIVersion returns the version of the JSON calling convention, which
+// changes only when a change would break existing callers
+//
+//export NLDAPIVersion
+func NLDAPIVersion() C.int {
+	return C.int(core.APIVersion)
+}
+
+// call runs an operation on a C request and returns its response as a C
+// string allocated with malloc
+func call(op func(request []byte) []byte, request *C.char) *C.char {
+	var data []byte
+	if request != nil {
+		data = []byte(C.GoString(request))
+	}
+	return C.CString(string(op(data)))
+}
+
+func main() {}
@@ -0,0 +1,106 @@
+//go:build js && wasm
+
+// Command nld-wasm exposes the NLD validator and renderer to JavaScript.
+// Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o nld.wasm ./cmd/nld-wasm
+//
+// and load nld.wasm with the wasm_exec.js shipped with Go. The module
+// defines a global nld object:
+//
+//	nld.validate(json)          // {valid, errors: [{field, message, line, column}], warnings}
+//	nld.render(json, {format})  // {output} with format markdown, html or text
+//
+// Both functions return {error} when the document cannot be processed.
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/colemalphrus/nld/internal/core"
+	"github.com/colemalphrus/nld/internal/i18n"
+	"github.com/colemalphrus/nld/internal/render"
+)
+
+func main() {
+	js.Global().Set("nld", js.ValueOf(map[string]interface{}{
+		"validate": js.FuncOf(validate),
+		"render":   js.FuncOf(renderDocument),
+	}))
+	// Keep the functions available to JavaScript
+	select {}
+}
+
+// validate validates the document JSON in args[0]
+func validate(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return failure("validate expects the document as a JSON string")
+	}
+	result, err := core.Validate([]byte(args[0].String()))
+	if err != nil {
+		return failure(err.Error())
+	}
+
+	errs := []interface{}{}
+	for _, e := range result.Errors {
+		errs = append(errs, map[string]interface{}{
+			"field":   e.Field,
+			"message": e.Message,
+			"line":    e.Line,
+			"column":  e.Column,
+		})
+	}
+	warnings := []interface{}{}
+	for _, w := range result.Warnings {
+		warnings = append(warnings, map[string]interface{}{
+			"field":   w.Field,
+			"message": w.Message,
+		})
+	}
+	return map[string]interface{}{
+		"valid":    result.Valid,
+		"errors":   errs,
+		"warnings": warnings,
+	}
+}
+
+// renderDocument renders the document JSON in args[0] with the options in
+// args[1]: format, lang, locales and unnumbered
+func renderDocument(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return failure("render expects the document as a JSON string")
+	}
+	opts := render.Options{Format: render.HTML}
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		o := args[1]
+		if v := o.Get("format"); v.Type() == js.TypeString {
+			opts.Format = v.String()
+		}
+		if v := o.Get("unnumbered"); v.Type() == js.TypeBoolean {
+			opts.Unnumbered = v.Bool()
+		}
+		if v := o.Get("lang"); v.Type() == js.TypeString {
+			tr, err := i18n.Load(v.String())
+			if err != nil {
+				return failure(err.Error())
+			}
+			opts.Lang = tr
+		}
+		if v := o.Get("locales"); v.Type() == js.TypeObject {
+			for i := 0; i < v.Length(); i++ {
+				opts.Locales = append(opts.Locales, v.Index(i).String())
+			}
+		}
+	}
+
+	out, err := core.Render([]byte(args[0].String()), opts)
+	if err != nil {
+		return failure(err.Error())
+	}
+	return map[string]interface{}{"output": string(out)}
+}
+
+// failure is the result of a call that failed
+func failure(message string) map[string]interface{} {
+	return map[string]interface{}{"error": message}
+}
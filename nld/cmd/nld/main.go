@@ -1,16 +1,7 @@
 package main
 
-import (
-	"fmt"
-	"os"
-
-	"github.com/colemalphrus/nld/internal/cli"
-)
+import "github.com/colemalphrus/nld/pkg/nldcli"
 
 func main() {
-	c := cli.New()
-	if err := c.Execute(os.Args[1:]); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-}
\ No newline at end of file
+	nldcli.Main()
+}
@@ -0,0 +1,253 @@
+// Package anchor submits the root hashes of documents to a transparency
+// log, such as Rekor, and records the entry with its Merkle inclusion
+// proof in verification.anchors, so that anyone can later check offline
+// that the hash was logged.
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+// RecordKey is the key of the anchors inside the verification object
+const RecordKey = "anchors"
+
+// Log is a transparency log
+type Log interface {
+	// Name is the name of the log provider, such as "rekor"
+	Name() string
+	// Submit adds the root hash of a document to the log and returns its
+	// entry, with the proof that the entry is included in the log
+	Submit(ctx context.Context, root string) (*Anchor, error)
+}
+
+// Options configure a log
+type Options struct {
+	// URL is the address of the log, the public instance by default when
+	// the provider has one
+	URL string
+	// Signer signs the hash submitted, for logs that only accept signed
+	// entries
+	Signer crypto.Signer
+	// Client sends the requests to the log
+	Client *http.Client
+}
+
+// Anchor is an entry of a transparency log holding the root hash of a
+// document
+type Anchor struct {
+	// Log is the name of the log provider and URL its address
+	Log string `json:"log"`
+	URL string `json:"url"`
+	// Hash is the root hash of the document logged
+	Hash    string `json:"hash"`
+	EntryID string `json:"entryId,omitempty"`
+	// LogIndex is the index of the entry in the log
+	LogIndex int64 `json:"logIndex"`
+	// Integrated is when the log added the entry
+	Integrated *time.Time `json:"integrated,omitempty"`
+	// Body is the leaf of the entry in the Merkle tree, base64 encoded,
+	// for logs whose leaves are not the hash itself
+	Body  string `json:"body,omitempty"`
+	Proof Proof  `json:"proof"`
+}
+
+// Proof is an RFC 6962 inclusion proof of a leaf in a Merkle tree
+type Proof struct {
+	// LogIndex is the index of the leaf in the tree the proof is for
+	LogIndex int64 `json:"logIndex"`
+	TreeSize int64 `json:"treeSize"`
+	// RootHash and Hashes are hex encoded
+	RootHash string   `json:"rootHash"`
+	Hashes   []string `json:"hashes"`
+	// Checkpoint is the signed tree head of the log, when it gives one
+	Checkpoint string `json:"checkpoint,omitempty"`
+}
+
+// factories create the logs by name
+var factories = map[string]func(opts *Options) (Log, error){}
+
+// register makes a log provider available to New
+func register(name string, factory func(opts *Options) (Log, error)) {
+	factories[name] = factory
+}
+
+// Providers returns the names of the log providers, sorted
+func Providers() []string {
+	var names []string
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns the log of a provider
+func New(name string, opts *Options) (Log, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown transparency log %q (expected %s)", name, strings.Join(Providers(), " or "))
+	}
+	return factory(opts)
+}
+
+// Load returns the anchors recorded in doc
+func Load(doc document.Document) ([]Anchor, error) {
+	verification := doc.Verification(false)
+	if verification == nil {
+		return nil, nil
+	}
+	raw, ok := verification[RecordKey]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid anchors: %w", err)
+	}
+	var anchors []Anchor
+	if err := json.Unmarshal(data, &anchors); err != nil {
+		return nil, fmt.Errorf("invalid anchors: %w", err)
+	}
+	return anchors, nil
+}
+
+// Add appends an anchor to those recorded in doc
+func Add(doc document.Document, a *Anchor) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to encode anchor: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to encode anchor: %w", err)
+	}
+	document.Append(doc.Verification(true), RecordKey, raw)
+	return nil
+}
+
+// Verify checks offline that an anchor holds its hash and that its entry is
+// included in the tree of its proof
+func Verify(a *Anchor) error {
+	leaf := []byte(a.Hash)
+	if a.Body != "" {
+		body, err := base64.StdEncoding.DecodeString(a.Body)
+		if err != nil {
+			return fmt.Errorf("invalid entry body")
+		}
+		if err := checkBody(a.Log, body, a.Hash); err != nil {
+			return err
+		}
+		leaf = body
+	}
+	return VerifyInclusion(leaf, a.Proof)
+}
+
+// checkBody checks that the body of an entry holds hash
+func checkBody(log string, body []byte, hash string) error {
+	switch log {
+	case Rekor:
+		value, err := rekorHash(body)
+		if err != nil {
+			return err
+		}
+		if "sha256:"+value != hash {
+			return fmt.Errorf("entry holds hash sha256:%s, not %s", value, hash)
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot read entries of log %q", log)
+}
+
+// VerifyInclusion checks an RFC 6962 inclusion proof of leaf, computing the
+// root hash of the tree from the proof
+func VerifyInclusion(leaf []byte, p Proof) error {
+	if p.LogIndex < 0 || p.LogIndex >= p.TreeSize {
+		return fmt.Errorf("invalid inclusion proof: index %d is not in a tree of size %d", p.LogIndex, p.TreeSize)
+	}
+	root, err := hex.DecodeString(p.RootHash)
+	if err != nil {
+		return fmt.Errorf("invalid inclusion proof: bad root hash")
+	}
+	// RFC 9162, section 2.1.3.2
+	fn, sn := p.LogIndex, p.TreeSize-1
+	r := leafHash(leaf)
+	for _, encoded := range p.Hashes {
+		h, err := hex.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("invalid inclusion proof: bad hash %q", encoded)
+		}
+		if sn == 0 {
+			return fmt.Errorf("invalid inclusion proof: too many hashes")
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(h, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = nodeHash(r, h)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return fmt.Errorf("invalid inclusion proof: too few hashes")
+	}
+	if !bytes.Equal(r, root) {
+		return fmt.Errorf("inclusion proof does not lead to root hash %s", p.RootHash)
+	}
+	return nil
+}
+
+// leafHash is the hash of a leaf of a Merkle tree
+func leafHash(leaf []byte) []byte {
+	h := sha256.Sum256(append([]byte{0}, leaf...))
+	return h[:]
+}
+
+// nodeHash is the hash of an interior node of a Merkle tree
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// do sends a request and decodes the JSON response into out
+func do(client *http.Client, req *http.Request, out interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package anchor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const root = "sha256:0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+
+// treeHash is the RFC 6962 Merkle tree hash of leaves
+func treeHash(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return leafHash(leaves[0])
+	}
+	k := split(len(leaves))
+	return nodeHash(treeHash(leaves[:k]), treeHash(leaves[k:]))
+}
+
+// path is the RFC 6962 inclusion proof of leaf m
+func path(m int, leaves [][]byte) []string {
+	if len(leaves) == 1 {
+		return nil
+	}
+	k := split(len(leaves))
+	if m < k {
+		return append(path(m, leaves[:k]), hex.EncodeToString(treeHash(leaves[k:])))
+	}
+	return append(path(m-k, leaves[k:]), hex.EncodeToString(treeHash(leaves[:k])))
+}
+
+// split is the largest power of two smaller than n
+func split(n int) int {
+	k := 1
+	for k*2 < n {
+		k *= 2
+	}
+	return k
+}
+
+// proof returns the inclusion proof of leaf m of leaves
+func proof(m int, leaves [][]byte) Proof {
+	return Proof{LogIndex: int64(m), TreeSize: int64(len(leaves)), RootHash: hex.EncodeToString(treeHash(leaves)), Hashes: path(m, leaves)}
+}
+
+func TestVerifyInclusion(t *testing.T) {
+	var leaves [][]byte
+	for n := 1; n <= 13; n++ {
+		leaves = append(leaves, []byte(fmt.Sprintf("leaf %d", n)))
+		for m := 0; m < n; m++ {
+			p := proof(m, leaves)
+			if err := VerifyInclusion(leaves[m], p); err != nil {
+				t.Fatalf("VerifyInclusion of leaf %d of %d failed: %v", m, n, err)
+			}
+			if err := VerifyInclusion([]byte("other"), p); err == nil {
+				t.Errorf("VerifyInclusion of another leaf %d of %d succeeded", m, n)
+			}
+			if n > 1 {
+				short := p
+				short.Hashes = p.Hashes[1:]
+				if err := VerifyInclusion(leaves[m], short); err == nil {
+					t.Errorf("VerifyInclusion of a short proof of leaf %d of %d succeeded", m, n)
+				}
+				moved := p
+				moved.LogIndex = int64((m + 1) % n)
+				if err := VerifyInclusion(leaves[m], moved); err == nil {
+					t.Errorf("VerifyInclusion of leaf %d of %d at another index succeeded", m, n)
+				}
+			}
+		}
+	}
+	if err := VerifyInclusion(leaves[0], Proof{LogIndex: 3, TreeSize: 3}); err == nil {
+		t.Error("VerifyInclusion of an index outside the tree succeeded")
+	}
+}
+
+func TestRekor(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leaves := [][]byte{[]byte("first"), []byte("second")}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/log/entries" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var entry struct {
+			Kind string
+			Spec struct {
+				Data struct {
+					Hash struct{ Algorithm, Value string }
+				}
+				Signature struct {
+					Content   []byte
+					PublicKey struct{ Content []byte }
+				}
+			}
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &entry)
+		block, _ := pem.Decode(entry.Spec.Signature.PublicKey.Content)
+		if block == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		public, _ := x509.ParsePKIXPublicKey(block.Bytes)
+		digest, _ := hex.DecodeString(entry.Spec.Data.Hash.Value)
+		if entry.Kind != "hashedrekord" || !ecdsa.VerifyASN1(public.(*ecdsa.PublicKey), digest, entry.Spec.Signature.Content) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		leaves = append(leaves, body)
+		m := len(leaves) - 1
+		p := proof(m, leaves)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"24296fb24b8ad77a": map[string]interface{}{
+				"body":           base64.StdEncoding.EncodeToString(body),
+				"integratedTime": 1740823200,
+				"logIndex":       1000 + m,
+				"verification":   map[string]interface{}{"inclusionProof": p},
+			},
+		})
+	}))
+	defer server.Close()
+
+	if _, err := New(Rekor, &Options{URL: server.URL}); err == nil {
+		t.Error("New without a signer succeeded")
+	}
+	_, ed, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := New(Rekor, &Options{URL: server.URL, Signer: ed}); err == nil {
+		t.Error("New with an Ed25519 signer succeeded")
+	}
+	log, err := New(Rekor, &Options{URL: server.URL, Signer: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := log.Submit(context.Background(), root)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if a.Log != Rekor || a.EntryID != "24296fb24b8ad77a" || a.LogIndex != 1002 || a.Integrated.Unix() != 1740823200 || a.Proof.TreeSize != 3 {
+		t.Errorf("Submit = %+v", a)
+	}
+
+	// Anchors are recorded in the document and verified offline
+	doc := document.Document{}
+	if err := Add(doc, a); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := doc.Marshal()
+	doc, _ = document.Parse(data)
+	anchors, err := Load(doc)
+	if err != nil || len(anchors) != 1 {
+		t.Fatalf("Load = %v, %v", anchors, err)
+	}
+	if err := Verify(&anchors[0]); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+	other := anchors[0]
+	other.Hash = strings.Replace(root, "0f", "1f", 1)
+	if err := Verify(&other); err == nil || !strings.Contains(err.Error(), "entry holds hash") {
+		t.Errorf("Verify of another hash error = %v", err)
+	}
+	other = anchors[0]
+	other.Proof.RootHash = hex.EncodeToString(treeHash(leaves[:2]))
+	if err := Verify(&other); err == nil {
+		t.Error("Verify with another tree root succeeded")
+	}
+
+	if _, err := log.Submit(context.Background(), "md5:00"); err == nil {
+		t.Error("Submit of an invalid hash succeeded")
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	leaves := [][]byte{[]byte("first")}
+	lie := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct{ Hash string }
+		json.NewDecoder(r.Body).Decode(&in)
+		leaves = append(leaves, []byte(in.Hash))
+		m := len(leaves) - 1
+		p := proof(m, leaves)
+		if lie {
+			p.Hashes = nil
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"entryId": fmt.Sprint(m), "logIndex": m, "integrated": "2025-03-01T10:00:00Z", "proof": p})
+	}))
+	defer server.Close()
+
+	if _, err := New(Endpoint, &Options{}); err == nil {
+		t.Error("New without a URL succeeded")
+	}
+	log, _ := New(Endpoint, &Options{URL: server.URL})
+	a, err := log.Submit(context.Background(), root)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if a.Log != Endpoint || a.Hash != root || a.LogIndex != 1 || a.Integrated == nil || Verify(a) != nil {
+		t.Errorf("Submit = %+v", a)
+	}
+	lie = true
+	if _, err := log.Submit(context.Background(), root); err == nil || !strings.Contains(err.Error(), "inclusion proof") {
+		t.Errorf("Submit with a bad proof error = %v", err)
+	}
+	if _, err := New("blockchain", &Options{}); err == nil {
+		t.Error("New of an unknown log succeeded")
+	}
+}
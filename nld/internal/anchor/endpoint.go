@@ -0,0 +1,58 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Endpoint is the provider of logs reached at a configured URL. The root
+// hash is posted as {"hash": "sha256:..."} and the log answers with the
+// entry:
+//
+//	{"entryId": "...", "logIndex": 42, "integrated": "2025-03-01T10:00:00Z",
+//	 "proof": {"logIndex": 42, "treeSize": 100, "rootHash": "<hex>",
+//	           "hashes": ["<hex>", ...], "checkpoint": "..."}}
+//
+// The leaf of the entry in the Merkle tree is the root hash as a string.
+const Endpoint = "http"
+
+func init() {
+	register(Endpoint, func(opts *Options) (Log, error) {
+		if opts.URL == "" {
+			return nil, fmt.Errorf("the %s log needs a URL", Endpoint)
+		}
+		return &endpoint{url: opts.URL, client: opts.Client}, nil
+	})
+}
+
+// endpoint is a log at a configured URL
+type endpoint struct {
+	url    string
+	client *http.Client
+}
+
+func (e *endpoint) Name() string { return Endpoint }
+
+func (e *endpoint) Submit(ctx context.Context, root string) (*Anchor, error) {
+	body, err := json.Marshal(map[string]string{"hash": root})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var a Anchor
+	if err := do(e.client, req, &a); err != nil {
+		return nil, fmt.Errorf("failed to add entry to %s: %w", e.url, err)
+	}
+	a.Log, a.URL, a.Hash, a.Body = Endpoint, e.url, root, ""
+	if err := Verify(&a); err != nil {
+		return nil, fmt.Errorf("entry %s of %s: %w", a.EntryID, e.url, err)
+	}
+	return &a, nil
+}
@@ -0,0 +1,146 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Rekor is the provider of the Sigstore transparency log
+const Rekor = "rekor"
+
+// RekorDefaultURL is the public instance of Rekor
+const RekorDefaultURL = "https://rekor.sigstore.dev"
+
+func init() {
+	register(Rekor, func(opts *Options) (Log, error) {
+		if opts.Signer == nil {
+			return nil, fmt.Errorf("the %s log only accepts signed entries; give a signing key", Rekor)
+		}
+		if _, ok := opts.Signer.Public().(ed25519.PublicKey); ok {
+			return nil, fmt.Errorf("the %s log does not accept Ed25519 signatures of hashes; use an ECDSA or RSA key", Rekor)
+		}
+		url := opts.URL
+		if url == "" {
+			url = RekorDefaultURL
+		}
+		return &rekor{url: strings.TrimRight(url, "/"), signer: opts.Signer, client: opts.Client}, nil
+	})
+}
+
+// rekor adds hashedrekord entries to a Rekor log through its REST API
+type rekor struct {
+	url    string
+	signer crypto.Signer
+	client *http.Client
+}
+
+func (r *rekor) Name() string { return Rekor }
+
+// rekorEntry is a log entry returned by Rekor
+type rekorEntry struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogIndex       int64  `json:"logIndex"`
+	Verification   struct {
+		InclusionProof *Proof `json:"inclusionProof"`
+	} `json:"verification"`
+}
+
+func (r *rekor) Submit(ctx context.Context, root string) (*Anchor, error) {
+	value, ok := strings.CutPrefix(root, "sha256:")
+	sum, err := hex.DecodeString(value)
+	if !ok || err != nil || len(sum) != 32 {
+		return nil, fmt.Errorf("invalid root hash %q", root)
+	}
+	sig, err := r.signer.Sign(rand.Reader, sum, crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign hash: %w", err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(r.signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]interface{}{
+			"data": map[string]interface{}{"hash": map[string]string{"algorithm": "sha256", "value": value}},
+			"signature": map[string]interface{}{
+				"content":   base64.StdEncoding.EncodeToString(sig),
+				"publicKey": map[string]string{"content": base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}))},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url+"/api/v1/log/entries", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	var entries map[string]rekorEntry
+	if err := do(r.client, req, &entries); err != nil {
+		return nil, fmt.Errorf("failed to add entry to %s: %w", r.url, err)
+	}
+	if len(entries) != 1 {
+		return nil, fmt.Errorf("failed to add entry to %s: %d entries returned", r.url, len(entries))
+	}
+	for uuid, e := range entries {
+		if e.Verification.InclusionProof == nil {
+			return nil, fmt.Errorf("%s returned no inclusion proof for entry %s", r.url, uuid)
+		}
+		integrated := time.Unix(e.IntegratedTime, 0).UTC()
+		a := &Anchor{
+			Log:        Rekor,
+			URL:        r.url,
+			Hash:       root,
+			EntryID:    uuid,
+			LogIndex:   e.LogIndex,
+			Integrated: &integrated,
+			Body:       e.Body,
+			Proof:      *e.Verification.InclusionProof,
+		}
+		if err := Verify(a); err != nil {
+			return nil, fmt.Errorf("entry %s of %s: %w", uuid, r.url, err)
+		}
+		return a, nil
+	}
+	return nil, nil
+}
+
+// rekorHash returns the hex SHA-256 hash held by a hashedrekord entry body
+func rekorHash(body []byte) (string, error) {
+	var entry struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			Data struct {
+				Hash struct {
+					Algorithm string `json:"algorithm"`
+					Value     string `json:"value"`
+				} `json:"hash"`
+			} `json:"data"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return "", fmt.Errorf("invalid entry body: %w", err)
+	}
+	if entry.Kind != "hashedrekord" || entry.Spec.Data.Hash.Algorithm != "sha256" {
+		return "", fmt.Errorf("entry is not a sha256 hashedrekord entry")
+	}
+	return entry.Spec.Data.Hash.Value, nil
+}
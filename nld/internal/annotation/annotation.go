@@ -0,0 +1,144 @@
+// Package annotation manages the comments recorded in the top-level
+// annotations of a document. Annotations refer to sections by ID and, for
+// comments on part of a section, to a range of its plain text content.
+package annotation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Key is the key of the annotations in a document
+const Key = "annotations"
+
+// Load returns the annotations of a document
+func Load(doc document.Document) ([]nld.Annotation, error) {
+	if doc[Key] == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(doc[Key])
+	if err != nil {
+		return nil, err
+	}
+	var annotations []nld.Annotation
+	if err := json.Unmarshal(data, &annotations); err != nil {
+		return nil, fmt.Errorf("invalid annotations: %w", err)
+	}
+	return annotations, nil
+}
+
+// save replaces the annotations of a document
+func save(doc document.Document, annotations []nld.Annotation) error {
+	data, err := json.Marshal(annotations)
+	if err != nil {
+		return err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	doc[Key] = raw
+	return nil
+}
+
+// Add records a new annotation, giving it the next free ID and the current
+// date unless it has one. An annotation with a Quote but no Range is
+// anchored at the first occurrence of the quote in the section; one with a
+// Range gets the text it covers as its Quote.
+func Add(doc document.Document, a nld.Annotation) (nld.Annotation, error) {
+	if strings.TrimSpace(a.Text) == "" {
+		return a, fmt.Errorf("annotation text is required")
+	}
+	section := doc.FindSection(a.Section)
+	if section == nil {
+		return a, fmt.Errorf("section not found: %s", a.Section)
+	}
+
+	if a.Range != nil || a.Quote != "" {
+		content, ok := section["content"].(string)
+		if !ok {
+			return a, fmt.Errorf("section %s has no plain text content to annotate a range of", a.Section)
+		}
+		text := []rune(content)
+		if a.Range == nil {
+			i := strings.Index(content, a.Quote)
+			if i == -1 {
+				return a, fmt.Errorf("text not found in section %s: %q", a.Section, a.Quote)
+			}
+			start := len([]rune(content[:i]))
+			a.Range = &nld.TextRange{Start: start, End: start + len([]rune(a.Quote))}
+		}
+		if a.Range.Start < 0 || a.Range.End <= a.Range.Start || a.Range.End > len(text) {
+			return a, fmt.Errorf("invalid range %d:%d of section %s, which has %d characters", a.Range.Start, a.Range.End, a.Section, len(text))
+		}
+		a.Quote = string(text[a.Range.Start:a.Range.End])
+	}
+
+	annotations, err := Load(doc)
+	if err != nil {
+		return a, err
+	}
+	a.ID = nextID(annotations)
+	if a.Date == "" {
+		a.Date = time.Now().UTC().Format(time.RFC3339)
+	}
+	return a, save(doc, append(annotations, a))
+}
+
+// nextID returns the ID following the highest numbered one, "a1" for the
+// first annotation
+func nextID(annotations []nld.Annotation) string {
+	highest := 0
+	for _, a := range annotations {
+		if n, err := strconv.Atoi(strings.TrimPrefix(a.ID, "a")); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return fmt.Sprintf("a%d", highest+1)
+}
+
+// Resolve marks the annotation with the given ID as resolved, or as open
+// again when resolved is false
+func Resolve(doc document.Document, id string, resolved bool) error {
+	annotations, err := Load(doc)
+	if err != nil {
+		return err
+	}
+	for i := range annotations {
+		if annotations[i].ID == id {
+			if annotations[i].Resolved == resolved {
+				state := "open"
+				if resolved {
+					state = "resolved"
+				}
+				return fmt.Errorf("annotation %s is already %s", id, state)
+			}
+			annotations[i].Resolved = resolved
+			return save(doc, annotations)
+		}
+	}
+	return fmt.Errorf("annotation not found: %s", id)
+}
+
+// Stale reports whether the text an annotation was made on has changed
+// since, as when the section was edited or removed in an amendment
+func Stale(doc document.Document, a nld.Annotation) bool {
+	section := doc.FindSection(a.Section)
+	if section == nil {
+		return true
+	}
+	if a.Range == nil {
+		return false
+	}
+	text := []rune(document.String(section, "content"))
+	if a.Range.Start < 0 || a.Range.End > len(text) || a.Range.Start > a.Range.End {
+		return true
+	}
+	return string(text[a.Range.Start:a.Range.End]) != a.Quote
+}
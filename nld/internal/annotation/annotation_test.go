@@ -0,0 +1,114 @@
+package annotation
+
+import (
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const testDocument = `{
+  "metadata": {"type": "contract", "version": "1.0.0", "created": "2025-06-27T12:00:00Z"},
+  "content": {"sections": [
+    {"id": "terms", "title": "Términos", "content": "Payment is due within 30 days of invoice.",
+     "sections": [{"id": "late", "title": "Late Payment", "content": "Interest accrues at 2% a month."}]},
+    {"id": "fees", "title": "Fees", "content": [{"type": "paragraph", "text": "EUR 100"}]}
+  ]}
+}`
+
+func parse(t *testing.T) document.Document {
+	t.Helper()
+	doc, err := document.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	return doc
+}
+
+func TestAdd(t *testing.T) {
+	testCases := []struct {
+		name  string
+		a     nld.Annotation
+		quote string
+		ok    bool
+	}{
+		{"Section", nld.Annotation{Section: "terms", Text: "Agreed"}, "", true},
+		{"Subsection", nld.Annotation{Section: "late", Text: "Too high"}, "", true},
+		{"Quote", nld.Annotation{Section: "terms", Quote: "30 days", Text: "60?"}, "30 days", true},
+		{"Range", nld.Annotation{Section: "terms", Range: &nld.TextRange{Start: 0, End: 7}, Text: "Which payment?"}, "Payment", true},
+		{"Missing Quote", nld.Annotation{Section: "terms", Quote: "90 days", Text: "60?"}, "", false},
+		{"Range Past End", nld.Annotation{Section: "terms", Range: &nld.TextRange{Start: 30, End: 300}, Text: "x"}, "", false},
+		{"Empty Range", nld.Annotation{Section: "terms", Range: &nld.TextRange{Start: 3, End: 3}, Text: "x"}, "", false},
+		{"Blocks", nld.Annotation{Section: "fees", Quote: "EUR", Text: "x"}, "", false},
+		{"Unknown Section", nld.Annotation{Section: "warranty", Text: "x"}, "", false},
+		{"No Text", nld.Annotation{Section: "terms"}, "", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := parse(t)
+			a, err := Add(doc, tc.a)
+			if (err == nil) != tc.ok {
+				t.Fatalf("Add error = %v", err)
+			}
+			annotations, _ := Load(doc)
+			if !tc.ok {
+				if len(annotations) != 0 {
+					t.Errorf("failed Add recorded %+v", annotations)
+				}
+				return
+			}
+			if a.ID != "a1" || a.Date == "" || a.Quote != tc.quote || len(annotations) != 1 || annotations[0].ID != "a1" {
+				t.Errorf("Add = %+v, annotations %+v", a, annotations)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	doc := parse(t)
+	for _, text := range []string{"First", "Second"} {
+		if _, err := Add(doc, nld.Annotation{Section: "terms", Quote: "invoice", Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Resolve(doc, "a2", true); err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if err := Resolve(doc, "a2", true); err == nil {
+		t.Error("resolving a resolved annotation succeeded")
+	}
+	if err := Resolve(doc, "a3", true); err == nil {
+		t.Error("resolving an unknown annotation succeeded")
+	}
+	annotations, _ := Load(doc)
+	if annotations[0].Resolved || !annotations[1].Resolved {
+		t.Errorf("annotations = %+v", annotations)
+	}
+
+	// IDs are not reused once annotations are removed
+	doc[Key] = doc[Key].([]interface{})[1:]
+	if a, _ := Add(doc, nld.Annotation{Section: "terms", Text: "Third"}); a.ID != "a3" {
+		t.Errorf("next ID = %s, expected a3", a.ID)
+	}
+	if err := Resolve(doc, "a2", false); err != nil {
+		t.Errorf("reopening failed: %v", err)
+	}
+}
+
+func TestStale(t *testing.T) {
+	doc := parse(t)
+	a, err := Add(doc, nld.Annotation{Section: "terms", Quote: "30 days", Text: "60?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Stale(doc, a) {
+		t.Error("fresh annotation is stale")
+	}
+	doc.FindSection("terms")["content"] = "Payment is due within 60 days of invoice."
+	if !Stale(doc, a) {
+		t.Error("annotation on edited text is not stale")
+	}
+	if Stale(doc, nld.Annotation{Section: "late"}) || !Stale(doc, nld.Annotation{Section: "warranty"}) {
+		t.Error("section annotations are stale only when the section is removed")
+	}
+}
@@ -0,0 +1,236 @@
+// Package anonymize replaces the names, contact details, amounts and other
+// personal data of a document with pseudonyms, so that real documents can
+// be shared as bug reports or test fixtures. Pseudonyms are derived from
+// the original values with a keyed hash of a seed: the same value gets the
+// same pseudonym throughout a document and across runs with the same seed,
+// and cannot be recovered without it.
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/pii"
+)
+
+// Kinds of values replaced, besides those of pii
+const (
+	Name    = "name"
+	Address = "address"
+)
+
+// Options controls anonymization
+type Options struct {
+	// Seed keys the pseudonyms
+	Seed string
+	// KeepAmounts leaves amounts of money alone, such as those of receipts
+	// whose totals must still add up
+	KeepAmounts bool
+}
+
+// Report summarizes an anonymization
+type Report struct {
+	// Replaced counts the values replaced by kind
+	Replaced map[string]int `json:"replaced"`
+}
+
+// skipKeys are the keys of values that identify or describe the structure
+// of a document rather than hold its text
+var skipKeys = map[string]bool{
+	"id": true, "type": true, "role": true, "status": true, "version": true,
+	"created": true, "date": true, "effective": true, "expires": true,
+	"term": true, "noticePeriod": true, "timezone": true, "language": true,
+	"currency": true, "section": true, "signerId": true, "rate": true,
+	"jurisdiction": true, "revision": true, "hash": true, "root": true,
+	"previous": true, "signers": true, "keyIds": true,
+}
+
+// entityFields are the fields of entities replaced with a pseudonym of
+// their kind
+var entityFields = map[string]string{"name": Name, "email": pii.Email, "phone": pii.Phone, "address": Address}
+
+// replacement is the pseudonym of a known value
+type replacement struct {
+	kind      string
+	pseudonym string
+}
+
+// anonymizer replaces values with their pseudonyms
+type anonymizer struct {
+	opts Options
+	// known maps the values of entity fields to their pseudonyms, so that
+	// they are replaced wherever the text mentions them
+	known  map[string]replacement
+	report *Report
+}
+
+// Apply anonymizes doc in place. Verification data, which holds the
+// signatures and hashes of the original, is cleared.
+func Apply(doc document.Document, opts Options) (*Report, error) {
+	if opts.Seed == "" {
+		return nil, fmt.Errorf("a seed is required")
+	}
+	a := &anonymizer{opts: opts, known: map[string]replacement{}, report: &Report{Replaced: map[string]int{}}}
+
+	meta := doc.Metadata()
+	if meta == nil {
+		return nil, fmt.Errorf("document has no metadata")
+	}
+	entities, _ := meta["entities"].([]interface{})
+	for _, raw := range entities {
+		e, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for field, kind := range entityFields {
+			if v := document.String(e, field); v != "" {
+				a.known[v] = replacement{kind, a.pseudonym(kind, v)}
+				e[field] = a.known[v].pseudonym
+			}
+		}
+	}
+	if author := document.String(meta, "author"); author != "" {
+		a.known[author] = replacement{Name, a.pseudonym(Name, author)}
+		meta["author"] = a.known[author].pseudonym
+	}
+
+	for key, value := range doc {
+		switch key {
+		case "verification":
+			doc[key] = map[string]interface{}{}
+		case "metadata":
+			for k, v := range meta {
+				if k != "entities" && k != "author" {
+					meta[k] = a.walk(k, v)
+				}
+			}
+		default:
+			doc[key] = a.walk(key, value)
+		}
+	}
+	return a.report, nil
+}
+
+// walk returns value, found under key, with its text anonymized
+func (a *anonymizer) walk(key string, value interface{}) interface{} {
+	if skipKeys[key] {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = a.walk(k, item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = a.walk(key, item)
+		}
+	case string:
+		if key == "amount" {
+			return a.amount(v)
+		}
+		if key == "author" {
+			return a.pseudonym(Name, v)
+		}
+		return a.text(v)
+	case json.Number:
+		if key == "amount" {
+			return json.Number(a.amount(v.String()))
+		}
+	}
+	return value
+}
+
+// text replaces the personal data found in s and the mentions of known
+// values. Pseudonyms are derived from the values alone, so an email address
+// found in the text gets the pseudonym of the same address of an entity.
+func (a *anonymizer) text(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range pii.Find(s) {
+		b.WriteString(a.mentions(s[last:m.Start]))
+		if m.Kind == pii.Amount {
+			b.WriteString(a.amount(m.Text))
+		} else {
+			b.WriteString(a.pseudonym(m.Kind, m.Text))
+		}
+		last = m.End
+	}
+	b.WriteString(a.mentions(s[last:]))
+	return b.String()
+}
+
+// mentions replaces the known values mentioned in s
+func (a *anonymizer) mentions(s string) string {
+	// Longer values first, so that "XYZ Ltd" is replaced before "XYZ"
+	known := make([]string, 0, len(a.known))
+	for v := range a.known {
+		if strings.Contains(s, v) {
+			known = append(known, v)
+		}
+	}
+	sort.Slice(known, func(i, j int) bool { return len(known[i]) > len(known[j]) })
+	for _, v := range known {
+		n := strings.Count(s, v)
+		s = strings.ReplaceAll(s, v, a.known[v].pseudonym)
+		a.report.Replaced[a.known[v].kind] += n
+	}
+	return s
+}
+
+// amount replaces the digits of an amount, keeping its currency,
+// separators and number of digits
+func (a *anonymizer) amount(s string) string {
+	if a.opts.KeepAmounts {
+		return s
+	}
+	sum := a.sum(pii.Amount, s)
+	out := []byte(s)
+	first := true
+	for i, c := range out {
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := sum[i%len(sum)] % 10
+		if first {
+			// No leading zeros
+			d = 1 + sum[i%len(sum)]%9
+			first = false
+		}
+		out[i] = '0' + d
+	}
+	a.report.Replaced[pii.Amount]++
+	return string(out)
+}
+
+// pseudonym returns the pseudonym of a value of a kind
+func (a *anonymizer) pseudonym(kind, value string) string {
+	sum := a.sum(kind, value)
+	tag := hex.EncodeToString(sum[:3])
+	a.report.Replaced[kind]++
+	switch kind {
+	case Name:
+		return "Party " + strings.ToUpper(tag)
+	case pii.Email:
+		return "party-" + tag + "@example.com"
+	case pii.Phone:
+		// 555-0100 to 555-0199 are reserved for fictional use
+		return fmt.Sprintf("+1 555 01%02d", int(sum[3])%100)
+	case Address:
+		return fmt.Sprintf("%d Example Street, Sampletown", 1+int(sum[3])%999)
+	}
+	return strings.ToUpper(kind) + "-" + tag
+}
+
+// sum returns the keyed hash of a value of a kind
+func (a *anonymizer) sum(kind, value string) []byte {
+	mac := hmac.New(sha256.New, []byte(a.opts.Seed))
+	mac.Write([]byte(kind + "\x00" + strings.TrimSpace(value)))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,75 @@
+package anonymize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const sample = `{
+  "metadata": {"type": "contract", "version": "1.0", "created": "2025-03-12T00:00:00Z", "author": "Jane Doe",
+    "entities": [{"id": "party1", "name": "XYZ Ltd", "role": "Licensor", "email": "legal@xyz.example", "address": "1 High Street, London"}]},
+  "content": {"sections": [{"id": "fees", "title": "Fees", "content": "XYZ Ltd charges $1,299.99. Contact legal@xyz.example or +44 20 7946 0958."}],
+    "items": [{"id": "fee", "amount": 250}]},
+  "verification": {"signatures": [{"signerId": "party1", "value": "abc"}]}
+}`
+
+func anonymized(t *testing.T, opts Options) (string, *Report) {
+	t.Helper()
+	doc, err := document.Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Apply(doc, opts)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	out, err := doc.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), report
+}
+
+func TestApply(t *testing.T) {
+	out, report := anonymized(t, Options{Seed: "seed"})
+	for _, original := range []string{"XYZ Ltd", "Jane Doe", "legal@xyz.example", "High Street", "7946", "1,299.99", "250", "signatures"} {
+		if strings.Contains(out, original) {
+			t.Errorf("output still contains %q:\n%s", original, out)
+		}
+	}
+	for _, kept := range []string{`"party1"`, `"Licensor"`, `"fees"`, `"2025-03-12T00:00:00Z"`, "example.com", "+1 555 01"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("output does not contain %q:\n%s", kept, out)
+		}
+	}
+	if report.Replaced[Name] == 0 || report.Replaced["amount"] != 2 {
+		t.Errorf("report = %+v", report.Replaced)
+	}
+
+	// The entity name is replaced by the same pseudonym in the text
+	doc, _ := document.Parse([]byte(out))
+	name := document.String(doc.Metadata()["entities"].([]interface{})[0].(map[string]interface{}), "name")
+	if strings.Count(out, name) != 2 {
+		t.Errorf("pseudonym %q not used for the mention of the entity:\n%s", name, out)
+	}
+
+	if again, _ := anonymized(t, Options{Seed: "seed"}); again != out {
+		t.Errorf("output differs with the same seed:\n%s\n%s", out, again)
+	}
+	if other, _ := anonymized(t, Options{Seed: "other"}); other == out {
+		t.Error("output is the same with another seed")
+	}
+}
+
+func TestApplyKeepAmounts(t *testing.T) {
+	out, _ := anonymized(t, Options{Seed: "seed", KeepAmounts: true})
+	if !strings.Contains(out, "$1,299.99") || !strings.Contains(out, `"amount": 250`) {
+		t.Errorf("amounts not kept:\n%s", out)
+	}
+	doc, _ := document.Parse([]byte(sample))
+	if _, err := Apply(doc, Options{}); err == nil {
+		t.Error("Apply without a seed succeeded")
+	}
+}
@@ -0,0 +1,394 @@
+// Package approval runs the approval workflows of nld serve: the documents
+// of a type need the approval of the approvers configured for it before
+// they may be signed. Approvals are recorded in verification.approvals
+// with the root hash they approve, so that changing a document afterwards
+// leaves them stale. Workflows may also require the attestations of third
+// parties, such as a notary, recorded with nld attest.
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/attest"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/internal/workflow"
+	"gopkg.in/yaml.v3"
+)
+
+// RecordKey is the key of the approvals in verification
+const RecordKey = "approvals"
+
+var (
+	// ErrNoWorkflow is wrapped by the errors of approving documents of a
+	// type without a workflow
+	ErrNoWorkflow = errors.New("no approval workflow")
+	// ErrNotApprover is wrapped by the errors of approvals by someone who
+	// is not an approver of the document
+	ErrNotApprover = errors.New("not an approver")
+	// ErrApproved is wrapped by the errors of approving a document twice
+	ErrApproved = errors.New("already approved")
+	// ErrPending is wrapped by the errors of signing a document whose
+	// approvals or attestations are not complete
+	ErrPending = errors.New("approvals pending")
+)
+
+// Workflow lists the approvers of the documents of a type
+type Workflow struct {
+	Type string `yaml:"type"`
+	// Approvers are the names of the clients that approve, as
+	// authenticated by the server
+	Approvers []string `yaml:"approvers"`
+	// Required is the number of approvals needed; defaults to all of the
+	// approvers
+	Required int `yaml:"required"`
+	// Attestations are the roles, such as notary, that must attest the
+	// document
+	Attestations []string `yaml:"attestations"`
+	// Attesters are the key IDs of the attesters whose attestations
+	// count; any key counts when empty
+	Attesters []string `yaml:"attesters"`
+}
+
+// Config is a workflows config file:
+//
+//	workflows:
+//	  - type: contract
+//	    approvers: [legal, finance, sales]
+//	    required: 2
+//	    attestations: [notary]
+//	    attesters: [sha256:...]
+type Config struct {
+	Workflows []Workflow `yaml:"workflows"`
+}
+
+// Load reads a workflows config file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workflows config: %w", err)
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid workflows config %s: %w", path, err)
+	}
+	if err := c.check(); err != nil {
+		return nil, fmt.Errorf("invalid workflows config %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// check validates a config and fills in the defaults
+func (c *Config) check() error {
+	seen := map[string]bool{}
+	for i := range c.Workflows {
+		w := &c.Workflows[i]
+		if w.Type == "" {
+			return fmt.Errorf("workflow %d: no type", i+1)
+		}
+		w.Type = strings.ToLower(w.Type)
+		if seen[w.Type] {
+			return fmt.Errorf("workflow %s: defined twice", w.Type)
+		}
+		seen[w.Type] = true
+		if len(w.Approvers) == 0 && len(w.Attestations) == 0 {
+			return fmt.Errorf("workflow %s: no approvers", w.Type)
+		}
+		for _, role := range w.Attestations {
+			if !attest.ValidRole(role) {
+				return fmt.Errorf("workflow %s: unknown attestation role %q (expected one of: %s)", w.Type, role, strings.Join(attest.Roles(), ", "))
+			}
+		}
+		if w.Required == 0 {
+			w.Required = len(w.Approvers)
+		}
+		if w.Required < 0 || w.Required > len(w.Approvers) {
+			return fmt.Errorf("workflow %s: required must be between 1 and the %d approvers", w.Type, len(w.Approvers))
+		}
+	}
+	return nil
+}
+
+// For returns the workflow of a document type, nil if it has none
+func (c *Config) For(docType string) *Workflow {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Workflows {
+		if c.Workflows[i].Type == strings.ToLower(docType) {
+			return &c.Workflows[i]
+		}
+	}
+	return nil
+}
+
+// Approval is the approval of a document by an approver
+type Approval struct {
+	Approver string    `json:"approver"`
+	Date     time.Time `json:"date"`
+	// Root is the root hash of the document approved
+	Root    string `json:"root"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// State is the progress of the workflow of a document
+type State struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Status is the lifecycle status of the document
+	Status string `json:"status"`
+	// Root is the current root hash of the document
+	Root      string   `json:"root"`
+	Approvers []string `json:"approvers"`
+	Required  int      `json:"required"`
+	// Approvals are those of the current root hash
+	Approvals []Approval `json:"approvals"`
+	// Pending are the approvers yet to approve
+	Pending []string `json:"pending"`
+	// Attestations are the roles that must attest the document
+	Attestations []string `json:"attestations"`
+	// PendingAttestations are the roles without an attestation of the
+	// current root hash
+	PendingAttestations []string `json:"pendingAttestations"`
+	// Complete is true once Required approvers approved the current root
+	// hash and every role attested it, and for documents of a type without
+	// a workflow
+	Complete bool `json:"complete"`
+}
+
+// LoadApprovals returns the approvals recorded in a document
+func LoadApprovals(doc document.Document) ([]Approval, error) {
+	verification := doc.Verification(false)
+	if verification == nil || verification[RecordKey] == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(verification[RecordKey])
+	if err != nil {
+		return nil, err
+	}
+	var approvals []Approval
+	if err := json.Unmarshal(data, &approvals); err != nil {
+		return nil, fmt.Errorf("invalid approvals: %w", err)
+	}
+	return approvals, nil
+}
+
+// Compute returns the state of the workflow of doc, stored under id
+func (c *Config) Compute(id string, doc document.Document) (*State, error) {
+	rec, err := digest.Compute(doc)
+	if err != nil {
+		return nil, err
+	}
+	approvals, err := LoadApprovals(doc)
+	if err != nil {
+		return nil, err
+	}
+	s := &State{ID: id, Type: doc.Type(), Status: string(workflow.Get(doc)), Root: rec.Root, Approvers: []string{}, Approvals: []Approval{}, Pending: []string{}, Attestations: []string{}, PendingAttestations: []string{}}
+	w := c.For(s.Type)
+	if w == nil {
+		s.Complete = true
+		return s, nil
+	}
+	s.Approvers, s.Required = w.Approvers, w.Required
+	approved := map[string]bool{}
+	for _, a := range approvals {
+		if a.Root == s.Root && slices.Contains(w.Approvers, a.Approver) && !approved[a.Approver] {
+			approved[a.Approver] = true
+			s.Approvals = append(s.Approvals, a)
+		}
+	}
+	for _, name := range w.Approvers {
+		if !approved[name] {
+			s.Pending = append(s.Pending, name)
+		}
+	}
+	if len(w.Attestations) > 0 {
+		attested, err := attest.Attested(doc, s.Root, w.Attesters)
+		if err != nil {
+			return nil, err
+		}
+		s.Attestations = w.Attestations
+		for _, role := range w.Attestations {
+			if !slices.Contains(attested, role) {
+				s.PendingAttestations = append(s.PendingAttestations, role)
+			}
+		}
+	}
+	s.Complete = s.approved() && len(s.PendingAttestations) == 0
+	return s, nil
+}
+
+// approved reports whether Required approvers approved the current root
+// hash
+func (s *State) approved() bool {
+	return len(s.Approvals) >= s.Required
+}
+
+// Engine runs the workflows of the documents of a store
+type Engine struct {
+	Config *Config
+	Store  store.Store
+
+	// mu serializes the changes to stored documents, which read the
+	// latest version before storing the next
+	mu sync.Mutex
+}
+
+// load reads the latest version of a stored document
+func (e *Engine) load(ctx context.Context, id string) (document.Document, error) {
+	data, _, err := e.Store.Get(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+	return document.Parse(data)
+}
+
+// State returns the state of the workflow of a stored document
+func (e *Engine) State(ctx context.Context, id string) (*State, error) {
+	doc, err := e.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return e.Config.Compute(id, doc)
+}
+
+// Approve records the approval of the current version of a stored
+// document by approver, storing a new version of it. The document moves to
+// review with its first approval and to approved with the last one.
+func (e *Engine) Approve(ctx context.Context, id, approver, comment string) (*State, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	doc, err := e.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s, err := e.Config.Compute(id, doc)
+	if err != nil {
+		return nil, err
+	}
+	w := e.Config.For(s.Type)
+	switch {
+	case w == nil:
+		return nil, fmt.Errorf("%w for documents of type %q", ErrNoWorkflow, s.Type)
+	case !slices.Contains(w.Approvers, approver):
+		return nil, fmt.Errorf("%w: %s does not approve documents of type %s", ErrNotApprover, approver, s.Type)
+	case !slices.Contains(s.Pending, approver):
+		return nil, fmt.Errorf("%w by %s", ErrApproved, approver)
+	}
+
+	a := Approval{Approver: approver, Date: time.Now().UTC().Truncate(time.Second), Root: s.Root, Comment: comment}
+	raw, err := toJSONValue(a)
+	if err != nil {
+		return nil, err
+	}
+	document.Append(doc.Verification(true), RecordKey, raw)
+	if s, err = e.Config.Compute(id, doc); err != nil {
+		return nil, err
+	}
+	// The status only moves forward along the lifecycle; attestations are
+	// given once the document is approved
+	next := workflow.Review
+	if s.approved() {
+		next = workflow.Approved
+	}
+	for _, to := range []workflow.Status{workflow.Review, next} {
+		if workflow.CanTransition(workflow.Get(doc), to) {
+			workflow.Set(doc, to, false)
+		}
+	}
+	s.Status = string(workflow.Get(doc))
+
+	data, err := doc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.Store.Put(ctx, id, data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Put stores a document under id, with the approvals of its latest stored
+// version in place of its own, so that approvals are only ever added by
+// Approve
+func (e *Engine) Put(ctx context.Context, id string, data []byte) (store.Info, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	doc, err := document.Parse(data)
+	if err != nil {
+		return store.Info{}, fmt.Errorf("%w document: %w", store.ErrInvalid, err)
+	}
+	var approvals []Approval
+	if stored, err := e.load(ctx, id); err == nil {
+		if approvals, err = LoadApprovals(stored); err != nil {
+			return store.Info{}, err
+		}
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return store.Info{}, err
+	}
+
+	if verification := doc.Verification(len(approvals) > 0); verification != nil && (verification[RecordKey] != nil || len(approvals) > 0) {
+		delete(verification, RecordKey)
+		for _, a := range approvals {
+			raw, err := toJSONValue(a)
+			if err != nil {
+				return store.Info{}, err
+			}
+			document.Append(verification, RecordKey, raw)
+		}
+		if data, err = doc.Marshal(); err != nil {
+			return store.Info{}, err
+		}
+	}
+	return e.Store.Put(ctx, id, data)
+}
+
+// Sign signs the latest version of a stored document with sign, once its
+// approvals are complete, and stores the signed document as the next
+// version
+func (e *Engine) Sign(ctx context.Context, id string, sign func(data []byte) ([]byte, error)) (store.Info, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	doc, err := e.load(ctx, id)
+	if err != nil {
+		return store.Info{}, err
+	}
+	s, err := e.Config.Compute(id, doc)
+	if err != nil {
+		return store.Info{}, err
+	}
+	if !s.approved() {
+		return store.Info{}, fmt.Errorf("%w: %d of %d approvals given, waiting for %s", ErrPending, len(s.Approvals), s.Required, strings.Join(s.Pending, ", "))
+	}
+	if !s.Complete {
+		return store.Info{}, fmt.Errorf("%w: waiting for the attestation of a %s", ErrPending, strings.Join(s.PendingAttestations, " and a "))
+	}
+	data, err := doc.Marshal()
+	if err != nil {
+		return store.Info{}, err
+	}
+	if data, err = sign(data); err != nil {
+		return store.Info{}, err
+	}
+	return e.Store.Put(ctx, id, data)
+}
+
+// toJSONValue converts v to its generic JSON form
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
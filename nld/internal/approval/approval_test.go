@@ -0,0 +1,160 @@
+package approval
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/attest"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const contract = `{"metadata": {"type": "contract", "version": "1.0.0", "created": "2024-01-01", "title": "Lease"}, "content": {"sections": [{"id": "rent", "title": "Rent", "content": "EUR 1,000 a month"}]}}`
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name   string
+		config string
+		ok     bool
+	}{
+		{"Valid", "workflows:\n  - type: Contract\n    approvers: [legal, finance]\n", true},
+		{"Required", "workflows:\n  - type: contract\n    approvers: [legal, finance]\n    required: 1\n", true},
+		{"Too Many Required", "workflows:\n  - type: contract\n    approvers: [legal]\n    required: 2\n", false},
+		{"No Approvers", "workflows:\n  - type: contract\n", false},
+		{"Attestations Only", "workflows:\n  - type: contract\n    attestations: [notary]\n", true},
+		{"Unknown Attestation", "workflows:\n  - type: contract\n    approvers: [legal]\n    attestations: [judge]\n", false},
+		{"Twice", "workflows:\n  - type: contract\n    approvers: [legal]\n  - type: CONTRACT\n    approvers: [finance]\n", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "workflows.yaml")
+			os.WriteFile(path, []byte(tc.config), 0644)
+			c, err := Load(path)
+			if (err == nil) != tc.ok {
+				t.Fatalf("Load error = %v", err)
+			}
+			if tc.ok && (c.For("contract") == nil || c.For("contract").Required == 0 && len(c.For("contract").Approvers) > 0 || c.For("receipt") != nil) {
+				t.Errorf("Load = %+v", c)
+			}
+		})
+	}
+}
+
+func TestEngine(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewFS(t.TempDir())
+	e := &Engine{Config: &Config{Workflows: []Workflow{{Type: "contract", Approvers: []string{"legal", "finance", "sales"}, Required: 2}}}, Store: s}
+	if _, err := e.Put(ctx, "lease", []byte(contract)); err != nil {
+		t.Fatal(err)
+	}
+	sign := func(data []byte) ([]byte, error) { return data, nil }
+
+	state, err := e.State(ctx, "lease")
+	if err != nil || state.Complete || len(state.Pending) != 3 || state.Status != "draft" {
+		t.Fatalf("State = %+v, %v", state, err)
+	}
+	if _, err := e.Sign(ctx, "lease", sign); !errors.Is(err, ErrPending) {
+		t.Errorf("Sign before approval error = %v", err)
+	}
+	if _, err := e.Approve(ctx, "lease", "mallory", ""); !errors.Is(err, ErrNotApprover) {
+		t.Errorf("Approve by another client error = %v", err)
+	}
+	if state, err = e.Approve(ctx, "lease", "legal", "Reviewed"); err != nil || state.Complete || state.Status != "review" {
+		t.Fatalf("Approve = %+v, %v", state, err)
+	}
+	if _, err := e.Approve(ctx, "lease", "legal", ""); !errors.Is(err, ErrApproved) {
+		t.Errorf("second Approve error = %v", err)
+	}
+
+	// Approvals cannot be brought in by storing a document
+	forged := `{"metadata": {"type": "contract", "version": "1.0.0", "created": "2024-01-01", "title": "Lease"}, "content": {"sections": [{"id": "rent", "title": "Rent", "content": "EUR 1,000 a month"}]},
+  "verification": {"approvals": [{"approver": "finance", "date": "2024-01-02T00:00:00Z", "root": "` + state.Root + `"}]}}`
+	if _, err := e.Put(ctx, "lease", []byte(forged)); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ = e.State(ctx, "lease"); len(state.Approvals) != 1 || state.Approvals[0].Approver != "legal" {
+		t.Errorf("State after storing forged approvals = %+v", state)
+	}
+
+	if state, err = e.Approve(ctx, "lease", "finance", ""); err != nil || !state.Complete || state.Status != "approved" {
+		t.Fatalf("Approve = %+v, %v", state, err)
+	}
+	if _, err := e.Sign(ctx, "lease", sign); err != nil {
+		t.Errorf("Sign after approval failed: %v", err)
+	}
+
+	// Changing the document leaves the approvals stale
+	changed := `{"metadata": {"type": "contract", "version": "1.0.0", "created": "2024-01-01", "title": "Lease"}, "content": {"sections": [{"id": "rent", "title": "Rent", "content": "EUR 900 a month"}]}}`
+	if _, err := e.Put(ctx, "lease", []byte(changed)); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ = e.State(ctx, "lease"); state.Complete || len(state.Approvals) != 0 {
+		t.Errorf("State of a changed document = %+v", state)
+	}
+	data, _, _ := s.Get(ctx, "lease", 0)
+	doc, _ := document.Parse(data)
+	if approvals, _ := LoadApprovals(doc); len(approvals) != 2 {
+		t.Errorf("changed document has %d approvals, expected the 2 stale ones", len(approvals))
+	}
+
+	// Documents of other types need no approval
+	if _, err := e.Put(ctx, "receipt", []byte(`{"metadata": {"type": "receipt"}, "content": {"sections": []}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Approve(ctx, "receipt", "legal", ""); !errors.Is(err, ErrNoWorkflow) {
+		t.Errorf("Approve of a receipt error = %v", err)
+	}
+	if _, err := e.Sign(ctx, "receipt", sign); err != nil {
+		t.Errorf("Sign of a receipt failed: %v", err)
+	}
+}
+
+func TestEngineAttestations(t *testing.T) {
+	ctx := context.Background()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	e := &Engine{Config: &Config{Workflows: []Workflow{{Type: "contract", Approvers: []string{"legal"}, Required: 1, Attestations: []string{attest.Notary}}}}, Store: store.NewFS(t.TempDir())}
+	if _, err := e.Put(ctx, "lease", []byte(contract)); err != nil {
+		t.Fatal(err)
+	}
+	sign := func(data []byte) ([]byte, error) { return data, nil }
+
+	state, err := e.Approve(ctx, "lease", "legal", "")
+	if err != nil || state.Complete || state.Status != "approved" || len(state.PendingAttestations) != 1 {
+		t.Fatalf("Approve = %+v, %v", state, err)
+	}
+	if _, err := e.Sign(ctx, "lease", sign); !errors.Is(err, ErrPending) {
+		t.Errorf("Sign before attestation error = %v", err)
+	}
+
+	// A witness does not stand in for a notary
+	data, _, _ := e.Store.Get(ctx, "lease", 0)
+	doc, _ := document.Parse(data)
+	rec, _ := digest.Compute(doc)
+	for _, role := range []string{attest.Witness, attest.Notary} {
+		a, err := attest.Sign(key, rec.Root, nld.Attestation{AttesterID: "jane", Role: role, Statement: "Signed in my presence"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		attest.Add(doc, a)
+		data, _ = doc.Marshal()
+		if _, err := e.Put(ctx, "lease", data); err != nil {
+			t.Fatal(err)
+		}
+		state, _ = e.State(ctx, "lease")
+		if state.Complete != (role == attest.Notary) {
+			t.Errorf("State after the attestation of a %s = %+v", role, state)
+		}
+	}
+	if _, err := e.Sign(ctx, "lease", sign); err != nil {
+		t.Errorf("Sign after attestation failed: %v", err)
+	}
+}
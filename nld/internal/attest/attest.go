@@ -0,0 +1,160 @@
+// Package attest records attestations of documents by third parties, such
+// as a notary witnessing the signatures, in verification.attestations. The
+// attestations are signed with the identity key of the attester over the
+// root hash of the document, the role attested in and the statement, so
+// they are checked like signatures, trust policies included.
+package attest
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/signing"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Roles attesters attest in
+const (
+	// Notary is a notary public witnessing the signatures
+	Notary = "notary"
+	// Witness is any other third party witnessing the signatures
+	Witness = "witness"
+)
+
+// Roles returns the roles attesters attest in
+func Roles() []string {
+	return []string{Notary, Witness}
+}
+
+// ValidRole reports whether role is one of Roles
+func ValidRole(role string) bool {
+	for _, r := range Roles() {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// subjectPrefix versions the subject of attestations
+const subjectPrefix = "nld-attestation-v1"
+
+// Subject returns the hash an attestation signs in place of the root hash
+// of the document, covering its role and statement as well
+func Subject(root string, a nld.Attestation) string {
+	return digest.Sum([]byte(strings.Join([]string{subjectPrefix, root, a.Role, a.Statement}, "\n")))
+}
+
+// Signature returns an attestation as the signature of its attester over
+// its subject
+func Signature(a nld.Attestation) nld.Signature {
+	return nld.Signature{
+		SignerID:     a.AttesterID,
+		Date:         a.Date,
+		Value:        a.Value,
+		Algorithm:    a.Algorithm,
+		KeyID:        a.KeyID,
+		PublicKey:    a.PublicKey,
+		Certificates: a.Certificates,
+	}
+}
+
+// Sign signs an attestation of the document with root hash root with the
+// identity key of the attester. The date defaults to now.
+func Sign(signer crypto.Signer, root string, a nld.Attestation) (nld.Attestation, error) {
+	switch {
+	case a.AttesterID == "":
+		return a, fmt.Errorf("attester is required")
+	case !ValidRole(a.Role):
+		return a, fmt.Errorf("unknown role %q (expected one of: %s)", a.Role, strings.Join(Roles(), ", "))
+	case strings.TrimSpace(a.Statement) == "":
+		return a, fmt.Errorf("statement is required")
+	}
+	if a.Date == "" {
+		a.Date = time.Now().UTC().Format(time.RFC3339)
+	}
+	sig, err := signing.Sign(signer, Subject(root, a), a.AttesterID, a.Date)
+	if err != nil {
+		return a, err
+	}
+	a.Value, a.Algorithm, a.KeyID, a.PublicKey = sig.Value, sig.Algorithm, sig.KeyID, sig.PublicKey
+	return a, nil
+}
+
+// Attach embeds the certificate chain of the identity key, leaf first, in
+// an attestation
+func Attach(a *nld.Attestation, chain []*x509.Certificate) error {
+	sig := Signature(*a)
+	if err := signing.Attach(&sig, chain); err != nil {
+		return err
+	}
+	a.Certificates = sig.Certificates
+	return nil
+}
+
+// Verify checks an attestation against the root hash of a document. It
+// returns signing.ErrUnsigned for attestations without a signature, such
+// as those recorded by redaction.
+func Verify(a nld.Attestation, root string) error {
+	return signing.Verify(Signature(a), Subject(root, a))
+}
+
+// Load returns the attestations of a document
+func Load(doc document.Document) ([]nld.Attestation, error) {
+	verification := doc.Verification(false)
+	if verification == nil || verification["attestations"] == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(verification["attestations"])
+	if err != nil {
+		return nil, err
+	}
+	var attestations []nld.Attestation
+	if err := json.Unmarshal(data, &attestations); err != nil {
+		return nil, fmt.Errorf("invalid attestations: %w", err)
+	}
+	return attestations, nil
+}
+
+// Add appends an attestation to verification.attestations
+func Add(doc document.Document, a nld.Attestation) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	document.Append(doc.Verification(true), "attestations", raw)
+	return nil
+}
+
+// Attested returns the roles of the attestations of doc that verify
+// against its root hash, made with one of keys, by key ID, unless keys is
+// empty
+func Attested(doc document.Document, root string, keys []string) ([]string, error) {
+	attestations, err := Load(doc)
+	if err != nil {
+		return nil, err
+	}
+	var roles []string
+	seen := map[string]bool{}
+	for _, a := range attestations {
+		if a.Role == "" || seen[a.Role] || (len(keys) > 0 && !slices.Contains(keys, a.KeyID)) {
+			continue
+		}
+		if Verify(a, root) == nil {
+			seen[a.Role] = true
+			roles = append(roles, a.Role)
+		}
+	}
+	return roles, nil
+}
@@ -0,0 +1,85 @@
+package attest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/signing"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const root = "sha256:0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+
+func TestSignVerify(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	a, err := Sign(key, root, nld.Attestation{AttesterID: "jane", Role: Notary, Statement: "Signed in my presence"})
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if a.Date == "" || a.Algorithm != signing.ES256 || a.Value == "" {
+		t.Errorf("attestation = %+v", a)
+	}
+	if err := Verify(a, root); err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+
+	changed := []func(a *nld.Attestation) string{
+		func(a *nld.Attestation) string { a.Role = Witness; return root },
+		func(a *nld.Attestation) string { a.Statement = "Signed remotely"; return root },
+		func(a *nld.Attestation) string { a.AttesterID = "john"; return root },
+		func(a *nld.Attestation) string { return strings.Replace(root, "0f", "1f", 1) },
+	}
+	for i, change := range changed {
+		c := a
+		if err := Verify(c, change(&c)); err == nil {
+			t.Errorf("change %d: Verify succeeded", i)
+		}
+	}
+
+	if err := Verify(nld.Attestation{AttesterID: "nld", Statement: "Redacted", Type: "redaction"}, root); !errors.Is(err, signing.ErrUnsigned) {
+		t.Errorf("Verify of an unsigned attestation error = %v", err)
+	}
+
+	for _, bad := range []nld.Attestation{
+		{Role: Notary, Statement: "x"},
+		{AttesterID: "jane", Role: "judge", Statement: "x"},
+		{AttesterID: "jane", Role: Notary},
+	} {
+		if _, err := Sign(key, root, bad); err == nil {
+			t.Errorf("Sign of %+v succeeded", bad)
+		}
+	}
+}
+
+func TestAttested(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	doc, err := document.Parse([]byte(`{"metadata": {"type": "contract"}, "content": {"sections": []},
+  "verification": {"attestations": [{"attesterId": "nld", "date": "2025-01-01T00:00:00Z", "statement": "Redacted", "type": "redaction"}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale, _ := Sign(key, strings.Replace(root, "0f", "1f", 1), nld.Attestation{AttesterID: "john", Role: Witness, Statement: "Witnessed"})
+	notary, _ := Sign(key, root, nld.Attestation{AttesterID: "jane", Role: Notary, Statement: "Notarized"})
+	for _, a := range []nld.Attestation{stale, notary} {
+		if err := Add(doc, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	roles, err := Attested(doc, root, nil)
+	if err != nil || !reflect.DeepEqual(roles, []string{Notary}) {
+		t.Errorf("Attested = %v, %v", roles, err)
+	}
+	if roles, _ := Attested(doc, root, []string{"sha256:00"}); len(roles) != 0 {
+		t.Errorf("Attested with other keys = %v", roles)
+	}
+	if attestations, _ := Load(doc); len(attestations) != 3 || attestations[2].KeyID != notary.KeyID {
+		t.Errorf("Load = %+v", attestations)
+	}
+}
@@ -0,0 +1,211 @@
+// Package audit keeps a log of the operations performed on documents.
+// Every entry holds the hash of the entry before it, so that changing,
+// removing or reordering entries breaks the chain, and the backend records
+// the head of the log, the number and hash of its last entry, apart from
+// the entries, so that dropping entries from the end is detected too.
+// Someone who can rewrite both the entries and the head can still replace
+// the log; comparing it with a head kept elsewhere, such as one printed by
+// an earlier nld audit verify, detects that.
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/digest"
+)
+
+// LogEnv names the audit log operations are recorded in
+const LogEnv = "NLD_AUDIT_LOG"
+
+// ActorEnv names the actor recorded in audit entries, instead of the user
+// running nld
+const ActorEnv = "NLD_ACTOR"
+
+// Operations recorded in the audit log
+const (
+	Sign            = "sign"
+	Amend           = "amend"
+	Redact          = "redact"
+	Verify          = "verify"
+	VerifySignature = "verify-signature"
+	Status          = "status"
+	Finalize        = "finalize"
+	Attest          = "attest"
+	Anonymize       = "anonymize"
+	Delete          = "delete"
+)
+
+// Outcomes of operations
+const (
+	OK     = "ok"
+	Failed = "failed"
+)
+
+// Entry is an operation recorded in the audit log
+type Entry struct {
+	// Seq numbers the entries of the log from 1
+	Seq       int       `json:"seq"`
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor"`
+	Operation string    `json:"operation"`
+	// Document is the ID of the document and File the file operated on
+	Document string `json:"document"`
+	File     string `json:"file,omitempty"`
+	// Root is the root hash of the document after the operation
+	Root    string `json:"root,omitempty"`
+	Outcome string `json:"outcome"`
+	Detail  string `json:"detail,omitempty"`
+	// Prev is the hash of the entry before, empty for the first one, and
+	// Hash the hash of this entry without its Hash
+	Prev string `json:"prev"`
+	Hash string `json:"hash,omitempty"`
+}
+
+// Head is the head of a log: the Seq and Hash of its last entry
+type Head struct {
+	Seq  int    `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// ErrConflict is returned by Backend.Append when another entry was
+// appended since the log was read
+var ErrConflict = errors.New("audit log changed while appending")
+
+// hash computes the hash of an entry
+func (e Entry) hash() (string, error) {
+	e.Hash = ""
+	return digest.Hash(e)
+}
+
+// Backend holds the entries of a log
+type Backend interface {
+	// Read returns the entries, in the order they were appended, and the
+	// head recorded by the last Append, zero when there was none
+	Read(ctx context.Context) ([]Entry, Head, error)
+	// Append adds an entry after the others. It fails with ErrConflict
+	// unless the entry follows the head.
+	Append(ctx context.Context, e Entry) error
+}
+
+// Log is a hash-chained audit log
+type Log struct {
+	Backend Backend
+}
+
+// appendAttempts is how often Append chains an entry again when other
+// entries were appended at the same time
+const appendAttempts = 10
+
+// Append chains an entry to the last one of the log and adds it, setting
+// its Seq, Prev and Hash. A log that fails Check is not appended to.
+func (l *Log) Append(ctx context.Context, e *Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC().Truncate(time.Millisecond)
+	for attempt := 0; ; attempt++ {
+		entries, err := l.Entries(ctx)
+		if err != nil {
+			return err
+		}
+		e.Seq, e.Prev = 1, ""
+		if n := len(entries); n > 0 {
+			e.Seq, e.Prev = entries[n-1].Seq+1, entries[n-1].Hash
+		}
+		if e.Hash, err = e.hash(); err != nil {
+			return err
+		}
+		err = l.Backend.Append(ctx, *e)
+		if errors.Is(err, ErrConflict) && attempt < appendAttempts {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		return nil
+	}
+}
+
+// Entries returns the entries of the log, and a *TamperError along with
+// them when they fail Check or do not end at the recorded head
+func (l *Log) Entries(ctx context.Context) ([]Entry, error) {
+	entries, head, err := l.Backend.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := Check(entries); err != nil {
+		return entries, err
+	}
+	return entries, checkHead(entries, head)
+}
+
+// checkHead checks that entries end at head
+func checkHead(entries []Entry, head Head) error {
+	n := len(entries)
+	switch {
+	case head.Seq > n:
+		return &TamperError{Seq: n + 1, Reason: fmt.Sprintf("log ends at entry %d, but %d were appended", n, head.Seq)}
+	case head.Seq < n:
+		return &TamperError{Seq: head.Seq + 1, Reason: fmt.Sprintf("entries after %d were not appended to the log", head.Seq)}
+	case n > 0 && head.Hash != entries[n-1].Hash:
+		return &TamperError{Seq: n, Reason: "entry is not the recorded head of the log"}
+	}
+	return nil
+}
+
+// TamperError reports the first entry of a log that breaks its chain
+type TamperError struct {
+	Seq    int
+	Reason string
+}
+
+func (e *TamperError) Error() string {
+	return fmt.Sprintf("audit log tampered with at entry %d: %s", e.Seq, e.Reason)
+}
+
+// Check verifies the chain of the entries of a log, returning a
+// *TamperError for the first entry that does not follow from those before
+func Check(entries []Entry) error {
+	prev := ""
+	for i, e := range entries {
+		seq := i + 1
+		switch {
+		case e.Seq != seq:
+			return &TamperError{Seq: seq, Reason: fmt.Sprintf("entry is numbered %d", e.Seq)}
+		case e.Prev != prev:
+			return &TamperError{Seq: seq, Reason: "entry does not follow the one before"}
+		}
+		hash, err := e.hash()
+		if err != nil {
+			return err
+		}
+		if hash != e.Hash {
+			return &TamperError{Seq: seq, Reason: "entry does not match its hash"}
+		}
+		prev = e.Hash
+	}
+	return nil
+}
+
+// IsTampered reports whether err is a *TamperError
+func IsTampered(err error) bool {
+	var t *TamperError
+	return errors.As(err, &t)
+}
+
+// Actor returns the actor of the operations of this process: $NLD_ACTOR,
+// or the name of the user running it
+func Actor() string {
+	if actor := os.Getenv(ActorEnv); actor != "" {
+		return actor
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "unknown"
+}
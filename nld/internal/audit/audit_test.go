@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/store"
+)
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+	for name, backend := range map[string]Backend{
+		"File":  &File{Path: filepath.Join(dir, "logs", "audit.log")},
+		"Store": &Store{Store: store.NewFS(filepath.Join(dir, "store"))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			log := &Log{Backend: backend}
+			if entries, err := log.Entries(ctx); err != nil || len(entries) != 0 {
+				t.Fatalf("Entries of a new log = %v, %v", entries, err)
+			}
+			for _, op := range []string{Sign, Amend, Verify} {
+				e := &Entry{Actor: "alice", Operation: op, Document: "lease", File: "lease.json", Outcome: OK}
+				if err := log.Append(ctx, e); err != nil {
+					t.Fatalf("Append failed: %v", err)
+				}
+			}
+			entries, err := log.Entries(ctx)
+			if err != nil || len(entries) != 3 {
+				t.Fatalf("Entries = %v, %v", entries, err)
+			}
+			if err := Check(entries); err != nil {
+				t.Errorf("Check failed: %v", err)
+			}
+			if entries[0].Prev != "" || entries[2].Seq != 3 || entries[2].Prev != entries[1].Hash || !strings.HasPrefix(entries[2].Hash, "sha256:") {
+				t.Errorf("entries are not chained: %+v", entries)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	ctx := context.Background()
+	log := &Log{Backend: &File{Path: path}}
+	for _, actor := range []string{"alice", "bob", "carol"} {
+		if err := log.Append(ctx, &Entry{Actor: actor, Operation: Sign, Document: "lease", Outcome: OK}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, _ := os.ReadFile(path)
+	lines := strings.SplitAfter(strings.TrimSpace(string(data)), "\n")
+	head, _ := os.ReadFile(path + ".head")
+
+	testCases := []struct {
+		name  string
+		lines []string
+		seq   int
+	}{
+		{"Changed", []string{lines[0], strings.Replace(lines[1], "bob", "mallory", 1), lines[2]}, 2},
+		{"Removed", []string{lines[0], lines[2]}, 2},
+		{"Reordered", []string{lines[1], lines[0], lines[2]}, 1},
+		{"Truncated", []string{lines[0], lines[1]}, 3},
+		{"Emptied", nil, 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tampered := filepath.Join(t.TempDir(), "audit.log")
+			if tc.lines != nil {
+				os.WriteFile(tampered, []byte(strings.Join(tc.lines, "\n")+"\n"), 0600)
+			}
+			os.WriteFile(tampered+".head", head, 0600)
+			log := &Log{Backend: &File{Path: tampered}}
+			_, err := log.Entries(ctx)
+			if te, ok := err.(*TamperError); !ok || te.Seq != tc.seq {
+				t.Errorf("Check error = %v, expected tampering at entry %d", err, tc.seq)
+			}
+			if err := log.Append(ctx, &Entry{Operation: Verify}); !IsTampered(err) {
+				t.Errorf("Append to a tampered log error = %v", err)
+			}
+		})
+	}
+}
+
+func TestActor(t *testing.T) {
+	t.Setenv(ActorEnv, "legal-bot")
+	if actor := Actor(); actor != "legal-bot" {
+		t.Errorf("Actor = %q", actor)
+	}
+}
+
+func TestStoreLog(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewFS(t.TempDir())
+	log := &Log{Backend: &Store{Store: s}}
+	for _, op := range []string{Sign, Verify} {
+		if err := log.Append(ctx, &Entry{Actor: "alice", Operation: op, Document: "lease", Outcome: OK}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	// The store refuses to replace or delete the log
+	if _, err := s.Put(ctx, StoreID, []byte(`{"auditLog": []}`)); !errors.Is(err, store.ErrInvalid) {
+		t.Errorf("Put of the log error = %v", err)
+	}
+	if err := s.Delete(ctx, StoreID); !errors.Is(err, store.ErrInvalid) {
+		t.Errorf("Delete of the log error = %v", err)
+	}
+
+	// A version that drops entries is detected
+	if _, err := s.Create(ctx, StoreID, 3, []byte(`{"auditLog": []}`)); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := log.Entries(ctx); !IsTampered(err) {
+		t.Errorf("Entries of a truncated log error = %v", err)
+	}
+}
+
+func TestAppendConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	for name, backend := range map[string]Backend{
+		"File":  &File{Path: filepath.Join(dir, "audit.log")},
+		"Store": &Store{Store: store.NewFS(filepath.Join(dir, "store"))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			var wg sync.WaitGroup
+			errs := make(chan error, 8)
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					log := &Log{Backend: backend}
+					errs <- log.Append(ctx, &Entry{Actor: "alice", Operation: Verify, Document: "lease", Outcome: OK})
+				}()
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Errorf("Append failed: %v", err)
+				}
+			}
+			entries, err := (&Log{Backend: backend}).Entries(ctx)
+			if err != nil || len(entries) != 8 {
+				t.Errorf("Entries = %d entries, %v", len(entries), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/store"
+)
+
+// lockTimeout is how long File waits for the lock of its log, and the age
+// after which a lock left behind by a process that died is taken over
+const lockTimeout = 10 * time.Second
+
+// File is a log kept in a local file, one JSON entry per line. The head is
+// kept next to it in <path>.head, and appends are serialized by the lock
+// file <path>.lock.
+type File struct {
+	Path string
+}
+
+func (f *File) Read(ctx context.Context) ([]Entry, Head, error) {
+	entries, err := f.entries()
+	if err != nil {
+		return nil, Head{}, err
+	}
+	var head Head
+	data, err := os.ReadFile(f.Path + ".head")
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, Head{}, fmt.Errorf("failed to read audit log head: %w", err)
+	default:
+		if err := json.Unmarshal(data, &head); err != nil {
+			return nil, Head{}, fmt.Errorf("invalid audit log head %s.head: %w", f.Path, err)
+		}
+	}
+	return entries, head, nil
+}
+
+// entries reads the entries of the log
+func (f *File) entries() ([]Entry, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	var entries []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("invalid audit log %s, line %d: %w", f.Path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+func (f *File) Append(ctx context.Context, e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	unlock, err := f.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, head, err := f.Read(ctx)
+	if err != nil {
+		return err
+	}
+	if e.Seq != head.Seq+1 || e.Prev != head.Hash {
+		return ErrConflict
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(append(line, '\n')); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(Head{Seq: e.Seq, Hash: e.Hash})
+	if err != nil {
+		return err
+	}
+	tmp := f.Path + ".head.tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.Path+".head")
+}
+
+// lock creates the lock file of the log, waiting for other writers to
+// remove theirs, and returns the function that removes it
+func (f *File) lock(ctx context.Context) (func(), error) {
+	path := f.Path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			out.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock audit log: %w", err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockTimeout {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock audit log: %s is held by another process", path)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+// StoreID is the ID of the audit log in a document store
+const StoreID = store.AuditID
+
+// Store is a log kept in a document store as the document _audit, which
+// the store reserves for it. Entry n is added as version n of the
+// document, holding the whole log, so the store keeps every state of the
+// log and the latest version number is its head.
+type Store struct {
+	Store store.Store
+}
+
+// storedLog is the stored form of a log
+type storedLog struct {
+	Entries []Entry `json:"auditLog"`
+}
+
+func (s *Store) Read(ctx context.Context) ([]Entry, Head, error) {
+	data, info, err := s.Store.Get(ctx, StoreID, 0)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, Head{}, nil
+	}
+	if err != nil {
+		return nil, Head{}, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	var log storedLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		return nil, Head{}, fmt.Errorf("invalid audit log in the store: %w", err)
+	}
+	head := Head{Seq: info.Version}
+	if n := len(log.Entries); n == info.Version {
+		head.Hash = log.Entries[n-1].Hash
+	}
+	return log.Entries, head, nil
+}
+
+func (s *Store) Append(ctx context.Context, e Entry) error {
+	entries, head, err := s.Read(ctx)
+	if err != nil {
+		return err
+	}
+	if e.Seq != head.Seq+1 || e.Prev != head.Hash {
+		return ErrConflict
+	}
+	data, err := json.MarshalIndent(storedLog{Entries: append(entries, e)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Claiming the version of the entry fails when another writer appended
+	// an entry since the log was read
+	_, err = s.Store.Create(ctx, StoreID, e.Seq, data)
+	if errors.Is(err, store.ErrConflict) {
+		return ErrConflict
+	}
+	return err
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scopes grant access to groups of API routes
+const (
+	// ScopeValidate allows validating, rendering and converting documents
+	ScopeValidate = "validate"
+	// ScopeSign allows recording the digest of documents
+	ScopeSign = "sign"
+	// ScopeStore allows reading and changing the document store
+	ScopeStore = "store"
+	// ScopeApprove allows approving stored documents
+	ScopeApprove = "approve"
+)
+
+// Methods of authentication
+const (
+	MethodAPIKey = "api-key"
+	MethodOIDC   = "oidc"
+)
+
+// APIKeyHeader is the header an API key can be sent in, as an alternative
+// to an Authorization: Bearer header
+const APIKeyHeader = "X-API-Key"
+
+var (
+	// ErrNoCredentials is returned for requests without credentials
+	ErrNoCredentials = errors.New("authentication required")
+	// ErrInvalidCredentials is wrapped by the errors of unknown API keys
+	// and tokens that fail verification
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Scopes returns the scopes
+func Scopes() []string {
+	return []string{ScopeValidate, ScopeSign, ScopeStore, ScopeApprove}
+}
+
+// APIKey is a key a client authenticates with
+type APIKey struct {
+	// Name identifies the client in the audit log
+	Name string `yaml:"name"`
+	// SHA256 is the hex SHA-256 digest of the key, so that the config file
+	// does not hold the key itself; KeyEnv names an environment variable
+	// holding the key instead
+	SHA256 string `yaml:"sha256"`
+	KeyEnv string `yaml:"key-env"`
+	// Scopes are the scopes granted to the key
+	Scopes []string `yaml:"scopes"`
+}
+
+// Config is an auth config file:
+//
+//	api-keys:
+//	  - name: billing
+//	    sha256: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//	    scopes: [validate, sign]
+//	oidc:
+//	  issuer: https://login.example.com
+//	  audience: nld
+type Config struct {
+	APIKeys []APIKey `yaml:"api-keys"`
+	OIDC    *OIDC    `yaml:"oidc"`
+}
+
+// Load reads an auth config file, resolving keys from the environment
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth config: %w", err)
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid auth config %s: %w", path, err)
+	}
+	if err := c.check(); err != nil {
+		return nil, fmt.Errorf("invalid auth config %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// check validates a config and resolves the keys in KeyEnv
+func (c *Config) check() error {
+	if len(c.APIKeys) == 0 && c.OIDC == nil {
+		return fmt.Errorf("no api-keys or oidc given")
+	}
+	for i := range c.APIKeys {
+		k := &c.APIKeys[i]
+		if k.Name == "" {
+			return fmt.Errorf("api key %d: no name", i+1)
+		}
+		if k.KeyEnv != "" {
+			key := os.Getenv(k.KeyEnv)
+			if key == "" {
+				return fmt.Errorf("api key %s: %s is not set", k.Name, k.KeyEnv)
+			}
+			k.SHA256 = HashKey(key)
+		}
+		if _, err := hex.DecodeString(k.SHA256); err != nil || len(k.SHA256) != 64 {
+			return fmt.Errorf("api key %s: sha256 must be the 64 hex digits of the SHA-256 of the key", k.Name)
+		}
+		k.SHA256 = strings.ToLower(k.SHA256)
+		if err := checkScopes(k.Scopes); err != nil {
+			return fmt.Errorf("api key %s: %w", k.Name, err)
+		}
+	}
+	if c.OIDC != nil {
+		if c.OIDC.Issuer == "" || c.OIDC.Audience == "" {
+			return fmt.Errorf("oidc: issuer and audience are required")
+		}
+		if err := checkScopes(c.OIDC.DefaultScopes); err != nil {
+			return fmt.Errorf("oidc: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkScopes rejects unknown scopes
+func checkScopes(scopes []string) error {
+	for _, s := range scopes {
+		if !slices.Contains(Scopes(), s) {
+			return fmt.Errorf("unknown scope %q (available: %s)", s, strings.Join(Scopes(), ", "))
+		}
+	}
+	return nil
+}
+
+// HashKey returns the digest of an API key to put in a config file
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Principal is an authenticated client
+type Principal struct {
+	// Name is the API key name, or the subject of an OIDC token
+	Name   string   `json:"name"`
+	Method string   `json:"method"`
+	Scopes []string `json:"scopes"`
+}
+
+// Has reports whether the principal was granted scope
+func (p *Principal) Has(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Authenticator authenticates API requests by API key or OIDC bearer token
+type Authenticator struct {
+	keys []APIKey
+	oidc *verifier
+}
+
+// New returns an authenticator for a config. OIDC tokens are verified
+// with the keys published by the issuer, fetched with client when first
+// needed; a nil client uses http.DefaultClient.
+func New(c *Config, client *http.Client) (*Authenticator, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	a := &Authenticator{keys: c.APIKeys}
+	if c.OIDC != nil {
+		if client == nil {
+			client = http.DefaultClient
+		}
+		a.oidc = newVerifier(*c.OIDC, client)
+	}
+	return a, nil
+}
+
+// Authenticate returns the client making a request. Credentials are an API
+// key, in the X-API-Key header or as a bearer token, or an OIDC ID or
+// access token as a bearer token.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	credential := r.Header.Get(APIKeyHeader)
+	if credential == "" {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return nil, ErrNoCredentials
+		}
+		credential = strings.TrimSpace(token)
+	}
+
+	digest := HashKey(credential)
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(digest), []byte(k.SHA256)) == 1 {
+			return &Principal{Name: k.Name, Method: MethodAPIKey, Scopes: k.Scopes}, nil
+		}
+	}
+	// JSON Web Tokens have three dot-separated parts
+	if a.oidc != nil && strings.Count(credential, ".") == 2 {
+		return a.oidc.verify(r.Context(), credential)
+	}
+	return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("NLD_TEST_KEY", "secret")
+	path := filepath.Join(t.TempDir(), "auth.yaml")
+	os.WriteFile(path, []byte("api-keys:\n  - name: ci\n    key-env: NLD_TEST_KEY\n    scopes: [validate]\n"), 0o600)
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.APIKeys[0].SHA256 != HashKey("secret") {
+		t.Errorf("key not resolved from the environment: %+v", c.APIKeys[0])
+	}
+
+	for _, bad := range []string{
+		"{}",
+		"api-keys:\n  - name: ci\n    sha256: abc\n",
+		"api-keys:\n  - name: ci\n    key-env: NLD_TEST_KEY\n    scopes: [admin]\n",
+		"api-keys:\n  - name: ci\n    key-env: NLD_TEST_UNSET\n",
+		"oidc:\n  issuer: https://login.example.com\n",
+	} {
+		os.WriteFile(path, []byte(bad), 0o600)
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	a, err := New(&Config{APIKeys: []APIKey{{Name: "ci", SHA256: HashKey("secret"), Scopes: []string{ScopeValidate}}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, header := range [][2]string{{"Authorization", "Bearer secret"}, {APIKeyHeader, "secret"}} {
+		r := httptest.NewRequest(http.MethodPost, "/v1/validate", nil)
+		r.Header.Set(header[0], header[1])
+		p, err := a.Authenticate(r)
+		if err != nil {
+			t.Fatalf("%s: %v", header[0], err)
+		}
+		if p.Name != "ci" || p.Method != MethodAPIKey || !p.Has(ScopeValidate) || p.Has(ScopeSign) {
+			t.Errorf("%s: principal = %+v", header[0], p)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/validate", nil)
+	if _, err := a.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("no credentials: %v", err)
+	}
+	r.Header.Set("Authorization", "Bearer wrong")
+	if _, err := a.Authenticate(r); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong key: %v", err)
+	}
+}
+
+// issuer is an OIDC issuer signing tokens with an RSA key
+type issuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+}
+
+func newIssuer(t *testing.T) *issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &issuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": iss.URL, "jwks_uri": iss.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		e := big.NewInt(int64(key.E)).Bytes()
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "k1", "kty": "RSA", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(e),
+		}}})
+	})
+	iss.Server = httptest.NewServer(mux)
+	t.Cleanup(iss.Close)
+	return iss
+}
+
+// token returns a token signed by the issuer with claims
+func (iss *issuer) token(t *testing.T, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, iss.key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOIDC(t *testing.T) {
+	iss := newIssuer(t)
+	a, err := New(&Config{OIDC: &OIDC{Issuer: iss.URL, Audience: "nld", ScopePrefix: "nld:", DefaultScopes: []string{ScopeValidate}}}, iss.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := func(change map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"iss": iss.URL, "sub": "alice", "aud": []string{"nld", "other"}, "exp": exp, "scope": "openid nld:sign store"}
+		for k, v := range change {
+			c[k] = v
+		}
+		return c
+	}
+	authenticate := func(token string) (*Principal, error) {
+		r := httptest.NewRequest(http.MethodPost, "/v1/sign", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return a.Authenticate(r)
+	}
+
+	p, err := authenticate(iss.token(t, claims(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "alice" || p.Method != MethodOIDC || strings.Join(p.Scopes, " ") != "validate sign" {
+		t.Errorf("principal = %+v", p)
+	}
+
+	for name, change := range map[string]map[string]interface{}{
+		"issuer":   {"iss": "https://evil.example.com"},
+		"audience": {"aud": "other"},
+		"expired":  {"exp": time.Now().Add(-time.Hour).Unix()},
+		"future":   {"nbf": time.Now().Add(time.Hour).Unix()},
+	} {
+		if _, err := authenticate(iss.token(t, claims(change))); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	token := iss.token(t, claims(nil))
+	tampered := strings.Split(token, ".")
+	payload, _ := json.Marshal(claims(map[string]interface{}{"sub": "mallory"}))
+	tampered[1] = base64.RawURLEncoding.EncodeToString(payload)
+	if _, err := authenticate(strings.Join(tampered, ".")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("tampered token: %v", err)
+	}
+}
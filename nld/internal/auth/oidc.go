@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OIDC configures the verification of OIDC bearer tokens
+type OIDC struct {
+	// Issuer is the issuer URL; its signing keys are found through
+	// Issuer/.well-known/openid-configuration
+	Issuer string `yaml:"issuer"`
+	// Audience must be one of the audiences of a token
+	Audience string `yaml:"audience"`
+	// ScopePrefix is prepended to scopes in the scope or scp claim of a
+	// token, so that a prefix of "nld:" grants the sign scope for a token
+	// scope of "nld:sign"
+	ScopePrefix string `yaml:"scope-prefix"`
+	// DefaultScopes are granted to every valid token, for issuers that do
+	// not issue scopes
+	DefaultScopes []string `yaml:"scopes"`
+}
+
+// refreshInterval limits how often the keys of the issuer are fetched for
+// tokens signed with an unknown key
+const refreshInterval = time.Minute
+
+// leeway is the clock skew allowed when checking the times of a token
+const leeway = time.Minute
+
+// verifier verifies tokens of an issuer
+type verifier struct {
+	config OIDC
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+func newVerifier(config OIDC, client *http.Client) *verifier {
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+	return &verifier{config: config, client: client, now: time.Now}
+}
+
+// claims are the claims of a token used by nld
+type claims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  json.RawMessage `json:"aud"`
+	Expires   float64         `json:"exp"`
+	NotBefore float64         `json:"nbf"`
+	Scope     string          `json:"scope"`
+	Scp       json.RawMessage `json:"scp"`
+}
+
+// verify checks the signature and claims of a JSON Web Token
+func (v *verifier) verify(ctx context.Context, token string) (*Principal, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidCredentials, fmt.Sprintf(format, args...))
+	}
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodePart(parts[0], &header); err != nil {
+		return nil, invalid("malformed token header")
+	}
+	var c claims
+	if err := decodePart(parts[1], &c); err != nil {
+		return nil, invalid("malformed token claims")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, invalid("malformed token signature")
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, invalid("%v", err)
+	}
+
+	now := v.now()
+	switch {
+	case strings.TrimSuffix(c.Issuer, "/") != v.config.Issuer:
+		return nil, invalid("token issuer %q is not %s", c.Issuer, v.config.Issuer)
+	case !hasAudience(c.Audience, v.config.Audience):
+		return nil, invalid("token audience is not %s", v.config.Audience)
+	case c.Expires == 0 || now.After(unixTime(c.Expires).Add(leeway)):
+		return nil, invalid("token expired")
+	case c.NotBefore != 0 && now.Add(leeway).Before(unixTime(c.NotBefore)):
+		return nil, invalid("token not valid yet")
+	case c.Subject == "":
+		return nil, invalid("token has no subject")
+	}
+
+	p := &Principal{Name: c.Subject, Method: MethodOIDC, Scopes: append([]string(nil), v.config.DefaultScopes...)}
+	for _, s := range tokenScopes(c) {
+		if name, ok := strings.CutPrefix(s, v.config.ScopePrefix); ok && slices.Contains(Scopes(), name) && !p.Has(name) {
+			p.Scopes = append(p.Scopes, name)
+		}
+	}
+	return p, nil
+}
+
+// key returns the signing key kid of the issuer, fetching the keys of the
+// issuer when kid is not known
+func (v *verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if key, ok := v.lookup(kid); ok {
+		return key, nil
+	}
+	if !v.fetched.IsZero() && v.now().Sub(v.fetched) < refreshInterval {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidCredentials, kid)
+	}
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch keys of %s: %w", v.config.Issuer, err)
+	}
+	v.keys, v.fetched = keys, v.now()
+	if key, ok := v.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidCredentials, kid)
+}
+
+// lookup finds a key by ID; tokens without a key ID may use the only key
+func (v *verifier) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// fetchKeys reads the JSON Web Key Set of the issuer
+func (v *verifier) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := v.getJSON(ctx, v.config.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("no jwks_uri in the OpenID configuration")
+	}
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := v.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+			e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+			if err1 != nil || err2 != nil || len(e) > 4 {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			default:
+				continue
+			}
+			x, err1 := base64.RawURLEncoding.DecodeString(k.X)
+			y, err2 := base64.RawURLEncoding.DecodeString(k.Y)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	return keys, nil
+}
+
+func (v *verifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("GET %s: %w", url, err)
+	}
+	return nil
+}
+
+// checkSignature verifies the signature of a token with key
+func checkSignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	sum := h.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if alg[0] != 'R' || rsa.VerifyPKCS1v15(key, hash, sum, signature) != nil {
+			return fmt.Errorf("bad token signature")
+		}
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if alg[0] != 'E' || len(signature) != 2*size {
+			return fmt.Errorf("bad token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, sum, r, s) {
+			return fmt.Errorf("bad token signature")
+		}
+	default:
+		return fmt.Errorf("unsupported signing key")
+	}
+	return nil
+}
+
+func decodePart(part string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// hasAudience reports whether the aud claim, a string or a list of
+// strings, holds audience
+func hasAudience(aud json.RawMessage, audience string) bool {
+	var one string
+	if json.Unmarshal(aud, &one) == nil {
+		return one == audience
+	}
+	var list []string
+	if json.Unmarshal(aud, &list) == nil {
+		return slices.Contains(list, audience)
+	}
+	return false
+}
+
+// tokenScopes returns the scopes of the scope claim, space separated, and
+// of the scp claim, a list or a space separated string
+func tokenScopes(c claims) []string {
+	scopes := strings.Fields(c.Scope)
+	var list []string
+	var one string
+	if json.Unmarshal(c.Scp, &list) == nil {
+		scopes = append(scopes, list...)
+	} else if json.Unmarshal(c.Scp, &one) == nil {
+		scopes = append(scopes, strings.Fields(one)...)
+	}
+	return scopes
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0)
+}
@@ -0,0 +1,104 @@
+// Package baseline records the validation findings of a set of documents so
+// that later runs only fail on new ones
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/colemalphrus/nld/internal/validator"
+)
+
+// Version is the version of the baseline file format
+const Version = 1
+
+// Finding is a validation finding recorded in a baseline. Line numbers are
+// left out so that findings still match after unrelated edits.
+type Finding struct {
+	File    string `json:"file"`
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Baseline is a set of known findings
+type Baseline struct {
+	Version  int       `json:"version"`
+	Findings []Finding `json:"findings"`
+}
+
+// Load reads a baseline file. A missing file is an empty baseline.
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Baseline{Version: Version}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if b.Version != Version {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", b.Version, path)
+	}
+	return &b, nil
+}
+
+// Save writes the baseline to a file, with its findings ordered by file
+// and field
+func (b *Baseline) Save(path string) error {
+	sort.SliceStable(b.Findings, func(i, j int) bool {
+		x, y := b.Findings[i], b.Findings[j]
+		if x.File != y.File {
+			return x.File < y.File
+		}
+		return x.Field < y.Field
+	})
+	b.Version = Version
+	if b.Findings == nil {
+		b.Findings = []Finding{}
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Add records the findings of a validation result for a file
+func (b *Baseline) Add(file string, result *validator.ValidationResult) {
+	for _, e := range result.Findings() {
+		b.Findings = append(b.Findings, newFinding(file, e))
+	}
+}
+
+// Filter leaves out the findings of a result for a file that the baseline
+// records, each recorded finding matching one error, and returns their
+// number. The result is valid when only known findings were left out.
+func (b *Baseline) Filter(file string, result *validator.ValidationResult) int {
+	known := map[Finding]int{}
+	for _, f := range b.Findings {
+		known[f]++
+	}
+	count := 0
+	result.Filter(func(e validator.ValidationError) bool {
+		f := newFinding(file, e)
+		if known[f] == 0 {
+			return true
+		}
+		known[f]--
+		count++
+		return false
+	})
+	return count
+}
+
+// newFinding returns the finding of an error of a file
+func newFinding(file string, e validator.ValidationError) Finding {
+	return Finding{File: filepath.ToSlash(filepath.Clean(file)), Field: e.Field, Rule: e.Rule, Message: e.Message}
+}
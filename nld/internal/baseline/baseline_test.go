@@ -0,0 +1,76 @@
+package baseline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/validator"
+)
+
+// result returns an invalid result with the given section id errors
+func result(fields ...string) *validator.ValidationResult {
+	r := &validator.ValidationResult{}
+	for _, field := range fields {
+		r.Errors = append(r.Errors, validator.ValidationError{Field: field, Message: "duplicate section id", Rule: validator.RuleSectionIDs})
+	}
+	return r
+}
+
+func TestBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+
+	b, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load of a missing baseline failed: %v", err)
+	}
+	b.Add("docs/b.json", result("/content/sections/2/id"))
+	b.Add("./docs/a.json", result("/content/sections/1/id", "/content/sections/1/id"))
+	if err := b.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	b, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(b.Findings) != 3 || b.Findings[0].File != "docs/a.json" {
+		t.Fatalf("Expected 3 findings ordered by file, got %+v", b.Findings)
+	}
+
+	// Known findings are left out, each matching one error
+	r := result("/content/sections/1/id", "/content/sections/1/id", "/content/sections/1/id", "/content/sections/4/id")
+	if known := b.Filter("docs/a.json", r); known != 2 {
+		t.Errorf("Expected 2 known findings, got %d", known)
+	}
+	if r.Valid || len(r.Errors) != 2 {
+		t.Errorf("Expected 2 new errors, got %+v", r.Errors)
+	}
+
+	r = result("/content/sections/2/id")
+	if known := b.Filter("docs/b.json", r); known != 1 || !r.Valid {
+		t.Errorf("Expected a valid result with 1 known finding, got %d %+v", known, r)
+	}
+
+	// Findings of other files do not match
+	r = result("/content/sections/2/id")
+	if known := b.Filter("docs/c.json", r); known != 0 || r.Valid {
+		t.Errorf("Expected no known findings, got %d %+v", known, r)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"invalid.json": "{",
+		"version.json": `{"version": 2, "findings": []}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("Expected an error loading %s", name)
+		}
+	}
+}
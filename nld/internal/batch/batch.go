@@ -0,0 +1,391 @@
+package batch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/ignore"
+	"gopkg.in/yaml.v3"
+)
+
+// Steps that a pipeline can run on each document
+const (
+	StepValidate = "validate"
+	StepFill     = "fill"
+	StepSign     = "sign"
+	StepRender   = "render"
+)
+
+// stepOptions lists the options accepted by each step
+var stepOptions = map[string][]string{
+	StepValidate: {"schema", "profile", "currency", "requireLocales"},
+	StepFill:     {"values", "set"},
+	StepSign:     {},
+	StepRender:   {"format", "numbering", "locales"},
+}
+
+// NamePlaceholder is replaced by the name of the input document, its file
+// name without extension, in the values file of a fill step
+const NamePlaceholder = "{name}"
+
+// SummaryFile is the name of the run summary written to the output
+// directory
+const SummaryFile = "batch-summary.json"
+
+// Pipeline is a batch pipeline read from a YAML or JSON config file. Paths
+// are relative to the config file.
+type Pipeline struct {
+	// Inputs are glob patterns matching the documents to process
+	Inputs Patterns `yaml:"inputs"`
+	// Output is the directory the documents and renderings are written to
+	Output string `yaml:"output"`
+	// Parallel is the number of documents processed at once; zero uses
+	// one per CPU
+	Parallel int `yaml:"parallel"`
+	// Steps are run on each document in order
+	Steps []Step `yaml:"steps"`
+
+	// Path is the config file the pipeline was loaded from
+	Path string `yaml:"-"`
+	// dir is the directory relative paths are resolved against, whose
+	// .nldignore files apply to the inputs
+	dir string
+}
+
+// Patterns is a list of glob patterns, written as a list or a single
+// pattern
+type Patterns []string
+
+// UnmarshalYAML accepts a single pattern as well as a list
+func (p *Patterns) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*p = Patterns{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*p = list
+	return nil
+}
+
+// Step is one step of a pipeline. It is written as the step name, or as a
+// mapping from the name to its options:
+//
+//	steps:
+//	  - validate
+//	  - fill:
+//	      values: data/{name}.yaml
+//	  - sign
+//	  - render:
+//	      format: html
+type Step struct {
+	Name string `yaml:"-"`
+
+	// Schema, Profile, Currency and RequireLocales configure a validate
+	// step as the validate command flags do
+	Schema         string   `yaml:"schema"`
+	Profile        string   `yaml:"profile"`
+	Currency       string   `yaml:"currency"`
+	RequireLocales []string `yaml:"requireLocales"`
+
+	// Values is the YAML or JSON file of placeholder values of a fill
+	// step. {name} is replaced by the name of each input document, so
+	// that every document can have its own values.
+	Values string `yaml:"values"`
+	// Set are placeholder values that apply to every document, taking
+	// precedence over Values
+	Set map[string]string `yaml:"set"`
+
+	// Format, Numbering and Locales configure a render step as the render
+	// command flags do
+	Format    string   `yaml:"format"`
+	Numbering string   `yaml:"numbering"`
+	Locales   []string `yaml:"locales"`
+}
+
+// UnmarshalYAML reads a step written as its name or as a mapping from its
+// name to its options
+func (s *Step) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = Step{Name: node.Value}
+		return s.check(nil)
+	}
+	if node.Kind != yaml.MappingNode || len(node.Content) != 2 {
+		return fmt.Errorf("line %d: a step is a name or a mapping from one name to its options", node.Line)
+	}
+	name, options := node.Content[0].Value, node.Content[1]
+	*s = Step{Name: name}
+	if options.Kind == yaml.ScalarNode && options.Tag == "!!null" {
+		return s.check(nil)
+	}
+	if options.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: options of step %s must be a mapping", options.Line, name)
+	}
+	var keys []string
+	for i := 0; i < len(options.Content); i += 2 {
+		keys = append(keys, options.Content[i].Value)
+	}
+	if err := s.check(keys); err != nil {
+		return fmt.Errorf("line %d: %w", options.Line, err)
+	}
+	// The plain type decodes the options without recursing into this
+	// method
+	type plain Step
+	if err := options.Decode((*plain)(s)); err != nil {
+		return err
+	}
+	s.Name = name
+	return nil
+}
+
+// check reports unknown steps and options
+func (s *Step) check(keys []string) error {
+	allowed, ok := stepOptions[s.Name]
+	if !ok {
+		return fmt.Errorf("unknown step %q (expected %s, %s, %s or %s)", s.Name, StepValidate, StepFill, StepSign, StepRender)
+	}
+	for _, k := range keys {
+		found := false
+		for _, a := range allowed {
+			found = found || a == k
+		}
+		if !found {
+			return fmt.Errorf("unknown option %q for step %s", k, s.Name)
+		}
+	}
+	return nil
+}
+
+// Load reads a pipeline from a YAML or JSON config file
+func Load(path string) (*Pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pipeline: %w", err)
+	}
+	p, err := Parse(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pipeline %s: %w", path, err)
+	}
+	p.Path = path
+	return p, nil
+}
+
+// Parse reads a pipeline config, resolving its relative paths against dir
+func Parse(data []byte, dir string) (*Pipeline, error) {
+	var p Pipeline
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Inputs) == 0 {
+		return nil, fmt.Errorf("no inputs given")
+	}
+	if len(p.Steps) == 0 {
+		return nil, fmt.Errorf("no steps given")
+	}
+	if p.Parallel < 0 {
+		return nil, fmt.Errorf("parallel must not be negative")
+	}
+	if p.Output == "" && p.Writes() {
+		return nil, fmt.Errorf("an output directory is required for the %s step", p.writer())
+	}
+
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	for i, pattern := range p.Inputs {
+		p.Inputs[i] = resolve(pattern)
+	}
+	p.Output = resolve(p.Output)
+	p.dir = dir
+	for i := range p.Steps {
+		s := &p.Steps[i]
+		s.Values = resolve(s.Values)
+		s.Schema = resolve(s.Schema)
+		// Profiles are built-in names or files
+		if ext := strings.ToLower(filepath.Ext(s.Profile)); ext == ".yaml" || ext == ".yml" || ext == ".json" {
+			s.Profile = resolve(s.Profile)
+		}
+	}
+	return &p, nil
+}
+
+// Writes reports whether the pipeline writes files for each document
+func (p *Pipeline) Writes() bool {
+	return p.writer() != ""
+}
+
+// writer returns the first step that writes a file
+func (p *Pipeline) writer() string {
+	for _, s := range p.Steps {
+		if s.Name != StepValidate {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// Files returns the documents matched by the inputs, in sorted order.
+// Directories matched are searched for .json files, and documents ignored
+// by .nldignore files are left out. Documents whose outputs would
+// overwrite each other are rejected.
+func (p *Pipeline) Files() ([]string, error) {
+	dir := p.dir
+	if dir == "" {
+		dir = "."
+	}
+	ignored, err := ignore.New(dir)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var files []string
+	for _, pattern := range p.Inputs {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input pattern %q: %w", pattern, err)
+		}
+		if matches, err = ignored.Expand(matches); err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err != nil || info.IsDir() || seen[m] {
+				continue
+			}
+			skip, err := ignored.Ignored(m, false)
+			if err != nil {
+				return nil, err
+			}
+			if skip {
+				continue
+			}
+			// Earlier outputs are not processed again
+			if p.Output != "" && filepath.Dir(m) == filepath.Clean(p.Output) {
+				continue
+			}
+			seen[m] = true
+			files = append(files, m)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no documents match %s", strings.Join(p.Inputs, ", "))
+	}
+	sort.Strings(files)
+
+	names := map[string]string{}
+	for _, f := range files {
+		name := Name(f)
+		if other, ok := names[name]; ok && p.Writes() {
+			return nil, fmt.Errorf("%s and %s would both be written as %s in %s", other, f, name, p.Output)
+		}
+		names[name] = f
+	}
+	return files, nil
+}
+
+// Name returns the name of a document, its file name without extension
+func Name(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// Status values of a file result
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// FileResult is the outcome of running the pipeline on one document
+type FileResult struct {
+	Input  string `json:"input"`
+	Status string `json:"status"`
+	// Outputs are the files written for the document
+	Outputs []string `json:"outputs,omitempty"`
+	// Step is the step that failed
+	Step     string   `json:"step,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	// Duration is the processing time in milliseconds
+	Duration int64 `json:"durationMs"`
+}
+
+// Summary is the machine-readable record of a run
+type Summary struct {
+	Pipeline  string    `json:"pipeline"`
+	Started   time.Time `json:"started"`
+	Duration  int64     `json:"durationMs"`
+	Parallel  int       `json:"parallel"`
+	Total     int       `json:"total"`
+	Succeeded int       `json:"succeeded"`
+	Failed    int       `json:"failed"`
+	// Files are in input order
+	Files []FileResult `json:"files"`
+}
+
+// Run processes files with up to parallel workers, calling report as each
+// file completes. Results are returned in the order of files.
+func Run(files []string, parallel int, process func(file string) FileResult, report func(FileResult)) *Summary {
+	if parallel <= 0 {
+		parallel = runtime.NumCPU()
+	}
+	if parallel > len(files) {
+		parallel = len(files)
+	}
+
+	summary := &Summary{Started: time.Now().UTC(), Parallel: parallel, Total: len(files)}
+	results := make([]FileResult, len(files))
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				start := time.Now()
+				r := process(files[i])
+				r.Input = files[i]
+				r.Duration = time.Since(start).Milliseconds()
+				if r.Status == "" {
+					r.Status = StatusOK
+					if r.Error != "" {
+						r.Status = StatusFailed
+					}
+				}
+				results[i] = r
+
+				mu.Lock()
+				if report != nil {
+					report(r)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	summary.Files = results
+	for _, r := range results {
+		if r.Status == StatusOK {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+	}
+	summary.Duration = time.Since(summary.Started).Milliseconds()
+	return summary
+}
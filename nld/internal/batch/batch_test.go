@@ -0,0 +1,174 @@
+package batch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	config := `
+inputs: docs/*.json
+output: out
+parallel: 4
+steps:
+  - validate:
+      profile: uk
+      requireLocales: [en, fr]
+  - fill:
+      values: data/{name}.yaml
+      set: {company: Acme}
+  - sign
+  - render:
+      format: html
+`
+	p, err := Parse([]byte(config), "/work")
+	if err != nil {
+		t.Fatalf("Failed to parse pipeline: %v", err)
+	}
+	if !reflect.DeepEqual(p.Inputs, Patterns{"/work/docs/*.json"}) || p.Output != "/work/out" || p.Parallel != 4 {
+		t.Errorf("Unexpected pipeline %+v", p)
+	}
+	want := []Step{
+		{Name: StepValidate, Profile: "uk", RequireLocales: []string{"en", "fr"}},
+		{Name: StepFill, Values: "/work/data/{name}.yaml", Set: map[string]string{"company": "Acme"}},
+		{Name: StepSign},
+		{Name: StepRender, Format: "html"},
+	}
+	if !reflect.DeepEqual(p.Steps, want) {
+		t.Errorf("Expected steps %+v, got %+v", want, p.Steps)
+	}
+
+	// JSON is read as well
+	p, err = Parse([]byte(`{"inputs": ["a.json", "b.json"], "steps": ["validate"]}`), ".")
+	if err != nil {
+		t.Fatalf("Failed to parse JSON pipeline: %v", err)
+	}
+	if len(p.Inputs) != 2 || p.Writes() {
+		t.Errorf("Unexpected pipeline %+v", p)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"no inputs", "steps: [validate]", "no inputs"},
+		{"no steps", "inputs: '*.json'", "no steps"},
+		{"unknown step", "inputs: '*.json'\nsteps: [publish]", `unknown step "publish"`},
+		{"unknown option", "inputs: '*.json'\nsteps:\n  - render:\n      colour: red", `unknown option "colour" for step render`},
+		{"two names", "inputs: '*.json'\nsteps:\n  - {validate: {}, sign: {}}", "a step is a name or a mapping"},
+		{"no output", "inputs: '*.json'\nsteps: [validate, sign]", "output directory is required for the sign step"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.config), ".")
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a/one.json", "a/two.json", "b/one.json", "out/one.json"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("{}"), 0644)
+	}
+
+	p := &Pipeline{Inputs: Patterns{filepath.Join(dir, "a", "*.json"), filepath.Join(dir, "out", "*.json")}, Output: filepath.Join(dir, "out"), Steps: []Step{{Name: StepSign}}}
+	files, err := p.Files()
+	if err != nil {
+		t.Fatalf("Failed to list files: %v", err)
+	}
+	want := []string{filepath.Join(dir, "a", "one.json"), filepath.Join(dir, "a", "two.json")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected %v, got %v", want, files)
+	}
+
+	p.Inputs = append(p.Inputs, filepath.Join(dir, "b", "*.json"))
+	if _, err := p.Files(); err == nil || !strings.Contains(err.Error(), "would both be written as one") {
+		t.Errorf("Expected output collision error, got %v", err)
+	}
+
+	// Validation alone writes nothing, so names may repeat
+	p.Steps = []Step{{Name: StepValidate}}
+	if files, err := p.Files(); err != nil || len(files) != 3 {
+		t.Errorf("Expected 3 files, got %v (%v)", files, err)
+	}
+}
+
+func TestFilesIgnored(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"docs/one.json", "docs/drafts/two.json", "docs/three.draft.json", "docs/2025/four.json"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("{}"), 0644)
+	}
+	os.WriteFile(filepath.Join(dir, ".nldignore"), []byte("drafts/\n*.draft.json\n"), 0644)
+
+	p, err := Parse([]byte("inputs: [docs/*]\nsteps: [validate]\n"), dir)
+	if err != nil {
+		t.Fatalf("Failed to parse pipeline: %v", err)
+	}
+	files, err := p.Files()
+	if err != nil {
+		t.Fatalf("Failed to list files: %v", err)
+	}
+	// Directories matched are searched, but for ignored ones
+	want := []string{filepath.Join(dir, "docs", "2025", "four.json"), filepath.Join(dir, "docs", "one.json")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected %v, got %v", want, files)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var files []string
+	for i := 0; i < 20; i++ {
+		files = append(files, fmt.Sprintf("doc%02d.json", i))
+	}
+
+	var running, peak int32
+	process := func(file string) FileResult {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		if strings.HasSuffix(file, "7.json") {
+			return FileResult{Step: StepFill, Error: "unresolved placeholders: client"}
+		}
+		return FileResult{Outputs: []string{"out/" + file}}
+	}
+	reported := 0
+	summary := Run(files, 4, process, func(FileResult) { reported++ })
+
+	if summary.Total != 20 || summary.Succeeded != 18 || summary.Failed != 2 || reported != 20 {
+		t.Errorf("Unexpected summary %+v (%d reported)", summary, reported)
+	}
+	if peak > 4 {
+		t.Errorf("Expected at most 4 documents at once, got %d", peak)
+	}
+	for i, r := range summary.Files {
+		if r.Input != files[i] {
+			t.Fatalf("Expected results in input order, got %s at %d", r.Input, i)
+		}
+	}
+	if r := summary.Files[7]; r.Status != StatusFailed || r.Step != StepFill {
+		t.Errorf("Expected doc07 to fail in fill, got %+v", r)
+	}
+}
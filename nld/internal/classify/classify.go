@@ -0,0 +1,210 @@
+// Package classify suggests the NLD document type of arbitrary JSON and
+// Markdown documents from their structure and wording, for documents whose
+// type is not given
+package classify
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/validator"
+)
+
+// DefaultType is suggested for documents without any distinctive content
+const DefaultType = "contract"
+
+// MinScore is the score below which a suggestion is too weak to act on,
+// such as a document mentioning "total" once
+const MinScore = 3
+
+// declaredWeight is the score of the type a document declares, which
+// outweighs its wording
+const declaredWeight = 20
+
+// Suggestion is a document type a document may be
+type Suggestion struct {
+	Type string `json:"type"`
+	// Schema is the schema file of the type, relative to the executable
+	Schema string `json:"schema"`
+	Score  int    `json:"score"`
+	// Confidence is the share of the score of all types, from 0 to 1
+	Confidence float64 `json:"confidence"`
+	// Reasons are the signals that matched
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// signal is a keyword of the text, or a property name of JSON documents,
+// that suggests a type
+type signal struct {
+	keyword  string
+	property bool
+	weight   int
+}
+
+// signals are the signals of every document type with a schema
+var signals = map[string][]signal{
+	"receipt": {
+		{keyword: "receipt", weight: 4},
+		{keyword: "invoice", weight: 3},
+		{keyword: "subtotal", weight: 3},
+		{keyword: "unit price", weight: 2},
+		{keyword: "payment method", weight: 2},
+		{keyword: "amount paid", weight: 2},
+		{keyword: "quantity", weight: 1},
+		{keyword: "total", weight: 1},
+		{keyword: "tax", weight: 1},
+		{keyword: "items", property: true, weight: 2},
+		{keyword: "lineitems", property: true, weight: 3},
+		{keyword: "unitprice", property: true, weight: 3},
+		{keyword: "quantity", property: true, weight: 2},
+		{keyword: "subtotal", property: true, weight: 3},
+		{keyword: "taxes", property: true, weight: 2},
+		{keyword: "total", property: true, weight: 2},
+	},
+	"nda": {
+		{keyword: "non-disclosure", weight: 5},
+		{keyword: "nondisclosure", weight: 5},
+		{keyword: "confidential information", weight: 4},
+		{keyword: "disclosing party", weight: 3},
+		{keyword: "receiving party", weight: 3},
+		{keyword: "confidentiality", weight: 2},
+		{keyword: "trade secret", weight: 1},
+	},
+	"contract": {
+		{keyword: "statement of work", weight: 3},
+		{keyword: "scope of work", weight: 3},
+		{keyword: "service provider", weight: 3},
+		{keyword: "deliverables", weight: 2},
+		{keyword: "contractor", weight: 2},
+		{keyword: "payment terms", weight: 2},
+		{keyword: "contract", weight: 2},
+		{keyword: "services", weight: 1},
+		{keyword: "client", weight: 1},
+		{keyword: "fees", weight: 1},
+		{keyword: "termination", weight: 1},
+		{keyword: "indemnif", weight: 1},
+		{keyword: "warrant", weight: 1},
+	},
+	"agreement": {
+		{keyword: "agreement", weight: 2},
+		{keyword: "hereby agree", weight: 2},
+		{keyword: "terms and conditions", weight: 2},
+		{keyword: "whereas", weight: 1},
+		{keyword: "in witness whereof", weight: 1},
+		{keyword: "governing law", weight: 1},
+		{keyword: "parties", weight: 1},
+	},
+}
+
+// features are what a document is classified by
+type features struct {
+	// text is the lower case text of the document
+	text string
+	// properties are the lower case property names of JSON documents
+	properties map[string]bool
+	// declared is the type the document declares, if any
+	declared string
+}
+
+// Classify suggests the types of a JSON document, or of a Markdown or plain
+// text one when data is not JSON, most likely first
+func Classify(data []byte) []Suggestion {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err == nil {
+		return Document(v)
+	}
+	return Markdown(string(data))
+}
+
+// Document suggests the types of a decoded JSON document, most likely
+// first. The type it declares in metadata.type counts for most.
+func Document(v interface{}) []Suggestion {
+	f := features{properties: map[string]bool{}}
+	var text strings.Builder
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, item := range v {
+				f.properties[strings.ToLower(k)] = true
+				walk(item)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		case string:
+			text.WriteString(strings.ToLower(v))
+			text.WriteString("\n")
+		}
+	}
+	if obj, ok := v.(map[string]interface{}); ok {
+		if meta, ok := obj["metadata"].(map[string]interface{}); ok {
+			f.declared, _ = meta["type"].(string)
+		}
+	}
+	walk(v)
+	f.text = text.String()
+	return f.suggest()
+}
+
+// frontMatterType finds the type of YAML front matter
+var frontMatterType = regexp.MustCompile(`(?m)^type:\s*["']?([^"'\s]+)`)
+
+// Markdown suggests the types of a Markdown or plain text document, most
+// likely first. The type given in its front matter counts for most.
+func Markdown(text string) []Suggestion {
+	f := features{text: strings.ToLower(text)}
+	if strings.HasPrefix(text, "---\n") {
+		if end := strings.Index(text[4:], "\n---"); end >= 0 {
+			if m := frontMatterType.FindStringSubmatch(text[4 : 4+end]); m != nil {
+				f.declared = m[1]
+			}
+		}
+	}
+	return f.suggest()
+}
+
+// suggest scores every type against the features
+func (f features) suggest() []Suggestion {
+	var suggestions []Suggestion
+	total := 0
+	for _, t := range validator.DocumentTypes() {
+		s := Suggestion{Type: t, Schema: validator.SchemaFile(t)}
+		if strings.EqualFold(f.declared, t) {
+			s.Score += declaredWeight
+			s.Reasons = append(s.Reasons, fmt.Sprintf("declares type %s", f.declared))
+		}
+		for _, sig := range signals[t] {
+			switch {
+			case sig.property && f.properties[sig.keyword]:
+				s.Reasons = append(s.Reasons, fmt.Sprintf("has property %q", sig.keyword))
+			case !sig.property && strings.Contains(f.text, sig.keyword):
+				s.Reasons = append(s.Reasons, fmt.Sprintf("mentions %q", sig.keyword))
+			default:
+				continue
+			}
+			s.Score += sig.weight
+		}
+		total += s.Score
+		suggestions = append(suggestions, s)
+	}
+
+	for i := range suggestions {
+		if total > 0 {
+			suggestions[i].Confidence = float64(suggestions[i].Score) / float64(total)
+		}
+	}
+	// Types score alike fall back on the default type, then on their names
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Type == DefaultType && b.Type != DefaultType
+	})
+	return suggestions
+}
@@ -0,0 +1,71 @@
+package classify
+
+import (
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		name   string
+		data   string
+		expect string
+		schema string
+	}{
+		{
+			name:   "Receipt JSON",
+			data:   `{"store": "Corner Shop", "lineItems": [{"description": "Coffee", "quantity": 2, "unitPrice": 3.5}], "subtotal": 7, "total": 7.7}`,
+			expect: "receipt",
+			schema: "schemas/document-v1.json",
+		},
+		{
+			name:   "NDA Markdown",
+			data:   "# Mutual Non-Disclosure Agreement\n\nThe Receiving Party shall hold the Confidential Information of the Disclosing Party in confidence.\n",
+			expect: "nda",
+			schema: "schemas/nda.schema.json",
+		},
+		{
+			name:   "Services Contract",
+			data:   "## Scope of Work\n\nThe Service Provider delivers the deliverables to the Client. Payment terms are 30 days.\n",
+			expect: "contract",
+		},
+		{
+			name:   "Agreement",
+			data:   "WHEREAS the parties wish to cooperate, they hereby agree to the following terms and conditions.",
+			expect: "agreement",
+		},
+		{
+			name:   "Declared Type",
+			data:   "---\ntitle: Terms\ntype: receipt\n---\n# Terms\n\nThe parties hereby agree to the terms and conditions of this agreement.\n",
+			expect: "receipt",
+		},
+		{
+			name:   "Declared In Metadata",
+			data:   `{"metadata": {"type": "NDA"}, "content": {"sections": [{"id": "fees", "content": "Fees are due as per the payment terms of the contract."}]}}`,
+			expect: "nda",
+		},
+		{
+			name:   "Nothing Distinctive",
+			data:   `{"hello": "world"}`,
+			expect: DefaultType,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			suggestions := Classify([]byte(tc.data))
+			if len(suggestions) == 0 {
+				t.Fatal("Classify returned no suggestions")
+			}
+			best := suggestions[0]
+			if best.Type != tc.expect {
+				t.Errorf("Classify = %+v, expected %s", suggestions, tc.expect)
+			}
+			if tc.schema != "" && best.Schema != tc.schema {
+				t.Errorf("schema = %s, expected %s", best.Schema, tc.schema)
+			}
+			if best.Score > 0 && (len(best.Reasons) == 0 || best.Confidence <= 0 || best.Confidence > 1) {
+				t.Errorf("best suggestion = %+v", best)
+			}
+		})
+	}
+}
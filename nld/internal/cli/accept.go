@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/colors"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/redline"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+// addAcceptCommand adds the accept command
+func (c *CLI) addAcceptCommand() {
+	var force bool
+
+	acceptCmd := &cobra.Command{
+		Use:   "accept [file]",
+		Short: "Finalize a document",
+		Long: `Finalize a document once its terms are agreed: the document is validated,
+rewritten in canonical form, hashed with its digest record stored in
+verification.digest as nld hash --write does, and moved to the executed
+status.
+
+Documents are only finalized when they are valid, have no proposed changes
+left in their redline and every entity has signed; --force skips the
+signature check. Commands that later edit the content of an executed
+document in place warn that it should be amended with nld amend instead.`,
+		Example: `  nld accept contract.json
+  nld verify contract.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := c.runAccept(args[0], force)
+			detail := ""
+			if err == nil {
+				detail = "finalized as " + string(workflow.Executed)
+			}
+			return c.audited(audit.Finalize, args[0], args[0], detail, nil, err)
+		},
+	}
+
+	acceptCmd.Flags().BoolVar(&force, "force", false, "Finalize the document even if not every entity has signed")
+
+	c.rootCmd.AddCommand(acceptCmd)
+}
+
+// runAccept runs the accept command
+func (c *CLI) runAccept(path string, force bool) error {
+	doc, err := c.loadDocument(path, nil)
+	if err != nil {
+		return err
+	}
+	if workflow.Final(doc) {
+		return fmt.Errorf("%s is already %s", path, workflow.Get(doc))
+	}
+	changes, err := redline.Load(doc)
+	if err != nil {
+		return err
+	}
+	if len(changes) > 0 {
+		return fmt.Errorf("%s has %d proposed change(s); accept or reject them with nld redline first", path, len(changes))
+	}
+
+	result, err := checkDocument(c.ctx, doc, nil, validateOptions{})
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		var problems []string
+		for _, e := range result.Errors {
+			problems = append(problems, e.Message)
+		}
+		return withExitCode(ExitValidation, fmt.Errorf("document validation failed: %s", strings.Join(problems, "; ")))
+	}
+	if unsigned := workflow.Unsigned(doc); len(unsigned) > 0 && !force {
+		return fmt.Errorf("cannot finalize a document before every entity has signed (unsigned: %s; use --force to finalize anyway)", strings.Join(unsigned, ", "))
+	}
+
+	// The lifecycle is skipped: accepting a document is the decision to
+	// execute it
+	if _, err := workflow.Set(doc, workflow.Executed, true); err != nil {
+		return err
+	}
+	rec, err := digest.Compute(doc)
+	if err != nil {
+		return fmt.Errorf("failed to hash document: %w", err)
+	}
+	if err := digest.Store(doc, rec); err != nil {
+		return err
+	}
+	if err := c.saveDocument(path, doc); err != nil {
+		return err
+	}
+
+	c.log.Debug("finalized document", "file", path, "root", rec.Root)
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Finalized %s", path)))
+		fmt.Printf("Root: %s\n", rec.Root)
+	}
+	return nil
+}
+
+// warnFinalized warns when doc, about to be written over path, changes the
+// content of an executed or archived document, which should be amended
+// instead
+func (c *CLI) warnFinalized(path string, doc document.Document) {
+	if path == stdio || c.quiet {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	stored, err := document.Parse(data)
+	if err != nil || !workflow.Final(stored) {
+		return
+	}
+	before, err := digest.Compute(stored)
+	if err != nil {
+		return
+	}
+	if after, err := digest.Compute(doc); err != nil || after.Root == before.Root {
+		return
+	}
+	fmt.Fprintln(os.Stderr, colors.Warning(fmt.Sprintf("! %s is %s and this changes its content; use nld amend to create a new revision instead", path, workflow.Get(stored))))
+}
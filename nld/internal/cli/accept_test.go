@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/workflow"
+)
+
+// stderr runs fn and returns what it printed to standard error
+func stderr(t *testing.T, fn func()) string {
+	t.Helper()
+	saved := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	os.Stderr = w
+	printed := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		printed <- buf.String()
+	}()
+
+	fn()
+	w.Close()
+	os.Stderr = saved
+	return <-printed
+}
+
+// signAll signs the document at path for all of its entities
+func signAll(t *testing.T, path string) {
+	t.Helper()
+	key := writeKey(t)
+	for _, signer := range []string{"acme", "xyz"} {
+		if _, err := run(t, New(), "sign", path, "--signer", signer, "--key", key); err != nil {
+			t.Fatalf("sign failed: %v", err)
+		}
+	}
+}
+
+func TestAccept(t *testing.T) {
+	path := writeTestFile(t, "contract.json", testDocument)
+	signAll(t, path)
+
+	if _, err := run(t, New(), "accept", path); err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	doc, err := document.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	if workflow.Get(doc) != workflow.Executed || doc.Verification(false)["digest"] == nil {
+		t.Errorf("Expected an executed document with its digest, got %s", data)
+	}
+	if _, err := run(t, New(), "verify-signature", path); err != nil {
+		t.Errorf("Expected the signatures to survive finalizing, got %v", err)
+	}
+
+	_, err = run(t, New(), "accept", path)
+	if err == nil || !strings.Contains(err.Error(), "is already executed") {
+		t.Errorf("Expected accepting a final document to fail, got %v", err)
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, data) {
+		t.Error("Expected a final document to be left alone")
+	}
+}
+
+func TestAcceptRedlines(t *testing.T) {
+	path := writeTestFile(t, "contract.json", testDocument)
+	if _, err := run(t, New(), "redline", "propose", path, "--section", "fees", "--replace", "10,000", "--text", "12,000"); err != nil {
+		t.Fatalf("redline propose failed: %v", err)
+	}
+	signAll(t, path)
+
+	_, err := run(t, New(), "accept", path, "--force")
+	if err == nil || !strings.Contains(err.Error(), "1 proposed change(s)") {
+		t.Errorf("Expected pending redlines to be refused, got %v", err)
+	}
+}
+
+func TestAcceptUnsigned(t *testing.T) {
+	path := writeTestFile(t, "contract.json", testDocument)
+	key := writeKey(t)
+	if _, err := run(t, New(), "sign", path, "--signer", "acme", "--key", key); err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+
+	_, err := run(t, New(), "accept", path)
+	if err == nil || !strings.Contains(err.Error(), "unsigned: xyz") {
+		t.Errorf("Expected the unsigned entity to be refused, got %v", err)
+	}
+	if _, err := run(t, New(), "accept", path, "--force"); err != nil {
+		t.Errorf("accept --force failed: %v", err)
+	}
+}
+
+func TestWarnFinalized(t *testing.T) {
+	path := writeTestFile(t, "contract.json", testDocument)
+	if _, err := run(t, New(), "accept", path, "--force"); err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	doc, err := document.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	// Saving it without changing its content does not warn
+	warned := stderr(t, func() {
+		if err := New().saveDocument(path, doc); err != nil {
+			t.Fatalf("saveDocument failed: %v", err)
+		}
+	})
+	if warned != "" {
+		t.Errorf("Expected no warning, got %q", warned)
+	}
+
+	doc.Sections()[1]["content"] = "USD 12,000"
+	warned = stderr(t, func() {
+		if err := New().saveDocument(path, doc); err != nil {
+			t.Fatalf("saveDocument failed: %v", err)
+		}
+	})
+	if !strings.Contains(warned, "is executed and this changes its content; use nld amend") {
+		t.Errorf("Expected a warning, got %q", warned)
+	}
+
+	// Quiet commands do not warn
+	c := New()
+	c.quiet = true
+	doc.Sections()[1]["content"] = "USD 15,000"
+	if warned := stderr(t, func() { c.saveDocument(path, doc) }); warned != "" {
+		t.Errorf("Expected no warning with --quiet, got %q", warned)
+	}
+}
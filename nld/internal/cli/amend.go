@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/internal/workflow"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addAmendCommand adds the amend command
+func (c *CLI) addAmendCommand() {
+	var outputPath string
+	var author string
+	var summary string
+	var force bool
+	var keyfile string
+	var passphraseFile string
+
+	amendCmd := &cobra.Command{
+		Use:   "amend [file]",
+		Short: "Create a new revision of an NLD document",
+		Long: `Create the next revision of an NLD document.
+
+The new revision records the root hash of the document it amends in its
+revisions history and carries the full history forward. Verification data
+is dropped because the amended document must be signed again, and a
+document with a status starts over as a draft. The revisions history is
+part of the root hash, so it cannot be changed once the revision is signed.
+
+Encrypted documents are amended into revisions encrypted with the same key.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			return c.runAmend(args[0], outputPath, author, summary, force, key)
+		},
+	}
+
+	amendCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: <file>.rN.json)")
+	amendCmd.Flags().StringVar(&author, "author", "", "Author of the amendment")
+	amendCmd.Flags().StringVarP(&summary, "summary", "m", "", "Summary of the amendment")
+	amendCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+	amendCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	amendCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(amendCmd)
+}
+
+// addRevisionsCommand adds the revisions command
+func (c *CLI) addRevisionsCommand() {
+	revisionsCmd := &cobra.Command{
+		Use:   "revisions [file...]",
+		Short: "Show the amendment history of a document",
+		Long: `Show the amendment history recorded in a document.
+
+When several revisions of the same document are given, they are ordered
+into an amendment chain and every link is checked against the hash of the
+revision it amends.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runRevisions(args)
+		},
+	}
+
+	c.rootCmd.AddCommand(revisionsCmd)
+}
+
+// runAmend runs the amend command
+func (c *CLI) runAmend(inputPath, outputPath, author, summary string, force bool, key *envelope.Key) error {
+	outputPath, err := c.amend(inputPath, outputPath, author, summary, force, key)
+	detail := ""
+	if outputPath != "" {
+		detail = "amended into " + outputPath
+	}
+	return c.audited(audit.Amend, inputPath, outputPath, detail, key, err)
+}
+
+// amend creates the next revision of a document, returning the path it was
+// written to
+func (c *CLI) amend(inputPath, outputPath, author, summary string, force bool, key *envelope.Key) (string, error) {
+	doc, encrypted, err := c.loadSealed(inputPath, key)
+	if err != nil {
+		return "", err
+	}
+
+	rec, err := digest.Compute(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash document: %w", err)
+	}
+
+	meta := doc.Metadata()
+	current := 1
+	if n, err := strconv.Atoi(fmt.Sprint(meta["revision"])); err == nil && n > 0 {
+		current = n
+	}
+	next := current + 1
+
+	if outputPath == "" && inputPath == stdio {
+		outputPath = stdio
+	} else if outputPath == "" {
+		ext := filepath.Ext(inputPath)
+		base := strings.TrimSuffix(inputPath, ext)
+		if i := strings.LastIndex(base, ".r"); i != -1 {
+			if _, err := strconv.Atoi(base[i+2:]); err == nil {
+				base = base[:i]
+			}
+		}
+		outputPath = fmt.Sprintf("%s.r%d%s", base, next, ext)
+	}
+
+	if exists(outputPath) && !force {
+		return "", fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+
+	entry, err := toJSONValue(nld.Revision{
+		Number:   next,
+		Previous: rec.Root,
+		Date:     time.Now().UTC().Format(time.RFC3339),
+		Author:   author,
+		Summary:  summary,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	meta["revision"] = next
+	revisions, _ := doc["revisions"].([]interface{})
+	doc["revisions"] = append(revisions, entry)
+	delete(doc, "verification")
+	if _, ok := meta["status"]; ok {
+		meta["status"] = string(workflow.Draft)
+	}
+
+	if err := c.writeDocument(outputPath, doc, encrypted, key); err != nil {
+		return "", err
+	}
+
+	c.log.Debug("amended document", "revision", next, "previous", rec.Root)
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Created revision %d of %s: %s", next, inputPath, outputPath)))
+	}
+	return outputPath, nil
+}
+
+// runRevisions runs the revisions command
+func (c *CLI) runRevisions(filePaths []string) error {
+	docs := make([]*nld.Document, 0, len(filePaths))
+	paths := make(map[*nld.Document]string, len(filePaths))
+	for _, path := range filePaths {
+		data, err := c.readDocument(path, nil)
+		if err != nil {
+			return fmt.Errorf("failed to read document: %w", err)
+		}
+		doc, err := nld.Parse(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		docs = append(docs, doc)
+		paths[doc] = path
+	}
+
+	chain, err := nld.Chain(docs)
+	if err != nil {
+		return err
+	}
+	latest := chain[len(chain)-1]
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(latest.History(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+		return nil
+	}
+
+	if c.quiet {
+		return nil
+	}
+
+	fmt.Printf("%s (revision %d)\n", latest.Metadata.Title, latest.CurrentRevision())
+	if len(chain) == 1 && len(latest.History()) == 0 {
+		fmt.Println("  No amendments recorded")
+		return nil
+	}
+
+	fmt.Println("  r1: original")
+	for _, rev := range latest.History() {
+		line := fmt.Sprintf("  r%d: %s", rev.Number, rev.Date)
+		if rev.Author != "" {
+			line += " by " + rev.Author
+		}
+		if rev.Summary != "" {
+			line += " - " + rev.Summary
+		}
+		fmt.Println(line)
+		if c.verbose {
+			fmt.Printf("      amends %s\n", rev.Previous)
+		}
+	}
+
+	if len(chain) > 1 {
+		fmt.Println()
+		for _, doc := range chain {
+			hash, _ := doc.Hash()
+			fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ r%d %s %s", doc.CurrentRevision(), paths[doc], hash)))
+		}
+	}
+	return nil
+}
+
+// toJSONValue converts v into the generic form used by document.Document
+func toJSONValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
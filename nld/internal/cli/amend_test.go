@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+func TestAmendEncrypted(t *testing.T) {
+	path, keyfile := writeEncrypted(t)
+	output := filepath.Join(filepath.Dir(path), "contract.r2.json")
+
+	if _, err := run(t, New(), "amend", path, "--keyfile", keyfile, "-m", "Raise fees"); err != nil {
+		t.Fatalf("amend failed: %v", err)
+	}
+	plaintext := checkEncrypted(t, output)
+	if !strings.Contains(plaintext, `"summary": "Raise fees"`) {
+		t.Errorf("Expected the amendment in the revisions, got %s", plaintext)
+	}
+}
+
+func TestAmendRevisionsSigned(t *testing.T) {
+	path := writeTestFile(t, "contract.json", testDocument)
+	output := filepath.Join(filepath.Dir(path), "contract.r2.json")
+	if _, err := run(t, New(), "amend", path, "-m", "Raise fees"); err != nil {
+		t.Fatalf("amend failed: %v", err)
+	}
+	if _, err := run(t, New(), "hash", "--write", output); err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+
+	// Pointing the amendment at another document must be detected
+	data, _ := os.ReadFile(output)
+	doc, err := document.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	doc["revisions"].([]interface{})[0].(map[string]interface{})["previous"] = "sha256:" + strings.Repeat("0", 64)
+	out, _ := doc.Marshal()
+	os.WriteFile(output, out, 0644)
+
+	printed, err := run(t, New(), "verify", output)
+	if ExitCode(err) != ExitSignature || !strings.Contains(printed, "revisions: modified") {
+		t.Errorf("Expected the changed revisions to fail verify, got %v: %s", err, printed)
+	}
+}
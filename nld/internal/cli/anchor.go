@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/anchor"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/signing"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addAnchorCommand adds the anchor command
+func (c *CLI) addAnchorCommand() {
+	var logName string
+	var url string
+	var keyPath string
+	var keyName string
+	var passphraseFile string
+	var configPath string
+
+	anchorCmd := &cobra.Command{
+		Use:   "anchor [file...]",
+		Short: "Record the root hashes of documents in a transparency log",
+		Long: `Submit the root hash of each document, as nld hash computes it, to a
+transparency log, and record the log entry with its Merkle inclusion proof
+in verification.anchors. The digest record is stored too. nld verify later
+checks the proofs offline.
+
+The logs are:
+
+  rekor  a Sigstore Rekor log, ` + anchor.RekorDefaultURL + ` unless --url
+         gives another. Entries are signed with the key of --key,
+         --keyring-key or the signing config, as for nld sign; Ed25519 keys
+         are not accepted.
+  http   a log at the URL of --url, to which the hash is posted as JSON
+
+Documents already anchored in the log with their current root hash are
+skipped.`,
+		Example: `  nld anchor contract.json --keyring-key party1
+  nld anchor contracts/ --log http --url https://log.example.com/entries`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := expandPaths(args)
+			if err != nil {
+				return err
+			}
+			opts := &anchor.Options{URL: url}
+			if logName == anchor.Rekor {
+				cfg, err := signingConfig(keyPath, keyName, passphraseFile, configPath)
+				if err != nil {
+					return err
+				}
+				if opts.Signer, err = signing.Open(c.ctx, cfg); err != nil {
+					return err
+				}
+			}
+			log, err := anchor.New(logName, opts)
+			if err != nil {
+				return withExitCode(ExitUsage, err)
+			}
+			return c.runAnchor(files, log)
+		},
+	}
+
+	anchorCmd.Flags().StringVar(&logName, "log", anchor.Rekor, "Transparency log ("+strings.Join(anchor.Providers(), ", ")+")")
+	anchorCmd.Flags().StringVar(&url, "url", "", "URL of the log")
+	anchorCmd.Flags().StringVar(&keyPath, "key", "", "PEM private key file to sign entries with, instead of the signing config")
+	anchorCmd.Flags().StringVar(&keyName, "keyring-key", "", "Name of the key of the local keyring to sign entries with, instead of the signing config")
+	anchorCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase of an encrypted signing key")
+	anchorCmd.Flags().StringVar(&configPath, "signing-config", "", "Signing config file (default: $"+signing.ConfigEnv+" or ~/.nld/signing.yaml)")
+
+	c.rootCmd.AddCommand(anchorCmd)
+}
+
+// anchorResult is the outcome of anchoring one document
+type anchorResult struct {
+	File    string         `json:"file"`
+	Anchor  *anchor.Anchor `json:"anchor,omitempty"`
+	Skipped bool           `json:"skipped,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// runAnchor anchors documents in a log
+func (c *CLI) runAnchor(files []string, log anchor.Log) error {
+	var results []anchorResult
+	failed := 0
+	for _, path := range files {
+		r := anchorResult{File: path}
+		var err error
+		r.Anchor, r.Skipped, err = c.anchorDocument(path, log)
+		if err != nil {
+			r.Error = err.Error()
+			failed++
+		}
+		results = append(results, r)
+	}
+
+	if c.outputFormat == "json" {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else if !c.quiet {
+		for _, r := range results {
+			switch {
+			case r.Error != "":
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.File, r.Error)))
+			case r.Skipped:
+				fmt.Printf("  %s: already anchored in %s entry %d\n", r.File, r.Anchor.Log, r.Anchor.LogIndex)
+			default:
+				fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Anchored %s root %s in %s entry %d", r.File, r.Anchor.Hash, r.Anchor.Log, r.Anchor.LogIndex)))
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to anchor %d document(s)", failed)
+	}
+	return nil
+}
+
+// anchorDocument anchors the root hash of a document, unless an anchor of
+// the log already holds it
+func (c *CLI) anchorDocument(path string, log anchor.Log) (*anchor.Anchor, bool, error) {
+	doc, err := c.loadDocument(path, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	rec, err := digest.Compute(doc)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to hash document: %w", err)
+	}
+	anchors, err := anchor.Load(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range anchors {
+		if anchors[i].Log == log.Name() && anchors[i].Hash == rec.Root {
+			return &anchors[i], true, nil
+		}
+	}
+
+	a, err := log.Submit(c.ctx, rec.Root)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := digest.Store(doc, rec); err != nil {
+		return nil, false, err
+	}
+	if err := anchor.Add(doc, a); err != nil {
+		return nil, false, err
+	}
+	if err := c.saveDocument(path, doc); err != nil {
+		return nil, false, err
+	}
+	return a, false, nil
+}
+
+// Statuses of an anchor reported by verify
+const (
+	anchorVerified = "verified"
+	anchorInvalid  = "invalid"
+	// anchorStale anchors hold a root hash other than that of the digest
+	// record, such as that of an earlier revision
+	anchorStale = "stale"
+)
+
+// anchorCheck is the outcome of checking one anchor offline
+type anchorCheck struct {
+	Log        string     `json:"log"`
+	URL        string     `json:"url"`
+	LogIndex   int64      `json:"logIndex"`
+	Hash       string     `json:"hash"`
+	Integrated *time.Time `json:"integrated,omitempty"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// checkAnchors checks the inclusion proofs of the anchors of a document
+// against the root hash of its digest record
+func checkAnchors(doc document.Document, root string) ([]anchorCheck, error) {
+	anchors, err := anchor.Load(doc)
+	if err != nil {
+		return nil, err
+	}
+	var checks []anchorCheck
+	for i := range anchors {
+		a := &anchors[i]
+		check := anchorCheck{Log: a.Log, URL: a.URL, LogIndex: a.LogIndex, Hash: a.Hash, Integrated: a.Integrated, Status: anchorVerified}
+		if err := anchor.Verify(a); err != nil {
+			check.Status, check.Error = anchorInvalid, err.Error()
+		} else if a.Hash != root {
+			check.Status = anchorStale
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
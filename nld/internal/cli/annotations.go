@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/annotation"
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addAnnotationsCommand adds the annotations command and its subcommands
+func (c *CLI) addAnnotationsCommand() {
+	annotationsCmd := &cobra.Command{
+		Use:   "annotations",
+		Short: "Comment on the sections of a document",
+		Long: `Add, list and resolve annotations: comments on a section of a document or on
+a range of its text, with their author and date. Annotations are kept in the
+top-level annotations of the document, outside the root hash, so commenting
+on a signed document keeps its signatures valid.
+
+nld render --format html --annotations shows the open annotations as margin
+notes.`,
+	}
+
+	var a nld.Annotation
+	var textRange string
+	addCmd := &cobra.Command{
+		Use:   "add [file]",
+		Short: "Annotate a section of a document",
+		Long: `Annotate a section of a document. With --quote the annotation is about the
+first occurrence of the quoted text in the section; --range gives the start
+and end character offsets of the text instead. The author defaults to the
+actor recorded in the audit log.`,
+		Example: `  nld annotations add lease.json --section rent --text "Check against the market rate"
+  nld annotations add lease.json --section rent --quote "EUR 900" --text "Too low"`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if textRange != "" {
+				r, err := parseTextRange(textRange)
+				if err != nil {
+					return withExitCode(ExitUsage, err)
+				}
+				a.Range = r
+			}
+			if a.Author == "" {
+				a.Author = audit.Actor()
+			}
+			return c.runAnnotationsAdd(args[0], a)
+		},
+	}
+	addCmd.Flags().StringVar(&a.Section, "section", "", "ID of the section to annotate")
+	addCmd.Flags().StringVar(&a.Text, "text", "", "Text of the annotation")
+	addCmd.Flags().StringVar(&a.Quote, "quote", "", "Annotate the first occurrence of this text in the section")
+	addCmd.Flags().StringVar(&textRange, "range", "", "Annotate the characters from start to end of the section, as start:end")
+	addCmd.Flags().StringVar(&a.Author, "author", "", "Author of the annotation (default: $"+audit.ActorEnv+" or the current user)")
+	addCmd.MarkFlagRequired("section")
+	addCmd.MarkFlagRequired("text")
+	addCmd.MarkFlagsMutuallyExclusive("quote", "range")
+	addCmd.RegisterFlagCompletionFunc("section", completeSectionIDs)
+
+	var section string
+	var all bool
+	listCmd := &cobra.Command{
+		Use:   "list [file...]",
+		Short: "List the annotations of documents",
+		Long: `List the open annotations of documents, or every annotation with --all.
+Annotations whose section or quoted text has changed since they were made are
+marked as outdated.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := expandPaths(args)
+			if err != nil {
+				return err
+			}
+			return c.runAnnotationsList(files, section, all)
+		},
+	}
+	listCmd.Flags().StringVar(&section, "section", "", "Only list the annotations of this section")
+	listCmd.Flags().BoolVar(&all, "all", false, "Include resolved annotations")
+
+	var reopen bool
+	resolveCmd := &cobra.Command{
+		Use:     "resolve [file] [id...]",
+		Short:   "Mark annotations as resolved",
+		Example: `  nld annotations resolve lease.json a1 a2`,
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runAnnotationsResolve(args[0], args[1:], !reopen)
+		},
+	}
+	resolveCmd.Flags().BoolVar(&reopen, "reopen", false, "Mark resolved annotations as open again")
+
+	annotationsCmd.AddCommand(addCmd, listCmd, resolveCmd)
+	c.rootCmd.AddCommand(annotationsCmd)
+}
+
+// parseTextRange parses a start:end character range
+func parseTextRange(s string) (*nld.TextRange, error) {
+	start, end, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid range %q: expected start:end", s)
+	}
+	var r nld.TextRange
+	var err error
+	if r.Start, err = strconv.Atoi(start); err != nil {
+		return nil, fmt.Errorf("invalid range %q: %w", s, err)
+	}
+	if r.End, err = strconv.Atoi(end); err != nil {
+		return nil, fmt.Errorf("invalid range %q: %w", s, err)
+	}
+	return &r, nil
+}
+
+// runAnnotationsAdd runs the annotations add command
+func (c *CLI) runAnnotationsAdd(filePath string, a nld.Annotation) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+	if a, err = annotation.Add(doc, a); err != nil {
+		return err
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Added annotation %s to section %s of %s", a.ID, a.Section, filePath)))
+	}
+	return nil
+}
+
+// documentAnnotation is an annotation listed by annotations list
+type documentAnnotation struct {
+	File string `json:"file"`
+	nld.Annotation
+	Outdated bool `json:"outdated,omitempty"`
+}
+
+// runAnnotationsList runs the annotations list command
+func (c *CLI) runAnnotationsList(files []string, section string, all bool) error {
+	list := []documentAnnotation{}
+	for _, path := range files {
+		doc, err := c.loadDocument(path, nil)
+		if err != nil {
+			return err
+		}
+		annotations, err := annotation.Load(doc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, a := range annotations {
+			if (section == "" || a.Section == section) && (all || !a.Resolved) {
+				list = append(list, documentAnnotation{File: path, Annotation: a, Outdated: annotation.Stale(doc, a)})
+			}
+		}
+	}
+
+	if c.outputFormat == "json" {
+		return printJSON(list)
+	}
+	if c.quiet {
+		return nil
+	}
+	if len(list) == 0 {
+		fmt.Println("No annotations")
+		return nil
+	}
+	for _, a := range list {
+		var notes []string
+		if a.Resolved {
+			notes = append(notes, "resolved")
+		}
+		if a.Outdated {
+			notes = append(notes, "outdated")
+		}
+		state := ""
+		if len(notes) > 0 {
+			state = " (" + strings.Join(notes, ", ") + ")"
+		}
+		prefix := ""
+		if len(files) > 1 {
+			prefix = a.File + ": "
+		}
+		fmt.Printf("%s%s\t%s\t%s\t%s%s\n", prefix, a.ID, a.Section, a.Author, a.Date, state)
+		if a.Quote != "" {
+			fmt.Printf("  %q\n", a.Quote)
+		}
+		fmt.Printf("  %s\n", a.Text)
+	}
+	return nil
+}
+
+// runAnnotationsResolve runs the annotations resolve command
+func (c *CLI) runAnnotationsResolve(filePath string, ids []string, resolved bool) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := annotation.Resolve(doc, id, resolved); err != nil {
+			return err
+		}
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		verb := "Resolved"
+		if !resolved {
+			verb = "Reopened"
+		}
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("%s %s in %s", verb, strings.Join(ids, ", "), filePath)))
+	}
+	return nil
+}
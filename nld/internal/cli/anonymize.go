@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/anonymize"
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addAnonymizeCommand adds the anonymize command
+func (c *CLI) addAnonymizeCommand() {
+	var opts anonymize.Options
+	var outputPath string
+	var force bool
+	var keyfile string
+	var passphraseFile string
+
+	anonymizeCmd := &cobra.Command{
+		Use:   "anonymize [file]",
+		Short: "Replace the personal data of an NLD document with pseudonyms",
+		Long: `Write a copy of an NLD document with entity names, email addresses, phone
+numbers, addresses and amounts replaced with pseudonyms, so that real
+documents can be shared as bug reports or test fixtures.
+
+Pseudonyms are derived from the original values with a keyed hash of the
+seed: the same value gets the same pseudonym throughout the document, where
+the text mentions an entity included, and across runs with the same seed.
+Without --seed a random seed is generated and printed. Identifiers, roles and
+dates are kept, and the verification data of the original is cleared.
+
+Names mentioned in the text but not recorded as entities are not replaced,
+so review the copy before sharing it.`,
+		Example: `  nld anonymize contract.json --seed my-seed
+  nld anonymize receipt.json --seed my-seed --keep-amounts -o fixture.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			if outputPath == "" && args[0] == stdio {
+				outputPath = stdio
+			} else if outputPath == "" {
+				ext := filepath.Ext(args[0])
+				outputPath = strings.TrimSuffix(args[0], ext) + ".anonymized" + ext
+			}
+			generated := opts.Seed == ""
+			if generated {
+				seed := make([]byte, 16)
+				if _, err := rand.Read(seed); err != nil {
+					return fmt.Errorf("failed to generate seed: %w", err)
+				}
+				opts.Seed = hex.EncodeToString(seed)
+			}
+			return c.audited(audit.Anonymize, args[0], outputPath, "anonymized into "+outputPath, key, c.runAnonymize(args[0], outputPath, opts, generated, key, force))
+		},
+	}
+
+	anonymizeCmd.Flags().StringVar(&opts.Seed, "seed", "", "Seed of the pseudonyms (default: random)")
+	anonymizeCmd.Flags().BoolVar(&opts.KeepAmounts, "keep-amounts", false, "Keep amounts of money")
+	anonymizeCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: <file>.anonymized.json)")
+	anonymizeCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+	anonymizeCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	anonymizeCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(anonymizeCmd)
+}
+
+// runAnonymize runs the anonymize command
+func (c *CLI) runAnonymize(inputPath, outputPath string, opts anonymize.Options, generated bool, key *envelope.Key, force bool) error {
+	c.log.Info("anonymizing document", "file", inputPath, "output", outputPath)
+
+	if exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+
+	doc, err := c.loadDocument(inputPath, key)
+	if err != nil {
+		return err
+	}
+
+	report, err := anonymize.Apply(doc, opts)
+	if err != nil {
+		return err
+	}
+
+	out, err := doc.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := c.writeOutput(outputPath, out, 0644); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		total := 0
+		for _, n := range report.Replaced {
+			total += n
+		}
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Anonymized %d value(s) of %s into %s", total, inputPath, outputPath)))
+		if generated {
+			fmt.Printf("Seed: %s (use --seed %s to anonymize with the same pseudonyms)\n", opts.Seed, opts.Seed)
+		}
+		if c.verbose {
+			kinds := make([]string, 0, len(report.Replaced))
+			for kind := range report.Replaced {
+				kinds = append(kinds, kind)
+			}
+			sort.Strings(kinds)
+			for _, kind := range kinds {
+				fmt.Printf("  - %d %s(s)\n", report.Replaced[kind], kind)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/attest"
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/signing"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addAttestCommand adds the attest command
+func (c *CLI) addAttestCommand() {
+	var a nld.Attestation
+	var keyPath string
+	var configPath string
+	var certPath string
+	var keyName string
+	var passphraseFile string
+
+	attestCmd := &cobra.Command{
+		Use:   "attest [file]",
+		Short: "Attest a document as a notary or witness",
+		Long: `Record the attestation of a third party, such as a notary witnessing the
+signatures, in verification.attestations. The attestation is signed with the
+identity key of the attester over the root hash of the document, the role
+and the statement, so changing any of them invalidates it. Roles are:
+
+  notary   a notary public
+  witness  any other third party
+
+The key is selected as nld sign does: --key, --keyring-key or the signing
+config, with the certificate chain of --cert or the config embedded in the
+attestation. nld verify-signature checks attestations like signatures, and
+approval workflows of nld serve can require attestations of given roles
+before documents are signed.`,
+		Example: `  nld attest contract.json --role notary --attester jane.doe --statement "Signed in my presence on 12 March 2025" --key notary.pem
+  nld attest contract.json --role witness --attester john.roe --statement "Witnessed the signature of party1" --keyring-key john`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !attest.ValidRole(a.Role) {
+				return withExitCode(ExitUsage, fmt.Errorf("unknown role %q (expected one of: %s)", a.Role, strings.Join(attest.Roles(), ", ")))
+			}
+			cfg, err := signingConfig(keyPath, keyName, passphraseFile, configPath)
+			if err != nil {
+				return err
+			}
+			if certPath != "" {
+				cfg.Certificates = certPath
+			}
+			detail := fmt.Sprintf("attested as %s by %s", a.Role, a.AttesterID)
+			return c.audited(audit.Attest, args[0], args[0], detail, nil, c.runAttest(args[0], a, cfg))
+		},
+	}
+
+	attestCmd.Flags().StringVar(&a.Role, "role", attest.Notary, "Role of the attester: "+strings.Join(attest.Roles(), " or "))
+	attestCmd.Flags().StringVar(&a.AttesterID, "attester", "", "ID of the attester")
+	attestCmd.Flags().StringVar(&a.Statement, "statement", "", "Statement of the attester")
+	attestCmd.Flags().StringVar(&keyPath, "key", "", "PEM private key file of the attester, instead of the signing config")
+	attestCmd.Flags().StringVar(&keyName, "keyring-key", "", "Name of the key of the local keyring to sign with, instead of the signing config")
+	attestCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase of an encrypted signing key")
+	attestCmd.Flags().StringVar(&configPath, "signing-config", "", "Signing config file (default: $"+signing.ConfigEnv+" or ~/.nld/signing.yaml)")
+	attestCmd.Flags().StringVar(&certPath, "cert", "", "PEM certificate chain of the key, leaf first, to embed in the attestation")
+	attestCmd.MarkFlagRequired("attester")
+	attestCmd.MarkFlagRequired("statement")
+
+	c.rootCmd.AddCommand(attestCmd)
+}
+
+// runAttest records an attestation of a document signed with the key of cfg
+func (c *CLI) runAttest(filePath string, a nld.Attestation, cfg *signing.Config) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+	rec, err := digest.Compute(doc)
+	if err != nil {
+		return fmt.Errorf("failed to hash document: %w", err)
+	}
+
+	signer, err := signing.Open(c.ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if a, err = attest.Sign(signer, rec.Root, a); err != nil {
+		return err
+	}
+	if cfg.Certificates != "" {
+		chain, err := signing.LoadCertificates(cfg.Certificates)
+		if err != nil {
+			return err
+		}
+		if err := attest.Attach(&a, chain); err != nil {
+			return err
+		}
+	}
+
+	if err := digest.Store(doc, rec); err != nil {
+		return err
+	}
+	if err := attest.Add(doc, a); err != nil {
+		return err
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if c.outputFormat == "json" {
+		return printJSON(a)
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Attested %s as %s for %s with %s key %s", filePath, a.Role, a.AttesterID, a.Algorithm, a.KeyID)))
+	}
+	return nil
+}
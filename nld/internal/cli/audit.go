@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addAuditCommand adds the audit command and its subcommands
+func (c *CLI) addAuditCommand() {
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show and check the audit log of document operations",
+		Long: `Show the operations recorded in the audit log and check that it was not
+tampered with.
+
+When an audit log is given with --audit-log or $` + audit.LogEnv + `, nld sign, attest,
+amend, redact, anonymize, verify, verify-signature, status set and accept record every
+operation in it, as retention sweep does its deletions, with the actor ($` + audit.ActorEnv + ` or the user running nld), the time, the
+outcome and the root hash of the document. The log is one of:
+
+  <path>          a local file, one JSON entry per line
+  store           the document ` + audit.StoreID + ` of the local store
+  store:<remote>  the document ` + audit.StoreID + ` of a remote store, by URL or name
+
+Each entry holds the hash of the entry before it, so that changing, removing
+or reordering entries is detected, and the head of the log, its last entry,
+is recorded apart from the entries, in <path>.head or as the version of the
+stored log, so that dropping entries from the end is detected too. The
+store reserves ` + audit.StoreID + ` for the log: it cannot be replaced or deleted with
+nld store, nld serve or the retention and privacy commands. Someone who can
+rewrite the log and its head can still replace it; keep the head printed by
+nld audit verify elsewhere and check it with --head to detect that.
+
+Documents are identified by their file name without extension and revision
+suffix, so that contract.json and contract.r2.json share their history.`,
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show [doc-id]",
+		Short: "Show the operations recorded for a document",
+		Long: `Show the operations recorded in the audit log for a document, given by ID or
+by file, or for every document when none is given. The whole log is checked
+for tampering first.`,
+		Example: `  nld audit show lease --audit-log audit.log
+  nld audit show contracts/lease.r2.json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := ""
+			if len(args) == 1 {
+				id = args[0]
+				if _, err := os.Stat(id); err == nil {
+					id = auditDocumentID(id)
+				}
+			}
+			return c.runAuditShow(id)
+		},
+	}
+
+	var heads []string
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check the audit log for tampering",
+		Long: `Check that the entries of the audit log are chained and end at its recorded
+head. With --head the log must also still hold the entries with the given
+hashes, such as heads printed by earlier runs and kept apart from the log,
+so that a log that was truncated or replaced along with its head is
+detected.`,
+		Example: `  nld audit verify --audit-log audit.log
+  nld audit verify --head sha256:5f0c...`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runAuditVerify(heads)
+		},
+	}
+	verifyCmd.Flags().StringArrayVar(&heads, "head", nil, "Hash of an entry the log must still hold, such as an earlier head (repeatable)")
+
+	auditCmd.AddCommand(showCmd, verifyCmd)
+	c.rootCmd.AddCommand(auditCmd)
+}
+
+// auditLogSpec returns the audit log of --audit-log or $NLD_AUDIT_LOG, ""
+// when none is configured
+func (c *CLI) auditLogSpec() string {
+	if c.auditLog != "" {
+		return c.auditLog
+	}
+	return os.Getenv(audit.LogEnv)
+}
+
+// openAuditLog opens the audit log of spec
+func (c *CLI) openAuditLog(spec string) (*audit.Log, error) {
+	if spec == "store" || strings.HasPrefix(spec, "store:") {
+		s, err := c.openStore("", strings.TrimPrefix(strings.TrimPrefix(spec, "store"), ":"))
+		if err != nil {
+			return nil, err
+		}
+		return &audit.Log{Backend: &audit.Store{Store: s}}, nil
+	}
+	return &audit.Log{Backend: &audit.File{Path: spec}}, nil
+}
+
+// revisionSuffix matches the suffix nld amend gives to revisions
+var revisionSuffix = regexp.MustCompile(`\.r[0-9]+$`)
+
+// auditDocumentID returns the ID of the document at path in the audit log
+func auditDocumentID(path string) string {
+	id, err := documentID(path)
+	if err != nil {
+		return stdio
+	}
+	return revisionSuffix.ReplaceAllString(id, "")
+}
+
+// audited records an operation in the audit log, and returns the error of
+// the operation, or else the error of recording it
+func (c *CLI) audited(op, path, resultPath, detail string, key *envelope.Key, err error) error {
+	auditErr := c.recordAudit(op, path, resultPath, detail, key, err)
+	if err != nil {
+		if auditErr != nil {
+			c.log.Warn("failed to record operation in audit log", "operation", op, "error", auditErr)
+		}
+		return err
+	}
+	return auditErr
+}
+
+// recordAudit records an operation on the document at path in the audit
+// log, if one is configured. The root hash recorded is that of the
+// document at resultPath, when it can be read.
+func (c *CLI) recordAudit(op, path, resultPath, detail string, key *envelope.Key, opErr error) error {
+	spec := c.auditLogSpec()
+	if spec == "" {
+		return nil
+	}
+	log, err := c.openAuditLog(spec)
+	if err != nil {
+		return err
+	}
+	e := &audit.Entry{Actor: audit.Actor(), Operation: op, Document: auditDocumentID(path), File: path, Outcome: audit.OK, Detail: detail}
+	if opErr != nil {
+		e.Outcome, e.Detail = audit.Failed, opErr.Error()
+	}
+	if resultPath != "" && resultPath != stdio {
+		if doc, err := c.loadDocument(resultPath, key); err == nil {
+			if rec, err := digest.Compute(doc); err == nil {
+				e.Root = rec.Root
+			}
+		}
+	}
+	c.log.Debug("recording operation in audit log", "operation", op, "document", e.Document)
+	return log.Append(c.ctx, e)
+}
+
+// auditReport is the outcome of reading the audit log
+type auditReport struct {
+	Log     string        `json:"log"`
+	Intact  bool          `json:"intact"`
+	Error   string        `json:"error,omitempty"`
+	Head    string        `json:"head,omitempty"`
+	Entries []audit.Entry `json:"entries"`
+}
+
+// readAuditLog reads and checks the configured audit log, keeping the
+// entries of the document id, or all of them when id is empty
+func (c *CLI) readAuditLog(id string) (*auditReport, error) {
+	spec := c.auditLogSpec()
+	if spec == "" {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("no audit log configured; give --audit-log or set %s", audit.LogEnv))
+	}
+	log, err := c.openAuditLog(spec)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := log.Entries(c.ctx)
+	if err != nil && !audit.IsTampered(err) {
+		return nil, withExitCode(ExitIO, err)
+	}
+	report := &auditReport{Log: spec, Intact: true, Entries: []audit.Entry{}}
+	if err != nil {
+		report.Intact, report.Error = false, err.Error()
+	}
+	if n := len(entries); n > 0 {
+		report.Head = entries[n-1].Hash
+	}
+	for _, e := range entries {
+		if id == "" || e.Document == id {
+			report.Entries = append(report.Entries, e)
+		}
+	}
+	return report, nil
+}
+
+// runAuditShow shows the entries of a document, or all of them
+func (c *CLI) runAuditShow(id string) error {
+	report, err := c.readAuditLog(id)
+	if err != nil {
+		return err
+	}
+	if c.outputFormat == "json" {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else if !c.quiet {
+		printAuditIntegrity(report)
+		if len(report.Entries) == 0 {
+			fmt.Println("  No operations recorded")
+		}
+		for _, e := range report.Entries {
+			line := fmt.Sprintf("  #%-4d %s  %-16s %-6s %s", e.Seq, e.Time.Format(time.RFC3339), e.Operation, e.Outcome, e.Actor)
+			if id == "" {
+				line += "  " + e.Document
+			}
+			if e.Detail != "" {
+				line += ": " + e.Detail
+			}
+			fmt.Println(line)
+			if c.verbose && e.Root != "" {
+				fmt.Printf("         root %s\n", e.Root)
+			}
+		}
+	}
+	if !report.Intact {
+		return withExitCode(ExitSignature, fmt.Errorf("%s", report.Error))
+	}
+	return nil
+}
+
+// runAuditVerify checks the audit log for tampering, and that it holds the
+// entries of heads
+func (c *CLI) runAuditVerify(heads []string) error {
+	report, err := c.readAuditLog("")
+	if err != nil {
+		return err
+	}
+	for _, head := range heads {
+		found := false
+		for _, e := range report.Entries {
+			found = found || e.Hash == head
+		}
+		if !found && report.Intact {
+			report.Intact, report.Error = false, fmt.Sprintf("audit log has no entry %s; it was truncated or replaced", head)
+		}
+	}
+	if c.outputFormat == "json" {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else if !c.quiet {
+		printAuditIntegrity(report)
+	}
+	if !report.Intact {
+		return withExitCode(ExitSignature, fmt.Errorf("%s", report.Error))
+	}
+	return nil
+}
+
+// printAuditIntegrity prints whether the audit log is intact
+func printAuditIntegrity(r *auditReport) {
+	if !r.Intact {
+		fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.Log, r.Error)))
+		return
+	}
+	if r.Head == "" {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ %s has no entries", r.Log)))
+		return
+	}
+	fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ %s is intact, head %s", r.Log, r.Head)))
+}
@@ -0,0 +1,380 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/batch"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/fill"
+	"github.com/colemalphrus/nld/internal/render"
+	"github.com/colemalphrus/nld/internal/schema"
+	"github.com/colemalphrus/nld/internal/tracing"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// renderExtensions are the file extensions of rendered documents
+var renderExtensions = map[string]string{
+	render.Markdown: ".md",
+	render.HTML:     ".html",
+	render.Text:     ".txt",
+}
+
+// addBatchCommand adds the batch command
+func (c *CLI) addBatchCommand() {
+	var parallel int
+	var force bool
+	var keyfile string
+	var passphraseFile string
+
+	batchCmd := &cobra.Command{
+		Use:   "batch [pipeline]",
+		Short: "Run a pipeline of steps over many documents",
+		Long: `Run the steps of a pipeline config file over every document matched by its
+inputs, several documents at a time.
+
+The pipeline is a YAML or JSON file; paths in it are relative to the file:
+
+  inputs: contracts/*.json      # glob pattern, or a list of them
+  output: out                   # directory for documents and renderings
+  parallel: 8                   # documents at a time (default: one per CPU)
+  steps:
+    - validate                  # schema, receipt and profile checks
+    - fill:
+        values: data/{name}.yaml  # {name} is the input file name
+        set: {company: Acme}
+    - sign                      # record the digest, as hash --write does
+    - render:
+        format: html
+
+Steps run in order on each document, and a document stops at the first
+step that fails. Documents changed by fill or sign are written to the
+output directory under their input name, and renderings next to them with
+the extension of their format.
+
+The status of each document is printed as it completes, and a summary of the
+run is written to ` + batch.SummaryFile + ` in the output directory (or printed with
+--output-format json).`,
+		Example: `  nld batch pipeline.yaml
+  nld batch pipeline.yaml --parallel 16 --force`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			p, err := batch.Load(args[0])
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("parallel") {
+				p.Parallel = parallel
+			}
+			return c.runBatch(p, force, key)
+		},
+	}
+
+	batchCmd.Flags().IntVarP(&parallel, "parallel", "j", 0, "Documents processed at once (default: from the pipeline, or one per CPU)")
+	batchCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing output files")
+	batchCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	batchCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(batchCmd)
+}
+
+// batchStep is a pipeline step prepared to run on documents
+type batchStep struct {
+	batch.Step
+	// schema is the schema of a validate step, compiled once for all
+	// documents
+	schema   *schema.Schema
+	validate validateOptions
+	render   render.Options
+}
+
+// runBatch runs the batch command
+func (c *CLI) runBatch(p *batch.Pipeline, force bool, key *envelope.Key) error {
+	files, err := p.Files()
+	if err != nil {
+		return err
+	}
+
+	var steps []batchStep
+	for _, s := range p.Steps {
+		step := batchStep{Step: s}
+		switch s.Name {
+		case batch.StepValidate:
+			step.validate = validateOptions{currency: s.Currency, profile: s.Profile, requireLocales: s.RequireLocales}
+			if s.Schema != "" {
+				if step.schema, err = schema.Load(s.Schema); err != nil {
+					return err
+				}
+			}
+		case batch.StepRender:
+			step.render = render.Options{Format: s.Format, Lang: c.tr, Locales: s.Locales}
+			if step.render.Format == "" {
+				step.render.Format = render.Markdown
+			}
+			if renderExtensions[step.render.Format] == "" {
+				return fmt.Errorf("unsupported render format %q (expected %s)", s.Format, strings.Join(render.Formats(), ", "))
+			}
+			if s.Numbering != "" {
+				if step.render.Numbering, err = nld.LookupNumberingStyle(s.Numbering); err != nil {
+					return err
+				}
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	process := func(file string) batch.FileResult {
+		return c.processBatchFile(p, steps, file, force, key)
+	}
+	bar := c.progress(c.tr.T("progress.batch"), len(files))
+	out := bar.Writer(os.Stdout)
+	report := func(r batch.FileResult) {
+		c.log.Debug("processed document", "file", r.Input, "status", r.Status, "step", r.Step, "duration_ms", r.Duration)
+		bar.Add(1)
+		if c.quiet || c.outputFormat == "json" {
+			return
+		}
+		if r.Status != batch.StatusOK {
+			fmt.Fprintln(out, validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s: %s", r.Input, r.Step, r.Error)))
+		} else if len(r.Outputs) > 0 {
+			fmt.Fprintln(out, validator.ColoredOutput(true, fmt.Sprintf("✓ %s: %s", r.Input, strings.Join(r.Outputs, ", "))))
+		} else {
+			fmt.Fprintln(out, validator.ColoredOutput(true, "✓ "+r.Input))
+		}
+		if c.verbose {
+			for _, w := range r.Warnings {
+				fmt.Fprintf(out, "  ! %s\n", w)
+			}
+		}
+	}
+	summary := batch.Run(files, p.Parallel, process, report)
+	bar.Done()
+	summary.Pipeline = p.Path
+
+	jsonData, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format summary: %w", err)
+	}
+	summaryPath := ""
+	if p.Output != "" {
+		summaryPath = filepath.Join(p.Output, batch.SummaryFile)
+		if err := writeFile(summaryPath, append(jsonData, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+
+	if c.outputFormat == "json" {
+		fmt.Println(string(jsonData))
+	} else if !c.quiet {
+		duration := time.Duration(summary.Duration) * time.Millisecond
+		fmt.Printf("\n%s\n", c.tr.N("batch.summary", summary.Total, summary.Total, duration.Round(time.Millisecond), summary.Succeeded, summary.Failed))
+		if summaryPath != "" {
+			fmt.Println(c.tr.T("batch.summary.file", summaryPath))
+		}
+	}
+
+	if summary.Failed > 0 {
+		return withExitCode(ExitValidation, errors.New(c.tr.N("batch.failed", summary.Total, summary.Failed, summary.Total)))
+	}
+	return nil
+}
+
+// processBatchFile runs the steps of a pipeline on one document
+func (c *CLI) processBatchFile(p *batch.Pipeline, steps []batchStep, file string, force bool, key *envelope.Key) batch.FileResult {
+	ctx, span := tracing.Span(c.ctx, "nld.batch.file", attribute.String("nld.file", file))
+	defer span.End()
+	var r batch.FileResult
+	fail := func(step string, err error) batch.FileResult {
+		r.Step, r.Error, r.Status = step, err.Error(), batch.StatusFailed
+		span.SetAttributes(attribute.String("nld.batch.step", step))
+		span.SetStatus(codes.Error, err.Error())
+		return r
+	}
+	name := batch.Name(file)
+	write := func(path string, data []byte) error {
+		if _, err := os.Stat(path); err == nil && !force {
+			return fmt.Errorf("file already exists: %s (use --force to overwrite)", path)
+		}
+		if err := writeFile(path, data, 0644); err != nil {
+			return err
+		}
+		r.Outputs = append(r.Outputs, path)
+		return nil
+	}
+
+	doc, err := c.loadDocument(file, key)
+	if err != nil {
+		return fail("read", err)
+	}
+
+	var renderings []struct {
+		path string
+		data []byte
+	}
+	changed := false
+	for _, s := range steps {
+		switch s.Name {
+		case batch.StepValidate:
+			warnings, err := validateDocument(ctx, doc, s.schema, s.validate)
+			r.Warnings = append(r.Warnings, warnings...)
+			if err != nil {
+				return fail(s.Name, err)
+			}
+
+		case batch.StepFill:
+			values := map[string]interface{}{}
+			if s.Values != "" {
+				if values, err = fill.LoadValues(strings.ReplaceAll(s.Values, batch.NamePlaceholder, name)); err != nil {
+					return fail(s.Name, err)
+				}
+			}
+			for k, v := range s.Set {
+				values[k] = v
+			}
+			if err := fill.Apply(doc, values); err != nil {
+				return fail(s.Name, err)
+			}
+			changed = true
+
+		case batch.StepSign:
+			rec, err := digest.Compute(doc)
+			if err != nil {
+				return fail(s.Name, fmt.Errorf("failed to hash document: %w", err))
+			}
+			if err := digest.Store(doc, rec); err != nil {
+				return fail(s.Name, err)
+			}
+			changed = true
+
+		case batch.StepRender:
+			typed, err := typedDocument(doc)
+			if err != nil {
+				return fail(s.Name, err)
+			}
+			var buf bytes.Buffer
+			if err := render.RenderContext(ctx, &buf, typed, s.render); err != nil {
+				return fail(s.Name, err)
+			}
+			path := filepath.Join(p.Output, name+renderExtensions[s.render.Format])
+			renderings = append(renderings, struct {
+				path string
+				data []byte
+			}{path, buf.Bytes()})
+		}
+	}
+
+	// Nothing is written until every step has passed
+	if changed {
+		out, err := doc.Marshal()
+		if err != nil {
+			return fail("write", err)
+		}
+		if err := write(filepath.Join(p.Output, name+".json"), out); err != nil {
+			return fail("write", err)
+		}
+	}
+	for _, rendering := range renderings {
+		if err := write(rendering.path, rendering.data); err != nil {
+			return fail("write", err)
+		}
+	}
+	return r
+}
+
+// validateDocument runs the checks of the validate command on a document,
+// against s or the schema for its type when s is nil. It returns the
+// warnings found and an error listing the problems of an invalid document.
+func validateDocument(ctx context.Context, doc document.Document, s *schema.Schema, opts validateOptions) ([]string, error) {
+	result, err := checkDocument(ctx, doc, s, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, w := range result.Warnings {
+		warnings = append(warnings, w.Message)
+	}
+	if !result.Valid {
+		var problems []string
+		for _, e := range result.Errors {
+			problems = append(problems, e.Message)
+		}
+		return warnings, fmt.Errorf("document validation failed: %s", strings.Join(problems, "; "))
+	}
+	return warnings, nil
+}
+
+// checkDocument runs the checks of the validate command on a document and
+// returns the result
+func checkDocument(ctx context.Context, doc document.Document, s *schema.Schema, opts validateOptions) (*validator.ValidationResult, error) {
+	docBytes, err := doc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return checkBytes(ctx, docBytes, s, opts)
+}
+
+// checkBytes runs the checks of the validate command on an encoded
+// document
+func checkBytes(ctx context.Context, docBytes []byte, s *schema.Schema, opts validateOptions) (*validator.ValidationResult, error) {
+	var err error
+	if s == nil {
+		if s, err = schema.GetDocumentSchemaFromBytesContext(ctx, docBytes); err != nil {
+			return nil, fmt.Errorf("failed to determine schema: %w", err)
+		}
+	}
+	result, err := validateSchema(ctx, s, docBytes)
+	if err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if result.Valid {
+		if err := checkReceipt(docBytes, opts.currency, result); err != nil {
+			return nil, err
+		}
+		if _, err := checkProfile(docBytes, opts.profile, result); err != nil {
+			return nil, err
+		}
+		checkLocales(docBytes, opts.requireLocales, result)
+	}
+	opts.severities.Apply(result)
+	return result, nil
+}
+
+// largeDocument is the size from which documents are validated against
+// their schema incrementally rather than decoded into memory as a whole
+const largeDocument = 16 << 20
+
+// validateSchema validates an encoded document against a schema,
+// incrementally when it is large
+func validateSchema(ctx context.Context, s *schema.Schema, docBytes []byte) (*validator.ValidationResult, error) {
+	if len(docBytes) >= largeDocument {
+		return s.ValidateReader(bytes.NewReader(docBytes))
+	}
+	return s.ValidateContext(ctx, docBytes)
+}
+
+// typedDocument converts a document to the typed model
+func typedDocument(doc document.Document) (*nld.Document, error) {
+	data, err := doc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return nld.Parse(data)
+}
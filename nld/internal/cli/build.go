@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/include"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addBuildCommand adds the build command
+func (c *CLI) addBuildCommand() {
+	var outputPath string
+	var libraries []string
+	var force bool
+	var keyfile string
+	var passphraseFile string
+
+	buildCmd := &cobra.Command{
+		Use:   "build [file]",
+		Short: "Resolve clause library includes into a flattened document",
+		Long: `Resolve every {"$include": "<clause>"} entry in a document's sections and
+definitions against a clause library and write the flattened document.
+
+Libraries are local directories or http(s) registries, searched in the
+order given by --library and then by the NLD_CLAUSE_PATH environment
+variable. The clause "confidentiality/mutual" is read from
+<library>/confidentiality/mutual.json. Properties next to $include
+override those of the included clause.
+
+Encrypted documents are built into documents encrypted with the same key.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			if outputPath == "" && args[0] == stdio {
+				outputPath = stdio
+			} else if outputPath == "" {
+				ext := filepath.Ext(args[0])
+				outputPath = strings.TrimSuffix(args[0], ext) + ".built" + ext
+			}
+			return c.runBuild(args[0], outputPath, libraries, force, key)
+		},
+	}
+
+	buildCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: <file>.built.json)")
+	buildCmd.Flags().StringArrayVarP(&libraries, "library", "l", nil, "Clause library directory or registry URL (repeatable)")
+	buildCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+	buildCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	buildCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(buildCmd)
+}
+
+// runBuild runs the build command
+func (c *CLI) runBuild(inputPath, outputPath string, libraries []string, force bool, key *envelope.Key) error {
+	if exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+
+	doc, encrypted, err := c.loadSealed(inputPath, key)
+	if err != nil {
+		return err
+	}
+
+	resolver := &include.Resolver{Sources: include.Sources(libraries)}
+	resolved, err := resolver.Resolve(doc)
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeDocument(outputPath, doc, encrypted, key); err != nil {
+		return err
+	}
+
+	for _, r := range resolved {
+		c.log.Debug("included clause", "ref", r.Ref, "source", r.Source)
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Built %s with %d include(s): %s", inputPath, len(resolved), outputPath)))
+	}
+	return nil
+}
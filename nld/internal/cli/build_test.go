@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+)
+
+func TestBuildEncrypted(t *testing.T) {
+	library := t.TempDir()
+	os.MkdirAll(filepath.Join(library, "confidentiality"), 0755)
+	os.WriteFile(filepath.Join(library, "confidentiality", "mutual.json"), []byte(`{"id": "confidentiality", "title": "Confidentiality", "content": "Each party keeps the other's information confidential."}`), 0644)
+
+	doc := strings.Replace(testDocument, `"sections": [`, `"sections": [{"$include": "confidentiality/mutual"},`, 1)
+	keyfile := writeTestFile(t, "key", "0123456789abcdef")
+	sealed, err := envelope.Encrypt([]byte(doc), &envelope.Key{Keyfile: []byte("0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	path := writeTestFile(t, "contract.json", string(sealed))
+	output := filepath.Join(filepath.Dir(path), "built.json")
+
+	if _, err := run(t, New(), "build", path, "--keyfile", keyfile, "--library", library, "-o", output); err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if plaintext := checkEncrypted(t, output); !strings.Contains(plaintext, "information confidential") {
+		t.Errorf("Expected the clause to be included, got %s", plaintext)
+	}
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/classify"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/schema"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addClassifyCommand adds the classify command
+func (c *CLI) addClassifyCommand() {
+	var keyfile string
+	var passphraseFile string
+
+	classifyCmd := &cobra.Command{
+		Use:   "classify [file...]",
+		Short: "Suggest the document type and schema of JSON or Markdown documents",
+		Long: `Suggest the most likely NLD document type of JSON and Markdown documents,
+NLD or not, from their structure and wording: property names such as
+lineItems and unitPrice, and phrases such as "confidential information" or
+"statement of work". The type a document declares, in metadata.type or its
+front matter, counts for most. Files that are not JSON are read as Markdown
+or plain text.
+
+Each document is reported with its most likely type, the confidence of the
+suggestion, the share of the score of all types, and the schema of the
+type. With --verbose every type is listed with the signals that matched.
+
+nld import guesses the type of sources that give none the same way, and
+nld validate --auto validates documents without a known type against the
+schema of their most likely type.`,
+		Example: `  nld classify notes.md legacy.json
+  nld classify -v export.json
+  nld classify --output-format json contracts/*.md`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			return c.runClassify(args, key)
+		},
+	}
+
+	classifyCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	classifyCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(classifyCmd)
+}
+
+// classifyResult is the JSON output of the classify command for a file
+type classifyResult struct {
+	File        string                `json:"file"`
+	Suggestions []classify.Suggestion `json:"suggestions,omitempty"`
+	Error       string                `json:"error,omitempty"`
+}
+
+// runClassify suggests the types of files
+func (c *CLI) runClassify(files []string, key *envelope.Key) error {
+	var results []classifyResult
+	failed := 0
+	for _, path := range files {
+		result := classifyResult{File: path}
+		data, err := c.readDocument(path, key)
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+		} else {
+			result.Suggestions = classify.Classify(data)
+		}
+		results = append(results, result)
+	}
+
+	if c.outputFormat == "json" {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else {
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Fprintln(os.Stderr, validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.File, r.Error)))
+				continue
+			}
+			if !c.quiet {
+				c.printSuggestions(r)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return withExitCode(ExitIO, fmt.Errorf("%d file(s) could not be read", failed))
+	}
+	return nil
+}
+
+// printSuggestions prints the suggestions for a file, all of them with
+// --verbose
+func (c *CLI) printSuggestions(r classifyResult) {
+	best := r.Suggestions[0]
+	if best.Score < classify.MinScore {
+		fmt.Printf("%s: %s (no distinctive content; default type, %s)\n", r.File, best.Type, best.Schema)
+	} else {
+		fmt.Printf("%s: %s (%.0f%% confidence, %s)\n", r.File, best.Type, best.Confidence*100, best.Schema)
+	}
+	if !c.verbose {
+		return
+	}
+	for _, s := range r.Suggestions {
+		fmt.Printf("  %-10s %3.0f%%  score %d", s.Type, s.Confidence*100, s.Score)
+		if len(s.Reasons) > 0 {
+			fmt.Printf("  %s", strings.Join(s.Reasons, ", "))
+		}
+		fmt.Println()
+	}
+}
+
+// classifiedSchema returns the schema of a document for validate --auto:
+// that of the type it declares when the type has a schema, or else that of
+// its most likely type, which is returned too
+func (c *CLI) classifiedSchema(docBytes []byte) (*schema.Schema, *classify.Suggestion, error) {
+	var doc struct {
+		Metadata struct {
+			Type string `json:"type"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(docBytes, &doc); err != nil {
+		// Reported with its position
+		s, err := schema.GetDocumentSchemaFromBytesContext(c.ctx, docBytes)
+		return s, nil, err
+	}
+	for _, t := range validator.DocumentTypes() {
+		if strings.EqualFold(t, doc.Metadata.Type) {
+			s, err := schema.ForTypeContext(c.ctx, t)
+			return s, nil, err
+		}
+	}
+	best := classify.Classify(docBytes)[0]
+	s, err := schema.ForTypeContext(c.ctx, best.Type)
+	return s, &best, err
+}
@@ -1,21 +1,39 @@
 package cli
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
-	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/baseline"
+	"github.com/colemalphrus/nld/internal/classify"
+	"github.com/colemalphrus/nld/internal/colors"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/i18n"
+	"github.com/colemalphrus/nld/internal/logging"
+	"github.com/colemalphrus/nld/internal/metrics"
+	"github.com/colemalphrus/nld/internal/progress"
+	"github.com/colemalphrus/nld/internal/report"
 	"github.com/colemalphrus/nld/internal/schema"
+	"github.com/colemalphrus/nld/internal/tracing"
+	"github.com/colemalphrus/nld/internal/tracing/otlp"
 	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/trace"
 )
 
-const (
-	// Version is the current version of the NLD tool
-	Version = "0.1.0"
-)
+// Version is the current version of the NLD tool, set when linking
+// release builds as described by nld.BuildInfo
+var Version = nld.BuildInfo().Version
 
 // CLI handles command-line interface operations for the NLD tool
 type CLI struct {
@@ -24,12 +42,45 @@ type CLI struct {
 	verbose      bool
 	quiet        bool
 	outputFormat string
+	// lang is the language selected with --lang and tr its catalog
+	lang string
+	tr   *i18n.Catalog
+	// log receives diagnostic messages, configured by logOptions; closeLog
+	// closes its log file
+	log        *slog.Logger
+	logOptions logging.Options
+	closeLog   func() error
+	// statsd is the address metrics are sent to, if any
+	statsd       string
+	statsdClient *metrics.Statsd
+	// color is the mode of --color
+	color string
+	// auditLog is the audit log of --audit-log
+	auditLog string
+	// ctx is the context of the running command, holding its trace span;
+	// stopTracing flushes the spans
+	ctx         context.Context
+	span        trace.Span
+	stopTracing func(context.Context) error
+	// stdin is read for the file name "-" and stdinData holds what was read
+	stdin     io.Reader
+	stdinData []byte
+	// stdout receives the documents written to the output path "-"
+	stdout io.Writer
+	// newRelease receives the newer release found by the update check, or
+	// "", when one runs
+	newRelease chan string
 }
 
 // New creates a new CLI instance
 func New() *CLI {
 	cli := &CLI{
 		validator: validator.New(),
+		tr:        i18n.English(),
+		log:       logging.Discard(),
+		ctx:       context.Background(),
+		stdin:     os.Stdin,
+		stdout:    os.Stdout,
 	}
 	
 	cli.setupCommands()
@@ -38,8 +89,29 @@ func New() *CLI {
 
 // Execute executes the CLI with the given arguments
 func (c *CLI) Execute(args []string) error {
+	start := time.Now()
 	c.rootCmd.SetArgs(args)
-	return c.rootCmd.Execute()
+	cmd, err := c.rootCmd.ExecuteC()
+	if err != nil {
+		metrics.Errors.Inc(exitClass(ExitCode(err)))
+	}
+	c.notifyUpdate()
+	c.recordTelemetry(cmd, start, err)
+	if c.span != nil {
+		tracing.End(c.span, err)
+	}
+	if c.stopTracing != nil {
+		flush, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		c.stopTracing(flush)
+		cancel()
+	}
+	if c.statsdClient != nil {
+		c.statsdClient.Close()
+	}
+	if c.closeLog != nil {
+		c.closeLog()
+	}
+	return err
 }
 
 // setupCommands initializes all CLI commands
@@ -49,59 +121,284 @@ func (c *CLI) setupCommands() {
 		Use:   "nld",
 		Short: "NLD - Next-Gen Layout Document Tool",
 		Long: `NLD is a tool for working with Next-Gen Layout Documents.
-It provides functionality for creating, validating, and managing NLD documents.`,
+It provides functionality for creating, validating, and managing NLD documents.
+
+Use - as a file name to read a document from standard input, or as an
+output path to write to standard output. Status messages are then left out,
+so that only the document is written there.`,
 		SilenceUsage: true,
 		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.loadLanguage(); err != nil {
+				return err
+			}
+			if err := c.configureColors(); err != nil {
+				return err
+			}
+			c.checkForUpdate(cmd)
+			if err := c.startLogging(); err != nil {
+				return err
+			}
+			if err := c.startTracing(cmd); err != nil {
+				return err
+			}
+			return c.startMetrics()
+		},
 	}
 
 	// Global flags
 	c.rootCmd.PersistentFlags().BoolVarP(&c.verbose, "verbose", "v", false, "Enable verbose output")
 	c.rootCmd.PersistentFlags().BoolVarP(&c.quiet, "quiet", "q", false, "Suppress all output except errors")
-	c.rootCmd.PersistentFlags().StringVar(&c.outputFormat, "output-format", "text", "Output format (text, json)")
+	c.rootCmd.PersistentFlags().StringVar(&c.outputFormat, "output-format", "text", "Output format ("+strings.Join(report.Formats(), ", ")+")")
+	c.rootCmd.PersistentFlags().StringVar(&c.logOptions.Level, "log-level", "", "Lowest level of diagnostic messages logged ("+strings.Join(logging.Levels(), ", ")+"; default: warn, or debug with --verbose)")
+	c.rootCmd.PersistentFlags().StringVar(&c.logOptions.Format, "log-format", logging.Text, "Format of diagnostic messages ("+strings.Join(logging.Formats(), ", ")+")")
+	c.rootCmd.PersistentFlags().StringVar(&c.logOptions.File, "log-file", "", "Append diagnostic messages to this file (default: standard error)")
+	c.rootCmd.PersistentFlags().StringVar(&c.statsd, "statsd", "", "Send metrics to the statsd server at this host:port (default: $"+metrics.StatsdEnv+")")
+	c.rootCmd.PersistentFlags().StringVar(&c.color, "color", colors.Auto, "When to color output ("+strings.Join(colors.Modes(), ", ")+"; auto colors terminals unless $"+colors.NoColorEnv+" is set)")
+	c.rootCmd.PersistentFlags().StringVar(&c.auditLog, "audit-log", "", "Record sign, attest, amend, redact, anonymize, verify, verify-signature, status set, accept and retention sweep operations in this audit log: a file, store or store:<remote> (default: $"+audit.LogEnv+")")
+	c.rootCmd.PersistentFlags().StringVar(&c.lang, "lang", "", "Language of messages and rendered documents ("+strings.Join(i18n.Languages(), ", ")+"; default: $"+i18n.LangEnv+", the locale, or en)")
 	
 	// Version flag on root command
 	c.rootCmd.Flags().BoolP("version", "V", false, "Display version information")
-	c.rootCmd.Run = func(cmd *cobra.Command, args []string) {
+	c.rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
 		versionFlag, _ := cmd.Flags().GetBool("version")
 		if versionFlag {
-			c.showVersion()
-			return
+			return c.showVersion()
 		}
 		
 		// If no subcommand is provided, show help
 		if len(args) == 0 {
 			cmd.Help()
 		}
+		return nil
 	}
 
 	// Add subcommands
 	c.addValidateCommand()
 	c.addInitCommand()
 	c.addVersionCommand()
+	c.addEncryptCommand()
+	c.addDecryptCommand()
+	c.addRedactCommand()
+	c.addAnonymizeCommand()
+	c.addHashCommand()
+	c.addVerifyCommand()
+	c.addSignCommand()
+	c.addVerifySignatureCommand()
+	c.addAttestCommand()
+	c.addKeysCommand()
+	c.addAnchorCommand()
+	c.addAuditCommand()
+	c.addStatusCommand()
+	c.addAcceptCommand()
+	c.addEsignCommand()
+	c.addAmendCommand()
+	c.addRevisionsCommand()
+	c.addHistoryCommand()
+	c.addCompareCommand()
+	c.addPlaybookCommand()
+	c.addHooksCommand()
+	c.addStoreCommand()
+	c.addSearchCommand()
+	c.addDedupeCommand()
+	c.addBuildCommand()
+	c.addFillCommand()
+	c.addEntityCommand()
+	c.addSectionsCommand()
+	c.addAnnotationsCommand()
+	c.addRedlineCommand()
+	c.addRenderCommand()
+	c.addLintCommand()
+	c.addStatsCommand()
+	c.addTermsCommand()
+	c.addObligationsCommand()
+	c.addExpiryCommand()
+	c.addRetentionCommand()
+	c.addPrivacyCommand()
+	c.addGraphCommand()
+	c.addFmtCommand()
+	c.addExportCommand()
+	c.addImportCommand()
+	c.addClassifyCommand()
+	c.addBatchCommand()
+	c.addGenerateCommand()
+	c.addGenCommand()
+	c.addSchemaCommand()
+	c.addFuzzCommand()
+	c.addPreviewCommand()
+	c.addServeCommand()
+	c.addPluginCommand()
+	c.addDoctorCommand()
+	c.addTelemetryCommand()
+	c.addCompletionCommand()
+	c.addExitCodesTopic()
+	// Plugins come last so that built-in commands take precedence
+	c.addPluginCommands()
+
+	c.registerCompletions()
 }
 
 // addValidateCommand adds the validate command
 func (c *CLI) addValidateCommand() {
-	var schemaPath string
+	var opts validateOptions
 	var force bool
+	var stream bool
+	var keyfile string
+	var passphraseFile string
+	var severities map[string]string
 	
 	validateCmd := &cobra.Command{
-		Use:   "validate [file...]",
+		Use:   "validate [file|dir...]",
 		Short: "Validate an NLD document",
-		Long:  "Validate one or more NLD documents against their schema",
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Validate one or more NLD documents against their schema. Directories
+are searched for .json files, skipping those listed in .nldignore files.
+
+Receipts are also checked for arithmetic: line amounts must equal quantity
+times unit price, and the line items, taxes and total must add up, to the
+precision of the receipt currency (or --currency).
+
+Contracts and agreements are also checked against the validation profile for
+their metadata.jurisdiction, such as a governing law section or a GDPR clause
+for EU documents. Select another profile by name or file with --profile, or
+disable profiles with --profile none. Built-in profiles: ` + strings.Join(profileNames(), ", ") + `.
+
+With --stream, the file (or standard input when none is given) holds
+newline-delimited JSON, one document per line, such as a document export.
+Records are read and validated one at a time, so memory use does not grow
+with the size of the stream. Invalid records are reported with their record
+and line number; with --output-format json one result is written per line.
+The github and sarif formats are not supported with --stream.
+
+Schema errors are shown as a tree, each under the error it caused. Documents
+with many similar errors, such as the same property missing from every
+section, are easier to read with --group-by:
+
+  field    errors with the same message at the same field of different list
+           items, listed with their indexes: sections/[3, 7, 9]/title
+  keyword  errors of the same schema keyword, whatever their message
+
+--max-errors caps the errors, or groups, reported for each document.
+
+Errors with an obvious fix are reported with it: a missing required
+property is added with an empty value or its default, a string that is a
+typo of one value of an enum is replaced by it, and a value of the wrong
+type is converted when nothing is lost, such as "12" to 12. --fix applies
+them to the files, asking for each, or for none with --yes, before the
+documents are validated.
+
+Every error has a rule: the schema keyword that failed, such as required,
+enum or type, or one of json, section-ids, tables, dates, relationships,
+receipt, profile and locales. --severity reports the errors of a rule as
+warnings, or leaves them out with off:
+
+  nld validate --severity additionalProperties=warning,dates=off *.json
+
+To adopt nld on existing documents, record their findings in a baseline
+file with --update-baseline. Later runs with --baseline report and fail
+on new findings only. Findings are matched by file, field, rule and
+message, so run nld from the same directory each time.
+
+Documents that declare no type, or a type without a schema, are validated
+against the default schema. With --auto they are validated against the
+schema of the type their content suggests instead, as nld classify reports
+it.`,
+		Example: `  nld validate contract.json receipt.json
+  nld validate --stream export.ndjson
+  nld validate --auto legacy/*.json
+  nld validate large-contract.json --group-by field --max-errors 20
+  nld validate contract.json --fix
+  nld validate --baseline baseline.json --update-baseline contracts/*.json
+  nld validate --baseline baseline.json contracts/*.json
+  curl -s https://example.com/export | nld validate --stream --output-format json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if stream {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.runValidateFiles(args, schemaPath, force)
+			if opts.maxErrors < 0 {
+				return withExitCode(ExitUsage, fmt.Errorf("--max-errors must not be negative"))
+			}
+			if opts.groupBy != "" && opts.groupBy != validator.GroupByField && opts.groupBy != validator.GroupByKeyword {
+				return withExitCode(ExitUsage, fmt.Errorf("unsupported grouping: %s (supported: %s, %s)", opts.groupBy, validator.GroupByField, validator.GroupByKeyword))
+			}
+			var err error
+			if opts.severities, err = validator.ParseSeverities(severities); err != nil {
+				return withExitCode(ExitUsage, err)
+			}
+			if opts.updateBaseline && opts.baselinePath == "" {
+				return withExitCode(ExitUsage, fmt.Errorf("--update-baseline requires --baseline"))
+			}
+			if opts.auto && opts.schemaPath != "" {
+				return withExitCode(ExitUsage, fmt.Errorf("--auto cannot be used with --schema"))
+			}
+			if stream {
+				if opts.fix {
+					return withExitCode(ExitUsage, fmt.Errorf("--fix cannot be used with --stream"))
+				}
+				if opts.groupBy != "" {
+					return withExitCode(ExitUsage, fmt.Errorf("--group-by cannot be used with --stream"))
+				}
+				if opts.baselinePath != "" {
+					return withExitCode(ExitUsage, fmt.Errorf("--baseline cannot be used with --stream"))
+				}
+				if opts.auto {
+					return withExitCode(ExitUsage, fmt.Errorf("--auto cannot be used with --stream"))
+				}
+				if c.outputFormat != report.Text && c.outputFormat != report.JSON {
+					return withExitCode(ExitUsage, fmt.Errorf("--output-format %s cannot be used with --stream (supported: %s, %s)", c.outputFormat, report.Text, report.JSON))
+				}
+				path := ""
+				if len(args) == 1 {
+					path = args[0]
+				}
+				return c.runValidateStream(path, opts)
+			}
+			if opts.updateBaseline {
+				opts.baseline = &baseline.Baseline{}
+			} else if opts.baselinePath != "" {
+				if opts.baseline, err = baseline.Load(opts.baselinePath); err != nil {
+					return withExitCode(ExitIO, err)
+				}
+			}
+			files, err := expandPaths(args)
+			if err != nil {
+				return err
+			}
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			opts.key = key
+			return c.runValidateFiles(files, opts, force)
 		},
 	}
 	
 	// Add validate-specific flags
-	validateCmd.Flags().StringVarP(&schemaPath, "schema", "s", "", "Path to schema file (optional)")
+	validateCmd.Flags().StringVarP(&opts.schemaPath, "schema", "s", "", "Path to schema file (optional)")
+	validateCmd.Flags().BoolVar(&opts.auto, "auto", false, "Validate documents without a known type against the schema of the type their content suggests")
+	validateCmd.Flags().StringVar(&opts.currency, "currency", "", "ISO 4217 currency whose precision is used for receipt amounts (default: the receipt currency)")
+	validateCmd.Flags().StringSliceVar(&opts.requireLocales, "require-locales", nil, "Locales every section must be translated into")
+	validateCmd.Flags().StringVar(&opts.profile, "profile", "", "Validation profile name or file, or none (default: based on jurisdiction)")
 	validateCmd.Flags().BoolVar(&force, "force", false, "Continue validation even if some files fail")
+	validateCmd.Flags().IntVar(&opts.maxErrors, "max-errors", 0, "Report at most this many errors, or groups of errors, per document (default: all)")
+	validateCmd.Flags().StringVar(&opts.groupBy, "group-by", "", "Group similar errors by field or keyword")
+	validateCmd.Flags().BoolVar(&opts.fix, "fix", false, "Apply the suggested fixes to the files, asking for each")
+	validateCmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "Apply the suggested fixes without asking")
+	validateCmd.Flags().StringToStringVar(&severities, "severity", nil, "Severity of rules, as rule=error, rule=warning or rule=off")
+	validateCmd.Flags().StringVar(&opts.baselinePath, "baseline", "", "Baseline file of known findings, which are not reported")
+	validateCmd.Flags().BoolVar(&opts.updateBaseline, "update-baseline", false, "Record the findings of the files in the --baseline file")
+	validateCmd.Flags().BoolVar(&stream, "stream", false, "Validate newline-delimited JSON documents from a file or standard input")
+	validateCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	validateCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
 	
 	c.rootCmd.AddCommand(validateCmd)
 }
 
+// initTemplates are the document types init has a template for
+var initTemplates = map[string]bool{"contract": true, "receipt": true, "agreement": true}
+
 // addInitCommand adds the init command
 func (c *CLI) addInitCommand() {
 	var docType string
@@ -134,22 +431,174 @@ func (c *CLI) addVersionCommand() {
 	versionCmd := &cobra.Command{
 		Use:   "version",
 		Short: "Display version information",
-		Long:  "Display detailed version information about the NLD tool",
-		Run: func(cmd *cobra.Command, args []string) {
-			c.showVersion()
+		Long: `Display the version of the NLD tool and how it was built: the commit and
+date of the build, the Go version and the schemas built in, with their
+SHA-256 digests.
+
+With --output-format json, the same information is written as an object,
+for tooling that checks which nld is installed.`,
+		Example: `  nld version
+  nld version --output-format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.showVersion()
 		},
 	}
 	
 	c.rootCmd.AddCommand(versionCmd)
 }
 
+// loadLanguage loads the catalog for --lang, or for NLD_LANG or the
+// locale when the flag is not given
+func (c *CLI) loadLanguage() error {
+	lang := c.lang
+	if lang == "" {
+		lang = i18n.FromEnv()
+	}
+	tr, err := i18n.Load(lang)
+	if err != nil {
+		return err
+	}
+	c.tr = tr
+	return nil
+}
+
+// configureColors turns colors on or off as --color asks, with the theme of
+// NLD_COLORS
+func (c *CLI) configureColors() error {
+	enabled, err := colors.Enabled(c.color, os.Stdout)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	theme, err := colors.ParseTheme(os.Getenv(colors.ThemeEnv))
+	if err != nil {
+		return withExitCode(ExitUsage, fmt.Errorf("invalid %s: %w", colors.ThemeEnv, err))
+	}
+	colors.Configure(enabled, theme)
+	return nil
+}
+
+// startTracing exports a trace of the command when the OTEL_ environment
+// variables ask for it, as a child of TRACEPARENT when set
+func (c *CLI) startTracing(cmd *cobra.Command) error {
+	stop, err := otlp.Start(context.Background(), Version)
+	if err != nil {
+		return err
+	}
+	c.stopTracing = stop
+	c.ctx, c.span = tracing.Span(tracing.FromEnv(context.Background()), cmd.CommandPath())
+	return nil
+}
+
+// startMetrics sends metrics to the statsd server of --statsd, or of
+// NLD_STATSD when the flag is not given
+func (c *CLI) startMetrics() error {
+	addr := c.statsd
+	if addr == "" {
+		addr = os.Getenv(metrics.StatsdEnv)
+	}
+	if addr == "" {
+		return nil
+	}
+	s, err := metrics.DialStatsd(addr)
+	if err != nil {
+		return err
+	}
+	c.statsdClient = s
+	metrics.Default.Forward(s)
+	return nil
+}
+
+// startLogging sets up the logger from the --log-* flags. Without
+// --log-level, --verbose logs debug messages and --quiet only errors.
+func (c *CLI) startLogging() error {
+	opts := c.logOptions
+	if opts.Level == "" {
+		switch {
+		case c.verbose:
+			opts.Level = "debug"
+		case c.quiet:
+			opts.Level = "error"
+		default:
+			opts.Level = "warn"
+		}
+	}
+	logger, closeLog, err := logging.New(os.Stderr, opts)
+	if err != nil {
+		return err
+	}
+	c.log, c.closeLog = logger, closeLog
+	return nil
+}
+
+// validateOptions holds the settings of the validate command
+type validateOptions struct {
+	schemaPath string
+	// currency overrides the currency used for receipt amounts
+	currency string
+	// profile names the validation profile; empty selects it by
+	// jurisdiction
+	profile string
+	// requireLocales lists the locales every section must be translated
+	// into
+	requireLocales []string
+	key            *envelope.Key
+	// maxErrors is the number of errors, or groups of errors, reported
+	// for each document; 0 reports all
+	maxErrors int
+	// groupBy groups similar errors by field or keyword when set
+	groupBy string
+	// fix applies the suggested fixes before validating, asking for each
+	// unless yes is set
+	fix bool
+	yes bool
+	// severities sets the severity of rules, such as required=warning
+	severities validator.Severities
+	// baseline holds the known findings, which do not fail validation.
+	// With updateBaseline the findings are recorded into it instead.
+	baseline       *baseline.Baseline
+	baselinePath   string
+	updateBaseline bool
+	// auto validates documents that declare no known type against the
+	// schema of the type their content suggests
+	auto bool
+}
+
 // runValidateFiles runs the validate command for multiple files
-func (c *CLI) runValidateFiles(filePaths []string, schemaPath string, force bool) error {
+func (c *CLI) runValidateFiles(filePaths []string, opts validateOptions, force bool) error {
+	// Progress is shown for several documents, but not while --fix asks
+	// which fixes to apply
+	var bar *progress.Bar
+	if len(filePaths) > 1 && !opts.fix {
+		bar = c.progress(c.tr.T("progress.validate"), len(filePaths))
+	}
+	rep, err := c.reporter("validate", bar.Writer(os.Stdout))
+	if err != nil {
+		bar.Done()
+		return err
+	}
+	err = c.validateFiles(rep, filePaths, opts, force, bar)
+	bar.Done()
+	if closeErr := rep.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// validateFiles validates files and reports their results to rep, and
+// their progress to bar
+func (c *CLI) validateFiles(rep report.Reporter, filePaths []string, opts validateOptions, force bool, bar *progress.Bar) error {
 	validCount := 0
 	invalidCount := 0
 	
+	answers := bufio.NewReader(c.stdin)
 	for _, filePath := range filePaths {
-		err := c.runValidate(filePath, schemaPath)
+		if opts.fix {
+			if err := c.fixDocument(rep, filePath, opts, answers); err != nil {
+				return err
+			}
+		}
+		err := c.runValidate(rep, filePath, opts)
+		bar.Add(1)
 		if err != nil {
 			invalidCount++
 			if !force {
@@ -160,106 +609,124 @@ func (c *CLI) runValidateFiles(filePaths []string, schemaPath string, force bool
 		}
 	}
 	
-	// Summary output
-	if !c.quiet {
-		if len(filePaths) > 1 {
-			fmt.Printf("\nValidation summary: %d valid, %d invalid\n", validCount, invalidCount)
+	rep.Summary(validCount, invalidCount)
+	
+	if opts.updateBaseline {
+		if err := opts.baseline.Save(opts.baselinePath); err != nil {
+			return withExitCode(ExitIO, fmt.Errorf("failed to write baseline: %w", err))
 		}
+		rep.Notice(c.tr.N("validate.baseline.saved", len(opts.baseline.Findings), len(opts.baseline.Findings), opts.baselinePath))
 	}
 	
 	// Return error if any files were invalid
 	if invalidCount > 0 {
-		return fmt.Errorf("%d file(s) failed validation", invalidCount)
+		return withExitCode(ExitValidation, errors.New(c.tr.N("validate.failed", invalidCount, invalidCount)))
 	}
 	
 	return nil
 }
 
-// runValidate runs the validate command for a single file
-func (c *CLI) runValidate(filePath, schemaPath string) error {
-	if c.verbose {
-		fmt.Printf("Validating file: %s\n", filePath)
-		if schemaPath != "" {
-			fmt.Printf("Using schema: %s\n", schemaPath)
-		}
-	}
+// runValidate runs the validate command for a single file, reporting its
+// result to rep
+func (c *CLI) runValidate(rep report.Reporter, filePath string, opts validateOptions) error {
+	schemaPath := opts.schemaPath
+	c.log.Debug("validating document", "file", filePath, "schema", schemaPath)
 	
 	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		if !c.quiet {
-			fmt.Printf("✗ %s: file not found\n", filePath)
-		}
-		return fmt.Errorf("file not found: %s", filePath)
+	if _, err := os.Stat(filePath); filePath != stdio && os.IsNotExist(err) {
+		rep.Failure(filePath, "file not found")
+		return withExitCode(ExitIO, fmt.Errorf("file not found: %s", filePath))
+	}
+	
+	// Read the document, decrypting it if necessary
+	docBytes, err := c.readDocument(filePath, opts.key)
+	if err != nil {
+		rep.Failure(filePath, fmt.Sprintf("failed to read document: %v", err))
+		return fmt.Errorf("failed to read document: %w", err)
 	}
 	
 	var result *validator.ValidationResult
-	var err error
 	
-	if schemaPath != "" {
+	if result = validator.CheckSyntax(docBytes); result != nil {
+		// Syntax errors are reported with their position, before the
+		// schema is determined from the document
+	} else if schemaPath != "" {
 		// Use the specified schema
-		result, err = c.validator.ValidateDocument(filePath, schemaPath)
+		compiled, loadErr := c.validator.LoadSchema(schemaPath)
+		if loadErr != nil {
+			err = fmt.Errorf("failed to load schema: %w", loadErr)
+		} else {
+			result, err = validateSchema(c.ctx, &schema.Schema{Path: schemaPath, Compiled: compiled}, docBytes)
+		}
 	} else {
-		// Determine the schema based on the document type
-		s, err := schema.GetDocumentSchema(filePath)
+		// Determine the schema based on the document type, or with --auto
+		// the type its content suggests
+		var s *schema.Schema
+		var guessed *classify.Suggestion
+		if opts.auto {
+			s, guessed, err = c.classifiedSchema(docBytes)
+		} else {
+			s, err = schema.GetDocumentSchemaFromBytesContext(c.ctx, docBytes)
+		}
 		if err != nil {
-			if !c.quiet {
-				fmt.Printf("✗ %s: failed to determine schema: %v\n", filePath, err)
-			}
+			rep.Failure(filePath, fmt.Sprintf("failed to determine schema: %v", err))
 			return fmt.Errorf("failed to determine schema: %w", err)
 		}
-		
-		// Read the document
-		docBytes, err := os.ReadFile(filePath)
-		if err != nil {
-			if !c.quiet {
-				fmt.Printf("✗ %s: failed to read document: %v\n", filePath, err)
-			}
-			return fmt.Errorf("failed to read document: %w", err)
+		if guessed != nil {
+			rep.Notice(fmt.Sprintf("Validating as %s (%.0f%% confidence): %s", guessed.Type, guessed.Confidence*100, s.Path))
 		}
 		
 		// Validate using the determined schema
-		result, err = s.Validate(docBytes)
+		result, err = validateSchema(c.ctx, s, docBytes)
+		if err != nil {
+			rep.Failure(filePath, fmt.Sprintf("validation error: %v", err))
+			return fmt.Errorf("validation error: %w", err)
+		}
 	}
 	
 	if err != nil {
-		if !c.quiet {
-			fmt.Printf("✗ %s: validation error: %v\n", filePath, err)
-		}
+		rep.Failure(filePath, fmt.Sprintf("validation error: %v", err))
 		return fmt.Errorf("validation error: %w", err)
 	}
 	
-	// Output the result
-	if !c.quiet {
-		if c.outputFormat == "json" {
-			// Output as JSON
-			jsonResult, err := json.MarshalIndent(result, "", "  ")
-			if err != nil {
-				return fmt.Errorf("failed to format result as JSON: %w", err)
-			}
-			fmt.Println(string(jsonResult))
-		} else {
-			// Output as text with colors
-			if result.Valid {
-				fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ %s is valid", filePath)))
-			} else {
-				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s has %d errors:", filePath, len(result.Errors))))
-				for _, err := range result.Errors {
-					lineInfo := ""
-					if err.Line > 0 {
-						lineInfo = fmt.Sprintf("Line %d: ", err.Line)
-					}
-					fmt.Printf("  - %s%s\n", lineInfo, err.Message)
-					if c.verbose && err.Field != "" {
-						fmt.Printf("    at %s\n", err.Field)
-					}
-				}
-			}
+	// Check receipt arithmetic once the document matches its schema
+	if result.Valid {
+		if err := checkReceipt(docBytes, opts.currency, result); err != nil {
+			return err
+		}
+		p, err := checkProfile(docBytes, opts.profile, result)
+		if err != nil {
+			rep.Failure(filePath, err.Error())
+			return err
 		}
+		if p != nil && c.verbose {
+			rep.Notice(fmt.Sprintf("Using profile: %s", p.Name))
+		}
+		checkLocales(docBytes, opts.requireLocales, result)
+	}
+	opts.severities.Apply(result)
+	known := 0
+	if opts.baseline != nil {
+		if opts.updateBaseline {
+			opts.baseline.Add(filePath, result)
+		}
+		known = opts.baseline.Filter(filePath, result)
+	}
+	
+	// Report the result
+	v := report.NewValidation(filePath, result, opts.groupBy, opts.maxErrors)
+	v.Known = known
+	v.Locate = c.locator(filePath, docBytes)
+	if !opts.fix {
+		v.Fixes = len(collectFixes(result.Errors))
+	}
+	if err := rep.Validation(v); err != nil {
+		return err
 	}
 	
 	// Return an error if the document is invalid
 	if !result.Valid {
-		return fmt.Errorf("document validation failed")
+		return withExitCode(ExitValidation, fmt.Errorf("document validation failed"))
 	}
 	
 	return nil
@@ -267,12 +734,10 @@ func (c *CLI) runValidate(filePath, schemaPath string) error {
 
 // runInit runs the init command
 func (c *CLI) runInit(docType, outputPath string, force, interactive bool, title string) error {
-	if c.verbose {
-		fmt.Printf("Initializing new %s document: %s\n", docType, outputPath)
-	}
+	c.log.Info("initializing document", "type", docType, "file", outputPath)
 	
 	// Check if file exists and force flag is not set
-	if _, err := os.Stat(outputPath); err == nil && !force {
+	if exists(outputPath) && !force {
 		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
 	}
 	
@@ -355,9 +820,20 @@ func (c *CLI) runInit(docType, outputPath string, force, interactive bool, title
 				"content": "Transaction details go here.",
 			},
 			{
-				"id":      "items",
-				"title":   "Items",
-				"content": "List of items purchased.",
+				"id":    "items",
+				"title": "Items",
+				"content": []map[string]interface{}{
+					{
+						"type": "table",
+						"columns": []map[string]interface{}{
+							{"title": "Item"},
+							{"title": "Quantity", "type": "number", "key": "quantity"},
+							{"title": "Unit Price", "type": "number", "key": "unitPrice"},
+							{"title": "Amount", "type": "number", "key": "amount", "total": true},
+						},
+						"rows": [][]string{},
+					},
+				},
 			},
 			{
 				"id":      "payment",
@@ -394,11 +870,19 @@ func (c *CLI) runInit(docType, outputPath string, force, interactive bool, title
 	}
 	
 	// Create the document structure
+	content := map[string]interface{}{
+		"sections": sections,
+	}
+	if docType == "receipt" {
+		content["totals"] = map[string]interface{}{
+			"currency": "USD",
+			"subtotal": "0.00",
+			"total":    "0.00",
+		}
+	}
 	doc := map[string]interface{}{
 		"metadata": metadata,
-		"content": map[string]interface{}{
-			"sections": sections,
-		},
+		"content":  content,
 	}
 	
 	// Convert to JSON
@@ -407,16 +891,8 @@ func (c *CLI) runInit(docType, outputPath string, force, interactive bool, title
 		return fmt.Errorf("failed to create document: %w", err)
 	}
 	
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(outputPath)
-	if dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory: %w", err)
-		}
-	}
-	
-	// Write to file
-	if err := os.WriteFile(outputPath, jsonData, 0644); err != nil {
+	// Write to file, creating its directory if it doesn't exist
+	if err := c.writeOutput(outputPath, jsonData, 0644); err != nil {
 		return fmt.Errorf("failed to write document: %w", err)
 	}
 	
@@ -427,7 +903,32 @@ func (c *CLI) runInit(docType, outputPath string, force, interactive bool, title
 }
 
 // showVersion displays version information
-func (c *CLI) showVersion() {
+func (c *CLI) showVersion() error {
+	build := nld.BuildInfo()
+	if c.outputFormat == "json" {
+		jsonData, err := json.MarshalIndent(build, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format version: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
 	fmt.Printf("NLD - Next-Gen Layout Document Tool\n")
-	fmt.Printf("Version: %s\n", Version)
+	fmt.Printf("Version: %s\n", build.Version)
+	if build.Commit != "" {
+		modified := ""
+		if build.Modified {
+			modified = " (modified)"
+		}
+		fmt.Printf("Commit: %s%s\n", build.Commit, modified)
+	}
+	if build.Date != "" {
+		fmt.Printf("Built: %s\n", build.Date)
+	}
+	fmt.Printf("Go: %s\n", build.GoVersion)
+	fmt.Printf("Schemas:\n")
+	for _, s := range build.Schemas {
+		fmt.Printf("  %-20s sha256:%.12s  %s\n", s.File, s.SHA256, s.Title)
+	}
+	return nil
 }
\ No newline at end of file
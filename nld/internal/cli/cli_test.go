@@ -92,6 +92,69 @@ func TestValidateCommand(t *testing.T) {
 	}
 }
 
+func TestValidateGroupBy(t *testing.T) {
+	// With errors in both the metadata and the content, the schema reports
+	// one without a message of its own above those of the content
+	path := writeTestFile(t, "contract.json", `{
+  "metadata": {"type": "contract", "version": "1.0.0", "created": "2025-06-27", "title": "Services Agreement"},
+  "content": {"sections": [{"id": "a"}, {"id": "b"}, {"title": "C"}]}
+}`)
+
+	for _, by := range []string{"field", "keyword"} {
+		t.Run(by, func(t *testing.T) {
+			output, err := run(t, New(), "validate", path, "--group-by", by)
+			if ExitCode(err) != ExitValidation {
+				t.Errorf("Expected a validation failure, got %v", err)
+			}
+			for _, line := range strings.Split(output, "\n") {
+				if strings.TrimSpace(line) == "-" {
+					t.Errorf("Expected no empty bullet, got:\n%s", output)
+				}
+			}
+			if !strings.Contains(output, "has 8 errors") {
+				t.Errorf("Expected the errors with a message to be counted, got:\n%s", output)
+			}
+			if !strings.Contains(output, "(3 errors)\n    at /content/sections/[0, 1, 2]") {
+				t.Errorf("Expected the errors of the sections to be grouped, got:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestValidateGithubSyntaxError(t *testing.T) {
+	path := writeTestFile(t, "contract.json", "{\n  \"metadata\": {\n    \"type\": \"contract\",\n  }\n}\n")
+
+	output, err := run(t, New(), "validate", path, "--output-format", "github")
+	if ExitCode(err) != ExitValidation {
+		t.Errorf("Expected a validation failure, got %v", err)
+	}
+	want := "::error file=" + path + ",line=4,col=3,title=nld validate::Invalid JSON: "
+	if !strings.HasPrefix(output, want) {
+		t.Errorf("Expected an annotation at the syntax error, got:\n%s", output)
+	}
+}
+
+func TestValidateStreamFormats(t *testing.T) {
+	path := writeTestFile(t, "export.ndjson", strings.ReplaceAll(testDocument, "\n", "")+"\n")
+
+	for _, format := range []string{"github", "sarif"} {
+		t.Run(format, func(t *testing.T) {
+			output, err := run(t, New(), "validate", "--stream", path, "--output-format", format)
+			if ExitCode(err) != ExitUsage || !strings.Contains(err.Error(), "cannot be used with --stream") {
+				t.Errorf("Expected a usage error, got %v", err)
+			}
+			if output != "" {
+				t.Errorf("Expected no output, got:\n%s", output)
+			}
+		})
+	}
+
+	output, err := run(t, New(), "validate", "--stream", path, "--output-format", "json")
+	if err != nil || !strings.Contains(output, `"record":1`) {
+		t.Errorf("Expected one JSON result, got %v:\n%s", err, output)
+	}
+}
+
 func TestInitCommand(t *testing.T) {
 	// Skip this test in automated testing environments
 	t.Skip("Skipping CLI tests that require file system access")
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/diff"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/spf13/cobra"
+)
+
+// addCompareCommand adds the compare command
+func (c *CLI) addCompareCommand() {
+	var strict bool
+	var keyfile string
+	var passphraseFile string
+
+	compareCmd := &cobra.Command{
+		Use:   "compare [template] [file]",
+		Short: "Compare a negotiated document against its template",
+		Long: `Compare a document against the template it was made from and report
+every clause as unchanged, modified, removed or added. Clauses are the
+sections and subsections, matched by ID.
+
+Placeholders of the template, such as {{ buyer.name }}, match any text,
+so a document filled from the template with nld fill or nld generate only
+deviates where its language was negotiated. Modified clauses list the
+fields that differ, such as content or title.
+
+With --strict, the command fails when any clause deviates.`,
+		Example: `  nld compare templates/msa.json acme-msa.json
+  nld compare --strict --output-format json templates/msa.json acme-msa.json`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			return c.runCompare(args[0], args[1], strict, key)
+		},
+	}
+
+	compareCmd.Flags().BoolVar(&strict, "strict", false, "Fail when any clause deviates from the template")
+	compareCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	compareCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(compareCmd)
+}
+
+// runCompare runs the compare command
+func (c *CLI) runCompare(templatePath, filePath string, strict bool, key *envelope.Key) error {
+	template, err := c.loadDocument(templatePath, key)
+	if err != nil {
+		return fmt.Errorf("failed to load template: %w", err)
+	}
+	doc, err := c.loadDocument(filePath, key)
+	if err != nil {
+		return err
+	}
+
+	clauses := diff.Template(template, doc)
+	counts := map[diff.Kind]int{}
+	for _, clause := range clauses {
+		counts[clause.Status]++
+	}
+	deviations := len(clauses) - counts[diff.Unchanged]
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(clauses, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+	} else {
+		for _, clause := range clauses {
+			if clause.Status == diff.Unchanged {
+				if !c.quiet {
+					fmt.Printf("  %-24s %s\n", clause.ID, clause.Title)
+				}
+				continue
+			}
+			line := fmt.Sprintf("%s %-24s %s", changeMarker(clause.Status), clause.ID, clause.Title)
+			if len(clause.Detail) > 0 {
+				line += fmt.Sprintf(" (%s)", strings.Join(clause.Detail, ", "))
+			}
+			fmt.Println(line)
+		}
+		if !c.quiet {
+			fmt.Printf("\n%d unchanged, %d modified, %d removed, %d added\n",
+				counts[diff.Unchanged], counts[diff.Modified], counts[diff.Removed], counts[diff.Added])
+		}
+	}
+
+	if strict && deviations > 0 {
+		return withExitCode(ExitValidation, fmt.Errorf("%d clause(s) deviate from %s", deviations, templatePath))
+	}
+	return nil
+}
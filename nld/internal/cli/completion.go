@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/anchor"
+	"github.com/colemalphrus/nld/internal/attest"
+	"github.com/colemalphrus/nld/internal/colors"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/esign"
+	"github.com/colemalphrus/nld/internal/graph"
+	"github.com/colemalphrus/nld/internal/hooks"
+	"github.com/colemalphrus/nld/internal/i18n"
+	"github.com/colemalphrus/nld/internal/importer"
+	"github.com/colemalphrus/nld/internal/lint"
+	"github.com/colemalphrus/nld/internal/obligation"
+	"github.com/colemalphrus/nld/internal/pii"
+	"github.com/colemalphrus/nld/internal/profile"
+	"github.com/colemalphrus/nld/internal/render"
+	"github.com/colemalphrus/nld/internal/report"
+	"github.com/colemalphrus/nld/internal/signing"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// completionShells are the shells completion scripts can be generated for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// addCompletionCommand adds the completion command
+func (c *CLI) addCompletionCommand() {
+	var noDescriptions bool
+
+	completionCmd := &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate a shell completion script",
+		Long: `Generate a script that completes nld commands, flags and values in your
+shell. Besides commands and flags, it completes document types, built-in
+templates, schema files, validation profiles and lint rule IDs.
+
+Bash (requires the bash-completion package):
+
+  source <(nld completion bash)                       # current shell
+  nld completion bash > /etc/bash_completion.d/nld    # every shell
+
+Zsh:
+
+  nld completion zsh > "${fpath[1]}/_nld"
+
+Fish:
+
+  nld completion fish > ~/.config/fish/completions/nld.fish
+
+PowerShell:
+
+  nld completion powershell | Out-String | Invoke-Expression
+
+Start a new shell after installing a script for it to take effect.`,
+		ValidArgs:             completionShells,
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				return c.rootCmd.GenBashCompletionV2(out, !noDescriptions)
+			case "zsh":
+				if noDescriptions {
+					return c.rootCmd.GenZshCompletionNoDesc(out)
+				}
+				return c.rootCmd.GenZshCompletion(out)
+			case "fish":
+				return c.rootCmd.GenFishCompletion(out, !noDescriptions)
+			default:
+				if noDescriptions {
+					return c.rootCmd.GenPowerShellCompletion(out)
+				}
+				return c.rootCmd.GenPowerShellCompletionWithDesc(out)
+			}
+		},
+	}
+
+	completionCmd.Flags().BoolVar(&noDescriptions, "no-descriptions", false, "Complete values without their descriptions")
+
+	c.rootCmd.AddCommand(completionCmd)
+}
+
+// registerCompletions adds dynamic completion of flag values to every
+// command. Completions keyed by "command flag" apply to that command only;
+// those keyed by a flag name apply to the flag wherever it is defined.
+func (c *CLI) registerCompletions() {
+	byCommand := map[string]cobra.CompletionFunc{
+		"init type":          completeDocumentTypes,
+		"import type":        completeDocumentTypes,
+		"search type":        completeDocumentTypes,
+		"import format":      completeValues(importer.Formats()),
+		"lint disable":       completeLintRules,
+		"lint only":          completeLintRules,
+		"lint pii-kinds":     completeValues(pii.Kinds()),
+		"lint pii-types":     completeDocumentTypes,
+		"render format":      completeValues(render.Formats()),
+		"graph format":       completeValues(graph.Formats()),
+		"install checks":     completeValues(hooks.Checks()),
+		"export format":      completeValues(exportFormats),
+		"generate template":  completeExtensions("json"),
+		"generate data":      completeExtensions("csv", "tsv", "json", "yaml", "yml"),
+		"obligations format": completeValues(obligation.Formats()),
+		"send provider":      completeValues(esign.Providers()),
+		"sign key":           completeExtensions("pem", "key"),
+		"sign cert":          completeExtensions("pem", "crt"),
+		"sign keyring-key":   completeKeyringKeys,
+		"sign assemble":      completeExtensions("sig"),
+		"attest role":        completeValues(attest.Roles()),
+		"attest key":         completeExtensions("pem", "key"),
+		"attest cert":        completeExtensions("pem", "crt"),
+		"attest keyring-key": completeKeyringKeys,
+		"anchor keyring-key": completeKeyringKeys,
+		"anchor key":         completeExtensions("pem", "key"),
+		"anchor log":         completeValues(anchor.Providers()),
+		"generate algorithm": completeValues([]string{signing.ES256, signing.ES384, signing.RS256, signing.EdDSA}),
+	}
+	byFlag := map[string]cobra.CompletionFunc{
+		"schema":         completeSchemas,
+		"profile":        completeProfiles,
+		"numbering":      completeValues(nld.NumberingStyles()),
+		"playbook":       completeExtensions("yaml", "yml", "json"),
+		"risk-weights":   completeExtensions("yaml", "yml", "json"),
+		"signing-config": completeExtensions("yaml", "yml", "json"),
+		"trust-policy":   completeExtensions("yaml", "yml", "json"),
+	}
+
+	c.rootCmd.RegisterFlagCompletionFunc("output-format", completeValues(report.Formats()))
+	c.rootCmd.RegisterFlagCompletionFunc("lang", completeValues(i18n.Languages()))
+	c.rootCmd.RegisterFlagCompletionFunc("color", completeValues(colors.Modes()))
+
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for key, complete := range byCommand {
+			if name, flag, _ := strings.Cut(key, " "); name == cmd.Name() && cmd.Flags().Lookup(flag) != nil {
+				cmd.RegisterFlagCompletionFunc(flag, complete)
+			}
+		}
+		for flag, complete := range byFlag {
+			if cmd.Flags().Lookup(flag) != nil {
+				cmd.RegisterFlagCompletionFunc(flag, complete)
+			}
+		}
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	for _, cmd := range c.rootCmd.Commands() {
+		walk(cmd)
+	}
+}
+
+// completeValues completes a flag from a fixed list of values
+func completeValues(values []string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeExtensions completes file names with the given extensions
+func completeExtensions(extensions ...string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return extensions, cobra.ShellCompDirectiveFilterFileExt
+	}
+}
+
+// completeDocumentTypes completes the document types, noting those that
+// init has a template for
+func completeDocumentTypes(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	var completions []cobra.Completion
+	for _, t := range validator.DocumentTypes() {
+		if initTemplates[t] {
+			completions = append(completions, cobra.CompletionWithDesc(t, "built-in template"))
+		} else {
+			completions = append(completions, t)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfiles completes the names of the validation profiles
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	completions := []cobra.Completion{cobra.CompletionWithDesc("none", "disable profile checks")}
+	profiles, err := profile.All()
+	if err != nil {
+		return completions, cobra.ShellCompDirectiveDefault
+	}
+	for _, p := range profiles {
+		completions = append(completions, cobra.CompletionWithDesc(p.Name, p.Description))
+	}
+	// Profiles may also be read from files
+	return completions, cobra.ShellCompDirectiveDefault
+}
+
+// completeKeyringKeys completes the names of the keys of the keyring of
+// --keyring, or the default one
+func completeKeyringKeys(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	dir, _ := cmd.Flags().GetString("keyring")
+	r, err := signing.OpenKeyring(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	entries, err := r.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []cobra.Completion
+	for _, e := range entries {
+		if e.Revoked == nil {
+			completions = append(completions, cobra.CompletionWithDesc(e.Name, e.Algorithm))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeLintRules completes the comma-separated rule IDs of --disable
+// and --only
+func completeLintRules(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	prefix := ""
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		prefix = toComplete[:i+1]
+	}
+	var completions []cobra.Completion
+	for _, r := range lint.Rules() {
+		completions = append(completions, cobra.CompletionWithDesc(prefix+r.ID, r.Name))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeSchemas completes the schema files in ./schemas and in the
+// schemas directory next to the executable, where documents find their
+// schema by type. Other files can still be completed when there are none.
+func completeSchemas(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	dirs := []string{"schemas"}
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Join(filepath.Dir(exe), "schemas"))
+	}
+	seen := map[string]bool{}
+	var completions []cobra.Completion
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		for _, m := range matches {
+			abs, err := filepath.Abs(m)
+			if err != nil || seen[abs] {
+				continue
+			}
+			seen[abs] = true
+			completions = append(completions, m)
+		}
+	}
+	if len(completions) == 0 {
+		return []cobra.Completion{"json"}, cobra.ShellCompDirectiveFilterFileExt
+	}
+	sort.Strings(completions)
+	return completions, cobra.ShellCompDirectiveDefault
+}
+
+// completeSectionIDs completes the IDs of the sections of the document
+// given as the first argument
+func completeSectionIDs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	doc, err := document.Parse(data)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []cobra.Completion
+	for _, ref := range doc.AllSections() {
+		completions = append(completions, cobra.CompletionWithDesc(document.String(ref.Section, "id"), document.String(ref.Section, "title")))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
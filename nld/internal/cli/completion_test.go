@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/lint"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// flagCompletion returns the completion function registered for a flag of
+// the command at path
+func flagCompletion(t *testing.T, c *CLI, path, flag string) cobra.CompletionFunc {
+	t.Helper()
+	cmd, _, err := c.rootCmd.Find(strings.Fields(path))
+	if err != nil {
+		t.Fatalf("No command %s: %v", path, err)
+	}
+	complete, ok := cmd.GetFlagCompletionFunc(flag)
+	if !ok {
+		t.Fatalf("No completion for %s --%s", path, flag)
+	}
+	return complete
+}
+
+func TestCompleteDocumentTypes(t *testing.T) {
+	c := New()
+	for _, path := range []string{"init", "import", "search", "lint"} {
+		flag := "type"
+		if path == "lint" {
+			flag = "pii-types"
+		}
+		completions, directive := flagCompletion(t, c, path, flag)(c.rootCmd, nil, "")
+		if len(completions) != len(validator.DocumentTypes()) {
+			t.Errorf("%s --%s: expected every document type, got %v", path, flag, completions)
+		}
+		if !slices.Contains(completions, "contract\tbuilt-in template") {
+			t.Errorf("%s --%s: expected contract to be noted as a built-in template, got %v", path, flag, completions)
+		}
+		if directive != cobra.ShellCompDirectiveNoFileComp {
+			t.Errorf("%s --%s: expected no file completion, got directive %d", path, flag, directive)
+		}
+	}
+}
+
+func TestCompleteLintRules(t *testing.T) {
+	c := New()
+	rules := lint.Rules()
+	for _, flag := range []string{"disable", "only"} {
+		complete := flagCompletion(t, c, "lint", flag)
+
+		completions, directive := complete(c.rootCmd, nil, "")
+		if len(completions) != len(rules) || completions[0] != rules[0].ID+"\t"+rules[0].Name {
+			t.Errorf("--%s: expected every rule with its name, got %v", flag, completions)
+		}
+		if directive != cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace {
+			t.Errorf("--%s: expected no file completion and no space, got directive %d", flag, directive)
+		}
+
+		// Rule IDs are completed after those already given
+		completions, _ = complete(c.rootCmd, nil, rules[0].ID+",")
+		for _, completion := range completions {
+			if !strings.HasPrefix(completion, rules[0].ID+",") {
+				t.Errorf("--%s: expected %s to keep the rules given", flag, completion)
+			}
+		}
+	}
+
+	// The shell asks for completions through the __complete command
+	printed, err := run(t, New(), cobra.ShellCompRequestCmd, "lint", "--only", "")
+	if err != nil {
+		t.Fatalf("%s failed: %v", cobra.ShellCompRequestCmd, err)
+	}
+	if !strings.Contains(printed, rules[0].ID+"\t") {
+		t.Errorf("Expected the rule IDs to be completed, got %s", printed)
+	}
+}
+
+func TestCompleteStatuses(t *testing.T) {
+	c := New()
+	cmd, _, err := c.rootCmd.Find([]string{"status", "set"})
+	if err != nil {
+		t.Fatalf("No command status set: %v", err)
+	}
+	completions, directive := cmd.ValidArgsFunction(cmd, []string{"contract.json"}, "")
+	if !slices.Contains(completions, "executed") || directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("Expected the statuses after the file, got %v with directive %d", completions, directive)
+	}
+	if completions, _ := cmd.ValidArgsFunction(cmd, nil, ""); completions != nil {
+		t.Errorf("Expected files to be completed first, got %v", completions)
+	}
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/index"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// addDedupeCommand adds the dedupe command and its subcommands
+func (c *CLI) addDedupeCommand() {
+	dedupeCmd := &cobra.Command{
+		Use:   "dedupe",
+		Short: "Find duplicated clauses in the document store",
+	}
+
+	var dir string
+	var minWords int
+	var reindex bool
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Report identical and divergent clauses across stored documents",
+		Long: `Compare the sections of the latest version of every document in the document
+store and report:
+
+  identical  sections with the same content in more than one document,
+             whatever their titles
+  divergent  section titles used in more than one document with different
+             content, such as a governing law clause that names another
+             state in one contract
+
+Content is compared in a canonical form, ignoring case, punctuation and
+white space. Sections shorter than --min-words words are left out.`,
+		Example: `  nld dedupe report
+  nld dedupe report --min-words 25 --output-format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runDedupeReport(dir, minWords, reindex)
+		},
+	}
+	reportCmd.Flags().StringVar(&dir, "store-dir", "", "Directory of the store (default: $"+store.DirEnv+" or ~/.nld/store)")
+	reportCmd.Flags().IntVar(&minWords, "min-words", index.DefaultMinWords, "Leave out sections shorter than this many words")
+	reportCmd.Flags().BoolVar(&reindex, "reindex", false, "Rebuild the index from scratch first")
+
+	dedupeCmd.AddCommand(reportCmd)
+	c.rootCmd.AddCommand(dedupeCmd)
+}
+
+// runDedupeReport reports the duplicated clauses of the local store in dir
+func (c *CLI) runDedupeReport(dir string, minWords int, reindex bool) error {
+	x, err := c.openIndex(dir, reindex)
+	if err != nil {
+		return err
+	}
+	defer x.Close()
+	report, err := x.Dedupe(context.Background(), minWords)
+	if err != nil {
+		return withExitCode(ExitIO, err)
+	}
+
+	if c.outputFormat == "json" {
+		return printJSON(report)
+	}
+	if len(report.Identical) == 0 && len(report.Divergent) == 0 {
+		if !c.quiet {
+			fmt.Println("No duplicated clauses found")
+		}
+		return nil
+	}
+	if len(report.Identical) > 0 {
+		fmt.Printf("Identical clauses (%d):\n", len(report.Identical))
+		for _, g := range report.Identical {
+			fmt.Printf("  %d copies, %d words: %s\n", len(g.Clauses), g.Words, formatClauses(g.Clauses, true))
+		}
+	}
+	if len(report.Divergent) > 0 {
+		if len(report.Identical) > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Divergent clauses (%d):\n", len(report.Divergent))
+		for _, d := range report.Divergent {
+			fmt.Printf("  %s: %d variants\n", d.Title, len(d.Variants))
+			for i, v := range d.Variants {
+				fmt.Printf("    %d. %d words: %s\n", i+1, v.Words, formatClauses(v.Clauses, false))
+			}
+		}
+	}
+	return nil
+}
+
+// formatClauses lists clauses as id vN#section, with their titles when
+// asked for
+func formatClauses(clauses []index.Clause, titles bool) string {
+	var list []string
+	for _, cl := range clauses {
+		s := fmt.Sprintf("%s v%d#%s", cl.Document, cl.Version, cl.Section)
+		if titles && cl.Title != "" {
+			s += fmt.Sprintf(" (%s)", cl.Title)
+		}
+		list = append(list, s)
+	}
+	return strings.Join(list, ", ")
+}
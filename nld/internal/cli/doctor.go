@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/colemalphrus/nld/internal/colors"
+	"github.com/colemalphrus/nld/internal/doctor"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/include"
+	"github.com/colemalphrus/nld/internal/profile"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addDoctorCommand adds the doctor command
+func (c *CLI) addDoctorCommand() {
+	var opts doctor.Options
+
+	doctorCmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the environment of nld for problems",
+		Long: `Check the environment nld runs in and suggest a fix for every problem found:
+
+  schemas   the schema of every document type is found and compiles, or
+            is built in
+  config    the store config file (` + "$" + store.ConfigEnv + ` or ~/.nld/store.yaml)
+            and the ` + "$" + `NLD_LANG and ` + "$" + colors.ThemeEnv + ` settings are valid
+  library   clause libraries of --library and ` + "$" + include.LibraryPathEnv + ` exist
+            and hold valid JSON
+  profiles  the profiles of ` + "$" + profile.PathEnv + ` load
+  index     the search index of the local store is intact
+  keys      the key material of --keyfile, --passphrase-file or
+            ` + "$" + envelope.PassphraseEnv + ` is readable
+  registry  clause registries answer, unless --offline is given
+
+Warnings do not fail the command; errors exit with code 1.`,
+		Example: `  nld doctor
+  nld doctor --library https://clauses.example.com --keyfile team.key
+  nld doctor --offline --output-format json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runDoctor(opts)
+		},
+	}
+
+	doctorCmd.Flags().StringArrayVarP(&opts.Libraries, "library", "l", nil, "Clause library directory or registry URL to check (repeatable)")
+	doctorCmd.Flags().StringVar(&opts.Keyfile, "keyfile", "", "Keyfile to check")
+	doctorCmd.Flags().StringVar(&opts.PassphraseFile, "passphrase-file", "", "Passphrase file to check")
+	doctorCmd.Flags().BoolVar(&opts.Offline, "offline", false, "Skip the checks that need the network")
+
+	c.rootCmd.AddCommand(doctorCmd)
+}
+
+// runDoctor runs the doctor command
+func (c *CLI) runDoctor(opts doctor.Options) error {
+	results := doctor.Run(c.ctx, opts)
+
+	if c.outputFormat == "json" {
+		jsonData, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result: %w", err)
+		}
+		fmt.Println(string(jsonData))
+	} else {
+		for _, r := range results {
+			switch r.Status {
+			case doctor.OK:
+				if !c.quiet {
+					fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ %s: %s", r.Check, r.Message)))
+				}
+			case doctor.Warning:
+				fmt.Println(colors.Warning(fmt.Sprintf("! %s: %s", r.Check, r.Message)))
+			default:
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.Check, r.Message)))
+			}
+			if r.Fix != "" {
+				fmt.Printf("  %s\n", c.tr.T("validate.fix", r.Fix))
+			}
+		}
+	}
+
+	if n := doctor.Problems(results); n > 0 {
+		return withExitCode(ExitUsage, errors.New(c.tr.N("doctor.failed", n, n)))
+	}
+	return nil
+}
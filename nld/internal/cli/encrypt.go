@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addEncryptCommand adds the encrypt command
+func (c *CLI) addEncryptCommand() {
+	var outputPath string
+	var keyfile string
+	var passphraseFile string
+	var force bool
+
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt [file]",
+		Short: "Encrypt an NLD document",
+		Long: `Encrypt an NLD document into an AES-256-GCM envelope.
+
+The key is taken from --keyfile, --passphrase-file, or the NLD_PASSPHRASE
+environment variable. Encrypted documents can be validated directly by
+passing the same key to the validate command.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			if key == nil {
+				return fmt.Errorf("no key provided; use --keyfile, --passphrase-file or set %s", envelope.PassphraseEnv)
+			}
+			if outputPath == "" && args[0] == stdio {
+				outputPath = stdio
+			} else if outputPath == "" {
+				outputPath = args[0] + ".enc"
+			}
+			return c.runEncrypt(args[0], outputPath, key, force)
+		},
+	}
+
+	encryptCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: <file>.enc)")
+	encryptCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to encrypt the document")
+	encryptCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to encrypt the document")
+	encryptCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+
+	c.rootCmd.AddCommand(encryptCmd)
+}
+
+// addDecryptCommand adds the decrypt command
+func (c *CLI) addDecryptCommand() {
+	var outputPath string
+	var keyfile string
+	var passphraseFile string
+	var force bool
+
+	decryptCmd := &cobra.Command{
+		Use:   "decrypt [file]",
+		Short: "Decrypt an encrypted NLD document",
+		Long:  "Decrypt an NLD document previously encrypted with the encrypt command",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			if outputPath == "" && args[0] == stdio {
+				outputPath = stdio
+			} else if outputPath == "" {
+				outputPath = strings.TrimSuffix(args[0], ".enc")
+				if outputPath == args[0] {
+					outputPath = args[0] + ".json"
+				}
+			}
+			return c.runDecrypt(args[0], outputPath, key, force)
+		},
+	}
+
+	decryptCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: <file> without .enc)")
+	decryptCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt the document")
+	decryptCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt the document")
+	decryptCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+
+	c.rootCmd.AddCommand(decryptCmd)
+}
+
+// runEncrypt runs the encrypt command
+func (c *CLI) runEncrypt(inputPath, outputPath string, key *envelope.Key, force bool) error {
+	c.log.Info("encrypting document", "file", inputPath, "output", outputPath)
+
+	if exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+
+	data, err := c.readInput(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read document: %w", err)
+	}
+	if envelope.IsEncrypted(data) {
+		return fmt.Errorf("document is already encrypted: %s", inputPath)
+	}
+
+	sealed, err := envelope.Encrypt(data, key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt document: %w", err)
+	}
+
+	if err := c.writeOutput(outputPath, sealed, 0600); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Encrypted %s to %s", inputPath, outputPath)))
+	}
+	return nil
+}
+
+// runDecrypt runs the decrypt command
+func (c *CLI) runDecrypt(inputPath, outputPath string, key *envelope.Key, force bool) error {
+	c.log.Info("decrypting document", "file", inputPath, "output", outputPath)
+
+	if exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+
+	data, err := c.readInput(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to read document: %w", err)
+	}
+	if !envelope.IsEncrypted(data) {
+		return fmt.Errorf("document is not encrypted: %s", inputPath)
+	}
+
+	plaintext, err := envelope.Decrypt(data, key)
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeOutput(outputPath, plaintext, 0600); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Decrypted %s to %s", inputPath, outputPath)))
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/entity"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addEntityCommand adds the entity command and its subcommands
+func (c *CLI) addEntityCommand() {
+	entityCmd := &cobra.Command{
+		Use:   "entity",
+		Short: "Manage the entities of an NLD document",
+		Long:  "Add, list and remove the entities (parties) declared in a document's metadata",
+	}
+
+	var e entity.Entity
+	var anyRole bool
+	addCmd := &cobra.Command{
+		Use:   "add [file]",
+		Short: "Add an entity to a document",
+		Long: `Add an entity to a document's metadata.entities.
+
+The role is checked against the roles allowed for the document type; use
+--any-role to accept a role outside that list.
+
+--key-id registers the ID of a signing key of the entity, as nld keys generate
+and nld sign print it. The signatures of an entity with registered keys
+must be made with one of them, which nld sign and nld validate check.`,
+		Example: `  nld entity add contract.json --id client --name "XYZ Ltd" --role Client --email legal@xyz.example
+  nld entity add contract.json --id provider --name "ABC Corp" --role "Service Provider" --key-id sha256:d8817ae6...`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runEntityAdd(args[0], e, anyRole)
+		},
+	}
+	addCmd.Flags().StringVar(&e.ID, "id", "", "Entity identifier")
+	addCmd.Flags().StringVar(&e.Name, "name", "", "Entity name")
+	addCmd.Flags().StringVar(&e.Role, "role", "", "Entity role in the document")
+	addCmd.Flags().StringVar(&e.Email, "email", "", "Contact email address")
+	addCmd.Flags().StringVar(&e.Phone, "phone", "", "Contact phone number")
+	addCmd.Flags().StringVar(&e.Address, "address", "", "Postal address")
+	addCmd.Flags().StringArrayVar(&e.KeyIDs, "key-id", nil, "ID of a signing key registered for the entity (repeatable)")
+	addCmd.Flags().BoolVar(&anyRole, "any-role", false, "Accept roles not defined for the document type")
+	addCmd.MarkFlagRequired("id")
+	addCmd.MarkFlagRequired("name")
+	addCmd.MarkFlagRequired("role")
+
+	listCmd := &cobra.Command{
+		Use:   "list [file]",
+		Short: "List the entities of a document",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runEntityList(args[0])
+		},
+	}
+
+	var force bool
+	removeCmd := &cobra.Command{
+		Use:   "remove [file] [id]",
+		Short: "Remove an entity from a document",
+		Long:  "Remove an entity from a document. Entities referenced by signatures or attestations are kept unless --force is given.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runEntityRemove(args[0], args[1], force)
+		},
+	}
+	removeCmd.Flags().BoolVar(&force, "force", false, "Remove the entity even if it is still referenced")
+
+	entityCmd.AddCommand(addCmd, listCmd, removeCmd)
+	c.rootCmd.AddCommand(entityCmd)
+}
+
+// runEntityAdd runs the entity add command
+func (c *CLI) runEntityAdd(filePath string, e entity.Entity, anyRole bool) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := entity.Add(doc, e, anyRole); err != nil {
+		return err
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Added entity %s to %s", e.ID, filePath)))
+	}
+	return nil
+}
+
+// runEntityList runs the entity list command
+func (c *CLI) runEntityList(filePath string) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+	entities := entity.List(doc)
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(entities, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+		return nil
+	}
+
+	if len(entities) == 0 {
+		if !c.quiet {
+			fmt.Printf("%s declares no entities\n", filePath)
+		}
+		return nil
+	}
+
+	for _, e := range entities {
+		fmt.Printf("%s\t%s\t%s\n", e.ID, e.Name, e.Role)
+		if c.verbose {
+			var contact []string
+			for _, v := range []string{e.Email, e.Phone, e.Address} {
+				if v != "" {
+					contact = append(contact, v)
+				}
+			}
+			if len(contact) > 0 {
+				fmt.Printf("  %s\n", strings.Join(contact, ", "))
+			}
+			for _, id := range e.KeyIDs {
+				fmt.Printf("  key %s\n", id)
+			}
+		}
+	}
+	return nil
+}
+
+// runEntityRemove runs the entity remove command
+func (c *CLI) runEntityRemove(filePath, id string, force bool) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := entity.Remove(doc, id, force); err != nil {
+		return err
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Removed entity %s from %s", id, filePath)))
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/esign"
+	"github.com/colemalphrus/nld/internal/render"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addEsignCommand adds the esign command and its subcommands
+func (c *CLI) addEsignCommand() {
+	esignCmd := &cobra.Command{
+		Use:   "esign",
+		Short: "Send documents for signature with an e-signature provider",
+		Long: `Send a document for signature with an e-signature provider and bring the
+signatures back into the document once they are collected.
+
+The providers are configured with environment variables:
+
+  docusign      ` + esign.DocuSignTokenEnv + ` and ` + esign.DocuSignAccountEnv + `, with
+                ` + esign.DocuSignURLEnv + ` for accounts outside the developer
+                environment (default: ` + esign.DocuSignDefaultURL + `)
+  dropbox-sign  ` + esign.DropboxSignKeyEnv + `, with ` + esign.DropboxSignTestEnv + `=1 to send
+                test requests
+
+The envelope sent is recorded in verification.esign of the document.`,
+	}
+
+	var provider string
+	var signers []string
+	var message string
+	var force bool
+	sendCmd := &cobra.Command{
+		Use:   "send [file]",
+		Short: "Render a document and send it to its signers",
+		Long: `Render a document as HTML and send it with the provider to the entities that
+sign it, one after the other: those given with --signer, or else every
+entity with an email address.`,
+		Example: `  nld esign send contract.json --provider docusign
+  nld esign send contract.json --provider dropbox-sign --signer acme --signer client`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runEsignSend(args[0], provider, signers, message, force)
+		},
+	}
+	sendCmd.Flags().StringVar(&provider, "provider", "", "E-signature provider ("+strings.Join(esign.Providers(), ", ")+")")
+	sendCmd.Flags().StringArrayVar(&signers, "signer", nil, "Entity ID of a signer, in signing order (default: every entity with an email address)")
+	sendCmd.Flags().StringVar(&message, "message", "", "Message sent to the signers with the document")
+	sendCmd.Flags().BoolVar(&force, "force", false, "Send again when an envelope is still out for signature")
+	sendCmd.MarkFlagRequired("provider")
+
+	syncCmd := &cobra.Command{
+		Use:   "sync [file...]",
+		Short: "Bring collected signatures back into documents",
+		Long: `Ask the provider for the status of the envelope of each document, and add a
+signature to verification.signatures for every signer who signed since the
+last sync. Documents whose envelope is completed, declined or voided are
+not asked about again.
+
+The command fails when an envelope was declined or voided.`,
+		Example: `  nld esign sync contract.json
+  nld esign sync contracts/`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := expandPaths(args)
+			if err != nil {
+				return err
+			}
+			return c.runEsignSync(files)
+		},
+	}
+
+	esignCmd.AddCommand(sendCmd, syncCmd)
+	c.rootCmd.AddCommand(esignCmd)
+}
+
+// runEsignSend renders a document, sends it with a provider and records
+// the envelope in the document
+func (c *CLI) runEsignSend(filePath, providerName string, ids []string, message string, force bool) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+	rec, err := esign.Load(doc)
+	if err != nil {
+		return err
+	}
+	if rec != nil && !rec.Done() && !force {
+		return fmt.Errorf("%s is already out for signature with %s (envelope %s); run 'nld esign sync' or use --force to send it again", filePath, rec.Provider, rec.Envelope)
+	}
+	signers, err := esign.Signers(doc, ids)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	provider, err := esign.New(providerName)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	data, err := doc.Marshal()
+	if err != nil {
+		return err
+	}
+	parsed, err := nld.Parse(data)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := render.RenderContext(c.ctx, &buf, parsed, render.Options{Format: render.HTML, Lang: c.tr}); err != nil {
+		return err
+	}
+	title := parsed.Metadata.Title
+	if title == "" {
+		title = filepath.Base(filePath)
+	}
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(filePath), ".json"), ".nld")
+
+	id, err := provider.Send(c.ctx, &esign.Request{
+		Title:    title,
+		Message:  message,
+		FileName: name + ".html",
+		Content:  buf.Bytes(),
+		Signers:  signers,
+	})
+	if err != nil {
+		return err
+	}
+	rec = &esign.Record{Provider: provider.Name(), Envelope: id, Status: esign.Sent, Sent: time.Now().UTC(), Signers: signers}
+	if err := esign.Store(doc, rec); err != nil {
+		return err
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return fmt.Errorf("sent envelope %s but failed to record it: %w", id, err)
+	}
+
+	if c.outputFormat == "json" {
+		return printJSON(rec)
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Sent %s to %d signer(s) with %s (envelope %s)", filePath, len(signers), provider.Name(), id)))
+	}
+	return nil
+}
+
+// esignSyncResult is the outcome of syncing one document
+type esignSyncResult struct {
+	File   string        `json:"file"`
+	Record *esign.Record `json:"record,omitempty"`
+	// Added is the number of signatures added
+	Added int    `json:"added"`
+	Error string `json:"error,omitempty"`
+}
+
+// runEsignSync brings the signatures of the envelopes of files back into
+// them
+func (c *CLI) runEsignSync(files []string) error {
+	providers := map[string]esign.Provider{}
+	var results []esignSyncResult
+	failed := 0
+	for _, path := range files {
+		result := esignSyncResult{File: path}
+		added, rec, err := c.syncEsign(path, providers)
+		result.Added, result.Record = added, rec
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if err != nil || (rec != nil && (rec.Status == esign.Declined || rec.Status == esign.Voided)) {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if c.outputFormat == "json" {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else if !c.quiet {
+		for _, r := range results {
+			switch {
+			case r.Error != "":
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.File, r.Error)))
+			case r.Record.Status == esign.Declined || r.Record.Status == esign.Voided:
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: envelope %s was %s", r.File, r.Record.Envelope, r.Record.Status)))
+			default:
+				signed := 0
+				for _, s := range r.Record.Signers {
+					if s.Status == esign.Signed {
+						signed++
+					}
+				}
+				fmt.Println(validator.ColoredOutput(r.Record.Status == esign.Completed, fmt.Sprintf("%s: %s, %d of %d signed, %d signature(s) added", r.File, r.Record.Status, signed, len(r.Record.Signers), r.Added)))
+			}
+		}
+	}
+
+	if failed > 0 {
+		return withExitCode(ExitSignature, fmt.Errorf("%d document(s) could not be synced or were not signed", failed))
+	}
+	return nil
+}
+
+// syncEsign syncs the envelope of one document, reusing the providers
+// already created
+func (c *CLI) syncEsign(path string, providers map[string]esign.Provider) (int, *esign.Record, error) {
+	doc, err := c.loadDocument(path, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	rec, err := esign.Load(doc)
+	if err != nil {
+		return 0, nil, err
+	}
+	if rec == nil {
+		return 0, nil, fmt.Errorf("not sent for signature; run 'nld esign send %s' first", path)
+	}
+	if rec.Done() {
+		return 0, rec, nil
+	}
+	provider, ok := providers[rec.Provider]
+	if !ok {
+		if provider, err = esign.New(rec.Provider); err != nil {
+			return 0, rec, err
+		}
+		providers[rec.Provider] = provider
+	}
+	status, err := provider.Status(c.ctx, rec.Envelope)
+	if err != nil {
+		return 0, rec, err
+	}
+	added, err := esign.Update(doc, rec, status, time.Now())
+	if err != nil {
+		return 0, rec, err
+	}
+	return added, rec, c.saveDocument(path, doc)
+}
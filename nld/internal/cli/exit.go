@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the nld command. They are part of its interface for
+// scripts and must not change meaning.
+const (
+	// ExitOK means the command succeeded
+	ExitOK = 0
+	// ExitUsage means the command line or a config file was invalid, or
+	// the command failed for a reason without a code of its own
+	ExitUsage = 1
+	// ExitValidation means a document is invalid: it failed validation or
+	// linting, is not valid JSON, or failed in batch or generate
+	ExitValidation = 2
+	// ExitIO means a file could not be read or written, or a download
+	// failed
+	ExitIO = 3
+	// ExitSignature means a document failed verification against its
+	// digest record, or has none
+	ExitSignature = 4
+	// ExitKey means an encrypted document was read without a key or with
+	// the wrong one
+	ExitKey = 5
+)
+
+// exitError is an error that ends the command with a given exit code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode marks err to end the command with code
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns the exit code for an error returned by Execute. Errors
+// not marked with a code are classified by their cause.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	if errors.Is(err, envelope.ErrNoKey) || errors.Is(err, envelope.ErrDecrypt) {
+		return ExitKey
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	// A truncated document ends in an unexpected EOF
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ExitValidation
+	}
+	var pathErr *fs.PathError
+	var netErr net.Error
+	if errors.As(err, &pathErr) || errors.As(err, &netErr) {
+		return ExitIO
+	}
+	return ExitUsage
+}
+
+// exitClass returns the error class of an exit code in metrics
+func exitClass(code int) string {
+	switch code {
+	case ExitValidation:
+		return "validation"
+	case ExitIO:
+		return "io"
+	case ExitSignature:
+		return "signature"
+	case ExitKey:
+		return "key"
+	default:
+		return "usage"
+	}
+}
+
+// addExitCodesTopic adds the exit-codes help topic
+func (c *CLI) addExitCodesTopic() {
+	c.rootCmd.AddCommand(&cobra.Command{
+		Use:   "exit-codes",
+		Short: "Exit codes returned by nld commands",
+		Long: `Every nld command exits with one of these codes, so that scripts can tell
+kinds of failure apart without parsing messages:
+
+  0  Success.
+  1  Usage error: an unknown command or flag, a missing or invalid
+     argument, an invalid config file, or any failure without a code of
+     its own, such as an existing output file without --force.
+  2  Validation failure: a document failed validation, lint or profile
+     checks, is not valid JSON, or one or more documents failed in batch
+     or generate.
+  3  I/O error: a file could not be read or written, or a download
+     failed.
+  4  Signature failure: verify found changes to a document, its digest
+     record was tampered with, or it has no digest record, or audit found
+     a tampered audit log.
+  5  Key error: an encrypted document was read without a key, or the key
+     is wrong.
+
+When validate checks several files with --force, it exits with 2 if any
+of them failed.
+
+For example:
+
+  nld validate contract.json
+  case $? in
+    0) echo "valid" ;;
+    2) echo "invalid" ;;
+    3) echo "could not read contract.json" ;;
+  esac`,
+	})
+}
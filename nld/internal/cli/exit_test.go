@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/envelope"
+)
+
+func TestExitCode(t *testing.T) {
+	_, readErr := os.ReadFile("does-not-exist.json")
+	_, parseErr := document.Parse([]byte("{"))
+
+	testCases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"success", nil, ExitOK},
+		{"unclassified", fmt.Errorf("unknown flag: --bogus"), ExitUsage},
+		{"marked", withExitCode(ExitValidation, fmt.Errorf("lint failed")), ExitValidation},
+		{"wrapped mark", fmt.Errorf("batch: %w", withExitCode(ExitSignature, fmt.Errorf("document verification failed"))), ExitSignature},
+		{"missing file", fmt.Errorf("failed to read document: %w", readErr), ExitIO},
+		{"invalid JSON", parseErr, ExitValidation},
+		{"no key", fmt.Errorf("failed to read document: %w", envelope.ErrNoKey), ExitKey},
+		{"wrong key", envelope.ErrDecrypt, ExitKey},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if code := ExitCode(tc.err); code != tc.code {
+				t.Errorf("Expected exit code %d for %v, got %d", tc.code, tc.err, code)
+			}
+		})
+	}
+}
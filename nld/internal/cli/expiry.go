@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/expiry"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addExpiryCommand adds the expiry command
+func (c *CLI) addExpiryCommand() {
+	var opts expiry.Options
+	var fromStore bool
+	var dir string
+	var remote string
+	var keyfile string
+	var passphraseFile string
+
+	expiryCmd := &cobra.Command{
+		Use:   "expiry [path...]",
+		Short: "Report documents that expire soon",
+		Long: `Report the documents that expire within --within days: those whose
+metadata.expires, or the end of the term from metadata.effective, falls in
+that window. Documents with a notice period (metadata.noticePeriod) are also
+reported when the last day to give notice falls in the window, so that they
+are not renewed by default.
+
+The paths are files or directories, or with --store the latest version of
+every document in the document store. Open-ended documents are left out, as
+are those that expired already unless --expired is given.
+
+The command fails when any document is reported, or cannot be read, so that
+it can alert from cron or CI:
+
+  nld expiry contracts/ --within 60 || mail -s "Contracts expiring" legal@example.com`,
+		Example: `  nld expiry contracts/
+  nld expiry --store --within 90 --output-format json
+  nld expiry lease.json --expired`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if fromStore == (len(args) > 0) {
+				return fmt.Errorf("specify files or directories, or --store")
+			}
+			if opts.Within < 0 {
+				return fmt.Errorf("--within must not be negative")
+			}
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			if fromStore {
+				s, err := c.openStore(dir, remote)
+				if err != nil {
+					return err
+				}
+				return c.runExpiryStore(s, opts, key)
+			}
+			files, err := expandPaths(args)
+			if err != nil {
+				return err
+			}
+			return c.runExpiryFiles(files, opts, key)
+		},
+	}
+
+	expiryCmd.Flags().IntVar(&opts.Within, "within", 30, "Number of days ahead to look")
+	expiryCmd.Flags().BoolVar(&opts.Expired, "expired", false, "Also report the documents that expired already")
+	expiryCmd.Flags().BoolVar(&fromStore, "store", false, "Check the documents of the document store")
+	expiryCmd.Flags().StringVar(&dir, "store-dir", "", "Directory of the store (default: $"+store.DirEnv+" or ~/.nld/store)")
+	expiryCmd.Flags().StringVar(&remote, "remote", "", "Use the store in a bucket URL or named remote instead")
+	expiryCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	expiryCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(expiryCmd)
+}
+
+// expiryError is a document that could not be checked
+type expiryError struct {
+	Document string `json:"document"`
+	Error    string `json:"error"`
+}
+
+// expiryResult is the outcome of the expiry command
+type expiryResult struct {
+	Within  int             `json:"within"`
+	Entries []*expiry.Entry `json:"entries"`
+	Errors  []expiryError   `json:"errors,omitempty"`
+}
+
+// runExpiryFiles checks the expiry of documents in files
+func (c *CLI) runExpiryFiles(files []string, opts expiry.Options, key *envelope.Key) error {
+	result := &expiryResult{Within: opts.Within, Entries: []*expiry.Entry{}}
+	for _, path := range files {
+		doc, err := c.parseDocument(path, key)
+		result.add(path, doc, err, opts)
+	}
+	return c.printExpiry(result)
+}
+
+// runExpiryStore checks the expiry of the latest version of every document
+// in a store
+func (c *CLI) runExpiryStore(s store.Store, opts expiry.Options, key *envelope.Key) error {
+	ctx := context.Background()
+	list, err := s.List(ctx)
+	if err != nil {
+		return withExitCode(ExitIO, fmt.Errorf("failed to list documents: %w", err))
+	}
+	result := &expiryResult{Within: opts.Within, Entries: []*expiry.Entry{}}
+	for _, info := range list {
+		if store.Reserved(info.ID) {
+			continue
+		}
+		data, _, err := s.Get(ctx, info.ID, 0)
+		if err == nil && envelope.IsEncrypted(data) {
+			data, err = envelope.Decrypt(data, key)
+		}
+		var doc *nld.Document
+		if err == nil {
+			doc, err = nld.Parse(data)
+		}
+		result.add(info.ID, doc, err, opts)
+	}
+	return c.printExpiry(result)
+}
+
+// add checks a document, recording err instead when it could not be read
+func (r *expiryResult) add(name string, doc *nld.Document, err error, opts expiry.Options) {
+	var e *expiry.Entry
+	if err == nil {
+		e, err = expiry.Check(name, doc, opts)
+	}
+	switch {
+	case err != nil:
+		r.Errors = append(r.Errors, expiryError{Document: name, Error: err.Error()})
+	case e != nil:
+		r.Entries = append(r.Entries, e)
+	}
+}
+
+// printExpiry prints the entries, soonest first, and fails when there are
+// any
+func (c *CLI) printExpiry(result *expiryResult) error {
+	sort.SliceStable(result.Entries, func(i, j int) bool {
+		return result.Entries[i].Expires.Before(result.Entries[j].Expires)
+	})
+
+	if c.outputFormat == "json" {
+		if err := printJSON(result); err != nil {
+			return err
+		}
+	} else {
+		for _, e := range result.Errors {
+			fmt.Fprintln(os.Stderr, validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", e.Document, e.Error)))
+		}
+		if !c.quiet {
+			printExpiryEntries(result)
+		}
+	}
+
+	switch {
+	case len(result.Entries) > 0:
+		return withExitCode(ExitValidation, fmt.Errorf("%d document(s) need attention within %d days", len(result.Entries), result.Within))
+	case len(result.Errors) > 0:
+		return withExitCode(ExitValidation, fmt.Errorf("%d document(s) could not be checked", len(result.Errors)))
+	}
+	return nil
+}
+
+// printExpiryEntries prints the entries as text
+func printExpiryEntries(result *expiryResult) {
+	if len(result.Entries) == 0 {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ No documents expire within %d days", result.Within)))
+		return
+	}
+	for _, e := range result.Entries {
+		var when string
+		switch e.Status {
+		case expiry.Expired:
+			when = fmt.Sprintf("expired %d day(s) ago", -e.Days)
+		case expiry.Notice:
+			when = fmt.Sprintf("expires in %d day(s), notice by %s", e.Days, e.NoticeBy.Format(nld.DateLayout))
+		default:
+			when = fmt.Sprintf("expires in %d day(s)", e.Days)
+		}
+		title := e.Document
+		if e.Title != "" {
+			title += " (" + e.Title + ")"
+		}
+		fmt.Printf("  %-10s %-10s %s: %s\n", e.Expires.Format(nld.DateLayout), e.Status, title, when)
+	}
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/docx"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// exportFormats are the formats supported by the export command
+var exportFormats = []string{"docx"}
+
+// addExportCommand adds the export command
+func (c *CLI) addExportCommand() {
+	var format string
+	var outputPath string
+	var numbering string
+	var unnumbered bool
+	var locale string
+	var force bool
+	var keyfile string
+	var passphraseFile string
+
+	exportCmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Export an NLD document to an office format",
+		Long: `Export an NLD document to a file that can be edited in other tools.
+
+The docx format produces a Word document in which sections are Word headings
+(Heading 1 for top-level sections, Heading 2 for their subsections, and so
+on) and definitions form a glossary at the end. The NLD metadata is stored in
+a custom XML part so that it is kept when the document is edited and saved
+in Word.`,
+		Example: `  nld export contract.json --format docx
+  nld export contract.json -o contract-final.docx --locale fr`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			if outputPath == "" && args[0] == stdio {
+				outputPath = stdio
+			} else if outputPath == "" {
+				ext := filepath.Ext(args[0])
+				outputPath = strings.TrimSuffix(args[0], ext) + "." + format
+			}
+
+			opts := docx.Options{Unnumbered: unnumbered, Lang: c.tr, Locale: locale}
+			if numbering != "" {
+				if opts.Numbering, err = nld.LookupNumberingStyle(numbering); err != nil {
+					return err
+				}
+			}
+			return c.runExport(args[0], outputPath, format, opts, force, key)
+		},
+	}
+
+	exportCmd.Flags().StringVar(&format, "format", "docx", "Export format ("+strings.Join(exportFormats, ", ")+")")
+	exportCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: <file>.<format>)")
+	exportCmd.Flags().StringVar(&numbering, "numbering", "", "Section numbering style (default: based on jurisdiction)")
+	exportCmd.Flags().BoolVar(&unnumbered, "no-numbers", false, "Export section headings without numbers")
+	exportCmd.Flags().StringVar(&locale, "locale", "", "Locale of section content")
+	exportCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+	exportCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	exportCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(exportCmd)
+}
+
+// runExport runs the export command
+func (c *CLI) runExport(inputPath, outputPath, format string, opts docx.Options, force bool, key *envelope.Key) error {
+	if format != "docx" {
+		return fmt.Errorf("unsupported export format %q (supported: %s)", format, strings.Join(exportFormats, ", "))
+	}
+	if exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+
+	doc, err := c.parseDocument(inputPath, key)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := docx.Write(&buf, doc, opts); err != nil {
+		return fmt.Errorf("failed to export document: %w", err)
+	}
+	if err := c.writeOutput(outputPath, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Exported %s: %s", inputPath, outputPath)))
+	}
+	return nil
+}
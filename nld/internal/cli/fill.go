@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/fill"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addFillCommand adds the fill command
+func (c *CLI) addFillCommand() {
+	var valuesPath string
+	var sets []string
+	var outputPath string
+	var list bool
+	var force bool
+	var keyfile string
+	var passphraseFile string
+
+	fillCmd := &cobra.Command{
+		Use:   "fill [file]",
+		Short: "Substitute placeholder variables in a document",
+		Long: `Replace {{name}} placeholders in a document with values from a YAML or
+JSON file, producing a finalized document. Dotted names such as
+{{party.buyer.name}} are looked up in nested values.
+
+The command fails, without writing output, if any placeholder is left
+unresolved. Encrypted documents are filled into documents encrypted with the
+same key.`,
+		Example: `  nld fill contract.json --values values.yaml
+  nld fill contract.json --set effective_date=2025-07-01 --set fee=1500
+  nld fill contract.json --list`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			if list {
+				return c.runListPlaceholders(args[0], key)
+			}
+			if outputPath == "" && args[0] == stdio {
+				outputPath = stdio
+			} else if outputPath == "" {
+				ext := filepath.Ext(args[0])
+				outputPath = strings.TrimSuffix(args[0], ext) + ".filled" + ext
+			}
+			return c.runFill(args[0], outputPath, valuesPath, sets, force, key)
+		},
+	}
+
+	fillCmd.Flags().StringVar(&valuesPath, "values", "", "YAML or JSON file with placeholder values")
+	fillCmd.Flags().StringArrayVar(&sets, "set", nil, "Set a placeholder value (name=value, repeatable)")
+	fillCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: <file>.filled.json)")
+	fillCmd.Flags().BoolVar(&list, "list", false, "List the placeholders used in the document")
+	fillCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+	fillCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	fillCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(fillCmd)
+}
+
+// runListPlaceholders prints the placeholders used in a document
+func (c *CLI) runListPlaceholders(filePath string, key *envelope.Key) error {
+	doc, err := c.loadDocument(filePath, key)
+	if err != nil {
+		return err
+	}
+	for _, name := range fill.Placeholders(doc) {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// runFill runs the fill command
+func (c *CLI) runFill(inputPath, outputPath, valuesPath string, sets []string, force bool, key *envelope.Key) error {
+	if exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+
+	values := map[string]interface{}{}
+	if valuesPath != "" {
+		loaded, err := fill.LoadValues(valuesPath)
+		if err != nil {
+			return err
+		}
+		values = loaded
+	}
+	for _, set := range sets {
+		name, value, ok := strings.Cut(set, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("invalid --set value %q (expected name=value)", set)
+		}
+		values[name] = value
+	}
+
+	doc, encrypted, err := c.loadSealed(inputPath, key)
+	if err != nil {
+		return err
+	}
+
+	if err := fill.Apply(doc, values); err != nil {
+		var unresolved *fill.UnresolvedError
+		if errors.As(err, &unresolved) && !c.quiet {
+			fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s has %d unresolved placeholder(s):", inputPath, len(unresolved.Names))))
+			for _, name := range unresolved.Names {
+				fmt.Printf("  - {{%s}}\n", name)
+			}
+		}
+		return err
+	}
+
+	if err := c.writeDocument(outputPath, doc, encrypted, key); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Filled %s: %s", inputPath, outputPath)))
+	}
+	return nil
+}
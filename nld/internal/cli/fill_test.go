@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFillEncrypted(t *testing.T) {
+	path, keyfile := writeEncrypted(t)
+	output := filepath.Join(filepath.Dir(path), "filled.json")
+
+	if _, err := run(t, New(), "fill", path, "--keyfile", keyfile, "--set", "client=Acme Corp", "-o", output); err != nil {
+		t.Fatalf("fill failed: %v", err)
+	}
+	if plaintext := checkEncrypted(t, output); !strings.Contains(plaintext, "Acme Corp and XYZ Ltd") {
+		t.Errorf("Expected the placeholder to be filled, got %s", plaintext)
+	}
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/report"
+	"github.com/colemalphrus/nld/internal/schema"
+	"github.com/colemalphrus/nld/internal/validator"
+)
+
+// fixDocument applies the fixes that validation suggests to the document
+// at path, asking for each unless opts.yes is set. Documents that cannot
+// be validated are left for runValidate to report; the fixes applied are
+// reported to rep.
+func (c *CLI) fixDocument(rep report.Reporter, path string, opts validateOptions, answers *bufio.Reader) error {
+	if path == stdio {
+		return withExitCode(ExitUsage, fmt.Errorf("--fix cannot be used with standard input"))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	if envelope.IsEncrypted(data) {
+		return withExitCode(ExitUsage, fmt.Errorf("--fix cannot be used with encrypted documents: %s", path))
+	}
+
+	var s *schema.Schema
+	if opts.schemaPath != "" {
+		compiled, err := c.validator.LoadSchema(opts.schemaPath)
+		if err != nil {
+			return nil
+		}
+		s = &schema.Schema{Path: opts.schemaPath, Compiled: compiled}
+	} else if opts.auto {
+		if s, _, err = c.classifiedSchema(data); err != nil {
+			return nil
+		}
+	} else if s, err = schema.GetDocumentSchemaFromBytesContext(c.ctx, data); err != nil {
+		return nil
+	}
+	result, err := validateSchema(c.ctx, s, data)
+	if err != nil || result.Valid {
+		return nil
+	}
+	fixes := collectFixes(result.Errors)
+	if len(fixes) == 0 {
+		return nil
+	}
+
+	doc, err := document.Parse(data)
+	if err != nil {
+		return nil
+	}
+	applied := 0
+	for _, fix := range fixes {
+		if !opts.yes {
+			fmt.Printf("%s: %s at %s? [y/N] ", path, fix.Description, fix.Path)
+			answer, _ := answers.ReadString('\n')
+			if answer := strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+				continue
+			}
+		}
+		if err := doc.Set(fix.Path, fix.Value); err != nil {
+			return fmt.Errorf("failed to fix %s: %w", path, err)
+		}
+		applied++
+	}
+	if applied == 0 {
+		return nil
+	}
+	if err := c.saveDocument(path, doc); err != nil {
+		return withExitCode(ExitIO, fmt.Errorf("failed to write document: %w", err))
+	}
+	return rep.Notice(fmt.Sprintf("Applied %d of %d fixes to %s", applied, len(fixes), path))
+}
+
+// collectFixes returns the fixes of errors, the first for each path
+func collectFixes(errs []validator.ValidationError) []validator.Fix {
+	var fixes []validator.Fix
+	seen := map[string]bool{}
+	for _, e := range errs {
+		for _, fix := range e.Fixes {
+			if !seen[fix.Path] {
+				seen[fix.Path] = true
+				fixes = append(fixes, fix)
+			}
+		}
+	}
+	return fixes
+}
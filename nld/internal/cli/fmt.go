@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addFmtCommand adds the fmt command
+func (c *CLI) addFmtCommand() {
+	var check bool
+
+	fmtCmd := &cobra.Command{
+		Use:   "fmt [file...]",
+		Short: "Rewrite NLD documents in canonical form",
+		Long: `Rewrite NLD documents in the canonical form written by nld init and the
+commands that edit documents: two-space indentation with the well-known
+top-level keys first, in schema order.
+
+With --check, documents are not changed; the command lists the documents that
+are not in canonical form and fails with exit code 2 if there are any. Give -
+to format standard input to standard output.`,
+		Example: `  nld fmt contract.json
+  nld fmt --check contracts/*.json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runFmt(args, check)
+		},
+	}
+
+	fmtCmd.Flags().BoolVar(&check, "check", false, "Report documents that are not formatted instead of rewriting them")
+
+	c.rootCmd.AddCommand(fmtCmd)
+}
+
+// fmtResult is the formatting result for one file
+type fmtResult struct {
+	File      string `json:"file"`
+	Formatted bool   `json:"formatted"`
+	Error     string `json:"error,omitempty"`
+}
+
+// runFmt runs the fmt command
+func (c *CLI) runFmt(filePaths []string, check bool) error {
+	var results []fmtResult
+	var lastErr error
+	failed, unformatted := 0, 0
+	for _, path := range filePaths {
+		result := fmtResult{File: path}
+		formatted, changed, err := c.formatDocument(path)
+		if err == nil && !check && (changed || path == stdio) {
+			err = c.writeOutput(path, formatted, 0644)
+		}
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+			lastErr = err
+			failed++
+		case changed:
+			unformatted++
+		default:
+			result.Formatted = true
+		}
+		results = append(results, result)
+	}
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+	} else {
+		for _, r := range results {
+			switch {
+			case r.Error != "":
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.File, r.Error)))
+			case !r.Formatted && check:
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s is not formatted", r.File)))
+			case !r.Formatted && !c.quiet:
+				fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Formatted %s", r.File)))
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to format %d of %d document(s): %w", failed, len(filePaths), lastErr)
+	}
+	if check && unformatted > 0 {
+		return withExitCode(ExitValidation, fmt.Errorf("%d document(s) not formatted (run nld fmt to fix)", unformatted))
+	}
+	return nil
+}
+
+// formatDocument returns the canonical form of the document at path and
+// whether it differs from the file. Encrypted documents are formatted as
+// their envelope and never decrypted.
+func (c *CLI) formatDocument(path string) ([]byte, bool, error) {
+	data, err := c.readInput(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read document: %w", err)
+	}
+	doc, err := document.Parse(data)
+	if err != nil {
+		return nil, false, err
+	}
+	formatted, err := doc.Marshal()
+	if err != nil {
+		return nil, false, err
+	}
+	// A final newline is allowed either way
+	return formatted, !bytes.Equal(bytes.TrimSuffix(data, []byte("\n")), formatted), nil
+}
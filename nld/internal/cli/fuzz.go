@@ -0,0 +1,202 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/codegen"
+	"github.com/colemalphrus/nld/internal/fuzz"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/schemas"
+	"github.com/spf13/cobra"
+)
+
+// fuzzReport is a finding of nld fuzz and the documents it was found in
+type fuzzReport struct {
+	fuzz.Finding
+	// Count is the number of documents with the finding
+	Count int `json:"count"`
+	// Seed is the file of the document the first of them was made from
+	Seed string `json:"seed"`
+	// Crasher is the file the first of them was saved to, with --crashers
+	Crasher string `json:"crasher,omitempty"`
+}
+
+// addFuzzCommand adds the fuzz command
+func (c *CLI) addFuzzCommand() {
+	var count int
+	var seed int64
+	var corpusDir, crashersDir string
+
+	fuzzCmd := &cobra.Command{
+		Use:   "fuzz [FILE...]",
+		Short: "Test nld with near-valid documents",
+		Long: `Test the validator, the parser and the renderers of nld with near-valid
+documents, made by changing valid ones at random: properties removed,
+values of other types or at the edges of what nld expects, list items
+repeated, deep nesting and, in some, bytes changed so that they are no
+longer JSON. This is a developer command for finding crashes.
+
+The seeds are the given documents, by default an example of each built-in
+schema, as nld schema example makes. Every variant is checked for panics
+and for properties that should hold: validating twice gives the same
+result, the streaming validator agrees with the validator, and valid
+documents parse.
+
+The same seed gives the same variants; without --seed one is chosen and
+printed, so that a run can be repeated. --crashers saves the variants with
+findings. --corpus saves every variant as a seed corpus of the Go fuzz
+targets, such as testdata/fuzz/FuzzCheck for go test -fuzz FuzzCheck.
+
+Exits with status 2 when anything is found.`,
+		Example: `  nld fuzz
+  nld fuzz examples/*.json --count 10000 --seed 42
+  nld fuzz contract.json --crashers crashers
+  nld fuzz --corpus internal/fuzz/testdata/fuzz/FuzzCheck`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if count < 1 {
+				return withExitCode(ExitUsage, fmt.Errorf("--count must be at least 1"))
+			}
+			seeds, names, err := c.fuzzSeeds(args)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("seed") {
+				seed = time.Now().UnixNano()
+				fmt.Fprintf(os.Stderr, "Seed: %d\n", seed)
+			}
+			r := rand.New(rand.NewSource(seed))
+
+			found := map[string]*fuzzReport{}
+			for i := 0; i < count; i++ {
+				n := i % len(seeds)
+				data := fuzz.Mutate(seeds[n], r)
+				if corpusDir != "" {
+					if err := writeFuzzFile(corpusDir, data, true); err != nil {
+						return err
+					}
+				}
+				for _, f := range fuzz.Check(data) {
+					key := f.String()
+					if report, ok := found[key]; ok {
+						report.Count++
+						continue
+					}
+					report := &fuzzReport{Finding: f, Count: 1, Seed: names[n]}
+					if crashersDir != "" {
+						if err := writeFuzzFile(crashersDir, data, false); err != nil {
+							return err
+						}
+						report.Crasher = filepath.Join(crashersDir, fuzzFileName(data)+".json")
+					}
+					found[key] = report
+				}
+			}
+
+			reports := make([]*fuzzReport, 0, len(found))
+			for _, report := range found {
+				reports = append(reports, report)
+			}
+			sort.Slice(reports, func(i, j int) bool {
+				return reports[i].String() < reports[j].String()
+			})
+			if c.outputFormat == "json" {
+				if err := printJSON(reports); err != nil {
+					return err
+				}
+			} else {
+				for _, report := range reports {
+					fmt.Println(validator.ColoredOutput(false, report.String()))
+					fmt.Printf("  %d documents, the first from %s", report.Count, report.Seed)
+					if report.Crasher != "" {
+						fmt.Printf(", saved to %s", report.Crasher)
+					}
+					fmt.Println()
+					if report.Stack != "" && c.verbose {
+						fmt.Println(report.Stack)
+					}
+				}
+				if len(reports) == 0 && !c.quiet {
+					fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("No findings in %d documents", count)))
+				}
+			}
+			if len(reports) > 0 {
+				return withExitCode(ExitValidation, fmt.Errorf("%d findings in %d documents (seed %d)", len(reports), count, seed))
+			}
+			return nil
+		},
+	}
+
+	fuzzCmd.Flags().IntVarP(&count, "count", "n", 1000, "Number of documents to test")
+	fuzzCmd.Flags().Int64Var(&seed, "seed", 0, "Seed of the changes (default: random)")
+	fuzzCmd.Flags().StringVar(&corpusDir, "corpus", "", "Directory to save every document to as a Go fuzz corpus")
+	fuzzCmd.Flags().StringVar(&crashersDir, "crashers", "", "Directory to save documents with findings to")
+
+	c.rootCmd.AddCommand(fuzzCmd)
+}
+
+// fuzzSeeds reads the seed documents of nld fuzz, or makes an example of
+// each built-in schema when no files are given. It returns the documents
+// and their names.
+func (c *CLI) fuzzSeeds(paths []string) ([][]byte, []string, error) {
+	var seeds [][]byte
+	for _, path := range paths {
+		data, err := c.readInput(path)
+		if err != nil {
+			return nil, nil, withExitCode(ExitIO, fmt.Errorf("failed to read document: %w", err))
+		}
+		seeds = append(seeds, data)
+	}
+	if len(paths) > 0 {
+		return seeds, paths, nil
+	}
+
+	entries, err := schemas.FS.ReadDir(".")
+	if err != nil {
+		return nil, nil, withExitCode(ExitIO, fmt.Errorf("failed to read built-in schemas: %w", err))
+	}
+	var names []string
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := schemas.FS.ReadFile(entry.Name())
+		if err != nil {
+			return nil, nil, withExitCode(ExitIO, fmt.Errorf("failed to read built-in schema: %w", err))
+		}
+		example, err := codegen.Example(data, codegen.ExampleOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate an example of schemas/%s: %w", entry.Name(), err)
+		}
+		seeds = append(seeds, example)
+		names = append(names, "the example of schemas/"+entry.Name())
+	}
+	return seeds, names, nil
+}
+
+// writeFuzzFile saves a document to dir under a name derived from its
+// content, as a Go fuzz corpus file or as it is
+func writeFuzzFile(dir string, data []byte, corpus bool) error {
+	path := filepath.Join(dir, fuzzFileName(data)+".json")
+	if corpus {
+		// The format of go test fuzz corpus files, whose names are not
+		// significant
+		path = filepath.Join(dir, fuzzFileName(data))
+		data = []byte(fmt.Sprintf("go test fuzz v1\n[]byte(%q)\n", data))
+	}
+	if err := writeFile(path, data, 0644); err != nil {
+		return withExitCode(ExitIO, fmt.Errorf("failed to save document: %w", err))
+	}
+	return nil
+}
+
+// fuzzFileName returns a name for a document derived from its content
+func fuzzFileName(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))[:16]
+}
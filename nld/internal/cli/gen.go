@@ -0,0 +1,307 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/colemalphrus/nld/internal/codegen"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/schemas"
+	"github.com/spf13/cobra"
+)
+
+// genOptions holds the settings shared by the gen commands
+type genOptions struct {
+	schemaPath string
+	docType    string
+	name       string
+	outputPath string
+	force      bool
+}
+
+// addGenCommand adds the gen command and its subcommands
+func (c *CLI) addGenCommand() {
+	genCmd := &cobra.Command{
+		Use:   "gen",
+		Short: "Generate code from document schemas",
+		Long: `Generate typed models of a document schema for services that consume
+documents of a specific type, so that they do not maintain them by hand.
+
+The schema is a schema file, or one of the schemas built into nld by file
+name, such as document-v1.json; --type picks the built-in schema of a
+document type. The default is the schema of contracts and receipts.`,
+	}
+
+	c.addGenGoCommand(genCmd)
+	c.addGenTSCommand(genCmd)
+	c.addGenFormCommand(genCmd)
+
+	c.rootCmd.AddCommand(genCmd)
+}
+
+// addGenGoCommand adds the gen go command
+func (c *CLI) addGenGoCommand(genCmd *cobra.Command) {
+	var opts genOptions
+	var pkg string
+
+	goCmd := &cobra.Command{
+		Use:   "go",
+		Short: "Generate Go types from a document schema",
+		Long: `Generate Go structs of a document schema, with json tags, so that documents
+can be decoded with encoding/json.
+
+Objects become structs named after their property, such as Metadata, or
+their definition, such as Section. Optional objects are pointers and
+optional fields are omitted when empty. Strings with an enum become string
+types with a constant for each value. Values that may be of several kinds,
+such as a string or an object, become structs with a field for each kind,
+of which one is set.
+
+Every type has a Validate method checking what its Go type cannot express:
+patterns, enums, minimums and maximums, the number of items of lists and
+the presence of required lists, objects and values of several kinds.
+Errors name the path of the value, such as content.sections[2].content.
+Validate is not a full schema validation; use nld validate for that.
+
+Generated files are overwritten; other files need --force.`,
+		Example: `  nld gen go -o internal/nld/document.go
+  nld gen go --schema nda.schema.json --package nda -o nda/types.go
+  nld gen go --schema my-schema.json --name Invoice --package invoices`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			model, source, err := c.loadGenModel(opts)
+			if err != nil {
+				return err
+			}
+			if pkg == "" {
+				pkg = packageName(opts.outputPath)
+			}
+			out, err := codegen.Go(model, codegen.GoOptions{Package: pkg, Source: source})
+			if err != nil {
+				return err
+			}
+			return c.writeGenerated(opts.outputPath, out, opts.force)
+		},
+	}
+
+	addGenFlags(goCmd, &opts)
+	goCmd.Flags().StringVar(&pkg, "package", "", "Package of the code (default: the directory of --output, or nld)")
+
+	genCmd.AddCommand(goCmd)
+}
+
+// addGenTSCommand adds the gen ts command
+func (c *CLI) addGenTSCommand(genCmd *cobra.Command) {
+	var opts genOptions
+	var bundlePath string
+
+	tsCmd := &cobra.Command{
+		Use:   "ts",
+		Short: "Generate TypeScript types and a schema bundle from a document schema",
+		Long: `Generate TypeScript declarations of a document schema, as a .d.ts file,
+and a bundle of the schema for validators such as ajv, so that web forms
+creating documents stay in sync with the schema.
+
+Objects become interfaces named as nld gen go names its structs, with
+optional properties marked with ?. Enums become unions of string literals
+and values that may be of several kinds unions of their types.
+
+The bundle is the schema as one self-contained file: every $ref is
+replaced by the schema it refers to, but for recursive ones, such as
+subsections, which refer to the definitions kept for them. It is written
+to --bundle, by default beside the declarations as NAME.schema.json when
+--output is a file.
+
+Generated files are overwritten; other files need --force.`,
+		Example: `  nld gen ts --schema document-v1.json -o src/nld/document.d.ts
+  nld gen ts --type nda -o nda.d.ts --bundle public/nda.schema.json
+  nld gen ts --schema my-schema.json --name Invoice > invoice.d.ts`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			model, source, err := c.loadGenModel(opts)
+			if err != nil {
+				return err
+			}
+			if bundlePath == "" && opts.outputPath != stdio {
+				base := strings.TrimSuffix(strings.TrimSuffix(opts.outputPath, ".ts"), ".d")
+				bundlePath = base + ".schema.json"
+			}
+			// The bundle is written first, as standard output is then
+			// left to the declarations
+			if bundlePath != "" {
+				data, _, err := readGenSchema(opts.schemaPath, opts.docType)
+				if err != nil {
+					return err
+				}
+				bundle, err := codegen.Bundle(data, fmt.Sprintf("Code generated by nld gen ts from %s; DO NOT EDIT.", source))
+				if err != nil {
+					return withExitCode(ExitValidation, fmt.Errorf("failed to bundle schema %s: %w", source, err))
+				}
+				if err := c.writeGenerated(bundlePath, bundle, opts.force); err != nil {
+					return err
+				}
+			}
+			return c.writeGenerated(opts.outputPath, codegen.TypeScript(model, codegen.TypeScriptOptions{Source: source}), opts.force)
+		},
+	}
+
+	addGenFlags(tsCmd, &opts)
+	tsCmd.Flags().StringVar(&bundlePath, "bundle", "", "File the bundled schema is written to (default: NAME.schema.json beside --output)")
+
+	genCmd.AddCommand(tsCmd)
+}
+
+// addGenFormCommand adds the gen form command
+func (c *CLI) addGenFormCommand(genCmd *cobra.Command) {
+	var opts genOptions
+	var format string
+
+	formCmd := &cobra.Command{
+		Use:   "form",
+		Short: "Generate a document entry form from a document schema",
+		Long: `Generate a form for entering documents of a schema, so that people who do
+not write JSON can create documents that validate.
+
+Formats:
+  html       A standalone HTML page (default). Fields are inputs of their
+             type, enums are selects and required fields are marked with *
+             and cannot be left empty. Lists are edited with add and remove
+             buttons, and values of several kinds, such as section content,
+             with a select of their kind. Submitting the form shows the
+             document and offers it for download as document.json.
+  jsonforms  The schema, bundled as by nld gen ts, and a UI schema for JSON
+             Forms (jsonforms.io), as {"schema": ..., "uischema": ...}.
+
+The form checks the types, enums, patterns, bounds and required fields of
+the schema; run nld validate on the documents it creates for the rest.
+
+Generated files are overwritten; other files need --force.`,
+		Example: `  nld gen form -o new-contract.html
+  nld gen form --type nda -o nda.html
+  nld gen form --format jsonforms -o src/forms/document.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "html" && format != "jsonforms" {
+				return withExitCode(ExitUsage, fmt.Errorf("unsupported format: %s (supported: html, jsonforms)", format))
+			}
+			model, source, err := c.loadGenModel(opts)
+			if err != nil {
+				return err
+			}
+			if format == "html" {
+				return c.writeGenerated(opts.outputPath, codegen.Form(model, codegen.FormOptions{Source: source}), opts.force)
+			}
+			data, _, err := readGenSchema(opts.schemaPath, opts.docType)
+			if err != nil {
+				return err
+			}
+			bundle, err := codegen.Bundle(data, fmt.Sprintf("Code generated by nld gen form from %s; DO NOT EDIT.", source))
+			if err != nil {
+				return withExitCode(ExitValidation, fmt.Errorf("failed to bundle schema %s: %w", source, err))
+			}
+			out, err := codegen.JSONForms(model, bundle)
+			if err != nil {
+				return err
+			}
+			return c.writeGenerated(opts.outputPath, out, opts.force)
+		},
+	}
+
+	addGenFlags(formCmd, &opts)
+	formCmd.Flags().StringVarP(&format, "format", "f", "html", "Form format (html, jsonforms)")
+
+	genCmd.AddCommand(formCmd)
+}
+
+// addGenFlags adds the flags shared by the gen commands
+func addGenFlags(cmd *cobra.Command, opts *genOptions) {
+	cmd.Flags().StringVar(&opts.schemaPath, "schema", "", "Schema file, or the file name of a built-in schema")
+	cmd.Flags().StringVar(&opts.docType, "type", "", "Document type whose built-in schema is used")
+	cmd.Flags().StringVar(&opts.name, "name", "Document", "Name of the type of whole documents")
+	cmd.Flags().StringVarP(&opts.outputPath, "output", "o", stdio, "Output file path (default: standard output)")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Overwrite the output file even if it was not generated by nld")
+}
+
+// loadGenModel reads the schema of a gen command and returns its model and
+// the name of the schema
+func (c *CLI) loadGenModel(opts genOptions) (*codegen.Model, string, error) {
+	if opts.schemaPath != "" && opts.docType != "" {
+		return nil, "", withExitCode(ExitUsage, fmt.Errorf("--schema and --type cannot be used together"))
+	}
+	data, source, err := readGenSchema(opts.schemaPath, opts.docType)
+	if err != nil {
+		return nil, "", err
+	}
+	model, err := codegen.Parse(data, opts.name)
+	if err != nil {
+		return nil, "", withExitCode(ExitValidation, fmt.Errorf("failed to read schema %s: %w", source, err))
+	}
+	return model, source, nil
+}
+
+// readGenSchema reads a schema file, falling back to the schemas built
+// into nld, or the built-in schema of a document type
+func readGenSchema(path, docType string) ([]byte, string, error) {
+	if path == "" {
+		path = validator.SchemaFile(docType)
+		data, err := schemas.FS.ReadFile(strings.TrimPrefix(path, "schemas/"))
+		if err != nil {
+			return nil, "", withExitCode(ExitIO, fmt.Errorf("no built-in schema %s: %w", path, err))
+		}
+		return data, path, nil
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return data, filepath.ToSlash(path), nil
+	}
+	if builtin, berr := schemas.FS.ReadFile(filepath.Base(path)); berr == nil && os.IsNotExist(err) {
+		return builtin, "schemas/" + filepath.Base(path), nil
+	}
+	return nil, "", withExitCode(ExitIO, fmt.Errorf("failed to read schema: %w", err))
+}
+
+// writeGenerated writes generated code to path, refusing to overwrite
+// files that were not generated unless forced
+func (c *CLI) writeGenerated(path string, out []byte, force bool) error {
+	if exists(path) && !force {
+		existing, err := os.ReadFile(path)
+		if err != nil || !bytes.Contains(existing, []byte("generated by nld gen")) {
+			return fmt.Errorf("file already exists and was not generated by nld: %s (use --force to overwrite)", path)
+		}
+	}
+	if err := c.writeOutput(path, out, 0644); err != nil {
+		return err
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Generated %s", path)))
+	}
+	return nil
+}
+
+// packageName returns the Go package name of the directory of an output
+// file, or nld for standard output and directories that are not valid
+// package names
+func packageName(outputPath string) string {
+	if outputPath == stdio {
+		return "nld"
+	}
+	dir, err := filepath.Abs(filepath.Dir(outputPath))
+	if err != nil {
+		return "nld"
+	}
+	name := strings.ToLower(filepath.Base(dir))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return -1
+	}, name)
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		return "nld"
+	}
+	return name
+}
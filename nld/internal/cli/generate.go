@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/generate"
+	"github.com/colemalphrus/nld/internal/schema"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// generatedDocument is the JSON output of the generate command for one
+// data row
+type generatedDocument struct {
+	Row      string   `json:"row"`
+	Output   string   `json:"output,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// generateOptions holds the settings of the generate command
+type generateOptions struct {
+	templatePath string
+	dataPath     string
+	outputDir    string
+	maps         []string
+	sets         []string
+	noValidate   bool
+	validate     validateOptions
+	force        bool
+	generate.Options
+}
+
+// addGenerateCommand adds the generate command
+func (c *CLI) addGenerateCommand() {
+	var opts generateOptions
+	var keyfile string
+	var passphraseFile string
+
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate one document per data row from a template",
+		Long: `Generate documents from a template and a data file, one document per row,
+like a mail merge.
+
+The template is an NLD document with {{placeholder}} values. The data is a
+CSV or TSV file with a header row, or a JSON or YAML list of objects. Each
+column fills the placeholder named by its header (a header such as
+"client.name" fills {{client.name}}); --map fills a placeholder from another
+column, by header or 1-based number. {{row}} is the row number.
+
+File names come from the --name template, which uses the same placeholders;
+characters that cannot appear in file names are replaced. Every generated
+document is validated as the validate command does, unless --no-validate is
+given, and documents that fail are reported and not written.`,
+		Example: `  nld generate --template contract.tmpl.json --data parties.csv -o contracts
+  nld generate --template nda.json --data clients.csv --name "{{client}}-nda.json" --map client="Company Name"
+  nld generate --template receipt.tmpl.json --data orders.yaml --set seller=Acme --no-validate`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			if opts.Name == "" {
+				opts.Name = generate.DefaultName(opts.templatePath)
+			}
+			opts.Map = map[string]string{}
+			for _, m := range opts.maps {
+				name, column, ok := strings.Cut(m, "=")
+				if !ok || name == "" {
+					return fmt.Errorf("invalid --map value %q (expected placeholder=column)", m)
+				}
+				opts.Map[name] = column
+			}
+			opts.Set = map[string]interface{}{}
+			for _, set := range opts.sets {
+				name, value, ok := strings.Cut(set, "=")
+				if !ok || name == "" {
+					return fmt.Errorf("invalid --set value %q (expected name=value)", set)
+				}
+				opts.Set[name] = value
+			}
+			return c.runGenerate(opts, key)
+		},
+	}
+
+	generateCmd.Flags().StringVar(&opts.templatePath, "template", "", "Template document with {{placeholder}} values")
+	generateCmd.Flags().StringVar(&opts.dataPath, "data", "", "CSV, TSV, JSON or YAML file with one record per document")
+	generateCmd.Flags().StringVarP(&opts.outputDir, "output", "o", ".", "Directory the documents are written to")
+	generateCmd.Flags().StringVar(&opts.Name, "name", "", "File name template (default: <template>-{{row}}.json)")
+	generateCmd.Flags().StringArrayVar(&opts.maps, "map", nil, "Fill a placeholder from a data column (placeholder=header or number, repeatable)")
+	generateCmd.Flags().StringArrayVar(&opts.sets, "set", nil, "Set a placeholder value for every document (name=value, repeatable)")
+	generateCmd.Flags().BoolVar(&opts.noValidate, "no-validate", false, "Write documents without validating them")
+	generateCmd.Flags().StringVarP(&opts.validate.schemaPath, "schema", "s", "", "Path to schema file (default: based on the document type)")
+	generateCmd.Flags().StringVar(&opts.validate.profile, "profile", "", "Validation profile name or file, or none (default: based on jurisdiction)")
+	generateCmd.Flags().BoolVar(&opts.force, "force", false, "Overwrite existing files")
+	generateCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt an encrypted template")
+	generateCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt an encrypted template")
+	generateCmd.MarkFlagRequired("template")
+	generateCmd.MarkFlagRequired("data")
+
+	c.rootCmd.AddCommand(generateCmd)
+}
+
+// runGenerate runs the generate command
+func (c *CLI) runGenerate(opts generateOptions, key *envelope.Key) error {
+	template, err := c.readDocument(opts.templatePath, key)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+	data, err := generate.ReadData(opts.dataPath)
+	if err != nil {
+		return err
+	}
+	g, err := generate.New(template, data, opts.Options)
+	if err != nil {
+		return err
+	}
+	var compiled *schema.Schema
+	if opts.validate.schemaPath != "" && !opts.noValidate {
+		if compiled, err = schema.Load(opts.validate.schemaPath); err != nil {
+			return err
+		}
+	}
+
+	// Every row is generated before anything is written so that rows
+	// whose file names clash are caught first
+	results := make([]generatedDocument, len(data.Rows))
+	written := map[string]string{}
+	var writes []func() error
+	bar := c.progress(c.tr.T("progress.generate"), len(data.Rows))
+	defer bar.Done()
+	for i, row := range data.Rows {
+		bar.Add(1)
+		r := &results[i]
+		r.Row = row.Location
+		doc, name, err := g.Generate(row, i+1)
+		if err != nil {
+			r.Error = err.Error()
+			continue
+		}
+		path := filepath.Join(opts.outputDir, name)
+		if other, ok := written[path]; ok {
+			return fmt.Errorf("%s and %s would both be written to %s (use --name to give each document its own file name)", other, row.Location, path)
+		}
+		written[path] = row.Location
+
+		if !opts.noValidate {
+			warnings, err := validateDocument(c.ctx, doc, compiled, opts.validate)
+			r.Warnings = warnings
+			if err != nil {
+				r.Error = err.Error()
+				continue
+			}
+		}
+		if _, err := os.Stat(path); err == nil && !opts.force {
+			r.Error = fmt.Sprintf("file already exists: %s (use --force to overwrite)", path)
+			continue
+		}
+		r.Output = path
+		c.log.Debug("generated document", "row", row.Location, "file", path)
+		writes = append(writes, func() error {
+			out, err := doc.Marshal()
+			if err != nil {
+				return err
+			}
+			return writeFile(path, out, 0644)
+		})
+	}
+	for _, write := range writes {
+		if err := write(); err != nil {
+			return err
+		}
+	}
+	bar.Done()
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+
+	if c.outputFormat == "json" {
+		jsonData, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result: %w", err)
+		}
+		fmt.Println(string(jsonData))
+	} else if !c.quiet {
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.Row, r.Error)))
+			} else {
+				fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ %s: %s", r.Row, r.Output)))
+			}
+			for _, w := range r.Warnings {
+				fmt.Printf("  ! %s\n", w)
+			}
+		}
+		fmt.Printf("\n%s\n", c.tr.N("generate.summary", len(results)-failed, len(results)-failed, failed))
+	}
+
+	if failed > 0 {
+		return withExitCode(ExitValidation, errors.New(c.tr.N("generate.failed", len(results), failed, len(results))))
+	}
+	return nil
+}
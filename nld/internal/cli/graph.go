@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/graph"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addGraphCommand adds the graph command
+func (c *CLI) addGraphCommand() {
+	var format string
+	var outputPath string
+	var force bool
+	var keyfile string
+	var passphraseFile string
+
+	graphCmd := &cobra.Command{
+		Use:   "graph [file]",
+		Short: "Draw the relationships of an NLD document as a graph",
+		Long: `Write the dependencies, references and conditions of an NLD document as a
+Graphviz DOT or Mermaid graph, to visualize how sections depend on each
+other in docs and reviews.
+
+Sections are labelled with their number and title. Conditions are drawn as
+diamonds, linked from the sections and conditions named in their predicate
+and to the ones named in their effect. Dependencies are solid edges,
+references dashed and condition links dotted; relationship endpoints that
+are not in the document are drawn dashed.
+
+The graph is written to standard output unless --output is given. With
+--output-format json the nodes and edges are written as JSON instead.`,
+		Example: `  nld graph contract.json | dot -Tsvg > contract.svg
+  nld graph contract.json --format mermaid -o contract.mmd`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			return c.runGraph(args[0], outputPath, format, force, key)
+		},
+	}
+
+	graphCmd.Flags().StringVar(&format, "format", graph.DOT, "Graph format ("+strings.Join(graph.Formats(), ", ")+")")
+	graphCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: standard output)")
+	graphCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+	graphCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	graphCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(graphCmd)
+}
+
+// runGraph runs the graph command
+func (c *CLI) runGraph(inputPath, outputPath, format string, force bool, key *envelope.Key) error {
+	if outputPath != "" {
+		if exists(outputPath) && !force {
+			return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+		}
+	}
+
+	doc, err := c.parseDocument(inputPath, key)
+	if err != nil {
+		return err
+	}
+	g := graph.Build(doc)
+
+	var buf bytes.Buffer
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		buf.Write(jsonResult)
+		buf.WriteString("\n")
+	} else if err := graph.Write(&buf, g, format); err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := c.writeOutput(outputPath, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Wrote graph of %s: %s", inputPath, outputPath)))
+	}
+	return nil
+}
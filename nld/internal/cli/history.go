@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/colemalphrus/nld/internal/diff"
+	"github.com/colemalphrus/nld/internal/history"
+	"github.com/spf13/cobra"
+)
+
+// addHistoryCommand adds the history command
+func (c *CLI) addHistoryCommand() {
+	var limit int
+
+	historyCmd := &cobra.Command{
+		Use:   "history [file]",
+		Short: "Show the structural change log of a document in git",
+		Long: `Walk the git commits that touched a document and print a structural
+change log: metadata fields, sections, entities and definitions that were
+added, removed, modified or moved in each commit.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runHistory(args[0], limit)
+		},
+	}
+
+	historyCmd.Flags().IntVarP(&limit, "limit", "n", 0, "Maximum number of commits to examine")
+
+	c.rootCmd.AddCommand(historyCmd)
+}
+
+// runHistory runs the history command
+func (c *CLI) runHistory(filePath string, limit int) error {
+	entries, err := history.Log(filePath, history.Options{Limit: limit})
+	if err != nil {
+		return err
+	}
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+		return nil
+	}
+
+	if c.quiet {
+		return nil
+	}
+
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s %s %s\n", entry.Commit[:min(7, len(entry.Commit))], entry.Date.Format("2006-01-02"), entry.Subject)
+		if c.verbose {
+			fmt.Printf("  Author: %s\n", entry.Author)
+		}
+		if entry.Error != "" {
+			fmt.Printf("  ! document could not be parsed: %s\n", entry.Error)
+			continue
+		}
+		if len(entry.Changes) == 0 {
+			fmt.Println("  (no structural changes)")
+			continue
+		}
+		for _, change := range entry.Changes {
+			fmt.Printf("  %s %s\n", changeMarker(change.Kind), change)
+		}
+	}
+	return nil
+}
+
+// changeMarker returns a diff-style marker for a change kind
+func changeMarker(kind diff.Kind) string {
+	switch kind {
+	case diff.Added:
+		return "+"
+	case diff.Removed:
+		return "-"
+	case diff.Moved:
+		return ">"
+	default:
+		return "~"
+	}
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/hooks"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addHooksCommand adds the hooks command and its subcommands
+func (c *CLI) addHooksCommand() {
+	hooksCmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Manage the git pre-commit hook that checks NLD documents",
+		Long:  "Install or uninstall a git pre-commit hook that checks staged NLD documents",
+	}
+
+	var opts hooks.Options
+	var dir string
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Install the pre-commit hook",
+		Long: `Install a git pre-commit hook that runs nld on the staged version of every
+document matching --pattern, so broken documents never reach the repository.
+The commit is aborted if any check fails.
+
+Checks: ` + strings.Join(hooks.Checks(), ", ") + `. Select them with --checks, or
+change them later without reinstalling:
+
+  git config nld.hooks.checks "validate lint"
+
+The hook runs the nld found on PATH, or the one named by the NLD environment
+variable. An existing pre-commit hook is only replaced with --force.`,
+		Example: `  nld hooks install
+  nld hooks install --checks fmt,validate,lint --pattern '*.json'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := hooks.Install(dir, opts)
+			if err != nil {
+				return err
+			}
+			if !c.quiet {
+				fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Installed pre-commit hook: %s", path)))
+			}
+			return nil
+		},
+	}
+	installCmd.Flags().StringSliceVar(&opts.Checks, "checks", hooks.DefaultChecks(), "Checks to run ("+strings.Join(hooks.Checks(), ", ")+")")
+	installCmd.Flags().StringVar(&opts.Pattern, "pattern", hooks.DefaultPattern, "Pathspec of the documents to check")
+	installCmd.Flags().BoolVar(&opts.Force, "force", false, "Replace an existing pre-commit hook")
+	installCmd.Flags().StringVarP(&dir, "dir", "C", ".", "Directory inside the git repository")
+
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the pre-commit hook",
+		Long:  "Remove the pre-commit hook installed by nld hooks install. Hooks installed by other tools are left alone.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := hooks.Uninstall(dir, "")
+			if err != nil {
+				return err
+			}
+			if !c.quiet {
+				fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Removed pre-commit hook: %s", path)))
+			}
+			return nil
+		},
+	}
+	uninstallCmd.Flags().StringVarP(&dir, "dir", "C", ".", "Directory inside the git repository")
+
+	hooksCmd.AddCommand(installCmd, uninstallCmd)
+	c.rootCmd.AddCommand(hooksCmd)
+}
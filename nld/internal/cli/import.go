@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/classify"
+	"github.com/colemalphrus/nld/internal/importer"
+	"github.com/colemalphrus/nld/internal/progress"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// importResult is the JSON output of the import command
+type importResult struct {
+	File     string             `json:"file"`
+	Output   string             `json:"output"`
+	Warnings []importer.Warning `json:"warnings"`
+	// Classified is the type guessed for sources that give none
+	Classified *classify.Suggestion `json:"classified,omitempty"`
+}
+
+// addImportCommand adds the import command
+func (c *CLI) addImportCommand() {
+	var opts importer.Options
+	var format string
+	var outputPath string
+	var force bool
+	var columns []string
+	var taxes []string
+
+	importCmd := &cobra.Command{
+		Use:   "import [file or URL]",
+		Short: "Convert a document from another format into NLD",
+		Long: `Convert a document written in another format into an NLD document.
+
+Markdown documents (.md, .markdown) are imported as follows:
+  - YAML front matter becomes metadata (title, author, type, effective, ...)
+  - headings become sections, nested by heading level, with any section
+    numbers removed; a single leading level 1 heading is the title
+  - paragraphs, lists and pipe tables become section content
+  - definition lists ("Term" followed by ": definition" lines) and a
+    "Definitions" section become definitions
+  - a "Parties" section of "**Name** (Role)" items becomes entities
+
+Word documents (.docx) are imported as follows:
+  - paragraphs in Word's heading styles (or styles based on them) become
+    sections, nested by heading level; the Title paragraph is the title
+  - other paragraphs, bulleted and numbered lists and tables become
+    section content; columns of numbers become number columns
+  - the metadata embedded by "nld export" is restored, otherwise the title
+    and author come from the document properties
+
+PDF documents (.pdf) are imported on a best-effort basis from their text:
+  - numbered lines such as "1. Payment", "2.1 Fees" or "Article III Term"
+    become section headings, as do short lines set in a larger or bold font
+  - running headers, footers and page numbers are removed
+  - paragraphs of the form "Term" means ... become definitions
+The result is a draft to review: headings found from formatting alone, gaps
+in the numbering, pages without text (scanned images need OCR first) and
+text that did not decode are reported as warnings.
+
+Spreadsheets (.csv, .tsv, .xlsx) are imported as receipts, one line item
+per row:
+  - the first row holds the column headers; Item or Description, Quantity or
+    Qty, Unit Price or Price, and Amount or Total are recognised, and other
+    columns can be mapped with --column (by header or 1-based number)
+  - amounts may carry currency symbols, thousands separators and decimal
+    commas; missing amounts are computed from quantity and unit price
+  - subtotal, tax and total rows are skipped, and the totals are computed
+    from the line items and the taxes given with --tax
+
+HTML pages (.html, .htm, or any http:// or https:// address) are imported
+from their main or article element, or the body when there is neither:
+  - h1 to h6 become sections nested by level; a single leading h1 is the
+    title, otherwise the page title is used
+  - paragraphs, lists, tables and definition lists (dl) become content
+  - navigation, scripts, forms and the page header and footer are left out
+
+Markdown and HTML import reverse "nld render" and Word import reverses
+"nld export". Content that cannot be mapped to NLD, such as images,
+footnotes, tracked changes and merged table cells, is reported so that it
+can be reviewed by hand.
+
+Without --type or a type in the source, documents are imported as the type
+their content suggests, as nld classify reports it, such as an nda for a
+document about confidential information. Documents whose content suggests
+no type are imported as contracts.`,
+		Example: `  nld import contract.md --type contract
+  nld import notes.md -o agreement.json --title "Services Agreement"
+  nld import legacy-lease.docx --type lease --output-format json
+  nld import signed-scan.pdf -o draft.json
+  nld import items.csv --type receipt --currency USD --tax VAT=20
+  nld import export.csv --column item=Product --column amount=4
+  nld import https://example.com/legal/terms --type agreement`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			remote := isURL(args[0])
+			if format == "" {
+				format = importer.FormatOf(args[0])
+				if format == "" && remote {
+					// Web pages are HTML whatever their path
+					format = "html"
+				}
+				if format == "" {
+					return fmt.Errorf("cannot determine the format of %s (use --format: %s)", args[0], strings.Join(importer.Formats(), ", "))
+				}
+			}
+			for _, column := range columns {
+				field, name, ok := strings.Cut(column, "=")
+				if !ok {
+					return fmt.Errorf("invalid --column %q (expected field=column)", column)
+				}
+				if opts.Columns == nil {
+					opts.Columns = map[string]string{}
+				}
+				opts.Columns[field] = name
+			}
+			for _, tax := range taxes {
+				name, rate, ok := strings.Cut(tax, "=")
+				if !ok {
+					return fmt.Errorf("invalid --tax %q (expected name=rate)", tax)
+				}
+				opts.Taxes = append(opts.Taxes, nld.Tax{Name: name, Rate: strings.TrimSuffix(rate, "%")})
+			}
+			if outputPath == "" && remote {
+				name := "index"
+				if u, err := url.Parse(args[0]); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+					name = path.Base(u.Path)
+				}
+				outputPath = strings.TrimSuffix(name, path.Ext(name)) + ".json"
+			} else if outputPath == "" && args[0] == stdio {
+				outputPath = stdio
+			} else if outputPath == "" {
+				outputPath = strings.TrimSuffix(args[0], filepath.Ext(args[0])) + ".json"
+			}
+			return c.runImport(args[0], outputPath, format, opts, force)
+		},
+	}
+
+	importCmd.Flags().StringVar(&opts.Type, "type", "", "Document type (default: from the source, or guessed from the content)")
+	importCmd.Flags().StringVar(&opts.Title, "title", "", "Document title (default: from the source)")
+	importCmd.Flags().StringArrayVar(&columns, "column", nil, "Map a receipt line item field (item, quantity, unitPrice, amount) to a spreadsheet column (field=header or number, repeatable)")
+	importCmd.Flags().StringVar(&opts.Currency, "currency", "", "Currency of imported receipt amounts (default: from the amounts)")
+	importCmd.Flags().StringArrayVar(&taxes, "tax", nil, "Tax charged on an imported receipt's subtotal (name=rate in percent, repeatable)")
+	importCmd.Flags().StringVar(&format, "format", "", "Source format ("+strings.Join(importer.Formats(), ", ")+"; default: based on the file extension)")
+	importCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: <file>.json)")
+	importCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+
+	c.rootCmd.AddCommand(importCmd)
+}
+
+// runImport runs the import command
+func (c *CLI) runImport(inputPath, outputPath, format string, opts importer.Options, force bool) error {
+	if exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+
+	// No spinner is shown while standard input or output, which may be the
+	// terminal, is in use
+	var bar *progress.Bar
+	if inputPath != stdio && outputPath != stdio {
+		bar = c.progress(c.tr.T("progress.import", inputPath), 0)
+		defer bar.Done()
+	}
+	var data []byte
+	var err error
+	if isURL(inputPath) {
+		c.log.Info("fetching import source", "url", inputPath)
+		data, err = fetch(inputPath)
+	} else {
+		data, err = c.readInput(inputPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	result, err := importer.Import(format, data, opts)
+	if err != nil {
+		return fmt.Errorf("failed to import document: %w", err)
+	}
+	if err := c.saveDocument(outputPath, result.Document); err != nil {
+		return err
+	}
+	bar.Done()
+
+	if c.outputFormat == "json" {
+		out := importResult{File: inputPath, Output: outputPath, Warnings: result.Warnings, Classified: result.Classified}
+		if out.Warnings == nil {
+			out.Warnings = []importer.Warning{}
+		}
+		jsonData, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Imported %s: %s", inputPath, outputPath)))
+		if g := result.Classified; g != nil {
+			fmt.Printf("Type %s guessed from the content (%.0f%% confidence); set it with --type\n", g.Type, g.Confidence*100)
+		}
+		if len(result.Warnings) > 0 {
+			fmt.Printf("Content that could not be mapped (%d):\n", len(result.Warnings))
+		}
+		for _, w := range result.Warnings {
+			fmt.Printf("  ! %s: %s\n", w.Location, w.Message)
+		}
+	}
+	return nil
+}
+
+// isURL reports whether an import source is a web address
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// fetch downloads an import source from the web
+func fetch(source string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, withExitCode(ExitIO, fmt.Errorf("%s returned %s", source, resp.Status))
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
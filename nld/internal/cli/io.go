@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/ignore"
+)
+
+// stdio is the file name that stands for standard input, or standard output
+// when given as an output path
+const stdio = "-"
+
+// expandPaths replaces the directories among paths by the JSON documents
+// below them, skipping those ignored by .nldignore files
+func expandPaths(paths []string) ([]string, error) {
+	m, err := ignore.New(".")
+	if err != nil {
+		return nil, err
+	}
+	files, err := m.Expand(paths)
+	if err != nil {
+		return nil, withExitCode(ExitIO, fmt.Errorf("failed to list documents: %w", err))
+	}
+	if len(files) == 0 {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("no documents found in %s", strings.Join(paths, ", ")))
+	}
+	return files, nil
+}
+
+// readInput reads a file, or standard input when path is "-". Standard
+// input is read once and kept, so that it can be read again.
+func (c *CLI) readInput(path string) ([]byte, error) {
+	if path != stdio {
+		return os.ReadFile(path)
+	}
+	if c.stdinData == nil {
+		data, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read standard input: %w", err)
+		}
+		c.stdinData = data
+	}
+	return c.stdinData, nil
+}
+
+// readDocument reads a document from disk or standard input, transparently
+// decrypting it when it is stored in an encrypted envelope
+func (c *CLI) readDocument(path string, key *envelope.Key) ([]byte, error) {
+	data, err := c.readInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !envelope.IsEncrypted(data) {
+		return data, nil
+	}
+
+	c.log.Debug("decrypting document", "file", path)
+	return envelope.Decrypt(data, key)
+}
+
+// loadDocument reads and parses a document
+func (c *CLI) loadDocument(path string, key *envelope.Key) (document.Document, error) {
+	data, err := c.readDocument(path, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	return document.Parse(data)
+}
+
+// loadSealed is loadDocument for commands that write a new document made
+// from the one they read. It also reports whether that document was
+// encrypted, so that what is made from it can be encrypted again with
+// writeDocument.
+func (c *CLI) loadSealed(path string, key *envelope.Key) (document.Document, bool, error) {
+	data, err := c.readInput(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read document: %w", err)
+	}
+	encrypted := envelope.IsEncrypted(data)
+	if encrypted {
+		c.log.Debug("decrypting document", "file", path)
+		if data, err = envelope.Decrypt(data, key); err != nil {
+			return nil, false, fmt.Errorf("failed to read document: %w", err)
+		}
+	}
+	doc, err := document.Parse(data)
+	return doc, encrypted, err
+}
+
+// writeDocument writes a document made from one read with loadSealed.
+// When that one was encrypted the document is encrypted with the same key
+// and made readable by its owner only, so that commands never lower the
+// protection of the documents they read.
+func (c *CLI) writeDocument(path string, doc document.Document, encrypted bool, key *envelope.Key) error {
+	out, err := doc.Marshal()
+	if err != nil {
+		return err
+	}
+	if !encrypted {
+		return c.writeOutput(path, out, 0644)
+	}
+	sealed, err := envelope.Encrypt(out, key)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt document: %w", err)
+	}
+	return c.writeOutput(path, sealed, 0600)
+}
+
+// saveDocument writes doc back to path. Commands that edit documents in
+// place load them without a key, so encrypted documents are never
+// overwritten with plaintext.
+func (c *CLI) saveDocument(path string, doc document.Document) error {
+	out, err := doc.Marshal()
+	if err != nil {
+		return err
+	}
+	c.warnFinalized(path, doc)
+	return c.writeOutput(path, out, 0644)
+}
+
+// writeOutput writes the output of a command to path, or to standard output
+// when path is "-". Status messages are then suppressed so that only the
+// output is written there and it can be piped to another command.
+func (c *CLI) writeOutput(path string, data []byte, perm os.FileMode) error {
+	if path != stdio {
+		return writeFile(path, data, perm)
+	}
+	c.quiet = true
+	if _, err := c.stdout.Write(data); err != nil {
+		return fmt.Errorf("failed to write standard output: %w", err)
+	}
+	return nil
+}
+
+// exists reports whether an output file is already present. Standard
+// output never is.
+func exists(path string) bool {
+	if path == stdio {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// writeFile writes data to path, creating parent directories as needed
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testDocument is a contract with a placeholder, for commands that read and
+// write documents
+const testDocument = `{
+  "metadata": {
+    "type": "contract",
+    "version": "1.0.0",
+    "created": "2025-06-27T12:00:00Z",
+    "title": "Services Agreement",
+    "entities": [
+      {"id": "acme", "name": "Acme Corp", "role": "client"},
+      {"id": "xyz", "name": "XYZ Ltd", "role": "provider"}
+    ]
+  },
+  "content": {
+    "sections": [
+      {"id": "parties", "title": "Parties", "content": "{{client}} and XYZ Ltd"},
+      {"id": "fees", "title": "Fees", "content": "USD 10,000"}
+    ]
+  }
+}`
+
+// run executes the CLI with args and returns what it printed to standard
+// output
+func run(t *testing.T, c *CLI, args ...string) (string, error) {
+	t.Helper()
+	t.Setenv("DO_NOT_TRACK", "1")
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	os.Stdout = w
+	printed := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		printed <- buf.String()
+	}()
+
+	err = c.Execute(args)
+	w.Close()
+	os.Stdout = stdout
+	return <-printed, err
+}
+
+// writeTestFile writes data to name in a temporary directory and returns
+// its path
+func writeTestFile(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestReadInput(t *testing.T) {
+	c := New()
+	c.stdin = strings.NewReader(testDocument)
+	for i := 0; i < 2; i++ {
+		data, err := c.readInput(stdio)
+		if err != nil {
+			t.Fatalf("readInput failed: %v", err)
+		}
+		if string(data) != testDocument {
+			t.Errorf("Read %d returned %q", i+1, data)
+		}
+	}
+
+	path := writeTestFile(t, "contract.json", "{}")
+	if data, err := c.readInput(path); err != nil || string(data) != "{}" {
+		t.Errorf("readInput(%s) = %q, %v", path, data, err)
+	}
+}
+
+func TestWriteOutput(t *testing.T) {
+	c := New()
+	var stdout bytes.Buffer
+	c.stdout = &stdout
+
+	path := filepath.Join(t.TempDir(), "out", "doc.json")
+	if err := c.writeOutput(path, []byte("file"), 0644); err != nil {
+		t.Fatalf("writeOutput failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "file" || stdout.Len() != 0 || c.quiet {
+		t.Errorf("Wrote %q to the file and %q to standard output, quiet %v", data, stdout.String(), c.quiet)
+	}
+
+	if err := c.writeOutput(stdio, []byte("piped"), 0644); err != nil {
+		t.Fatalf("writeOutput failed: %v", err)
+	}
+	if stdout.String() != "piped" || !c.quiet {
+		t.Errorf("Wrote %q to standard output, quiet %v", stdout.String(), c.quiet)
+	}
+}
+
+func TestPipes(t *testing.T) {
+	t.Run("Standard Input and Output", func(t *testing.T) {
+		c := New()
+		var stdout bytes.Buffer
+		c.stdin = strings.NewReader(testDocument)
+		c.stdout = &stdout
+
+		printed, err := run(t, c, "fill", stdio, "--set", "client=Acme Corp")
+		if err != nil {
+			t.Fatalf("fill failed: %v", err)
+		}
+		if !strings.Contains(stdout.String(), `"content": "Acme Corp and XYZ Ltd"`) {
+			t.Errorf("Expected the filled document on standard output, got %s", stdout.String())
+		}
+		if printed != "" {
+			t.Errorf("Expected no status line, got %q", printed)
+		}
+	})
+
+	t.Run("File", func(t *testing.T) {
+		c := New()
+		var stdout bytes.Buffer
+		c.stdout = &stdout
+		path := writeTestFile(t, "contract.json", testDocument)
+
+		printed, err := run(t, c, "fill", path, "--set", "client=Acme Corp")
+		if err != nil {
+			t.Fatalf("fill failed: %v", err)
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("Expected nothing on standard output, got %s", stdout.String())
+		}
+		if !strings.Contains(printed, "Filled "+path) {
+			t.Errorf("Expected a status line, got %q", printed)
+		}
+		if _, err := os.Stat(strings.TrimSuffix(path, ".json") + ".filled.json"); err != nil {
+			t.Errorf("Expected the filled document next to the input: %v", err)
+		}
+	})
+
+	t.Run("Standard Output", func(t *testing.T) {
+		c := New()
+		var stdout bytes.Buffer
+		c.stdout = &stdout
+		path := writeTestFile(t, "contract.json", testDocument)
+
+		printed, err := run(t, c, "fill", path, "--set", "client=Acme Corp", "-o", stdio)
+		if err != nil {
+			t.Fatalf("fill failed: %v", err)
+		}
+		if !strings.HasPrefix(stdout.String(), "{") || printed != "" {
+			t.Errorf("Expected only the document on standard output, got %q and %q", stdout.String(), printed)
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/signing"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addKeysCommand adds the keys command and its subcommands
+func (c *CLI) addKeysCommand() {
+	var dir string
+
+	keysCmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage the signing keys of the local keyring",
+		Long: `Generate, import and export the private keys documents are signed with. The
+keyring is the directory given with --keyring, $` + signing.KeyringEnv + ` or ~/.nld/keys.
+
+Keys are encrypted as nld encrypt does, with the passphrase of
+--passphrase-file or $` + envelope.PassphraseEnv + `. Sign with a key of the keyring with
+nld sign --keyring-key, or with a signing config:
+
+  provider: keyring
+  key: party1
+  passphraseFile: ~/.nld/passphrase`,
+	}
+	keysCmd.PersistentFlags().StringVar(&dir, "keyring", "", "Keyring directory (default: $"+signing.KeyringEnv+" or ~/.nld/keys)")
+
+	var algorithm string
+	var passphraseFile string
+	var noPassphrase bool
+	generateCmd := &cobra.Command{
+		Use:   "generate [name]",
+		Short: "Generate a key in the keyring",
+		Long: `Generate a private key for an algorithm and add it to the keyring under a
+name: ES256 (P-256, the default), ES384 (P-384), RS256 (RSA 3072) or EdDSA
+(Ed25519).`,
+		Example: `  nld keys generate party1 --passphrase-file passphrase.txt
+  nld keys generate legal --algorithm EdDSA`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := keyringPassphrase(passphraseFile, noPassphrase)
+			if err != nil {
+				return err
+			}
+			return c.runKeysAdd(dir, func(r *signing.Keyring) (*signing.KeyEntry, error) {
+				return r.Generate(args[0], algorithm, key)
+			})
+		},
+	}
+	generateCmd.Flags().StringVar(&algorithm, "algorithm", signing.ES256, "Signature algorithm ("+strings.Join([]string{signing.ES256, signing.ES384, signing.RS256, signing.EdDSA}, ", ")+")")
+	generateCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase the key is encrypted with")
+	generateCmd.Flags().BoolVar(&noPassphrase, "no-passphrase", false, "Store the key unencrypted")
+
+	importCmd := &cobra.Command{
+		Use:   "import [name] [file]",
+		Short: "Import a PEM private key into the keyring",
+		Long: `Add a PEM private key in PKCS#8, SEC 1 or PKCS#1 form to the keyring under a
+name. A key encrypted with nld encrypt is decrypted with the passphrase it
+is then stored with.`,
+		Example: `  nld keys import party1 party1.pem --passphrase-file passphrase.txt`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := keyringPassphrase(passphraseFile, noPassphrase)
+			if err != nil {
+				return err
+			}
+			data, err := c.readInput(args[1])
+			if err != nil {
+				return fmt.Errorf("failed to read key: %w", err)
+			}
+			return c.runKeysAdd(dir, func(r *signing.Keyring) (*signing.KeyEntry, error) {
+				return r.Import(args[0], data, key)
+			})
+		},
+	}
+	importCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase the key is encrypted with")
+	importCmd.Flags().BoolVar(&noPassphrase, "no-passphrase", false, "Store the key unencrypted")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the keys of the keyring",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runKeysList(dir)
+		},
+	}
+
+	var private bool
+	var outputPath string
+	exportCmd := &cobra.Command{
+		Use:               "export [name]",
+		ValidArgsFunction: completeKeyringKeys,
+		Short:             "Export the public key of a key",
+		Long: `Write the PEM public key of a key of the keyring, for those who verify its
+signatures. With --private, the private key is written instead, still
+encrypted when it is protected by a passphrase.`,
+		Example: `  nld keys export party1 > party1.pub.pem
+  nld keys export party1 --private --output party1.key.pem`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runKeysExport(dir, args[0], private, outputPath)
+		},
+	}
+	exportCmd.Flags().BoolVar(&private, "private", false, "Export the private key instead of the public key")
+	exportCmd.Flags().StringVarP(&outputPath, "output", "o", stdio, "Output file path (default: standard output)")
+
+	var reason string
+	revokeCmd := &cobra.Command{
+		Use:               "revoke [name]",
+		ValidArgsFunction: completeKeyringKeys,
+		Short:             "Revoke a key so that it no longer signs",
+		Long: `Mark a key of the keyring as revoked. Revoked keys no longer sign, but stay
+in the keyring so that their public key can still be exported.`,
+		Example: `  nld keys revoke party1 --reason "key compromise"`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runKeysRevoke(dir, args[0], reason)
+		},
+	}
+	revokeCmd.Flags().StringVar(&reason, "reason", "", "Reason the key is revoked")
+
+	keysCmd.AddCommand(generateCmd, importCmd, listCmd, exportCmd, revokeCmd)
+	c.rootCmd.AddCommand(keysCmd)
+}
+
+// keyringPassphrase returns the key new keyring keys are encrypted with.
+// Keys are only stored unencrypted when asked to.
+func keyringPassphrase(passphraseFile string, noPassphrase bool) (*envelope.Key, error) {
+	if noPassphrase {
+		if passphraseFile != "" {
+			return nil, withExitCode(ExitUsage, fmt.Errorf("--passphrase-file and --no-passphrase cannot be used together"))
+		}
+		return nil, nil
+	}
+	key, err := envelope.LoadKey("", passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, withExitCode(ExitUsage, fmt.Errorf("no passphrase to encrypt the key with: give --passphrase-file, set %s or pass --no-passphrase", envelope.PassphraseEnv))
+	}
+	return key, nil
+}
+
+// runKeysAdd adds a key to the keyring of dir with add
+func (c *CLI) runKeysAdd(dir string, add func(r *signing.Keyring) (*signing.KeyEntry, error)) error {
+	r, err := signing.OpenKeyring(dir)
+	if err != nil {
+		return err
+	}
+	e, err := add(r)
+	if err != nil {
+		return err
+	}
+	if c.outputFormat == "json" {
+		return printJSON(e)
+	}
+	if !c.quiet {
+		protection := "encrypted"
+		if !e.Encrypted {
+			protection = "unencrypted"
+		}
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Added %s key %s (%s) to %s", e.Algorithm, e.Name, protection, r.Dir)))
+		fmt.Printf("  Key ID: %s\n", e.KeyID)
+	}
+	return nil
+}
+
+// runKeysList lists the keys of the keyring of dir
+func (c *CLI) runKeysList(dir string) error {
+	r, err := signing.OpenKeyring(dir)
+	if err != nil {
+		return err
+	}
+	entries, err := r.List()
+	if err != nil {
+		return err
+	}
+	if c.outputFormat == "json" {
+		if entries == nil {
+			entries = []*signing.KeyEntry{}
+		}
+		return printJSON(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No keys in %s\n", r.Dir)
+		return nil
+	}
+	for _, e := range entries {
+		status := "active"
+		if e.Revoked != nil {
+			status = "revoked " + e.Revoked.Format(time.DateOnly)
+		}
+		id := strings.TrimPrefix(e.KeyID, digest.Prefix)
+		fmt.Printf("  %-16s %-6s %s  %s  %s\n", e.Name, e.Algorithm, id[:min(16, len(id))], e.Created.Format(time.DateOnly), status)
+	}
+	return nil
+}
+
+// runKeysExport writes the public or private key of a key of the keyring
+func (c *CLI) runKeysExport(dir, name string, private bool, outputPath string) error {
+	r, err := signing.OpenKeyring(dir)
+	if err != nil {
+		return err
+	}
+	e, err := r.Get(name)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	perm := os.FileMode(0644)
+	if private {
+		data, err = r.PrivateKey(name)
+		perm = 0600
+	} else {
+		data, err = e.PublicKeyPEM()
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.writeOutput(outputPath, data, perm); err != nil {
+		return err
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Exported key %s to %s", name, outputPath)))
+	}
+	return nil
+}
+
+// runKeysRevoke revokes a key of the keyring
+func (c *CLI) runKeysRevoke(dir, name, reason string) error {
+	r, err := signing.OpenKeyring(dir)
+	if err != nil {
+		return err
+	}
+	e, err := r.Revoke(name, reason)
+	if err != nil {
+		return err
+	}
+	if c.outputFormat == "json" {
+		return printJSON(e)
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Revoked key %s", name)))
+	}
+	return nil
+}
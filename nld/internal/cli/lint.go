@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/lint"
+	"github.com/colemalphrus/nld/internal/pii"
+	"github.com/colemalphrus/nld/internal/report"
+	"github.com/spf13/cobra"
+)
+
+// addLintCommand adds the lint command
+func (c *CLI) addLintCommand() {
+	var opts lint.Options
+	var listRules bool
+	var strict bool
+	var keyfile string
+	var passphraseFile string
+
+	lintCmd := &cobra.Command{
+		Use:   "lint [file|dir...]",
+		Short: "Check NLD documents for ambiguous or inconsistent content",
+		Long: `Check NLD documents for problems that schema validation does not catch,
+such as dates that can be read as day/month or month/day, or sections
+whose prose is too complex for their readers.
+
+Rules with IDs starting NLD1 report errors and make the command fail; NLD2
+rules report warnings, which only fail the command with --strict. Use
+--list-rules to see every rule.
+
+The complex-section rule (NLD2003) reports sections above a Flesch-Kincaid
+grade level or average sentence length. For consumer-facing agreements,
+lower the thresholds with --max-grade and --max-sentence-length; plain
+English is around grade 8 with sentences of 15 to 20 words.
+
+The pii-in-template rule (NLD2007) reports personal data left in the
+sections of templates: documents with placeholders, such as {{ client.name }},
+or of the types of --pii-types. It looks for the kinds of --pii-kinds, by
+default email addresses, phone numbers, national ID numbers and IBANs.
+Addresses at domains reserved for examples, such as example.com, are not
+reported. With --output-format sarif the findings can be uploaded to code
+scanning like any other.
+
+A finding is suppressed by an x-nld-ignore list on an object containing
+it, such as its section, with an entry naming the rule and the reason it
+does not apply: ["NLD2003: wording prescribed by the regulator"].
+
+Directories are searched for .json files, skipping those listed in
+.nldignore files.`,
+		Example: `  nld lint contract.json
+  nld lint --disable NLD2001 contract.json receipt.json
+  nld lint --only complex-section --max-grade 8 terms-of-service.json
+  nld lint --only pii-in-template --pii-kinds email,iban --output-format sarif templates/`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if listRules {
+				return c.runLintRules()
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("no files specified")
+			}
+			for _, kind := range opts.PIIKinds {
+				if !pii.ValidKind(kind) {
+					return withExitCode(ExitUsage, fmt.Errorf("unknown PII kind: %s (expected one of: %s)", kind, strings.Join(pii.Kinds(), ", ")))
+				}
+			}
+			files, err := expandPaths(args)
+			if err != nil {
+				return err
+			}
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			return c.runLint(files, opts, strict, key)
+		},
+	}
+
+	lintCmd.Flags().StringSliceVar(&opts.Disable, "disable", nil, "Rules to skip, by ID or name")
+	lintCmd.Flags().StringSliceVar(&opts.Only, "only", nil, "Run only these rules, by ID or name")
+	lintCmd.Flags().BoolVar(&listRules, "list-rules", false, "List the available rules")
+	lintCmd.Flags().BoolVar(&strict, "strict", false, "Fail on warnings as well as errors")
+	lintCmd.Flags().Float64Var(&opts.MaxGradeLevel, "max-grade", lint.DefaultMaxGradeLevel, "Flesch-Kincaid grade level above which a section is reported")
+	lintCmd.Flags().Float64Var(&opts.MaxSentenceLength, "max-sentence-length", lint.DefaultMaxSentenceLength, "Average sentence length, in words, above which a section is reported")
+	lintCmd.Flags().StringSliceVar(&opts.PIIKinds, "pii-kinds", lint.DefaultPIIKinds, "Kinds of personal data the pii-in-template rule looks for: "+strings.Join(pii.Kinds(), ", "))
+	lintCmd.Flags().StringSliceVar(&opts.PIITypes, "pii-types", lint.DefaultPIITypes, "Document types the pii-in-template rule checks, besides documents with placeholders")
+	lintCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	lintCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(lintCmd)
+}
+
+// runLint runs the lint command
+func (c *CLI) runLint(filePaths []string, opts lint.Options, strict bool, key *envelope.Key) error {
+	rep, err := c.reporter("lint", os.Stdout)
+	if err != nil {
+		return err
+	}
+	failed := false
+	for _, path := range filePaths {
+		result := &report.Lint{File: path, Findings: []lint.Finding{}, Strict: strict}
+		data, err := c.readDocument(path, key)
+		if err == nil {
+			result.Locate = c.locator(path, data)
+			var findings []lint.Finding
+			if findings, err = lint.Lint(data, opts); err == nil && findings != nil {
+				result.Findings = findings
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		failed = failed || result.Failed()
+		if err := rep.Lint(result); err != nil {
+			return err
+		}
+	}
+	if err := rep.Close(); err != nil {
+		return err
+	}
+
+	if failed {
+		return withExitCode(ExitValidation, fmt.Errorf("lint failed"))
+	}
+	return nil
+}
+
+// runLintRules prints the available lint rules
+func (c *CLI) runLintRules() error {
+	type ruleInfo struct {
+		ID          string        `json:"id"`
+		Name        string        `json:"name"`
+		Severity    lint.Severity `json:"severity"`
+		Description string        `json:"description"`
+	}
+	var list []ruleInfo
+	for _, r := range lint.Rules() {
+		list = append(list, ruleInfo{ID: r.ID, Name: r.Name, Severity: r.Severity, Description: r.Description})
+	}
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+		return nil
+	}
+
+	for _, r := range list {
+		fmt.Printf("%s\t%s\t%s\t%s\n", r.ID, r.Severity, r.Name, r.Description)
+	}
+	return nil
+}
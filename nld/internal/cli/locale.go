@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// checkLocales adds sections that are not translated into every required
+// locale to result
+func checkLocales(docBytes []byte, required []string, result *validator.ValidationResult) {
+	if len(required) == 0 {
+		return
+	}
+	doc, err := nld.Parse(docBytes)
+	if err != nil {
+		return
+	}
+
+	for _, e := range doc.CheckLocales(required) {
+		result.Errors = append(result.Errors, validator.ValidationError{Field: e.Path, Message: e.Message, Rule: validator.RuleLocales})
+		result.Valid = false
+	}
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/obligation"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addObligationsCommand adds the obligations command
+func (c *CLI) addObligationsCommand() {
+	var format string
+	var outputPath string
+	var force bool
+	var keyfile string
+	var passphraseFile string
+
+	obligationsCmd := &cobra.Command{
+		Use:   "obligations [file]",
+		Short: "List or export the obligations of a document",
+		Long: `List who must do what by when, from the items and conditions of a document
+whose type is "obligation":
+
+  "items": [{"id": "deliver", "type": "obligation", "value": {
+    "owner": "party1", "action": "Deliver the software",
+    "due": "2025-09-01", "section": "delivery"}}]
+
+  "conditions": [{"id": "return", "type": "obligation", "owner": "party2",
+    "predicate": "the agreement terminates",
+    "effect": "Return all Confidential Information", "due": "P30D"}]
+
+The owner is the ID of an entity. Due is a date, a datetime or an ISO 8601
+duration from metadata.effective, or from when the condition holds for
+conditions.
+
+With --format, the obligations are exported instead: ics writes a calendar
+with an event on each deadline, for calendar and task tools, and csv a
+spreadsheet of every obligation.
+
+The command fails when an obligation has no action, no owner entity or no
+valid due date, which nld lint also reports as obligation-incomplete
+(NLD1003).`,
+		Example: `  nld obligations contract.json
+  nld obligations contract.json --format ics -o deadlines.ics
+  nld obligations contract.json --format csv > obligations.csv`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			return c.runObligations(args[0], format, outputPath, force, key)
+		},
+	}
+
+	obligationsCmd.Flags().StringVar(&format, "format", "", "Export format ("+strings.Join(obligation.Formats(), ", ")+")")
+	obligationsCmd.Flags().StringVarP(&outputPath, "output", "o", stdio, "Output file path of the export")
+	obligationsCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+	obligationsCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	obligationsCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(obligationsCmd)
+}
+
+// runObligations runs the obligations command
+func (c *CLI) runObligations(filePath, format, outputPath string, force bool, key *envelope.Key) error {
+	if format != "" && exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+	doc, err := c.parseDocument(filePath, key)
+	if err != nil {
+		return err
+	}
+	obligations, problems := obligation.Extract(doc)
+
+	switch {
+	case format != "":
+		hash, err := doc.Hash()
+		if err != nil {
+			return fmt.Errorf("failed to hash document: %w", err)
+		}
+		calendar := strings.TrimPrefix(hash, "sha256:")
+		var buf bytes.Buffer
+		if err := obligation.Write(&buf, format, calendar[:min(16, len(calendar))], obligations); err != nil {
+			return err
+		}
+		if err := c.writeOutput(outputPath, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		if !c.quiet {
+			fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Exported %d obligation(s) to %s", len(obligations), outputPath)))
+		}
+	case c.outputFormat == "json":
+		jsonResult, err := json.MarshalIndent(struct {
+			Obligations []obligation.Obligation `json:"obligations"`
+			Problems    []obligation.Problem    `json:"problems,omitempty"`
+		}{obligations, problems}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+	case !c.quiet:
+		printObligations(filePath, obligations)
+	}
+
+	if len(problems) > 0 {
+		if c.outputFormat != "json" {
+			for _, p := range problems {
+				fmt.Fprintln(os.Stderr, validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", p.Path, p.Message)))
+			}
+		}
+		return withExitCode(ExitValidation, fmt.Errorf("%d obligation problem(s) in %s", len(problems), filePath))
+	}
+	return nil
+}
+
+// printObligations prints the obligations of a document as text
+func printObligations(filePath string, obligations []obligation.Obligation) {
+	fmt.Printf("%s: %d obligation(s)\n", filePath, len(obligations))
+	for _, o := range obligations {
+		due := o.Due
+		if o.Deadline != nil {
+			due = o.Deadline.Format(time.RFC3339)
+			if o.AllDay {
+				due = o.Deadline.Format(nld.DateLayout)
+			}
+		}
+		owner := o.OwnerName
+		if owner == "" {
+			owner = o.Owner
+		}
+		fmt.Printf("  %-12s %-20s %s\n", due, owner, o.Action)
+		if o.Condition != "" {
+			fmt.Printf("  %-12s %-20s when %s\n", "", "", o.Condition)
+		}
+	}
+}
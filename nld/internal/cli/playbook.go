@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/colemalphrus/nld/internal/colors"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/playbook"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addPlaybookCommand adds the playbook command
+func (c *CLI) addPlaybookCommand() {
+	playbookCmd := &cobra.Command{
+		Use:   "playbook",
+		Short: "Check documents against a negotiation playbook",
+		Long: `A playbook gives, for each clause, the approved language, the fallbacks
+that may be accepted without review, and who reviews anything else. It is
+a YAML or JSON file:
+
+  name: Sales
+  escalation:
+    to: legal@example.com
+  unlisted: ignore
+  clauses:
+    - id: payment
+      required: true
+      approved:
+        - Invoices are payable within {{ days }} days of receipt.
+      fallbacks:
+        - Invoices are payable within 60 days.
+      escalation:
+        to: finance@example.com
+        note: Longer terms need the approval of the CFO.
+
+Clauses are matched to sections by ID. Placeholders such as {{ days }}
+match any text, and differences in white space are ignored. The
+escalation of the playbook applies to clauses without their own;
+unlisted: escalate also escalates sections the playbook has no clause for.`,
+	}
+
+	var playbookPath string
+	var keyfile string
+	var passphraseFile string
+	checkCmd := &cobra.Command{
+		Use:   "check [file...]",
+		Short: "Classify the clauses of documents against a playbook",
+		Long: `Classify every section of documents as approved, fallback, deviation or
+unlisted, and report the required clauses they lack as missing.
+Deviations and missing clauses need escalation, as do unlisted sections
+when the playbook says so; the command fails when any clause does.`,
+		Example: `  nld playbook check acme-msa.json --playbook sales.yaml
+  nld playbook check --output-format json --playbook sales.yaml contracts/*.json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := playbook.Load(playbookPath)
+			if err != nil {
+				return err
+			}
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			return c.runPlaybookCheck(args, p, key)
+		},
+	}
+	checkCmd.Flags().StringVarP(&playbookPath, "playbook", "p", "", "Playbook file (YAML or JSON)")
+	checkCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	checkCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+	checkCmd.MarkFlagRequired("playbook")
+
+	playbookCmd.AddCommand(checkCmd)
+	c.rootCmd.AddCommand(playbookCmd)
+}
+
+// playbookResult is the playbook check of one file
+type playbookResult struct {
+	File        string            `json:"file"`
+	Results     []playbook.Result `json:"results,omitempty"`
+	Escalations int               `json:"escalations"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// runPlaybookCheck runs the playbook check command
+func (c *CLI) runPlaybookCheck(filePaths []string, p *playbook.Playbook, key *envelope.Key) error {
+	var results []playbookResult
+	var lastErr error
+	failed, escalations := 0, 0
+	for _, path := range filePaths {
+		result := playbookResult{File: path}
+		doc, err := c.parseDocument(path, key)
+		if err == nil {
+			result.Results = p.Check(doc)
+			result.Escalations = playbook.Escalations(result.Results)
+			escalations += result.Escalations
+		} else {
+			result.Error = err.Error()
+			lastErr = err
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+	} else {
+		for i, r := range results {
+			if r.Error != "" {
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.File, r.Error)))
+				continue
+			}
+			if c.quiet && r.Escalations == 0 {
+				continue
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			c.printPlaybook(r, p)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to read %d of %d document(s): %w", failed, len(filePaths), lastErr)
+	}
+	if escalations > 0 {
+		return withExitCode(ExitValidation, fmt.Errorf("%d clause(s) need escalation", escalations))
+	}
+	return nil
+}
+
+// printPlaybook prints the playbook check of a document as text
+func (c *CLI) printPlaybook(r playbookResult, p *playbook.Playbook) {
+	name := p.Name
+	if name == "" {
+		name = p.Path
+	}
+	fmt.Printf("%s: checked against the %s playbook\n", r.File, name)
+	for _, res := range r.Results {
+		line := fmt.Sprintf("%-24s %s", res.ID, res.Status)
+		if res.Fallback > 0 {
+			line += fmt.Sprintf(" %d", res.Fallback)
+		}
+		switch {
+		case res.Escalate:
+			if e := res.Escalation; e != nil && e.To != "" {
+				line += ", escalate to " + e.To
+			} else {
+				line += ", escalate"
+			}
+			if e := res.Escalation; e != nil && e.Note != "" {
+				line += ": " + e.Note
+			}
+			fmt.Println(validator.ColoredOutput(false, "  ✗ "+line))
+		case c.quiet:
+		case res.Status == playbook.Approved:
+			fmt.Println(validator.ColoredOutput(true, "  ✓ "+line))
+		case res.Status == playbook.Fallback:
+			fmt.Println(colors.Warning("  ~ " + line))
+		default:
+			fmt.Println("    " + line)
+		}
+	}
+	if r.Escalations == 0 {
+		fmt.Println(validator.ColoredOutput(true, "  ✓ no clause needs escalation"))
+	}
+}
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/plugins"
+	"github.com/colemalphrus/nld/pkg/plugin"
+	"github.com/spf13/cobra"
+)
+
+// pluginFlags are the global flags passed to plugin executables in the
+// environment
+var pluginFlags = []string{"verbose", "quiet", "output-format", "lang", "log-level", "log-format", "log-file"}
+
+// pluginInfo describes a plugin for nld plugin list
+type pluginInfo struct {
+	Name string `json:"name"`
+	// Path is the executable, empty for compiled-in commands
+	Path     string   `json:"path,omitempty"`
+	Shadowed []string `json:"shadowed,omitempty"`
+	// Conflict is the command that hides the plugin, if any
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// addPluginCommand adds the plugin command and its subcommands
+func (c *CLI) addPluginCommand() {
+	pluginCmd := &cobra.Command{
+		Use:   "plugin",
+		Short: "Manage commands added by plugins",
+		Long: `Plugins add commands to nld. A plugin is an executable on PATH named
+nld-<name>, such as nld-crm-sync, which runs as nld crm-sync, or a command
+compiled into nld with the Go package github.com/colemalphrus/nld/pkg/plugin.
+
+Plugin executables get their arguments after the command name unparsed,
+except the global flags of nld, which are passed in environment variables
+such as NLD_OUTPUT_FORMAT and NLD_QUIET. NLD_BINARY holds the path of nld so
+the plugin can call it back. Built-in commands cannot be replaced by
+plugins.`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the plugins found on PATH and compiled into nld",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runPluginList()
+		},
+	}
+
+	pluginCmd.AddCommand(listCmd)
+	c.rootCmd.AddCommand(pluginCmd)
+}
+
+// findPlugins returns the compiled-in commands and the plugin executables
+// on PATH, with the commands that hide them
+func (c *CLI) findPlugins() []pluginInfo {
+	builtin := map[string]bool{"help": true}
+	for _, cmd := range c.rootCmd.Commands() {
+		if cmd.Annotations["plugin"] == "" {
+			builtin[cmd.Name()] = true
+		}
+	}
+
+	var list []pluginInfo
+	owner := map[string]string{}
+	claim := func(info pluginInfo, source string) {
+		switch {
+		case builtin[info.Name]:
+			info.Conflict = "built-in command " + info.Name
+		case owner[info.Name] != "":
+			info.Conflict = owner[info.Name]
+		default:
+			owner[info.Name] = source
+		}
+		list = append(list, info)
+	}
+	for _, cmd := range plugin.Commands() {
+		claim(pluginInfo{Name: cmd.Name}, "compiled-in command "+cmd.Name)
+	}
+	for _, p := range plugins.Find(os.Getenv("PATH")) {
+		claim(pluginInfo{Name: p.Name, Path: p.Path, Shadowed: p.Shadowed}, p.Path)
+	}
+	return list
+}
+
+// addPluginCommands adds a command for every compiled-in command and every
+// plugin executable on PATH. Built-in commands take precedence over both,
+// and compiled-in commands over executables.
+func (c *CLI) addPluginCommands() {
+	compiled := map[string]plugin.Command{}
+	for _, cmd := range plugin.Commands() {
+		compiled[cmd.Name] = cmd
+	}
+	executables := map[string]plugins.Plugin{}
+	for _, p := range plugins.Find(os.Getenv("PATH")) {
+		executables[p.Name] = p
+	}
+
+	for _, info := range c.findPlugins() {
+		if info.Conflict != "" {
+			continue
+		}
+		var cmd *cobra.Command
+		if info.Path == "" {
+			p := compiled[info.Name]
+			cmd = c.pluginCommand(p.Name, p.Short, p.Long, p.Example, func(args []string) error {
+				return p.Run(c.pluginContext(), args)
+			})
+		} else {
+			p := executables[info.Name]
+			cmd = c.pluginCommand(p.Name, "Run the "+plugins.Prefix+p.Name+" plugin", "Run the plugin executable "+p.Path+".", "", func(args []string) error {
+				return c.runPlugin(p, args)
+			})
+		}
+		c.rootCmd.AddCommand(cmd)
+	}
+}
+
+// pluginCommand returns the command running a plugin. Its flags are left
+// for the plugin to parse, apart from the global flags of nld.
+func (c *CLI) pluginCommand(name, short, long, example string, run func(args []string) error) *cobra.Command {
+	return &cobra.Command{
+		Use:                name,
+		Short:              short,
+		Long:               long,
+		Example:            example,
+		Annotations:        map[string]string{"plugin": "true"},
+		DisableFlagParsing: true,
+		// The global flags are only known once RunE has taken them from
+		// the arguments
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		RunE: func(cmd *cobra.Command, args []string) error {
+			args, err := c.takeGlobalFlags(args)
+			if err != nil {
+				return withExitCode(ExitUsage, err)
+			}
+			if err := c.loadLanguage(); err != nil {
+				return err
+			}
+			if err := c.startLogging(); err != nil {
+				return err
+			}
+			return run(args)
+		},
+	}
+}
+
+// takeGlobalFlags sets the global flags found in the arguments of a plugin
+// command and returns the other arguments. Arguments after -- are all
+// left for the plugin.
+func (c *CLI) takeGlobalFlags(args []string) ([]string, error) {
+	flags := c.rootCmd.PersistentFlags()
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		var name, value string
+		var hasValue bool
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue = strings.Cut(arg[2:], "=")
+		case len(arg) == 2 && arg[0] == '-', len(arg) > 2 && arg[0] == '-' && arg[2] == '=':
+			if f := flags.ShorthandLookup(arg[1:2]); f != nil {
+				name = f.Name
+			}
+			if len(arg) > 2 {
+				value, hasValue = arg[3:], true
+			}
+		}
+		f := flags.Lookup(name)
+		if name == "" || f == nil {
+			rest = append(rest, arg)
+			continue
+		}
+
+		if !hasValue {
+			if f.Value.Type() == "bool" {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return nil, fmt.Errorf("flag needs an argument: %s", arg)
+			}
+		}
+		if err := flags.Set(f.Name, value); err != nil {
+			return nil, fmt.Errorf("invalid argument %q for --%s: %w", value, f.Name, err)
+		}
+	}
+	return rest, nil
+}
+
+// pluginContext returns the context passed to compiled-in commands
+func (c *CLI) pluginContext() *plugin.Context {
+	return &plugin.Context{
+		OutputFormat: c.outputFormat,
+		Quiet:        c.quiet,
+		Verbose:      c.verbose,
+		Lang:         c.tr.Lang,
+		Stdin:        c.stdin,
+		Stdout:       os.Stdout,
+		Stderr:       os.Stderr,
+		Logger:       c.log,
+	}
+}
+
+// runPlugin runs a plugin executable with the global flags in its
+// environment. Its exit code becomes the exit code of nld.
+func (c *CLI) runPlugin(p plugins.Plugin, args []string) error {
+	env := os.Environ()
+	for _, name := range pluginFlags {
+		if f := c.rootCmd.PersistentFlags().Lookup(name); f != nil && f.Value.String() != "" {
+			env = append(env, plugins.FlagEnv(name)+"="+f.Value.String())
+		}
+	}
+	if self, err := os.Executable(); err == nil {
+		env = append(env, plugins.BinaryEnv+"="+self)
+	}
+
+	c.log.Debug("running plugin", "name", p.Name, "path", p.Path)
+	cmd := exec.Command(p.Path, args...)
+	cmd.Env = env
+	cmd.Stdin = c.stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			return withExitCode(exitErr.ExitCode(), fmt.Errorf("plugin %s failed: %w", p.Name, err))
+		}
+		return fmt.Errorf("failed to run plugin %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// runPluginList prints the plugins
+func (c *CLI) runPluginList() error {
+	list := c.findPlugins()
+	if c.outputFormat == "json" {
+		if list == nil {
+			list = []pluginInfo{}
+		}
+		jsonResult, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+		return nil
+	}
+
+	if len(list) == 0 {
+		if !c.quiet {
+			fmt.Println("No plugins found")
+		}
+		return nil
+	}
+	for _, p := range list {
+		source := p.Path
+		if source == "" {
+			source = "(compiled in)"
+		}
+		fmt.Printf("%-20s %s\n", p.Name, source)
+		if p.Conflict != "" {
+			fmt.Printf("  ! hidden by %s\n", p.Conflict)
+		}
+		for _, s := range p.Shadowed {
+			fmt.Printf("  ! shadows %s\n", s)
+		}
+	}
+	return nil
+}
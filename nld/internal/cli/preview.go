@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/preview"
+	"github.com/colemalphrus/nld/internal/render"
+	"github.com/colemalphrus/nld/internal/schema"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// previewInterval is how often the previewed file is checked for changes
+const previewInterval = 300 * time.Millisecond
+
+// addPreviewCommand adds the preview command
+func (c *CLI) addPreviewCommand() {
+	var addr string
+	var numbering string
+	var locales []string
+	var validate validateOptions
+	var noValidate bool
+	var keyfile string
+	var passphraseFile string
+
+	previewCmd := &cobra.Command{
+		Use:   "preview [file]",
+		Short: "Preview a document in the browser as it is edited",
+		Long: `Start a local web server showing the HTML rendering of a document, and
+reload the page in the browser whenever the file is saved.
+
+The document is validated as the validate command does on every change.
+Errors and warnings are listed at the top of the page and shown under the
+section they are found in. When the file cannot be read or rendered, the
+last good rendering is kept and the problem is shown above it.
+
+The server listens on localhost only unless --addr gives another address,
+and runs until it is interrupted.`,
+		Example: `  nld preview contract.json
+  nld preview contract.json --addr localhost:9000 --locale fr
+  nld preview receipt.json --profile none --no-validate`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			opts := render.Options{Format: render.HTML, Lang: c.tr, Locales: locales}
+			if numbering != "" {
+				if opts.Numbering, err = nld.LookupNumberingStyle(numbering); err != nil {
+					return err
+				}
+			}
+			var s *schema.Schema
+			if validate.schemaPath != "" && !noValidate {
+				if s, err = schema.Load(validate.schemaPath); err != nil {
+					return err
+				}
+			}
+			build := func() preview.Page {
+				return c.buildPreview(args[0], opts, s, validate, noValidate, key)
+			}
+			return c.runPreview(args[0], addr, build)
+		},
+	}
+
+	previewCmd.Flags().StringVar(&addr, "addr", "localhost:8080", "Address the server listens on (port 0 picks a free port)")
+	previewCmd.Flags().StringVar(&numbering, "numbering", "", "Section numbering style (default: based on jurisdiction)")
+	previewCmd.Flags().StringSliceVar(&locales, "locale", nil, "Locale of section content; two locales render side by side")
+	previewCmd.Flags().StringVarP(&validate.schemaPath, "schema", "s", "", "Path to schema file (default: based on the document type)")
+	previewCmd.Flags().StringVar(&validate.profile, "profile", "", "Validation profile name or file, or none (default: based on jurisdiction)")
+	previewCmd.Flags().BoolVar(&noValidate, "no-validate", false, "Show the rendering without validating the document")
+	previewCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	previewCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(previewCmd)
+}
+
+// runPreview serves the preview of a document until interrupted
+func (c *CLI) runPreview(path, addr string, build func() preview.Page) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to read document: %w", err)
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start preview server: %w", err)
+	}
+
+	if !c.quiet {
+		fmt.Printf("Previewing %s at http://%s (press Ctrl+C to stop)\n", path, listener.Addr())
+	}
+	s := preview.New(path, build)
+	page, _ := s.Refresh()
+	c.reportPreview(path, page)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go s.Watch(ctx, previewInterval, func(page preview.Page) {
+		c.reportPreview(path, page)
+	})
+
+	server := &http.Server{Handler: s}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("preview server failed: %w", err)
+	}
+	return nil
+}
+
+// reportPreview prints the outcome of building the preview
+func (c *CLI) reportPreview(path string, page preview.Page) {
+	errs := page.Errors()
+	c.log.Info("built preview", "file", path, "errors", errs, "warnings", len(page.Problems)-errs)
+	if c.quiet {
+		return
+	}
+	if errs == 0 {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ %s %s", time.Now().Format("15:04:05"), path)))
+	} else {
+		fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s %s: %d error(s)", time.Now().Format("15:04:05"), path, errs)))
+	}
+	if c.verbose {
+		for _, p := range page.Problems {
+			fmt.Printf("  ! %s\n", p.Message)
+		}
+	}
+}
+
+// buildPreview reads, validates and renders the previewed document. Every
+// failure is reported as a problem of the page.
+func (c *CLI) buildPreview(path string, opts render.Options, s *schema.Schema, validate validateOptions, noValidate bool, key *envelope.Key) preview.Page {
+	var page preview.Page
+	fail := func(err error) preview.Page {
+		page.Problems = append(page.Problems, preview.Problem{Message: err.Error()})
+		return page
+	}
+
+	data, err := c.readDocument(path, key)
+	if err != nil {
+		return fail(fmt.Errorf("failed to read document: %w", err))
+	}
+	doc, err := document.Parse(data)
+	if err != nil {
+		return fail(err)
+	}
+	page.Sections = preview.Sections(doc)
+
+	if !noValidate {
+		result, err := checkDocument(c.ctx, doc, s, validate)
+		if err != nil {
+			page.Problems = append(page.Problems, preview.Problem{Message: err.Error()})
+		} else {
+			for _, e := range result.Errors {
+				if e.Message == "" {
+					continue
+				}
+				page.Problems = append(page.Problems, preview.Problem{Field: e.Field, Message: e.Message})
+			}
+			for _, w := range result.Warnings {
+				page.Problems = append(page.Problems, preview.Problem{Field: w.Field, Message: w.Message, Warning: true})
+			}
+		}
+	}
+
+	typed, err := nld.Parse(data)
+	if err != nil {
+		return fail(err)
+	}
+	var buf bytes.Buffer
+	if err := render.RenderContext(c.ctx, &buf, typed, opts); err != nil {
+		return fail(fmt.Errorf("failed to render document: %w", err))
+	}
+	page.HTML = buf.Bytes()
+	return page
+}
@@ -0,0 +1,369 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/privacy"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addPrivacyCommand adds the privacy command and its subcommands
+func (c *CLI) addPrivacyCommand() {
+	var entityID string
+	var dir string
+	var remote string
+	var keyfile string
+	var passphraseFile string
+
+	privacyCmd := &cobra.Command{
+		Use:   "privacy",
+		Short: "Export or erase the personal data of an entity in the document store",
+		Long: `Answer the requests of data subjects, such as those of the GDPR, to access
+or erase their personal data in the documents of the document store. The
+data subject is an entity of the documents, given by its ID in
+metadata.entities with --entity.
+
+Encrypted documents are read with --keyfile or --passphrase-file; documents
+that cannot be read make the commands fail, so that no document is missed.`,
+	}
+	privacyCmd.PersistentFlags().StringVar(&entityID, "entity", "", "ID of the entity whose data is exported or erased")
+	privacyCmd.PersistentFlags().StringVar(&dir, "store-dir", "", "Directory of the store (default: $"+store.DirEnv+" or ~/.nld/store)")
+	privacyCmd.PersistentFlags().StringVar(&remote, "remote", "", "Use the store in a bucket URL or named remote instead")
+	privacyCmd.PersistentFlags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	privacyCmd.PersistentFlags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+	privacyCmd.MarkPersistentFlagRequired("entity")
+
+	// open returns the store and key of the privacy commands
+	open := func() (store.Store, *envelope.Key, error) {
+		key, err := envelope.LoadKey(keyfile, passphraseFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		s, err := c.openStore(dir, remote)
+		return s, key, err
+	}
+
+	var outputPath string
+	var force bool
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Collect the stored documents that reference an entity",
+		Long: `Write every stored document that lists the entity in metadata.entities to
+one JSON file, latest versions in full, with the personal data of the
+entity, the sections that mention it and whether it signed the document.`,
+		Example: `  nld privacy export --entity E123
+  nld privacy export --entity E123 --remote contracts -o jane-doe.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, key, err := open()
+			if err != nil {
+				return err
+			}
+			if outputPath == "" {
+				outputPath = entityID + ".export.json"
+			}
+			return c.runPrivacyExport(s, entityID, outputPath, force, key)
+		},
+	}
+	exportCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: <entity>.export.json)")
+	exportCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+
+	var opts privacy.Options
+	var dryRun bool
+	eraseCmd := &cobra.Command{
+		Use:   "erase",
+		Short: "Erase the personal data of an entity from the stored documents",
+		Long: `Mask the name, email address, phone number and address of the entity in
+every stored document that lists it, as nld redact does, keeping its ID and
+role. With --sections the sections that mention the entity are masked too.
+A redaction attestation records the erasure in each document, and the
+erasures are recorded in the audit log as redact operations.
+
+The erased document replaces every version of the stored document, so that
+the data is not kept in earlier versions: it is stored first, and the
+earlier versions are deleted once it is. Earlier versions that could not be
+deleted are deleted when the command is run again. Documents under legal hold are
+not erased, but listed. Use --dry-run to list the documents that would be
+erased.`,
+		Example: `  nld privacy erase --entity E123 --dry-run
+  nld privacy erase --entity E123 --sections --redactor dpo@example.com`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, key, err := open()
+			if err != nil {
+				return err
+			}
+			return c.runPrivacyErase(s, entityID, opts, dryRun, key)
+		},
+	}
+	eraseCmd.Flags().BoolVar(&opts.Sections, "sections", false, "Also mask the sections that mention the entity")
+	eraseCmd.Flags().StringVar(&opts.Redactor, "redactor", "", "Identifier of the person or system performing the erasure")
+	eraseCmd.Flags().StringVar(&opts.Reason, "reason", "", "Reason recorded in the redaction attestation (default: erasure at the request of the data subject)")
+	eraseCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the documents that would be erased without changing them")
+
+	privacyCmd.AddCommand(exportCmd, eraseCmd)
+	c.rootCmd.AddCommand(privacyCmd)
+}
+
+// storedDocument is the latest version of a stored document, decrypted
+type storedDocument struct {
+	info      store.Info
+	data      []byte
+	encrypted bool
+}
+
+// privacyDocuments visits the latest version of every document in a store
+// that references an entity, with its reference to it. Documents that cannot
+// be read are returned as errors.
+func privacyDocuments(ctx context.Context, s store.Store, entityID string, key *envelope.Key, visit func(d storedDocument, doc document.Document, ref *privacy.Reference)) ([]expiryError, error) {
+	list, err := s.List(ctx)
+	if err != nil {
+		return nil, withExitCode(ExitIO, fmt.Errorf("failed to list documents: %w", err))
+	}
+	var errs []expiryError
+	for _, info := range list {
+		if store.Reserved(info.ID) {
+			continue
+		}
+		d := storedDocument{info: info}
+		var doc document.Document
+		d.data, _, err = s.Get(ctx, info.ID, 0)
+		if err == nil && envelope.IsEncrypted(d.data) {
+			d.encrypted = true
+			d.data, err = envelope.Decrypt(d.data, key)
+		}
+		if err == nil {
+			doc, err = document.Parse(d.data)
+		}
+		if err != nil {
+			errs = append(errs, expiryError{Document: info.ID, Error: err.Error()})
+			continue
+		}
+		if ref := privacy.Find(doc, entityID); ref != nil {
+			visit(d, doc, ref)
+		}
+	}
+	return errs, nil
+}
+
+// privacyExport is the output of the privacy export command
+type privacyExport struct {
+	Entity    string             `json:"entity"`
+	Exported  time.Time          `json:"exported"`
+	Documents []exportedDocument `json:"documents"`
+	Errors    []expiryError      `json:"errors,omitempty"`
+}
+
+// exportedDocument is a stored document that references the entity
+type exportedDocument struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+	Title   string `json:"title,omitempty"`
+	*privacy.Reference
+	Document json.RawMessage `json:"document"`
+}
+
+// runPrivacyExport writes the stored documents that reference an entity
+func (c *CLI) runPrivacyExport(s store.Store, entityID, outputPath string, force bool, key *envelope.Key) error {
+	if exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+
+	result := &privacyExport{Entity: entityID, Exported: time.Now().UTC(), Documents: []exportedDocument{}}
+	errs, err := privacyDocuments(context.Background(), s, entityID, key, func(d storedDocument, doc document.Document, ref *privacy.Reference) {
+		result.Documents = append(result.Documents, exportedDocument{
+			ID:        d.info.ID,
+			Version:   d.info.Version,
+			Title:     document.String(doc.Metadata(), "title"),
+			Reference: ref,
+			Document:  json.RawMessage(d.data),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	result.Errors = errs
+
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format export as JSON: %w", err)
+	}
+	if err := c.writeOutput(outputPath, append(out, '\n'), 0600); err != nil {
+		return err
+	}
+
+	for _, e := range errs {
+		fmt.Fprintln(os.Stderr, validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", e.Document, e.Error)))
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Exported %d document(s) referencing %s into %s", len(result.Documents), entityID, outputPath)))
+	}
+	if len(errs) > 0 {
+		return withExitCode(ExitValidation, fmt.Errorf("%d document(s) could not be read; the export is incomplete", len(errs)))
+	}
+	return nil
+}
+
+// privacyErasure is the outcome of the privacy erase command
+type privacyErasure struct {
+	Entity string `json:"entity"`
+	DryRun bool   `json:"dryRun,omitempty"`
+	// Erased are the IDs of the documents erased, or to erase in a dry run
+	Erased []string `json:"erased"`
+	// Held are the IDs of the documents under legal hold, left alone
+	Held   []string      `json:"held,omitempty"`
+	Errors []expiryError `json:"errors,omitempty"`
+}
+
+// runPrivacyErase erases the personal data of an entity from the stored
+// documents that reference it
+func (c *CLI) runPrivacyErase(s store.Store, entityID string, opts privacy.Options, dryRun bool, key *envelope.Key) error {
+	ctx := context.Background()
+	result := &privacyErasure{Entity: entityID, DryRun: dryRun, Erased: []string{}}
+	errs, err := privacyDocuments(ctx, s, entityID, key, func(d storedDocument, doc document.Document, ref *privacy.Reference) {
+		id := d.info.ID
+		if ref.Erased() {
+			// The earlier versions of a document erased before may have been
+			// left behind by a failure to delete them
+			left, err := unerasedVersions(ctx, s, d.info, entityID, key)
+			switch {
+			case err != nil:
+				result.Errors = append(result.Errors, expiryError{Document: id, Error: err.Error()})
+			case !left:
+			case dryRun:
+				result.Erased = append(result.Erased, id)
+			default:
+				detail := fmt.Sprintf("deleted the earlier versions holding the personal data of entity %s", entityID)
+				if err := c.audited(audit.Redact, id, "", detail, nil, s.Prune(ctx, id, d.info.Version)); err != nil {
+					result.Errors = append(result.Errors, expiryError{Document: id, Error: fmt.Sprintf("failed to delete earlier versions: %v", err)})
+					return
+				}
+				result.Erased = append(result.Erased, id)
+			}
+			return
+		}
+		if parsed, err := nld.Parse(d.data); err == nil && parsed.Metadata.LegalHold() {
+			result.Held = append(result.Held, id)
+			return
+		}
+		if dryRun {
+			result.Erased = append(result.Erased, id)
+			return
+		}
+		detail := fmt.Sprintf("erased the personal data of entity %s", entityID)
+		if err := c.audited(audit.Redact, id, "", detail, nil, c.eraseStored(ctx, s, d, doc, ref, opts, key)); err != nil {
+			result.Errors = append(result.Errors, expiryError{Document: id, Error: err.Error()})
+			return
+		}
+		result.Erased = append(result.Erased, id)
+	})
+	if err != nil {
+		return err
+	}
+	result.Errors = append(errs, result.Errors...)
+
+	if c.outputFormat == "json" {
+		if err := printJSON(result); err != nil {
+			return err
+		}
+	} else {
+		for _, e := range result.Errors {
+			fmt.Fprintln(os.Stderr, validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", e.Document, e.Error)))
+		}
+		if !c.quiet {
+			printErasure(result)
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		return withExitCode(ExitValidation, fmt.Errorf("%d document(s) could not be erased", len(result.Errors)))
+	}
+	return nil
+}
+
+// eraseStored erases the personal data of an entity from a stored document,
+// stores the erased version and deletes the earlier ones
+func (c *CLI) eraseStored(ctx context.Context, s store.Store, d storedDocument, doc document.Document, ref *privacy.Reference, opts privacy.Options, key *envelope.Key) error {
+	if _, err := privacy.Erase(doc, ref, opts); err != nil {
+		return err
+	}
+	out, err := doc.Marshal()
+	if err != nil {
+		return err
+	}
+	if d.encrypted {
+		if out, err = envelope.Encrypt(out, key); err != nil {
+			return err
+		}
+	}
+	// The erased version is stored before the earlier ones are deleted, so
+	// that a failure never leaves the document without a version
+	info, err := s.Put(ctx, d.info.ID, out)
+	if err != nil {
+		return withExitCode(ExitIO, fmt.Errorf("failed to store erased document; it was left unchanged: %w", err))
+	}
+	if err := s.Prune(ctx, d.info.ID, info.Version); err != nil {
+		return withExitCode(ExitIO, fmt.Errorf("stored the erased document as version %d, but failed to delete the earlier versions, which still hold the personal data: %w", info.Version, err))
+	}
+	return nil
+}
+
+// unerasedVersions reports whether a version of a stored document older
+// than info still holds personal data of an entity
+func unerasedVersions(ctx context.Context, s store.Store, info store.Info, entityID string, key *envelope.Key) (bool, error) {
+	versions, err := s.Versions(ctx, info.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range versions {
+		if v.Version >= info.Version {
+			continue
+		}
+		data, _, err := s.Get(ctx, info.ID, v.Version)
+		if err == nil && envelope.IsEncrypted(data) {
+			data, err = envelope.Decrypt(data, key)
+		}
+		var doc document.Document
+		if err == nil {
+			doc, err = document.Parse(data)
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to read version %d: %w", v.Version, err)
+		}
+		if ref := privacy.Find(doc, entityID); ref != nil && !ref.Erased() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// printErasure prints the outcome of the privacy erase command as text
+func printErasure(result *privacyErasure) {
+	verb := "Erased"
+	if result.DryRun {
+		verb = "Would erase"
+	}
+	for _, id := range result.Held {
+		fmt.Printf("  %-10s %s: under legal hold\n", "held", id)
+	}
+	if len(result.Erased) == 0 && len(result.Held) > 0 {
+		fmt.Printf("%d document(s) under legal hold were not erased\n", len(result.Held))
+		return
+	}
+	if len(result.Erased) == 0 {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ No stored documents hold personal data of %s", result.Entity)))
+		return
+	}
+	fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ %s the personal data of %s in %d document(s): %s", verb, result.Entity, len(result.Erased), strings.Join(result.Erased, ", "))))
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/privacy"
+	"github.com/colemalphrus/nld/internal/store"
+)
+
+// faultyStore is a store whose Put or Prune fails
+type faultyStore struct {
+	store.Store
+	put, prune error
+}
+
+func (s *faultyStore) Put(ctx context.Context, id string, data []byte) (store.Info, error) {
+	if s.put != nil {
+		return store.Info{}, s.put
+	}
+	return s.Store.Put(ctx, id, data)
+}
+
+func (s *faultyStore) Prune(ctx context.Context, id string, before int) error {
+	if s.prune != nil {
+		return s.prune
+	}
+	return s.Store.Prune(ctx, id, before)
+}
+
+func TestPrivacyEraseFailure(t *testing.T) {
+	t.Setenv("NLD_AUDIT_LOG", "")
+	ctx := context.Background()
+	fs := store.NewFS(t.TempDir())
+	for _, doc := range []string{testDocument, strings.Replace(testDocument, "USD 10,000", "USD 12,000", 1)} {
+		if _, err := fs.Put(ctx, "lease", []byte(doc)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	erase := func(s store.Store) error {
+		c := New()
+		c.quiet = true
+		var err error
+		stderr(t, func() { err = c.runPrivacyErase(s, "xyz", privacy.Options{}, false, nil) })
+		return err
+	}
+	latest := func() string {
+		data, _, err := fs.Get(ctx, "lease", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// A failure to store the erased version leaves the document alone
+	if err := erase(&faultyStore{Store: fs, put: errors.New("disk full")}); err == nil {
+		t.Fatal("Expected the erasure to fail")
+	}
+	if versions, _ := fs.Versions(ctx, "lease"); len(versions) != 2 || !strings.Contains(latest(), `"XYZ Ltd"`) {
+		t.Errorf("Expected the document to be left unchanged, got %d version(s):\n%s", len(versions), latest())
+	}
+
+	// A failure to delete the earlier versions is reported, with the erased
+	// version stored
+	if err := erase(&faultyStore{Store: fs, prune: errors.New("access denied")}); err == nil {
+		t.Fatal("Expected the erasure to fail")
+	}
+	if versions, _ := fs.Versions(ctx, "lease"); len(versions) != 3 || strings.Contains(latest(), `"XYZ Ltd"`) {
+		t.Errorf("Expected the erased version to be stored, got %d version(s):\n%s", len(versions), latest())
+	}
+
+	// Running it again deletes them
+	if err := erase(fs); err != nil {
+		t.Fatalf("erase failed: %v", err)
+	}
+	if versions, _ := fs.Versions(ctx, "lease"); len(versions) != 1 || versions[0].Version != 3 {
+		t.Errorf("Expected only the erased version to be left, got %+v", versions)
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/colemalphrus/nld/internal/profile"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// checkProfile adds the problems found by a jurisdiction profile to result.
+// The profile is named by name, or selected by the document's jurisdiction
+// when name is empty; profile.None disables the check. It returns the
+// profile used, if any.
+func checkProfile(docBytes []byte, name string, result *validator.ValidationResult) (*profile.Profile, error) {
+	if name == profile.None {
+		return nil, nil
+	}
+	doc, err := nld.Parse(docBytes)
+	if err != nil {
+		return nil, nil
+	}
+
+	var p *profile.Profile
+	if name != "" {
+		p, err = profile.Load(name)
+	} else {
+		p, err = profile.Select(doc.Metadata.Jurisdiction, doc.Metadata.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile: %w", err)
+	}
+	if p == nil {
+		return nil, nil
+	}
+
+	for _, problem := range p.Check(doc) {
+		if problem.Severity == profile.Warning {
+			result.Warnings = append(result.Warnings, validator.ValidationWarning{Field: problem.Path, Message: problem.Message})
+			continue
+		}
+		result.Errors = append(result.Errors, validator.ValidationError{Field: problem.Path, Message: problem.Message, Rule: validator.RuleProfile})
+		result.Valid = false
+	}
+	return p, nil
+}
+
+// profileNames returns the names of the available profiles for help text
+func profileNames() []string {
+	profiles, err := profile.All()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, p := range profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/colemalphrus/nld/internal/progress"
+	"github.com/colemalphrus/nld/internal/report"
+)
+
+// progress starts showing the progress of an operation on standard error,
+// or returns nil, which shows nothing, when standard error is not a
+// terminal or output is quiet or meant for programs. total is the amount of
+// work, or 0 for a spinner.
+func (c *CLI) progress(label string, total int) *progress.Bar {
+	if c.quiet || (c.outputFormat != report.Text && c.outputFormat != "") || !progress.Enabled(os.Stderr) {
+		return nil
+	}
+	return progress.New(os.Stderr, label, total)
+}
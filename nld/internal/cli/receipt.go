@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/receipt"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// checkReceipt adds receipt arithmetic problems to result. Documents of
+// other types are left untouched.
+func checkReceipt(docBytes []byte, currency string, result *validator.ValidationResult) error {
+	doc, err := nld.Parse(docBytes)
+	if err != nil || !strings.EqualFold(doc.Metadata.Type, "receipt") {
+		return nil
+	}
+
+	problems, err := receipt.Check(doc, currency)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		result.Errors = append(result.Errors, validator.ValidationError{Field: p.Path, Message: p.Message, Rule: validator.RuleReceipt})
+	}
+	if len(problems) > 0 {
+		result.Valid = false
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/redact"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addRedactCommand adds the redact command
+func (c *CLI) addRedactCommand() {
+	var sections []string
+	var paths []string
+	var mode string
+	var mask string
+	var redactor string
+	var reason string
+	var outputPath string
+	var force bool
+	var keyfile string
+	var passphraseFile string
+
+	redactCmd := &cobra.Command{
+		Use:   "redact [file]",
+		Short: "Redact sections or fields from an NLD document",
+		Long: `Mask or remove sections (by ID) and fields (by JSONPath) from an NLD document.
+
+A digest record is stored in verification.digest before anything is changed,
+so the remaining sections stay verifiable with the verify command. A
+redaction attestation is added to verification.attestations, listing every
+change with the hash of the value it masked or removed, which verify checks
+against the recorded hashes. Only the default mask verifies as a redaction.
+
+Encrypted documents are redacted into documents encrypted with the same key.`,
+		Example: `  nld redact contract.json --section fees
+  nld redact contract.json --path '$.metadata.entities[*].name' --mode remove`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			if outputPath == "" && args[0] == stdio {
+				outputPath = stdio
+			} else if outputPath == "" {
+				ext := filepath.Ext(args[0])
+				outputPath = strings.TrimSuffix(args[0], ext) + ".redacted" + ext
+			}
+			opts := redact.Options{
+				Sections: sections,
+				Paths:    paths,
+				Mode:     redact.Mode(mode),
+				Mask:     mask,
+				Redactor: redactor,
+				Reason:   reason,
+			}
+			return c.audited(audit.Redact, args[0], outputPath, "redacted into "+outputPath, key, c.runRedact(args[0], outputPath, opts, key, force))
+		},
+	}
+
+	redactCmd.Flags().StringSliceVar(&sections, "section", nil, "ID of a section to redact (repeatable)")
+	redactCmd.Flags().StringArrayVar(&paths, "path", nil, "JSONPath of a field to redact (repeatable)")
+	redactCmd.Flags().StringVar(&mode, "mode", string(redact.ModeMask), "Redaction mode (mask, remove)")
+	redactCmd.Flags().StringVar(&mask, "mask", redact.DefaultMask, "Placeholder text used in mask mode; only the default verifies as a redaction")
+	redactCmd.Flags().StringVar(&redactor, "redactor", "", "Identifier of the person or system performing the redaction")
+	redactCmd.Flags().StringVar(&reason, "reason", "", "Reason recorded in the redaction attestation")
+	redactCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: <file>.redacted.json)")
+	redactCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+	redactCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	redactCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(redactCmd)
+}
+
+// runRedact runs the redact command
+func (c *CLI) runRedact(inputPath, outputPath string, opts redact.Options, key *envelope.Key, force bool) error {
+	c.log.Info("redacting document", "file", inputPath, "output", outputPath)
+
+	if exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+
+	doc, encrypted, err := c.loadSealed(inputPath, key)
+	if err != nil {
+		return err
+	}
+
+	report, err := redact.Apply(doc, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeDocument(outputPath, doc, encrypted, key); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Redacted %d item(s) from %s into %s", report.Changed, inputPath, outputPath)))
+		if c.verbose {
+			for _, id := range report.Sections {
+				fmt.Printf("  - section %s\n", id)
+			}
+			for _, p := range report.Paths {
+				fmt.Printf("  - %s\n", p)
+			}
+		}
+	}
+	return nil
+}
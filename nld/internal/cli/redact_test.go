@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+)
+
+// writeEncrypted writes testDocument encrypted with a new keyfile and
+// returns the paths of the document and the keyfile
+func writeEncrypted(t *testing.T) (string, string) {
+	t.Helper()
+	keyfile := writeTestFile(t, "key", "0123456789abcdef")
+	sealed, err := envelope.Encrypt([]byte(testDocument), &envelope.Key{Keyfile: []byte("0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	path := writeTestFile(t, "contract.json", string(sealed))
+	// As nld encrypt writes it
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+	return path, keyfile
+}
+
+// checkEncrypted checks that the file at path is readable by its owner
+// only and encrypted with the key of writeEncrypted, and returns its
+// plaintext
+func checkEncrypted(t *testing.T, path string) string {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("No output: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected mode 0600, got %o", perm)
+	}
+	data, _ := os.ReadFile(path)
+	if !envelope.IsEncrypted(data) {
+		t.Fatalf("Expected encrypted output, got %s", data)
+	}
+	plaintext, err := envelope.Decrypt(data, &envelope.Key{Keyfile: []byte("0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	return string(plaintext)
+}
+
+func TestRedactEncrypted(t *testing.T) {
+	path, keyfile := writeEncrypted(t)
+	output := filepath.Join(filepath.Dir(path), "redacted.json")
+
+	if _, err := run(t, New(), "redact", path, "--keyfile", keyfile, "--section", "fees", "-o", output); err != nil {
+		t.Fatalf("redact failed: %v", err)
+	}
+	plaintext := checkEncrypted(t, output)
+	if strings.Contains(plaintext, "USD 10,000") || !strings.Contains(plaintext, "[REDACTED]") {
+		t.Errorf("Expected the fees section to be redacted, got %s", plaintext)
+	}
+}
+
+func TestRedactPlaintext(t *testing.T) {
+	path := writeTestFile(t, "contract.json", testDocument)
+	output := filepath.Join(filepath.Dir(path), "redacted.json")
+
+	if _, err := run(t, New(), "redact", path, "--section", "fees", "-o", output); err != nil {
+		t.Fatalf("redact failed: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil || envelope.IsEncrypted(data) || !strings.Contains(string(data), "[REDACTED]") {
+		t.Errorf("Expected a plaintext redacted document, got %s (%v)", data, err)
+	}
+}
@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/colors"
+	"github.com/colemalphrus/nld/internal/redline"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addRedlineCommand adds the redline command and its subcommands
+func (c *CLI) addRedlineCommand() {
+	redlineCmd := &cobra.Command{
+		Use:   "redline",
+		Short: "Propose, accept and reject changes to a document",
+		Long: `Propose changes to the text of a document's sections instead of editing it
+directly. Proposed changes are kept in the top-level redline of the document,
+outside the root hash, until they are accepted, which applies them to the
+section, or rejected, which discards them.
+
+nld render --format html --redline shows the proposed changes, with deleted
+text struck through and inserted text underlined.`,
+	}
+
+	var ch nld.Change
+	var textRange string
+	at := -1
+	proposeCmd := &cobra.Command{
+		Use:   "propose [file]",
+		Short: "Propose a change to a section",
+		Long: `Propose a change to the plain text content of a section. --replace replaces
+the first occurrence of the given text with --text, or deletes it without
+--text; --range replaces the characters from start to end instead, and --at
+inserts --text at a character offset. The author defaults to the actor
+recorded in the audit log.`,
+		Example: `  nld redline propose lease.json --section rent --replace "EUR 900" --text "EUR 950"
+  nld redline propose lease.json --section rent --replace " or later"
+  nld redline propose lease.json --section rent --at 0 --text "Unless agreed otherwise, "`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			change := ch
+			switch {
+			case textRange != "":
+				r, err := parseTextRange(textRange)
+				if err != nil {
+					return withExitCode(ExitUsage, err)
+				}
+				change.Range = *r
+			case at >= 0:
+				change.Range = nld.TextRange{Start: at, End: at}
+			}
+			if change.Author == "" {
+				change.Author = audit.Actor()
+			}
+			return c.runRedlinePropose(args[0], change)
+		},
+	}
+	proposeCmd.Flags().StringVar(&ch.Section, "section", "", "ID of the section to change")
+	proposeCmd.Flags().StringVar(&ch.Deleted, "replace", "", "Replace the first occurrence of this text in the section")
+	proposeCmd.Flags().StringVar(&textRange, "range", "", "Replace the characters from start to end of the section, as start:end")
+	proposeCmd.Flags().IntVar(&at, "at", -1, "Insert at this character offset of the section")
+	proposeCmd.Flags().StringVar(&ch.Inserted, "text", "", "Text to insert")
+	proposeCmd.Flags().StringVar(&ch.Author, "author", "", "Author of the change (default: $"+audit.ActorEnv+" or the current user)")
+	proposeCmd.MarkFlagRequired("section")
+	proposeCmd.MarkFlagsOneRequired("replace", "range", "at")
+	proposeCmd.MarkFlagsMutuallyExclusive("replace", "range", "at")
+	proposeCmd.RegisterFlagCompletionFunc("section", completeSectionIDs)
+
+	var section string
+	listCmd := &cobra.Command{
+		Use:   "list [file...]",
+		Short: "List the changes proposed to documents",
+		Long: `List the changes proposed to documents. Changes to text that has been edited
+since they were proposed are marked as outdated; they can only be rejected.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := expandPaths(args)
+			if err != nil {
+				return err
+			}
+			return c.runRedlineList(files, section)
+		},
+	}
+	listCmd.Flags().StringVar(&section, "section", "", "Only list the changes of this section")
+
+	var acceptAll bool
+	acceptCmd := &cobra.Command{
+		Use:   "accept [file] [id...]",
+		Short: "Apply proposed changes to a document",
+		Long: `Apply proposed changes to the sections of a document, in the order given,
+and remove them from the redline. A change that overlaps another pending
+change is not applied until one of them is rejected. Accepting changes edits
+the document, so its signatures no longer verify.`,
+		Example: `  nld redline accept lease.json c1 c3
+  nld redline accept lease.json --all`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runRedlineDecide(args[0], args[1:], acceptAll, true)
+		},
+	}
+	acceptCmd.Flags().BoolVar(&acceptAll, "all", false, "Accept every proposed change")
+
+	var rejectAll bool
+	rejectCmd := &cobra.Command{
+		Use:   "reject [file] [id...]",
+		Short: "Discard proposed changes",
+		Example: `  nld redline reject lease.json c2
+  nld redline reject lease.json --all`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runRedlineDecide(args[0], args[1:], rejectAll, false)
+		},
+	}
+	rejectCmd.Flags().BoolVar(&rejectAll, "all", false, "Reject every proposed change")
+
+	redlineCmd.AddCommand(proposeCmd, listCmd, acceptCmd, rejectCmd)
+	c.rootCmd.AddCommand(redlineCmd)
+}
+
+// runRedlinePropose runs the redline propose command
+func (c *CLI) runRedlinePropose(filePath string, change nld.Change) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+	if change, err = redline.Propose(doc, change); err != nil {
+		return err
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Proposed change %s to section %s of %s", change.ID, change.Section, filePath)))
+	}
+	return nil
+}
+
+// documentChange is a change listed by redline list
+type documentChange struct {
+	File string `json:"file"`
+	nld.Change
+	Outdated bool `json:"outdated,omitempty"`
+}
+
+// runRedlineList runs the redline list command
+func (c *CLI) runRedlineList(files []string, section string) error {
+	list := []documentChange{}
+	for _, path := range files {
+		doc, err := c.loadDocument(path, nil)
+		if err != nil {
+			return err
+		}
+		changes, err := redline.Load(doc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, ch := range changes {
+			if section == "" || ch.Section == section {
+				list = append(list, documentChange{File: path, Change: ch, Outdated: redline.Stale(doc, ch)})
+			}
+		}
+	}
+
+	if c.outputFormat == "json" {
+		return printJSON(list)
+	}
+	if c.quiet {
+		return nil
+	}
+	if len(list) == 0 {
+		fmt.Println("No proposed changes")
+		return nil
+	}
+	for _, ch := range list {
+		prefix := ""
+		if len(files) > 1 {
+			prefix = ch.File + ": "
+		}
+		state := ""
+		if ch.Outdated {
+			state = " (outdated)"
+		}
+		fmt.Printf("%s%s\t%s %d:%d\t%s\t%s%s\n", prefix, ch.ID, ch.Section, ch.Range.Start, ch.Range.End, ch.Author, ch.Date, state)
+		if ch.Deleted != "" {
+			fmt.Println(colors.Error(fmt.Sprintf("  - %q", ch.Deleted)))
+		}
+		if ch.Inserted != "" {
+			fmt.Println(colors.Success(fmt.Sprintf("  + %q", ch.Inserted)))
+		}
+	}
+	return nil
+}
+
+// runRedlineDecide runs the redline accept and reject commands
+func (c *CLI) runRedlineDecide(filePath string, ids []string, all, accept bool) error {
+	verb := "reject"
+	if accept {
+		verb = "accept"
+	}
+	if all == (len(ids) > 0) {
+		return withExitCode(ExitUsage, fmt.Errorf("give the IDs of the changes to %s or --all", verb))
+	}
+
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+	if all {
+		changes, err := redline.Load(doc)
+		if err != nil {
+			return err
+		}
+		for _, ch := range changes {
+			ids = append(ids, ch.ID)
+		}
+	}
+	for _, id := range ids {
+		if accept {
+			err = redline.Accept(doc, id)
+		} else {
+			err = redline.Reject(doc, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	c.log.Debug(verb+"ed proposed changes", "file", filePath, "changes", ids)
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("%sed %d change(s) in %s: %s", strings.ToUpper(verb[:1])+verb[1:], len(ids), filePath, strings.Join(ids, ", "))))
+		if signatures, _ := doc.Verification(false)["signatures"].([]interface{}); accept && len(ids) > 0 && len(signatures) > 0 {
+			fmt.Println(colors.Warning(fmt.Sprintf("! %s is signed; its signatures no longer verify", filePath)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/render"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addRenderCommand adds the render command
+func (c *CLI) addRenderCommand() {
+	var format string
+	var outputPath string
+	var numbering string
+	var unnumbered bool
+	var outline bool
+	var annotations bool
+	var redline bool
+	var force bool
+	var locales []string
+	var keyfile string
+	var passphraseFile string
+
+	renderCmd := &cobra.Command{
+		Use:   "render [file]",
+		Short: "Render an NLD document as Markdown, HTML or text",
+		Long: `Render an NLD document in a human-readable format with numbered sections.
+
+Sections are numbered hierarchically (1, 1.1, 1.1.a) using the customary
+style for the document's jurisdiction unless --numbering selects one of the
+built-in styles: ` + strings.Join(nld.NumberingStyles(), ", ") + `.
+
+Sections with translations are rendered in the locale given with --locale.
+Give two locales (--locale en,fr) to render them side by side.
+
+With --annotations, HTML output shows the open annotations of the document
+as margin notes beside their sections, highlighting the text they are about.
+With --redline, it shows the changes proposed with nld redline, with deleted
+text struck through and inserted text underlined.
+
+The rendering is written to standard output unless --output is given. With
+--outline only the numbered section outline is printed.`,
+		Example: `  nld render contract.json
+  nld render contract.json --format html -o contract.html
+  nld render contract.json --numbering uk --outline
+  nld render contract.json --locale en,fr --format html
+  nld render contract.json --format html --annotations -o review.html
+  nld render contract.json --format html --redline -o redline.html`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+
+			opts := render.Options{Format: format, Unnumbered: unnumbered, Lang: c.tr, Locales: locales, Annotations: annotations, Redline: redline}
+			if numbering != "" {
+				if opts.Numbering, err = nld.LookupNumberingStyle(numbering); err != nil {
+					return err
+				}
+			}
+			if outline {
+				return c.runOutline(args[0], opts.Numbering, key)
+			}
+			return c.runRender(args[0], outputPath, opts, force, key)
+		},
+	}
+
+	renderCmd.Flags().StringVar(&format, "format", render.Markdown, "Output format ("+strings.Join(render.Formats(), ", ")+")")
+	renderCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: standard output)")
+	renderCmd.Flags().StringVar(&numbering, "numbering", "", "Section numbering style (default: based on jurisdiction)")
+	renderCmd.Flags().BoolVar(&unnumbered, "no-numbers", false, "Render section titles without numbers")
+	renderCmd.Flags().BoolVar(&outline, "outline", false, "Print the numbered section outline only")
+	renderCmd.Flags().BoolVar(&annotations, "annotations", false, "Show open annotations as margin notes (HTML only)")
+	renderCmd.Flags().BoolVar(&redline, "redline", false, "Show proposed changes as deletions and insertions (HTML only)")
+	renderCmd.Flags().StringSliceVar(&locales, "locale", nil, "Locale of section content; two locales render side by side")
+	renderCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+	renderCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	renderCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(renderCmd)
+}
+
+// parseDocument reads a document into the typed model
+func (c *CLI) parseDocument(path string, key *envelope.Key) (*nld.Document, error) {
+	data, err := c.readDocument(path, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	return nld.Parse(data)
+}
+
+// runRender runs the render command
+func (c *CLI) runRender(inputPath, outputPath string, opts render.Options, force bool, key *envelope.Key) error {
+	if outputPath != "" {
+		if exists(outputPath) && !force {
+			return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+		}
+	}
+
+	doc, err := c.parseDocument(inputPath, key)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := render.RenderContext(c.ctx, &buf, doc, opts); err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := c.writeOutput(outputPath, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Rendered %s: %s", inputPath, outputPath)))
+	}
+	return nil
+}
+
+// runOutline prints the numbered outline of a document
+func (c *CLI) runOutline(inputPath string, style nld.NumberingStyle, key *envelope.Key) error {
+	doc, err := c.parseDocument(inputPath, key)
+	if err != nil {
+		return err
+	}
+	outline := doc.Outline(style)
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(outline, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+		return nil
+	}
+
+	var printEntries func([]nld.OutlineEntry)
+	printEntries = func(entries []nld.OutlineEntry) {
+		for _, e := range entries {
+			fmt.Printf("%s%s %s\n", strings.Repeat("  ", e.Depth), e.Number, e.Title)
+			printEntries(e.Children)
+		}
+	}
+	printEntries(outline)
+	return nil
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/report"
+)
+
+// reporter returns the reporter of a command for the output format,
+// writing to out
+func (c *CLI) reporter(command string, out io.Writer) (report.Reporter, error) {
+	rep, err := report.New(c.outputFormat, report.Options{
+		Out:     out,
+		Tr:      c.tr,
+		Quiet:   c.quiet,
+		Verbose: c.verbose,
+		Command: command,
+		Version: Version,
+	})
+	if err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+	return rep, nil
+}
+
+// locator returns a function that finds the line and column of a JSON
+// pointer in the document at path. Lines are not reported for encrypted
+// documents, whose plaintext lines do not exist in the file.
+func (c *CLI) locator(path string, data []byte) func(pointer string) (int, int) {
+	if raw, err := c.readInput(path); err != nil || envelope.IsEncrypted(raw) {
+		return func(string) (int, int) { return 0, 0 }
+	}
+	return func(pointer string) (int, int) {
+		return document.Locate(data, pointer)
+	}
+}
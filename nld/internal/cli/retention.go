@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/retention"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addRetentionCommand adds the retention command and its subcommands
+func (c *CLI) addRetentionCommand() {
+	retentionCmd := &cobra.Command{
+		Use:   "retention",
+		Short: "Enforce the retention periods of stored documents",
+		Long: `Enforce the retention periods and legal holds recorded in the metadata of
+documents:
+
+  "retention": {
+    "retainUntil": "2032-06-30",
+    "legalHold": true,
+    "holdReason": "Smith v. XYZ Ltd"
+  }
+
+Documents must be kept until retainUntil, or indefinitely without one.
+Documents under legal hold must be kept whatever their retention period,
+until a new version releases the hold.`,
+	}
+
+	var dir string
+	var remote string
+	var remove bool
+	var keyfile string
+	var passphraseFile string
+	sweepCmd := &cobra.Command{
+		Use:   "sweep",
+		Short: "Report or delete the stored documents past their retention period",
+		Long: `Check the latest version of every document in the document store against
+its retention metadata and report those past their retention period. With
+--delete they are deleted, all versions included.
+
+Documents under legal hold are never deleted, and neither are documents
+that cannot be read, such as encrypted documents without --keyfile or
+--passphrase-file. The command fails when any document could not be
+checked or deleted.`,
+		Example: `  nld retention sweep
+  nld retention sweep --remote contracts --delete`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			s, err := c.openStore(dir, remote)
+			if err != nil {
+				return err
+			}
+			return c.runRetentionSweep(s, remove, key)
+		},
+	}
+	sweepCmd.Flags().BoolVar(&remove, "delete", false, "Delete the documents past their retention period")
+	sweepCmd.Flags().StringVar(&dir, "store-dir", "", "Directory of the store (default: $"+store.DirEnv+" or ~/.nld/store)")
+	sweepCmd.Flags().StringVar(&remote, "remote", "", "Use the store in a bucket URL or named remote instead")
+	sweepCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	sweepCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	retentionCmd.AddCommand(sweepCmd)
+	c.rootCmd.AddCommand(retentionCmd)
+}
+
+// sweepResult is the outcome of the retention sweep command
+type sweepResult struct {
+	Entries []*retention.Entry `json:"entries"`
+	// Deleted are the IDs of the documents deleted
+	Deleted []string      `json:"deleted,omitempty"`
+	Errors  []expiryError `json:"errors,omitempty"`
+}
+
+// runRetentionSweep checks the latest version of every document in a store
+// against its retention metadata, deleting the expired ones when remove is
+// set
+func (c *CLI) runRetentionSweep(s store.Store, remove bool, key *envelope.Key) error {
+	ctx := context.Background()
+	list, err := s.List(ctx)
+	if err != nil {
+		return withExitCode(ExitIO, fmt.Errorf("failed to list documents: %w", err))
+	}
+
+	now := time.Now()
+	result := &sweepResult{Entries: []*retention.Entry{}}
+	for _, info := range list {
+		if store.Reserved(info.ID) {
+			continue
+		}
+		e, err := checkRetention(ctx, s, info.ID, key, now)
+		if err != nil {
+			result.Errors = append(result.Errors, expiryError{Document: info.ID, Error: err.Error()})
+			continue
+		}
+		result.Entries = append(result.Entries, e)
+		if !remove || e.Status != retention.Expired {
+			continue
+		}
+		detail := "deleted by retention sweep, retained until " + e.RetainUntil.Format(nld.DateLayout)
+		if err := c.audited(audit.Delete, info.ID, "", detail, nil, s.Delete(ctx, info.ID)); err != nil {
+			result.Errors = append(result.Errors, expiryError{Document: info.ID, Error: fmt.Sprintf("failed to delete: %v", err)})
+			continue
+		}
+		result.Deleted = append(result.Deleted, info.ID)
+	}
+
+	if c.outputFormat == "json" {
+		if err := printJSON(result); err != nil {
+			return err
+		}
+	} else {
+		for _, e := range result.Errors {
+			fmt.Fprintln(os.Stderr, validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", e.Document, e.Error)))
+		}
+		if !c.quiet {
+			c.printSweep(result, remove, now)
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		return withExitCode(ExitValidation, fmt.Errorf("%d document(s) could not be swept", len(result.Errors)))
+	}
+	return nil
+}
+
+// checkRetention returns the retention status of the latest version of a
+// stored document
+func checkRetention(ctx context.Context, s store.Store, id string, key *envelope.Key, now time.Time) (*retention.Entry, error) {
+	data, _, err := s.Get(ctx, id, 0)
+	if err == nil && envelope.IsEncrypted(data) {
+		data, err = envelope.Decrypt(data, key)
+	}
+	var doc *nld.Document
+	if err == nil {
+		doc, err = nld.Parse(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return retention.Check(id, doc, now)
+}
+
+// printSweep prints the documents past their retention period, and with
+// --verbose those retained as well
+func (c *CLI) printSweep(result *sweepResult, remove bool, now time.Time) {
+	deleted := map[string]bool{}
+	for _, id := range result.Deleted {
+		deleted[id] = true
+	}
+	expired := 0
+	for _, e := range result.Entries {
+		title := e.Document
+		if e.Title != "" {
+			title += " (" + e.Title + ")"
+		}
+		switch {
+		case deleted[e.Document]:
+			expired++
+			fmt.Printf("  %-10s %s: retained until %s\n", "deleted", title, e.RetainUntil.Format(nld.DateLayout))
+		case e.Status == retention.Expired:
+			expired++
+			fmt.Printf("  %-10s %s: retained until %s\n", e.Status, title, e.RetainUntil.Format(nld.DateLayout))
+		case e.Status == retention.Held && (e.Lapsed(now) || c.verbose):
+			reason := ""
+			if e.HoldReason != "" {
+				reason = ": " + e.HoldReason
+			}
+			fmt.Printf("  %-10s %s: under legal hold%s\n", e.Status, title, reason)
+		case c.verbose && e.RetainUntil != nil:
+			fmt.Printf("  %-10s %s: retained until %s\n", e.Status, title, e.RetainUntil.Format(nld.DateLayout))
+		case c.verbose:
+			fmt.Printf("  %-10s %s: retained indefinitely\n", e.Status, title)
+		}
+	}
+
+	switch {
+	case expired == 0:
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ No documents past their retention period among %d", len(result.Entries))))
+	case remove:
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Deleted %d of %d document(s) past their retention period", len(result.Deleted), expired)))
+	default:
+		fmt.Printf("%d document(s) past their retention period; delete them with --delete\n", expired)
+	}
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/lint"
+	"github.com/colemalphrus/nld/internal/playbook"
+	"github.com/colemalphrus/nld/internal/profile"
+	"github.com/colemalphrus/nld/internal/risk"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// riskOptions are the flags of the commands that score the risk of
+// documents
+type riskOptions struct {
+	weights  string
+	playbook string
+	profile  string
+}
+
+// addFlags adds the risk flags to cmd
+func (o *riskOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.weights, "risk-weights", "", "YAML or JSON file of risk score weights and grades")
+	cmd.Flags().StringVar(&o.playbook, "playbook", "", "Playbook whose deviations add to the risk score")
+	cmd.Flags().StringVar(&o.profile, "profile", "", "Jurisdiction profile whose missing clauses add to the risk score ("+strings.Join(profileNames(), ", ")+", or "+profile.None+"; default: selected by metadata.jurisdiction)")
+}
+
+// riskScorer scores documents with the weights and playbook of
+// riskOptions
+type riskScorer struct {
+	config   *risk.Config
+	playbook *playbook.Playbook
+	profile  string
+}
+
+// scorer loads the weights and playbook of the options
+func (o riskOptions) scorer() (*riskScorer, error) {
+	s := &riskScorer{config: risk.Default(), profile: o.profile}
+	var err error
+	if o.weights != "" {
+		if s.config, err = risk.Load(o.weights); err != nil {
+			return nil, err
+		}
+	}
+	if o.playbook != "" {
+		if s.playbook, err = playbook.Load(o.playbook); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// score returns the risk score of a document
+func (s *riskScorer) score(raw document.Document) (*risk.Score, error) {
+	data, err := raw.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	var in risk.Input
+	if in.Findings, err = lint.Lint(data, lint.Options{}); err != nil {
+		return nil, fmt.Errorf("failed to lint document: %w", err)
+	}
+	doc, err := nld.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if s.playbook != nil {
+		in.Playbook = s.playbook.Check(doc)
+	}
+	if s.profile != profile.None {
+		var p *profile.Profile
+		if s.profile != "" {
+			p, err = profile.Load(s.profile)
+		} else {
+			p, err = profile.Select(doc.Metadata.Jurisdiction, doc.Metadata.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load profile: %w", err)
+		}
+		if p != nil {
+			in.Problems = p.Check(doc)
+		}
+	}
+	return s.config.Score(in), nil
+}
+
+// printRisk prints a risk score as text, with its factors when verbose
+func (c *CLI) printRisk(label string, score *risk.Score) {
+	fmt.Printf("%s%.0f/%d, grade %s\n", label, score.Score, risk.Max, score.Grade)
+	if !c.verbose {
+		return
+	}
+	for _, f := range score.Factors {
+		where := f.ID
+		if f.Path != "" {
+			where = strings.TrimSpace(f.ID + " " + f.Path)
+		}
+		fmt.Printf("    %+5.0f  %s %s: %s\n", f.Points, f.Source, where, f.Message)
+	}
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/codegen"
+	"github.com/colemalphrus/nld/internal/schema"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// exampleAttempts is the number of random examples drawn for each one
+// written before giving up on finding a valid one
+const exampleAttempts = 100
+
+// addSchemaCommand adds the schema command and its subcommands
+func (c *CLI) addSchemaCommand() {
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Work with document schemas",
+		Long: `Work with document schemas.
+
+The schema is a schema file, or one of the schemas built into nld by file
+name, such as document-v1.json; --type picks the built-in schema of a
+document type. The default is the schema of contracts and receipts.`,
+	}
+
+	c.addSchemaExampleCommand(schemaCmd)
+
+	c.rootCmd.AddCommand(schemaCmd)
+}
+
+// addSchemaExampleCommand adds the schema example command
+func (c *CLI) addSchemaExampleCommand(schemaCmd *cobra.Command) {
+	var docType, outputPath string
+	var count int
+	var seed int64
+	var force bool
+
+	exampleCmd := &cobra.Command{
+		Use:   "example [SCHEMA]",
+		Short: "Generate example documents from a schema",
+		Long: `Generate a valid example document of a schema, for tests and documentation.
+
+Values follow the constraints of the schema: types, enums, patterns,
+formats, bounds and required properties. Strings are realistic sample
+values picked by property name, such as a party name or an email address,
+where they fit. The example has every optional property and nested
+sections two levels deep.
+
+With --count or --seed, examples are randomized instead: optional
+properties, alternatives, enum values and list lengths are picked at
+random. The same seed gives the same examples; without --seed one is
+chosen and printed, so that a run can be repeated.
+
+Every example is validated as the validate command does. Random examples
+that fail rules the schema cannot express, such as receipt arithmetic, are
+drawn again.
+
+With --count, --output is a directory that gets example-1.json and so on,
+and standard output gets a JSON array of the examples.`,
+		Example: `  nld schema example document-v1.json
+  nld schema example --type nda -o examples/nda.json
+  nld schema example my-schema.json --count 20 --seed 42 -o testdata/examples`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) > 0 {
+				path = args[0]
+			}
+			if path != "" && docType != "" {
+				return withExitCode(ExitUsage, fmt.Errorf("a schema and --type cannot be used together"))
+			}
+			if count < 1 {
+				return withExitCode(ExitUsage, fmt.Errorf("--count must be at least 1"))
+			}
+
+			data, source, err := readGenSchema(path, docType)
+			if err != nil {
+				return err
+			}
+			compiled, err := c.validator.LoadSchemaBytes(source, data)
+			if err != nil {
+				return withExitCode(ExitValidation, err)
+			}
+			s := &schema.Schema{Path: source, RawData: data, Compiled: compiled}
+
+			var rng *rand.Rand
+			if count > 1 || cmd.Flags().Changed("seed") {
+				if !cmd.Flags().Changed("seed") {
+					seed = time.Now().UnixNano()
+					fmt.Fprintf(os.Stderr, "Seed: %d\n", seed)
+				}
+				rng = rand.New(rand.NewSource(seed))
+			}
+
+			var examples [][]byte
+			for i := 0; i < count; i++ {
+				example, err := c.example(s, rng)
+				if err != nil {
+					return err
+				}
+				examples = append(examples, example)
+			}
+
+			if count == 1 {
+				if exists(outputPath) && !force {
+					return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+				}
+				if err := c.writeOutput(outputPath, examples[0], 0644); err != nil {
+					return err
+				}
+				if !c.quiet {
+					fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Generated %s", outputPath)))
+				}
+				return nil
+			}
+			if outputPath == stdio {
+				list := make([]json.RawMessage, len(examples))
+				for i, example := range examples {
+					list[i] = example
+				}
+				return printJSON(list)
+			}
+			for i, example := range examples {
+				path := filepath.Join(outputPath, fmt.Sprintf("example-%d.json", i+1))
+				if exists(path) && !force {
+					return fmt.Errorf("file already exists: %s (use --force to overwrite)", path)
+				}
+				if err := writeFile(path, example, 0644); err != nil {
+					return err
+				}
+			}
+			if !c.quiet {
+				fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Generated %d examples in %s", count, outputPath)))
+			}
+			return nil
+		},
+	}
+
+	exampleCmd.Flags().StringVar(&docType, "type", "", "Document type whose built-in schema is used")
+	exampleCmd.Flags().StringVarP(&outputPath, "output", "o", stdio, "Output file, or directory with --count (default: standard output)")
+	exampleCmd.Flags().IntVarP(&count, "count", "n", 1, "Number of random examples")
+	exampleCmd.Flags().Int64Var(&seed, "seed", 0, "Seed of random examples (default: random)")
+	exampleCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing files")
+
+	schemaCmd.AddCommand(exampleCmd)
+}
+
+// example returns a valid example of schema s, randomized with rng if set
+func (c *CLI) example(s *schema.Schema, rng *rand.Rand) ([]byte, error) {
+	var problem string
+	for attempt := 0; attempt < exampleAttempts; attempt++ {
+		example, err := codegen.Example(s.RawData, codegen.ExampleOptions{Rand: rng})
+		if err != nil {
+			return nil, withExitCode(ExitValidation, fmt.Errorf("failed to generate an example of %s: %w", s.Path, err))
+		}
+		result, err := validateSchema(c.ctx, s, example)
+		if err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+		if result.Valid {
+			if err := checkReceipt(example, "", result); err != nil {
+				return nil, err
+			}
+			if _, err := checkProfile(example, "", result); err != nil {
+				return nil, err
+			}
+		}
+		if result.Valid {
+			return example, nil
+		}
+		problem = result.Errors[0].Message
+		if rng == nil {
+			break
+		}
+	}
+	if rng != nil {
+		return nil, withExitCode(ExitValidation, fmt.Errorf("no valid example of %s in %d attempts: %s", s.Path, exampleAttempts, problem))
+	}
+	return nil, withExitCode(ExitValidation, fmt.Errorf("the example of %s is not valid: %s (try --seed for random examples)", s.Path, problem))
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/index"
+	"github.com/colemalphrus/nld/internal/search"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// addSearchCommand adds the search command
+func (c *CLI) addSearchCommand() {
+	var dir string
+	var q index.Query
+	var reindex bool
+
+	searchCmd := &cobra.Command{
+		Use:   "search [query] [dir]",
+		Short: "Search the documents of the document store or a directory",
+		Long: `Search the latest version of the documents in the document store by the
+text of their sections and definitions, and by metadata. Documents with the
+most matching sections come first. Store searches use an index kept beside
+the store, which is brought up to date with the store before every search.
+
+Given a directory, search the NLD documents under it instead, without the
+store: every section and definition matching the query is listed with its
+document, section ID and a snippet, best match first. Matches are ranked
+with BM25, which favours rare words and short sections; words in titles
+count double. Files that are not NLD documents, including encrypted
+documents, are skipped.
+
+Words of the query must all appear in a section, and "quoted phrases" must
+appear as written. Store searches also support OR, NOT and prefix*.
+Matched words are shown in [brackets].
+
+--after and --before compare the effective date of a document, or its
+creation date when it has none. --entity matches part of the name or role
+of an entity.`,
+		Example: `  nld search indemnification --type contract --after 2024-01-01
+  nld search '"governing law" AND delaware'
+  nld search --entity "Acme Corp"
+  nld search "limitation of liability" contracts/`,
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				q.Text = args[0]
+			}
+			if len(args) == 2 {
+				if q.Text == "" {
+					return withExitCode(ExitUsage, fmt.Errorf("a query is required when searching a directory"))
+				}
+				return c.runSearchDir(args[1], q)
+			}
+			if q.Text == "" && q.Type == "" && q.After == "" && q.Before == "" && q.Entity == "" {
+				return withExitCode(ExitUsage, fmt.Errorf("give a query or at least one of --type, --after, --before and --entity"))
+			}
+			return c.runSearch(dir, q, reindex)
+		},
+	}
+
+	searchCmd.Flags().StringVar(&q.Type, "type", "", "Only documents of this type")
+	searchCmd.Flags().StringVar(&q.After, "after", "", "Only documents dated on or after this date (YYYY-MM-DD)")
+	searchCmd.Flags().StringVar(&q.Before, "before", "", "Only documents dated on or before this date (YYYY-MM-DD)")
+	searchCmd.Flags().StringVar(&q.Entity, "entity", "", "Only documents with an entity whose name or role contains this text")
+	searchCmd.Flags().IntVar(&q.Limit, "limit", 0, "Maximum number of results (default: no limit)")
+	searchCmd.Flags().StringVar(&dir, "store-dir", "", "Directory of the store (default: $"+store.DirEnv+" or ~/.nld/store)")
+	searchCmd.Flags().BoolVar(&reindex, "reindex", false, "Rebuild the index from scratch before searching")
+
+	c.rootCmd.AddCommand(searchCmd)
+}
+
+// runSearchDir searches the NLD documents under dir
+func (c *CLI) runSearchDir(dir string, q index.Query) error {
+	if err := q.Validate(); err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	docs, skipped, err := search.Load(dir)
+	if err != nil {
+		return withExitCode(ExitIO, fmt.Errorf("failed to read documents: %w", err))
+	}
+	for _, path := range skipped {
+		c.log.Debug("skipped file that is not an NLD document", "path", path)
+	}
+	var selected []search.Document
+	for _, d := range docs {
+		if q.Matches(d.Doc.Metadata) {
+			selected = append(selected, d)
+		}
+	}
+
+	hits := search.Search(selected, q.Text)
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	if c.outputFormat == "json" {
+		return printJSON(hits)
+	}
+	if len(hits) == 0 {
+		if !c.quiet {
+			fmt.Printf("No matches in %d documents\n", len(selected))
+		}
+		return nil
+	}
+	for _, h := range hits {
+		where := h.Section
+		if where == "" {
+			where = "definition"
+		}
+		fmt.Printf("%s %s %s: %s\n", h.Path, where, h.Title, h.Snippet)
+	}
+	return nil
+}
+
+// openIndex opens the index of the local store in dir, or the default
+// store, and brings it up to date with the store. With reindex, the index
+// is rebuilt from scratch.
+func (c *CLI) openIndex(dir string, reindex bool) (*index.Index, error) {
+	if dir == "" {
+		var err error
+		if dir, err = store.DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	path := index.Path(dir)
+	if reindex {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove index: %w", err)
+		}
+	}
+
+	x, err := index.Open(path)
+	if err != nil {
+		return nil, withExitCode(ExitIO, err)
+	}
+	n, err := x.Sync(context.Background(), store.NewFS(dir))
+	if err != nil {
+		x.Close()
+		return nil, withExitCode(ExitIO, fmt.Errorf("failed to update index: %w", err))
+	}
+	c.log.Debug("index updated", "path", path, "documents", n)
+	return x, nil
+}
+
+// runSearch searches the index of the local store in dir
+func (c *CLI) runSearch(dir string, q index.Query, reindex bool) error {
+	x, err := c.openIndex(dir, reindex)
+	if err != nil {
+		return err
+	}
+	defer x.Close()
+
+	results, err := x.Search(context.Background(), q)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+
+	if c.outputFormat == "json" {
+		return printJSON(results)
+	}
+	if len(results) == 0 {
+		if !c.quiet {
+			fmt.Println("No documents found")
+		}
+		return nil
+	}
+	for _, r := range results {
+		fmt.Printf("%s v%d", r.ID, r.Version)
+		var about []string
+		for _, s := range []string{r.Type, r.Date} {
+			if s != "" {
+				about = append(about, s)
+			}
+		}
+		if r.Title != "" {
+			fmt.Printf(" %s", r.Title)
+		}
+		if len(about) > 0 {
+			fmt.Printf(" (%s)", strings.Join(about, ", "))
+		}
+		fmt.Println()
+		for _, m := range r.Matches {
+			where := m.Section
+			if where == "" {
+				where = "definition"
+			}
+			fmt.Printf("  %s %s: %s\n", where, m.Title, strings.ReplaceAll(m.Snippet, "\n", " "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/sections"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addSectionsCommand adds the sections command and its subcommands
+func (c *CLI) addSectionsCommand() {
+	sectionsCmd := &cobra.Command{
+		Use:   "sections",
+		Short: "Manage the sections of an NLD document",
+		Long:  "List, add, remove, move and renumber the sections of a document",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list [file]",
+		Short: "List the sections of a document in order",
+		Long:  "List the sections of a document in order. Nested subsections are indented beneath their parent.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runSectionsList(args[0])
+		},
+	}
+
+	var id, title, content string
+	var addPos sections.Position
+	addCmd := &cobra.Command{
+		Use:     "add [file]",
+		Short:   "Add a section to a document",
+		Long:    "Add a section to a document. The section is appended unless --before, --after or --position is given.",
+		Example: `  nld sections add contract.json --id warranty --title "Warranty" --after terms`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			section := map[string]interface{}{"id": id, "title": title, "content": content}
+			return c.runSectionsAdd(args[0], section, addPos)
+		},
+	}
+	addCmd.Flags().StringVar(&id, "id", "", "Section identifier")
+	addCmd.Flags().StringVar(&title, "title", "", "Section title")
+	addCmd.Flags().StringVar(&content, "content", "", "Section content")
+	addPositionFlags(addCmd, &addPos)
+	addCmd.MarkFlagRequired("id")
+	addCmd.MarkFlagRequired("title")
+
+	var force bool
+	removeCmd := &cobra.Command{
+		Use:   "remove [file] [id]",
+		Short: "Remove a section from a document",
+		Long: `Remove a section from a document, with its subsections. Sections referenced
+by relationships, the predicates or effects of conditions, annotations or
+redlines are kept unless --force is given, in which case those references are
+removed too.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runSectionsRemove(args[0], args[1], force)
+		},
+	}
+	removeCmd.Flags().BoolVar(&force, "force", false, "Remove the section and the relationships, conditions, annotations and redlines that reference it")
+
+	var movePos sections.Position
+	moveCmd := &cobra.Command{
+		Use:   "move [file] [id]",
+		Short: "Move a section within a document",
+		Long: `Move a section within a document. Relationships refer to sections by ID and
+are left intact. Without --before, --after or --position the section is moved
+to the end.`,
+		Example: `  nld sections move contract.json terms --before signatures`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runSectionsMove(args[0], args[1], movePos)
+		},
+	}
+	addPositionFlags(moveCmd, &movePos)
+
+	var prefix string
+	renumberCmd := &cobra.Command{
+		Use:   "renumber [file]",
+		Short: "Renumber section IDs in document order",
+		Long: `Rename every section to the prefix followed by its position (section1,
+section2, ...) and update the relationships, conditions, annotations and
+redlines that reference them.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runSectionsRenumber(args[0], prefix)
+		},
+	}
+	renumberCmd.Flags().StringVar(&prefix, "prefix", "section", "Prefix for the new section IDs")
+
+	sectionsCmd.AddCommand(listCmd, addCmd, removeCmd, moveCmd, renumberCmd)
+	c.rootCmd.AddCommand(sectionsCmd)
+}
+
+// addPositionFlags adds the flags that select a section position
+func addPositionFlags(cmd *cobra.Command, pos *sections.Position) {
+	cmd.Flags().StringVar(&pos.Before, "before", "", "Place the section before this section")
+	cmd.Flags().StringVar(&pos.After, "after", "", "Place the section after this section")
+	cmd.Flags().IntVar(&pos.Index, "position", 0, "Place the section at this 1-based position")
+	cmd.MarkFlagsMutuallyExclusive("before", "after", "position")
+}
+
+// runSectionsList runs the sections list command
+func (c *CLI) runSectionsList(filePath string) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+
+	type sectionInfo struct {
+		ID     string `json:"id"`
+		Title  string `json:"title"`
+		Parent string `json:"parent,omitempty"`
+		// Position is the 1-based position among the section's siblings
+		Position int `json:"position"`
+		depth    int
+	}
+	var list []sectionInfo
+	positions := map[string]int{}
+	for _, ref := range doc.AllSections() {
+		positions[ref.Parent]++
+		list = append(list, sectionInfo{
+			ID:       document.String(ref.Section, "id"),
+			Title:    document.String(ref.Section, "title"),
+			Parent:   ref.Parent,
+			Position: positions[ref.Parent],
+			depth:    ref.Depth,
+		})
+	}
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+		return nil
+	}
+
+	for _, s := range list {
+		fmt.Printf("%s%d\t%s\t%s\n", strings.Repeat("  ", s.depth), s.Position, s.ID, s.Title)
+	}
+	return nil
+}
+
+// runSectionsAdd runs the sections add command
+func (c *CLI) runSectionsAdd(filePath string, section map[string]interface{}, pos sections.Position) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := sections.Add(doc, section, pos); err != nil {
+		return err
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Added section %s to %s", section["id"], filePath)))
+	}
+	return nil
+}
+
+// runSectionsRemove runs the sections remove command
+func (c *CLI) runSectionsRemove(filePath, id string, force bool) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+
+	removed, err := sections.Remove(doc, id, force)
+	if err != nil {
+		return err
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Removed section %s from %s", id, filePath)))
+		if removed > 0 {
+			fmt.Printf("Removed %d relationship(s), condition(s), annotation(s) or redline change(s) referencing %s\n", removed, id)
+		}
+	}
+	return nil
+}
+
+// runSectionsMove runs the sections move command
+func (c *CLI) runSectionsMove(filePath, id string, pos sections.Position) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := sections.Move(doc, id, pos); err != nil {
+		return err
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Moved section %s to position %d in %s", id, sections.Index(doc, id)+1, filePath)))
+	}
+	return nil
+}
+
+// runSectionsRenumber runs the sections renumber command
+func (c *CLI) runSectionsRenumber(filePath, prefix string) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+
+	renamed, err := sections.Renumber(doc, prefix)
+	if err != nil {
+		return err
+	}
+	if len(renamed) == 0 {
+		if !c.quiet {
+			fmt.Printf("Sections in %s are already numbered\n", filePath)
+		}
+		return nil
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Renumbered %d section(s) in %s", len(renamed), filePath)))
+		if c.verbose {
+			for _, s := range doc.Sections() {
+				newID := document.String(s, "id")
+				for oldID, id := range renamed {
+					if id == newID {
+						fmt.Printf("  %s -> %s\n", oldID, newID)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
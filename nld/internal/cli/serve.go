@@ -0,0 +1,294 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/approval"
+	"github.com/colemalphrus/nld/internal/auth"
+	"github.com/colemalphrus/nld/internal/server"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/internal/webhook"
+	"github.com/spf13/cobra"
+)
+
+// addServeCommand adds the serve command
+func (c *CLI) addServeCommand() {
+	var addr string
+	var storeDir, storeRemote string
+	var webhooksPath string
+	var workflowsPath string
+	var authPath, auditPath string
+	var noStore, noMetrics, printOpenAPI bool
+	var maxSize string
+	var rateLimit float64
+	var burst int
+	var timeout time.Duration
+	var maxInFlight int
+
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the nld HTTP API",
+		Long: `Start an HTTP server that validates, renders and converts documents, for
+services that cannot run nld themselves. Requests and responses are JSON:
+
+  GET  /v1/health
+  GET  /metrics
+  GET  /openapi.json
+  POST /v1/validate   {"document": {...}}
+  POST /v1/render     {"document": {...}, "format": "html"}
+  POST /v1/convert    {"format": "markdown", "text": "..."}
+  POST /v1/sign       {"document": {...}}
+
+Documents of the document store are served at /v1/documents: GET lists
+them, PUT /v1/documents/{id} stores one and GET returns it. The store is the
+one used by nld store unless --store-dir or --store-remote gives another;
+--no-store turns these endpoints off.
+
+/metrics serves Prometheus metrics: validations by document type and
+result, validation and render durations, schema and clause cache hits and
+misses, requests by route and status, and errors by class. It needs no
+credentials; --no-metrics turns it off.
+
+/openapi.json serves the OpenAPI 3 document of the API, from which client
+SDKs can be generated for other languages; --print-openapi prints it and
+exits. It describes the server as configured by the other flags, such as
+the security of --auth and the 429 responses of --rate-limit.
+
+With OTEL_EXPORTER_OTLP_ENDPOINT set, every request is traced with
+OpenTelemetry, continuing the trace of its traceparent header.
+
+/v1/sign records the digest of a document in verification.digest, as
+nld hash --write does, and returns the document with its root hash.
+
+With --workflows, stored documents of the types listed need the approval of
+their approvers before they are signed. The config file lists the
+approvers of each type, by the names of their API keys or OIDC subjects,
+and how many of them must approve (all by default):
+
+  workflows:
+    - type: contract
+      approvers: [legal, finance, sales]
+      required: 2
+      attestations: [notary]
+      attesters: [sha256:...]
+
+Approvers POST /v1/documents/{id}/approvals, optionally with {"comment"},
+and need the approve scope. Approvals are recorded in
+verification.approvals with the root hash of the document, so that they no
+longer count once it changes, and move it to review and then approved.
+Workflows with attestations also wait for a verified attestation of each
+role, recorded with nld attest by one of the keys of attesters if given.
+GET /v1/documents/{id}/workflow returns the approvals and attestations given
+and pending, and POST /v1/documents/{id}/sign records the digest of the
+stored document once they are complete, storing the result as its next
+version. Documents of
+those types are refused by /v1/sign with 409.
+
+With --webhooks, the server notifies URLs of validated, signed, stored,
+deleted and approved documents. The config file lists the webhooks:
+
+  webhooks:
+    - url: https://crm.example.com/hooks/nld
+      secret-env: CRM_WEBHOOK_SECRET
+      events: [document.signed, document.stored]
+
+Deliveries are POSTed JSON events of the form {"id", "type", "time",
+"data"}, with the headers NLD-Event, NLD-Delivery and NLD-Timestamp. With a
+secret, NLD-Signature holds sha256= and the hex HMAC-SHA256 of the
+timestamp, a dot and the body. Deliveries failing with a network error or a
+status of 429 or 5xx are retried 5 times with exponential backoff.
+
+With --auth, every endpoint but health needs an API key or an OIDC bearer
+token, and the scopes granted to the client decide the endpoints it may
+use: validate for validate, render and convert, sign for sign and the sign
+endpoint of stored documents, approve for approvals, and store for the other
+documents endpoints. The config file lists the keys by the SHA-256 of
+the key, or the environment variable holding it, and the OIDC issuer:
+
+  api-keys:
+    - name: billing
+      sha256: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+      scopes: [validate, sign]
+    - name: archive
+      key-env: NLD_ARCHIVE_KEY
+      scopes: [store]
+  oidc:
+    issuer: https://login.example.com
+    audience: nld
+    scope-prefix: "nld:"
+
+Clients send keys as Authorization: Bearer KEY or X-API-Key: KEY. OIDC
+tokens are verified with the keys of the issuer, and are granted the
+scopes of their scope or scp claim that start with scope-prefix, and the
+scopes listed under oidc.scopes. Every authenticated operation and every
+denied request is written to the audit log, as JSON lines appended to
+--audit-log, or written to standard error otherwise.
+
+To keep one client from exhausting the service, request bodies are limited
+to --max-document-size (413), documents that take longer than --timeout to
+process get 503, and with --rate-limit every client may make that many
+requests a second, in bursts of up to --burst, before getting 429 with a
+Retry-After header. The limit applies to every IP address before its
+requests are authenticated, and with --auth to every client by its
+credentials as well. At most --max-in-flight documents are processed at
+once; work on a document whose request timed out is stopped where it can
+be and still counts until it ends, and requests wait for their turn within
+--timeout.
+
+Documents are validated against the schemas built into nld. Go programs can
+call the API with the package github.com/colemalphrus/nld/pkg/nldclient.
+
+The server listens on localhost only unless --addr gives another address,
+and runs until it is interrupted.`,
+		Example: `  nld serve
+  nld serve --addr :8080
+  nld serve --webhooks webhooks.yaml
+  nld serve --auth auth.yaml --workflows workflows.yaml
+  nld serve --addr :8080 --auth auth.yaml --audit-log audit.jsonl
+  nld serve --rate-limit 5 --burst 20 --max-document-size 4MB --timeout 10s
+  nld serve --auth auth.yaml --print-openapi > openapi.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var s store.Store
+			// The OpenAPI document does not depend on the store
+			if !noStore && !printOpenAPI {
+				var err error
+				if s, err = c.openStore(storeDir, storeRemote); err != nil {
+					return err
+				}
+			}
+			size, err := parseSize(maxSize)
+			if err != nil {
+				return withExitCode(ExitUsage, fmt.Errorf("invalid --max-document-size: %w", err))
+			}
+			if rateLimit < 0 || burst < 0 || timeout < 0 || maxInFlight < 0 {
+				return withExitCode(ExitUsage, fmt.Errorf("--rate-limit, --burst, --timeout and --max-in-flight cannot be negative"))
+			}
+			opts := server.Options{
+				Version:         Version,
+				Log:             c.log,
+				Store:           s,
+				MaxDocumentSize: size,
+				RateLimit:       rateLimit,
+				Burst:           burst,
+				Timeout:         timeout,
+				MaxInFlight:     maxInFlight,
+				Metrics:         !noMetrics,
+			}
+			if webhooksPath != "" {
+				hooks, err := webhook.Load(webhooksPath)
+				if err != nil {
+					return withExitCode(ExitUsage, err)
+				}
+				opts.Webhooks = webhook.New(hooks, webhook.Options{Log: c.log})
+			}
+			if workflowsPath != "" {
+				if opts.Workflows, err = approval.Load(workflowsPath); err != nil {
+					return withExitCode(ExitUsage, err)
+				}
+			}
+			if authPath != "" {
+				config, err := auth.Load(authPath)
+				if err != nil {
+					return withExitCode(ExitUsage, err)
+				}
+				if opts.Auth, err = auth.New(config, nil); err != nil {
+					return withExitCode(ExitUsage, err)
+				}
+				opts.Audit = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+			}
+			if printOpenAPI {
+				return printJSON(server.New(opts).OpenAPI())
+			}
+			if auditPath != "" {
+				f, err := os.OpenFile(auditPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+				if err != nil {
+					return withExitCode(ExitIO, fmt.Errorf("failed to open audit log: %w", err))
+				}
+				defer f.Close()
+				opts.Audit = slog.New(slog.NewJSONHandler(f, nil))
+			}
+			return c.runServe(addr, opts)
+		},
+	}
+
+	serveCmd.Flags().StringVar(&addr, "addr", "localhost:8080", "Address the server listens on (port 0 picks a free port)")
+	serveCmd.Flags().StringVar(&storeDir, "store-dir", "", "Directory of the document store (default: $"+store.DirEnv+" or ~/.nld/store)")
+	serveCmd.Flags().StringVar(&storeRemote, "store-remote", "", "Serve the store in a bucket URL or named remote instead")
+	serveCmd.Flags().StringVar(&webhooksPath, "webhooks", "", "Webhooks config file of URLs notified of document events")
+	serveCmd.Flags().StringVar(&workflowsPath, "workflows", "", "Workflows config file of the approvers of each document type")
+	serveCmd.Flags().StringVar(&authPath, "auth", "", "Auth config file of API keys and OIDC issuer; requests must then authenticate")
+	serveCmd.Flags().StringVar(&auditPath, "audit-log", "", "File the audit log of authenticated operations is appended to, as JSON lines")
+	serveCmd.Flags().StringVar(&maxSize, "max-document-size", "32MB", "Largest request body accepted, in bytes or with a KB, MB or GB suffix")
+	serveCmd.Flags().Float64Var(&rateLimit, "rate-limit", 0, "Requests a second each client may make (default: no limit)")
+	serveCmd.Flags().IntVar(&burst, "burst", 0, "Requests a client may make at once under --rate-limit (default: the rate rounded up)")
+	serveCmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "Longest time spent processing a document, 0 for no limit")
+	serveCmd.Flags().IntVar(&maxInFlight, "max-in-flight", server.DefaultMaxInFlight, "Documents processed at once, counting those still processed after their request timed out")
+	serveCmd.Flags().BoolVar(&noStore, "no-store", false, "Do not serve the document store")
+	serveCmd.Flags().BoolVar(&noMetrics, "no-metrics", false, "Do not serve Prometheus metrics at /metrics")
+	serveCmd.Flags().BoolVar(&printOpenAPI, "print-openapi", false, "Print the OpenAPI document of the API and exit")
+
+	c.rootCmd.AddCommand(serveCmd)
+}
+
+// runServe serves the API until interrupted
+func (c *CLI) runServe(addr string, opts server.Options) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+	if !c.quiet {
+		fmt.Printf("Serving the nld API at http://%s (press Ctrl+C to stop)\n", listener.Addr())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	srv := &http.Server{
+		Handler:           server.New(opts),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+		// Pending webhook deliveries get a little longer to finish
+		deliveries, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		opts.Webhooks.Close(deliveries)
+	}()
+
+	if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+	return nil
+}
+
+// parseSize parses a size in bytes, with an optional KB, MB or GB suffix
+// in powers of 1024
+func parseSize(s string) (int64, error) {
+	n := strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for suffix, size := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if strings.HasSuffix(n, suffix) {
+			n, unit = strings.TrimSpace(strings.TrimSuffix(n, suffix)), size
+			break
+		}
+	}
+	n = strings.TrimSuffix(n, "B")
+	v, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("%q is not a size", s)
+	}
+	return v * unit, nil
+}
@@ -0,0 +1,693 @@
+package cli
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/attest"
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/entity"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/signing"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addSignCommand adds the sign command
+func (c *CLI) addSignCommand() {
+	var signerID string
+	var keyPath string
+	var configPath string
+	var certPath string
+	var keyName string
+	var passphraseFile string
+	var detached bool
+	var outputPath string
+	var assemble []string
+	var force bool
+
+	signCmd := &cobra.Command{
+		Use:   "sign [file]",
+		Short: "Sign a document for an entity",
+		Long: `Sign the root hash of a document, as nld hash computes it, for one of its
+entities, and add the signature to verification.signatures. The digest
+record is stored too, so that nld verify can locate later changes.
+
+The key is a PEM private key given with --key, a key of the local keyring
+given with --keyring-key (see nld keys), or the key of the signing config:
+--signing-config, $` + signing.ConfigEnv + ` or ~/.nld/signing.yaml. The config
+selects a key provider, so that production keys can stay in an HSM or a
+cloud KMS:
+
+  file     a PEM private key, which may be encrypted with nld encrypt
+  keyring  a key of the local keyring
+  pkcs11   a key in a PKCS#11 token, used through pkcs11-tool
+  awskms   a key in AWS KMS
+  gcpkms   a key version in Google Cloud KMS
+
+For example:
+
+  provider: awskms
+  key: alias/contracts
+  region: us-east-1
+  certificates: contracts-chain.pem
+
+The X.509 certificate chain of the key, leaf first, is embedded in the
+signature when the config names it in certificates, or --cert gives it, so
+that verifiers can trust the key through its certificate authority.
+
+In signing ceremonies where the parties sign apart, each party signs with
+--detached, which leaves the document alone and writes the signature to a
+file of its own, <file>.<signer>.sig by default. The signature files are
+exchanged out of band, and a coordinator adds them to the document with
+--assemble, which checks that every one of them signs the current root hash
+of the document and adds none of them otherwise.
+
+An entity signs a document once. Signing again for an entity that already
+signed it is refused unless --force is given, in which case the new
+signature replaces the earlier ones of the entity.
+
+Check signatures with nld verify-signature.`,
+		Example: `  nld sign contract.json --signer party1 --key party1.pem
+  nld sign contract.json --signer party1
+  nld sign contract.json --signer party1 --keyring-key party1 --passphrase-file passphrase.txt
+  nld sign contract.json --signer party1 --key party1.pem --cert party1-chain.pem
+  nld sign contract.json --signer party2 --key party2.pem --detached
+  nld sign contract.json --assemble contract.party1.sig --assemble contract.party2.sig`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(assemble) > 0 {
+				detail := fmt.Sprintf("assembled %d detached signature(s)", len(assemble))
+				return c.audited(audit.Sign, args[0], args[0], detail, nil, c.runAssemble(args[0], assemble, force))
+			}
+			if outputPath != "" && !detached {
+				return withExitCode(ExitUsage, fmt.Errorf("--output is only used with --detached"))
+			}
+			cfg, err := signingConfig(keyPath, keyName, passphraseFile, configPath)
+			if err != nil {
+				return err
+			}
+			if certPath != "" {
+				cfg.Certificates = certPath
+			}
+			if detached {
+				if outputPath == "" {
+					outputPath = strings.TrimSuffix(args[0], filepath.Ext(args[0])) + "." + signerID + ".sig"
+				}
+				return c.audited(audit.Sign, args[0], args[0], "signed by "+signerID+" in "+outputPath, nil, c.runSignDetached(args[0], outputPath, signerID, cfg, force))
+			}
+			return c.audited(audit.Sign, args[0], args[0], "signed by "+signerID, nil, c.runSign(args[0], signerID, cfg, force))
+		},
+	}
+
+	signCmd.Flags().StringVar(&signerID, "signer", "", "ID of the entity signing")
+	signCmd.Flags().StringVar(&keyPath, "key", "", "PEM private key file to sign with, instead of the signing config")
+	signCmd.Flags().StringVar(&keyName, "keyring-key", "", "Name of the key of the local keyring to sign with, instead of the signing config")
+	signCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase of an encrypted signing key")
+	signCmd.Flags().StringVar(&configPath, "signing-config", "", "Signing config file (default: $"+signing.ConfigEnv+" or ~/.nld/signing.yaml)")
+	signCmd.Flags().StringVar(&certPath, "cert", "", "PEM certificate chain of the key, leaf first, to embed in the signature")
+	signCmd.Flags().BoolVar(&detached, "detached", false, "Write the signature to a file of its own instead of adding it to the document")
+	signCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path of a detached signature (default: <file>.<signer>.sig)")
+	signCmd.Flags().StringArrayVar(&assemble, "assemble", nil, "Detached signature file to add to the document (repeatable)")
+	signCmd.Flags().BoolVar(&force, "force", false, "Replace the signature of an entity that already signed the document")
+	signCmd.MarkFlagsOneRequired("signer", "assemble")
+	signCmd.MarkFlagsMutuallyExclusive("signer", "assemble")
+	signCmd.MarkFlagsMutuallyExclusive("detached", "assemble")
+
+	c.rootCmd.AddCommand(signCmd)
+}
+
+// addVerifySignatureCommand adds the verify-signature command
+func (c *CLI) addVerifySignatureCommand() {
+	var keyfile string
+	var passphraseFile string
+	var policyPath string
+
+	verifySignatureCmd := &cobra.Command{
+		Use:   "verify-signature [file...]",
+		Short: "Verify the signatures of documents",
+		Long: `Verify every cryptographic signature in verification.signatures against the
+root hash of the document. Signatures without an algorithm, such as those
+brought in by nld esign sync, are listed but not checked.
+
+A document redacted with nld redact or nld privacy erase is checked against
+the root hash recorded in verification.digest, as long as nld verify shows
+that every part changed since is a redaction.
+
+A trust policy, given with --trust-policy or $` + signing.TrustPolicyEnv + `, also decides
+whether the keys of matching signatures are trusted:
+
+  roots: [root-ca.pem]
+  intermediates: [signing-ca.pem]
+  keys: [sha256:...]
+  keyUsages: [digitalSignature, contentCommitment]
+  extKeyUsages: [documentSigning, emailProtection]
+  revocation: any
+
+A key is trusted when it is listed in keys, or the certificate chain
+embedded in the signature leads to one of the roots, was valid on the date
+of the signature and allows one of the usages. Revocation is checked with
+the CRL (crl) or OCSP responder (ocsp) of each certificate, or either (any).
+Signatures that match but are not trusted are reported as untrusted.
+
+Signed attestations of third parties, such as those of nld attest, are
+checked the same way.
+
+The command fails when a signature or attestation does not match or is
+untrusted, or a document has no cryptographic signature.`,
+		Example: `  nld verify-signature contract.json
+  nld verify-signature contracts/ --output-format json
+  nld verify-signature contract.json --trust-policy trust.yaml`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := expandPaths(args)
+			if err != nil {
+				return err
+			}
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			var policy *signing.Policy
+			if policyPath != "" {
+				if policy, err = signing.LoadPolicy(policyPath); err != nil {
+					return err
+				}
+			}
+			return c.runVerifySignature(files, key, policy)
+		},
+	}
+
+	verifySignatureCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	verifySignatureCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+	verifySignatureCmd.Flags().StringVar(&policyPath, "trust-policy", os.Getenv(signing.TrustPolicyEnv), "Trust policy file deciding which keys are trusted (default: $"+signing.TrustPolicyEnv+")")
+
+	c.rootCmd.AddCommand(verifySignatureCmd)
+}
+
+// signingConfig selects the signing key: a PEM file, a key of the local
+// keyring, or else the key of the signing config
+func signingConfig(keyPath, keyName, passphraseFile, configPath string) (*signing.Config, error) {
+	switch {
+	case keyPath != "" && keyName != "":
+		return nil, withExitCode(ExitUsage, fmt.Errorf("--key and --keyring-key cannot be used together"))
+	case keyPath != "":
+		return &signing.Config{Provider: signing.File, Key: keyPath, PassphraseFile: passphraseFile}, nil
+	case keyName != "":
+		return &signing.Config{Provider: signing.KeyringProvider, Key: keyName, PassphraseFile: passphraseFile}, nil
+	}
+	cfg, err := signing.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if passphraseFile != "" {
+		cfg.PassphraseFile = passphraseFile
+	}
+	return cfg, nil
+}
+
+// runSign signs a document for an entity with the key of cfg
+func (c *CLI) runSign(filePath, signerID string, cfg *signing.Config, force bool) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+	if err := checkSigned(doc, filePath, signerID, force); err != nil {
+		return err
+	}
+	rec, sig, err := c.signDocument(filePath, doc, signerID, cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := digest.Store(doc, rec); err != nil {
+		return err
+	}
+	removeSignatures(doc, signerID)
+	if err := addSignature(doc, sig); err != nil {
+		return err
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if c.outputFormat == "json" {
+		return printJSON(sig)
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Signed %s for %s with %s key %s", filePath, signerID, sig.Algorithm, sig.KeyID)))
+	}
+	return nil
+}
+
+// runSignDetached signs a document for an entity with the key of cfg and
+// writes the signature to outputPath, leaving the document unchanged
+func (c *CLI) runSignDetached(filePath, outputPath, signerID string, cfg *signing.Config, force bool) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+	if err := checkSigned(doc, filePath, signerID, force); err != nil {
+		return err
+	}
+	rec, sig, err := c.signDocument(filePath, doc, signerID, cfg)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(signing.NewDetached(document.String(doc.Metadata(), "title"), rec.Root, sig), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := c.writeOutput(outputPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Signed %s for %s with %s key %s in %s", filePath, signerID, sig.Algorithm, sig.KeyID, outputPath)))
+		fmt.Printf("Root: %s\n", rec.Root)
+	}
+	return nil
+}
+
+// runAssemble adds detached signatures to a document. Every signature must
+// be that of an entity and verify against the current root hash of the
+// document, or none is added. Signatures of entities that already signed
+// the document, or that another file signs for, are refused unless force
+// is set, in which case each replaces the earlier ones of its entity.
+func (c *CLI) runAssemble(filePath string, sigPaths []string, force bool) error {
+	doc, err := c.loadDocument(filePath, nil)
+	if err != nil {
+		return err
+	}
+	rec, err := digest.Compute(doc)
+	if err != nil {
+		return fmt.Errorf("failed to hash document: %w", err)
+	}
+	// Signature values are unique, so the same signature is only added once
+	signed := map[string]bool{}
+	list, _ := doc.Verification(false)["signatures"].([]interface{})
+	for _, raw := range list {
+		if sig, ok := raw.(map[string]interface{}); ok {
+			signed[document.String(sig, "value")] = true
+		}
+	}
+
+	var sigs []nld.Signature
+	assembled := map[string]bool{}
+	for _, path := range sigPaths {
+		data, err := c.readInput(path)
+		if err != nil {
+			return err
+		}
+		d, err := signing.ParseDetached(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if !isEntity(doc, d.Signature.SignerID) {
+			return withExitCode(ExitUsage, fmt.Errorf("%s: signer %s is not an entity of %s", path, d.Signature.SignerID, filePath))
+		}
+		if err := d.Check(rec.Root); err != nil {
+			return withExitCode(ExitSignature, fmt.Errorf("%s: %w", path, err))
+		}
+		if signed[d.Signature.Value] {
+			c.log.Debug("skipping signature already in document", "file", path, "signer", d.Signature.SignerID)
+			continue
+		}
+		if assembled[d.Signature.SignerID] && !force {
+			return withExitCode(ExitUsage, fmt.Errorf("%s: another signature file signs for %s (use --force to keep the last one)", path, d.Signature.SignerID))
+		}
+		if err := checkSigned(doc, filePath, d.Signature.SignerID, force); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		signed[d.Signature.Value] = true
+		assembled[d.Signature.SignerID] = true
+		sigs = append(sigs, d.Signature)
+	}
+
+	if err := digest.Store(doc, rec); err != nil {
+		return err
+	}
+	for _, sig := range sigs {
+		removeSignatures(doc, sig.SignerID)
+		if err := addSignature(doc, sig); err != nil {
+			return err
+		}
+	}
+	if err := c.saveDocument(filePath, doc); err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		for _, sig := range sigs {
+			fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Added the signature of %s with %s key %s to %s", sig.SignerID, sig.Algorithm, sig.KeyID, filePath)))
+		}
+		if skipped := len(sigPaths) - len(sigs); skipped > 0 {
+			fmt.Printf("%d signature(s) already in %s\n", skipped, filePath)
+		}
+	}
+	return nil
+}
+
+// signDocument signs the root hash of doc, read from filePath, for an
+// entity with the key of cfg
+func (c *CLI) signDocument(filePath string, doc document.Document, signerID string, cfg *signing.Config) (*digest.Record, nld.Signature, error) {
+	if !isEntity(doc, signerID) {
+		return nil, nld.Signature{}, withExitCode(ExitUsage, fmt.Errorf("signer %s is not an entity of %s", signerID, filePath))
+	}
+	rec, err := digest.Compute(doc)
+	if err != nil {
+		return nil, nld.Signature{}, fmt.Errorf("failed to hash document: %w", err)
+	}
+
+	signer, err := signing.Open(c.ctx, cfg)
+	if err != nil {
+		return nil, nld.Signature{}, err
+	}
+	if err := checkRegisteredKey(doc, signerID, signer.Public()); err != nil {
+		return nil, nld.Signature{}, withExitCode(ExitUsage, err)
+	}
+	sig, err := signing.Sign(signer, rec.Root, signerID, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, nld.Signature{}, err
+	}
+	if cfg.Certificates != "" {
+		chain, err := signing.LoadCertificates(cfg.Certificates)
+		if err != nil {
+			return nil, nld.Signature{}, err
+		}
+		if err := signing.Attach(&sig, chain); err != nil {
+			return nil, nld.Signature{}, err
+		}
+	}
+	return rec, sig, nil
+}
+
+// checkRegisteredKey checks that pub is one of the keys registered for an
+// entity, if it has any
+func checkRegisteredKey(doc document.Document, signerID string, pub crypto.PublicKey) error {
+	for _, e := range entity.List(doc) {
+		if e.ID != signerID || len(e.KeyIDs) == 0 {
+			continue
+		}
+		id, err := signing.KeyID(pub)
+		if err != nil {
+			return err
+		}
+		for _, registered := range e.KeyIDs {
+			if registered == id {
+				return nil
+			}
+		}
+		return fmt.Errorf("key %s is not registered for entity %s (registered: %s)", id, signerID, strings.Join(e.KeyIDs, ", "))
+	}
+	return nil
+}
+
+// checkSigned refuses a signature by an entity that already signed doc,
+// unless force is set
+func checkSigned(doc document.Document, filePath, signerID string, force bool) error {
+	if force {
+		return nil
+	}
+	list, _ := doc.Verification(false)["signatures"].([]interface{})
+	for _, raw := range list {
+		if sig, ok := raw.(map[string]interface{}); ok && document.String(sig, "signerId") == signerID {
+			return withExitCode(ExitUsage, fmt.Errorf("%s is already signed by %s (use --force to replace its signature)", filePath, signerID))
+		}
+	}
+	return nil
+}
+
+// removeSignatures removes the signatures of an entity from
+// verification.signatures
+func removeSignatures(doc document.Document, signerID string) {
+	verification := doc.Verification(false)
+	list, _ := verification["signatures"].([]interface{})
+	kept := list[:0:0]
+	for _, raw := range list {
+		if sig, ok := raw.(map[string]interface{}); ok && document.String(sig, "signerId") == signerID {
+			continue
+		}
+		kept = append(kept, raw)
+	}
+	if len(kept) < len(list) {
+		verification["signatures"] = kept
+	}
+}
+
+// addSignature appends a signature to verification.signatures
+func addSignature(doc document.Document, sig nld.Signature) error {
+	data, err := json.Marshal(sig)
+	if err != nil {
+		return err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	document.Append(doc.Verification(true), "signatures", raw)
+	return nil
+}
+
+// isEntity reports whether id is the ID of an entity of doc
+func isEntity(doc document.Document, id string) bool {
+	list, _ := doc.Metadata()["entities"].([]interface{})
+	for _, raw := range list {
+		if e, ok := raw.(map[string]interface{}); ok && document.String(e, "id") == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Statuses of a signature reported by verify-signature
+const (
+	signatureValid     = "valid"
+	signatureInvalid   = "invalid"
+	signatureUnsigned  = "unverifiable"
+	signatureUntrusted = "untrusted"
+)
+
+// signatureResult is the outcome of verifying one signature
+type signatureResult struct {
+	SignerID  string `json:"signerId"`
+	Role      string `json:"role,omitempty"`
+	Date      string `json:"date"`
+	Algorithm string `json:"algorithm,omitempty"`
+	KeyID     string `json:"keyId,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// signatureReport is the outcome of verifying the signatures of a document
+type signatureReport struct {
+	File string `json:"file"`
+	Root string `json:"root,omitempty"`
+	// Redacted is the number of parts redacted since the document was
+	// signed
+	Redacted   int               `json:"redacted,omitempty"`
+	Signatures []signatureResult `json:"signatures"`
+	// Attestations are the signed attestations of third parties
+	Attestations []signatureResult `json:"attestations,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// failed reports whether a document has an invalid or untrusted signature
+// or attestation, or no signature that can be verified
+func (r *signatureReport) failed() bool {
+	for _, a := range r.Attestations {
+		if a.Status == signatureInvalid || a.Status == signatureUntrusted {
+			return true
+		}
+	}
+	verified := false
+	for _, s := range r.Signatures {
+		if s.Status == signatureInvalid || s.Status == signatureUntrusted {
+			return true
+		}
+		verified = verified || s.Status == signatureValid
+	}
+	return !verified
+}
+
+// runVerifySignature verifies the signatures of documents, and checks their
+// keys against policy unless it is nil
+func (c *CLI) runVerifySignature(files []string, key *envelope.Key, policy *signing.Policy) error {
+	var reports []*signatureReport
+	failed := 0
+	for _, path := range files {
+		report := c.verifySignatures(path, key, policy)
+		if report.failed() {
+			failed++
+		}
+		reports = append(reports, report)
+	}
+
+	if c.outputFormat == "json" {
+		if err := printJSON(reports); err != nil {
+			return err
+		}
+	} else if !c.quiet {
+		for _, r := range reports {
+			printSignatureReport(r)
+		}
+	}
+
+	var auditErr error
+	for _, r := range reports {
+		var opErr error
+		if r.Error != "" {
+			opErr = errors.New(r.Error)
+		} else if r.failed() {
+			opErr = errors.New("signature verification failed")
+		}
+		detail := fmt.Sprintf("%d signature(s) verified", len(r.Signatures))
+		if err := c.recordAudit(audit.VerifySignature, r.File, r.File, detail, key, opErr); err != nil && auditErr == nil {
+			auditErr = err
+		}
+	}
+
+	if failed > 0 {
+		return withExitCode(ExitSignature, fmt.Errorf("signature verification failed for %d document(s)", failed))
+	}
+	return auditErr
+}
+
+// verifySignatures verifies the signatures of one document
+func (c *CLI) verifySignatures(path string, key *envelope.Key, policy *signing.Policy) *signatureReport {
+	report := &signatureReport{File: path, Signatures: []signatureResult{}}
+	doc, err := c.loadDocument(path, key)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	root, redacted, err := signedRoot(doc)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Root, report.Redacted = root, redacted
+	data, err := doc.Marshal()
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	parsed, err := nld.Parse(data)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	for _, sig := range parsed.Verification.Signatures {
+		report.Signatures = append(report.Signatures, c.checkSignature(sig, signing.Verify(sig, root), policy))
+	}
+	// Attestations without a signature, such as those of nld redact, are
+	// statements of nld itself and are not listed
+	for _, a := range parsed.Verification.Attestations {
+		if a.Algorithm == "" {
+			continue
+		}
+		result := c.checkSignature(attest.Signature(a), attest.Verify(a, root), policy)
+		result.Role = a.Role
+		report.Attestations = append(report.Attestations, result)
+	}
+	return report
+}
+
+// signedRoot returns the root hash that the signatures of doc are over:
+// the root of its digest record when the record is intact and every part
+// changed since is a verified redaction, and its current root otherwise,
+// along with the number of redacted parts
+func signedRoot(doc document.Document) (string, int, error) {
+	current, err := digest.Compute(doc)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to hash document: %w", err)
+	}
+	rec, err := digest.Load(doc)
+	if err != nil || rec == nil || rec.Root == current.Root {
+		return current.Root, 0, err
+	}
+	report, err := digest.Verify(doc, rec)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to verify document: %w", err)
+	}
+	changed := report.Changed()
+	for _, part := range changed {
+		if part.Status != digest.StatusRedacted {
+			return current.Root, 0, nil
+		}
+	}
+	if !report.RecordIntact {
+		return current.Root, 0, nil
+	}
+	return rec.Root, len(changed), nil
+}
+
+// checkSignature returns the result of verifying sig, given the error
+// verifying it returned, and checks its key against policy unless it is nil
+func (c *CLI) checkSignature(sig nld.Signature, err error, policy *signing.Policy) signatureResult {
+	result := signatureResult{SignerID: sig.SignerID, Date: sig.Date, Algorithm: sig.Algorithm, KeyID: sig.KeyID, Status: signatureValid}
+	if errors.Is(err, signing.ErrUnsigned) {
+		result.Status = signatureUnsigned
+	} else if err != nil {
+		result.Status, result.Error = signatureInvalid, err.Error()
+	} else if policy != nil {
+		if err := policy.Check(c.ctx, sig); err != nil {
+			result.Status, result.Error = signatureUntrusted, err.Error()
+		}
+	}
+	return result
+}
+
+// printSignatureReport prints the signatures of a document as text
+func printSignatureReport(r *signatureReport) {
+	switch {
+	case r.Error != "":
+		fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.File, r.Error)))
+		return
+	case r.failed():
+		reason := "no cryptographic signature"
+		for _, s := range append(r.Signatures, r.Attestations...) {
+			kind := "signature"
+			if s.Role != "" {
+				kind = "attestation"
+			}
+			switch {
+			case s.Status == signatureInvalid:
+				reason = "invalid " + kind
+			case s.Status == signatureUntrusted && !strings.HasPrefix(reason, "invalid"):
+				reason = "untrusted " + kind
+			}
+		}
+		fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.File, reason)))
+	default:
+		line := fmt.Sprintf("✓ %s: signatures match root %s", r.File, r.Root)
+		if r.Redacted > 0 {
+			line += fmt.Sprintf(", with %d redacted part(s)", r.Redacted)
+		}
+		fmt.Println(validator.ColoredOutput(true, line))
+	}
+	for _, s := range append(r.Signatures, r.Attestations...) {
+		line := fmt.Sprintf("  - %s, %s: %s", s.SignerID, s.Date, s.Status)
+		if s.Role != "" {
+			line = fmt.Sprintf("  - %s (%s), %s: %s", s.SignerID, s.Role, s.Date, s.Status)
+		}
+		if s.Algorithm != "" {
+			id := strings.TrimPrefix(s.KeyID, digest.Prefix)
+			line += fmt.Sprintf(" (%s key %s)", s.Algorithm, id[:min(16, len(id))])
+		}
+		if s.Error != "" {
+			line += ": " + s.Error
+		}
+		fmt.Println(line)
+	}
+}
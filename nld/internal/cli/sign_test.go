@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/privacy"
+)
+
+// writeKey writes a new PEM private key and returns its path
+func writeKey(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	return writeTestFile(t, "key.pem", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+}
+
+// signers returns the signer IDs of the signatures of the document at path
+func signers(t *testing.T, path string) []string {
+	t.Helper()
+	data, _ := os.ReadFile(path)
+	doc, err := document.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	var ids []string
+	list, _ := doc.Verification(false)["signatures"].([]interface{})
+	for _, raw := range list {
+		ids = append(ids, document.String(raw.(map[string]interface{}), "signerId"))
+	}
+	return ids
+}
+
+func TestSignTwice(t *testing.T) {
+	path := writeTestFile(t, "contract.json", testDocument)
+	key := writeKey(t)
+
+	if _, err := run(t, New(), "sign", path, "--signer", "acme", "--key", key); err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+	_, err := run(t, New(), "sign", path, "--signer", "acme", "--key", key)
+	if ExitCode(err) != ExitUsage || !strings.Contains(err.Error(), "already signed by acme") {
+		t.Errorf("Expected a second signature by acme to be refused, got %v", err)
+	}
+	if _, err := run(t, New(), "sign", path, "--signer", "acme", "--key", key, "--detached"); ExitCode(err) != ExitUsage {
+		t.Errorf("Expected a second detached signature by acme to be refused, got %v", err)
+	}
+
+	if _, err := run(t, New(), "sign", path, "--signer", "xyz", "--key", key); err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+	if _, err := run(t, New(), "sign", path, "--signer", "acme", "--key", key, "--force"); err != nil {
+		t.Fatalf("sign --force failed: %v", err)
+	}
+	if got := strings.Join(signers(t, path), ","); got != "xyz,acme" {
+		t.Errorf("Expected the signature of acme to be replaced, got signers %s", got)
+	}
+	if _, err := run(t, New(), "verify-signature", path); err != nil {
+		t.Errorf("verify-signature failed: %v", err)
+	}
+}
+
+func TestAssembleTwice(t *testing.T) {
+	path := writeTestFile(t, "contract.json", testDocument)
+	key := writeKey(t)
+	first, second := path+".1.sig", path+".2.sig"
+	for _, sig := range []string{first, second} {
+		if _, err := run(t, New(), "sign", path, "--signer", "acme", "--key", key, "--detached", "-o", sig); err != nil {
+			t.Fatalf("sign failed: %v", err)
+		}
+	}
+
+	if _, err := run(t, New(), "sign", path, "--assemble", first, "--assemble", second); ExitCode(err) != ExitUsage {
+		t.Errorf("Expected two signatures by acme to be refused, got %v", err)
+	}
+	if _, err := run(t, New(), "sign", path, "--assemble", first); err != nil {
+		t.Fatalf("sign --assemble failed: %v", err)
+	}
+	if _, err := run(t, New(), "sign", path, "--assemble", second); ExitCode(err) != ExitUsage {
+		t.Errorf("Expected a second signature by acme to be refused, got %v", err)
+	}
+	if _, err := run(t, New(), "sign", path, "--assemble", second, "--force"); err != nil {
+		t.Fatalf("sign --assemble --force failed: %v", err)
+	}
+	if got := signers(t, path); len(got) != 1 {
+		t.Errorf("Expected one signature by acme, got %v", got)
+	}
+}
+
+func TestVerifySignatureRedacted(t *testing.T) {
+	path := writeTestFile(t, "contract.json", testDocument)
+	key := writeKey(t)
+	if _, err := run(t, New(), "sign", path, "--signer", "acme", "--key", key); err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+
+	redacted := path + ".redacted.json"
+	if _, err := run(t, New(), "redact", path, "--section", "fees", "-o", redacted); err != nil {
+		t.Fatalf("redact failed: %v", err)
+	}
+	out, err := run(t, New(), "verify-signature", redacted)
+	if err != nil {
+		t.Fatalf("verify-signature of the redacted document failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "1 redacted part(s)") {
+		t.Errorf("Expected the redaction to be reported, got:\n%s", out)
+	}
+
+	// An erasure is a redaction too
+	data, _ := os.ReadFile(path)
+	doc, err := document.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	if _, err := privacy.Erase(doc, privacy.Find(doc, "xyz"), privacy.Options{Sections: true}); err != nil {
+		t.Fatalf("Erase failed: %v", err)
+	}
+	data, _ = doc.Marshal()
+	erased := writeTestFile(t, "erased.json", string(data))
+	if out, err := run(t, New(), "verify-signature", erased); err != nil {
+		t.Errorf("verify-signature of the erased document failed: %v\n%s", err, out)
+	}
+
+	// Any other change still invalidates the signatures
+	data, _ = os.ReadFile(redacted)
+	changed := writeTestFile(t, "changed.json", strings.Replace(string(data), "XYZ Ltd", "XYZ Inc", 1))
+	if _, err := run(t, New(), "verify-signature", changed); ExitCode(err) != ExitSignature {
+		t.Errorf("Expected a changed document to fail, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/risk"
+	"github.com/colemalphrus/nld/internal/stats"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addStatsCommand adds the stats command
+func (c *CLI) addStatsCommand() {
+	var keyfile string
+	var passphraseFile string
+	var riskOpts riskOptions
+
+	statsCmd := &cobra.Command{
+		Use:   "stats [file...]",
+		Short: "Report statistics about NLD documents",
+		Long: `Report the size and complexity of NLD documents: the number of sections,
+words per section, entities, how many definitions are used in the text, the
+Flesch reading ease and Flesch-Kincaid grade level of the prose and the status
+of the digest record, and a risk score.
+
+Reading ease runs from about 0 (very hard) to 100 (very easy); plain English
+scores 60 to 70 and most contracts score below 30. Word counts and reading
+ease cover paragraphs and list items, not table cells.
+
+The risk score adds up points for every lint finding, every deviation from
+the playbook of --playbook and every clause the jurisdiction profile
+requires but the document lacks, from 0 to 100, and grades the total from
+A to F. --risk-weights changes the points and grades; --verbose lists what
+contributed.
+
+With --output-format json, one object is written per document, for
+dashboards tracking contract complexity.`,
+		Example: `  nld stats contract.json
+  nld stats --output-format json contracts/*.json
+  nld stats --playbook sales.yaml --risk-weights risk.yaml --verbose contract.json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			scorer, err := riskOpts.scorer()
+			if err != nil {
+				return err
+			}
+			return c.runStats(args, key, scorer)
+		},
+	}
+
+	statsCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	statsCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+	riskOpts.addFlags(statsCmd)
+
+	c.rootCmd.AddCommand(statsCmd)
+}
+
+// statsResult is the statistics of one file
+type statsResult struct {
+	File string `json:"file"`
+	*stats.Stats
+	Risk  *risk.Score `json:"risk,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// runStats runs the stats command
+func (c *CLI) runStats(filePaths []string, key *envelope.Key, scorer *riskScorer) error {
+	var results []statsResult
+	var lastErr error
+	failed := 0
+	for _, path := range filePaths {
+		result := statsResult{File: path}
+		raw, err := c.loadDocument(path, key)
+		if err == nil {
+			doc, perr := typedDocument(raw)
+			if perr != nil {
+				err = fmt.Errorf("failed to parse document: %w", perr)
+			} else {
+				result.Stats, err = stats.Compute(raw, doc)
+			}
+			if err == nil {
+				result.Risk, err = scorer.score(raw)
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+			lastErr = err
+			failed++
+			c.log.Debug("stats failed", "file", path, "error", err)
+		}
+		results = append(results, result)
+	}
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+	} else if !c.quiet {
+		for i, r := range results {
+			if i > 0 {
+				fmt.Println()
+			}
+			if r.Error != "" {
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.File, r.Error)))
+				continue
+			}
+			printStats(r.File, r.Stats)
+			c.printRisk("  Risk:         ", r.Risk)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to read %d of %d document(s): %w", failed, len(filePaths), lastErr)
+	}
+	return nil
+}
+
+// printStats prints the statistics of a document as text
+func printStats(file string, s *stats.Stats) {
+	fmt.Printf("%s: %s (%s)\n", file, s.Title, s.Type)
+	fmt.Printf("  Sections:     %d\n", s.Sections)
+	fmt.Printf("  Words:        %d\n", s.Words)
+	fmt.Printf("  Entities:     %d\n", s.Entities)
+	fmt.Printf("  Readability:  %.1f reading ease, grade %.1f\n", s.ReadingEase, s.GradeLevel)
+
+	d := s.Definitions
+	fmt.Printf("  Definitions:  %d of %d used (%.1f%%)\n", d.Used, d.Total, d.Coverage)
+	if len(d.Unused) > 0 {
+		fmt.Printf("                unused: %s\n", strings.Join(d.Unused, ", "))
+	}
+
+	sig := s.Signature
+	status := sig.Status
+	switch sig.Status {
+	case stats.SignatureIntact:
+		status = validator.ColoredOutput(true, status)
+	case stats.SignatureChanged, stats.SignatureTampered:
+		status = validator.ColoredOutput(false, status)
+	}
+	if sig.Changed > 0 {
+		status += fmt.Sprintf(", %d part(s) changed", sig.Changed)
+	}
+	fmt.Printf("  Digest:       %s\n", status)
+	fmt.Printf("  Signatures:   %d\n", sig.Signatures)
+
+	if len(s.PerSection) == 0 {
+		return
+	}
+	fmt.Println("  Per section:")
+	for _, sec := range s.PerSection {
+		label := strings.Repeat("  ", sec.Depth) + sec.ID
+		fmt.Printf("    %-24s %6d words  %6.1f ease  grade %4.1f\n", label, sec.Words, sec.ReadingEase, sec.GradeLevel)
+	}
+}
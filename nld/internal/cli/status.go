@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/internal/workflow"
+	"github.com/spf13/cobra"
+)
+
+// addStatusCommand adds the status command and its subcommands
+func (c *CLI) addStatusCommand() {
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show and change the lifecycle status of documents",
+		Long: `Show and change the lifecycle status of documents, recorded in
+metadata.status. Documents without a status are drafts. The lifecycle is:
+
+  draft     -> review, archived
+  review    -> draft, approved, archived
+  approved  -> review, executed, archived
+  executed  -> archived
+  archived  (final)
+
+Documents are only executed once every entity has signed. The status is not
+part of the root hash, so changing it keeps signatures valid, and nld amend
+puts a document with a status back into draft.`,
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get [file...]",
+		Short: "Show the status of documents",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := expandPaths(args)
+			if err != nil {
+				return err
+			}
+			return c.runStatusGet(files)
+		},
+	}
+
+	var force bool
+	setCmd := &cobra.Command{
+		Use:   "set [file] [status]",
+		Short: "Move a document to another status",
+		Long: `Move a document to another status. The transition must be allowed from its
+current status; --force skips that check and the check that every entity
+has signed before the document is executed.`,
+		Example: `  nld status set contract.json review
+  nld status set contract.json executed`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return statusNames(workflow.Statuses()), cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, err := c.runStatusSet(args[0], workflow.Status(args[1]), force)
+			detail := ""
+			if err == nil {
+				detail = fmt.Sprintf("%s -> %s", from, args[1])
+			}
+			return c.audited(audit.Status, args[0], args[0], detail, nil, err)
+		},
+	}
+	setCmd.Flags().BoolVar(&force, "force", false, "Move the document even if the transition is not allowed")
+
+	statusCmd.AddCommand(getCmd, setCmd)
+	c.rootCmd.AddCommand(statusCmd)
+}
+
+// documentStatus is the status of one document
+type documentStatus struct {
+	File     string   `json:"file"`
+	Status   string   `json:"status,omitempty"`
+	Next     []string `json:"next,omitempty"`
+	Unsigned []string `json:"unsigned,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// runStatusGet runs the status get command
+func (c *CLI) runStatusGet(files []string) error {
+	var results []documentStatus
+	failed := 0
+	for _, path := range files {
+		r := documentStatus{File: path}
+		doc, err := c.loadDocument(path, nil)
+		if err != nil {
+			r.Error = err.Error()
+			failed++
+		} else {
+			status := workflow.Get(doc)
+			r.Status, r.Next, r.Unsigned = string(status), statusNames(workflow.Next(status)), workflow.Unsigned(doc)
+		}
+		results = append(results, r)
+	}
+
+	if c.outputFormat == "json" {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else if !c.quiet {
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.File, r.Error)))
+				continue
+			}
+			fmt.Printf("%s\t%s\n", r.File, r.Status)
+			if c.verbose {
+				next := "none"
+				if len(r.Next) > 0 {
+					next = strings.Join(r.Next, ", ")
+				}
+				fmt.Printf("  next: %s\n", next)
+				if len(r.Unsigned) > 0 {
+					fmt.Printf("  unsigned: %s\n", strings.Join(r.Unsigned, ", "))
+				}
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to read %d document(s)", failed)
+	}
+	return nil
+}
+
+// runStatusSet runs the status set command, returning the status the
+// document was in
+func (c *CLI) runStatusSet(path string, to workflow.Status, force bool) (workflow.Status, error) {
+	doc, err := c.loadDocument(path, nil)
+	if err != nil {
+		return "", err
+	}
+	from, err := workflow.Set(doc, to, force)
+	if err != nil {
+		return from, err
+	}
+	if err := c.saveDocument(path, doc); err != nil {
+		return from, err
+	}
+
+	c.log.Debug("changed document status", "file", path, "from", from, "to", to)
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Moved %s from %s to %s", path, from, to)))
+	}
+	return from, nil
+}
+
+// statusNames returns the names of statuses
+func statusNames(statuses []workflow.Status) []string {
+	names := make([]string, len(statuses))
+	for i, s := range statuses {
+		names[i] = string(s)
+	}
+	return names
+}
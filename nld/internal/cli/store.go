@@ -0,0 +1,362 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addStoreCommand adds the store command and its subcommands
+func (c *CLI) addStoreCommand() {
+	var dir, remote string
+
+	storeCmd := &cobra.Command{
+		Use:   "store",
+		Short: "Manage documents by ID in the document store",
+		Long: `Keep documents in a store and refer to them by ID rather than by path.
+Every change to a document is kept as a new version.
+
+The store is a directory, ~/.nld/store by default; set another one with
+--store-dir or the ` + store.DirEnv + ` environment variable. Encrypted documents
+are stored as they are.
+
+With --remote, the commands use a store in an S3 or Google Cloud Storage
+bucket instead, given by URL (s3://bucket/prefix/ or gs://bucket/prefix/)
+or by the name of a remote in ~/.nld/store.yaml:
+
+  remotes:
+    contracts:
+      url: s3://acme-legal/contracts/
+      region: eu-west-1
+      encryption: aws:kms
+      kms-key-id: alias/legal
+
+S3 credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+AWS_SESSION_TOKEN; Google Cloud Storage uses an HMAC key in
+` + store.GCSAccessKeyEnv + ` and ` + store.GCSSecretKeyEnv + `. Remotes given by URL
+take their server-side encryption from ` + store.EncryptionEnv + ` (AES256 or
+aws:kms) and ` + store.KMSKeyEnv + `.`,
+	}
+	storeCmd.PersistentFlags().StringVar(&dir, "store-dir", "", "Directory of the store (default: $"+store.DirEnv+" or ~/.nld/store)")
+	storeCmd.PersistentFlags().StringVar(&remote, "remote", "", "Use the store in a bucket URL or named remote instead")
+
+	var id string
+	addCmd := &cobra.Command{
+		Use:   "add [file]",
+		Short: "Add a document, or a new version of it, to the store",
+		Long: `Add a document to the store under an ID, by default its file name without
+the .json or .nld.json extension. Adding a document whose ID is already in
+the store adds a new version, unless the content is unchanged.`,
+		Example: `  nld store add lease.json
+  nld store add draft.json --id lease-2024`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := c.openStore(dir, remote)
+			if err != nil {
+				return err
+			}
+			return c.runStoreAdd(s, args[0], id)
+		},
+	}
+	addCmd.Flags().StringVar(&id, "id", "", "Document ID (default: the file name without extension)")
+
+	var version int
+	var outputPath string
+	var force bool
+	getCmd := &cobra.Command{
+		Use:   "get [id]",
+		Short: "Write a stored document",
+		Long:  "Write the latest version of a stored document, or the one given with --version, to standard output or a file.",
+		Example: `  nld store get lease-2024 -o lease.json
+  nld store get lease-2024 --version 1`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := c.openStore(dir, remote)
+			if err != nil {
+				return err
+			}
+			return c.runStoreGet(s, args[0], version, outputPath, force)
+		},
+	}
+	getCmd.Flags().IntVar(&version, "version", 0, "Version to write (default: the latest)")
+	getCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: standard output)")
+	getCmd.Flags().BoolVar(&force, "force", false, "Overwrite existing file if it exists")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the stored documents",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := c.openStore(dir, remote)
+			if err != nil {
+				return err
+			}
+			list, err := s.List(context.Background())
+			if err != nil {
+				return err
+			}
+			return c.printStoreInfos(list)
+		},
+	}
+
+	versionsCmd := &cobra.Command{
+		Use:   "versions [id]",
+		Short: "List the versions of a stored document",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := c.openStore(dir, remote)
+			if err != nil {
+				return err
+			}
+			list, err := s.Versions(context.Background(), args[0])
+			if err != nil {
+				return storeError(err)
+			}
+			return c.printStoreInfos(list)
+		},
+	}
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete [id]",
+		Short: "Delete a stored document and all its versions",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := c.openStore(dir, remote)
+			if err != nil {
+				return err
+			}
+			if err := checkLegalHold(s, args[0]); err != nil {
+				return err
+			}
+			if err := s.Delete(context.Background(), args[0]); err != nil {
+				return storeError(err)
+			}
+			if !c.quiet {
+				fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Deleted %s", args[0])))
+			}
+			return nil
+		},
+	}
+
+	var pushID string
+	pushCmd := &cobra.Command{
+		Use:   "push [file|id]",
+		Short: "Copy a document to a remote store",
+		Long: `Copy a document to the store of a remote, as a new version. The document is
+a file, or the latest version of a document of the local store given by
+its ID. The remote is --remote, or the one named by ` + store.RemoteEnv + `.`,
+		Example: `  nld store push lease.json --remote s3://acme-legal/contracts/
+  nld store push lease-2024 --remote contracts`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target, err := c.openRemote(remote)
+			if err != nil {
+				return err
+			}
+			return c.runStorePush(dir, target, args[0], pushID)
+		},
+	}
+	pushCmd.Flags().StringVar(&pushID, "id", "", "Document ID in the remote store (default: the local ID or file name)")
+
+	storeCmd.AddCommand(addCmd, getCmd, listCmd, versionsCmd, deleteCmd, pushCmd)
+	c.rootCmd.AddCommand(storeCmd)
+}
+
+// openStore opens the store of a remote when one is given, or else the store
+// in dir or the default store
+func (c *CLI) openStore(dir, remote string) (store.Store, error) {
+	if remote != "" {
+		return c.openRemote(remote)
+	}
+	if dir == "" {
+		var err error
+		if dir, err = store.DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	c.log.Debug("opening store", "dir", dir)
+	return store.NewFS(dir), nil
+}
+
+// openRemote opens the store of a remote given by URL or name, or of the
+// remote in $NLD_STORE_REMOTE
+func (c *CLI) openRemote(name string) (store.Store, error) {
+	config, err := store.LoadConfig()
+	if err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+	r, err := config.Resolve(name)
+	if err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+	s, err := store.OpenRemote(r)
+	if err != nil {
+		return nil, withExitCode(ExitUsage, err)
+	}
+	c.log.Debug("opening remote store", "url", r.URL, "encryption", r.Encryption)
+	return s, nil
+}
+
+// storeError marks documents missing from the store with the I/O exit
+// code
+func storeError(err error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return withExitCode(ExitIO, err)
+	}
+	return err
+}
+
+// checkLegalHold refuses to delete a stored document whose latest version
+// is under legal hold. Encrypted documents cannot be checked and are left to
+// the caller.
+func checkLegalHold(s store.Store, id string) error {
+	data, _, err := s.Get(context.Background(), id, 0)
+	if err != nil {
+		return storeError(err)
+	}
+	if envelope.IsEncrypted(data) {
+		return nil
+	}
+	if doc, err := nld.Parse(data); err == nil && doc.Metadata.LegalHold() {
+		return withExitCode(ExitUsage, fmt.Errorf("%s is under legal hold and cannot be deleted until a new version releases the hold", id))
+	}
+	return nil
+}
+
+// documentID returns the default store ID of the document at path
+func documentID(path string) (string, error) {
+	if path == stdio {
+		return "", fmt.Errorf("--id is required when reading standard input")
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".json"), ".nld"), nil
+}
+
+// runStoreAdd adds a document to the store
+func (c *CLI) runStoreAdd(s store.Store, path, id string) error {
+	if id == "" {
+		var err error
+		if id, err = documentID(path); err != nil {
+			return err
+		}
+	}
+	data, err := c.readInput(path)
+	if err != nil {
+		return fmt.Errorf("failed to read document: %w", err)
+	}
+	info, err := s.Put(context.Background(), id, data)
+	if err != nil {
+		return fmt.Errorf("failed to store document: %w", err)
+	}
+
+	if c.outputFormat == "json" {
+		return printJSON(info)
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Stored %s as %s version %d", path, info.ID, info.Version)))
+	}
+	return nil
+}
+
+// runStorePush copies a file, or the latest version of a document of the
+// local store in dir, to a remote store
+func (c *CLI) runStorePush(dir string, target store.Store, arg, id string) error {
+	ctx := context.Background()
+	var data []byte
+	var err error
+	if arg == stdio || exists(arg) {
+		if id == "" {
+			if id, err = documentID(arg); err != nil {
+				return err
+			}
+		}
+		if data, err = c.readInput(arg); err != nil {
+			return fmt.Errorf("failed to read document: %w", err)
+		}
+	} else {
+		var local store.Store
+		if local, err = c.openStore(dir, ""); err != nil {
+			return err
+		}
+		if data, _, err = local.Get(ctx, arg, 0); err != nil {
+			return storeError(fmt.Errorf("no such file or stored document: %w", err))
+		}
+		if id == "" {
+			id = arg
+		}
+	}
+
+	info, err := target.Put(ctx, id, data)
+	if err != nil {
+		return withExitCode(ExitIO, fmt.Errorf("failed to push document: %w", err))
+	}
+	if c.outputFormat == "json" {
+		return printJSON(info)
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ Pushed %s as %s version %d", arg, info.ID, info.Version)))
+	}
+	return nil
+}
+
+// runStoreGet writes a stored document
+func (c *CLI) runStoreGet(s store.Store, id string, version int, outputPath string, force bool) error {
+	if outputPath != "" && exists(outputPath) && !force {
+		return fmt.Errorf("file already exists: %s (use --force to overwrite)", outputPath)
+	}
+	data, info, err := s.Get(context.Background(), id, version)
+	if err != nil {
+		return storeError(err)
+	}
+	if outputPath == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := c.writeOutput(outputPath, data, 0644); err != nil {
+		return err
+	}
+	if !c.quiet {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Wrote %s version %d: %s", info.ID, info.Version, outputPath)))
+	}
+	return nil
+}
+
+// printStoreInfos prints stored document versions
+func (c *CLI) printStoreInfos(list []store.Info) error {
+	if c.outputFormat == "json" {
+		return printJSON(list)
+	}
+	if len(list) == 0 {
+		if !c.quiet {
+			fmt.Println("No documents stored")
+		}
+		return nil
+	}
+	for _, info := range list {
+		fmt.Printf("%s v%d %s", info.ID, info.Version, info.Created.Local().Format("2006-01-02 15:04"))
+		if info.Title != "" {
+			fmt.Printf(" %s (%s)", info.Title, info.Type)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// printJSON prints v as indented JSON
+func printJSON(v interface{}) error {
+	jsonResult, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format result as JSON: %w", err)
+	}
+	fmt.Println(string(jsonResult))
+	return nil
+}
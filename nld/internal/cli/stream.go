@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/report"
+	"github.com/colemalphrus/nld/internal/schema"
+	"github.com/colemalphrus/nld/internal/validator"
+)
+
+// streamRecord is the result of validating one record of an NDJSON stream,
+// written as one line of JSON with --output-format json
+type streamRecord struct {
+	Record   int             `json:"record"`
+	Line     int             `json:"line"`
+	Valid    bool            `json:"valid"`
+	Errors   []streamProblem `json:"errors,omitempty"`
+	Warnings []streamProblem `json:"warnings,omitempty"`
+	// Omitted is the number of errors left out by --max-errors
+	Omitted int `json:"omitted,omitempty"`
+}
+
+// streamProblem is an error or warning found in a record
+type streamProblem struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// runValidateStream validates the documents of a newline-delimited JSON
+// file, or of standard input when path is empty or "-", one record at a
+// time
+func (c *CLI) runValidateStream(path string, opts validateOptions) error {
+	var r io.Reader = c.stdin
+	name := "standard input"
+	if path != "" && path != stdio {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+		defer f.Close()
+		r, name = f, path
+	}
+
+	var explicit *schema.Schema
+	if opts.schemaPath != "" {
+		var err error
+		if explicit, err = schema.Load(opts.schemaPath); err != nil {
+			return err
+		}
+	}
+	// Schemas selected by document type are compiled once per type
+	schemas := map[string]*schema.Schema{}
+
+	stream := document.NewStream(r)
+	out := json.NewEncoder(os.Stdout)
+	total, invalid := 0, 0
+	for {
+		rec, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+		total++
+		result := c.validateRecord(rec, explicit, schemas, opts)
+		result.Record = total
+		if !result.Valid {
+			invalid++
+		}
+
+		if c.outputFormat == "json" {
+			if err := out.Encode(result); err != nil {
+				return fmt.Errorf("failed to format result as JSON: %w", err)
+			}
+			continue
+		}
+		if c.quiet || (result.Valid && len(result.Warnings) == 0 && !c.verbose) {
+			continue
+		}
+		label := fmt.Sprintf("record %d (line %d)", result.Record, result.Line)
+		if result.Valid {
+			fmt.Println(validator.ColoredOutput(true, "✓ "+c.tr.T("validate.valid", label)))
+		} else {
+			fmt.Println(validator.ColoredOutput(false, "✗ "+c.tr.N("validate.invalid", len(result.Errors), label, len(result.Errors))))
+		}
+		for _, e := range result.Errors {
+			fmt.Printf("  - %s\n", e.Message)
+			if c.verbose && e.Field != "" {
+				fmt.Printf("    at %s\n", e.Field)
+			}
+		}
+		if result.Omitted > 0 {
+			fmt.Printf("  %s\n", c.tr.T("validate.more", result.Omitted))
+		}
+		for _, w := range result.Warnings {
+			fmt.Printf("  ! %s\n", w.Message)
+		}
+	}
+
+	if c.outputFormat != "json" && !c.quiet {
+		fmt.Printf("\n%s\n", c.tr.N("stream.summary", total, total, name, total-invalid, invalid))
+	}
+	if invalid > 0 {
+		return withExitCode(ExitValidation, errors.New(c.tr.N("stream.failed", total, invalid, total)))
+	}
+	return nil
+}
+
+// validateRecord validates one record of a stream against explicit, or the
+// schema for its type, compiled on first use and kept in schemas
+func (c *CLI) validateRecord(rec document.Record, explicit *schema.Schema, schemas map[string]*schema.Schema, opts validateOptions) streamRecord {
+	result := streamRecord{Line: rec.Line}
+	fail := func(err error) streamRecord {
+		result.Errors = append(result.Errors, streamProblem{Message: err.Error()})
+		return result
+	}
+
+	var header struct {
+		Metadata struct {
+			Type string `json:"type"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(rec.Data, &header); err != nil {
+		return fail(fmt.Errorf("invalid JSON: %w", err))
+	}
+	s := explicit
+	if s == nil {
+		if s = schemas[header.Metadata.Type]; s == nil {
+			var err error
+			if s, err = schema.GetDocumentSchemaFromBytes(rec.Data); err != nil {
+				return fail(fmt.Errorf("failed to determine schema: %w", err))
+			}
+			schemas[header.Metadata.Type] = s
+		}
+	}
+
+	checked, err := checkBytes(c.ctx, rec.Data, s, opts)
+	if err != nil {
+		return fail(err)
+	}
+	result.Valid = checked.Valid
+	out := report.NewValidation("", checked, "", opts.maxErrors)
+	for _, e := range out.Errors {
+		result.Errors = append(result.Errors, streamProblem{Field: e.Field, Message: e.Message})
+	}
+	result.Omitted = out.Omitted
+	for _, w := range checked.Warnings {
+		result.Warnings = append(result.Warnings, streamProblem{Field: w.Field, Message: w.Message})
+	}
+	return result
+}
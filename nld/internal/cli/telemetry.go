@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+// addTelemetryCommand adds the telemetry command
+func (c *CLI) addTelemetryCommand() {
+	telemetryCmd := &cobra.Command{
+		Use:   "telemetry",
+		Short: "Turn anonymous usage statistics on or off",
+		Long: `Turn anonymous usage statistics on or off. Telemetry is off until you turn
+it on, and helps decide which commands to improve.
+
+When it is on, every run records:
+
+  the command run, such as "validate" or "store get"; plugins are
+  recorded as "plugin"
+  how long it took, and the class of error it ended with, if any
+  the version of nld, the operating system and architecture
+  a random ID of the installation, which is not tied to you
+
+Documents, file names, arguments and flags are never recorded. Events
+are kept in the user cache directory and sent at most once a day, to
+$` + telemetry.EndpointEnv + ` or the endpoint given to nld telemetry on.
+$` + telemetry.DoNotTrackEnv + `=1 turns telemetry off whatever is configured.`,
+		Example: `  nld telemetry status
+  nld telemetry on
+  nld telemetry on --endpoint https://telemetry.example.com/events
+  nld telemetry off`,
+	}
+
+	var endpoint string
+	onCmd := &cobra.Command{
+		Use:   "on",
+		Short: "Turn anonymous usage statistics on",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := telemetry.New()
+			if err != nil {
+				return err
+			}
+			config, err := t.Enable(endpoint)
+			if err != nil {
+				return fmt.Errorf("failed to turn telemetry on: %w", err)
+			}
+			if !c.quiet {
+				fmt.Printf("Telemetry is on, sending to %s; thank you\n", config.URL())
+			}
+			return nil
+		},
+	}
+	onCmd.Flags().StringVar(&endpoint, "endpoint", "", "Send events to this URL (default: "+telemetry.DefaultEndpoint+")")
+
+	offCmd := &cobra.Command{
+		Use:   "off",
+		Short: "Turn anonymous usage statistics off and delete unsent events",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := telemetry.New()
+			if err != nil {
+				return err
+			}
+			if err := t.Disable(); err != nil {
+				return fmt.Errorf("failed to turn telemetry off: %w", err)
+			}
+			if !c.quiet {
+				fmt.Println("Telemetry is off")
+			}
+			return nil
+		},
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether anonymous usage statistics are on",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runTelemetryStatus()
+		},
+	}
+
+	telemetryCmd.AddCommand(onCmd, offCmd, statusCmd)
+	c.rootCmd.AddCommand(telemetryCmd)
+}
+
+// runTelemetryStatus runs the telemetry status command
+func (c *CLI) runTelemetryStatus() error {
+	t, err := telemetry.New()
+	if err != nil {
+		return err
+	}
+	config, err := t.Load()
+	if err != nil {
+		return err
+	}
+	status := struct {
+		Enabled    bool   `json:"enabled"`
+		DoNotTrack bool   `json:"doNotTrack,omitempty"`
+		ID         string `json:"id,omitempty"`
+		Endpoint   string `json:"endpoint,omitempty"`
+		Pending    int    `json:"pending"`
+		// Next is when the pending events are sent
+		Next *time.Time `json:"next,omitempty"`
+	}{
+		Enabled:    config.Enabled && !telemetry.DoNotTrack(),
+		DoNotTrack: telemetry.DoNotTrack(),
+		Pending:    t.Pending(),
+	}
+	if config.Enabled {
+		status.ID, status.Endpoint = config.ID, config.URL()
+		if !config.Sent.IsZero() {
+			next := config.Sent.Add(telemetry.Interval)
+			status.Next = &next
+		}
+	}
+
+	if c.outputFormat == "json" {
+		jsonData, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+	switch {
+	case status.DoNotTrack:
+		fmt.Printf("Telemetry is off: $%s is set\n", telemetry.DoNotTrackEnv)
+	case !status.Enabled:
+		fmt.Println("Telemetry is off; turn it on with nld telemetry on")
+	default:
+		fmt.Println("Telemetry is on")
+	}
+	if config.Enabled {
+		fmt.Printf("  ID:       %s\n", status.ID)
+		fmt.Printf("  Endpoint: %s\n", status.Endpoint)
+		fmt.Printf("  Pending:  %d event(s)\n", status.Pending)
+		if status.Next != nil {
+			fmt.Printf("  Next:     %s\n", status.Next.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// recordTelemetry records the run of cmd when telemetry is on. Errors are
+// only logged, as telemetry must never fail a command.
+func (c *CLI) recordTelemetry(cmd *cobra.Command, start time.Time, err error) {
+	if cmd == nil || strings.HasPrefix(cmd.Name(), "__complete") || telemetry.DoNotTrack() {
+		return
+	}
+	t, tErr := telemetry.New()
+	if tErr != nil {
+		return
+	}
+	e := telemetry.Event{
+		Version:  Version,
+		Command:  strings.TrimPrefix(strings.TrimPrefix(cmd.CommandPath(), c.rootCmd.Name()), " "),
+		Duration: time.Since(start).Milliseconds(),
+	}
+	if e.Command == "" {
+		e.Command = c.rootCmd.Name()
+	}
+	for p := cmd; p != nil; p = p.Parent() {
+		if p.Annotations["plugin"] == "true" {
+			e.Command = "plugin"
+		}
+	}
+	if err != nil {
+		e.Error = exitClass(ExitCode(err))
+	}
+	if err := t.Record(context.Background(), e); err != nil {
+		c.log.Debug("telemetry not recorded", "error", err)
+	}
+}
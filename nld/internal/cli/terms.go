@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/terms"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+// addTermsCommand adds the terms command
+func (c *CLI) addTermsCommand() {
+	var strict bool
+	var keyfile string
+	var passphraseFile string
+
+	termsCmd := &cobra.Command{
+		Use:   "terms [file...]",
+		Short: "Report how defined terms are used in NLD documents",
+		Long: `Report every definition of NLD documents with the number of times its term
+is used and the sections using it, the definitions that are never used,
+and the capitalized terms used in section content that have no definition.
+
+Terms are matched as whole words in the singular or plural, in paragraphs,
+list items and other definitions, and by definitionRef blocks. A run of
+capitalized words, such as Statement of Work, or a capitalized phrase in
+double quotes is treated as a defined term; entity names and roles,
+section titles, months and days, and references such as Section 4 are not.
+
+The same checks run in nld lint as the undefined-term (NLD2004) and
+unused-definition (NLD2005) rules. With --strict, the command fails when
+a document has unused definitions or undefined terms.`,
+		Example: `  nld terms contract.json
+  nld terms --strict --output-format json contracts/*.json`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			return c.runTerms(args, strict, key)
+		},
+	}
+
+	termsCmd.Flags().BoolVar(&strict, "strict", false, "Fail when there are unused definitions or undefined terms")
+	termsCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	termsCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(termsCmd)
+}
+
+// termsResult is the defined-term report of one file
+type termsResult struct {
+	File string `json:"file"`
+	*terms.Report
+	Unused []terms.Term `json:"unused,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// runTerms runs the terms command
+func (c *CLI) runTerms(filePaths []string, strict bool, key *envelope.Key) error {
+	var results []termsResult
+	var lastErr error
+	failed, findings := 0, 0
+	for _, path := range filePaths {
+		result := termsResult{File: path}
+		doc, err := c.parseDocument(path, key)
+		if err == nil {
+			result.Report = terms.Analyze(doc)
+			result.Unused = result.Report.Unused()
+			findings += len(result.Unused) + len(result.Undefined)
+		} else {
+			result.Error = err.Error()
+			lastErr = err
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+	} else {
+		for i, r := range results {
+			if r.Error != "" {
+				fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: %s", r.File, r.Error)))
+				continue
+			}
+			if c.quiet && len(r.Unused)+len(r.Undefined) == 0 {
+				continue
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			c.printTerms(r)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to read %d of %d document(s): %w", failed, len(filePaths), lastErr)
+	}
+	if strict && findings > 0 {
+		return withExitCode(ExitValidation, fmt.Errorf("found %d unused definition(s) or undefined term(s)", findings))
+	}
+	return nil
+}
+
+// printTerms prints the defined-term report of a document as text
+func (c *CLI) printTerms(r termsResult) {
+	fmt.Printf("%s: %d definition(s)\n", r.File, len(r.Defined))
+	if !c.quiet {
+		for _, t := range r.Defined {
+			sections := ""
+			if len(t.Sections) > 0 {
+				sections = " in " + strings.Join(t.Sections, ", ")
+			}
+			fmt.Printf("  %-28s %3d use(s)%s\n", t.Term, t.Uses, sections)
+		}
+	}
+	for _, t := range r.Unused {
+		fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("  ✗ unused definition %q at %s", t.Term, t.Path)))
+	}
+	for _, u := range r.Undefined {
+		fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("  ✗ undefined term %q used %d time(s) in %s", u.Term, u.Uses, strings.Join(u.Sections, ", "))))
+	}
+	if len(r.Unused)+len(r.Undefined) == 0 {
+		fmt.Println(validator.ColoredOutput(true, "  ✓ every definition is used and every term is defined"))
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/colors"
+	"github.com/colemalphrus/nld/internal/update"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+// checkForUpdate starts looking for a newer release of nld while the
+// command runs, unless it is turned off, output is quiet or meant for
+// programs, or standard error is not a terminal
+func (c *CLI) checkForUpdate(cmd *cobra.Command) {
+	if update.Disabled() || c.quiet || (c.outputFormat != "text" && c.outputFormat != "") ||
+		strings.HasPrefix(cmd.Name(), "__complete") || cmd.Name() == "completion" ||
+		!isatty.IsTerminal(os.Stderr.Fd()) {
+		return
+	}
+	checker, err := update.New()
+	if err != nil {
+		c.log.Debug("update check skipped", "error", err)
+		return
+	}
+	c.newRelease = make(chan string, 1)
+	go func() {
+		latest, err := checker.Newer(context.Background(), Version)
+		if err != nil {
+			c.log.Debug("update check failed", "error", err)
+		}
+		c.newRelease <- latest
+	}()
+}
+
+// notifyUpdate prints a notice on standard error when the update check
+// found a newer release
+func (c *CLI) notifyUpdate() {
+	if c.newRelease == nil {
+		return
+	}
+	if latest := <-c.newRelease; latest != "" {
+		fmt.Fprintln(os.Stderr, colors.Warning(c.tr.T("update.available", latest, Version, update.ReleasesPage, update.DisableEnv)))
+	}
+}
@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/audit"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/risk"
+	"github.com/colemalphrus/nld/internal/threshold"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/spf13/cobra"
+)
+
+// addHashCommand adds the hash command
+func (c *CLI) addHashCommand() {
+	var write bool
+	var keyfile string
+	var passphraseFile string
+
+	hashCmd := &cobra.Command{
+		Use:   "hash [file]",
+		Short: "Compute per-section hashes and the document root hash",
+		Long: `Compute the hash of the metadata, each section and the relationships of an
+NLD document, and of every other part of it, such as definitions, receipt
+totals and revisions, rolled up into a Merkle root hash. The verification
+data, which holds the signatures, the lifecycle status, annotations and
+redlines are left out.
+
+With --write the record is stored in verification.digest so that later
+changes can be located with the verify command. Encrypted documents stay
+encrypted with the same key.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			return c.runHash(args[0], write, key)
+		},
+	}
+
+	hashCmd.Flags().BoolVarP(&write, "write", "w", false, "Store the digest record in the document")
+	hashCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	hashCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+
+	c.rootCmd.AddCommand(hashCmd)
+}
+
+// addVerifyCommand adds the verify command
+func (c *CLI) addVerifyCommand() {
+	var keyfile string
+	var passphraseFile string
+	var riskOpts riskOptions
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify [file]",
+		Short: "Verify a document against its recorded hashes",
+		Long: `Verify an NLD document against the digest record stored in
+verification.digest, reporting which parts are unchanged, modified,
+redacted, added or removed, and the risk score of the document as nld
+stats computes it.
+
+The inclusion proofs of the transparency log anchors recorded by nld anchor
+are checked offline. Anchors of a root hash other than that of the digest
+record, such as that of an earlier revision, are reported as stale.
+
+The signature policies in metadata.signaturePolicies, such as two of three
+directors must sign, are evaluated too: a policy is satisfied once enough of
+its signers have a cryptographic signature that verifies against the root
+hash of the document, or the recorded root hash when every change since is a
+redaction. The command fails when a policy is not satisfied.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := envelope.LoadKey(keyfile, passphraseFile)
+			if err != nil {
+				return err
+			}
+			scorer, err := riskOpts.scorer()
+			if err != nil {
+				return err
+			}
+			return c.audited(audit.Verify, args[0], args[0], "", key, c.runVerify(args[0], key, scorer))
+		},
+	}
+
+	verifyCmd.Flags().StringVar(&keyfile, "keyfile", "", "Keyfile used to decrypt encrypted documents")
+	verifyCmd.Flags().StringVar(&passphraseFile, "passphrase-file", "", "File containing the passphrase used to decrypt encrypted documents")
+	riskOpts.addFlags(verifyCmd)
+
+	c.rootCmd.AddCommand(verifyCmd)
+}
+
+// runHash runs the hash command
+func (c *CLI) runHash(filePath string, write bool, key *envelope.Key) error {
+	doc, encrypted, err := c.loadSealed(filePath, key)
+	if err != nil {
+		return err
+	}
+
+	rec, err := digest.Compute(doc)
+	if err != nil {
+		return fmt.Errorf("failed to hash document: %w", err)
+	}
+
+	if write {
+		if err := digest.Store(doc, rec); err != nil {
+			return err
+		}
+		if err := c.writeDocument(filePath, doc, encrypted, key); err != nil {
+			return err
+		}
+	}
+
+	if c.quiet {
+		return nil
+	}
+
+	if c.outputFormat == "json" {
+		jsonResult, err := json.MarshalIndent(rec, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format result as JSON: %w", err)
+		}
+		fmt.Println(string(jsonResult))
+		return nil
+	}
+
+	fmt.Printf("Root: %s\n", rec.Root)
+	if c.verbose {
+		fmt.Printf("  metadata: %s\n", rec.Metadata)
+		for _, s := range rec.Sections {
+			fmt.Printf("  section %s: %s\n", s.ID, s.Hash)
+		}
+		if rec.Relationships != "" {
+			fmt.Printf("  relationships: %s\n", rec.Relationships)
+		}
+		for _, p := range rec.Parts {
+			fmt.Printf("  %s: %s\n", p.Part, p.Hash)
+		}
+	}
+	if write {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("Recorded digest in %s", filePath)))
+	}
+	return nil
+}
+
+// runVerify runs the verify command
+func (c *CLI) runVerify(filePath string, key *envelope.Key, scorer *riskScorer) error {
+	doc, err := c.loadDocument(filePath, key)
+	if err != nil {
+		return err
+	}
+
+	rec, err := digest.Load(doc)
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		return withExitCode(ExitSignature, fmt.Errorf("document has no digest record; run 'nld hash --write %s' first", filePath))
+	}
+
+	report, err := digest.Verify(doc, rec)
+	if err != nil {
+		return fmt.Errorf("failed to verify document: %w", err)
+	}
+	score, err := scorer.score(doc)
+	if err != nil {
+		return err
+	}
+	anchors, err := checkAnchors(doc, rec.Root)
+	if err != nil {
+		return err
+	}
+	policies, err := checkPolicies(doc)
+	if err != nil {
+		return err
+	}
+
+	if !c.quiet {
+		if c.outputFormat == "json" {
+			jsonResult, err := json.MarshalIndent(struct {
+				*digest.Report
+				Risk     *risk.Score        `json:"risk"`
+				Anchors  []anchorCheck      `json:"anchors,omitempty"`
+				Policies []threshold.Result `json:"policies,omitempty"`
+			}{report, score, anchors, policies}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to format result as JSON: %w", err)
+			}
+			fmt.Println(string(jsonResult))
+		} else {
+			c.printVerifyReport(filePath, report)
+			c.printRisk("  Risk: ", score)
+			printAnchorChecks(anchors)
+			printPolicies(policies)
+		}
+	}
+
+	if !report.RecordIntact {
+		return withExitCode(ExitSignature, fmt.Errorf("digest record has been tampered with"))
+	}
+	for _, part := range report.Changed() {
+		if part.Status != digest.StatusRedacted {
+			return withExitCode(ExitSignature, fmt.Errorf("document verification failed"))
+		}
+	}
+	for _, a := range anchors {
+		if a.Status == anchorInvalid {
+			return withExitCode(ExitSignature, fmt.Errorf("anchor in %s entry %d is invalid", a.Log, a.LogIndex))
+		}
+	}
+	for _, p := range policies {
+		if !p.Satisfied {
+			return withExitCode(ExitSignature, fmt.Errorf("signature policy %s is not satisfied: %d of %d required signature(s)", p.ID, len(p.Signed), p.Required))
+		}
+	}
+	return nil
+}
+
+// checkPolicies evaluates the signature policies of a document against the
+// root hash its signatures are over
+func checkPolicies(doc document.Document) ([]threshold.Result, error) {
+	data, err := doc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := nld.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsed.Metadata.SignaturePolicies) == 0 {
+		return nil, nil
+	}
+	root, _, err := signedRoot(doc)
+	if err != nil {
+		return nil, err
+	}
+	return threshold.Evaluate(parsed, root), nil
+}
+
+// printPolicies prints the signature policies of a document as text
+func printPolicies(policies []threshold.Result) {
+	for _, p := range policies {
+		line := fmt.Sprintf("%d of %d signer(s) signed, %d required", len(p.Signed), len(p.Signers), p.Required)
+		if len(p.Signed) > 0 {
+			line += " (" + strings.Join(p.Signed, ", ") + ")"
+		}
+		fmt.Println(validator.ColoredOutput(p.Satisfied, fmt.Sprintf("  Policy %s: %s", p.ID, line)))
+	}
+}
+
+// printAnchorChecks prints the anchors of a document as text
+func printAnchorChecks(anchors []anchorCheck) {
+	for _, a := range anchors {
+		line := fmt.Sprintf("  Anchor: %s entry %d, %s", a.Log, a.LogIndex, a.Status)
+		if a.Integrated != nil {
+			line += fmt.Sprintf(" (logged %s)", a.Integrated.Format(time.RFC3339))
+		}
+		if a.Error != "" {
+			line += ": " + a.Error
+		}
+		fmt.Println(line)
+	}
+}
+
+// printVerifyReport prints a verification report as text
+func (c *CLI) printVerifyReport(filePath string, report *digest.Report) {
+	if !report.RecordIntact {
+		fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s: digest record does not match its root hash", filePath)))
+		return
+	}
+	if report.Intact {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ %s matches root %s", filePath, report.Root)))
+		return
+	}
+
+	changed := report.Changed()
+	onlyRedacted := true
+	for _, part := range changed {
+		if part.Status != digest.StatusRedacted {
+			onlyRedacted = false
+		}
+	}
+	if onlyRedacted {
+		fmt.Println(validator.ColoredOutput(true, fmt.Sprintf("✓ %s verified with %d redacted part(s)", filePath, len(changed))))
+	} else {
+		fmt.Println(validator.ColoredOutput(false, fmt.Sprintf("✗ %s has %d changed part(s):", filePath, len(changed))))
+	}
+
+	for _, part := range report.Parts {
+		if part.Status == digest.StatusOK && !c.verbose {
+			continue
+		}
+		name := part.Part
+		if part.ID != "" {
+			name = fmt.Sprintf("%s %s", part.Part, part.ID)
+		}
+		fmt.Printf("  - %s: %s\n", name, part.Status)
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+// testReceipt is a receipt with its line items and totals
+const testReceipt = `{
+  "metadata": {"type": "receipt", "version": "1.0.0", "created": "2025-06-27T12:00:00Z", "title": "Receipt"},
+  "content": {
+    "sections": [{"id": "items", "title": "Items", "content": [{"type": "table",
+      "columns": [{"title": "Item"}, {"title": "Qty", "type": "number", "key": "quantity"},
+                  {"title": "Price", "type": "number", "key": "unitPrice"}, {"title": "Amount", "type": "number", "key": "amount", "total": true}],
+      "rows": [["Widget", "2", "4.50", "9.00"]]}]}],
+    "totals": {"currency": "USD", "subtotal": "9.00", "total": "9.00"}
+  }
+}`
+
+func TestVerifyReceipt(t *testing.T) {
+	testCases := []struct {
+		name   string
+		mutate func(doc document.Document)
+		part   string
+	}{
+		{"Total", func(doc document.Document) {
+			doc.Body(false)["totals"].(map[string]interface{})["total"] = "1.00"
+		}, "content.totals: modified"},
+		{"Line Item", func(doc document.Document) {
+			table := doc.Sections()[0]["content"].([]interface{})[0].(map[string]interface{})
+			table["rows"].([]interface{})[0].([]interface{})[3] = "90.00"
+		}, "section items: modified"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeTestFile(t, "receipt.json", testReceipt)
+			if _, err := run(t, New(), "hash", "--write", path); err != nil {
+				t.Fatalf("hash failed: %v", err)
+			}
+			if _, err := run(t, New(), "verify", path); err != nil {
+				t.Fatalf("verify failed before the change: %v", err)
+			}
+
+			data, _ := os.ReadFile(path)
+			doc, err := document.Parse(data)
+			if err != nil {
+				t.Fatalf("Failed to parse document: %v", err)
+			}
+			tc.mutate(doc)
+			out, _ := doc.Marshal()
+			os.WriteFile(path, out, 0644)
+
+			printed, err := run(t, New(), "verify", path)
+			if ExitCode(err) != ExitSignature {
+				t.Errorf("Expected exit code %d, got %d (%v)", ExitSignature, ExitCode(err), err)
+			}
+			if !strings.Contains(printed, tc.part) {
+				t.Errorf("Expected %q, got %s", tc.part, printed)
+			}
+		})
+	}
+}
+
+func TestHashWriteEncrypted(t *testing.T) {
+	path, keyfile := writeEncrypted(t)
+	if _, err := run(t, New(), "hash", "--write", path, "--keyfile", keyfile); err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	if plaintext := checkEncrypted(t, path); !strings.Contains(plaintext, `"digest"`) {
+		t.Errorf("Expected the digest record, got %s", plaintext)
+	}
+	if _, err := run(t, New(), "verify", path, "--keyfile", keyfile); err != nil {
+		t.Errorf("verify failed: %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Bundle returns a schema as one self-contained document for validators
+// such as ajv: references are replaced by the schemas they refer to, and
+// definitions are dropped. Recursive references cannot be replaced, so
+// they refer to the definitions kept for them. comment, if not empty, is
+// set as the $comment of the schema.
+func Bundle(data []byte, comment string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON in schema: %w", err)
+	}
+	doc, ok := v.(*object)
+	if !ok {
+		return nil, fmt.Errorf("invalid schema: not a JSON object")
+	}
+
+	b := &bundler{p: &parser{doc: doc}, state: map[string]int{}, kept: map[string]string{}}
+	if err := b.scan(doc, true); err != nil {
+		return nil, err
+	}
+	// Kept definitions get names under definitions
+	var keptRefs []string
+	names := map[string]bool{}
+	for ref := range b.state {
+		if b.state[ref] == recursive && ref != "#" {
+			keptRefs = append(keptRefs, ref)
+		}
+	}
+	sort.Strings(keptRefs)
+	for _, ref := range keptRefs {
+		name := unescape(ref[strings.LastIndex(ref, "/")+1:])
+		for i := 2; names[name]; i++ {
+			name = unescape(ref[strings.LastIndex(ref, "/")+1:]) + strconv.Itoa(i)
+		}
+		names[name] = true
+		b.kept[ref] = "#/definitions/" + escape(name)
+	}
+
+	out := &object{}
+	if comment != "" {
+		for _, key := range doc.keys {
+			if key == "$schema" {
+				out.set(key, doc.get(key))
+			}
+		}
+		out.set("$comment", comment)
+	}
+	root, err := b.inline(doc)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range root.(*object).keys {
+		if key != "definitions" && key != "$defs" && (key != "$comment" || comment == "") {
+			out.set(key, root.(*object).get(key))
+		}
+	}
+	if len(keptRefs) > 0 {
+		defs := &object{}
+		for _, ref := range keptRefs {
+			target, _ := b.p.lookup(ref)
+			inlined, err := b.inline(target)
+			if err != nil {
+				return nil, err
+			}
+			defs.set(unescape(strings.TrimPrefix(b.kept[ref], "#/definitions/")), inlined)
+		}
+		out.set("definitions", defs)
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, out, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// The states of references while scanning
+const (
+	unvisited = iota
+	visiting
+	visited
+	// recursive references are those a reference within their schema
+	// returns to
+	recursive
+)
+
+// bundler replaces the references of a schema
+type bundler struct {
+	p     *parser
+	state map[string]int
+	// kept maps recursive references to their references in the bundle
+	kept map[string]string
+}
+
+// scan finds the recursive references reachable from v: those met again
+// while their schema is being scanned. top is set for the whole schema,
+// whose definitions are only scanned when referred to.
+func (b *bundler) scan(v interface{}, top bool) error {
+	switch v := v.(type) {
+	case *object:
+		if ref := v.str("$ref"); ref != "" {
+			if ref != "#" && !strings.HasPrefix(ref, "#/") {
+				return fmt.Errorf("unsupported $ref %q: only references within the schema are supported", ref)
+			}
+			switch b.state[ref] {
+			case visiting, recursive:
+				b.state[ref] = recursive
+				return nil
+			case visited:
+				return nil
+			}
+			if ref == "#" {
+				b.state[ref] = recursive
+				return nil
+			}
+			target, err := b.p.lookup(ref)
+			if err != nil {
+				return err
+			}
+			b.state[ref] = visiting
+			if err := b.scan(target, false); err != nil {
+				return err
+			}
+			if b.state[ref] == visiting {
+				b.state[ref] = visited
+			}
+			return nil
+		}
+		for _, key := range v.keys {
+			if top && (key == "definitions" || key == "$defs") {
+				continue
+			}
+			if err := b.scan(v.get(key), false); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := b.scan(item, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// inline returns a copy of v with its references replaced by their
+// schemas, but for recursive ones
+func (b *bundler) inline(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case *object:
+		if ref := v.str("$ref"); ref != "" {
+			if ref == "#" {
+				return v, nil
+			}
+			if kept, ok := b.kept[ref]; ok {
+				out := &object{}
+				out.set("$ref", kept)
+				return out, nil
+			}
+			target, err := b.p.lookup(ref)
+			if err != nil {
+				return nil, err
+			}
+			return b.inline(target)
+		}
+		out := &object{}
+		for _, key := range v.keys {
+			item, err := b.inline(v.get(key))
+			if err != nil {
+				return nil, err
+			}
+			out.set(key, item)
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			inlined, err := b.inline(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = inlined
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// writeJSON writes v indented, with the keys of objects in their order
+func writeJSON(buf *bytes.Buffer, v interface{}, indent string) error {
+	switch v := v.(type) {
+	case *object:
+		if len(v.keys) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, key := range v.keys {
+			buf.WriteString(indent + "  ")
+			if err := writeJSON(buf, key, ""); err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+			if err := writeJSON(buf, v.get(key), indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v.keys)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range v {
+			buf.WriteString(indent + "  ")
+			if err := writeJSON(buf, item, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	default:
+		// Patterns are written as they are, without escaping < and >
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	return nil
+}
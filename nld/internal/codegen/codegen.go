@@ -0,0 +1,751 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kind is the kind of JSON value a type describes
+type Kind int
+
+const (
+	// Any is a value the schema does not constrain
+	Any Kind = iota
+	String
+	Integer
+	Number
+	Boolean
+	Array
+	Map
+	Struct
+	// Union is a value that is one of several kinds, such as a string or
+	// an object
+	Union
+)
+
+// Type is a type of a schema. Structs, unions and strings with an enum are
+// named; other types are written in place.
+type Type struct {
+	Kind Kind
+	Name string
+	// Pointer is the JSON pointer of the schema of named types
+	Pointer     string
+	Description string
+	// Elem is the element type of arrays and maps
+	Elem   *Type
+	Fields []*Field
+	// Alternatives are the types of a union, at most one of each kind
+	Alternatives []*Type
+	// Enum lists the values of a string type
+	Enum    []string
+	Pattern string
+	// Format is the format of a string type, such as date-time
+	Format  string
+	Minimum *float64
+	Maximum *float64
+	// MinItems and MaxItems bound the length of arrays; negative if unset
+	MinItems int
+	MaxItems int
+}
+
+// Field is a property of a struct
+type Field struct {
+	// Name is the name of the property in JSON
+	Name        string
+	Type        *Type
+	Required    bool
+	Description string
+}
+
+// Model is the types of a schema
+type Model struct {
+	// Root is the type of the documents of the schema
+	Root *Type
+	// Types lists the named types, in the order of the schema
+	Types []*Type
+}
+
+// Parse reads the types of a JSON schema, naming the type of the whole
+// document root. Only references within the schema are supported.
+func Parse(data []byte, root string) (*Model, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON in schema: %w", err)
+	}
+	doc, ok := v.(*object)
+	if !ok {
+		return nil, fmt.Errorf("invalid schema: not a JSON object")
+	}
+
+	p := &parser{doc: doc, names: map[string]bool{}, reserved: map[string]bool{}, refs: map[string]*Type{}, model: &Model{}}
+	// Definitions keep their names, so other types cannot take them
+	for _, key := range []string{"definitions", "$defs"} {
+		if defs := doc.obj(key); defs != nil {
+			for _, name := range defs.keys {
+				p.reserved[GoName(name)] = true
+			}
+		}
+	}
+	p.model.Root = &Type{}
+	p.refs["#"] = p.model.Root
+	name := GoName(root)
+	if err := p.fill(p.model.Root, doc, "#", name, name); err != nil {
+		return nil, err
+	}
+	return p.model, nil
+}
+
+// parser builds the model of a schema
+type parser struct {
+	doc *object
+	// names holds the names of the named types so far, and reserved the
+	// names of definitions not yet resolved
+	names    map[string]bool
+	reserved map[string]bool
+	// refs holds the types of the references resolved so far, by JSON
+	// pointer, so that recursive references end
+	refs  map[string]*Type
+	model *Model
+}
+
+// resolve returns the type of schema s at pointer ptr. Named types get
+// name, or fallback if name is taken.
+func (p *parser) resolve(s *object, ptr, name, fallback string) (*Type, error) {
+	if ref := s.str("$ref"); ref != "" {
+		// References share the type of their target, which may still be
+		// being filled if the reference is recursive
+		t, err := p.ref(ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ptr, err)
+		}
+		return t, nil
+	}
+	t := &Type{}
+	return t, p.fill(t, s, ptr, name, fallback)
+}
+
+// fill sets t to the type of schema s
+func (p *parser) fill(t *Type, s *object, ptr, name, fallback string) error {
+	t.Pointer, t.Description, t.MinItems, t.MaxItems = ptr, s.str("description"), -1, -1
+
+	if ref := s.str("$ref"); ref != "" {
+		target, err := p.ref(ref)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ptr, err)
+		}
+		*t = *target
+		return nil
+	}
+	if parts := s.list("allOf"); parts != nil {
+		merged, err := p.allOf(s, parts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", ptr, err)
+		}
+		s = merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if alts := s.list(key); alts != nil {
+			return p.union(t, s, alts, ptr, key, name, fallback)
+		}
+	}
+
+	switch kind := kindOf(s); kind {
+	case String:
+		t.Kind, t.Pattern, t.Format = String, s.str("pattern"), s.str("format")
+		for _, v := range s.list("enum") {
+			if str, ok := v.(string); ok {
+				t.Enum = append(t.Enum, str)
+			}
+		}
+		if c, ok := s.get("const").(string); ok {
+			t.Enum = []string{c}
+		}
+		if t.Enum != nil {
+			t.Name = p.claim(fallback, fallback)
+			p.model.Types = append(p.model.Types, t)
+		}
+	case Integer, Number:
+		t.Kind = kind
+		t.Minimum, t.Maximum = s.num("minimum"), s.num("maximum")
+	case Boolean:
+		t.Kind = Boolean
+	case Array:
+		t.Kind = Array
+		if n := s.num("minItems"); n != nil {
+			t.MinItems = int(*n)
+		}
+		if n := s.num("maxItems"); n != nil {
+			t.MaxItems = int(*n)
+		}
+		items := s.obj("items")
+		if items == nil {
+			items = &object{}
+		}
+		elem, err := p.resolve(items, ptr+"/items", singular(name), singular(fallback))
+		if err != nil {
+			return err
+		}
+		t.Elem = elem
+	case Struct:
+		t.Kind, t.Name = Struct, p.claim(name, fallback)
+		p.model.Types = append(p.model.Types, t)
+		props := s.obj("properties")
+		required := map[string]bool{}
+		for _, r := range s.list("required") {
+			if str, ok := r.(string); ok {
+				required[str] = true
+			}
+		}
+		for _, key := range props.keys {
+			prop, ok := props.get(key).(*object)
+			if !ok {
+				prop = &object{}
+			}
+			field := GoName(key)
+			ft, err := p.resolve(prop, ptr+"/properties/"+escape(key), field, t.Name+field)
+			if err != nil {
+				return err
+			}
+			t.Fields = append(t.Fields, &Field{Name: key, Type: ft, Required: required[key], Description: prop.str("description")})
+		}
+	case Map:
+		t.Kind = Map
+		elem := &object{}
+		if ap, ok := s.get("additionalProperties").(*object); ok {
+			elem = ap
+		}
+		e, err := p.resolve(elem, ptr+"/additionalProperties", singular(name), singular(fallback))
+		if err != nil {
+			return err
+		}
+		t.Elem = e
+	default:
+		t.Kind = Any
+	}
+	return nil
+}
+
+// ref returns the type of a reference within the schema
+func (p *parser) ref(ref string) (*Type, error) {
+	if t, ok := p.refs[ref]; ok {
+		return t, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q: only references within the schema are supported", ref)
+	}
+	s, err := p.lookup(ref)
+	if err != nil {
+		return nil, err
+	}
+	// The type is registered before it is filled, so that references to
+	// it from within refer to it rather than recurse
+	t := &Type{}
+	p.refs[ref] = t
+	segments := strings.Split(ref, "/")
+	name := GoName(unescape(segments[len(segments)-1]))
+	if len(segments) == 3 && (segments[1] == "definitions" || segments[1] == "$defs") {
+		delete(p.reserved, name)
+	}
+	return t, p.fill(t, s, ref, name, name)
+}
+
+// lookup returns the schema at a JSON pointer of the form #/a/b
+func (p *parser) lookup(ptr string) (*object, error) {
+	s := p.doc
+	for _, segment := range strings.Split(ptr, "/")[1:] {
+		next, ok := s.get(unescape(segment)).(*object)
+		if !ok {
+			return nil, fmt.Errorf("$ref %q does not refer to a schema", ptr)
+		}
+		s = next
+	}
+	return s, nil
+}
+
+// allOf merges the schemas of an allOf with the rest of s into one
+// schema: the properties and required properties of all of them
+func (p *parser) allOf(s *object, parts []interface{}) (*object, error) {
+	merged := &object{}
+	for _, key := range s.keys {
+		if key != "allOf" {
+			merged.set(key, s.get(key))
+		}
+	}
+	// The properties and required properties of s are copied, as they are
+	// extended with those of the parts
+	props, required := &object{}, append([]interface{}(nil), merged.list("required")...)
+	if own := merged.obj("properties"); own != nil {
+		for _, key := range own.keys {
+			props.set(key, own.get(key))
+		}
+	}
+	for _, part := range parts {
+		ps, ok := part.(*object)
+		if !ok {
+			continue
+		}
+		if ref := ps.str("$ref"); ref != "" {
+			var err error
+			if ps, err = p.lookup(ref); err != nil {
+				return nil, err
+			}
+		}
+		if more := ps.obj("properties"); more != nil {
+			for _, key := range more.keys {
+				props.set(key, more.get(key))
+			}
+		}
+		required = append(required, ps.list("required")...)
+		for _, key := range []string{"type", "description", "additionalProperties"} {
+			if merged.get(key) == nil && ps.get(key) != nil {
+				merged.set(key, ps.get(key))
+			}
+		}
+	}
+	merged.set("properties", props)
+	merged.set("required", required)
+	return merged, nil
+}
+
+// union sets t to the type of the alternatives of a oneOf or anyOf. Go
+// cannot tell alternatives of the same kind apart, so objects are merged
+// into one struct and other kinds keep their first alternative. With a
+// single kind left, the union is that kind.
+func (p *parser) union(t *Type, s *object, alts []interface{}, ptr, key, name, fallback string) error {
+	var objects, refs []*object
+	var others []*object
+	var pointers []string
+	seen := map[Kind]bool{}
+	for i, alt := range alts {
+		as, ok := alt.(*object)
+		if !ok {
+			continue
+		}
+		resolved := as
+		if ref := as.str("$ref"); ref != "" {
+			target, err := p.lookup(ref)
+			if err != nil {
+				return fmt.Errorf("%s/%s: %w", ptr, key, err)
+			}
+			resolved = target
+		}
+		kind := kindOf(resolved)
+		if kind == Any {
+			// An alternative of any value allows any value
+			t.Kind = Any
+			return nil
+		}
+		if kind == Struct {
+			objects, refs = append(objects, resolved), append(refs, as)
+			continue
+		}
+		if kind == Integer {
+			kind = Number
+		}
+		if !seen[kind] {
+			seen[kind] = true
+			others = append(others, as)
+			pointers = append(pointers, ptr+"/"+key+"/"+strconv.Itoa(i))
+		}
+	}
+
+	var object *object
+	if len(objects) == 1 {
+		object = refs[0]
+	} else if len(objects) > 1 {
+		object = mergeObjects(objects)
+	}
+	description := s.str("description")
+	switch {
+	case object != nil && len(others) == 0:
+		if err := p.fill(t, object, ptr, name, fallback); err != nil {
+			return err
+		}
+		if description != "" {
+			t.Description = description
+		}
+		return nil
+	case object == nil && len(others) == 1:
+		if err := p.fill(t, others[0], pointers[0], name, fallback); err != nil {
+			return err
+		}
+		if description != "" {
+			t.Description = description
+		}
+		return nil
+	case object == nil && len(others) == 0:
+		t.Kind = Any
+		return nil
+	}
+
+	t.Kind, t.Name, t.Pointer, t.Description = Union, p.claim(name, fallback), ptr, description
+	p.model.Types = append(p.model.Types, t)
+	for i, other := range others {
+		altName := t.Name + "Value"
+		if kindOf(other) == Array {
+			altName = t.Name + "Items"
+		}
+		alt, err := p.resolve(other, pointers[i], altName, altName)
+		if err != nil {
+			return err
+		}
+		t.Alternatives = append(t.Alternatives, alt)
+	}
+	if object != nil {
+		alt, err := p.resolve(object, ptr, t.Name+"Object", t.Name+"Object")
+		if err != nil {
+			return err
+		}
+		t.Alternatives = append(t.Alternatives, alt)
+	}
+	return nil
+}
+
+// mergeObjects merges the object alternatives of a union into one object
+// schema. Properties are required if every alternative requires them, and
+// properties with a const in every alternative become an enum, as with the
+// type of tagged unions.
+func mergeObjects(objects []*object) *object {
+	props := &object{}
+	var all [][]*object
+	count := map[string]int{}
+	for _, o := range objects {
+		ps := o.obj("properties")
+		if ps == nil {
+			continue
+		}
+		for _, key := range ps.keys {
+			prop, _ := ps.get(key).(*object)
+			if prop == nil {
+				prop = &object{}
+			}
+			if props.get(key) == nil {
+				props.set(key, len(all))
+				all = append(all, nil)
+			}
+			i := props.get(key).(int)
+			all[i] = append(all[i], prop)
+		}
+		for _, r := range o.list("required") {
+			if str, ok := r.(string); ok {
+				count[str]++
+			}
+		}
+	}
+	var required []interface{}
+	for _, key := range props.keys {
+		props.set(key, mergeProperty(all[props.get(key).(int)]))
+		if count[key] == len(objects) {
+			required = append(required, key)
+		}
+	}
+	merged := &object{}
+	merged.set("type", "object")
+	merged.set("properties", props)
+	merged.set("required", required)
+	return merged
+}
+
+// mergeProperty merges the schemas of a property of several objects
+func mergeProperty(schemas []*object) *object {
+	if len(schemas) == 1 {
+		return schemas[0]
+	}
+	var enum []interface{}
+	seen := map[string]bool{}
+	for _, s := range schemas {
+		values := s.list("enum")
+		if c := s.get("const"); c != nil {
+			values = []interface{}{c}
+		}
+		if values == nil {
+			enum = nil
+			break
+		}
+		for _, v := range values {
+			if str, ok := v.(string); ok && !seen[str] {
+				seen[str] = true
+				enum = append(enum, str)
+			}
+		}
+	}
+	merged := &object{}
+	if enum != nil {
+		merged.set("type", "string")
+		merged.set("enum", enum)
+		return merged
+	}
+	kind := kindOf(schemas[0])
+	for _, s := range schemas[1:] {
+		if kindOf(s) != kind {
+			return merged
+		}
+	}
+	if d := schemas[0].str("description"); d != "" {
+		merged.set("description", d)
+	}
+	if kind == Struct || kind == Array || kind == Map {
+		// Structured properties of the same kind keep the first schema
+		return schemas[0]
+	}
+	switch kind {
+	case String:
+		merged.set("type", "string")
+	case Integer:
+		merged.set("type", "integer")
+	case Number:
+		merged.set("type", "number")
+	case Boolean:
+		merged.set("type", "boolean")
+	}
+	return merged
+}
+
+// kindOf returns the kind of value a schema describes, from its type or,
+// without one, its other keywords
+func kindOf(s *object) Kind {
+	var types []string
+	switch v := s.get("type").(type) {
+	case string:
+		types = []string{v}
+	case []interface{}:
+		for _, t := range v {
+			if str, ok := t.(string); ok && str != "null" {
+				types = append(types, str)
+			}
+		}
+	}
+	if len(types) == 1 {
+		switch types[0] {
+		case "string":
+			return String
+		case "integer":
+			return Integer
+		case "number":
+			return Number
+		case "boolean":
+			return Boolean
+		case "array":
+			return Array
+		case "object":
+			if s.obj("properties") == nil {
+				return Map
+			}
+			return Struct
+		}
+	}
+	if len(types) > 1 {
+		return Any
+	}
+	switch {
+	case s.obj("properties") != nil:
+		return Struct
+	case s.get("items") != nil:
+		return Array
+	case s.str("pattern") != "":
+		return String
+	}
+	if _, ok := s.get("const").(string); ok {
+		return String
+	}
+	if enum := s.list("enum"); len(enum) > 0 {
+		if _, ok := enum[0].(string); ok {
+			return String
+		}
+	}
+	return Any
+}
+
+// claim reserves the name of a named type: name, or fallback if name is
+// taken, or fallback with a number
+func (p *parser) claim(name, fallback string) string {
+	for _, n := range []string{name, fallback} {
+		if n != "" && !p.names[n] && !p.reserved[n] {
+			p.names[n] = true
+			return n
+		}
+	}
+	for i := 2; ; i++ {
+		if n := fallback + strconv.Itoa(i); !p.names[n] && !p.reserved[n] {
+			p.names[n] = true
+			return n
+		}
+	}
+}
+
+// initialisms are written in upper case in Go names, as golint suggests
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true, "ip": true, "json": true,
+	"pdf": true, "sql": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// GoName turns a JSON name such as noticePeriod or created_at into an
+// exported Go name such as NoticePeriod or CreatedAt
+func GoName(name string) string {
+	var b strings.Builder
+	for _, word := range words(name) {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		r := []rune(word)
+		b.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+	out := b.String()
+	switch {
+	case out == "":
+		return "Value"
+	case unicode.IsDigit(rune(out[0])):
+		return "N" + out
+	}
+	return out
+}
+
+// words splits a name into words at non-alphanumeric characters and
+// changes from lower to upper case
+func words(name string) []string {
+	var out []string
+	var word []rune
+	prev := rune(0)
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if len(word) > 0 {
+				out = append(out, string(word))
+			}
+			word = nil
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) && len(word) > 0:
+			out = append(out, string(word))
+			word = []rune{r}
+		default:
+			word = append(word, r)
+		}
+		prev = r
+	}
+	if len(word) > 0 {
+		out = append(out, string(word))
+	}
+	return out
+}
+
+// singular returns the name of the elements of a list named name, such as
+// Entity for Entities
+func singular(name string) string {
+	switch {
+	case name == "":
+		return ""
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"),
+		strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"), strings.HasSuffix(name, "is"):
+		return name + "Item"
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name + "Item"
+}
+
+// escape and unescape convert names to and from JSON pointer segments
+func escape(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+}
+
+// object is a JSON object that keeps the order of its keys, so that types
+// are generated in the order of the schema
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *object) get(key string) interface{} {
+	if o == nil {
+		return nil
+	}
+	return o.values[key]
+}
+
+func (o *object) set(key string, v interface{}) {
+	if o.values == nil {
+		o.values = map[string]interface{}{}
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+func (o *object) obj(key string) *object {
+	v, _ := o.get(key).(*object)
+	return v
+}
+
+func (o *object) str(key string) string {
+	v, _ := o.get(key).(string)
+	return v
+}
+
+func (o *object) list(key string) []interface{} {
+	v, _ := o.get(key).([]interface{})
+	return v
+}
+
+func (o *object) num(key string) *float64 {
+	v, ok := o.get(key).(json.Number)
+	if !ok {
+		return nil
+	}
+	f, err := v.Float64()
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+// decode reads the next JSON value of dec, with objects as *object
+func decode(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		o := &object{values: map[string]interface{}{}}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			o.set(key.(string), v)
+		}
+		_, err := dec.Token()
+		return o, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			v, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
@@ -0,0 +1,449 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/schemas"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestParse(t *testing.T) {
+	data, err := schemas.FS.ReadFile("document-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(data, "Document")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := map[string]*Type{}
+	for _, typ := range m.Types {
+		if types[typ.Name] != nil {
+			t.Errorf("type %s declared twice", typ.Name)
+		}
+		types[typ.Name] = typ
+	}
+	for name, kind := range map[string]Kind{
+		"Document": Struct, "Metadata": Struct, "MetadataType": String, "Entity": Struct,
+		"Section": Struct, "SectionContent": Union, "Block": Struct, "BlockType": String,
+		"Money": Union, "MoneyObject": Struct, "Tax": Struct, "Translation": Struct,
+	} {
+		if types[name] == nil || types[name].Kind != kind {
+			t.Errorf("type %s = %+v, want kind %d", name, types[name], kind)
+		}
+	}
+	if m.Root != types["Document"] {
+		t.Errorf("root is not Document")
+	}
+
+	// Recursive references share their type
+	section := types["Section"]
+	sections := field(t, section, "sections")
+	if sections.Type.Kind != Array || sections.Type.Elem != section {
+		t.Errorf("Section.sections = %+v, want a list of Section", sections.Type)
+	}
+	if !field(t, section, "content").Required || field(t, section, "sections").Required {
+		t.Errorf("Section required fields are wrong")
+	}
+
+	// The consts of the alternatives of blocks become an enum
+	if got := strings.Join(types["BlockType"].Enum, ","); got != "paragraph,list,table,definitionRef" {
+		t.Errorf("BlockType enum = %s", got)
+	}
+	if field(t, types["Block"], "text").Required || !field(t, types["Block"], "type").Required {
+		t.Errorf("Block fields should be required only if every alternative requires them")
+	}
+}
+
+// field returns the field of a struct named name
+func field(t *testing.T, typ *Type, name string) *Field {
+	t.Helper()
+	for _, f := range typ.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	t.Fatalf("%s has no field %s", typ.Name, name)
+	return nil
+}
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"noticePeriod":  "NoticePeriod",
+		"id":            "ID",
+		"created_at":    "CreatedAt",
+		"html-url":      "HTMLURL",
+		"NDA":           "NDA",
+		"definitionRef": "DefinitionRef",
+		"2fa":           "N2fa",
+		"":              "Value",
+	} {
+		if got := GoName(in); got != want {
+			t.Errorf("GoName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// goTest is the test of the generated code, run with the go command
+const goTest = `package generated
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerated(t *testing.T) {
+	data, err := os.ReadFile("testdata/contract.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	if doc.Metadata.Type != MetadataTypeContract || doc.Content.Sections[0].Content.String == nil {
+		t.Fatalf("decoded %+v", doc)
+	}
+
+	// Documents survive a round trip
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again Document
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatal(err)
+	}
+	if out2, _ := json.Marshal(again); string(out2) != string(out) {
+		t.Errorf("round trip changed the document:\n%s\n%s", out, out2)
+	}
+
+	doc.Metadata.Type = "memo"
+	doc.Metadata.Version = "1"
+	doc.Content.Sections[0].Content = SectionContent{Array: []Block{{Type: "heading"}}}
+	doc.Content.Sections[0].Sections = []Section{{ID: "1.1"}}
+	got := doc.Validate().Error()
+	for _, want := range []string{
+		"metadata.version: must match",
+		"metadata.type: must be one of contract, receipt, agreement",
+		"content.sections[0].content[0].type: must be one of",
+		"content.sections[0].sections[0].content: is required",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Validate() = %v, want %q", got, want)
+		}
+	}
+}
+`
+
+func TestGo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles the generated code")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command")
+	}
+	data, err := schemas.FS.ReadFile("document-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(data, "Document")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := Go(m, GoOptions{Package: "generated", Source: "document-v1.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, err := os.ReadFile("../../examples/valid-contract.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":                 "module generated\n\ngo 1.21\n",
+		"document.go":            string(code),
+		"document_test.go":       goTest,
+		"testdata/contract.json": string(contract),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"vet", "./..."}, {"test", "./..."}} {
+		cmd := exec.Command(goCmd, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local", "GOWORK=off")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+}
+
+func TestTypeScript(t *testing.T) {
+	data, err := schemas.FS.ReadFile("document-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(data, "Document")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(TypeScript(m, TypeScriptOptions{Source: "document-v1.json"}))
+	for _, want := range []string{
+		"// Code generated by nld gen ts from document-v1.json; DO NOT EDIT.",
+		"export interface Document {",
+		"  revisions?: Revision[];",
+		"  sections?: Section[];",
+		"  translations?: Record<string, Translation>;",
+		"export type MetadataType = \"contract\" | \"receipt\" | \"agreement\";",
+		"export type SectionContent = string | Block[];",
+		"export type Money = string | MoneyObject;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("TypeScript() does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestBundle(t *testing.T) {
+	data, err := schemas.FS.ReadFile("document-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Bundle(data, "generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bundle struct {
+		Comment     string                     `json:"$comment"`
+		Definitions map[string]json.RawMessage `json:"definitions"`
+	}
+	if err := json.Unmarshal(out, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Comment != "generated" {
+		t.Errorf("$comment = %q", bundle.Comment)
+	}
+	// Only subsections refer to a definition
+	if len(bundle.Definitions) != 1 || bundle.Definitions["section"] == nil {
+		t.Errorf("definitions = %v, want only section", bundle.Definitions)
+	}
+	refs := strings.Count(string(out), `"$ref"`)
+	if kept := strings.Count(string(out), `"$ref": "#/definitions/section"`); refs == 0 || kept != refs {
+		t.Errorf("bundle has %d references, %d to section:\n%s", refs, kept, out)
+	}
+
+	// The bundle validates documents as the schema does
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("bundle.json", bytes.NewReader(out)); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := compiler.Compile("bundle.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, err := os.ReadFile("../../examples/valid-contract.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(contract, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Validate(doc); err != nil {
+		t.Errorf("valid contract: %v", err)
+	}
+	doc.(map[string]interface{})["metadata"].(map[string]interface{})["type"] = "memo"
+	if err := schema.Validate(doc); err == nil {
+		t.Errorf("invalid contract validated")
+	}
+}
+
+func TestForm(t *testing.T) {
+	data, err := schemas.FS.ReadFile("document-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(data, "Document")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(Form(m, FormOptions{Source: "document-v1.json"}))
+	for _, want := range []string{
+		"<!-- Code generated by nld gen form from document-v1.json; DO NOT EDIT. -->",
+		"<title>New document</title>",
+		// Required fields are marked and enums are selects
+		`<span>Title <abbr class="nld-required" title="required">*</abbr></span><input type="text" data-kind="string" data-key="title" data-required required>`,
+		`<select data-kind="string" data-key="type" data-required required><option>contract</option><option>receipt</option><option>agreement</option></select>`,
+		`<input type="datetime-local" data-kind="string" data-key="created" data-required data-format="date-time" required>`,
+		`<input type="number" step="1" data-kind="integer" data-key="revision" min="1">`,
+		`<span>Notice period</span>`,
+		// Optional objects are added when needed
+		`<div data-kind="optional" data-key="totals"`,
+		"<option value=\"nld-template-",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Form() does not contain %q", want)
+		}
+	}
+	// Subsections use the template of their section
+	section := strings.Index(out, "<legend>Section</legend>")
+	if section < 0 {
+		t.Fatalf("no section template:\n%s", out)
+	}
+	start := strings.LastIndex(out[:section], `<template id="`)
+	id := out[start+len(`<template id="`) : start+strings.Index(out[start:], `">`)]
+	if n := strings.Count(out, `data-template="`+id+`"`); n != 2 {
+		t.Errorf("template %s used %d times, want sections and subsections", id, n)
+	}
+}
+
+func TestJSONForms(t *testing.T) {
+	data, err := schemas.FS.ReadFile("document-v1.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(data, "Document")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := Bundle(data, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := JSONForms(m, bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var form struct {
+		Schema   map[string]interface{} `json:"schema"`
+		UISchema map[string]interface{} `json:"uischema"`
+	}
+	if err := json.Unmarshal(out, &form); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every control refers to a property of the schema
+	var controls int
+	var walk func(element map[string]interface{})
+	walk = func(element map[string]interface{}) {
+		if scope, ok := element["scope"].(string); ok {
+			controls++
+			var v interface{} = form.Schema
+			for _, part := range strings.Split(strings.TrimPrefix(scope, "#/"), "/") {
+				v = v.(map[string]interface{})[part]
+			}
+			if v == nil {
+				t.Errorf("control %s is not in the schema", scope)
+			}
+		}
+		elements, _ := element["elements"].([]interface{})
+		for _, e := range elements {
+			walk(e.(map[string]interface{}))
+		}
+	}
+	walk(form.UISchema)
+	if controls == 0 || form.UISchema["type"] != "VerticalLayout" {
+		t.Errorf("uischema = %v", form.UISchema)
+	}
+	if !strings.Contains(string(out), `"scope": "#/properties/metadata/properties/noticePeriod"`) {
+		t.Errorf("no control of metadata.noticePeriod:\n%s", out)
+	}
+}
+
+func TestExample(t *testing.T) {
+	for _, name := range []string{"document-v1.json", "nda.schema.json"} {
+		data, err := schemas.FS.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource(name, bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+		schema, err := compiler.Compile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := Example(data, ExampleOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var doc interface{}
+		if err := json.Unmarshal(out, &doc); err != nil {
+			t.Fatal(err)
+		}
+		if err := schema.Validate(doc); err != nil {
+			t.Errorf("%s: example is not valid: %v\n%s", name, err, out)
+		}
+		for _, want := range []string{`"version": "1.0.0"`, `"id": "section-1"`, `"id": "section-2"`, `"created": "2024-01-15T09:00:00Z"`} {
+			if !strings.Contains(string(out), want) {
+				t.Errorf("%s: example does not contain %s:\n%s", name, want, out)
+			}
+		}
+
+		// Random examples match the schema and depend only on the seed
+		for seed := int64(0); seed < 20; seed++ {
+			out, err := Example(data, ExampleOptions{Rand: rand.New(rand.NewSource(seed))})
+			if err != nil {
+				t.Fatalf("%s: seed %d: %v", name, seed, err)
+			}
+			again, _ := Example(data, ExampleOptions{Rand: rand.New(rand.NewSource(seed))})
+			if string(again) != string(out) {
+				t.Errorf("%s: seed %d gave different examples", name, seed)
+			}
+			if err := json.Unmarshal(out, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if err := schema.Validate(doc); err != nil {
+				t.Errorf("%s: seed %d: example is not valid: %v\n%s", name, seed, err, out)
+			}
+		}
+	}
+}
+
+func TestExampleMatch(t *testing.T) {
+	e := &exampler{rand: rand.New(rand.NewSource(1))}
+	for _, pattern := range []string{
+		`^\d+\.\d+\.\d+$`,
+		`^P(\d+Y)?(\d+M)?(\d+W)?(\d+D)?(T(\d+H)?(\d+M)?(\d+S)?)?$`,
+		`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8})*$`,
+		`^(foo|bar)+[^a-z]?x{3}$`,
+		`[a-z]`,
+	} {
+		re := regexp.MustCompile(pattern)
+		for i := 0; i < 20; i++ {
+			v, err := e.match(pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !re.MatchString(v) {
+				t.Errorf("match(%q) = %q, which does not match", pattern, v)
+			}
+		}
+	}
+}
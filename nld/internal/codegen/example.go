@@ -0,0 +1,531 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ExampleOptions configures Example
+type ExampleOptions struct {
+	// Rand, if set, picks optional properties, alternatives, enum values,
+	// list lengths and sample values at random. Otherwise the example has
+	// every property and the first of every choice.
+	Rand *rand.Rand
+}
+
+// Example returns a document of the schema in data built from the
+// constraints of the schema: types, enums and consts, patterns, formats,
+// bounds and required properties. Strings are sample values chosen by
+// property name, such as an email address for email, when they fit the
+// constraints. Recursive references, such as subsections, are followed
+// twice.
+//
+// Rules a schema cannot express are not known, so examples should be
+// validated; with Rand, another example can be drawn if one is invalid.
+func Example(data []byte, opts ExampleOptions) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON in schema: %w", err)
+	}
+	doc, ok := v.(*object)
+	if !ok {
+		return nil, fmt.Errorf("invalid schema: not a JSON object")
+	}
+
+	e := &exampler{p: &parser{doc: doc}, rand: opts.Rand, depth: map[string]int{}, counts: map[string]int{}}
+	out, err := e.value(doc, "", "#")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, out, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// errRecursion is returned for values that would follow a recursive
+// reference too deep. Optional values are then left out.
+var errRecursion = errors.New("recursive reference")
+
+// maxDepth is the number of times a reference is followed within itself
+const maxDepth = 2
+
+// exampler builds examples of a schema
+type exampler struct {
+	p    *parser
+	rand *rand.Rand
+	// depth counts the references being followed
+	depth map[string]int
+	// item is the name of the items of the innermost list, and counts
+	// numbers identifiers by item name, so that they are unique
+	item   string
+	counts map[string]int
+}
+
+// value returns an example of schema s, the value of property name at
+// JSON pointer ptr of the schema
+func (e *exampler) value(s *object, name, ptr string) (interface{}, error) {
+	if ref := s.str("$ref"); ref != "" {
+		target, err := e.p.lookup(ref)
+		if ref == "#" {
+			target, err = e.p.doc, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ptr, err)
+		}
+		if e.depth[ref] >= maxDepth {
+			return nil, errRecursion
+		}
+		e.depth[ref]++
+		defer func() { e.depth[ref]-- }()
+		return e.value(target, name, ref)
+	}
+	if parts := s.list("allOf"); parts != nil {
+		merged, err := e.p.allOf(s, parts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ptr, err)
+		}
+		s = merged
+	}
+	if c := s.get("const"); c != nil {
+		return c, nil
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if alts := s.list(key); alts != nil {
+			return e.alternative(s, alts, name, ptr+"/"+key)
+		}
+	}
+	if enum := s.list("enum"); len(enum) > 0 {
+		return enum[e.intn(len(enum))], nil
+	}
+
+	switch kindOf(s) {
+	case String:
+		return e.string(s, name, ptr)
+	case Integer, Number:
+		return e.number(s, name, kindOf(s) == Integer), nil
+	case Boolean:
+		return e.rand == nil || e.rand.Intn(2) == 0, nil
+	case Array:
+		return e.array(s, name, ptr)
+	case Struct:
+		return e.object(s, ptr)
+	case Map:
+		return e.mapValue(s, name, ptr)
+	}
+	if types := s.list("type"); len(types) > 0 {
+		// Values of several types are examples of the first of them
+		for _, t := range types {
+			if t != "null" {
+				narrowed := &object{}
+				for _, key := range s.keys {
+					narrowed.set(key, s.get(key))
+				}
+				narrowed.set("type", t)
+				return e.value(narrowed, name, ptr)
+			}
+		}
+		return nil, nil
+	}
+	return e.string(s, name, ptr)
+}
+
+// alternative returns an example of one of the alternatives of a oneOf or
+// anyOf, trying the others if it recurses too deep
+func (e *exampler) alternative(s *object, alts []interface{}, name, ptr string) (interface{}, error) {
+	start := e.intn(len(alts))
+	for i := range alts {
+		n := (start + i) % len(alts)
+		alt, ok := alts[n].(*object)
+		if !ok {
+			continue
+		}
+		// Keywords beside the alternatives apply to each of them
+		merged := &object{}
+		for _, key := range s.keys {
+			if key != "oneOf" && key != "anyOf" {
+				merged.set(key, s.get(key))
+			}
+		}
+		if merged.get("type") != nil || merged.obj("properties") != nil {
+			merged.set("allOf", []interface{}{alt})
+		} else {
+			merged = alt
+		}
+		v, err := e.value(merged, name, ptr+"/"+strconv.Itoa(n))
+		if errors.Is(err, errRecursion) {
+			continue
+		}
+		return v, err
+	}
+	return nil, errRecursion
+}
+
+// object returns an example of an object with properties
+func (e *exampler) object(s *object, ptr string) (interface{}, error) {
+	required := map[string]bool{}
+	for _, r := range s.list("required") {
+		if str, ok := r.(string); ok {
+			required[str] = true
+		}
+	}
+	out := &object{}
+	props := s.obj("properties")
+	for _, key := range props.keys {
+		prop, ok := props.get(key).(*object)
+		if !ok {
+			prop = &object{}
+		}
+		if !required[key] && e.rand != nil && e.rand.Intn(2) == 0 {
+			continue
+		}
+		v, err := e.value(prop, key, ptr+"/properties/"+escape(key))
+		if errors.Is(err, errRecursion) {
+			if !required[key] {
+				continue
+			}
+			if kindOf(prop) == Array {
+				v, err = []interface{}{}, nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		out.set(key, v)
+	}
+	return out, nil
+}
+
+// mapValue returns an example of an object without properties, with an
+// entry whose key matches the propertyNames of s
+func (e *exampler) mapValue(s *object, name, ptr string) (interface{}, error) {
+	out := &object{}
+	elem, ok := s.get("additionalProperties").(*object)
+	if !ok {
+		return out, nil
+	}
+	key, err := e.string(s.obj("propertyNames"), "key", ptr+"/propertyNames")
+	if err != nil {
+		return nil, err
+	}
+	v, err := e.value(elem, singular(name), ptr+"/additionalProperties")
+	if errors.Is(err, errRecursion) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out.set(fmt.Sprint(key), v)
+	return out, nil
+}
+
+// array returns an example of an array of two items, or of the number of
+// items the schema needs
+func (e *exampler) array(s *object, name, ptr string) (interface{}, error) {
+	n := 2
+	if e.rand != nil {
+		n = e.rand.Intn(4)
+	}
+	if min := s.num("minItems"); min != nil && n < int(*min) {
+		n = int(*min)
+	}
+	if max := s.num("maxItems"); max != nil && n > int(*max) {
+		n = int(*max)
+	}
+	items, ok := s.get("items").(*object)
+	if !ok {
+		items = &object{}
+	}
+	item := e.item
+	e.item = singular(name)
+	defer func() { e.item = item }()
+	list := []interface{}{}
+	for i := 0; i < n; i++ {
+		v, err := e.value(items, singular(name), ptr+"/items")
+		if errors.Is(err, errRecursion) && len(list) > 0 {
+			if min := s.num("minItems"); min == nil || len(list) >= int(*min) {
+				break
+			}
+		}
+		// Lists without items because of recursion are left out
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// number returns an example of a number within the bounds of s
+func (e *exampler) number(s *object, name string, integer bool) interface{} {
+	lo, hi := 1.0, 100.0
+	if min := s.num("minimum"); min != nil {
+		lo = *min
+		if hi < lo {
+			hi = lo + 100
+		}
+	}
+	if max := s.num("maximum"); max != nil {
+		hi = *max
+		if lo > hi {
+			lo = hi
+		}
+	}
+	if integer {
+		lo, hi = math.Ceil(lo), math.Floor(hi)
+	}
+	v := lo
+	if e.rand != nil {
+		v = lo + e.rand.Float64()*(hi-lo)
+	}
+	if integer || v == math.Trunc(v) {
+		return json.Number(strconv.FormatFloat(math.Round(v), 'f', -1, 64))
+	}
+	return json.Number(strconv.FormatFloat(v, 'f', 2, 64))
+}
+
+// string returns an example of a string: a sample value of the property
+// name if one matches the constraints of s, otherwise one built from its
+// pattern or format
+func (e *exampler) string(s *object, name, ptr string) (interface{}, error) {
+	pattern := s.str("pattern")
+	var re *regexp.Regexp
+	if pattern != "" {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%s: unsupported pattern %q: %w", ptr, pattern, err)
+		}
+	}
+	fits := func(v string) bool {
+		n := len([]rune(v))
+		if min := s.num("minLength"); min != nil && n < int(*min) {
+			return false
+		}
+		if max := s.num("maxLength"); max != nil && n > int(*max) {
+			return false
+		}
+		return re == nil || re.MatchString(v)
+	}
+
+	var candidates []string
+	if v, ok := samples[strings.ToLower(name)]; ok {
+		candidates = v
+	} else if v, ok := formats[s.str("format")]; ok {
+		candidates = v
+	}
+	if len(candidates) > 0 {
+		// Without Rand, properties of the same name take the samples in
+		// turn
+		start := e.intn(len(candidates))
+		if e.rand == nil {
+			start = e.counts["sample:"+name] % len(candidates)
+			e.counts["sample:"+name]++
+		}
+		for i := range candidates {
+			v := candidates[(start+i)%len(candidates)]
+			if name == "id" {
+				v = e.identifier()
+			}
+			if fits(v) {
+				return v, nil
+			}
+		}
+	}
+	if re != nil {
+		v, err := e.match(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ptr, err)
+		}
+		if fits(v) {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%s: no example of pattern %q within the length bounds", ptr, pattern)
+	}
+	v := "Example " + strings.ToLower(label(name))
+	if name == "" {
+		v = "Example"
+	}
+	if min := s.num("minLength"); min != nil {
+		for len([]rune(v)) < int(*min) {
+			v += "."
+		}
+	}
+	if max := s.num("maxLength"); max != nil && len([]rune(v)) > int(*max) {
+		v = string([]rune(v)[:int(*max)])
+	}
+	return v, nil
+}
+
+// identifier returns a new identifier of an item of the innermost list,
+// such as section-3
+func (e *exampler) identifier() string {
+	prefix := strings.ToLower(e.item)
+	if prefix == "" {
+		prefix = "item"
+	}
+	e.counts[prefix]++
+	return prefix + "-" + strconv.Itoa(e.counts[prefix])
+}
+
+// intn returns 0, or a random number below n with Rand
+func (e *exampler) intn(n int) int {
+	if e.rand == nil || n <= 1 {
+		return 0
+	}
+	return e.rand.Intn(n)
+}
+
+// match returns a string matching a regular expression
+func (e *exampler) match(pattern string) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("unsupported pattern %q: %w", pattern, err)
+	}
+	var b strings.Builder
+	if err := e.write(&b, re.Simplify()); err != nil {
+		return "", fmt.Errorf("no example of pattern %q: %w", pattern, err)
+	}
+	return b.String(), nil
+}
+
+// write writes a string matching re
+func (e *exampler) write(b *strings.Builder, re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(e.char(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune('x')
+	case syntax.OpCapture:
+		return e.write(b, re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := e.write(b, sub); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		return e.write(b, re.Sub[e.intn(len(re.Sub))])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := 0, 1
+		switch re.Op {
+		case syntax.OpStar:
+			max = 3
+		case syntax.OpPlus:
+			min, max = 1, 3
+		case syntax.OpRepeat:
+			min, max = re.Min, re.Max
+			if max < 0 || max > min+3 {
+				max = min + 3
+			}
+		}
+		n := min
+		if e.rand != nil {
+			n += e.rand.Intn(max - min + 1)
+		}
+		for i := 0; i < n; i++ {
+			if err := e.write(b, re.Sub[0]); err != nil {
+				return err
+			}
+		}
+	case syntax.OpNoMatch:
+		return fmt.Errorf("the pattern matches nothing")
+	}
+	// Anchors and empty matches write nothing
+	return nil
+}
+
+// char returns a character of a class, given as pairs of ranges. Letters
+// and digits are preferred, and without Rand the first of them is taken.
+func (e *exampler) char(ranges []rune) rune {
+	var preferred, other []rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1] && r < 0x80; r++ {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				preferred = append(preferred, r)
+			} else if unicode.IsPrint(r) {
+				other = append(other, r)
+			}
+		}
+	}
+	if len(preferred) == 0 {
+		preferred = other
+	}
+	if len(preferred) == 0 {
+		if len(ranges) == 0 {
+			return 'x'
+		}
+		return ranges[0]
+	}
+	return preferred[e.intn(len(preferred))]
+}
+
+// samples are sample values of strings by property name, used when they
+// fit the constraints of the schema. Identifiers are numbered.
+var samples = map[string][]string{
+	"id":           {""},
+	"title":        {"Services Agreement", "Scope of Work", "Payment Terms", "Confidentiality", "Governing Law", "Termination"},
+	"name":         {"Acme Corporation", "Jane Doe", "Globex Ltd", "John Smith"},
+	"author":       {"Jane Doe", "John Smith"},
+	"role":         {"client", "provider", "buyer", "seller"},
+	"email":        {"jane.doe@example.com", "legal@example.org"},
+	"phone":        {"+1 555 0100", "+44 20 7946 0000"},
+	"address":      {"1 Main Street, Springfield", "221B Baker Street, London"},
+	"jurisdiction": {"California, USA", "England and Wales"},
+	"language":     {"en", "fr", "de"},
+	"key":          {"example", "fr", "de"},
+	"locale":       {"fr", "de", "es"},
+	"timezone":     {"America/New_York", "Europe/London"},
+	"version":      {"1.0.0"},
+	"created":      {"2024-01-15T09:00:00Z", "2024-03-01T14:30:00Z"},
+	"date":         {"2024-01-15T09:00:00Z", "2024-03-01T14:30:00Z"},
+	"effective":    {"2024-02-01", "2024-04-01"},
+	"expires":      {"2025-02-01", "2026-03-31"},
+	"term":         {"P1Y", "P2Y", "P6M"},
+	"noticeperiod": {"P30D", "P2W"},
+	"currency":     {"USD", "EUR", "GBP"},
+	"amount":       {"100.00", "2500.00", "49.99"},
+	"subtotal":     {"100.00", "2500.00"},
+	"total":        {"108.25", "2700.00"},
+	"rate":         {"8.25", "20"},
+	"content": {
+		"The Provider shall perform the services described in this agreement.",
+		"The Client shall pay each invoice within thirty days of receipt.",
+		"Each party shall keep the other party's confidential information secret.",
+	},
+	"text": {
+		"The Provider shall perform the services described in this agreement.",
+		"Either party may terminate this agreement with written notice.",
+	},
+	"summary":    {"Extended the term", "Updated the payment terms"},
+	"statement":  {"The parties agree to the terms of this agreement."},
+	"source":     {"section-1"},
+	"target":     {"section-2"},
+	"type":       {"requires", "references"},
+	"definition": {"Information disclosed by either party that is marked confidential."},
+	"previous":   {"sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+}
+
+// formats are sample values of strings by format
+var formats = map[string][]string{
+	"date-time": {"2024-01-15T09:00:00Z"},
+	"date":      {"2024-01-15"},
+	"time":      {"09:00:00Z"},
+	"email":     {"jane.doe@example.com"},
+	"uri":       {"https://example.com"},
+	"uuid":      {"123e4567-e89b-12d3-a456-426614174000"},
+}
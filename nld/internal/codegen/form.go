@@ -0,0 +1,536 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// FormOptions configures Form
+type FormOptions struct {
+	// Source names the schema in the header of the form
+	Source string
+}
+
+// Form returns an HTML page with a form for entering documents of m. Fields
+// are inputs of their type, enums are selects and required fields are
+// marked and cannot be left empty; lists, maps and values of several kinds
+// are edited with buttons and selects. A script turns the form into a
+// document, offered for download.
+func Form(m *Model, opts FormOptions) []byte {
+	f := &form{templates: map[formTemplate]string{}}
+	var body strings.Builder
+	for _, field := range m.Root.Fields {
+		f.field(&body, field, []*Type{m.Root})
+	}
+	// Templates are written last, as writing them may need more of them
+	var templates strings.Builder
+	for i := 0; i < len(f.queue); i++ {
+		t := f.queue[i]
+		fmt.Fprintf(&templates, "<template id=\"%s\">\n", f.templates[t])
+		switch {
+		case t.entry:
+			templates.WriteString("<div class=\"nld-item\" data-kind=\"entry\">\n<label class=\"nld-field\"><span>Key <abbr class=\"nld-required\" title=\"required\">*</abbr></span><input type=\"text\" data-map-key required></label>\n")
+		case t.item:
+			templates.WriteString("<div class=\"nld-item\">\n")
+		}
+		// Only objects are labeled, as single values are labeled by their list
+		n := formNode{name: t.name, required: true}
+		if t.typ.Kind == Struct {
+			n.label = t.label
+		}
+		f.widget(&templates, n, t.typ, nil)
+		if t.item || t.entry {
+			templates.WriteString("<button type=\"button\" class=\"nld-remove\" data-remove>Remove</button>\n</div>\n")
+		}
+		templates.WriteString("</template>\n")
+	}
+
+	title := html.EscapeString("New " + strings.ToLower(label(m.Root.Name)))
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<!-- Code generated by nld gen form from %s; DO NOT EDIT. -->\n", html.EscapeString(opts.Source))
+	fmt.Fprintf(&b, "<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n%s</head>\n<body>\n<h1>%s</h1>\n", title, formStyle, title)
+	if m.Root.Description != "" {
+		fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(m.Root.Description))
+	}
+	fmt.Fprintf(&b, "<form id=\"nld-form\" data-kind=\"object\" data-required>\n%s<button type=\"submit\">Create document</button>\n</form>\n", body.String())
+	b.WriteString("<section id=\"nld-result\" hidden>\n<h2>Document</h2>\n<p><a id=\"nld-download\" download=\"document.json\">Download document.json</a></p>\n<pre></pre>\n</section>\n")
+	b.WriteString(templates.String())
+	b.WriteString(formScript)
+	b.WriteString("</body>\n</html>\n")
+	return b.Bytes()
+}
+
+// JSONForms returns the schema and UI schema of a form for JSON Forms, as
+// an object with schema and uischema properties. bundle is the schema of m
+// as returned by Bundle. Objects are groups of controls, in the order of
+// the schema, and JSON Forms renders the controls of their type.
+func JSONForms(m *Model, bundle []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(bundle))
+	dec.UseNumber()
+	schema, err := decode(dec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON in schema: %w", err)
+	}
+	out := &object{}
+	out.set("schema", schema)
+	out.set("uischema", uiLayout(m.Root, "#", "", []*Type{m.Root}))
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, out, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// uiLayout returns the layout of the fields of struct t at scope, a group
+// if it has a label
+func uiLayout(t *Type, scope, groupLabel string, stack []*Type) *object {
+	layout := &object{}
+	if groupLabel != "" {
+		layout.set("type", "Group")
+		layout.set("label", groupLabel)
+	} else {
+		layout.set("type", "VerticalLayout")
+	}
+	var elements []interface{}
+	for _, field := range t.Fields {
+		fieldScope := scope + "/properties/" + escape(field.Name)
+		recursive := false
+		for _, s := range stack {
+			recursive = recursive || s == field.Type
+		}
+		if field.Type.Kind == Struct && !recursive {
+			elements = append(elements, uiLayout(field.Type, fieldScope, label(field.Name), append(stack, field.Type)))
+			continue
+		}
+		control := &object{}
+		control.set("type", "Control")
+		control.set("scope", fieldScope)
+		control.set("label", label(field.Name))
+		elements = append(elements, control)
+	}
+	layout.set("elements", elements)
+	return layout
+}
+
+// form writes the widgets of a form
+type form struct {
+	// templates holds the IDs of the templates of list items and of the
+	// alternatives of unions, and queue the templates to write
+	templates map[formTemplate]string
+	queue     []formTemplate
+}
+
+// formTemplate is a template of a form, added by the script
+type formTemplate struct {
+	typ   *Type
+	name  string
+	label string
+	// item is set for list items and entry for map entries, which can
+	// be removed
+	item  bool
+	entry bool
+}
+
+// formNode is a value edited by a widget
+type formNode struct {
+	// key is the property of the value in its object, if any
+	key string
+	// name is the name of the value, used for labels
+	name        string
+	label       string
+	required    bool
+	description string
+}
+
+// template returns the ID of the template of t
+func (f *form) template(t formTemplate) string {
+	if id, ok := f.templates[t]; ok {
+		return id
+	}
+	id := fmt.Sprintf("nld-template-%d", len(f.queue)+1)
+	f.templates[t] = id
+	f.queue = append(f.queue, t)
+	return id
+}
+
+// field writes the widget of a field of a struct
+func (f *form) field(b *strings.Builder, field *Field, stack []*Type) {
+	f.widget(b, formNode{
+		key:         field.Name,
+		name:        field.Name,
+		label:       label(field.Name),
+		required:    field.Required,
+		description: field.Description,
+	}, field.Type, stack)
+}
+
+// widget writes the widget of a value of type t. stack holds the structs
+// being written, so that a struct containing itself ends.
+func (f *form) widget(b *strings.Builder, n formNode, t *Type, stack []*Type) {
+	attrs := ""
+	if n.key != "" {
+		attrs += fmt.Sprintf(" data-key=\"%s\"", html.EscapeString(n.key))
+	}
+	if n.required {
+		attrs += " data-required"
+	}
+	legend := func() {
+		if n.label != "" {
+			fmt.Fprintf(b, "<legend>%s</legend>\n", labelHTML(n))
+		}
+		if n.description != "" {
+			fmt.Fprintf(b, "<small>%s</small>\n", html.EscapeString(n.description))
+		}
+	}
+
+	switch t.Kind {
+	case Struct:
+		for _, s := range stack {
+			if s == t {
+				f.input(b, n, `<textarea data-kind="json" placeholder="JSON"`+attrs, "</textarea>")
+				return
+			}
+		}
+		if !n.required {
+			// Optional objects are added when needed, as their required
+			// fields could not be left empty otherwise
+			id := f.template(formTemplate{typ: t, name: n.name, label: n.label, item: true})
+			fmt.Fprintf(b, "<div data-kind=\"optional\"%s data-template=\"%s\" data-max=\"1\">\n", attrs, id)
+			fmt.Fprintf(b, "<div class=\"nld-items\"></div>\n<button type=\"button\" data-add>Add %s</button>\n</div>\n", html.EscapeString(strings.ToLower(n.label)))
+			return
+		}
+		fmt.Fprintf(b, "<fieldset data-kind=\"object\"%s>\n", attrs)
+		legend()
+		for _, field := range t.Fields {
+			f.field(b, field, append(stack, t))
+		}
+		b.WriteString("</fieldset>\n")
+	case Array, Map:
+		item := singular(n.name)
+		if item == "" || item == n.name {
+			item = "item"
+			if t.Elem.Name != "" {
+				item = t.Elem.Name
+			}
+		}
+		tmpl, kind := formTemplate{typ: t.Elem, name: item, label: label(item), item: true}, "array"
+		if t.Kind == Map {
+			tmpl.item, tmpl.entry, kind = false, true, "map"
+		}
+		id := f.template(tmpl)
+		fmt.Fprintf(b, "<fieldset data-kind=\"%s\"%s data-template=\"%s\"", kind, attrs, id)
+		if t.MinItems > 0 {
+			fmt.Fprintf(b, " data-min=\"%d\"", t.MinItems)
+		}
+		if t.MaxItems >= 0 {
+			fmt.Fprintf(b, " data-max=\"%d\"", t.MaxItems)
+		}
+		b.WriteString(">\n")
+		legend()
+		fmt.Fprintf(b, "<div class=\"nld-items\"></div>\n<button type=\"button\" data-add>Add %s</button>\n", html.EscapeString(strings.ToLower(label(item))))
+		b.WriteString("</fieldset>\n")
+	case Union:
+		fmt.Fprintf(b, "<fieldset data-kind=\"union\"%s>\n", attrs)
+		legend()
+		b.WriteString("<select data-choose>\n")
+		if !n.required {
+			b.WriteString("<option value=\"\"></option>\n")
+		}
+		for _, alt := range t.Alternatives {
+			id := f.template(formTemplate{typ: alt, name: n.name})
+			fmt.Fprintf(b, "<option value=\"%s\">%s</option>\n", id, kindLabel(alt))
+		}
+		b.WriteString("</select>\n<div class=\"nld-choice\"></div>\n</fieldset>\n")
+	case String:
+		if t.Enum != nil {
+			var options strings.Builder
+			if !n.required {
+				options.WriteString("<option value=\"\"></option>")
+			}
+			for _, v := range t.Enum {
+				fmt.Fprintf(&options, "<option>%s</option>", html.EscapeString(v))
+			}
+			f.input(b, n, `<select data-kind="string"`+attrs, options.String()+"</select>")
+			return
+		}
+		if t.Pattern == "" && t.Format == "" && longText[n.name] {
+			f.input(b, n, `<textarea data-kind="string" rows="4"`+attrs, "</textarea>")
+			return
+		}
+		input := "text"
+		switch t.Format {
+		case "date-time":
+			input = "datetime-local"
+		case "date", "email":
+			input = t.Format
+		case "uri":
+			input = "url"
+		}
+		if t.Format != "" {
+			attrs += fmt.Sprintf(" data-format=\"%s\"", html.EscapeString(t.Format))
+		}
+		if t.Pattern != "" {
+			attrs += fmt.Sprintf(" pattern=\"%s\"", html.EscapeString(htmlPattern(t.Pattern)))
+		}
+		f.input(b, n, `<input type="`+input+`" data-kind="string"`+attrs, "")
+	case Integer, Number:
+		step, kind := "any", "number"
+		if t.Kind == Integer {
+			step, kind = "1", "integer"
+		}
+		if t.Minimum != nil {
+			attrs += " min=\"" + strconv.FormatFloat(*t.Minimum, 'f', -1, 64) + "\""
+		}
+		if t.Maximum != nil {
+			attrs += " max=\"" + strconv.FormatFloat(*t.Maximum, 'f', -1, 64) + "\""
+		}
+		f.input(b, n, `<input type="number" step="`+step+`" data-kind="`+kind+`"`+attrs, "")
+	case Boolean:
+		// Unchecked boxes are false, so they are never missing
+		n.required = false
+		f.input(b, n, `<input type="checkbox" data-kind="boolean"`+attrs, "")
+	default:
+		f.input(b, n, `<textarea data-kind="json" placeholder="JSON"`+attrs, "</textarea>")
+	}
+}
+
+// input writes the labeled input of a single value: the start tag of its
+// element, open until its required attribute, and the rest of the element
+func (f *form) input(b *strings.Builder, n formNode, open, rest string) {
+	b.WriteString("<label class=\"nld-field\">")
+	if n.label != "" {
+		fmt.Fprintf(b, "<span>%s</span>", labelHTML(n))
+	}
+	b.WriteString(open)
+	if n.required {
+		b.WriteString(" required")
+	}
+	b.WriteString(">" + rest)
+	if n.description != "" {
+		fmt.Fprintf(b, "<small>%s</small>", html.EscapeString(n.description))
+	}
+	b.WriteString("</label>\n")
+}
+
+// longText holds the names of strings written with text areas, as they are
+// usually longer than a line
+var longText = map[string]bool{"content": true, "text": true, "description": true}
+
+// label returns the label of a name, such as Notice period for noticePeriod
+func label(name string) string {
+	w := words(name)
+	if len(w) == 0 {
+		return "Value"
+	}
+	for i := range w {
+		// Initialisms such as ID keep their case
+		if g := GoName(w[i]); len(g) > 1 && strings.ToUpper(g) == g {
+			w[i] = g
+		} else {
+			w[i] = strings.ToLower(w[i])
+		}
+	}
+	first := []rune(w[0])
+	w[0] = strings.ToUpper(string(first[:1])) + string(first[1:])
+	return strings.Join(w, " ")
+}
+
+// labelHTML returns the label of a node, marked if it is a required
+// property
+func labelHTML(n formNode) string {
+	s := html.EscapeString(n.label)
+	if n.required && n.key != "" {
+		s += " <abbr class=\"nld-required\" title=\"required\">*</abbr>"
+	}
+	return s
+}
+
+// kindLabel returns the label of an alternative of a union
+func kindLabel(t *Type) string {
+	switch t.Kind {
+	case String:
+		return "Text"
+	case Integer, Number:
+		return "Number"
+	case Boolean:
+		return "Yes or no"
+	case Array:
+		return "List"
+	case Map:
+		return "Entries"
+	case Struct:
+		return "Details"
+	}
+	return "JSON"
+}
+
+// htmlPattern returns a schema pattern as the pattern of an input, which
+// must match the whole value while schema patterns match anywhere in it
+func htmlPattern(pattern string) string {
+	if strings.HasPrefix(pattern, "^") && strings.HasSuffix(pattern, "$") && !strings.HasSuffix(pattern, `\$`) {
+		return pattern
+	}
+	return `[\s\S]*(?:` + pattern + `)[\s\S]*`
+}
+
+// formStyle is added to the head of forms
+const formStyle = `<style>
+body { font-family: sans-serif; max-width: 50em; margin: 2em auto; padding: 0 1em; }
+fieldset { border: 1px solid #ccc; margin: 0.5em 0; padding: 0.5em 1em; }
+.nld-field { display: block; margin: 0.5em 0; }
+.nld-field > span { display: block; font-weight: bold; }
+.nld-field small, fieldset > small { display: block; color: #555; }
+.nld-field input:not([type=checkbox]), .nld-field select, .nld-field textarea { width: 100%; box-sizing: border-box; }
+.nld-required { color: #d33; text-decoration: none; }
+.nld-item { border-left: 3px solid #ddd; padding-left: 0.75em; margin: 0.5em 0; }
+#nld-result pre { background: #f6f6f6; padding: 1em; overflow: auto; }
+</style>
+`
+
+// formScript adds list items and alternatives of unions, and turns the
+// form into a document
+const formScript = `<script>
+(function () {
+  var form = document.getElementById("nld-form");
+
+  function instantiate(id) {
+    return document.getElementById(id).content.firstElementChild.cloneNode(true);
+  }
+
+  // children returns the values directly inside node
+  function children(node) {
+    return Array.prototype.filter.call(node.querySelectorAll("[data-kind]"), function (el) {
+      return el.parentElement.closest("[data-kind]") === node;
+    });
+  }
+
+  function add(list) {
+    var items = list.querySelector(".nld-items");
+    if (list.dataset.max && items.children.length >= Number(list.dataset.max)) {
+      return;
+    }
+    var item = instantiate(list.dataset.template);
+    init(item);
+    items.appendChild(item);
+    update(list);
+  }
+
+  function remove(item) {
+    var list = item.parentElement.closest("[data-template]");
+    item.remove();
+    update(list);
+  }
+
+  // update hides the add button of full lists
+  function update(list) {
+    var button = list.querySelector(":scope > [data-add]");
+    button.hidden = !!list.dataset.max && list.querySelector(".nld-items").children.length >= Number(list.dataset.max);
+  }
+
+  function choose(select) {
+    var choice = select.nextElementSibling;
+    choice.innerHTML = "";
+    if (select.value) {
+      var alt = instantiate(select.value);
+      init(alt);
+      choice.appendChild(alt);
+    }
+  }
+
+  // all returns the elements of root, including root, matching selector
+  function all(root, selector) {
+    var found = Array.prototype.slice.call(root.querySelectorAll(selector));
+    if (root.matches(selector)) {
+      found.unshift(root);
+    }
+    return found;
+  }
+
+  function init(root) {
+    all(root, "[data-template][data-min]").forEach(function (list) {
+      for (var i = 0; i < Number(list.dataset.min); i++) {
+        add(list);
+      }
+    });
+    all(root, "select[data-choose]").forEach(choose);
+  }
+
+  function value(node) {
+    var required = node.hasAttribute("data-required"), v;
+    switch (node.dataset.kind) {
+    case "object":
+      v = {};
+      children(node).forEach(function (child) {
+        var cv = value(child);
+        if (cv !== undefined) {
+          v[child.dataset.key] = cv;
+        }
+      });
+      return Object.keys(v).length || required ? v : undefined;
+    case "array":
+      v = children(node).map(value).filter(function (item) { return item !== undefined; });
+      return v.length || required ? v : undefined;
+    case "map":
+      v = {};
+      children(node).forEach(function (entry) {
+        v[entry.querySelector("[data-map-key]").value] = value(children(entry)[0]);
+      });
+      return Object.keys(v).length || required ? v : undefined;
+    case "optional":
+    case "union":
+      v = children(node)[0];
+      return v ? value(v) : undefined;
+    case "string":
+      if (node.value === "") {
+        return required ? "" : undefined;
+      }
+      if (node.dataset.format === "date-time") {
+        return new Date(node.value).toISOString().replace(".000Z", "Z");
+      }
+      return node.value;
+    case "integer":
+    case "number":
+      return node.value === "" ? undefined : Number(node.value);
+    case "boolean":
+      return node.checked || required ? node.checked : undefined;
+    case "json":
+      return node.value === "" ? undefined : JSON.parse(node.value);
+    }
+  }
+
+  form.addEventListener("click", function (e) {
+    if (e.target.hasAttribute("data-add")) {
+      add(e.target.closest("[data-template]"));
+    } else if (e.target.hasAttribute("data-remove")) {
+      remove(e.target.closest(".nld-item"));
+    }
+  });
+  form.addEventListener("change", function (e) {
+    if (e.target.hasAttribute("data-choose")) {
+      choose(e.target);
+    }
+  });
+  form.addEventListener("submit", function (e) {
+    e.preventDefault();
+    var doc;
+    try {
+      doc = JSON.stringify(value(form), null, 2);
+    } catch (err) {
+      alert("Invalid JSON: " + err.message);
+      return;
+    }
+    var result = document.getElementById("nld-result");
+    result.querySelector("pre").textContent = doc;
+    document.getElementById("nld-download").href = URL.createObjectURL(new Blob([doc + "\n"], { type: "application/json" }));
+    result.hidden = false;
+    result.scrollIntoView();
+  });
+  init(form);
+})();
+</script>
+`
@@ -0,0 +1,427 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// GoOptions configures Go
+type GoOptions struct {
+	// Package is the name of the package of the code
+	Package string
+	// Source names the schema in the header of the code
+	Source string
+}
+
+// Go returns Go code declaring the types of m, with json tags matching
+// the schema. Every named type has a Validate method checking the
+// constraints its Go type cannot express.
+func Go(m *Model, opts GoOptions) ([]byte, error) {
+	g := &goGen{patterns: map[string]string{}}
+	for _, t := range m.Types {
+		switch {
+		case t.Kind == Struct:
+			g.structType(t)
+		case t.Kind == Union:
+			g.unionType(t)
+		case t.Enum != nil:
+			g.enumType(t)
+		}
+	}
+	g.helpers()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by nld gen go from %s; DO NOT EDIT.\n\npackage %s\n\n", opts.Source, opts.Package)
+	body := g.body.String()
+	var imports []string
+	for _, pkg := range []string{"encoding/json", "errors", "fmt", "regexp"} {
+		if strings.Contains(body+g.vars.String(), pkg[strings.LastIndex(pkg, "/")+1:]+".") {
+			imports = append(imports, strconv.Quote(pkg))
+		}
+	}
+	if len(imports) > 0 {
+		fmt.Fprintf(&b, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+	if len(g.patterns) > 0 {
+		b.WriteString("var (\n")
+		b.WriteString(g.vars.String())
+		b.WriteString(")\n\n")
+	}
+	b.WriteString(body)
+
+	out, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return out, nil
+}
+
+// goGen writes the declarations of the types of a model
+type goGen struct {
+	body strings.Builder
+	vars strings.Builder
+	// patterns holds the variables of compiled patterns by pattern
+	patterns map[string]string
+}
+
+func (g *goGen) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+// comment writes the doc comment of a named type, with a note added to
+// its first paragraph
+func (g *goGen) comment(t *Type, what, note string) {
+	g.printf("// %s is %s of %s in the schema.%s\n", t.Name, what, t.Pointer, note)
+	if t.Description != "" {
+		g.printf("//\n")
+		g.lines(t.Description)
+	}
+}
+
+// lines writes text as comment lines
+func (g *goGen) lines(text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		g.printf("// %s\n", strings.TrimSpace(line))
+	}
+}
+
+// goType returns the Go type of t, as a pointer if optional and t has no
+// zero value of its own
+func goType(t *Type, optional bool) string {
+	switch t.Kind {
+	case String:
+		if t.Enum != nil {
+			return t.Name
+		}
+		return "string"
+	case Integer:
+		return "int"
+	case Number:
+		return "float64"
+	case Boolean:
+		return "bool"
+	case Array:
+		return "[]" + goType(t.Elem, false)
+	case Map:
+		return "map[string]" + goType(t.Elem, false)
+	case Struct, Union:
+		if optional {
+			return "*" + t.Name
+		}
+		return t.Name
+	}
+	return "json.RawMessage"
+}
+
+// structType declares a struct
+func (g *goGen) structType(t *Type) {
+	g.comment(t, "the object", "")
+	g.printf("type %s struct {\n", t.Name)
+	names := map[string]bool{}
+	for _, f := range t.Fields {
+		if f.Description != "" {
+			g.lines(f.Description)
+		}
+		tag := f.Name
+		if !f.Required {
+			tag += ",omitempty"
+		}
+		g.printf("%s %s `json:%q`\n", fieldName(f, names), goType(f.Type, !f.Required), tag)
+	}
+	g.printf("}\n\n")
+
+	g.validateMethod(t, "m")
+	names = map[string]bool{}
+	for _, f := range t.Fields {
+		p := optional
+		if f.Required {
+			p = required
+		}
+		g.check("m."+fieldName(f, names), fmt.Sprintf("at(path, %q)", f.Name), f.Type, p, 0)
+	}
+	g.printf("return errs\n}\n\n")
+}
+
+// fieldName returns the Go name of a field, unique among names
+func fieldName(f *Field, names map[string]bool) string {
+	name := GoName(f.Name)
+	for i := 2; names[name]; i++ {
+		name = GoName(f.Name) + strconv.Itoa(i)
+	}
+	names[name] = true
+	return name
+}
+
+// altName returns the name of the field of an alternative of a union
+func altName(t *Type) string {
+	switch t.Kind {
+	case String:
+		return "String"
+	case Integer, Number:
+		return "Number"
+	case Boolean:
+		return "Bool"
+	case Array:
+		return "Array"
+	case Map:
+		return "Map"
+	}
+	return "Object"
+}
+
+// unionType declares a union as a struct with a field for each of its
+// alternatives, of which one is set, and methods encoding it as the set
+// alternative
+func (g *goGen) unionType(t *Type) {
+	g.comment(t, "the value", "\n// One of its fields is set.")
+	g.printf("type %s struct {\n", t.Name)
+	for _, alt := range t.Alternatives {
+		typ := goType(alt, true)
+		if alt.Kind != Array && alt.Kind != Map && !strings.HasPrefix(typ, "*") {
+			typ = "*" + typ
+		}
+		g.printf("%s %s\n", altName(alt), typ)
+	}
+	g.printf("}\n\n")
+
+	g.printf("// MarshalJSON implements json.Marshaler, writing the field that is set\n")
+	g.printf("func (u %s) MarshalJSON() ([]byte, error) {\nswitch {\n", t.Name)
+	for _, alt := range t.Alternatives {
+		g.printf("case u.%s != nil:\nreturn json.Marshal(u.%[1]s)\n", altName(alt))
+	}
+	g.printf("}\nreturn []byte(\"null\"), nil\n}\n\n")
+
+	g.printf("// UnmarshalJSON implements json.Unmarshaler, setting the field of the\n// kind of value in data\n")
+	g.printf("func (u *%s) UnmarshalJSON(data []byte) error {\n*u = %[1]s{}\n", t.Name)
+	g.printf("for len(data) > 0 && (data[0] == ' ' || data[0] == '\\t' || data[0] == '\\n' || data[0] == '\\r') {\ndata = data[1:]\n}\n")
+	g.printf("if len(data) == 0 || data[0] == 'n' {\nreturn nil\n}\nswitch data[0] {\n")
+	var kinds []string
+	for _, alt := range t.Alternatives {
+		field := altName(alt)
+		switch alt.Kind {
+		case String:
+			g.printf("case '\"':\n")
+			kinds = append(kinds, "a string")
+		case Integer, Number:
+			g.printf("case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':\n")
+			kinds = append(kinds, "a number")
+		case Boolean:
+			g.printf("case 't', 'f':\n")
+			kinds = append(kinds, "a boolean")
+		case Array:
+			g.printf("case '[':\nreturn json.Unmarshal(data, &u.%s)\n", field)
+			kinds = append(kinds, "an array")
+			continue
+		case Map:
+			g.printf("case '{':\nreturn json.Unmarshal(data, &u.%s)\n", field)
+			kinds = append(kinds, "an object")
+			continue
+		default:
+			g.printf("case '{':\n")
+			kinds = append(kinds, "an object")
+		}
+		g.printf("u.%s = new(%s)\nreturn json.Unmarshal(data, u.%[1]s)\n", field, strings.TrimPrefix(goType(alt, true), "*"))
+	}
+	g.printf("}\nreturn errors.New(\"%s must be %s\")\n}\n\n", t.Name, orList(kinds))
+
+	g.validateMethod(t, "u")
+	g.printf("switch {\n")
+	for _, alt := range t.Alternatives {
+		field := altName(alt)
+		g.printf("case u.%s != nil:\n", field)
+		expr := "u." + field
+		if alt.Kind != Array && alt.Kind != Map && alt.Kind != Struct && alt.Kind != Union && alt.Enum == nil {
+			expr = "*" + expr
+		}
+		g.check(expr, "path", alt, present, 0)
+	}
+	g.printf("default:\nerrs = append(errs, fail(path, \"is required\"))\n}\nreturn errs\n}\n\n")
+}
+
+// enumType declares a string type and constants of its values
+func (g *goGen) enumType(t *Type) {
+	g.comment(t, "the string", "")
+	g.printf("type %s string\n\n", t.Name)
+	g.printf("// The values of %s\nconst (\n", t.Name)
+	var consts []string
+	seen := map[string]bool{}
+	for _, v := range t.Enum {
+		name := t.Name + GoName(v)
+		for i := 2; seen[name]; i++ {
+			name = t.Name + GoName(v) + strconv.Itoa(i)
+		}
+		seen[name] = true
+		consts = append(consts, name)
+		g.printf("%s %s = %q\n", name, t.Name, v)
+	}
+	g.printf(")\n\n")
+
+	g.printf("// Validate checks that v is one of the values of %s\n", t.Name)
+	g.printf("func (v %s) Validate() error {\nreturn errors.Join(v.validate(\"\")...)\n}\n\n", t.Name)
+	g.printf("func (v %s) validate(path string) []error {\nswitch v {\ncase %s:\nreturn nil\n}\n", t.Name, strings.Join(consts, ", "))
+	g.printf("return []error{fail(path, \"must be one of %%s\", %q)}\n}\n\n", strings.Join(t.Enum, ", "))
+}
+
+// validateMethod starts the validate method of a struct or union, after
+// its exported Validate method
+func (g *goGen) validateMethod(t *Type, recv string) {
+	g.printf("// Validate checks the constraints of the schema that the Go type of\n// %s cannot express, returning an error for each that fails\n", t.Name)
+	g.printf("func (%s *%s) Validate() error {\nreturn errors.Join(%[1]s.validate(\"\")...)\n}\n\n", recv, t.Name)
+	g.printf("func (%s *%s) validate(path string) (errs []error) {\n", recv, t.Name)
+}
+
+// presence is what is known of the presence of a value to check
+type presence int
+
+const (
+	// optional values are only checked if present
+	optional presence = iota
+	// required values are reported if missing
+	required
+	// present values are known to be present
+	present
+)
+
+// check writes the checks of a value of type t, the Go expression expr at
+// the path given by the Go expression path. The presence of required
+// strings, numbers and booleans cannot be checked, as they are their zero
+// value when missing.
+func (g *goGen) check(expr, path string, t *Type, p presence, depth int) {
+	if !needsCheck(t) && !(p == required && (t.Kind == Array || t.Kind == Map || t.Kind == Any)) {
+		return
+	}
+	switch t.Kind {
+	case Struct, Union:
+		if p != optional {
+			g.printf("errs = append(errs, %s.validate(%s)...)\n", expr, path)
+			return
+		}
+		g.printf("if %s != nil {\nerrs = append(errs, %[1]s.validate(%s)...)\n}\n", expr, path)
+	case String:
+		cond := ""
+		if p == optional {
+			cond = expr + ` != "" && `
+		}
+		if t.Enum != nil {
+			if cond != "" {
+				g.printf("if %s {\nerrs = append(errs, %s.validate(%s)...)\n}\n", strings.TrimSuffix(cond, " && "), expr, path)
+				return
+			}
+			g.printf("errs = append(errs, %s.validate(%s)...)\n", expr, path)
+			return
+		}
+		g.printf("if %s!%s.MatchString(%s) {\nerrs = append(errs, fail(%s, \"must match %%s\", %q))\n}\n", cond, g.pattern(t.Pattern), expr, path, t.Pattern)
+	case Integer, Number:
+		cond := ""
+		if p == optional {
+			cond = expr + " != 0 && "
+		}
+		if t.Minimum != nil {
+			min := strconv.FormatFloat(*t.Minimum, 'g', -1, 64)
+			g.printf("if %s%s < %s {\nerrs = append(errs, fail(%s, \"must be at least %s\"))\n}\n", cond, expr, min, path, min)
+		}
+		if t.Maximum != nil {
+			max := strconv.FormatFloat(*t.Maximum, 'g', -1, 64)
+			g.printf("if %s%s > %s {\nerrs = append(errs, fail(%s, \"must be at most %s\"))\n}\n", cond, expr, max, path, max)
+		}
+	case Array, Map:
+		if p == required {
+			g.printf("if %s == nil {\nerrs = append(errs, fail(%s, \"is required\"))\n}\n", expr, path)
+		}
+		cond := ""
+		if p == optional {
+			cond = expr + " != nil && "
+		}
+		if t.MinItems >= 0 {
+			g.printf("if %slen(%s) < %d {\nerrs = append(errs, fail(%s, \"must have at least %d items\"))\n}\n", cond, expr, t.MinItems, path, t.MinItems)
+		}
+		if t.MaxItems >= 0 {
+			g.printf("if len(%s) > %d {\nerrs = append(errs, fail(%s, \"must have at most %d items\"))\n}\n", expr, t.MaxItems, path, t.MaxItems)
+		}
+		if !needsCheck(t.Elem) {
+			return
+		}
+		index, value := fmt.Sprintf("i%d", depth), fmt.Sprintf("v%d", depth)
+		if t.Kind == Array {
+			g.printf("for %s, %s := range %s {\n", index, value, expr)
+			g.check(value, fmt.Sprintf("fmt.Sprintf(\"%%s[%%d]\", %s, %s)", path, index), t.Elem, present, depth+1)
+		} else {
+			g.printf("for %s, %s := range %s {\n", index, value, expr)
+			g.check(value, fmt.Sprintf("fmt.Sprintf(\"%%s[%%q]\", %s, %s)", path, index), t.Elem, present, depth+1)
+		}
+		g.printf("}\n")
+	case Any:
+		if p != required {
+			return
+		}
+		g.printf("if %s == nil {\nerrs = append(errs, fail(%s, \"is required\"))\n}\n", expr, path)
+	}
+}
+
+// needsCheck reports whether values of type t have constraints to check
+// besides their presence
+func needsCheck(t *Type) bool {
+	switch t.Kind {
+	case Struct, Union:
+		return true
+	case String:
+		return t.Enum != nil || (t.Pattern != "" && validPattern(t.Pattern))
+	case Integer, Number:
+		return t.Minimum != nil || t.Maximum != nil
+	case Array, Map:
+		return t.MinItems >= 0 || t.MaxItems >= 0 || needsCheck(t.Elem)
+	}
+	return false
+}
+
+// validPattern reports whether a pattern of the schema, which is an
+// ECMAScript regular expression, is also one of Go. Patterns that are not
+// are left unchecked.
+func validPattern(pattern string) bool {
+	_, err := regexp.Compile(pattern)
+	return err == nil
+}
+
+// pattern returns the variable of a compiled pattern, declaring it on
+// first use
+func (g *goGen) pattern(pattern string) string {
+	if name, ok := g.patterns[pattern]; ok {
+		return name
+	}
+	name := "pattern" + strconv.Itoa(len(g.patterns)+1)
+	g.patterns[pattern] = name
+	fmt.Fprintf(&g.vars, "%s = regexp.MustCompile(%q)\n", name, pattern)
+	return name
+}
+
+// helpers declares the functions the validate methods use
+func (g *goGen) helpers() {
+	g.printf(`// at returns the path of a field of the value at path
+func at(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// fail returns the error of a failed constraint of the value at path
+func fail(path, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if path == "" {
+		return errors.New(msg)
+	}
+	return fmt.Errorf("%%s: %%s", path, msg)
+}
+`)
+}
+
+// orList joins items as a list ending in "or"
+func orList(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
@@ -0,0 +1,116 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TypeScriptOptions configures TypeScript
+type TypeScriptOptions struct {
+	// Source names the schema in the header of the declarations
+	Source string
+}
+
+// TypeScript returns TypeScript declarations, as in a .d.ts file, of the
+// types of m. Optional properties are marked with ?, enums are unions of
+// string literals and unions are unions of their alternatives.
+func TypeScript(m *Model, opts TypeScriptOptions) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by nld gen ts from %s; DO NOT EDIT.\n", opts.Source)
+	for _, t := range m.Types {
+		b.WriteString("\n")
+		tsComment(&b, "", t.Description, "Generated from "+t.Pointer+" in the schema.")
+		switch {
+		case t.Kind == Struct:
+			fmt.Fprintf(&b, "export interface %s {\n", t.Name)
+			for _, f := range t.Fields {
+				tsComment(&b, "  ", f.Description, "")
+				optional := "?"
+				if f.Required {
+					optional = ""
+				}
+				fmt.Fprintf(&b, "  %s%s: %s;\n", tsProperty(f.Name), optional, tsType(f.Type))
+			}
+			b.WriteString("}\n")
+		case t.Kind == Union:
+			var alts []string
+			for _, alt := range t.Alternatives {
+				alts = append(alts, tsType(alt))
+			}
+			fmt.Fprintf(&b, "export type %s = %s;\n", t.Name, strings.Join(alts, " | "))
+		case t.Enum != nil:
+			var values []string
+			for _, v := range t.Enum {
+				values = append(values, strconv.Quote(v))
+			}
+			fmt.Fprintf(&b, "export type %s = %s;\n", t.Name, strings.Join(values, " | "))
+		}
+	}
+	return []byte(b.String())
+}
+
+// tsType returns the TypeScript type of t
+func tsType(t *Type) string {
+	switch t.Kind {
+	case String:
+		if t.Enum != nil {
+			return t.Name
+		}
+		return "string"
+	case Integer, Number:
+		return "number"
+	case Boolean:
+		return "boolean"
+	case Array:
+		return tsType(t.Elem) + "[]"
+	case Map:
+		return "Record<string, " + tsType(t.Elem) + ">"
+	case Struct, Union:
+		return t.Name
+	}
+	return "unknown"
+}
+
+// tsIdentifier matches property names that need no quotes
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsProperty returns a property name, quoted if needed
+func tsProperty(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// tsComment writes a JSDoc comment of a description and a note, if any
+func tsComment(b *strings.Builder, indent, description, note string) {
+	var lines []string
+	if description = strings.TrimSpace(description); description != "" {
+		lines = append(lines, strings.Split(description, "\n")...)
+	}
+	if note != "" {
+		if lines != nil {
+			lines = append(lines, "")
+		}
+		lines = append(lines, note)
+	}
+	switch len(lines) {
+	case 0:
+		return
+	case 1:
+		fmt.Fprintf(b, "%s/** %s */\n", indent, strings.ReplaceAll(lines[0], "*/", "*\\/"))
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		line = strings.ReplaceAll(strings.TrimSpace(line), "*/", "*\\/")
+		if line == "" {
+			fmt.Fprintf(b, "%s *\n", indent)
+		} else {
+			fmt.Fprintf(b, "%s * %s\n", indent, line)
+		}
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
@@ -0,0 +1,152 @@
+// Package colors decides whether output is colored and with which colors:
+// in color on terminals unless NO_COLOR is set, as --color asks, and with
+// the theme of NLD_COLORS
+package colors
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// Modes of --color
+const (
+	// Auto colors output written to a terminal, unless NO_COLOR is set or
+	// TERM is dumb
+	Auto   = "auto"
+	Always = "always"
+	Never  = "never"
+)
+
+const (
+	// NoColorEnv turns colors off in Auto mode when set to anything but
+	// the empty string, as https://no-color.org asks
+	NoColorEnv = "NO_COLOR"
+	// ThemeEnv sets the colors of the theme, as
+	// "success=green,error=red,warning=yellow"
+	ThemeEnv = "NLD_COLORS"
+)
+
+// Modes returns the modes of --color
+func Modes() []string {
+	return []string{Auto, Always, Never}
+}
+
+// Enabled reports whether output written to out is colored in a mode
+func Enabled(mode string, out *os.File) (bool, error) {
+	switch mode {
+	case Always:
+		return true, nil
+	case Never:
+		return false, nil
+	case Auto, "":
+		if os.Getenv(NoColorEnv) != "" || os.Getenv("TERM") == "dumb" || out == nil {
+			return false, nil
+		}
+		return isatty.IsTerminal(out.Fd()) || isatty.IsCygwinTerminal(out.Fd()), nil
+	}
+	return false, fmt.Errorf("unsupported color mode: %s (supported: %s)", mode, strings.Join(Modes(), ", "))
+}
+
+// Theme is the colors of the kinds of messages
+type Theme struct {
+	Success color.Attribute
+	Error   color.Attribute
+	Warning color.Attribute
+}
+
+// DefaultTheme returns the theme used when NLD_COLORS is not set
+func DefaultTheme() Theme {
+	return Theme{Success: color.FgGreen, Error: color.FgRed, Warning: color.FgYellow}
+}
+
+// names are the colors a theme can use
+var names = map[string]color.Attribute{
+	"black":          color.FgBlack,
+	"red":            color.FgRed,
+	"green":          color.FgGreen,
+	"yellow":         color.FgYellow,
+	"blue":           color.FgBlue,
+	"magenta":        color.FgMagenta,
+	"cyan":           color.FgCyan,
+	"white":          color.FgWhite,
+	"bright-black":   color.FgHiBlack,
+	"bright-red":     color.FgHiRed,
+	"bright-green":   color.FgHiGreen,
+	"bright-yellow":  color.FgHiYellow,
+	"bright-blue":    color.FgHiBlue,
+	"bright-magenta": color.FgHiMagenta,
+	"bright-cyan":    color.FgHiCyan,
+	"bright-white":   color.FgHiWhite,
+}
+
+// Names returns the names of the colors a theme can use
+func Names() []string {
+	list := make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// ParseTheme returns the default theme with the colors of a spec such as
+// "success=green,error=bright-red" changed. An empty spec is the default
+// theme.
+func ParseTheme(spec string) (Theme, error) {
+	theme := DefaultTheme()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, name, ok := strings.Cut(entry, "=")
+		if !ok {
+			return Theme{}, fmt.Errorf("invalid color %q: expected kind=color", entry)
+		}
+		attr, ok := names[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return Theme{}, fmt.Errorf("unknown color %q (supported: %s)", strings.TrimSpace(name), strings.Join(Names(), ", "))
+		}
+		switch strings.ToLower(strings.TrimSpace(kind)) {
+		case "success":
+			theme.Success = attr
+		case "error":
+			theme.Error = attr
+		case "warning":
+			theme.Warning = attr
+		default:
+			return Theme{}, fmt.Errorf("unknown message kind %q (supported: success, error, warning)", strings.TrimSpace(kind))
+		}
+	}
+	return theme, nil
+}
+
+// current is the theme of Success, Error and Warning
+var current = DefaultTheme()
+
+// Configure turns colors on or off for the whole process and sets the
+// theme
+func Configure(enabled bool, theme Theme) {
+	color.NoColor = !enabled
+	current = theme
+}
+
+// Success colors a message that something went well
+func Success(message string) string {
+	return color.New(current.Success).Sprint(message)
+}
+
+// Error colors a message that something failed
+func Error(message string) string {
+	return color.New(current.Error).Sprint(message)
+}
+
+// Warning colors a warning
+func Warning(message string) string {
+	return color.New(current.Warning).Sprint(message)
+}
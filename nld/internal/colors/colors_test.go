@@ -0,0 +1,77 @@
+package colors
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestEnabled(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	t.Setenv(NoColorEnv, "")
+	t.Setenv("TERM", "xterm")
+
+	cases := []struct {
+		mode string
+		want bool
+	}{
+		{Always, true},
+		{Never, false},
+		// A file is not a terminal
+		{Auto, false},
+		{"", false},
+	}
+	for _, c := range cases {
+		got, err := Enabled(c.mode, f)
+		if err != nil || got != c.want {
+			t.Errorf("Enabled(%q) = %v, %v; want %v", c.mode, got, err, c.want)
+		}
+	}
+	if _, err := Enabled("sometimes", f); err == nil {
+		t.Error("expected an error for an unsupported mode")
+	}
+
+	t.Setenv(NoColorEnv, "1")
+	if got, _ := Enabled(Always, f); !got {
+		t.Error("NO_COLOR must not override --color=always")
+	}
+}
+
+func TestParseTheme(t *testing.T) {
+	theme, err := ParseTheme("")
+	if err != nil || theme != DefaultTheme() {
+		t.Errorf("empty spec = %+v, %v; want the default theme", theme, err)
+	}
+	theme, err = ParseTheme("success=cyan, Error=bright-red")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Theme{Success: color.FgCyan, Error: color.FgHiRed, Warning: color.FgYellow}
+	if theme != want {
+		t.Errorf("theme = %+v, want %+v", theme, want)
+	}
+	for _, spec := range []string{"success", "success=teal", "info=red"} {
+		if _, err := ParseTheme(spec); err == nil {
+			t.Errorf("ParseTheme(%q): expected an error", spec)
+		}
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer Configure(!color.NoColor, current)
+
+	Configure(false, DefaultTheme())
+	if got := Error("failed"); got != "failed" {
+		t.Errorf("colors off: Error = %q", got)
+	}
+	Configure(true, Theme{Success: color.FgBlue, Error: color.FgRed, Warning: color.FgYellow})
+	if got := Success("ok"); !strings.HasPrefix(got, "\x1b[34m") || !strings.Contains(got, "ok") {
+		t.Errorf("colors on: Success = %q, want blue", got)
+	}
+}
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/i18n"
+	"github.com/colemalphrus/nld/internal/importer"
+	"github.com/colemalphrus/nld/internal/render"
+	"github.com/colemalphrus/nld/internal/tracing"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// APIVersion is the version of the JSON calling convention of ValidateJSON,
+// RenderJSON and ConvertJSON. It changes only when a change would break
+// existing callers.
+const APIVersion = 1
+
+// Problem is a validation error or warning in a JSON response
+type Problem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// ValidateRequest is the request of ValidateJSON
+type ValidateRequest struct {
+	Document json.RawMessage `json:"document"`
+}
+
+// ValidateResponse is the response of ValidateJSON
+type ValidateResponse struct {
+	Valid    bool      `json:"valid"`
+	Errors   []Problem `json:"errors"`
+	Warnings []Problem `json:"warnings"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// RenderRequest is the request of RenderJSON. Format defaults to html.
+type RenderRequest struct {
+	Document   json.RawMessage `json:"document"`
+	Format     string          `json:"format,omitempty"`
+	Lang       string          `json:"lang,omitempty"`
+	Locales    []string        `json:"locales,omitempty"`
+	Unnumbered bool            `json:"unnumbered,omitempty"`
+}
+
+// RenderResponse is the response of RenderJSON
+type RenderResponse struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ConvertRequest is the request of ConvertJSON. The source is given as
+// Text for text formats such as markdown and html, or base64-encoded as
+// Data for binary formats such as docx and pdf.
+type ConvertRequest struct {
+	// Format is an import format; when empty it is found from Filename
+	Format   string    `json:"format,omitempty"`
+	Filename string    `json:"filename,omitempty"`
+	Text     string    `json:"text,omitempty"`
+	Data     []byte    `json:"data,omitempty"`
+	Type     string    `json:"type,omitempty"`
+	Title    string    `json:"title,omitempty"`
+	Currency string    `json:"currency,omitempty"`
+	Taxes    []nld.Tax `json:"taxes,omitempty"`
+}
+
+// ConvertResponse is the response of ConvertJSON
+type ConvertResponse struct {
+	Document json.RawMessage    `json:"document,omitempty"`
+	Warnings []importer.Warning `json:"warnings,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// SignRequest is the request of HandleSign
+type SignRequest struct {
+	Document json.RawMessage `json:"document"`
+}
+
+// SignResponse is the response of HandleSign: the document with its digest
+// record, and the root hash of the record
+type SignResponse struct {
+	Document json.RawMessage `json:"document"`
+	Root     string          `json:"root"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// ErrInvalidRequest is wrapped by the errors of requests that cannot be
+// processed as given, rather than documents that fail
+var ErrInvalidRequest = errors.New("invalid request")
+
+// HandleValidate validates the document of a request
+func HandleValidate(ctx context.Context, req ValidateRequest) (*ValidateResponse, error) {
+	if len(req.Document) == 0 {
+		return nil, fmt.Errorf("%w: no document", ErrInvalidRequest)
+	}
+	result, err := ValidateContext(ctx, req.Document)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ValidateResponse{Valid: result.Valid, Errors: []Problem{}, Warnings: []Problem{}}
+	for _, e := range result.Errors {
+		resp.Errors = append(resp.Errors, Problem{Field: e.Field, Message: e.Message, Line: e.Line, Column: e.Column})
+	}
+	for _, w := range result.Warnings {
+		resp.Warnings = append(resp.Warnings, Problem{Field: w.Field, Message: w.Message})
+	}
+	return resp, nil
+}
+
+// HandleRender renders the document of a request
+func HandleRender(ctx context.Context, req RenderRequest) (*RenderResponse, error) {
+	if len(req.Document) == 0 {
+		return nil, fmt.Errorf("%w: no document", ErrInvalidRequest)
+	}
+	opts := render.Options{Format: req.Format, Locales: req.Locales, Unnumbered: req.Unnumbered}
+	if opts.Format == "" {
+		opts.Format = render.HTML
+	}
+	if req.Lang != "" {
+		tr, err := i18n.Load(req.Lang)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+		}
+		opts.Lang = tr
+	}
+	out, err := RenderContext(ctx, req.Document, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &RenderResponse{Output: string(out)}, nil
+}
+
+// HandleConvert imports the source of a request into an NLD document
+func HandleConvert(ctx context.Context, req ConvertRequest) (*ConvertResponse, error) {
+	format := req.Format
+	if format == "" {
+		format = importer.FormatOf(req.Filename)
+	}
+	if format == "" {
+		return nil, fmt.Errorf("%w: no format or filename with a known extension", ErrInvalidRequest)
+	}
+	data := req.Data
+	if data == nil {
+		data = []byte(req.Text)
+	}
+
+	_, span := tracing.Span(ctx, "nld.convert", attribute.String("nld.convert.format", format))
+	result, err := importer.Import(format, data, importer.Options{
+		Type:     req.Type,
+		Title:    req.Title,
+		Currency: req.Currency,
+		Taxes:    req.Taxes,
+	})
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := result.Document.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &ConvertResponse{Document: doc, Warnings: result.Warnings}, nil
+}
+
+// HandleSign records the digest of the document of a request in
+// verification.digest, as nld hash --write does, so that later changes can
+// be found with nld verify
+func HandleSign(ctx context.Context, req SignRequest) (*SignResponse, error) {
+	if len(req.Document) == 0 {
+		return nil, fmt.Errorf("%w: no document", ErrInvalidRequest)
+	}
+	doc, err := document.Parse(req.Document)
+	if err != nil {
+		return nil, err
+	}
+	_, span := tracing.Span(ctx, "nld.sign")
+	rec, err := digest.Compute(doc)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash document: %w", err)
+	}
+	if err := digest.Store(doc, rec); err != nil {
+		return nil, err
+	}
+	out, err := doc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &SignResponse{Document: out, Root: rec.Root}, nil
+}
+
+// ValidateJSON validates the document of a ValidateRequest and returns a
+// ValidateResponse. Failures are reported in the error field of the
+// response, so the result is always a JSON object.
+func ValidateJSON(request []byte) []byte {
+	var req ValidateRequest
+	return handleJSON(request, &req, func() (interface{}, error) { return HandleValidate(context.Background(), req) })
+}
+
+// RenderJSON renders the document of a RenderRequest and returns a
+// RenderResponse
+func RenderJSON(request []byte) []byte {
+	var req RenderRequest
+	return handleJSON(request, &req, func() (interface{}, error) { return HandleRender(context.Background(), req) })
+}
+
+// ConvertJSON imports the source of a ConvertRequest into an NLD document
+// and returns a ConvertResponse
+func ConvertJSON(request []byte) []byte {
+	var req ConvertRequest
+	return handleJSON(request, &req, func() (interface{}, error) { return HandleConvert(context.Background(), req) })
+}
+
+// handleJSON decodes a request into req and encodes the response of handle
+func handleJSON(request []byte, req interface{}, handle func() (interface{}, error)) []byte {
+	var resp interface{}
+	if err := json.Unmarshal(request, req); err != nil {
+		resp = map[string]string{"error": fmt.Sprintf("%v: %v", ErrInvalidRequest, err)}
+	} else if r, err := handle(); err != nil {
+		resp = map[string]string{"error": err.Error()}
+	} else {
+		resp = r
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	return data
+}
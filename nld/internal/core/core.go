@@ -0,0 +1,89 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/receipt"
+	"github.com/colemalphrus/nld/internal/render"
+	"github.com/colemalphrus/nld/internal/schema"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Validate validates a document against the schema built into nld for its
+// type, then checks the arithmetic of receipts. It reads no files, so it
+// runs in the browser and in other hosts without the nld executable.
+func Validate(data []byte) (*validator.ValidationResult, error) {
+	return ValidateContext(context.Background(), data)
+}
+
+// ValidateContext is Validate traced as a child of the span of ctx
+func ValidateContext(ctx context.Context, data []byte) (*validator.ValidationResult, error) {
+	var doc struct {
+		Metadata struct {
+			Type string `json:"type"`
+		} `json:"metadata"`
+	}
+	// Invalid JSON is reported by the validator with its location
+	json.Unmarshal(data, &doc)
+
+	s, err := schema.EmbeddedContext(ctx, doc.Metadata.Type)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.ValidateContext(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	defer locate(data, result)
+	if !result.Valid || !strings.EqualFold(doc.Metadata.Type, "receipt") {
+		return result, nil
+	}
+
+	typed, err := nld.Parse(data)
+	if err != nil {
+		return result, nil
+	}
+	problems, err := receipt.Check(typed, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range problems {
+		result.Errors = append(result.Errors, validator.ValidationError{Field: p.Path, Message: p.Message, Rule: validator.RuleReceipt})
+		result.Valid = false
+	}
+	return result, nil
+}
+
+// Render renders a document in the format of opts
+func Render(data []byte, opts render.Options) ([]byte, error) {
+	return RenderContext(context.Background(), data, opts)
+}
+
+// RenderContext is Render traced as a child of the span of ctx
+func RenderContext(ctx context.Context, data []byte, opts render.Options) ([]byte, error) {
+	doc, err := nld.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := render.RenderContext(ctx, &buf, doc, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// locate sets the line and column of errors that have none from their
+// JSON pointer
+func locate(data []byte, result *validator.ValidationResult) {
+	for i, e := range result.Errors {
+		if e.Line == 0 && e.Field != "" {
+			result.Errors[i].Line, result.Errors[i].Column = document.Locate(data, e.Field)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/render"
+)
+
+func readExample(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "examples", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		valid  bool
+		errors int
+	}{
+		{name: "valid contract", data: readExample(t, "valid-contract.json"), valid: true},
+		{name: "valid nda", data: readExample(t, "nda.json"), valid: true},
+		{name: "missing fields", data: readExample(t, "invalid-missing-fields.json"), errors: 3},
+		{name: "invalid JSON", data: []byte(`{"metadata": `), errors: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Validate(tt.data)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if result.Valid != tt.valid {
+				t.Errorf("Valid = %v, want %v (errors: %+v)", result.Valid, tt.valid, result.Errors)
+			}
+			if !tt.valid && len(result.Errors) != tt.errors {
+				t.Errorf("got %d errors, want %d: %+v", len(result.Errors), tt.errors, result.Errors)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	out, err := Render(readExample(t, "valid-contract.json"), render.Options{Format: render.HTML})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(string(out), "<h1>") {
+		t.Errorf("Render() = %q, want HTML", out)
+	}
+	if _, err := Render([]byte("{"), render.Options{}); err == nil {
+		t.Error("Render() of invalid JSON succeeded")
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	doc := readExample(t, "invalid-missing-fields.json")
+	var resp ValidateResponse
+	if err := json.Unmarshal(ValidateJSON([]byte(`{"document": `+string(doc)+`}`)), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Valid || resp.Error != "" || len(resp.Errors) != 3 {
+		t.Errorf("ValidateJSON() = %+v, want 3 errors", resp)
+	}
+	if resp.Errors[1].Line == 0 {
+		t.Errorf("error %+v has no line", resp.Errors[1])
+	}
+
+	for _, request := range []string{`{`, `{}`} {
+		resp = ValidateResponse{}
+		if err := json.Unmarshal(ValidateJSON([]byte(request)), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(resp.Error, "invalid request") {
+			t.Errorf("ValidateJSON(%s) error = %q", request, resp.Error)
+		}
+	}
+}
+
+func TestConvertJSON(t *testing.T) {
+	request := `{"filename": "terms.md", "type": "agreement", "text": "# Terms\n\n## Payment\n\nFees are due monthly.\n"}`
+	var resp ConvertResponse
+	if err := json.Unmarshal(ConvertJSON([]byte(request)), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("ConvertJSON() error = %s", resp.Error)
+	}
+
+	var validated ValidateResponse
+	if err := json.Unmarshal(ValidateJSON([]byte(`{"document": `+string(resp.Document)+`}`)), &validated); err != nil {
+		t.Fatal(err)
+	}
+	if !validated.Valid {
+		t.Errorf("converted document is invalid: %+v\n%s", validated.Errors, resp.Document)
+	}
+
+	resp = ConvertResponse{}
+	if err := json.Unmarshal(ConvertJSON([]byte(`{"text": "x"}`)), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == "" {
+		t.Error("ConvertJSON() without a format succeeded")
+	}
+}
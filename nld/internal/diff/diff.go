@@ -0,0 +1,283 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+// Kind describes how an element changed
+type Kind string
+
+const (
+	Added    Kind = "added"
+	Removed  Kind = "removed"
+	Modified Kind = "modified"
+	Moved    Kind = "moved"
+	// Unchanged is only reported when comparing against a template
+	Unchanged Kind = "unchanged"
+)
+
+// Change is a single structural change between two documents
+type Change struct {
+	// Area is the part of the document that changed: metadata, section,
+	// entity or definition
+	Area string `json:"area"`
+	// ID identifies the changed element (metadata field, section ID,
+	// entity ID or defined term)
+	ID   string `json:"id"`
+	Kind Kind   `json:"kind"`
+	// Detail lists the fields that changed for modified elements
+	Detail []string `json:"detail,omitempty"`
+}
+
+// String returns a one-line description of the change
+func (c Change) String() string {
+	s := fmt.Sprintf("%s %s %s", c.Area, c.ID, c.Kind)
+	if len(c.Detail) > 0 {
+		s += fmt.Sprintf(" (%s)", strings.Join(c.Detail, ", "))
+	}
+	return s
+}
+
+// Compare returns the structural changes needed to turn old into new.
+// Either document may be nil, which is treated as empty.
+func Compare(old, new document.Document) []Change {
+	if old == nil {
+		old = document.Document{}
+	}
+	if new == nil {
+		new = document.Document{}
+	}
+
+	var changes []Change
+	changes = append(changes, compareMetadata(old.Metadata(), new.Metadata())...)
+	changes = append(changes, compareSections(old, new)...)
+	changes = append(changes, compareKeyed("entity", "id", metaList(old, "entities"), metaList(new, "entities"))...)
+	changes = append(changes, compareKeyed("definition", "term", bodyList(old, "definitions"), bodyList(new, "definitions"))...)
+	return changes
+}
+
+// compareMetadata reports changed scalar metadata fields. Entities are
+// compared separately.
+func compareMetadata(old, new map[string]interface{}) []Change {
+	keys := map[string]bool{}
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+	delete(keys, "entities")
+
+	var changes []Change
+	for _, k := range sortedKeys(keys) {
+		ov, inOld := old[k]
+		nv, inNew := new[k]
+		switch {
+		case !inOld:
+			changes = append(changes, Change{Area: "metadata", ID: k, Kind: Added})
+		case !inNew:
+			changes = append(changes, Change{Area: "metadata", ID: k, Kind: Removed})
+		case !reflect.DeepEqual(ov, nv):
+			changes = append(changes, Change{Area: "metadata", ID: k, Kind: Modified})
+		}
+	}
+	return changes
+}
+
+// compareSections reports added, removed, modified and moved sections,
+// including nested subsections. A section is moved when it was placed under
+// a different parent, or when its position relative to the siblings common
+// to both documents changed. Changes to subsections are reported for the
+// subsections themselves, not for their parents.
+func compareSections(old, new document.Document) []Change {
+	oldRefs := old.AllSections()
+	newRefs := new.AllSections()
+	changes := compareKeyed("section", "id", flattenSections(oldRefs), flattenSections(newRefs))
+
+	oldParent := map[string]string{}
+	for _, ref := range oldRefs {
+		oldParent[document.String(ref.Section, "id")] = ref.Parent
+	}
+	newParent := map[string]string{}
+	for _, ref := range newRefs {
+		newParent[document.String(ref.Section, "id")] = ref.Parent
+	}
+
+	// Sibling order of the sections that kept their parent
+	oldOrder := map[string][]string{}
+	for _, ref := range oldRefs {
+		id := document.String(ref.Section, "id")
+		if p, ok := newParent[id]; ok && p == ref.Parent {
+			oldOrder[ref.Parent] = append(oldOrder[ref.Parent], id)
+		}
+	}
+	newOrder := map[string][]string{}
+	var parents []string
+	for _, ref := range newRefs {
+		id := document.String(ref.Section, "id")
+		p, ok := oldParent[id]
+		if !ok {
+			continue
+		}
+		if p != ref.Parent {
+			changes = append(changes, Change{Area: "section", ID: id, Kind: Moved, Detail: []string{"parent"}})
+			continue
+		}
+		if _, seen := newOrder[ref.Parent]; !seen {
+			parents = append(parents, ref.Parent)
+		}
+		newOrder[ref.Parent] = append(newOrder[ref.Parent], id)
+	}
+
+	// Sections outside the longest common subsequence of their siblings
+	// have moved
+	for _, parent := range parents {
+		keep := lcs(oldOrder[parent], newOrder[parent])
+		for _, id := range newOrder[parent] {
+			if !keep[id] {
+				changes = append(changes, Change{Area: "section", ID: id, Kind: Moved})
+			}
+		}
+	}
+	return changes
+}
+
+// flattenSections returns the sections without their nested subsections so
+// that each section is compared on its own fields
+func flattenSections(refs []document.SectionRef) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(refs))
+	for _, ref := range refs {
+		flat := make(map[string]interface{}, len(ref.Section))
+		for k, v := range ref.Section {
+			if k != "sections" {
+				flat[k] = v
+			}
+		}
+		out = append(out, flat)
+	}
+	return out
+}
+
+// compareKeyed compares two lists of objects identified by key
+func compareKeyed(area, key string, old, new []map[string]interface{}) []Change {
+	oldByID := map[string]map[string]interface{}{}
+	for _, o := range old {
+		oldByID[document.String(o, key)] = o
+	}
+	newByID := map[string]map[string]interface{}{}
+	for _, n := range new {
+		newByID[document.String(n, key)] = n
+	}
+
+	var changes []Change
+	for _, o := range old {
+		id := document.String(o, key)
+		if _, ok := newByID[id]; !ok {
+			changes = append(changes, Change{Area: area, ID: id, Kind: Removed})
+		}
+	}
+	for _, n := range new {
+		id := document.String(n, key)
+		o, ok := oldByID[id]
+		if !ok {
+			changes = append(changes, Change{Area: area, ID: id, Kind: Added})
+			continue
+		}
+		if fields := changedFields(o, n); len(fields) > 0 {
+			changes = append(changes, Change{Area: area, ID: id, Kind: Modified, Detail: fields})
+		}
+	}
+	return changes
+}
+
+// changedFields returns the names of fields that differ between two objects
+func changedFields(old, new map[string]interface{}) []string {
+	keys := map[string]bool{}
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+
+	var fields []string
+	for _, k := range sortedKeys(keys) {
+		if !reflect.DeepEqual(old[k], new[k]) {
+			fields = append(fields, k)
+		}
+	}
+	return fields
+}
+
+// lcs returns the set of IDs in the longest common subsequence of a and b
+func lcs(a, b []string) map[string]bool {
+	n, m := len(a), len(b)
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	keep := map[string]bool{}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[i] == b[j]:
+			keep[a[i]] = true
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return keep
+}
+
+// metaList returns an array of objects stored in metadata
+func metaList(doc document.Document, key string) []map[string]interface{} {
+	return objects(doc.Metadata(), key)
+}
+
+// bodyList returns an array of objects stored in the document body
+func bodyList(doc document.Document, key string) []map[string]interface{} {
+	return objects(doc.Body(false), key)
+}
+
+func objects(parent map[string]interface{}, key string) []map[string]interface{} {
+	if parent == nil {
+		return nil
+	}
+	raw, _ := parent[key].([]interface{})
+	var out []map[string]interface{}
+	for _, r := range raw {
+		if obj, ok := r.(map[string]interface{}); ok {
+			out = append(out, obj)
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
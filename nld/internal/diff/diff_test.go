@@ -0,0 +1,169 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+func parse(t *testing.T, s string) document.Document {
+	t.Helper()
+	doc, err := document.Parse([]byte(s))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	return doc
+}
+
+func TestCompare(t *testing.T) {
+	old := parse(t, `{
+		"metadata": {"title": "A", "author": "Legal", "entities": [{"id": "p1", "name": "Acme", "role": "Seller"}, {"id": "p2", "name": "XYZ", "role": "Buyer"}]},
+		"content": {"sections": [
+			{"id": "a", "title": "A", "content": "one"},
+			{"id": "b", "title": "B", "content": "two"},
+			{"id": "c", "title": "C", "content": "three"},
+			{"id": "d", "title": "D", "content": "four"}
+		]}
+	}`)
+	new := parse(t, `{
+		"metadata": {"title": "B", "jurisdiction": "EU", "entities": [{"id": "p1", "name": "Acme Inc", "role": "Seller"}, {"id": "p3", "name": "New", "role": "Buyer"}]},
+		"content": {"sections": [
+			{"id": "c", "title": "C", "content": "three"},
+			{"id": "a", "title": "A", "content": "one"},
+			{"id": "b", "title": "B2", "content": "two"},
+			{"id": "e", "title": "E", "content": "five"}
+		]}
+	}`)
+
+	expected := map[string]Kind{
+		"metadata title":        Modified,
+		"metadata author":       Removed,
+		"metadata jurisdiction": Added,
+		"section d":             Removed,
+		"section e":             Added,
+		"section b":             Modified,
+		"section c":             Moved,
+		"entity p1":             Modified,
+		"entity p2":             Removed,
+		"entity p3":             Added,
+	}
+
+	changes := Compare(old, new)
+	found := map[string]Kind{}
+	for _, c := range changes {
+		found[c.Area+" "+c.ID] = c.Kind
+	}
+
+	for key, kind := range expected {
+		if found[key] != kind {
+			t.Errorf("Expected %s to be %s, got %q", key, kind, found[key])
+		}
+	}
+	if len(changes) != len(expected) {
+		t.Errorf("Expected %d changes, got %d: %v", len(expected), len(changes), changes)
+	}
+}
+
+func TestCompareWithNil(t *testing.T) {
+	doc := parse(t, `{"metadata": {"title": "A"}, "content": {"sections": [{"id": "a"}]}}`)
+
+	added := Compare(nil, doc)
+	if len(added) != 2 {
+		t.Errorf("Expected 2 additions, got %v", added)
+	}
+	removed := Compare(doc, nil)
+	for _, c := range removed {
+		if c.Kind != Removed {
+			t.Errorf("Expected only removals, got %v", c)
+		}
+	}
+}
+
+func TestCompareNestedSections(t *testing.T) {
+	old := parse(t, `{"content": {"sections": [
+		{"id": "a", "title": "A", "content": "", "sections": [
+			{"id": "a1", "title": "A1", "content": "one"},
+			{"id": "a2", "title": "A2", "content": "two"},
+			{"id": "a3", "title": "A3", "content": "three"},
+			{"id": "a4", "title": "A4", "content": "six"}
+		]},
+		{"id": "b", "title": "B", "content": "", "sections": [
+			{"id": "b1", "title": "B1", "content": "four"}
+		]}
+	]}}`)
+	new := parse(t, `{"content": {"sections": [
+		{"id": "a", "title": "A", "content": "", "sections": [
+			{"id": "a4", "title": "A4", "content": "six"},
+			{"id": "a1", "title": "A1", "content": "one changed"},
+			{"id": "a3", "title": "A3", "content": "three"}
+		]},
+		{"id": "b", "title": "B", "content": "", "sections": [
+			{"id": "b1", "title": "B1", "content": "four"},
+			{"id": "a2", "title": "A2", "content": "two"},
+			{"id": "b2", "title": "B2", "content": "five"}
+		]}
+	]}}`)
+
+	expected := map[string]Kind{
+		"a1": Modified,
+		"a2": Moved,
+		"a4": Moved,
+		"b2": Added,
+	}
+
+	changes := Compare(old, new)
+	found := map[string]Kind{}
+	for _, c := range changes {
+		found[c.ID] = c.Kind
+		if c.ID == "a2" && (len(c.Detail) != 1 || c.Detail[0] != "parent") {
+			t.Errorf("Expected a2 to move to a new parent, got %v", c)
+		}
+	}
+	for id, kind := range expected {
+		if found[id] != kind {
+			t.Errorf("Expected %s to be %s, got %q", id, kind, found[id])
+		}
+	}
+	if len(changes) != len(expected) {
+		t.Errorf("Expected %d changes, got %d: %v", len(expected), len(changes), changes)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	template := parse(t, `{
+		"metadata": {"type": "contract"},
+		"content": {"sections": [
+			{"id": "parties", "title": "Parties", "content": "Between {{ seller }} and {{ buyer }}."},
+			{"id": "payment", "title": "Payment", "content": "Paid within 30 days.", "sections": [
+				{"id": "late", "title": "Late Payment", "content": "Interest of 1% a month."}
+			]},
+			{"id": "liability", "title": "Liability", "content": "Liability is capped."}
+		]}
+	}`)
+	doc := parse(t, `{
+		"metadata": {"type": "contract"},
+		"content": {"sections": [
+			{"id": "parties", "title": "Parties", "content": "Between Acme Inc. and XYZ Ltd."},
+			{"id": "payment", "title": "Payment", "content": "Paid within 60 days.", "sections": [
+				{"id": "late", "title": "Late Payment", "content": "Interest of 1% a month."}
+			]},
+			{"id": "audit", "title": "Audit", "content": "The buyer may audit."}
+		]}
+	}`)
+
+	var got []string
+	for _, c := range Template(template, doc) {
+		got = append(got, c.ID+" "+string(c.Status)+" "+strings.Join(c.Detail, ","))
+	}
+	expected := []string{
+		"parties unchanged ",
+		"payment modified content",
+		"late unchanged ",
+		"liability removed ",
+		"audit added ",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected clauses\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"reflect"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/fill"
+)
+
+// Clause is how a section of a document deviates from the template it
+// was made from
+type Clause struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	// Status is Unchanged, Modified, Removed or Added
+	Status Kind `json:"status"`
+	// Detail lists the fields that differ for modified clauses
+	Detail []string `json:"detail,omitempty"`
+}
+
+// Template compares doc against the template it was made from and returns
+// every clause: the sections of the template in order, followed by the
+// sections only doc has. Placeholders of the template, such as
+// {{ buyer.name }}, match any text, so filling them in is not a deviation.
+// Subsections are clauses of their own.
+func Template(template, doc document.Document) []Clause {
+	docSections := map[string]map[string]interface{}{}
+	for _, s := range flattenSections(doc.AllSections()) {
+		docSections[document.String(s, "id")] = s
+	}
+
+	var clauses []Clause
+	inTemplate := map[string]bool{}
+	for _, t := range flattenSections(template.AllSections()) {
+		id := document.String(t, "id")
+		inTemplate[id] = true
+		s, ok := docSections[id]
+		if !ok {
+			clauses = append(clauses, Clause{ID: id, Title: document.String(t, "title"), Status: Removed})
+			continue
+		}
+		clause := Clause{ID: id, Title: document.String(s, "title"), Status: Unchanged}
+		if fields := deviations(t, s); len(fields) > 0 {
+			clause.Status, clause.Detail = Modified, fields
+		}
+		clauses = append(clauses, clause)
+	}
+	for _, s := range flattenSections(doc.AllSections()) {
+		if id := document.String(s, "id"); !inTemplate[id] {
+			clauses = append(clauses, Clause{ID: id, Title: document.String(s, "title"), Status: Added})
+		}
+	}
+	return clauses
+}
+
+// deviations returns the names of the fields of a section that do not
+// match the template section
+func deviations(template, section map[string]interface{}) []string {
+	keys := map[string]bool{}
+	for k := range template {
+		keys[k] = true
+	}
+	for k := range section {
+		keys[k] = true
+	}
+
+	var fields []string
+	for _, k := range sortedKeys(keys) {
+		if !matches(template[k], section[k]) {
+			fields = append(fields, k)
+		}
+	}
+	return fields
+}
+
+// matches reports whether v is the template value t, with its
+// placeholders filled
+func matches(t, v interface{}) bool {
+	switch tv := t.(type) {
+	case string:
+		s, ok := v.(string)
+		return ok && fill.Match(tv, s)
+	case map[string]interface{}:
+		m, ok := v.(map[string]interface{})
+		return ok && len(deviations(tv, m)) == 0
+	case []interface{}:
+		list, ok := v.([]interface{})
+		if !ok || len(list) != len(tv) {
+			return false
+		}
+		for i := range tv {
+			if !matches(tv[i], list[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(t, v)
+}
@@ -0,0 +1,89 @@
+package digest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const (
+	// Prefix identifies the hash algorithm in encoded digests
+	Prefix = "sha256:"
+)
+
+// SectionHash records the digest of a single section
+type SectionHash struct {
+	ID   string `json:"id"`
+	Hash string `json:"hash"`
+}
+
+// Canonical returns the canonical JSON encoding of v: object keys sorted,
+// no insignificant whitespace and no HTML escaping
+func Canonical(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to canonicalize: %w", err)
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// Sum returns the encoded SHA-256 digest of data
+func Sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return Prefix + hex.EncodeToString(sum[:])
+}
+
+// Hash returns the digest of the canonical encoding of v
+func Hash(v interface{}) (string, error) {
+	data, err := Canonical(v)
+	if err != nil {
+		return "", err
+	}
+	return Sum(data), nil
+}
+
+// Decode returns the raw digest bytes of an encoded hash
+func Decode(hash string) ([]byte, error) {
+	if !strings.HasPrefix(hash, Prefix) {
+		return nil, fmt.Errorf("unsupported hash format: %s", hash)
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(hash, Prefix))
+	if err != nil || len(raw) != sha256.Size {
+		return nil, fmt.Errorf("invalid hash: %s", hash)
+	}
+	return raw, nil
+}
+
+// SectionHashes computes the tree hash of every top-level section in doc
+func SectionHashes(doc document.Document) ([]SectionHash, error) {
+	var hashes []SectionHash
+	raw, _ := doc.Body(false)["sections"].([]interface{})
+	for i, item := range raw {
+		// A section removed by redaction, while its changes are undone
+		if h, ok := item.(hidden); ok {
+			hashes = append(hashes, SectionHash{ID: h.id, Hash: h.hash})
+			continue
+		}
+		section, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("section %d is not an object", i)
+		}
+		id := document.String(section, "id")
+		if id == "" {
+			return nil, fmt.Errorf("section %d has no id", i)
+		}
+		hash, err := TreeHash(section)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash section %s: %w", id, err)
+		}
+		hashes = append(hashes, SectionHash{ID: id, Hash: hash})
+	}
+	return hashes, nil
+}
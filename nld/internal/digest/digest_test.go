@@ -0,0 +1,422 @@
+package digest
+
+import (
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const testDocument = `{
+  "metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Test"},
+  "content": {
+    "sections": [
+      {"id": "parties", "title": "Parties", "content": "Acme and XYZ"},
+      {"id": "fees", "title": "Fees", "content": "USD 10,000"},
+      {"id": "terms", "title": "Terms", "content": "Standard terms"}
+    ]
+  }
+}`
+
+func parse(t *testing.T) document.Document {
+	t.Helper()
+	doc, err := document.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	return doc
+}
+
+func TestCanonical(t *testing.T) {
+	a, _ := Canonical(map[string]interface{}{"b": 1, "a": "<x>"})
+	if string(a) != `{"a":"<x>","b":1}` {
+		t.Errorf("Unexpected canonical form: %s", a)
+	}
+}
+
+func TestComputeDeterministic(t *testing.T) {
+	first, err := Compute(parse(t))
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	second, err := Compute(parse(t))
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if first.Root != second.Root {
+		t.Errorf("Expected deterministic root, got %s and %s", first.Root, second.Root)
+	}
+	if len(first.Sections) != 3 {
+		t.Errorf("Expected 3 section hashes, got %d", len(first.Sections))
+	}
+}
+
+func TestComputeIgnoresStatus(t *testing.T) {
+	doc := parse(t)
+	before, _ := Compute(doc)
+	doc.Metadata()["status"] = "executed"
+	after, err := Compute(doc)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if before.Root != after.Root {
+		t.Errorf("Expected the status to be left out of the root, got %s and %s", before.Root, after.Root)
+	}
+	if doc.Metadata()["status"] != "executed" {
+		t.Error("Compute changed the metadata")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	testCases := []struct {
+		name         string
+		mutate       func(doc document.Document)
+		expectIntact bool
+		expectStatus map[string]string
+	}{
+		{
+			name:         "Unchanged",
+			mutate:       func(doc document.Document) {},
+			expectIntact: true,
+		},
+		{
+			name: "Modified Section",
+			mutate: func(doc document.Document) {
+				doc.Sections()[1]["content"] = "USD 1,000,000"
+			},
+			expectStatus: map[string]string{"fees": StatusModified, "parties": StatusOK},
+		},
+		{
+			name: "Removed Section",
+			mutate: func(doc document.Document) {
+				doc.SetSections(doc.Sections()[:2])
+			},
+			expectStatus: map[string]string{"terms": StatusRemoved},
+		},
+		{
+			name: "Added Section",
+			mutate: func(doc document.Document) {
+				doc.SetSections(append(doc.Sections(), map[string]interface{}{"id": "extra", "title": "Extra", "content": ""}))
+			},
+			expectStatus: map[string]string{"extra": StatusAdded},
+		},
+		{
+			name: "Redacted Section",
+			mutate: func(doc document.Document) {
+				hash, _ := TreeHash(doc.Sections()[0]["content"])
+				doc.Sections()[0]["content"] = Mask
+				doc.Sections()[0]["redacted"] = true
+				doc.Verification(true)["attestations"] = []interface{}{map[string]interface{}{
+					"type": "redaction",
+					"changes": []interface{}{
+						map[string]interface{}{"op": "mask", "pointer": "/content/sections/0/content", "hash": hash},
+						map[string]interface{}{"op": "add", "pointer": "/content/sections/0/redacted"},
+					},
+				}}
+			},
+			expectStatus: map[string]string{"parties": StatusRedacted, "fees": StatusOK},
+		},
+		{
+			name: "Removed Section With Recorded Change",
+			mutate: func(doc document.Document) {
+				hash, _ := TreeHash(doc.Sections()[2])
+				doc.SetSections(doc.Sections()[:2])
+				doc.Verification(true)["attestations"] = []interface{}{map[string]interface{}{
+					"type": "redaction",
+					"changes": []interface{}{
+						map[string]interface{}{"op": "remove", "pointer": "/content/sections/2", "hash": hash, "id": "terms"},
+					},
+				}}
+			},
+			expectStatus: map[string]string{"terms": StatusRedacted},
+		},
+		{
+			name: "Rewritten Section Flagged As Redacted",
+			mutate: func(doc document.Document) {
+				doc.Sections()[0]["content"] = "Acme alone"
+				doc.Sections()[0]["redacted"] = true
+			},
+			expectStatus: map[string]string{"parties": StatusModified},
+		},
+		{
+			name: "Rewritten Section With Recorded Change",
+			mutate: func(doc document.Document) {
+				// The change names the hash of the rewritten content rather
+				// than of the signed content
+				hash, _ := TreeHash("Acme alone")
+				doc.Sections()[0]["content"] = Mask
+				doc.Verification(true)["attestations"] = []interface{}{map[string]interface{}{
+					"type": "redaction",
+					"changes": []interface{}{
+						map[string]interface{}{"op": "mask", "pointer": "/content/sections/0/content", "hash": hash},
+					},
+				}}
+			},
+			expectStatus: map[string]string{"parties": StatusModified},
+		},
+		{
+			name: "Modified Metadata",
+			mutate: func(doc document.Document) {
+				doc.Metadata()["title"] = "Other"
+			},
+			expectStatus: map[string]string{"metadata": StatusModified},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := parse(t)
+			rec, err := Compute(doc)
+			if err != nil {
+				t.Fatalf("Compute failed: %v", err)
+			}
+			if err := Store(doc, rec); err != nil {
+				t.Fatalf("Store failed: %v", err)
+			}
+
+			tc.mutate(doc)
+
+			loaded, err := Load(doc)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			report, err := Verify(doc, loaded)
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if !report.RecordIntact {
+				t.Errorf("Expected record to be intact")
+			}
+			if report.Intact != tc.expectIntact {
+				t.Errorf("Expected intact=%v, got %v", tc.expectIntact, report.Intact)
+			}
+
+			for key, want := range tc.expectStatus {
+				found := false
+				for _, p := range report.Parts {
+					if p.ID == key || (p.ID == "" && p.Part == key) {
+						found = true
+						if p.Status != want {
+							t.Errorf("Expected %s to be %s, got %s", key, want, p.Status)
+						}
+					}
+				}
+				if !found {
+					t.Errorf("No result for %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyTamperedRecord(t *testing.T) {
+	doc := parse(t)
+	rec, err := Compute(doc)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	// Rewriting a section hash without fixing the root must be detected
+	rec.Sections[0].Hash = rec.Sections[1].Hash
+	report, err := Verify(doc, rec)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if report.RecordIntact {
+		t.Errorf("Expected tampered record to be detected")
+	}
+}
+
+const testReceipt = `{
+  "metadata": {"version": "1.0.0", "type": "receipt", "created": "2025-06-27T12:00:00Z", "title": "Receipt"},
+  "content": {
+    "sections": [{"id": "terms", "title": "Terms", "content": "Paid in full"}],
+    "definitions": [{"term": "Goods", "definition": "The items sold"}],
+    "currency": "USD",
+    "items": [{"description": "Widget", "quantity": 2, "unitPrice": "5.00", "amount": "10.00"}],
+    "totals": {"subtotal": "10.00", "total": "10.00"}
+  },
+  "relationships": {"references": []},
+  "revisions": [{"number": 1, "previous": "sha256:00", "date": "2025-06-27T12:00:00Z"}],
+  "extensions": {"x-erp": "4711"}
+}`
+
+func TestComputeCoversParts(t *testing.T) {
+	parse := func(t *testing.T) document.Document {
+		doc, err := document.Parse([]byte(testReceipt))
+		if err != nil {
+			t.Fatalf("Failed to parse document: %v", err)
+		}
+		return doc
+	}
+	body := func(doc document.Document) map[string]interface{} { return doc.Body(false) }
+
+	testCases := []struct {
+		name    string
+		part    string
+		mutate  func(doc document.Document)
+		changes bool
+	}{
+		{"Definitions", "content.definitions", func(doc document.Document) {
+			body(doc)["definitions"].([]interface{})[0].(map[string]interface{})["definition"] = "Anything"
+		}, true},
+		{"Items", "content.items", func(doc document.Document) {
+			body(doc)["items"].([]interface{})[0].(map[string]interface{})["amount"] = "1000.00"
+		}, true},
+		{"Totals", "content.totals", func(doc document.Document) {
+			body(doc)["totals"].(map[string]interface{})["total"] = "1.00"
+		}, true},
+		{"Other Body Property", "content.currency", func(doc document.Document) {
+			body(doc)["currency"] = "EUR"
+		}, true},
+		{"Added Body Property", "content.notes", func(doc document.Document) {
+			body(doc)["notes"] = "Added later"
+		}, true},
+		{"Revisions", "revisions", func(doc document.Document) {
+			doc["revisions"] = append(doc["revisions"].([]interface{}), map[string]interface{}{"number": 2, "previous": "sha256:ff"})
+		}, true},
+		{"Other Top-Level Property", "extensions", func(doc document.Document) {
+			doc["extensions"] = map[string]interface{}{}
+		}, true},
+		{"Verification", "", func(doc document.Document) {
+			doc.Verification(true)["signatures"] = []interface{}{map[string]interface{}{"signerId": "acme"}}
+		}, false},
+		{"Annotations", "", func(doc document.Document) {
+			doc["annotations"] = []interface{}{map[string]interface{}{"id": "a1", "text": "Check"}}
+		}, false},
+		{"Redline", "", func(doc document.Document) {
+			doc["redline"] = []interface{}{map[string]interface{}{"id": "c1", "section": "terms"}}
+		}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := parse(t)
+			before, err := Compute(doc)
+			if err != nil {
+				t.Fatalf("Compute failed: %v", err)
+			}
+			tc.mutate(doc)
+			after, err := Compute(doc)
+			if err != nil {
+				t.Fatalf("Compute failed: %v", err)
+			}
+			if changed := after.Root != before.Root; changed != tc.changes {
+				t.Fatalf("Expected the root to change: %v, got %v", tc.changes, changed)
+			}
+			if !tc.changes {
+				return
+			}
+
+			report, err := Verify(doc, before)
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if report.Intact {
+				t.Error("Expected the change to be detected")
+			}
+			changed := report.Changed()
+			if len(changed) != 1 || changed[0].Part != tc.part {
+				t.Errorf("Expected only %s to change, got %+v", tc.part, changed)
+			}
+		})
+	}
+}
+
+func TestComputeBindsNames(t *testing.T) {
+	doc, err := document.Parse([]byte(testReceipt))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	before, err := Compute(doc)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	// Renaming a part keeps its hash, but not the root
+	body := doc.Body(false)
+	body["definitionz"] = body["definitions"]
+	delete(body, "definitions")
+	after, err := Compute(doc)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+	if after.Root == before.Root {
+		t.Error("Expected renaming a part to change the root")
+	}
+
+	// Nor can a hash move to another kind of part
+	moved := *before
+	moved.Relationships = ""
+	moved.Parts = append([]PartHash{{Part: "relationships", Hash: before.Relationships}}, before.Parts...)
+	root, err := moved.computeRoot()
+	if err != nil {
+		t.Fatalf("computeRoot failed: %v", err)
+	}
+	if root == before.Root {
+		t.Error("Expected moving a hash to another kind of part to change the root")
+	}
+}
+
+func TestVerifyRedactedPart(t *testing.T) {
+	doc, err := document.Parse([]byte(testReceipt))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	rec, err := Compute(doc)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	pointer := "/content/definitions/0/definition"
+	hash, err := OriginalHash(doc, nil, pointer)
+	if err != nil {
+		t.Fatalf("OriginalHash failed: %v", err)
+	}
+	if err := doc.Set(pointer, Mask); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	doc.Verification(true)["attestations"] = []interface{}{map[string]interface{}{
+		"type":    "redaction",
+		"changes": []interface{}{map[string]interface{}{"op": "mask", "pointer": pointer, "hash": hash}},
+	}}
+	report, err := Verify(doc, rec)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	for _, p := range report.Parts {
+		want := StatusOK
+		if p.Part == "content.definitions" {
+			want = StatusRedacted
+		}
+		if p.Status != want {
+			t.Errorf("Expected %s %s to be %s, got %s", p.Part, p.ID, want, p.Status)
+		}
+	}
+}
+
+func TestVerifyUnrecordedRedaction(t *testing.T) {
+	doc := parse(t)
+	doc.Metadata()["entities"] = []interface{}{map[string]interface{}{"id": "acme", "name": "Acme Corp"}}
+	rec, err := Compute(doc)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	// A redaction attestation naming a part does not excuse changes to it
+	// that it does not record
+	doc.Metadata()["entities"].([]interface{})[0].(map[string]interface{})["name"] = "Evil Corp"
+	doc.Verification(true)["attestations"] = []interface{}{map[string]interface{}{
+		"type":    "redaction",
+		"targets": []interface{}{"$.metadata.entities[0].name"},
+		"changes": []interface{}{map[string]interface{}{"op": "mask", "pointer": "/metadata/entities/0/name", "hash": rec.Metadata}},
+	}}
+	report, err := Verify(doc, rec)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	for _, p := range report.Parts {
+		if p.Part == "metadata" && p.Status != StatusModified {
+			t.Errorf("Expected metadata to be modified, got %s", p.Status)
+		}
+	}
+}
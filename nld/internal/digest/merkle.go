@@ -0,0 +1,401 @@
+package digest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const (
+	// MerkleAlgorithm identifies the tree construction used for Record.Root
+	MerkleAlgorithm = "sha256-merkle-v1"
+
+	// RecordKey is the key of the record inside the verification object
+	RecordKey = "digest"
+
+	leafPrefix = 0x00
+	nodePrefix = 0x01
+)
+
+// Part status values reported by Verify
+const (
+	StatusOK       = "ok"
+	StatusModified = "modified"
+	StatusRedacted = "redacted"
+	StatusAdded    = "added"
+	StatusRemoved  = "removed"
+)
+
+// Record holds the per-part hashes of a document and the Merkle root they
+// roll up into. Leaves are, in order: metadata without its status, every
+// section, relationships when present, and then every other part of the
+// document by name. Each leaf hashes the kind and name of its part with
+// the part hash, so that a part cannot be renamed or moved to another kind
+// without changing the root.
+// Part hashes are tree hashes, so that redacted parts can be checked with
+// the hashes of the values redaction replaced.
+type Record struct {
+	Algorithm     string        `json:"algorithm"`
+	Root          string        `json:"root"`
+	Metadata      string        `json:"metadata"`
+	Sections      []SectionHash `json:"sections"`
+	Relationships string        `json:"relationships,omitempty"`
+	// Parts are the other properties of the body, such as
+	// content.definitions, content.items and content.totals, and the other
+	// top-level properties, such as revisions
+	Parts []PartHash `json:"parts,omitempty"`
+}
+
+// PartHash records the digest of a part of a document other than its
+// metadata, sections and relationships
+type PartHash struct {
+	Part string `json:"part"`
+	Hash string `json:"hash"`
+}
+
+// unsigned are the top-level properties left out of the root hash: the
+// verification data, which holds the signatures of the root, and the
+// annotations and redline, which comment on and propose changes to the
+// signed text without changing it
+var unsigned = map[string]bool{"verification": true, "annotations": true, "redline": true}
+
+// PartResult is the verification status of one part of a document
+type PartResult struct {
+	Part   string `json:"part"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+}
+
+// Report is the outcome of verifying a document against its record
+type Report struct {
+	// RecordIntact is false when the recorded hashes do not produce the
+	// recorded root, meaning the record itself was altered
+	RecordIntact bool `json:"recordIntact"`
+	// Intact is true when the document matches the record exactly
+	Intact bool         `json:"intact"`
+	Root   string       `json:"root"`
+	Parts  []PartResult `json:"parts"`
+}
+
+// Changed returns the parts that are not StatusOK
+func (r *Report) Changed() []PartResult {
+	var changed []PartResult
+	for _, p := range r.Parts {
+		if p.Status != StatusOK {
+			changed = append(changed, p)
+		}
+	}
+	return changed
+}
+
+// Compute builds a record for the current state of doc
+func Compute(doc document.Document) (*Record, error) {
+	meta := doc.Metadata()
+	if meta == nil {
+		return nil, fmt.Errorf("document has no metadata")
+	}
+
+	// The lifecycle status moves on after a document is signed, so it is
+	// not part of what is signed
+	if _, ok := meta["status"]; ok {
+		unsigned := make(map[string]interface{}, len(meta))
+		for k, v := range meta {
+			if k != "status" {
+				unsigned[k] = v
+			}
+		}
+		meta = unsigned
+	}
+	metaHash, err := TreeHash(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err := SectionHashes(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &Record{
+		Algorithm: MerkleAlgorithm,
+		Metadata:  metaHash,
+		Sections:  sections,
+	}
+
+	if rel, ok := doc["relationships"]; ok {
+		if rec.Relationships, err = TreeHash(rel); err != nil {
+			return nil, err
+		}
+	}
+
+	if rec.Parts, err = partHashes(doc); err != nil {
+		return nil, err
+	}
+
+	if rec.Root, err = rec.computeRoot(); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// partHashes computes the digest of every part of doc that is not its
+// metadata, a section, its relationships or unsigned, sorted by name
+func partHashes(doc document.Document) ([]PartHash, error) {
+	parts := map[string]interface{}{}
+	bodyKey := doc.BodyKey()
+	for key, value := range doc {
+		switch {
+		case key == "metadata" || key == "relationships" || unsigned[key]:
+		case key == bodyKey:
+			body, ok := value.(map[string]interface{})
+			if !ok {
+				parts[key] = value
+				continue
+			}
+			for k, v := range body {
+				if k != "sections" {
+					parts[key+"."+k] = v
+				}
+			}
+		default:
+			parts[key] = value
+		}
+	}
+
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var hashes []PartHash
+	for _, name := range names {
+		hash, err := TreeHash(parts[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", name, err)
+		}
+		hashes = append(hashes, PartHash{Part: name, Hash: hash})
+	}
+	return hashes, nil
+}
+
+// Kinds of leaves
+const (
+	leafMetadata      = "metadata"
+	leafSection       = "section"
+	leafRelationships = "relationships"
+	leafPart          = "part"
+)
+
+// leaf is a part of a document as it enters the tree
+type leaf struct {
+	kind, name, hash string
+}
+
+// leaves returns the leaves of the record in tree order
+func (r *Record) leaves() []leaf {
+	leaves := []leaf{{leafMetadata, "metadata", r.Metadata}}
+	for _, s := range r.Sections {
+		leaves = append(leaves, leaf{leafSection, s.ID, s.Hash})
+	}
+	if r.Relationships != "" {
+		leaves = append(leaves, leaf{leafRelationships, "relationships", r.Relationships})
+	}
+	for _, p := range r.Parts {
+		leaves = append(leaves, leaf{leafPart, p.Part, p.Hash})
+	}
+	return leaves
+}
+
+// computeRoot rolls the record leaves up into a Merkle root. Each leaf is
+// the hash of the canonical encoding of its kind, name and part hash.
+// Leaves and interior nodes are domain separated; an odd node is promoted
+// unchanged.
+func (r *Record) computeRoot() (string, error) {
+	var level [][]byte
+	for _, l := range r.leaves() {
+		if _, err := Decode(l.hash); err != nil {
+			return "", err
+		}
+		data, err := Canonical([]string{l.kind, l.name, l.hash})
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256(append([]byte{leafPrefix}, data...))
+		level = append(level, sum[:])
+	}
+
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			buf := append([]byte{nodePrefix}, level[i]...)
+			buf = append(buf, level[i+1]...)
+			sum := sha256.Sum256(buf)
+			next = append(next, sum[:])
+		}
+		level = next
+	}
+
+	return Prefix + hex.EncodeToString(level[0]), nil
+}
+
+// Load reads the record stored in doc's verification object
+func Load(doc document.Document) (*Record, error) {
+	verification := doc.Verification(false)
+	if verification == nil {
+		return nil, nil
+	}
+	raw, ok := verification[RecordKey]
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest record: %w", err)
+	}
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("invalid digest record: %w", err)
+	}
+	if rec.Algorithm != MerkleAlgorithm {
+		return nil, fmt.Errorf("unsupported digest algorithm: %s", rec.Algorithm)
+	}
+	return &rec, nil
+}
+
+// Store writes rec into doc's verification object
+func Store(doc document.Document, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode digest record: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to encode digest record: %w", err)
+	}
+	doc.Verification(true)[RecordKey] = raw
+	return nil
+}
+
+// Verify compares doc against rec part by part. A part that changed is
+// reported as redacted only when undoing the changes recorded by redaction
+// attestations restores its recorded hash, so that masks and removals are
+// checked against the values that were signed and cannot cover other
+// changes.
+func Verify(doc document.Document, rec *Record) (*Report, error) {
+	report := &Report{Root: rec.Root}
+
+	root, err := rec.computeRoot()
+	if err != nil {
+		return nil, err
+	}
+	report.RecordIntact = root == rec.Root
+
+	current, err := Compute(doc)
+	if err != nil {
+		return nil, err
+	}
+	report.Intact = report.RecordIntact && current.Root == rec.Root
+
+	restored := current
+	if changes := Changes(doc); len(changes) > 0 {
+		original, err := Restore(doc, changes)
+		if err != nil {
+			return nil, err
+		}
+		if restored, err = Compute(original); err != nil {
+			return nil, fmt.Errorf("failed to undo redactions: %w", err)
+		}
+	}
+
+	partStatus := func(recorded, actual, original string) string {
+		switch {
+		case recorded == actual:
+			return StatusOK
+		case recorded == "":
+			return StatusAdded
+		case recorded == original:
+			return StatusRedacted
+		case actual == "":
+			return StatusRemoved
+		default:
+			return StatusModified
+		}
+	}
+
+	report.Parts = append(report.Parts, PartResult{
+		Part:   "metadata",
+		Status: partStatus(rec.Metadata, current.Metadata, restored.Metadata),
+	})
+
+	actual := sectionMap(current.Sections)
+	original := sectionMap(restored.Sections)
+	recorded := sectionMap(rec.Sections)
+	for _, s := range rec.Sections {
+		report.Parts = append(report.Parts, PartResult{
+			Part:   "section",
+			ID:     s.ID,
+			Status: partStatus(s.Hash, actual[s.ID], original[s.ID]),
+		})
+	}
+	for _, s := range current.Sections {
+		if _, ok := recorded[s.ID]; !ok {
+			report.Parts = append(report.Parts, PartResult{Part: "section", ID: s.ID, Status: StatusAdded})
+		}
+	}
+
+	if rec.Relationships != "" || current.Relationships != "" {
+		report.Parts = append(report.Parts, PartResult{
+			Part:   "relationships",
+			Status: partStatus(rec.Relationships, current.Relationships, restored.Relationships),
+		})
+	}
+
+	recordedParts := partMap(rec.Parts)
+	actualParts := partMap(current.Parts)
+	originalParts := partMap(restored.Parts)
+	var names []string
+	for _, p := range rec.Parts {
+		names = append(names, p.Part)
+	}
+	for _, p := range current.Parts {
+		if _, ok := recordedParts[p.Part]; !ok {
+			names = append(names, p.Part)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.Parts = append(report.Parts, PartResult{
+			Part:   name,
+			Status: partStatus(recordedParts[name], actualParts[name], originalParts[name]),
+		})
+	}
+
+	return report, nil
+}
+
+// sectionMap returns section hashes by section ID
+func sectionMap(hashes []SectionHash) map[string]string {
+	m := make(map[string]string, len(hashes))
+	for _, h := range hashes {
+		m[h.ID] = h.Hash
+	}
+	return m
+}
+
+// partMap returns part hashes by part name
+func partMap(hashes []PartHash) map[string]string {
+	m := make(map[string]string, len(hashes))
+	for _, h := range hashes {
+		m[h.Part] = h.Hash
+	}
+	return m
+}
@@ -0,0 +1,356 @@
+package digest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const (
+	// Mask is the placeholder that redaction writes in place of masked
+	// values, and the only one verification accepts
+	Mask = "[REDACTED]"
+
+	// RedactionType marks the attestations that record redactions
+	RedactionType = "redaction"
+
+	// RedactedKey flags the sections changed by a redaction
+	RedactedKey = "redacted"
+
+	objectPrefix = 0x02
+	arrayPrefix  = 0x03
+	valuePrefix  = 0x04
+)
+
+// Operations of the changes made by redaction
+const (
+	// ChangeMask replaced a value with Mask
+	ChangeMask = "mask"
+	// ChangeRemove removed a value
+	ChangeRemove = "remove"
+	// ChangeAdd added Mask as the content of a section that had none, or
+	// the redacted flag of a section
+	ChangeAdd = "add"
+)
+
+// Change is a change made to a document by redaction. Redaction attestations
+// list them in the order they were made, each with the JSON pointer of the
+// value as it was just before the change, and the hash of the value it
+// masked or removed. The hashes let verification undo the changes and check
+// that the document was signed with the values they replace, so that they
+// cannot excuse any other change.
+type Change struct {
+	Op      string `json:"op"`
+	Pointer string `json:"pointer"`
+	Hash    string `json:"hash,omitempty"`
+	// ID is the ID of a removed section
+	ID string `json:"id,omitempty"`
+}
+
+// hidden stands for a value masked or removed by redaction while its
+// changes are undone. It hashes to the hash of the value.
+type hidden struct {
+	hash string
+	id   string
+}
+
+// probe marks the value whose hash OriginalHash computes, so that it can be
+// found again once changes around or inside it are undone
+type probe struct {
+	value interface{}
+}
+
+// TreeHash returns the digest of v computed over its structure: an object
+// hashes its keys with the tree hashes of their values, an array the tree
+// hashes of its items and any other value its canonical encoding. A value
+// can then be checked with the hash of any value inside it in its place,
+// which is how redacted documents are verified.
+func TreeHash(v interface{}) (string, error) {
+	sum, err := treeHash(v)
+	if err != nil {
+		return "", err
+	}
+	return Prefix + hex.EncodeToString(sum), nil
+}
+
+func treeHash(v interface{}) ([]byte, error) {
+	var buf []byte
+	switch n := v.(type) {
+	case *probe:
+		return treeHash(n.value)
+	case hidden:
+		return Decode(n.hash)
+	case document.Document:
+		return treeHash(map[string]interface{}(n))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf = []byte{objectPrefix}
+		for _, k := range keys {
+			key, err := Canonical(k)
+			if err != nil {
+				return nil, err
+			}
+			child, err := treeHash(n[k])
+			if err != nil {
+				return nil, err
+			}
+			buf = append(append(buf, key...), child...)
+		}
+	case []interface{}:
+		buf = []byte{arrayPrefix}
+		for _, item := range n {
+			child, err := treeHash(item)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, child...)
+		}
+	case nil, string, bool, float64, json.Number, int, int64:
+		data, err := Canonical(n)
+		if err != nil {
+			return nil, err
+		}
+		buf = append([]byte{valuePrefix}, data...)
+	default:
+		// Other types, such as typed slices, hash as the JSON they encode to
+		plain, err := plainCopy(n)
+		if err != nil {
+			return nil, err
+		}
+		return treeHash(plain)
+	}
+	sum := sha256.Sum256(buf)
+	return sum[:], nil
+}
+
+// Changes returns the changes recorded by the redaction attestations of
+// doc, in the order they were made
+func Changes(doc document.Document) []Change {
+	verification := doc.Verification(false)
+	if verification == nil {
+		return nil
+	}
+	var changes []Change
+	attestations, _ := verification["attestations"].([]interface{})
+	for _, a := range attestations {
+		obj, ok := a.(map[string]interface{})
+		if !ok || obj["type"] != RedactionType {
+			continue
+		}
+		data, err := json.Marshal(obj["changes"])
+		if err != nil {
+			continue
+		}
+		var list []Change
+		if json.Unmarshal(data, &list) == nil {
+			changes = append(changes, list...)
+		}
+	}
+	return changes
+}
+
+// Restore returns a copy of doc with changes undone, the masked and removed
+// values standing in by their hashes. Changes that do not apply, such as a
+// mask that is no longer Mask, are left alone, so that the parts they
+// touch do not verify.
+func Restore(doc document.Document, changes []Change) (document.Document, error) {
+	plain, err := plainCopy(doc)
+	if err != nil {
+		return nil, err
+	}
+	root, _ := plain.(map[string]interface{})
+	undo(root, changes)
+	return document.Document(root), nil
+}
+
+// OriginalHash returns the tree hash of the value at pointer in doc as it
+// was before changes, which are the changes already made to doc
+func OriginalHash(doc document.Document, changes []Change, pointer string) (string, error) {
+	plain, err := plainCopy(doc)
+	if err != nil {
+		return "", err
+	}
+	root, _ := plain.(map[string]interface{})
+	path, ok := resolve(root, pointer)
+	if !ok || len(path) < 2 {
+		return "", fmt.Errorf("nothing at %s", pointer)
+	}
+	p := &probe{value: path[len(path)-1].value}
+	set(path[len(path)-2].value, path[len(path)-1].token, p)
+	undo(root, changes)
+	return TreeHash(p)
+}
+
+// undo undoes changes in root, the last one first
+func undo(root map[string]interface{}, changes []Change) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		undoChange(root, changes[i])
+	}
+}
+
+// undoChange undoes a change and reports whether it applied
+func undoChange(root map[string]interface{}, c Change) bool {
+	path, ok := resolve(root, parentPointer(c.Pointer))
+	if !ok || c.Pointer == "" {
+		return false
+	}
+	token := lastToken(c.Pointer)
+	switch parent := unwrap(path[len(path)-1].value).(type) {
+	case map[string]interface{}:
+		current, present := parent[token]
+		switch c.Op {
+		case ChangeMask:
+			if !present || unwrap(current) != Mask || c.Hash == "" {
+				return false
+			}
+			set(parent, token, hidden{hash: c.Hash})
+		case ChangeRemove:
+			if present || c.Hash == "" {
+				return false
+			}
+			parent[token] = hidden{hash: c.Hash, id: c.ID}
+		case ChangeAdd:
+			added := unwrap(current)
+			if !present || (added != Mask && !(token == RedactedKey && added == true)) {
+				return false
+			}
+			delete(parent, token)
+		default:
+			return false
+		}
+	case []interface{}:
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || c.Hash == "" {
+			return false
+		}
+		switch c.Op {
+		case ChangeMask:
+			if i >= len(parent) || unwrap(parent[i]) != Mask {
+				return false
+			}
+			set(parent, token, hidden{hash: c.Hash})
+		case ChangeRemove:
+			if i > len(parent) || len(path) < 2 {
+				return false
+			}
+			items := make([]interface{}, 0, len(parent)+1)
+			items = append(append(append(items, parent[:i]...), hidden{hash: c.Hash, id: c.ID}), parent[i:]...)
+			set(unwrap(path[len(path)-2].value), path[len(path)-1].token, items)
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// step is a value on the way to the value of a pointer, with the token
+// that leads to it
+type step struct {
+	token string
+	value interface{}
+}
+
+// resolve returns the values along pointer, from root to the value it
+// points to, and whether that value exists. Values hidden by redaction
+// cannot be entered.
+func resolve(root map[string]interface{}, pointer string) ([]step, bool) {
+	path := []step{{value: root}}
+	if pointer == "" {
+		return path, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+	var node interface{} = root
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch n := unwrap(node).(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return path, false
+			}
+			node = child
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return path, false
+			}
+			node = n[i]
+		default:
+			return path, false
+		}
+		path = append(path, step{token: token, value: node})
+	}
+	return path, true
+}
+
+// set sets the value of token in container, or the value of the probe
+// there
+func set(container interface{}, token string, value interface{}) {
+	switch c := unwrap(container).(type) {
+	case map[string]interface{}:
+		if p, ok := c[token].(*probe); ok {
+			p.value = value
+			return
+		}
+		c[token] = value
+	case []interface{}:
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= len(c) {
+			return
+		}
+		if p, ok := c[i].(*probe); ok {
+			p.value = value
+			return
+		}
+		c[i] = value
+	}
+}
+
+// unwrap returns the value of a probe, or v itself
+func unwrap(v interface{}) interface{} {
+	if p, ok := v.(*probe); ok {
+		return p.value
+	}
+	return v
+}
+
+// parentPointer returns the pointer of the parent of the value of pointer
+func parentPointer(pointer string) string {
+	if i := strings.LastIndex(pointer, "/"); i >= 0 {
+		return pointer[:i]
+	}
+	return ""
+}
+
+// lastToken returns the unescaped last token of pointer
+func lastToken(pointer string) string {
+	token := pointer[strings.LastIndex(pointer, "/")+1:]
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
+
+// plainCopy returns a deep copy of v made of the types JSON decodes to
+func plainCopy(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy document: %w", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to copy document: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,290 @@
+// Package doctor checks the environment nld runs in: its schemas, config
+// files, clause libraries and profiles, store index, key material and
+// clause registries, suggesting a fix for every problem found
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/colors"
+	"github.com/colemalphrus/nld/internal/envelope"
+	"github.com/colemalphrus/nld/internal/i18n"
+	"github.com/colemalphrus/nld/internal/include"
+	"github.com/colemalphrus/nld/internal/index"
+	"github.com/colemalphrus/nld/internal/profile"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/schemas"
+)
+
+// Statuses of a check
+const (
+	OK      = "ok"
+	Warning = "warning"
+	Error   = "error"
+)
+
+// Result is the outcome of one check
+type Result struct {
+	// Check names what was checked, such as "schemas" or "registry"
+	Check   string `json:"check"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// Fix tells how to solve the problem, for warnings and errors
+	Fix string `json:"fix,omitempty"`
+}
+
+// Options are the settings of a run
+type Options struct {
+	// Libraries are clause library locations given on the command line,
+	// checked with those of NLD_CLAUSE_PATH
+	Libraries []string
+	// Keyfile and PassphraseFile are key material to check, besides
+	// NLD_PASSPHRASE
+	Keyfile        string
+	PassphraseFile string
+	// Offline skips the checks that need the network
+	Offline bool
+	// Client fetches from clause registries; nil uses a client with a
+	// short timeout
+	Client *http.Client
+}
+
+// Run runs every check and returns their results in order
+func Run(ctx context.Context, opts Options) []Result {
+	var results []Result
+	for _, check := range []func(context.Context, Options) []Result{
+		checkSchemas,
+		checkConfig,
+		checkLibraries,
+		checkProfiles,
+		checkIndex,
+		checkKeys,
+		checkRegistries,
+	} {
+		results = append(results, check(ctx, opts)...)
+	}
+	return results
+}
+
+// Problems returns the number of results with an error
+func Problems(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == Error {
+			n++
+		}
+	}
+	return n
+}
+
+func ok(check, format string, args ...interface{}) Result {
+	return Result{Check: check, Status: OK, Message: fmt.Sprintf(format, args...)}
+}
+
+func warning(check, fix, format string, args ...interface{}) Result {
+	return Result{Check: check, Status: Warning, Message: fmt.Sprintf(format, args...), Fix: fix}
+}
+
+func failure(check, fix, format string, args ...interface{}) Result {
+	return Result{Check: check, Status: Error, Message: fmt.Sprintf(format, args...), Fix: fix}
+}
+
+// checkSchemas checks that the schema of every document type is found
+// beside the executable and compiles, or else is built in
+func checkSchemas(ctx context.Context, opts Options) []Result {
+	v := validator.New()
+	var results []Result
+	for _, docType := range validator.DocumentTypes() {
+		path, err := v.GetSchemaForDocumentType(docType)
+		if err != nil {
+			results = append(results, failure("schemas", "reinstall nld", "no schema for %s documents: %v", docType, err))
+			continue
+		}
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			name := strings.TrimPrefix(validator.SchemaFile(docType), "schemas/")
+			if _, err := schemas.FS.ReadFile(name); err != nil {
+				results = append(results, failure("schemas", "reinstall nld", "no schema for %s documents at %s, and none built in", docType, path))
+			} else {
+				results = append(results, ok("schemas", "%s documents use the built-in schema %s", docType, name))
+			}
+			continue
+		}
+		if _, err := v.LoadSchema(path); err != nil {
+			results = append(results, failure("schemas", "restore "+path+" from a release of nld, or delete it to use the built-in schema", "schema for %s documents does not compile: %v", docType, err))
+			continue
+		}
+		results = append(results, ok("schemas", "%s documents use %s", docType, path))
+	}
+	return results
+}
+
+// checkConfig checks the store config file and the settings of
+// environment variables
+func checkConfig(ctx context.Context, opts Options) []Result {
+	var results []Result
+	path, err := store.ConfigPath()
+	switch {
+	case err != nil:
+		results = append(results, failure("config", "set "+store.ConfigEnv, "%v", err))
+	default:
+		if _, err := store.LoadConfig(); err != nil {
+			results = append(results, failure("config", "fix the file, or see nld store --help for its format", "%v", err))
+		} else if _, statErr := os.Stat(path); statErr == nil {
+			results = append(results, ok("config", "store config %s is valid", path))
+		} else {
+			results = append(results, ok("config", "no store config at %s", path))
+		}
+	}
+	if lang := os.Getenv(i18n.LangEnv); lang != "" {
+		if _, err := i18n.Load(lang); err != nil {
+			results = append(results, failure("config", "set "+i18n.LangEnv+" to one of "+strings.Join(i18n.Languages(), ", "), "%s: %v", i18n.LangEnv, err))
+		}
+	}
+	if spec := os.Getenv(colors.ThemeEnv); spec != "" {
+		if _, err := colors.ParseTheme(spec); err != nil {
+			results = append(results, failure("config", "set "+colors.ThemeEnv+" as \"success=green,error=red,warning=yellow\"", "%s: %v", colors.ThemeEnv, err))
+		}
+	}
+	return results
+}
+
+// checkLibraries checks that the clause library directories exist and
+// hold valid clauses
+func checkLibraries(ctx context.Context, opts Options) []Result {
+	var results []Result
+	for _, source := range include.Sources(opts.Libraries) {
+		dir, isDir := source.(include.DirSource)
+		if !isDir {
+			continue
+		}
+		info, err := os.Stat(dir.Dir)
+		if err != nil || !info.IsDir() {
+			results = append(results, failure("library", "create the directory, or remove it from --library or "+include.LibraryPathEnv, "clause library %s is not a directory", dir.Dir))
+			continue
+		}
+		clauses, invalid := 0, 0
+		err = filepath.WalkDir(dir.Dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+				return err
+			}
+			clauses++
+			data, err := os.ReadFile(path)
+			if err == nil && !json.Valid(data) {
+				err = errors.New("invalid JSON")
+			}
+			if err != nil {
+				invalid++
+				results = append(results, failure("library", "fix or remove the clause", "clause %s: %v", path, err))
+			}
+			return nil
+		})
+		if err != nil {
+			results = append(results, failure("library", "check the permissions of the directory", "failed to read clause library %s: %v", dir.Dir, err))
+			continue
+		}
+		if invalid == 0 {
+			results = append(results, ok("library", "clause library %s has %d clause(s)", dir.Dir, clauses))
+		}
+	}
+	return results
+}
+
+// checkProfiles checks the directories of NLD_PROFILE_PATH and the
+// profiles in them
+func checkProfiles(ctx context.Context, opts Options) []Result {
+	var results []Result
+	for _, dir := range filepath.SplitList(os.Getenv(profile.PathEnv)) {
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			results = append(results, warning("profiles", "create the directory, or remove it from "+profile.PathEnv, "profile directory %s is not a directory", dir))
+		}
+	}
+	profiles, err := profile.All()
+	if err != nil {
+		return append(results, failure("profiles", "fix or remove the profile file", "%v", err))
+	}
+	return append(results, ok("profiles", "%d profile(s) available", len(profiles)))
+}
+
+// checkIndex checks the integrity of the search index of the local store
+func checkIndex(ctx context.Context, opts Options) []Result {
+	dir, err := store.DefaultDir()
+	if err != nil {
+		return []Result{warning("index", "set "+store.DirEnv, "%v", err)}
+	}
+	path := index.Path(dir)
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return []Result{ok("index", "no search index at %s yet", path)}
+	}
+	switch err := index.Verify(path); {
+	case errors.Is(err, index.ErrStale):
+		return []Result{warning("index", "run nld search to rebuild it", "search index %s has an old format", path)}
+	case err != nil:
+		return []Result{failure("index", "delete "+path+"; nld search rebuilds it from the store", "search index %s: %v", path, err)}
+	}
+	return []Result{ok("index", "search index %s is intact", path)}
+}
+
+// checkKeys checks the key material given, or of NLD_PASSPHRASE
+func checkKeys(ctx context.Context, opts Options) []Result {
+	key, err := envelope.LoadKey(opts.Keyfile, opts.PassphraseFile)
+	switch {
+	case err != nil:
+		return []Result{failure("keys", "check the path and permissions of the file, which must not be empty", "%v", err)}
+	case key == nil:
+		return []Result{ok("keys", "no key configured; encrypted documents need --keyfile, --passphrase-file or %s", envelope.PassphraseEnv)}
+	case opts.Keyfile != "":
+		return []Result{ok("keys", "keyfile %s is readable", opts.Keyfile)}
+	case opts.PassphraseFile != "":
+		return []Result{ok("keys", "passphrase file %s is readable", opts.PassphraseFile)}
+	}
+	return []Result{ok("keys", "passphrase set in %s", envelope.PassphraseEnv)}
+}
+
+// checkRegistries checks that the clause registries answer
+func checkRegistries(ctx context.Context, opts Options) []Result {
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	var results []Result
+	for _, source := range include.Sources(opts.Libraries) {
+		registry, isHTTP := source.(include.HTTPSource)
+		if !isHTTP {
+			continue
+		}
+		if opts.Offline {
+			results = append(results, warning("registry", "run without --offline to check it", "clause registry %s not checked", registry.BaseURL))
+			continue
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, registry.BaseURL, nil)
+		if err != nil {
+			results = append(results, failure("registry", "fix the URL", "clause registry %s: %v", registry.BaseURL, err))
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			results = append(results, failure("registry", "check the URL, the network and any proxy settings", "clause registry %s is unreachable: %v", registry.BaseURL, err))
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			results = append(results, warning("registry", "try again later, or contact the registry operator", "clause registry %s answered %s", registry.BaseURL, resp.Status))
+			continue
+		}
+		results = append(results, ok("registry", "clause registry %s is reachable", registry.BaseURL))
+	}
+	return results
+}
@@ -0,0 +1,84 @@
+package doctor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/include"
+	"github.com/colemalphrus/nld/internal/profile"
+	"github.com/colemalphrus/nld/internal/store"
+)
+
+// find returns the results of a check
+func find(results []Result, check string) []Result {
+	var found []Result
+	for _, r := range results {
+		if r.Check == check {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	library := filepath.Join(dir, "clauses")
+	if err := os.MkdirAll(library, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(library, "good.json"), []byte(`{"id": "good"}`), 0644)
+	os.WriteFile(filepath.Join(library, "bad.json"), []byte(`{"id": `), 0644)
+	config := filepath.Join(dir, "store.yaml")
+	os.WriteFile(config, []byte("remotes:\n  broken:\n    url: ftp://nowhere\n"), 0644)
+	keyfile := filepath.Join(dir, "empty.key")
+	os.WriteFile(keyfile, nil, 0600)
+
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer registry.Close()
+
+	t.Setenv(store.DirEnv, filepath.Join(dir, "store"))
+	t.Setenv(store.ConfigEnv, config)
+	t.Setenv(include.LibraryPathEnv, "")
+	t.Setenv(profile.PathEnv, "")
+	t.Setenv("NLD_LANG", "")
+	t.Setenv("NLD_COLORS", "")
+
+	results := Run(context.Background(), Options{Libraries: []string{library, registry.URL}, Keyfile: keyfile})
+
+	if r := find(results, "schemas"); len(r) == 0 || r[0].Status != OK {
+		t.Errorf("schemas: %+v", r)
+	}
+	if r := find(results, "config"); len(r) != 1 || r[0].Status != Error || r[0].Fix == "" {
+		t.Errorf("config: %+v", r)
+	}
+	if r := find(results, "library"); len(r) != 1 || r[0].Status != Error || !strings.Contains(r[0].Message, "bad.json") {
+		t.Errorf("library: %+v", r)
+	}
+	if r := find(results, "index"); len(r) != 1 || r[0].Status != OK {
+		t.Errorf("index: %+v", r)
+	}
+	if r := find(results, "keys"); len(r) != 1 || r[0].Status != Error {
+		t.Errorf("keys: %+v", r)
+	}
+	if r := find(results, "registry"); len(r) != 1 || r[0].Status != OK {
+		t.Errorf("registry: %+v", r)
+	}
+	if n := Problems(results); n != 3 {
+		t.Errorf("Problems = %d, want 3", n)
+	}
+
+	registry.Close()
+	results = Run(context.Background(), Options{Libraries: []string{registry.URL}})
+	if r := find(results, "registry"); len(r) != 1 || r[0].Status != Error {
+		t.Errorf("unreachable registry: %+v", r)
+	}
+	results = Run(context.Background(), Options{Libraries: []string{registry.URL}, Offline: true})
+	if r := find(results, "registry"); len(r) != 1 || r[0].Status != Warning {
+		t.Errorf("offline: %+v", r)
+	}
+}
@@ -0,0 +1,257 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Document is an NLD document held as generic JSON so that commands which
+// rewrite documents preserve fields they do not know about
+type Document map[string]interface{}
+
+// Parse decodes a document, keeping numbers exact
+func Parse(data []byte) (Document, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc Document
+	if err := dec.Decode(&doc); err != nil {
+		if line, column := ErrorPosition(err, data); line > 0 {
+			return nil, fmt.Errorf("invalid JSON in document at line %d, column %d: %w", line, column, err)
+		}
+		return nil, fmt.Errorf("invalid JSON in document: %w", err)
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("document must be a JSON object")
+	}
+	return doc, nil
+}
+
+// topLevelOrder is the order in which well-known top-level keys are written
+var topLevelOrder = []string{"metadata", "content", "structure", "relationships", "verification"}
+
+// Marshal encodes a document in the indented form written by nld init.
+// Well-known top-level keys are written first, in schema order; all other
+// keys follow alphabetically.
+func (d Document) Marshal() ([]byte, error) {
+	keys := make([]string, 0, len(d))
+	seen := make(map[string]bool, len(d))
+	for _, k := range topLevelOrder {
+		if _, ok := d[k]; ok {
+			keys = append(keys, k)
+			seen[k] = true
+		}
+	}
+	rest := make([]string, 0, len(d))
+	for k := range d {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  ")
+		name, err := encode(k, "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode document: %w", err)
+		}
+		value, err := encode(d[k], "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode document: %w", err)
+		}
+		buf.Write(name)
+		buf.WriteString(": ")
+		buf.Write(value)
+	}
+	if len(keys) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// encode marshals v with two-space indentation starting at prefix
+func encode(v interface{}, prefix string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(prefix, "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// Object returns the child object stored under key, creating it when create
+// is set and it does not exist yet
+func (d Document) Object(key string, create bool) map[string]interface{} {
+	if obj, ok := d[key].(map[string]interface{}); ok {
+		return obj
+	}
+	if !create {
+		return nil
+	}
+	obj := map[string]interface{}{}
+	d[key] = obj
+	return obj
+}
+
+// Metadata returns the metadata object, or nil if missing
+func (d Document) Metadata() map[string]interface{} {
+	return d.Object("metadata", false)
+}
+
+// Type returns metadata.type
+func (d Document) Type() string {
+	if meta := d.Metadata(); meta != nil {
+		if t, ok := meta["type"].(string); ok {
+			return t
+		}
+	}
+	return ""
+}
+
+// BodyKey returns the key holding the document body. Version 1 documents
+// use "content" while typed schemas such as the NDA schema use "structure".
+func (d Document) BodyKey() string {
+	if _, ok := d["structure"].(map[string]interface{}); ok {
+		return "structure"
+	}
+	return "content"
+}
+
+// Body returns the document body object, creating it when create is set
+func (d Document) Body(create bool) map[string]interface{} {
+	return d.Object(d.BodyKey(), create)
+}
+
+// Sections returns the top-level sections as objects
+func (d Document) Sections() []map[string]interface{} {
+	body := d.Body(false)
+	if body == nil {
+		return nil
+	}
+	return Subsections(body)
+}
+
+// Subsections returns the sections nested under parent, which may be the
+// document body or a section
+func Subsections(parent map[string]interface{}) []map[string]interface{} {
+	raw, _ := parent["sections"].([]interface{})
+
+	sections := make([]map[string]interface{}, 0, len(raw))
+	for _, s := range raw {
+		if obj, ok := s.(map[string]interface{}); ok {
+			sections = append(sections, obj)
+		}
+	}
+	return sections
+}
+
+// SectionRef locates a section within the section tree
+type SectionRef struct {
+	Section map[string]interface{}
+	// Parent is the ID of the enclosing section, empty for top-level
+	// sections
+	Parent string
+	// Depth is 0 for top-level sections
+	Depth int
+}
+
+// AllSections returns every section, including nested subsections, in
+// document order
+func (d Document) AllSections() []SectionRef {
+	var refs []SectionRef
+	var walk func(sections []map[string]interface{}, parent string, depth int)
+	walk = func(sections []map[string]interface{}, parent string, depth int) {
+		for _, s := range sections {
+			refs = append(refs, SectionRef{Section: s, Parent: parent, Depth: depth})
+			walk(Subsections(s), String(s, "id"), depth+1)
+		}
+	}
+	walk(d.Sections(), "", 0)
+	return refs
+}
+
+// FindSection returns the section with the given ID anywhere in the
+// section tree, or nil
+func (d Document) FindSection(id string) map[string]interface{} {
+	for _, ref := range d.AllSections() {
+		if String(ref.Section, "id") == id {
+			return ref.Section
+		}
+	}
+	return nil
+}
+
+// SetSections replaces the top-level sections
+func (d Document) SetSections(sections []map[string]interface{}) {
+	raw := make([]interface{}, len(sections))
+	for i, s := range sections {
+		raw[i] = s
+	}
+	d.Body(true)["sections"] = raw
+}
+
+// Verification returns the verification object, creating it when create is set
+func (d Document) Verification(create bool) map[string]interface{} {
+	return d.Object("verification", create)
+}
+
+// Set sets the value at a JSON pointer: an object member is added or
+// replaced, or an array item replaced. The parent of the value must exist.
+func (d Document) Set(pointer string, value interface{}) error {
+	if !strings.HasPrefix(pointer, "/") {
+		return fmt.Errorf("cannot set %q: not a pointer into the document", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	var parent interface{} = map[string]interface{}(d)
+	for i, token := range tokens {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		last := i == len(tokens)-1
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if last {
+				p[token] = value
+				return nil
+			}
+			parent = p[token]
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(p) {
+				return fmt.Errorf("cannot set %s: no item %s", pointer, token)
+			}
+			if last {
+				p[index] = value
+				return nil
+			}
+			parent = p[index]
+		default:
+			return fmt.Errorf("cannot set %s: %s is not in an object or list", pointer, token)
+		}
+	}
+	return nil
+}
+
+// Append adds value to the array stored under key in obj
+func Append(obj map[string]interface{}, key string, value interface{}) {
+	list, _ := obj[key].([]interface{})
+	obj[key] = append(list, value)
+}
+
+// String returns the string stored under key in obj
+func String(obj map[string]interface{}, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
@@ -0,0 +1,249 @@
+package document
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseAndMarshal(t *testing.T) {
+	input := `{"verification":{},"x-extra":true,"content":{"sections":[{"id":"a","title":"A","content":"text","amount":10.50}]},"metadata":{"type":"contract"}}`
+
+	doc, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if doc.Type() != "contract" {
+		t.Errorf("Expected type contract, got %s", doc.Type())
+	}
+	if doc.BodyKey() != "content" {
+		t.Errorf("Expected body key content, got %s", doc.BodyKey())
+	}
+	if len(doc.Sections()) != 1 {
+		t.Fatalf("Expected 1 section, got %d", len(doc.Sections()))
+	}
+
+	out, err := doc.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	s := string(out)
+
+	// Known keys are written in schema order, unknown keys last
+	order := []string{`"metadata"`, `"content"`, `"verification"`, `"x-extra"`}
+	last := -1
+	for _, key := range order {
+		idx := strings.Index(s, key)
+		if idx <= last {
+			t.Errorf("Expected %s after previous keys in:\n%s", key, s)
+		}
+		last = idx
+	}
+
+	// Numbers are preserved exactly
+	if !strings.Contains(s, "10.50") {
+		t.Errorf("Expected number to be preserved, got:\n%s", s)
+	}
+
+	reparsed, err := Parse(out)
+	if err != nil {
+		t.Fatalf("Marshal output does not parse: %v", err)
+	}
+	if len(reparsed.Sections()) != 1 {
+		t.Errorf("Expected roundtrip to keep sections")
+	}
+}
+
+func TestStructureBody(t *testing.T) {
+	doc, err := Parse([]byte(`{"metadata":{"type":"NDA"},"structure":{"sections":[{"id":"a"},{"id":"b"}]}}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if doc.BodyKey() != "structure" {
+		t.Errorf("Expected body key structure, got %s", doc.BodyKey())
+	}
+
+	sections := doc.Sections()
+	doc.SetSections(sections[:1])
+	if len(doc.Sections()) != 1 {
+		t.Errorf("Expected 1 section after SetSections, got %d", len(doc.Sections()))
+	}
+	if _, ok := doc["content"]; ok {
+		t.Errorf("SetSections must not create a content body for structure documents")
+	}
+}
+
+func TestAllSections(t *testing.T) {
+	doc, err := Parse([]byte(`{"content":{"sections":[
+		{"id":"a","sections":[{"id":"a1","sections":[{"id":"a1x"}]},{"id":"a2"}]},
+		{"id":"b"}
+	]}}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var got []string
+	for _, ref := range doc.AllSections() {
+		got = append(got, fmt.Sprintf("%s/%s/%d", ref.Parent, String(ref.Section, "id"), ref.Depth))
+	}
+	expected := "/a/0 a/a1/1 a1/a1x/2 a/a2/1 /b/0"
+	if strings.Join(got, " ") != expected {
+		t.Errorf("Expected %s, got %s", expected, strings.Join(got, " "))
+	}
+
+	if doc.FindSection("a1x") == nil {
+		t.Errorf("Expected nested section to be found")
+	}
+	if doc.FindSection("missing") != nil {
+		t.Errorf("Expected missing section not to be found")
+	}
+}
+
+func TestParseRejectsNonObject(t *testing.T) {
+	for _, input := range []string{`[]`, `null`, `{`} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Expected error for %s", input)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	input := "{\"metadata\":{\"type\":\"receipt\"}}\n\n  \r\n{\"metadata\":{\"type\":\"contract\"}}\r\n{\"broken\"\n{\"last\":true}"
+	s := NewStream(strings.NewReader(input))
+
+	want := []Record{
+		{Line: 1, Data: []byte(`{"metadata":{"type":"receipt"}}`)},
+		{Line: 4, Data: []byte(`{"metadata":{"type":"contract"}}`)},
+		{Line: 5, Data: []byte(`{"broken"`)},
+		{Line: 6, Data: []byte(`{"last":true}`)},
+	}
+	for _, w := range want {
+		r, err := s.Next()
+		if err != nil {
+			t.Fatalf("Failed to read record at line %d: %v", w.Line, err)
+		}
+		if r.Line != w.Line || string(r.Data) != string(w.Data) {
+			t.Errorf("Expected line %d %s, got line %d %s", w.Line, w.Data, r.Line, r.Data)
+		}
+	}
+	if _, err := s.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end of the stream, got %v", err)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	data := []byte(`{
+  "metadata": {"version": "1.0.0", "title": "A/B"},
+  "content": {
+    "sections": [
+      {"id": "a", "title": "A"},
+      {"id": "b", "title": "B", "content": [1, [2, 3]]}
+    ],
+    "a/b": {"c~d": true}
+  }
+}`)
+
+	testCases := []struct {
+		pointer      string
+		line, column int
+	}{
+		{pointer: "", line: 1, column: 1},
+		{pointer: "/metadata", line: 2, column: 15},
+		{pointer: "/metadata/title", line: 2, column: 45},
+		{pointer: "/content/sections/1", line: 6, column: 7},
+		{pointer: "/content/sections/1/content/1/0", line: 6, column: 49},
+		{pointer: "/content/a~1b/c~0d", line: 8, column: 20},
+		// Missing values locate their nearest parent
+		{pointer: "/content/sections/0/content", line: 5, column: 7},
+		{pointer: "/content/sections/9", line: 4, column: 17},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pointer, func(t *testing.T) {
+			line, column := Locate(data, tc.pointer)
+			if line != tc.line || column != tc.column {
+				t.Errorf("Expected %d:%d, got %d:%d", tc.line, tc.column, line, column)
+			}
+		})
+	}
+
+	if line, _ := Locate([]byte(`{"a": `), "/a"); line != 1 {
+		t.Errorf("Expected the root of truncated JSON, got line %d", line)
+	}
+
+	// Columns count characters, and CRLF line endings end lines
+	line, column := Locate([]byte("{\r\n  \"\u00e9t\u00e9\": [\"\u00fc\", 2]\r\n}"), "/\u00e9t\u00e9/1")
+	if line != 2 || column != 16 {
+		t.Errorf("Expected 2:16, got %d:%d", line, column)
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	var typed struct{ Count int }
+	typeErr := json.Unmarshal([]byte("{\n  \"Count\": \"\u00fc\"}"), &typed)
+
+	testCases := []struct {
+		name         string
+		data         string
+		err          error
+		line, column int
+	}{
+		{name: "Invalid Character", data: `{"a": x}`, line: 1, column: 7},
+		{name: "CRLF", data: "{\r\n  \"a\": 1,\r\n  x\r\n}", line: 3, column: 3},
+		{name: "Unicode", data: "{\"\u540d\u524d\": \"\u00fc\", x}", line: 1, column: 13},
+		{name: "Unexpected End", data: "{\n  \"a\": ", line: 2, column: 8},
+		{name: "Data After Value", data: `[1]]`, line: 1, column: 4},
+		{name: "Type Error", data: "{\n  \"Count\": \"\u00fc\"}", err: typeErr, line: 2, column: 14},
+		{name: "Other Error", data: `{}`, err: errors.New("failed"), line: 0, column: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.err
+			if err == nil {
+				var v interface{}
+				err = json.Unmarshal([]byte(tc.data), &v)
+			}
+			line, column := ErrorPosition(err, []byte(tc.data))
+			if line != tc.line || column != tc.column {
+				t.Errorf("Expected %d:%d, got %d:%d (%v)", tc.line, tc.column, line, column, err)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	doc, err := Parse([]byte(`{"metadata": {"type": "Contrct"}, "content": {"sections": [{"id": "a/b"}, {"id": "c"}]}}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	for pointer, value := range map[string]interface{}{
+		"/metadata/type":              "contract",
+		"/content/sections/0/title":   "A",
+		"/content/sections/1":         map[string]interface{}{"id": "d"},
+		"/content/sections/0/x~1y~0z": true,
+	} {
+		if err := doc.Set(pointer, value); err != nil {
+			t.Errorf("Set(%s) failed: %v", pointer, err)
+		}
+	}
+	out, err := doc.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, want := range []string{`"type": "contract"`, `"title": "A"`, `"id": "d"`, `"x/y~z": true`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("Expected %s in %s", want, out)
+		}
+	}
+
+	for _, pointer := range []string{"", "/content/sections/2", "/content/sections/x", "/missing/key", "/metadata/type/x"} {
+		if err := doc.Set(pointer, 1); err == nil {
+			t.Errorf("Expected Set(%q) to fail", pointer)
+		}
+	}
+}
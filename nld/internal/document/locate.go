@@ -0,0 +1,156 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Locate returns the 1-based line and column where the value at the JSON
+// pointer starts in data. When the pointer does not exist, as for a
+// missing property, the deepest existing value on its path is located
+// instead. Line is 0 when data is not valid JSON up to that value.
+func Locate(data []byte, pointer string) (line, column int) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	offset := -1
+	var stack []*container
+
+	for {
+		start := valueStart(data, int(dec.InputOffset()))
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+
+		// Work out the pointer of the value this token starts
+		ptr := ""
+		isKey := false
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			switch {
+			case top.array:
+				ptr = top.ptr + "/" + strconv.Itoa(top.index)
+			case !top.hasKey:
+				isKey = true
+			default:
+				ptr = top.ptr + "/" + escapePointer(top.key)
+			}
+		}
+
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			next(stack)
+			continue
+		}
+		if isKey {
+			top := stack[len(stack)-1]
+			top.key, _ = tok.(string)
+			top.hasKey = true
+			continue
+		}
+
+		if ptr == pointer || strings.HasPrefix(pointer, ptr+"/") {
+			offset = start
+			if ptr == pointer {
+				break
+			}
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			stack = append(stack, &container{ptr: ptr, array: delim == '['})
+			continue
+		}
+		next(stack)
+	}
+
+	if offset < 0 {
+		return 0, 0
+	}
+	return Position(data, offset)
+}
+
+// ErrorPosition returns the 1-based line and column in data where a JSON
+// decoding error occurred, or 0, 0 when err has no position. Type errors
+// are located by the last character of the value of the wrong type.
+func ErrorPosition(err error, data []byte) (line, column int) {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+		// The offset counts the byte the decoder stopped at, except at the
+		// end of the input, where there is no such byte
+		if offset < int64(len(data)) || !strings.HasPrefix(syntaxErr.Error(), "unexpected end") {
+			offset--
+		}
+	case errors.As(err, &typeErr):
+		// The offset is just after the value
+		offset = typeErr.Offset - 1
+	default:
+		return 0, 0
+	}
+	return Position(data, int(offset))
+}
+
+// Position returns the 1-based line and column of the byte at offset in
+// data. Columns count characters rather than bytes, and the carriage return
+// of a CRLF line ending belongs to the line it ends. An offset at the end
+// of data is the position just after the last character.
+func Position(data []byte, offset int) (line, column int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	start := bytes.LastIndexByte(before, '\n') + 1
+	return 1 + bytes.Count(before, []byte("\n")), 1 + utf8.RuneCount(before[start:])
+}
+
+// container is an object or array open while locating a value
+type container struct {
+	ptr   string
+	array bool
+	// index is the position of the next element of an array
+	index int
+	// key is the member name of the current value of an object, when
+	// hasKey is set
+	key    string
+	hasKey bool
+}
+
+// next moves the innermost container past its current value
+func next(stack []*container) {
+	if len(stack) == 0 {
+		return
+	}
+	top := stack[len(stack)-1]
+	if top.array {
+		top.index++
+	} else {
+		top.hasKey = false
+	}
+}
+
+// valueStart skips the whitespace and separators before the token that
+// starts at or after offset
+func valueStart(data []byte, offset int) int {
+	for offset < len(data) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ':', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// escapePointer escapes an object key for use in a JSON pointer
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,47 @@
+package document
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// Stream reads documents from newline-delimited JSON (NDJSON), one document
+// per line. Only the current record is held in memory, so streams of any
+// length can be read.
+type Stream struct {
+	r    *bufio.Reader
+	line int
+}
+
+// Record is one document of a stream
+type Record struct {
+	// Line is the 1-based line number of the record
+	Line int
+	Data []byte
+}
+
+// NewStream returns a stream reading records from r
+func NewStream(r io.Reader) *Stream {
+	return &Stream{r: bufio.NewReaderSize(r, 64<<10)}
+}
+
+// Next returns the next record, skipping blank lines. It returns io.EOF
+// when the stream is exhausted.
+func (s *Stream) Next() (Record, error) {
+	for {
+		data, err := s.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return Record{}, err
+		}
+		if err != nil && err != io.EOF {
+			return Record{}, err
+		}
+		s.line++
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		return Record{Line: s.line, Data: data}, nil
+	}
+}
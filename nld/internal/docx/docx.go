@@ -0,0 +1,374 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/i18n"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const (
+	// MetadataNamespace is the namespace of the custom XML part that holds
+	// the NLD metadata of an exported document
+	MetadataNamespace = "urn:nld:metadata"
+
+	// metadataPart is the custom XML part holding the metadata
+	metadataPart = "customXml/item1.xml"
+
+	// metadataItemID identifies the metadata part in the custom XML data
+	// store
+	metadataItemID = "{6E4C7A1D-2B3F-4D5E-9A8B-0C1D2E3F4A5B}"
+)
+
+// Options controls how a document is exported
+type Options struct {
+	// Numbering is the style used to number sections. The zero value uses
+	// the customary style for the document's jurisdiction.
+	Numbering nld.NumberingStyle
+	// Unnumbered writes section headings without numbers
+	Unnumbered bool
+	// Lang is the language of headings and labels added by the exporter.
+	// Nil writes English.
+	Lang *i18n.Catalog
+	// Locale selects the locale of section titles and content
+	Locale string
+}
+
+// Write writes doc to w as a Word document. Sections become Word headings
+// at their nesting level, definitions a glossary at the end, and the NLD
+// metadata is embedded in a custom XML part so that it survives a round
+// trip through Word.
+func Write(w io.Writer, doc *nld.Document, opts Options) error {
+	tr := opts.Lang
+	if tr == nil {
+		tr = i18n.English()
+	}
+	if opts.Locale != "" {
+		doc = doc.Localize(opts.Locale)
+	}
+
+	meta, err := json.Marshal(doc.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"docProps/core.xml", coreProperties(doc)},
+		{"word/_rels/document.xml.rels", documentRels},
+		{"word/document.xml", body(doc, tr, opts)},
+		{"word/styles.xml", styles},
+		{metadataPart, xml.Header + `<nld:metadata xmlns:nld="` + MetadataNamespace + `">` + escape(string(meta)) + `</nld:metadata>`},
+		{"customXml/_rels/item1.xml.rels", metadataRels},
+		{"customXml/itemProps1.xml", metadataProperties},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, p := range parts {
+		// A fixed modification time keeps exports of the same document
+		// byte-identical
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     p.name,
+			Method:   zip.Deflate,
+			Modified: time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// ReadMetadata returns the NLD metadata embedded in a Word document by
+// Write. The boolean is false if the document has no NLD metadata.
+func ReadMetadata(data []byte) (nld.Metadata, bool, error) {
+	var meta nld.Metadata
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return meta, false, fmt.Errorf("invalid Word document: %w", err)
+	}
+
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, "customXml/item") || strings.Contains(f.Name, "Props") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return meta, false, err
+		}
+		var part struct {
+			XMLName xml.Name
+			Text    string `xml:",chardata"`
+		}
+		err = xml.NewDecoder(rc).Decode(&part)
+		rc.Close()
+		if err != nil || part.XMLName.Space != MetadataNamespace || part.XMLName.Local != "metadata" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(part.Text), &meta); err != nil {
+			return meta, false, fmt.Errorf("invalid NLD metadata: %w", err)
+		}
+		return meta, true, nil
+	}
+	return meta, false, nil
+}
+
+// escape escapes text for use in XML character data and attributes
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// run returns a run of text, turning line breaks into Word breaks
+func run(text string, bold bool) string {
+	var b strings.Builder
+	b.WriteString("<w:r>")
+	if bold {
+		b.WriteString("<w:rPr><w:b/></w:rPr>")
+	}
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.WriteString("<w:br/>")
+		}
+		b.WriteString(`<w:t xml:space="preserve">` + escape(line) + "</w:t>")
+	}
+	b.WriteString("</w:r>")
+	return b.String()
+}
+
+// paragraph returns a paragraph with the given style and runs
+func paragraph(style, align string, runs ...string) string {
+	var props string
+	if style != "" {
+		props += `<w:pStyle w:val="` + style + `"/>`
+	}
+	if align != "" {
+		props += `<w:jc w:val="` + align + `"/>`
+	}
+	if props != "" {
+		props = "<w:pPr>" + props + "</w:pPr>"
+	}
+	return "<w:p>" + props + strings.Join(runs, "") + "</w:p>"
+}
+
+// heading returns the heading text for a numbered section
+func heading(number, title string) string {
+	if number == "" {
+		return title
+	}
+	if strings.ContainsAny(number, ".( ") {
+		return number + " " + title
+	}
+	return number + ". " + title
+}
+
+// body returns word/document.xml
+func body(doc *nld.Document, tr *i18n.Catalog, opts Options) string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+
+	title := doc.Metadata.Title
+	if title == "" {
+		title = tr.T("render.untitled")
+	}
+	b.WriteString(paragraph("Title", "", run(title, false)))
+
+	if doc.Metadata.Jurisdiction != "" {
+		b.WriteString(paragraph("", "", run(tr.T("render.jurisdiction")+": ", true), run(doc.Metadata.Jurisdiction, false)))
+	}
+	if len(doc.Metadata.Entities) > 0 {
+		b.WriteString(paragraph("Heading1", "", run(tr.T("render.parties"), false)))
+		for _, e := range doc.Metadata.Entities {
+			b.WriteString(paragraph("ListParagraph", "", run(e.Name, true), run(" ("+e.Role+")", false)))
+		}
+	}
+
+	var walk func(sections []nld.Section, entries []nld.OutlineEntry)
+	walk = func(sections []nld.Section, entries []nld.OutlineEntry) {
+		for i, s := range sections {
+			entry := entries[i]
+			number := entry.Number
+			if opts.Unnumbered {
+				number = ""
+			}
+			level := entry.Depth + 1
+			if level > 6 {
+				level = 6
+			}
+			b.WriteString(paragraph(fmt.Sprintf("Heading%d", level), "", run(heading(number, s.Title), false)))
+			for _, block := range s.Body() {
+				b.WriteString(blockXML(doc, tr, block))
+			}
+			walk(s.Sections, entry.Children)
+		}
+	}
+	walk(doc.Structure.Sections, doc.Outline(opts.Numbering))
+
+	if len(doc.Structure.Definitions) > 0 {
+		b.WriteString(paragraph("Heading1", "", run(tr.T("render.definitions"), false)))
+		for _, d := range doc.Structure.Definitions {
+			b.WriteString(paragraph("Definition", "", run(d.Term, true), run(": "+d.Definition, false)))
+		}
+	}
+
+	// US documents are printed on Letter paper, others on A4
+	width, height := 11906, 16838
+	if nld.NumberingStyleFor(doc.Metadata.Jurisdiction).Name == "us" {
+		width, height = 12240, 15840
+	}
+	fmt.Fprintf(&b, `<w:sectPr><w:pgSz w:w="%d" w:h="%d"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>`, width, height)
+	b.WriteString("</w:body></w:document>")
+	return b.String()
+}
+
+// blockXML returns the Word markup for a content block
+func blockXML(doc *nld.Document, tr *i18n.Catalog, block nld.Block) string {
+	switch block.Type {
+	case nld.BlockList:
+		var b strings.Builder
+		for i, item := range block.Items {
+			marker := "•\t"
+			if block.Ordered {
+				marker = fmt.Sprintf("%d.\t", i+1)
+			}
+			b.WriteString(paragraph("ListParagraph", "", run(marker+item, false)))
+		}
+		return b.String()
+	case nld.BlockTable:
+		return tableXML(tr, block)
+	case nld.BlockDefinitionRef:
+		text := ""
+		if d := doc.Definition(block.Term); d != nil {
+			text = d.Definition
+		}
+		return paragraph("", "", run(`"`+block.Term+`"`, true), run(" "+tr.T("render.means")+" "+text, false))
+	default:
+		return paragraph("", "", run(block.Text, false))
+	}
+}
+
+// tableXML returns the Word markup for a table block
+func tableXML(tr *i18n.Catalog, block nld.Block) string {
+	if block.TotalLabel == "" {
+		block.TotalLabel = tr.T("render.total")
+	}
+	header, rows := block.TableHeader()
+	totals, _ := block.TableTotals()
+	var numeric []bool
+	for _, c := range block.TableColumns() {
+		numeric = append(numeric, c.Type == nld.ColumnNumber)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="0" w:type="auto"/></w:tblPr><w:tblGrid>`)
+	for range header {
+		b.WriteString(`<w:gridCol/>`)
+	}
+	b.WriteString(`</w:tblGrid>`)
+
+	row := func(cells []string, bold, repeat bool) {
+		b.WriteString("<w:tr>")
+		if repeat {
+			b.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+		}
+		for i, c := range cells {
+			align := ""
+			if i < len(numeric) && numeric[i] {
+				align = "right"
+			}
+			b.WriteString("<w:tc>" + paragraph("", align, run(c, bold)) + "</w:tc>")
+		}
+		b.WriteString("</w:tr>")
+	}
+	row(header, true, true)
+	for _, r := range rows {
+		row(r, false, false)
+	}
+	if totals != nil {
+		row(totals, true, false)
+	}
+	b.WriteString("</w:tbl>")
+	// Word requires a paragraph between consecutive tables
+	b.WriteString(paragraph("", ""))
+	return b.String()
+}
+
+// coreProperties returns docProps/core.xml
+func coreProperties(doc *nld.Document) string {
+	return xml.Header + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+		`<dc:title>` + escape(doc.Metadata.Title) + `</dc:title>` +
+		`<dc:creator>` + escape(doc.Metadata.Author) + `</dc:creator>` +
+		`<cp:category>` + escape(doc.Metadata.Type) + `</cp:category>` +
+		`</cp:coreProperties>`
+}
+
+const contentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+	`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+	`<Override PartName="/customXml/itemProps1.xml" ContentType="application/vnd.openxmlformats-officedocument.customXmlProperties+xml"/>` +
+	`</Types>`
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+	`</Relationships>`
+
+const documentRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/customXml" Target="../` + metadataPart + `"/>` +
+	`</Relationships>`
+
+const metadataRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/customXmlProps" Target="itemProps1.xml"/>` +
+	`</Relationships>`
+
+const metadataProperties = xml.Header + `<ds:datastoreItem ds:itemID="` + metadataItemID + `" xmlns:ds="http://schemas.openxmlformats.org/officeDocument/2006/customXml">` +
+	`<ds:schemaRefs><ds:schemaRef ds:uri="` + MetadataNamespace + `"/></ds:schemaRefs></ds:datastoreItem>`
+
+// styles defines the paragraph styles used by the exporter. Headings use
+// Word's built-in names so that they appear in the navigation pane and
+// table of contents.
+var styles = xml.Header + `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+	`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:cs="Calibri"/><w:sz w:val="22"/></w:rPr></w:rPrDefault>` +
+	`<w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="259" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>` +
+	`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="240"/></w:pPr><w:rPr><w:sz w:val="48"/></w:rPr></w:style>` +
+	headingStyles() +
+	`<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Definition"><w:name w:val="Definition"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:ind w:left="360"/></w:pPr></w:style>` +
+	`<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders>` +
+	`<w:top w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:left w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+	`<w:bottom w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:right w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+	`<w:insideH w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="auto"/>` +
+	`</w:tblBorders></w:tblPr></w:style>` +
+	`</w:styles>`
+
+// headingStyles returns the styles Heading1 to Heading6
+func headingStyles() string {
+	sizes := []int{32, 28, 26, 24, 22, 22}
+	var b strings.Builder
+	for i, size := range sizes {
+		fmt.Fprintf(&b, `<w:style w:type="paragraph" w:styleId="Heading%d"><w:name w:val="heading %d"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>`+
+			`<w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="%d"/></w:pPr><w:rPr><w:b/><w:sz w:val="%d"/></w:rPr></w:style>`, i+1, i+1, i, size)
+	}
+	return b.String()
+}
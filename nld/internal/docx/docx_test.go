@@ -0,0 +1,225 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const testDocument = `{
+  "metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Service <Agreement>",
+    "author": "Legal", "jurisdiction": "California, USA", "language": "en",
+    "entities": [{"id": "p1", "name": "Acme Corporation", "role": "Service Provider"}]},
+  "content": {
+    "sections": [
+      {"id": "intro", "title": "Introduction", "content": "First paragraph.\n\nSecond paragraph."},
+      {"id": "fees", "title": "Fees", "content": [
+        {"type": "list", "ordered": true, "items": ["Monthly"]},
+        {"type": "table", "columns": [{"title": "Item"}, {"title": "Amount", "type": "number", "total": true}], "rows": [["Widget", "10.00"], ["Gadget", "2.50"]]}
+      ], "sections": [
+        {"id": "late", "title": "Late Payment", "content": [{"type": "definitionRef", "term": "Due Date"}]}
+      ]}
+    ],
+    "definitions": [{"term": "Due Date", "definition": "the 1st of each month"}]
+  }
+}`
+
+// parts returns the files of a Word document
+func parts(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Export is not a zip archive: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestWrite(t *testing.T) {
+	doc, err := nld.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, doc, Options{}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	files := parts(t, buf.Bytes())
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/styles.xml", "word/_rels/document.xml.rels", "docProps/core.xml", "customXml/item1.xml", "customXml/itemProps1.xml", "customXml/_rels/item1.xml.rels"} {
+		content, ok := files[name]
+		if !ok {
+			t.Errorf("Missing part %s", name)
+			continue
+		}
+		// Every part must be well-formed XML
+		d := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := d.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("Part %s is not well-formed: %v", name, err)
+				break
+			}
+		}
+	}
+
+	body := files["word/document.xml"]
+	for _, s := range []string{
+		`<w:pStyle w:val="Title"/></w:pPr><w:r><w:t xml:space="preserve">Service &lt;Agreement&gt;</w:t>`,
+		`<w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">Article I Introduction</w:t>`,
+		`<w:t xml:space="preserve">Second paragraph.</w:t>`,
+		`<w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t xml:space="preserve">Section 1 Late Payment</w:t>`,
+		`<w:jc w:val="right"/></w:pPr><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">12.50</w:t>`,
+		`<w:t xml:space="preserve">&#34;Due Date&#34;</w:t></w:r><w:r><w:t xml:space="preserve"> means the 1st of each month</w:t>`,
+		`<w:pStyle w:val="Definition"/>`,
+		`<w:pgSz w:w="12240" w:h="15840"/>`,
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("Expected document.xml to contain %q, got:\n%s", s, body)
+		}
+	}
+	if !strings.Contains(files["docProps/core.xml"], "<dc:creator>Legal</dc:creator>") {
+		t.Errorf("Expected the author in the core properties, got %s", files["docProps/core.xml"])
+	}
+
+	// The same document exports to the same bytes
+	var again bytes.Buffer
+	if err := Write(&again, doc, Options{}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Error("Expected exports of the same document to be identical")
+	}
+}
+
+func TestReadMetadata(t *testing.T) {
+	doc, err := nld.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, doc, Options{}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	meta, ok, err := ReadMetadata(buf.Bytes())
+	if err != nil || !ok {
+		t.Fatalf("ReadMetadata failed: %v (found %v)", err, ok)
+	}
+	if !reflect.DeepEqual(meta, doc.Metadata) {
+		t.Errorf("Expected metadata %+v, got %+v", doc.Metadata, meta)
+	}
+
+	if _, _, err := ReadMetadata([]byte("not a zip")); err == nil {
+		t.Error("Expected an error for a file that is not a Word document")
+	}
+}
+
+// archive returns a Word document made of the given parts
+func archive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		io.WriteString(w, content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	const w = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+	data := archive(t, map[string]string{
+		"word/document.xml": `<w:document ` + w + `><w:body>` +
+			`<w:p><w:pPr><w:pStyle w:val="Title"/></w:pPr><w:r><w:t>Lease</w:t></w:r></w:p>` +
+			`<w:p><w:pPr><w:pStyle w:val="Clause"/></w:pPr><w:r><w:t>1.</w:t></w:r><w:r><w:tab/><w:t>Rent</w:t></w:r></w:p>` +
+			`<w:p><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">Due </w:t></w:r><w:r><w:rPr><w:b w:val="0"/></w:rPr><w:t>monthly</w:t></w:r>` +
+			`<w:del><w:r><w:delText>weekly</w:delText></w:r></w:del><w:r><w:drawing><w:t>ignored</w:t></w:drawing></w:r></w:p>` +
+			`<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="2"/></w:numPr></w:pPr><w:r><w:t>First</w:t></w:r></w:p>` +
+			`<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Bullet</w:t></w:r></w:p>` +
+			`<w:tbl><w:tr><w:trPr><w:tblHeader/></w:trPr><w:tc><w:p><w:r><w:t>Item</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Cost</w:t></w:r></w:p></w:tc></w:tr>` +
+			`<w:tr><w:tc><w:tcPr><w:gridSpan w:val="2"/></w:tcPr><w:p><w:r><w:t>Rent</w:t></w:r></w:p><w:p><w:r><w:t>Deposit</w:t></w:r></w:p></w:tc></w:tr></w:tbl>` +
+			`<w:sectPr><w:pgSz w:w="11906"/></w:sectPr></w:body></w:document>`,
+		"word/styles.xml": `<w:styles ` + w + `>` +
+			`<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/></w:style>` +
+			`<w:style w:type="paragraph" w:styleId="Berschrift2"><w:name w:val="heading 2"/></w:style>` +
+			`<w:style w:type="paragraph" w:styleId="Clause"><w:name w:val="Clause"/><w:basedOn w:val="Berschrift2"/></w:style>` +
+			`</w:styles>`,
+		"word/numbering.xml": `<w:numbering ` + w + `>` +
+			`<w:abstractNum w:abstractNumId="0"><w:lvl w:ilvl="0"><w:numFmt w:val="bullet"/></w:lvl></w:abstractNum>` +
+			`<w:abstractNum w:abstractNumId="1"><w:lvl w:ilvl="0"><w:numFmt w:val="decimal"/></w:lvl></w:abstractNum>` +
+			`<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num><w:num w:numId="2"><w:abstractNumId w:val="1"/></w:num>` +
+			`</w:numbering>`,
+		"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+			`<dc:title>Lease Agreement</dc:title><dc:creator>Legal</dc:creator></cp:coreProperties>`,
+	})
+
+	content, err := Read(data)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if content.Title != "Lease Agreement" || content.Creator != "Legal" {
+		t.Errorf("Unexpected properties %q, %q", content.Title, content.Creator)
+	}
+	if len(content.Elements) != 6 {
+		t.Fatalf("Expected 6 elements, got %d", len(content.Elements))
+	}
+
+	title := content.Elements[0].Paragraph
+	if !title.Title || title.Level != 0 {
+		t.Errorf("Expected title paragraph, got %+v", title)
+	}
+	heading := content.Elements[1].Paragraph
+	if heading.Level != 2 || heading.Text() != "1.\tRent" {
+		t.Errorf("Expected level 2 heading from based-on style, got %+v", heading)
+	}
+	body := content.Elements[2].Paragraph
+	wantRuns := []Run{{Text: "Due ", Bold: true}, {Text: "monthly"}, {}}
+	if !reflect.DeepEqual(body.Runs, wantRuns) {
+		t.Errorf("Expected runs %+v, got %+v", wantRuns, body.Runs)
+	}
+	if !reflect.DeepEqual(body.Unmapped, []string{"tracked change", "image"}) {
+		t.Errorf("Expected unmapped content, got %v", body.Unmapped)
+	}
+	if p := content.Elements[3].Paragraph; !p.List || !p.Ordered {
+		t.Errorf("Expected numbered list item, got %+v", p)
+	}
+	if p := content.Elements[4].Paragraph; !p.List || p.Ordered {
+		t.Errorf("Expected bulleted list item, got %+v", p)
+	}
+
+	table := content.Elements[5].Table
+	if len(table.Rows) != 2 || !table.Rows[0].Header || table.Rows[1].Header {
+		t.Fatalf("Unexpected table rows %+v", table.Rows)
+	}
+	if got := table.Rows[1].Cells[0].Text(); got != "Rent\nDeposit" {
+		t.Errorf("Expected cell text from both paragraphs, got %q", got)
+	}
+	if !reflect.DeepEqual(table.Unmapped, []string{"merged cells"}) {
+		t.Errorf("Expected merged cells to be reported, got %v", table.Unmapped)
+	}
+}
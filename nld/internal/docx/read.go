@@ -0,0 +1,507 @@
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Content is the body of a Word document as a sequence of paragraphs and
+// tables
+type Content struct {
+	Elements []Element
+	// Title and Creator are the document properties
+	Title   string
+	Creator string
+}
+
+// Element is a paragraph or a table in the body of a document
+type Element struct {
+	Paragraph *Paragraph
+	Table     *Table
+}
+
+// Paragraph is a paragraph of a Word document
+type Paragraph struct {
+	// Style is the style ID, such as "Heading1"
+	Style string
+	// Level is the outline level of a heading, 1 for top-level headings,
+	// or 0 for body text
+	Level int
+	// Title is true for paragraphs in the Title style
+	Title bool
+	// List is true for list items, and Ordered for numbered list items
+	List    bool
+	Ordered bool
+	// Align is the paragraph alignment, such as "right"
+	Align string
+	Runs  []Run
+	// Unmapped lists content in the paragraph that has no text, such as
+	// images and footnotes
+	Unmapped []string
+}
+
+// Run is a run of text with the same formatting
+type Run struct {
+	Text string
+	Bold bool
+}
+
+// Table is a table of a Word document
+type Table struct {
+	Rows []Row
+	// Unmapped lists table features that have no NLD equivalent, such as
+	// merged cells
+	Unmapped []string
+}
+
+// Row is a table row
+type Row struct {
+	// Header is true for rows repeated as a header on each page
+	Header bool
+	Cells  []Cell
+}
+
+// Cell is a table cell
+type Cell struct {
+	Paragraphs []Paragraph
+}
+
+// Text returns the text of the paragraph
+func (p Paragraph) Text() string {
+	var b strings.Builder
+	for _, r := range p.Runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+// Text returns the text of the cell, one line per paragraph
+func (c Cell) Text() string {
+	var lines []string
+	for _, p := range c.Paragraphs {
+		lines = append(lines, p.Text())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// style is a paragraph style defined in word/styles.xml
+type style struct {
+	ID      string `xml:"styleId,attr"`
+	Type    string `xml:"type,attr"`
+	Name    value  `xml:"name"`
+	BasedOn value  `xml:"basedOn"`
+	Outline *value `xml:"pPr>outlineLvl"`
+}
+
+// value is an element whose value is held in a w:val attribute
+type value struct {
+	Val string `xml:"val,attr"`
+}
+
+// reader reads the body of a Word document
+type reader struct {
+	dec       *xml.Decoder
+	styles    map[string]style
+	numbering map[string]bool
+}
+
+// Read reads the paragraphs and tables of a Word document. Heading levels
+// and list numbering are resolved from the document's styles and
+// numbering definitions.
+func Read(data []byte) (*Content, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Word document: %w", err)
+	}
+	parts := map[string]*zip.File{}
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+	part := func(name string) ([]byte, error) {
+		f, ok := parts[name]
+		if !ok {
+			return nil, nil
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	doc, err := part("word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("invalid Word document: missing word/document.xml")
+	}
+
+	r := &reader{styles: map[string]style{}, numbering: map[string]bool{}}
+	if styles, err := part("word/styles.xml"); err == nil && styles != nil {
+		var s struct {
+			Styles []style `xml:"style"`
+		}
+		if xml.Unmarshal(styles, &s) == nil {
+			for _, st := range s.Styles {
+				if st.Type == "paragraph" {
+					r.styles[st.ID] = st
+				}
+			}
+		}
+	}
+	if numbering, err := part("word/numbering.xml"); err == nil && numbering != nil {
+		r.readNumbering(numbering)
+	}
+
+	content := &Content{}
+	if core, err := part("docProps/core.xml"); err == nil && core != nil {
+		var props struct {
+			Title   string `xml:"title"`
+			Creator string `xml:"creator"`
+		}
+		if xml.Unmarshal(core, &props) == nil {
+			content.Title, content.Creator = props.Title, props.Creator
+		}
+	}
+
+	r.dec = xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := r.dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid Word document: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		// Other elements, such as content controls, are descended into
+		switch start.Name.Local {
+		case "p":
+			p, err := r.paragraph()
+			if err != nil {
+				return nil, err
+			}
+			content.Elements = append(content.Elements, Element{Paragraph: p})
+		case "tbl":
+			t, err := r.table()
+			if err != nil {
+				return nil, err
+			}
+			content.Elements = append(content.Elements, Element{Table: t})
+		case "sectPr":
+			if err := r.dec.Skip(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return content, nil
+}
+
+// readNumbering records which list definitions are numbered rather than
+// bulleted, keyed by numbering ID and level ("1/0")
+func (r *reader) readNumbering(data []byte) {
+	var n struct {
+		Abstract []struct {
+			ID     string `xml:"abstractNumId,attr"`
+			Levels []struct {
+				Level  string `xml:"ilvl,attr"`
+				Format value  `xml:"numFmt"`
+			} `xml:"lvl"`
+		} `xml:"abstractNum"`
+		Nums []struct {
+			ID       string `xml:"numId,attr"`
+			Abstract value  `xml:"abstractNumId"`
+		} `xml:"num"`
+	}
+	if xml.Unmarshal(data, &n) != nil {
+		return
+	}
+	formats := map[string]bool{}
+	for _, a := range n.Abstract {
+		for _, l := range a.Levels {
+			formats[a.ID+"/"+l.Level] = l.Format.Val != "bullet" && l.Format.Val != "none"
+		}
+	}
+	for _, num := range n.Nums {
+		for key, ordered := range formats {
+			if strings.HasPrefix(key, num.Abstract.Val+"/") {
+				r.numbering[num.ID+strings.TrimPrefix(key, num.Abstract.Val)] = ordered
+			}
+		}
+	}
+}
+
+// headingLevel returns the outline level of a style, following the
+// styles it is based on
+func (r *reader) headingLevel(id string) int {
+	for i := 0; i < 10 && id != ""; i++ {
+		st, ok := r.styles[id]
+		if !ok {
+			break
+		}
+		if st.Outline != nil {
+			return outlineLevel(st.Outline.Val)
+		}
+		if name := strings.ToLower(st.Name.Val); strings.HasPrefix(name, "heading ") {
+			n, _ := strconv.Atoi(strings.TrimPrefix(name, "heading "))
+			return n
+		}
+		id = st.BasedOn.Val
+	}
+	if strings.HasPrefix(id, "Heading") {
+		n, _ := strconv.Atoi(strings.TrimPrefix(id, "Heading"))
+		return n
+	}
+	return 0
+}
+
+// outlineLevel converts a zero-based w:outlineLvl value, where 9 is body
+// text, to a heading level
+func outlineLevel(val string) int {
+	n, err := strconv.Atoi(val)
+	if err != nil || n >= 9 {
+		return 0
+	}
+	return n + 1
+}
+
+// styleName returns the lower-case name of a style, or its ID
+func (r *reader) styleName(id string) string {
+	if st, ok := r.styles[id]; ok && st.Name.Val != "" {
+		return strings.ToLower(st.Name.Val)
+	}
+	return strings.ToLower(id)
+}
+
+// attr returns the value of the w:val attribute of an element
+func attr(start xml.StartElement) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == "val" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// enabled reports whether a toggle property such as w:b is switched on
+func enabled(start xml.StartElement) bool {
+	v := attr(start)
+	return v == "" || v == "1" || v == "true" || v == "on"
+}
+
+// paragraph reads a paragraph after its start element
+func (r *reader) paragraph() (*Paragraph, error) {
+	p := &Paragraph{}
+	var run *Run
+	var outline string
+	numID, numLevel := "", "0"
+	depth := 0
+	inProps := false
+	unmapped := func(what string) {
+		for _, u := range p.Unmapped {
+			if u == what {
+				return
+			}
+		}
+		p.Unmapped = append(p.Unmapped, what)
+	}
+
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid Word document: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch t.Name.Local {
+			case "pPr":
+				inProps = true
+			case "pStyle":
+				p.Style = attr(t)
+			case "outlineLvl":
+				outline = attr(t)
+			case "numId":
+				numID = attr(t)
+			case "ilvl":
+				numLevel = attr(t)
+			case "jc":
+				if inProps {
+					p.Align = attr(t)
+				}
+			case "r":
+				p.Runs = append(p.Runs, Run{})
+				run = &p.Runs[len(p.Runs)-1]
+			case "b":
+				if run != nil && !inProps {
+					run.Bold = enabled(t)
+				}
+			case "t":
+				var text string
+				if err := r.dec.DecodeElement(&text, &t); err != nil {
+					return nil, err
+				}
+				depth--
+				if run != nil {
+					run.Text += text
+				}
+			case "tab":
+				if run != nil && !inProps {
+					run.Text += "\t"
+				}
+			case "br", "cr":
+				if run != nil {
+					run.Text += "\n"
+				}
+			case "drawing", "pict", "object":
+				unmapped("image")
+				if err := r.dec.Skip(); err != nil {
+					return nil, err
+				}
+				depth--
+			case "footnoteReference", "endnoteReference":
+				unmapped("footnote")
+			case "commentReference":
+				unmapped("comment")
+			case "del", "moveFrom":
+				// Tracked deletions are not part of the current text
+				unmapped("tracked change")
+				if err := r.dec.Skip(); err != nil {
+					return nil, err
+				}
+				depth--
+			case "ins", "moveTo":
+				unmapped("tracked change")
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				p.resolve(r, outline, numID, numLevel)
+				return p, nil
+			}
+			depth--
+			switch t.Name.Local {
+			case "pPr":
+				inProps = false
+			case "r":
+				run = nil
+			}
+		}
+	}
+}
+
+// resolve sets the heading level and list properties of a paragraph
+func (p *Paragraph) resolve(r *reader, outline, numID, numLevel string) {
+	name := r.styleName(p.Style)
+	p.Title = name == "title"
+	if outline != "" {
+		p.Level = outlineLevel(outline)
+	} else if !p.Title {
+		p.Level = r.headingLevel(p.Style)
+	}
+	if p.Level > 0 {
+		return
+	}
+
+	switch {
+	case numID != "" && numID != "0":
+		p.List = true
+		p.Ordered = r.numbering[numID+"/"+numLevel]
+	case strings.HasPrefix(name, "list number"):
+		p.List, p.Ordered = true, true
+	case strings.HasPrefix(name, "list bullet"), name == "list paragraph", name == "listparagraph":
+		p.List = true
+	}
+}
+
+// table reads a table after its start element
+func (r *reader) table() (*Table, error) {
+	t := &Table{}
+	unmapped := func(what string) {
+		for _, u := range t.Unmapped {
+			if u == what {
+				return
+			}
+		}
+		t.Unmapped = append(t.Unmapped, what)
+	}
+
+	var row *Row
+	var cell *Cell
+	depth := 0
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid Word document: %w", err)
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "tr":
+				t.Rows = append(t.Rows, Row{})
+				row = &t.Rows[len(t.Rows)-1]
+			case "tblHeader":
+				if row != nil && enabled(el) {
+					row.Header = true
+				}
+			case "tc":
+				if row != nil {
+					row.Cells = append(row.Cells, Cell{})
+					cell = &row.Cells[len(row.Cells)-1]
+				}
+			case "gridSpan", "vMerge", "hMerge":
+				unmapped("merged cells")
+			case "p":
+				p, err := r.paragraph()
+				if err != nil {
+					return nil, err
+				}
+				if cell != nil {
+					cell.Paragraphs = append(cell.Paragraphs, *p)
+				}
+				for _, u := range p.Unmapped {
+					unmapped(u)
+				}
+				continue
+			case "tbl":
+				// Nested tables are flattened into the text of the cell
+				nested, err := r.table()
+				if err != nil {
+					return nil, err
+				}
+				unmapped("nested table")
+				if cell != nil {
+					for _, nr := range nested.Rows {
+						var texts []string
+						for _, nc := range nr.Cells {
+							texts = append(texts, nc.Text())
+						}
+						cell.Paragraphs = append(cell.Paragraphs, Paragraph{Runs: []Run{{Text: strings.Join(texts, " ")}}})
+					}
+				}
+				continue
+			}
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				return t, nil
+			}
+			depth--
+			switch el.Name.Local {
+			case "tr":
+				row = nil
+			case "tc":
+				cell = nil
+			}
+		}
+	}
+}
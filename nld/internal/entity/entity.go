@@ -0,0 +1,194 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+// Entity is a party or other participant listed in metadata.entities
+type Entity struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Address string `json:"address,omitempty"`
+	// KeyIDs are the IDs of the signing keys registered for the entity
+	KeyIDs []string `json:"keyIds,omitempty"`
+}
+
+// roles lists the entity roles accepted for each document type. Types not
+// listed here accept any role.
+var roles = map[string][]string{
+	"contract":  {"Service Provider", "Client", "Contractor", "Party", "Guarantor", "Witness"},
+	"agreement": {"Party", "Guarantor", "Witness"},
+	"receipt":   {"Seller", "Buyer", "Payer", "Payee"},
+	"nda":       {"Disclosing Party", "Receiving Party", "Party", "Witness"},
+}
+
+// Roles returns the roles accepted for a document type, or nil if any role
+// is accepted
+func Roles(docType string) []string {
+	return roles[strings.ToLower(docType)]
+}
+
+// ValidateRole checks that role is allowed for the document type and
+// returns the role in its canonical spelling
+func ValidateRole(docType, role string) (string, error) {
+	if strings.TrimSpace(role) == "" {
+		return "", fmt.Errorf("entity role is required")
+	}
+
+	allowed := Roles(docType)
+	if allowed == nil {
+		return role, nil
+	}
+	for _, r := range allowed {
+		if strings.EqualFold(r, role) {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("invalid role %q for %s documents (expected one of: %s)", role, docType, strings.Join(allowed, ", "))
+}
+
+// List returns the entities declared in the document
+func List(doc document.Document) []Entity {
+	meta := doc.Metadata()
+	if meta == nil {
+		return nil
+	}
+	raw, _ := meta["entities"].([]interface{})
+
+	entities := make([]Entity, 0, len(raw))
+	for _, r := range raw {
+		obj, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var keyIDs []string
+		list, _ := obj["keyIds"].([]interface{})
+		for _, id := range list {
+			if s, ok := id.(string); ok {
+				keyIDs = append(keyIDs, s)
+			}
+		}
+		entities = append(entities, Entity{
+			ID:      document.String(obj, "id"),
+			Name:    document.String(obj, "name"),
+			Role:    document.String(obj, "role"),
+			Email:   document.String(obj, "email"),
+			Phone:   document.String(obj, "phone"),
+			Address: document.String(obj, "address"),
+			KeyIDs:  keyIDs,
+		})
+	}
+	return entities
+}
+
+// Add appends e to the document's entities. The role is validated against
+// the document type unless anyRole is set.
+func Add(doc document.Document, e Entity, anyRole bool) error {
+	if e.ID == "" {
+		return fmt.Errorf("entity id is required")
+	}
+	if e.Name == "" {
+		return fmt.Errorf("entity name is required")
+	}
+	if !anyRole {
+		role, err := ValidateRole(doc.Type(), e.Role)
+		if err != nil {
+			return err
+		}
+		e.Role = role
+	} else if e.Role == "" {
+		return fmt.Errorf("entity role is required")
+	}
+
+	for _, existing := range List(doc) {
+		if existing.ID == e.ID {
+			return fmt.Errorf("entity already exists: %s", e.ID)
+		}
+	}
+
+	obj := map[string]interface{}{
+		"id":   e.ID,
+		"name": e.Name,
+		"role": e.Role,
+	}
+	if e.Email != "" {
+		obj["email"] = e.Email
+	}
+	if e.Phone != "" {
+		obj["phone"] = e.Phone
+	}
+	if e.Address != "" {
+		obj["address"] = e.Address
+	}
+	if len(e.KeyIDs) > 0 {
+		ids := make([]interface{}, len(e.KeyIDs))
+		for i, id := range e.KeyIDs {
+			ids[i] = id
+		}
+		obj["keyIds"] = ids
+	}
+
+	document.Append(doc.Object("metadata", true), "entities", obj)
+	return nil
+}
+
+// References returns the places in the document that refer to an entity
+// by ID, such as signatures and attestations
+func References(doc document.Document, id string) []string {
+	var refs []string
+	verification := doc.Verification(false)
+	if verification == nil {
+		return nil
+	}
+	for _, key := range []string{"signatures", "attestations"} {
+		field := "signerId"
+		if key == "attestations" {
+			field = "attesterId"
+		}
+		list, _ := verification[key].([]interface{})
+		for i, item := range list {
+			if obj, ok := item.(map[string]interface{}); ok && document.String(obj, field) == id {
+				refs = append(refs, fmt.Sprintf("verification.%s[%d]", key, i))
+			}
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// Remove deletes the entity with the given ID. Entities still referenced
+// elsewhere in the document are only removed when force is set.
+func Remove(doc document.Document, id string, force bool) error {
+	meta := doc.Metadata()
+	if meta == nil {
+		return fmt.Errorf("entity not found: %s", id)
+	}
+	raw, _ := meta["entities"].([]interface{})
+
+	kept := make([]interface{}, 0, len(raw))
+	found := false
+	for _, r := range raw {
+		if obj, ok := r.(map[string]interface{}); ok && document.String(obj, "id") == id {
+			found = true
+			continue
+		}
+		kept = append(kept, r)
+	}
+	if !found {
+		return fmt.Errorf("entity not found: %s", id)
+	}
+
+	if refs := References(doc, id); len(refs) > 0 && !force {
+		return fmt.Errorf("entity %s is referenced by %s (use --force to remove anyway)", id, strings.Join(refs, ", "))
+	}
+
+	meta["entities"] = kept
+	return nil
+}
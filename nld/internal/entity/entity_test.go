@@ -0,0 +1,124 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const testDocument = `{
+  "metadata": {"type": "receipt", "entities": [{"id": "seller", "name": "Tech Store Inc.", "role": "Seller"}]},
+  "content": {"sections": []},
+  "verification": {"signatures": [{"signerId": "seller", "date": "2025-06-27T14:30:00Z", "value": "sig"}]}
+}`
+
+func parse(t *testing.T) document.Document {
+	t.Helper()
+	doc, err := document.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	return doc
+}
+
+func TestAdd(t *testing.T) {
+	testCases := []struct {
+		name        string
+		entity      Entity
+		anyRole     bool
+		expectError bool
+		expectRole  string
+	}{
+		{
+			name:       "Valid Role Canonicalized",
+			entity:     Entity{ID: "buyer", Name: "John Doe", Role: "buyer", Email: "john@example.com"},
+			expectRole: "Buyer",
+		},
+		{
+			name:        "Invalid Role",
+			entity:      Entity{ID: "buyer", Name: "John Doe", Role: "Client"},
+			expectError: true,
+		},
+		{
+			name:       "Any Role",
+			entity:     Entity{ID: "buyer", Name: "John Doe", Role: "Client"},
+			anyRole:    true,
+			expectRole: "Client",
+		},
+		{
+			name:       "Registered Key",
+			entity:     Entity{ID: "buyer", Name: "John Doe", Role: "Buyer", KeyIDs: []string{"sha256:d8817ae66bf979a7"}},
+			expectRole: "Buyer",
+		},
+		{
+			name:        "Duplicate ID",
+			entity:      Entity{ID: "seller", Name: "Other", Role: "Seller"},
+			expectError: true,
+		},
+		{
+			name:        "Missing Name",
+			entity:      Entity{ID: "buyer", Role: "Buyer"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := parse(t)
+			err := Add(doc, tc.entity, tc.anyRole)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected success, got error: %v", err)
+			}
+
+			entities := List(doc)
+			if len(entities) != 2 {
+				t.Fatalf("Expected 2 entities, got %d", len(entities))
+			}
+			if entities[1].Role != tc.expectRole {
+				t.Errorf("Expected role %s, got %s", tc.expectRole, entities[1].Role)
+			}
+			if strings.Join(entities[1].KeyIDs, ",") != strings.Join(tc.entity.KeyIDs, ",") {
+				t.Errorf("Expected keys %v, got %v", tc.entity.KeyIDs, entities[1].KeyIDs)
+			}
+			if entities[1].Email != tc.entity.Email {
+				t.Errorf("Expected email %q, got %q", tc.entity.Email, entities[1].Email)
+			}
+		})
+	}
+}
+
+func TestRemove(t *testing.T) {
+	doc := parse(t)
+	if err := Add(doc, Entity{ID: "buyer", Name: "John Doe", Role: "Buyer"}, false); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	if err := Remove(doc, "missing", false); err == nil {
+		t.Errorf("Expected error removing unknown entity")
+	}
+	if err := Remove(doc, "seller", false); err == nil {
+		t.Errorf("Expected error removing a signer without force")
+	}
+	if err := Remove(doc, "buyer", false); err != nil {
+		t.Errorf("Remove failed: %v", err)
+	}
+	if err := Remove(doc, "seller", true); err != nil {
+		t.Errorf("Forced remove failed: %v", err)
+	}
+	if n := len(List(doc)); n != 0 {
+		t.Errorf("Expected no entities, got %d", n)
+	}
+}
+
+func TestValidateRoleUnknownType(t *testing.T) {
+	if _, err := ValidateRole("invoice", "Anything"); err != nil {
+		t.Errorf("Expected unknown document types to accept any role, got %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// FormatVersion is the current version of the encrypted envelope format
+	FormatVersion = 1
+
+	// Algorithm is the content encryption algorithm used for envelopes
+	Algorithm = "AES-256-GCM"
+
+	// KDFScrypt derives the content key from a passphrase
+	KDFScrypt = "scrypt"
+
+	// KDFKeyfile derives the content key from the SHA-256 of a keyfile
+	KDFKeyfile = "keyfile-sha256"
+
+	// PassphraseEnv is the environment variable consulted for a passphrase
+	PassphraseEnv = "NLD_PASSPHRASE"
+
+	// scrypt parameters recommended for interactive use
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	keySize  = 32
+	saltSize = 16
+)
+
+// ErrNoKey is returned when an encrypted document is read without a key
+var ErrNoKey = errors.New("document is encrypted; provide --keyfile, --passphrase-file or set " + PassphraseEnv)
+
+// ErrDecrypt is returned when a document cannot be decrypted with the key
+// given
+var ErrDecrypt = errors.New("decryption failed: wrong key or corrupted document")
+
+// Key holds the secret material used to encrypt or decrypt a document.
+// Exactly one of Passphrase or Keyfile should be set.
+type Key struct {
+	Passphrase []byte
+	Keyfile    []byte
+}
+
+// Envelope is the on-disk format of an encrypted NLD document
+type Envelope struct {
+	Encrypted  Header `json:"nldEncrypted"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// Header describes how the envelope ciphertext was produced
+type Header struct {
+	Version   int    `json:"version"`
+	Algorithm string `json:"alg"`
+	KDF       string `json:"kdf"`
+	Salt      string `json:"salt,omitempty"`
+	Nonce     string `json:"nonce"`
+}
+
+// LoadKey builds a key from a keyfile path, a passphrase file path, or the
+// NLD_PASSPHRASE environment variable, in that order of preference. It
+// returns nil when no key material is configured.
+func LoadKey(keyfilePath, passphrasePath string) (*Key, error) {
+	if keyfilePath != "" {
+		data, err := os.ReadFile(keyfilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keyfile: %w", err)
+		}
+		if len(data) == 0 {
+			return nil, fmt.Errorf("keyfile is empty: %s", keyfilePath)
+		}
+		return &Key{Keyfile: data}, nil
+	}
+
+	if passphrasePath != "" {
+		data, err := os.ReadFile(passphrasePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		passphrase := strings.TrimRight(string(data), "\r\n")
+		if passphrase == "" {
+			return nil, fmt.Errorf("passphrase file is empty: %s", passphrasePath)
+		}
+		return &Key{Passphrase: []byte(passphrase)}, nil
+	}
+
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return &Key{Passphrase: []byte(passphrase)}, nil
+	}
+
+	return nil, nil
+}
+
+// IsEncrypted reports whether data is an encrypted envelope
+func IsEncrypted(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return false
+	}
+
+	var probe struct {
+		Encrypted *Header `json:"nldEncrypted"`
+	}
+	if err := json.Unmarshal(trimmed, &probe); err != nil {
+		return false
+	}
+	return probe.Encrypted != nil
+}
+
+// Encrypt seals plaintext into an envelope using the given key
+func Encrypt(plaintext []byte, key *Key) ([]byte, error) {
+	if key == nil {
+		return nil, errors.New("no encryption key provided")
+	}
+
+	header := Header{
+		Version:   FormatVersion,
+		Algorithm: Algorithm,
+	}
+
+	var salt []byte
+	if key.Keyfile == nil {
+		header.KDF = KDFScrypt
+		salt = make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		header.Salt = base64.StdEncoding.EncodeToString(salt)
+	} else {
+		header.KDF = KDFKeyfile
+	}
+
+	contentKey, err := deriveKey(key, header.KDF, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	header.Nonce = base64.StdEncoding.EncodeToString(nonce)
+
+	// Bind the header to the ciphertext so it cannot be altered
+	aad, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode envelope header: %w", err)
+	}
+
+	env := Envelope{
+		Encrypted:  header,
+		Ciphertext: base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, aad)),
+	}
+
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// Decrypt opens an envelope produced by Encrypt and returns the plaintext
+func Decrypt(data []byte, key *Key) ([]byte, error) {
+	if key == nil {
+		return nil, ErrNoKey
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+
+	header := env.Encrypted
+	if header.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported envelope version: %d", header.Version)
+	}
+	if header.Algorithm != Algorithm {
+		return nil, fmt.Errorf("unsupported envelope algorithm: %s", header.Algorithm)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(header.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope salt: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(header.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope nonce: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope ciphertext: %w", err)
+	}
+
+	contentKey, err := deriveKey(key, header.KDF, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid envelope nonce length: %d", len(nonce))
+	}
+
+	aad, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode envelope header: %w", err)
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+// deriveKey turns the user supplied key material into an AES-256 key
+func deriveKey(key *Key, kdf string, salt []byte) ([]byte, error) {
+	switch kdf {
+	case KDFScrypt:
+		if key.Passphrase == nil {
+			return nil, errors.New("document was encrypted with a passphrase; provide --passphrase-file or set " + PassphraseEnv)
+		}
+		derived, err := scrypt.Key(key.Passphrase, salt, scryptN, scryptR, scryptP, keySize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		return derived, nil
+	case KDFKeyfile:
+		if key.Keyfile == nil {
+			return nil, errors.New("document was encrypted with a keyfile; provide --keyfile")
+		}
+		sum := sha256.Sum256(key.Keyfile)
+		return sum[:], nil
+	default:
+		return nil, fmt.Errorf("unsupported key derivation: %s", kdf)
+	}
+}
+
+// newGCM creates an AES-GCM AEAD for the given key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,110 @@
+package envelope
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	plaintext := []byte(`{"metadata":{"type":"contract"},"content":{"sections":[]}}`)
+
+	// Define test cases
+	testCases := []struct {
+		name       string
+		encryptKey *Key
+		decryptKey *Key
+		expectOK   bool
+	}{
+		{
+			name:       "Passphrase Roundtrip",
+			encryptKey: &Key{Passphrase: []byte("correct horse")},
+			decryptKey: &Key{Passphrase: []byte("correct horse")},
+			expectOK:   true,
+		},
+		{
+			name:       "Keyfile Roundtrip",
+			encryptKey: &Key{Keyfile: []byte("0123456789abcdef0123456789abcdef")},
+			decryptKey: &Key{Keyfile: []byte("0123456789abcdef0123456789abcdef")},
+			expectOK:   true,
+		},
+		{
+			name:       "Wrong Passphrase",
+			encryptKey: &Key{Passphrase: []byte("correct horse")},
+			decryptKey: &Key{Passphrase: []byte("battery staple")},
+			expectOK:   false,
+		},
+		{
+			name:       "Keyfile Document With Passphrase",
+			encryptKey: &Key{Keyfile: []byte("secret")},
+			decryptKey: &Key{Passphrase: []byte("secret")},
+			expectOK:   false,
+		},
+		{
+			name:       "Missing Key",
+			encryptKey: &Key{Passphrase: []byte("correct horse")},
+			decryptKey: nil,
+			expectOK:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sealed, err := Encrypt(plaintext, tc.encryptKey)
+			if err != nil {
+				t.Fatalf("Encrypt failed: %v", err)
+			}
+			if !IsEncrypted(sealed) {
+				t.Fatalf("Expected envelope to be detected as encrypted")
+			}
+			if bytes.Contains(sealed, []byte("contract")) {
+				t.Errorf("Envelope leaks plaintext: %s", sealed)
+			}
+
+			opened, err := Decrypt(sealed, tc.decryptKey)
+			if tc.expectOK {
+				if err != nil {
+					t.Fatalf("Expected success, got error: %v", err)
+				}
+				if !bytes.Equal(opened, plaintext) {
+					t.Errorf("Expected %s, got %s", plaintext, opened)
+				}
+			} else if err == nil {
+				t.Fatalf("Expected error, got success")
+			}
+		})
+	}
+}
+
+func TestDecryptTamperedHeader(t *testing.T) {
+	key := &Key{Keyfile: []byte("secret")}
+	sealed, err := Encrypt([]byte(`{"a":1}`), key)
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	tampered := bytes.Replace(sealed, []byte(`"version": 1`), []byte(`"version": 2`), 1)
+	if _, err := Decrypt(tampered, key); err == nil {
+		t.Errorf("Expected tampered envelope to fail decryption")
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	testCases := []struct {
+		name   string
+		data   string
+		expect bool
+	}{
+		{name: "Plain Document", data: `{"metadata":{}}`, expect: false},
+		{name: "Envelope", data: `{"nldEncrypted":{"version":1},"ciphertext":""}`, expect: true},
+		{name: "Not JSON", data: `not json`, expect: false},
+		{name: "Empty", data: ``, expect: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsEncrypted([]byte(tc.data)); got != tc.expect {
+				t.Errorf("Expected %v, got %v", tc.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package esign
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DocuSign is the name of the DocuSign provider
+	DocuSign = "docusign"
+	// DocuSignTokenEnv holds an OAuth access token of DocuSign
+	DocuSignTokenEnv = "DOCUSIGN_ACCESS_TOKEN"
+	// DocuSignAccountEnv holds the API account ID
+	DocuSignAccountEnv = "DOCUSIGN_ACCOUNT_ID"
+	// DocuSignURLEnv replaces the base URL of the eSignature REST API, such
+	// as with https://na3.docusign.net/restapi in production
+	DocuSignURLEnv = "DOCUSIGN_BASE_URL"
+	// DocuSignDefaultURL is the base URL of the developer environment
+	DocuSignDefaultURL = "https://demo.docusign.net/restapi"
+)
+
+func init() {
+	register(DocuSign, func() (Provider, error) {
+		d := &docuSign{
+			BaseURL: os.Getenv(DocuSignURLEnv),
+			Account: os.Getenv(DocuSignAccountEnv),
+			Token:   os.Getenv(DocuSignTokenEnv),
+			Client:  &http.Client{Timeout: 30 * time.Second},
+		}
+		if d.BaseURL == "" {
+			d.BaseURL = DocuSignDefaultURL
+		}
+		if d.Token == "" || d.Account == "" {
+			return nil, fmt.Errorf("set %s and %s to use DocuSign", DocuSignTokenEnv, DocuSignAccountEnv)
+		}
+		return d, nil
+	})
+}
+
+// docuSign sends envelopes with the DocuSign eSignature REST API
+type docuSign struct {
+	BaseURL string
+	Account string
+	Token   string
+	Client  *http.Client
+}
+
+// docuSignSigner is a signer recipient of an envelope
+type docuSignSigner struct {
+	Email          string `json:"email"`
+	Name           string `json:"name"`
+	RecipientID    string `json:"recipientId"`
+	RoutingOrder   string `json:"routingOrder"`
+	Status         string `json:"status,omitempty"`
+	SignedDateTime string `json:"signedDateTime,omitempty"`
+}
+
+// Name returns "docusign"
+func (d *docuSign) Name() string {
+	return DocuSign
+}
+
+// Send creates an envelope with status "sent", so that DocuSign emails
+// the signers, who sign one after the other
+func (d *docuSign) Send(ctx context.Context, req *Request) (string, error) {
+	var signers []docuSignSigner
+	for i, s := range req.Signers {
+		n := strconv.Itoa(i + 1)
+		signers = append(signers, docuSignSigner{Email: s.Email, Name: s.Name, RecipientID: n, RoutingOrder: n})
+	}
+	ext := strings.TrimPrefix(path.Ext(req.FileName), ".")
+	body, err := json.Marshal(map[string]interface{}{
+		"emailSubject": req.Title,
+		"emailBlurb":   req.Message,
+		"status":       "sent",
+		"documents": []map[string]string{{
+			"documentId":     "1",
+			"name":           req.FileName,
+			"fileExtension":  ext,
+			"documentBase64": base64.StdEncoding.EncodeToString(req.Content),
+		}},
+		"recipients": map[string]interface{}{"signers": signers},
+	})
+	if err != nil {
+		return "", err
+	}
+	r, err := d.request(ctx, http.MethodPost, "envelopes", body)
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		EnvelopeID string `json:"envelopeId"`
+	}
+	if err := do(d.Client, r, &out); err != nil {
+		return "", fmt.Errorf("failed to create DocuSign envelope: %w", err)
+	}
+	return out.EnvelopeID, nil
+}
+
+// Status returns the status of an envelope and its signer recipients
+func (d *docuSign) Status(ctx context.Context, id string) (*Status, error) {
+	r, err := d.request(ctx, http.MethodGet, "envelopes/"+url.PathEscape(id)+"?include=recipients", nil)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Status     string `json:"status"`
+		Recipients struct {
+			Signers []docuSignSigner `json:"signers"`
+		} `json:"recipients"`
+	}
+	if err := do(d.Client, r, &out); err != nil {
+		return nil, fmt.Errorf("failed to get DocuSign envelope %s: %w", id, err)
+	}
+
+	status := &Status{Status: Sent}
+	switch out.Status {
+	case "completed":
+		status.Status = Completed
+	case "declined":
+		status.Status = Declined
+	case "voided":
+		status.Status = Voided
+	}
+	signers := out.Recipients.Signers
+	sort.SliceStable(signers, func(i, j int) bool {
+		a, _ := strconv.Atoi(signers[i].RecipientID)
+		b, _ := strconv.Atoi(signers[j].RecipientID)
+		return a < b
+	})
+	for _, s := range signers {
+		signer := Signer{Name: s.Name, Email: s.Email, Status: Sent}
+		switch s.Status {
+		case "signed", "completed":
+			signer.Status = Signed
+			if t, err := time.Parse(time.RFC3339, s.SignedDateTime); err == nil {
+				signer.Signed = &t
+			}
+		case "declined":
+			signer.Status = Declined
+		}
+		status.Signers = append(status.Signers, signer)
+	}
+	return status, nil
+}
+
+// request returns a request of a path of the account
+func (d *docuSign) request(ctx context.Context, method, rel string, body []byte) (*http.Request, error) {
+	u := strings.TrimSuffix(d.BaseURL, "/") + "/v2.1/accounts/" + url.PathEscape(d.Account) + "/" + rel
+	r, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Authorization", "Bearer "+d.Token)
+	r.Header.Set("Accept", "application/json")
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	return r, nil
+}
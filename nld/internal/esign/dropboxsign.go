@@ -0,0 +1,172 @@
+package esign
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DropboxSign is the name of the Dropbox Sign provider
+	DropboxSign = "dropbox-sign"
+	// DropboxSignKeyEnv holds an API key of Dropbox Sign
+	DropboxSignKeyEnv = "DROPBOX_SIGN_API_KEY"
+	// DropboxSignURLEnv replaces the base URL of the API
+	DropboxSignURLEnv = "DROPBOX_SIGN_BASE_URL"
+	// DropboxSignDefaultURL is the base URL of the API
+	DropboxSignDefaultURL = "https://api.hellosign.com/v3"
+	// DropboxSignTestEnv sends test requests, which are free and not
+	// legally binding, when set to anything but the empty string or 0
+	DropboxSignTestEnv = "DROPBOX_SIGN_TEST_MODE"
+)
+
+func init() {
+	register(DropboxSign, func() (Provider, error) {
+		test := os.Getenv(DropboxSignTestEnv)
+		d := &dropboxSign{
+			BaseURL: os.Getenv(DropboxSignURLEnv),
+			Key:     os.Getenv(DropboxSignKeyEnv),
+			Test:    test != "" && test != "0",
+			Client:  &http.Client{Timeout: 30 * time.Second},
+		}
+		if d.BaseURL == "" {
+			d.BaseURL = DropboxSignDefaultURL
+		}
+		if d.Key == "" {
+			return nil, fmt.Errorf("set %s to use Dropbox Sign", DropboxSignKeyEnv)
+		}
+		return d, nil
+	})
+}
+
+// dropboxSign sends signature requests with the Dropbox Sign API
+type dropboxSign struct {
+	BaseURL string
+	Key     string
+	Test    bool
+	Client  *http.Client
+}
+
+// Name returns "dropbox-sign"
+func (d *dropboxSign) Name() string {
+	return DropboxSign
+}
+
+// Send sends a signature request, which the signers sign in order
+func (d *dropboxSign) Send(ctx context.Context, req *Request) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("title", req.Title)
+	form.WriteField("subject", req.Title)
+	if req.Message != "" {
+		form.WriteField("message", req.Message)
+	}
+	if d.Test {
+		form.WriteField("test_mode", "1")
+	}
+	for i, s := range req.Signers {
+		prefix := fmt.Sprintf("signers[%d]", i)
+		form.WriteField(prefix+"[email_address]", s.Email)
+		form.WriteField(prefix+"[name]", s.Name)
+		form.WriteField(prefix+"[order]", strconv.Itoa(i))
+	}
+	file, err := form.CreateFormFile("files[0]", req.FileName)
+	if err != nil {
+		return "", err
+	}
+	file.Write(req.Content)
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	r, err := d.request(ctx, http.MethodPost, "signature_request/send", &body)
+	if err != nil {
+		return "", err
+	}
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	var out struct {
+		SignatureRequest struct {
+			ID string `json:"signature_request_id"`
+		} `json:"signature_request"`
+	}
+	if err := do(d.Client, r, &out); err != nil {
+		return "", fmt.Errorf("failed to send Dropbox Sign request: %w", err)
+	}
+	return out.SignatureRequest.ID, nil
+}
+
+// Status returns the status of a signature request and its signatures
+func (d *dropboxSign) Status(ctx context.Context, id string) (*Status, error) {
+	r, err := d.request(ctx, http.MethodGet, "signature_request/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		SignatureRequest struct {
+			IsComplete bool `json:"is_complete"`
+			IsDeclined bool `json:"is_declined"`
+			Signatures []struct {
+				Email    string `json:"signer_email_address"`
+				Name     string `json:"signer_name"`
+				Order    int    `json:"order"`
+				Status   string `json:"status_code"`
+				SignedAt *int64 `json:"signed_at"`
+			} `json:"signatures"`
+		} `json:"signature_request"`
+	}
+	if err := do(d.Client, r, &out); err != nil {
+		return nil, fmt.Errorf("failed to get Dropbox Sign request %s: %w", id, err)
+	}
+
+	sr := out.SignatureRequest
+	status := &Status{Status: Sent}
+	switch {
+	case sr.IsDeclined:
+		status.Status = Declined
+	case sr.IsComplete:
+		status.Status = Completed
+	}
+	sort.SliceStable(sr.Signatures, func(i, j int) bool { return sr.Signatures[i].Order < sr.Signatures[j].Order })
+	for _, s := range sr.Signatures {
+		signer := Signer{Name: s.Name, Email: s.Email, Status: Sent}
+		switch s.Status {
+		case "signed":
+			signer.Status = Signed
+			if s.SignedAt != nil {
+				t := time.Unix(*s.SignedAt, 0).UTC()
+				signer.Signed = &t
+			}
+		case "declined":
+			signer.Status = Declined
+		}
+		status.Signers = append(status.Signers, signer)
+	}
+	return status, nil
+}
+
+// request returns a request of a path of the API, authenticated with the
+// API key
+func (d *dropboxSign) request(ctx context.Context, method, rel string, body *bytes.Buffer) (*http.Request, error) {
+	u := strings.TrimSuffix(d.BaseURL, "/") + "/" + rel
+	var r *http.Request
+	var err error
+	if body != nil {
+		r, err = http.NewRequestWithContext(ctx, method, u, body)
+	} else {
+		r, err = http.NewRequestWithContext(ctx, method, u, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.SetBasicAuth(d.Key, "")
+	r.Header.Set("Accept", "application/json")
+	return r, nil
+}
@@ -0,0 +1,248 @@
+// Package esign sends documents out for signature with an e-signature
+// provider, such as DocuSign or Dropbox Sign, and brings the signatures
+// back into the verification object of the document once they are
+// collected. The envelope sent is recorded in verification.esign.
+package esign
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+// RecordKey is the key of the record inside the verification object
+const RecordKey = "esign"
+
+// Statuses of an envelope and its signers
+const (
+	// Sent envelopes wait for signers; Sent signers have not signed yet
+	Sent = "sent"
+	// Completed envelopes are signed by every signer; Signed signers have
+	// signed
+	Completed = "completed"
+	Signed    = "signed"
+	// Declined envelopes and signers refused to sign
+	Declined = "declined"
+	// Voided envelopes were cancelled by the sender
+	Voided = "voided"
+)
+
+// Provider is an e-signature service
+type Provider interface {
+	// Name is the name of the provider, such as "docusign"
+	Name() string
+	// Send creates an envelope with the rendered document and sends it
+	// to the signers, returning its ID
+	Send(ctx context.Context, req *Request) (string, error)
+	// Status returns the status of an envelope and of its signers, who are
+	// in the order they were sent in
+	Status(ctx context.Context, id string) (*Status, error)
+}
+
+// Request is a document to send for signature
+type Request struct {
+	// Title is the subject of the envelope
+	Title string
+	// Message is sent to the signers with the document
+	Message string
+	// FileName is the name of the rendered document and Content its bytes
+	FileName string
+	Content  []byte
+	// Signers sign in order
+	Signers []Signer
+}
+
+// Signer is an entity that signs a document
+type Signer struct {
+	// ID is the ID of the entity
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	// Signed is when the signer signed
+	Signed *time.Time `json:"signed,omitempty"`
+}
+
+// Status is the status of an envelope at the provider
+type Status struct {
+	Status string
+	// Signers are the status of the signers, in the order of the request
+	Signers []Signer
+}
+
+// Record is an envelope sent for a document
+type Record struct {
+	Provider string    `json:"provider"`
+	Envelope string    `json:"envelope"`
+	Status   string    `json:"status"`
+	Sent     time.Time `json:"sent"`
+	// Synced is when the status was last brought in from the provider
+	Synced  *time.Time `json:"synced,omitempty"`
+	Signers []Signer   `json:"signers"`
+}
+
+// Done reports whether the envelope will not change anymore
+func (r *Record) Done() bool {
+	return r.Status == Completed || r.Status == Declined || r.Status == Voided
+}
+
+// factories create the providers by name
+var factories = map[string]func() (Provider, error){}
+
+// register makes a provider available to New
+func register(name string, factory func() (Provider, error)) {
+	factories[name] = factory
+}
+
+// Providers returns the names of the providers, sorted
+func Providers() []string {
+	var names []string
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns the provider of a name, configured from its environment
+// variables
+func New(name string) (Provider, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown e-signature provider %q (expected %s)", name, strings.Join(Providers(), " or "))
+	}
+	return factory()
+}
+
+// Signers returns the entities of doc that sign it: those of ids, in that
+// order, or when ids is empty every entity with an email address
+func Signers(doc document.Document, ids []string) ([]Signer, error) {
+	entities := map[string]Signer{}
+	var all []Signer
+	list, _ := doc.Metadata()["entities"].([]interface{})
+	for _, raw := range list {
+		e, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		s := Signer{ID: document.String(e, "id"), Name: document.String(e, "name"), Email: document.String(e, "email"), Status: Sent}
+		entities[s.ID] = s
+		if s.Email != "" {
+			all = append(all, s)
+		}
+	}
+	if len(ids) == 0 {
+		if len(all) == 0 {
+			return nil, fmt.Errorf("no entity has an email address to send the document to")
+		}
+		return all, nil
+	}
+
+	var signers []Signer
+	for _, id := range ids {
+		s, ok := entities[id]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("signer %s is not an entity", id)
+		case s.Email == "":
+			return nil, fmt.Errorf("signer %s has no email address", id)
+		}
+		signers = append(signers, s)
+	}
+	return signers, nil
+}
+
+// Load reads the record of doc, or returns nil when it has none
+func Load(doc document.Document) (*Record, error) {
+	verification := doc.Verification(false)
+	if verification == nil {
+		return nil, nil
+	}
+	raw, ok := verification[RecordKey]
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid e-signature record: %w", err)
+	}
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("invalid e-signature record: %w", err)
+	}
+	return &rec, nil
+}
+
+// Store writes rec into the verification object of doc
+func Store(doc document.Document, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode e-signature record: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to encode e-signature record: %w", err)
+	}
+	doc.Verification(true)[RecordKey] = raw
+	return nil
+}
+
+// Update applies the status of the envelope at the provider to rec, and
+// adds a signature to doc for each signer who signed since it was last
+// synced. It returns the number of signatures added.
+func Update(doc document.Document, rec *Record, status *Status, now time.Time) (int, error) {
+	if len(status.Signers) != len(rec.Signers) {
+		return 0, fmt.Errorf("envelope %s has %d signer(s), not %d", rec.Envelope, len(status.Signers), len(rec.Signers))
+	}
+	added := 0
+	for i, s := range status.Signers {
+		signer := &rec.Signers[i]
+		if s.Status == Signed && signer.Status != Signed {
+			signed := now.UTC().Truncate(time.Second)
+			if s.Signed != nil {
+				signed = *s.Signed
+			}
+			document.Append(doc.Verification(true), "signatures", map[string]interface{}{
+				"signerId": signer.ID,
+				"date":     signed.UTC().Format(time.RFC3339),
+				"value":    rec.Provider + ":" + rec.Envelope,
+			})
+			signer.Signed = &signed
+			added++
+		}
+		signer.Status = s.Status
+	}
+	synced := now.UTC().Truncate(time.Second)
+	rec.Status, rec.Synced = status.Status, &synced
+	return added, Store(doc, rec)
+}
+
+// do sends an HTTP request and decodes the JSON response into out
+func do(client *http.Client, req *http.Request, out interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package esign
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+func parse(t *testing.T, data string) document.Document {
+	t.Helper()
+	doc, err := document.Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	return doc
+}
+
+const contract = `{
+  "metadata": {"title": "Services Agreement", "entities": [
+    {"id": "acme", "name": "Acme Corp", "role": "provider", "email": "legal@acme.example"},
+    {"id": "witness", "name": "Witness", "role": "witness"},
+    {"id": "client", "name": "Client Ltd", "role": "client", "email": "ceo@client.example"}
+  ]},
+  "content": {"sections": []}
+}`
+
+func TestSigners(t *testing.T) {
+	doc := parse(t, contract)
+
+	signers, err := Signers(doc, nil)
+	if err != nil || len(signers) != 2 || signers[0].ID != "acme" || signers[1].ID != "client" {
+		t.Errorf("Signers = %+v, %v; expected acme and client", signers, err)
+	}
+	signers, err = Signers(doc, []string{"client", "acme"})
+	if err != nil || len(signers) != 2 || signers[0].ID != "client" || signers[0].Status != Sent {
+		t.Errorf("Signers(client, acme) = %+v, %v", signers, err)
+	}
+	if _, err := Signers(doc, []string{"witness"}); err == nil || !strings.Contains(err.Error(), "no email") {
+		t.Errorf("Signers(witness) error = %v, expected no email address", err)
+	}
+	if _, err := Signers(doc, []string{"nobody"}); err == nil {
+		t.Error("Signers(nobody) succeeded")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	doc := parse(t, contract)
+	signers, _ := Signers(doc, nil)
+	rec := &Record{Provider: DocuSign, Envelope: "env-1", Status: Sent, Signers: signers}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	signed := time.Date(2025, 2, 28, 9, 30, 0, 0, time.UTC)
+
+	added, err := Update(doc, rec, &Status{Status: Sent, Signers: []Signer{{Status: Signed, Signed: &signed}, {Status: Sent}}}, now)
+	if err != nil || added != 1 {
+		t.Fatalf("Update = %d, %v; expected 1 signature", added, err)
+	}
+	// Signatures already brought in are not added again
+	added, err = Update(doc, rec, &Status{Status: Completed, Signers: []Signer{{Status: Signed, Signed: &signed}, {Status: Signed}}}, now)
+	if err != nil || added != 1 {
+		t.Fatalf("Update = %d, %v; expected 1 more signature", added, err)
+	}
+	if _, err := Update(doc, rec, &Status{Status: Completed}, now); err == nil {
+		t.Error("Update with other signers succeeded")
+	}
+
+	got, err := Load(doc)
+	if err != nil || got == nil || got.Status != Completed || !got.Done() || got.Signers[1].Signed == nil {
+		t.Fatalf("Load = %+v, %v", got, err)
+	}
+	signatures, _ := doc.Verification(false)["signatures"].([]interface{})
+	if len(signatures) != 2 {
+		t.Fatalf("%d signatures, expected 2", len(signatures))
+	}
+	first := signatures[0].(map[string]interface{})
+	if first["signerId"] != "acme" || first["date"] != "2025-02-28T09:30:00Z" || first["value"] != "docusign:env-1" {
+		t.Errorf("first signature = %v", first)
+	}
+	if second := signatures[1].(map[string]interface{}); second["date"] != "2025-03-01T12:00:00Z" {
+		t.Errorf("signature without a date = %v, expected the time of the sync", second)
+	}
+}
+
+func TestDocuSign(t *testing.T) {
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2.1/accounts/acct/envelopes":
+			json.NewDecoder(r.Body).Decode(&sent)
+			w.Write([]byte(`{"envelopeId": "env-1", "status": "sent"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2.1/accounts/acct/envelopes/env-1":
+			w.Write([]byte(`{"status": "completed", "recipients": {"signers": [
+				{"recipientId": "2", "email": "ceo@client.example", "status": "completed", "signedDateTime": "2025-03-02T10:00:00Z"},
+				{"recipientId": "1", "email": "legal@acme.example", "status": "completed", "signedDateTime": "2025-03-01T10:00:00Z"}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	d := &docuSign{BaseURL: server.URL, Account: "acct", Token: "token"}
+	testProvider(t, d, func() {
+		if sent["emailSubject"] != "Services Agreement" || sent["status"] != "sent" {
+			t.Errorf("envelope = %v", sent)
+		}
+		documents, _ := sent["documents"].([]interface{})
+		if len(documents) != 1 || documents[0].(map[string]interface{})["fileExtension"] != "html" {
+			t.Errorf("documents = %v, expected one HTML document", documents)
+		}
+	})
+}
+
+func TestDropboxSign(t *testing.T) {
+	var form map[string][]string
+	var file string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, _, _ := r.BasicAuth(); key != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/signature_request/send":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			form = r.MultipartForm.Value
+			if f, _, err := r.FormFile("files[0]"); err == nil {
+				data, _ := io.ReadAll(f)
+				file = string(data)
+			}
+			w.Write([]byte(`{"signature_request": {"signature_request_id": "env-1"}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/signature_request/env-1":
+			w.Write([]byte(`{"signature_request": {"is_complete": true, "signatures": [
+				{"signer_email_address": "ceo@client.example", "order": 1, "status_code": "signed", "signed_at": 1740909600},
+				{"signer_email_address": "legal@acme.example", "order": 0, "status_code": "signed", "signed_at": 1740823200}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	d := &dropboxSign{BaseURL: server.URL, Key: "key", Test: true}
+	testProvider(t, d, func() {
+		if form["title"][0] != "Services Agreement" || form["test_mode"][0] != "1" || form["signers[1][email_address]"][0] != "ceo@client.example" {
+			t.Errorf("form = %v", form)
+		}
+		if file != "<p>Services</p>" {
+			t.Errorf("file = %q", file)
+		}
+	})
+}
+
+// testProvider sends the contract with p and syncs the completed envelope,
+// calling check after sending
+func testProvider(t *testing.T, p Provider, check func()) {
+	t.Helper()
+	ctx := context.Background()
+	doc := parse(t, contract)
+	signers, _ := Signers(doc, nil)
+
+	id, err := p.Send(ctx, &Request{Title: "Services Agreement", FileName: "contract.html", Content: []byte("<p>Services</p>"), Signers: signers})
+	if err != nil || id != "env-1" {
+		t.Fatalf("Send = %q, %v", id, err)
+	}
+	check()
+
+	status, err := p.Status(ctx, id)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	rec := &Record{Provider: p.Name(), Envelope: id, Status: Sent, Signers: signers}
+	if added, err := Update(doc, rec, status, time.Now()); err != nil || added != 2 {
+		t.Fatalf("Update = %d, %v; expected 2 signatures", added, err)
+	}
+	if rec.Status != Completed || rec.Signers[0].Signed == nil || !rec.Signers[0].Signed.Equal(time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("record = %+v, expected acme to sign first on 2025-03-01", rec)
+	}
+
+	if _, err := p.Status(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Status of a missing envelope error = %v, expected 404", err)
+	}
+}
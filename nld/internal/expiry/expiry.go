@@ -0,0 +1,99 @@
+// Package expiry finds documents that expire, or whose notice period to
+// prevent renewal ends, within a number of days
+package expiry
+
+import (
+	"math"
+	"time"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Statuses of an entry
+const (
+	// Expiring documents expire within the window
+	Expiring = "expiring"
+	// Notice documents expire later, but notice must be given within the
+	// window
+	Notice = "notice"
+	// Expired documents have expired already
+	Expired = "expired"
+)
+
+// Options select the documents reported
+type Options struct {
+	// Within is the number of days ahead to look
+	Within int
+	// Expired includes the documents that have expired already
+	Expired bool
+	// Now is the time to look ahead from; zero means the current time
+	Now time.Time
+}
+
+// Entry is a document that needs attention
+type Entry struct {
+	// Document is the file or store ID of the document
+	Document string `json:"document"`
+	Title    string `json:"title,omitempty"`
+	Status   string `json:"status"`
+	// Expires is when the document expires: metadata.expires, or the end
+	// of its term
+	Expires time.Time `json:"expires"`
+	// Days is the number of days until it expires, negative once expired
+	Days int `json:"days"`
+	// NoticeBy is the last day to give notice, when the document has a
+	// notice period
+	NoticeBy *time.Time `json:"noticeBy,omitempty"`
+}
+
+// Check returns the entry of a document when it needs attention, or nil
+// when it is open-ended or expires after the window. The error is that of
+// invalid dates.
+func Check(name string, doc *nld.Document, opts Options) (*Entry, error) {
+	_, end, err := doc.Metadata.Period()
+	if err != nil || end.IsZero() {
+		return nil, err
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	horizon := now.AddDate(0, 0, opts.Within)
+
+	e := &Entry{Document: name, Title: doc.Metadata.Title, Expires: end, Days: days(now, end)}
+	if doc.Metadata.NoticePeriod != "" {
+		notice, err := nld.ParseDuration(doc.Metadata.NoticePeriod)
+		if err != nil {
+			return nil, err
+		}
+		by := subtract(notice, end)
+		e.NoticeBy = &by
+	}
+
+	switch {
+	case end.Before(now):
+		if !opts.Expired {
+			return nil, nil
+		}
+		e.Status = Expired
+	case !end.After(horizon):
+		e.Status = Expiring
+	case e.NoticeBy != nil && days(now, *e.NoticeBy) >= 0 && !e.NoticeBy.After(horizon):
+		e.Status = Notice
+	default:
+		return nil, nil
+	}
+	return e, nil
+}
+
+// days returns the whole days from now until t, counting part of a day as
+// a day
+func days(now, t time.Time) int {
+	return int(math.Ceil(t.Sub(now).Hours() / 24))
+}
+
+// subtract returns t moved back by the duration
+func subtract(d nld.Duration, t time.Time) time.Time {
+	t = t.AddDate(-d.Years, -d.Months, -(d.Weeks*7 + d.Days))
+	return t.Add(-(time.Duration(d.Hours)*time.Hour + time.Duration(d.Minutes)*time.Minute + time.Duration(d.Seconds)*time.Second))
+}
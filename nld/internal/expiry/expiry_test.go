@@ -0,0 +1,55 @@
+package expiry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		metadata nld.Metadata
+		opts     Options
+		status   string
+		days     int
+	}{
+		{"Open Ended", nld.Metadata{Effective: "2025-01-01"}, Options{Within: 30}, "", 0},
+		{"Expiring", nld.Metadata{Expires: "2025-06-20"}, Options{Within: 30}, Expiring, 19},
+		{"Term Ends", nld.Metadata{Effective: "2024-06-15", Term: "P1Y"}, Options{Within: 30}, Expiring, 14},
+		{"Later", nld.Metadata{Expires: "2025-09-01"}, Options{Within: 30}, "", 0},
+		{"Notice Due", nld.Metadata{Expires: "2025-09-01", NoticePeriod: "P3M"}, Options{Within: 30}, Notice, 92},
+		{"Expired", nld.Metadata{Expires: "2025-05-01"}, Options{Within: 30}, "", 0},
+		{"Expired Included", nld.Metadata{Expires: "2025-05-01"}, Options{Within: 30, Expired: true}, Expired, -31},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.Now = now
+			e, err := Check("contract.json", &nld.Document{Metadata: tc.metadata}, tc.opts)
+			if err != nil {
+				t.Fatalf("Check failed: %v", err)
+			}
+			if tc.status == "" {
+				if e != nil {
+					t.Errorf("Expected no entry, got %+v", e)
+				}
+				return
+			}
+			if e == nil || e.Status != tc.status || e.Days != tc.days {
+				t.Errorf("Expected %s in %d days, got %+v", tc.status, tc.days, e)
+			}
+		})
+	}
+
+	e, _ := Check("contract.json", &nld.Document{Metadata: nld.Metadata{Expires: "2025-09-01", NoticePeriod: "P3M"}}, Options{Within: 30, Now: now})
+	if e.NoticeBy == nil || e.NoticeBy.Format(nld.DateLayout) != "2025-06-01" {
+		t.Errorf("Expected notice by 2025-06-01, got %v", e.NoticeBy)
+	}
+
+	if _, err := Check("bad.json", &nld.Document{Metadata: nld.Metadata{Expires: "soon"}}, Options{Now: now}); err == nil {
+		t.Error("Expected an error for an invalid date")
+	}
+}
@@ -0,0 +1,204 @@
+package fill
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"gopkg.in/yaml.v3"
+)
+
+// placeholderPattern matches {{ name }} placeholders. Names are dotted paths
+// of letters, digits, underscores and hyphens.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// UnresolvedError lists placeholders that had no value
+type UnresolvedError struct {
+	Names []string
+}
+
+func (e *UnresolvedError) Error() string {
+	return fmt.Sprintf("unresolved placeholders: %s", strings.Join(e.Names, ", "))
+}
+
+// LoadValues reads placeholder values from a YAML or JSON file
+func LoadValues(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+
+	var values map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	default:
+		err = yaml.Unmarshal(data, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid values file %s: %w", path, err)
+	}
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
+
+// Lookup resolves a dotted name such as "party.buyer.name" in values. A
+// flat key containing dots takes precedence over nested lookup.
+func Lookup(values map[string]interface{}, name string) (string, bool) {
+	if v, ok := values[name]; ok {
+		return scalar(v)
+	}
+
+	var cur interface{} = values
+	for _, part := range strings.Split(name, ".") {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if cur, ok = obj[part]; !ok {
+			return "", false
+		}
+	}
+	return scalar(cur)
+}
+
+// scalar formats a value for substitution; objects and arrays cannot be
+// substituted into text
+func scalar(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case nil:
+		return "", false
+	case map[string]interface{}, []interface{}:
+		return "", false
+	case string:
+		return val, true
+	case time.Time:
+		// YAML decodes unquoted dates as timestamps; keep them as written
+		if val.Equal(val.Truncate(24 * time.Hour)) {
+			return val.Format("2006-01-02"), true
+		}
+		return val.Format(time.RFC3339), true
+	default:
+		return fmt.Sprint(val), true
+	}
+}
+
+// Placeholders returns the distinct placeholder names used in doc, sorted
+func Placeholders(doc document.Document) []string {
+	seen := map[string]bool{}
+	walkStrings(map[string]interface{}(doc), func(s string) string {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(s, -1) {
+			seen[m[1]] = true
+		}
+		return s
+	})
+	return sortedNames(seen)
+}
+
+// Apply substitutes placeholders in every string of doc except the
+// verification block. It returns an *UnresolvedError, leaving doc
+// partially filled, when any placeholder has no value.
+func Apply(doc document.Document, values map[string]interface{}) error {
+	missing := map[string]bool{}
+	walkStrings(map[string]interface{}(doc), func(s string) string {
+		return substitute(s, values, nil, missing)
+	})
+
+	if len(missing) > 0 {
+		return &UnresolvedError{Names: sortedNames(missing)}
+	}
+	return nil
+}
+
+// Expand substitutes placeholders in a single string, such as a file name
+// template. Values are passed through transform, when given, before they
+// are substituted. It returns an *UnresolvedError when any placeholder has
+// no value.
+func Expand(s string, values map[string]interface{}, transform func(string) string) (string, error) {
+	missing := map[string]bool{}
+	s = substitute(s, values, transform, missing)
+	if len(missing) > 0 {
+		return "", &UnresolvedError{Names: sortedNames(missing)}
+	}
+	return s, nil
+}
+
+// Match reports whether s could be the template string filled: the text
+// around its placeholders is the same, and each placeholder stands for any
+// text
+func Match(template, s string) bool {
+	locs := placeholderPattern.FindAllStringIndex(template, -1)
+	if len(locs) == 0 {
+		return template == s
+	}
+	var pattern strings.Builder
+	pattern.WriteString("^(?s)")
+	last := 0
+	for _, loc := range locs {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		pattern.WriteString(".*?")
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString("$")
+	re, err := regexp.Compile(pattern.String())
+	return err == nil && re.MatchString(s)
+}
+
+// substitute replaces the placeholders in s, recording those without a
+// value in missing
+func substitute(s string, values map[string]interface{}, transform func(string) string, missing map[string]bool) string {
+	return placeholderPattern.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		v, ok := Lookup(values, name)
+		if !ok {
+			missing[name] = true
+			return match
+		}
+		if transform != nil {
+			v = transform(v)
+		}
+		return v
+	})
+}
+
+// walkStrings replaces every string value below node with fn(value). The
+// verification block is skipped since it must not change when filling.
+func walkStrings(node interface{}, fn func(string) string) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for k, v := range n {
+			if k == "verification" {
+				continue
+			}
+			n[k] = walkStrings(v, fn)
+		}
+		return n
+	case []interface{}:
+		for i, v := range n {
+			n[i] = walkStrings(v, fn)
+		}
+		return n
+	case string:
+		return fn(n)
+	default:
+		return node
+	}
+}
+
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,156 @@
+package fill
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const testDocument = `{
+  "metadata": {"title": "Agreement with {{party.buyer.name}}"},
+  "content": {"sections": [
+    {"id": "parties", "title": "Parties", "content": "Between {{ party.seller.name }} and {{party.buyer.name}}, effective {{effective_date}}."},
+    {"id": "fees", "title": "Fees", "content": "Fee: {{fee}} {{currency}}"}
+  ]},
+  "verification": {"attestations": [{"statement": "{{not.a.placeholder}}"}]}
+}`
+
+func TestPlaceholders(t *testing.T) {
+	doc, err := document.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	names := Placeholders(doc)
+	expected := []string{"currency", "effective_date", "fee", "party.buyer.name", "party.seller.name"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected %s at %d, got %s", expected[i], i, names[i])
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	valuesPath := filepath.Join(dir, "values.yaml")
+	values := `party:
+  buyer:
+    name: XYZ Ltd
+  seller:
+    name: Acme Corporation
+effective_date: 2025-07-01
+fee: 1500
+`
+	if err := os.WriteFile(valuesPath, []byte(values), 0644); err != nil {
+		t.Fatalf("Failed to write values: %v", err)
+	}
+
+	loaded, err := LoadValues(valuesPath)
+	if err != nil {
+		t.Fatalf("LoadValues failed: %v", err)
+	}
+
+	doc, _ := document.Parse([]byte(testDocument))
+	err = Apply(doc, loaded)
+
+	var unresolved *UnresolvedError
+	if !errors.As(err, &unresolved) {
+		t.Fatalf("Expected unresolved error, got %v", err)
+	}
+	if len(unresolved.Names) != 1 || unresolved.Names[0] != "currency" {
+		t.Errorf("Expected only currency to be unresolved, got %v", unresolved.Names)
+	}
+
+	loaded["currency"] = "USD"
+	doc, _ = document.Parse([]byte(testDocument))
+	if err := Apply(doc, loaded); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if got := doc.Metadata()["title"]; got != "Agreement with XYZ Ltd" {
+		t.Errorf("Unexpected title: %v", got)
+	}
+	if got := doc.Sections()[0]["content"]; got != "Between Acme Corporation and XYZ Ltd, effective 2025-07-01." {
+		t.Errorf("Unexpected content: %v", got)
+	}
+	if got := doc.Sections()[1]["content"]; got != "Fee: 1500 USD" {
+		t.Errorf("Unexpected content: %v", got)
+	}
+	statement := doc.Verification(false)["attestations"].([]interface{})[0].(map[string]interface{})["statement"]
+	if statement != "{{not.a.placeholder}}" {
+		t.Errorf("Verification must not be filled, got %v", statement)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	values := map[string]interface{}{
+		"flat.key": "flat",
+		"nested":   map[string]interface{}{"key": "nested", "list": []interface{}{1}},
+	}
+
+	testCases := []struct {
+		name   string
+		expect string
+		ok     bool
+	}{
+		{name: "flat.key", expect: "flat", ok: true},
+		{name: "nested.key", expect: "nested", ok: true},
+		{name: "nested", ok: false},
+		{name: "nested.list", ok: false},
+		{name: "missing", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := Lookup(values, tc.name)
+			if ok != tc.ok || got != tc.expect {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tc.expect, tc.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	values := map[string]interface{}{"client": map[string]interface{}{"name": "Foo/Bar"}}
+	upper := func(s string) string { return strings.ToUpper(s) }
+
+	got, err := Expand("{{client.name}}-{{ client.name }}.json", values, upper)
+	if err != nil {
+		t.Fatalf("Failed to expand: %v", err)
+	}
+	if got != "FOO/BAR-FOO/BAR.json" {
+		t.Errorf("Expected transformed values, got %q", got)
+	}
+
+	_, err = Expand("{{client.id}}.json", values, nil)
+	var unresolved *UnresolvedError
+	if !errors.As(err, &unresolved) || len(unresolved.Names) != 1 || unresolved.Names[0] != "client.id" {
+		t.Errorf("Expected unresolved client.id, got %v", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	testCases := []struct {
+		template, s string
+		expect      bool
+	}{
+		{"Paid within 30 days.", "Paid within 30 days.", true},
+		{"Paid within 30 days.", "Paid within 60 days.", false},
+		{"Paid by {{ buyer }} within {{days}} days.", "Paid by Acme Inc. within 30 days.", true},
+		{"Paid by {{ buyer }} within {{days}} days.", "Paid by Acme within 30 business days, or later.", false},
+		{"{{ party }} (the \"Seller\")", "XYZ\nLtd (the \"Seller\")", true},
+	}
+
+	for _, tc := range testCases {
+		if got := Match(tc.template, tc.s); got != tc.expect {
+			t.Errorf("Match(%q, %q) = %v, expected %v", tc.template, tc.s, got, tc.expect)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package fuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/profile"
+	"github.com/colemalphrus/nld/internal/receipt"
+	"github.com/colemalphrus/nld/internal/render"
+	"github.com/colemalphrus/nld/internal/schema"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Kinds of findings
+const (
+	// Panic is a stage that panicked
+	Panic = "panic"
+	// Inconsistency is a property of the stages that does not hold, such
+	// as a valid document that does not parse
+	Inconsistency = "inconsistency"
+)
+
+// Finding is a problem Check found with a document
+type Finding struct {
+	Kind string `json:"kind"`
+	// Stage is the part of nld that failed, such as validate or render html
+	Stage   string `json:"stage"`
+	Message string `json:"message"`
+	// Stack is the stack trace of panics
+	Stack string `json:"stack,omitempty"`
+}
+
+// String returns a one-line summary of the finding
+func (f Finding) String() string {
+	return fmt.Sprintf("%s in %s: %s", f.Kind, f.Stage, f.Message)
+}
+
+// Check runs a document through the validator, the streaming validator,
+// the parser and, for documents that parse, the receipt and profile checks
+// and the renderers. It returns the stages that panicked and the
+// properties that do not hold: validating twice gives the same result, the
+// streaming validator agrees with the validator, and valid documents
+// parse.
+func Check(data []byte) []Finding {
+	var findings []Finding
+	stage := func(name string, fn func()) {
+		defer func() {
+			if r := recover(); r != nil {
+				findings = append(findings, Finding{Kind: Panic, Stage: name, Message: fmt.Sprint(r), Stack: string(debug.Stack())})
+			}
+		}()
+		fn()
+	}
+	inconsistent := func(name, format string, args ...interface{}) {
+		findings = append(findings, Finding{Kind: Inconsistency, Stage: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Documents that are not JSON are validated against the default
+	// schema, which reports where the syntax error is
+	var header struct {
+		Metadata struct {
+			Type interface{} `json:"type"`
+		} `json:"metadata"`
+	}
+	_ = json.Unmarshal(data, &header)
+	docType, _ := header.Metadata.Type.(string)
+	s, err := schema.Embedded(docType)
+	if err != nil {
+		s, err = schema.Embedded("")
+	}
+	if err != nil {
+		return []Finding{{Kind: Inconsistency, Stage: "schema", Message: err.Error()}}
+	}
+
+	var first, second, streamed *validator.ValidationResult
+	stage("validate", func() {
+		first, _ = s.Validate(data)
+		second, _ = s.Validate(data)
+	})
+	if first != nil && second != nil && !sameResult(first, second) {
+		inconsistent("validate", "validating twice gave different results: %s and %s", summary(first), summary(second))
+	}
+	stage("validate stream", func() {
+		streamed, _ = s.ValidateReader(bytes.NewReader(data))
+	})
+	if first != nil && streamed != nil && first.Valid != streamed.Valid {
+		inconsistent("validate stream", "the streaming validator disagrees: %s, but streamed %s", summary(first), summary(streamed))
+	}
+
+	var doc *nld.Document
+	var parseErr error
+	stage("parse", func() {
+		doc, parseErr = nld.Parse(data)
+	})
+	if first != nil && first.Valid && parseErr != nil {
+		inconsistent("parse", "valid document does not parse: %v", parseErr)
+	}
+	if doc == nil {
+		return findings
+	}
+
+	stage("receipt", func() {
+		_, _ = receipt.Check(doc, "")
+	})
+	stage("profile", func() {
+		if p, err := profile.Select(doc.Metadata.Jurisdiction, doc.Metadata.Type); err == nil && p != nil {
+			p.Check(doc)
+		}
+	})
+	for _, format := range render.Formats() {
+		stage("render "+format, func() {
+			_ = render.Render(io.Discard, doc, render.Options{Format: format})
+		})
+	}
+	return findings
+}
+
+// sameResult reports whether two validation results have the same errors
+// in the same order
+func sameResult(a, b *validator.ValidationResult) bool {
+	return a.Valid == b.Valid && summary(a) == summary(b)
+}
+
+// summary returns the validity and errors of a result
+func summary(r *validator.ValidationResult) string {
+	if r.Valid {
+		return "valid"
+	}
+	var messages []string
+	for _, e := range r.Errors {
+		messages = append(messages, fmt.Sprintf("%s %s (%d:%d)", e.Field, e.Message, e.Line, e.Column))
+	}
+	return "invalid: " + strings.Join(messages, "; ")
+}
+
+// Mutate returns a near-valid variant of a document: a copy with one to
+// three changes, such as a property removed, a value of another type or a
+// list item repeated. One variant in five also has its bytes changed, so
+// that it may no longer be JSON. Documents that are not JSON only have
+// their bytes changed.
+func Mutate(data []byte, r *rand.Rand) []byte {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return mutateBytes(data, r)
+	}
+
+	for i := r.Intn(3); i >= 0; i-- {
+		var locs []location
+		walk(doc, func(v interface{}) { doc = v }, nil, &locs)
+		locs[r.Intn(len(locs))].mutate(r)
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return mutateBytes(data, r)
+	}
+	if r.Intn(5) == 0 {
+		return mutateBytes(out, r)
+	}
+	return append(out, '\n')
+}
+
+// location is a value of a document and the means to change it
+type location struct {
+	value interface{}
+	set   func(interface{})
+	// remove removes the value from its object or list; it is nil for the
+	// whole document
+	remove func()
+}
+
+// walk appends the locations of v and the values within it to locs
+func walk(v interface{}, set func(interface{}), remove func(), locs *[]location) {
+	*locs = append(*locs, location{value: v, set: set, remove: remove})
+	switch v := v.(type) {
+	case map[string]interface{}:
+		// Keys are sorted, so that a seed always gives the same variants
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			key := key
+			walk(v[key], func(n interface{}) { v[key] = n }, func() { delete(v, key) }, locs)
+		}
+	case []interface{}:
+		for i, item := range v {
+			i := i
+			walk(item, func(n interface{}) { v[i] = n }, func() { set(append(v[:i:i], v[i+1:]...)) }, locs)
+		}
+	}
+}
+
+// mutate changes the value at l
+func (l location) mutate(r *rand.Rand) {
+	switch r.Intn(6) {
+	case 0:
+		if l.remove != nil {
+			l.remove()
+			return
+		}
+	case 1:
+		// Repeated items, such as sections, share their identifiers
+		if list, ok := l.value.([]interface{}); ok && len(list) > 0 {
+			l.set(append(list, list[r.Intn(len(list))]))
+			return
+		}
+	case 2:
+		if list, ok := l.value.([]interface{}); ok {
+			l.set(list[:0])
+			return
+		}
+	case 3:
+		if obj, ok := l.value.(map[string]interface{}); ok {
+			obj[edgeStrings[r.Intn(len(edgeStrings))]] = edgeValue(r)
+			return
+		}
+	case 4:
+		// Deeply nested values
+		v := l.value
+		for i := 0; i < 64; i++ {
+			v = []interface{}{v}
+		}
+		l.set(v)
+		return
+	}
+	l.set(edgeValue(r))
+}
+
+// edgeStrings are strings at the edges of what nld expects
+var edgeStrings = []string{
+	"",
+	" ",
+	"P",
+	"PT",
+	"P0D",
+	"P99999999999Y",
+	"0000-00-00",
+	"2024-02-30",
+	"9999-12-31T23:59:59+14:00",
+	"2024-01-01T00:00:00",
+	"Mars/Olympus_Mons",
+	"-0",
+	"1e999",
+	"0.000000000000000000001",
+	"NaN",
+	"\r\n\r\n",
+	"\u00fc\u202e\u0000\ufeff",
+	"\U0001F469\u200d\u2696\ufe0f",
+	"{{placeholder}}",
+	"../../etc/passwd",
+	strings.Repeat("a", 10000),
+	"paragraph",
+	"table",
+	"definitionRef",
+	"receipt",
+	"contract",
+}
+
+// edgeValue returns a value at the edges of what nld expects
+func edgeValue(r *rand.Rand) interface{} {
+	switch r.Intn(8) {
+	case 0:
+		return nil
+	case 1:
+		return r.Intn(2) == 0
+	case 2:
+		return json.Number([]string{"0", "-1", "1.5", "1e308", "-1e400", "9007199254740993"}[r.Intn(6)])
+	case 3:
+		return []interface{}{}
+	case 4:
+		return map[string]interface{}{}
+	case 5:
+		return []interface{}{edgeStrings[r.Intn(len(edgeStrings))], nil}
+	}
+	return edgeStrings[r.Intn(len(edgeStrings))]
+}
+
+// mutateBytes changes, removes or inserts a few bytes of data, or
+// truncates it
+func mutateBytes(data []byte, r *rand.Rand) []byte {
+	out := append([]byte(nil), data...)
+	special := []byte("{}[]\",:\\\n\r\t 0a\x00\xff")
+	for i := r.Intn(3); i >= 0; i-- {
+		if len(out) == 0 {
+			return append(out, special[r.Intn(len(special))])
+		}
+		at := r.Intn(len(out))
+		switch r.Intn(4) {
+		case 0:
+			out[at] = special[r.Intn(len(special))]
+		case 1:
+			out = append(out[:at], out[at+1:]...)
+		case 2:
+			out = append(out[:at], append([]byte{special[r.Intn(len(special))]}, out[at:]...)...)
+		default:
+			out = out[:at]
+		}
+	}
+	return out
+}
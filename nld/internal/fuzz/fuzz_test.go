@@ -0,0 +1,91 @@
+package fuzz
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readExamples(t testing.TB) [][]byte {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("..", "..", "examples", "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Failed to find examples: %v", err)
+	}
+	var examples [][]byte
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read example: %v", err)
+		}
+		examples = append(examples, data)
+	}
+	return examples
+}
+
+func TestMutate(t *testing.T) {
+	for _, example := range readExamples(t) {
+		first := Mutate(example, rand.New(rand.NewSource(7)))
+		second := Mutate(example, rand.New(rand.NewSource(7)))
+		if !bytes.Equal(first, second) {
+			t.Errorf("Expected the same variant for the same seed")
+		}
+
+		changed := 0
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 20; i++ {
+			if !bytes.Equal(Mutate(example, r), example) {
+				changed++
+			}
+		}
+		if changed < 15 {
+			t.Errorf("Expected most variants to differ from the example, got %d of 20", changed)
+		}
+	}
+
+	// Documents that are not JSON have their bytes changed
+	if out := Mutate([]byte("{"), rand.New(rand.NewSource(1))); bytes.Equal(out, []byte("{")) {
+		t.Errorf("Expected a change to invalid JSON, got %q", out)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	examples := readExamples(t)
+	for _, example := range examples {
+		if findings := Check(example); len(findings) > 0 {
+			t.Errorf("Expected no findings for an example, got %v", findings)
+		}
+	}
+
+	n := 1000
+	if testing.Short() {
+		n = 100
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		data := Mutate(examples[i%len(examples)], r)
+		for _, f := range Check(data) {
+			t.Errorf("%s\n%s\n%s", f, f.Stack, data)
+		}
+	}
+}
+
+// FuzzCheck runs the validator, the parser and the renderers on near-valid
+// documents. Run it with go test -fuzz FuzzCheck ./internal/fuzz.
+func FuzzCheck(f *testing.F) {
+	r := rand.New(rand.NewSource(1))
+	for _, example := range readExamples(f) {
+		f.Add(example)
+		for i := 0; i < 10; i++ {
+			f.Add(Mutate(example, r))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, finding := range Check(data) {
+			t.Errorf("%s\n%s", finding, finding.Stack)
+		}
+	})
+}
@@ -0,0 +1,235 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/fill"
+	"gopkg.in/yaml.v3"
+)
+
+// RowPlaceholder is the placeholder holding the 1-based number of the
+// data row, available in file name templates and documents
+const RowPlaceholder = "row"
+
+// Data is the table of records read from a data file
+type Data struct {
+	// Columns are the column headers of a CSV file, or the keys of the
+	// records of a JSON or YAML file in sorted order
+	Columns []string
+	Rows    []Row
+}
+
+// Row is one record of the data
+type Row struct {
+	// Location describes where in the data file the row is, such as
+	// "line 3" or "record 2"
+	Location string
+	// Values are the values of the row by column
+	Values map[string]interface{}
+}
+
+// ReadData reads records from a CSV or TSV file with a header row, or from
+// a JSON or YAML list of objects
+func ReadData(path string) (*Data, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+	var d *Data
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		d, err = parseCSV(data, ',')
+	case ".tsv":
+		d, err = parseCSV(data, '\t')
+	case ".json", ".yaml", ".yml":
+		d, err = parseRecords(data)
+	default:
+		return nil, fmt.Errorf("unsupported data file %s (expected .csv, .tsv, .json or .yaml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid data file %s: %w", path, err)
+	}
+	if len(d.Rows) == 0 {
+		return nil, fmt.Errorf("data file %s has no rows", path)
+	}
+	return d, nil
+}
+
+// parseCSV reads delimited values with a header row. Empty lines are
+// skipped.
+func parseCSV(data []byte, comma rune) (*Data, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.Comma = comma
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing header row: %w", err)
+	}
+	d := &Data{}
+	for _, h := range header {
+		d.Columns = append(d.Columns, strings.TrimSpace(h))
+	}
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		row := Row{Location: fmt.Sprintf("line %d", line), Values: map[string]interface{}{}}
+		for i, column := range d.Columns {
+			if i < len(record) && column != "" {
+				row.Values[column] = strings.TrimSpace(record[i])
+			}
+		}
+		d.Rows = append(d.Rows, row)
+	}
+	return d, nil
+}
+
+// parseRecords reads a JSON or YAML list of objects
+func parseRecords(data []byte) (*Data, error) {
+	var records []map[string]interface{}
+	if err := yaml.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	d := &Data{}
+	seen := map[string]bool{}
+	for i, record := range records {
+		if record == nil {
+			return nil, fmt.Errorf("record %d is not an object", i+1)
+		}
+		for k := range record {
+			if !seen[k] {
+				seen[k] = true
+				d.Columns = append(d.Columns, k)
+			}
+		}
+		d.Rows = append(d.Rows, Row{Location: fmt.Sprintf("record %d", i+1), Values: record})
+	}
+	sort.Strings(d.Columns)
+	return d, nil
+}
+
+// Options controls how documents are generated
+type Options struct {
+	// Map maps placeholder names to the data column holding their value,
+	// by header or 1-based column number. Other columns fill the
+	// placeholders named by their header.
+	Map map[string]string
+	// Set are values shared by every document; row values take precedence
+	Set map[string]interface{}
+	// Name is the file name template of the generated documents, such as
+	// "{{client}}-contract.json"
+	Name string
+}
+
+// Generator produces one document per data row from a template
+type Generator struct {
+	template []byte
+	columns  map[string]string
+	opts     Options
+}
+
+// New returns a generator for a template document. The mapped columns are
+// checked against the columns of data.
+func New(template []byte, data *Data, opts Options) (*Generator, error) {
+	if _, err := document.Parse(template); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	if opts.Name == "" {
+		return nil, fmt.Errorf("a file name template is required")
+	}
+	g := &Generator{template: template, columns: map[string]string{}, opts: opts}
+	for name, column := range opts.Map {
+		found := ""
+		if n, err := strconv.Atoi(column); err == nil && strconv.Itoa(n) == column {
+			if n >= 1 && n <= len(data.Columns) {
+				found = data.Columns[n-1]
+			}
+		} else {
+			for _, c := range data.Columns {
+				if c == column {
+					found = c
+				} else if found == "" && strings.EqualFold(c, column) {
+					found = c
+				}
+			}
+		}
+		if found == "" {
+			return nil, fmt.Errorf("column %q for %s not found (columns: %s)", column, name, strings.Join(data.Columns, ", "))
+		}
+		g.columns[name] = found
+	}
+	return g, nil
+}
+
+// Values returns the placeholder values of a row, numbered from 1
+func (g *Generator) Values(row Row, number int) map[string]interface{} {
+	values := map[string]interface{}{}
+	for k, v := range g.opts.Set {
+		values[k] = v
+	}
+	for k, v := range row.Values {
+		values[k] = v
+	}
+	for name, column := range g.columns {
+		values[name] = row.Values[column]
+	}
+	values[RowPlaceholder] = strconv.Itoa(number)
+	return values
+}
+
+// Generate fills the template with the values of a row, numbered from 1,
+// and returns the document and its file name
+func (g *Generator) Generate(row Row, number int) (document.Document, string, error) {
+	values := g.Values(row, number)
+	name, err := fill.Expand(g.opts.Name, values, safeName)
+	if err != nil {
+		return nil, "", fmt.Errorf("file name: %w", err)
+	}
+	if strings.TrimSpace(filepath.Base(name)) == "" || filepath.Base(name) == ".json" {
+		return nil, "", fmt.Errorf("file name %q is empty", name)
+	}
+
+	doc, err := document.Parse(g.template)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := fill.Apply(doc, values); err != nil {
+		return nil, "", err
+	}
+	return doc, filepath.FromSlash(name), nil
+}
+
+// DefaultName returns the file name template used when none is given: the
+// template's name followed by the row number
+func DefaultName(templatePath string) string {
+	base := filepath.Base(templatePath)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	base = strings.TrimSuffix(base, ".tmpl")
+	return base + "-{{" + RowPlaceholder + "}}.json"
+}
+
+// safeName makes a value safe to use in a file name, replacing path
+// separators and characters that file systems reject
+func safeName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`/\:*?"<>|`, r):
+			return '-'
+		}
+		return r
+	}, s)
+	return strings.Trim(strings.TrimSpace(s), ".")
+}
@@ -0,0 +1,153 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const testTemplate = `{
+  "metadata": {"type": "contract", "version": "1.0.0", "created": "2025-06-27T12:00:00Z", "title": "Agreement with {{client.name}}"},
+  "content": {"sections": [{"id": "parties", "title": "Parties", "content": "{{client.name}} of {{city}}, no. {{row}}, for {{seller}}."}]}
+}`
+
+// writeData writes a data file to a temporary directory
+func writeData(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write data: %v", err)
+	}
+	return path
+}
+
+func TestReadData(t *testing.T) {
+	testCases := []struct {
+		name      string
+		file      string
+		content   string
+		columns   []string
+		locations []string
+		first     map[string]interface{}
+	}{
+		{
+			name:      "CSV",
+			file:      "parties.csv",
+			content:   "\xef\xbb\xbfclient.name, Town\nXYZ Ltd,London\n\n\"Foo, Inc\",Paris\n",
+			columns:   []string{"client.name", "Town"},
+			locations: []string{"line 2", "line 4"},
+			first:     map[string]interface{}{"client.name": "XYZ Ltd", "Town": "London"},
+		},
+		{
+			name:      "TSV",
+			file:      "parties.tsv",
+			content:   "name\tcity\nXYZ\tLondon\n",
+			columns:   []string{"name", "city"},
+			locations: []string{"line 2"},
+			first:     map[string]interface{}{"name": "XYZ", "city": "London"},
+		},
+		{
+			name:      "YAML",
+			file:      "parties.yaml",
+			content:   "- client: {name: XYZ Ltd}\n  city: London\n- client: {name: Foo}\n  fee: 10\n",
+			columns:   []string{"city", "client", "fee"},
+			locations: []string{"record 1", "record 2"},
+			first:     map[string]interface{}{"client": map[string]interface{}{"name": "XYZ Ltd"}, "city": "London"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := ReadData(writeData(t, tc.file, tc.content))
+			if err != nil {
+				t.Fatalf("Failed to read data: %v", err)
+			}
+			if !reflect.DeepEqual(data.Columns, tc.columns) {
+				t.Errorf("Expected columns %v, got %v", tc.columns, data.Columns)
+			}
+			var locations []string
+			for _, r := range data.Rows {
+				locations = append(locations, r.Location)
+			}
+			if !reflect.DeepEqual(locations, tc.locations) {
+				t.Errorf("Expected rows at %v, got %v", tc.locations, locations)
+			}
+			if !reflect.DeepEqual(data.Rows[0].Values, tc.first) {
+				t.Errorf("Expected first row %v, got %v", tc.first, data.Rows[0].Values)
+			}
+		})
+	}
+
+	if _, err := ReadData(writeData(t, "empty.csv", "name\n")); err == nil || !strings.Contains(err.Error(), "no rows") {
+		t.Errorf("Expected error for a file without rows, got %v", err)
+	}
+	if _, err := ReadData(writeData(t, "data.txt", "x")); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("Expected error for an unsupported file, got %v", err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	data, err := ReadData(writeData(t, "parties.csv", "client.name,Town\nXYZ Ltd,London\nFoo/Bar: Inc,Paris\n"))
+	if err != nil {
+		t.Fatalf("Failed to read data: %v", err)
+	}
+	opts := Options{
+		Map:  map[string]string{"city": "town"},
+		Set:  map[string]interface{}{"seller": "Acme", "city": "ignored"},
+		Name: "{{client.name}}/{{row}}.json",
+	}
+	g, err := New([]byte(testTemplate), data, opts)
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		title   string
+		content string
+	}{
+		{filepath.Join("XYZ Ltd", "1.json"), "Agreement with XYZ Ltd", "XYZ Ltd of London, no. 1, for Acme."},
+		{filepath.Join("Foo-Bar- Inc", "2.json"), "Agreement with Foo/Bar: Inc", "Foo/Bar: Inc of Paris, no. 2, for Acme."},
+	}
+	for i, tc := range testCases {
+		doc, name, err := g.Generate(data.Rows[i], i+1)
+		if err != nil {
+			t.Fatalf("Failed to generate row %d: %v", i+1, err)
+		}
+		if name != tc.name {
+			t.Errorf("Expected file name %q, got %q", tc.name, name)
+		}
+		if title := document.String(doc.Metadata(), "title"); title != tc.title {
+			t.Errorf("Expected title %q, got %q", tc.title, title)
+		}
+		if content := document.String(doc.Sections()[0], "content"); content != tc.content {
+			t.Errorf("Expected content %q, got %q", tc.content, content)
+		}
+	}
+
+	// Placeholders without a column fail the row
+	g, _ = New([]byte(testTemplate), data, Options{Name: "{{row}}.json"})
+	if _, _, err := g.Generate(data.Rows[0], 1); err == nil || !strings.Contains(err.Error(), "city") {
+		t.Errorf("Expected unresolved placeholder error, got %v", err)
+	}
+	if _, err := New([]byte(testTemplate), data, Options{Name: "x.json", Map: map[string]string{"city": "3"}}); err == nil {
+		t.Error("Expected error for a missing column")
+	}
+}
+
+func TestDefaultName(t *testing.T) {
+	testCases := map[string]string{
+		"contract.tmpl.json":   "contract-{{row}}.json",
+		"templates/nda.json":   "nda-{{row}}.json",
+		"receipt.template.yml": "receipt.template-{{row}}.json",
+	}
+	for path, want := range testCases {
+		if got := DefaultName(path); got != want {
+			t.Errorf("DefaultName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Output formats supported by Write
+const (
+	DOT     = "dot"
+	Mermaid = "mermaid"
+)
+
+// Node kinds
+const (
+	// KindSection is a section of the document
+	KindSection = "section"
+	// KindCondition is an entry of the conditions relationship list
+	KindCondition = "condition"
+	// KindUnknown is a relationship endpoint that is neither a section nor
+	// a condition of the document
+	KindUnknown = "unknown"
+)
+
+// Edge kinds
+const (
+	KindDependency = "dependency"
+	KindReference  = "reference"
+	// KindPredicate links a section or condition named in the predicate of
+	// a condition to that condition
+	KindPredicate = "predicate"
+	// KindEffect links a condition to a section or condition named in its
+	// effect
+	KindEffect = "effect"
+)
+
+// Node is a section or condition of the graph
+type Node struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+}
+
+// Edge is a relationship between two nodes
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	// Type is the type given in the document, such as requires
+	Type string `json:"type,omitempty"`
+}
+
+// Graph is the relationships and conditions of a document
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Formats returns the supported output formats
+func Formats() []string {
+	return []string{DOT, Mermaid}
+}
+
+// Build returns the graph of the dependencies, references and conditions of
+// a document. Sections are labelled with their number and title; sections
+// without relationships are left out.
+//
+// Conditions are linked to the sections and conditions whose IDs appear as
+// whole words in their predicate and effect.
+func Build(doc *nld.Document) *Graph {
+	sections := map[string]string{}
+	var walk func(entries []nld.OutlineEntry)
+	walk = func(entries []nld.OutlineEntry) {
+		for _, e := range entries {
+			sections[e.ID] = strings.TrimSpace(e.Number + " " + e.Title)
+			walk(e.Children)
+		}
+	}
+	walk(doc.Outline(nld.NumberingStyle{}))
+
+	g := &Graph{Nodes: []Node{}, Edges: []Edge{}}
+	seen := map[string]bool{}
+	node := func(id string) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		if label, ok := sections[id]; ok {
+			g.Nodes = append(g.Nodes, Node{ID: id, Kind: KindSection, Label: label})
+		} else {
+			g.Nodes = append(g.Nodes, Node{ID: id, Kind: KindUnknown, Label: id})
+		}
+	}
+
+	// Conditions come first so that they are not taken for unknown nodes
+	rel := doc.Relationships
+	conditions := map[string]string{}
+	for _, c := range rel.Conditions {
+		conditions[c.ID] = c.Predicate
+		if !seen[c.ID] {
+			seen[c.ID] = true
+			g.Nodes = append(g.Nodes, Node{ID: c.ID, Kind: KindCondition, Label: c.ID + ": " + c.Predicate})
+		}
+	}
+
+	for _, r := range rel.Dependencies {
+		node(r.Source)
+		node(r.Target)
+		g.Edges = append(g.Edges, Edge{From: r.Source, To: r.Target, Kind: KindDependency, Type: r.Type})
+	}
+	for _, r := range rel.References {
+		node(r.Source)
+		node(r.Target)
+		g.Edges = append(g.Edges, Edge{From: r.Source, To: r.Target, Kind: KindReference, Type: r.Type})
+	}
+	for _, c := range rel.Conditions {
+		for _, id := range mentions(c.Predicate, sections, conditions) {
+			if id != c.ID {
+				node(id)
+				g.Edges = append(g.Edges, Edge{From: id, To: c.ID, Kind: KindPredicate})
+			}
+		}
+		for _, id := range mentions(c.Effect, sections, conditions) {
+			if id != c.ID {
+				node(id)
+				g.Edges = append(g.Edges, Edge{From: c.ID, To: id, Kind: KindEffect})
+			}
+		}
+	}
+	return g
+}
+
+var idRe = regexp.MustCompile(`[A-Za-z0-9_][A-Za-z0-9_.-]*[A-Za-z0-9_]|[A-Za-z0-9_]`)
+
+// mentions returns the IDs of sets that appear as whole words in text, in
+// order of first appearance
+func mentions(text string, sets ...map[string]string) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, word := range idRe.FindAllString(text, -1) {
+		if seen[word] {
+			continue
+		}
+		for _, set := range sets {
+			if _, ok := set[word]; ok {
+				seen[word] = true
+				ids = append(ids, word)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// Write writes the graph in the given format
+func Write(w io.Writer, g *Graph, format string) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case DOT, "graphviz", "":
+		writeDOT(bw, g)
+	case Mermaid:
+		writeMermaid(bw, g)
+	default:
+		return fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return bw.Flush()
+}
+
+// writeDOT writes the graph in the Graphviz DOT language
+func writeDOT(w *bufio.Writer, g *Graph) {
+	w.WriteString("digraph relationships {\n")
+	w.WriteString("  rankdir=LR;\n")
+	w.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		attrs := fmt.Sprintf("label=%s", dotQuote(n.Label))
+		switch n.Kind {
+		case KindCondition:
+			attrs += ", shape=diamond"
+		case KindUnknown:
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(w, "  %s [%s];\n", dotQuote(n.ID), attrs)
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Type != "" {
+			attrs = append(attrs, "label="+dotQuote(e.Type))
+		}
+		switch e.Kind {
+		case KindReference:
+			attrs = append(attrs, "style=dashed")
+		case KindPredicate, KindEffect:
+			attrs = append(attrs, "style=dotted")
+		}
+		fmt.Fprintf(w, "  %s -> %s", dotQuote(e.From), dotQuote(e.To))
+		if len(attrs) > 0 {
+			fmt.Fprintf(w, " [%s]", strings.Join(attrs, ", "))
+		}
+		w.WriteString(";\n")
+	}
+	w.WriteString("}\n")
+}
+
+// dotQuote quotes a DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// writeMermaid writes the graph as a Mermaid flowchart. Nodes are named n0,
+// n1 and so on since Mermaid IDs cannot hold every character of an NLD ID.
+func writeMermaid(w *bufio.Writer, g *Graph) {
+	names := map[string]string{}
+	w.WriteString("flowchart LR\n")
+	for i, n := range g.Nodes {
+		name := fmt.Sprintf("n%d", i)
+		names[n.ID] = name
+		label := mermaidQuote(n.Label)
+		switch n.Kind {
+		case KindCondition:
+			fmt.Fprintf(w, "  %s{%s}\n", name, label)
+		case KindUnknown:
+			fmt.Fprintf(w, "  %s([%s])\n", name, label)
+		default:
+			fmt.Fprintf(w, "  %s[%s]\n", name, label)
+		}
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		switch e.Kind {
+		case KindReference, KindPredicate, KindEffect:
+			arrow = "-.->"
+		}
+		if e.Type != "" {
+			arrow += "|" + mermaidQuote(e.Type) + "|"
+		}
+		fmt.Fprintf(w, "  %s %s %s\n", names[e.From], arrow, names[e.To])
+	}
+}
+
+// mermaidQuote quotes a Mermaid label
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s) + `"`
+}
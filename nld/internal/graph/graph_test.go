@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const testDoc = `{
+  "metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Services Agreement"},
+  "content": {"sections": [
+    {"id": "scope", "title": "Scope", "content": "Text"},
+    {"id": "fees", "title": "Fees", "content": "Text",
+     "sections": [{"id": "late-fees", "title": "Late \"fees\"", "content": "Text"}]},
+    {"id": "notices", "title": "Notices", "content": "Text"}
+  ]},
+  "relationships": {
+    "dependencies": [{"source": "fees", "target": "scope", "type": "requires"}],
+    "references": [{"source": "late-fees", "target": "schedule-1", "type": "cites"}],
+    "conditions": [{"id": "late", "predicate": "payment under fees is overdue", "effect": "late-fees applies"}]
+  }
+}`
+
+func TestBuild(t *testing.T) {
+	doc, err := nld.Parse([]byte(testDoc))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	g := Build(doc)
+
+	var nodes []string
+	for _, n := range g.Nodes {
+		nodes = append(nodes, n.ID+" "+n.Kind+" "+n.Label)
+	}
+	expect := []string{
+		"late condition late: payment under fees is overdue",
+		"fees section 2 Fees",
+		"scope section 1 Scope",
+		`late-fees section 2.1 Late "fees"`,
+		"schedule-1 unknown schedule-1",
+	}
+	if strings.Join(nodes, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("Expected nodes:\n%s\ngot:\n%s", strings.Join(expect, "\n"), strings.Join(nodes, "\n"))
+	}
+
+	var edges []string
+	for _, e := range g.Edges {
+		edges = append(edges, e.From+" -> "+e.To+" "+e.Kind)
+	}
+	expect = []string{
+		"fees -> scope dependency",
+		"late-fees -> schedule-1 reference",
+		"fees -> late predicate",
+		"late -> late-fees effect",
+	}
+	if strings.Join(edges, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("Expected edges:\n%s\ngot:\n%s", strings.Join(expect, "\n"), strings.Join(edges, "\n"))
+	}
+}
+
+func TestWrite(t *testing.T) {
+	doc, err := nld.Parse([]byte(testDoc))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	g := Build(doc)
+
+	testCases := []struct {
+		format string
+		expect []string
+	}{
+		{format: DOT, expect: []string{
+			"digraph relationships {",
+			`  "late" [label="late: payment under fees is overdue", shape=diamond];`,
+			`  "late-fees" [label="2.1 Late \"fees\""];`,
+			`  "schedule-1" [label="schedule-1", style=dashed];`,
+			`  "fees" -> "scope" [label="requires"];`,
+			`  "late-fees" -> "schedule-1" [label="cites", style=dashed];`,
+			`  "fees" -> "late" [style=dotted];`,
+		}},
+		{format: Mermaid, expect: []string{
+			"flowchart LR",
+			`  n0{"late: payment under fees is overdue"}`,
+			`  n3["2.1 Late #quot;fees#quot;"]`,
+			`  n4(["schedule-1"])`,
+			`  n1 -->|"requires"| n2`,
+			`  n3 -.->|"cites"| n4`,
+			`  n0 -.-> n3`,
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, g, tc.format); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			for _, line := range tc.expect {
+				if !strings.Contains(buf.String(), line+"\n") {
+					t.Errorf("Expected line %q in:\n%s", line, buf.String())
+				}
+			}
+		})
+	}
+
+	if err := Write(&bytes.Buffer{}, g, "svg"); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,170 @@
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/diff"
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+// Entry describes one commit that touched a document
+type Entry struct {
+	Commit  string        `json:"commit"`
+	Author  string        `json:"author"`
+	Date    time.Time     `json:"date"`
+	Subject string        `json:"subject"`
+	Changes []diff.Change `json:"changes"`
+	// Error is set when the document could not be parsed at this commit
+	Error string `json:"error,omitempty"`
+}
+
+// Options controls how history is collected
+type Options struct {
+	// Limit caps the number of commits listed; zero means no limit. The
+	// oldest commit listed is still compared with the version before it.
+	Limit int
+	// Git is the git executable to run; defaults to "git"
+	Git string
+}
+
+// Log walks the git history of the document at path, oldest commit first,
+// and returns the structural changes introduced by each commit
+func Log(path string, opts Options) ([]Entry, error) {
+	if opts.Git == "" {
+		opts.Git = "git"
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(abs)
+
+	root, err := run(opts.Git, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("not a git repository: %s", dir)
+	}
+	root = strings.TrimSpace(root)
+
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return nil, err
+	}
+	rel = filepath.ToSlash(rel)
+
+	args := []string{"log", "--follow", "--format=%H%x00%an%x00%aI%x00%s", "--name-only"}
+	if opts.Limit > 0 {
+		// One more commit, for the version the oldest one listed changed
+		args = append(args, fmt.Sprintf("-n%d", opts.Limit+1))
+	}
+	args = append(args, "--", rel)
+
+	out, err := run(opts.Git, root, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := parseLog(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits found for %s", path)
+	}
+
+	// git log lists newest first; walk oldest first so each version is
+	// compared with the one before it
+	var entries []Entry
+	var prev document.Document
+	if opts.Limit > 0 && len(commits) > opts.Limit {
+		base := commits[len(commits)-1]
+		commits = commits[:len(commits)-1]
+		if content, err := run(opts.Git, root, "show", base.hash+":"+base.path); err == nil {
+			prev, _ = document.Parse([]byte(content))
+		}
+	}
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		entry := Entry{Commit: c.hash, Author: c.author, Date: c.date, Subject: c.subject}
+
+		content, err := run(opts.Git, root, "show", c.hash+":"+c.path)
+		if err != nil {
+			// The file was deleted in this commit
+			entry.Changes = diff.Compare(prev, nil)
+			prev = nil
+			entries = append(entries, entry)
+			continue
+		}
+
+		doc, err := document.Parse([]byte(content))
+		if err != nil {
+			entry.Error = err.Error()
+			entries = append(entries, entry)
+			continue
+		}
+
+		entry.Changes = diff.Compare(prev, doc)
+		prev = doc
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+type commit struct {
+	hash    string
+	author  string
+	date    time.Time
+	subject string
+	path    string
+}
+
+// parseLog parses the output of git log with --name-only
+func parseLog(out string) ([]commit, error) {
+	var commits []commit
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, "\x00")
+		if len(fields) == 4 {
+			date, err := time.Parse(time.RFC3339, fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid commit date %q: %w", fields[2], err)
+			}
+			commits = append(commits, commit{hash: fields[0], author: fields[1], date: date, subject: fields[3]})
+			continue
+		}
+
+		// A file name line belongs to the preceding commit; with --follow
+		// it reflects the path the document had at that commit
+		if len(commits) > 0 && commits[len(commits)-1].path == "" {
+			commits[len(commits)-1].path = line
+		}
+	}
+	return commits, nil
+}
+
+// run executes git in dir and returns its standard output
+func run(git, dir string, args ...string) (string, error) {
+	cmd := exec.Command(git, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
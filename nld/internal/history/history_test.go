@@ -0,0 +1,94 @@
+package history
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/diff"
+)
+
+func TestLog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "doc.json"), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write document: %v", err)
+		}
+	}
+
+	git("init", "-q")
+	write(`{"metadata":{"title":"Draft"},"content":{"sections":[{"id":"a","title":"A","content":"x"}]}}`)
+	git("add", "doc.json")
+	git("commit", "-q", "-m", "Initial draft")
+	write(`{"metadata":{"title":"Draft"},"content":{"sections":[{"id":"a","title":"A","content":"y"},{"id":"b","title":"B","content":"z"}]}}`)
+	git("commit", "-q", "-am", "Add section b")
+
+	entries, err := Log(filepath.Join(dir, "doc.json"), Options{})
+	if err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Subject != "Initial draft" {
+		t.Errorf("Expected oldest commit first, got %q", entries[0].Subject)
+	}
+
+	found := map[string]diff.Kind{}
+	for _, c := range entries[1].Changes {
+		found[c.Area+" "+c.ID] = c.Kind
+	}
+	if found["section a"] != diff.Modified || found["section b"] != diff.Added {
+		t.Errorf("Unexpected changes in second commit: %v", entries[1].Changes)
+	}
+
+	// With a limit the oldest commit listed is compared with the version
+	// before it, not with an empty document
+	write(`{"metadata":{"title":"Draft"},"content":{"sections":[{"id":"a","title":"A","content":"y"},{"id":"b","title":"B","content":"z"},{"id":"c","title":"C","content":"w"}]}}`)
+	git("commit", "-q", "-am", "Add section c")
+	entries, err = Log(filepath.Join(dir, "doc.json"), Options{Limit: 1})
+	if err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Subject != "Add section c" {
+		t.Fatalf("Expected the latest commit only, got %+v", entries)
+	}
+	if changes := entries[0].Changes; len(changes) != 1 || changes[0].ID != "c" || changes[0].Kind != diff.Added {
+		t.Errorf("Expected only section c to be added, got %v", changes)
+	}
+
+	entries, err = Log(filepath.Join(dir, "doc.json"), Options{Limit: 5})
+	if err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if len(entries) != 3 || entries[0].Subject != "Initial draft" {
+		t.Errorf("Expected all 3 commits, got %+v", entries)
+	}
+}
+
+func TestLogOutsideRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	path := filepath.Join(t.TempDir(), "doc.json")
+	if _, err := Log(path, Options{}); err == nil {
+		t.Errorf("Expected error outside a git repository")
+	}
+}
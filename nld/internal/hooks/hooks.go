@@ -0,0 +1,203 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Checks a hook can run on staged documents
+const (
+	CheckFmt      = "fmt"
+	CheckValidate = "validate"
+	CheckLint     = "lint"
+)
+
+// DefaultPattern matches the documents checked by the hook
+const DefaultPattern = "*.nld.json"
+
+// marker identifies hooks written by Install
+const marker = "# Installed by nld hooks install"
+
+// commands are the nld commands run for each check, reading the staged
+// document from standard input
+var commands = map[string]string{
+	CheckFmt:      `fmt --check -`,
+	CheckValidate: `validate -`,
+	CheckLint:     `lint -`,
+}
+
+// Checks returns the checks a hook can run, in the order they run
+func Checks() []string {
+	return []string{CheckFmt, CheckValidate, CheckLint}
+}
+
+// DefaultChecks returns the checks run when none are configured
+func DefaultChecks() []string {
+	return []string{CheckFmt, CheckValidate}
+}
+
+// Options controls the installed hook
+type Options struct {
+	// Checks are the checks to run, DefaultChecks when empty
+	Checks []string
+	// Pattern is the pathspec of the documents to check, DefaultPattern
+	// when empty
+	Pattern string
+	// Force replaces a pre-commit hook that was not installed by nld
+	Force bool
+	// Git is the git executable to run; defaults to "git"
+	Git string
+}
+
+// Script returns the pre-commit hook script. The checks run on the staged
+// version of each matching document, so unstaged edits do not hide
+// problems. The nld.hooks.checks git config setting overrides the checks
+// when set, for example:
+//
+//	git config nld.hooks.checks "validate lint"
+func Script(opts Options) (string, error) {
+	checks, err := resolve(opts.Checks)
+	if err != nil {
+		return "", err
+	}
+	pattern := opts.Pattern
+	if pattern == "" {
+		pattern = DefaultPattern
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString(marker + "\n")
+	b.WriteString("# Checks staged NLD documents before they are committed.\n")
+	b.WriteString("# Set the checks with: git config nld.hooks.checks \"" + strings.Join(Checks(), " ") + "\"\n\n")
+	b.WriteString(`NLD="${NLD:-nld}"` + "\n")
+	fmt.Fprintf(&b, "CHECKS=$(git config --get nld.hooks.checks || echo %s)\n", shellQuote(strings.Join(checks, " ")))
+	fmt.Fprintf(&b, "PATTERN=%s\n\n", shellQuote(pattern))
+	b.WriteString(`status=0
+files=$(git diff --cached --name-only --diff-filter=ACMR -- "$PATTERN")
+[ -z "$files" ] && exit 0
+
+for check in $CHECKS; do
+	case "$check" in
+`)
+	for _, check := range Checks() {
+		fmt.Fprintf(&b, "\t%s) args=%s ;;\n", check, shellQuote(commands[check]))
+	}
+	b.WriteString(`	*) echo "nld: unknown check $check in nld.hooks.checks" >&2; exit 1 ;;
+	esac
+	while IFS= read -r file; do
+		if ! out=$(git show ":$file" | "$NLD" $args 2>&1); then
+			echo "nld $check failed for $file:" >&2
+			echo "$out" >&2
+			status=1
+		fi
+	done <<EOF
+$files
+EOF
+done
+
+if [ $status -ne 0 ]; then
+	echo "Commit aborted by nld. Fix the documents above or commit with --no-verify." >&2
+fi
+exit $status
+`)
+	return b.String(), nil
+}
+
+// Install writes the pre-commit hook of the git repository containing dir
+// and returns its path
+func Install(dir string, opts Options) (string, error) {
+	script, err := Script(opts)
+	if err != nil {
+		return "", err
+	}
+	path, err := hookPath(dir, opts.Git)
+	if err != nil {
+		return "", err
+	}
+
+	if existing, err := os.ReadFile(path); err == nil && !bytes.Contains(existing, []byte(marker)) && !opts.Force {
+		return "", fmt.Errorf("a pre-commit hook already exists at %s (use --force to replace it)", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", fmt.Errorf("failed to write hook: %w", err)
+	}
+	return path, nil
+}
+
+// Uninstall removes the pre-commit hook of the git repository containing
+// dir and returns its path. Hooks not installed by nld are left alone.
+func Uninstall(dir, git string) (string, error) {
+	path, err := hookPath(dir, git)
+	if err != nil {
+		return "", err
+	}
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("no pre-commit hook installed at %s", path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read hook: %w", err)
+	}
+	if !bytes.Contains(existing, []byte(marker)) {
+		return "", fmt.Errorf("the pre-commit hook at %s was not installed by nld", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove hook: %w", err)
+	}
+	return path, nil
+}
+
+// resolve validates a list of checks, in the order they run
+func resolve(checks []string) ([]string, error) {
+	if len(checks) == 0 {
+		return DefaultChecks(), nil
+	}
+	selected := map[string]bool{}
+	for _, check := range checks {
+		if _, ok := commands[check]; !ok {
+			return nil, fmt.Errorf("unknown check %q (available: %s)", check, strings.Join(Checks(), ", "))
+		}
+		selected[check] = true
+	}
+	var list []string
+	for _, check := range Checks() {
+		if selected[check] {
+			list = append(list, check)
+		}
+	}
+	return list, nil
+}
+
+// hookPath returns the path of the pre-commit hook of the repository
+// containing dir, honouring core.hooksPath
+func hookPath(dir, git string) (string, error) {
+	if git == "" {
+		git = "git"
+	}
+	cmd := exec.Command(git, "rev-parse", "--git-path", "hooks/pre-commit")
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("not a git repository: %s", dir)
+	}
+	path := strings.TrimSpace(stdout.String())
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path, nil
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,90 @@
+package hooks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	script, err := Script(Options{Checks: []string{CheckLint, CheckFmt}, Pattern: "docs/*.json"})
+	if err != nil {
+		t.Fatalf("Script failed: %v", err)
+	}
+	for _, want := range []string{
+		marker,
+		"CHECKS=$(git config --get nld.hooks.checks || echo 'fmt lint')",
+		"PATTERN='docs/*.json'",
+		"fmt) args='fmt --check -' ;;",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("Expected script to contain %q:\n%s", want, script)
+		}
+	}
+
+	if _, err := Script(Options{Checks: []string{"spellcheck"}}); err == nil {
+		t.Error("Expected an error for an unknown check")
+	}
+}
+
+func TestInstall(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+
+	path, err := Install(dir, Options{})
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if path != hook {
+		t.Errorf("Expected hook at %s, got %s", hook, path)
+	}
+	info, err := os.Stat(hook)
+	if err != nil {
+		t.Fatalf("Hook not written: %v", err)
+	}
+	if info.Mode()&0111 == 0 {
+		t.Error("Expected the hook to be executable")
+	}
+
+	// Reinstalling replaces our own hook
+	if _, err := Install(dir, Options{Checks: []string{CheckValidate}}); err != nil {
+		t.Errorf("Reinstall failed: %v", err)
+	}
+
+	if _, err := Uninstall(dir, ""); err != nil {
+		t.Fatalf("Uninstall failed: %v", err)
+	}
+	if _, err := os.Stat(hook); !os.IsNotExist(err) {
+		t.Error("Expected the hook to be removed")
+	}
+	if _, err := Uninstall(dir, ""); err == nil {
+		t.Error("Expected an error when no hook is installed")
+	}
+
+	// Hooks from other tools are kept unless forced
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatalf("Failed to write hook: %v", err)
+	}
+	if _, err := Install(dir, Options{}); err == nil {
+		t.Error("Expected an error for an existing hook")
+	}
+	if _, err := Uninstall(dir, ""); err == nil {
+		t.Error("Expected an error removing a hook not installed by nld")
+	}
+	if _, err := Install(dir, Options{Force: true}); err != nil {
+		t.Errorf("Forced install failed: %v", err)
+	}
+
+	if _, err := Install(t.TempDir(), Options{}); err == nil {
+		t.Error("Expected an error outside a git repository")
+	}
+}
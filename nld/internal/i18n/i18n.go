@@ -0,0 +1,242 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Default is the language used when none is selected, and for
+	// messages missing from a catalog
+	Default = "en"
+
+	// LangEnv selects the language when --lang is not given
+	LangEnv = "NLD_LANG"
+)
+
+// localeEnvs are the variables naming the locale of the user, in order of
+// precedence
+var localeEnvs = []string{"LC_ALL", "LC_MESSAGES", "LANG"}
+
+//go:embed catalogs/*.json
+var catalogs embed.FS
+
+// Catalog holds the translated messages of one language
+type Catalog struct {
+	Lang     string
+	messages map[string]message
+	fallback *Catalog
+}
+
+// Plural categories of messages that depend on a count, as named by the
+// Unicode CLDR
+const (
+	One   = "one"
+	Other = "other"
+)
+
+// message is a message of a catalog: a string, or an object with a form
+// for each plural category, such as {"one": "%d file", "other": "%d files"}
+type message struct {
+	text  string
+	forms map[string]string
+}
+
+// UnmarshalJSON reads a message, which is a string or an object of plural
+// forms with at least the other form
+func (m *message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.text); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &m.forms); err != nil {
+		return fmt.Errorf("message must be a string or an object of plural forms")
+	}
+	for category := range m.forms {
+		if category != One && category != Other {
+			return fmt.Errorf("unknown plural category %q (supported: %s, %s)", category, One, Other)
+		}
+	}
+	text, ok := m.forms[Other]
+	if !ok {
+		return fmt.Errorf("plural message has no %q form", Other)
+	}
+	m.text = text
+	return nil
+}
+
+// form returns the form of a message for a plural category
+func (m message) form(category string) string {
+	if text, ok := m.forms[category]; ok {
+		return text
+	}
+	return m.text
+}
+
+// Languages returns the languages with an embedded catalog
+func Languages() []string {
+	entries, _ := catalogs.ReadDir("catalogs")
+	var langs []string
+	for _, e := range entries {
+		langs = append(langs, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Load returns the catalog for a language tag such as "de", "de-CH" or
+// "de_DE.UTF-8". Regional variants without their own catalog use the
+// catalog of the base language. An empty tag selects English.
+func Load(lang string) (*Catalog, error) {
+	tag := normalize(lang)
+	if tag == "" {
+		tag = Default
+	}
+
+	english, err := read(Default)
+	if err != nil {
+		return nil, err
+	}
+	if tag == Default {
+		return english, nil
+	}
+
+	for _, candidate := range []string{tag, strings.SplitN(tag, "-", 2)[0]} {
+		c, err := read(candidate)
+		if err == nil {
+			c.fallback = english
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(Languages(), ", "))
+}
+
+// FromEnv returns the language selected by NLD_LANG or, when it is not
+// set, by the locale of LC_ALL, LC_MESSAGES or LANG. Locales without a
+// catalog, such as C or POSIX, select English, returned as "".
+func FromEnv() string {
+	if lang := os.Getenv(LangEnv); lang != "" {
+		return lang
+	}
+	for _, env := range localeEnvs {
+		locale := os.Getenv(env)
+		if locale == "" {
+			continue
+		}
+		if _, err := Load(locale); err != nil {
+			return ""
+		}
+		return locale
+	}
+	return ""
+}
+
+// English returns the English catalog
+func English() *Catalog {
+	c, err := read(Default)
+	if err != nil {
+		panic("i18n: " + err.Error())
+	}
+	return c
+}
+
+// normalize turns a language tag or locale name into lower-case
+// "lang-region" form
+func normalize(lang string) string {
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i]
+	}
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+}
+
+// read loads an embedded catalog
+func read(tag string) (*Catalog, error) {
+	data, err := catalogs.ReadFile("catalogs/" + tag + ".json")
+	if err != nil {
+		return nil, err
+	}
+	c := &Catalog{Lang: tag}
+	if err := json.Unmarshal(data, &c.messages); err != nil {
+		return nil, fmt.Errorf("invalid catalog %s: %w", tag, err)
+	}
+	return c, nil
+}
+
+// T returns the message for key formatted with args. Messages missing from
+// the catalog are taken from English; unknown keys are returned as is.
+// Plural messages take their other form.
+func (c *Catalog) T(key string, args ...interface{}) string {
+	return c.format(key, Other, args)
+}
+
+// N returns the form of the message for key that agrees with the count n,
+// such as "1 file failed" or "3 files failed", formatted with args, which
+// usually include n
+func (c *Catalog) N(key string, n int, args ...interface{}) string {
+	return c.format(key, c.plural(n), args)
+}
+
+// format returns the form of the message for key for a plural category,
+// formatted with args
+func (c *Catalog) format(key, category string, args []interface{}) string {
+	msg := key
+	if m, ok := c.lookup(key); ok {
+		msg = m.form(category)
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// plural returns the plural category of the count n in the catalog's
+// language. French uses the singular for 0 as well as 1.
+func (c *Catalog) plural(n int) string {
+	lang := Default
+	if c != nil {
+		lang = c.Lang
+	}
+	switch {
+	case n == 1, n == 0 && strings.SplitN(lang, "-", 2)[0] == "fr":
+		return One
+	}
+	return Other
+}
+
+// lookup finds the message for key in the catalog or its fallback
+func (c *Catalog) lookup(key string) (message, bool) {
+	if c == nil {
+		return English().lookup(key)
+	}
+	if msg, ok := c.messages[key]; ok {
+		return msg, true
+	}
+	if c.fallback != nil {
+		return c.fallback.lookup(key)
+	}
+	return message{}, false
+}
+
+// Keys returns the message keys of the catalog
+func (c *Catalog) Keys() []string {
+	var keys []string
+	for k := range c.messages {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Date formats a date in the catalog's long date format, such as
+// "1 July 2025" or "1. Juli 2025"
+func (c *Catalog) Date(t time.Time) string {
+	return strings.NewReplacer(
+		"{day}", fmt.Sprint(t.Day()),
+		"{month}", c.T(fmt.Sprintf("month.%d", int(t.Month()))),
+		"{year}", fmt.Sprint(t.Year()),
+	).Replace(c.T("date.format"))
+}
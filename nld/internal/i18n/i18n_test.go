@@ -0,0 +1,164 @@
+package i18n
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	testCases := []struct {
+		name        string
+		lang        string
+		expect      string
+		expectError bool
+	}{
+		{name: "Default", lang: "", expect: "en"},
+		{name: "Language", lang: "de", expect: "de"},
+		{name: "Region", lang: "fr-CA", expect: "fr"},
+		{name: "Locale", lang: "es_ES.UTF-8", expect: "es"},
+		{name: "Unsupported", lang: "xx", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := Load(tc.lang)
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, got catalog %s", c.Lang)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if c.Lang != tc.expect {
+				t.Errorf("Expected catalog %s, got %s", tc.expect, c.Lang)
+			}
+		})
+	}
+}
+
+// TestCatalogsComplete checks that every catalog translates every English
+// message with the same format verbs in each plural form
+func TestCatalogsComplete(t *testing.T) {
+	english := English()
+	for _, lang := range Languages() {
+		c, err := Load(lang)
+		if err != nil {
+			t.Fatalf("Load(%s) failed: %v", lang, err)
+		}
+		for _, key := range english.Keys() {
+			msg, ok := c.messages[key]
+			if !ok {
+				t.Errorf("%s: missing message %s", lang, key)
+				continue
+			}
+			for _, category := range []string{One, Other} {
+				got, want := verbs(msg.form(category)), verbs(english.messages[key].form(category))
+				if got != want {
+					t.Errorf("%s: message %s (%s) has verbs %q, expected %q", lang, key, category, got, want)
+				}
+			}
+		}
+	}
+}
+
+// verbs returns the format verbs of a message in order
+func verbs(msg string) string {
+	var out []string
+	for i := 0; i < len(msg)-1; i++ {
+		if msg[i] == '%' {
+			out = append(out, msg[i:i+2])
+			i++
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func TestTranslate(t *testing.T) {
+	date := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		lang   string
+		expect string
+		date   string
+	}{
+		{lang: "en", expect: "contract.json has 2 errors:", date: "March 1, 2025"},
+		{lang: "de", expect: "contract.json hat 2 Fehler:", date: "1. März 2025"},
+		{lang: "fr", expect: "contract.json contient 2 erreurs :", date: "1 mars 2025"},
+		{lang: "es", expect: "contract.json tiene 2 errores:", date: "1 de marzo de 2025"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.lang, func(t *testing.T) {
+			c, err := Load(tc.lang)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if got := c.T("validate.invalid", "contract.json", 2); got != tc.expect {
+				t.Errorf("Expected %q, got %q", tc.expect, got)
+			}
+			if got := c.Date(date); got != tc.date {
+				t.Errorf("Expected date %q, got %q", tc.date, got)
+			}
+			if got := c.T("no.such.key"); got != "no.such.key" {
+				t.Errorf("Expected unknown keys to be returned as is, got %q", got)
+			}
+		})
+	}
+}
+
+func TestPlural(t *testing.T) {
+	testCases := []struct {
+		lang   string
+		n      int
+		expect string
+	}{
+		{lang: "en", n: 1, expect: "contract.json has 1 error:"},
+		{lang: "en", n: 0, expect: "contract.json has 0 errors:"},
+		{lang: "fr", n: 0, expect: "contract.json contient 0 erreur :"},
+		{lang: "fr", n: 3, expect: "contract.json contient 3 erreurs :"},
+		{lang: "es", n: 1, expect: "contract.json tiene 1 error:"},
+		// German uses the same word for both forms
+		{lang: "de", n: 1, expect: "contract.json hat 1 Fehler:"},
+	}
+	for _, tc := range testCases {
+		c, err := Load(tc.lang)
+		if err != nil {
+			t.Fatalf("Load(%s) failed: %v", tc.lang, err)
+		}
+		if got := c.N("validate.invalid", tc.n, "contract.json", tc.n); got != tc.expect {
+			t.Errorf("%s, %d: expected %q, got %q", tc.lang, tc.n, tc.expect, got)
+		}
+	}
+
+	var m message
+	for _, data := range []string{`{"one": "%d file"}`, `{"few": "%d files", "other": "%d files"}`, `3`} {
+		if err := json.Unmarshal([]byte(data), &m); err == nil {
+			t.Errorf("expected an error for message %s", data)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	testCases := []struct {
+		nldLang, lcAll, lang string
+		expect               string
+	}{
+		{nldLang: "de", lang: "fr_FR.UTF-8", expect: "de"},
+		{lang: "fr_FR.UTF-8", expect: "fr_FR.UTF-8"},
+		{lcAll: "es_ES.UTF-8", lang: "fr_FR.UTF-8", expect: "es_ES.UTF-8"},
+		{lang: "C.UTF-8", expect: ""},
+		{lcAll: "ja_JP.UTF-8", lang: "fr_FR.UTF-8", expect: ""},
+	}
+	for _, tc := range testCases {
+		t.Setenv(LangEnv, tc.nldLang)
+		t.Setenv("LC_ALL", tc.lcAll)
+		t.Setenv("LC_MESSAGES", "")
+		t.Setenv("LANG", tc.lang)
+		if got := FromEnv(); got != tc.expect {
+			t.Errorf("FromEnv() with %+v = %q, expected %q", tc, got, tc.expect)
+		}
+	}
+}
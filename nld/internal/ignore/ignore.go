@@ -0,0 +1,254 @@
+// Package ignore matches paths against .nldignore files, which list the
+// files and directories that commands given a directory skip, in gitignore
+// syntax
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName is the name of ignore files
+const FileName = ".nldignore"
+
+// pattern is a line of an ignore file
+type pattern struct {
+	re *regexp.Regexp
+	// negate re-includes the paths matched, for patterns starting with !
+	negate bool
+	// dirOnly matches directories only, for patterns ending with /
+	dirOnly bool
+}
+
+// parse parses the lines of an ignore file. Blank lines and lines starting
+// with # are skipped.
+func parse(data []byte) ([]pattern, error) {
+	var patterns []pattern
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var p pattern
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// Patterns with a slash are relative to the directory of the
+		// file; others match at any depth
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		expr := translate(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern on line %d: %w", n, err)
+		}
+		p.re = re
+		patterns = append(patterns, p)
+	}
+	return patterns, scanner.Err()
+}
+
+// translate converts a glob to a regular expression: * and ? match within
+// a path segment, ** matches any number of segments and [...] matches a
+// character class
+func translate(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**") && i+2 == len(glob):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return b.String()
+}
+
+// Matcher matches paths under a root directory against the ignore files of
+// the root and of the directories below it. The patterns of a deeper file
+// take precedence, and within a file the last matching pattern decides.
+type Matcher struct {
+	root  string
+	files map[string][]pattern
+}
+
+// New returns a matcher for the paths under root. Ignore files are read as
+// they are needed.
+func New(root string) (*Matcher, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Matcher{root: abs, files: map[string][]pattern{}}, nil
+}
+
+// Ignored reports whether a file or directory is ignored, either itself or
+// as it is in an ignored directory. Paths outside the root are not.
+func (m *Matcher) Ignored(path string, isDir bool) (bool, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(m.root, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false, nil
+	}
+	tokens := strings.Split(filepath.ToSlash(rel), "/")
+	for i := 1; i <= len(tokens); i++ {
+		ignored, err := m.match(tokens[:i], isDir || i < len(tokens))
+		if ignored || err != nil {
+			return ignored, err
+		}
+	}
+	return false, nil
+}
+
+// match reports whether the patterns of the ignore files above a path,
+// given as tokens relative to the root, ignore it
+func (m *Matcher) match(tokens []string, isDir bool) (bool, error) {
+	for depth := len(tokens) - 1; depth >= 0; depth-- {
+		dir := strings.Join(tokens[:depth], "/")
+		patterns, err := m.patterns(dir)
+		if err != nil {
+			return false, err
+		}
+		rel := strings.Join(tokens[depth:], "/")
+		for i := len(patterns) - 1; i >= 0; i-- {
+			p := patterns[i]
+			if (!p.dirOnly || isDir) && p.re.MatchString(rel) {
+				return !p.negate, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// patterns returns the patterns of the ignore file of a directory relative
+// to the root, reading it on first use
+func (m *Matcher) patterns(dir string) ([]pattern, error) {
+	if patterns, ok := m.files[dir]; ok {
+		return patterns, nil
+	}
+	path := filepath.Join(m.root, filepath.FromSlash(dir), FileName)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	patterns, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ignore file %s: %w", path, err)
+	}
+	m.files[dir] = patterns
+	return patterns, nil
+}
+
+// Expand replaces the directories among paths by the JSON files below
+// them, in lexical order, skipping hidden directories and the files and
+// directories that are ignored. Directories outside the root are matched
+// against their own ignore files. Other paths, such as files named
+// explicitly, are kept as they are.
+func (m *Matcher) Expand(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		m := m
+		if rel, err := filepath.Rel(m.root, mustAbs(path)); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			if m, err = New(path); err != nil {
+				return nil, err
+			}
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && p == path {
+				return nil
+			}
+			if d.IsDir() && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && !strings.EqualFold(filepath.Ext(p), ".json") {
+				return nil
+			}
+			ignored, err := m.match(tokensOf(m.root, p), d.IsDir())
+			if err != nil {
+				return err
+			}
+			switch {
+			case ignored && d.IsDir():
+				return filepath.SkipDir
+			case !ignored && !d.IsDir():
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// tokensOf returns the tokens of a path relative to root
+func tokensOf(root, path string) []string {
+	rel, err := filepath.Rel(root, mustAbs(path))
+	if err != nil || rel == "." {
+		return nil
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")
+}
+
+// mustAbs returns the absolute form of path, or path when it has none
+func mustAbs(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
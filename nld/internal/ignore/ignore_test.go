@@ -0,0 +1,122 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles creates files with their content under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIgnored(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		FileName:           "# generated output\nout/\n*.draft.json\n!keep.draft.json\n/root-only.json\ndocs/**/old-*.json\n\\#literal.json\n",
+		"docs/" + FileName: "internal/\n!out/\n",
+	})
+	m, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"contract.json", false, false},
+		{"out", true, true},
+		{"out/contract.json", false, true},
+		{"a/out/contract.json", false, true},
+		// out/ matches directories only
+		{"out", false, false},
+		{"contract.draft.json", false, true},
+		{"a/b/contract.draft.json", false, true},
+		{"keep.draft.json", false, false},
+		{"root-only.json", false, true},
+		{"a/root-only.json", false, false},
+		{"docs/old-terms.json", false, true},
+		{"docs/2020/old-terms.json", false, true},
+		{"old-terms.json", false, false},
+		{"#literal.json", false, true},
+		{"docs/internal/memo.json", false, true},
+		{"internal/memo.json", false, false},
+		// The deeper ignore file re-includes out/ below docs
+		{"docs/out/contract.json", false, false},
+		{"../outside.json", false, false},
+	}
+	for _, tt := range tests {
+		ignored, err := m.Ignored(filepath.Join(dir, filepath.FromSlash(tt.path)), tt.isDir)
+		if err != nil {
+			t.Fatalf("Ignored(%s) failed: %v", tt.path, err)
+		}
+		if ignored != tt.ignored {
+			t.Errorf("Ignored(%s, %v) = %v, want %v", tt.path, tt.isDir, ignored, tt.ignored)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		FileName:                      "drafts/\n*.draft.json\n",
+		"contract.json":               "{}",
+		"contract.draft.json":         "{}",
+		"notes.txt":                   "",
+		"drafts/terms.json":           "{}",
+		"receipts/2025/r1.json":       "{}",
+		"receipts/2025/r1.JSON":       "{}",
+		"receipts/2025/r2.draft.json": "{}",
+		".git/config.json":            "{}",
+		"receipts/" + FileName:        "2024/\n",
+		"receipts/2024/old.json":      "{}",
+		"receipts/2025/" + "x.md":     "",
+	})
+	other := filepath.Join(dir, "drafts", "terms.json")
+
+	// dir is outside the root, so its own ignore files apply
+	m, err := New(filepath.Join(dir, "receipts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := m.Expand([]string{dir, other})
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "contract.json"),
+		filepath.Join(dir, "receipts", "2025", "r1.JSON"),
+		filepath.Join(dir, "receipts", "2025", "r1.json"),
+		// Files named explicitly are kept
+		other,
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected %v, got %v", want, files)
+	}
+
+	// The ignore files above a directory within the root apply too
+	m, err = New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err = m.Expand([]string{filepath.Join(dir, "receipts")})
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("Expected the files of receipts/2025, got %v", files)
+	}
+}
@@ -0,0 +1,341 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/classify"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Importers describe inline text in Markdown form (**bold**, *emphasis*)
+// so that the layout written by the renderers and exporters, such as the
+// bold term of a definition, can be recognised in any source format.
+var (
+	labelLine     = regexp.MustCompile(`^\*\*([^*]+?):\*\*\s*(.+)$`)
+	definitionRef = regexp.MustCompile(`^\*\*"(.+?)"\*\*\s+means\s+`)
+	partyItem     = regexp.MustCompile(`^\*\*(.+?)\*\*\s*(?:\((.+)\))?$`)
+	termItem      = regexp.MustCompile(`^\*\*(.+?)\*\*:?\s*:?\s*(.*)$`)
+
+	links    = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	strong   = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	emphasis = regexp.MustCompile(`(^|[^\w*])[*_]([^*_]+?)[*_]([^\w*]|$)`)
+	code     = regexp.MustCompile("`([^`]*)`")
+)
+
+// item is a heading or block read from a source document
+type item struct {
+	// location describes where in the source the item starts
+	location string
+	// heading is the level of a heading, or 0 for a block
+	heading int
+	text    string
+	block   nld.Block
+	// raw is the Markdown inline text of a paragraph
+	raw string
+}
+
+// build assembles a document from metadata and the items of the source.
+// Headings become sections nested by level, and the Parties, Definitions
+// and Signatures sections written by the renderers are read back into
+// entities and definitions or dropped.
+func build(meta map[string]interface{}, items []item, opts Options, warnings []Warning) (*Result, error) {
+	var o outline
+	var entities []interface{}
+	var definitions []nld.Definition
+	// chrome is the Parties, Definitions or Signatures heading whose
+	// content is being read; it becomes a section only if it holds other
+	// content
+	chrome, chromeTitle, chromeLevel := "", "", 0
+	open := func() {
+		if chrome != "" && chrome != "signatures" {
+			o.heading(chromeLevel, chromeTitle)
+		}
+		chrome = ""
+	}
+	for _, it := range items {
+		if it.heading > 0 {
+			title := stripNumber(it.text)
+			if chrome == "signatures" && it.heading > chromeLevel {
+				warnings = append(warnings, Warning{Location: it.location, Message: fmt.Sprintf("heading %q inside signatures was not imported", it.text)})
+				continue
+			}
+			if chrome != "" && it.heading > chromeLevel {
+				open()
+			}
+			chrome = ""
+			switch strings.ToLower(title) {
+			case "parties", "definitions", "signatures":
+				chrome, chromeTitle, chromeLevel = strings.ToLower(title), title, it.heading
+				if chrome == "signatures" {
+					warnings = append(warnings, Warning{Location: it.location, Message: "signatures are not imported; sign the imported document instead"})
+				}
+				continue
+			}
+			o.heading(it.heading, title)
+			continue
+		}
+
+		switch chrome {
+		case "parties":
+			if it.block.Type != nld.BlockList {
+				break
+			}
+			for _, item := range it.block.Items {
+				if m := partyItem.FindStringSubmatch(item); m != nil {
+					entity := map[string]interface{}{"id": slug(m[1]), "name": plain(m[1]), "role": plain(m[2])}
+					entities = append(entities, entity)
+				} else {
+					warnings = append(warnings, Warning{Location: it.location, Message: fmt.Sprintf("party %q was not imported", item)})
+				}
+			}
+			continue
+		case "definitions":
+			// Definitions are list items or paragraphs of a bold term
+			// followed by its definition
+			entries := it.block.Items
+			if it.block.Type == nld.BlockParagraph {
+				entries = []string{it.raw}
+			}
+			if it.block.Type == nld.BlockList || termItem.MatchString(it.raw) {
+				for _, entry := range entries {
+					if m := termItem.FindStringSubmatch(entry); m != nil {
+						definitions = append(definitions, nld.Definition{Term: plain(m[1]), Definition: plain(m[2])})
+					}
+				}
+				continue
+			}
+		case "signatures":
+			continue
+		}
+
+		if it.block.Type == blockDefinitions {
+			definitions = append(definitions, listDefinitions(it.block)...)
+			continue
+		}
+
+		// Labels such as "**Jurisdiction:** England" above the first
+		// section are metadata
+		if len(o.roots) == 0 && chrome == "" && it.block.Type == nld.BlockParagraph {
+			if m := labelLine.FindStringSubmatch(it.raw); m != nil && label(meta, m[1], plain(m[2])) {
+				continue
+			}
+		}
+		if it.block.Type == nld.BlockList {
+			for i, item := range it.block.Items {
+				it.block.Items[i] = plain(item)
+			}
+		}
+		open()
+		o.add(it.block)
+	}
+
+	if len(entities) > 0 && meta["entities"] == nil {
+		meta["entities"] = entities
+	}
+	resolveDefinitionRefs(o.roots, definitions)
+	sections := o.sections()
+
+	body := map[string]interface{}{"sections": sections}
+	if sections == nil {
+		body["sections"] = []nld.Section{}
+	}
+	if len(definitions) > 0 {
+		body["definitions"] = definitions
+	}
+	var classified *classify.Suggestion
+	if opts.Type == "" && (meta["type"] == nil || meta["type"] == "") {
+		if classified = classifyType(meta, body); classified != nil {
+			meta["type"] = classified.Type
+		}
+	}
+	doc := newDocument(meta, opts)
+	doc["content"] = body
+	result, err := finish(doc, warnings)
+	if err != nil {
+		return nil, err
+	}
+	result.Classified = classified
+	return result, nil
+}
+
+// classifyType returns the type the content of a document suggests, or nil
+// when it suggests none with enough confidence
+func classifyType(meta, body map[string]interface{}) *classify.Suggestion {
+	data, err := json.Marshal(map[string]interface{}{"metadata": meta, "content": body})
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil
+	}
+	best := classify.Document(v)[0]
+	if best.Score < classify.MinScore {
+		return nil
+	}
+	return &best
+}
+
+// leadingTitle returns the text of a level 1 heading that starts the
+// items and is the only one, which is the document title
+func leadingTitle(items []item) string {
+	h1 := 0
+	for _, it := range items {
+		if it.heading == 1 {
+			h1++
+		}
+	}
+	if len(items) > 0 && items[0].heading == 1 && h1 == 1 {
+		return items[0].text
+	}
+	return ""
+}
+
+// label stores a rendered metadata label, reporting whether it was one
+func label(meta map[string]interface{}, name, value string) bool {
+	switch strings.ToLower(name) {
+	case "jurisdiction":
+		if meta["jurisdiction"] == nil {
+			meta["jurisdiction"] = value
+		}
+		return true
+	case "effective", "expires":
+		key := strings.ToLower(name)
+		if meta[key] == nil {
+			if t, err := time.Parse("January 2, 2006", value); err == nil {
+				meta[key] = t.Format(nld.DateLayout)
+			} else {
+				meta[key] = value
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// blockDefinitions is the type of the pseudo block holding a definition
+// list; it never appears in a section
+const blockDefinitions = "definitions"
+
+// listDefinitions returns the entries of a definition list block, stored
+// as alternating terms and definitions in Items
+func listDefinitions(b nld.Block) []nld.Definition {
+	var defs []nld.Definition
+	for i := 0; i+1 < len(b.Items); i += 2 {
+		defs = append(defs, nld.Definition{Term: b.Items[i], Definition: b.Items[i+1]})
+	}
+	return defs
+}
+
+// table returns a table block. Cells hold Markdown inline text, number
+// columns are marked in numeric, and a bold last row such as the one
+// written by nld render is read back as column totals.
+func table(titles []string, numeric []bool, body [][]string) nld.Block {
+	block := nld.Block{Type: nld.BlockTable}
+	hasNumbers := false
+	columns := make([]nld.Column, len(titles))
+	for i, t := range titles {
+		columns[i].Title = plain(t)
+		if i < len(numeric) && numeric[i] {
+			columns[i].Type = nld.ColumnNumber
+			hasNumbers = true
+		}
+	}
+
+	if n := len(body); n > 0 && isTotals(body[n-1]) {
+		last := body[n-1]
+		body = body[:n-1]
+		for i, c := range last {
+			if i == 0 {
+				block.TotalLabel = plain(c)
+			} else if i < len(columns) && c != "" && columns[i].Type == nld.ColumnNumber {
+				columns[i].Total = true
+			}
+		}
+		hasNumbers = true
+		if block.TotalLabel == "Total" {
+			block.TotalLabel = ""
+		}
+	}
+
+	for _, r := range body {
+		row := make([]string, len(titles))
+		for i := range row {
+			if i < len(r) {
+				row[i] = plain(r[i])
+			}
+		}
+		block.Rows = append(block.Rows, row)
+	}
+	if block.Rows == nil {
+		block.Rows = [][]string{}
+	}
+
+	if hasNumbers {
+		block.Columns = columns
+	} else {
+		for _, c := range columns {
+			block.Header = append(block.Header, c.Title)
+		}
+	}
+	return block
+}
+
+// isTotals reports whether a table row is a bold totals row
+func isTotals(row []string) bool {
+	if len(row) < 2 || !strings.HasPrefix(row[0], "**") {
+		return false
+	}
+	for _, c := range row {
+		if c != "" && !(strings.HasPrefix(c, "**") && strings.HasSuffix(c, "**")) {
+			return false
+		}
+	}
+	return true
+}
+
+// literal escapes the characters of plain text that Markdown inline
+// formatting would interpret
+func literal(s string) string {
+	return strings.NewReplacer("*", `\*`, "_", `\_`).Replace(s)
+}
+
+// plain removes inline Markdown formatting
+func plain(s string) string {
+	s = links.ReplaceAllString(s, "$1")
+	s = code.ReplaceAllString(s, "$1")
+	s = strong.ReplaceAllString(s, "$1$2")
+	s = emphasis.ReplaceAllString(s, "$1$2$3")
+	s = strings.NewReplacer(`\*`, "*", `\_`, "_", `\#`, "#", `\|`, "|").Replace(s)
+	return strings.TrimSpace(s)
+}
+
+// resolveDefinitionRefs keeps definitionRef blocks for defined terms and
+// turns the others back into paragraphs
+func resolveDefinitionRefs(nodes []*node, definitions []nld.Definition) {
+	defined := map[string]bool{}
+	for _, d := range definitions {
+		defined[d.Term] = true
+	}
+	var resolve func(nodes []*node)
+	resolve = func(nodes []*node) {
+		for _, n := range nodes {
+			for i, b := range n.blocks {
+				if b.Type != nld.BlockDefinitionRef {
+					continue
+				}
+				if defined[b.Term] {
+					n.blocks[i].Text = ""
+				} else {
+					n.blocks[i] = nld.Block{Type: nld.BlockParagraph, Text: b.Text}
+				}
+			}
+			resolve(n.children)
+		}
+	}
+	resolve(nodes)
+}
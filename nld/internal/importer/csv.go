@@ -0,0 +1,380 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/colemalphrus/nld/internal/receipt"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// FieldItem is the line item field holding the item description. The
+// other fields use the receipt column keys.
+const FieldItem = "item"
+
+// receiptFields lists the line item fields in column order
+var receiptFields = []string{FieldItem, receipt.KeyQuantity, receipt.KeyUnitPrice, receipt.KeyAmount}
+
+// fieldHeaders are the column headers recognised for each line item field,
+// lower case with punctuation and spaces removed
+var fieldHeaders = map[string][]string{
+	FieldItem:            {"item", "description", "product", "name", "article", "service"},
+	receipt.KeyQuantity:  {"quantity", "qty", "units", "count"},
+	receipt.KeyUnitPrice: {"unitprice", "price", "unitcost", "rate", "each", "priceeach"},
+	receipt.KeyAmount:    {"amount", "total", "linetotal", "lineamount", "sum", "value"},
+}
+
+// fieldTitles are the column titles written for each line item field
+var fieldTitles = map[string]string{
+	FieldItem:            "Item",
+	receipt.KeyQuantity:  "Quantity",
+	receipt.KeyUnitPrice: "Unit Price",
+	receipt.KeyAmount:    "Amount",
+}
+
+// currencySymbols maps currency symbols found in amounts to ISO 4217 codes
+var currencySymbols = map[string]string{"$": "USD", "€": "EUR", "£": "GBP", "¥": "JPY", "₹": "INR", "CHF": "CHF"}
+
+// summaryRow matches the subtotal, tax and total rows that spreadsheets
+// often carry below their line items
+var summaryRow = regexp.MustCompile(`(?i)^\s*(sub-?total|total|grand total|tax|vat|gst|sales tax)\b`)
+
+// CSV imports a receipt from comma, semicolon or tab separated values. The
+// first row holds column headers; each later row is a line item. Columns
+// are matched to line item fields by their headers (Item or Description,
+// Quantity or Qty, Unit Price or Price, Amount or Total) unless mapped
+// explicitly in Options.Columns. Missing line amounts are computed from
+// quantity and unit price, and the subtotal, taxes and total are computed
+// from the line items.
+func CSV(data []byte, opts Options) (*Result, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = delimiter(data)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	return receiptFromRows(rows, "line", opts)
+}
+
+// delimiter guesses the field separator from the first line
+func delimiter(data []byte) rune {
+	first := string(data)
+	if i := strings.IndexByte(first, '\n'); i >= 0 {
+		first = first[:i]
+	}
+	best, count := ',', strings.Count(first, ",")
+	for _, d := range []rune{';', '\t'} {
+		if n := strings.Count(first, string(d)); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+// normalizeHeader lower-cases a header and removes everything but letters
+// and digits
+func normalizeHeader(h string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(h) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// mapColumns returns the column index of each line item field. Columns
+// given in opts are matched by header or 1-based number; the others are
+// recognised from their headers.
+func mapColumns(header []string, opts Options) (map[string]int, error) {
+	columns := map[string]int{}
+	used := map[int]bool{}
+	for field, column := range opts.Columns {
+		if fieldTitles[field] == "" {
+			return nil, fmt.Errorf("unknown line item field %q (expected %s)", field, strings.Join(receiptFields, ", "))
+		}
+		index := -1
+		var n int
+		if _, err := fmt.Sscanf(column, "%d", &n); err == nil && fmt.Sprint(n) == column {
+			index = n - 1
+		} else {
+			for i, h := range header {
+				if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(column)) || normalizeHeader(h) == normalizeHeader(column) {
+					index = i
+					break
+				}
+			}
+		}
+		if index < 0 || index >= len(header) {
+			return nil, fmt.Errorf("column %q for %s not found", column, field)
+		}
+		columns[field] = index
+		used[index] = true
+	}
+
+	for _, field := range receiptFields {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+		for _, name := range fieldHeaders[field] {
+			found := -1
+			for i, h := range header {
+				if !used[i] && normalizeHeader(h) == name {
+					found = i
+					break
+				}
+			}
+			if found >= 0 {
+				columns[field] = found
+				used[found] = true
+				break
+			}
+		}
+	}
+
+	if _, ok := columns[FieldItem]; !ok {
+		return nil, fmt.Errorf("no item column found (map one with --column item=<header>)")
+	}
+	_, hasAmount := columns[receipt.KeyAmount]
+	_, hasQuantity := columns[receipt.KeyQuantity]
+	_, hasPrice := columns[receipt.KeyUnitPrice]
+	if !hasAmount && !(hasQuantity && hasPrice) {
+		return nil, fmt.Errorf("no amount column found, and no quantity and unit price columns to compute it from (map them with --column)")
+	}
+	return columns, nil
+}
+
+// parseAmount converts a spreadsheet number such as "$1,299.99", "1.299,99"
+// or "(5.00)" to a decimal string, returning any currency symbol found
+func parseAmount(s string) (amount, currency string, ok bool) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")")
+	s = strings.Trim(s, "()")
+	for symbol, code := range currencySymbols {
+		if strings.Contains(s, symbol) {
+			currency = code
+			s = strings.ReplaceAll(s, symbol, "")
+		}
+	}
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	if strings.HasPrefix(s, "-") {
+		negative = !negative
+		s = s[1:]
+	}
+
+	// The last of "." and "," is the decimal separator, unless a lone
+	// comma is followed by three digits as in "1,000"
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case dot >= 0 && comma >= 0 && comma > dot:
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	case comma >= 0 && dot < 0 && strings.Count(s, ",") == 1 && len(s)-comma-1 != 3:
+		s = strings.Replace(s, ",", ".", 1)
+	default:
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	if negative {
+		s = "-" + s
+	}
+	if _, err := nld.NewMoney(s, ""); err != nil || s == "" {
+		return "", currency, false
+	}
+	return s, currency, true
+}
+
+// receiptFromRows builds a receipt from a header row and line item rows.
+// unit names the rows in warnings, such as "line" or "row".
+func receiptFromRows(rows [][]string, unit string, opts Options) (*Result, error) {
+	if opts.Type != "" && opts.Type != "receipt" {
+		return nil, fmt.Errorf("line item import produces receipts, not %s documents", opts.Type)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rows to import")
+	}
+	columns, err := mapColumns(rows[0], opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []Warning
+	mapped := map[int]bool{}
+	for _, i := range columns {
+		mapped[i] = true
+	}
+	for i, h := range rows[0] {
+		if !mapped[i] && strings.TrimSpace(h) != "" {
+			warnings = append(warnings, Warning{Location: "header", Message: fmt.Sprintf("column %q was not imported", h)})
+		}
+	}
+
+	currency := strings.ToUpper(opts.Currency)
+	symbols := map[string]bool{}
+	cell := func(row []string, field string) string {
+		if i, ok := columns[field]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	// Columns are written in field order, for the fields present
+	var fields []string
+	for _, f := range receiptFields {
+		if _, ok := columns[f]; ok || f == receipt.KeyAmount {
+			fields = append(fields, f)
+		}
+	}
+
+	var amounts []nld.Money
+	var lines [][]string
+	for n, row := range rows[1:] {
+		loc := fmt.Sprintf("%s %d", unit, n+2)
+		item := cell(row, FieldItem)
+		if strings.Join(row, "") == "" {
+			continue
+		}
+		if summaryRow.MatchString(item) {
+			warnings = append(warnings, Warning{Location: loc, Message: fmt.Sprintf("%q row skipped; totals are computed from the line items", item)})
+			continue
+		}
+
+		values := map[string]string{FieldItem: item}
+		valid := true
+		for _, f := range []string{receipt.KeyQuantity, receipt.KeyUnitPrice, receipt.KeyAmount} {
+			raw := cell(row, f)
+			if raw == "" {
+				continue
+			}
+			amount, symbol, ok := parseAmount(raw)
+			if !ok {
+				warnings = append(warnings, Warning{Location: loc, Message: fmt.Sprintf("%s %q is not a number; row skipped", fieldTitles[f], raw)})
+				valid = false
+				break
+			}
+			if symbol != "" {
+				symbols[symbol] = true
+			}
+			values[f] = amount
+		}
+		if !valid {
+			continue
+		}
+
+		quantity, price := values[receipt.KeyQuantity], values[receipt.KeyUnitPrice]
+		if quantity == "" && price != "" {
+			quantity = "1"
+			values[receipt.KeyQuantity] = quantity
+		}
+		if quantity != "" && price != "" {
+			computed, _ := nld.Money{Amount: price}.Mul(quantity)
+			if values[receipt.KeyAmount] == "" {
+				values[receipt.KeyAmount] = computed.Amount
+			} else if cmp, _ := computed.Cmp(nld.Money{Amount: values[receipt.KeyAmount]}); cmp != 0 {
+				warnings = append(warnings, Warning{Location: loc, Message: fmt.Sprintf("amount %s does not match %s × %s", values[receipt.KeyAmount], quantity, price)})
+			}
+		}
+		if values[receipt.KeyAmount] == "" {
+			warnings = append(warnings, Warning{Location: loc, Message: "row has no amount; row skipped"})
+			continue
+		}
+
+		var line []string
+		for _, f := range fields {
+			line = append(line, values[f])
+		}
+		lines = append(lines, line)
+		amounts = append(amounts, nld.Money{Amount: values[receipt.KeyAmount]})
+	}
+
+	if currency == "" {
+		var found []string
+		for s := range symbols {
+			found = append(found, s)
+		}
+		sort.Strings(found)
+		if len(found) == 1 {
+			currency = found[0]
+		} else {
+			warnings = append(warnings, Warning{Location: "document", Message: "currency could not be determined; set it with --currency"})
+		}
+	}
+	precision := nld.DefaultCurrencyPrecision
+	if currency != "" {
+		if precision, err = nld.CurrencyPrecision(currency); err != nil {
+			return nil, err
+		}
+	}
+
+	// Line amounts are rounded to the currency before they are added up
+	amountColumn := len(fields) - 1
+	for i := range lines {
+		rounded, err := nld.Money{Amount: lines[i][amountColumn]}.Round(precision)
+		if err != nil {
+			return nil, err
+		}
+		lines[i][amountColumn] = rounded.Amount
+		amounts[i] = rounded
+	}
+	totals, err := receiptTotals(amounts, opts.Taxes, currency, precision)
+	if err != nil {
+		return nil, err
+	}
+
+	var tableColumns []nld.Column
+	for _, f := range fields {
+		c := nld.Column{Title: fieldTitles[f]}
+		if f != FieldItem {
+			c.Type, c.Key = nld.ColumnNumber, f
+		}
+		c.Total = f == receipt.KeyAmount
+		tableColumns = append(tableColumns, c)
+	}
+	if lines == nil {
+		lines = [][]string{}
+	}
+	table := nld.Block{Type: nld.BlockTable, Columns: tableColumns, Rows: lines}
+
+	doc := newDocument(map[string]interface{}{"type": "receipt"}, opts)
+	doc["content"] = map[string]interface{}{
+		"sections": []nld.Section{{ID: "items", Title: "Items", Blocks: []nld.Block{table}}},
+		"totals":   totals,
+	}
+	return finish(doc, warnings)
+}
+
+// receiptTotals computes the subtotal, taxes and total of line amounts
+func receiptTotals(amounts []nld.Money, taxes []nld.Tax, currency string, precision int) (*nld.Totals, error) {
+	subtotal, err := nld.Sum(amounts...)
+	if err != nil {
+		return nil, err
+	}
+	if subtotal, err = subtotal.Round(precision); err != nil {
+		return nil, err
+	}
+
+	totals := &nld.Totals{Currency: currency, Subtotal: subtotal}
+	total := subtotal
+	for _, tax := range taxes {
+		amount, err := subtotal.Percent(tax.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tax rate %q: %w", tax.Rate, err)
+		}
+		if amount, err = amount.Round(precision); err != nil {
+			return nil, err
+		}
+		totals.Taxes = append(totals.Taxes, nld.Tax{Name: tax.Name, Rate: tax.Rate, Amount: amount})
+		if total, err = total.Add(amount); err != nil {
+			return nil, err
+		}
+	}
+	totals.Total = total
+	return totals, nil
+}
@@ -0,0 +1,216 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/receipt"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// checkReceipt parses an imported receipt and verifies that it is
+// schema-valid and adds up
+func checkReceipt(t *testing.T, result *Result) *nld.Document {
+	t.Helper()
+	data, err := json.Marshal(result.Document)
+	if err != nil {
+		t.Fatalf("Failed to encode document: %v", err)
+	}
+	doc, err := nld.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse imported document: %v", err)
+	}
+	problems, err := receipt.Check(doc, "")
+	if err != nil {
+		t.Fatalf("Failed to check receipt: %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Expected receipt to add up, got %v", problems)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	v := validator.New()
+	schema, err := v.LoadSchema(filepath.Join(wd, "..", "..", "schemas", "document-v1.json"))
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+	validation, err := v.ValidateBytes(data, schema)
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if !validation.Valid {
+		t.Errorf("Expected imported receipt to be valid, got %v", validation.Errors)
+	}
+	return doc
+}
+
+func TestCSV(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   string
+		opts     Options
+		rows     [][]string
+		subtotal string
+		total    string
+		currency string
+		warnings []string
+		mismatch bool
+	}{
+		{
+			name:     "headers",
+			source:   "Description,Qty,Unit Price,Amount\nWidget,2,$1.50,$3.00\nGadget,1,\"$1,299.99\",\"$1,299.99\"\n",
+			rows:     [][]string{{"Widget", "2", "1.50", "3.00"}, {"Gadget", "1", "1299.99", "1299.99"}},
+			subtotal: "1302.99",
+			total:    "1302.99",
+			currency: "USD",
+		},
+		{
+			name:     "computed amounts and taxes",
+			source:   "Item;Quantity;Price\nCoffee;3;2,50\nCake;1;4,20\nTotal;;11,70\n",
+			opts:     Options{Currency: "eur", Taxes: []nld.Tax{{Name: "VAT", Rate: "20"}}},
+			rows:     [][]string{{"Coffee", "3", "2.50", "7.50"}, {"Cake", "1", "4.20", "4.20"}},
+			subtotal: "11.70",
+			total:    "14.04",
+			currency: "EUR",
+			warnings: []string{`line 4: "Total" row skipped; totals are computed from the line items`},
+		},
+		{
+			name:     "mapped columns",
+			source:   "SKU\tWhat\tCost\nA-1\tPaper\t(5.00)\nA-2\tInk\t12\n",
+			opts:     Options{Columns: map[string]string{"item": "What", "amount": "3"}},
+			rows:     [][]string{{"Paper", "-5.00"}, {"Ink", "12.00"}},
+			subtotal: "7.00",
+			total:    "7.00",
+			warnings: []string{
+				`header: column "SKU" was not imported`,
+				"document: currency could not be determined; set it with --currency",
+			},
+		},
+		{
+			name:     "mismatched and invalid rows",
+			source:   "Item,Qty,Price,Total\nBolts,10,0.25,2.60\nNuts,ten,0.10,1.00\n",
+			opts:     Options{Currency: "USD"},
+			rows:     [][]string{{"Bolts", "10", "0.25", "2.60"}},
+			subtotal: "2.60",
+			total:    "2.60",
+			currency: "USD",
+			warnings: []string{
+				"line 2: amount 2.60 does not match 10 × 0.25",
+				`line 3: Quantity "ten" is not a number; row skipped`,
+			},
+			mismatch: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Import("csv", []byte(tc.source), tc.opts)
+			if err != nil {
+				t.Fatalf("Failed to import: %v", err)
+			}
+			var warnings []string
+			for _, w := range result.Warnings {
+				warnings = append(warnings, w.Location+": "+w.Message)
+			}
+			if !reflect.DeepEqual(warnings, tc.warnings) {
+				t.Errorf("Expected warnings %q, got %q", tc.warnings, warnings)
+			}
+			if tc.mismatch {
+				// The mismatch is kept as imported, so the receipt does not
+				// add up; only the rows are checked
+				data, _ := json.Marshal(result.Document)
+				doc, err := nld.Parse(data)
+				if err != nil {
+					t.Fatalf("Failed to parse imported document: %v", err)
+				}
+				if rows := doc.Structure.Sections[0].Blocks[0].Rows; !reflect.DeepEqual(rows, tc.rows) {
+					t.Errorf("Expected rows %q, got %q", tc.rows, rows)
+				}
+				return
+			}
+
+			doc := checkReceipt(t, result)
+			if doc.Metadata.Type != "receipt" {
+				t.Errorf("Expected type receipt, got %q", doc.Metadata.Type)
+			}
+			if rows := doc.Structure.Sections[0].Blocks[0].Rows; !reflect.DeepEqual(rows, tc.rows) {
+				t.Errorf("Expected rows %q, got %q", tc.rows, rows)
+			}
+			totals := doc.Structure.Totals
+			if totals.Subtotal.Amount != tc.subtotal || totals.Total.Amount != tc.total || totals.Currency != tc.currency {
+				t.Errorf("Expected %s %s / %s, got %s %s / %s", tc.currency, tc.subtotal, tc.total, totals.Currency, totals.Subtotal.Amount, totals.Total.Amount)
+			}
+		})
+	}
+}
+
+func TestCSVInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		source string
+		opts   Options
+		err    string
+	}{
+		{"no item column", "Qty,Amount\n1,2\n", Options{}, "no item column"},
+		{"no amount column", "Item,Qty\nA,1\n", Options{}, "no amount column"},
+		{"unknown field", "Item,Amount\nA,1\n", Options{Columns: map[string]string{"sku": "1"}}, "unknown line item field"},
+		{"missing column", "Item,Amount\nA,1\n", Options{Columns: map[string]string{"amount": "Cost"}}, `column "Cost" for amount not found`},
+		{"other type", "Item,Amount\nA,1\n", Options{Type: "contract"}, "not contract documents"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Import("csv", []byte(tc.source), tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	const ns = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"xl/workbook.xml": `<workbook ` + ns + `><sheets><sheet name="Items" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/items.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst ` + ns + `><si><t>Item</t></si><si><t>Qty</t></si><si><t>Price</t></si><si><r><t>Blue </t></r><r><t>pen</t></r></si></sst>`,
+		"xl/worksheets/items.xml": `<worksheet ` + ns + `><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>3</v></c><c r="B2"><v>3</v></c><c r="C2"><v>0.10000000000000001</v></c></row>` +
+			`<row r="3"><c r="A3" t="inlineStr"><is><t>Pad</t></is></c><c r="C3"><v>2.5</v></c></row>` +
+			`</sheetData></worksheet>`,
+	} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+
+	result, err := Import("xlsx", buf.Bytes(), Options{Currency: "USD"})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", result.Warnings)
+	}
+	doc := checkReceipt(t, result)
+	want := [][]string{{"Blue pen", "3", "0.1", "0.30"}, {"Pad", "1", "2.5", "2.50"}}
+	if rows := doc.Structure.Sections[0].Blocks[0].Rows; !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected rows %q, got %q", want, rows)
+	}
+	if total := doc.Structure.Totals.Total.Amount; total != "2.80" {
+		t.Errorf("Expected total 2.80, got %s", total)
+	}
+}
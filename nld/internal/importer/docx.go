@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/docx"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+var (
+	// listMarker matches the typed bullets and numbers that start list items
+	// without Word numbering, as written by nld export
+	listMarker = regexp.MustCompile(`^\s*(?:([•·▪◦‣\-–])|(\d+[.)]))\s+`)
+	decimal    = regexp.MustCompile(`^-?\d+(?:\.\d+)?$`)
+)
+
+// DOCX imports a Word document. Headings become sections nested by their
+// outline level, and paragraphs, lists and tables become content. The
+// metadata embedded by nld export is restored; otherwise the title and
+// author are taken from the document properties. Images, footnotes,
+// comments, tracked changes and merged table cells are reported as
+// warnings.
+func DOCX(data []byte, opts Options) (*Result, error) {
+	content, err := docx.Read(data)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := map[string]interface{}{}
+	embedded, ok, err := docx.ReadMetadata(data)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		encoded, err := json.Marshal(embedded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode metadata: %w", err)
+		}
+		if err := json.Unmarshal(encoded, &meta); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata: %w", err)
+		}
+		for k, v := range meta {
+			if v == "" {
+				delete(meta, k)
+			}
+		}
+	} else {
+		if content.Title != "" {
+			meta["title"] = content.Title
+		}
+		if content.Creator != "" {
+			meta["author"] = content.Creator
+		}
+	}
+
+	var items []item
+	var warnings []Warning
+	var list *item
+	flush := func() {
+		if list != nil {
+			items = append(items, *list)
+			list = nil
+		}
+	}
+
+	paragraphs, tables := 0, 0
+	for _, el := range content.Elements {
+		if t := el.Table; t != nil {
+			tables++
+			loc := fmt.Sprintf("table %d", tables)
+			flush()
+			for _, u := range t.Unmapped {
+				warnings = append(warnings, Warning{Location: loc, Message: u + " flattened"})
+			}
+			if block, ok := docxTable(t); ok {
+				items = append(items, item{location: loc, block: block})
+			}
+			continue
+		}
+
+		p := el.Paragraph
+		paragraphs++
+		loc := fmt.Sprintf("paragraph %d", paragraphs)
+		for _, u := range p.Unmapped {
+			warnings = append(warnings, Warning{Location: loc, Message: u + " was not imported"})
+		}
+		raw := inline(p.Runs)
+		if raw == "" {
+			continue
+		}
+
+		switch {
+		case p.Title:
+			flush()
+			if meta["title"] == nil {
+				meta["title"] = plain(raw)
+			}
+		case p.Level > 0:
+			flush()
+			items = append(items, item{location: loc, heading: p.Level, text: strings.Join(strings.Fields(p.Text()), " ")})
+		case p.List || listMarker.MatchString(raw):
+			ordered := p.Ordered
+			if m := listMarker.FindStringSubmatch(raw); m != nil {
+				ordered = ordered || m[2] != ""
+				raw = raw[len(m[0]):]
+			}
+			if list != nil && list.block.Ordered != ordered {
+				flush()
+			}
+			if list == nil {
+				list = &item{location: loc, block: nld.Block{Type: nld.BlockList, Ordered: ordered}}
+			}
+			list.block.Items = append(list.block.Items, raw)
+		default:
+			flush()
+			it := item{location: loc, block: nld.Block{Type: nld.BlockParagraph, Text: plain(raw)}, raw: raw}
+			if m := definitionRef.FindStringSubmatch(raw); m != nil {
+				it.block = nld.Block{Type: nld.BlockDefinitionRef, Term: m[1], Text: plain(raw)}
+			}
+			items = append(items, it)
+		}
+	}
+	flush()
+
+	return build(meta, items, opts, warnings)
+}
+
+// inline returns the text of runs in Markdown inline form, marking bold
+// text with ** and escaping characters that Markdown would interpret
+func inline(runs []docx.Run) string {
+	var merged []docx.Run
+	for _, r := range runs {
+		if r.Text == "" {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].Bold == r.Bold {
+			merged[n-1].Text += r.Text
+		} else {
+			merged = append(merged, r)
+		}
+	}
+
+	var b strings.Builder
+	for _, r := range merged {
+		text := literal(strings.ReplaceAll(r.Text, "\t", " "))
+		trimmed := strings.TrimSpace(text)
+		if !r.Bold || trimmed == "" {
+			b.WriteString(text)
+			continue
+		}
+		// Spaces stay outside the markers so that "**Jurisdiction:** X"
+		// reads as a label
+		lead := text[:strings.Index(text, trimmed)]
+		b.WriteString(lead + "**" + trimmed + "**" + text[len(lead)+len(trimmed):])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// docxTable converts a Word table. Leading header rows, or the first row,
+// give the column titles, and columns holding only decimal numbers are
+// number columns.
+func docxTable(t *docx.Table) (nld.Block, bool) {
+	if len(t.Rows) == 0 {
+		return nld.Block{}, false
+	}
+	header := 1
+	for header < len(t.Rows) && t.Rows[header].Header {
+		header++
+	}
+
+	cellText := func(c docx.Cell) string {
+		var parts []string
+		for _, p := range c.Paragraphs {
+			if text := inline(p.Runs); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+
+	var titles []string
+	for _, c := range t.Rows[header-1].Cells {
+		titles = append(titles, cellText(c))
+	}
+	var body [][]string
+	for _, r := range t.Rows[header:] {
+		var row []string
+		for _, c := range r.Cells {
+			row = append(row, cellText(c))
+		}
+		body = append(body, row)
+	}
+
+	numeric := make([]bool, len(titles))
+	for i := range titles {
+		count := 0
+		numeric[i] = true
+		for _, row := range body {
+			if i >= len(row) || row[i] == "" {
+				continue
+			}
+			if !decimal.MatchString(strings.Trim(row[i], "*")) {
+				numeric[i] = false
+			}
+			count++
+		}
+		numeric[i] = numeric[i] && count > 0
+	}
+	return table(titles, numeric, body), true
+}
@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/docx"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+func TestDOCXRoundTrip(t *testing.T) {
+	original, err := nld.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := docx.Write(&buf, original, docx.Options{}); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	result, err := Import("docx", buf.Bytes(), Options{})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", result.Warnings)
+	}
+	data, _ := json.Marshal(result.Document)
+	doc, err := nld.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse imported document: %v", err)
+	}
+	if !reflect.DeepEqual(doc.Metadata, original.Metadata) {
+		t.Errorf("Expected metadata %+v, got %+v", original.Metadata, doc.Metadata)
+	}
+	if !reflect.DeepEqual(doc.Structure, original.Structure) {
+		got, _ := json.Marshal(doc.Structure)
+		want, _ := json.Marshal(original.Structure)
+		t.Errorf("Expected structure\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestDOCX(t *testing.T) {
+	const w = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"word/document.xml": `<w:document ` + w + `><w:body>` +
+			`<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>1. Rent</w:t></w:r></w:p>` +
+			`<w:p><w:r><w:t>Paid monthly.</w:t></w:r><w:r><w:footnoteReference w:id="1"/></w:r></w:p>` +
+			`<w:p/>` +
+			`<w:p><w:r><w:t>Paid in advance.</w:t></w:r></w:p>` +
+			`<w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>1.1 Amounts</w:t></w:r></w:p>` +
+			`<w:tbl><w:tr><w:tc><w:p><w:r><w:t>Month</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Rent</w:t></w:r></w:p></w:tc></w:tr>` +
+			`<w:tr><w:tc><w:p><w:r><w:t>May</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>900.00</w:t></w:r></w:p></w:tc></w:tr></w:tbl>` +
+			`<w:p><w:pPr><w:pStyle w:val="ListBullet"/></w:pPr><w:r><w:t>Cash</w:t></w:r></w:p>` +
+			`<w:p><w:pPr><w:pStyle w:val="ListBullet"/></w:pPr><w:r><w:t>Cheque</w:t></w:r></w:p>` +
+			`</w:body></w:document>`,
+		"word/styles.xml": `<w:styles ` + w + `><w:style w:type="paragraph" w:styleId="ListBullet"><w:name w:val="List Bullet"/></w:style></w:styles>`,
+		"docProps/core.xml": `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+			`<dc:title>Lease</dc:title><dc:creator>Legal</dc:creator></cp:coreProperties>`,
+	} {
+		f, _ := zw.Create(name)
+		io.WriteString(f, content)
+	}
+	zw.Close()
+
+	result, err := DOCX(buf.Bytes(), Options{Type: "lease", Created: time.Date(2025, 6, 27, 12, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	data, _ := json.Marshal(result.Document)
+	doc, err := nld.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse imported document: %v", err)
+	}
+
+	if m := doc.Metadata; m.Title != "Lease" || m.Author != "Legal" || m.Type != "lease" {
+		t.Errorf("Unexpected metadata %+v", m)
+	}
+	s := doc.Structure.Sections
+	if len(s) != 1 || s[0].Title != "Rent" || s[0].Content != "Paid monthly.\n\nPaid in advance." {
+		t.Fatalf("Unexpected sections %+v", s)
+	}
+	sub := s[0].Sections
+	if len(sub) != 1 || sub[0].ID != "amounts" || len(sub[0].Blocks) != 2 {
+		t.Fatalf("Unexpected subsections %+v", sub)
+	}
+	if cols := sub[0].Blocks[0].Columns; len(cols) != 2 || cols[1].Type != nld.ColumnNumber {
+		t.Errorf("Expected number column, got %+v", cols)
+	}
+	if items := sub[0].Blocks[1].Items; !reflect.DeepEqual(items, []string{"Cash", "Cheque"}) {
+		t.Errorf("Expected list items, got %v", items)
+	}
+
+	if len(result.Warnings) != 1 || result.Warnings[0].Location != "paragraph 2" || !strings.Contains(result.Warnings[0].Message, "footnote") {
+		t.Errorf("Expected footnote warning, got %v", result.Warnings)
+	}
+}
@@ -0,0 +1,454 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/docx"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped are the elements holding page chrome or scripts rather than
+// document text. Headers and footers are only skipped outside of the
+// article or main content.
+var skipped = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true,
+	atom.Template: true, atom.Nav: true, atom.Aside: true, atom.Form: true,
+	atom.Button: true, atom.Select: true, atom.Input: true, atom.Textarea: true,
+	atom.Svg: true, atom.Dialog: true,
+}
+
+// embedded are the elements whose content cannot be imported
+var embedded = map[atom.Atom]string{
+	atom.Img: "image", atom.Picture: "image", atom.Iframe: "embedded frame",
+	atom.Video: "video", atom.Audio: "audio", atom.Object: "embedded object",
+	atom.Embed: "embedded object", atom.Canvas: "canvas",
+}
+
+// HTML imports a web page. The text of the main or article element, or
+// of the body when there is neither, is read: h1 to h6 become sections
+// nested by level, and paragraphs, lists, tables and definition lists
+// become content. Navigation, scripts, forms and the page header and
+// footer are left out. A single leading h1 is the document title,
+// otherwise the page title is used. Images and other embedded content are
+// reported as warnings.
+func HTML(data []byte, opts Options) (*Result, error) {
+	root, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTML: %w", err)
+	}
+
+	meta := map[string]interface{}{}
+	h := &htmlReader{counts: map[string]int{}}
+	var body, content *html.Node
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Html:
+				if lang := attr(n, "lang"); lang != "" {
+					meta["language"] = lang
+				}
+			case atom.Title:
+				if title := collapse(text(n)); title != "" && h.title == "" {
+					h.title = title
+				}
+			case atom.Meta:
+				if strings.EqualFold(attr(n, "name"), "author") && attr(n, "content") != "" {
+					meta["author"] = collapse(attr(n, "content"))
+				}
+			case atom.Body:
+				body = n
+			case atom.Main:
+				if content == nil {
+					content = n
+				}
+			case atom.Article:
+				if content == nil || content.DataAtom != atom.Main {
+					content = n
+				}
+			}
+			if attr(n, "role") == "main" && content == nil {
+				content = n
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(root)
+	if content == nil {
+		content = body
+	}
+	if content != nil {
+		h.block(content, content != body)
+		h.flush()
+	}
+
+	items := h.items
+	if title := leadingTitle(items); title != "" {
+		meta["title"] = title
+		items = items[1:]
+	} else if h.title != "" {
+		meta["title"] = h.title
+	}
+	return build(meta, items, opts, h.warnings)
+}
+
+// htmlReader collects the items of an HTML page
+type htmlReader struct {
+	items    []item
+	warnings []Warning
+	// title is the page title from the head
+	title string
+	// counts numbers the elements of each kind for warning locations
+	counts map[string]int
+	// runs is the inline text of the paragraph being read
+	runs []docx.Run
+}
+
+// locate numbers an element of a kind, such as "paragraph 3"
+func (h *htmlReader) locate(kind string) string {
+	h.counts[kind]++
+	return fmt.Sprintf("%s %d", kind, h.counts[kind])
+}
+
+// flush ends the paragraph being read
+func (h *htmlReader) flush() {
+	raw := inline(h.runs)
+	h.runs = nil
+	if raw == "" {
+		return
+	}
+	raw = collapse(raw)
+	it := item{location: h.locate("paragraph"), block: nld.Block{Type: nld.BlockParagraph, Text: plain(raw)}, raw: raw}
+	if m := definitionRef.FindStringSubmatch(raw); m != nil {
+		it.block = nld.Block{Type: nld.BlockDefinitionRef, Term: m[1], Text: plain(raw)}
+	}
+	h.items = append(h.items, it)
+}
+
+// block reads the children of a block element. Inline content between
+// block elements forms paragraphs. inContent reports whether n is inside
+// the article or main content, where headers and footers belong to the
+// document.
+func (h *htmlReader) block(n *html.Node, inContent bool) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode {
+			h.runs = append(h.runs, docx.Run{Text: c.Data})
+			continue
+		}
+		if c.Type != html.ElementNode || hidden(c) {
+			continue
+		}
+		a := c.DataAtom
+		switch {
+		case skipped[a]:
+			continue
+		case (a == atom.Header || a == atom.Footer) && !inContent:
+			continue
+		case embedded[a] != "":
+			h.embedded(c)
+			continue
+		}
+
+		switch a {
+		case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+			h.flush()
+			if title := collapse(text(c)); title != "" {
+				h.items = append(h.items, item{location: h.locate("heading"), heading: int(c.Data[1] - '0'), text: title})
+			}
+		case atom.P, atom.Pre, atom.Address:
+			h.flush()
+			h.inline(c, false)
+			h.flush()
+		case atom.Ul, atom.Ol, atom.Menu:
+			h.flush()
+			h.list(c)
+		case atom.Table:
+			h.flush()
+			h.table(c)
+		case atom.Dl:
+			h.flush()
+			h.definitions(c)
+		case atom.Hr, atom.Br:
+			h.flush()
+		case atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer,
+			atom.Blockquote, atom.Figure, atom.Figcaption, atom.Details, atom.Summary,
+			atom.Center, atom.Li, atom.Dd, atom.Dt, atom.Tbody, atom.Body:
+			h.flush()
+			h.block(c, inContent || a == atom.Article || a == atom.Main || a == atom.Section)
+			h.flush()
+		default:
+			h.inline(c, false)
+		}
+	}
+}
+
+// inline appends the text of an inline element to the paragraph being
+// read, marking the text of strong and b elements as bold
+func (h *htmlReader) inline(n *html.Node, bold bool) {
+	bold = bold || n.DataAtom == atom.Strong || n.DataAtom == atom.B
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode:
+			h.runs = append(h.runs, docx.Run{Text: c.Data, Bold: bold})
+		case c.Type != html.ElementNode || hidden(c) || skipped[c.DataAtom]:
+		case embedded[c.DataAtom] != "":
+			h.embedded(c)
+		case c.DataAtom == atom.Br:
+			h.runs = append(h.runs, docx.Run{Text: " "})
+		case c.DataAtom == atom.Dfn:
+			// A defining instance reads as the quoted bold term that
+			// starts a definition paragraph
+			h.runs = append(h.runs, docx.Run{Text: `"` + collapse(text(c)) + `"`, Bold: true})
+		default:
+			h.inline(c, bold)
+		}
+	}
+}
+
+// embedded reports content that cannot be imported, keeping the
+// alternative text of images
+func (h *htmlReader) embedded(n *html.Node) {
+	kind := embedded[n.DataAtom]
+	loc := h.locate(kind)
+	if alt := collapse(attr(n, "alt")); alt != "" {
+		h.warnings = append(h.warnings, Warning{Location: loc, Message: fmt.Sprintf("%s %q was not imported", kind, alt)})
+		return
+	}
+	h.warnings = append(h.warnings, Warning{Location: loc, Message: kind + " was not imported"})
+}
+
+// list reads a ul or ol element. Nested lists are flattened into the
+// outer list.
+func (h *htmlReader) list(n *html.Node) {
+	loc := h.locate("list")
+	block := nld.Block{Type: nld.BlockList, Ordered: n.DataAtom == atom.Ol}
+	nested := false
+	var read func(n *html.Node)
+	read = func(n *html.Node) {
+		for li := n.FirstChild; li != nil; li = li.NextSibling {
+			if li.Type != html.ElementNode || li.DataAtom != atom.Li || hidden(li) {
+				continue
+			}
+			var sub []*html.Node
+			entry := &htmlReader{counts: h.counts}
+			for c := li.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && (c.DataAtom == atom.Ul || c.DataAtom == atom.Ol) {
+					sub = append(sub, c)
+					continue
+				}
+				if c.Type == html.TextNode {
+					entry.runs = append(entry.runs, docx.Run{Text: c.Data})
+				} else if c.Type == html.ElementNode {
+					entry.inline(c, false)
+				}
+			}
+			h.warnings = append(h.warnings, entry.warnings...)
+			if raw := collapse(inline(entry.runs)); raw != "" {
+				block.Items = append(block.Items, raw)
+			}
+			for _, s := range sub {
+				nested = true
+				read(s)
+			}
+		}
+	}
+	read(n)
+	if nested {
+		h.warnings = append(h.warnings, Warning{Location: loc, Message: "nested list flattened"})
+	}
+	if len(block.Items) > 0 {
+		h.items = append(h.items, item{location: loc, block: block})
+	}
+}
+
+// definitions reads a dl element into a definition list. Several dd
+// elements for one term are joined.
+func (h *htmlReader) definitions(n *html.Node) {
+	loc := h.locate("definition list")
+	block := nld.Block{Type: blockDefinitions}
+	var dd []string
+	term := ""
+	add := func() {
+		if term != "" && len(dd) > 0 {
+			block.Items = append(block.Items, term, strings.Join(dd, " "))
+		}
+		term, dd = "", nil
+	}
+	var read func(n *html.Node)
+	read = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || hidden(c) {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Dt:
+				if len(dd) > 0 {
+					add()
+				}
+				if term != "" {
+					term += " "
+				}
+				term += collapse(text(c))
+			case atom.Dd:
+				if t := collapse(text(c)); t != "" {
+					dd = append(dd, t)
+				}
+			case atom.Div:
+				// Groups of dt and dd may be wrapped in div elements
+				read(c)
+			}
+		}
+	}
+	read(n)
+	add()
+	if len(block.Items) == 0 {
+		return
+	}
+	h.items = append(h.items, item{location: loc, block: block})
+}
+
+// table reads a table element. Header cells give the column titles, a
+// tfoot row is read as column totals, and columns holding only decimal
+// numbers are number columns.
+func (h *htmlReader) table(n *html.Node) {
+	loc := h.locate("table")
+	var header []string
+	var body, foot [][]string
+	merged := false
+
+	cells := func(tr *html.Node) ([]string, bool) {
+		var row []string
+		allHeaders := true
+		for c := tr.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || (c.DataAtom != atom.Td && c.DataAtom != atom.Th) {
+				continue
+			}
+			if attr(c, "colspan") != "" && attr(c, "colspan") != "1" || attr(c, "rowspan") != "" && attr(c, "rowspan") != "1" {
+				merged = true
+			}
+			cell := &htmlReader{counts: h.counts}
+			cell.inline(c, false)
+			h.warnings = append(h.warnings, cell.warnings...)
+			row = append(row, collapse(inline(cell.runs)))
+			allHeaders = allHeaders && c.DataAtom == atom.Th
+		}
+		return row, allHeaders && len(row) > 0
+	}
+
+	var read func(n *html.Node, section atom.Atom)
+	read = func(n *html.Node, section atom.Atom) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				read(c, c.DataAtom)
+			case atom.Table:
+				h.warnings = append(h.warnings, Warning{Location: loc, Message: "nested table was not imported"})
+			case atom.Tr:
+				row, isHeader := cells(c)
+				switch {
+				case header == nil && (section == atom.Thead || isHeader):
+					header = row
+				case section == atom.Tfoot:
+					foot = append(foot, row)
+				default:
+					body = append(body, row)
+				}
+			}
+		}
+	}
+	read(n, 0)
+	if merged {
+		h.warnings = append(h.warnings, Warning{Location: loc, Message: "merged cells flattened"})
+	}
+	if header == nil {
+		if len(body) == 0 {
+			return
+		}
+		header, body = body[0], body[1:]
+	}
+
+	numeric := make([]bool, len(header))
+	for i := range header {
+		count := 0
+		numeric[i] = true
+		for _, row := range body {
+			if i >= len(row) || row[i] == "" {
+				continue
+			}
+			if !decimal.MatchString(strings.Trim(row[i], "*")) {
+				numeric[i] = false
+			}
+			count++
+		}
+		numeric[i] = numeric[i] && count > 0
+	}
+
+	// A footer row becomes the totals row of the table, marked bold as
+	// the renderers write it
+	if len(foot) > 0 {
+		last := foot[len(foot)-1]
+		row := make([]string, len(last))
+		for i, c := range last {
+			if c = plain(c); c != "" {
+				row[i] = "**" + c + "**"
+			}
+		}
+		body = append(body, row)
+	}
+	h.items = append(h.items, item{location: loc, block: table(header, numeric, body)})
+}
+
+// attr returns the value of an attribute
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hidden reports whether an element is hidden from readers
+func hidden(n *html.Node) bool {
+	for _, a := range n.Attr {
+		if a.Key == "hidden" || a.Key == "aria-hidden" && a.Val == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// text returns the text of a node and its descendants
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && (skipped[n.DataAtom] || hidden(n)):
+			return
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			b.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// collapse collapses runs of white space as browsers do
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/render"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+func TestHTMLRoundTrip(t *testing.T) {
+	original, err := nld.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := render.Render(&buf, original, render.Options{Format: "html"}); err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	result, err := Import("html", buf.Bytes(), Options{Created: time.Date(2025, 6, 27, 12, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", result.Warnings)
+	}
+	data, _ := json.Marshal(result.Document)
+	doc, err := nld.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse imported document: %v", err)
+	}
+	// The page language defaults to English
+	want := original.Metadata
+	want.Language = "en"
+	if !reflect.DeepEqual(doc.Metadata, want) {
+		t.Errorf("Expected metadata %+v, got %+v", want, doc.Metadata)
+	}
+	if !reflect.DeepEqual(doc.Structure, original.Structure) {
+		got, _ := json.Marshal(doc.Structure)
+		want, _ := json.Marshal(original.Structure)
+		t.Errorf("Expected structure\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   string
+		check    func(t *testing.T, doc *nld.Document)
+		warnings []string
+	}{
+		{
+			name: "Page Chrome",
+			source: `<html><head><title>Terms | Acme</title><meta name="author" content="Acme Inc."><script>var x;</script></head>
+<body><header><nav><a href="/">Home</a></nav><h1>Acme</h1></header>
+<main><h2>1. Use</h2><p>Be <em>nice</em>.</p><div hidden>Secret</div><form><button>Accept</button></form></main>
+<footer>© Acme</footer></body></html>`,
+			check: func(t *testing.T, doc *nld.Document) {
+				if doc.Metadata.Title != "Terms | Acme" || doc.Metadata.Author != "Acme Inc." {
+					t.Errorf("Unexpected metadata %+v", doc.Metadata)
+				}
+				s := doc.Structure.Sections
+				if len(s) != 1 || s[0].Title != "Use" || s[0].Content != "Be nice." {
+					t.Errorf("Unexpected sections %+v", s)
+				}
+			},
+		},
+		{
+			name:   "Body Without Main",
+			source: `<body><header>Site</header><h1>Privacy</h1><div>Loose text <b>here</b>.<p>Paragraph.</p></div><h2>Data</h2><ol><li>Name<ol><li>First</li></ol></li></ol><img alt="Chart"></body>`,
+			check: func(t *testing.T, doc *nld.Document) {
+				if doc.Metadata.Title != "Privacy" {
+					t.Errorf("Expected title from h1, got %q", doc.Metadata.Title)
+				}
+				s := doc.Structure.Sections
+				if len(s) != 2 || s[0].Content != "Loose text here.\n\nParagraph." {
+					t.Fatalf("Unexpected sections %+v", s)
+				}
+				want := []string{"Name", "First"}
+				if b := s[1].Blocks; len(b) != 1 || !b[0].Ordered || !reflect.DeepEqual(b[0].Items, want) {
+					t.Errorf("Expected ordered list %v, got %+v", want, b)
+				}
+			},
+			warnings: []string{"nested list flattened", `image "Chart" was not imported`},
+		},
+		{
+			name: "Tables And Definitions",
+			source: `<article><h2>Prices</h2><table><tr><td>Plan</td><td>Fee</td></tr><tr><td colspan="2">Monthly</td></tr><tr><td>Pro</td><td>9.99</td></tr></table>
+<dl><div><dt>Plan</dt><dd>A subscription</dd><dd>billed monthly.</dd></div></dl><iframe src="x"></iframe></article>`,
+			check: func(t *testing.T, doc *nld.Document) {
+				b := doc.Structure.Sections[0].Blocks
+				if len(b) != 1 || len(b[0].Columns) != 2 || b[0].Columns[1].Type != nld.ColumnNumber || len(b[0].Rows) != 2 {
+					t.Errorf("Unexpected table %+v", b)
+				}
+				want := []nld.Definition{{Term: "Plan", Definition: "A subscription billed monthly."}}
+				if !reflect.DeepEqual(doc.Structure.Definitions, want) {
+					t.Errorf("Expected definitions %v, got %v", want, doc.Structure.Definitions)
+				}
+			},
+			warnings: []string{"merged cells flattened", "embedded frame was not imported"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Import("html", []byte(tc.source), Options{})
+			if err != nil {
+				t.Fatalf("Failed to import: %v", err)
+			}
+			data, _ := json.Marshal(result.Document)
+			doc, err := nld.Parse(data)
+			if err != nil {
+				t.Fatalf("Failed to parse imported document: %v", err)
+			}
+			tc.check(t, doc)
+
+			if len(result.Warnings) != len(tc.warnings) {
+				t.Fatalf("Expected %d warnings, got %v", len(tc.warnings), result.Warnings)
+			}
+			for i, w := range tc.warnings {
+				if !strings.Contains(result.Warnings[i].Message, w) {
+					t.Errorf("Expected warning %q, got %q", w, result.Warnings[i].Message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/colemalphrus/nld/internal/classify"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Options controls how a document is imported
+type Options struct {
+	// Type is the document type. When empty the type found in the source
+	// is used, or else the type the content suggests, or contract.
+	Type string
+	// Title overrides the title found in the source
+	Title string
+	// Created is the creation time recorded when the source has none. The
+	// zero value uses the current time.
+	Created time.Time
+
+	// Columns maps receipt line item fields (item, quantity, unitPrice,
+	// amount) to the header or 1-based number of the column holding them,
+	// for spreadsheet imports. Unmapped fields are found by their headers.
+	Columns map[string]string
+	// Currency is the ISO 4217 currency of imported receipt amounts
+	Currency string
+	// Taxes are charged on the subtotal of imported receipts; each needs a
+	// name and rate
+	Taxes []nld.Tax
+}
+
+// Warning describes source content that could not be mapped to NLD
+// structure, or was mapped with low confidence
+type Warning struct {
+	// Location describes where in the source the content was found
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+// Result is an imported document
+type Result struct {
+	Document document.Document
+	Warnings []Warning
+	// Classified is the type guessed from the content, for sources that
+	// give none
+	Classified *classify.Suggestion
+}
+
+// Importer converts a source format into an NLD document
+type Importer func(data []byte, opts Options) (*Result, error)
+
+var importers = map[string]Importer{
+	"markdown": Markdown,
+	"docx":     DOCX,
+	"pdf":      PDF,
+	"csv":      CSV,
+	"xlsx":     XLSX,
+	"html":     HTML,
+}
+
+// extensions maps file extensions to import formats
+var extensions = map[string]string{
+	".md":       "markdown",
+	".markdown": "markdown",
+	".docx":     "docx",
+	".pdf":      "pdf",
+	".csv":      "csv",
+	".tsv":      "csv",
+	".xlsx":     "xlsx",
+	".html":     "html",
+	".htm":      "html",
+}
+
+// Formats returns the supported import formats
+func Formats() []string {
+	var formats []string
+	for f := range importers {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// FormatOf returns the import format for a file name based on its
+// extension, or an empty string
+func FormatOf(path string) string {
+	return extensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// Import converts data in the given format into an NLD document
+func Import(format string, data []byte, opts Options) (*Result, error) {
+	importer, ok := importers[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported import format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return importer(data, opts)
+}
+
+// newDocument returns a document with the metadata required by the schema,
+// taking values from opts over those found in the source
+func newDocument(meta map[string]interface{}, opts Options) document.Document {
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	if opts.Type != "" {
+		meta["type"] = opts.Type
+	} else if meta["type"] == nil || meta["type"] == "" {
+		meta["type"] = "contract"
+	}
+	if opts.Title != "" {
+		meta["title"] = opts.Title
+	} else if meta["title"] == nil || meta["title"] == "" {
+		meta["title"] = "Imported " + fmt.Sprint(meta["type"])
+	}
+	if meta["version"] == nil {
+		meta["version"] = "1.0.0"
+	}
+	if meta["created"] == nil {
+		created := opts.Created
+		if created.IsZero() {
+			created = time.Now()
+		}
+		meta["created"] = created.UTC().Format(time.RFC3339)
+	}
+	return document.Document{"metadata": meta}
+}
+
+// finish normalizes a document built from Go values into the generic JSON
+// form read from files
+func finish(doc document.Document, warnings []Warning) (*Result, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	parsed, err := document.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Document: parsed, Warnings: warnings}, nil
+}
+
+// numberPrefix matches the section numbers written by renderers and common
+// in legal documents: "1.", "2.1", "1.1.a", "(a)", "(iv)", "Article I",
+// "Section 3:". A bare number needs a dot so that titles such as
+// "2025 Budget" are left alone.
+var numberPrefix = regexp.MustCompile(`(?i)^(?:(?:article|section|clause|schedule)\s+[0-9ivxlcdm]+[.:]?|\d+(?:\.\d+)+(?:\.[a-z]{1,4})*\.?|\d+\.|\([a-z]{1,4}\)|\(\d+\))\s+`)
+
+// stripNumber removes a section number from the start of a heading
+func stripNumber(title string) string {
+	title = strings.TrimSpace(title)
+	if loc := numberPrefix.FindStringIndex(title); loc != nil && loc[1] < len(title) {
+		return strings.TrimSpace(title[loc[1]:])
+	}
+	return title
+}
+
+// slug returns an identifier made from the words of s
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// node is a section being built
+type node struct {
+	id       string
+	title    string
+	level    int
+	blocks   []nld.Block
+	children []*node
+}
+
+// outline builds a section tree from headings and the blocks beneath them
+type outline struct {
+	roots []*node
+	stack []*node
+	ids   map[string]int
+}
+
+// heading starts a section at the given heading level. Sections nest
+// beneath the nearest preceding heading of a lower level.
+func (o *outline) heading(level int, title string) {
+	n := &node{id: o.uniqueID(title), title: title, level: level}
+	for len(o.stack) > 0 && o.stack[len(o.stack)-1].level >= level {
+		o.stack = o.stack[:len(o.stack)-1]
+	}
+	if len(o.stack) == 0 {
+		o.roots = append(o.roots, n)
+	} else {
+		parent := o.stack[len(o.stack)-1]
+		parent.children = append(parent.children, n)
+	}
+	o.stack = append(o.stack, n)
+}
+
+// add appends a block to the current section, starting a preamble section
+// for content that precedes the first heading. The preamble is given a
+// level below any heading so that the first heading closes it.
+func (o *outline) add(b nld.Block) {
+	if len(o.stack) == 0 {
+		o.heading(7, "Preamble")
+	}
+	n := o.stack[len(o.stack)-1]
+	n.blocks = append(n.blocks, b)
+}
+
+// uniqueID returns an ID for a section title that is not yet in use
+func (o *outline) uniqueID(title string) string {
+	if o.ids == nil {
+		o.ids = map[string]int{}
+	}
+	id := slug(title)
+	if id == "" {
+		id = "section"
+	}
+	o.ids[id]++
+	if n := o.ids[id]; n > 1 {
+		return fmt.Sprintf("%s-%d", id, n)
+	}
+	return id
+}
+
+// sections returns the section tree as NLD sections. Sections holding only
+// paragraphs get plain text content.
+func (o *outline) sections() []nld.Section {
+	var convert func(nodes []*node) []nld.Section
+	convert = func(nodes []*node) []nld.Section {
+		var out []nld.Section
+		for _, n := range nodes {
+			s := nld.Section{ID: n.id, Title: n.title, Sections: convert(n.children)}
+			plain := true
+			var texts []string
+			for _, b := range n.blocks {
+				if b.Type != nld.BlockParagraph {
+					plain = false
+				}
+				texts = append(texts, b.Text)
+			}
+			if plain {
+				s.Content = strings.Join(texts, "\n\n")
+			} else {
+				s.Blocks = n.blocks
+			}
+			out = append(out, s)
+		}
+		return out
+	}
+	return convert(o.roots)
+}
@@ -0,0 +1,308 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	atxHeading    = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	listItem      = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	setextRule    = regexp.MustCompile(`^(=+|-+)\s*$`)
+	tableRule     = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	thematicBreak = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+)
+
+// frontMatterFields are the metadata fields read from YAML front matter
+var frontMatterFields = map[string]bool{
+	"title": true, "author": true, "type": true, "version": true, "created": true,
+	"jurisdiction": true, "language": true, "effective": true, "expires": true,
+	"term": true, "noticePeriod": true, "timezone": true, "entities": true,
+	"status": true,
+}
+
+// Markdown imports a Markdown document. YAML front matter becomes metadata,
+// headings become sections nested by level, and paragraphs, lists, tables
+// and definition lists become content. A single leading level 1 heading is
+// the document title. The Parties and Definitions sections written by
+// nld render are read back into entities and definitions.
+func Markdown(data []byte, opts Options) (*Result, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	var warnings []Warning
+
+	meta, text, start, err := frontMatter(text, &warnings)
+	if err != nil {
+		return nil, err
+	}
+	items := parseMarkdown(strings.Split(text, "\n"), start, &warnings)
+
+	if title := leadingTitle(items); title != "" {
+		if meta["title"] == nil {
+			meta["title"] = title
+		}
+		items = items[1:]
+	}
+
+	return build(meta, items, opts, warnings)
+}
+
+// location describes a source line
+func location(line int) string {
+	return fmt.Sprintf("line %d", line)
+}
+
+// frontMatter reads YAML front matter delimited by "---" lines. It returns
+// the metadata, the remaining text and the line number it starts at.
+func frontMatter(text string, warnings *[]Warning) (map[string]interface{}, string, int, error) {
+	meta := map[string]interface{}{}
+	if !strings.HasPrefix(text, "---\n") {
+		return meta, text, 1, nil
+	}
+	end := strings.Index(text[4:], "\n---")
+	if end < 0 {
+		return meta, text, 1, nil
+	}
+	source := text[4 : 4+end]
+	rest := text[4+end+4:]
+	rest = strings.TrimPrefix(rest, "\n")
+	start := strings.Count(text[:len(text)-len(rest)], "\n") + 1
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(source), &values); err != nil {
+		return nil, "", 0, fmt.Errorf("invalid front matter: %w", err)
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := values[k]
+		if !frontMatterFields[k] {
+			*warnings = append(*warnings, Warning{Location: "front matter", Message: fmt.Sprintf("field %q was not imported", k)})
+			continue
+		}
+		if t, ok := v.(time.Time); ok {
+			// YAML reads unquoted dates as timestamps
+			if k != "created" && t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+				v = t.Format(nld.DateLayout)
+			} else {
+				v = t.Format(time.RFC3339)
+			}
+		}
+		meta[k] = v
+	}
+	return meta, rest, start, nil
+}
+
+// parseMarkdown splits Markdown lines into headings and blocks
+func parseMarkdown(lines []string, start int, warnings *[]Warning) []item {
+	var items []item
+	warn := func(i int, msg string) {
+		*warnings = append(*warnings, Warning{Location: location(start + i), Message: msg})
+	}
+	blank := func(i int) bool { return i >= len(lines) || strings.TrimSpace(lines[i]) == "" }
+	fence := func(s string) bool {
+		t := strings.TrimSpace(s)
+		return strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~")
+	}
+	isTable := func(i int) bool {
+		return i+1 < len(lines) && strings.Contains(lines[i], "|") && tableRule.MatchString(lines[i+1])
+	}
+	startsBlock := func(i int) bool {
+		return atxHeading.MatchString(lines[i]) || listItem.MatchString(lines[i]) || fence(lines[i]) || isTable(i) || thematicBreak.MatchString(lines[i])
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			i++
+
+		case fence(line):
+			marker := trimmed[:3]
+			j := i + 1
+			var code []string
+			for j < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[j]), marker) {
+				code = append(code, lines[j])
+				j++
+			}
+			warn(i, "code block imported as a paragraph")
+			items = append(items, item{location: location(start + i), block: nld.Block{Type: nld.BlockParagraph, Text: strings.Join(code, "\n")}})
+			i = j + 1
+
+		case atxHeading.MatchString(line):
+			m := atxHeading.FindStringSubmatch(line)
+			items = append(items, item{location: location(start + i), heading: len(m[1]), text: plain(m[2])})
+			i++
+
+		case i+1 < len(lines) && !listItem.MatchString(line) && setextRule.MatchString(lines[i+1]) && !thematicBreak.MatchString(line):
+			level := 1
+			if strings.HasPrefix(lines[i+1], "-") {
+				level = 2
+			}
+			items = append(items, item{location: location(start + i), heading: level, text: plain(trimmed)})
+			i += 2
+
+		case thematicBreak.MatchString(line):
+			i++
+
+		case isTable(i):
+			j := i + 2
+			for j < len(lines) && strings.Contains(lines[j], "|") && !blank(j) {
+				j++
+			}
+			items = append(items, item{location: location(start + i), block: parseTable(lines[i], lines[i+1], lines[i+2:j])})
+			i = j
+
+		case listItem.MatchString(line):
+			block, next, nested := parseList(lines, i)
+			if nested {
+				warn(i, "nested list flattened")
+			}
+			items = append(items, item{location: location(start + i), block: block})
+			i = next
+
+		case i+1 < len(lines) && strings.HasPrefix(lines[i+1], ": "):
+			block := nld.Block{Type: blockDefinitions}
+			j := i
+			for j+1 < len(lines) && !blank(j) && strings.HasPrefix(lines[j+1], ": ") {
+				term := plain(strings.TrimSpace(lines[j]))
+				j++
+				var defs []string
+				for j < len(lines) && strings.HasPrefix(lines[j], ": ") {
+					defs = append(defs, plain(strings.TrimSpace(lines[j][2:])))
+					j++
+				}
+				block.Items = append(block.Items, term, strings.Join(defs, " "))
+				for blank(j) && j < len(lines) && j+2 < len(lines) && strings.HasPrefix(lines[j+2], ": ") {
+					j++
+				}
+			}
+			items = append(items, item{location: location(start + i), block: block})
+			i = j
+
+		case strings.HasPrefix(trimmed, "<"):
+			j := i
+			for !blank(j) {
+				j++
+			}
+			warn(i, "HTML was not imported")
+			i = j
+
+		default:
+			j := i
+			var raw []string
+			for !blank(j) && (j == i || !startsBlock(j)) {
+				raw = append(raw, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[j]), ">")))
+				j++
+			}
+			text := strings.Join(raw, " ")
+			if strings.HasPrefix(text, "![") && strings.HasSuffix(text, ")") {
+				warn(i, "image was not imported")
+				i = j
+				continue
+			}
+			block := nld.Block{Type: nld.BlockParagraph, Text: plain(text)}
+			if m := definitionRef.FindStringSubmatch(text); m != nil {
+				block = nld.Block{Type: nld.BlockDefinitionRef, Term: m[1], Text: plain(text)}
+			}
+			items = append(items, item{location: location(start + i), block: block, raw: text})
+			i = j
+		}
+	}
+	return items
+}
+
+// parseList reads a list starting at line i, keeping the inline formatting
+// of its items. It returns the list, the line after it and whether nested
+// items were flattened.
+func parseList(lines []string, i int) (nld.Block, int, bool) {
+	first := listItem.FindStringSubmatch(lines[i])
+	block := nld.Block{Type: nld.BlockList, Ordered: ordered(first[2])}
+	indent := len(first[1])
+	nested := false
+
+	j := i
+	for j < len(lines) {
+		line := lines[j]
+		if m := listItem.FindStringSubmatch(line); m != nil {
+			if len(m[1]) <= indent && ordered(m[2]) != block.Ordered {
+				break
+			}
+			if len(m[1]) > indent {
+				nested = true
+			}
+			block.Items = append(block.Items, m[3])
+			j++
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			// A blank line continues the list only if another item of
+			// the same kind follows
+			if m := listItem.FindStringSubmatch(next(lines, j)); m != nil && ordered(m[2]) == block.Ordered {
+				j++
+				continue
+			}
+			break
+		}
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			last := len(block.Items) - 1
+			block.Items[last] += " " + strings.TrimSpace(line)
+			j++
+			continue
+		}
+		break
+	}
+	return block, j, nested
+}
+
+// ordered reports whether a list marker is numbered
+func ordered(marker string) bool {
+	return marker[0] >= '0' && marker[0] <= '9'
+}
+
+// next returns the line after i, or an empty string
+func next(lines []string, i int) string {
+	if i+1 < len(lines) {
+		return lines[i+1]
+	}
+	return ""
+}
+
+// cells splits a table row into cells
+func cells(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	row = strings.TrimSuffix(row, "|")
+	row = strings.ReplaceAll(row, `\|`, "\x00")
+	var out []string
+	for _, c := range strings.Split(row, "|") {
+		out = append(out, strings.TrimSpace(strings.ReplaceAll(c, "\x00", "|")))
+	}
+	return out
+}
+
+// parseTable reads a pipe table. Right-aligned columns are number columns.
+func parseTable(header, rule string, rows []string) nld.Block {
+	aligns := cells(rule)
+	titles := cells(header)
+
+	var body [][]string
+	for _, r := range rows {
+		body = append(body, cells(r))
+	}
+
+	numeric := make([]bool, len(titles))
+	for i := range titles {
+		numeric[i] = i < len(aligns) && strings.HasSuffix(aligns[i], ":") && !strings.HasPrefix(aligns[i], ":")
+	}
+	return table(titles, numeric, body)
+}
@@ -0,0 +1,229 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/render"
+	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const testDocument = `{
+  "metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Service Agreement",
+    "jurisdiction": "California, USA",
+    "entities": [{"id": "acme-corporation", "name": "Acme Corporation", "role": "Service Provider"}]},
+  "content": {
+    "sections": [
+      {"id": "introduction", "title": "Introduction", "content": "First paragraph.\n\nSecond paragraph."},
+      {"id": "fees", "title": "Fees", "content": [
+        {"type": "list", "ordered": true, "items": ["Monthly", "Yearly"]},
+        {"type": "table", "columns": [{"title": "Item"}, {"title": "Amount", "type": "number", "total": true}], "rows": [["Widget", "10.00"], ["Gadget", "2.50"]]}
+      ], "sections": [
+        {"id": "late-payment", "title": "Late Payment", "content": [{"type": "definitionRef", "term": "Due Date"}]}
+      ]}
+    ],
+    "definitions": [{"term": "Due Date", "definition": "the 1st of each month"}]
+  }
+}`
+
+// importMarkdown imports Markdown and parses the result as a typed document
+func importMarkdown(t *testing.T, source string) (*nld.Document, []Warning) {
+	t.Helper()
+	result, err := Import("markdown", []byte(source), Options{Created: time.Date(2025, 6, 27, 12, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	data, err := json.Marshal(result.Document)
+	if err != nil {
+		t.Fatalf("Failed to encode document: %v", err)
+	}
+	doc, err := nld.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse imported document: %v", err)
+	}
+	return doc, result.Warnings
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	original, err := nld.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := render.Render(&buf, original, render.Options{Format: "markdown"}); err != nil {
+		t.Fatalf("Failed to render: %v", err)
+	}
+
+	doc, warnings := importMarkdown(t, buf.String())
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+	if !reflect.DeepEqual(doc.Metadata, original.Metadata) {
+		t.Errorf("Expected metadata %+v, got %+v", original.Metadata, doc.Metadata)
+	}
+	if !reflect.DeepEqual(doc.Structure, original.Structure) {
+		got, _ := json.Marshal(doc.Structure)
+		want, _ := json.Marshal(original.Structure)
+		t.Errorf("Expected structure\n%s\ngot\n%s", want, got)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	v := validator.New()
+	schema, err := v.LoadSchema(filepath.Join(wd, "..", "..", "schemas", "document-v1.json"))
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+	data, _ := json.Marshal(doc)
+	result, err := v.ValidateBytes(data, schema)
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if !result.Valid {
+		t.Errorf("Expected imported document to be valid, got %v", result.Errors)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   string
+		check    func(t *testing.T, doc *nld.Document)
+		warnings []string
+	}{
+		{
+			name:   "Front Matter",
+			source: "---\ntitle: Lease\ntype: lease\neffective: 2025-03-01\ncolour: blue\n---\n\n## Rent\n\nMonthly.\n",
+			check: func(t *testing.T, doc *nld.Document) {
+				m := doc.Metadata
+				if m.Title != "Lease" || m.Type != "lease" || m.Effective != "2025-03-01" {
+					t.Errorf("Unexpected metadata %+v", m)
+				}
+				if m.Created != "2025-06-27T12:00:00Z" || m.Version != "1.0.0" {
+					t.Errorf("Expected default created and version, got %+v", m)
+				}
+			},
+			warnings: []string{`field "colour" was not imported`},
+		},
+		{
+			name:   "Nested Headings",
+			source: "Opening words.\n\n# 1. Services\n\n## 1.1 Scope\n\nDesign work.\n\nTerm\n----\n\nOne year.\n\n# Article II Fees\n",
+			check: func(t *testing.T, doc *nld.Document) {
+				s := doc.Structure.Sections
+				if len(s) != 3 || s[0].ID != "preamble" || s[1].Title != "Services" || s[2].Title != "Fees" {
+					t.Fatalf("Unexpected sections %+v", s)
+				}
+				if len(s[1].Sections) != 2 || s[1].Sections[0].ID != "scope" || s[1].Sections[1].Content != "One year." {
+					t.Errorf("Unexpected subsections %+v", s[1].Sections)
+				}
+				if doc.Metadata.Title != "Imported contract" {
+					t.Errorf("Expected default title, got %q", doc.Metadata.Title)
+				}
+			},
+		},
+		{
+			name:   "Definition List",
+			source: "# Terms\n\n## Use\n\nSee below.\n\nLicensor\n: The owner\n: of the software.\n\nUser\n: Anyone using it.\n",
+			check: func(t *testing.T, doc *nld.Document) {
+				want := []nld.Definition{{Term: "Licensor", Definition: "The owner of the software."}, {Term: "User", Definition: "Anyone using it."}}
+				if !reflect.DeepEqual(doc.Structure.Definitions, want) {
+					t.Errorf("Expected definitions %v, got %v", want, doc.Structure.Definitions)
+				}
+				if doc.Metadata.Title != "Terms" {
+					t.Errorf("Expected title from heading, got %q", doc.Metadata.Title)
+				}
+			},
+		},
+		{
+			name:   "Lists And Unmapped Content",
+			source: "## Duties\n\n- One\n- *Two*\n  continued\n    - nested\n\n1. First\n\n![logo](logo.png)\n\n<div>raw</div>\n",
+			check: func(t *testing.T, doc *nld.Document) {
+				blocks := doc.Structure.Sections[0].Blocks
+				if len(blocks) != 2 || blocks[1].Ordered != true {
+					t.Fatalf("Expected two lists, got %+v", blocks)
+				}
+				want := []string{"One", "Two continued", "nested"}
+				if !reflect.DeepEqual(blocks[0].Items, want) {
+					t.Errorf("Expected items %v, got %v", want, blocks[0].Items)
+				}
+			},
+			warnings: []string{"nested list flattened", "image was not imported", "HTML was not imported"},
+		},
+		{
+			name:   "Undefined Term",
+			source: "## Use\n\n**\"Thing\"** means something.\n",
+			check: func(t *testing.T, doc *nld.Document) {
+				if got := doc.Structure.Sections[0].Content; got != "\"Thing\" means something." {
+					t.Errorf("Expected paragraph for undefined term, got %q", got)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, warnings := importMarkdown(t, tc.source)
+			tc.check(t, doc)
+
+			if len(warnings) != len(tc.warnings) {
+				t.Fatalf("Expected %d warnings, got %v", len(tc.warnings), warnings)
+			}
+			for i, w := range tc.warnings {
+				if !strings.Contains(warnings[i].Message, w) {
+					t.Errorf("Expected warning %q, got %q", w, warnings[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestStripNumber(t *testing.T) {
+	testCases := map[string]string{
+		"1. Services":        "Services",
+		"2.1 Scope":          "Scope",
+		"(a) Payment":        "Payment",
+		"Article IV Notices": "Notices",
+		"Section 3: Term":    "Term",
+		"2025 Budget Review": "2025 Budget Review",
+		"1.":                 "1.",
+	}
+	for in, want := range testCases {
+		if got := stripNumber(in); got != want {
+			t.Errorf("stripNumber(%q) = %q, expected %q", in, got, want)
+		}
+	}
+}
+
+func TestMarkdownClassified(t *testing.T) {
+	source := "# Mutual NDA\n\n## Confidentiality\n\nThe Receiving Party keeps the Confidential Information of the Disclosing Party secret.\n"
+	result, err := Markdown([]byte(source), Options{})
+	if err != nil {
+		t.Fatalf("Markdown failed: %v", err)
+	}
+	if got := result.Document.Metadata()["type"]; got != "nda" || result.Classified == nil || result.Classified.Type != "nda" {
+		t.Errorf("type = %v, classified %+v", got, result.Classified)
+	}
+
+	for _, opts := range []Options{{Type: "agreement"}, {}} {
+		src := source
+		if opts.Type == "" {
+			src = "---\ntype: contract\n---\n" + source
+		}
+		result, err := Markdown([]byte(src), opts)
+		if err != nil {
+			t.Fatalf("Markdown failed: %v", err)
+		}
+		if result.Classified != nil || result.Document.Metadata()["type"] == "nda" {
+			t.Errorf("given type overridden: %v, classified %+v", result.Document.Metadata()["type"], result.Classified)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+	"github.com/ledongthuc/pdf"
+)
+
+var (
+	// numberedHeading matches headings such as "Article IV Notices",
+	// "Section 2: Term", "3. Payment" and "3.1 Fees"
+	numberedHeading = regexp.MustCompile(`(?i)^(?:(article|schedule|section|clause)\s+([0-9ivxlcdm]+)[.:]?|(\d+)\.|(\d+(?:\.\d+)+)\.?)\s+(\S.*)$`)
+	// pageNumber matches running page numbers such as "3", "- 3 -" and
+	// "Page 3 of 10"
+	pageNumber = regexp.MustCompile(`(?i)^(?:page\s+)?[-–]?\s*\d+\s*[-–]?(?:\s+of\s+\d+)?$`)
+	// meansDefinition matches defining paragraphs such as
+	// "Services" means the work described in Schedule 1.
+	meansDefinition = regexp.MustCompile(`^["“]([^"”]{1,60})["”]\s+(?:means|shall mean|has the meaning)\s+(.+)$`)
+	digits          = regexp.MustCompile(`\d+`)
+)
+
+// pdfLine is a line of text on a page
+type pdfLine struct {
+	page int
+	y    float64
+	text string
+	// size is the font size of the line in points
+	size float64
+	bold bool
+}
+
+// PDF imports the text of a PDF document. Text is read line by line and
+// joined into paragraphs; numbered lines such as "1. Payment" or
+// "Article II Term" become section headings, as do short lines set larger
+// or bolder than the body text. Running headers, footers and page numbers
+// are removed.
+//
+// PDF files record where text is drawn rather than its structure, so the
+// result is a draft: headings detected from formatting alone, gaps in the
+// section numbering, pages without text (such as scanned images) and text
+// that did not decode are reported as warnings to review.
+func PDF(data []byte, opts Options) (result *Result, err error) {
+	// The PDF reader panics on malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("invalid PDF document: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PDF document: %w", err)
+	}
+
+	var warnings []Warning
+	var lines []pdfLine
+	pages := r.NumPage()
+	for i := 1; i <= pages; i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		pageLines, err := readPage(page, i)
+		if err != nil {
+			warnings = append(warnings, Warning{Location: pageLocation(i), Message: fmt.Sprintf("page could not be read: %v", err)})
+			continue
+		}
+		if len(pageLines) == 0 {
+			warnings = append(warnings, Warning{Location: pageLocation(i), Message: "page has no text; it may be a scanned image that needs OCR"})
+		}
+		lines = append(lines, pageLines...)
+	}
+	lines = removeRunningText(lines, pages)
+
+	meta := map[string]interface{}{}
+	items, more := pdfItems(lines, meta)
+	warnings = append(warnings, more...)
+	return build(meta, items, opts, warnings)
+}
+
+// pageLocation describes a page
+func pageLocation(page int) string {
+	return fmt.Sprintf("page %d", page)
+}
+
+// readPage returns the lines of text on a page from top to bottom
+func readPage(page pdf.Page, number int) (lines []pdfLine, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	var glyphs []pdf.Text
+	for _, g := range page.Content().Text {
+		if g.S != "\n" && g.S != "\r" {
+			glyphs = append(glyphs, g)
+		}
+	}
+	sort.SliceStable(glyphs, func(i, j int) bool { return glyphs[i].Y > glyphs[j].Y })
+
+	// Glyphs within half a font size of each other vertically share a line
+	var groups [][]pdf.Text
+	for _, g := range glyphs {
+		n := len(groups)
+		if n > 0 {
+			first := groups[n-1][0]
+			if math.Abs(first.Y-g.Y) <= math.Max(first.FontSize, 1)/2 {
+				groups[n-1] = append(groups[n-1], g)
+				continue
+			}
+		}
+		groups = append(groups, []pdf.Text{g})
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].X < group[j].X })
+		var b strings.Builder
+		line := pdfLine{page: number, y: group[0].Y, bold: true}
+		var prev *pdf.Text
+		for i := range group {
+			g := &group[i]
+			// Words positioned apart without a space character. Fonts
+			// without widths give no positions to compare.
+			if prev != nil && prev.W > 0 && g.S != " " && g.X-(prev.X+prev.W) > g.FontSize/5 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(g.S)
+			prev = g
+			if strings.TrimSpace(g.S) == "" {
+				continue
+			}
+			line.size = math.Max(line.size, g.FontSize)
+			if !isBoldFont(g.Font) {
+				line.bold = false
+			}
+		}
+		line.text = strings.Join(strings.Fields(b.String()), " ")
+		if line.text != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// isBoldFont reports whether a font name denotes a bold face
+func isBoldFont(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "bold") || strings.Contains(name, "black") || strings.Contains(name, "heavy")
+}
+
+// removeRunningText removes page numbers and the headers and footers
+// repeated at the top or bottom of most pages
+func removeRunningText(lines []pdfLine, pages int) []pdfLine {
+	// edge reports whether a line is among the first or last two of its page
+	edge := make([]bool, len(lines))
+	for i := range lines {
+		before, after := 0, 0
+		for j := i - 1; j >= 0 && lines[j].page == lines[i].page; j-- {
+			before++
+		}
+		for j := i + 1; j < len(lines) && lines[j].page == lines[i].page; j++ {
+			after++
+		}
+		edge[i] = before < 2 || after < 2
+	}
+
+	seen := map[string]map[int]bool{}
+	for i, l := range lines {
+		if edge[i] {
+			key := digits.ReplaceAllString(l.text, "#")
+			if seen[key] == nil {
+				seen[key] = map[int]bool{}
+			}
+			seen[key][l.page] = true
+		}
+	}
+
+	var out []pdfLine
+	for i, l := range lines {
+		if edge[i] {
+			if pageNumber.MatchString(l.text) {
+				continue
+			}
+			if n := len(seen[digits.ReplaceAllString(l.text, "#")]); pages > 1 && n > 1 && n*2 >= pages {
+				continue
+			}
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// bodySize returns the font size of most of the text
+func bodySize(lines []pdfLine) float64 {
+	weights := map[float64]int{}
+	for _, l := range lines {
+		weights[math.Round(l.size*2)/2] += len(l.text)
+	}
+	size, best := 0.0, -1
+	for s, w := range weights {
+		if w > best || w == best && s < size {
+			size, best = s, w
+		}
+	}
+	return size
+}
+
+// lineSpacing returns the usual distance between consecutive lines of
+// body text
+func lineSpacing(lines []pdfLine, body float64) float64 {
+	var gaps []float64
+	for i := 1; i < len(lines); i++ {
+		if lines[i].page == lines[i-1].page && math.Abs(lines[i].size-body) < 0.5 {
+			if gap := lines[i-1].y - lines[i].y; gap > 0 {
+				gaps = append(gaps, gap)
+			}
+		}
+	}
+	if len(gaps) == 0 {
+		return body * 1.2
+	}
+	sort.Float64s(gaps)
+	return gaps[len(gaps)/2]
+}
+
+// pdfItems turns lines into headings and paragraphs, recording the title
+// in meta and returning warnings for low-confidence structure
+func pdfItems(lines []pdfLine, meta map[string]interface{}) ([]item, []Warning) {
+	var items []item
+	var warnings []Warning
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	body := bodySize(lines)
+	spacing := lineSpacing(lines, body)
+
+	// Unnumbered headings are ranked by size: the largest is level 1
+	var sizes []float64
+	for _, l := range lines {
+		if isStyledHeading(l, body) {
+			sizes = appendSize(sizes, l.size)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(sizes)))
+
+	var para []string
+	paraPage := 0
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		text := joinLines(para)
+		loc := pageLocation(paraPage)
+		para = nil
+		if garbled(text) {
+			warnings = append(warnings, Warning{Location: loc, Message: "text may not have been decoded correctly; compare it with the original"})
+		}
+		if m := meansDefinition.FindStringSubmatch(text); m != nil {
+			items = append(items,
+				item{location: loc, block: nld.Block{Type: blockDefinitions, Items: []string{m[1], m[2]}}},
+				item{location: loc, block: nld.Block{Type: nld.BlockDefinitionRef, Term: m[1]}})
+			return
+		}
+		items = append(items, item{location: loc, block: nld.Block{Type: nld.BlockParagraph, Text: text}, raw: literal(text)})
+	}
+
+	numbered := false
+	lastTop := 0
+	for i, l := range lines {
+		loc := pageLocation(l.page)
+		level, title, rest, ok := numberedTitle(l, body)
+		if ok {
+			flush()
+			numbered = true
+			if level == 1 {
+				if n, err := strconv.Atoi(strings.TrimSuffix(strings.Fields(l.text)[0], ".")); err == nil {
+					if lastTop > 0 && n != lastTop+1 {
+						warnings = append(warnings, Warning{Location: loc, Message: fmt.Sprintf("section numbering jumps from %d to %d; a heading may have been missed", lastTop, n)})
+					}
+					lastTop = n
+				}
+			}
+			items = append(items, item{location: loc, heading: level, text: title})
+			if rest != "" {
+				para, paraPage = []string{rest}, l.page
+			}
+			continue
+		}
+
+		if isStyledHeading(l, body) {
+			flush()
+			// The first heading above any numbered section is the title
+			if !numbered && meta["title"] == nil && len(items) == 0 {
+				meta["title"] = l.text
+				continue
+			}
+			level := 1
+			for j, s := range sizes {
+				if math.Abs(s-l.size) < 0.5 {
+					level = j + 1
+				}
+			}
+			items = append(items, item{location: loc, heading: level, text: titleCase(l.text)})
+			warnings = append(warnings, Warning{Location: loc, Message: fmt.Sprintf("heading %q was detected from its formatting only", l.text)})
+			continue
+		}
+
+		// A wide gap, or a page break after a finished sentence, ends a
+		// paragraph
+		if len(para) > 0 && i > 0 {
+			prev := lines[i-1]
+			if prev.page == l.page && prev.y-l.y > spacing*1.4 {
+				flush()
+			} else if prev.page != l.page && strings.ContainsAny(prev.text[len(prev.text)-1:], ".:;!?") {
+				flush()
+			}
+		}
+		if len(para) == 0 {
+			paraPage = l.page
+		}
+		para = append(para, l.text)
+	}
+	flush()
+
+	if !numbered {
+		warnings = append(warnings, Warning{Location: "document", Message: "no numbered headings were found; the section structure is a guess"})
+	}
+	return items, warnings
+}
+
+// numberedTitle reports whether a line is a numbered heading, returning
+// its level and title. A numbered clause that runs on into its text, such
+// as "2. Payment. The Client shall pay...", returns the text in rest.
+func numberedTitle(l pdfLine, body float64) (level int, title, rest string, ok bool) {
+	m := numberedHeading.FindStringSubmatch(l.text)
+	if m == nil {
+		return 0, "", "", false
+	}
+	switch {
+	case m[1] != "":
+		level = 1
+		if kind := strings.ToLower(m[1]); kind == "section" || kind == "clause" {
+			level = 2
+		}
+	case m[3] != "":
+		level = 1
+	default:
+		level = strings.Count(m[4], ".") + 1
+	}
+
+	text := m[5]
+	words := strings.Fields(text)
+	if len(words) <= 12 && !strings.HasSuffix(text, ".") {
+		return level, titleCase(text), "", true
+	}
+	// A short leading sentence is the clause title
+	if i := strings.Index(text, ". "); i > 0 {
+		lead := text[:i]
+		if n := len(strings.Fields(lead)); n <= 6 && unicode.IsUpper([]rune(lead)[0]) {
+			return level, titleCase(lead), text[i+2:], true
+		}
+	}
+	// Headings set apart by their formatting may end with a full stop
+	if len(words) <= 12 && (l.bold || l.size > body*1.1) {
+		return level, titleCase(strings.TrimSuffix(text, ".")), "", true
+	}
+	return 0, "", "", false
+}
+
+// isStyledHeading reports whether a line looks like an unnumbered heading:
+// short, without a full stop, and larger, bolder or in capitals compared
+// with the body text
+func isStyledHeading(l pdfLine, body float64) bool {
+	words := strings.Fields(l.text)
+	if len(words) == 0 || len(words) > 12 || strings.HasSuffix(l.text, ".") || strings.HasSuffix(l.text, ",") {
+		return false
+	}
+	if l.size > body*1.15 || l.bold {
+		return true
+	}
+	letters := 0
+	for _, r := range l.text {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters > 3
+}
+
+// appendSize adds a font size to a list of distinct sizes
+func appendSize(sizes []float64, size float64) []float64 {
+	for _, s := range sizes {
+		if math.Abs(s-size) < 0.5 {
+			return sizes
+		}
+	}
+	return append(sizes, size)
+}
+
+// titleCase converts headings set in capitals, common in contracts, to
+// title case
+func titleCase(s string) string {
+	if strings.ToUpper(s) != s {
+		return s
+	}
+	words := strings.Fields(strings.ToLower(s))
+	for i, w := range words {
+		switch w {
+		case "a", "an", "and", "as", "at", "by", "for", "in", "of", "on", "or", "the", "to":
+			if i > 0 {
+				continue
+			}
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	return strings.Join(words, " ")
+}
+
+// joinLines joins the lines of a paragraph, rejoining words hyphenated at
+// the end of a line
+func joinLines(lines []string) string {
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			if strings.HasSuffix(prev, "-") && len(prev) > 1 && unicode.IsLetter(rune(prev[len(prev)-2])) && unicode.IsLower([]rune(l)[0]) {
+				s := b.String()
+				b.Reset()
+				b.WriteString(s[:len(s)-1])
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(l)
+	}
+	return b.String()
+}
+
+// garbled reports whether text looks like it was not decoded, as happens
+// with fonts that lack a Unicode mapping
+func garbled(text string) bool {
+	if strings.ContainsRune(text, unicode.ReplacementChar) {
+		return true
+	}
+	letters, other := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+		case unicode.IsControl(r) || r >= 0xE000 && r <= 0xF8FF:
+			other++
+		}
+	}
+	return other*10 > letters
+}
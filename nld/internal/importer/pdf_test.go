@@ -0,0 +1,160 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// pdfText is a line of text drawn on a test PDF page
+type pdfText struct {
+	bold bool
+	size float64
+	y    float64
+	text string
+}
+
+// testPDF returns a PDF document with the given pages, using Helvetica
+// with fixed widths
+func testPDF(pages [][]pdfText) []byte {
+	var objects []string
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	font := func(name string) string {
+		return fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding /FirstChar 32 /LastChar 126 /Widths [%s] >>", name, widths)
+	}
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>", "", font("Helvetica"), font("Helvetica-Bold"))
+
+	var kids []string
+	for _, page := range pages {
+		var content strings.Builder
+		for _, t := range page {
+			f := "F1"
+			if t.bold {
+				f = "F2"
+			}
+			text := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(t.text)
+			fmt.Fprintf(&content, "BT /%s %g Tf 1 0 0 1 72 %g Tm (%s) Tj ET\n", f, t.size, t.y, text)
+		}
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", len(objects)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objects)))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, o := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+func TestPDF(t *testing.T) {
+	data := testPDF([][]pdfText{
+		{
+			{size: 9, y: 770, text: "ACME CONFIDENTIAL"},
+			{bold: true, size: 18, y: 720, text: "Services Agreement"},
+			{size: 11, y: 690, text: "This agreement is made between Acme and"},
+			{size: 11, y: 676, text: "Client."},
+			{bold: true, size: 11, y: 650, text: "1. Definitions"},
+			{size: 11, y: 630, text: `"Services" means the consulting work`},
+			{size: 11, y: 616, text: "described in the proposal."},
+			{bold: true, size: 11, y: 590, text: "2. Payment"},
+			{size: 11, y: 570, text: "The Client shall pay each invoice within thirty days of receipt and"},
+			{size: 11, y: 556, text: "late pay-"},
+			{size: 11, y: 542, text: "ments accrue interest."},
+			{size: 11, y: 516, text: "2.1 Invoices"},
+			{size: 11, y: 496, text: "Invoices are sent monthly."},
+			{size: 9, y: 40, text: "Page 1 of 3"},
+		},
+		{
+			{size: 9, y: 770, text: "ACME CONFIDENTIAL"},
+			{size: 11, y: 720, text: "4. Term. This agreement lasts for one year from the date it is signed."},
+			{size: 11, y: 690, text: "NOTICES"},
+			{size: 11, y: 670, text: "Notices must be in writing."},
+			{size: 9, y: 40, text: "Page 2 of 3"},
+		},
+		{},
+	})
+
+	result, err := Import("pdf", data, Options{Created: time.Date(2025, 6, 27, 12, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	encoded, _ := json.Marshal(result.Document)
+	doc, err := nld.Parse(encoded)
+	if err != nil {
+		t.Fatalf("Failed to parse imported document: %v", err)
+	}
+
+	if doc.Metadata.Title != "Services Agreement" {
+		t.Errorf("Expected title from the first heading, got %q", doc.Metadata.Title)
+	}
+	want := []nld.Definition{{Term: "Services", Definition: "the consulting work described in the proposal."}}
+	if !reflect.DeepEqual(doc.Structure.Definitions, want) {
+		t.Errorf("Expected definitions %v, got %v", want, doc.Structure.Definitions)
+	}
+
+	var titles []string
+	var walk func(sections []nld.Section, depth int)
+	walk = func(sections []nld.Section, depth int) {
+		for _, s := range sections {
+			titles = append(titles, strings.Repeat("-", depth)+s.Title)
+			walk(s.Sections, depth+1)
+		}
+	}
+	walk(doc.Structure.Sections, 0)
+	wantTitles := []string{"Preamble", "Definitions", "Payment", "-Invoices", "Term", "-Notices"}
+	if !reflect.DeepEqual(titles, wantTitles) {
+		t.Errorf("Expected sections %v, got %v", wantTitles, titles)
+	}
+
+	s := doc.Structure.Sections
+	if s[0].Content != "This agreement is made between Acme and Client." {
+		t.Errorf("Expected preamble without running headers, got %q", s[0].Content)
+	}
+	if got := s[2].Content; got != "The Client shall pay each invoice within thirty days of receipt and late payments accrue interest." {
+		t.Errorf("Expected joined paragraph, got %q", got)
+	}
+	if got := s[3].Content; got != "This agreement lasts for one year from the date it is signed." {
+		t.Errorf("Expected clause text after its title, got %q", got)
+	}
+
+	var messages []string
+	for _, w := range result.Warnings {
+		messages = append(messages, w.Location+": "+w.Message)
+	}
+	for _, expect := range []string{
+		"page 3: page has no text",
+		"page 2: section numbering jumps from 2 to 4",
+		`page 2: heading "NOTICES" was detected from its formatting only`,
+	} {
+		found := false
+		for _, m := range messages {
+			found = found || strings.HasPrefix(m, expect)
+		}
+		if !found {
+			t.Errorf("Expected warning %q, got %v", expect, messages)
+		}
+	}
+}
+
+func TestPDFInvalid(t *testing.T) {
+	if _, err := Import("pdf", []byte("not a pdf"), Options{}); err == nil {
+		t.Error("Expected error for invalid PDF")
+	}
+}
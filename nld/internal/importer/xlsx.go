@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// XLSX imports a receipt from the first worksheet of an Excel workbook,
+// mapping its rows to line items as CSV does
+func XLSX(data []byte, opts Options) (*Result, error) {
+	rows, err := readSheet(data)
+	if err != nil {
+		return nil, err
+	}
+	return receiptFromRows(rows, "row", opts)
+}
+
+// readSheet returns the cell values of the first worksheet of a workbook
+func readSheet(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Excel workbook: %w", err)
+	}
+	parts := map[string]*zip.File{}
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+	part := func(name string) ([]byte, error) {
+		f, ok := parts[name]
+		if !ok {
+			return nil, nil
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	sheet, err := firstSheet(part)
+	if err != nil {
+		return nil, err
+	}
+	content, err := part(sheet)
+	if err != nil || content == nil {
+		return nil, fmt.Errorf("invalid Excel workbook: missing worksheet %s", sheet)
+	}
+
+	var strs []string
+	if shared, err := part("xl/sharedStrings.xml"); err == nil && shared != nil {
+		var sst struct {
+			Items []struct {
+				Text string `xml:"t"`
+				Runs []struct {
+					Text string `xml:"t"`
+				} `xml:"r"`
+			} `xml:"si"`
+		}
+		if err := xml.Unmarshal(shared, &sst); err != nil {
+			return nil, fmt.Errorf("invalid Excel workbook: %w", err)
+		}
+		for _, si := range sst.Items {
+			text := si.Text
+			for _, r := range si.Runs {
+				text += r.Text
+			}
+			strs = append(strs, text)
+		}
+	}
+
+	var ws struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline struct {
+					Text string `xml:"t"`
+				} `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(content, &ws); err != nil {
+		return nil, fmt.Errorf("invalid Excel workbook: %w", err)
+	}
+
+	var rows [][]string
+	for _, r := range ws.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			col := i
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err == nil && n >= 0 && n < len(strs) {
+					row[col] = strs[n]
+				}
+			case "inlineStr":
+				row[col] = c.Inline.Text
+			case "n", "":
+				// Numbers are stored as binary floating point; the shortest
+				// representation recovers the value typed in the cell
+				if f, err := strconv.ParseFloat(c.Value, 64); err == nil {
+					row[col] = strconv.FormatFloat(f, 'f', -1, 64)
+				} else {
+					row[col] = c.Value
+				}
+			default:
+				row[col] = c.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// firstSheet returns the part name of the first worksheet in the workbook
+func firstSheet(part func(string) ([]byte, error)) (string, error) {
+	workbook, err := part("xl/workbook.xml")
+	if err != nil || workbook == nil {
+		return "", fmt.Errorf("invalid Excel workbook: missing xl/workbook.xml")
+	}
+	var wb struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(workbook, &wb); err != nil {
+		return "", fmt.Errorf("invalid Excel workbook: %w", err)
+	}
+
+	rels, _ := part("xl/_rels/workbook.xml.rels")
+	var r struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if len(wb.Sheets) > 0 && rels != nil && xml.Unmarshal(rels, &r) == nil {
+		for _, rel := range r.Rels {
+			if rel.ID == wb.Sheets[0].ID {
+				if strings.HasPrefix(rel.Target, "/") {
+					return strings.TrimPrefix(rel.Target, "/"), nil
+				}
+				return path.Join("xl", rel.Target), nil
+			}
+		}
+	}
+
+	// Workbooks without relationships keep their first sheet here
+	if data, _ := part("xl/worksheets/sheet1.xml"); data != nil {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+	return "", fmt.Errorf("invalid Excel workbook: no worksheets")
+}
+
+// columnIndex returns the zero-based column of a cell reference such as
+// "C7"
+func columnIndex(ref string) int {
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		n = n*26 + int(r-'A'+1)
+	}
+	return n - 1
+}
@@ -0,0 +1,264 @@
+package include
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/metrics"
+)
+
+const (
+	// Key is the property that marks an entry as a reference into the
+	// clause library
+	Key = "$include"
+
+	// LibraryPathEnv lists clause library locations separated by the OS
+	// path list separator, consulted after any --library flags
+	LibraryPathEnv = "NLD_CLAUSE_PATH"
+
+	// maxDepth bounds nested includes
+	maxDepth = 16
+)
+
+// ErrNotFound is returned by a Source that does not contain a clause
+var ErrNotFound = errors.New("clause not found")
+
+// Source is a clause library location
+type Source interface {
+	// Fetch returns the JSON for the clause identified by ref
+	Fetch(ref string) ([]byte, error)
+	// String describes the source for messages
+	String() string
+}
+
+// DirSource is a clause library stored in a local directory. The clause
+// "confidentiality/mutual" is read from <Dir>/confidentiality/mutual.json.
+type DirSource struct {
+	Dir string
+}
+
+// Fetch implements Source
+func (s DirSource) Fetch(ref string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir, filepath.FromSlash(ref)+".json"))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s DirSource) String() string { return s.Dir }
+
+// HTTPSource is a remote clause registry. The clause
+// "confidentiality/mutual" is fetched from <BaseURL>/confidentiality/mutual.json.
+type HTTPSource struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// Fetch implements Source
+func (s HTTPSource) Fetch(ref string) ([]byte, error) {
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	resp, err := client.Get(strings.TrimRight(s.BaseURL, "/") + "/" + ref + ".json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
+
+func (s HTTPSource) String() string { return s.BaseURL }
+
+// ParseSource turns a library location into a Source. Locations starting
+// with http:// or https:// are remote registries; anything else is a
+// directory.
+func ParseSource(location string) Source {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return HTTPSource{BaseURL: location}
+	}
+	return DirSource{Dir: location}
+}
+
+// Sources builds the library search path from explicit locations followed
+// by those listed in NLD_CLAUSE_PATH
+func Sources(locations []string) []Source {
+	var sources []Source
+	for _, loc := range locations {
+		sources = append(sources, ParseSource(loc))
+	}
+	for _, loc := range filepath.SplitList(os.Getenv(LibraryPathEnv)) {
+		if loc != "" {
+			sources = append(sources, ParseSource(loc))
+		}
+	}
+	return sources
+}
+
+// Resolved records one include that was expanded
+type Resolved struct {
+	Ref    string `json:"ref"`
+	Source string `json:"source"`
+	Hash   string `json:"hash"`
+}
+
+// Resolver expands $include references against a list of sources
+type Resolver struct {
+	Sources []Source
+
+	cache map[string]cached
+}
+
+type cached struct {
+	value  map[string]interface{}
+	source string
+}
+
+// Resolve expands every $include in the document's sections and
+// definitions in place. Properties next to $include override those of the
+// included clause, and each expanded entry records its origin under
+// "source".
+func (r *Resolver) Resolve(doc document.Document) ([]Resolved, error) {
+	body := doc.Body(false)
+	if body == nil {
+		return nil, nil
+	}
+
+	var resolved []Resolved
+	for _, key := range []string{"sections", "definitions"} {
+		list, ok := body[key].([]interface{})
+		if !ok {
+			continue
+		}
+		for i, entry := range list {
+			obj, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			expanded, refs, err := r.expand(obj, nil)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", key, i, err)
+			}
+			list[i] = expanded
+			resolved = append(resolved, refs...)
+		}
+	}
+	return resolved, nil
+}
+
+// expand resolves a single entry, following nested includes
+func (r *Resolver) expand(entry map[string]interface{}, stack []string) (map[string]interface{}, []Resolved, error) {
+	raw, ok := entry[Key]
+	if !ok {
+		return entry, nil, nil
+	}
+	ref, ok := raw.(string)
+	if !ok || ref == "" {
+		return nil, nil, fmt.Errorf("%s must be a non-empty string", Key)
+	}
+	ref = path.Clean(strings.TrimPrefix(ref, "/"))
+	if strings.HasPrefix(ref, "..") {
+		return nil, nil, fmt.Errorf("invalid clause reference: %s", ref)
+	}
+
+	for _, s := range stack {
+		if s == ref {
+			return nil, nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), ref)
+		}
+	}
+	if len(stack) >= maxDepth {
+		return nil, nil, fmt.Errorf("includes nested deeper than %d levels", maxDepth)
+	}
+
+	clause, source, err := r.fetch(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base, refs, err := r.expand(clause, append(stack, ref))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hash, err := digest.Hash(clause)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make(map[string]interface{}, len(base)+len(entry))
+	for k, v := range base {
+		result[k] = v
+	}
+	for k, v := range entry {
+		if k != Key {
+			result[k] = v
+		}
+	}
+	result["source"] = map[string]interface{}{"include": ref, "hash": hash}
+
+	refs = append(refs, Resolved{Ref: ref, Source: source, Hash: hash})
+	return result, refs, nil
+}
+
+// fetch looks ref up in each source in order
+func (r *Resolver) fetch(ref string) (map[string]interface{}, string, error) {
+	c, ok := r.cache[ref]
+	metrics.Cache("clause", ok)
+	if ok {
+		return copyMap(c.value), c.source, nil
+	}
+
+	if len(r.Sources) == 0 {
+		return nil, "", fmt.Errorf("no clause library configured for %s; use --library or set %s", ref, LibraryPathEnv)
+	}
+
+	for _, src := range r.Sources {
+		data, err := src.Fetch(ref)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to fetch %s from %s: %w", ref, src, err)
+		}
+
+		parsed, err := document.Parse(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid clause %s in %s: %w", ref, src, err)
+		}
+		clause := map[string]interface{}(parsed)
+
+		if r.cache == nil {
+			r.cache = map[string]cached{}
+		}
+		r.cache[ref] = cached{value: clause, source: src.String()}
+		return copyMap(clause), src.String(), nil
+	}
+
+	return nil, "", fmt.Errorf("clause not found in library: %s", ref)
+}
+
+// copyMap returns a deep copy of a decoded JSON object
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	data, _ := json.Marshal(m)
+	out, _ := document.Parse(data)
+	return out
+}
@@ -0,0 +1,132 @@
+package include
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+func writeClause(t *testing.T, dir, ref, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(ref)+".json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create clause directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write clause: %v", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	writeClause(t, dir, "confidentiality/mutual", `{"id": "confidentiality", "title": "Confidentiality", "content": "Each party shall keep confidential..."}`)
+	writeClause(t, dir, "confidentiality/extended", `{"$include": "confidentiality/mutual", "title": "Extended Confidentiality"}`)
+	writeClause(t, dir, "definitions/affiliate", `{"term": "Affiliate", "definition": "Any entity controlling a party."}`)
+	writeClause(t, dir, "loop/a", `{"$include": "loop/b"}`)
+	writeClause(t, dir, "loop/b", `{"$include": "loop/a"}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/clauses/governing-law/california.json" {
+			w.Write([]byte(`{"id": "law", "title": "Governing Law", "content": "California law governs."}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name        string
+		doc         string
+		expectError string
+		check       func(t *testing.T, doc document.Document)
+	}{
+		{
+			name: "Local Section With Override",
+			doc:  `{"content": {"sections": [{"$include": "confidentiality/mutual", "id": "conf"}]}}`,
+			check: func(t *testing.T, doc document.Document) {
+				s := doc.Sections()[0]
+				if s["id"] != "conf" || s["title"] != "Confidentiality" {
+					t.Errorf("Unexpected section: %v", s)
+				}
+				if _, ok := s[Key]; ok {
+					t.Errorf("Expected %s to be removed", Key)
+				}
+				src := s["source"].(map[string]interface{})
+				if src["include"] != "confidentiality/mutual" || !strings.HasPrefix(src["hash"].(string), "sha256:") {
+					t.Errorf("Unexpected source: %v", src)
+				}
+			},
+		},
+		{
+			name: "Nested Include",
+			doc:  `{"content": {"sections": [{"$include": "confidentiality/extended"}]}}`,
+			check: func(t *testing.T, doc document.Document) {
+				s := doc.Sections()[0]
+				if s["title"] != "Extended Confidentiality" || s["content"] != "Each party shall keep confidential..." {
+					t.Errorf("Unexpected section: %v", s)
+				}
+			},
+		},
+		{
+			name: "Definition",
+			doc:  `{"structure": {"sections": [], "definitions": [{"$include": "definitions/affiliate"}]}}`,
+			check: func(t *testing.T, doc document.Document) {
+				defs := doc.Body(false)["definitions"].([]interface{})
+				if defs[0].(map[string]interface{})["term"] != "Affiliate" {
+					t.Errorf("Unexpected definition: %v", defs[0])
+				}
+			},
+		},
+		{
+			name: "Remote Registry",
+			doc:  `{"content": {"sections": [{"$include": "governing-law/california"}]}}`,
+			check: func(t *testing.T, doc document.Document) {
+				if doc.Sections()[0]["id"] != "law" {
+					t.Errorf("Unexpected section: %v", doc.Sections()[0])
+				}
+			},
+		},
+		{
+			name:        "Missing Clause",
+			doc:         `{"content": {"sections": [{"$include": "missing/clause"}]}}`,
+			expectError: "not found",
+		},
+		{
+			name:        "Cycle",
+			doc:         `{"content": {"sections": [{"$include": "loop/a"}]}}`,
+			expectError: "cycle",
+		},
+		{
+			name:        "Escaping Library",
+			doc:         `{"content": {"sections": [{"$include": "../secret"}]}}`,
+			expectError: "invalid clause reference",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := document.Parse([]byte(tc.doc))
+			if err != nil {
+				t.Fatalf("Failed to parse document: %v", err)
+			}
+
+			r := &Resolver{Sources: []Source{DirSource{Dir: dir}, ParseSource(server.URL + "/clauses")}}
+			_, err = r.Resolve(doc)
+			if tc.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectError) {
+					t.Fatalf("Expected error containing %q, got %v", tc.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve failed: %v", err)
+			}
+			tc.check(t, doc)
+		})
+	}
+}
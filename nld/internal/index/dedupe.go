@@ -0,0 +1,145 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultMinWords is the length below which sections are left out of
+// deduplication, so that stock lines such as "Intentionally left blank" are
+// not reported
+const DefaultMinWords = 10
+
+// Clause is a section of a stored document
+type Clause struct {
+	Document string `json:"document"`
+	Version  int    `json:"version"`
+	Section  string `json:"section"`
+	Title    string `json:"title"`
+}
+
+// Group is a clause found with identical canonical content in several
+// documents
+type Group struct {
+	// Digest is the digest of the canonical content
+	Digest  string   `json:"digest"`
+	Words   int      `json:"words"`
+	Clauses []Clause `json:"clauses"`
+}
+
+// Divergence is a clause title used in several documents with different
+// content. Each variant is a group of identical copies.
+type Divergence struct {
+	Title    string  `json:"title"`
+	Variants []Group `json:"variants"`
+}
+
+// Report is the duplicated and divergent clauses of the indexed documents
+type Report struct {
+	Identical []Group      `json:"identical"`
+	Divergent []Divergence `json:"divergent"`
+}
+
+var punctuationRe = regexp.MustCompile(`[^\p{L}\p{N}\s]+`)
+
+// Canonical returns the canonical form of clause text, under which copies
+// that differ only in case, punctuation or white space are equal
+func Canonical(text string) string {
+	text = punctuationRe.ReplaceAllString(strings.ToLower(text), " ")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Dedupe reports the sections with identical canonical content in more than
+// one document, largest groups first, and the section titles used in more
+// than one document with different content. Sections shorter than minWords
+// words are left out.
+func (x *Index) Dedupe(ctx context.Context, minWords int) (*Report, error) {
+	rows, err := x.db.QueryContext(ctx, `SELECT c.document, d.version, c.section, c.title, c.digest, c.words
+		FROM clauses c JOIN documents d ON d.id = c.document
+		WHERE c.words >= ? ORDER BY c.document, c.rowid`, minWords)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	defer rows.Close()
+
+	groups := map[string]*Group{}
+	var order []string
+	titles := map[string][]string{}
+	titleNames := map[string]string{}
+	for rows.Next() {
+		var c Clause
+		var sum string
+		var words int
+		if err := rows.Scan(&c.Document, &c.Version, &c.Section, &c.Title, &sum, &words); err != nil {
+			return nil, fmt.Errorf("failed to read index: %w", err)
+		}
+		g, ok := groups[sum]
+		if !ok {
+			g = &Group{Digest: sum, Words: words}
+			groups[sum] = g
+			order = append(order, sum)
+		}
+		g.Clauses = append(g.Clauses, c)
+
+		if title := Canonical(c.Title); title != "" {
+			if _, ok := titleNames[title]; !ok {
+				titleNames[title] = c.Title
+			}
+			if !slices.Contains(titles[title], sum) {
+				titles[title] = append(titles[title], sum)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	report := &Report{Identical: []Group{}, Divergent: []Divergence{}}
+	for _, sum := range order {
+		if g := groups[sum]; documents(g.Clauses) > 1 {
+			report.Identical = append(report.Identical, *g)
+		}
+	}
+	sort.SliceStable(report.Identical, func(i, j int) bool {
+		return len(report.Identical[i].Clauses) > len(report.Identical[j].Clauses)
+	})
+
+	for title, sums := range titles {
+		if len(sums) < 2 {
+			continue
+		}
+		d := Divergence{Title: titleNames[title]}
+		var clauses []Clause
+		for _, sum := range sums {
+			// A variant holds only the copies with this title
+			variant := Group{Digest: sum, Words: groups[sum].Words}
+			for _, c := range groups[sum].Clauses {
+				if Canonical(c.Title) == title {
+					variant.Clauses = append(variant.Clauses, c)
+				}
+			}
+			clauses = append(clauses, variant.Clauses...)
+			d.Variants = append(d.Variants, variant)
+		}
+		if documents(clauses) < 2 {
+			continue
+		}
+		sort.SliceStable(d.Variants, func(i, j int) bool { return len(d.Variants[i].Clauses) > len(d.Variants[j].Clauses) })
+		report.Divergent = append(report.Divergent, d)
+	}
+	sort.Slice(report.Divergent, func(i, j int) bool { return report.Divergent[i].Title < report.Divergent[j].Title })
+	return report, nil
+}
+
+// documents returns the number of documents the clauses come from
+func documents(clauses []Clause) int {
+	seen := map[string]bool{}
+	for _, c := range clauses {
+		seen[c.Document] = true
+	}
+	return len(seen)
+}
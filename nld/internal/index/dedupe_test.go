@@ -0,0 +1,70 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/store"
+)
+
+const confidentiality = "Each party shall keep the Confidential Information of the other party secret and use it only for this Agreement."
+
+func TestDedupe(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := store.NewFS(dir)
+	doc := func(sections string) []byte {
+		return []byte(`{"metadata": {"type": "contract"}, "structure": {"sections": [` + sections + `]}}`)
+	}
+	section := func(id, title, content string) string {
+		return fmt.Sprintf(`{"id": %q, "title": %q, "content": %q}`, id, title, content)
+	}
+	law := "This Agreement is governed by the laws of the State of %s, without regard to its conflict of laws rules."
+	docs := map[string][]byte{
+		"lease": doc(section("conf", "Confidentiality", confidentiality) + "," + section("law", "Governing Law", fmt.Sprintf(law, "Delaware"))),
+		// Case, punctuation and white space do not matter
+		"nda": doc(section("secrecy", "Secrecy", "EACH party shall keep the confidential information of the other party secret, and use it only for this  Agreement") + "," +
+			section("law", "Governing law", fmt.Sprintf(law, "New York")) + "," + section("blank", "Reserved", "Intentionally left blank.")),
+		"msa": doc(section("law", "Governing Law", fmt.Sprintf(law, "Delaware")) + "," + section("blank", "Reserved", "Intentionally left blank.")),
+	}
+	for id, data := range docs {
+		if _, err := s.Put(ctx, id, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	x, err := Open(filepath.Join(dir, File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if _, err := x.Sync(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := x.Dedupe(ctx, DefaultMinWords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Identical) != 2 {
+		t.Fatalf("Dedupe() identical = %+v", report.Identical)
+	}
+	for _, g := range report.Identical {
+		if len(g.Clauses) != 2 {
+			t.Errorf("Dedupe() group = %+v", g)
+		}
+	}
+	if len(report.Divergent) != 1 {
+		t.Fatalf("Dedupe() divergent = %+v", report.Divergent)
+	}
+	d := report.Divergent[0]
+	if d.Title != "Governing Law" || len(d.Variants) != 2 || len(d.Variants[0].Clauses) != 2 || d.Variants[1].Clauses[0].Document != "nda" {
+		t.Errorf("Dedupe() divergence = %+v", d)
+	}
+
+	// Short sections count when asked for
+	if report, _ = x.Dedupe(ctx, 1); len(report.Identical) != 3 {
+		t.Errorf("Dedupe() with short sections = %+v", report.Identical)
+	}
+}
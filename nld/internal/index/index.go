@@ -0,0 +1,473 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/readability"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/pkg/nld"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// File is the name of the index database in the directory of a local store
+const File = "index.db"
+
+// Highlight marks the matched words of a snippet
+const (
+	HighlightStart = "["
+	HighlightEnd   = "]"
+)
+
+// schemaVersion is stored as the user_version of the database. An index
+// with another version is rebuilt, since it only caches the store.
+const schemaVersion = 2
+
+const schema = `
+CREATE TABLE IF NOT EXISTS documents (
+	id TEXT PRIMARY KEY,
+	version INTEGER NOT NULL,
+	digest TEXT NOT NULL,
+	type TEXT NOT NULL,
+	title TEXT NOT NULL,
+	author TEXT NOT NULL,
+	jurisdiction TEXT NOT NULL,
+	-- date is the effective date, or the creation date, as YYYY-MM-DD
+	date TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS entities (
+	document TEXT NOT NULL,
+	name TEXT NOT NULL,
+	role TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS entities_document ON entities (document);
+CREATE VIRTUAL TABLE IF NOT EXISTS sections USING fts4 (
+	document, section, title, content,
+	notindexed=document, notindexed=section
+);
+-- clauses holds the canonical content digest of every section
+CREATE TABLE IF NOT EXISTS clauses (
+	document TEXT NOT NULL,
+	section TEXT NOT NULL,
+	title TEXT NOT NULL,
+	digest TEXT NOT NULL,
+	words INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS clauses_document ON clauses (document);
+CREATE INDEX IF NOT EXISTS clauses_digest ON clauses (digest);
+`
+
+// tables are the tables of the schema, dropped when it changes
+var tables = []string{"documents", "entities", "sections", "clauses"}
+
+// Index is a SQLite index of the latest version of stored documents: their
+// metadata, entities and the text of their sections
+type Index struct {
+	db *sql.DB
+}
+
+// Path returns the path of the index of the local store in dir
+func Path(dir string) string {
+	return filepath.Join(dir, File)
+}
+
+// Open opens the index database at path, creating it when missing
+func Open(path string) (*Index, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open index: %w", err)
+	}
+	// SQLite allows one writer; a single connection avoids lock errors
+	db.SetMaxOpenConns(1)
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open index %s: %w", path, err)
+	}
+	return &Index{db: db}, nil
+}
+
+// migrate creates the schema, dropping the tables of an index with another
+// schema version so that the next Sync rebuilds it
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version != schemaVersion {
+		for _, table := range tables {
+			if _, err := db.Exec(`DROP TABLE IF EXISTS ` + table); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+	_, err := db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, schemaVersion))
+	return err
+}
+
+// ErrStale is returned by Verify for an index of another schema version,
+// which the next Sync rebuilds
+var ErrStale = errors.New("index has an old schema and will be rebuilt")
+
+// Verify checks the integrity of the index database at path without
+// changing it
+func Verify(path string) error {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRow(`PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("failed to check index: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("index is corrupt: %s", result)
+	}
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to check index: %w", err)
+	}
+	if version != schemaVersion {
+		return ErrStale
+	}
+	return nil
+}
+
+// Close closes the index database
+func (x *Index) Close() error {
+	return x.db.Close()
+}
+
+// Sync brings the index up to date with a store: documents whose latest
+// version changed are indexed again, and deleted documents removed. It
+// returns the number of documents indexed.
+func (x *Index) Sync(ctx context.Context, s store.Store) (int, error) {
+	list, err := s.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	indexed := map[string]string{}
+	rows, err := x.db.QueryContext(ctx, `SELECT id, digest FROM documents`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read index: %w", err)
+	}
+	for rows.Next() {
+		var id, sum string
+		if err := rows.Scan(&id, &sum); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to read index: %w", err)
+		}
+		indexed[id] = sum
+	}
+	rows.Close()
+
+	updated := 0
+	for _, info := range list {
+		// The audit log is not a document
+		if store.Reserved(info.ID) {
+			continue
+		}
+		sum, ok := indexed[info.ID]
+		delete(indexed, info.ID)
+		if ok && sum == info.Digest {
+			continue
+		}
+		data, info, err := s.Get(ctx, info.ID, info.Version)
+		if err != nil {
+			return updated, err
+		}
+		if err := x.Update(ctx, info, data); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	for id := range indexed {
+		if err := x.Remove(ctx, id); err != nil {
+			return updated, err
+		}
+	}
+	return updated, nil
+}
+
+// Update indexes a stored version of a document, replacing what was indexed
+// for its ID. Documents that cannot be parsed, such as encrypted ones, are
+// indexed by their Info only.
+func (x *Index) Update(ctx context.Context, info store.Info, data []byte) error {
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	defer tx.Rollback()
+	if err := remove(ctx, tx, info.ID); err != nil {
+		return err
+	}
+
+	doc, err := nld.Parse(data)
+	if err != nil {
+		doc = &nld.Document{Metadata: nld.Metadata{Type: info.Type, Title: info.Title}}
+	}
+	m := doc.Metadata
+	date := m.Effective
+	if date == "" {
+		date = m.Created
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO documents (id, version, digest, type, title, author, jurisdiction, date) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		info.ID, info.Version, info.Digest, m.Type, m.Title, m.Author, m.Jurisdiction, day(date)); err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	for _, e := range m.Entities {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO entities (document, name, role) VALUES (?, ?, ?)`, info.ID, e.Name, e.Role); err != nil {
+			return fmt.Errorf("failed to update index: %w", err)
+		}
+	}
+
+	insert := func(section, title, content string) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO sections (document, section, title, content) VALUES (?, ?, ?, ?)`, info.ID, section, title, content)
+		return err
+	}
+	var walk func(sections []nld.Section) error
+	walk = func(sections []nld.Section) error {
+		for _, s := range sections {
+			text := strings.Join(readability.Prose(s), "\n\n")
+			if err := insert(s.ID, s.Title, text); err != nil {
+				return err
+			}
+			canonical := Canonical(text)
+			if _, err := tx.ExecContext(ctx, `INSERT INTO clauses (document, section, title, digest, words) VALUES (?, ?, ?, ?, ?)`,
+				info.ID, s.ID, s.Title, digest.Sum([]byte(canonical)), len(strings.Fields(canonical))); err != nil {
+				return err
+			}
+			if err := walk(s.Sections); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(doc.Structure.Sections); err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	for _, d := range doc.Structure.Definitions {
+		if err := insert("", d.Term, d.Definition); err != nil {
+			return fmt.Errorf("failed to update index: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	return nil
+}
+
+// Remove removes a document from the index
+func (x *Index) Remove(ctx context.Context, id string) error {
+	tx, err := x.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update index: %w", err)
+	}
+	defer tx.Rollback()
+	if err := remove(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func remove(ctx context.Context, tx *sql.Tx, id string) error {
+	for _, stmt := range []string{
+		`DELETE FROM documents WHERE id = ?`,
+		`DELETE FROM entities WHERE document = ?`,
+		`DELETE FROM sections WHERE document = ?`,
+		`DELETE FROM clauses WHERE document = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+			return fmt.Errorf("failed to update index: %w", err)
+		}
+	}
+	return nil
+}
+
+// Query selects documents. Empty fields do not restrict the results.
+type Query struct {
+	// Text is matched against the titles and text of sections and
+	// definitions, with the SQLite full-text query syntax: words must all
+	// appear, "quoted phrases" appear as written, and OR, NOT and prefix*
+	// are supported
+	Text string
+	// Type is the document type, such as contract
+	Type string
+	// After and Before bound the document date, its effective date or
+	// else its creation date, as YYYY-MM-DD; both are inclusive
+	After  string
+	Before string
+	// Entity is part of the name or role of an entity of the document
+	Entity string
+	// Limit is the maximum number of documents, unlimited when 0
+	Limit int
+}
+
+// Result is a document matching a query
+type Result struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+	Type    string `json:"type,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Date    string `json:"date,omitempty"`
+	// Matches are the sections and definitions matching the query text
+	Matches []Match `json:"matches,omitempty"`
+}
+
+// Match is a section or definition matching the query text
+type Match struct {
+	// Section is the section ID, empty for definitions
+	Section string `json:"section,omitempty"`
+	Title   string `json:"title"`
+	// Snippet is the matching text, with the matched words between
+	// HighlightStart and HighlightEnd
+	Snippet string `json:"snippet"`
+}
+
+var dayRe = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// Validate checks the dates of a query
+func (q Query) Validate() error {
+	for _, date := range []string{q.After, q.Before} {
+		if date != "" && !dayRe.MatchString(date) {
+			return fmt.Errorf("invalid date %q: use YYYY-MM-DD", date)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether a document with metadata m passes the type, date
+// and entity filters of the query, as Search applies them
+func (q Query) Matches(m nld.Metadata) bool {
+	if q.Type != "" && m.Type != q.Type {
+		return false
+	}
+	date := m.Effective
+	if date == "" {
+		date = m.Created
+	}
+	date = day(date)
+	if (q.After != "" && (date == "" || date < q.After)) || (q.Before != "" && (date == "" || date > q.Before)) {
+		return false
+	}
+	if q.Entity == "" {
+		return true
+	}
+	entity := strings.ToLower(q.Entity)
+	for _, e := range m.Entities {
+		if strings.Contains(strings.ToLower(e.Name), entity) || strings.Contains(strings.ToLower(e.Role), entity) {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the documents matching a query. With query text, the
+// documents with the most matching sections come first; otherwise
+// documents are sorted by ID.
+func (x *Index) Search(ctx context.Context, q Query) ([]Result, error) {
+	var where []string
+	var args []interface{}
+	if q.Type != "" {
+		where = append(where, `d.type = ?`)
+		args = append(args, q.Type)
+	}
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	for _, bound := range []struct{ value, op string }{{q.After, ">="}, {q.Before, "<="}} {
+		if bound.value == "" {
+			continue
+		}
+		where = append(where, `d.date != '' AND d.date `+bound.op+` ?`)
+		args = append(args, bound.value)
+	}
+	if q.Entity != "" {
+		where = append(where, `d.id IN (SELECT document FROM entities WHERE name LIKE ? OR role LIKE ?)`)
+		pattern := "%" + q.Entity + "%"
+		args = append(args, pattern, pattern)
+	}
+
+	var query string
+	if q.Text != "" {
+		query = `SELECT d.id, d.version, d.type, d.title, d.date, s.section, s.title,
+			snippet(sections, '` + HighlightStart + `', '` + HighlightEnd + `', '…', -1, 16)
+			FROM sections s JOIN documents d ON d.id = s.document
+			WHERE sections MATCH ?`
+		args = append([]interface{}{q.Text}, args...)
+		if len(where) > 0 {
+			query += ` AND ` + strings.Join(where, ` AND `)
+		}
+	} else {
+		query = `SELECT d.id, d.version, d.type, d.title, d.date, NULL, NULL, NULL FROM documents d`
+		if len(where) > 0 {
+			query += ` WHERE ` + strings.Join(where, ` AND `)
+		}
+	}
+	query += ` ORDER BY d.id`
+
+	rows, err := x.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, searchError(q, err)
+	}
+	defer rows.Close()
+
+	results := []Result{}
+	for rows.Next() {
+		var r Result
+		var section, title, snippet sql.NullString
+		if err := rows.Scan(&r.ID, &r.Version, &r.Type, &r.Title, &r.Date, &section, &title, &snippet); err != nil {
+			return nil, fmt.Errorf("failed to search index: %w", err)
+		}
+		if n := len(results); n == 0 || results[n-1].ID != r.ID {
+			results = append(results, r)
+		}
+		if snippet.Valid {
+			last := &results[len(results)-1]
+			last.Matches = append(last.Matches, Match{Section: section.String, Title: title.String, Snippet: snippet.String})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, searchError(q, err)
+	}
+
+	if q.Text != "" {
+		sort.SliceStable(results, func(i, j int) bool { return len(results[i].Matches) > len(results[j].Matches) })
+	}
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results, nil
+}
+
+// searchError describes a failed search, blaming the query text when SQLite
+// could not parse it
+func searchError(q Query, err error) error {
+	if q.Text != "" && strings.Contains(err.Error(), "MATCH") {
+		return fmt.Errorf("invalid query %q: %w", q.Text, err)
+	}
+	return fmt.Errorf("failed to search index: %w", err)
+}
+
+// day returns the date part of a date or datetime, or "" when it has none
+func day(value string) string {
+	if len(value) >= 10 && dayRe.MatchString(value[:10]) {
+		return value[:10]
+	}
+	return ""
+}
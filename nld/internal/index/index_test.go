@@ -0,0 +1,113 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const lease = `{
+  "metadata": {"type": "contract", "version": "1.0.0", "created": "2023-11-02", "effective": "2024-03-01", "title": "Lease",
+    "entities": [{"id": "landlord", "name": "Acme Properties", "role": "landlord"}]},
+  "structure": {"sections": [
+    {"id": "rent", "title": "Rent", "content": "The tenant pays rent monthly."},
+    {"id": "indemnity", "title": "Indemnification", "content": "The tenant shall indemnify the landlord against all claims."}
+  ]}
+}`
+
+const nda = `{
+  "metadata": {"type": "contract", "version": "1.0.0", "created": "2023-06-10", "title": "NDA"},
+  "structure": {"sections": [{"id": "confidentiality", "title": "Confidentiality", "content": "Each party shall indemnify the other for any disclosure."}]}
+}`
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := store.NewFS(dir)
+	for id, doc := range map[string]string{"lease": lease, "nda": nda, "memo": `{"metadata": {"type": "memo", "created": "2024-05-01"}, "structure": {"sections": []}}`} {
+		if _, err := s.Put(ctx, id, []byte(doc)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	x, err := Open(filepath.Join(dir, File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if n, err := x.Sync(ctx, s); err != nil || n != 3 {
+		t.Fatalf("Sync() = %d, %v", n, err)
+	}
+	if n, err := x.Sync(ctx, s); err != nil || n != 0 {
+		t.Errorf("Sync() of an unchanged store = %d, %v", n, err)
+	}
+
+	results, err := x.Search(ctx, Query{Text: "indemnify", Type: "contract"})
+	if err != nil || len(results) != 2 {
+		t.Fatalf("Search() = %+v, %v", results, err)
+	}
+	m := results[0].Matches
+	if results[0].ID != "lease" || len(m) != 1 || m[0].Section != "indemnity" || !strings.Contains(m[0].Snippet, "[indemnify]") {
+		t.Errorf("Search() first result = %+v", results[0])
+	}
+
+	if results, _ = x.Search(ctx, Query{Text: "indemnify", After: "2024-01-01"}); len(results) != 1 || results[0].ID != "lease" || results[0].Date != "2024-03-01" {
+		t.Errorf("Search() after 2024-01-01 = %+v", results)
+	}
+	if results, _ = x.Search(ctx, Query{Entity: "acme"}); len(results) != 1 || results[0].ID != "lease" {
+		t.Errorf("Search() by entity = %+v", results)
+	}
+	if results, _ = x.Search(ctx, Query{Type: "memo"}); len(results) != 1 || results[0].ID != "memo" {
+		t.Errorf("Search() by type = %+v", results)
+	}
+	if _, err := x.Search(ctx, Query{After: "March 2024"}); err == nil {
+		t.Error("Search() with an invalid date succeeded")
+	}
+
+	meta := nld.Metadata{Type: "contract", Created: "2023-11-02", Effective: "2024-03-01T09:00:00Z", Entities: []nld.Entity{{Name: "Acme Properties"}}}
+	if !(Query{Type: "contract", After: "2024-03-01", Entity: "ACME"}).Matches(meta) || (Query{Before: "2024-02-29"}).Matches(meta) {
+		t.Errorf("Matches() disagrees with Search()")
+	}
+
+	if err := s.Delete(ctx, "lease"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := x.Sync(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	if results, _ = x.Search(ctx, Query{Text: "indemnify"}); len(results) != 1 || results[0].ID != "nda" {
+		t.Errorf("Search() after Delete() = %+v", results)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), File)
+	x, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(path); err != nil {
+		t.Errorf("Verify of a new index: %v", err)
+	}
+	if _, err := x.db.Exec(`PRAGMA user_version = 1`); err != nil {
+		t.Fatal(err)
+	}
+	x.Close()
+	if err := Verify(path); !errors.Is(err, ErrStale) {
+		t.Errorf("Verify of an old index = %v, want ErrStale", err)
+	}
+
+	garbage := filepath.Join(t.TempDir(), File)
+	if err := os.WriteFile(garbage, []byte("not a database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(garbage); err == nil {
+		t.Error("expected an error for a file that is not a database")
+	}
+}
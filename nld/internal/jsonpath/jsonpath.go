@@ -0,0 +1,242 @@
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Step is a single component of a parsed path
+type Step struct {
+	// Key selects an object member
+	Key string
+	// Index selects an array element when IsIndex is set
+	Index   int
+	IsIndex bool
+	// Wildcard selects every member or element
+	Wildcard bool
+}
+
+// Path is a parsed JSONPath expression
+type Path struct {
+	Raw   string
+	Steps []Step
+}
+
+// Match is a location selected by a path
+type Match struct {
+	// Pointer is the JSON Pointer of the matched value
+	Pointer string
+	Value   interface{}
+}
+
+// Parse parses the supported JSONPath subset: $, .key, ['key'], [n] and
+// the [*] / .* wildcards
+func Parse(expr string) (*Path, error) {
+	s := strings.TrimSpace(expr)
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("path must start with $: %s", expr)
+	}
+	s = s[1:]
+
+	path := &Path{Raw: expr}
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end == -1 {
+				end = len(s)
+			}
+			name := s[:end]
+			if name == "" {
+				return nil, fmt.Errorf("empty member name in path: %s", expr)
+			}
+			if name == "*" {
+				path.Steps = append(path.Steps, Step{Wildcard: true})
+			} else {
+				path.Steps = append(path.Steps, Step{Key: name})
+			}
+			s = s[end:]
+		case '[':
+			end := strings.Index(s, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated bracket in path: %s", expr)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+
+			switch {
+			case inner == "*":
+				path.Steps = append(path.Steps, Step{Wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path.Steps = append(path.Steps, Step{Key: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid index %q in path: %s", inner, expr)
+				}
+				path.Steps = append(path.Steps, Step{Index: n, IsIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in path: %s", s[0], expr)
+		}
+	}
+
+	return path, nil
+}
+
+// Find returns every value in root selected by the path
+func (p *Path) Find(root interface{}) []Match {
+	var matches []Match
+	walk(root, p.Steps, "", func(ptr string, v interface{}) {
+		matches = append(matches, Match{Pointer: ptr, Value: v})
+	})
+	return matches
+}
+
+// Replace sets every value selected by the path to the result of fn and
+// returns the number of values replaced
+func (p *Path) Replace(root interface{}, fn func(old interface{}) interface{}) int {
+	if len(p.Steps) == 0 {
+		return 0
+	}
+	count := 0
+	parents := p.parents(root)
+	for _, parent := range parents {
+		last := p.Steps[len(p.Steps)-1]
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			for _, key := range memberKeys(node, last) {
+				node[key] = fn(node[key])
+				count++
+			}
+		case []interface{}:
+			for _, i := range elementIndexes(node, last) {
+				node[i] = fn(node[i])
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Delete removes every value selected by the path. Array elements are
+// removed in place, so the new root is returned alongside the count.
+func (p *Path) Delete(root interface{}) (interface{}, int) {
+	if len(p.Steps) == 0 {
+		return root, 0
+	}
+	count := 0
+	newRoot := deleteAt(root, p.Steps, &count)
+	return newRoot, count
+}
+
+// parents returns the containers addressed by all but the last step
+func (p *Path) parents(root interface{}) []interface{} {
+	var parents []interface{}
+	walk(root, p.Steps[:len(p.Steps)-1], "", func(_ string, v interface{}) {
+		parents = append(parents, v)
+	})
+	return parents
+}
+
+// walk visits every value addressed by steps
+func walk(node interface{}, steps []Step, ptr string, visit func(string, interface{})) {
+	if len(steps) == 0 {
+		visit(ptr, node)
+		return
+	}
+	step := steps[0]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for _, key := range memberKeys(n, step) {
+			walk(n[key], steps[1:], ptr+"/"+escape(key), visit)
+		}
+	case []interface{}:
+		for _, i := range elementIndexes(n, step) {
+			walk(n[i], steps[1:], ptr+"/"+strconv.Itoa(i), visit)
+		}
+	}
+}
+
+// deleteAt removes the addressed values below node and returns the new node
+func deleteAt(node interface{}, steps []Step, count *int) interface{} {
+	step := steps[0]
+	last := len(steps) == 1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for _, key := range memberKeys(n, step) {
+			if last {
+				delete(n, key)
+				*count++
+			} else {
+				n[key] = deleteAt(n[key], steps[1:], count)
+			}
+		}
+		return n
+	case []interface{}:
+		indexes := elementIndexes(n, step)
+		if !last {
+			for _, i := range indexes {
+				n[i] = deleteAt(n[i], steps[1:], count)
+			}
+			return n
+		}
+		drop := make(map[int]bool, len(indexes))
+		for _, i := range indexes {
+			drop[i] = true
+		}
+		kept := make([]interface{}, 0, len(n))
+		for i, v := range n {
+			if drop[i] {
+				*count++
+				continue
+			}
+			kept = append(kept, v)
+		}
+		return kept
+	}
+	return node
+}
+
+// memberKeys returns the object keys selected by step
+func memberKeys(obj map[string]interface{}, step Step) []string {
+	if step.IsIndex {
+		return nil
+	}
+	if step.Wildcard {
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	if _, ok := obj[step.Key]; ok {
+		return []string{step.Key}
+	}
+	return nil
+}
+
+// elementIndexes returns the array indexes selected by step
+func elementIndexes(arr []interface{}, step Step) []int {
+	if step.Wildcard {
+		indexes := make([]int, len(arr))
+		for i := range arr {
+			indexes[i] = i
+		}
+		return indexes
+	}
+	if step.IsIndex && step.Index < len(arr) {
+		return []int{step.Index}
+	}
+	return nil
+}
+
+// escape escapes a key for use in a JSON Pointer
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
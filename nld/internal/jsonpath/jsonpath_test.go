@@ -0,0 +1,87 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("Failed to decode test JSON: %v", err)
+	}
+	return v
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expr        string
+		expectSteps int
+		expectError bool
+	}{
+		{name: "Root", expr: "$", expectSteps: 0},
+		{name: "Dotted", expr: "$.metadata.author", expectSteps: 2},
+		{name: "Index", expr: "$.content.sections[1].content", expectSteps: 4},
+		{name: "Quoted", expr: "$['metadata']['title']", expectSteps: 2},
+		{name: "Wildcard", expr: "$.metadata.entities[*].name", expectSteps: 4},
+		{name: "Missing Root", expr: "metadata.author", expectError: true},
+		{name: "Bad Index", expr: "$.a[x]", expectError: true},
+		{name: "Unterminated", expr: "$.a[0", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse(tc.expr)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected success, got error: %v", err)
+			}
+			if len(p.Steps) != tc.expectSteps {
+				t.Errorf("Expected %d steps, got %d", tc.expectSteps, len(p.Steps))
+			}
+		})
+	}
+}
+
+func TestFindReplaceDelete(t *testing.T) {
+	doc := decode(t, `{"metadata":{"entities":[{"name":"Acme"},{"name":"XYZ"}]}}`)
+
+	p, err := Parse("$.metadata.entities[*].name")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	matches := p.Find(doc)
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 matches, got %d", len(matches))
+	}
+	if matches[1].Pointer != "/metadata/entities/1/name" {
+		t.Errorf("Unexpected pointer: %s", matches[1].Pointer)
+	}
+
+	if n := p.Replace(doc, func(interface{}) interface{} { return "X" }); n != 2 {
+		t.Errorf("Expected 2 replacements, got %d", n)
+	}
+	for _, m := range p.Find(doc) {
+		if m.Value != "X" {
+			t.Errorf("Expected replaced value, got %v", m.Value)
+		}
+	}
+
+	del, _ := Parse("$.metadata.entities[0]")
+	_, n := del.Delete(doc)
+	if n != 1 {
+		t.Errorf("Expected 1 deletion, got %d", n)
+	}
+	entities := doc.(map[string]interface{})["metadata"].(map[string]interface{})["entities"].([]interface{})
+	if len(entities) != 1 {
+		t.Errorf("Expected 1 remaining entity, got %d", len(entities))
+	}
+}
@@ -0,0 +1,86 @@
+package lint
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// numericDate matches dates written as numbers separated by slashes, dots
+// or dashes, such as 03/04/2025 or 3.4.25
+var numericDate = regexp.MustCompile(`\b(\d{1,2})([/.-])(\d{1,2})([/.-])(\d{4}|\d{2})\b`)
+
+func init() {
+	register(Rule{
+		ID:          "NLD1001",
+		Name:        "date-range",
+		Description: "Metadata dates and durations must parse, and the document must expire after it takes effect",
+		Severity:    Error,
+		Check:       checkDateRange,
+	})
+	register(Rule{
+		ID:          "NLD2001",
+		Name:        "ambiguous-date",
+		Description: "Dates in the text must not be open to day/month confusion or use two-digit years",
+		Severity:    Warning,
+		Check:       checkAmbiguousDates,
+	})
+	register(Rule{
+		ID:          "NLD2002",
+		Name:        "datetime-without-offset",
+		Description: "Metadata datetimes should include a UTC offset",
+		Severity:    Warning,
+		Check:       checkDatetimeOffsets,
+	})
+}
+
+// checkDateRange reports the metadata date errors also reported by
+// validation
+func checkDateRange(d *Document) []Finding {
+	var findings []Finding
+	for _, e := range d.Doc.Metadata.CheckDates() {
+		findings = append(findings, finding("/metadata/"+e.Field, "%s", e.Message))
+	}
+	return findings
+}
+
+// checkAmbiguousDates reports numeric dates in the text whose day and
+// month could be swapped, and dates with two-digit years
+func checkAmbiguousDates(d *Document) []Finding {
+	var findings []Finding
+	for _, t := range d.Texts() {
+		for _, m := range numericDate.FindAllStringSubmatch(t.Value, -1) {
+			// Mixed separators are most likely not a date
+			if m[2] != m[4] {
+				continue
+			}
+			a, _ := strconv.Atoi(m[1])
+			b, _ := strconv.Atoi(m[3])
+			switch {
+			case a >= 1 && a <= 12 && b >= 1 && b <= 12 && a != b:
+				findings = append(findings, finding(t.Path, "date %q could be read as day/month or month/day; write it as YYYY-MM-DD or spell out the month", m[0]))
+			case len(m[5]) == 2:
+				findings = append(findings, finding(t.Path, "date %q has a two-digit year", m[0]))
+			}
+		}
+	}
+	return findings
+}
+
+// checkDatetimeOffsets reports metadata datetimes that depend on the
+// reader's time zone
+func checkDatetimeOffsets(d *Document) []Finding {
+	meta := d.Doc.Metadata
+	var findings []Finding
+	for _, f := range []struct{ name, value string }{{"created", meta.Created}, {"effective", meta.Effective}, {"expires", meta.Expires}} {
+		if f.value != "" && !nld.HasZone(f.value) {
+			if meta.Timezone != "" {
+				findings = append(findings, finding("/metadata/"+f.name, "datetime %q has no UTC offset and is read in %s", f.value, meta.Timezone))
+			} else {
+				findings = append(findings, finding("/metadata/"+f.name, "datetime %q has no UTC offset and is read as UTC", f.value))
+			}
+		}
+	}
+	return findings
+}
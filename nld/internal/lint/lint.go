@@ -0,0 +1,246 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/pii"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Severity is the severity of a lint finding
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Info    Severity = "info"
+)
+
+// Finding is a problem reported by a lint rule
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// Path is the JSON pointer of the offending value
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// String returns a one-line description of the finding
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s %s: %s", f.Path, f.Severity, f.Rule, f.Message)
+}
+
+// Document is the input to lint rules: the raw JSON object and the typed
+// model of the same document, and the options of the run
+type Document struct {
+	Raw     document.Document
+	Doc     *nld.Document
+	Options Options
+}
+
+// Rule is a single lint check. Rule IDs starting with NLD1 report errors
+// in the meaning of a document; NLD2 rules report style and ambiguity.
+type Rule struct {
+	ID          string
+	Name        string
+	Description string
+	Severity    Severity
+	// Check returns the findings for a document. Rule and Severity are
+	// filled in by Lint.
+	Check func(d *Document) []Finding
+}
+
+// Options selects the rules to run
+type Options struct {
+	// Only runs just the listed rules when set
+	Only []string
+	// Disable skips the listed rules
+	Disable []string
+	// MaxGradeLevel and MaxSentenceLength are the thresholds of the
+	// complex-section rule; zero selects the defaults
+	MaxGradeLevel     float64
+	MaxSentenceLength float64
+	// PIIKinds are the kinds of personal data the pii-in-template rule
+	// looks for, and PIITypes the document types it checks besides
+	// documents with placeholders; nil selects the defaults
+	PIIKinds []string
+	PIITypes []string
+}
+
+var rules = map[string]Rule{}
+
+// register adds a rule to the registry
+func register(r Rule) {
+	if _, dup := rules[r.ID]; dup {
+		panic("lint: duplicate rule " + r.ID)
+	}
+	rules[r.ID] = r
+}
+
+// Rules returns all registered rules ordered by ID
+func Rules() []Rule {
+	list := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Lookup returns the rule with the given ID or name
+func Lookup(id string) (Rule, bool) {
+	if r, ok := rules[strings.ToUpper(id)]; ok {
+		return r, true
+	}
+	for _, r := range rules {
+		if r.Name == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Lint parses a document and runs the selected rules over it. Findings
+// suppressed by the x-nld-ignore property of an object containing them are
+// left out, and the others are ordered by path and rule.
+func Lint(data []byte, opts Options) ([]Finding, error) {
+	raw, err := document.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := nld.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := selectRules(opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, kind := range opts.PIIKinds {
+		if !pii.ValidKind(kind) {
+			return nil, fmt.Errorf("unknown PII kind: %s (expected one of: %s)", kind, strings.Join(pii.Kinds(), ", "))
+		}
+	}
+
+	d := &Document{Raw: raw, Doc: doc, Options: opts}
+	var findings []Finding
+	for _, r := range selected {
+		for _, f := range r.Check(d) {
+			f.Rule = r.ID
+			f.Severity = r.Severity
+			findings = append(findings, f)
+		}
+	}
+
+	suppressed, _ := suppressions(raw)
+	findings = suppress(findings, suppressed)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Rule < findings[j].Rule
+	})
+	return findings, nil
+}
+
+// HasErrors reports whether any finding has error severity
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// selectRules resolves the rules chosen by opts
+func selectRules(opts Options) ([]Rule, error) {
+	resolve := func(ids []string) (map[string]bool, error) {
+		set := map[string]bool{}
+		for _, id := range ids {
+			r, ok := Lookup(id)
+			if !ok {
+				return nil, fmt.Errorf("unknown lint rule: %s", id)
+			}
+			set[r.ID] = true
+		}
+		return set, nil
+	}
+
+	only, err := resolve(opts.Only)
+	if err != nil {
+		return nil, err
+	}
+	disabled, err := resolve(opts.Disable)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []Rule
+	for _, r := range Rules() {
+		if (len(only) == 0 || only[r.ID]) && !disabled[r.ID] {
+			selected = append(selected, r)
+		}
+	}
+	return selected, nil
+}
+
+// finding builds a finding for a rule's Check function
+func finding(path, format string, args ...interface{}) Finding {
+	return Finding{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+// Text is a piece of prose in a document together with its location
+type Text struct {
+	Path  string
+	Value string
+	// Section is the ID of the section containing the text, empty for
+	// definitions
+	Section string
+}
+
+// Texts returns the prose of a document: section content, including the
+// text of content blocks, and definitions
+func (d *Document) Texts() []Text {
+	var texts []Text
+	body := "/" + d.Doc.BodyKey()
+
+	var walk func(sections []nld.Section, path string)
+	walk = func(sections []nld.Section, path string) {
+		for i, s := range sections {
+			sp := fmt.Sprintf("%s/%d", path, i)
+			if s.Blocks == nil {
+				texts = append(texts, Text{Path: sp + "/content", Value: s.Content, Section: s.ID})
+			}
+			for j, b := range s.Blocks {
+				bp := fmt.Sprintf("%s/content/%d", sp, j)
+				switch b.Type {
+				case nld.BlockParagraph:
+					texts = append(texts, Text{Path: bp + "/text", Value: b.Text, Section: s.ID})
+				case nld.BlockList:
+					for k, item := range b.Items {
+						texts = append(texts, Text{Path: fmt.Sprintf("%s/items/%d", bp, k), Value: item, Section: s.ID})
+					}
+				case nld.BlockTable:
+					for r, row := range b.Rows {
+						for c, cell := range row {
+							texts = append(texts, Text{Path: fmt.Sprintf("%s/rows/%d/%d", bp, r, c), Value: cell, Section: s.ID})
+						}
+					}
+				}
+			}
+			walk(s.Sections, sp+"/sections")
+		}
+	}
+	walk(d.Doc.Structure.Sections, body+"/sections")
+
+	for i, def := range d.Doc.Structure.Definitions {
+		texts = append(texts, Text{Path: fmt.Sprintf("%s/definitions/%d/definition", body, i), Value: def.Definition})
+	}
+	return texts
+}
@@ -0,0 +1,440 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDateRules(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata string
+		content  string
+		expect   []string
+	}{
+		{
+			name:     "Clean",
+			metadata: `"effective": "2025-07-01", "expires": "2026-06-30T17:00:00+01:00"`,
+			content:  `"Payment is due on 2025-08-15 or 31/12/2025."`,
+		},
+		{
+			name:     "Ambiguous Date",
+			metadata: `"effective": "2025-07-01"`,
+			content:  `"Payment is due on 03/04/2025."`,
+			expect:   []string{`/content/sections/0/content: warning NLD2001: date "03/04/2025" could be read as day/month or month/day; write it as YYYY-MM-DD or spell out the month`},
+		},
+		{
+			name:     "Two Digit Year",
+			metadata: `"effective": "2025-07-01"`,
+			content:  `[{"type": "list", "items": ["Delivery by 25.12.25"]}]`,
+			expect:   []string{`/content/sections/0/content/0/items/0: warning NLD2001: date "25.12.25" has a two-digit year`},
+		},
+		{
+			name:     "Expiry Before Effective",
+			metadata: `"effective": "2025-07-01", "expires": "2025-06-30"`,
+			content:  `"Text"`,
+			expect:   []string{`/metadata/expires: error NLD1001: 2025-06-30 is not after the effective date 2025-07-01`},
+		},
+		{
+			name:     "Datetime Without Offset",
+			metadata: `"effective": "2025-07-01T09:00:00", "timezone": "Europe/London"`,
+			content:  `"Text"`,
+			expect:   []string{`/metadata/effective: warning NLD2002: datetime "2025-07-01T09:00:00" has no UTC offset and is read in Europe/London`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := `{"metadata": {"type": "contract", "created": "2025-06-27T12:00:00Z", ` + tc.metadata + `},
+  "content": {"sections": [{"id": "terms", "title": "Terms", "content": ` + tc.content + `}]}}`
+			findings, err := Lint([]byte(data), Options{})
+			if err != nil {
+				t.Fatalf("Lint failed: %v", err)
+			}
+
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected findings:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	data := []byte(`{"metadata": {"type": "contract", "created": "2025-06-27T12:00:00"},
+  "content": {"sections": [{"id": "terms", "title": "Terms", "content": "Due 03/04/2025"}]}}`)
+
+	testCases := []struct {
+		name   string
+		opts   Options
+		expect []string
+	}{
+		{name: "All", opts: Options{}, expect: []string{"NLD2001", "NLD2002"}},
+		{name: "Disable", opts: Options{Disable: []string{"NLD2001"}}, expect: []string{"NLD2002"}},
+		{name: "Only By Name", opts: Options{Only: []string{"ambiguous-date"}}, expect: []string{"NLD2001"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			findings, err := Lint(data, tc.opts)
+			if err != nil {
+				t.Fatalf("Lint failed: %v", err)
+			}
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.Rule)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.expect, ",") {
+				t.Errorf("Expected rules %v, got %v", tc.expect, got)
+			}
+		})
+	}
+
+	if _, err := Lint(data, Options{Disable: []string{"NLD9999"}}); err == nil {
+		t.Error("Expected an error for an unknown rule")
+	}
+}
+
+func TestComplexSections(t *testing.T) {
+	plain := `"The client pays each month. The fee is ten pounds. You can stop at any time."`
+	complex := `"Notwithstanding any provision to the contrary herein, the indemnifying party shall, at its own expense, defend, indemnify and hold harmless the indemnified party against all liabilities, damages and expenses arising from any third-party claim."`
+
+	testCases := []struct {
+		name    string
+		content string
+		opts    Options
+		expect  []string
+	}{
+		{name: "Plain", content: plain},
+		{
+			name:    "Complex",
+			content: complex,
+			expect: []string{
+				`/content/sections/0: warning NLD2003: section "terms" reads at grade level 22.0, above the maximum of 16`,
+				`/content/sections/0: warning NLD2003: sentences in section "terms" average 34.0 words, above the maximum of 30`,
+			},
+		},
+		{
+			name:    "Lower Thresholds",
+			content: plain,
+			opts:    Options{MaxSentenceLength: 4},
+			expect:  []string{`/content/sections/0: warning NLD2003: sentences in section "terms" average 5.3 words, above the maximum of 4`},
+		},
+		{name: "Higher Thresholds", content: complex, opts: Options{MaxGradeLevel: 30, MaxSentenceLength: 40}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := `{"metadata": {"type": "contract", "created": "2025-06-27T12:00:00Z"},
+  "content": {"sections": [{"id": "terms", "title": "Terms", "content": ` + tc.content + `}]}}`
+			tc.opts.Only = []string{"complex-section"}
+			findings, err := Lint([]byte(data), tc.opts)
+			if err != nil {
+				t.Fatalf("Lint failed: %v", err)
+			}
+
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected findings:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestTermRules(t *testing.T) {
+	testCases := []struct {
+		name        string
+		definitions string
+		content     string
+		expect      []string
+	}{
+		{
+			name:        "Defined And Used",
+			definitions: `[{"term": "Services", "definition": "The work described in Schedule 1."}]`,
+			content:     `"The Supplier's Services start on 1 July. Each Service is billed monthly."`,
+			expect:      []string{`/content/sections/0/content: warning NLD2004: term "Supplier" is capitalized like a defined term but has no definition`},
+		},
+		{
+			name:        "Unused Definition",
+			definitions: `[{"term": "Services", "definition": "The work."}, {"term": "Deliverables", "definition": "The reports."}]`,
+			content:     `"We provide the Services."`,
+			expect:      []string{`/content/definitions/1: warning NLD2005: definition of "Deliverables" is never used`},
+		},
+		{
+			name:        "Used In Another Definition",
+			definitions: `[{"term": "Services", "definition": "The work and any Deliverables."}, {"term": "Deliverables", "definition": "The reports."}]`,
+			content:     `"We provide the Services."`,
+		},
+		{
+			name:        "Quoted Term",
+			definitions: `[]`,
+			content:     `"This contract (the \"Master Agreement\") starts in March under Section 2."`,
+			expect:      []string{`/content/sections/0/content: warning NLD2004: term "Master Agreement" is capitalized like a defined term but has no definition`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := `{"metadata": {"type": "contract", "created": "2025-06-27T12:00:00Z"},
+  "content": {"definitions": ` + tc.definitions + `, "sections": [{"id": "terms", "title": "Terms", "content": ` + tc.content + `}]}}`
+			findings, err := Lint([]byte(data), Options{Only: []string{"undefined-term", "unused-definition"}})
+			if err != nil {
+				t.Fatalf("Lint failed: %v", err)
+			}
+
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected findings:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestStatusRules(t *testing.T) {
+	testCases := []struct {
+		name       string
+		status     string
+		signatures string
+		expect     []string
+	}{
+		{
+			name:       "No Status",
+			signatures: `[{"signerId": "provider", "date": "2025-06-27", "value": "sig"}]`,
+		},
+		{
+			name:       "Executed",
+			status:     `"executed"`,
+			signatures: `[{"signerId": "provider", "date": "2025-06-27", "value": "sig"}, {"signerId": "client", "date": "2025-06-28", "value": "docusign:env-1"}]`,
+		},
+		{
+			name:       "Executed Unsigned",
+			status:     `"executed"`,
+			signatures: `[{"signerId": "provider", "date": "2025-06-27", "value": "sig"}]`,
+			expect:     []string{`/metadata/entities/1: error NLD1005: document is executed but entity client has not signed`},
+		},
+		{
+			name:       "Signed In Review",
+			status:     `"review"`,
+			signatures: `[{"signerId": "provider", "date": "2025-06-27", "value": "sig"}]`,
+			expect:     []string{`/verification/signatures/0: warning NLD2006: document is signed by provider while still in review`},
+		},
+		{
+			name:       "Unknown Status",
+			status:     `"signed"`,
+			signatures: `[]`,
+			expect:     []string{`/metadata/status: error NLD1004: unknown status "signed" (expected one of: draft, review, approved, executed, archived)`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status := ""
+			if tc.status != "" {
+				status = `"status": ` + tc.status + `, `
+			}
+			data := `{"metadata": {"type": "contract", "created": "2025-06-27T12:00:00Z", ` + status + `"entities": [{"id": "provider", "name": "ABC Corp", "role": "Service Provider"}, {"id": "client", "name": "XYZ Ltd", "role": "Client"}]},
+  "content": {"sections": [{"id": "terms", "title": "Terms", "content": "Text"}]},
+  "verification": {"signatures": ` + tc.signatures + `}}`
+			findings, err := Lint([]byte(data), Options{Only: []string{"invalid-status", "executed-unsigned", "signed-before-approval"}})
+			if err != nil {
+				t.Fatalf("Lint failed: %v", err)
+			}
+
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected findings:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestSuppressions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sections string
+		expect   []string
+	}{
+		{
+			name:     "None",
+			sections: `{"id": "a", "title": "A", "content": "Due 03/04/2025"}`,
+			expect:   []string{"/content/sections/0/content NLD2001"},
+		},
+		{
+			name: "Section",
+			sections: `{"id": "a", "title": "A", "content": "Due 03/04/2025", "x-nld-ignore": ["NLD2001: dates follow the UK convention of the counterparty"]},
+				{"id": "b", "title": "B", "content": "Due 05/06/2025"}`,
+			expect: []string{"/content/sections/1/content NLD2001"},
+		},
+		{
+			name:     "By Name",
+			sections: `{"id": "a", "title": "A", "content": "Due 03/04/2025", "x-nld-ignore": ["ambiguous-date: quoted from the original order"]}`,
+		},
+		{
+			name:     "Other Rule",
+			sections: `{"id": "a", "title": "A", "content": "Due 03/04/2025", "x-nld-ignore": ["NLD2003: legal wording"]}`,
+			expect:   []string{"/content/sections/0/content NLD2001"},
+		},
+		{
+			name:     "No Reason",
+			sections: `{"id": "a", "title": "A", "content": "Due 03/04/2025", "x-nld-ignore": ["NLD2001"]}`,
+			expect:   []string{"/content/sections/0/content NLD2001", "/content/sections/0/x-nld-ignore/0 NLD1002"},
+		},
+		{
+			name:     "Unknown Rule",
+			sections: `{"id": "a", "title": "A", "content": "Due 2025-04-03", "x-nld-ignore": ["NLD9999: no such rule", "NLD1002: cannot be suppressed"]}`,
+			expect:   []string{"/content/sections/0/x-nld-ignore/0 NLD1002", "/content/sections/0/x-nld-ignore/1 NLD1002"},
+		},
+		{
+			name:     "Not A List",
+			sections: `{"id": "a", "title": "A", "content": "Due 2025-04-03", "x-nld-ignore": "NLD2001: reason"}`,
+			expect:   []string{"/content/sections/0/x-nld-ignore NLD1002"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := `{"metadata": {"type": "contract", "created": "2025-06-27T12:00:00Z"},
+  "content": {"sections": [` + tc.sections + `]}}`
+			findings, err := Lint([]byte(data), Options{})
+			if err != nil {
+				t.Fatalf("Lint failed: %v", err)
+			}
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.Path+" "+f.Rule)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected findings:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestSignaturePolicyRule(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policies string
+		expect   []string
+	}{
+		{
+			name:     "Valid",
+			policies: `[{"id": "board", "role": "Director", "required": 2}, {"id": "parties", "signers": ["client", "d1"], "required": 2}]`,
+		},
+		{
+			name:     "Unknown Signer",
+			policies: `[{"id": "board", "signers": ["d1", "d4"], "required": 1}]`,
+			expect:   []string{`/metadata/signaturePolicies/0: error NLD1006: signature policy board names d4, which is not an entity`},
+		},
+		{
+			name:     "Too Many Required",
+			policies: `[{"id": "board", "role": "Director", "required": 4}]`,
+			expect:   []string{`/metadata/signaturePolicies/0: error NLD1006: signature policy board requires 4 signature(s), expected between 1 and its 3 signer(s)`},
+		},
+		{
+			name:     "No Signers",
+			policies: `[{"id": "board", "role": "Officer", "required": 1}]`,
+			expect:   []string{`/metadata/signaturePolicies/0: error NLD1006: signature policy board has no signers`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := `{"metadata": {"type": "contract", "created": "2025-06-27T12:00:00Z", "signaturePolicies": ` + tc.policies + `,
+  "entities": [{"id": "d1", "name": "Ann", "role": "Director"}, {"id": "d2", "name": "Bob", "role": "Director"}, {"id": "d3", "name": "Cy", "role": "Director"}, {"id": "client", "name": "XYZ Ltd", "role": "Client"}]},
+  "content": {"sections": [{"id": "terms", "title": "Terms", "content": "Text"}]}}`
+			findings, err := Lint([]byte(data), Options{Only: []string{"invalid-signature-policy"}})
+			if err != nil {
+				t.Fatalf("Lint failed: %v", err)
+			}
+
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected findings:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestPIIRule(t *testing.T) {
+	testCases := []struct {
+		name    string
+		docType string
+		content string
+		opts    Options
+		expect  []string
+	}{
+		{
+			name:    "Template",
+			docType: "contract",
+			content: `Contact {{ client.name }} at legal@xyz.com or +44 20 7946 0958, not sample@example.com.`,
+			expect: []string{
+				`/content/sections/0/content: warning NLD2007: section "terms" contains an email address (leg***)`,
+				`/content/sections/0/content: warning NLD2007: section "terms" contains a phone number (+44***)`,
+			},
+		},
+		{
+			name:    "Template Type",
+			docType: "template",
+			content: `Pay into GB82 WEST 1234 5698 7654 32 by 2025-07-01.`,
+			expect:  []string{`/content/sections/0/content: warning NLD2007: section "terms" contains an IBAN (GB8***)`},
+		},
+		{
+			name:    "Selected Kinds",
+			docType: "template",
+			content: `Contact legal@xyz.com, SSN 123-45-6789.`,
+			opts:    Options{PIIKinds: []string{"national-id"}},
+			expect:  []string{`/content/sections/0/content: warning NLD2007: section "terms" contains a national ID number (123***)`},
+		},
+		{
+			name:    "Document",
+			docType: "contract",
+			content: `Contact legal@xyz.com.`,
+		},
+		{
+			name:    "Selected Types",
+			docType: "contract",
+			content: `Contact legal@xyz.com.`,
+			opts:    Options{PIITypes: []string{"Contract"}},
+			expect:  []string{`/content/sections/0/content: warning NLD2007: section "terms" contains an email address (leg***)`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := `{"metadata": {"type": "` + tc.docType + `", "created": "2025-06-27T12:00:00Z"},
+  "content": {"sections": [{"id": "terms", "title": "Terms", "content": "` + tc.content + `"}]}}`
+			tc.opts.Only = []string{"pii-in-template"}
+			findings, err := Lint([]byte(data), tc.opts)
+			if err != nil {
+				t.Fatalf("Lint failed: %v", err)
+			}
+
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected findings:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+
+	if _, err := Lint([]byte(`{"metadata": {"type": "template"}, "content": {"sections": []}}`), Options{PIIKinds: []string{"passport"}}); err == nil {
+		t.Error("Lint with an unknown PII kind succeeded")
+	}
+}
@@ -0,0 +1,23 @@
+package lint
+
+import "github.com/colemalphrus/nld/internal/obligation"
+
+func init() {
+	register(Rule{
+		ID:          "NLD1003",
+		Name:        "obligation-incomplete",
+		Description: "Obligations must say what must be done, by which entity and by when",
+		Severity:    Error,
+		Check:       checkObligations,
+	})
+}
+
+// checkObligations reports the obligations that cannot be tracked
+func checkObligations(d *Document) []Finding {
+	var findings []Finding
+	_, problems := obligation.Extract(d.Doc)
+	for _, p := range problems {
+		findings = append(findings, finding(p.Path, "%s", p.Message))
+	}
+	return findings
+}
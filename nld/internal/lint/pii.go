@@ -0,0 +1,104 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/fill"
+	"github.com/colemalphrus/nld/internal/pii"
+)
+
+// DefaultPIIKinds are the kinds of personal data the pii-in-template rule
+// looks for unless the lint options list others
+var DefaultPIIKinds = []string{pii.Email, pii.Phone, pii.NationalID, pii.IBAN}
+
+// DefaultPIITypes are the document types checked by the pii-in-template
+// rule, besides documents with placeholders, unless the lint options list
+// others
+var DefaultPIITypes = []string{"template"}
+
+// piiLabels name the kinds of personal data in findings
+var piiLabels = map[string]string{
+	pii.Email:      "an email address",
+	pii.Phone:      "a phone number",
+	pii.NationalID: "a national ID number",
+	pii.IBAN:       "an IBAN",
+	pii.Amount:     "an amount",
+}
+
+// exampleDomains are the domains reserved for examples by RFC 2606, whose
+// email addresses are fictitious
+var exampleDomains = []string{"example.com", "example.org", "example.net", ".example", ".test", ".invalid"}
+
+func init() {
+	register(Rule{
+		ID:          "NLD2007",
+		Name:        "pii-in-template",
+		Description: "Sections of templates should not contain personal data such as email addresses, phone numbers, national ID numbers or IBANs",
+		Severity:    Warning,
+		Check:       checkPII,
+	})
+}
+
+// checkPII reports personal data in the sections of documents that are
+// meant to be reused, such as templates, where it was most likely left
+// behind from a real document
+func checkPII(d *Document) []Finding {
+	if !d.reusable() {
+		return nil
+	}
+	kinds := d.Options.PIIKinds
+	if len(kinds) == 0 {
+		kinds = DefaultPIIKinds
+	}
+
+	var findings []Finding
+	for _, t := range d.Texts() {
+		if t.Section == "" {
+			continue
+		}
+		for _, m := range pii.FindKinds(t.Value, kinds) {
+			if m.Kind == pii.Email && fictitious(m.Text) {
+				continue
+			}
+			findings = append(findings, finding(t.Path, "section %q contains %s (%s)", t.Section, piiLabels[m.Kind], mask(m.Text)))
+		}
+	}
+	return findings
+}
+
+// reusable reports whether a document is a template: it has placeholders,
+// or one of the types of the lint options
+func (d *Document) reusable() bool {
+	types := d.Options.PIITypes
+	if types == nil {
+		types = DefaultPIITypes
+	}
+	for _, t := range types {
+		if strings.EqualFold(t, d.Doc.Metadata.Type) {
+			return true
+		}
+	}
+	return len(fill.Placeholders(d.Raw)) > 0
+}
+
+// fictitious reports whether an email address is at a domain reserved for
+// examples
+func fictitious(email string) bool {
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	for _, d := range exampleDomains {
+		if domain == d || strings.HasSuffix(domain, "."+strings.TrimPrefix(d, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// mask hides all but the first characters of personal data, so that
+// findings do not spread it further
+func mask(s string) string {
+	r := []rune(s)
+	if len(r) <= 6 {
+		return "***"
+	}
+	return string(r[:3]) + "***"
+}
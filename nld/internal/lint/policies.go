@@ -0,0 +1,27 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/colemalphrus/nld/internal/threshold"
+)
+
+func init() {
+	register(Rule{
+		ID:          "NLD1006",
+		Name:        "invalid-signature-policy",
+		Description: "Signature policies must name entities of the document and require a number of signatures they can give",
+		Severity:    Error,
+		Check:       checkSignaturePolicies,
+	})
+}
+
+// checkSignaturePolicies reports signature policies that can never be
+// satisfied
+func checkSignaturePolicies(d *Document) []Finding {
+	var findings []Finding
+	for _, p := range threshold.Check(d.Doc) {
+		findings = append(findings, finding(fmt.Sprintf("/metadata/signaturePolicies/%d", p.Index), "%s", p.Message))
+	}
+	return findings
+}
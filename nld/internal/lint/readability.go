@@ -0,0 +1,53 @@
+package lint
+
+import (
+	"github.com/colemalphrus/nld/internal/readability"
+)
+
+// Default thresholds of the complex-section rule
+const (
+	// DefaultMaxGradeLevel is the Flesch-Kincaid grade level above which
+	// a section is reported, about the level of a university graduate
+	DefaultMaxGradeLevel = 16
+	// DefaultMaxSentenceLength is the average sentence length, in words,
+	// above which a section is reported
+	DefaultMaxSentenceLength = 30
+)
+
+// minGradedWords is the number of words a section needs before its grade
+// level is meaningful
+const minGradedWords = 20
+
+func init() {
+	register(Rule{
+		ID:          "NLD2003",
+		Name:        "complex-section",
+		Description: "Section prose should not exceed the maximum Flesch-Kincaid grade level or average sentence length",
+		Severity:    Warning,
+		Check:       checkComplexSections,
+	})
+}
+
+// checkComplexSections reports sections whose prose is harder to read than
+// the thresholds in the lint options
+func checkComplexSections(d *Document) []Finding {
+	maxGrade := d.Options.MaxGradeLevel
+	if maxGrade <= 0 {
+		maxGrade = DefaultMaxGradeLevel
+	}
+	maxLength := d.Options.MaxSentenceLength
+	if maxLength <= 0 {
+		maxLength = DefaultMaxSentenceLength
+	}
+
+	var findings []Finding
+	for _, s := range readability.Sections(d.Doc) {
+		if s.Words >= minGradedWords && s.GradeLevel > maxGrade {
+			findings = append(findings, finding(s.Path, "section %q reads at grade level %.1f, above the maximum of %g", s.ID, s.GradeLevel, maxGrade))
+		}
+		if s.AverageSentenceLength > maxLength {
+			findings = append(findings, finding(s.Path, "sentences in section %q average %.1f words, above the maximum of %g", s.ID, s.AverageSentenceLength, maxLength))
+		}
+	}
+	return findings
+}
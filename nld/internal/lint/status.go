@@ -0,0 +1,77 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/workflow"
+)
+
+func init() {
+	register(Rule{
+		ID:          "NLD1004",
+		Name:        "invalid-status",
+		Description: "The status of the document must be a state of the lifecycle",
+		Severity:    Error,
+		Check:       checkStatus,
+	})
+	register(Rule{
+		ID:          "NLD1005",
+		Name:        "executed-unsigned",
+		Description: "Executed documents must be signed by every entity",
+		Severity:    Error,
+		Check:       checkExecutedSignatures,
+	})
+	register(Rule{
+		ID:          "NLD2006",
+		Name:        "signed-before-approval",
+		Description: "Documents should not be signed while in draft or review",
+		Severity:    Warning,
+		Check:       checkEarlySignatures,
+	})
+}
+
+// checkStatus reports a status outside the lifecycle
+func checkStatus(d *Document) []Finding {
+	status := workflow.Get(d.Raw)
+	if workflow.Valid(status) {
+		return nil
+	}
+	names := make([]string, 0, len(workflow.Statuses()))
+	for _, s := range workflow.Statuses() {
+		names = append(names, string(s))
+	}
+	return []Finding{finding("/metadata/status", "unknown status %q (expected one of: %s)", status, strings.Join(names, ", "))}
+}
+
+// checkExecutedSignatures reports the entities that have not signed an
+// executed document
+func checkExecutedSignatures(d *Document) []Finding {
+	if workflow.Get(d.Raw) != workflow.Executed {
+		return nil
+	}
+	var findings []Finding
+	ids := map[string]int{}
+	for i, e := range d.Doc.Metadata.Entities {
+		ids[e.ID] = i
+	}
+	for _, id := range workflow.Unsigned(d.Raw) {
+		findings = append(findings, finding(fmt.Sprintf("/metadata/entities/%d", ids[id]), "document is executed but entity %s has not signed", id))
+	}
+	return findings
+}
+
+// checkEarlySignatures reports signatures made before the document was
+// approved, which do not cover the changes still to come. Documents without
+// a status are not checked.
+func checkEarlySignatures(d *Document) []Finding {
+	status := workflow.Status(d.Doc.Metadata.Status)
+	if status != workflow.Draft && status != workflow.Review {
+		return nil
+	}
+	var findings []Finding
+	for i, sig := range d.Doc.Verification.Signatures {
+		findings = append(findings, finding(fmt.Sprintf("/verification/signatures/%d", i), "document is signed by %s while still in %s", sig.SignerID, status))
+	}
+	return findings
+}
@@ -0,0 +1,132 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SuppressKey is the property of a document object listing the rules that
+// are not reported for the object and everything in it, each with the
+// reason, such as ["NLD2003: statutory wording quoted verbatim"]
+const SuppressKey = "x-nld-ignore"
+
+// invalidSuppression is the ID of the rule reporting suppressions without
+// a known rule or a reason, which cannot itself be suppressed
+const invalidSuppression = "NLD1002"
+
+func init() {
+	register(Rule{
+		ID:          invalidSuppression,
+		Name:        "invalid-suppression",
+		Description: "Entries of " + SuppressKey + " must name a rule and give the reason it does not apply, as \"NLD2003: reason\"",
+		Severity:    Error,
+		Check: func(d *Document) []Finding {
+			_, invalid := suppressions(d.Raw)
+			return invalid
+		},
+	})
+}
+
+// suppression is a rule suppressed for the object at a JSON pointer
+type suppression struct {
+	path   string
+	rule   string
+	reason string
+}
+
+// covers reports whether the suppression applies to a finding
+func (s suppression) covers(f Finding) bool {
+	return f.Rule == s.rule && f.Rule != invalidSuppression &&
+		(s.path == "" || f.Path == s.path || strings.HasPrefix(f.Path, s.path+"/"))
+}
+
+// suppressions returns the suppressions of a document, and findings for
+// the entries that are not valid
+func suppressions(raw map[string]interface{}) ([]suppression, []Finding) {
+	var list []suppression
+	var invalid []Finding
+	var walk func(v interface{}, path string)
+	walk = func(v interface{}, path string) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if k != SuppressKey {
+					walk(v[k], path+"/"+escapePointer(k))
+					continue
+				}
+				at := path + "/" + SuppressKey
+				entries, ok := v[k].([]interface{})
+				if !ok {
+					invalid = append(invalid, finding(at, "%s must be a list of \"RULE: reason\" entries", SuppressKey))
+					continue
+				}
+				for i, entry := range entries {
+					s, err := parseSuppression(entry)
+					if err != nil {
+						invalid = append(invalid, finding(fmt.Sprintf("%s/%d", at, i), "%v", err))
+						continue
+					}
+					s.path = path
+					list = append(list, s)
+				}
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	}
+	walk(raw, "")
+	return list, invalid
+}
+
+// parseSuppression parses an entry of x-nld-ignore, "RULE: reason", where
+// the rule is given by ID or name
+func parseSuppression(entry interface{}) (suppression, error) {
+	text, ok := entry.(string)
+	if !ok {
+		return suppression{}, fmt.Errorf("suppression must be a string, as \"NLD2003: reason\"")
+	}
+	id, reason, _ := strings.Cut(text, ":")
+	id, reason = strings.TrimSpace(id), strings.TrimSpace(reason)
+	r, ok := Lookup(id)
+	if !ok {
+		return suppression{}, fmt.Errorf("unknown lint rule in suppression: %s", id)
+	}
+	if r.ID == invalidSuppression {
+		return suppression{}, fmt.Errorf("%s cannot be suppressed", r.ID)
+	}
+	if reason == "" {
+		return suppression{}, fmt.Errorf("suppression of %s needs a reason, as \"%s: reason\"", r.ID, r.ID)
+	}
+	return suppression{rule: r.ID, reason: reason}, nil
+}
+
+// suppress returns the findings that no suppression covers
+func suppress(findings []Finding, list []suppression) []Finding {
+	if len(list) == 0 {
+		return findings
+	}
+	var kept []Finding
+	for _, f := range findings {
+		covered := false
+		for _, s := range list {
+			covered = covered || s.covers(f)
+		}
+		if !covered {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// escapePointer escapes a key as a JSON pointer token
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,42 @@
+package lint
+
+import (
+	"github.com/colemalphrus/nld/internal/terms"
+)
+
+func init() {
+	register(Rule{
+		ID:          "NLD2004",
+		Name:        "undefined-term",
+		Description: "Capitalized terms used in section content should have a definition",
+		Severity:    Warning,
+		Check:       checkUndefinedTerms,
+	})
+	register(Rule{
+		ID:          "NLD2005",
+		Name:        "unused-definition",
+		Description: "Every definition should be used in the document",
+		Severity:    Warning,
+		Check:       checkUnusedDefinitions,
+	})
+}
+
+// checkUndefinedTerms reports capitalized phrases that are used like
+// defined terms but have no definition, at their first use
+func checkUndefinedTerms(d *Document) []Finding {
+	var findings []Finding
+	for _, u := range terms.Analyze(d.Doc).Undefined {
+		findings = append(findings, finding(u.Paths[0], "term %q is capitalized like a defined term but has no definition", u.Term))
+	}
+	return findings
+}
+
+// checkUnusedDefinitions reports definitions whose term does not appear in
+// the document
+func checkUnusedDefinitions(d *Document) []Finding {
+	var findings []Finding
+	for _, t := range terms.Analyze(d.Doc).Unused() {
+		findings = append(findings, finding(t.Path, "definition of %q is never used", t.Term))
+	}
+	return findings
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Formats of log output
+const (
+	Text = "text"
+	JSON = "json"
+)
+
+// Levels returns the names of the log levels, from most to least verbose
+func Levels() []string {
+	return []string{"debug", "info", "warn", "error"}
+}
+
+// Formats returns the supported log formats
+func Formats() []string {
+	return []string{Text, JSON}
+}
+
+// Options configures a logger
+type Options struct {
+	// Level is the lowest level logged, one of Levels
+	Level string
+	// Format is Text or JSON; empty selects Text
+	Format string
+	// File is the file log records are appended to instead of the writer
+	// given to New
+	File string
+}
+
+// ParseLevel returns the slog level with the given name. "warning" is
+// accepted for warn.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if strings.EqualFold(name, "warning") {
+		return slog.LevelWarn, nil
+	}
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("unknown log level %q (expected %s)", name, strings.Join(Levels(), ", "))
+	}
+	return level, nil
+}
+
+// New returns a logger writing records to w, or to opts.File when set, and
+// a function that closes the log file
+func New(w io.Writer, opts Options) (*slog.Logger, func() error, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	closeFile := func() error { return nil }
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w, closeFile = f, f.Close
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch opts.Format {
+	case "", Text:
+		handler = slog.NewTextHandler(w, handlerOpts)
+	case JSON:
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		closeFile()
+		return nil, nil, fmt.Errorf("unknown log format %q (expected %s)", opts.Format, strings.Join(Formats(), ", "))
+	}
+	return slog.New(handler), closeFile, nil
+}
+
+// Discard returns a logger that drops every record
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	testCases := []struct {
+		name  string
+		level slog.Level
+		err   bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"loud", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			level, err := ParseLevel(tc.name)
+			if tc.err {
+				if err == nil {
+					t.Errorf("Expected error for %q", tc.name)
+				}
+				return
+			}
+			if err != nil || level != tc.level {
+				t.Errorf("Expected %v, got %v (%v)", tc.level, level, err)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, closeLog, err := New(&buf, Options{Level: "info", Format: JSON})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer closeLog()
+	logger.Debug("hidden")
+	logger.Info("validating document", "file", "contract.json")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["msg"] != "validating document" || record["file"] != "contract.json" || record["level"] != "INFO" {
+		t.Errorf("Unexpected record %v", record)
+	}
+
+	if _, _, err := New(&buf, Options{Level: "info", Format: "xml"}); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nld.log")
+	for i := 0; i < 2; i++ {
+		logger, closeLog, err := New(os.Stderr, Options{Level: "warn", File: path})
+		if err != nil {
+			t.Fatalf("Failed to create logger: %v", err)
+		}
+		logger.Warn("schema not found")
+		if err := closeLog(); err != nil {
+			t.Fatalf("Failed to close log: %v", err)
+		}
+	}
+	data, _ := os.ReadFile(path)
+	if n := strings.Count(string(data), "msg=\"schema not found\""); n != 2 {
+		t.Errorf("Expected the log file to be appended to, got:\n%s", data)
+	}
+}
@@ -0,0 +1,213 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the content type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds of the buckets of duration
+// histograms, in seconds
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds metrics and writes them in the Prometheus text format
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+	statsd   *Statsd
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// family is a metric and its series, one for every set of label values
+type family struct {
+	registry *Registry
+	name     string
+	help     string
+	kind     string
+	labels   []string
+	buckets  []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series holds the value of a counter, or the buckets, sum and count of a
+// histogram
+type series struct {
+	values []string
+	value  float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.families {
+		if existing.name == f.name {
+			panic("metrics: " + f.name + " registered twice")
+		}
+	}
+	f.registry, f.series = r, map[string]*series{}
+	r.families = append(r.families, f)
+	return f
+}
+
+// Counter is a count that only goes up, such as of documents validated
+type Counter struct{ f *family }
+
+// Counter registers a counter with the given label names
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(&family{name: name, help: help, kind: "counter", labels: labels})}
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v to the series with the given label values
+func (c *Counter) Add(v float64, values ...string) {
+	c.f.mu.Lock()
+	c.f.get(values).value += v
+	c.f.mu.Unlock()
+	c.f.registry.forward(c.f, v, values)
+}
+
+// Histogram counts observations, such as durations, in buckets
+type Histogram struct{ f *family }
+
+// Histogram registers a histogram with the given bucket upper bounds and
+// label names
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{r.register(&family{name: name, help: help, kind: "histogram", labels: labels, buckets: buckets})}
+}
+
+// Observe records v in the series with the given label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.f.mu.Lock()
+	s := h.f.get(values)
+	for i, bound := range h.f.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+	h.f.mu.Unlock()
+	h.f.registry.forward(h.f, v, values)
+}
+
+// Since records the seconds since start
+func (h *Histogram) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+// get returns the series of the label values, creating it when needed
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if f.kind == "histogram" {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// WriteText writes every metric in the Prometheus text format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (f *family) write(b *strings.Builder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", f.name, escape(f.help, false), f.name, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for k := range f.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := f.series[k]
+		if f.kind == "counter" {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, labels(f.labels, s.values, "", ""), number(s.value))
+			continue
+		}
+		for i, bound := range f.buckets {
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, labels(f.labels, s.values, "le", number(bound)), s.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, labels(f.labels, s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, labels(f.labels, s.values, "", ""), number(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, labels(f.labels, s.values, "", ""), s.count)
+	}
+}
+
+// labels formats label pairs, with an extra pair when name is not empty
+func labels(names, values []string, name, value string) string {
+	var pairs []string
+	for i, n := range names {
+		pairs = append(pairs, n+`="`+escape(values[i], true)+`"`)
+	}
+	if name != "" {
+		pairs = append(pairs, name+`="`+value+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escape(s string, quotes bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quotes {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
+
+func number(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Handler returns a handler serving the metrics of r
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteText(w)
+	})
+}
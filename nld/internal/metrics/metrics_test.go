@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("test_validations_total", "Documents validated.", "type", "result")
+	h := r.Histogram("test_duration_seconds", "Time spent.", []float64{0.1, 1})
+	c.Inc("contract", Valid)
+	c.Add(2, "contract", Valid)
+	c.Inc(`odd "type"`, Invalid)
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(5)
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_duration_seconds Time spent.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{le="0.1"} 1
+test_duration_seconds_bucket{le="1"} 2
+test_duration_seconds_bucket{le="+Inf"} 3
+test_duration_seconds_sum 5.55
+test_duration_seconds_count 3
+# HELP test_validations_total Documents validated.
+# TYPE test_validations_total counter
+test_validations_total{type="contract",result="valid"} 3
+test_validations_total{type="odd \"type\"",result="invalid"} 1
+`
+	if b.String() != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestStatsd(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r := NewRegistry()
+	c := r.Counter("nld_validations_total", "Documents validated.", "type", "result")
+	h := r.Histogram("nld_render_duration_seconds", "Time spent rendering.", DefaultBuckets, "format")
+	c.Inc("before", Valid)
+	s, err := DialStatsd(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Forward(s)
+	c.Inc("contract", Invalid)
+	h.Observe(0.25, "html")
+	s.Close()
+
+	buf := make([]byte, maxPacket)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "nld.validations:1|c|#type:contract,result:invalid\nnld.render_duration:250|ms|#format:html"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("packet = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+package metrics
+
+// Default holds the metrics of nld, served by nld serve at /metrics
+var Default = NewRegistry()
+
+// Results of validations, cache lookups and renders
+const (
+	Valid   = "valid"
+	Invalid = "invalid"
+	Error   = "error"
+	Hit     = "hit"
+	Miss    = "miss"
+	OK      = "ok"
+)
+
+// The metrics of nld
+var (
+	// Validations counts validated documents by document type and result:
+	// Valid, Invalid, or Error when the document could not be validated
+	Validations = Default.Counter("nld_validations_total", "Documents validated, by document type and result.", "type", "result")
+	// ValidationDuration is the time spent validating a document
+	ValidationDuration = Default.Histogram("nld_validation_duration_seconds", "Time spent validating a document, by document type.", DefaultBuckets, "type")
+	// Renders counts rendered documents by format and result, OK or Error
+	Renders = Default.Counter("nld_renders_total", "Documents rendered, by format and result.", "format", "result")
+	// RenderDuration is the time spent rendering a document
+	RenderDuration = Default.Histogram("nld_render_duration_seconds", "Time spent rendering a document, by format.", DefaultBuckets, "format")
+	// CacheRequests counts the lookups of the schema and clause caches, by
+	// cache and result, Hit or Miss
+	CacheRequests = Default.Counter("nld_cache_requests_total", "Cache lookups, by cache and result.", "cache", "result")
+	// Errors counts failed operations by class, such as the exit code
+	// name of a failed command or the status name of a failed request
+	Errors = Default.Counter("nld_errors_total", "Failed operations, by error class.", "class")
+	// Requests counts API requests by route and status code
+	Requests = Default.Counter("nld_http_requests_total", "API requests, by route and status code.", "route", "code")
+	// RequestDuration is the time spent serving an API request
+	RequestDuration = Default.Histogram("nld_http_request_duration_seconds", "Time spent serving an API request, by route.", DefaultBuckets, "route")
+)
+
+// Cache records a lookup of cache
+func Cache(cache string, hit bool) {
+	if hit {
+		CacheRequests.Inc(cache, Hit)
+	} else {
+		CacheRequests.Inc(cache, Miss)
+	}
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"sync"
+)
+
+// StatsdEnv holds the address of the statsd server the CLI sends metrics
+// to, as --statsd does
+const StatsdEnv = "NLD_STATSD"
+
+// maxPacket keeps statsd packets within the MTU of most networks
+const maxPacket = 1432
+
+// Statsd sends metrics to a statsd server over UDP, for batch jobs that
+// end before Prometheus could scrape them. Labels are sent as DogStatsD
+// tags, which Datadog, Telegraf and the Prometheus statsd exporter read.
+type Statsd struct {
+	conn net.Conn
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// DialStatsd returns a client for the statsd server at addr (host:port)
+func DialStatsd(addr string) (*Statsd, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd at %s: %w", addr, err)
+	}
+	return &Statsd{conn: conn}, nil
+}
+
+// Forward sends every later change of the metrics of r to s
+func (r *Registry) Forward(s *Statsd) {
+	r.mu.Lock()
+	r.statsd = s
+	r.mu.Unlock()
+}
+
+// forward sends a change of a metric to the statsd client of r, if any
+func (r *Registry) forward(f *family, v float64, values []string) {
+	r.mu.Lock()
+	s := r.statsd
+	r.mu.Unlock()
+	if s != nil {
+		s.send(f, v, values)
+	}
+}
+
+// send buffers the line of a metric change, sending the buffer when full.
+// Counters are sent as counts and histograms of seconds as timers in
+// milliseconds, named without the _total and _seconds suffixes and with
+// dots, so nld_validation_duration_seconds is nld.validation_duration.
+func (s *Statsd) send(f *family, v float64, values []string) {
+	name := strings.Replace(f.name, "_", ".", 1)
+	kind := "c"
+	switch {
+	case strings.HasSuffix(name, "_total"):
+		name = strings.TrimSuffix(name, "_total")
+	case strings.HasSuffix(name, "_seconds"):
+		name, kind, v = strings.TrimSuffix(name, "_seconds"), "ms", math.Round(v*1e6)/1e3
+	case f.kind == "histogram":
+		kind = "h"
+	}
+	line := name + ":" + number(v) + "|" + kind
+	if len(f.labels) > 0 {
+		tags := make([]string, len(f.labels))
+		for i, l := range f.labels {
+			tags[i] = l + ":" + strings.NewReplacer(",", "_", "|", "_", "\n", " ").Replace(values[i])
+		}
+		line += "|#" + strings.Join(tags, ",")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buf.Len() > 0 && s.buf.Len()+1+len(line) > maxPacket {
+		s.flush()
+	}
+	if s.buf.Len() > 0 {
+		s.buf.WriteByte('\n')
+	}
+	s.buf.WriteString(line)
+}
+
+// flush sends the buffered lines; UDP errors are ignored, as statsd
+// metrics are best effort
+func (s *Statsd) flush() {
+	if s.buf.Len() > 0 {
+		s.conn.Write(s.buf.Bytes())
+		s.buf.Reset()
+	}
+}
+
+// Close sends the buffered lines and closes the connection
+func (s *Statsd) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flush()
+	return s.conn.Close()
+}
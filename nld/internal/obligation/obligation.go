@@ -0,0 +1,260 @@
+// Package obligation extracts what the parties of a document must do and
+// by when: items and conditions of type "obligation", such as
+//
+//	{"id": "deliver", "type": "obligation", "value": {
+//	  "owner": "party1", "action": "Deliver the software",
+//	  "due": "2025-09-01", "section": "delivery"}}
+//
+// in the items of the document body, and
+//
+//	{"id": "return", "type": "obligation", "owner": "party2",
+//	 "predicate": "the agreement terminates",
+//	 "effect": "Return all Confidential Information", "due": "P30D"}
+//
+// in relationships.conditions. Due is a date, a datetime or an ISO 8601
+// duration from metadata.effective.
+package obligation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Type is the type of items and conditions that are obligations
+const Type = "obligation"
+
+// Obligation is something an entity of a document must do
+type Obligation struct {
+	ID string `json:"id"`
+	// Path is the JSON pointer of the item or condition
+	Path string `json:"path"`
+	// Owner is the ID of the entity bound by the obligation and OwnerName
+	// its name
+	Owner     string `json:"owner,omitempty"`
+	OwnerName string `json:"ownerName,omitempty"`
+	Action    string `json:"action"`
+	// Due is the deadline as written, and Deadline when it falls; Deadline
+	// is nil when Due is missing or invalid, or runs from when the
+	// condition of the obligation holds
+	Due      string     `json:"due,omitempty"`
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// AllDay reports that the deadline is a date without a time
+	AllDay bool `json:"allDay,omitempty"`
+	// Section is the ID of the section stating the obligation
+	Section string `json:"section,omitempty"`
+	// Condition is the predicate of conditional obligations
+	Condition string `json:"condition,omitempty"`
+}
+
+// Problem is an obligation that cannot be tracked
+type Problem struct {
+	ID      string `json:"id"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Extract returns the obligations of a document, items first, and the
+// problems of those without an action, an owner entity or a valid due
+// date
+func Extract(doc *nld.Document) ([]Obligation, []Problem) {
+	names := map[string]string{}
+	for _, e := range doc.Metadata.Entities {
+		names[e.ID] = e.Name
+	}
+	loc, err := doc.Metadata.Location()
+	if err != nil {
+		loc = time.UTC
+	}
+	start, _, _ := doc.Metadata.Period()
+
+	var obligations []Obligation
+	var problems []Problem
+	add := func(o Obligation) {
+		report := func(format string, args ...interface{}) {
+			problems = append(problems, Problem{ID: o.ID, Path: o.Path, Message: fmt.Sprintf("obligation %s %s", o.ID, fmt.Sprintf(format, args...))})
+		}
+		if o.Action == "" {
+			report("does not say what must be done")
+		}
+		switch name, ok := names[o.Owner]; {
+		case o.Owner == "":
+			report("has no owner")
+		case !ok:
+			report("is owned by %s, which is not an entity", o.Owner)
+		default:
+			o.OwnerName = name
+		}
+		if o.Due == "" {
+			report("has no due date")
+		} else if err := o.resolve(start, loc); err != nil {
+			report("%v", err)
+		}
+		obligations = append(obligations, o)
+	}
+
+	body := "/" + doc.BodyKey()
+	for i, item := range doc.Structure.Items {
+		if item.Type != Type {
+			continue
+		}
+		o := Obligation{ID: item.ID, Path: body + "/items/" + strconv.Itoa(i)}
+		switch v := item.Value.(type) {
+		case string:
+			o.Action = v
+		case map[string]interface{}:
+			o.Owner, _ = v["owner"].(string)
+			o.Action, _ = v["action"].(string)
+			o.Due, _ = v["due"].(string)
+			o.Section, _ = v["section"].(string)
+		}
+		add(o)
+	}
+	for i, c := range doc.Relationships.Conditions {
+		if c.Type != Type {
+			continue
+		}
+		add(Obligation{
+			ID:        c.ID,
+			Path:      "/relationships/conditions/" + strconv.Itoa(i),
+			Owner:     c.Owner,
+			Action:    c.Effect,
+			Due:       c.Due,
+			Condition: c.Predicate,
+		})
+	}
+	return obligations, problems
+}
+
+// resolve sets the deadline of the obligation from Due, a date or datetime
+// in loc, or a duration from start
+func (o *Obligation) resolve(start time.Time, loc *time.Location) error {
+	if strings.HasPrefix(o.Due, "P") {
+		d, err := nld.ParseDuration(o.Due)
+		if err != nil {
+			return fmt.Errorf("has an invalid due date: %v", err)
+		}
+		if o.Condition != "" {
+			// The duration runs from when the condition holds, which
+			// is not known in advance
+			return nil
+		}
+		if start.IsZero() {
+			return fmt.Errorf("is due %s after metadata.effective, which is not set", o.Due)
+		}
+		deadline := d.AddTo(start)
+		o.Deadline, o.AllDay = &deadline, d.Hours == 0 && d.Minutes == 0 && d.Seconds == 0
+		return nil
+	}
+	t, hasTime, err := nld.ParseDate(o.Due, loc)
+	if err != nil {
+		return fmt.Errorf("has an invalid due date: %v", err)
+	}
+	o.Deadline, o.AllDay = &t, !hasTime
+	return nil
+}
+
+// Formats of Write
+const (
+	ICS = "ics"
+	CSV = "csv"
+)
+
+// Formats returns the formats of Write
+func Formats() []string {
+	return []string{ICS, CSV}
+}
+
+// Write writes obligations in a format. calendar names the iCalendar
+// events, which need an ID unique to the document, such as its root hash.
+func Write(w io.Writer, format, calendar string, obligations []Obligation) error {
+	switch format {
+	case ICS:
+		return writeICS(w, calendar, obligations, time.Now())
+	case CSV:
+		return writeCSV(w, obligations)
+	}
+	return fmt.Errorf("unknown format %q (expected %s)", format, strings.Join(Formats(), " or "))
+}
+
+// writeCSV writes one row per obligation after a header
+func writeCSV(w io.Writer, obligations []Obligation) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"id", "owner", "owner_name", "action", "due", "deadline", "section", "condition"})
+	for _, o := range obligations {
+		deadline := ""
+		if o.Deadline != nil {
+			deadline = o.Deadline.Format(time.RFC3339)
+			if o.AllDay {
+				deadline = o.Deadline.Format(nld.DateLayout)
+			}
+		}
+		out.Write([]string{o.ID, o.Owner, o.OwnerName, o.Action, o.Due, deadline, o.Section, o.Condition})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// writeICS writes an iCalendar file with an event on the deadline of each
+// obligation. Obligations without a deadline are left out.
+func writeICS(w io.Writer, calendar string, obligations []Obligation, now time.Time) error {
+	var b strings.Builder
+	line := func(s string) {
+		// Lines are folded at 75 octets
+		for len(s) > 75 {
+			cut := 75
+			for cut > 0 && s[cut]&0xC0 == 0x80 {
+				cut--
+			}
+			b.WriteString(s[:cut] + "\r\n")
+			s = " " + s[cut:]
+		}
+		b.WriteString(s + "\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//nld//obligations//EN")
+	for _, o := range obligations {
+		if o.Deadline == nil {
+			continue
+		}
+		line("BEGIN:VEVENT")
+		line("UID:" + o.ID + "." + calendar + "@nld")
+		line("DTSTAMP:" + now.UTC().Format("20060102T150405Z"))
+		if o.AllDay {
+			line("DTSTART;VALUE=DATE:" + o.Deadline.Format("20060102"))
+		} else {
+			line("DTSTART:" + o.Deadline.UTC().Format("20060102T150405Z"))
+		}
+		summary := o.Action
+		if o.OwnerName != "" {
+			summary = o.OwnerName + ": " + o.Action
+		}
+		line("SUMMARY:" + escape(summary))
+		var description []string
+		if o.Section != "" {
+			description = append(description, "Section: "+o.Section)
+		}
+		if o.Condition != "" {
+			description = append(description, "When: "+o.Condition)
+		}
+		if len(description) > 0 {
+			line("DESCRIPTION:" + escape(strings.Join(description, "\n")))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escape escapes text values of iCalendar
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,104 @@
+package obligation
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const contract = `{
+	"metadata": {"type": "contract", "created": "2025-06-27T12:00:00Z", "effective": "2025-07-01",
+		"entities": [{"id": "party1", "name": "Acme, Inc.", "role": "Provider"}, {"id": "party2", "name": "XYZ Ltd", "role": "Client"}]},
+	"content": {
+		"sections": [{"id": "delivery", "title": "Delivery", "content": "Text"}],
+		"items": [
+			{"id": "deliver", "type": "obligation", "value": {"owner": "party1", "action": "Deliver the software", "due": "P60D", "section": "delivery"}},
+			{"id": "fee", "type": "amount", "value": 100},
+			{"id": "pay", "type": "obligation", "value": {"owner": "party3", "action": "Pay the fee", "due": "2025-09-01T17:00:00Z"}},
+			{"id": "train", "type": "obligation", "value": "Train the staff"}
+		]
+	},
+	"relationships": {"conditions": [
+		{"id": "return", "type": "obligation", "owner": "party2", "predicate": "the agreement terminates", "effect": "Return all materials", "due": "P30D"},
+		{"id": "renew", "predicate": "neither party gives notice", "effect": "The term renews"}
+	]}
+}`
+
+func TestExtract(t *testing.T) {
+	doc, err := nld.Parse([]byte(contract))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	obligations, problems := Extract(doc)
+	var ids []string
+	for _, o := range obligations {
+		ids = append(ids, o.ID)
+	}
+	if strings.Join(ids, ",") != "deliver,pay,train,return" {
+		t.Fatalf("Expected the obligation items and conditions, got %v", ids)
+	}
+	if o := obligations[0]; o.OwnerName != "Acme, Inc." || o.Deadline == nil || !o.AllDay || o.Deadline.Format(nld.DateLayout) != "2025-08-30" {
+		t.Errorf("Expected delivery 60 days after the effective date, got %+v", o)
+	}
+	if o := obligations[1]; o.Deadline == nil || o.AllDay || !o.Deadline.Equal(time.Date(2025, 9, 1, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected payment at a datetime, got %+v", o)
+	}
+	if o := obligations[3]; o.Deadline != nil || o.Condition != "the agreement terminates" || o.OwnerName != "XYZ Ltd" {
+		t.Errorf("Expected a conditional obligation without deadline, got %+v", o)
+	}
+
+	var messages []string
+	for _, p := range problems {
+		messages = append(messages, p.Path+": "+p.Message)
+	}
+	expected := []string{
+		"/content/items/2: obligation pay is owned by party3, which is not an entity",
+		"/content/items/3: obligation train has no owner",
+		"/content/items/3: obligation train has no due date",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected problems\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(messages, "\n"))
+	}
+}
+
+func TestWrite(t *testing.T) {
+	doc, _ := nld.Parse([]byte(contract))
+	obligations, _ := Extract(doc)
+
+	var ics bytes.Buffer
+	if err := writeICS(&ics, "abc", obligations, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:deliver.abc@nld\r\n",
+		"DTSTART;VALUE=DATE:20250830\r\n",
+		"SUMMARY:Acme\\, Inc.: Deliver the software\r\n",
+		"DTSTART:20250901T170000Z\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics.String(), line) {
+			t.Errorf("Expected %q in\n%s", line, ics.String())
+		}
+	}
+	if n := strings.Count(ics.String(), "BEGIN:VEVENT"); n != 2 {
+		t.Errorf("Expected events for the 2 obligations with a deadline, got %d", n)
+	}
+
+	var csv bytes.Buffer
+	if err := Write(&csv, CSV, "abc", obligations); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	if len(lines) != 5 || lines[1] != `deliver,party1,"Acme, Inc.",Deliver the software,P60D,2025-08-30,delivery,` {
+		t.Errorf("Unexpected CSV:\n%s", csv.String())
+	}
+
+	if err := Write(&csv, "pdf", "abc", obligations); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,131 @@
+// Package pii finds personal and confidential data in document text, such
+// as email addresses, phone numbers, national ID numbers, bank account
+// numbers and amounts of money.
+package pii
+
+import (
+	"math/big"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of data found
+const (
+	Email = "email"
+	IBAN  = "iban"
+	// NationalID is a US social security or UK national insurance number
+	NationalID = "national-id"
+	Amount     = "amount"
+	Phone      = "phone"
+)
+
+// Kinds returns the kinds of data Find looks for
+func Kinds() []string {
+	return []string{Email, IBAN, NationalID, Amount, Phone}
+}
+
+// ValidKind reports whether kind is one of Kinds
+func ValidKind(kind string) bool {
+	for _, k := range Kinds() {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// currencies are the ISO 4217 codes recognized next to amounts
+const currencies = `USD|EUR|GBP|JPY|CHF|CAD|AUD|NZD|SEK|NOK|DKK|PLN|CZK|HUF|CNY|INR|BRL|MXN|ZAR|SGD|HKD`
+
+// number is an amount with optional thousands separators and decimals
+const number = `\d{1,3}(?:[,.' ]\d{3})*(?:[.,]\d{1,3})?|\d+(?:[.,]\d{1,3})?`
+
+// patterns find the kinds of data in the order of Kinds. valid, when set,
+// rules out false positives.
+var patterns = []struct {
+	kind  string
+	re    *regexp.Regexp
+	valid func(string) bool
+}{
+	{Email, regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), nil},
+	{IBAN, regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`), validIBAN},
+	{NationalID, regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b|\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?\d{2} ?\d{2} ?\d{2} ?[A-D]\b`), validNationalID},
+	{Amount, regexp.MustCompile(`(?:[$€£¥]\s?|\b(?:` + currencies + `)\s?)(?:` + number + `)|(?:` + number + `)\s?(?:€|\b(?:` + currencies + `)\b)`), nil},
+	// Numbers of at least three groups, so that dates such as 2025-06-27
+	// are not taken for phone numbers
+	{Phone, regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[\s.-]\d{3,4}[\s.-]\d{3,4}\b`), nil},
+}
+
+// validIBAN checks the ISO 13616 check digits of an IBAN
+func validIBAN(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	var digits strings.Builder
+	for _, c := range s[4:] + s[:4] {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(c-'A') + 10))
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && n.Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// validNationalID rules out social security numbers that are never issued
+func validNationalID(s string) bool {
+	if len(s) != 11 || s[3] != '-' {
+		// A national insurance number
+		return true
+	}
+	area, group, serial := s[:3], s[4:6], s[7:]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
+
+// Match is data found in a text, at byte offsets Start to End
+type Match struct {
+	Kind  string `json:"kind"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// Find returns the data found in text, in order. Where matches overlap, the
+// one of the kind listed first in Kinds is kept.
+func Find(text string) []Match {
+	return FindKinds(text, nil)
+}
+
+// FindKinds is Find for the given kinds of data only, or all of them when
+// kinds is empty
+func FindKinds(text string, kinds []string) []Match {
+	var matches []Match
+	taken := func(start, end int) bool {
+		for _, m := range matches {
+			if start < m.End && m.Start < end {
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range patterns {
+		if len(kinds) > 0 && !slices.Contains(kinds, p.kind) {
+			continue
+		}
+		for _, loc := range p.re.FindAllStringIndex(text, -1) {
+			if p.valid != nil && !p.valid(text[loc[0]:loc[1]]) {
+				continue
+			}
+			if !taken(loc[0], loc[1]) {
+				matches = append(matches, Match{Kind: p.kind, Start: loc[0], End: loc[1], Text: text[loc[0]:loc[1]]})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	return matches
+}
@@ -0,0 +1,33 @@
+package pii
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	testCases := []struct {
+		text   string
+		expect []string
+	}{
+		{"Contact legal@xyz.example or +44 20 7946 0958.", []string{"email legal@xyz.example", "phone +44 20 7946 0958"}},
+		{"Call (555) 123-4567 before 2025-06-27.", []string{"phone (555) 123-4567"}},
+		{"Total amount: $1,299.99, then EUR 950 a month and 1.250,00 € in fees.", []string{"amount $1,299.99", "amount EUR 950", "amount 1.250,00 €"}},
+		{"Model XPS-15, Serial: ABC123456, signed on 12 March 2025.", nil},
+		{"Pay into GB82 WEST 1234 5698 7654 32, not GB00 WEST ABCD EFGH IJ.", []string{"iban GB82 WEST 1234 5698 7654 32"}},
+		{"SSN 123-45-6789 (not 666-12-3456), NI number AB 12 34 56 C.", []string{"national-id 123-45-6789", "national-id AB 12 34 56 C"}},
+	}
+	for _, tc := range testCases {
+		var got []string
+		for _, m := range Find(tc.text) {
+			if tc.text[m.Start:m.End] != m.Text {
+				t.Errorf("match %+v does not cover its text", m)
+			}
+			got = append(got, fmt.Sprintf("%s %s", m.Kind, m.Text))
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+			t.Errorf("Find(%q) = %q, expected %q", tc.text, got, tc.expect)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Package playbook checks documents against a negotiation playbook, which
+// gives for each clause the approved language, the fallbacks that may be
+// accepted without review, and who to escalate other language to
+package playbook
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/fill"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"gopkg.in/yaml.v3"
+)
+
+// Statuses of a clause
+const (
+	// Approved clauses use approved language
+	Approved = "approved"
+	// Fallback clauses use an acceptable fallback
+	Fallback = "fallback"
+	// Deviation clauses use language the playbook does not accept
+	Deviation = "deviation"
+	// Missing clauses are required by the playbook but not in the document
+	Missing = "missing"
+	// Unlisted sections have no clause in the playbook
+	Unlisted = "unlisted"
+)
+
+// Settings of Playbook.Unlisted
+const (
+	Ignore   = "ignore"
+	Escalate = "escalate"
+)
+
+// Playbook is a negotiation playbook read from a YAML or JSON file:
+//
+//	name: Sales
+//	escalation:
+//	  to: legal@example.com
+//	clauses:
+//	  - id: payment
+//	    required: true
+//	    approved:
+//	      - Invoices are payable within {{ days }} days.
+//	    fallbacks:
+//	      - Invoices are payable within 60 days.
+//	    escalation:
+//	      to: finance@example.com
+//	      note: Longer terms need the approval of the CFO.
+type Playbook struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Escalation applies to the clauses without their own
+	Escalation Escalation `yaml:"escalation"`
+	// Unlisted is whether sections without a clause in the playbook are
+	// ignored, the default, or escalated
+	Unlisted string   `yaml:"unlisted"`
+	Clauses  []Clause `yaml:"clauses"`
+
+	// Path is the file the playbook was read from
+	Path string `yaml:"-"`
+}
+
+// Clause is the position of the playbook on the section with the same ID
+type Clause struct {
+	ID    string `yaml:"id"`
+	Title string `yaml:"title"`
+	// Required clauses are escalated when the document lacks them
+	Required bool `yaml:"required"`
+	// Approved and Fallbacks are the accepted texts of the clause.
+	// Placeholders such as {{ days }} match any text, and differences in
+	// white space are ignored.
+	Approved  []string `yaml:"approved"`
+	Fallbacks []string `yaml:"fallbacks"`
+	// Escalation is who reviews other language
+	Escalation Escalation `yaml:"escalation"`
+}
+
+// Escalation tells who reviews a clause and why
+type Escalation struct {
+	To   string `yaml:"to" json:"to,omitempty"`
+	Note string `yaml:"note" json:"note,omitempty"`
+}
+
+// Result is the outcome of checking one clause
+type Result struct {
+	ID     string `json:"id"`
+	Title  string `json:"title,omitempty"`
+	Status string `json:"status"`
+	// Fallback is the number of the fallback used, from 1
+	Fallback int `json:"fallback,omitempty"`
+	// Escalate reports that the clause needs review, by Escalation
+	Escalate   bool        `json:"escalate"`
+	Escalation *Escalation `json:"escalation,omitempty"`
+}
+
+// Parse reads a playbook from YAML or JSON
+func Parse(data []byte) (*Playbook, error) {
+	var p Playbook
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid playbook: %w", err)
+	}
+	switch p.Unlisted {
+	case "":
+		p.Unlisted = Ignore
+	case Ignore, Escalate:
+	default:
+		return nil, fmt.Errorf("invalid playbook: unlisted must be %s or %s, not %q", Ignore, Escalate, p.Unlisted)
+	}
+	seen := map[string]bool{}
+	for _, c := range p.Clauses {
+		switch {
+		case c.ID == "":
+			return nil, fmt.Errorf("invalid playbook: clause without id")
+		case seen[c.ID]:
+			return nil, fmt.Errorf("invalid playbook: duplicate clause %s", c.ID)
+		case len(c.Approved) == 0:
+			return nil, fmt.Errorf("invalid playbook: clause %s has no approved language", c.ID)
+		}
+		seen[c.ID] = true
+	}
+	return &p, nil
+}
+
+// Load reads a playbook from a file
+func Load(path string) (*Playbook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playbook: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.Path = path
+	return p, nil
+}
+
+// Check classifies every section of doc, including subsections, in
+// document order, followed by the required clauses the document lacks
+func (p *Playbook) Check(doc *nld.Document) []Result {
+	clauses := map[string]*Clause{}
+	for i := range p.Clauses {
+		clauses[p.Clauses[i].ID] = &p.Clauses[i]
+	}
+
+	var results []Result
+	found := map[string]bool{}
+	var walk func(list []nld.Section)
+	walk = func(list []nld.Section) {
+		for _, s := range list {
+			found[s.ID] = true
+			if c, ok := clauses[s.ID]; ok {
+				results = append(results, p.classify(c, s))
+			} else {
+				r := Result{ID: s.ID, Title: s.Title, Status: Unlisted}
+				if p.Unlisted == Escalate {
+					r.Escalate, r.Escalation = true, p.escalation(nil)
+				}
+				results = append(results, r)
+			}
+			walk(s.Sections)
+		}
+	}
+	walk(doc.Structure.Sections)
+
+	for i := range p.Clauses {
+		if c := &p.Clauses[i]; c.Required && !found[c.ID] {
+			results = append(results, Result{ID: c.ID, Title: c.Title, Status: Missing, Escalate: true, Escalation: p.escalation(c)})
+		}
+	}
+	return results
+}
+
+// classify compares the text of a section with the language of its clause
+func (p *Playbook) classify(c *Clause, s nld.Section) Result {
+	r := Result{ID: s.ID, Title: s.Title}
+	text := Text(s)
+	if matchAny(c.Approved, text) >= 0 {
+		r.Status = Approved
+		return r
+	}
+	if i := matchAny(c.Fallbacks, text); i >= 0 {
+		r.Status, r.Fallback = Fallback, i+1
+		return r
+	}
+	r.Status, r.Escalate, r.Escalation = Deviation, true, p.escalation(c)
+	return r
+}
+
+// escalation returns the escalation of a clause, or that of the playbook
+// when the clause has none
+func (p *Playbook) escalation(c *Clause) *Escalation {
+	e := p.Escalation
+	if c != nil && c.Escalation.To != "" {
+		e = c.Escalation
+	} else if c != nil && c.Escalation.Note != "" {
+		e.Note = c.Escalation.Note
+	}
+	if e.To == "" && e.Note == "" {
+		return nil
+	}
+	return &e
+}
+
+// Escalations returns the number of results that need review
+func Escalations(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if r.Escalate {
+			n++
+		}
+	}
+	return n
+}
+
+// Text returns the text of a section without its subsections: its
+// paragraphs, list items and table rows, with white space collapsed
+func Text(s nld.Section) string {
+	var parts []string
+	for _, b := range s.Body() {
+		parts = append(parts, b.Text)
+		parts = append(parts, b.Items...)
+		for _, row := range b.Rows {
+			parts = append(parts, row...)
+		}
+	}
+	return normalize(strings.Join(parts, " "))
+}
+
+// matchAny returns the index of the first text s matches, or -1
+func matchAny(texts []string, s string) int {
+	for i, t := range texts {
+		if fill.Match(normalize(t), s) {
+			return i
+		}
+	}
+	return -1
+}
+
+// normalize collapses runs of white space into single spaces
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
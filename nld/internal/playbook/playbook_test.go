@@ -0,0 +1,104 @@
+package playbook
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const sales = `
+name: Sales
+escalation:
+  to: legal@example.com
+clauses:
+  - id: payment
+    required: true
+    approved:
+      - Invoices are payable within {{ days }} days of receipt.
+    fallbacks:
+      - Invoices are payable within 60 days.
+      - Invoices are payable on receipt.
+    escalation:
+      to: finance@example.com
+      note: Longer terms need the approval of the CFO.
+  - id: liability
+    approved:
+      - Liability is capped at the fees paid.
+  - id: confidentiality
+    title: Confidentiality
+    required: true
+    approved:
+      - Both parties keep the terms confidential.
+    escalation:
+      note: Confidentiality is mandatory.
+`
+
+func TestCheck(t *testing.T) {
+	p, err := Parse([]byte(sales))
+	if err != nil {
+		t.Fatalf("Failed to parse playbook: %v", err)
+	}
+	doc, err := nld.Parse([]byte(`{
+		"metadata": {"type": "contract"},
+		"content": {"sections": [
+			{"id": "payment", "title": "Payment", "content": "Invoices are payable\nwithin 60 days.", "sections": [
+				{"id": "late", "title": "Late Payment", "content": "Interest accrues."}
+			]},
+			{"id": "liability", "title": "Liability", "content": "Liability is unlimited."}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	results := p.Check(doc)
+	var got []string
+	for _, r := range results {
+		line := r.ID + " " + r.Status
+		if r.Escalate {
+			line += " -> " + r.Escalation.To + " " + r.Escalation.Note
+		}
+		got = append(got, strings.TrimSpace(line))
+	}
+	expected := []string{
+		"payment fallback",
+		"late unlisted",
+		"liability deviation -> legal@example.com",
+		"confidentiality missing -> legal@example.com Confidentiality is mandatory.",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+	if results[0].Fallback != 1 {
+		t.Errorf("Expected the first fallback, got %d", results[0].Fallback)
+	}
+	if n := Escalations(results); n != 2 {
+		t.Errorf("Expected 2 escalations, got %d", n)
+	}
+
+	doc.Structure.Sections[0].Content = "Invoices are payable within 30 days of receipt."
+	if r := p.Check(doc)[0]; r.Status != Approved {
+		t.Errorf("Expected filled placeholder to be approved, got %s", r.Status)
+	}
+
+	p.Unlisted = Escalate
+	if r := p.Check(doc)[1]; !r.Escalate || r.Escalation.To != "legal@example.com" {
+		t.Errorf("Expected unlisted section to be escalated, got %+v", r)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := map[string]string{
+		"clauses: [{approved: [x]}]":                                "clause without id",
+		"clauses: [{id: a, approved: [x]}, {id: a, approved: [x]}]": "duplicate clause a",
+		"clauses: [{id: a}]":                                        "no approved language",
+		"unlisted: sometimes":                                       "unlisted must be",
+		"clause: []":                                                "field clause not found",
+	}
+	for data, expected := range testCases {
+		if _, err := Parse([]byte(data)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Parse(%q) = %v, expected %q", data, err, expected)
+		}
+	}
+}
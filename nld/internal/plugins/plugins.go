@@ -0,0 +1,98 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Prefix is the file name prefix of plugin executables, as in nld-crm-sync
+const Prefix = "nld-"
+
+// BinaryEnv names the environment variable holding the path of the nld
+// executable that runs a plugin, so the plugin can call it back
+const BinaryEnv = "NLD_BINARY"
+
+// Plugin is an executable that adds a command to nld
+type Plugin struct {
+	// Name is the command name, the file name without Prefix
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Shadowed are the paths of executables of the same name later on
+	// PATH, which never run
+	Shadowed []string `json:"shadowed,omitempty"`
+}
+
+// Find returns the plugins in the directories of path, a list in the form
+// of the PATH environment variable, sorted by name. When two directories
+// hold a plugin of the same name, the first one wins as it would in a
+// shell.
+func Find(path string) []Plugin {
+	byName := map[string]*Plugin{}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			name, ok := commandName(e.Name())
+			if !ok {
+				continue
+			}
+			full := filepath.Join(dir, e.Name())
+			if !executable(full) {
+				continue
+			}
+			if p, seen := byName[name]; seen {
+				if p.Path != full {
+					p.Shadowed = append(p.Shadowed, full)
+				}
+				continue
+			}
+			byName[name] = &Plugin{Name: name, Path: full}
+		}
+	}
+
+	list := make([]Plugin, 0, len(byName))
+	for _, p := range byName {
+		list = append(list, *p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// commandName returns the command name of a plugin file name
+func commandName(file string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		ext := filepath.Ext(file)
+		if !strings.EqualFold(ext, ".exe") && !strings.EqualFold(ext, ".bat") && !strings.EqualFold(ext, ".cmd") {
+			return "", false
+		}
+		file = strings.TrimSuffix(file, ext)
+	}
+	name := strings.TrimPrefix(file, Prefix)
+	if name == file || name == "" || strings.ContainsAny(name, " \t") {
+		return "", false
+	}
+	return name, true
+}
+
+// executable reports whether path is a regular file that can be run
+func executable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0
+}
+
+// FlagEnv returns the environment variable a global flag is passed to
+// plugins in, as in NLD_OUTPUT_FORMAT for --output-format
+func FlagEnv(flag string) string {
+	return "NLD_" + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
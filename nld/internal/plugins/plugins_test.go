@@ -0,0 +1,44 @@
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin files are named differently on Windows")
+	}
+	first, second := t.TempDir(), t.TempDir()
+	write := func(dir, name string, perm os.FileMode) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), perm); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	crm := write(first, "nld-crm-sync", 0755)
+	shadowed := write(second, "nld-crm-sync", 0755)
+	audit := write(second, "nld-audit", 0755)
+	write(first, "nld-notes", 0644)
+	write(first, "other-tool", 0755)
+	write(first, "nld-", 0755)
+
+	got := Find(first + string(os.PathListSeparator) + second + string(os.PathListSeparator) + filepath.Join(first, "missing"))
+	want := []Plugin{
+		{Name: "audit", Path: audit},
+		{Name: "crm-sync", Path: crm, Shadowed: []string{shadowed}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFlagEnv(t *testing.T) {
+	if got := FlagEnv("output-format"); got != "NLD_OUTPUT_FORMAT" {
+		t.Errorf("FlagEnv() = %q", got)
+	}
+}
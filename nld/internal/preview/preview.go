@@ -0,0 +1,319 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+// Problem is a validation error or warning shown in the preview
+type Problem struct {
+	// Field is the JSON pointer of the value the problem is about, if known
+	Field   string
+	Message string
+	Warning bool
+}
+
+// Page is one build of the previewed document
+type Page struct {
+	// HTML is the rendered document. When it is nil the document could not
+	// be rendered and the previous rendering is shown with the problems.
+	HTML     []byte
+	Problems []Problem
+	// Sections maps the JSON pointers of sections to their IDs, so that
+	// problems can be shown under the section they are found in
+	Sections map[string]string
+}
+
+// Errors returns the number of problems that are errors
+func (p Page) Errors() int {
+	n := 0
+	for _, problem := range p.Problems {
+		if !problem.Warning {
+			n++
+		}
+	}
+	return n
+}
+
+// Sections returns the JSON pointers of the sections of doc, including
+// nested subsections, mapped to the section IDs
+func Sections(doc document.Document) map[string]string {
+	pointers := map[string]string{}
+	var walk func(parent map[string]interface{}, location string)
+	walk = func(parent map[string]interface{}, location string) {
+		raw, _ := parent["sections"].([]interface{})
+		for i, item := range raw {
+			section, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			path := fmt.Sprintf("%s/sections/%d", location, i)
+			if id := document.String(section, "id"); id != "" {
+				pointers[path] = id
+			}
+			walk(section, path)
+		}
+	}
+	if body := doc.Body(false); body != nil {
+		walk(body, "/"+doc.BodyKey())
+	}
+	return pointers
+}
+
+// section returns the ID of the innermost section containing the value at
+// field, or "" when the value is outside the sections
+func (p Page) section(field string) string {
+	id, longest := "", 0
+	for pointer, sectionID := range p.Sections {
+		if (field == pointer || strings.HasPrefix(field, pointer+"/")) && len(pointer) > longest {
+			id, longest = sectionID, len(pointer)
+		}
+	}
+	return id
+}
+
+// style is added to the head of the rendering
+const style = `<style>
+.nld-preview { font-family: sans-serif; border: 1px solid #d33; background: #fdf2f2; padding: 0.5em 1em; margin: 0 0 1em; }
+.nld-preview.valid { border-color: #3a3; background: #f2fbf2; }
+.nld-preview ul { margin: 0.5em 0; padding-left: 1.5em; }
+.nld-preview-problem { font-family: sans-serif; font-size: 0.9em; border-left: 4px solid #d33; background: #fdf2f2; padding: 0.25em 0.75em; margin: 0.25em 0 0.75em; }
+.nld-preview-problem.warning, .nld-preview li.warning { border-color: #c90; background: #fdf8ec; }
+.nld-preview code, .nld-preview-problem code { color: #555; }
+</style>
+`
+
+// script reloads the page when the server reports a newer version
+const script = `<script>
+(function () {
+  var version = "%d";
+  var events = new EventSource("/events");
+  events.onmessage = function (e) { if (e.data !== version) { location.reload(); } };
+})();
+</script>
+`
+
+// Annotate returns the rendering of a page with its problems shown in a
+// banner and under the sections they are found in, and a script that
+// reloads the page when the version served by the server changes
+func Annotate(page Page, version int) []byte {
+	out := page.HTML
+	if out == nil {
+		out = []byte("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>NLD preview</title>\n</head>\n<body>\n</body>\n</html>\n")
+	}
+	out = append([]byte(nil), out...)
+
+	var banner bytes.Buffer
+	errs := page.Errors()
+	if len(page.Problems) == 0 {
+		banner.WriteString("<div class=\"nld-preview valid\">✓ Document is valid</div>\n")
+	} else {
+		fmt.Fprintf(&banner, "<div class=\"nld-preview\">\n<strong>%d error(s), %d warning(s)</strong>\n<ul>\n", errs, len(page.Problems)-errs)
+	}
+	inline := map[string]string{}
+	var ids []string
+	for _, problem := range page.Problems {
+		class, kind := "", "error"
+		if problem.Warning {
+			class, kind = " class=\"warning\"", "warning"
+		}
+		message := html.EscapeString(problem.Message)
+		if problem.Field != "" {
+			message += " <code>" + html.EscapeString(problem.Field) + "</code>"
+		}
+		id := page.section(problem.Field)
+		if id != "" {
+			fmt.Fprintf(&banner, "<li%s><a href=\"#%s\">%s</a>: %s</li>\n", class, html.EscapeString(id), kind, message)
+			if _, ok := inline[id]; !ok {
+				ids = append(ids, id)
+			}
+			inline[id] += fmt.Sprintf("<div class=\"nld-preview-problem %s\">%s</div>\n", kind, message)
+		} else {
+			fmt.Fprintf(&banner, "<li%s>%s: %s</li>\n", class, kind, message)
+		}
+	}
+	if len(page.Problems) > 0 {
+		banner.WriteString("</ul>\n</div>\n")
+	}
+	for _, id := range ids {
+		out = insertAfterHeading(out, id, inline[id])
+	}
+
+	out = insertAfter(out, "<body>\n", banner.String())
+	out = insertBefore(out, "</head>", style)
+	return insertBefore(out, "</body>", fmt.Sprintf(script, version))
+}
+
+// insertAfterHeading inserts s after the heading with the given ID
+func insertAfterHeading(page []byte, id, s string) []byte {
+	start := bytes.Index(page, []byte(` id="`+html.EscapeString(id)+`"`))
+	if start < 0 {
+		return page
+	}
+	end := bytes.Index(page[start:], []byte("</h"))
+	if end < 0 {
+		return page
+	}
+	end += start
+	gt := bytes.IndexByte(page[end:], '>')
+	if gt < 0 {
+		return page
+	}
+	return insertAt(page, end+gt+1, "\n"+s)
+}
+
+// insertAfter inserts s after the first occurrence of marker, or at the
+// start of page when it is missing
+func insertAfter(page []byte, marker, s string) []byte {
+	i := bytes.Index(page, []byte(marker))
+	if i < 0 {
+		return insertAt(page, 0, s)
+	}
+	return insertAt(page, i+len(marker), s)
+}
+
+// insertBefore inserts s before the last occurrence of marker, or at the
+// end of page when it is missing
+func insertBefore(page []byte, marker, s string) []byte {
+	i := bytes.LastIndex(page, []byte(marker))
+	if i < 0 {
+		return insertAt(page, len(page), s)
+	}
+	return insertAt(page, i, s)
+}
+
+func insertAt(page []byte, i int, s string) []byte {
+	out := make([]byte, 0, len(page)+len(s))
+	out = append(out, page[:i]...)
+	out = append(out, s...)
+	return append(out, page[i:]...)
+}
+
+// Server serves the live preview of a document. The document is built
+// again whenever its file changes, and open pages reload.
+type Server struct {
+	path  string
+	build func() Page
+
+	mu       sync.Mutex
+	stamp    stamp
+	built    bool
+	version  int
+	last     []byte
+	page     Page
+	rendered []byte
+	// changed is closed when a new version is built
+	changed chan struct{}
+}
+
+// stamp identifies a version of the watched file
+type stamp struct {
+	modTime time.Time
+	size    int64
+	missing bool
+}
+
+// New returns a server previewing the file at path, rendered by build
+func New(path string, build func() Page) *Server {
+	return &Server{path: path, build: build, changed: make(chan struct{})}
+}
+
+// Refresh builds the document again if its file has changed since the last
+// build, and reports whether it did
+func (s *Server) Refresh() (Page, bool) {
+	var current stamp
+	if info, err := os.Stat(s.path); err != nil {
+		current.missing = true
+	} else {
+		current.modTime, current.size = info.ModTime(), info.Size()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.built && current == s.stamp {
+		return s.page, false
+	}
+	page := s.build()
+	if page.HTML == nil {
+		page.HTML = s.last
+	} else {
+		s.last = page.HTML
+	}
+	s.stamp, s.built, s.page = current, true, page
+	s.version++
+	s.rendered = Annotate(page, s.version)
+	close(s.changed)
+	s.changed = make(chan struct{})
+	return page, true
+}
+
+// Watch checks the file for changes every interval until ctx is done,
+// calling changed with each new build
+func (s *Server) Watch(ctx context.Context, interval time.Duration, changed func(Page)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if page, ok := s.Refresh(); ok && changed != nil {
+				changed(page)
+			}
+		}
+	}
+}
+
+// ServeHTTP serves the annotated rendering at / and a stream of versions
+// at /events that open pages use to reload
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		s.Refresh()
+		s.mu.Lock()
+		page := s.rendered
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(page)
+	case "/events":
+		s.events(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// events streams the current version as server-sent events, sending it
+// again after each change
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	for {
+		s.mu.Lock()
+		version, changed := s.version, s.changed
+		s.mu.Unlock()
+		if _, err := fmt.Fprintf(w, "data: %d\n\n", version); err != nil {
+			return
+		}
+		flusher.Flush()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
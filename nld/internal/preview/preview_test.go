@@ -0,0 +1,144 @@
+package preview
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+func TestSections(t *testing.T) {
+	doc, err := document.Parse([]byte(`{"content": {"sections": [
+		{"id": "terms", "sections": [{"id": "payment"}, {"title": "untitled"}]},
+		{"id": "law"}
+	]}}`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	want := map[string]string{
+		"/content/sections/0":            "terms",
+		"/content/sections/0/sections/0": "payment",
+		"/content/sections/1":            "law",
+	}
+	if got := Sections(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	page := Page{
+		HTML: []byte("<!DOCTYPE html>\n<html>\n<head>\n<title>Contract</title>\n</head>\n<body>\n<h2 id=\"terms\">1 Terms</h2>\n<h3 id=\"payment\">1.1 Payment</h3>\n<p>Pay &amp; go</p>\n</body>\n</html>\n"),
+		Problems: []Problem{
+			{Field: "/content/sections/0/sections/0/content/0", Message: "missing <amount>"},
+			{Field: "/metadata/date", Message: "date is in the future", Warning: true},
+		},
+		Sections: map[string]string{"/content/sections/0": "terms", "/content/sections/0/sections/0": "payment"},
+	}
+	out := string(Annotate(page, 3))
+
+	testCases := []struct {
+		name string
+		want string
+	}{
+		{"summary", "<strong>1 error(s), 1 warning(s)</strong>"},
+		{"linked error", `<li><a href="#payment">error</a>: missing &lt;amount&gt;`},
+		{"unplaced warning", `<li class="warning">warning: date is in the future <code>/metadata/date</code></li>`},
+		{"inline error", "<h3 id=\"payment\">1.1 Payment</h3>\n<div class=\"nld-preview-problem error\">missing &lt;amount&gt;"},
+		{"style", "<style>"},
+		{"reload script", `var version = "3";`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if !strings.Contains(out, tc.want) {
+				t.Errorf("Expected %q in:\n%s", tc.want, out)
+			}
+		})
+	}
+	if strings.Index(out, "</style>") > strings.Index(out, "</head>") || strings.Index(out, "<script>") > strings.Index(out, "</body>") {
+		t.Errorf("Expected style in the head and script in the body:\n%s", out)
+	}
+
+	valid := string(Annotate(Page{}, 1))
+	if !strings.Contains(valid, "Document is valid") || !strings.Contains(valid, "<body>") {
+		t.Errorf("Expected a page for a valid document without rendering, got:\n%s", valid)
+	}
+}
+
+func TestServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.json")
+	os.WriteFile(path, []byte("one"), 0644)
+
+	builds := 0
+	s := New(path, func() Page {
+		builds++
+		data, _ := os.ReadFile(path)
+		if string(data) == "broken" {
+			return Page{Problems: []Problem{{Message: "invalid JSON"}}}
+		}
+		return Page{HTML: []byte("<html><head></head><body>\n<p>" + string(data) + "</p>\n</body></html>")}
+	})
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	get := func() string {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Failed to get page: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	if page := get(); !strings.Contains(page, "<p>one</p>") || !strings.Contains(page, `var version = "1"`) {
+		t.Fatalf("Unexpected page:\n%s", page)
+	}
+	if _, changed := s.Refresh(); changed || builds != 1 {
+		t.Errorf("Expected no rebuild of an unchanged file, got %d builds", builds)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open events: %v", err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewReader(resp.Body)
+	next := func() string {
+		for {
+			line, err := events.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Failed to read event: %v", err)
+			}
+			if strings.HasPrefix(line, "data: ") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+			}
+		}
+	}
+	if v := next(); v != "1" {
+		t.Errorf("Expected version 1, got %s", v)
+	}
+
+	// A broken document keeps the last rendering and shows the problem
+	os.WriteFile(path, []byte("broken"), 0644)
+	os.Chtimes(path, time.Now().Add(time.Second), time.Now().Add(time.Second))
+	if _, changed := s.Refresh(); !changed {
+		t.Fatal("Expected a rebuild of a changed file")
+	}
+	if v := next(); v != "2" {
+		t.Errorf("Expected version 2, got %s", v)
+	}
+	if page := get(); !strings.Contains(page, "<p>one</p>") || !strings.Contains(page, "invalid JSON") {
+		t.Errorf("Expected the last rendering with the problem, got:\n%s", page)
+	}
+}
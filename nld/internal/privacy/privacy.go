@@ -0,0 +1,144 @@
+// Package privacy finds the personal data of an entity in documents and
+// erases it, for the requests of data subjects to access or erase their
+// data
+package privacy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/entity"
+	"github.com/colemalphrus/nld/internal/redact"
+)
+
+// personalFields are the fields of entities holding personal data
+var personalFields = []string{"name", "email", "phone", "address"}
+
+// Reference is the personal data of an entity in a document
+type Reference struct {
+	Entity entity.Entity `json:"entity"`
+	// index is the position of the entity in metadata.entities
+	index int
+	// Sections are the IDs of the top-level sections that mention the name
+	// or contact details of the entity
+	Sections []string `json:"sections,omitempty"`
+	// Signed is set when the entity signed the document
+	Signed bool `json:"signed,omitempty"`
+}
+
+// Find returns the reference of a document to the entity with the given
+// ID, or nil when the document does not list it
+func Find(doc document.Document, id string) *Reference {
+	var ref *Reference
+	for i, e := range entity.List(doc) {
+		if e.ID == id {
+			ref = &Reference{Entity: e, index: i}
+			break
+		}
+	}
+	if ref == nil {
+		return nil
+	}
+
+	values := ref.values()
+	for _, section := range doc.Sections() {
+		data, err := json.Marshal(section)
+		if err != nil {
+			continue
+		}
+		text := strings.ToLower(string(data))
+		for _, v := range values {
+			if strings.Contains(text, strings.ToLower(v)) {
+				ref.Sections = append(ref.Sections, document.String(section, "id"))
+				break
+			}
+		}
+	}
+
+	if verification := doc.Verification(false); verification != nil {
+		signatures, _ := verification["signatures"].([]interface{})
+		for _, raw := range signatures {
+			if sig, ok := raw.(map[string]interface{}); ok && document.String(sig, "signerId") == id && document.String(sig, "value") != "" {
+				ref.Signed = true
+			}
+		}
+	}
+	return ref
+}
+
+// fields returns the personal data of the entity by field, leaving out
+// the fields that are empty or erased already
+func (r *Reference) fields() map[string]string {
+	fields := map[string]string{}
+	for f, v := range map[string]string{"name": r.Entity.Name, "email": r.Entity.Email, "phone": r.Entity.Phone, "address": r.Entity.Address} {
+		if strings.TrimSpace(v) != "" && v != redact.DefaultMask {
+			fields[f] = v
+		}
+	}
+	return fields
+}
+
+// values returns the personal data of the entity that text may mention
+func (r *Reference) values() []string {
+	var values []string
+	fields := r.fields()
+	for _, f := range personalFields {
+		if v, ok := fields[f]; ok {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Erased reports whether the personal data of the entity was erased
+// already
+func (r *Reference) Erased() bool {
+	return len(r.fields()) == 0
+}
+
+// Options controls erasure
+type Options struct {
+	// Sections masks the sections that mention the entity as well as its
+	// fields in metadata.entities
+	Sections bool
+	Redactor string
+	// Reason is recorded in the redaction attestation; it defaults to a
+	// statement of the erasure
+	Reason string
+	Now    time.Time
+}
+
+// Erase masks the personal data of the entity of ref in doc, keeping its
+// ID and role, and records the erasure in a redaction attestation
+func Erase(doc document.Document, ref *Reference, opts Options) (*redact.Report, error) {
+	var paths []string
+	fields := ref.fields()
+	for _, f := range personalFields {
+		if _, ok := fields[f]; ok {
+			paths = append(paths, fmt.Sprintf("$.metadata.entities[%d].%s", ref.index, f))
+		}
+	}
+	var sections []string
+	if opts.Sections {
+		sections = ref.Sections
+	}
+	if len(paths) == 0 && len(sections) == 0 {
+		return nil, fmt.Errorf("entity %s has no personal data to erase", ref.Entity.ID)
+	}
+
+	reason := opts.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("erasure of the personal data of entity %s at the request of the data subject", ref.Entity.ID)
+	}
+	return redact.Apply(doc, redact.Options{
+		Sections: sections,
+		Paths:    paths,
+		Mode:     redact.ModeMask,
+		Redactor: opts.Redactor,
+		Reason:   reason,
+		Now:      opts.Now,
+	})
+}
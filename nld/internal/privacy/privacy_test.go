@@ -0,0 +1,82 @@
+package privacy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/redact"
+)
+
+const sample = `{
+  "metadata": {"type": "contract", "created": "2025-03-12T00:00:00Z", "entities": [
+    {"id": "E122", "name": "XYZ Ltd", "role": "Client"},
+    {"id": "E123", "name": "Jane Doe", "role": "Contractor", "email": "jane@doe.example"}]},
+  "content": {"sections": [
+    {"id": "parties", "title": "Parties", "content": "XYZ Ltd engages Jane Doe."},
+    {"id": "notices", "title": "Notices", "content": "Notices go to JANE@doe.example."},
+    {"id": "fees", "title": "Fees", "content": "The fee is $100."}]},
+  "verification": {"signatures": [{"signerId": "E123", "date": "2025-03-12T00:00:00Z", "value": "abc"}]}
+}`
+
+func TestFind(t *testing.T) {
+	doc, err := document.Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := Find(doc, "E123")
+	if ref == nil {
+		t.Fatal("Find returned nil")
+	}
+	if ref.Entity.Name != "Jane Doe" || !ref.Signed || !reflect.DeepEqual(ref.Sections, []string{"parties", "notices"}) {
+		t.Errorf("Find = %+v", ref)
+	}
+	if ref := Find(doc, "E122"); ref == nil || ref.Signed || !reflect.DeepEqual(ref.Sections, []string{"parties"}) {
+		t.Errorf("Find E122 = %+v", ref)
+	}
+	if ref := Find(doc, "E999"); ref != nil {
+		t.Errorf("Find of an unknown entity = %+v", ref)
+	}
+}
+
+func TestErase(t *testing.T) {
+	for _, sections := range []bool{false, true} {
+		doc, _ := document.Parse([]byte(sample))
+		rec, _ := digest.Compute(doc)
+		report, err := Erase(doc, Find(doc, "E123"), Options{Sections: sections, Redactor: "dpo"})
+		if err != nil {
+			t.Fatalf("Erase failed: %v", err)
+		}
+		out, _ := doc.Marshal()
+		if strings.Contains(string(out), "jane@doe.example") || !strings.Contains(string(out), "XYZ Ltd") {
+			t.Errorf("Erase (sections %v) left:\n%s", sections, out)
+		}
+		if strings.Contains(string(out), "Jane Doe") != !sections {
+			t.Errorf("Erase (sections %v) of the mentions:\n%s", sections, out)
+		}
+		if len(report.Paths) != 2 || !strings.Contains(string(out), "at the request of the data subject") {
+			t.Errorf("report = %+v", report)
+		}
+
+		// The erasure verifies against the hashes of the signed document
+		verified, err := digest.Verify(doc, rec)
+		if err != nil {
+			t.Fatalf("Verify failed: %v", err)
+		}
+		for _, p := range verified.Changed() {
+			if p.Status != digest.StatusRedacted {
+				t.Errorf("Erase (sections %v) left %s %s %s", sections, p.Part, p.ID, p.Status)
+			}
+		}
+
+		ref := Find(doc, "E123")
+		if !ref.Erased() || ref.Entity.ID != "E123" || ref.Entity.Role != "Contractor" || ref.Entity.Name != redact.DefaultMask {
+			t.Errorf("erased entity = %+v", ref)
+		}
+		if _, err := Erase(doc, ref, Options{}); err == nil {
+			t.Error("Erase of an erased entity succeeded")
+		}
+	}
+}
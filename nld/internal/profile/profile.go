@@ -0,0 +1,389 @@
+package profile
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const (
+	// PathEnv lists directories of profile files separated by the OS path
+	// list separator. Profiles found there take precedence over the
+	// built-in profiles of the same name.
+	PathEnv = "NLD_PROFILE_PATH"
+
+	// None disables profile checks when given as the profile name
+	None = "none"
+)
+
+// Severity of a profile problem. Errors make a document invalid; warnings
+// are reported only.
+const (
+	Error   = "error"
+	Warning = "warning"
+)
+
+//go:embed profiles/*.json
+var builtin embed.FS
+
+// Profile is a set of jurisdiction-specific requirements checked in
+// addition to the schema
+type Profile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Jurisdictions are matched against metadata.jurisdiction word by word,
+	// so "usa" matches "California, USA"
+	Jurisdictions []string `json:"jurisdictions"`
+	// Types limits the profile to these document types; empty means all
+	Types []string `json:"types,omitempty"`
+	// Fields are metadata fields that must be present
+	Fields   []FieldRule   `json:"fields,omitempty"`
+	Sections []SectionRule `json:"sections,omitempty"`
+	Clauses  []ClauseRule  `json:"clauses,omitempty"`
+
+	// Path is the file the profile was read from, empty for built-ins
+	Path string `json:"-"`
+}
+
+// FieldRule requires a metadata field
+type FieldRule struct {
+	Name     string `json:"name"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// SectionRule requires a section, found by ID or by a title containing
+// Title or one of Match (case-insensitive) at any depth
+type SectionRule struct {
+	ID       string   `json:"id"`
+	Title    string   `json:"title"`
+	Match    []string `json:"match,omitempty"`
+	Severity string   `json:"severity,omitempty"`
+}
+
+// ClauseRule requires text matching one of Patterns somewhere in the
+// document. When set, the clause is only required if the document also
+// contains text matching one of When.
+type ClauseRule struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Patterns    []string `json:"patterns"`
+	When        []string `json:"when,omitempty"`
+	Severity    string   `json:"severity,omitempty"`
+
+	patterns []*regexp.Regexp
+	when     []*regexp.Regexp
+}
+
+// Problem is a profile requirement the document does not meet
+type Problem struct {
+	Path     string
+	Message  string
+	Severity string
+}
+
+// Parse reads a profile from JSON
+func Parse(data []byte) (*Profile, error) {
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid profile: %w", err)
+	}
+	if p.Name == "" {
+		return nil, fmt.Errorf("invalid profile: missing name")
+	}
+
+	severities := []*string{}
+	for i := range p.Fields {
+		severities = append(severities, &p.Fields[i].Severity)
+	}
+	for i := range p.Sections {
+		severities = append(severities, &p.Sections[i].Severity)
+	}
+	for i := range p.Clauses {
+		c := &p.Clauses[i]
+		severities = append(severities, &c.Severity)
+		var err error
+		if c.patterns, err = compile(c.Patterns); err != nil {
+			return nil, fmt.Errorf("invalid profile %s: clause %s: %w", p.Name, c.Name, err)
+		}
+		if c.when, err = compile(c.When); err != nil {
+			return nil, fmt.Errorf("invalid profile %s: clause %s: %w", p.Name, c.Name, err)
+		}
+	}
+	for _, s := range severities {
+		switch *s {
+		case "":
+			*s = Error
+		case Error, Warning:
+		default:
+			return nil, fmt.Errorf("invalid profile %s: unknown severity %q", p.Name, *s)
+		}
+	}
+	return &p, nil
+}
+
+// compile compiles case-insensitive patterns
+func compile(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Builtin returns the profiles shipped with nld ordered by name
+func Builtin() ([]*Profile, error) {
+	entries, err := builtin.ReadDir("profiles")
+	if err != nil {
+		return nil, err
+	}
+	var profiles []*Profile
+	for _, e := range entries {
+		data, err := builtin.ReadFile("profiles/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		p, err := Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// All returns the profiles in NLD_PROFILE_PATH followed by the built-in
+// profiles they do not override
+func All() ([]*Profile, error) {
+	var profiles []*Profile
+	seen := map[string]bool{}
+	for _, dir := range filepath.SplitList(os.Getenv(PathEnv)) {
+		if dir == "" {
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			p, err := LoadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				profiles = append(profiles, p)
+			}
+		}
+	}
+
+	builtins, err := Builtin()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range builtins {
+		if !seen[p.Name] {
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles, nil
+}
+
+// LoadFile reads a profile from a file
+func LoadFile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.Path = path
+	return p, nil
+}
+
+// Load returns the profile with the given name, or reads it from a file if
+// name is a path to a JSON file
+func Load(name string) (*Profile, error) {
+	if strings.HasSuffix(name, ".json") || strings.ContainsRune(name, filepath.Separator) {
+		return LoadFile(name)
+	}
+
+	profiles, err := All()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range profiles {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown profile: %s", name)
+}
+
+// Select returns the profile that matches the jurisdiction most
+// specifically and applies to the document type, or nil if there is none.
+// "Northern Ireland" selects the profile listing "northern ireland" over
+// one listing only "ireland".
+func Select(jurisdiction, docType string) (*Profile, error) {
+	if jurisdiction == "" {
+		return nil, nil
+	}
+	profiles, err := All()
+	if err != nil {
+		return nil, err
+	}
+	var selected *Profile
+	best := 0
+	for _, p := range profiles {
+		if n := p.match(jurisdiction); n > best && p.Applies(docType) {
+			selected, best = p, n
+		}
+	}
+	return selected, nil
+}
+
+// Matches reports whether the profile covers the jurisdiction
+func (p *Profile) Matches(jurisdiction string) bool {
+	return p.match(jurisdiction) > 0
+}
+
+// match returns the number of words in the longest of the profile's
+// jurisdictions found in jurisdiction, or 0
+func (p *Profile) match(jurisdiction string) int {
+	words := " " + strings.Join(split(jurisdiction), " ") + " "
+	best := 0
+	for _, j := range p.Jurisdictions {
+		w := split(j)
+		if len(w) > best && strings.Contains(words, " "+strings.Join(w, " ")+" ") {
+			best = len(w)
+		}
+	}
+	return best
+}
+
+// split returns the lower-case words of s
+func split(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Applies reports whether the profile applies to the document type
+func (p *Profile) Applies(docType string) bool {
+	if len(p.Types) == 0 {
+		return true
+	}
+	for _, t := range p.Types {
+		if strings.EqualFold(t, docType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns the requirements of the profile that the document does not
+// meet
+func (p *Profile) Check(doc *nld.Document) []Problem {
+	var problems []Problem
+	body := "/" + doc.BodyKey()
+
+	var meta map[string]interface{}
+	if data, err := json.Marshal(doc.Metadata); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+	for _, f := range p.Fields {
+		if v, ok := meta[f.Name]; !ok || v == "" {
+			problems = append(problems, Problem{
+				Path:     "/metadata/" + f.Name,
+				Message:  fmt.Sprintf("%s profile requires metadata.%s", p.Name, f.Name),
+				Severity: f.Severity,
+			})
+		}
+	}
+
+	var sections []nld.Section
+	var text strings.Builder
+	var walk func(list []nld.Section)
+	walk = func(list []nld.Section) {
+		for _, s := range list {
+			sections = append(sections, s)
+			text.WriteString(s.Title + "\n")
+			for _, b := range s.Body() {
+				text.WriteString(b.Text + "\n" + strings.Join(b.Items, "\n") + "\n")
+				for _, row := range b.Rows {
+					text.WriteString(strings.Join(row, " ") + "\n")
+				}
+			}
+			walk(s.Sections)
+		}
+	}
+	walk(doc.Structure.Sections)
+	for _, d := range doc.Structure.Definitions {
+		text.WriteString(d.Term + " " + d.Definition + "\n")
+	}
+
+	for _, r := range p.Sections {
+		if !hasSection(sections, r) {
+			problems = append(problems, Problem{
+				Path:     body + "/sections",
+				Message:  fmt.Sprintf("%s profile requires a %q section (id %s)", p.Name, r.Title, r.ID),
+				Severity: r.Severity,
+			})
+		}
+	}
+
+	for _, c := range p.Clauses {
+		if len(c.when) > 0 && !matchAny(c.when, text.String()) {
+			continue
+		}
+		if !matchAny(c.patterns, text.String()) {
+			problems = append(problems, Problem{
+				Path:     body + "/sections",
+				Message:  fmt.Sprintf("%s profile requires %s", p.Name, c.Description),
+				Severity: c.Severity,
+			})
+		}
+	}
+	return problems
+}
+
+// hasSection reports whether a section satisfies the rule
+func hasSection(sections []nld.Section, r SectionRule) bool {
+	titles := append([]string{r.Title}, r.Match...)
+	for _, s := range sections {
+		if s.ID == r.ID {
+			return true
+		}
+		for _, t := range titles {
+			if t != "" && strings.Contains(strings.ToLower(s.Title), strings.ToLower(t)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchAny reports whether any pattern matches s
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
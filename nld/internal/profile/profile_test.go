@@ -0,0 +1,125 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+func TestSelect(t *testing.T) {
+	testCases := []struct {
+		name         string
+		jurisdiction string
+		docType      string
+		expect       string
+	}{
+		{name: "US State", jurisdiction: "California, USA", docType: "contract", expect: "us"},
+		{name: "EU Member State", jurisdiction: "Germany", docType: "agreement", expect: "eu"},
+		{name: "England", jurisdiction: "England and Wales", docType: "contract", expect: "uk"},
+		{name: "Most Specific", jurisdiction: "Northern Ireland", docType: "contract", expect: "uk"},
+		{name: "Other Type", jurisdiction: "Germany", docType: "receipt", expect: ""},
+		{name: "Unknown", jurisdiction: "Atlantis", docType: "contract", expect: ""},
+		{name: "Empty", jurisdiction: "", docType: "contract", expect: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Select(tc.jurisdiction, tc.docType)
+			if err != nil {
+				t.Fatalf("Select failed: %v", err)
+			}
+			got := ""
+			if p != nil {
+				got = p.Name
+			}
+			if got != tc.expect {
+				t.Errorf("Expected profile %q, got %q", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		name     string
+		profile  string
+		sections string
+		expect   []string
+	}{
+		{
+			name:     "EU Compliant",
+			profile:  "eu",
+			sections: `[{"id": "data", "title": "Data Protection", "content": "Personal data is processed in accordance with the GDPR."}, {"id": "law", "title": "Applicable Law", "content": "German law applies."}]`,
+		},
+		{
+			name:     "EU Missing GDPR Clause",
+			profile:  "eu",
+			sections: `[{"id": "data", "title": "Data", "content": "The Supplier may process personal data."}, {"id": "governing-law", "title": "Law", "content": "German law applies."}]`,
+			expect:   []string{"error: eu profile requires a data protection clause referring to the GDPR when personal data is processed"},
+		},
+		{
+			name:     "EU No Personal Data",
+			profile:  "eu",
+			sections: `[{"id": "governing-law", "title": "Governing Law", "content": "German law applies."}]`,
+		},
+		{
+			name:     "US Missing Governing Law",
+			profile:  "us",
+			sections: `[{"id": "scope", "title": "Scope", "sections": [{"id": "sub", "title": "Detail", "content": "Text"}]}]`,
+			expect:   []string{`warning: us profile requires a "Governing Law" section (id governing-law)`},
+		},
+		{
+			name:     "Nested Section",
+			profile:  "uk",
+			sections: `[{"id": "general", "title": "General", "sections": [{"id": "law", "title": "Law and Jurisdiction", "content": "English law applies."}]}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Load(tc.profile)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			doc, err := nld.Parse([]byte(`{"metadata": {"type": "contract", "jurisdiction": "Somewhere"}, "content": {"sections": ` + tc.sections + `}}`))
+			if err != nil {
+				t.Fatalf("Failed to parse document: %v", err)
+			}
+
+			var got []string
+			for _, problem := range p.Check(doc) {
+				got = append(got, problem.Severity+": "+problem.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected problems:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestProfilePath(t *testing.T) {
+	dir := t.TempDir()
+	custom := `{"name": "us", "jurisdictions": ["usa"], "sections": [{"id": "arbitration", "title": "Arbitration"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "us.json"), []byte(custom), 0644); err != nil {
+		t.Fatalf("Failed to write profile: %v", err)
+	}
+	t.Setenv(PathEnv, dir)
+
+	p, err := Select("Texas, USA", "receipt")
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+	if p == nil || p.Path == "" || len(p.Sections) != 1 || p.Sections[0].ID != "arbitration" {
+		t.Errorf("Expected the profile from %s to override the built-in profile, got %+v", PathEnv, p)
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected an error for a missing profile file")
+	}
+	if _, err := Parse([]byte(`{"name": "bad", "clauses": [{"name": "x", "patterns": ["("]}]}`)); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,174 @@
+// Package progress shows the progress of long operations on a terminal, as
+// a bar with the time left when the amount of work is known and as a
+// spinner otherwise
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	// interval is how often the display is redrawn
+	interval = 100 * time.Millisecond
+	// width is the number of cells of a bar
+	width = 30
+	// clearLine returns to the start of the line and erases it
+	clearLine = "\r\x1b[K"
+)
+
+// frames are the frames of a spinner
+var frames = []string{"\u280b", "\u2819", "\u2839", "\u2838", "\u283c", "\u2834", "\u2826", "\u2827", "\u2807", "\u280f"}
+
+// Enabled reports whether progress can be shown on out, which must be a
+// terminal that understands cursor movement
+func Enabled(out *os.File) bool {
+	if out == nil || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isatty.IsTerminal(out.Fd()) || isatty.IsCygwinTerminal(out.Fd())
+}
+
+// Bar shows the progress of an operation on one line of a terminal,
+// redrawing it as work is done and as time passes. Its methods do nothing
+// on a nil Bar, which is how commands show no progress.
+type Bar struct {
+	mu    sync.Mutex
+	out   io.Writer
+	label string
+	// total is the amount of work, or 0 when it is not known, and done the
+	// amount done
+	total int
+	done  int
+	start time.Time
+	// frame is the frame of the spinner and drawn reports whether the line
+	// shows the bar
+	frame int
+	drawn bool
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// New starts showing the progress of an operation on out, labeled label.
+// total is the amount of work, or 0 to show a spinner.
+func New(out io.Writer, label string, total int) *Bar {
+	b := &Bar{out: out, label: label, total: total, start: time.Now(), stop: make(chan struct{})}
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+// run redraws the bar until Done
+func (b *Bar) run() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	b.draw()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			b.frame++
+			b.mu.Unlock()
+			b.draw()
+		}
+	}
+}
+
+// Add records n more units of work done
+func (b *Bar) Add(n int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.done += n
+	b.mu.Unlock()
+	b.draw()
+}
+
+// Done stops showing progress and erases the bar. Calls after the first do
+// nothing.
+func (b *Bar) Done() {
+	if b == nil {
+		return
+	}
+	b.once.Do(func() {
+		close(b.stop)
+		b.wg.Wait()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.erase()
+	})
+}
+
+// Writer returns a writer to w that erases the bar before each write, so
+// that output written while progress is shown is not mixed with the bar.
+// The bar is drawn again below the output.
+func (b *Bar) Writer(w io.Writer) io.Writer {
+	if b == nil {
+		return w
+	}
+	return writer{b, w}
+}
+
+// writer is a writer that erases a bar before writing
+type writer struct {
+	b *Bar
+	w io.Writer
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	w.b.mu.Lock()
+	defer w.b.mu.Unlock()
+	w.b.erase()
+	return w.w.Write(p)
+}
+
+// draw redraws the bar
+func (b *Bar) draw() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fmt.Fprint(b.out, clearLine+b.render(time.Now()))
+	b.drawn = true
+}
+
+// erase erases the bar, if it is drawn. b.mu must be held.
+func (b *Bar) erase() {
+	if b.drawn {
+		fmt.Fprint(b.out, clearLine)
+		b.drawn = false
+	}
+}
+
+// render returns the line showing the progress at now. b.mu must be held.
+func (b *Bar) render(now time.Time) string {
+	if b.total <= 0 {
+		line := frames[b.frame%len(frames)] + " " + b.label
+		if b.done > 0 {
+			line += fmt.Sprintf(" (%d)", b.done)
+		}
+		return line
+	}
+	done := min(b.done, b.total)
+	filled := width * done / b.total
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+	line := fmt.Sprintf("%s [%s] %d/%d %3d%%", b.label, bar, done, b.total, 100*done/b.total)
+	if done > 0 && done < b.total {
+		elapsed := now.Sub(b.start)
+		left := elapsed * time.Duration(b.total-done) / time.Duration(done)
+		line += " ETA " + left.Round(time.Second).String()
+	}
+	return line
+}
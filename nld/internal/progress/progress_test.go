@@ -0,0 +1,66 @@
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	start := time.Now()
+	b := &Bar{label: "Validating", total: 4, start: start}
+	if got := b.render(start); !strings.HasPrefix(got, "Validating [>") || !strings.Contains(got, "0/4   0%") || strings.Contains(got, "ETA") {
+		t.Errorf("nothing done: %q", got)
+	}
+
+	b.done = 1
+	got := b.render(start.Add(3 * time.Second))
+	if !strings.Contains(got, "1/4  25%") || !strings.HasSuffix(got, "ETA 9s") {
+		t.Errorf("a quarter done in 3s: %q", got)
+	}
+
+	b.done = 4
+	if got := b.render(start.Add(time.Minute)); !strings.Contains(got, "["+strings.Repeat("=", width)+"] 4/4 100%") || strings.Contains(got, "ETA") {
+		t.Errorf("all done: %q", got)
+	}
+
+	spinner := &Bar{label: "Importing", frame: 1}
+	if got := spinner.render(start); got != frames[1]+" Importing" {
+		t.Errorf("spinner = %q", got)
+	}
+	spinner.done = 12
+	if got := spinner.render(start); got != frames[1]+" Importing (12)" {
+		t.Errorf("spinner with count = %q", got)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var term, out bytes.Buffer
+	b := New(&term, "Validating", 2)
+	b.Add(1)
+	fmt.Fprintln(b.Writer(&out), "result")
+	b.Done()
+	b.Done()
+
+	if out.String() != "result\n" {
+		t.Errorf("output = %q", out.String())
+	}
+	if !strings.HasSuffix(term.String(), clearLine) {
+		t.Errorf("bar not erased: %q", term.String())
+	}
+	if !strings.Contains(term.String(), "1/2") {
+		t.Errorf("bar not drawn: %q", term.String())
+	}
+}
+
+func TestNil(t *testing.T) {
+	var b *Bar
+	b.Add(1)
+	b.Done()
+	var out bytes.Buffer
+	if w := b.Writer(&out); w != &out {
+		t.Error("a nil bar must return the writer itself")
+	}
+}
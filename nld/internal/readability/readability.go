@@ -0,0 +1,131 @@
+package readability
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Metrics are counts and scores for a piece of prose
+type Metrics struct {
+	Words     int `json:"words"`
+	Sentences int `json:"sentences"`
+	Syllables int `json:"syllables"`
+	// ReadingEase is the Flesch reading ease score: higher scores are
+	// easier to read, 60 to 70 is plain English and below 30 is very hard
+	ReadingEase float64 `json:"readingEase"`
+	// GradeLevel is the Flesch-Kincaid grade level, the years of US
+	// schooling needed to understand the text
+	GradeLevel float64 `json:"gradeLevel"`
+	// AverageSentenceLength and LongestSentence are in words
+	AverageSentenceLength float64 `json:"averageSentenceLength"`
+	LongestSentence       int     `json:"longestSentence"`
+}
+
+// Analyze counts the words, sentences and syllables of text and scores it
+func Analyze(text string) Metrics {
+	var m Metrics
+	m.Add(text)
+	return m
+}
+
+// Add adds the counts of text to m and rescores it, so that the metrics of
+// several pieces of prose can be combined
+func (m *Metrics) Add(text string) {
+	for _, sentence := range Split(text) {
+		words := Words(sentence)
+		if len(words) == 0 {
+			continue
+		}
+		m.Sentences++
+		m.Words += len(words)
+		m.LongestSentence = max(m.LongestSentence, len(words))
+		for _, w := range words {
+			m.Syllables += Syllables(w)
+		}
+	}
+
+	m.ReadingEase, m.GradeLevel, m.AverageSentenceLength = 0, 0, 0
+	if m.Words > 0 {
+		wordsPerSentence := float64(m.Words) / float64(m.Sentences)
+		syllablesPerWord := float64(m.Syllables) / float64(m.Words)
+		m.ReadingEase = 206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord
+		m.GradeLevel = 0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59
+		m.AverageSentenceLength = wordsPerSentence
+	}
+}
+
+// Words returns the words of text: runs of letters and digits, with inner
+// apostrophes and hyphens
+func Words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’' && r != '-'
+	})
+}
+
+// Sentences returns the number of sentences in text
+func Sentences(text string) int {
+	return len(Split(text))
+}
+
+// Split splits text into sentences at their terminating punctuation. A
+// full stop followed by a digit or a lower case word, as in 1.5 or e.g.
+// travel, does not end a sentence. Text after the last terminator is a
+// sentence of its own.
+func Split(text string) []string {
+	runes := []rune(text)
+	var sentences []string
+	start := 0
+	inSentence := false
+	for i, r := range runes {
+		switch {
+		case r == '.' || r == '!' || r == '?':
+			if inSentence && (r != '.' || endsSentence(runes[i+1:])) {
+				sentences = append(sentences, strings.TrimSpace(string(runes[start:i+1])))
+				start = i + 1
+				inSentence = false
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			inSentence = true
+		}
+	}
+	if inSentence {
+		sentences = append(sentences, strings.TrimSpace(string(runes[start:])))
+	}
+	return sentences
+}
+
+// endsSentence reports whether a full stop followed by rest ends a sentence
+func endsSentence(rest []rune) bool {
+	if len(rest) == 0 {
+		return true
+	}
+	if !unicode.IsSpace(rest[0]) {
+		return false
+	}
+	for _, r := range rest {
+		if !unicode.IsSpace(r) {
+			return !unicode.IsLower(r)
+		}
+	}
+	return true
+}
+
+// Syllables estimates the number of syllables in an English word by
+// counting groups of vowels
+func Syllables(word string) int {
+	word = strings.ToLower(word)
+	n := 0
+	prevVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !prevVowel {
+			n++
+		}
+		prevVowel = vowel
+	}
+	// A final silent e does not make a syllable, unlike the e of -le
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && n > 1 {
+		n--
+	}
+	return max(n, 1)
+}
@@ -0,0 +1,136 @@
+package readability
+
+import (
+	"math"
+	"testing"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+func TestSyllables(t *testing.T) {
+	testCases := []struct {
+		word      string
+		syllables int
+	}{
+		{"the", 1},
+		{"party", 2},
+		{"agreement", 3},
+		{"terminate", 3},
+		{"table", 2},
+		{"indemnification", 6},
+		{"a", 1},
+		{"2025", 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.word, func(t *testing.T) {
+			if n := Syllables(tc.word); n != tc.syllables {
+				t.Errorf("Expected %d syllables in %q, got %d", tc.syllables, tc.word, n)
+			}
+		})
+	}
+}
+
+func TestSentences(t *testing.T) {
+	testCases := []struct {
+		name      string
+		text      string
+		sentences int
+	}{
+		{"empty", "", 0},
+		{"no punctuation", "The parties agree", 1},
+		{"two", "The parties agree. Payment is due in 30 days.", 2},
+		{"decimal", "The fee is 1.5 percent of the total.", 1},
+		{"abbreviation", "Costs, e.g. travel, are reimbursed! Are they?", 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if n := Sentences(tc.text); n != tc.sentences {
+				t.Errorf("Expected %d sentences, got %d", tc.sentences, n)
+			}
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	m := Analyze("The cat sat on the mat.")
+	if m.Words != 6 || m.Sentences != 1 || m.Syllables != 6 {
+		t.Fatalf("Unexpected counts %+v", m)
+	}
+	// 206.835 - 1.015*6 - 84.6*1
+	if math.Abs(m.ReadingEase-116.145) > 0.001 {
+		t.Errorf("Expected reading ease 116.145, got %f", m.ReadingEase)
+	}
+
+	hard := Analyze("Notwithstanding the foregoing, indemnification obligations shall survive termination.")
+	if hard.ReadingEase >= m.ReadingEase {
+		t.Errorf("Expected legal prose to score lower than %f, got %f", m.ReadingEase, hard.ReadingEase)
+	}
+
+	var combined Metrics
+	combined.Add("The cat sat on the mat.")
+	combined.Add("The cat sat on the mat.")
+	if combined.Words != 12 || combined.Sentences != 2 || combined.ReadingEase != m.ReadingEase {
+		t.Errorf("Expected combined metrics to double the counts, got %+v", combined)
+	}
+
+	if empty := Analyze("  "); empty.Words != 0 || empty.ReadingEase != 0 {
+		t.Errorf("Expected no metrics for empty text, got %+v", empty)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	got := Split("The fee is 1.5 percent. Costs, e.g. travel, are paid! Is that clear? Yes")
+	want := []string{"The fee is 1.5 percent.", "Costs, e.g. travel, are paid!", "Is that clear?", "Yes"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected sentence %q, got %q", want[i], got[i])
+		}
+	}
+}
+
+func TestGradeLevel(t *testing.T) {
+	m := Analyze("The cat sat on the mat. The dog ran to the big red door and sat down.")
+	if m.Sentences != 2 || m.LongestSentence != 11 || m.AverageSentenceLength != 8.5 {
+		t.Errorf("Unexpected sentence metrics %+v", m)
+	}
+	// 0.39*8.5 + 11.8*17/17 - 15.59
+	if math.Abs(m.GradeLevel-(-0.475)) > 0.001 {
+		t.Errorf("Expected grade level -0.475, got %f", m.GradeLevel)
+	}
+}
+
+func TestSections(t *testing.T) {
+	doc, err := nld.Parse([]byte(`{"metadata": {"type": "contract"}, "content": {"sections": [
+  {"id": "a", "title": "A", "content": "One sentence here.", "sections": [
+    {"id": "b", "title": "B", "content": [{"type": "list", "items": ["First item", "Second item"]},
+      {"type": "table", "rows": [["not", "prose"]]}]}]},
+  {"id": "c", "title": "C", "content": ""}]}}`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	sections := Sections(doc)
+	want := []struct {
+		path  string
+		depth int
+		words int
+	}{
+		{"/content/sections/0", 0, 3},
+		{"/content/sections/0/sections/0", 1, 4},
+		{"/content/sections/1", 0, 0},
+	}
+	if len(sections) != len(want) {
+		t.Fatalf("Expected %d sections, got %d", len(want), len(sections))
+	}
+	for i, w := range want {
+		s := sections[i]
+		if s.Path != w.path || s.Depth != w.depth || s.Words != w.words {
+			t.Errorf("Expected %+v, got %s at depth %d with %d words", w, s.Path, s.Depth, s.Words)
+		}
+	}
+}
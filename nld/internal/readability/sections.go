@@ -0,0 +1,54 @@
+package readability
+
+import (
+	"fmt"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Section is the analysis of the prose of one section, not counting its
+// subsections
+type Section struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Path is the JSON pointer of the section
+	Path string `json:"path"`
+	// Depth is 0 for top-level sections
+	Depth int `json:"depth"`
+	Metrics
+}
+
+// Sections analyzes every section of a document, including nested
+// subsections, in document order
+func Sections(doc *nld.Document) []Section {
+	var list []Section
+	var walk func(sections []nld.Section, path string, depth int)
+	walk = func(sections []nld.Section, path string, depth int) {
+		for i, s := range sections {
+			sp := fmt.Sprintf("%s/%d", path, i)
+			section := Section{ID: s.ID, Title: s.Title, Path: sp, Depth: depth}
+			for _, text := range Prose(s) {
+				section.Add(text)
+			}
+			list = append(list, section)
+			walk(s.Sections, sp+"/sections", depth+1)
+		}
+	}
+	walk(doc.Structure.Sections, "/"+doc.BodyKey()+"/sections", 0)
+	return list
+}
+
+// Prose returns the prose of a section: plain content, paragraphs and list
+// items. Table cells are data rather than prose and are left out.
+func Prose(s nld.Section) []string {
+	var texts []string
+	for _, b := range s.Body() {
+		switch b.Type {
+		case nld.BlockParagraph:
+			texts = append(texts, b.Text)
+		case nld.BlockList:
+			texts = append(texts, b.Items...)
+		}
+	}
+	return texts
+}
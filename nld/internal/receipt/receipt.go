@@ -0,0 +1,202 @@
+package receipt
+
+import (
+	"fmt"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Column keys that identify the line item columns of a table
+const (
+	KeyQuantity  = "quantity"
+	KeyUnitPrice = "unitPrice"
+	KeyAmount    = "amount"
+)
+
+// Problem is a computation that does not add up
+type Problem struct {
+	// Path is the JSON pointer of the offending value
+	Path    string
+	Message string
+}
+
+// Check verifies that the line items of a receipt multiply out and that
+// they add up to the subtotal, taxes and total, rounding computed amounts
+// half away from zero to the precision of the currency. currency overrides
+// the currency of the receipt totals when set.
+func Check(doc *nld.Document, currency string) ([]Problem, error) {
+	totals := doc.Structure.Totals
+	if currency == "" && totals != nil {
+		currency = totals.Currency
+		if currency == "" {
+			currency = totals.Subtotal.Currency
+		}
+	}
+	precision := nld.DefaultCurrencyPrecision
+	if currency != "" {
+		p, err := nld.CurrencyPrecision(currency)
+		if err != nil {
+			return nil, err
+		}
+		precision = p
+	}
+
+	c := &checker{currency: currency, precision: precision, sum: nld.Money{Amount: "0"}}
+	body := "/" + doc.BodyKey()
+	c.sections(doc.Structure.Sections, body+"/sections")
+
+	if totals == nil {
+		if c.items > 0 {
+			c.report(body+"/totals", "receipt has line items but no totals")
+		}
+		return c.problems, nil
+	}
+	c.totals(totals, body+"/totals")
+	return c.problems, nil
+}
+
+// checker accumulates line item amounts and problems
+type checker struct {
+	currency  string
+	precision int
+	// sum is the total of all line item amounts
+	sum      nld.Money
+	items    int
+	problems []Problem
+}
+
+func (c *checker) report(path, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// sections checks the line item tables of sections and their subsections
+func (c *checker) sections(sections []nld.Section, path string) {
+	for i, s := range sections {
+		sectionPath := fmt.Sprintf("%s/%d", path, i)
+		for j, b := range s.Blocks {
+			if b.Type == nld.BlockTable {
+				c.table(b, fmt.Sprintf("%s/content/%d", sectionPath, j))
+			}
+		}
+		c.sections(s.Sections, sectionPath+"/sections")
+	}
+}
+
+// table checks a line item table. Tables without an amount column are
+// ignored.
+func (c *checker) table(b nld.Block, path string) {
+	qty, price, amount := -1, -1, -1
+	for i, col := range b.Columns {
+		switch col.Key {
+		case KeyQuantity:
+			qty = i
+		case KeyUnitPrice:
+			price = i
+		case KeyAmount:
+			amount = i
+		}
+	}
+	if amount < 0 {
+		return
+	}
+
+	for r, row := range b.Rows {
+		rowPath := fmt.Sprintf("%s/rows/%d", path, r)
+		if amount >= len(row) {
+			continue
+		}
+		amountPath := fmt.Sprintf("%s/%d", rowPath, amount)
+		got, ok := c.amount(nld.Money{Amount: row[amount]}, amountPath)
+		if !ok {
+			continue
+		}
+		c.sum, _ = c.sum.Add(got)
+		c.items++
+
+		if qty < 0 || price < 0 || qty >= len(row) || price >= len(row) {
+			continue
+		}
+		// Malformed numbers are reported by table validation
+		want, err := nld.Money{Amount: row[price], Currency: c.currency}.Mul(row[qty])
+		if err != nil {
+			continue
+		}
+		if !c.matches(got, want) {
+			rounded, _ := want.Round(c.precision)
+			c.report(amountPath, "line amount %s does not match %s × %s = %s", row[amount], row[qty], row[price], rounded.Amount)
+		}
+	}
+}
+
+// totals checks the subtotal, taxes and total against the line items
+func (c *checker) totals(t *nld.Totals, path string) {
+	subtotal, ok := c.amount(t.Subtotal, path+"/subtotal")
+	if ok && c.items > 0 {
+		if cmp, _ := subtotal.Cmp(c.sum); cmp != 0 {
+			c.report(path+"/subtotal", "subtotal %s does not match the sum of line items %s", t.Subtotal.Amount, c.sum.Amount)
+		}
+	}
+
+	total := subtotal
+	for i, tax := range t.Taxes {
+		taxPath := fmt.Sprintf("%s/taxes/%d", path, i)
+		amount, okAmount := c.amount(tax.Amount, taxPath+"/amount")
+		if !okAmount {
+			ok = false
+			continue
+		}
+		if ok {
+			total, _ = total.Add(amount)
+		}
+
+		if tax.Rate == "" || !ok {
+			continue
+		}
+		want, err := subtotal.Percent(tax.Rate)
+		if err != nil {
+			c.report(taxPath+"/rate", "tax rate %q is not a number", tax.Rate)
+			continue
+		}
+		if !c.matches(amount, want) {
+			rounded, _ := want.Round(c.precision)
+			c.report(taxPath+"/amount", "%s amount %s does not match %s%% of %s = %s",
+				tax.Name, tax.Amount.Amount, tax.Rate, t.Subtotal.Amount, rounded.Amount)
+		}
+	}
+
+	got, okTotal := c.amount(t.Total, path+"/total")
+	if ok && okTotal {
+		if cmp, _ := got.Cmp(total); cmp != 0 {
+			c.report(path+"/total", "total %s does not match subtotal plus taxes %s", t.Total.Amount, total.Amount)
+		}
+	}
+}
+
+// amount validates an amount of money in the receipt currency, reporting
+// malformed values, other currencies and values more precise than the
+// currency allows
+func (c *checker) amount(m nld.Money, path string) (nld.Money, bool) {
+	if err := m.Validate(); err != nil {
+		c.report(path, "%q is not a decimal amount", m.Amount)
+		return nld.Money{}, false
+	}
+	if m.Currency != "" && c.currency != "" && m.Currency != c.currency {
+		c.report(path, "amount %s is not in the receipt currency %s", m, c.currency)
+		return nld.Money{}, false
+	}
+	if m.Places() > c.precision {
+		c.report(path, "amount %s has more than %d decimal places", m.Amount, c.precision)
+	}
+	m.Currency = c.currency
+	return m, true
+}
+
+// matches reports whether got equals want rounded to the currency precision
+func (c *checker) matches(got, want nld.Money) bool {
+	rounded, err := want.Round(c.precision)
+	if err != nil {
+		return false
+	}
+	cmp, err := got.Cmp(rounded)
+	return err == nil && cmp == 0
+}
@@ -0,0 +1,106 @@
+package receipt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+func receipt(t *testing.T, rows, totals string) *nld.Document {
+	t.Helper()
+	doc, err := nld.Parse([]byte(`{
+  "metadata": {"type": "receipt"},
+  "content": {
+    "sections": [{"id": "items", "title": "Items", "content": [{"type": "table",
+      "columns": [{"title": "Item"}, {"title": "Qty", "type": "number", "key": "quantity"},
+                  {"title": "Price", "type": "number", "key": "unitPrice"}, {"title": "Amount", "type": "number", "key": "amount", "total": true}],
+      "rows": ` + rows + `}]}],
+    "totals": ` + totals + `
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	return doc
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rows     string
+		totals   string
+		currency string
+		expect   []string
+	}{
+		{
+			name:   "Consistent",
+			rows:   `[["Widget", "3", "0.333", "1.00"], ["Gadget", "2", "4.50", "9.00"]]`,
+			totals: `{"currency": "USD", "subtotal": "10.00", "taxes": [{"name": "VAT", "rate": "20", "amount": "2.00"}], "total": "12.00"}`,
+		},
+		{
+			name:   "Line Amount",
+			rows:   `[["Widget", "3", "2.50", "7.00"]]`,
+			totals: `{"subtotal": "7.00", "total": "7.00"}`,
+			expect: []string{"/content/sections/0/content/0/rows/0/3: line amount 7.00 does not match 3 × 2.50 = 7.50"},
+		},
+		{
+			name:   "Subtotal And Total",
+			rows:   `[["Widget", "1", "5.00", "5.00"]]`,
+			totals: `{"subtotal": "6.00", "taxes": [{"name": "VAT", "amount": "1.00"}], "total": "6.00"}`,
+			expect: []string{"/content/totals/subtotal: subtotal 6.00 does not match the sum of line items 5.00", "/content/totals/total: total 6.00 does not match subtotal plus taxes 7.00"},
+		},
+		{
+			name:   "Tax Rate",
+			rows:   `[["Widget", "1", "9.99", "9.99"]]`,
+			totals: `{"subtotal": "9.99", "taxes": [{"name": "VAT", "rate": "20", "amount": "1.90"}], "total": "11.89"}`,
+			expect: []string{"/content/totals/taxes/0/amount: VAT amount 1.90 does not match 20% of 9.99 = 2.00"},
+		},
+		{
+			name:   "Yen Precision",
+			rows:   `[["Widget", "1", "500", "500.5"]]`,
+			totals: `{"currency": "JPY", "subtotal": "500.5", "total": "500.5"}`,
+			expect: []string{"rows/0/3: amount 500.5 has more than 0 decimal places", "rows/0/3: line amount 500.5 does not match 1 × 500 = 500", "subtotal: amount 500.5 has more than 0 decimal places", "total: amount 500.5 has more than 0 decimal places"},
+		},
+		{
+			name:     "Currency Flag Overrides",
+			rows:     `[["Widget", "1", "1.005", "1.005"]]`,
+			totals:   `{"currency": "USD", "subtotal": "1.005", "total": "1.005"}`,
+			currency: "BHD",
+		},
+		{
+			name:   "Malformed Line Amount",
+			rows:   `[["Widget", "1", "5.00", "$5.00"]]`,
+			totals: `{"subtotal": "5.00", "total": "5.00"}`,
+			expect: []string{`/content/sections/0/content/0/rows/0/3: "$5.00" is not a decimal amount`},
+		},
+		{
+			name:   "Money Objects",
+			rows:   `[["Widget", "2", "2.50", "5.00"]]`,
+			totals: `{"subtotal": {"amount": "5.00", "currency": "EUR"}, "taxes": [{"name": "VAT", "rate": "19", "amount": {"amount": "0.95", "currency": "EUR"}}], "total": {"amount": "5.95", "currency": "EUR"}}`,
+		},
+		{
+			name:   "Mixed Currencies",
+			rows:   `[["Widget", "2", "2.50", "5.00"]]`,
+			totals: `{"currency": "EUR", "subtotal": "5.00", "total": {"amount": "5.00", "currency": "USD"}}`,
+			expect: []string{"/content/totals/total: amount 5.00 USD is not in the receipt currency EUR"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			problems, err := Check(receipt(t, tc.rows, tc.totals), tc.currency)
+			if err != nil {
+				t.Fatalf("Check failed: %v", err)
+			}
+			if len(problems) != len(tc.expect) {
+				t.Fatalf("Expected %d problems, got %v", len(tc.expect), problems)
+			}
+			for i, p := range problems {
+				if got := p.Path + ": " + p.Message; !strings.Contains(got, tc.expect[i]) {
+					t.Errorf("Expected %q, got %q", tc.expect[i], got)
+				}
+			}
+		})
+	}
+}
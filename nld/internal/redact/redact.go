@@ -0,0 +1,412 @@
+package redact
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/jsonpath"
+)
+
+// Mode controls how redacted values are treated
+type Mode string
+
+const (
+	// ModeMask replaces redacted values with a placeholder
+	ModeMask Mode = "mask"
+	// ModeRemove deletes redacted values from the document
+	ModeRemove Mode = "remove"
+
+	// DefaultMask is the placeholder written in mask mode, and the only one
+	// that verification accepts
+	DefaultMask = digest.Mask
+
+	// AttestationType marks attestations recorded by redaction
+	AttestationType = digest.RedactionType
+)
+
+// Options describes what to redact and how
+type Options struct {
+	Sections []string
+	Paths    []string
+	Mode     Mode
+	Mask     string
+	Redactor string
+	Reason   string
+	Now      time.Time
+}
+
+// Report summarizes a redaction
+type Report struct {
+	Sections []string
+	Paths    []string
+	Changed  int
+}
+
+// Apply redacts doc in place.
+//
+// Before anything is changed a digest record is stored under
+// verification.digest (unless already present), so signatures over its
+// Merkle root remain verifiable for the parts that were not redacted.
+// Sections whose content no longer matches their recorded hash are flagged
+// with "redacted": true, and an attestation describing the redaction is
+// appended to verification.attestations. The attestation lists every
+// change with the hash of the value it masked or removed, which is what
+// lets verification tell the redaction from other changes.
+func Apply(doc document.Document, opts Options) (*Report, error) {
+	if len(opts.Sections) == 0 && len(opts.Paths) == 0 {
+		return nil, fmt.Errorf("nothing to redact; specify at least one section or path")
+	}
+	if opts.Mode == "" {
+		opts.Mode = ModeMask
+	}
+	if opts.Mode != ModeMask && opts.Mode != ModeRemove {
+		return nil, fmt.Errorf("invalid redaction mode: %s (expected mask or remove)", opts.Mode)
+	}
+	if opts.Mask == "" {
+		opts.Mask = DefaultMask
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	paths := make([]*jsonpath.Path, 0, len(opts.Paths))
+	for _, expr := range opts.Paths {
+		p, err := jsonpath.Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+		if len(p.Steps) > 0 && p.Steps[0].Key == "verification" {
+			return nil, fmt.Errorf("cannot redact verification data: %s", expr)
+		}
+		paths = append(paths, p)
+	}
+
+	recorded, err := recordDigest(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	r := &recorder{doc: doc, earlier: digest.Changes(doc)}
+
+	if err := r.redactSections(opts, report); err != nil {
+		return nil, err
+	}
+
+	for _, p := range paths {
+		matches := p.Find(map[string]interface{}(doc))
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("path matched nothing: %s", p.Raw)
+		}
+		if opts.Mode == ModeRemove {
+			// Later matches go first, so that removing an item does not
+			// move the ones still to be removed
+			for i := len(matches) - 1; i >= 0; i-- {
+				if err := r.remove(matches[i].Pointer, ""); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			for _, m := range matches {
+				if err := r.mask(m.Pointer, m.Value, opts.Mask); err != nil {
+					return nil, err
+				}
+			}
+		}
+		report.Paths = append(report.Paths, p.Raw)
+		report.Changed += len(matches)
+	}
+
+	if err := r.flagChangedSections(recorded); err != nil {
+		return nil, err
+	}
+
+	addAttestation(doc, opts, report, r.changes)
+	return report, nil
+}
+
+// recordDigest stores the document digest record unless one is already
+// present, and returns the recorded section hashes keyed by section ID
+func recordDigest(doc document.Document) (map[string]string, error) {
+	rec, err := digest.Load(doc)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		if rec, err = digest.Compute(doc); err != nil {
+			return nil, err
+		}
+		if err := digest.Store(doc, rec); err != nil {
+			return nil, err
+		}
+	}
+
+	recorded := make(map[string]string, len(rec.Sections))
+	for _, h := range rec.Sections {
+		recorded[h.ID] = h.Hash
+	}
+	return recorded, nil
+}
+
+// recorder makes the changes of a redaction, recording each one
+type recorder struct {
+	doc document.Document
+	// earlier are the changes of earlier redactions of the document
+	earlier []digest.Change
+	changes []digest.Change
+}
+
+// original returns the hash of the value at pointer as it was signed
+func (r *recorder) original(pointer string) (string, error) {
+	changes := append(append([]digest.Change{}, r.earlier...), r.changes...)
+	hash, err := digest.OriginalHash(r.doc, changes, pointer)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", pointer, err)
+	}
+	return hash, nil
+}
+
+// mask replaces the value at pointer with mask. Values masked already are
+// left alone.
+func (r *recorder) mask(pointer string, value interface{}, mask string) error {
+	if value == mask {
+		return nil
+	}
+	hash, err := r.original(pointer)
+	if err != nil {
+		return err
+	}
+	r.changes = append(r.changes, digest.Change{Op: digest.ChangeMask, Pointer: pointer, Hash: hash})
+	return r.doc.Set(pointer, mask)
+}
+
+// remove removes the value at pointer, recording id for sections
+func (r *recorder) remove(pointer, id string) error {
+	hash, err := r.original(pointer)
+	if err != nil {
+		return err
+	}
+	r.changes = append(r.changes, digest.Change{Op: digest.ChangeRemove, Pointer: pointer, Hash: hash, ID: id})
+
+	parent, token := path.Split(pointer)
+	token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	var node interface{} = map[string]interface{}(r.doc)
+	if parent != "/" {
+		if node = find(r.doc, strings.TrimSuffix(parent, "/")); node == nil {
+			return fmt.Errorf("cannot remove %s", pointer)
+		}
+	}
+	switch n := node.(type) {
+	case map[string]interface{}:
+		delete(n, token)
+	case []interface{}:
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= len(n) {
+			return fmt.Errorf("cannot remove %s", pointer)
+		}
+		return r.doc.Set(strings.TrimSuffix(parent, "/"), append(n[:i:i], n[i+1:]...))
+	}
+	return nil
+}
+
+// add sets key in the object at pointer to value, which is Mask or, for the
+// redacted flag, true
+func (r *recorder) add(pointer string, obj map[string]interface{}, key string, value interface{}) {
+	r.changes = append(r.changes, digest.Change{Op: digest.ChangeAdd, Pointer: pointer + "/" + key})
+	obj[key] = value
+}
+
+// find returns the value at pointer in doc, or nil
+func find(doc document.Document, pointer string) interface{} {
+	var node interface{} = map[string]interface{}(doc)
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node = n[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil
+			}
+			node = n[i]
+		default:
+			return nil
+		}
+	}
+	return node
+}
+
+// redactSections masks or removes the requested sections
+func (r *recorder) redactSections(opts Options, report *Report) error {
+	if len(opts.Sections) == 0 {
+		return nil
+	}
+
+	wanted := make(map[string]bool, len(opts.Sections))
+	for _, id := range opts.Sections {
+		wanted[id] = true
+	}
+
+	raw, _ := r.doc.Body(false)["sections"].([]interface{})
+	prefix := "/" + r.doc.BodyKey() + "/sections/"
+	// Later sections go first, so that removing a section does not move the
+	// ones still to be removed
+	var redacted []string
+	for i := len(raw) - 1; i >= 0; i-- {
+		section, ok := raw[i].(map[string]interface{})
+		id := document.String(section, "id")
+		if !ok || !wanted[id] {
+			continue
+		}
+		delete(wanted, id)
+		redacted = append([]string{id}, redacted...)
+		report.Changed++
+
+		pointer := prefix + strconv.Itoa(i)
+		if opts.Mode == ModeRemove {
+			if err := r.remove(pointer, id); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := r.maskSection(pointer, section, opts.Mask); err != nil {
+			return err
+		}
+	}
+
+	if len(wanted) > 0 {
+		missing := make([]string, 0, len(wanted))
+		for id := range wanted {
+			missing = append(missing, id)
+		}
+		sort.Strings(missing)
+		return fmt.Errorf("section not found: %s", strings.Join(missing, ", "))
+	}
+	report.Sections = redacted
+	return nil
+}
+
+// maskSection keeps only the ID and title of the section at pointer,
+// masking its content and flagging it as redacted
+func (r *recorder) maskSection(pointer string, section map[string]interface{}, mask string) error {
+	keys := make([]string, 0, len(section))
+	for key := range section {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch key {
+		case "id", "title":
+		case "content":
+			if err := r.mask(pointer+"/content", section[key], mask); err != nil {
+				return err
+			}
+		case digest.RedactedKey:
+			if section[key] == true {
+				continue
+			}
+			fallthrough
+		default:
+			if err := r.remove(pointer+"/"+key, ""); err != nil {
+				return err
+			}
+		}
+	}
+	if _, ok := section["content"]; !ok {
+		r.add(pointer, section, "content", mask)
+	}
+	if section[digest.RedactedKey] != true {
+		r.add(pointer, section, digest.RedactedKey, true)
+	}
+	return nil
+}
+
+// flagChangedSections marks sections whose hash differs from the recorded one
+func (r *recorder) flagChangedSections(recorded map[string]string) error {
+	prefix := "/" + r.doc.BodyKey() + "/sections/"
+	raw, _ := r.doc.Body(false)["sections"].([]interface{})
+	for i, item := range raw {
+		section, ok := item.(map[string]interface{})
+		if !ok || section[digest.RedactedKey] == true {
+			continue
+		}
+		id := document.String(section, "id")
+		hash, err := digest.TreeHash(section)
+		if err != nil {
+			return err
+		}
+		if want, ok := recorded[id]; !ok || want == hash {
+			continue
+		}
+		pointer := prefix + strconv.Itoa(i)
+		if _, ok := section[digest.RedactedKey]; ok {
+			if err := r.remove(pointer+"/"+digest.RedactedKey, ""); err != nil {
+				return err
+			}
+		}
+		r.add(pointer, section, digest.RedactedKey, true)
+	}
+	return nil
+}
+
+// addAttestation records the redaction in verification.attestations
+func addAttestation(doc document.Document, opts Options, report *Report, changes []digest.Change) {
+	redactor := opts.Redactor
+	if redactor == "" {
+		redactor = "nld"
+	}
+
+	var targets []string
+	for _, id := range report.Sections {
+		targets = append(targets, "section "+id)
+	}
+	targets = append(targets, report.Paths...)
+
+	statement := fmt.Sprintf("Redacted (%s) %s", opts.Mode, strings.Join(targets, ", "))
+	if opts.Reason != "" {
+		statement += ": " + opts.Reason
+	}
+
+	attestation := map[string]interface{}{
+		"attesterId": redactor,
+		"date":       opts.Now.UTC().Format(time.RFC3339),
+		"statement":  statement,
+		"type":       AttestationType,
+		"mode":       string(opts.Mode),
+		"targets":    toInterfaces(targets),
+	}
+	if opts.Reason != "" {
+		attestation["reason"] = opts.Reason
+	}
+	if len(changes) > 0 {
+		list := make([]interface{}, len(changes))
+		for i, c := range changes {
+			change := map[string]interface{}{"op": c.Op, "pointer": c.Pointer}
+			if c.Hash != "" {
+				change["hash"] = c.Hash
+			}
+			if c.ID != "" {
+				change["id"] = c.ID
+			}
+			list[i] = change
+		}
+		attestation["changes"] = list
+	}
+
+	document.Append(doc.Verification(true), "attestations", attestation)
+}
+
+func toInterfaces(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
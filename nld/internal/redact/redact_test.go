@@ -0,0 +1,195 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const testDocument = `{
+  "metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Test", "author": "Legal"},
+  "content": {
+    "sections": [
+      {"id": "parties", "title": "Parties", "content": "Acme and XYZ"},
+      {"id": "fees", "title": "Fees", "content": "USD 10,000"},
+      {"id": "terms", "title": "Terms", "content": "Standard terms"}
+    ]
+  }
+}`
+
+func TestApply(t *testing.T) {
+	testCases := []struct {
+		name           string
+		opts           Options
+		expectError    bool
+		expectSections int
+		expectFlagged  []string
+	}{
+		{
+			name:           "Mask Section",
+			opts:           Options{Sections: []string{"fees"}},
+			expectSections: 3,
+			expectFlagged:  []string{"fees"},
+		},
+		{
+			name:           "Remove Section",
+			opts:           Options{Sections: []string{"fees"}, Mode: ModeRemove},
+			expectSections: 2,
+		},
+		{
+			name:           "Mask Path Inside Section",
+			opts:           Options{Paths: []string{"$.content.sections[0].content"}},
+			expectSections: 3,
+			expectFlagged:  []string{"parties"},
+		},
+		{
+			name:           "Remove Metadata Field",
+			opts:           Options{Paths: []string{"$.metadata.author"}, Mode: ModeRemove},
+			expectSections: 3,
+		},
+		{
+			name:        "Unknown Section",
+			opts:        Options{Sections: []string{"missing"}},
+			expectError: true,
+		},
+		{
+			name:        "Unmatched Path",
+			opts:        Options{Paths: []string{"$.metadata.missing"}},
+			expectError: true,
+		},
+		{
+			name:        "Verification Path",
+			opts:        Options{Paths: []string{"$.verification.signatures"}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := document.Parse([]byte(testDocument))
+			if err != nil {
+				t.Fatalf("Failed to parse document: %v", err)
+			}
+			original, _ := digest.SectionHashes(doc)
+
+			tc.opts.Now = time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+			_, err = Apply(doc, tc.opts)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected success, got error: %v", err)
+			}
+
+			sections := doc.Sections()
+			if len(sections) != tc.expectSections {
+				t.Fatalf("Expected %d sections, got %d", tc.expectSections, len(sections))
+			}
+
+			flagged := map[string]bool{}
+			for _, id := range tc.expectFlagged {
+				flagged[id] = true
+			}
+
+			// Unredacted sections must still match their recorded hashes
+			rec, err := digest.Load(doc)
+			if err != nil || rec == nil {
+				t.Fatalf("Expected digest record, got %v (%v)", rec, err)
+			}
+			if len(rec.Sections) != len(original) {
+				t.Fatalf("Expected %d recorded hashes, got %d", len(original), len(rec.Sections))
+			}
+			report, err := digest.Verify(doc, rec)
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if !report.RecordIntact {
+				t.Errorf("Expected digest record to remain intact")
+			}
+			for _, part := range report.Changed() {
+				if part.Status != digest.StatusRedacted {
+					t.Errorf("Expected redaction to be reported, got %s %s %s", part.Status, part.Part, part.ID)
+				}
+			}
+			for _, section := range sections {
+				id := document.String(section, "id")
+				isFlagged := section["redacted"] == true
+				if isFlagged != flagged[id] {
+					t.Errorf("Section %s: expected redacted=%v, got %v", id, flagged[id], isFlagged)
+				}
+				if isFlagged {
+					continue
+				}
+				hash, _ := digest.TreeHash(section)
+				for _, h := range original {
+					if h.ID == id && h.Hash != hash {
+						t.Errorf("Section %s no longer matches its recorded hash", id)
+					}
+				}
+			}
+
+			attestations := doc.Verification(false)["attestations"].([]interface{})
+			attestation := attestations[0].(map[string]interface{})
+			if attestation["type"] != AttestationType {
+				t.Errorf("Expected redaction attestation, got %v", attestation["type"])
+			}
+			if !strings.HasPrefix(attestation["statement"].(string), "Redacted") {
+				t.Errorf("Unexpected statement: %v", attestation["statement"])
+			}
+		})
+	}
+}
+
+func TestApplyRepeated(t *testing.T) {
+	doc, err := document.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	rec, err := digest.Compute(doc)
+	if err != nil {
+		t.Fatalf("Compute failed: %v", err)
+	}
+
+	// Each redaction builds on the changes of the ones before it
+	steps := []Options{
+		{Paths: []string{"$.content.sections[1].content"}},
+		{Sections: []string{"fees", "terms"}},
+		{Sections: []string{"parties"}, Mode: ModeRemove},
+		{Paths: []string{"$.metadata.author"}},
+	}
+	for _, opts := range steps {
+		if _, err := Apply(doc, opts); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+
+	report, err := digest.Verify(doc, rec)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	for _, part := range report.Changed() {
+		if part.Status != digest.StatusRedacted {
+			t.Errorf("Expected redaction to be reported, got %s %s %s", part.Status, part.Part, part.ID)
+		}
+	}
+	if len(report.Changed()) != 4 {
+		t.Errorf("Expected 4 redacted parts, got %+v", report.Changed())
+	}
+
+	// Changing a redacted section afterwards is still detected
+	doc.Sections()[0]["title"] = "Other"
+	if report, err = digest.Verify(doc, rec); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	for _, part := range report.Parts {
+		if part.ID == "fees" && part.Status != digest.StatusModified {
+			t.Errorf("Expected fees to be modified, got %s", part.Status)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package redline manages the edits proposed to a document, kept in its
+// top-level redline instead of being applied to its sections. Proposing a
+// change leaves the root hash as it is; accepting one applies it to the
+// section content, and rejecting one discards it.
+package redline
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Key is the key of the proposed changes in a document
+const Key = "redline"
+
+// Load returns the proposed changes of a document
+func Load(doc document.Document) ([]nld.Change, error) {
+	if doc[Key] == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(doc[Key])
+	if err != nil {
+		return nil, err
+	}
+	var changes []nld.Change
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, fmt.Errorf("invalid redline: %w", err)
+	}
+	return changes, nil
+}
+
+// save replaces the proposed changes of a document, removing the redline
+// once none are left
+func save(doc document.Document, changes []nld.Change) error {
+	if len(changes) == 0 {
+		delete(doc, Key)
+		return nil
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	doc[Key] = raw
+	return nil
+}
+
+// content returns the plain text content of a section as characters
+func content(doc document.Document, id string) ([]rune, error) {
+	section := doc.FindSection(id)
+	if section == nil {
+		return nil, fmt.Errorf("section not found: %s", id)
+	}
+	text, ok := section["content"].(string)
+	if !ok {
+		return nil, fmt.Errorf("section %s has no plain text content to change", id)
+	}
+	return []rune(text), nil
+}
+
+// Propose records a proposed change, giving it the next free ID and the
+// current date unless it has one. A change with Deleted text and an empty
+// range replaces the first occurrence of that text in the section; other
+// changes get the text in their range as Deleted.
+func Propose(doc document.Document, c nld.Change) (nld.Change, error) {
+	text, err := content(doc, c.Section)
+	if err != nil {
+		return c, err
+	}
+	if c.Deleted != "" && c.Range.Start == c.Range.End {
+		i := strings.Index(string(text), c.Deleted)
+		if i == -1 {
+			return c, fmt.Errorf("text not found in section %s: %q", c.Section, c.Deleted)
+		}
+		start := len([]rune(string(text)[:i]))
+		c.Range = nld.TextRange{Start: start, End: start + len([]rune(c.Deleted))}
+	}
+	if c.Range.Start < 0 || c.Range.End < c.Range.Start || c.Range.End > len(text) {
+		return c, fmt.Errorf("invalid range %d:%d of section %s, which has %d characters", c.Range.Start, c.Range.End, c.Section, len(text))
+	}
+	c.Deleted = string(text[c.Range.Start:c.Range.End])
+	if c.Deleted == c.Inserted {
+		return c, fmt.Errorf("change to section %s changes nothing", c.Section)
+	}
+
+	changes, err := Load(doc)
+	if err != nil {
+		return c, err
+	}
+	c.ID = nextID(changes)
+	if c.Date == "" {
+		c.Date = time.Now().UTC().Format(time.RFC3339)
+	}
+	return c, save(doc, append(changes, c))
+}
+
+// nextID returns the ID following the highest numbered one, "c1" for the
+// first change
+func nextID(changes []nld.Change) string {
+	highest := 0
+	for _, c := range changes {
+		if n, err := strconv.Atoi(strings.TrimPrefix(c.ID, "c")); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return fmt.Sprintf("c%d", highest+1)
+}
+
+// find returns the index of the change with the given ID
+func find(changes []nld.Change, id string) (int, error) {
+	for i, c := range changes {
+		if c.ID == id {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("change not found: %s", id)
+}
+
+// Accept applies the change with the given ID to its section and removes
+// it from the redline. The pending changes after it in the section move
+// with the text; a change whose text was edited since it was proposed, or
+// that overlaps another pending change, is not applied.
+func Accept(doc document.Document, id string) error {
+	changes, err := Load(doc)
+	if err != nil {
+		return err
+	}
+	i, err := find(changes, id)
+	if err != nil {
+		return err
+	}
+	c := changes[i]
+	if Stale(doc, c) {
+		return fmt.Errorf("change %s is outdated: the text of section %s has changed since it was proposed", id, c.Section)
+	}
+	for _, other := range changes {
+		if other.ID != id && other.Section == c.Section && overlaps(c.Range, other.Range) {
+			return fmt.Errorf("change %s overlaps change %s; reject one of them first", id, other.ID)
+		}
+	}
+
+	text, _ := content(doc, c.Section)
+	updated := string(text[:c.Range.Start]) + c.Inserted + string(text[c.Range.End:])
+	doc.FindSection(c.Section)["content"] = updated
+
+	delta := len([]rune(c.Inserted)) - (c.Range.End - c.Range.Start)
+	rest := append(changes[:i:i], changes[i+1:]...)
+	for j := range rest {
+		if rest[j].Section == c.Section && rest[j].Range.Start >= c.Range.End {
+			rest[j].Range.Start += delta
+			rest[j].Range.End += delta
+		}
+	}
+	return save(doc, rest)
+}
+
+// Reject removes the change with the given ID from the redline, leaving
+// its section as it is
+func Reject(doc document.Document, id string) error {
+	changes, err := Load(doc)
+	if err != nil {
+		return err
+	}
+	i, err := find(changes, id)
+	if err != nil {
+		return err
+	}
+	return save(doc, append(changes[:i:i], changes[i+1:]...))
+}
+
+// overlaps reports whether two changes affect the same text. Insertions
+// overlap the ranges they fall strictly inside of.
+func overlaps(a, b nld.TextRange) bool {
+	switch {
+	case a.Start == a.End && b.Start == b.End:
+		return false
+	case a.Start == a.End:
+		return b.Start < a.Start && a.Start < b.End
+	case b.Start == b.End:
+		return a.Start < b.Start && b.Start < a.End
+	}
+	return a.Start < b.End && b.Start < a.End
+}
+
+// Stale reports whether the text a change was proposed on has changed
+// since, as when its section was edited directly
+func Stale(doc document.Document, c nld.Change) bool {
+	text, err := content(doc, c.Section)
+	if err != nil {
+		return true
+	}
+	if c.Range.Start < 0 || c.Range.End > len(text) || c.Range.Start > c.Range.End {
+		return true
+	}
+	return string(text[c.Range.Start:c.Range.End]) != c.Deleted
+}
@@ -0,0 +1,121 @@
+package redline
+
+import (
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const testDocument = `{
+  "metadata": {"type": "contract", "version": "1.0.0", "created": "2025-06-27T12:00:00Z"},
+  "content": {"sections": [
+    {"id": "terms", "title": "Terms", "content": "Payment is due within 30 days of the invoice date."},
+    {"id": "fees", "title": "Fees", "content": [{"type": "paragraph", "text": "EUR 100"}]}
+  ]}
+}`
+
+func parse(t *testing.T) document.Document {
+	t.Helper()
+	doc, err := document.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	return doc
+}
+
+func terms(doc document.Document) string {
+	return document.String(doc.FindSection("terms"), "content")
+}
+
+func TestPropose(t *testing.T) {
+	testCases := []struct {
+		name    string
+		c       nld.Change
+		deleted string
+		ok      bool
+	}{
+		{"Replace", nld.Change{Section: "terms", Deleted: "30 days", Inserted: "60 days"}, "30 days", true},
+		{"Delete Range", nld.Change{Section: "terms", Range: nld.TextRange{Start: 32, End: 36}}, " the", true},
+		{"Insert", nld.Change{Section: "terms", Range: nld.TextRange{Start: 0, End: 0}, Inserted: "Unless agreed otherwise, "}, "", true},
+		{"Missing Text", nld.Change{Section: "terms", Deleted: "90 days", Inserted: "60 days"}, "", false},
+		{"Range Past End", nld.Change{Section: "terms", Range: nld.TextRange{Start: 40, End: 400}}, "", false},
+		{"Nothing", nld.Change{Section: "terms", Range: nld.TextRange{Start: 3, End: 3}}, "", false},
+		{"Same Text", nld.Change{Section: "terms", Deleted: "30", Inserted: "30"}, "", false},
+		{"Blocks", nld.Change{Section: "fees", Deleted: "EUR", Inserted: "USD"}, "", false},
+		{"Unknown Section", nld.Change{Section: "warranty", Inserted: "x"}, "", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := parse(t)
+			before, _ := digest.Compute(doc)
+			c, err := Propose(doc, tc.c)
+			if (err == nil) != tc.ok {
+				t.Fatalf("Propose error = %v", err)
+			}
+			changes, _ := Load(doc)
+			if !tc.ok {
+				if len(changes) != 0 {
+					t.Errorf("failed Propose recorded %+v", changes)
+				}
+				return
+			}
+			if c.ID != "c1" || c.Date == "" || c.Deleted != tc.deleted || len(changes) != 1 {
+				t.Errorf("Propose = %+v, changes %+v", c, changes)
+			}
+			if after, _ := digest.Compute(doc); after.Root != before.Root {
+				t.Error("proposing a change changed the root hash")
+			}
+		})
+	}
+}
+
+func TestAccept(t *testing.T) {
+	doc := parse(t)
+	for _, c := range []nld.Change{
+		{Section: "terms", Range: nld.TextRange{Start: 0, End: 0}, Inserted: "Unless agreed otherwise, p"},
+		{Section: "terms", Range: nld.TextRange{Start: 0, End: 1}},
+		{Section: "terms", Deleted: "30 days", Inserted: "60 days"},
+		{Section: "terms", Deleted: "invoice date", Inserted: "date of the invoice"},
+		{Section: "terms", Deleted: "days of the", Inserted: "days from the"},
+	} {
+		if _, err := Propose(doc, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Accept(doc, "c3"); err == nil {
+		t.Error("accepting a change overlapping another succeeded")
+	}
+	for _, id := range []string{"c5", "c1", "c2", "c4", "c3"} {
+		var err error
+		if id == "c5" {
+			err = Reject(doc, id)
+		} else {
+			err = Accept(doc, id)
+		}
+		if err != nil {
+			t.Fatalf("change %s failed: %v", id, err)
+		}
+	}
+	if got, expect := terms(doc), "Unless agreed otherwise, payment is due within 60 days of the date of the invoice."; got != expect {
+		t.Errorf("content = %q, expected %q", got, expect)
+	}
+	if _, ok := doc[Key]; ok {
+		t.Error("redline left in the document once every change was decided")
+	}
+	if err := Accept(doc, "c1"); err == nil {
+		t.Error("accepting a decided change succeeded")
+	}
+
+	// Changes to text edited since are not applied
+	c, _ := Propose(doc, nld.Change{Section: "terms", Deleted: "60 days", Inserted: "90 days"})
+	doc.FindSection("terms")["content"] = "Payment is due on receipt."
+	if !Stale(doc, c) {
+		t.Error("change to edited text is not stale")
+	}
+	if err := Accept(doc, c.ID); err == nil {
+		t.Error("accepting an outdated change succeeded")
+	}
+}
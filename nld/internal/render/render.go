@@ -0,0 +1,880 @@
+package render
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/colemalphrus/nld/internal/i18n"
+	"github.com/colemalphrus/nld/internal/metrics"
+	"github.com/colemalphrus/nld/internal/tracing"
+	"github.com/colemalphrus/nld/pkg/nld"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Output formats supported by Render
+const (
+	Markdown = "markdown"
+	HTML     = "html"
+	Text     = "text"
+)
+
+// Options controls how a document is rendered
+type Options struct {
+	Format string
+	// Numbering is the style used to number sections. The zero value uses
+	// the customary style for the document's jurisdiction.
+	Numbering nld.NumberingStyle
+	// Unnumbered renders section titles without numbers
+	Unnumbered bool
+	// Lang is the language of headings, labels and dates added by the
+	// renderer. Nil renders English.
+	Lang *i18n.Catalog
+	// Locales selects the locale of section titles and content. With two
+	// locales the sections are rendered side by side.
+	Locales []string
+	// Annotations renders the open annotations of the document as margin
+	// notes beside their sections, highlighting the text they are about.
+	// Only HTML output shows annotations.
+	Annotations bool
+	// Redline renders the changes proposed to the document in its redline,
+	// struck through where text would be deleted and underlined where it
+	// would be inserted. Only HTML output shows proposed changes.
+	Redline bool
+}
+
+// Formats returns the supported output formats
+func Formats() []string {
+	return []string{Markdown, HTML, Text}
+}
+
+// Render writes a human-readable rendering of doc to w
+func Render(w io.Writer, doc *nld.Document, opts Options) error {
+	return RenderContext(context.Background(), w, doc, opts)
+}
+
+// RenderContext is Render traced as a child of the span of ctx
+func RenderContext(ctx context.Context, w io.Writer, doc *nld.Document, opts Options) error {
+	start := time.Now()
+	format := "other"
+	switch opts.Format {
+	case Markdown, "md", "":
+		format = Markdown
+	case HTML, Text:
+		format = opts.Format
+	case "txt":
+		format = Text
+	}
+	_, span := tracing.Span(ctx, "nld.render", attribute.String("nld.render.format", format), attribute.String("nld.document.type", doc.Metadata.Type))
+	err := render(w, doc, opts)
+	tracing.End(span, err)
+	if err != nil {
+		metrics.Renders.Inc(format, metrics.Error)
+	} else {
+		metrics.Renders.Inc(format, metrics.OK)
+	}
+	metrics.RenderDuration.Since(start, format)
+	return err
+}
+
+// render renders doc, for Render to time
+func render(w io.Writer, doc *nld.Document, opts Options) error {
+	tr := opts.Lang
+	if tr == nil {
+		tr = i18n.English()
+	}
+
+	var r renderer
+	switch opts.Format {
+	case Markdown, "md", "":
+		r = &markdownRenderer{doc: doc, tr: tr}
+	case HTML:
+		r = &htmlRenderer{doc: doc, tr: tr, annotate: opts.Annotations, redline: opts.Redline}
+	case Text, "txt":
+		r = &textRenderer{doc: doc, tr: tr}
+	default:
+		return fmt.Errorf("unsupported format %q (supported: %s)", opts.Format, strings.Join(Formats(), ", "))
+	}
+
+	switch len(opts.Locales) {
+	case 0:
+	case 1:
+		doc = doc.Localize(opts.Locales[0])
+	case 2:
+	default:
+		return fmt.Errorf("at most two locales can be rendered side by side, got %d", len(opts.Locales))
+	}
+
+	bw := bufio.NewWriter(w)
+	r.begin(bw, doc)
+	walk(bw, r, doc, doc.Structure.Sections, doc.Outline(opts.Numbering), opts)
+	r.end(bw, doc)
+	return bw.Flush()
+}
+
+// renderer emits one output format
+type renderer interface {
+	begin(w *bufio.Writer, doc *nld.Document)
+	section(w *bufio.Writer, s nld.Section, number string, depth int)
+	block(w *bufio.Writer, b nld.Block, depth int)
+	// parallel renders a section in two locales side by side
+	parallel(w *bufio.Writer, locales []string, left, right nld.Section, number string, depth int)
+	end(w *bufio.Writer, doc *nld.Document)
+}
+
+// bodyRenderer is implemented by renderers that render the content of some
+// sections as a whole rather than block by block
+type bodyRenderer interface {
+	// body renders the content of s, reporting whether it did
+	body(w *bufio.Writer, s nld.Section, depth int) bool
+}
+
+// walk renders sections alongside their outline entries, which mirror the
+// section tree
+func walk(w *bufio.Writer, r renderer, doc *nld.Document, sections []nld.Section, entries []nld.OutlineEntry, opts Options) {
+	for i, s := range sections {
+		entry := entries[i]
+		number := entry.Number
+		if opts.Unnumbered {
+			number = ""
+		}
+		if len(opts.Locales) == 2 {
+			left := s.Localize(opts.Locales[0], doc.Metadata.Language)
+			right := s.Localize(opts.Locales[1], doc.Metadata.Language)
+			r.parallel(w, opts.Locales, left, right, number, entry.Depth)
+		} else {
+			r.section(w, s, number, entry.Depth)
+			if br, ok := r.(bodyRenderer); !ok || !br.body(w, s, entry.Depth) {
+				for _, b := range s.Body() {
+					r.block(w, b, entry.Depth)
+				}
+			}
+		}
+		walk(w, r, doc, s.Sections, entry.Children, opts)
+	}
+}
+
+// pairs returns the blocks of two translations of a section as pairs of
+// plain text lines, the shorter side padded with empty cells
+func pairs(doc *nld.Document, tr *i18n.Catalog, left, right nld.Section) [][2][]string {
+	l, r := left.Body(), right.Body()
+	n := len(l)
+	if len(r) > n {
+		n = len(r)
+	}
+	out := make([][2][]string, n)
+	for i := 0; i < n; i++ {
+		if i < len(l) {
+			out[i][0] = lines(doc, tr, l[i])
+		}
+		if i < len(r) {
+			out[i][1] = lines(doc, tr, r[i])
+		}
+	}
+	return out
+}
+
+// lines returns a block as plain text lines, for side-by-side rendering
+func lines(doc *nld.Document, tr *i18n.Catalog, b nld.Block) []string {
+	switch b.Type {
+	case nld.BlockList:
+		var out []string
+		for i, item := range b.Items {
+			if b.Ordered {
+				out = append(out, fmt.Sprintf("%d. %s", i+1, item))
+			} else {
+				out = append(out, "- "+item)
+			}
+		}
+		return out
+	case nld.BlockTable:
+		t := tableOf(b, tr)
+		out := []string{strings.Join(t.header, " | ")}
+		for _, row := range t.rows {
+			out = append(out, strings.Join(row, " | "))
+		}
+		if t.totals != nil {
+			out = append(out, strings.Join(t.totals, " | "))
+		}
+		return out
+	case nld.BlockDefinitionRef:
+		return []string{fmt.Sprintf("\"%s\" %s %s", b.Term, tr.T("render.means"), definition(doc, b.Term))}
+	default:
+		return []string{b.Text}
+	}
+}
+
+// parallelTitle joins the titles of a section in two locales
+func parallelTitle(left, right nld.Section) string {
+	if left.Title == right.Title {
+		return left.Title
+	}
+	return left.Title + " / " + right.Title
+}
+
+// title returns the document title, falling back to its type
+func title(doc *nld.Document, tr *i18n.Catalog) string {
+	if doc.Metadata.Title != "" {
+		return doc.Metadata.Title
+	}
+	if doc.Metadata.Type == "" {
+		return tr.T("render.untitled")
+	}
+	return strings.ToUpper(doc.Metadata.Type[:1]) + doc.Metadata.Type[1:]
+}
+
+// heading joins a section number and title. Plain top-level numbers get a
+// trailing dot ("1. Introduction"); compound and bracketed numbers do not
+// ("1.1 Scope", "(a) Design").
+func heading(number, title string) string {
+	if number == "" {
+		return title
+	}
+	if strings.ContainsAny(number, ".( ") {
+		return number + " " + title
+	}
+	return number + ". " + title
+}
+
+// date formats a metadata date in the renderer's language, leaving values
+// that do not parse as they are
+func date(doc *nld.Document, tr *i18n.Catalog, value string) string {
+	loc, err := doc.Metadata.Location()
+	if err != nil {
+		return value
+	}
+	t, _, err := nld.ParseDate(value, loc)
+	if err != nil {
+		return value
+	}
+	return tr.Date(t)
+}
+
+// label is a label and value shown beneath the document title
+type label struct {
+	name, value string
+}
+
+// labels returns the jurisdiction and the period the document is in force
+func labels(doc *nld.Document, tr *i18n.Catalog) []label {
+	var list []label
+	if doc.Metadata.Jurisdiction != "" {
+		list = append(list, label{tr.T("render.jurisdiction"), doc.Metadata.Jurisdiction})
+	}
+	if doc.Metadata.Effective != "" {
+		list = append(list, label{tr.T("render.effective"), date(doc, tr, doc.Metadata.Effective)})
+	}
+	if doc.Metadata.Expires != "" {
+		list = append(list, label{tr.T("render.expires"), date(doc, tr, doc.Metadata.Expires)})
+	}
+	return list
+}
+
+// signatures returns a line for each signature naming the signer and date
+func signatures(doc *nld.Document, tr *i18n.Catalog) []string {
+	var lines []string
+	for _, sig := range doc.Verification.Signatures {
+		signer := sig.SignerID
+		for _, e := range doc.Metadata.Entities {
+			if e.ID == sig.SignerID && e.Name != "" {
+				signer = e.Name
+			}
+		}
+		lines = append(lines, tr.T("render.signed", signer, date(doc, tr, sig.Date)))
+	}
+	return lines
+}
+
+// definition returns the text of a defined term, or an empty string
+func definition(doc *nld.Document, term string) string {
+	if d := doc.Definition(term); d != nil {
+		return d.Definition
+	}
+	return ""
+}
+
+// table holds the parts of a table block prepared for rendering
+type table struct {
+	header []string
+	rows   [][]string
+	// totals is the totals row, or nil
+	totals []string
+	// numeric marks right-aligned number columns
+	numeric []bool
+}
+
+// tableOf prepares a table block for rendering. Totals that cannot be
+// computed are left out; validation reports the offending cells.
+func tableOf(b nld.Block, tr *i18n.Catalog) table {
+	var t table
+	if b.TotalLabel == "" {
+		b.TotalLabel = tr.T("render.total")
+	}
+	t.header, t.rows = b.TableHeader()
+	t.totals, _ = b.TableTotals()
+	for _, c := range b.TableColumns() {
+		t.numeric = append(t.numeric, c.Type == nld.ColumnNumber)
+	}
+	return t
+}
+
+// isNumeric reports whether column i is a number column
+func (t table) isNumeric(i int) bool {
+	return i < len(t.numeric) && t.numeric[i]
+}
+
+// markdownRenderer renders GitHub flavoured Markdown
+type markdownRenderer struct {
+	doc *nld.Document
+	tr  *i18n.Catalog
+}
+
+func (r markdownRenderer) begin(w *bufio.Writer, doc *nld.Document) {
+	fmt.Fprintf(w, "# %s\n\n", title(doc, r.tr))
+	for _, l := range labels(doc, r.tr) {
+		fmt.Fprintf(w, "**%s:** %s\n\n", l.name, l.value)
+	}
+	if len(doc.Metadata.Entities) > 0 {
+		fmt.Fprintf(w, "## %s\n\n", r.tr.T("render.parties"))
+		for _, e := range doc.Metadata.Entities {
+			fmt.Fprintf(w, "- **%s** (%s)\n", e.Name, e.Role)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func (markdownRenderer) section(w *bufio.Writer, s nld.Section, number string, depth int) {
+	level := depth + 2
+	if level > 6 {
+		level = 6
+	}
+	fmt.Fprintf(w, "%s %s\n\n", strings.Repeat("#", level), heading(number, s.Title))
+}
+
+func (r markdownRenderer) block(w *bufio.Writer, b nld.Block, depth int) {
+	switch b.Type {
+	case nld.BlockList:
+		for i, item := range b.Items {
+			if b.Ordered {
+				fmt.Fprintf(w, "%d. %s\n", i+1, item)
+			} else {
+				fmt.Fprintf(w, "- %s\n", item)
+			}
+		}
+	case nld.BlockTable:
+		t := tableOf(b, r.tr)
+		cell := strings.NewReplacer("|", "\\|", "\n", " ")
+		row := func(cells []string) {
+			for _, c := range cells {
+				fmt.Fprintf(w, "| %s ", cell.Replace(c))
+			}
+			fmt.Fprintf(w, "|\n")
+		}
+		row(t.header)
+		for i := range t.header {
+			if t.isNumeric(i) {
+				fmt.Fprintf(w, "| ---: ")
+			} else {
+				fmt.Fprintf(w, "| --- ")
+			}
+		}
+		fmt.Fprintf(w, "|\n")
+		for _, cells := range t.rows {
+			row(cells)
+		}
+		if t.totals != nil {
+			bold := make([]string, len(t.totals))
+			for i, c := range t.totals {
+				if c != "" {
+					bold[i] = "**" + c + "**"
+				}
+			}
+			row(bold)
+		}
+	case nld.BlockDefinitionRef:
+		fmt.Fprintf(w, "**\"%s\"** %s %s\n", b.Term, r.tr.T("render.means"), definition(r.doc, b.Term))
+	default:
+		fmt.Fprintf(w, "%s\n", b.Text)
+	}
+	fmt.Fprintln(w)
+}
+
+func (r markdownRenderer) parallel(w *bufio.Writer, locales []string, left, right nld.Section, number string, depth int) {
+	r.section(w, nld.Section{Title: parallelTitle(left, right)}, number, depth)
+	rows := pairs(r.doc, r.tr, left, right)
+	if len(rows) == 0 {
+		return
+	}
+	cell := strings.NewReplacer("|", "\\|", "\n", " ")
+	join := func(lines []string) string {
+		for i, l := range lines {
+			lines[i] = cell.Replace(l)
+		}
+		return strings.Join(lines, "<br>")
+	}
+	fmt.Fprintf(w, "| %s | %s |\n| --- | --- |\n", locales[0], locales[1])
+	for _, p := range rows {
+		fmt.Fprintf(w, "| %s | %s |\n", join(p[0]), join(p[1]))
+	}
+	fmt.Fprintln(w)
+}
+
+func (r markdownRenderer) end(w *bufio.Writer, doc *nld.Document) {
+	if len(doc.Structure.Definitions) > 0 {
+		fmt.Fprintf(w, "## %s\n\n", r.tr.T("render.definitions"))
+		for _, d := range doc.Structure.Definitions {
+			fmt.Fprintf(w, "- **%s**: %s\n", d.Term, d.Definition)
+		}
+		fmt.Fprintln(w)
+	}
+	if sigs := signatures(doc, r.tr); len(sigs) > 0 {
+		fmt.Fprintf(w, "## %s\n\n", r.tr.T("render.signatures"))
+		for _, line := range sigs {
+			fmt.Fprintf(w, "- %s\n", line)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// htmlRenderer renders a standalone HTML page
+type htmlRenderer struct {
+	doc      *nld.Document
+	tr       *i18n.Catalog
+	annotate bool
+	redline  bool
+	// notes are the annotations of the section being rendered, and marked
+	// the IDs of those whose text has been highlighted
+	notes  []nld.Annotation
+	marked map[string]bool
+}
+
+// annotationStyle places annotations in the right margin of the page
+const annotationStyle = `<style>
+body { margin-right: 20em; }
+.annotations { float: right; clear: right; width: 16em; margin-right: -19em; font-size: 0.85em; }
+.annotation { border-left: 3px solid #e0b000; padding: 0 0.5em; margin-bottom: 0.75em; }
+.annotation blockquote { margin: 0.25em 0; font-style: italic; }
+mark { background: #fff3b0; }
+</style>
+`
+
+// redlineStyle colors the text proposed changes delete and insert
+const redlineStyle = `<style>
+del { color: #b00020; }
+ins { color: #006400; }
+</style>
+`
+
+func (r htmlRenderer) begin(w *bufio.Writer, doc *nld.Document) {
+	t := html.EscapeString(title(doc, r.tr))
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html lang=\"%s\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", r.tr.Lang, t)
+	if r.annotate {
+		fmt.Fprint(w, annotationStyle)
+	}
+	if r.redline {
+		fmt.Fprint(w, redlineStyle)
+	}
+	fmt.Fprintf(w, "</head>\n<body>\n")
+	fmt.Fprintf(w, "<h1>%s</h1>\n", t)
+	for _, l := range labels(doc, r.tr) {
+		fmt.Fprintf(w, "<p><strong>%s:</strong> %s</p>\n", html.EscapeString(l.name), html.EscapeString(l.value))
+	}
+	if len(doc.Metadata.Entities) > 0 {
+		fmt.Fprintf(w, "<h2>%s</h2>\n<ul>\n", html.EscapeString(r.tr.T("render.parties")))
+		for _, e := range doc.Metadata.Entities {
+			fmt.Fprintf(w, "<li><strong>%s</strong> (%s)</li>\n", html.EscapeString(e.Name), html.EscapeString(e.Role))
+		}
+		fmt.Fprintf(w, "</ul>\n")
+	}
+}
+
+func (r *htmlRenderer) section(w *bufio.Writer, s nld.Section, number string, depth int) {
+	level := depth + 2
+	if level > 6 {
+		level = 6
+	}
+	fmt.Fprintf(w, "<h%d id=\"%s\">%s</h%d>\n", level, html.EscapeString(s.ID), html.EscapeString(heading(number, s.Title)), level)
+
+	r.notes, r.marked = nil, map[string]bool{}
+	if !r.annotate {
+		return
+	}
+	r.notes = r.doc.SectionAnnotations(s.ID, false)
+	if len(r.notes) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "<aside class=\"annotations\">\n")
+	for _, a := range r.notes {
+		id := html.EscapeString(a.ID)
+		fmt.Fprintf(w, "<div class=\"annotation\" id=\"annotation-%s\">\n<p><strong>%s</strong> %s</p>\n", id, html.EscapeString(a.Author), html.EscapeString(date(r.doc, r.tr, a.Date)))
+		if a.Quote != "" {
+			fmt.Fprintf(w, "<blockquote>%s</blockquote>\n", html.EscapeString(a.Quote))
+		}
+		fmt.Fprintf(w, "<p>%s</p>\n</div>\n", html.EscapeString(a.Text))
+	}
+	fmt.Fprintf(w, "</aside>\n")
+}
+
+// highlight escapes a paragraph of the current section, marking the first
+// occurrence of the text of each of its annotations not yet marked
+func (r *htmlRenderer) highlight(text string) string {
+	type span struct {
+		start, end int
+		id         string
+	}
+	var spans []span
+	for _, a := range r.notes {
+		if a.Quote == "" || r.marked[a.ID] {
+			continue
+		}
+		i := strings.Index(text, a.Quote)
+		if i == -1 {
+			continue
+		}
+		overlaps := false
+		for _, s := range spans {
+			if i < s.end && s.start < i+len(a.Quote) {
+				overlaps = true
+			}
+		}
+		if !overlaps {
+			spans = append(spans, span{i, i + len(a.Quote), a.ID})
+			r.marked[a.ID] = true
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	at := 0
+	for _, s := range spans {
+		b.WriteString(html.EscapeString(text[at:s.start]))
+		fmt.Fprintf(&b, "<mark data-annotation=\"%s\">%s</mark>", html.EscapeString(s.id), html.EscapeString(text[s.start:s.end]))
+		at = s.end
+	}
+	b.WriteString(html.EscapeString(text[at:]))
+	return b.String()
+}
+
+// body renders the plain text content of a section with the changes
+// proposed to it marked, when the renderer shows the redline. Changes to
+// text that differs from what they were proposed on, as in translations,
+// and changes overlapping an earlier one are left out.
+func (r *htmlRenderer) body(w *bufio.Writer, s nld.Section, depth int) bool {
+	if !r.redline || s.Blocks != nil {
+		return false
+	}
+	text := []rune(s.Content)
+	var changes []nld.Change
+	for _, c := range r.doc.SectionChanges(s.ID) {
+		if c.Range.Start >= 0 && c.Range.Start <= c.Range.End && c.Range.End <= len(text) && string(text[c.Range.Start:c.Range.End]) == c.Deleted {
+			changes = append(changes, c)
+		}
+	}
+	if len(changes) == 0 {
+		return false
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Range.Start < changes[j].Range.Start })
+
+	// The content is marked up piece by piece and then split into
+	// paragraphs on blank lines, as Section.Body splits plain text
+	var paragraphs []string
+	var p strings.Builder
+	blank := true
+	add := func(piece, tag string, c nld.Change) {
+		for i, part := range strings.Split(strings.ReplaceAll(piece, "\r\n", "\n"), "\n\n") {
+			if i > 0 {
+				if !blank {
+					paragraphs = append(paragraphs, strings.TrimSpace(p.String()))
+				}
+				p.Reset()
+				blank = true
+			}
+			if part == "" {
+				continue
+			}
+			if strings.TrimSpace(part) == "" && tag == "" {
+				p.WriteString(html.EscapeString(part))
+				continue
+			}
+			blank = false
+			if tag == "" {
+				p.WriteString(html.EscapeString(part))
+			} else {
+				fmt.Fprintf(&p, "<%s data-change=\"%s\" title=\"%s\">%s</%s>", tag, html.EscapeString(c.ID), html.EscapeString(c.Author), html.EscapeString(part), tag)
+			}
+		}
+	}
+	at := 0
+	for _, c := range changes {
+		if c.Range.Start < at {
+			continue
+		}
+		add(string(text[at:c.Range.Start]), "", c)
+		add(c.Deleted, "del", c)
+		add(c.Inserted, "ins", c)
+		at = c.Range.End
+	}
+	add(string(text[at:]), "", nld.Change{})
+	if !blank {
+		paragraphs = append(paragraphs, strings.TrimSpace(p.String()))
+	}
+
+	for _, para := range paragraphs {
+		fmt.Fprintf(w, "<p>%s</p>\n", para)
+	}
+	return true
+}
+
+func (r *htmlRenderer) block(w *bufio.Writer, b nld.Block, depth int) {
+	switch b.Type {
+	case nld.BlockList:
+		tag := "ul"
+		if b.Ordered {
+			tag = "ol"
+		}
+		fmt.Fprintf(w, "<%s>\n", tag)
+		for _, item := range b.Items {
+			fmt.Fprintf(w, "<li>%s</li>\n", html.EscapeString(item))
+		}
+		fmt.Fprintf(w, "</%s>\n", tag)
+	case nld.BlockTable:
+		t := tableOf(b, r.tr)
+		cells := func(tag string, values []string) {
+			fmt.Fprintf(w, "<tr>")
+			for i, c := range values {
+				if t.isNumeric(i) {
+					fmt.Fprintf(w, "<%s class=\"number\">%s</%s>", tag, html.EscapeString(c), tag)
+				} else {
+					fmt.Fprintf(w, "<%s>%s</%s>", tag, html.EscapeString(c), tag)
+				}
+			}
+			fmt.Fprintf(w, "</tr>\n")
+		}
+		fmt.Fprintf(w, "<table>\n<thead>\n")
+		cells("th", t.header)
+		fmt.Fprintf(w, "</thead>\n<tbody>\n")
+		for _, row := range t.rows {
+			cells("td", row)
+		}
+		fmt.Fprintf(w, "</tbody>\n")
+		if t.totals != nil {
+			fmt.Fprintf(w, "<tfoot>\n")
+			cells("td", t.totals)
+			fmt.Fprintf(w, "</tfoot>\n")
+		}
+		fmt.Fprintf(w, "</table>\n")
+	case nld.BlockDefinitionRef:
+		fmt.Fprintf(w, "<p class=\"definition\"><dfn>%s</dfn> %s %s</p>\n",
+			html.EscapeString(b.Term), html.EscapeString(r.tr.T("render.means")), html.EscapeString(definition(r.doc, b.Term)))
+	default:
+		fmt.Fprintf(w, "<p>%s</p>\n", r.highlight(b.Text))
+	}
+}
+
+func (r htmlRenderer) parallel(w *bufio.Writer, locales []string, left, right nld.Section, number string, depth int) {
+	r.section(w, nld.Section{ID: left.ID, Title: parallelTitle(left, right)}, number, depth)
+	rows := pairs(r.doc, r.tr, left, right)
+	if len(rows) == 0 {
+		return
+	}
+	join := func(lines []string) string {
+		for i, l := range lines {
+			lines[i] = html.EscapeString(l)
+		}
+		return strings.Join(lines, "<br>")
+	}
+	l, rt := html.EscapeString(locales[0]), html.EscapeString(locales[1])
+	fmt.Fprintf(w, "<table class=\"bilingual\">\n<thead>\n<tr><th lang=\"%s\">%s</th><th lang=\"%s\">%s</th></tr>\n</thead>\n<tbody>\n", l, l, rt, rt)
+	for _, p := range rows {
+		fmt.Fprintf(w, "<tr><td lang=\"%s\">%s</td><td lang=\"%s\">%s</td></tr>\n", l, join(p[0]), rt, join(p[1]))
+	}
+	fmt.Fprintf(w, "</tbody>\n</table>\n")
+}
+
+func (r htmlRenderer) end(w *bufio.Writer, doc *nld.Document) {
+	if len(doc.Structure.Definitions) > 0 {
+		fmt.Fprintf(w, "<h2>%s</h2>\n<dl>\n", html.EscapeString(r.tr.T("render.definitions")))
+		for _, d := range doc.Structure.Definitions {
+			fmt.Fprintf(w, "<dt>%s</dt>\n<dd>%s</dd>\n", html.EscapeString(d.Term), html.EscapeString(d.Definition))
+		}
+		fmt.Fprintf(w, "</dl>\n")
+	}
+	if sigs := signatures(doc, r.tr); len(sigs) > 0 {
+		fmt.Fprintf(w, "<h2>%s</h2>\n<ul class=\"signatures\">\n", html.EscapeString(r.tr.T("render.signatures")))
+		for _, line := range sigs {
+			fmt.Fprintf(w, "<li>%s</li>\n", html.EscapeString(line))
+		}
+		fmt.Fprintf(w, "</ul>\n")
+	}
+	fmt.Fprintf(w, "</body>\n</html>\n")
+}
+
+// textRenderer renders plain text, indenting nested sections
+type textRenderer struct {
+	doc *nld.Document
+	tr  *i18n.Catalog
+}
+
+func (r textRenderer) begin(w *bufio.Writer, doc *nld.Document) {
+	t := strings.ToUpper(title(doc, r.tr))
+	fmt.Fprintf(w, "%s\n%s\n\n", t, strings.Repeat("=", utf8.RuneCountInString(t)))
+	for _, l := range labels(doc, r.tr) {
+		fmt.Fprintf(w, "%s: %s\n\n", l.name, l.value)
+	}
+	if len(doc.Metadata.Entities) > 0 {
+		fmt.Fprintf(w, "%s:\n", r.tr.T("render.parties"))
+		for _, e := range doc.Metadata.Entities {
+			fmt.Fprintf(w, "  %s (%s)\n", e.Name, e.Role)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func (textRenderer) section(w *bufio.Writer, s nld.Section, number string, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(w, "%s%s\n\n", indent, heading(number, s.Title))
+}
+
+func (r textRenderer) block(w *bufio.Writer, b nld.Block, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch b.Type {
+	case nld.BlockList:
+		for i, item := range b.Items {
+			if b.Ordered {
+				fmt.Fprintf(w, "%s  %d. %s\n", indent, i+1, item)
+			} else {
+				fmt.Fprintf(w, "%s  - %s\n", indent, item)
+			}
+		}
+	case nld.BlockTable:
+		t := tableOf(b, r.tr)
+		all := append([][]string{t.header}, t.rows...)
+		if t.totals != nil {
+			all = append(all, t.totals)
+		}
+		widths := map[int]int{}
+		for _, cells := range all {
+			for i, c := range cells {
+				if len(c) > widths[i] {
+					widths[i] = len(c)
+				}
+			}
+		}
+		rule := func() {
+			line := indent
+			for i := range t.header {
+				line += strings.Repeat("-", widths[i]) + "  "
+			}
+			fmt.Fprintf(w, "%s\n", strings.TrimRight(line, " "))
+		}
+		for n, cells := range all {
+			if t.totals != nil && n == len(all)-1 {
+				rule()
+			}
+			line := indent
+			for i, c := range cells {
+				if t.isNumeric(i) {
+					line += fmt.Sprintf("%*s  ", widths[i], c)
+				} else {
+					line += fmt.Sprintf("%-*s  ", widths[i], c)
+				}
+			}
+			fmt.Fprintf(w, "%s\n", strings.TrimRight(line, " "))
+			if n == 0 && len(t.header) > 0 {
+				rule()
+			}
+		}
+	case nld.BlockDefinitionRef:
+		fmt.Fprintf(w, "%s\"%s\" %s %s\n", indent, b.Term, r.tr.T("render.means"), definition(r.doc, b.Term))
+	default:
+		fmt.Fprintf(w, "%s%s\n", indent, b.Text)
+	}
+	fmt.Fprintln(w)
+}
+
+// columnWidth is the width of each column of side-by-side text
+const columnWidth = 38
+
+func (r textRenderer) parallel(w *bufio.Writer, locales []string, left, right nld.Section, number string, depth int) {
+	r.section(w, nld.Section{Title: parallelTitle(left, right)}, number, depth)
+	indent := strings.Repeat("  ", depth)
+	for _, p := range pairs(r.doc, r.tr, left, right) {
+		var l, rt []string
+		for _, line := range p[0] {
+			l = append(l, wrap(line, columnWidth)...)
+		}
+		for _, line := range p[1] {
+			rt = append(rt, wrap(line, columnWidth)...)
+		}
+		for i := 0; i < len(l) || i < len(rt); i++ {
+			var a, b string
+			if i < len(l) {
+				a = l[i]
+			}
+			if i < len(rt) {
+				b = rt[i]
+			}
+			pad := columnWidth - utf8.RuneCountInString(a)
+			if pad < 0 {
+				pad = 0
+			}
+			fmt.Fprintf(w, "%s\n", strings.TrimRight(indent+a+strings.Repeat(" ", pad)+" | "+b, " "))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// wrap breaks text into lines of at most width runes, breaking at spaces
+// where possible
+func wrap(text string, width int) []string {
+	var out []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for utf8.RuneCountInString(word) > width {
+			if line != "" {
+				out = append(out, line)
+				line = ""
+			}
+			runes := []rune(word)
+			out = append(out, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			out = append(out, line)
+			line = word
+		}
+	}
+	if line != "" || len(out) == 0 {
+		out = append(out, line)
+	}
+	return out
+}
+
+func (r textRenderer) end(w *bufio.Writer, doc *nld.Document) {
+	if len(doc.Structure.Definitions) > 0 {
+		fmt.Fprintf(w, "%s:\n", r.tr.T("render.definitions"))
+		for _, d := range doc.Structure.Definitions {
+			fmt.Fprintf(w, "  %s: %s\n", d.Term, d.Definition)
+		}
+	}
+	if sigs := signatures(doc, r.tr); len(sigs) > 0 {
+		if len(doc.Structure.Definitions) > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s:\n", r.tr.T("render.signatures"))
+		for _, line := range sigs {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
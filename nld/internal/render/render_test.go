@@ -0,0 +1,354 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	// Documents in the tests use named time zones
+	_ "time/tzdata"
+
+	"github.com/colemalphrus/nld/internal/i18n"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const testDocument = `{
+  "metadata": {"type": "contract", "title": "Service <Agreement>", "jurisdiction": "GB",
+    "entities": [{"id": "p1", "name": "Acme Corporation", "role": "Service Provider"}]},
+  "content": {"sections": [
+    {"id": "intro", "title": "Introduction", "content": "First paragraph.\n\nSecond paragraph."},
+    {"id": "services", "title": "Services", "content": "", "sections": [
+      {"id": "scope", "title": "Scope", "content": "", "sections": [
+        {"id": "design", "title": "Design", "content": "Design work."}
+      ]}
+    ]}
+  ]}
+}`
+
+func TestRender(t *testing.T) {
+	doc, err := nld.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		opts        Options
+		expect      []string
+		reject      []string
+		expectError bool
+	}{
+		{
+			name:   "Markdown Jurisdiction Numbering",
+			opts:   Options{Format: Markdown},
+			expect: []string{"# Service <Agreement>", "## 1. Introduction", "## 2. Services", "### 2.1 Scope", "#### (a) Design", "- **Acme Corporation** (Service Provider)", "First paragraph.\n\nSecond paragraph."},
+		},
+		{
+			name:   "Markdown Explicit Style",
+			opts:   Options{Format: Markdown, Numbering: mustStyle(t, "default")},
+			expect: []string{"#### 2.1.a Design"},
+		},
+		{
+			name:   "Unnumbered",
+			opts:   Options{Format: Markdown, Unnumbered: true},
+			expect: []string{"## Introduction", "#### Design"},
+			reject: []string{"1. Introduction"},
+		},
+		{
+			name:   "HTML",
+			opts:   Options{Format: HTML},
+			expect: []string{"<title>Service &lt;Agreement&gt;</title>", `<h2 id="intro">1. Introduction</h2>`, "<p>Second paragraph.</p>", `<h4 id="design">(a) Design</h4>`},
+			reject: []string{"<Agreement>"},
+		},
+		{
+			name:   "Text",
+			opts:   Options{Format: Text},
+			expect: []string{"SERVICE <AGREEMENT>", "1. Introduction", "    (a) Design"},
+		},
+		{
+			name:        "Unsupported Format",
+			opts:        Options{Format: "pdf"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Render(&buf, doc, tc.opts)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+
+			out := buf.String()
+			for _, s := range tc.expect {
+				if !strings.Contains(out, s) {
+					t.Errorf("Expected output to contain %q, got:\n%s", s, out)
+				}
+			}
+			for _, s := range tc.reject {
+				if strings.Contains(out, s) {
+					t.Errorf("Expected output not to contain %q, got:\n%s", s, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderBlocks(t *testing.T) {
+	doc, err := nld.Parse([]byte(`{
+  "metadata": {"type": "receipt", "title": "Receipt"},
+  "content": {
+    "sections": [{"id": "items", "title": "Items", "content": [
+      {"type": "paragraph", "text": "The following goods were sold:"},
+      {"type": "list", "ordered": true, "items": ["Widget", "Gadget"]},
+      {"type": "table", "header": ["Item", "Price"], "rows": [["Widget", "10 | 12"], ["Gadget", "5"]]},
+      {"type": "definitionRef", "term": "Goods"},
+      {"type": "table", "columns": [{"title": "Item"}, {"title": "Qty", "type": "number"}, {"title": "Amount", "type": "number", "total": true}],
+       "rows": [["Widget", "2", "20.00"], ["Gadget", "1", "5.50"]]}
+    ]}],
+    "definitions": [{"term": "Goods", "definition": "the items listed above."}]
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	testCases := []struct {
+		format string
+		expect []string
+	}{
+		{format: Markdown, expect: []string{"The following goods were sold:\n\n", "1. Widget\n2. Gadget\n", "| Item | Price |\n| --- | --- |\n| Widget | 10 \\| 12 |", "**\"Goods\"** means the items listed above.", "| --- | ---: | ---: |", "| **Total** |  | **25.50** |"}},
+		{format: HTML, expect: []string{"<ol>\n<li>Widget</li>", "<th>Item</th><th>Price</th>", "<td>Widget</td><td>10 | 12</td>", "<dfn>Goods</dfn> means the items listed above.", "<td class=\"number\">20.00</td>", "<tfoot>\n<tr><td>Total</td><td class=\"number\"></td><td class=\"number\">25.50</td></tr>"}},
+		{format: Text, expect: []string{"  1. Widget", "Item    Price\n------  -------\nWidget  10 | 12", "\"Goods\" means the items listed above.", "Widget    2   20.00", "Total         25.50"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(&buf, doc, Options{Format: tc.format}); err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			for _, s := range tc.expect {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("Expected output to contain %q, got:\n%s", s, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRenderLanguage(t *testing.T) {
+	doc, err := nld.Parse([]byte(`{
+  "metadata": {"type": "contract", "title": "Vertrag", "effective": "2025-03-01", "timezone": "Europe/Berlin",
+    "entities": [{"id": "p1", "name": "Acme GmbH", "role": "Anbieter"}]},
+  "content": {"sections": [{"id": "waren", "title": "Waren", "content": [
+    {"type": "definitionRef", "term": "Waren"},
+    {"type": "table", "columns": [{"title": "Artikel"}, {"title": "Betrag", "type": "number", "total": true}], "rows": [["Widget", "10.00"]]}
+  ]}], "definitions": [{"term": "Waren", "definition": "die gelieferten Artikel"}]},
+  "verification": {"signatures": [{"signerId": "p1", "date": "2025-03-02T10:00:00+01:00", "value": "sig"}]}
+}`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	testCases := []struct {
+		lang   string
+		expect []string
+	}{
+		{lang: "en", expect: []string{"**Effective:** March 1, 2025", "## Parties", "**\"Waren\"** means die gelieferten Artikel", "| **Total** | **10.00** |", "## Definitions", "## Signatures\n\n- Signed by Acme GmbH on March 2, 2025"}},
+		{lang: "de", expect: []string{"**Gültig ab:** 1. März 2025", "## Parteien", "**\"Waren\"** bezeichnet die gelieferten Artikel", "| **Summe** | **10.00** |", "## Begriffsbestimmungen", "## Unterschriften\n\n- Unterzeichnet von Acme GmbH am 2. März 2025"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.lang, func(t *testing.T) {
+			lang, err := i18n.Load(tc.lang)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := Render(&buf, doc, Options{Format: Markdown, Lang: lang}); err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			for _, s := range tc.expect {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("Expected output to contain %q, got:\n%s", s, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRenderLocales(t *testing.T) {
+	doc, err := nld.Parse([]byte(`{
+  "metadata": {"type": "contract", "title": "Agreement", "language": "en"},
+  "content": {"sections": [
+    {"id": "scope", "title": "Scope", "content": "The Supplier provides consulting services to the Client.",
+     "translations": {"fr": {"title": "Portée", "content": "Le Fournisseur fournit des services de conseil au Client."}}},
+    {"id": "fees", "title": "Fees", "content": [{"type": "list", "items": ["Monthly", "In advance"]}]}
+  ]}
+}`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		opts        Options
+		expect      []string
+		reject      []string
+		expectError bool
+	}{
+		{
+			name:   "Single Locale",
+			opts:   Options{Format: Markdown, Locales: []string{"fr"}},
+			expect: []string{"## 1. Portée", "Le Fournisseur fournit", "## 2. Fees"},
+			reject: []string{"The Supplier"},
+		},
+		{
+			name:   "Markdown Side By Side",
+			opts:   Options{Format: Markdown, Locales: []string{"en", "fr"}},
+			expect: []string{"## 1. Scope / Portée", "| en | fr |\n| --- | --- |\n| The Supplier provides consulting services to the Client. | Le Fournisseur fournit des services de conseil au Client. |", "## 2. Fees\n", "| - Monthly<br>- In advance | - Monthly<br>- In advance |"},
+		},
+		{
+			name:   "HTML Side By Side",
+			opts:   Options{Format: HTML, Locales: []string{"en", "fr"}},
+			expect: []string{`<h2 id="scope">1. Scope / Portée</h2>`, `<th lang="en">en</th><th lang="fr">fr</th>`, `<td lang="fr">Le Fournisseur fournit des services de conseil au Client.</td>`},
+		},
+		{
+			name:   "Text Side By Side",
+			opts:   Options{Format: Text, Locales: []string{"en", "fr"}},
+			expect: []string{"The Supplier provides consulting       | Le Fournisseur fournit des services de\nservices to the Client.                | conseil au Client."},
+		},
+		{
+			name:        "Too Many Locales",
+			opts:        Options{Locales: []string{"en", "fr", "de"}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Render(&buf, doc, tc.opts)
+			if tc.expectError {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			for _, s := range tc.expect {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("Expected output to contain %q, got:\n%s", s, buf.String())
+				}
+			}
+			for _, s := range tc.reject {
+				if strings.Contains(buf.String(), s) {
+					t.Errorf("Expected output not to contain %q, got:\n%s", s, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRenderAnnotations(t *testing.T) {
+	doc, err := nld.Parse([]byte(`{
+  "metadata": {"type": "contract", "title": "Lease"},
+  "content": {"sections": [
+    {"id": "rent", "title": "Rent", "content": "Rent is <EUR 900> a month.\n\nRent is due monthly."},
+    {"id": "term", "title": "Term", "content": "One year."}
+  ]},
+  "annotations": [
+    {"id": "a1", "section": "rent", "range": {"start": 8, "end": 17}, "quote": "<EUR 900>", "author": "alice", "date": "2025-01-02T10:00:00Z", "text": "Too low"},
+    {"id": "a2", "section": "rent", "author": "bob", "date": "2025-01-03T10:00:00Z", "text": "Add indexation"},
+    {"id": "a3", "section": "term", "author": "bob", "date": "2025-01-03T10:00:00Z", "text": "Fixed", "resolved": true}
+  ]
+}`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, doc, Options{Format: HTML, Annotations: true}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := buf.String()
+	for _, s := range []string{"<style>", `<div class="annotation" id="annotation-a1">`, "<blockquote>&lt;EUR 900&gt;</blockquote>", "<p>Add indexation</p>",
+		`<p>Rent is <mark data-annotation="a1">&lt;EUR 900&gt;</mark> a month.</p>`, "<p>Rent is due monthly.</p>"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected output to contain %q, got:\n%s", s, out)
+		}
+	}
+	if strings.Contains(out, "annotation-a3") {
+		t.Errorf("Expected resolved annotations to be left out, got:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := Render(&buf, doc, Options{Format: HTML}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "annotation") || strings.Contains(buf.String(), "<mark") {
+		t.Errorf("Expected no annotations without the option, got:\n%s", buf.String())
+	}
+}
+
+func TestRenderRedline(t *testing.T) {
+	doc, err := nld.Parse([]byte(`{
+  "metadata": {"type": "contract", "title": "Lease"},
+  "content": {"sections": [
+    {"id": "rent", "title": "Rent", "content": "Rent is EUR 900 a month.\n\nRent is due <monthly>."},
+    {"id": "term", "title": "Term", "content": "One year."}
+  ]},
+  "redline": [
+    {"id": "c1", "section": "rent", "range": {"start": 8, "end": 15}, "deleted": "EUR 900", "inserted": "EUR 950", "author": "alice", "date": "2025-01-02T10:00:00Z"},
+    {"id": "c2", "section": "rent", "range": {"start": 24, "end": 24}, "inserted": "\n\nRent is indexed yearly.", "author": "bob", "date": "2025-01-02T10:00:00Z"},
+    {"id": "c3", "section": "rent", "range": {"start": 38, "end": 48}, "deleted": "<monthly>.", "author": "bob", "date": "2025-01-02T10:00:00Z"},
+    {"id": "c4", "section": "term", "range": {"start": 0, "end": 3}, "deleted": "Two", "inserted": "Three", "author": "bob", "date": "2025-01-02T10:00:00Z"}
+  ]
+}`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, doc, Options{Format: HTML, Redline: true}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	out := buf.String()
+	for _, s := range []string{
+		`<p>Rent is <del data-change="c1" title="alice">EUR 900</del><ins data-change="c1" title="alice">EUR 950</ins> a month.</p>`,
+		`<p><ins data-change="c2" title="bob">Rent is indexed yearly.</ins></p>`,
+		`<p>Rent is due <del data-change="c3" title="bob">&lt;monthly&gt;.</del></p>`,
+		// Changes proposed on other text are left out
+		"<p>One year.</p>",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Expected output to contain %q, got:\n%s", s, out)
+		}
+	}
+
+	buf.Reset()
+	if err := Render(&buf, doc, Options{Format: HTML}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "<del") || !strings.Contains(buf.String(), "<p>Rent is EUR 900 a month.</p>") {
+		t.Errorf("Expected no changes without the option, got:\n%s", buf.String())
+	}
+}
+
+func mustStyle(t *testing.T, name string) nld.NumberingStyle {
+	t.Helper()
+	style, err := nld.LookupNumberingStyle(name)
+	if err != nil {
+		t.Fatalf("LookupNumberingStyle failed: %v", err)
+	}
+	return style
+}
@@ -0,0 +1,102 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/lint"
+	"github.com/colemalphrus/nld/internal/validator"
+)
+
+// Annotation levels of GitHub Actions workflow commands
+const (
+	annotationError   = "error"
+	annotationWarning = "warning"
+	annotationNotice  = "notice"
+)
+
+// GithubReporter writes problems as GitHub Actions workflow commands, and
+// everything else as text
+type GithubReporter struct {
+	*TextReporter
+}
+
+// Validation writes the errors and warnings of a validation result as
+// annotations. They are written even when quiet, like errors.
+func (r *GithubReporter) Validation(v *Validation) error {
+	tool := r.opts.tool()
+	// Groups are not annotated, only the errors up to the limit
+	result, _ := limit(v.result, v.maxErrors)
+	for _, e := range result.Errors {
+		// Schema errors that only group the errors below them have no
+		// message of their own
+		if e.Message == "" {
+			continue
+		}
+		line, column := v.locate(e.Field, e.Line, e.Column)
+		fmt.Fprintln(r.opts.Out, annotation(annotationError, v.File, line, column, tool, e.Message))
+	}
+	for _, w := range result.Warnings {
+		line, column := v.locate(w.Field, 0, 0)
+		fmt.Fprintln(r.opts.Out, annotation(annotationWarning, v.File, line, column, tool, w.Message))
+	}
+	if result.Valid && !r.opts.Quiet {
+		fmt.Fprintln(r.opts.Out, validator.ColoredOutput(true, validMark+r.opts.Tr.T("validate.valid", v.File)))
+	}
+	return nil
+}
+
+// Lint writes lint findings as annotations
+func (r *GithubReporter) Lint(l *Lint) error {
+	tool := r.opts.tool()
+	if l.Error != "" {
+		fmt.Fprintln(r.opts.Out, annotation(annotationError, l.File, 0, 0, tool, l.Error))
+		return nil
+	}
+	levels := map[lint.Severity]string{lint.Error: annotationError, lint.Warning: annotationWarning, lint.Info: annotationNotice}
+	for _, f := range l.Findings {
+		line, column := l.locate(f.Path)
+		fmt.Fprintln(r.opts.Out, annotation(levels[f.Severity], l.File, line, column, tool+" "+f.Rule, f.Message))
+	}
+	return nil
+}
+
+// Failure writes a document that could not be checked as an annotation
+func (r *GithubReporter) Failure(file, message string) error {
+	fmt.Fprintln(r.opts.Out, annotation(annotationError, file, 0, 0, r.opts.tool(), message))
+	return nil
+}
+
+// annotation formats a workflow command annotating file at line and
+// column. Line and column are left out when zero, and file when the
+// document was read from standard input.
+func annotation(level, file string, line, column int, title, message string) string {
+	var props []string
+	if file != "" && file != "-" {
+		props = append(props, "file="+escapeProperty(file))
+	}
+	if line > 0 {
+		props = append(props, fmt.Sprintf("line=%d", line))
+		if column > 0 {
+			props = append(props, fmt.Sprintf("col=%d", column))
+		}
+	}
+	if title != "" {
+		props = append(props, "title="+escapeProperty(title))
+	}
+	cmd := "::" + level
+	if len(props) > 0 {
+		cmd += " " + strings.Join(props, ",")
+	}
+	return cmd + "::" + escapeData(message)
+}
+
+// escapeData escapes the message of a workflow command
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,73 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/colemalphrus/nld/internal/lint"
+)
+
+// JSONReporter writes results as JSON: a document for each validation
+// result, and one list of the lint results of the run
+type JSONReporter struct {
+	opts Options
+	// lint holds the lint results until Close
+	lint []*Lint
+}
+
+// Validation writes a validation result
+func (r *JSONReporter) Validation(v *Validation) error {
+	if r.opts.Quiet {
+		return nil
+	}
+	return r.write(v)
+}
+
+// Lint keeps the lint result of a document for Close
+func (r *JSONReporter) Lint(l *Lint) error {
+	if l.Findings == nil {
+		l.Findings = []lint.Finding{}
+	}
+	r.lint = append(r.lint, l)
+	return nil
+}
+
+// Failure writes a document that could not be checked, as an object with
+// its file and error
+func (r *JSONReporter) Failure(file, message string) error {
+	if r.opts.Quiet {
+		return nil
+	}
+	return r.write(struct {
+		File  string `json:"file"`
+		Error string `json:"error"`
+	}{file, message})
+}
+
+// Summary writes nothing, as each result says if its document is valid
+func (r *JSONReporter) Summary(valid, invalid int) error {
+	return nil
+}
+
+// Notice writes nothing, so that the output stays JSON
+func (r *JSONReporter) Notice(message string) error {
+	return nil
+}
+
+// Close writes the lint results
+func (r *JSONReporter) Close() error {
+	if r.lint == nil {
+		return nil
+	}
+	return r.write(r.lint)
+}
+
+// write writes a value as indented JSON
+func (r *JSONReporter) write(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format result as JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(r.opts.Out, string(data))
+	return err
+}
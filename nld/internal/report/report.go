@@ -0,0 +1,202 @@
+// Package report writes the results of the commands that check documents,
+// nld validate and nld lint, in one of several output formats
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/i18n"
+	"github.com/colemalphrus/nld/internal/lint"
+	"github.com/colemalphrus/nld/internal/validator"
+)
+
+// Output formats
+const (
+	Text = "text"
+	JSON = "json"
+	// Github writes problems as GitHub Actions workflow commands, which
+	// show as annotations on pull request diffs
+	Github = "github"
+	// SARIF writes a SARIF 2.1.0 log, read by code scanning tools
+	SARIF = "sarif"
+)
+
+// Formats returns the supported output formats
+func Formats() []string {
+	return []string{Text, JSON, Github, SARIF}
+}
+
+// Reporter writes the results of a command as they are found. Formats
+// that write one document for the whole run, such as SARIF, write it on
+// Close.
+type Reporter interface {
+	// Validation reports the validation result of a document
+	Validation(v *Validation) error
+	// Lint reports the lint findings of a document
+	Lint(l *Lint) error
+	// Failure reports a document that could not be checked
+	Failure(file, message string) error
+	// Summary reports the number of valid and invalid documents of a run
+	// of more than one
+	Summary(valid, invalid int) error
+	// Notice reports a message about the run, such as a file written
+	Notice(message string) error
+	// Close writes what is kept until the end of the run
+	Close() error
+}
+
+// Options are the settings of a reporter
+type Options struct {
+	Out io.Writer
+	Tr  *i18n.Catalog
+	// Quiet reports problems only
+	Quiet bool
+	// Verbose reports the location of every error
+	Verbose bool
+	// Command is the command reporting, such as validate, used to name
+	// the tool in annotations and logs
+	Command string
+	// Version is the version of nld
+	Version string
+}
+
+// New returns a reporter for an output format
+func New(format string, opts Options) (Reporter, error) {
+	if opts.Tr == nil {
+		opts.Tr = i18n.English()
+	}
+	text := &TextReporter{opts: opts}
+	switch format {
+	case Text, "":
+		return text, nil
+	case JSON:
+		return &JSONReporter{opts: opts}, nil
+	case Github:
+		return &GithubReporter{TextReporter: text}, nil
+	case SARIF:
+		return &SARIFReporter{opts: opts}, nil
+	}
+	return nil, fmt.Errorf("unsupported output format: %s (supported: %s)", format, strings.Join(Formats(), ", "))
+}
+
+// Validation is the validation result of a document as it is reported:
+// with its errors grouped, or some left out, when asked
+type Validation struct {
+	*validator.ValidationResult
+	// Groups are the errors grouped by field or keyword
+	Groups []validator.ErrorGroup `json:",omitempty"`
+	// Omitted is the number of errors, or of groups, left out
+	Omitted int `json:",omitempty"`
+	// Known is the number of findings left out as they are in the
+	// baseline
+	Known int `json:",omitempty"`
+
+	// File is the document
+	File string `json:"-"`
+	// Fixes is the number of fixes suggested for the errors, which text
+	// output points out
+	Fixes int `json:"-"`
+	// Locate returns the line and column of a JSON pointer in the
+	// document, or zeros
+	Locate func(pointer string) (int, int) `json:"-"`
+
+	// result is the whole result and maxErrors the number of errors
+	// reported
+	result    *validator.ValidationResult
+	maxErrors int
+}
+
+// NewValidation returns the validation result of a document as reported,
+// with its errors grouped by field or keyword when groupBy is set and at
+// most maxErrors errors, or groups, unless it is 0
+func NewValidation(file string, result *validator.ValidationResult, groupBy string, maxErrors int) *Validation {
+	v := &Validation{ValidationResult: result, File: file, result: result, maxErrors: maxErrors}
+	if groupBy != "" {
+		v.Groups, _ = validator.GroupErrors(result.Errors, groupBy)
+		if maxErrors > 0 && len(v.Groups) > maxErrors {
+			v.Omitted = len(v.Groups) - maxErrors
+			v.Groups = v.Groups[:maxErrors]
+		}
+		return v
+	}
+	v.ValidationResult, v.Omitted = limit(result, maxErrors)
+	return v
+}
+
+// Result returns the whole validation result
+func (v *Validation) Result() *validator.ValidationResult {
+	return v.result
+}
+
+// limit returns a result with its first n errors, or the result itself
+// when n is 0 or it has no more, and the number of errors left out
+func limit(result *validator.ValidationResult, n int) (*validator.ValidationResult, int) {
+	if n <= 0 || len(result.Errors) <= n {
+		return result, 0
+	}
+	// Errors lists the tree in order, so both keep the same errors
+	limited := *result
+	limited.Errors = result.Errors[:n]
+	count := n
+	limited.Tree = limitTree(result.Tree, &count)
+	return &limited, len(result.Errors) - n
+}
+
+// limitTree returns the first n errors of a tree, counting causes, and
+// decreases n by their number
+func limitTree(tree []validator.ValidationError, n *int) []validator.ValidationError {
+	var kept []validator.ValidationError
+	for _, e := range tree {
+		if *n == 0 {
+			break
+		}
+		*n--
+		e.Causes = limitTree(e.Causes, n)
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// locate returns the line and column of a JSON pointer in the document of
+// v, or those of the error when it has them
+func (v *Validation) locate(pointer string, line, column int) (int, int) {
+	if line > 0 || pointer == "" || v.Locate == nil {
+		return line, column
+	}
+	return v.Locate(pointer)
+}
+
+// Lint is the lint result of a document
+type Lint struct {
+	File     string         `json:"file"`
+	Findings []lint.Finding `json:"findings"`
+	Error    string         `json:"error,omitempty"`
+	// Strict reports that warnings fail the document as well as errors
+	Strict bool `json:"-"`
+	// Locate returns the line and column of a JSON pointer in the
+	// document, or zeros
+	Locate func(pointer string) (int, int) `json:"-"`
+}
+
+// Failed reports whether the document fails lint
+func (l *Lint) Failed() bool {
+	return l.Error != "" || lint.HasErrors(l.Findings) || (l.Strict && len(l.Findings) > 0)
+}
+
+// locate returns the line and column of a JSON pointer in the document
+func (l *Lint) locate(pointer string) (int, int) {
+	if l.Locate == nil {
+		return 0, 0
+	}
+	return l.Locate(pointer)
+}
+
+// tool returns the name of the tool of a command, such as nld validate
+func (o Options) tool() string {
+	if o.Command == "" {
+		return "nld"
+	}
+	return "nld " + o.Command
+}
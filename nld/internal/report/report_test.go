@@ -0,0 +1,142 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/lint"
+	"github.com/colemalphrus/nld/internal/validator"
+)
+
+// invalidResult returns a result with a tree of schema errors
+func invalidResult() *validator.ValidationResult {
+	tree := []validator.ValidationError{{
+		Message: "doesn't validate with schema#",
+		Causes: []validator.ValidationError{{
+			Field:           "/content/sections/0/content",
+			Message:         "oneOf failed",
+			KeywordLocation: "/definitions/section/properties/content/oneOf",
+			Rule:            "oneOf",
+			Causes: []validator.ValidationError{
+				{Field: "/content/sections/0/content", Message: "expected string, but got number", KeywordLocation: "/definitions/section/properties/content/oneOf/0/type", Rule: "type"},
+				{Field: "/content/sections/0/content", Message: "expected array, but got number", KeywordLocation: "/definitions/section/properties/content/oneOf/1/type", Rule: "type"},
+			},
+		}},
+	}}
+	return &validator.ValidationResult{
+		Errors:   []validator.ValidationError{tree[0], tree[0].Causes[0], tree[0].Causes[0].Causes[0], tree[0].Causes[0].Causes[1]},
+		Tree:     tree,
+		Warnings: []validator.ValidationWarning{{Field: "/metadata", Message: "no governing law"}},
+	}
+}
+
+// reporter returns a reporter of a format writing to a buffer
+func reporter(t *testing.T, format, command string) (Reporter, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	r, err := New(format, Options{Out: &buf, Command: command, Version: "1.2.3"})
+	if err != nil {
+		t.Fatalf("New(%s) failed: %v", format, err)
+	}
+	return r, &buf
+}
+
+func TestText(t *testing.T) {
+	r, buf := reporter(t, Text, "validate")
+	v := NewValidation("contract.json", invalidResult(), "", 3)
+	v.Fixes = 1
+	r.Validation(v)
+	r.Summary(1, 1)
+
+	want := "\u2717 contract.json has 4 errors:\n" +
+		"  - doesn't validate with schema#\n" +
+		"    - oneOf failed\n" +
+		"      - oneOf/0: expected string, but got number\n" +
+		"  ...and 1 more\n" +
+		"  1 fix suggested; apply it with --fix\n" +
+		"  ! no governing law\n" +
+		"\nValidation summary: 1 valid, 1 invalid\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestGithub(t *testing.T) {
+	r, buf := reporter(t, Github, "lint")
+	r.Lint(&Lint{
+		File:     "docs/a,b.json",
+		Findings: []lint.Finding{{Rule: "NLD2001", Severity: lint.Warning, Path: "/content", Message: "50% of\nthe dates"}},
+		Locate:   func(string) (int, int) { return 3, 5 },
+	})
+	r.Failure("-", "invalid JSON")
+
+	want := "::warning file=docs/a%2Cb.json,line=3,col=5,title=nld lint NLD2001::50%25 of%0Athe dates\n" +
+		"::error title=nld lint::invalid JSON\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	r, buf := reporter(t, JSON, "validate")
+	r.Validation(NewValidation("contract.json", invalidResult(), validator.GroupByKeyword, 0))
+	r.Notice("not JSON")
+	r.Close()
+
+	var out struct {
+		Valid  bool
+		Errors []validator.ValidationError
+		Groups []validator.ErrorGroup
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("Expected one JSON document, got %s: %v", buf, err)
+	}
+	if out.Valid || len(out.Errors) != 4 || len(out.Groups) != 4 {
+		t.Errorf("Expected 4 errors in 4 groups, got %+v", out)
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	r, buf := reporter(t, SARIF, "validate")
+	v := NewValidation("docs\\contract.json", invalidResult(), "", 1)
+	v.Locate = func(pointer string) (int, int) { return 7, 9 }
+	r.Validation(v)
+	r.Failure("missing.json", "file not found")
+	if buf.Len() != 0 {
+		t.Fatalf("Expected nothing before Close, got %s", buf)
+	}
+	r.Close()
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Invalid SARIF log %s: %v", buf, err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Version != "1.2.3" {
+		t.Fatalf("Unexpected log %+v", log)
+	}
+	// Every finding is reported, whatever the limit, with the warning and
+	// the failure
+	results := log.Runs[0].Results
+	var levels []string
+	for _, res := range results {
+		levels = append(levels, res.RuleID+":"+res.Level)
+	}
+	if got := strings.Join(levels, ","); got != "type:error,type:error,:warning,:error" {
+		t.Fatalf("Unexpected results %s", got)
+	}
+	loc := results[0].Locations[0]
+	if loc.PhysicalLocation.Region.StartLine != 7 || loc.LogicalLocations[0].FullyQualifiedName != "/content/sections/0/content" {
+		t.Errorf("Unexpected location %+v", loc)
+	}
+	if rules := log.Runs[0].Tool.Driver.Rules; len(rules) != 1 || rules[0].ID != "type" {
+		t.Errorf("Expected the type rule, got %+v", rules)
+	}
+}
+
+func TestNewUnsupported(t *testing.T) {
+	if _, err := New("xml", Options{}); err == nil {
+		t.Error("Expected an error for an unsupported format")
+	}
+}
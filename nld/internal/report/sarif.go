@@ -0,0 +1,185 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/colemalphrus/nld/internal/lint"
+)
+
+// sarifSchema is the JSON schema of SARIF 2.1.0 logs
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// SARIFReporter writes the results of a run as one SARIF 2.1.0 log on
+// Close, for code scanning tools such as GitHub code scanning
+type SARIFReporter struct {
+	opts    Options
+	results []sarifResult
+	// rules are the rules of the results by ID
+	rules map[string]sarifRule
+}
+
+// SARIF log format, as far as nld uses it
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version,omitempty"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules,omitempty"`
+	}
+	sarifRule struct {
+		ID               string        `json:"id"`
+		Name             string        `json:"name,omitempty"`
+		ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId,omitempty"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations,omitempty"`
+	}
+	sarifLocation struct {
+		PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+		LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn,omitempty"`
+	}
+	sarifLogicalLocation struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+	}
+)
+
+// Levels of SARIF results
+const (
+	sarifError   = "error"
+	sarifWarning = "warning"
+	sarifNote    = "note"
+)
+
+// Validation adds the findings and warnings of a validation result. All
+// are added, whatever the number of errors reported in other formats.
+func (r *SARIFReporter) Validation(v *Validation) error {
+	for _, e := range v.result.Findings() {
+		line, column := v.locate(e.Field, e.Line, e.Column)
+		r.add(e.Rule, sarifError, e.Message, v.File, e.Field, line, column)
+	}
+	for _, w := range v.result.Warnings {
+		line, column := v.locate(w.Field, 0, 0)
+		r.add("", sarifWarning, w.Message, v.File, w.Field, line, column)
+	}
+	return nil
+}
+
+// Lint adds lint findings, with the description of their rules
+func (r *SARIFReporter) Lint(l *Lint) error {
+	if l.Error != "" {
+		return r.Failure(l.File, l.Error)
+	}
+	levels := map[lint.Severity]string{lint.Error: sarifError, lint.Warning: sarifWarning, lint.Info: sarifNote}
+	for _, f := range l.Findings {
+		line, column := l.locate(f.Path)
+		r.add(f.Rule, levels[f.Severity], f.Message, l.File, f.Path, line, column)
+	}
+	return nil
+}
+
+// Failure adds a document that could not be checked as an error without
+// a rule
+func (r *SARIFReporter) Failure(file, message string) error {
+	r.add("", sarifError, message, file, "", 0, 0)
+	return nil
+}
+
+// Summary adds nothing, as the log has every result
+func (r *SARIFReporter) Summary(valid, invalid int) error {
+	return nil
+}
+
+// Notice adds nothing, so that the output stays a SARIF log
+func (r *SARIFReporter) Notice(message string) error {
+	return nil
+}
+
+// add adds a result at a JSON pointer of a file, and its rule
+func (r *SARIFReporter) add(rule, level, message, file, pointer string, line, column int) {
+	result := sarifResult{RuleID: rule, Level: level, Message: sarifMessage{Text: message}}
+	var loc sarifLocation
+	if file != "" && file != "-" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(file)}}
+		if line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: line, StartColumn: column}
+		}
+	}
+	if pointer != "" {
+		loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: pointer}}
+	}
+	if loc.PhysicalLocation != nil || loc.LogicalLocations != nil {
+		result.Locations = []sarifLocation{loc}
+	}
+	r.results = append(r.results, result)
+
+	if rule == "" {
+		return
+	}
+	if r.rules == nil {
+		r.rules = map[string]sarifRule{}
+	}
+	if _, ok := r.rules[rule]; !ok {
+		entry := sarifRule{ID: rule}
+		if lr, ok := lint.Lookup(rule); ok && lr.ID == rule {
+			entry.Name = lr.Name
+			entry.ShortDescription = &sarifMessage{Text: lr.Description}
+		}
+		r.rules[rule] = entry
+	}
+}
+
+// Close writes the SARIF log
+func (r *SARIFReporter) Close() error {
+	driver := sarifDriver{Name: "nld", Version: r.opts.Version, InformationURI: "https://github.com/colemalphrus/nld"}
+	for _, rule := range r.rules {
+		driver.Rules = append(driver.Rules, rule)
+	}
+	sort.Slice(driver.Rules, func(i, j int) bool { return driver.Rules[i].ID < driver.Rules[j].ID })
+	results := r.results
+	if results == nil {
+		results = []sarifResult{}
+	}
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format result as SARIF: %w", err)
+	}
+	_, err = fmt.Fprintln(r.opts.Out, string(data))
+	return err
+}
@@ -0,0 +1,183 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/colors"
+	"github.com/colemalphrus/nld/internal/validator"
+)
+
+// Marks of valid and invalid documents
+const (
+	validMark   = "\u2713 "
+	invalidMark = "\u2717 "
+)
+
+// TextReporter writes results for people to read, in color when the
+// output is a terminal
+type TextReporter struct {
+	opts Options
+}
+
+// Validation prints the status of a document and its errors and warnings.
+// Schema errors are shown as a tree, or as groups when grouped.
+func (r *TextReporter) Validation(v *Validation) error {
+	if r.opts.Quiet {
+		return nil
+	}
+	out, tr := r.opts.Out, r.opts.Tr
+	if v.Valid {
+		fmt.Fprintln(out, validator.ColoredOutput(true, validMark+tr.T("validate.valid", v.File)))
+	} else {
+		n := validator.CountErrors(v.result.Errors)
+		fmt.Fprintln(out, validator.ColoredOutput(false, invalidMark+tr.N("validate.invalid", n, v.File, n)))
+		switch {
+		case v.Groups != nil:
+			for _, g := range v.Groups {
+				r.printErrorGroup(g)
+			}
+		case len(v.Tree) > 0:
+			r.printErrorTree(v.Tree, nil, 1)
+		default:
+			r.printErrors(v.Errors)
+		}
+		if v.Omitted > 0 {
+			fmt.Fprintf(out, "  %s\n", tr.T("validate.more", v.Omitted))
+		}
+		if v.Fixes > 0 {
+			fmt.Fprintf(out, "  %s\n", tr.N("validate.fixes", v.Fixes, v.Fixes))
+		}
+	}
+	for _, w := range v.Warnings {
+		fmt.Fprintf(out, "  %s\n", colors.Warning("! "+w.Message))
+	}
+	if v.Known > 0 {
+		fmt.Fprintf(out, "  %s\n", tr.N("validate.baseline.known", v.Known, v.Known))
+	}
+	return nil
+}
+
+// printErrorGroup prints a group of similar errors and where they are
+func (r *TextReporter) printErrorGroup(g validator.ErrorGroup) {
+	if len(g.Fields) == 1 {
+		r.printErrors([]validator.ValidationError{{Field: g.Field, Message: g.Message}})
+		return
+	}
+	fmt.Fprintf(r.opts.Out, "  - %s %s\n", g.Message, r.opts.Tr.T("validate.group", len(g.Fields)))
+	fmt.Fprintf(r.opts.Out, "    at %s\n", g.Location(10))
+}
+
+// printErrors prints a list of validation errors
+func (r *TextReporter) printErrors(errs []validator.ValidationError) {
+	out, tr := r.opts.Out, r.opts.Tr
+	for _, err := range errs {
+		lineInfo := ""
+		if err.Line > 0 {
+			lineInfo = tr.T("validate.line", err.Line)
+		}
+		fmt.Fprintf(out, "  - %s%s\n", lineInfo, err.Message)
+		if r.opts.Verbose && err.Field != "" {
+			fmt.Fprintf(out, "    at %s\n", err.Field)
+		}
+		for _, fix := range err.Fixes {
+			fmt.Fprintf(out, "    %s\n", tr.T("validate.fix", fix.Description))
+		}
+	}
+}
+
+// printErrorTree prints schema errors indented under the errors they
+// caused. The causes of an anyOf or oneOf are labeled with the branch they
+// failed in.
+func (r *TextReporter) printErrorTree(errs []validator.ValidationError, parent *validator.ValidationError, depth int) {
+	out, tr := r.opts.Out, r.opts.Tr
+	indent := strings.Repeat("  ", depth)
+	for i := range errs {
+		e := &errs[i]
+		// Errors that only group the errors below them have no message
+		// of their own
+		if e.Message == "" {
+			r.printErrorTree(e.Causes, parent, depth)
+			continue
+		}
+		label := ""
+		if parent != nil {
+			label = branchLabel(parent.KeywordLocation, e.KeywordLocation)
+		}
+		lineInfo := ""
+		if e.Line > 0 {
+			lineInfo = tr.T("validate.line", e.Line)
+		}
+		fmt.Fprintf(out, "%s- %s%s%s\n", indent, lineInfo, label, e.Message)
+		if r.opts.Verbose && (e.Field != "" || e.KeywordLocation != "") {
+			fmt.Fprintf(out, "%s  at %s (schema %s)\n", indent, e.Field, e.KeywordLocation)
+		}
+		for _, fix := range e.Fixes {
+			fmt.Fprintf(out, "%s  %s\n", indent, tr.T("validate.fix", fix.Description))
+		}
+		r.printErrorTree(e.Causes, e, depth+1)
+	}
+}
+
+// branchLabel returns the label of an error caused within a branch of the
+// anyOf or oneOf keyword at parent, such as "oneOf/1: ", or "" for other
+// errors
+func branchLabel(parent, keyword string) string {
+	if !strings.HasSuffix(parent, "/anyOf") && !strings.HasSuffix(parent, "/oneOf") {
+		return ""
+	}
+	rest := strings.TrimPrefix(keyword, parent+"/")
+	if rest == keyword {
+		return ""
+	}
+	branch := strings.SplitN(rest, "/", 2)[0]
+	return parent[strings.LastIndex(parent, "/")+1:] + "/" + branch + ": "
+}
+
+// Lint prints the lint findings of a document
+func (r *TextReporter) Lint(l *Lint) error {
+	out, tr := r.opts.Out, r.opts.Tr
+	switch {
+	case l.Error != "":
+		fmt.Fprintln(out, validator.ColoredOutput(false, fmt.Sprintf("%s: %s", l.File, l.Error)))
+	case len(l.Findings) == 0:
+		if !r.opts.Quiet {
+			fmt.Fprintln(out, validator.ColoredOutput(true, tr.T("lint.clean", l.File)))
+		}
+	default:
+		fmt.Fprintln(out, validator.ColoredOutput(!l.Failed(), tr.N("lint.findings", len(l.Findings), l.File, len(l.Findings))))
+		for _, f := range l.Findings {
+			fmt.Fprintf(out, "  - %s\n", f)
+		}
+	}
+	return nil
+}
+
+// Failure prints a document that could not be checked
+func (r *TextReporter) Failure(file, message string) error {
+	if !r.opts.Quiet {
+		fmt.Fprintf(r.opts.Out, "%s%s: %s\n", invalidMark, file, message)
+	}
+	return nil
+}
+
+// Summary prints the number of valid and invalid documents
+func (r *TextReporter) Summary(valid, invalid int) error {
+	if !r.opts.Quiet && valid+invalid > 1 {
+		fmt.Fprintf(r.opts.Out, "\n%s\n", r.opts.Tr.T("validate.summary", valid, invalid))
+	}
+	return nil
+}
+
+// Notice prints a message about the run
+func (r *TextReporter) Notice(message string) error {
+	if !r.opts.Quiet {
+		fmt.Fprintln(r.opts.Out, validator.ColoredOutput(true, message))
+	}
+	return nil
+}
+
+// Close does nothing, as results are printed as they are reported
+func (r *TextReporter) Close() error {
+	return nil
+}
@@ -0,0 +1,63 @@
+// Package retention checks documents against their retention period and
+// legal hold, to find those that may be deleted
+package retention
+
+import (
+	"time"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Statuses of an entry
+const (
+	// Retained documents must still be kept: their retention period has
+	// not ended, or they have none
+	Retained = "retained"
+	// Expired documents are past their retention period and may be deleted
+	Expired = "expired"
+	// Held documents are under legal hold and must be kept whatever their
+	// retention period
+	Held = "held"
+)
+
+// Entry is the retention status of a document
+type Entry struct {
+	// Document is the store ID or file of the document
+	Document string `json:"document"`
+	Title    string `json:"title,omitempty"`
+	Status   string `json:"status"`
+	// RetainUntil is the end of the retention period, nil for documents
+	// kept indefinitely
+	RetainUntil *time.Time `json:"retainUntil,omitempty"`
+	HoldReason  string     `json:"holdReason,omitempty"`
+}
+
+// Check returns the retention status of a document at now, the current
+// time when zero. The error is that of an invalid retention date.
+func Check(name string, doc *nld.Document, now time.Time) (*Entry, error) {
+	if now.IsZero() {
+		now = time.Now()
+	}
+	until, err := doc.Metadata.RetainUntil()
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{Document: name, Title: doc.Metadata.Title, Status: Retained}
+	if !until.IsZero() {
+		e.RetainUntil = &until
+	}
+	switch {
+	case doc.Metadata.LegalHold():
+		e.Status = Held
+		e.HoldReason = doc.Metadata.Retention.HoldReason
+	case !until.IsZero() && until.Before(now):
+		e.Status = Expired
+	}
+	return e, nil
+}
+
+// Lapsed reports whether the retention period of the document has ended,
+// whether or not it is held
+func (e *Entry) Lapsed(now time.Time) bool {
+	return e.RetainUntil != nil && e.RetainUntil.Before(now)
+}
@@ -0,0 +1,50 @@
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		retention *nld.Retention
+		timezone  string
+		expect    string
+		lapsed    bool
+		err       bool
+	}{
+		{name: "No Retention", expect: Retained},
+		{name: "Retained", retention: &nld.Retention{RetainUntil: "2030-06-30"}, expect: Retained},
+		{name: "Expired", retention: &nld.Retention{RetainUntil: "2029-12-31"}, expect: Expired, lapsed: true},
+		{name: "Time Zone", retention: &nld.Retention{RetainUntil: "2030-01-01"}, timezone: "America/New_York", expect: Retained},
+		{name: "Held", retention: &nld.Retention{RetainUntil: "2020-01-01", LegalHold: true, HoldReason: "Smith v. XYZ"}, expect: Held, lapsed: true},
+		{name: "Held Indefinitely", retention: &nld.Retention{LegalHold: true}, expect: Held},
+		{name: "Invalid Date", retention: &nld.Retention{RetainUntil: "31/12/2029"}, err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := &nld.Document{Metadata: nld.Metadata{Type: "contract", Title: "Lease", Timezone: tc.timezone, Retention: tc.retention}}
+			e, err := Check("lease", doc, now)
+			if tc.err {
+				if err == nil {
+					t.Errorf("Check succeeded with %+v", e)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Check failed: %v", err)
+			}
+			if e.Status != tc.expect || e.Lapsed(now) != tc.lapsed || e.Document != "lease" || e.Title != "Lease" {
+				t.Errorf("Check = %+v, lapsed %v", e, e.Lapsed(now))
+			}
+			if tc.expect == Held && e.HoldReason != tc.retention.HoldReason {
+				t.Errorf("hold reason = %q", e.HoldReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Package risk scores how risky a document is for triage: every lint
+// finding, playbook deviation and clause a profile requires but the
+// document lacks adds points, and the total is graded from A to F
+package risk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/colemalphrus/nld/internal/lint"
+	"github.com/colemalphrus/nld/internal/playbook"
+	"github.com/colemalphrus/nld/internal/profile"
+	"gopkg.in/yaml.v3"
+)
+
+// Max is the highest score; totals above it are capped
+const Max = 100
+
+// Sources of a factor
+const (
+	Lint     = "lint"
+	Playbook = "playbook"
+	Profile  = "profile"
+)
+
+// Config is the weights of the scoring, read from a YAML or JSON file.
+// Weights the file leaves out keep their defaults:
+//
+//	severities: {error: 10, warning: 3, info: 1}
+//	rules: {NLD1001: 25}
+//	playbook: {deviation: 15, missing: 20, fallback: 2, unlisted: 0}
+//	missing: 20
+//	grades:
+//	  - {grade: A, max: 10}
+//	  - {grade: B, max: 25}
+//	  - {grade: C, max: 50}
+//	  - {grade: D, max: 75}
+type Config struct {
+	// Severities are the points of a lint finding by its severity
+	Severities map[string]float64 `yaml:"severities"`
+	// Rules are the points of the findings of lint rules by ID, instead
+	// of those of their severity
+	Rules map[string]float64 `yaml:"rules"`
+	// Playbook are the points of a clause by its playbook status
+	Playbook map[string]float64 `yaml:"playbook"`
+	// Missing are the points of each field, section or clause a profile
+	// requires but the document lacks
+	Missing float64 `yaml:"missing"`
+	// Grades are the highest score of each grade, from the best; higher
+	// scores are graded F
+	Grades []Grade `yaml:"grades"`
+}
+
+// Grade is the highest score of a grade
+type Grade struct {
+	Grade string  `yaml:"grade"`
+	Max   float64 `yaml:"max"`
+}
+
+// Input is what a document is scored on
+type Input struct {
+	Findings []lint.Finding
+	// Playbook are the results of a playbook check, if any
+	Playbook []playbook.Result
+	// Problems are those of the profile of the document, if any
+	Problems []profile.Problem
+}
+
+// Factor is one contribution to a score
+type Factor struct {
+	Source string `json:"source"`
+	// ID is the lint rule or clause ID; Path locates the finding or
+	// problem
+	ID      string  `json:"id,omitempty"`
+	Path    string  `json:"path,omitempty"`
+	Message string  `json:"message"`
+	Points  float64 `json:"points"`
+}
+
+// Score is the risk of a document, from 0 to Max
+type Score struct {
+	Score float64 `json:"score"`
+	Grade string  `json:"grade"`
+	// Factors are the contributions, highest first
+	Factors []Factor `json:"factors,omitempty"`
+}
+
+// Default returns the default weights
+func Default() *Config {
+	return &Config{
+		Severities: map[string]float64{string(lint.Error): 10, string(lint.Warning): 3, string(lint.Info): 1},
+		Rules:      map[string]float64{},
+		Playbook:   map[string]float64{playbook.Deviation: 15, playbook.Missing: 20, playbook.Fallback: 2, playbook.Unlisted: 0},
+		Missing:    20,
+		Grades:     []Grade{{"A", 10}, {"B", 25}, {"C", 50}, {"D", 75}},
+	}
+}
+
+// Parse reads weights from YAML or JSON over the defaults
+func Parse(data []byte) (*Config, error) {
+	c := Default()
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid risk weights: %w", err)
+	}
+	for i, g := range c.Grades {
+		if g.Grade == "" {
+			return nil, fmt.Errorf("invalid risk weights: grade %d has no name", i+1)
+		}
+		if i > 0 && g.Max <= c.Grades[i-1].Max {
+			return nil, fmt.Errorf("invalid risk weights: grade %s must allow a higher score than %s", g.Grade, c.Grades[i-1].Grade)
+		}
+	}
+	return c, nil
+}
+
+// Load reads weights from a file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk weights: %w", err)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Score scores a document
+func (c *Config) Score(in Input) *Score {
+	var factors []Factor
+	add := func(f Factor) {
+		if f.Points != 0 {
+			factors = append(factors, f)
+		}
+	}
+	for _, f := range in.Findings {
+		points, ok := c.Rules[f.Rule]
+		if !ok {
+			points = c.Severities[string(f.Severity)]
+		}
+		add(Factor{Source: Lint, ID: f.Rule, Path: f.Path, Message: f.Message, Points: points})
+	}
+	for _, r := range in.Playbook {
+		add(Factor{Source: Playbook, ID: r.ID, Message: "clause " + r.Status, Points: c.Playbook[r.Status]})
+	}
+	for _, p := range in.Problems {
+		add(Factor{Source: Profile, Path: p.Path, Message: p.Message, Points: c.Missing})
+	}
+	sort.SliceStable(factors, func(i, j int) bool { return factors[i].Points > factors[j].Points })
+
+	total := 0.0
+	for _, f := range factors {
+		total += f.Points
+	}
+	total = math.Min(math.Max(total, 0), Max)
+	return &Score{Score: total, Grade: c.grade(total), Factors: factors}
+}
+
+// grade returns the grade of a score
+func (c *Config) grade(score float64) string {
+	for _, g := range c.Grades {
+		if score <= g.Max {
+			return g.Grade
+		}
+	}
+	return "F"
+}
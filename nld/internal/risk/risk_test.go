@@ -0,0 +1,62 @@
+package risk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/lint"
+	"github.com/colemalphrus/nld/internal/playbook"
+	"github.com/colemalphrus/nld/internal/profile"
+)
+
+func TestScore(t *testing.T) {
+	in := Input{
+		Findings: []lint.Finding{
+			{Rule: "NLD1001", Severity: lint.Error, Path: "/content/sections/0"},
+			{Rule: "NLD2003", Severity: lint.Warning, Path: "/content/sections/1"},
+		},
+		Playbook: []playbook.Result{
+			{ID: "payment", Status: playbook.Approved},
+			{ID: "liability", Status: playbook.Deviation},
+		},
+		Problems: []profile.Problem{{Path: "/content/sections", Message: "requires a governing law clause"}},
+	}
+
+	s := Default().Score(in)
+	if s.Score != 48 || s.Grade != "C" {
+		t.Errorf("Expected 48 (C), got %v (%s)", s.Score, s.Grade)
+	}
+	if len(s.Factors) != 4 || s.Factors[0].Source != Profile || s.Factors[3].ID != "NLD2003" {
+		t.Errorf("Expected factors highest first, got %+v", s.Factors)
+	}
+
+	c, err := Parse([]byte("rules: {NLD1001: 100}\ngrades: [{grade: low, max: 50}, {grade: high, max: 90}]"))
+	if err != nil {
+		t.Fatalf("Failed to parse weights: %v", err)
+	}
+	if c.Severities["warning"] != 3 {
+		t.Error("Expected weights left out to keep their defaults")
+	}
+	if s := c.Score(in); s.Score != Max || s.Grade != "F" {
+		t.Errorf("Expected a capped score graded F, got %v (%s)", s.Score, s.Grade)
+	}
+	if s := c.Score(Input{}); s.Score != 0 || s.Grade != "low" || len(s.Factors) != 0 {
+		t.Errorf("Expected no risk, got %+v", s)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := map[string]string{
+		"grades: [{grade: A, max: 20}, {grade: B, max: 10}]": "must allow a higher score",
+		"grades: [{max: 20}]":                                "has no name",
+		"weights: {}":                                        "field weights not found",
+	}
+	for data, expected := range testCases {
+		if _, err := Parse([]byte(data)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Parse(%q) = %v, expected %q", data, err, expected)
+		}
+	}
+	if _, err := Parse(nil); err != nil {
+		t.Errorf("Expected empty weights to be the defaults, got %v", err)
+	}
+}
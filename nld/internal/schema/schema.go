@@ -1,13 +1,22 @@
 package schema
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/metrics"
+	"github.com/colemalphrus/nld/internal/tracing"
 	"github.com/colemalphrus/nld/internal/validator"
+	"github.com/colemalphrus/nld/schemas"
 	"github.com/santhosh-tekuri/jsonschema/v5"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Schema represents an NLD document schema
@@ -19,6 +28,14 @@ type Schema struct {
 
 // Load loads a schema from a file
 func Load(path string) (*Schema, error) {
+	return LoadContext(context.Background(), path)
+}
+
+// LoadContext is Load traced as a child of the span of ctx
+func LoadContext(ctx context.Context, path string) (s *Schema, err error) {
+	_, span := tracing.Span(ctx, "nld.schema.load", attribute.String("nld.schema.path", path))
+	defer func() { tracing.End(span, err) }()
+
 	// Check if the file exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, fmt.Errorf("schema file not found: %s", path)
@@ -52,8 +69,20 @@ func Load(path string) (*Schema, error) {
 
 // Validate validates a document against this schema
 func (s *Schema) Validate(document []byte) (*validator.ValidationResult, error) {
+	return s.ValidateContext(context.Background(), document)
+}
+
+// ValidateContext is Validate traced as a child of the span of ctx
+func (s *Schema) ValidateContext(ctx context.Context, document []byte) (*validator.ValidationResult, error) {
 	v := validator.New()
-	return v.ValidateBytes(document, s.Compiled)
+	return v.ValidateBytesContext(ctx, document, s.Compiled)
+}
+
+// ValidateReader validates a document read from r against this schema
+// without decoding it into memory as a whole, for very large documents
+func (s *Schema) ValidateReader(r io.Reader) (*validator.ValidationResult, error) {
+	v := validator.New()
+	return v.ValidateReader(r, s.Compiled)
 }
 
 // GetDocumentSchema returns the appropriate schema for a document
@@ -64,6 +93,18 @@ func GetDocumentSchema(docPath string) (*Schema, error) {
 		return nil, fmt.Errorf("failed to read document: %w", err)
 	}
 
+	return GetDocumentSchemaFromBytes(data)
+}
+
+// GetDocumentSchemaFromBytes returns the appropriate schema for a document
+// that has already been read into memory
+func GetDocumentSchemaFromBytes(data []byte) (*Schema, error) {
+	return GetDocumentSchemaFromBytesContext(context.Background(), data)
+}
+
+// GetDocumentSchemaFromBytesContext is GetDocumentSchemaFromBytes traced as
+// a child of the span of ctx
+func GetDocumentSchemaFromBytesContext(ctx context.Context, data []byte) (*Schema, error) {
 	// Parse the document to extract the type
 	var doc struct {
 		Metadata struct {
@@ -72,12 +113,26 @@ func GetDocumentSchema(docPath string) (*Schema, error) {
 	}
 
 	if err := json.Unmarshal(data, &doc); err != nil {
+		if line, column := document.ErrorPosition(err, data); line > 0 {
+			return nil, fmt.Errorf("invalid JSON in document at line %d, column %d: %w", line, column, err)
+		}
 		return nil, fmt.Errorf("invalid JSON in document: %w", err)
 	}
 
+	return ForTypeContext(ctx, doc.Metadata.Type)
+}
+
+// ForType returns the schema of a document type, or the default schema
+// for types without a schema of their own
+func ForType(docType string) (*Schema, error) {
+	return ForTypeContext(context.Background(), docType)
+}
+
+// ForTypeContext is ForType traced as a child of the span of ctx
+func ForTypeContext(ctx context.Context, docType string) (*Schema, error) {
 	// Get the schema path for this document type
 	v := validator.New()
-	schemaPath, err := v.GetSchemaForDocumentType(doc.Metadata.Type)
+	schemaPath, err := v.GetSchemaForDocumentType(docType)
 	if err != nil {
 		// If we can't determine the type, use the default schema
 		execPath, err := os.Executable()
@@ -89,8 +144,65 @@ func GetDocumentSchema(docPath string) (*Schema, error) {
 		schemaPath = filepath.Join(baseDir, "schemas/document-v1.json")
 	}
 
+	// Installs without a schemas directory, as with go install, use the
+	// schemas built into nld
+	if _, err := os.Stat(schemaPath); os.IsNotExist(err) {
+		return EmbeddedContext(ctx, docType)
+	}
+
 	// Load the schema
-	return Load(schemaPath)
+	return LoadContext(ctx, schemaPath)
+}
+
+// embedded holds the compiled embedded schemas by file name, as compiling
+// a schema takes longer than validating most documents
+var embedded sync.Map
+
+// Embedded returns the schema built into nld for a document type. It
+// needs no files, so it also works where there is no file system. The
+// schemas are compiled once and shared, so callers must not change them.
+func Embedded(docType string) (*Schema, error) {
+	return EmbeddedContext(context.Background(), docType)
+}
+
+// EmbeddedContext is Embedded traced as a child of the span of ctx
+func EmbeddedContext(ctx context.Context, docType string) (*Schema, error) {
+	name := validator.SchemaFile(docType)
+	_, span := tracing.Span(ctx, "nld.schema.load", attribute.String("nld.schema.path", name))
+	s, ok := embedded.Load(name)
+	metrics.Cache("schema", ok)
+	span.SetAttributes(attribute.Bool("nld.cache.hit", ok))
+	if ok {
+		span.End()
+		return s.(*Schema), nil
+	}
+	compiled, err := compileEmbedded(name)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	s, _ = embedded.LoadOrStore(name, compiled)
+	return s.(*Schema), nil
+}
+
+// compileEmbedded compiles the embedded schema file name
+func compileEmbedded(name string) (*Schema, error) {
+	data, err := schemas.FS.ReadFile(strings.TrimPrefix(name, "schemas/"))
+	if err != nil {
+		return nil, fmt.Errorf("no embedded schema %s: %w", name, err)
+	}
+
+	v := validator.New()
+	compiled, err := v.LoadSchemaBytes(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{
+		Path:     name,
+		RawData:  data,
+		Compiled: compiled,
+	}, nil
 }
 
 // GetSchemaVersion extracts the version from a schema file
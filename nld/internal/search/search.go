@@ -0,0 +1,318 @@
+package search
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/readability"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Highlight marks the matched words of a snippet
+const (
+	HighlightStart = "["
+	HighlightEnd   = "]"
+)
+
+// BM25 parameters: k1 limits the weight of repeated words and b how much
+// long sections are penalized
+const (
+	k1 = 1.2
+	b  = 0.75
+	// titleWeight counts a word in a title as this many words of text
+	titleWeight = 2
+	// snippetWords is the length of a snippet in words
+	snippetWords = 16
+)
+
+// Document is a parsed NLD file
+type Document struct {
+	Path string
+	Doc  *nld.Document
+}
+
+// Hit is a section or definition matching a query
+type Hit struct {
+	// Path is the file of the document
+	Path string `json:"path"`
+	// Document is the title of the document
+	Document string `json:"document,omitempty"`
+	// Section is the section ID, empty for definitions
+	Section string `json:"section,omitempty"`
+	// Title is the section title or the defined term
+	Title string  `json:"title"`
+	Score float64 `json:"score"`
+	// Snippet is the matching text, with the matched words between
+	// HighlightStart and HighlightEnd
+	Snippet string `json:"snippet"`
+}
+
+// Load parses the NLD documents under dir. Files that are not NLD
+// documents, such as encrypted documents or other JSON, are returned as
+// skipped. Hidden directories are not searched.
+func Load(dir string) (docs []Document, skipped []string, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		doc, err := nld.Parse(data)
+		if err != nil || doc.Metadata.Type == "" {
+			skipped = append(skipped, path)
+			return nil
+		}
+		docs = append(docs, Document{Path: path, Doc: doc})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return docs, skipped, nil
+}
+
+// unit is a section or definition being searched
+type unit struct {
+	hit   Hit
+	title []token
+	text  string
+	words []token
+}
+
+// token is a word of a text and where it is
+type token struct {
+	word       string
+	start, end int
+}
+
+var (
+	wordRe   = regexp.MustCompile(`[\p{L}\p{N}]+`)
+	phraseRe = regexp.MustCompile(`"([^"]*)"`)
+)
+
+// tokenize returns the words of text, in lower case
+func tokenize(text string) []token {
+	var tokens []token
+	for _, loc := range wordRe.FindAllStringIndex(text, -1) {
+		tokens = append(tokens, token{word: strings.ToLower(text[loc[0]:loc[1]]), start: loc[0], end: loc[1]})
+	}
+	return tokens
+}
+
+// Search returns the sections and definitions containing every word and
+// "quoted phrase" of query, best match first. Matches are ranked with BM25,
+// which favours rare words and short sections; words in titles count
+// double.
+func Search(docs []Document, query string) []Hit {
+	var phrases [][]string
+	for _, m := range phraseRe.FindAllStringSubmatch(query, -1) {
+		if words := words(m[1]); len(words) > 0 {
+			phrases = append(phrases, words)
+		}
+	}
+	terms := unique(words(query))
+	if len(terms) == 0 {
+		return []Hit{}
+	}
+
+	units := collect(docs)
+	var total float64
+	df := map[string]int{}
+	for _, u := range units {
+		total += float64(len(u.words) + titleWeight*len(u.title))
+		seen := map[string]bool{}
+		for _, t := range append(append([]token(nil), u.title...), u.words...) {
+			if !seen[t.word] {
+				seen[t.word] = true
+				df[t.word]++
+			}
+		}
+	}
+	avg := total / math.Max(1, float64(len(units)))
+
+	hits := []Hit{}
+	for _, u := range units {
+		tf := map[string]float64{}
+		for _, t := range u.title {
+			tf[t.word] += titleWeight
+		}
+		for _, t := range u.words {
+			tf[t.word]++
+		}
+		if !containsAll(tf, terms) || !containsPhrases(u, phrases) {
+			continue
+		}
+		length := float64(len(u.words) + titleWeight*len(u.title))
+		score := 0.0
+		for _, term := range terms {
+			n := float64(df[term])
+			idf := math.Log(1 + (float64(len(units))-n+0.5)/(n+0.5))
+			score += idf * tf[term] * (k1 + 1) / (tf[term] + k1*(1-b+b*length/avg))
+		}
+		u.hit.Score = math.Round(score*1000) / 1000
+		u.hit.Snippet = snippet(u.text, u.words, terms)
+		hits = append(hits, u.hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Path != hits[j].Path {
+			return hits[i].Path < hits[j].Path
+		}
+		return hits[i].Section < hits[j].Section
+	})
+	return hits
+}
+
+// collect returns the sections and definitions of the documents, in
+// document order
+func collect(docs []Document) []*unit {
+	var units []*unit
+	add := func(d Document, section, title, text string) {
+		units = append(units, &unit{
+			hit:   Hit{Path: d.Path, Document: d.Doc.Metadata.Title, Section: section, Title: title},
+			title: tokenize(title),
+			text:  text,
+			words: tokenize(text),
+		})
+	}
+	for _, d := range docs {
+		var walk func(sections []nld.Section)
+		walk = func(sections []nld.Section) {
+			for _, s := range sections {
+				add(d, s.ID, s.Title, strings.Join(readability.Prose(s), "\n\n"))
+				walk(s.Sections)
+			}
+		}
+		walk(d.Doc.Structure.Sections)
+		for _, def := range d.Doc.Structure.Definitions {
+			add(d, "", def.Term, def.Definition)
+		}
+	}
+	return units
+}
+
+// words returns the words of text in lower case
+func words(text string) []string {
+	var list []string
+	for _, t := range tokenize(text) {
+		list = append(list, t.word)
+	}
+	return list
+}
+
+func unique(list []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func containsAll(tf map[string]float64, terms []string) bool {
+	for _, term := range terms {
+		if tf[term] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// containsPhrases reports whether the title or text of u contains every
+// phrase as consecutive words
+func containsPhrases(u *unit, phrases [][]string) bool {
+	for _, phrase := range phrases {
+		if !hasPhrase(u.title, phrase) && !hasPhrase(u.words, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasPhrase(tokens []token, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		match := true
+		for j, word := range phrase {
+			if tokens[i+j].word != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet returns the words of text around the first matched term, with
+// the matched terms highlighted, or the start of text when only the title
+// matches
+func snippet(text string, tokens []token, terms []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	matched := map[string]bool{}
+	for _, term := range terms {
+		matched[term] = true
+	}
+	first := 0
+	for i, t := range tokens {
+		if matched[t.word] {
+			first = i
+			break
+		}
+	}
+	from := first - snippetWords/3
+	if from < 0 {
+		from = 0
+	}
+	to := from + snippetWords
+	if to > len(tokens) {
+		to = len(tokens)
+	}
+
+	var s strings.Builder
+	if from > 0 {
+		s.WriteString("…")
+	}
+	pos := tokens[from].start
+	for _, t := range tokens[from:to] {
+		s.WriteString(text[pos:t.start])
+		if matched[t.word] {
+			s.WriteString(HighlightStart + text[t.start:t.end] + HighlightEnd)
+		} else {
+			s.WriteString(text[t.start:t.end])
+		}
+		pos = t.end
+	}
+	if to < len(tokens) {
+		s.WriteString("…")
+	} else {
+		s.WriteString(text[pos:])
+	}
+	return strings.Join(strings.Fields(s.String()), " ")
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"lease.json": `{"metadata": {"type": "contract", "title": "Lease"}, "structure": {
+			"sections": [
+				{"id": "rent", "title": "Rent", "content": "The tenant pays rent monthly, and rent is due on the first day of the month."},
+				{"id": "indemnity", "title": "Indemnification", "content": "The tenant shall indemnify the landlord against all claims arising from the use of the premises by the tenant or its guests."}
+			],
+			"definitions": [{"term": "Premises", "definition": "The apartment leased to the tenant, including its storage space."}]}}`,
+		"sub/nda.json":     `{"metadata": {"type": "contract", "title": "NDA"}, "structure": {"sections": [{"id": "remedies", "title": "Remedies", "content": "The recipient shall indemnify the discloser."}]}}`,
+		"package.json":     `{"name": "not-a-document"}`,
+		".git/config.json": `{"metadata": {"type": "contract"}}`,
+		"notes.txt":        `indemnify`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	docs, skipped, err := Load(dir)
+	if err != nil || len(docs) != 2 || len(skipped) != 1 || filepath.Base(skipped[0]) != "package.json" {
+		t.Fatalf("Load() = %d documents, skipped %v, %v", len(docs), skipped, err)
+	}
+
+	hits := Search(docs, "indemnify")
+	if len(hits) != 2 {
+		t.Fatalf("Search() = %+v", hits)
+	}
+	// The shorter section ranks first
+	if filepath.Base(hits[0].Path) != "nda.json" || hits[0].Section != "remedies" || hits[0].Score <= hits[1].Score {
+		t.Errorf("Search() ranking = %+v", hits)
+	}
+	if hits[1].Snippet != "The tenant shall [indemnify] the landlord against all claims arising from the use of the premises…" {
+		t.Errorf("Search() snippet = %q", hits[1].Snippet)
+	}
+
+	if hits = Search(docs, "tenant premises"); len(hits) != 2 || hits[0].Section != "" || hits[0].Title != "Premises" {
+		t.Errorf("Search() of a defined term = %+v", hits)
+	}
+	if hits = Search(docs, `"rent is due"`); len(hits) != 1 || hits[0].Section != "rent" {
+		t.Errorf("Search() of a phrase = %+v", hits)
+	}
+	if hits = Search(docs, `"due rent"`); len(hits) != 0 {
+		t.Errorf("Search() of a missing phrase = %+v", hits)
+	}
+	if hits = Search(docs, "indemnification"); len(hits) != 1 || !strings.HasPrefix(hits[0].Snippet, "The tenant shall indemnify") {
+		t.Errorf("Search() of a title = %+v", hits)
+	}
+}
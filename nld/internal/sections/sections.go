@@ -0,0 +1,350 @@
+package sections
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+// relationshipKinds are the relationship lists whose source and target
+// fields refer to section IDs
+var relationshipKinds = []string{"dependencies", "references"}
+
+// anchorKeys are the top-level lists whose items are anchored at a section
+// by the ID in their section field: annotations and the proposed changes
+// of a redline
+var anchorKeys = []string{"annotations", "redline"}
+
+// wordRe matches the words of the predicates and effects of conditions,
+// which name sections by ID as nld graph reads them
+var wordRe = regexp.MustCompile(`[A-Za-z0-9_][A-Za-z0-9_.-]*[A-Za-z0-9_]|[A-Za-z0-9_]`)
+
+// sectionReference returns the ID of the section a word of a condition
+// refers to, for which known is true, and the rest of the word. The word
+// is either the ID or a dotted reference starting with it, such as
+// scope.completed; IDs with dots of their own are matched longest first.
+func sectionReference(word string, known func(id string) bool) (id, rest string, ok bool) {
+	for end := len(word); end > 0; end = strings.LastIndex(word[:end], ".") {
+		if known(word[:end]) {
+			return word[:end], word[end:], true
+		}
+	}
+	return "", "", false
+}
+
+// referenceList is a list of items that may refer to sections
+type referenceList struct {
+	// path is the path of the list in the document
+	path  string
+	items []interface{}
+	// refers reports whether an item refers to one of a set of IDs and
+	// rename renames the IDs it refers to
+	refers func(item map[string]interface{}, ids map[string]bool) bool
+	rename func(item map[string]interface{}, renamed map[string]string)
+	// set replaces the list
+	set func(items []interface{})
+}
+
+// referenceLists returns the lists of doc whose items refer to sections:
+// relationships by source and target, conditions in their predicate and
+// effect, and annotations and redlines by the section they are anchored at
+func referenceLists(doc document.Document) []referenceList {
+	var lists []referenceList
+	rel, _ := doc["relationships"].(map[string]interface{})
+	for _, kind := range relationshipKinds {
+		items, ok := rel[kind].([]interface{})
+		if !ok {
+			continue
+		}
+		kind := kind
+		lists = append(lists, referenceList{
+			path:  "relationships." + kind,
+			items: items,
+			refers: func(item map[string]interface{}, ids map[string]bool) bool {
+				return ids[document.String(item, "source")] || ids[document.String(item, "target")]
+			},
+			rename: func(item map[string]interface{}, renamed map[string]string) {
+				renameFields(item, renamed, "source", "target")
+			},
+			set: func(items []interface{}) { rel[kind] = items },
+		})
+	}
+
+	if items, ok := rel["conditions"].([]interface{}); ok {
+		lists = append(lists, referenceList{
+			path:  "relationships.conditions",
+			items: items,
+			refers: func(item map[string]interface{}, ids map[string]bool) bool {
+				for _, field := range []string{"predicate", "effect"} {
+					for _, word := range wordRe.FindAllString(document.String(item, field), -1) {
+						if _, _, ok := sectionReference(word, func(id string) bool { return ids[id] }); ok {
+							return true
+						}
+					}
+				}
+				return false
+			},
+			rename: func(item map[string]interface{}, renamed map[string]string) {
+				for _, field := range []string{"predicate", "effect"} {
+					if text, ok := item[field].(string); ok {
+						item[field] = wordRe.ReplaceAllStringFunc(text, func(word string) string {
+							id, rest, ok := sectionReference(word, func(id string) bool {
+								_, ok := renamed[id]
+								return ok
+							})
+							if !ok {
+								return word
+							}
+							return renamed[id] + rest
+						})
+					}
+				}
+			},
+			set: func(items []interface{}) { rel["conditions"] = items },
+		})
+	}
+
+	for _, key := range anchorKeys {
+		items, ok := doc[key].([]interface{})
+		if !ok {
+			continue
+		}
+		key := key
+		lists = append(lists, referenceList{
+			path:  key,
+			items: items,
+			refers: func(item map[string]interface{}, ids map[string]bool) bool {
+				return ids[document.String(item, "section")]
+			},
+			rename: func(item map[string]interface{}, renamed map[string]string) {
+				renameFields(item, renamed, "section")
+			},
+			set: func(items []interface{}) {
+				if len(items) == 0 {
+					delete(doc, key)
+					return
+				}
+				doc[key] = items
+			},
+		})
+	}
+	return lists
+}
+
+// renameFields renames the section IDs in the fields of item
+func renameFields(item map[string]interface{}, renamed map[string]string, fields ...string) {
+	for _, field := range fields {
+		if newID, ok := renamed[document.String(item, field)]; ok {
+			item[field] = newID
+		}
+	}
+}
+
+// sectionIDs returns the IDs of a section and of its subsections
+func sectionIDs(section map[string]interface{}) map[string]bool {
+	ids := map[string]bool{}
+	var walk func(s map[string]interface{})
+	walk = func(s map[string]interface{}) {
+		if id := document.String(s, "id"); id != "" {
+			ids[id] = true
+		}
+		for _, sub := range document.Subsections(s) {
+			walk(sub)
+		}
+	}
+	walk(section)
+	return ids
+}
+
+// The functions in this package operate on top-level sections; nested
+// subsections move with their parent.
+
+// Position selects where a section is placed. At most one field should be
+// set; the zero value means the end of the document.
+type Position struct {
+	Before string
+	After  string
+	// Index is a 1-based position
+	Index int
+}
+
+// Index returns the position of the section with the given ID, or -1
+func Index(doc document.Document, id string) int {
+	for i, s := range doc.Sections() {
+		if document.String(s, "id") == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add inserts a new section at pos
+func Add(doc document.Document, section map[string]interface{}, pos Position) error {
+	id := document.String(section, "id")
+	if id == "" {
+		return fmt.Errorf("section id is required")
+	}
+	if Index(doc, id) != -1 {
+		return fmt.Errorf("section already exists: %s", id)
+	}
+
+	list := doc.Sections()
+	at, err := resolve(list, pos)
+	if err != nil {
+		return err
+	}
+	doc.SetSections(insert(list, at, section))
+	return nil
+}
+
+// Move relocates the section with the given ID to pos. Relationships refer
+// to sections by ID, so they are unaffected.
+func Move(doc document.Document, id string, pos Position) error {
+	from := Index(doc, id)
+	if from == -1 {
+		return fmt.Errorf("section not found: %s", id)
+	}
+	if pos.Before == id || pos.After == id {
+		return fmt.Errorf("cannot move section %s relative to itself", id)
+	}
+
+	list := doc.Sections()
+	section := list[from]
+	rest := append(append([]map[string]interface{}{}, list[:from]...), list[from+1:]...)
+
+	at, err := resolve(rest, pos)
+	if err != nil {
+		return err
+	}
+	doc.SetSections(insert(rest, at, section))
+	return nil
+}
+
+// Remove deletes the section with the given ID and its subsections. If
+// relationships, conditions, annotations or redlines refer to them, the
+// section is only removed when force is set, in which case those are
+// removed too. The number of removed references is returned.
+func Remove(doc document.Document, id string, force bool) (int, error) {
+	at := Index(doc, id)
+	if at == -1 {
+		return 0, fmt.Errorf("section not found: %s", id)
+	}
+
+	list := doc.Sections()
+	ids := sectionIDs(list[at])
+	refs := references(doc, ids)
+	if len(refs) > 0 && !force {
+		return 0, fmt.Errorf("section %s is referenced by %s (use --force to remove it and what refers to it)", id, strings.Join(refs, ", "))
+	}
+
+	doc.SetSections(append(list[:at], list[at+1:]...))
+
+	removed := 0
+	for _, l := range referenceLists(doc) {
+		kept := make([]interface{}, 0, len(l.items))
+		for _, item := range l.items {
+			if obj, ok := item.(map[string]interface{}); ok && l.refers(obj, ids) {
+				removed++
+				continue
+			}
+			kept = append(kept, item)
+		}
+		if len(kept) != len(l.items) {
+			l.set(kept)
+		}
+	}
+	return removed, nil
+}
+
+// References returns the relationships, conditions, annotations and
+// redlines that refer to the section
+func References(doc document.Document, id string) []string {
+	return references(doc, map[string]bool{id: true})
+}
+
+// references returns the paths of the items that refer to one of ids
+func references(doc document.Document, ids map[string]bool) []string {
+	var refs []string
+	for _, l := range referenceLists(doc) {
+		for i, item := range l.items {
+			if obj, ok := item.(map[string]interface{}); ok && l.refers(obj, ids) {
+				refs = append(refs, fmt.Sprintf("%s[%d]", l.path, i))
+			}
+		}
+	}
+	return refs
+}
+
+// Renumber renames every top-level section to prefix followed by its
+// 1-based position and rewrites the relationships, conditions, annotations
+// and redlines that refer to them to use the new IDs. It returns the
+// mapping from old to new IDs for sections that were renamed.
+func Renumber(doc document.Document, prefix string) (map[string]string, error) {
+	if prefix == "" {
+		return nil, fmt.Errorf("prefix is required")
+	}
+
+	list := doc.Sections()
+	renamed := make(map[string]string)
+	for i, s := range list {
+		oldID := document.String(s, "id")
+		newID := fmt.Sprintf("%s%d", prefix, i+1)
+		if oldID != newID {
+			renamed[oldID] = newID
+		}
+		s["id"] = newID
+	}
+
+	for _, l := range referenceLists(doc) {
+		for _, item := range l.items {
+			if obj, ok := item.(map[string]interface{}); ok {
+				l.rename(obj, renamed)
+			}
+		}
+	}
+	return renamed, nil
+}
+
+// resolve converts a position into an insertion index for list
+func resolve(list []map[string]interface{}, pos Position) (int, error) {
+	find := func(id string) int {
+		for i, s := range list {
+			if document.String(s, "id") == id {
+				return i
+			}
+		}
+		return -1
+	}
+
+	switch {
+	case pos.Before != "":
+		i := find(pos.Before)
+		if i == -1 {
+			return 0, fmt.Errorf("section not found: %s", pos.Before)
+		}
+		return i, nil
+	case pos.After != "":
+		i := find(pos.After)
+		if i == -1 {
+			return 0, fmt.Errorf("section not found: %s", pos.After)
+		}
+		return i + 1, nil
+	case pos.Index > 0:
+		if pos.Index > len(list)+1 {
+			return 0, fmt.Errorf("position %d is out of range (1-%d)", pos.Index, len(list)+1)
+		}
+		return pos.Index - 1, nil
+	default:
+		return len(list), nil
+	}
+}
+
+// insert returns list with section inserted at index at
+func insert(list []map[string]interface{}, at int, section map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(list)+1)
+	out = append(out, list[:at]...)
+	out = append(out, section)
+	return append(out, list[at:]...)
+}
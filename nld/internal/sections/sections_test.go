@@ -0,0 +1,251 @@
+package sections
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const testDocument = `{
+  "metadata": {"type": "contract"},
+  "content": {"sections": [
+    {"id": "parties", "title": "Parties", "content": ""},
+    {"id": "terms", "title": "Terms", "content": ""},
+    {"id": "payment", "title": "Payment", "content": ""},
+    {"id": "signatures", "title": "Signatures", "content": ""}
+  ]},
+  "relationships": {
+    "references": [{"source": "payment", "target": "terms", "type": "references"}],
+    "dependencies": [{"source": "signatures", "target": "parties", "type": "requires"}]
+  }
+}`
+
+func parse(t *testing.T) document.Document {
+	t.Helper()
+	doc, err := document.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	return doc
+}
+
+func order(doc document.Document) string {
+	var ids []string
+	for _, s := range doc.Sections() {
+		ids = append(ids, document.String(s, "id"))
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestMove(t *testing.T) {
+	testCases := []struct {
+		name        string
+		id          string
+		pos         Position
+		expect      string
+		expectError bool
+	}{
+		{name: "Before", id: "terms", pos: Position{Before: "signatures"}, expect: "parties,payment,terms,signatures"},
+		{name: "After", id: "parties", pos: Position{After: "payment"}, expect: "terms,payment,parties,signatures"},
+		{name: "Index", id: "signatures", pos: Position{Index: 1}, expect: "signatures,parties,terms,payment"},
+		{name: "End", id: "parties", pos: Position{}, expect: "terms,payment,signatures,parties"},
+		{name: "Unknown Section", id: "missing", pos: Position{}, expectError: true},
+		{name: "Unknown Anchor", id: "terms", pos: Position{Before: "missing"}, expectError: true},
+		{name: "Self", id: "terms", pos: Position{Before: "terms"}, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := parse(t)
+			err := Move(doc, tc.id, tc.pos)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Move failed: %v", err)
+			}
+			if got := order(doc); got != tc.expect {
+				t.Errorf("Expected order %s, got %s", tc.expect, got)
+			}
+			if len(References(doc, "terms")) != 1 {
+				t.Errorf("Expected relationships to be preserved")
+			}
+		})
+	}
+}
+
+func TestAddAndRemove(t *testing.T) {
+	doc := parse(t)
+
+	if err := Add(doc, map[string]interface{}{"id": "fees", "title": "Fees", "content": ""}, Position{After: "terms"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if got := order(doc); got != "parties,terms,fees,payment,signatures" {
+		t.Errorf("Unexpected order after add: %s", got)
+	}
+	if err := Add(doc, map[string]interface{}{"id": "fees"}, Position{}); err == nil {
+		t.Errorf("Expected duplicate section to be rejected")
+	}
+
+	if _, err := Remove(doc, "terms", false); err == nil {
+		t.Errorf("Expected referenced section to be kept without force")
+	}
+	removed, err := Remove(doc, "terms", true)
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 relationship removed, got %d", removed)
+	}
+	if _, err := Remove(doc, "fees", false); err != nil {
+		t.Errorf("Remove of unreferenced section failed: %v", err)
+	}
+	if got := order(doc); got != "parties,payment,signatures" {
+		t.Errorf("Unexpected order after remove: %s", got)
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	doc := parse(t)
+
+	renamed, err := Renumber(doc, "section-")
+	if err != nil {
+		t.Fatalf("Renumber failed: %v", err)
+	}
+	if renamed["payment"] != "section-3" {
+		t.Errorf("Expected payment to become section-3, got %s", renamed["payment"])
+	}
+	if got := order(doc); got != "section-1,section-2,section-3,section-4" {
+		t.Errorf("Unexpected order: %s", got)
+	}
+
+	ref := doc["relationships"].(map[string]interface{})["references"].([]interface{})[0].(map[string]interface{})
+	if ref["source"] != "section-3" || ref["target"] != "section-2" {
+		t.Errorf("Expected relationship to follow renumbering, got %v", ref)
+	}
+}
+
+const testReferences = `{
+  "metadata": {"type": "contract"},
+  "content": {"sections": [
+    {"id": "terms", "title": "Terms", "content": ""},
+    {"id": "payment", "title": "Payment", "content": "", "sections": [
+      {"id": "late-fees", "title": "Late Fees", "content": ""}
+    ]},
+    {"id": "termination", "title": "Termination", "content": ""}
+  ]},
+  "relationships": {
+    "conditions": [
+      {"id": "c1", "predicate": "payment overdue 30 days", "effect": "termination applies"},
+      {"id": "c2", "predicate": "terms accepted", "effect": "c1 applies"}
+    ]
+  },
+  "annotations": [
+    {"id": "a1", "section": "late-fees", "text": "Too high?"},
+    {"id": "a2", "section": "terms", "text": "Check"}
+  ],
+  "redline": [
+    {"id": "r1", "section": "payment", "deleted": "30", "inserted": "45"}
+  ]
+}`
+
+func TestRemoveReferences(t *testing.T) {
+	doc, err := document.Parse([]byte(testReferences))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	_, err = Remove(doc, "payment", false)
+	if err == nil {
+		t.Fatal("Expected referenced section to be kept without force")
+	}
+	for _, ref := range []string{"relationships.conditions[0]", "annotations[0]", "redline[0]"} {
+		if !strings.Contains(err.Error(), ref) {
+			t.Errorf("Expected %s in %v", ref, err)
+		}
+	}
+
+	removed, err := Remove(doc, "payment", true)
+	if err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("Expected 3 references removed, got %d", removed)
+	}
+	if refs := references(doc, map[string]bool{"payment": true, "late-fees": true}); len(refs) != 0 {
+		t.Errorf("Expected no references left, got %v", refs)
+	}
+	conditions := doc["relationships"].(map[string]interface{})["conditions"].([]interface{})
+	if len(conditions) != 1 || len(doc["annotations"].([]interface{})) != 1 {
+		t.Errorf("Expected the other condition and annotation to be kept, got %v and %v", conditions, doc["annotations"])
+	}
+	if _, ok := doc["redline"]; ok {
+		t.Errorf("Expected the empty redline to be removed, got %v", doc["redline"])
+	}
+}
+
+func TestRenumberReferences(t *testing.T) {
+	doc, err := document.Parse([]byte(testReferences))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	if _, err := Renumber(doc, "s"); err != nil {
+		t.Fatalf("Renumber failed: %v", err)
+	}
+
+	conditions := doc["relationships"].(map[string]interface{})["conditions"].([]interface{})
+	c1 := conditions[0].(map[string]interface{})
+	if c1["predicate"] != "s2 overdue 30 days" || c1["effect"] != "s3 applies" {
+		t.Errorf("Expected the condition to follow renumbering, got %v", c1)
+	}
+	if c2 := conditions[1].(map[string]interface{}); c2["predicate"] != "s1 accepted" || c2["effect"] != "c1 applies" {
+		t.Errorf("Expected the condition to follow renumbering, got %v", c2)
+	}
+	annotations := doc["annotations"].([]interface{})
+	if a := annotations[0].(map[string]interface{}); a["section"] != "late-fees" {
+		t.Errorf("Expected the subsection annotation to be kept, got %v", a)
+	}
+	if a := annotations[1].(map[string]interface{}); a["section"] != "s1" {
+		t.Errorf("Expected the annotation to follow renumbering, got %v", a)
+	}
+	if r := doc["redline"].([]interface{})[0].(map[string]interface{}); r["section"] != "s2" {
+		t.Errorf("Expected the redline to follow renumbering, got %v", r)
+	}
+}
+
+func TestRenumberDottedReferences(t *testing.T) {
+	doc, err := document.Parse([]byte(`{
+  "metadata": {"type": "contract"},
+  "content": {"sections": [
+    {"id": "scope", "title": "Scope", "content": ""},
+    {"id": "fees.v2", "title": "Fees", "content": ""}
+  ]},
+  "relationships": {
+    "conditions": [
+      {"id": "c1", "predicate": "scope.completed and fees.v2.paid", "effect": "c2.met"},
+      {"id": "c2", "predicate": "scoped.work done", "effect": "fees applies"}
+    ]
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	if refs := References(doc, "scope"); len(refs) != 1 || refs[0] != "relationships.conditions[0]" {
+		t.Errorf("Expected the dotted reference to be found, got %v", refs)
+	}
+	if _, err := Renumber(doc, "s"); err != nil {
+		t.Fatalf("Renumber failed: %v", err)
+	}
+
+	conditions := doc["relationships"].(map[string]interface{})["conditions"].([]interface{})
+	if c1 := conditions[0].(map[string]interface{}); c1["predicate"] != "s1.completed and s2.paid" || c1["effect"] != "c2.met" {
+		t.Errorf("Expected the dotted references to follow renumbering, got %v", c1)
+	}
+	if c2 := conditions[1].(map[string]interface{}); c2["predicate"] != "scoped.work done" || c2["effect"] != "fees applies" {
+		t.Errorf("Expected words that only start like a section ID to be kept, got %v", c2)
+	}
+}
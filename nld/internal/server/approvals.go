@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/colemalphrus/nld/internal/approval"
+	"github.com/colemalphrus/nld/internal/core"
+	"github.com/colemalphrus/nld/internal/webhook"
+)
+
+// approvalRequest is the request of the approvals endpoint
+type approvalRequest struct {
+	// Approver names the approver on servers without an authenticator;
+	// otherwise the authenticated client approves
+	Approver string `json:"approver,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// documentWorkflow writes the state of the workflow of a stored document
+func (s *Server) documentWorkflow(w http.ResponseWriter, r *http.Request) {
+	if s.approvals == nil {
+		writeError(w, http.StatusNotImplemented, errNoStore)
+		return
+	}
+	state, err := s.approvals.State(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// approveDocument records the approval of a stored document by the client
+// and notifies webhooks
+func (s *Server) approveDocument(w http.ResponseWriter, r *http.Request) {
+	if s.approvals == nil {
+		writeError(w, http.StatusNotImplemented, errNoStore)
+		return
+	}
+	var req approvalRequest
+	if err := s.decode(w, r, &req); err != nil {
+		writeError(w, bodyStatus(err), err)
+		return
+	}
+	approver := req.Approver
+	if p := principal(r); p != nil {
+		approver = p.Name
+	}
+	if approver == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w: no approver", core.ErrInvalidRequest))
+		return
+	}
+
+	state, err := s.approvals.Approve(r.Context(), r.PathValue("id"), approver, req.Comment)
+	switch {
+	case errors.Is(err, approval.ErrNotApprover):
+		writeError(w, http.StatusForbidden, err)
+		return
+	case errors.Is(err, approval.ErrNoWorkflow), errors.Is(err, approval.ErrApproved):
+		writeError(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		writeStoreError(w, err)
+		return
+	}
+	s.opts.Webhooks.Send(webhook.Approval, state)
+	if state.Complete && len(state.Approvals) == state.Required {
+		s.opts.Webhooks.Send(webhook.Approved, state)
+	}
+	writeJSON(w, http.StatusOK, state)
+}
+
+// signDocument records the digest of a stored document once it is
+// approved, stores the result as its next version and notifies webhooks
+func (s *Server) signDocument(w http.ResponseWriter, r *http.Request) {
+	if s.approvals == nil {
+		writeError(w, http.StatusNotImplemented, errNoStore)
+		return
+	}
+	var signed *core.SignResponse
+	var signErr error
+	info, err := s.approvals.Sign(r.Context(), r.PathValue("id"), func(data []byte) ([]byte, error) {
+		var resp interface{}
+		if resp, signErr = s.call(r, func(ctx context.Context) (interface{}, error) {
+			return core.HandleSign(ctx, core.SignRequest{Document: data})
+		}); signErr != nil {
+			return nil, signErr
+		}
+		signed = resp.(*core.SignResponse)
+		return signed.Document, nil
+	})
+	switch {
+	case errors.Is(err, approval.ErrPending):
+		writeError(w, http.StatusConflict, err)
+		return
+	case errors.Is(signErr, errTimeout):
+		writeError(w, http.StatusServiceUnavailable, signErr)
+		return
+	case errors.Is(signErr, context.Canceled):
+		// the client is gone
+		return
+	case signErr != nil:
+		writeError(w, http.StatusUnprocessableEntity, signErr)
+		return
+	case err != nil:
+		writeStoreError(w, err)
+		return
+	}
+	e := newDocumentEvent(signed.Document)
+	e.Root = signed.Root
+	s.opts.Webhooks.Send(webhook.Signed, e)
+	writeJSON(w, http.StatusOK, info)
+}
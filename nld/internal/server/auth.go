@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/colemalphrus/nld/internal/auth"
+)
+
+// guard returns a handler that serves requests with scope by h, under the
+// name operation in the audit log, once the client is authenticated and
+// within its rate limit. Servers without an authenticator serve every
+// client.
+//
+// Clients are held to the rate limit of their address before they are
+// authenticated, so that requests over it cost no token verification or
+// fetch of OIDC keys, and then to that of their credentials.
+func (s *Server) guard(scope, operation string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.limited(w, r, nil, operation, remoteHost(r)) {
+			return
+		}
+		if s.opts.Auth == nil {
+			h(w, r)
+			return
+		}
+
+		p, err := s.opts.Auth.Authenticate(r)
+		if err != nil {
+			status := http.StatusUnauthorized
+			if !errors.Is(err, auth.ErrNoCredentials) && !errors.Is(err, auth.ErrInvalidCredentials) {
+				// the keys of the OIDC issuer could not be fetched
+				status = http.StatusServiceUnavailable
+			}
+			s.audit(r, nil, operation, status, err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="nld"`)
+			writeError(w, status, err)
+			return
+		}
+		if s.limited(w, r, p, operation, p.Method+":"+p.Name) {
+			return
+		}
+		if !p.Has(scope) {
+			err := errors.New("the " + scope + " scope is required")
+			s.audit(r, p, operation, http.StatusForbidden, err)
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+		s.audit(r, p, operation, rec.status, nil)
+	}
+}
+
+// limited takes a request of client, the principal p or an address, from
+// its rate limit, and answers it with 429 when the client is over the limit
+func (s *Server) limited(w http.ResponseWriter, r *http.Request, p *auth.Principal, operation, client string) bool {
+	if s.limiter == nil {
+		return false
+	}
+	ok, wait := s.limiter.allow(client)
+	if ok {
+		return false
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	err := errors.New("rate limit exceeded")
+	s.audit(r, p, operation, http.StatusTooManyRequests, err)
+	writeError(w, http.StatusTooManyRequests, err)
+	return true
+}
+
+// principalKey is the context key of the client of a request
+type principalKey struct{}
+
+// principal returns the authenticated client of a request, nil on servers
+// without an authenticator
+func principal(r *http.Request) *auth.Principal {
+	p, _ := r.Context().Value(principalKey{}).(*auth.Principal)
+	return p
+}
+
+// remoteHost returns the IP address of the client of a request
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// audit records an operation in the audit log. p is nil for requests that
+// could not be authenticated.
+func (s *Server) audit(r *http.Request, p *auth.Principal, operation string, status int, err error) {
+	if s.opts.Audit == nil {
+		return
+	}
+	attrs := []interface{}{"operation", operation, "method", r.Method, "path", r.URL.Path, "status", status}
+	if p != nil {
+		attrs = append(attrs, "principal", p.Name, "auth", p.Method)
+	}
+	if id := r.PathValue("id"); id != "" {
+		attrs = append(attrs, "document", id)
+	}
+	attrs = append(attrs, "remote", remoteHost(r))
+	if err != nil {
+		s.opts.Audit.Warn("denied", append(attrs, "error", err.Error())...)
+		return
+	}
+	s.opts.Audit.Info("allowed", attrs...)
+}
@@ -0,0 +1,337 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/colemalphrus/nld/internal/approval"
+	"github.com/colemalphrus/nld/internal/auth"
+	"github.com/colemalphrus/nld/internal/core"
+	"github.com/colemalphrus/nld/internal/metrics"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// route is an endpoint of the server. The mux and the OpenAPI document are
+// both built from the routes, so the document cannot fall behind the
+// handlers.
+type route struct {
+	method, path string
+	// operation names the endpoint in the audit log and the OpenAPI
+	// document
+	operation string
+	summary   string
+	// scope is the scope clients need; routes without one are open
+	scope string
+	// request and response are values of the types of the JSON bodies,
+	// nil for none. A string response is sent as produces.
+	request, response interface{}
+	produces          string
+	// status is the status of successful requests, 200 if zero
+	status int
+	params []param
+	// errors lists the statuses of failed requests, besides those of
+	// authentication and rate limiting
+	errors  []int
+	handler http.HandlerFunc
+}
+
+// param is a path or query parameter of a route
+type param struct {
+	name, in, description string
+	schema                map[string]interface{}
+}
+
+// idParam is the document ID of the /v1/documents/{id} endpoints
+var idParam = param{name: "id", in: "path", description: "ID of the document", schema: map[string]interface{}{"type": "string"}}
+
+// endpoints returns the routes of s
+func (s *Server) endpoints() []route {
+	processing := []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity, http.StatusServiceUnavailable}
+	routes := []route{
+		{method: http.MethodGet, path: "/v1/health", operation: "health", summary: "Report that the server is up",
+			response: healthResponse{}, handler: s.health},
+		{method: http.MethodGet, path: "/openapi.json", operation: "openapi", summary: "Describe the endpoints of the server",
+			response: map[string]interface{}{}, handler: s.openAPI},
+	}
+	if s.opts.Metrics {
+		routes = append(routes, route{method: http.MethodGet, path: "/metrics", operation: "metrics", summary: "Metrics in the Prometheus text format",
+			response: "", produces: "text/plain", handler: metrics.Default.Handler().ServeHTTP})
+	}
+	return append(routes,
+		route{method: http.MethodPost, path: "/v1/validate", operation: "validate", summary: "Validate a document", scope: auth.ScopeValidate,
+			request: core.ValidateRequest{}, response: core.ValidateResponse{}, errors: processing, handler: handle(s, s.validate)},
+		route{method: http.MethodPost, path: "/v1/render", operation: "render", summary: "Render a document", scope: auth.ScopeValidate,
+			request: core.RenderRequest{}, response: core.RenderResponse{}, errors: processing, handler: handle(s, core.HandleRender)},
+		route{method: http.MethodPost, path: "/v1/convert", operation: "convert", summary: "Import a document from another format", scope: auth.ScopeValidate,
+			request: core.ConvertRequest{}, response: core.ConvertResponse{}, errors: processing, handler: handle(s, core.HandleConvert)},
+		route{method: http.MethodPost, path: "/v1/sign", operation: "sign", summary: "Record the digest of a document", scope: auth.ScopeSign,
+			request: core.SignRequest{}, response: core.SignResponse{}, errors: append(processing, http.StatusConflict), handler: handle(s, s.sign)},
+		route{method: http.MethodGet, path: "/v1/documents", operation: "documents.list", summary: "List the latest version of every stored document", scope: auth.ScopeStore,
+			response: []store.Info{}, errors: []int{http.StatusNotImplemented}, handler: s.listDocuments},
+		route{method: http.MethodPut, path: "/v1/documents/{id}", operation: "documents.put", summary: "Store a document", scope: auth.ScopeStore,
+			request: json.RawMessage{}, response: store.Info{}, params: []param{idParam},
+			errors: []int{http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusNotImplemented}, handler: s.putDocument},
+		route{method: http.MethodGet, path: "/v1/documents/{id}", operation: "documents.get", summary: "Get a stored document", scope: auth.ScopeStore,
+			response: json.RawMessage{}, params: []param{idParam, {name: "version", in: "query", description: "Version of the document, the latest if not given",
+				schema: map[string]interface{}{"type": "integer", "minimum": 1}}},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusNotImplemented}, handler: s.getDocument},
+		route{method: http.MethodGet, path: "/v1/documents/{id}/versions", operation: "documents.versions", summary: "List the versions of a stored document", scope: auth.ScopeStore,
+			response: []store.Info{}, params: []param{idParam}, errors: []int{http.StatusNotFound, http.StatusNotImplemented}, handler: s.documentVersions},
+		route{method: http.MethodDelete, path: "/v1/documents/{id}", operation: "documents.delete", summary: "Delete a document and its versions", scope: auth.ScopeStore,
+			status: http.StatusNoContent, params: []param{idParam}, errors: []int{http.StatusNotFound, http.StatusNotImplemented}, handler: s.deleteDocument},
+		route{method: http.MethodGet, path: "/v1/documents/{id}/workflow", operation: "documents.workflow", summary: "Get the approval workflow state of a stored document", scope: auth.ScopeStore,
+			response: approval.State{}, params: []param{idParam}, errors: []int{http.StatusNotFound, http.StatusNotImplemented}, handler: s.documentWorkflow},
+		route{method: http.MethodPost, path: "/v1/documents/{id}/approvals", operation: "documents.approve", summary: "Approve a stored document", scope: auth.ScopeApprove,
+			request: approvalRequest{}, response: approval.State{}, params: []param{idParam},
+			errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusNotImplemented}, handler: s.approveDocument},
+		route{method: http.MethodPost, path: "/v1/documents/{id}/sign", operation: "documents.sign", summary: "Record the digest of an approved stored document", scope: auth.ScopeSign,
+			response: store.Info{}, params: []param{idParam},
+			errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusServiceUnavailable, http.StatusNotImplemented}, handler: s.signDocument},
+	)
+}
+
+// openAPI serves the OpenAPI document of s
+func (s *Server) openAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.OpenAPI())
+}
+
+// OpenAPI returns the OpenAPI 3 document of the endpoints of s, as served
+// at /openapi.json. It follows the options of s: the security of the
+// endpoints with an authenticator, and the 429 status with a rate limit.
+func (s *Server) OpenAPI() map[string]interface{} {
+	g := &schemas{components: map[string]interface{}{}, types: map[string]reflect.Type{}}
+	errorRef := g.of(reflect.TypeOf(errorResponse{}))
+	paths := map[string]interface{}{}
+	for _, rt := range s.routes {
+		op := map[string]interface{}{
+			"operationId": operationID(rt.operation),
+			"summary":     rt.summary,
+		}
+		if len(rt.params) > 0 {
+			var params []interface{}
+			for _, p := range rt.params {
+				params = append(params, map[string]interface{}{
+					"name": p.name, "in": p.in, "description": p.description,
+					"required": p.in == "path", "schema": p.schema,
+				})
+			}
+			op["parameters"] = params
+		}
+		if rt.request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": g.of(reflect.TypeOf(rt.request))}},
+			}
+		}
+
+		status := rt.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		ok := map[string]interface{}{"description": http.StatusText(status)}
+		if rt.response != nil {
+			produces := rt.produces
+			if produces == "" {
+				produces = "application/json"
+			}
+			ok["content"] = map[string]interface{}{produces: map[string]interface{}{"schema": g.of(reflect.TypeOf(rt.response))}}
+		}
+		responses := map[string]interface{}{strconv.Itoa(status): ok}
+		failures := append([]int(nil), rt.errors...)
+		if rt.scope != "" && s.opts.Auth != nil {
+			op["security"] = []interface{}{map[string]interface{}{"apiKey": []string{}}, map[string]interface{}{"bearer": []string{}}}
+			op["description"] = fmt.Sprintf("Needs the %s scope.", rt.scope)
+			failures = append(failures, http.StatusUnauthorized, http.StatusForbidden)
+		}
+		if rt.scope != "" && s.limiter != nil {
+			failures = append(failures, http.StatusTooManyRequests)
+		}
+		for _, code := range failures {
+			responses[strconv.Itoa(code)] = map[string]interface{}{
+				"description": http.StatusText(code),
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorRef}},
+			}
+		}
+		op["responses"] = responses
+
+		item, _ := paths[rt.path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[rt.path] = item
+		}
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	components := map[string]interface{}{"schemas": g.components}
+	if s.opts.Auth != nil {
+		components["securitySchemes"] = map[string]interface{}{
+			"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": auth.APIKeyHeader},
+			"bearer": map[string]interface{}{"type": "http", "scheme": "bearer", "description": "An API key or an OIDC access token"},
+		}
+	}
+	version := s.opts.Version
+	if version == "" {
+		version = strconv.Itoa(core.APIVersion)
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "nld",
+			"description": "Validate, render, convert, sign, store and approve NLD documents.",
+			"version":     version,
+		},
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+// operationID turns an operation name such as documents.put into an
+// operation ID such as documentsPut, which code generators can use as a
+// method name
+func operationID(operation string) string {
+	parts := strings.Split(operation, ".")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	timeType       = reflect.TypeOf(time.Time{})
+	moneyType      = reflect.TypeOf(nld.Money{})
+)
+
+// schemas builds the JSON schemas of the request and response types, in
+// the JSON encoding of encoding/json. Named structs are kept in components
+// and referred to by name.
+type schemas struct {
+	components map[string]interface{}
+	types      map[string]reflect.Type
+}
+
+// of returns the schema of t
+func (g *schemas) of(t reflect.Type) map[string]interface{} {
+	switch t {
+	case rawMessageType:
+		return g.component("Document", t, func() map[string]interface{} {
+			return map[string]interface{}{
+				"type":        "object",
+				"description": "An NLD document, as described by the schemas of nld",
+			}
+		})
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case moneyType:
+		// Amounts without a currency are written as plain decimal strings
+		return g.component("Money", t, func() map[string]interface{} {
+			return map[string]interface{}{"oneOf": []interface{}{
+				map[string]interface{}{"type": "string", "description": "A decimal amount, such as \"12.50\""},
+				g.object(t),
+			}}
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.of(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.component(g.name(t), t, func() map[string]interface{} { return g.object(t) })
+	}
+	return map[string]interface{}{}
+}
+
+// component adds the schema of t to the components under name, unless it
+// is there already, and returns a reference to it
+func (g *schemas) component(name string, t reflect.Type, schema func() map[string]interface{}) map[string]interface{} {
+	if _, ok := g.types[name]; !ok {
+		// Recursive types refer to the component before it is complete
+		g.types[name] = t
+		g.components[name] = schema()
+	}
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// name returns the component name of a named struct: its Go name, with the
+// name of its package if another struct has the same name. The unexported
+// types of this package, such as healthResponse, are named Health.
+func (g *schemas) name(t reflect.Type) string {
+	name := t.Name()
+	if unicode.IsLower(rune(name[0])) {
+		name = strings.ToUpper(name[:1]) + strings.TrimSuffix(name[1:], "Response")
+	}
+	if other, ok := g.types[name]; ok && other != t {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	return name
+}
+
+// object returns the schema of the fields of a struct
+func (g *schemas) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	g.fields(t, properties, &required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fields adds the JSON fields of a struct to properties, with those that
+// are always written to required
+func (g *schemas) fields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = g.of(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
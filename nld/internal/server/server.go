@@ -0,0 +1,481 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/approval"
+	"github.com/colemalphrus/nld/internal/auth"
+	"github.com/colemalphrus/nld/internal/core"
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/metrics"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/internal/tracing"
+	"github.com/colemalphrus/nld/internal/webhook"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultMaxDocumentSize is the largest request body accepted by default
+const DefaultMaxDocumentSize = 32 << 20
+
+// DefaultMaxInFlight is the number of documents processed at once by default
+const DefaultMaxInFlight = 64
+
+// Options configures a server
+type Options struct {
+	// Version is reported by the health endpoint
+	Version string
+	// Log receives a message for every request; nil discards them
+	Log *slog.Logger
+	// Store holds the documents of the /v1/documents endpoints; nil
+	// disables them
+	Store store.Store
+	// Webhooks is notified of validated, signed, stored, deleted and
+	// approved documents; nil sends no notifications
+	Webhooks *webhook.Dispatcher
+	// Auth authenticates the requests of every endpoint but health, and
+	// the scopes of the client decide the endpoints it may use; nil serves
+	// every request
+	Auth *auth.Authenticator
+	// Audit receives a message for every authenticated operation and every
+	// denied request; nil discards them
+	Audit *slog.Logger
+	// MaxDocumentSize is the largest request body accepted, in bytes;
+	// defaults to DefaultMaxDocumentSize
+	MaxDocumentSize int64
+	// RateLimit is the number of requests a second each client may make,
+	// in bursts of up to Burst requests; 0 disables rate limiting. Every
+	// IP address is held to the limit before its requests are
+	// authenticated, and then every client by its credentials.
+	RateLimit float64
+	// Burst defaults to RateLimit rounded up
+	Burst int
+	// Timeout limits the time spent validating, rendering, converting or
+	// signing a document; 0 disables the limit
+	Timeout time.Duration
+	// MaxInFlight is the number of documents validated, rendered,
+	// converted or signed at once, counting those still processed after
+	// their request timed out. Requests wait for their turn within their
+	// timeout. Defaults to DefaultMaxInFlight.
+	MaxInFlight int
+	// Metrics serves the metrics of nld, metrics.Default, at /metrics in
+	// the Prometheus text format
+	Metrics bool
+	// Workflows lists the approvers of the stored documents of each type,
+	// which cannot be signed before they approve them; nil needs no
+	// approvals
+	Workflows *approval.Config
+}
+
+// Server is the HTTP API of nld:
+//
+//	GET  /v1/health          {"status": "ok", "version": "..."}
+//	GET  /metrics            metrics in the Prometheus text format
+//	GET  /openapi.json       the OpenAPI document of these endpoints
+//	POST /v1/validate        core.ValidateRequest -> core.ValidateResponse
+//	POST /v1/render          core.RenderRequest -> core.RenderResponse
+//	POST /v1/convert         core.ConvertRequest -> core.ConvertResponse
+//	POST /v1/sign            core.SignRequest -> core.SignResponse
+//	GET  /v1/documents                 latest version of every document
+//	PUT  /v1/documents/{id}            store a document -> store.Info
+//	GET  /v1/documents/{id}            the document, ?version=N for a version
+//	GET  /v1/documents/{id}/versions   versions of a document
+//	DELETE /v1/documents/{id}          delete a document and its versions
+//	GET  /v1/documents/{id}/workflow   approval.State of a document
+//	POST /v1/documents/{id}/approvals  approvalRequest -> approval.State
+//	POST /v1/documents/{id}/sign       record the digest -> store.Info
+//
+// Failed requests get a status of 400 for malformed requests, 422 for
+// documents that cannot be processed, 404 for documents not in the store,
+// 409 for documents whose approvals are missing or already given, and a
+// body of {"error": "..."}. Request bodies over the maximum document
+// size get 413, clients over their rate limit 429 with a Retry-After
+// header, and documents that take too long to process 503.
+//
+// With an authenticator, requests without valid credentials get a status
+// of 401, and requests without the scope of the endpoint 403: validate,
+// render and convert need auth.ScopeValidate, sign and the sign endpoint
+// of stored documents auth.ScopeSign, approvals auth.ScopeApprove, and the
+// other documents endpoints auth.ScopeStore. Approvers are the
+// authenticated clients; without an authenticator, they name themselves in
+// the request.
+type Server struct {
+	opts      Options
+	mux       *http.ServeMux
+	limiter   *limiter
+	routes    []route
+	approvals *approval.Engine
+	// inFlight holds a token for every document being processed
+	inFlight chan struct{}
+}
+
+// New returns a server
+func New(opts Options) *Server {
+	if opts.Log == nil {
+		opts.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	if opts.MaxDocumentSize <= 0 {
+		opts.MaxDocumentSize = DefaultMaxDocumentSize
+	}
+	if opts.MaxInFlight <= 0 {
+		opts.MaxInFlight = DefaultMaxInFlight
+	}
+	s := &Server{opts: opts, mux: http.NewServeMux(), inFlight: make(chan struct{}, opts.MaxInFlight)}
+	if opts.RateLimit > 0 {
+		s.limiter = newLimiter(opts.RateLimit, opts.Burst)
+	}
+	if opts.Store != nil {
+		s.approvals = &approval.Engine{Config: opts.Workflows, Store: opts.Store}
+	}
+	s.routes = s.endpoints()
+	for _, rt := range s.routes {
+		h := rt.handler
+		if rt.scope != "" {
+			h = s.guard(rt.scope, rt.operation, h)
+		}
+		s.mux.HandleFunc(rt.method+" "+rt.path, h)
+	}
+	return s
+}
+
+// ServeHTTP serves a request and logs it
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	// Requests continue the trace of their traceparent header, if any
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := otel.Tracer(tracing.Name).Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+	r = r.WithContext(ctx)
+
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(rec, r)
+	s.opts.Log.Info("request", "method", r.Method, "path", r.URL.Path, "status", rec.status, "duration", time.Since(start))
+
+	// The route is the pattern the request matched, set by the mux
+	route := r.Pattern
+	if route == "" {
+		route = "unmatched"
+	}
+	span.SetAttributes(
+		attribute.String("http.request.method", r.Method),
+		attribute.String("url.path", r.URL.Path),
+		attribute.Int("http.response.status_code", rec.status),
+	)
+	if r.Pattern != "" {
+		span.SetName(r.Pattern)
+		span.SetAttributes(attribute.String("http.route", strings.TrimPrefix(r.Pattern, r.Method+" ")))
+	}
+	if rec.status >= 500 {
+		span.SetStatus(codes.Error, http.StatusText(rec.status))
+	}
+	metrics.Requests.Inc(route, strconv.Itoa(rec.status))
+	metrics.RequestDuration.Since(start, route)
+	if rec.status >= 400 {
+		metrics.Errors.Inc(strings.ReplaceAll(strings.ToLower(http.StatusText(rec.status)), " ", "_"))
+	}
+}
+
+// healthResponse is the response of the health endpoint
+type healthResponse struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
+// health reports that the server is up
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok", Version: s.opts.Version})
+}
+
+// documentEvent is the data of the webhook events of validated and signed
+// documents
+type documentEvent struct {
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+	// Digest is the digest of the document as received
+	Digest   string `json:"digest"`
+	Valid    *bool  `json:"valid,omitempty"`
+	Errors   int    `json:"errors,omitempty"`
+	Warnings int    `json:"warnings,omitempty"`
+	// Root is the root hash of the recorded digest of a signed document
+	Root string `json:"root,omitempty"`
+}
+
+// newDocumentEvent describes a document for a webhook event
+func newDocumentEvent(data []byte) documentEvent {
+	e := documentEvent{Digest: digest.Sum(data)}
+	var doc struct {
+		Metadata struct {
+			Type  string `json:"type"`
+			Title string `json:"title"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal(data, &doc) == nil {
+		e.Type, e.Title = doc.Metadata.Type, doc.Metadata.Title
+	}
+	return e
+}
+
+// validate validates a document and notifies webhooks
+func (s *Server) validate(ctx context.Context, req core.ValidateRequest) (*core.ValidateResponse, error) {
+	resp, err := core.HandleValidate(ctx, req)
+	if err == nil {
+		e := newDocumentEvent(req.Document)
+		e.Valid, e.Errors, e.Warnings = &resp.Valid, len(resp.Errors), len(resp.Warnings)
+		s.opts.Webhooks.Send(webhook.Validated, e)
+	}
+	return resp, err
+}
+
+// sign records the digest of a document and notifies webhooks. Documents
+// of a type with an approval workflow are only signed once stored and
+// approved.
+func (s *Server) sign(ctx context.Context, req core.SignRequest) (*core.SignResponse, error) {
+	if e := newDocumentEvent(req.Document); s.opts.Workflows.For(e.Type) != nil {
+		return nil, fmt.Errorf("%w: documents of type %s need approval; store them and sign them at /v1/documents/{id}/sign", approval.ErrPending, e.Type)
+	}
+	resp, err := core.HandleSign(ctx, req)
+	if err == nil {
+		e := newDocumentEvent(req.Document)
+		e.Root = resp.Root
+		s.opts.Webhooks.Send(webhook.Signed, e)
+	}
+	return resp, err
+}
+
+// errNoStore is the error of store endpoints on servers without a store
+var errNoStore = errors.New("this server has no document store")
+
+// listDocuments lists the latest version of every stored document
+func (s *Server) listDocuments(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Store == nil {
+		writeError(w, http.StatusNotImplemented, errNoStore)
+		return
+	}
+	list, err := s.opts.Store.List(r.Context())
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// putDocument stores a document under its ID
+func (s *Server) putDocument(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Store == nil {
+		writeError(w, http.StatusNotImplemented, errNoStore)
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.opts.MaxDocumentSize))
+	if err != nil {
+		writeError(w, bodyStatus(err), fmt.Errorf("%w: %w", core.ErrInvalidRequest, err))
+		return
+	}
+	info, err := s.approvals.Put(r.Context(), r.PathValue("id"), data)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.opts.Webhooks.Send(webhook.Stored, info)
+	writeJSON(w, http.StatusOK, info)
+}
+
+// getDocument writes a stored document
+func (s *Server) getDocument(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Store == nil {
+		writeError(w, http.StatusNotImplemented, errNoStore)
+		return
+	}
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: invalid version %q", core.ErrInvalidRequest, v))
+			return
+		}
+		version = n
+	}
+	data, info, err := s.opts.Store.Get(r.Context(), r.PathValue("id"), version)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("NLD-Version", strconv.Itoa(info.Version))
+	w.Header().Set("ETag", `"`+info.Digest+`"`)
+	w.Write(data)
+}
+
+// documentVersions lists the versions of a stored document
+func (s *Server) documentVersions(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Store == nil {
+		writeError(w, http.StatusNotImplemented, errNoStore)
+		return
+	}
+	list, err := s.opts.Store.Versions(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// deleteDocument deletes a stored document
+func (s *Server) deleteDocument(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Store == nil {
+		writeError(w, http.StatusNotImplemented, errNoStore)
+		return
+	}
+	if err := s.opts.Store.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.opts.Webhooks.Send(webhook.Deleted, map[string]string{"id": r.PathValue("id")})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeStoreError writes the response for a failed store operation
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, store.ErrInvalid):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// errTimeout is the error of requests that take longer than the timeout
+var errTimeout = errors.New("the document took too long to process")
+
+// handle returns a handler decoding requests of type Req for fn, within
+// the request size and timeout of s
+func handle[Req, Resp any](s *Server, fn func(context.Context, Req) (*Resp, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req Req
+		if err := s.decode(w, r, &req); err != nil {
+			writeError(w, bodyStatus(err), err)
+			return
+		}
+		resp, err := s.call(r, func(ctx context.Context) (interface{}, error) { return fn(ctx, req) })
+		switch {
+		case errors.Is(err, errTimeout):
+			writeError(w, http.StatusServiceUnavailable, err)
+		case errors.Is(err, context.Canceled):
+			// the client is gone
+		case errors.Is(err, core.ErrInvalidRequest):
+			writeError(w, http.StatusBadRequest, err)
+		case errors.Is(err, approval.ErrPending):
+			writeError(w, http.StatusConflict, err)
+		case err != nil:
+			writeError(w, http.StatusUnprocessableEntity, err)
+		default:
+			writeJSON(w, http.StatusOK, resp)
+		}
+	}
+}
+
+// call runs fn with a context that ends after the timeout of s or when the
+// client goes away, and gives up then. Not every step of the core handlers
+// checks the context, so fn may keep running, but its result is dropped.
+// Running calls, given up on or not, are limited to the MaxInFlight of s,
+// so that slow documents cannot pile up work beyond it.
+func (s *Server) call(r *http.Request, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	ctx := r.Context()
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+	select {
+	case s.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return nil, interrupted(r)
+	}
+
+	type result struct {
+		resp interface{}
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() { <-s.inFlight }()
+		resp, err := fn(ctx)
+		done <- result{resp, err}
+	}()
+	select {
+	case res := <-done:
+		if res.err != nil && ctx.Err() != nil {
+			return nil, interrupted(r)
+		}
+		return res.resp, res.err
+	case <-ctx.Done():
+		return nil, interrupted(r)
+	}
+}
+
+// interrupted returns the error of a call given up on: that of the request
+// context when the client went away, or errTimeout
+func interrupted(r *http.Request) error {
+	if err := r.Context().Err(); err != nil {
+		return err
+	}
+	return errTimeout
+}
+
+// decode reads a JSON request body into v
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.opts.MaxDocumentSize)).Decode(v); err != nil {
+		return fmt.Errorf("%w: %w", core.ErrInvalidRequest, err)
+	}
+	return nil
+}
+
+// bodyStatus returns the status of a request whose body could not be read
+func bodyStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorResponse is the body of failed requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// recorder remembers the status of a response for the request log
+type recorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
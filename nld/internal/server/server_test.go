@@ -0,0 +1,514 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/approval"
+	"github.com/colemalphrus/nld/internal/auth"
+	"github.com/colemalphrus/nld/internal/store"
+	"github.com/colemalphrus/nld/internal/webhook"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const contract = `{"metadata": {"type": "contract", "version": "1.0.0", "created": "2024-01-01", "title": "Lease"}, "content": {"sections": []}}`
+
+func TestWebhooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []webhook.Event
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e webhook.Event
+		json.NewDecoder(r.Body).Decode(&e)
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	hooks := webhook.New([]webhook.Hook{{URL: receiver.URL}}, webhook.Options{})
+	ts := httptest.NewServer(New(Options{Store: store.NewFS(t.TempDir()), Webhooks: hooks}))
+	defer ts.Close()
+
+	requests := []struct{ method, path, body string }{
+		{http.MethodPost, "/v1/validate", `{"document": ` + contract + `}`},
+		{http.MethodPost, "/v1/sign", `{"document": ` + contract + `}`},
+		{http.MethodPut, "/v1/documents/lease", contract},
+		{http.MethodDelete, "/v1/documents/lease", ""},
+		// Failed requests send nothing
+		{http.MethodDelete, "/v1/documents/lease", ""},
+		{http.MethodPost, "/v1/validate", `{}`},
+		// The audit log cannot be replaced or deleted
+		{http.MethodPut, "/v1/documents/_audit", contract},
+		{http.MethodDelete, "/v1/documents/_audit", ""},
+	}
+	for _, r := range requests {
+		req, _ := http.NewRequest(r.method, ts.URL+r.path, strings.NewReader(r.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	hooks.Close(context.Background())
+
+	got := map[string]map[string]interface{}{}
+	for _, e := range events {
+		got[e.Type], _ = e.Data.(map[string]interface{})
+	}
+	if len(events) != 4 || len(got) != 4 {
+		t.Fatalf("events = %+v", events)
+	}
+	if v := got[webhook.Validated]; v["valid"] == nil || v["title"] != "Lease" || !strings.HasPrefix(v["digest"].(string), "sha256:") {
+		t.Errorf("validated event data = %v", v)
+	}
+	if v := got[webhook.Signed]; v["root"] == "" || v["root"] == nil {
+		t.Errorf("signed event data = %v", v)
+	}
+	if v := got[webhook.Stored]; v["id"] != "lease" || v["version"] != 1.0 {
+		t.Errorf("stored event data = %v", v)
+	}
+	if v := got[webhook.Deleted]; v["id"] != "lease" {
+		t.Errorf("deleted event data = %v", v)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	a, err := auth.New(&auth.Config{APIKeys: []auth.APIKey{
+		{Name: "billing", SHA256: auth.HashKey("billing-key"), Scopes: []string{auth.ScopeValidate, auth.ScopeSign}},
+		{Name: "archive", SHA256: auth.HashKey("archive-key"), Scopes: []string{auth.ScopeStore}},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var audit bytes.Buffer
+	ts := httptest.NewServer(New(Options{Store: store.NewFS(t.TempDir()), Auth: a, Audit: slog.New(slog.NewJSONHandler(&audit, nil))}))
+	defer ts.Close()
+
+	tests := []struct {
+		method, path, key, body string
+		status                  int
+	}{
+		{http.MethodGet, "/v1/health", "", "", http.StatusOK},
+		{http.MethodPost, "/v1/validate", "", `{"document": ` + contract + `}`, http.StatusUnauthorized},
+		{http.MethodPost, "/v1/validate", "wrong-key", `{"document": ` + contract + `}`, http.StatusUnauthorized},
+		{http.MethodPost, "/v1/validate", "billing-key", `{"document": ` + contract + `}`, http.StatusOK},
+		{http.MethodPost, "/v1/sign", "billing-key", `{"document": ` + contract + `}`, http.StatusOK},
+		{http.MethodPut, "/v1/documents/lease", "billing-key", contract, http.StatusForbidden},
+		{http.MethodPut, "/v1/documents/lease", "archive-key", contract, http.StatusOK},
+		{http.MethodPost, "/v1/sign", "archive-key", `{"document": ` + contract + `}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+		if tt.key != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s with %q: status %d, want %d", tt.method, tt.path, tt.key, resp.StatusCode, tt.status)
+		}
+	}
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(audit.String()), "\n") {
+		var e map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("audit line %q: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	// Health is not audited
+	if len(entries) != len(tests)-1 {
+		t.Fatalf("audit log has %d entries:\n%s", len(entries), audit.String())
+	}
+	if e := entries[5]; e["msg"] != "allowed" || e["principal"] != "archive" || e["operation"] != "documents.put" || e["document"] != "lease" || e["status"] != 200.0 {
+		t.Errorf("audit entry = %v", e)
+	}
+	if e := entries[4]; e["msg"] != "denied" || e["principal"] != "billing" || e["status"] != 403.0 {
+		t.Errorf("audit entry = %v", e)
+	}
+}
+
+func TestApprovals(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		events = append(events, r.Header.Get(webhook.EventHeader))
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	a, err := auth.New(&auth.Config{APIKeys: []auth.APIKey{
+		{Name: "legal", SHA256: auth.HashKey("legal-key"), Scopes: []string{auth.ScopeApprove}},
+		{Name: "finance", SHA256: auth.HashKey("finance-key"), Scopes: []string{auth.ScopeApprove}},
+		{Name: "billing", SHA256: auth.HashKey("billing-key"), Scopes: []string{auth.ScopeSign, auth.ScopeStore, auth.ScopeApprove}},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks := webhook.New([]webhook.Hook{{URL: receiver.URL, Events: []string{webhook.Approval, webhook.Approved, webhook.Signed}}}, webhook.Options{})
+	workflows := &approval.Config{Workflows: []approval.Workflow{{Type: "contract", Approvers: []string{"legal", "finance"}, Required: 2}}}
+	ts := httptest.NewServer(New(Options{Store: store.NewFS(t.TempDir()), Auth: a, Webhooks: hooks, Workflows: workflows}))
+	defer ts.Close()
+
+	tests := []struct {
+		method, path, key, body string
+		status                  int
+	}{
+		{http.MethodPut, "/v1/documents/lease", "billing-key", contract, http.StatusOK},
+		{http.MethodPost, "/v1/sign", "billing-key", `{"document": ` + contract + `}`, http.StatusConflict},
+		{http.MethodPost, "/v1/documents/lease/sign", "billing-key", "", http.StatusConflict},
+		{http.MethodPost, "/v1/documents/lease/approvals", "billing-key", `{}`, http.StatusForbidden},
+		{http.MethodPost, "/v1/documents/lease/approvals", "legal-key", `{"comment": "Reviewed"}`, http.StatusOK},
+		{http.MethodPost, "/v1/documents/lease/approvals", "legal-key", `{}`, http.StatusConflict},
+		{http.MethodPost, "/v1/documents/lease/approvals", "finance-key", `{"approver": "legal"}`, http.StatusOK},
+		{http.MethodPost, "/v1/documents/lease/sign", "legal-key", "", http.StatusForbidden},
+		{http.MethodPost, "/v1/documents/lease/sign", "billing-key", "", http.StatusOK},
+		{http.MethodPost, "/v1/documents/other/approvals", "legal-key", `{}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "Bearer "+tt.key)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s %s with %q: status %d, want %d: %s", tt.method, tt.path, tt.key, resp.StatusCode, tt.status, body)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/v1/documents/lease/workflow", nil)
+	req.Header.Set("Authorization", "Bearer billing-key")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var state approval.State
+	json.NewDecoder(resp.Body).Decode(&state)
+	if !state.Complete || state.Status != "approved" || len(state.Approvals) != 2 || state.Approvals[1].Approver != "finance" {
+		t.Errorf("workflow = %+v", state)
+	}
+
+	hooks.Close(context.Background())
+	// Deliveries are concurrent
+	sort.Strings(events)
+	if strings.Join(events, ",") != "document.approval,document.approval,document.approved,document.signed" {
+		t.Errorf("events = %v", events)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	ts := httptest.NewServer(New(Options{MaxDocumentSize: 1024, RateLimit: 1, Burst: 2}))
+	defer ts.Close()
+
+	post := func(body string) *http.Response {
+		resp, err := http.Post(ts.URL+"/v1/validate", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp
+	}
+	if resp := post(`{"document": {"padding": "` + strings.Repeat("x", 2048) + `"}}`); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("large document: status %d", resp.StatusCode)
+	}
+	if resp := post(`{"document": ` + contract + `}`); resp.StatusCode != http.StatusOK {
+		t.Errorf("second request: status %d", resp.StatusCode)
+	}
+	resp := post(`{"document": ` + contract + `}`)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("third request: status %d, Retry-After %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	s := New(Options{Timeout: 10 * time.Millisecond})
+	r := httptest.NewRequest(http.MethodPost, "/v1/validate", nil)
+	if _, err := s.call(r, func(context.Context) (interface{}, error) { time.Sleep(time.Second); return nil, nil }); err != errTimeout {
+		t.Errorf("slow call: %v", err)
+	}
+}
+
+func TestLimitsBeforeAuthentication(t *testing.T) {
+	// The issuer is down, so every token sends for its keys
+	var fetches atomic.Int32
+	issuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer issuer.Close()
+	config := &auth.Config{
+		APIKeys: []auth.APIKey{{Name: "ci", SHA256: auth.HashKey("secret"), Scopes: auth.Scopes()}},
+		OIDC:    &auth.OIDC{Issuer: issuer.URL, Audience: "nld"},
+	}
+	authenticator, err := auth.New(config, issuer.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(Options{Auth: authenticator, RateLimit: 1, Burst: 2})
+	s.limiter.now = func() time.Time { return time.Unix(1700000000, 0) }
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	post := func(header, value string) int {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/v1/validate", strings.NewReader(`{"document": `+contract+`}`))
+		req.Header.Set(header, value)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	token := "eyJhbGciOiJSUzI1NiIsImtpZCI6ImsifQ.e30.c2ln"
+	if status := post("Authorization", "Bearer "+token); status != http.StatusServiceUnavailable {
+		t.Errorf("first token: status %d", status)
+	}
+	if status := post(auth.APIKeyHeader, "secret"); status != http.StatusOK {
+		t.Errorf("API key: status %d", status)
+	}
+	// The address is over its limit before the token is verified
+	if status := post("Authorization", "Bearer "+token); status != http.StatusTooManyRequests {
+		t.Errorf("second token: status %d", status)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("keys fetched %d times, expected once", n)
+	}
+}
+
+func TestInFlight(t *testing.T) {
+	s := New(Options{Timeout: 10 * time.Millisecond, MaxInFlight: 2})
+	r := httptest.NewRequest(http.MethodPost, "/v1/validate", nil)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	started := 0
+	stuck := func(context.Context) (interface{}, error) {
+		mu.Lock()
+		started++
+		mu.Unlock()
+		<-release
+		return nil, nil
+	}
+
+	// Calls given up on keep their slot until they return, so later calls
+	// time out without starting more work
+	for i := 0; i < 5; i++ {
+		if _, err := s.call(r, stuck); err != errTimeout {
+			t.Errorf("call %d: %v", i+1, err)
+		}
+	}
+	mu.Lock()
+	if started != 2 {
+		t.Errorf("started %d calls, want 2", started)
+	}
+	mu.Unlock()
+
+	close(release)
+	// Wait for the calls given up on to free their slots
+	for i := 0; i < 2; i++ {
+		s.inFlight <- struct{}{}
+	}
+	for i := 0; i < 2; i++ {
+		<-s.inFlight
+	}
+	if resp, err := s.call(r, func(context.Context) (interface{}, error) { return "ok", nil }); resp != "ok" || err != nil {
+		t.Errorf("call after the others returned = %v, %v", resp, err)
+	}
+
+	// The context of fn ends with the timeout
+	if _, err := s.call(r, func(ctx context.Context) (interface{}, error) { <-ctx.Done(); return nil, ctx.Err() }); err != errTimeout {
+		t.Errorf("call waiting for its context: %v", err)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newLimiter(2, 0)
+	l.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatalf("request %d denied", i+1)
+		}
+	}
+	if ok, wait := l.allow("a"); ok || wait != 500*time.Millisecond {
+		t.Errorf("over the limit: ok %v, wait %v", ok, wait)
+	}
+	if ok, _ := l.allow("b"); !ok {
+		t.Error("other client denied")
+	}
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.allow("a"); !ok {
+		t.Error("request after refill denied")
+	}
+	now = now.Add(time.Hour)
+	l.allow("c")
+	if len(l.buckets) != 1 {
+		t.Errorf("idle clients not pruned: %d buckets", len(l.buckets))
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ts := httptest.NewServer(New(Options{Metrics: true}))
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/v1/validate", "application/json", strings.NewReader(`{"document": `+contract+`}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	resp, err = http.Get(ts.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{
+		`nld_validations_total{type="contract",result=`,
+		`nld_http_requests_total{route="POST /v1/validate",code="200"}`,
+		`nld_cache_requests_total{cache="schema",result=`,
+		`nld_validation_duration_seconds_count{type="contract"}`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics have no %s:\n%s", want, body)
+		}
+	}
+
+	plain := httptest.NewServer(New(Options{}))
+	defer plain.Close()
+	resp, err = http.Get(plain.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("metrics served without Options.Metrics: status %d", resp.StatusCode)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(previous)
+
+	ts := httptest.NewServer(New(Options{}))
+	defer ts.Close()
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/v1/validate", strings.NewReader(`{"document": `+contract+`}`))
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+		if s.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("span %s is not in the trace of the request", s.Name())
+		}
+	}
+	server, validate := spans["POST /v1/validate"], spans["nld.validate"]
+	if server == nil || validate == nil || spans["nld.schema.load"] == nil || spans["nld.validate.schema"] == nil {
+		t.Fatalf("spans = %v", spans)
+	}
+	if server.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("server span parent = %s", server.Parent().SpanID())
+	}
+	if validate.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("validation span is not a child of the server span")
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	config := &auth.Config{APIKeys: []auth.APIKey{{Name: "ci", SHA256: auth.HashKey("secret"), Scopes: auth.Scopes()}}}
+	authenticator, err := auth.New(config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(Options{Auth: authenticator, RateLimit: 10, Metrics: true})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	// The document is open, like health
+	resp, err := http.Get(ts.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d", resp.StatusCode)
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string                     `json:"operationId"`
+			Security    []map[string][]string      `json:"security"`
+			Responses   map[string]json.RawMessage `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+
+	// Every route of the mux is described
+	for _, rt := range s.routes {
+		op, ok := doc.Paths[rt.path][strings.ToLower(rt.method)]
+		if !ok {
+			t.Errorf("%s %s is not in the document", rt.method, rt.path)
+			continue
+		}
+		if _, h := s.mux.Handler(httptest.NewRequest(rt.method, strings.ReplaceAll(rt.path, "{id}", "lease"), nil)); h != rt.method+" "+rt.path {
+			t.Errorf("%s %s is not routed, got %q", rt.method, rt.path, h)
+		}
+		if guarded := rt.scope != ""; guarded != (len(op.Security) > 0) {
+			t.Errorf("%s %s: security = %v", rt.method, rt.path, op.Security)
+		}
+		if _, limited := op.Responses["429"]; limited != (rt.scope != "") {
+			t.Errorf("%s %s: 429 response = %v", rt.method, rt.path, limited)
+		}
+	}
+	if got := doc.Paths["/v1/documents/{id}"]["put"].OperationID; got != "documentsPut" {
+		t.Errorf("operationId = %q, want documentsPut", got)
+	}
+
+	// Every referenced schema is in the components
+	data, _ := json.Marshal(s.OpenAPI())
+	for _, m := range regexp.MustCompile(`"#/components/schemas/(\w+)"`).FindAllStringSubmatch(string(data), -1) {
+		if _, ok := doc.Components.Schemas[m[1]]; !ok {
+			t.Errorf("schema %s is not in the components", m[1])
+		}
+	}
+}
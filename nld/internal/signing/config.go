@@ -0,0 +1,132 @@
+package signing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnv names the signing configuration file instead of the default
+const ConfigEnv = "NLD_SIGNING_CONFIG"
+
+// Config selects the key documents are signed with, read from a YAML or
+// JSON file:
+//
+//	provider: awskms
+//	key: arn:aws:kms:us-east-1:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab
+//	region: us-east-1
+//
+// The fields of each provider are:
+//
+//	file    key: path of a PEM private key, which may be encrypted with
+//	        nld encrypt; passphraseFile decrypts it, or NLD_PASSPHRASE
+//	keyring key: name of a key of the local keyring, see nld keys;
+//	        passphraseFile decrypts it, or NLD_PASSPHRASE
+//	pkcs11  key: hex ID of the key object; module: the PKCS#11 library;
+//	        token: the token label; tool: pkcs11-tool by default. The PIN
+//	        is read from NLD_PKCS11_PIN.
+//	awskms  key: key ID, ARN or alias; region and endpoint, or the AWS
+//	        environment variables. Credentials come from the environment.
+//	gcpkms  key: resource name of the key version; endpoint. The access
+//	        token is read from GOOGLE_OAUTH_ACCESS_TOKEN.
+//
+// Certificates is a PEM file with the X.509 certificate chain of the key,
+// leaf first, embedded in the signatures made with it.
+type Config struct {
+	Provider       string `yaml:"provider"`
+	Key            string `yaml:"key"`
+	PassphraseFile string `yaml:"passphraseFile"`
+	Module         string `yaml:"module"`
+	Token          string `yaml:"token"`
+	Tool           string `yaml:"tool"`
+	Region         string `yaml:"region"`
+	Endpoint       string `yaml:"endpoint"`
+	Certificates   string `yaml:"certificates"`
+
+	// Path is the file the configuration was read from
+	Path string `yaml:"-"`
+}
+
+// DefaultConfigPath returns the signing configuration file: $NLD_SIGNING_CONFIG,
+// or .nld/signing.yaml in the home directory
+func DefaultConfigPath() (string, error) {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory (set %s): %w", ConfigEnv, err)
+	}
+	return filepath.Join(home, ".nld", "signing.yaml"), nil
+}
+
+// ParseConfig reads a configuration from YAML or JSON. The provider
+// defaults to file.
+func ParseConfig(data []byte) (*Config, error) {
+	var c Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid signing config: %w", err)
+	}
+	if c.Provider == "" {
+		c.Provider = File
+	}
+	if _, ok := providers[c.Provider]; !ok {
+		return nil, fmt.Errorf("invalid signing config: unknown provider %q (expected %s)", c.Provider, strings.Join(Providers(), ", "))
+	}
+	return &c, nil
+}
+
+// LoadConfig reads a configuration file, the default one when path is
+// empty. Paths of files are relative to the configuration file, and may
+// start with ~/ for the home directory.
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no signing key configured: create %s or give a key file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing config: %w", err)
+	}
+	c, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c.Path = path
+	if c.Provider == File {
+		c.Key = resolve(filepath.Dir(path), c.Key)
+	}
+	c.PassphraseFile = resolve(filepath.Dir(path), c.PassphraseFile)
+	c.Certificates = resolve(filepath.Dir(path), c.Certificates)
+	return c, nil
+}
+
+// resolve returns path relative to dir, expanding a leading ~/
+func resolve(dir, path string) string {
+	if path == "" {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,53 @@
+package signing
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// DetachedFormat identifies detached signature files
+const DetachedFormat = "nld-detached-signature-v1"
+
+// Detached is a signature kept apart from the document it signs, so that
+// the parties to a document can sign it separately and exchange their
+// signatures before they are assembled into the document
+type Detached struct {
+	Format string `json:"format"`
+	// Title is the title of the document signed, for the people exchanging
+	// the signatures; only Root identifies the document
+	Title string `json:"title,omitempty"`
+	// Root is the root hash signed
+	Root      string        `json:"root"`
+	Signature nld.Signature `json:"signature"`
+}
+
+// NewDetached returns the detached form of a signature of root
+func NewDetached(title, root string, sig nld.Signature) Detached {
+	return Detached{Format: DetachedFormat, Title: title, Root: root, Signature: sig}
+}
+
+// ParseDetached parses a detached signature file
+func ParseDetached(data []byte) (*Detached, error) {
+	var d Detached
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("invalid detached signature: %w", err)
+	}
+	if d.Format != DetachedFormat {
+		return nil, fmt.Errorf("not a detached signature (format %q, expected %q)", d.Format, DetachedFormat)
+	}
+	if d.Root == "" || d.Signature.SignerID == "" {
+		return nil, fmt.Errorf("invalid detached signature: no root or signer")
+	}
+	return &d, nil
+}
+
+// Check verifies a detached signature against the root hash of the
+// document it is assembled into
+func (d *Detached) Check(root string) error {
+	if d.Root != root {
+		return fmt.Errorf("signed root %s, not the root %s of the document", d.Root, root)
+	}
+	return Verify(d.Signature, root)
+}
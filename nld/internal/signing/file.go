@@ -0,0 +1,71 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+)
+
+// File is the provider of keys in local PEM files
+const File = "file"
+
+func init() {
+	register(File, func(ctx context.Context, cfg *Config) (crypto.Signer, error) {
+		data, err := os.ReadFile(cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signing key: %w", err)
+		}
+		return decodePrivateKey(data, cfg.Key, cfg.PassphraseFile)
+	})
+}
+
+// decodePrivateKey parses a PEM private key named name, decrypting it with
+// the passphrase of passphraseFile or NLD_PASSPHRASE when it is encrypted
+func decodePrivateKey(data []byte, name, passphraseFile string) (crypto.Signer, error) {
+	if envelope.IsEncrypted(data) {
+		key, err := envelope.LoadKey("", passphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil {
+			return nil, fmt.Errorf("signing key %s is encrypted; give its passphrase file or set %s", name, envelope.PassphraseEnv)
+		}
+		if data, err = envelope.Decrypt(data, key); err != nil {
+			return nil, fmt.Errorf("failed to decrypt signing key %s: %w", name, err)
+		}
+	}
+	return ParsePrivateKey(data)
+}
+
+// ParsePrivateKey parses a PEM private key in PKCS#8, SEC 1 or PKCS#1 form
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signing key is not a PEM private key")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing key %T", key)
+	}
+	return signer, nil
+}
@@ -0,0 +1,301 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+)
+
+// KeyringProvider is the provider of keys in the local keyring, named by
+// the key of the configuration
+const KeyringProvider = "keyring"
+
+// KeyringEnv names the keyring directory instead of the default
+const KeyringEnv = "NLD_KEYRING"
+
+func init() {
+	register(KeyringProvider, func(ctx context.Context, cfg *Config) (crypto.Signer, error) {
+		r, err := OpenKeyring("")
+		if err != nil {
+			return nil, err
+		}
+		return r.Signer(cfg.Key, cfg.PassphraseFile)
+	})
+}
+
+// keyName matches the names of keyring keys, which are also file names
+var keyName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// KeyEntry describes a key of a keyring
+type KeyEntry struct {
+	Name      string     `json:"name"`
+	Algorithm string     `json:"algorithm"`
+	KeyID     string     `json:"keyId"`
+	PublicKey string     `json:"publicKey"`
+	Encrypted bool       `json:"encrypted"`
+	Created   time.Time  `json:"created"`
+	Revoked   *time.Time `json:"revoked,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+}
+
+// PublicKeyPEM returns the public key of an entry as a PEM block
+func (e *KeyEntry) PublicKeyPEM() ([]byte, error) {
+	der, err := base64.StdEncoding.DecodeString(e.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of key %s", e.Name)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// Keyring is a directory of private keys. Each key is stored as
+// <name>.pem, encrypted with nld encrypt when it is protected by a
+// passphrase, next to its description in <name>.json.
+type Keyring struct {
+	Dir string
+}
+
+// DefaultKeyringDir returns the keyring directory: $NLD_KEYRING, or
+// .nld/keys in the home directory
+func DefaultKeyringDir() (string, error) {
+	if dir := os.Getenv(KeyringEnv); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory (set %s): %w", KeyringEnv, err)
+	}
+	return filepath.Join(home, ".nld", "keys"), nil
+}
+
+// OpenKeyring returns the keyring of dir, the default one when dir is
+// empty. The directory is created when a key is first added.
+func OpenKeyring(dir string) (*Keyring, error) {
+	if dir == "" {
+		var err error
+		if dir, err = DefaultKeyringDir(); err != nil {
+			return nil, err
+		}
+	}
+	return &Keyring{Dir: dir}, nil
+}
+
+// Generate creates a key for an algorithm and adds it to the keyring,
+// encrypted with key unless it is nil
+func (r *Keyring) Generate(name, alg string, key *envelope.Key) (*KeyEntry, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case ES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case ES384:
+		private, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case RS256:
+		private, err = rsa.GenerateKey(rand.Reader, 3072)
+	case EdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q (expected %s, %s, %s or %s)", alg, ES256, ES384, RS256, EdDSA)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return r.add(name, private, key)
+}
+
+// Import adds a PEM private key to the keyring, encrypted with key unless
+// it is nil. A key encrypted with nld encrypt is decrypted with key first.
+func (r *Keyring) Import(name string, data []byte, key *envelope.Key) (*KeyEntry, error) {
+	if envelope.IsEncrypted(data) {
+		if key == nil {
+			return nil, envelope.ErrNoKey
+		}
+		var err error
+		if data, err = envelope.Decrypt(data, key); err != nil {
+			return nil, err
+		}
+	}
+	private, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return r.add(name, private, key)
+}
+
+// add stores a private key under a new name
+func (r *Keyring) add(name string, private crypto.Signer, key *envelope.Key) (*KeyEntry, error) {
+	if !keyName.MatchString(name) {
+		return nil, fmt.Errorf("invalid key name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	if _, err := os.Stat(r.path(name, ".json")); err == nil {
+		return nil, fmt.Errorf("key %s already exists in %s", name, r.Dir)
+	}
+	alg, err := Algorithm(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	id, err := KeyID(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	public, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if key != nil {
+		if data, err = envelope.Encrypt(data, key); err != nil {
+			return nil, err
+		}
+	}
+
+	e := &KeyEntry{
+		Name:      name,
+		Algorithm: alg,
+		KeyID:     id,
+		PublicKey: base64.StdEncoding.EncodeToString(public),
+		Encrypted: key != nil,
+		Created:   time.Now().UTC().Truncate(time.Second),
+	}
+	if err := os.MkdirAll(r.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create keyring: %w", err)
+	}
+	if err := os.WriteFile(r.path(name, ".pem"), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write key: %w", err)
+	}
+	if err := r.save(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// List returns the keys of the keyring, sorted by name
+func (r *Keyring) List() ([]*KeyEntry, error) {
+	files, err := os.ReadDir(r.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+	var entries []*KeyEntry
+	for _, f := range files {
+		name, ok := strings.CutSuffix(f.Name(), ".json")
+		if !ok || f.IsDir() {
+			continue
+		}
+		e, err := r.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Get returns the key of a name
+func (r *Keyring) Get(name string) (*KeyEntry, error) {
+	if !keyName.MatchString(name) {
+		return nil, fmt.Errorf("invalid key name %q", name)
+	}
+	data, err := os.ReadFile(r.path(name, ".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no key %s in %s", name, r.Dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	var e KeyEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("invalid key %s: %w", name, err)
+	}
+	return &e, nil
+}
+
+// PrivateKey returns the stored PEM private key of a name, still encrypted
+// when the key is protected by a passphrase
+func (r *Keyring) PrivateKey(name string) ([]byte, error) {
+	if _, err := r.Get(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(r.path(name, ".pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	return data, nil
+}
+
+// Signer returns the signer of the key of a name, decrypting it with the
+// passphrase of passphraseFile or NLD_PASSPHRASE. Revoked keys do not sign.
+func (r *Keyring) Signer(name, passphraseFile string) (crypto.Signer, error) {
+	e, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if e.Revoked != nil {
+		return nil, fmt.Errorf("key %s was revoked on %s", name, e.Revoked.Format(time.RFC3339))
+	}
+	data, err := r.PrivateKey(name)
+	if err != nil {
+		return nil, err
+	}
+	return decodePrivateKey(data, name, passphraseFile)
+}
+
+// Revoke marks the key of a name as revoked, so that it no longer signs.
+// The key stays in the keyring for its public key to be exported.
+func (r *Keyring) Revoke(name, reason string) (*KeyEntry, error) {
+	e, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if e.Revoked != nil {
+		return nil, fmt.Errorf("key %s is already revoked", name)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	e.Revoked, e.Reason = &now, reason
+	if err := r.save(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// save writes the description of a key
+func (r *Keyring) save(e *KeyEntry) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(r.path(e.Name, ".json"), append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+	return nil
+}
+
+// path returns the file of a key with an extension
+func (r *Keyring) path(name, ext string) string {
+	return filepath.Join(r.Dir, name+ext)
+}
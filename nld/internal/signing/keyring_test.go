@@ -0,0 +1,128 @@
+package signing
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/envelope"
+)
+
+func TestKeyring(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	t.Setenv(KeyringEnv, dir)
+	t.Setenv(envelope.PassphraseEnv, "")
+	r, err := OpenKeyring("")
+	if err != nil || r.Dir != dir {
+		t.Fatalf("OpenKeyring = %+v, %v", r, err)
+	}
+	if entries, err := r.List(); err != nil || len(entries) != 0 {
+		t.Errorf("List of a missing keyring = %v, %v", entries, err)
+	}
+	secret := &envelope.Key{Passphrase: []byte("secret")}
+	passphrase := filepath.Join(t.TempDir(), "passphrase")
+	os.WriteFile(passphrase, []byte("secret\n"), 0600)
+
+	for _, alg := range []string{ES256, ES384, RS256, EdDSA} {
+		t.Run(alg, func(t *testing.T) {
+			e, err := r.Generate(strings.ToLower(alg), alg, secret)
+			if err != nil {
+				t.Fatalf("Generate failed: %v", err)
+			}
+			if e.Algorithm != alg || !e.Encrypted || !strings.HasPrefix(e.KeyID, "sha256:") {
+				t.Errorf("Generate = %+v", e)
+			}
+			if _, err := r.Signer(e.Name, ""); err == nil || !strings.Contains(err.Error(), "encrypted") {
+				t.Errorf("Signer without passphrase error = %v", err)
+			}
+			signer, err := r.Signer(e.Name, passphrase)
+			if err != nil {
+				t.Fatalf("Signer failed: %v", err)
+			}
+			sig, err := Sign(signer, root, "party1", "2025-03-01T10:00:00Z")
+			if err != nil || sig.KeyID != e.KeyID || Verify(sig, root) != nil {
+				t.Errorf("Sign = %+v, %v", sig, err)
+			}
+			if data, err := e.PublicKeyPEM(); err != nil || !strings.Contains(string(data), "PUBLIC KEY") {
+				t.Errorf("PublicKeyPEM = %s, %v", data, err)
+			}
+		})
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "es256.pem"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %v, %v", info, err)
+	}
+	if _, err := r.Generate("es256", ES256, nil); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Generate of an existing key error = %v", err)
+	}
+	for _, name := range []string{"", "../escape", ".hidden", "a/b"} {
+		if _, err := r.Generate(name, ES256, nil); err == nil {
+			t.Errorf("Generate(%q) succeeded", name)
+		}
+	}
+	if _, err := r.Generate("dsa", "DSA", nil); err == nil {
+		t.Error("Generate of an unknown algorithm succeeded")
+	}
+
+	// Imported keys keep their key ID, and encrypted ones are decrypted
+	// with the passphrase they are stored with
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalECPrivateKey(key)
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	id, _ := KeyID(&key.PublicKey)
+	plain, err := r.Import("plain", data, nil)
+	if err != nil || plain.KeyID != id || plain.Encrypted {
+		t.Errorf("Import = %+v, %v", plain, err)
+	}
+	encrypted, _ := envelope.Encrypt(data, secret)
+	if _, err := r.Import("protected", encrypted, nil); err == nil {
+		t.Error("Import of an encrypted key without passphrase succeeded")
+	}
+	if e, err := r.Import("protected", encrypted, secret); err != nil || e.KeyID != id || !e.Encrypted {
+		t.Errorf("Import of an encrypted key = %+v, %v", e, err)
+	}
+	stored, err := r.PrivateKey("protected")
+	if err != nil || !envelope.IsEncrypted(stored) {
+		t.Errorf("PrivateKey = %s, %v", stored, err)
+	}
+
+	// The keyring provider signs with keys of the default keyring
+	signer, err := Open(context.Background(), &Config{Provider: KeyringProvider, Key: "plain"})
+	if err != nil || !key.PublicKey.Equal(signer.Public()) {
+		t.Errorf("Open = %v, %v", signer, err)
+	}
+
+	revoked, err := r.Revoke("plain", "superseded")
+	if err != nil || revoked.Revoked == nil || revoked.Reason != "superseded" {
+		t.Errorf("Revoke = %+v, %v", revoked, err)
+	}
+	if _, err := r.Signer("plain", ""); err == nil || !strings.Contains(err.Error(), "revoked") {
+		t.Errorf("Signer of a revoked key error = %v", err)
+	}
+	if _, err := r.Revoke("plain", ""); err == nil {
+		t.Error("Revoke of a revoked key succeeded")
+	}
+	if _, err := r.Revoke("missing", ""); err == nil {
+		t.Error("Revoke of a missing key succeeded")
+	}
+
+	entries, err := r.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if strings.Join(names, " ") != "eddsa es256 es384 plain protected rs256" {
+		t.Errorf("List = %v", names)
+	}
+}
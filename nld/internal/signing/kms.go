@@ -0,0 +1,226 @@
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/sigv4"
+)
+
+const (
+	// AWSKMS is the provider of keys in AWS Key Management Service
+	AWSKMS = "awskms"
+	// GCPKMS is the provider of keys in Google Cloud Key Management
+	GCPKMS = "gcpkms"
+	// GCPTokenEnv holds an OAuth access token of Google Cloud, such as the
+	// output of gcloud auth print-access-token
+	GCPTokenEnv = "GOOGLE_OAUTH_ACCESS_TOKEN"
+	// GCPDefaultEndpoint is the Cloud KMS API
+	GCPDefaultEndpoint = "https://cloudkms.googleapis.com/v1"
+)
+
+func init() {
+	register(AWSKMS, openAWS)
+	register(GCPKMS, openGCP)
+}
+
+// kmsSigner signs with a key that never leaves a cloud KMS
+type kmsSigner struct {
+	public crypto.PublicKey
+	sign   func(digest []byte, h crypto.Hash) ([]byte, error)
+}
+
+// Public returns the public key of the KMS key
+func (s *kmsSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs a digest with the KMS key
+func (s *kmsSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() == 0 {
+		return nil, fmt.Errorf("KMS keys must sign a hash")
+	}
+	return s.sign(digest, opts.HashFunc())
+}
+
+// awsKMS calls the API of AWS KMS in a region
+type awsKMS struct {
+	ctx      context.Context
+	endpoint string
+	region   string
+	creds    sigv4.Credentials
+	client   *http.Client
+}
+
+// openAWS returns the signer of an AWS KMS key, with credentials and
+// region from the AWS environment variables unless configured
+func openAWS(ctx context.Context, cfg *Config) (crypto.Signer, error) {
+	k := &awsKMS{
+		ctx:    ctx,
+		region: cfg.Region,
+		creds:  sigv4.Credentials{AccessKey: os.Getenv("AWS_ACCESS_KEY_ID"), SecretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"), Token: os.Getenv("AWS_SESSION_TOKEN")},
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+	if k.creds.AccessKey == "" || k.creds.SecretKey == "" {
+		return nil, fmt.Errorf("no credentials for AWS KMS: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if k.region == "" {
+			k.region = os.Getenv(env)
+		}
+	}
+	if k.region == "" {
+		return nil, fmt.Errorf("no region for AWS KMS: set region in the signing config or AWS_REGION")
+	}
+	k.endpoint = cfg.Endpoint
+	for _, env := range []string{"AWS_ENDPOINT_URL_KMS", "AWS_ENDPOINT_URL"} {
+		if k.endpoint == "" {
+			k.endpoint = os.Getenv(env)
+		}
+	}
+	if k.endpoint == "" {
+		k.endpoint = "https://kms." + k.region + ".amazonaws.com"
+	}
+
+	var out struct {
+		PublicKey []byte `json:"PublicKey"`
+	}
+	if err := k.call("GetPublicKey", map[string]interface{}{"KeyId": cfg.Key}, &out); err != nil {
+		return nil, fmt.Errorf("failed to get public key of AWS KMS key %s: %w", cfg.Key, err)
+	}
+	public, err := x509.ParsePKIXPublicKey(out.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of AWS KMS key %s: %w", cfg.Key, err)
+	}
+
+	return &kmsSigner{public: public, sign: func(digest []byte, h crypto.Hash) ([]byte, error) {
+		alg, err := awsAlgorithm(public, h)
+		if err != nil {
+			return nil, err
+		}
+		var out struct {
+			Signature []byte `json:"Signature"`
+		}
+		if err := k.call("Sign", map[string]interface{}{"KeyId": cfg.Key, "Message": digest, "MessageType": "DIGEST", "SigningAlgorithm": alg}, &out); err != nil {
+			return nil, fmt.Errorf("failed to sign with AWS KMS key %s: %w", cfg.Key, err)
+		}
+		return out.Signature, nil
+	}}, nil
+}
+
+// awsAlgorithm returns the AWS KMS signing algorithm of a key and hash
+func awsAlgorithm(public crypto.PublicKey, h crypto.Hash) (string, error) {
+	bits := map[crypto.Hash]string{crypto.SHA256: "256", crypto.SHA384: "384"}[h]
+	if bits == "" {
+		return "", fmt.Errorf("unsupported hash %v for AWS KMS", h)
+	}
+	switch public.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA_SHA_" + bits, nil
+	case *rsa.PublicKey:
+		return "RSASSA_PKCS1_V1_5_SHA_" + bits, nil
+	}
+	return "", fmt.Errorf("unsupported AWS KMS key %T", public)
+}
+
+// call calls an action of the AWS KMS JSON API; []byte fields are sent and
+// received in base64
+func (k *awsKMS) call(action string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(strings.TrimSuffix(k.endpoint, "/") + "/")
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %q", k.endpoint)
+	}
+	req, err := http.NewRequestWithContext(k.ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	sigv4.Sign(req, u.EscapedPath(), body, k.creds, k.region, "kms", time.Now())
+	return call(k.client, req, out)
+}
+
+// openGCP returns the signer of a Cloud KMS key version, authenticated with
+// the access token of GOOGLE_OAUTH_ACCESS_TOKEN
+func openGCP(ctx context.Context, cfg *Config) (crypto.Signer, error) {
+	token := os.Getenv(GCPTokenEnv)
+	if token == "" {
+		return nil, fmt.Errorf("no credentials for Cloud KMS: set %s, such as to the output of gcloud auth print-access-token", GCPTokenEnv)
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = GCPDefaultEndpoint
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	request := func(method, path string, in interface{}, out interface{}) error {
+		var body io.Reader
+		if in != nil {
+			data, err := json.Marshal(in)
+			if err != nil {
+				return err
+			}
+			body = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(endpoint, "/")+"/"+path, body)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		return call(client, req, out)
+	}
+
+	var out struct {
+		PEM string `json:"pem"`
+	}
+	if err := request(http.MethodGet, cfg.Key+"/publicKey", nil, &out); err != nil {
+		return nil, fmt.Errorf("failed to get public key of Cloud KMS key %s: %w", cfg.Key, err)
+	}
+	block, _ := pem.Decode([]byte(out.PEM))
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key of Cloud KMS key %s", cfg.Key)
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of Cloud KMS key %s: %w", cfg.Key, err)
+	}
+
+	return &kmsSigner{public: public, sign: func(digest []byte, h crypto.Hash) ([]byte, error) {
+		name := map[crypto.Hash]string{crypto.SHA256: "sha256", crypto.SHA384: "sha384"}[h]
+		if name == "" {
+			return nil, fmt.Errorf("unsupported hash %v for Cloud KMS", h)
+		}
+		var out struct {
+			Signature string `json:"signature"`
+		}
+		in := map[string]interface{}{"digest": map[string]string{name: base64.StdEncoding.EncodeToString(digest)}}
+		if err := request(http.MethodPost, cfg.Key+":asymmetricSign", in, &out); err != nil {
+			return nil, fmt.Errorf("failed to sign with Cloud KMS key %s: %w", cfg.Key, err)
+		}
+		sig, err := base64.StdEncoding.DecodeString(out.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature from Cloud KMS: %w", err)
+		}
+		return sig, nil
+	}}, nil
+}
@@ -0,0 +1,126 @@
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// PKCS11 is the provider of keys in PKCS#11 tokens, such as HSMs and
+	// smart cards
+	PKCS11 = "pkcs11"
+	// PINEnv holds the user PIN of the token
+	PINEnv = "NLD_PKCS11_PIN"
+	// DefaultTool is the OpenSC command that talks to the token
+	DefaultTool = "pkcs11-tool"
+)
+
+// digestInfo are the DER prefixes of the hashes signed with RSA PKCS #1
+// v1.5, which the RSA-PKCS mechanism expects before the hash
+var digestInfo = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+}
+
+func init() {
+	register(PKCS11, func(ctx context.Context, cfg *Config) (crypto.Signer, error) {
+		if cfg.Module == "" {
+			return nil, fmt.Errorf("no PKCS#11 module configured")
+		}
+		s := &pkcs11Signer{ctx: ctx, cfg: cfg, tool: cfg.Tool}
+		if s.tool == "" {
+			s.tool = DefaultTool
+		}
+		der, err := s.run(nil, "--read-object", "--type", "pubkey")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key of PKCS#11 key %s: %w", cfg.Key, err)
+		}
+		if s.public, err = x509.ParsePKIXPublicKey(der); err != nil {
+			return nil, fmt.Errorf("invalid public key of PKCS#11 key %s: %w", cfg.Key, err)
+		}
+		return s, nil
+	})
+}
+
+// pkcs11Signer signs with a key that never leaves its token, through
+// pkcs11-tool of OpenSC
+type pkcs11Signer struct {
+	ctx    context.Context
+	cfg    *Config
+	tool   string
+	public crypto.PublicKey
+}
+
+// Public returns the public key read from the token
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs a digest on the token
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var args []string
+	switch s.public.(type) {
+	case *rsa.PublicKey:
+		prefix, ok := digestInfo[opts.HashFunc()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported hash %v for PKCS#11 RSA keys", opts.HashFunc())
+		}
+		digest = append(append([]byte{}, prefix...), digest...)
+		args = []string{"--sign", "--mechanism", "RSA-PKCS"}
+	default:
+		if opts.HashFunc() == 0 {
+			return nil, fmt.Errorf("PKCS#11 keys must sign a hash")
+		}
+		args = []string{"--sign", "--mechanism", "ECDSA", "--signature-format", "openssl"}
+	}
+	args = append(args, "--login")
+	if os.Getenv(PINEnv) != "" {
+		args = append(args, "--pin", "env:"+PINEnv)
+	}
+	sig, err := s.run(digest, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with PKCS#11 key %s: %w", s.cfg.Key, err)
+	}
+	return sig, nil
+}
+
+// run runs the tool on the key with input in a temporary file, returning
+// what it writes to its output file
+func (s *pkcs11Signer) run(input []byte, args ...string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "nld-pkcs11-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+
+	args = append([]string{"--module", s.cfg.Module, "--id", s.cfg.Key, "--output-file", out}, args...)
+	if s.cfg.Token != "" {
+		args = append(args, "--token-label", s.cfg.Token)
+	}
+	if input != nil {
+		if err := os.WriteFile(in, input, 0600); err != nil {
+			return nil, err
+		}
+		args = append(args, "--input-file", in)
+	}
+	cmd := exec.CommandContext(s.ctx, s.tool, args...)
+	var stderr bytes.Buffer
+	cmd.Stdin, cmd.Stderr = os.Stdin, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return os.ReadFile(out)
+}
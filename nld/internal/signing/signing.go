@@ -0,0 +1,249 @@
+// Package signing signs the root hash of documents with keys held by a key
+// provider: a local key file, a PKCS#11 token such as an HSM, or a cloud
+// KMS, so that production keys need never be stored on disk in plaintext.
+// Signatures are added to verification.signatures with the algorithm and
+// public key needed to verify them.
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Algorithms of signatures, named as in JSON Web Signatures
+const (
+	ES256 = "ES256"
+	ES384 = "ES384"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// payloadPrefix versions the signed payload
+const payloadPrefix = "nld-signature-v1"
+
+// Payload returns the bytes signed for a signer: the root hash of the
+// document, the ID of the signing entity and the date of the signature,
+// so that none of them can be changed without breaking the signature
+func Payload(root, signerID, date string) []byte {
+	return []byte(strings.Join([]string{payloadPrefix, root, signerID, date}, "\n"))
+}
+
+// Algorithm returns the algorithm of signatures made with a public key
+func Algorithm(pub crypto.PublicKey) (string, error) {
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return ES256, nil
+		case elliptic.P384():
+			return ES384, nil
+		}
+		return "", fmt.Errorf("unsupported ECDSA curve %s", k.Curve.Params().Name)
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return "", fmt.Errorf("RSA keys must have at least 2048 bits, not %d", k.N.BitLen())
+		}
+		return RS256, nil
+	case ed25519.PublicKey:
+		return EdDSA, nil
+	}
+	return "", fmt.Errorf("unsupported key type %T", pub)
+}
+
+// hashOf returns the hash function of an algorithm, or 0 for EdDSA, which
+// signs the payload itself
+func hashOf(alg string) crypto.Hash {
+	switch alg {
+	case ES384:
+		return crypto.SHA384
+	case EdDSA:
+		return 0
+	}
+	return crypto.SHA256
+}
+
+// KeyID returns the ID of a public key: the hash of its DER encoding
+func KeyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key: %w", err)
+	}
+	return digest.Sum(der), nil
+}
+
+// Sign signs the root hash of a document for an entity, returning the
+// signature to add to its verification object. date is an RFC 3339 time.
+func Sign(signer crypto.Signer, root, signerID, date string) (nld.Signature, error) {
+	pub := signer.Public()
+	alg, err := Algorithm(pub)
+	if err != nil {
+		return nld.Signature{}, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nld.Signature{}, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	message := Payload(root, signerID, date)
+	h := hashOf(alg)
+	if h != 0 {
+		hasher := h.New()
+		hasher.Write(message)
+		message = hasher.Sum(nil)
+	}
+	value, err := signer.Sign(rand.Reader, message, h)
+	if err != nil {
+		return nld.Signature{}, fmt.Errorf("failed to sign: %w", err)
+	}
+	return nld.Signature{
+		SignerID:  signerID,
+		Date:      date,
+		Value:     base64.StdEncoding.EncodeToString(value),
+		Algorithm: alg,
+		KeyID:     digest.Sum(der),
+		PublicKey: base64.StdEncoding.EncodeToString(der),
+	}, nil
+}
+
+// ErrUnsigned is returned by Verify for signatures without an algorithm,
+// such as those brought in from e-signature providers, which cannot be
+// verified with a key
+var ErrUnsigned = errors.New("not a cryptographic signature")
+
+// PublicKey returns the public key of a signature, checking it against its
+// key ID
+func PublicKey(sig nld.Signature) (crypto.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(sig.PublicKey)
+	if err != nil || sig.PublicKey == "" {
+		return nil, fmt.Errorf("invalid public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if sig.KeyID != "" && sig.KeyID != digest.Sum(der) {
+		return nil, fmt.Errorf("public key does not match key ID %s", sig.KeyID)
+	}
+	return pub, nil
+}
+
+// Verify checks a signature against the root hash of a document
+func Verify(sig nld.Signature, root string) error {
+	if sig.Algorithm == "" {
+		return ErrUnsigned
+	}
+	pub, err := PublicKey(sig)
+	if err != nil {
+		return err
+	}
+	alg, err := Algorithm(pub)
+	if err != nil {
+		return err
+	}
+	if alg != sig.Algorithm {
+		return fmt.Errorf("algorithm %s does not match the %s key", sig.Algorithm, alg)
+	}
+	value, err := base64.StdEncoding.DecodeString(sig.Value)
+	if err != nil {
+		return fmt.Errorf("invalid signature value")
+	}
+
+	message := Payload(root, sig.SignerID, sig.Date)
+	h := hashOf(alg)
+	if h != 0 {
+		hasher := h.New()
+		hasher.Write(message)
+		message = hasher.Sum(nil)
+	}
+	ok := false
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(k, message, value)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(k, h, message, value) == nil
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(k, message, value)
+	}
+	if !ok {
+		return fmt.Errorf("signature does not match the document")
+	}
+	return nil
+}
+
+// providers open the signers of key providers by name
+var providers = map[string]func(ctx context.Context, cfg *Config) (crypto.Signer, error){}
+
+// register makes a key provider available to Open
+func register(name string, open func(ctx context.Context, cfg *Config) (crypto.Signer, error)) {
+	providers[name] = open
+}
+
+// Providers returns the names of the key providers, sorted
+func Providers() []string {
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open returns the signer of the key of a configuration. Requests of
+// remote providers are made with ctx.
+func Open(ctx context.Context, cfg *Config) (crypto.Signer, error) {
+	open, ok := providers[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown key provider %q (expected %s)", cfg.Provider, strings.Join(Providers(), ", "))
+	}
+	if cfg.Key == "" {
+		return nil, fmt.Errorf("no key configured for the %s provider", cfg.Provider)
+	}
+	signer, err := open(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Algorithm(signer.Public()); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// call sends an HTTP request and decodes the JSON response into out
+func call(client *http.Client, req *http.Request, out interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+	"golang.org/x/crypto/ocsp"
+	"gopkg.in/yaml.v3"
+)
+
+// TrustPolicyEnv names the trust policy file used by default
+const TrustPolicyEnv = "NLD_TRUST_POLICY"
+
+// Revocation checking modes of a trust policy
+const (
+	RevocationNone = "none"
+	RevocationCRL  = "crl"
+	RevocationOCSP = "ocsp"
+	// RevocationAny asks the OCSP responder of a certificate, or reads its
+	// CRL when it has no responder
+	RevocationAny = "any"
+)
+
+// documentSigning is the extended key usage of document signing
+// certificates, RFC 9336
+var documentSigning = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 36}
+
+// keyUsages are the key usages a policy can require, by name
+var keyUsages = map[string]x509.KeyUsage{
+	"digitalSignature":  x509.KeyUsageDigitalSignature,
+	"contentCommitment": x509.KeyUsageContentCommitment,
+}
+
+// extKeyUsages are the extended key usages a policy can allow, by name;
+// documentSigning is checked apart, as crypto/x509 does not know it
+var extKeyUsages = map[string]x509.ExtKeyUsage{
+	"any":             x509.ExtKeyUsageAny,
+	"emailProtection": x509.ExtKeyUsageEmailProtection,
+	"codeSigning":     x509.ExtKeyUsageCodeSigning,
+	"clientAuth":      x509.ExtKeyUsageClientAuth,
+	"documentSigning": -1,
+}
+
+// Policy decides which signatures are trusted, read from a YAML or JSON
+// file:
+//
+//	roots: [certs/root-ca.pem]
+//	keyUsages: [digitalSignature, contentCommitment]
+//	extKeyUsages: [documentSigning, emailProtection]
+//	revocation: any
+//
+// A signature is trusted when its key ID is listed in keys, or its
+// certificate chain leads to one of the roots, was valid on the date of
+// the signature, allows the usages and is not revoked.
+type Policy struct {
+	// Roots and Intermediates are PEM files of certificates, relative to
+	// the policy file. Intermediates complete the chains embedded in
+	// signatures.
+	Roots         []string `yaml:"roots"`
+	Intermediates []string `yaml:"intermediates"`
+	// Keys are the IDs of keys trusted without a certificate
+	Keys []string `yaml:"keys"`
+	// KeyUsages are the key usages of which the leaf certificate must allow
+	// at least one
+	KeyUsages []string `yaml:"keyUsages"`
+	// ExtKeyUsages are the extended key usages of which the leaf must allow
+	// at least one, when it has any; empty means any
+	ExtKeyUsages []string `yaml:"extKeyUsages"`
+	// Revocation is how certificates are checked for revocation: none, the
+	// default, crl, ocsp or any
+	Revocation string `yaml:"revocation"`
+
+	// Path is the file the policy was read from
+	Path string `yaml:"-"`
+	// Client fetches CRLs and asks OCSP responders
+	Client *http.Client `yaml:"-"`
+
+	roots         *x509.CertPool
+	intermediates []*x509.Certificate
+}
+
+// ParsePolicy reads a policy from YAML or JSON, loading its certificate
+// files relative to dir
+func ParsePolicy(data []byte, dir string) (*Policy, error) {
+	var p Policy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid trust policy: %w", err)
+	}
+	switch p.Revocation {
+	case "":
+		p.Revocation = RevocationNone
+	case RevocationNone, RevocationCRL, RevocationOCSP, RevocationAny:
+	default:
+		return nil, fmt.Errorf("invalid trust policy: revocation must be %s, %s, %s or %s, not %q", RevocationNone, RevocationCRL, RevocationOCSP, RevocationAny, p.Revocation)
+	}
+	for _, u := range p.KeyUsages {
+		if _, ok := keyUsages[u]; !ok {
+			return nil, fmt.Errorf("invalid trust policy: unknown key usage %q", u)
+		}
+	}
+	for _, u := range p.ExtKeyUsages {
+		if _, ok := extKeyUsages[u]; !ok {
+			return nil, fmt.Errorf("invalid trust policy: unknown extended key usage %q", u)
+		}
+	}
+	if len(p.Roots) == 0 && len(p.Keys) == 0 {
+		return nil, fmt.Errorf("invalid trust policy: no roots or keys to trust")
+	}
+
+	p.roots = x509.NewCertPool()
+	for _, path := range p.Roots {
+		certs, err := LoadCertificates(resolve(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range certs {
+			p.roots.AddCert(c)
+		}
+	}
+	for _, path := range p.Intermediates {
+		certs, err := LoadCertificates(resolve(dir, path))
+		if err != nil {
+			return nil, err
+		}
+		p.intermediates = append(p.intermediates, certs...)
+	}
+	p.Client = &http.Client{Timeout: 10 * time.Second}
+	return &p, nil
+}
+
+// LoadPolicy reads a policy file
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust policy: %w", err)
+	}
+	p, err := ParsePolicy(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.Path = path
+	return p, nil
+}
+
+// LoadCertificates reads the PEM certificates of a file, in order
+func LoadCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificates: %w", err)
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in %s: %w", path, err)
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates in %s", path)
+	}
+	return certs, nil
+}
+
+// Attach embeds a certificate chain, leaf first, in a signature. The leaf
+// must certify the key of the signature.
+func Attach(sig *nld.Signature, chain []*x509.Certificate) error {
+	if len(chain) == 0 {
+		return nil
+	}
+	der, err := base64.StdEncoding.DecodeString(sig.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key")
+	}
+	leaf, err := x509.MarshalPKIXPublicKey(chain[0].PublicKey)
+	if err != nil || !bytes.Equal(der, leaf) {
+		return fmt.Errorf("certificate %s is not for the signing key", chain[0].Subject)
+	}
+	sig.Certificates = nil
+	for _, c := range chain {
+		sig.Certificates = append(sig.Certificates, base64.StdEncoding.EncodeToString(c.Raw))
+	}
+	return nil
+}
+
+// Certificates returns the certificate chain embedded in a signature
+func Certificates(sig nld.Signature) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for i, encoded := range sig.Certificates {
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate %d", i+1)
+		}
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate %d: %w", i+1, err)
+		}
+		certs = append(certs, c)
+	}
+	return certs, nil
+}
+
+// Check reports why a signature, already verified with Verify, is not
+// trusted by the policy, or nil when it is
+func (p *Policy) Check(ctx context.Context, sig nld.Signature) error {
+	for _, id := range p.Keys {
+		if id == sig.KeyID {
+			return nil
+		}
+	}
+	certs, err := Certificates(sig)
+	if err != nil {
+		return err
+	}
+	if len(certs) == 0 {
+		return fmt.Errorf("key %s is not trusted and has no certificate", sig.KeyID)
+	}
+	leaf := certs[0]
+	der, _ := base64.StdEncoding.DecodeString(sig.PublicKey)
+	if key, err := x509.MarshalPKIXPublicKey(leaf.PublicKey); err != nil || !bytes.Equal(der, key) {
+		return fmt.Errorf("certificate %s is not for the signing key", leaf.Subject)
+	}
+	signed, err := time.Parse(time.RFC3339, sig.Date)
+	if err != nil {
+		return fmt.Errorf("invalid signature date %q", sig.Date)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range append(certs[1:], p.intermediates...) {
+		intermediates.AddCert(c)
+	}
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         p.roots,
+		Intermediates: intermediates,
+		CurrentTime:   signed,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("untrusted certificate %s: %w", leaf.Subject, err)
+	}
+	if err := p.checkUsage(leaf); err != nil {
+		return err
+	}
+	return p.checkRevocation(ctx, chains[0])
+}
+
+// checkUsage checks the key usages of the leaf certificate
+func (p *Policy) checkUsage(leaf *x509.Certificate) error {
+	if len(p.KeyUsages) > 0 {
+		ok := false
+		for _, name := range p.KeyUsages {
+			ok = ok || leaf.KeyUsage&keyUsages[name] != 0
+		}
+		if !ok {
+			return fmt.Errorf("certificate %s does not allow %s", leaf.Subject, strings.Join(p.KeyUsages, " or "))
+		}
+	}
+	if len(p.ExtKeyUsages) == 0 || (len(leaf.ExtKeyUsage) == 0 && len(leaf.UnknownExtKeyUsage) == 0) {
+		return nil
+	}
+	for _, name := range p.ExtKeyUsages {
+		if name == "any" {
+			return nil
+		}
+		if name == "documentSigning" {
+			for _, oid := range leaf.UnknownExtKeyUsage {
+				if oid.Equal(documentSigning) {
+					return nil
+				}
+			}
+			continue
+		}
+		for _, u := range leaf.ExtKeyUsage {
+			if u == extKeyUsages[name] || u == x509.ExtKeyUsageAny {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("certificate %s is not for %s", leaf.Subject, strings.Join(p.ExtKeyUsages, " or "))
+}
+
+// checkRevocation checks every certificate of a chain but its root
+func (p *Policy) checkRevocation(ctx context.Context, chain []*x509.Certificate) error {
+	if p.Revocation == RevocationNone {
+		return nil
+	}
+	for i := 0; i+1 < len(chain); i++ {
+		cert, issuer := chain[i], chain[i+1]
+		var err error
+		switch {
+		case p.Revocation == RevocationOCSP, p.Revocation == RevocationAny && len(cert.OCSPServer) > 0:
+			err = p.checkOCSP(ctx, cert, issuer)
+		default:
+			err = p.checkCRL(ctx, cert, issuer)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOCSP asks the OCSP responder of a certificate whether it is revoked
+func (p *Policy) checkOCSP(ctx context.Context, cert, issuer *x509.Certificate) error {
+	if len(cert.OCSPServer) == 0 {
+		return fmt.Errorf("certificate %s has no OCSP responder", cert.Subject)
+	}
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create OCSP request: %w", err)
+	}
+	body, err := p.fetch(ctx, http.MethodPost, cert.OCSPServer[0], req)
+	if err != nil {
+		return fmt.Errorf("failed to check revocation of %s: %w", cert.Subject, err)
+	}
+	resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return fmt.Errorf("invalid OCSP response for %s: %w", cert.Subject, err)
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		return nil
+	case ocsp.Revoked:
+		return fmt.Errorf("certificate %s was revoked on %s", cert.Subject, resp.RevokedAt.UTC().Format(time.RFC3339))
+	}
+	return fmt.Errorf("OCSP responder does not know certificate %s", cert.Subject)
+}
+
+// checkCRL reads the CRL of a certificate to find out whether it is
+// revoked
+func (p *Policy) checkCRL(ctx context.Context, cert, issuer *x509.Certificate) error {
+	if len(cert.CRLDistributionPoints) == 0 {
+		return fmt.Errorf("certificate %s has no CRL distribution point", cert.Subject)
+	}
+	body, err := p.fetch(ctx, http.MethodGet, cert.CRLDistributionPoints[0], nil)
+	if err != nil {
+		return fmt.Errorf("failed to check revocation of %s: %w", cert.Subject, err)
+	}
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return fmt.Errorf("invalid CRL for %s: %w", cert.Subject, err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return fmt.Errorf("CRL for %s is not signed by its issuer: %w", cert.Subject, err)
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return fmt.Errorf("certificate %s was revoked on %s", cert.Subject, entry.RevocationTime.UTC().Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// fetch requests a URL for revocation checking
+func (p *Policy) fetch(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/ocsp-request")
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
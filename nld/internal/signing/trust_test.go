@@ -0,0 +1,189 @@
+package signing
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+	"golang.org/x/crypto/ocsp"
+)
+
+// ca is a certificate authority of a test
+type ca struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate for key signed by parent, or self-signed
+// when parent is nil
+func issue(t *testing.T, parent *ca, key *ecdsa.PrivateKey, template *x509.Certificate) *ca {
+	t.Helper()
+	signer, issuer := key, template
+	if parent != nil {
+		signer, issuer = parent.key, parent.cert
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &ca{cert: cert, key: key}
+}
+
+func TestPolicy(t *testing.T) {
+	// Each CA publishes a CRL and answers OCSP requests under its own path
+	revoked := map[int64]bool{}
+	issuers := map[string]*ca{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, endpoint, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		issuer, ok := issuers[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		revokedAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		switch endpoint {
+		case "crl":
+			var entries []x509.RevocationListEntry
+			for serial := range revoked {
+				entries = append(entries, x509.RevocationListEntry{SerialNumber: big.NewInt(serial), RevocationTime: revokedAt})
+			}
+			der, _ := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{Number: big.NewInt(1), ThisUpdate: time.Now(), NextUpdate: time.Now().Add(time.Hour), RevokedCertificateEntries: entries}, issuer.cert, issuer.key)
+			w.Write(der)
+		case "ocsp":
+			body, _ := io.ReadAll(r.Body)
+			req, err := ocsp.ParseRequest(body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			status := ocsp.Good
+			if revoked[req.SerialNumber.Int64()] {
+				status = ocsp.Revoked
+			}
+			der, _ := ocsp.CreateResponse(issuer.cert, issuer.cert, ocsp.Response{Status: status, SerialNumber: req.SerialNumber, ThisUpdate: time.Now(), RevokedAt: revokedAt}, issuer.key)
+			w.Write(der)
+		}
+	}))
+	defer server.Close()
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		return key
+	}
+	notBefore, notAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	template := func(serial int64, name, issuer string) *x509.Certificate {
+		return &x509.Certificate{SerialNumber: big.NewInt(serial), Subject: pkix.Name{CommonName: name}, NotBefore: notBefore, NotAfter: notAfter,
+			CRLDistributionPoints: []string{server.URL + "/" + issuer + "/crl"}, OCSPServer: []string{server.URL + "/" + issuer + "/ocsp"}}
+	}
+	authority := func(serial int64, name, issuer string) *x509.Certificate {
+		c := template(serial, name, issuer)
+		c.IsCA, c.BasicConstraintsValid, c.KeyUsage = true, true, x509.KeyUsageCertSign|x509.KeyUsageCRLSign
+		return c
+	}
+	rootCA := issue(t, nil, newKey(), authority(1, "Root CA", ""))
+	intermediate := issue(t, rootCA, newKey(), authority(2, "Signing CA", "root"))
+	other := issue(t, nil, newKey(), authority(3, "Other CA", ""))
+	issuers["root"], issuers["signing"] = rootCA, intermediate
+	leaf := func(serial int64, usage x509.KeyUsage, ext []x509.ExtKeyUsage, unknown ...asn1.ObjectIdentifier) *ca {
+		c := template(serial, "Signer", "signing")
+		c.KeyUsage, c.ExtKeyUsage, c.UnknownExtKeyUsage = usage, ext, unknown
+		return issue(t, intermediate, newKey(), c)
+	}
+	signed := func(c *ca, chain ...*ca) nld.Signature {
+		sig, err := Sign(c.key, root, "party1", "2025-03-01T10:00:00Z")
+		if err != nil {
+			t.Fatal(err)
+		}
+		certs := []*x509.Certificate{c.cert}
+		for _, link := range chain {
+			certs = append(certs, link.cert)
+		}
+		if err := Attach(&sig, certs); err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+
+	dir := t.TempDir()
+	for name, c := range map[string]*ca{"root.pem": rootCA, "intermediate.pem": intermediate} {
+		os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0644)
+	}
+	policy := func(yaml string) *Policy {
+		path := filepath.Join(dir, "policy.yaml")
+		os.WriteFile(path, []byte(yaml), 0644)
+		p, err := LoadPolicy(path)
+		if err != nil {
+			t.Fatalf("LoadPolicy failed: %v", err)
+		}
+		return p
+	}
+
+	good := leaf(10, x509.KeyUsageDigitalSignature, nil, documentSigning)
+	email := leaf(11, x509.KeyUsageDigitalSignature|x509.KeyUsageContentCommitment, []x509.ExtKeyUsage{x509.ExtKeyUsageEmailProtection})
+	encipher := leaf(12, x509.KeyUsageKeyEncipherment, nil)
+	bad := leaf(13, x509.KeyUsageDigitalSignature, nil)
+	revoked[13] = true
+	pinned, _ := Sign(newKey(), root, "party1", "2025-03-01T10:00:00Z")
+	late := signed(good, intermediate)
+	late.Date = "2027-06-01T00:00:00Z"
+
+	testCases := []struct {
+		name   string
+		policy string
+		sig    nld.Signature
+		expect string
+	}{
+		{"Chain", "roots: [root.pem]\n", signed(good, intermediate), ""},
+		{"Policy Intermediates", "roots: [root.pem]\nintermediates: [intermediate.pem]\n", signed(good), ""},
+		{"Incomplete Chain", "roots: [root.pem]\n", signed(good), "untrusted certificate"},
+		{"Other Root", "roots: [root.pem]\n", signed(issue(t, other, newKey(), template(20, "Signer", "")), other), "untrusted certificate"},
+		{"Expired On Signing", "roots: [root.pem]\n", late, "untrusted certificate"},
+		{"No Certificate", "roots: [root.pem]\n", pinned, "has no certificate"},
+		{"Pinned Key", "keys: [" + pinned.KeyID + "]\n", pinned, ""},
+		{"Key Usage", "roots: [root.pem]\nkeyUsages: [digitalSignature, contentCommitment]\n", signed(encipher, intermediate), "does not allow"},
+		{"Document Signing", "roots: [root.pem]\nextKeyUsages: [documentSigning]\n", signed(good, intermediate), ""},
+		{"Extended Key Usage", "roots: [root.pem]\nextKeyUsages: [documentSigning]\n", signed(email, intermediate), "is not for documentSigning"},
+		{"Without Extended Key Usages", "roots: [root.pem]\nextKeyUsages: [emailProtection]\n", signed(encipher, intermediate), ""},
+		{"CRL Good", "roots: [root.pem]\nrevocation: crl\n", signed(good, intermediate), ""},
+		{"CRL Revoked", "roots: [root.pem]\nrevocation: crl\n", signed(bad, intermediate), "was revoked on 2025-02-01"},
+		{"OCSP Good", "roots: [root.pem]\nrevocation: ocsp\n", signed(good, intermediate), ""},
+		{"OCSP Revoked", "roots: [root.pem]\nrevocation: any\n", signed(bad, intermediate), "was revoked"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy(tc.policy).Check(context.Background(), tc.sig)
+			switch {
+			case tc.expect == "" && err != nil:
+				t.Errorf("Check failed: %v", err)
+			case tc.expect != "" && (err == nil || !strings.Contains(err.Error(), tc.expect)):
+				t.Errorf("Check error = %v, expected %q", err, tc.expect)
+			}
+		})
+	}
+
+	for _, data := range []string{"", "roots: [root.pem]\nrevocation: always\n", "roots: [root.pem]\nkeyUsages: [signing]\n", "roots: [missing.pem]\n"} {
+		if _, err := ParsePolicy([]byte(data), dir); err == nil {
+			t.Errorf("ParsePolicy(%q) succeeded", data)
+		}
+	}
+	sig := signed(good, intermediate)
+	if err := Attach(&sig, []*x509.Certificate{email.cert}); err == nil {
+		t.Error("Attach of the certificate of another key succeeded")
+	}
+}
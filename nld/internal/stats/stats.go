@@ -0,0 +1,178 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/readability"
+	"github.com/colemalphrus/nld/internal/terms"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Signature status values
+const (
+	// SignatureNone means the document has no digest record
+	SignatureNone = "none"
+	// SignatureIntact means the document matches its digest record
+	SignatureIntact = "intact"
+	// SignatureRedacted means the document only differs from its digest
+	// record by redactions
+	SignatureRedacted = "redacted"
+	// SignatureChanged means parts of the document changed since its
+	// digest record was written
+	SignatureChanged = "changed"
+	// SignatureTampered means the digest record itself was altered
+	SignatureTampered = "tampered"
+)
+
+// Stats describes the size and complexity of a document
+type Stats struct {
+	Title    string `json:"title"`
+	Type     string `json:"type"`
+	Sections int    `json:"sections"`
+	Words    int    `json:"words"`
+	Entities int    `json:"entities"`
+	// ReadingEase and GradeLevel are the Flesch reading ease and
+	// Flesch-Kincaid grade level of all section prose
+	ReadingEase float64        `json:"readingEase"`
+	GradeLevel  float64        `json:"gradeLevel"`
+	Definitions Definitions    `json:"definitions"`
+	Signature   Signature      `json:"signature"`
+	PerSection  []SectionStats `json:"perSection"`
+}
+
+// SectionStats are the statistics of one section, not counting its
+// subsections
+type SectionStats struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Depth is 0 for top-level sections
+	Depth       int     `json:"depth"`
+	Words       int     `json:"words"`
+	ReadingEase float64 `json:"readingEase"`
+	GradeLevel  float64 `json:"gradeLevel"`
+}
+
+// Definitions reports how many definitions are used in section content
+type Definitions struct {
+	Total int `json:"total"`
+	Used  int `json:"used"`
+	// Coverage is the percentage of definitions that are used, 100 when
+	// there are none
+	Coverage float64  `json:"coverage"`
+	Unused   []string `json:"unused,omitempty"`
+}
+
+// Signature is the status of the document's digest record and signatures
+type Signature struct {
+	Status string `json:"status"`
+	Root   string `json:"root,omitempty"`
+	// Changed counts the parts that differ from the digest record
+	Changed    int `json:"changed,omitempty"`
+	Signatures int `json:"signatures"`
+}
+
+// Compute returns the statistics of a document, given as the raw JSON
+// object and the typed model of the same document
+func Compute(raw document.Document, doc *nld.Document) (*Stats, error) {
+	s := &Stats{
+		Title:    doc.Metadata.Title,
+		Type:     doc.Metadata.Type,
+		Entities: len(doc.Metadata.Entities),
+	}
+
+	var total readability.Metrics
+	var prose []string
+	for _, sec := range doc.Structure.Sections {
+		prose = append(prose, sectionProse(sec)...)
+	}
+	for _, t := range prose {
+		total.Add(t)
+	}
+	for _, sec := range readability.Sections(doc) {
+		s.PerSection = append(s.PerSection, SectionStats{
+			ID:          sec.ID,
+			Title:       sec.Title,
+			Depth:       sec.Depth,
+			Words:       sec.Words,
+			ReadingEase: round(sec.ReadingEase),
+			GradeLevel:  round(sec.GradeLevel),
+		})
+	}
+	s.Sections = len(s.PerSection)
+	s.Words = total.Words
+	s.ReadingEase = round(total.ReadingEase)
+	s.GradeLevel = round(total.GradeLevel)
+
+	s.Definitions = definitions(doc)
+
+	sig, err := signature(raw)
+	if err != nil {
+		return nil, err
+	}
+	sig.Signatures = len(doc.Verification.Signatures)
+	s.Signature = sig
+	return s, nil
+}
+
+// sectionProse returns the prose of a section and its subsections
+func sectionProse(sec nld.Section) []string {
+	prose := readability.Prose(sec)
+	for _, sub := range sec.Sections {
+		prose = append(prose, sectionProse(sub)...)
+	}
+	return prose
+}
+
+// definitions reports which defined terms are used in the document
+func definitions(doc *nld.Document) Definitions {
+	d := Definitions{Total: len(doc.Structure.Definitions), Coverage: 100}
+	for _, t := range terms.Analyze(doc).Defined {
+		if t.Uses > 0 {
+			d.Used++
+		} else {
+			d.Unused = append(d.Unused, t.Term)
+		}
+	}
+	if d.Total > 0 {
+		d.Coverage = round(100 * float64(d.Used) / float64(d.Total))
+	}
+	return d
+}
+
+// signature verifies a document against its digest record
+func signature(raw document.Document) (Signature, error) {
+	rec, err := digest.Load(raw)
+	if err != nil {
+		return Signature{}, err
+	}
+	if rec == nil {
+		return Signature{Status: SignatureNone}, nil
+	}
+	report, err := digest.Verify(raw, rec)
+	if err != nil {
+		return Signature{}, fmt.Errorf("failed to verify document: %w", err)
+	}
+
+	sig := Signature{Status: SignatureIntact, Root: report.Root}
+	switch {
+	case !report.RecordIntact:
+		sig.Status = SignatureTampered
+	case !report.Intact:
+		sig.Status = SignatureRedacted
+		for _, part := range report.Changed() {
+			sig.Changed++
+			if part.Status != digest.StatusRedacted {
+				sig.Status = SignatureChanged
+			}
+		}
+	}
+	return sig, nil
+}
+
+// round rounds a score to one decimal place
+func round(f float64) float64 {
+	return math.Round(f*10) / 10
+}
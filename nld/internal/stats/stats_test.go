@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const testDoc = `{
+  "metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Services Agreement",
+    "entities": [{"id": "a", "name": "Acme", "role": "provider"}, {"id": "b", "name": "Beta", "role": "client"}]},
+  "content": {
+    "sections": [
+      {"id": "services", "title": "Services", "content": "The Provider will perform the Services. The Client will pay.",
+       "sections": [{"id": "scope", "title": "Scope", "content": [
+         {"type": "list", "items": ["Design", "Support"]},
+         {"type": "table", "header": ["Item", "Price"], "rows": [["Design work", "100.00"]]}]}]},
+      {"id": "terms", "title": "Terms", "content": [{"type": "definitionRef", "term": "Term"}]}
+    ],
+    "definitions": [
+      {"term": "Services", "definition": "The work described in Scope"},
+      {"term": "Term", "definition": "One year"},
+      {"term": "Fees", "definition": "The amounts payable"}
+    ]
+  }
+}`
+
+func parse(t *testing.T, data []byte) (document.Document, *nld.Document) {
+	t.Helper()
+	raw, err := document.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	doc, err := nld.Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	return raw, doc
+}
+
+func TestCompute(t *testing.T) {
+	s, err := Compute(parse(t, []byte(testDoc)))
+	if err != nil {
+		t.Fatalf("Failed to compute stats: %v", err)
+	}
+
+	if s.Sections != 3 || s.Entities != 2 || s.Words != 12 {
+		t.Errorf("Expected 3 sections, 2 entities and 12 words, got %+v", s)
+	}
+	want := []SectionStats{
+		{ID: "services", Depth: 0, Words: 10},
+		{ID: "scope", Depth: 1, Words: 2},
+		{ID: "terms", Depth: 0, Words: 0},
+	}
+	for i, w := range want {
+		got := s.PerSection[i]
+		if got.ID != w.ID || got.Depth != w.Depth || got.Words != w.Words {
+			t.Errorf("Expected section %+v, got %+v", w, got)
+		}
+	}
+	if s.ReadingEase == 0 {
+		t.Error("Expected a reading ease score")
+	}
+
+	d := s.Definitions
+	if d.Total != 3 || d.Used != 2 || d.Coverage != 66.7 || len(d.Unused) != 1 || d.Unused[0] != "Fees" {
+		t.Errorf("Unexpected definitions %+v", d)
+	}
+	if s.Signature.Status != SignatureNone {
+		t.Errorf("Expected signature status %s, got %s", SignatureNone, s.Signature.Status)
+	}
+}
+
+func TestComputeSignature(t *testing.T) {
+	raw, _ := parse(t, []byte(testDoc))
+	rec, err := digest.Compute(raw)
+	if err != nil {
+		t.Fatalf("Failed to compute digest: %v", err)
+	}
+	if err := digest.Store(raw, rec); err != nil {
+		t.Fatalf("Failed to store digest: %v", err)
+	}
+	signed, err := raw.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to marshal document: %v", err)
+	}
+
+	s, err := Compute(parse(t, signed))
+	if err != nil {
+		t.Fatalf("Failed to compute stats: %v", err)
+	}
+	if s.Signature.Status != SignatureIntact || s.Signature.Root != rec.Root {
+		t.Errorf("Expected an intact signature with root %s, got %+v", rec.Root, s.Signature)
+	}
+
+	raw, _ = parse(t, signed)
+	raw["metadata"].(map[string]interface{})["title"] = "Changed"
+	changed, _ := raw.Marshal()
+	s, err = Compute(parse(t, changed))
+	if err != nil {
+		t.Fatalf("Failed to compute stats: %v", err)
+	}
+	if s.Signature.Status != SignatureChanged || s.Signature.Changed != 1 {
+		t.Errorf("Expected one changed part, got %+v", s.Signature)
+	}
+}
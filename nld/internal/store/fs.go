@@ -0,0 +1,248 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FS is a store in a local directory. Each document is a directory named
+// by its ID holding one file per version, v1.json, v2.json and so on.
+type FS struct {
+	dir string
+}
+
+// NewFS returns a store in dir, which is created when the first document
+// is stored
+func NewFS(dir string) *FS {
+	return &FS{dir: dir}
+}
+
+// Put stores data as the next version of the document id
+func (s *FS) Put(ctx context.Context, id string, data []byte) (Info, error) {
+	if err := writable(id); err != nil {
+		return Info{}, err
+	}
+	if err := checkDocument(data); err != nil {
+		return Info{}, err
+	}
+	versions, err := s.versions(id)
+	if err != nil {
+		return Info{}, err
+	}
+	if n := len(versions); n > 0 {
+		latest, info, err := s.read(id, versions[n-1])
+		if err == nil && bytes.Equal(latest, data) {
+			return info, nil
+		}
+	}
+
+	next := 1
+	if n := len(versions); n > 0 {
+		next = versions[n-1] + 1
+	}
+	for {
+		info, err := s.create(id, next, data)
+		if errors.Is(err, ErrConflict) {
+			next++
+			continue
+		}
+		return info, err
+	}
+}
+
+// Create stores data as the given version of the document id
+func (s *FS) Create(ctx context.Context, id string, version int, data []byte) (Info, error) {
+	if err := ValidID(id); err != nil {
+		return Info{}, err
+	}
+	if err := checkDocument(data); err != nil {
+		return Info{}, err
+	}
+	if version < 1 {
+		return Info{}, fmt.Errorf("%w version %d", ErrInvalid, version)
+	}
+	return s.create(id, version, data)
+}
+
+// create writes a version of a document. Creating the file exclusively
+// claims the version number, so concurrent writers never overwrite each
+// other.
+func (s *FS) create(id string, version int, data []byte) (Info, error) {
+	dir := filepath.Join(s.dir, id)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return Info{}, fmt.Errorf("failed to create store directory: %w", err)
+	}
+	f, err := os.OpenFile(versionPath(dir, version), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return Info{}, fmt.Errorf("%w: %s version %d", ErrConflict, id, version)
+	}
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to store document: %w", err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return Info{}, fmt.Errorf("failed to store document: %w", err)
+	}
+	_, info, err := s.read(id, version)
+	return info, err
+}
+
+// Get returns a version of a document, the latest for version 0
+func (s *FS) Get(ctx context.Context, id string, version int) ([]byte, Info, error) {
+	if err := ValidID(id); err != nil {
+		return nil, Info{}, err
+	}
+	if version == 0 {
+		versions, err := s.versions(id)
+		if err != nil {
+			return nil, Info{}, err
+		}
+		if len(versions) == 0 {
+			return nil, Info{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		version = versions[len(versions)-1]
+	}
+	return s.read(id, version)
+}
+
+// List returns the latest version of every document, sorted by ID
+func (s *FS) List(ctx context.Context) ([]Info, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Info{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store: %w", err)
+	}
+	list := []Info{}
+	for _, e := range entries {
+		if !e.IsDir() || ValidID(e.Name()) != nil {
+			continue
+		}
+		_, info, err := s.Get(ctx, e.Name(), 0)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// Delete removes a document and all its versions
+func (s *FS) Delete(ctx context.Context, id string) error {
+	if err := writable(id); err != nil {
+		return err
+	}
+	dir := filepath.Join(s.dir, id)
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	return nil
+}
+
+// Prune removes the versions of a document older than version before
+func (s *FS) Prune(ctx context.Context, id string, before int) error {
+	if err := writable(id); err != nil {
+		return err
+	}
+	versions, err := s.versions(id)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if v >= before {
+			break
+		}
+		if err := os.Remove(versionPath(filepath.Join(s.dir, id), v)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete version %d: %w", v, err)
+		}
+	}
+	return nil
+}
+
+// Versions returns the versions of a document, oldest first
+func (s *FS) Versions(ctx context.Context, id string) ([]Info, error) {
+	if err := ValidID(id); err != nil {
+		return nil, err
+	}
+	versions, err := s.versions(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	list := make([]Info, 0, len(versions))
+	for _, v := range versions {
+		_, info, err := s.read(id, v)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, info)
+	}
+	return list, nil
+}
+
+// versions returns the version numbers of a document in order
+func (s *FS) versions(id string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store: %w", err)
+	}
+	var versions []int
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, "v") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if v, err := strconv.Atoi(strings.TrimSuffix(name[1:], ".json")); err == nil && v > 0 {
+			versions = append(versions, v)
+		}
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// read returns a stored version and its Info
+func (s *FS) read(id string, version int) ([]byte, Info, error) {
+	path := versionPath(filepath.Join(s.dir, id), version)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Info{}, fmt.Errorf("%w: %s version %d", ErrNotFound, id, version)
+	}
+	if err != nil {
+		return nil, Info{}, fmt.Errorf("failed to read stored document: %w", err)
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, Info{}, fmt.Errorf("failed to read stored document: %w", err)
+	}
+	return data, describe(id, version, data, stat.ModTime()), nil
+}
+
+// versionPath returns the file of a version in the directory of a document
+func versionPath(dir string, version int) string {
+	return filepath.Join(dir, fmt.Sprintf("v%d.json", version))
+}
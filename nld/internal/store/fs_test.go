@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFS(t *testing.T) {
+	ctx := context.Background()
+	s := NewFS(t.TempDir())
+
+	if list, err := s.List(ctx); err != nil || len(list) != 0 {
+		t.Fatalf("List() of an empty store = %v, %v", list, err)
+	}
+
+	v1 := []byte(`{"metadata": {"type": "contract", "title": "Lease"}}`)
+	info, err := s.Put(ctx, "lease-2024", v1)
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if info.Version != 1 || info.Type != "contract" || info.Title != "Lease" || info.Size != int64(len(v1)) {
+		t.Errorf("Put() = %+v", info)
+	}
+	if again, _ := s.Put(ctx, "lease-2024", v1); again.Version != 1 {
+		t.Errorf("Put() of unchanged content stored version %d", again.Version)
+	}
+	v2 := []byte(`{"metadata": {"type": "contract", "title": "Lease (amended)"}}`)
+	if info, err = s.Put(ctx, "lease-2024", v2); err != nil || info.Version != 2 {
+		t.Fatalf("Put() = %+v, %v", info, err)
+	}
+	if _, err := s.Put(ctx, "nda", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, info, err := s.Get(ctx, "lease-2024", 0)
+	if err != nil || string(data) != string(v2) || info.Version != 2 {
+		t.Errorf("Get() latest = %s, %+v, %v", data, info, err)
+	}
+	if data, _, err = s.Get(ctx, "lease-2024", 1); err != nil || string(data) != string(v1) {
+		t.Errorf("Get() version 1 = %s, %v", data, err)
+	}
+	if _, _, err = s.Get(ctx, "lease-2024", 3); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a missing version error = %v", err)
+	}
+
+	versions, err := s.Versions(ctx, "lease-2024")
+	if err != nil || len(versions) != 2 || versions[0].Version != 1 || versions[0].Digest == versions[1].Digest {
+		t.Errorf("Versions() = %+v, %v", versions, err)
+	}
+	list, err := s.List(ctx)
+	if err != nil || len(list) != 2 || list[0].ID != "lease-2024" || list[0].Version != 2 || list[1].ID != "nda" {
+		t.Errorf("List() = %+v, %v", list, err)
+	}
+
+	if err := s.Prune(ctx, "lease-2024", 2); err != nil {
+		t.Fatal(err)
+	}
+	if versions, err := s.Versions(ctx, "lease-2024"); err != nil || len(versions) != 1 || versions[0].Version != 2 {
+		t.Errorf("Versions() after Prune() = %+v, %v", versions, err)
+	}
+
+	if err := s.Delete(ctx, "lease-2024"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Get(ctx, "lease-2024", 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v", err)
+	}
+	if err := s.Delete(ctx, "lease-2024"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of a missing document error = %v", err)
+	}
+}
+
+func TestFSRejects(t *testing.T) {
+	s := NewFS(t.TempDir())
+	for _, id := range []string{"", "../etc", ".hidden", "-flag", "a/b", "a b"} {
+		if _, err := s.Put(context.Background(), id, []byte(`{}`)); err == nil {
+			t.Errorf("Put(%q) succeeded", id)
+		}
+	}
+	if _, err := s.Put(context.Background(), "doc", []byte(`not json`)); err == nil {
+		t.Error("Put() of invalid JSON succeeded")
+	}
+}
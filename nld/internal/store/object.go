@@ -0,0 +1,464 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/sigv4"
+)
+
+// Object is a store in an S3 or Google Cloud Storage bucket, laid out like
+// FS: the versions of a document are the objects <prefix><id>/v1.json,
+// <prefix><id>/v2.json and so on. Requests are signed with AWS Signature
+// Version 4, which Google Cloud Storage accepts with HMAC keys.
+type Object struct {
+	remote   Remote
+	scheme   string
+	bucket   string
+	prefix   string
+	endpoint *url.URL
+	// pathStyle puts the bucket in the path rather than the host name
+	pathStyle bool
+	region    string
+	accessKey string
+	secretKey string
+	token     string
+	client    *http.Client
+}
+
+// OpenRemote returns the store of a remote. Credentials come from the
+// environment: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN for s3:// remotes, and the HMAC key in
+// NLD_GCS_ACCESS_KEY_ID and NLD_GCS_SECRET_ACCESS_KEY for gs:// remotes.
+func OpenRemote(r Remote) (*Object, error) {
+	scheme, bucket, prefix, err := parseURL(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	s := &Object{remote: r, scheme: scheme, bucket: bucket, prefix: prefix, region: r.Region, client: http.DefaultClient}
+
+	endpoint := r.Endpoint
+	switch scheme {
+	case "s3":
+		s.accessKey, s.secretKey, s.token = os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN")
+		if s.accessKey == "" || s.secretKey == "" {
+			return nil, fmt.Errorf("no credentials for %s: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY", r.URL)
+		}
+		for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+			if s.region == "" {
+				s.region = os.Getenv(env)
+			}
+		}
+		if s.region == "" {
+			s.region = "us-east-1"
+		}
+		for _, env := range []string{"AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"} {
+			if endpoint == "" {
+				endpoint = os.Getenv(env)
+			}
+		}
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, s.region)
+		} else {
+			s.pathStyle = true
+		}
+		switch r.Encryption {
+		case "", EncryptionAES256, EncryptionKMS:
+		default:
+			return nil, fmt.Errorf("unsupported encryption %q for %s (supported: %s, %s)", r.Encryption, r.URL, EncryptionAES256, EncryptionKMS)
+		}
+	case "gs":
+		s.accessKey, s.secretKey = os.Getenv(GCSAccessKeyEnv), os.Getenv(GCSSecretKeyEnv)
+		if s.accessKey == "" || s.secretKey == "" {
+			return nil, fmt.Errorf("no credentials for %s: set %s and %s to an HMAC key", r.URL, GCSAccessKeyEnv, GCSSecretKeyEnv)
+		}
+		if s.region == "" {
+			s.region = "auto"
+		}
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+		s.pathStyle = true
+	}
+
+	if s.endpoint, err = url.Parse(strings.TrimSuffix(endpoint, "/")); err != nil || s.endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+	return s, nil
+}
+
+// Put stores data as the next version of the document id, with the
+// server-side encryption of the remote
+func (s *Object) Put(ctx context.Context, id string, data []byte) (Info, error) {
+	if err := writable(id); err != nil {
+		return Info{}, err
+	}
+	if err := checkDocument(data); err != nil {
+		return Info{}, err
+	}
+	versions, err := s.versions(ctx, id)
+	if err != nil {
+		return Info{}, err
+	}
+	next := 1
+	if n := len(versions); n > 0 {
+		latest, info, err := s.read(ctx, id, versions[n-1])
+		if err == nil && bytes.Equal(latest, data) {
+			return info, nil
+		}
+		next = versions[n-1] + 1
+	}
+
+	for attempt := 0; attempt < 100; attempt++ {
+		info, err := s.create(ctx, id, next, data)
+		if errors.Is(err, ErrConflict) {
+			next++
+			continue
+		}
+		return info, err
+	}
+	return Info{}, fmt.Errorf("failed to store document: too many concurrent writers")
+}
+
+// Create stores data as the given version of the document id, with the
+// server-side encryption of the remote
+func (s *Object) Create(ctx context.Context, id string, version int, data []byte) (Info, error) {
+	if err := ValidID(id); err != nil {
+		return Info{}, err
+	}
+	if err := checkDocument(data); err != nil {
+		return Info{}, err
+	}
+	if version < 1 {
+		return Info{}, fmt.Errorf("%w version %d", ErrInvalid, version)
+	}
+	return s.create(ctx, id, version, data)
+}
+
+// create writes a version of a document
+func (s *Object) create(ctx context.Context, id string, version int, data []byte) (Info, error) {
+	headers := map[string]string{"Content-Type": "application/json"}
+	// Conditional writes claim the version number, so concurrent writers
+	// never overwrite each other
+	if s.scheme == "gs" {
+		headers["x-goog-if-generation-match"] = "0"
+		if s.remote.KMSKeyID != "" {
+			headers["x-goog-encryption-kms-key-name"] = s.remote.KMSKeyID
+		}
+	} else {
+		headers["If-None-Match"] = "*"
+		if s.remote.Encryption != "" {
+			headers["x-amz-server-side-encryption"] = s.remote.Encryption
+		}
+		if s.remote.Encryption == EncryptionKMS && s.remote.KMSKeyID != "" {
+			headers["x-amz-server-side-encryption-aws-kms-key-id"] = s.remote.KMSKeyID
+		}
+	}
+	status, body, _, err := s.do(ctx, http.MethodPut, s.key(id, version), nil, data, headers)
+	if err != nil {
+		return Info{}, err
+	}
+	switch {
+	case status == http.StatusPreconditionFailed || status == http.StatusConflict:
+		return Info{}, fmt.Errorf("%w: %s version %d", ErrConflict, id, version)
+	case status >= 300:
+		return Info{}, s.apiError(http.MethodPut, s.key(id, version), status, body)
+	}
+	return describe(id, version, data, time.Now()), nil
+}
+
+// Get returns a version of a document, the latest for version 0
+func (s *Object) Get(ctx context.Context, id string, version int) ([]byte, Info, error) {
+	if err := ValidID(id); err != nil {
+		return nil, Info{}, err
+	}
+	if version == 0 {
+		versions, err := s.versions(ctx, id)
+		if err != nil {
+			return nil, Info{}, err
+		}
+		if len(versions) == 0 {
+			return nil, Info{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		version = versions[len(versions)-1]
+	}
+	return s.read(ctx, id, version)
+}
+
+// List returns the latest version of every document, sorted by ID
+func (s *Object) List(ctx context.Context) ([]Info, error) {
+	_, prefixes, err := s.listObjects(ctx, s.prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+	list := []Info{}
+	for _, p := range prefixes {
+		id := strings.TrimSuffix(strings.TrimPrefix(p, s.prefix), "/")
+		if ValidID(id) != nil {
+			continue
+		}
+		_, info, err := s.Get(ctx, id, 0)
+		if err == nil {
+			list = append(list, info)
+		} else if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// Delete removes a document and all its versions
+func (s *Object) Delete(ctx context.Context, id string) error {
+	if err := writable(id); err != nil {
+		return err
+	}
+	versions, err := s.versions(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	for _, v := range versions {
+		status, body, _, err := s.do(ctx, http.MethodDelete, s.key(id, v), nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if status >= 300 && status != http.StatusNotFound {
+			return s.apiError(http.MethodDelete, s.key(id, v), status, body)
+		}
+	}
+	return nil
+}
+
+// Prune removes the versions of a document older than version before
+func (s *Object) Prune(ctx context.Context, id string, before int) error {
+	if err := writable(id); err != nil {
+		return err
+	}
+	versions, err := s.versions(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if v >= before {
+			break
+		}
+		status, body, _, err := s.do(ctx, http.MethodDelete, s.key(id, v), nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if status >= 300 && status != http.StatusNotFound {
+			return s.apiError(http.MethodDelete, s.key(id, v), status, body)
+		}
+	}
+	return nil
+}
+
+// Versions returns the versions of a document, oldest first
+func (s *Object) Versions(ctx context.Context, id string) ([]Info, error) {
+	if err := ValidID(id); err != nil {
+		return nil, err
+	}
+	versions, err := s.versions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	list := make([]Info, 0, len(versions))
+	for _, v := range versions {
+		_, info, err := s.read(ctx, id, v)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, info)
+	}
+	return list, nil
+}
+
+// key returns the object key of a version
+func (s *Object) key(id string, version int) string {
+	return fmt.Sprintf("%s%s/v%d.json", s.prefix, id, version)
+}
+
+// versions returns the version numbers of a document in order
+func (s *Object) versions(ctx context.Context, id string) ([]int, error) {
+	keys, _, err := s.listObjects(ctx, s.prefix+id+"/", "/")
+	if err != nil {
+		return nil, err
+	}
+	var versions []int
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, s.prefix+id+"/")
+		if !strings.HasPrefix(name, "v") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if v, err := strconv.Atoi(strings.TrimSuffix(name[1:], ".json")); err == nil && v > 0 {
+			versions = append(versions, v)
+		}
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// read returns a stored version and its Info
+func (s *Object) read(ctx context.Context, id string, version int) ([]byte, Info, error) {
+	key := s.key(id, version)
+	status, body, header, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	if status == http.StatusNotFound {
+		return nil, Info{}, fmt.Errorf("%w: %s version %d", ErrNotFound, id, version)
+	}
+	if status >= 300 {
+		return nil, Info{}, s.apiError(http.MethodGet, key, status, body)
+	}
+	created, _ := http.ParseTime(header.Get("Last-Modified"))
+	return body, describe(id, version, body, created), nil
+}
+
+// listResult is a page of a ListObjectsV2 response
+type listResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// listObjects returns the keys and common prefixes of the objects under
+// prefix
+func (s *Object) listObjects(ctx context.Context, prefix, delimiter string) (keys, prefixes []string, err error) {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}, "delimiter": {delimiter}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		status, body, _, err := s.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if status >= 300 {
+			return nil, nil, s.apiError("LIST", prefix, status, body)
+		}
+		var page listResult
+		if err := xml.Unmarshal(body, &page); err != nil {
+			return nil, nil, fmt.Errorf("invalid listing of %s: %w", s.remote.URL, err)
+		}
+		for _, c := range page.Contents {
+			keys = append(keys, c.Key)
+		}
+		for _, p := range page.CommonPrefixes {
+			prefixes = append(prefixes, p.Prefix)
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return keys, prefixes, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// do sends a signed request for an object, or for the bucket when key is
+// empty, and returns the status, body and headers of the response
+func (s *Object) do(ctx context.Context, method, key string, query url.Values, body []byte, headers map[string]string) (int, []byte, http.Header, error) {
+	u := *s.endpoint
+	path := "/" + key
+	if s.pathStyle {
+		path = "/" + s.bucket + path
+	}
+	u.Path = u.Path + path
+	u.RawPath = s.endpoint.EscapedPath() + uriEncode(path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	s.sign(req, u.RawPath, body, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to reach %s: %w", s.remote.URL, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("failed to read response from %s: %w", s.remote.URL, err)
+	}
+	return resp.StatusCode, data, resp.Header, nil
+}
+
+// sign adds an AWS Signature Version 4 authorization to a request
+func (s *Object) sign(req *http.Request, path string, body []byte, now time.Time) {
+	sigv4.Sign(req, path, body, sigv4.Credentials{AccessKey: s.accessKey, SecretKey: s.secretKey, Token: s.token}, s.region, "s3", now)
+}
+
+// apiError describes an error response of the storage service
+func (s *Object) apiError(method, key string, status int, body []byte) error {
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	msg := http.StatusText(status)
+	if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+		msg = strings.TrimSpace(e.Code + ": " + e.Message)
+	}
+	return fmt.Errorf("%s %s://%s/%s failed: %s (HTTP %d)", method, s.scheme, s.bucket, key, msg, status)
+}
+
+// canonicalQuery encodes a query string as Signature Version 4 requires:
+// sorted, with every reserved character escaped
+func canonicalQuery(query url.Values) string {
+	var keys []string
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode escapes every byte of s but unreserved characters, and slashes
+// unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
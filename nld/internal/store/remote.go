@@ -0,0 +1,162 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables configuring remote stores
+const (
+	// ConfigEnv overrides the path of the remotes config file
+	ConfigEnv = "NLD_STORE_CONFIG"
+	// RemoteEnv names the remote used when none is given
+	RemoteEnv = "NLD_STORE_REMOTE"
+	// EncryptionEnv and KMSKeyEnv set the server-side encryption of remotes
+	// given by URL
+	EncryptionEnv = "NLD_STORE_ENCRYPTION"
+	KMSKeyEnv     = "NLD_STORE_KMS_KEY_ID"
+	// GCSAccessKeyEnv and GCSSecretKeyEnv hold the HMAC key of gs://
+	// remotes
+	GCSAccessKeyEnv = "NLD_GCS_ACCESS_KEY_ID"
+	GCSSecretKeyEnv = "NLD_GCS_SECRET_ACCESS_KEY"
+)
+
+// Server-side encryption modes of S3 remotes
+const (
+	EncryptionAES256 = "AES256"
+	EncryptionKMS    = "aws:kms"
+)
+
+// Remote configures a store in an S3 or Google Cloud Storage bucket
+type Remote struct {
+	// URL is the bucket and key prefix, such as s3://acme/contracts/ or
+	// gs://acme/contracts/
+	URL string `yaml:"url"`
+	// Region of an S3 bucket; defaults to $AWS_REGION or us-east-1
+	Region string `yaml:"region"`
+	// Endpoint overrides the service address, for S3-compatible services
+	// such as MinIO; defaults to $AWS_ENDPOINT_URL_S3 for s3:// remotes
+	Endpoint string `yaml:"endpoint"`
+	// Encryption is the server-side encryption of stored documents:
+	// AES256 or aws:kms for S3. Google Cloud Storage always encrypts, with
+	// the key in KMSKeyID when set.
+	Encryption string `yaml:"encryption"`
+	// KMSKeyID is the KMS key of aws:kms encryption, or the Cloud KMS key
+	// name of gs:// remotes
+	KMSKeyID string `yaml:"kms-key-id"`
+}
+
+// Config is the remotes config file, which names remotes:
+//
+//	remotes:
+//	  contracts:
+//	    url: s3://acme-legal/contracts/
+//	    region: eu-west-1
+//	    encryption: aws:kms
+//	    kms-key-id: alias/legal
+type Config struct {
+	Remotes map[string]Remote `yaml:"remotes"`
+}
+
+// ConfigPath returns the path of the remotes config file: $NLD_STORE_CONFIG,
+// or .nld/store.yaml in the home directory
+func ConfigPath() (string, error) {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory (set %s): %w", ConfigEnv, err)
+	}
+	return filepath.Join(home, ".nld", "store.yaml"), nil
+}
+
+// LoadConfig reads the remotes config file. A missing file is an empty
+// config.
+func LoadConfig() (*Config, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store config: %w", err)
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid store config %s: %w", path, err)
+	}
+	for name, r := range c.Remotes {
+		if _, _, _, err := parseURL(r.URL); err != nil {
+			return nil, fmt.Errorf("invalid store config %s: remote %s: %w", path, name, err)
+		}
+	}
+	return &c, nil
+}
+
+// Names returns the names of the configured remotes
+func (c *Config) Names() []string {
+	var names []string
+	for name := range c.Remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolve returns the remote named by name, or a remote for a bucket URL
+// with the encryption settings of the environment. An empty name uses
+// $NLD_STORE_REMOTE.
+func (c *Config) Resolve(name string) (Remote, error) {
+	if name == "" {
+		name = os.Getenv(RemoteEnv)
+	}
+	if name == "" {
+		return Remote{}, fmt.Errorf("no remote given (use --remote or set %s)", RemoteEnv)
+	}
+	if r, ok := c.Remotes[name]; ok {
+		return r, nil
+	}
+	if !strings.Contains(name, "://") {
+		if len(c.Remotes) == 0 {
+			return Remote{}, fmt.Errorf("unknown remote %q (no remotes are configured; give a URL such as s3://bucket/prefix/)", name)
+		}
+		return Remote{}, fmt.Errorf("unknown remote %q (configured: %s)", name, strings.Join(c.Names(), ", "))
+	}
+	r := Remote{URL: name, Encryption: os.Getenv(EncryptionEnv), KMSKeyID: os.Getenv(KMSKeyEnv)}
+	if _, _, _, err := parseURL(r.URL); err != nil {
+		return Remote{}, err
+	}
+	return r, nil
+}
+
+// parseURL splits a remote URL into its scheme, bucket and key prefix. The
+// prefix is empty or ends in a slash.
+func parseURL(raw string) (scheme, bucket, prefix string, err error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid remote URL %q: %w", raw, err)
+	}
+	if u.Scheme != "s3" && u.Scheme != "gs" {
+		return "", "", "", fmt.Errorf("invalid remote URL %q: use s3://bucket/prefix/ or gs://bucket/prefix/", raw)
+	}
+	if u.Host == "" {
+		return "", "", "", fmt.Errorf("invalid remote URL %q: no bucket", raw)
+	}
+	prefix = strings.Trim(u.Path, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return u.Scheme, u.Host, prefix, nil
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/digest"
+)
+
+// DirEnv names the environment variable that overrides the directory of
+// the local store
+const DirEnv = "NLD_STORE_DIR"
+
+var (
+	// ErrNotFound is returned for documents and versions that are not
+	// stored
+	ErrNotFound = errors.New("document not found")
+	// ErrInvalid is wrapped by the errors of invalid IDs and documents
+	ErrInvalid = errors.New("invalid")
+	// ErrConflict is returned by Create for versions that are stored
+	// already
+	ErrConflict = errors.New("version exists already")
+)
+
+// AuditID is the ID of the audit log of nld audit in a store. It is
+// reserved: Put and Delete refuse it, so that the log can only be appended
+// to with Create.
+const AuditID = "_audit"
+
+// Reserved reports whether id is reserved for nld itself
+func Reserved(id string) bool {
+	return id == AuditID
+}
+
+// writable rejects the IDs that documents cannot be put under or deleted
+// from
+func writable(id string) error {
+	if err := ValidID(id); err != nil {
+		return err
+	}
+	if Reserved(id) {
+		return fmt.Errorf("%w document ID %q: reserved for the audit log", ErrInvalid, id)
+	}
+	return nil
+}
+
+// Info describes a stored version of a document
+type Info struct {
+	ID      string    `json:"id"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Size    int64     `json:"size"`
+	// Digest is the SHA-256 digest of the stored bytes
+	Digest string `json:"digest"`
+	// Type and Title come from the document metadata; they are empty for
+	// encrypted documents
+	Type  string `json:"type,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// Store holds documents by ID. Every Put of changed content adds a
+// version; versions are numbered from 1 and never change.
+type Store interface {
+	// Put stores data as the next version of the document id. Data equal
+	// to the latest version is not stored again, and its Info returned.
+	Put(ctx context.Context, id string, data []byte) (Info, error)
+	// Create stores data as the given version of the document id, failing
+	// with ErrConflict when that version is stored already
+	Create(ctx context.Context, id string, version int, data []byte) (Info, error)
+	// Get returns a version of a document, the latest for version 0
+	Get(ctx context.Context, id string, version int) ([]byte, Info, error)
+	// List returns the latest version of every document, sorted by ID
+	List(ctx context.Context) ([]Info, error)
+	// Delete removes a document and all its versions
+	Delete(ctx context.Context, id string) error
+	// Prune removes the versions of a document older than version before
+	Prune(ctx context.Context, id string, before int) error
+	// Versions returns the versions of a document, oldest first
+	Versions(ctx context.Context, id string) ([]Info, error)
+}
+
+var idRe = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]{0,127}$`)
+
+// ValidID reports whether id can name a stored document: up to 128
+// letters, digits, dots, dashes and underscores, not starting with a dot
+// or dash
+func ValidID(id string) error {
+	if !idRe.MatchString(id) {
+		return fmt.Errorf("%w document ID %q: use up to 128 letters, digits, '.', '-' and '_', starting with a letter, digit or '_'", ErrInvalid, id)
+	}
+	return nil
+}
+
+// DefaultDir returns the directory of the local store: $NLD_STORE_DIR, or
+// .nld/store in the home directory
+func DefaultDir() (string, error) {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory (set %s): %w", DirEnv, err)
+	}
+	return filepath.Join(home, ".nld", "store"), nil
+}
+
+// describe returns the Info of a version from its content
+func describe(id string, version int, data []byte, created time.Time) Info {
+	info := Info{
+		ID:      id,
+		Version: version,
+		Created: created.UTC(),
+		Size:    int64(len(data)),
+		Digest:  digest.Sum(data),
+	}
+	var doc struct {
+		Metadata struct {
+			Type  string `json:"type"`
+			Title string `json:"title"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal(data, &doc) == nil {
+		info.Type, info.Title = doc.Metadata.Type, doc.Metadata.Title
+	}
+	return info
+}
+
+// checkDocument rejects data that is not a JSON object
+func checkDocument(data []byte) error {
+	var v map[string]json.RawMessage
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("%w document: not a JSON object: %v", ErrInvalid, err)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+// Package telemetry records anonymous usage of nld for users who opt in:
+// the command run, how long it took and the class of error it ended with.
+// Document content, file names and arguments are never recorded. Events
+// are kept in a local spool and sent at most once a day.
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	// DefaultEndpoint receives the events of users who opt in
+	DefaultEndpoint = "https://telemetry.nld.dev/v1/events"
+	// EndpointEnv replaces the endpoint, such as with a collector of an
+	// organization
+	EndpointEnv = "NLD_TELEMETRY_URL"
+	// DoNotTrackEnv turns telemetry off when set to anything but the empty
+	// string or 0, whatever the configuration says
+	DoNotTrackEnv = "DO_NOT_TRACK"
+	// Interval is how often the spooled events are sent
+	Interval = 24 * time.Hour
+	// maxEvents bounds the spool, dropping the oldest events
+	maxEvents = 1000
+)
+
+// Config is the telemetry setting of the user
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// ID is a random identifier of the installation, which tells apart
+	// the events of users without identifying them
+	ID string `json:"id,omitempty"`
+	// Endpoint receives the events; empty means DefaultEndpoint
+	Endpoint string `json:"endpoint,omitempty"`
+	// Sent is when events were last sent
+	Sent time.Time `json:"sent,omitempty"`
+}
+
+// Event is one run of a command
+type Event struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+	OS      string `json:"os"`
+	Arch    string `json:"arch"`
+	// Command is the command run, such as "validate" or "store get";
+	// plugins are all recorded as "plugin"
+	Command  string `json:"command"`
+	Duration int64  `json:"duration_ms"`
+	// Error is the class of error the command ended with, such as
+	// "validation", or "" when it succeeded
+	Error string    `json:"error,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// Telemetry keeps the config and spool of telemetry
+type Telemetry struct {
+	// ConfigPath is the config file and SpoolPath the file of events not
+	// sent yet
+	ConfigPath string
+	SpoolPath  string
+	Client     *http.Client
+	// now returns the current time
+	now func() time.Time
+}
+
+// New returns the telemetry of the user, with its config in the user
+// config directory and its spool in the user cache directory
+func New() (*Telemetry, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the config directory: %w", err)
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the cache directory: %w", err)
+	}
+	return &Telemetry{
+		ConfigPath: filepath.Join(config, "nld", "telemetry.json"),
+		SpoolPath:  filepath.Join(cache, "nld", "telemetry.ndjson"),
+		Client:     &http.Client{Timeout: 2 * time.Second},
+	}, nil
+}
+
+// DoNotTrack reports whether DO_NOT_TRACK turns telemetry off
+func DoNotTrack() bool {
+	v := os.Getenv(DoNotTrackEnv)
+	return v != "" && v != "0"
+}
+
+// Load reads the config. Without a config file telemetry is off.
+func (t *Telemetry) Load() (Config, error) {
+	var c Config
+	data, err := os.ReadFile(t.ConfigPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read telemetry config: %w", err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid telemetry config %s: %w", t.ConfigPath, err)
+	}
+	return c, nil
+}
+
+// save writes the config
+func (t *Telemetry) save(c Config) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.ConfigPath), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return os.WriteFile(t.ConfigPath, append(data, '\n'), 0644)
+}
+
+// Enable turns telemetry on, sending events to endpoint, or to the
+// default endpoint when it is empty. The installation gets an ID the first
+// time.
+func (t *Telemetry) Enable(endpoint string) (Config, error) {
+	c, err := t.Load()
+	if err != nil {
+		return c, err
+	}
+	if c.ID == "" {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return c, fmt.Errorf("failed to create telemetry ID: %w", err)
+		}
+		c.ID = hex.EncodeToString(id)
+	}
+	c.Enabled, c.Endpoint = true, endpoint
+	return c, t.save(c)
+}
+
+// Disable turns telemetry off and deletes the events not sent. The ID is
+// forgotten, so that turning it on again starts afresh.
+func (t *Telemetry) Disable() error {
+	if err := os.Remove(t.SpoolPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete telemetry events: %w", err)
+	}
+	return t.save(Config{})
+}
+
+// Pending returns the number of events not sent yet
+func (t *Telemetry) Pending() int {
+	events, _ := t.spooled()
+	return len(events)
+}
+
+// Record spools an event when telemetry is on, and sends the spool when it
+// was last sent more than a day ago. The event is completed with the ID,
+// platform and time.
+func (t *Telemetry) Record(ctx context.Context, e Event) error {
+	if DoNotTrack() {
+		return nil
+	}
+	c, err := t.Load()
+	if err != nil || !c.Enabled {
+		return err
+	}
+	now := time.Now()
+	if t.now != nil {
+		now = t.now()
+	}
+	e.ID, e.OS, e.Arch, e.Time = c.ID, runtime.GOOS, runtime.GOARCH, now.UTC()
+	events, err := t.spooled()
+	if err != nil {
+		return err
+	}
+	events = append(events, e)
+	if len(events) > maxEvents {
+		events = events[len(events)-maxEvents:]
+	}
+	if c.Sent.IsZero() {
+		// The first events wait a day like the others, so that one
+		// run does not send anything
+		c.Sent = now
+		if err := t.save(c); err != nil {
+			return err
+		}
+	}
+	if now.Sub(c.Sent) < Interval && !now.Before(c.Sent) {
+		return t.spool(events)
+	}
+
+	// A failed send is not retried for a day, so that commands run offline
+	// are not slowed down; the events wait in the spool
+	sendErr := t.send(ctx, c.URL(), events)
+	c.Sent = now
+	if err := t.save(c); err != nil {
+		return err
+	}
+	if sendErr != nil {
+		if err := t.spool(events); err != nil {
+			return err
+		}
+		return sendErr
+	}
+	return t.spool(nil)
+}
+
+// URL returns the endpoint events are sent to: NLD_TELEMETRY_URL, the
+// endpoint of the config, or DefaultEndpoint
+func (c Config) URL() string {
+	if url := os.Getenv(EndpointEnv); url != "" {
+		return url
+	}
+	if c.Endpoint != "" {
+		return c.Endpoint
+	}
+	return DefaultEndpoint
+}
+
+// send posts events to the endpoint as {"events": [...]}
+func (t *Telemetry) send(ctx context.Context, url string, events []Event) error {
+	body, err := json.Marshal(map[string]interface{}{"events": events})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telemetry: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to send telemetry: %s", resp.Status)
+	}
+	return nil
+}
+
+// spooled reads the events not sent yet, skipping lines that are not
+// events
+func (t *Telemetry) spooled() ([]Event, error) {
+	data, err := os.ReadFile(t.SpoolPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read telemetry events: %w", err)
+	}
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var e Event
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+// spool writes the events not sent yet, one JSON object per line
+func (t *Telemetry) spool(events []Event) error {
+	var buf bytes.Buffer
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	if err := os.MkdirAll(filepath.Dir(t.SpoolPath), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return os.WriteFile(t.SpoolPath, buf.Bytes(), 0644)
+}
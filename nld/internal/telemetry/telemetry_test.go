@@ -0,0 +1,114 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTelemetry(t *testing.T, url string, now *time.Time) *Telemetry {
+	dir := t.TempDir()
+	t.Setenv(DoNotTrackEnv, "")
+	t.Setenv(EndpointEnv, url)
+	return &Telemetry{
+		ConfigPath: filepath.Join(dir, "config", "telemetry.json"),
+		SpoolPath:  filepath.Join(dir, "cache", "telemetry.ndjson"),
+		now:        func() time.Time { return *now },
+	}
+}
+
+func TestRecord(t *testing.T) {
+	var sent []Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Events []Event `json:"events"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body.Events...)
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tel := newTelemetry(t, server.URL, &now)
+	ctx := context.Background()
+
+	if err := tel.Record(ctx, Event{Command: "validate"}); err != nil {
+		t.Fatal(err)
+	}
+	if tel.Pending() != 0 {
+		t.Error("events recorded while telemetry is off")
+	}
+
+	config, err := tel.Enable("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.ID) != 32 || config.URL() != server.URL {
+		t.Errorf("config = %+v", config)
+	}
+	tel.Record(ctx, Event{Command: "validate", Error: "validation"})
+	now = now.Add(time.Hour)
+	tel.Record(ctx, Event{Command: "store get"})
+	if tel.Pending() != 2 || len(sent) != 0 {
+		t.Fatalf("pending = %d, sent = %d; want events spooled for a day", tel.Pending(), len(sent))
+	}
+
+	now = now.Add(Interval)
+	if err := tel.Record(ctx, Event{Command: "lint"}); err != nil {
+		t.Fatal(err)
+	}
+	if tel.Pending() != 0 || len(sent) != 3 {
+		t.Fatalf("pending = %d, sent = %d; want the spool sent", tel.Pending(), len(sent))
+	}
+	if e := sent[0]; e.ID != config.ID || e.Command != "validate" || e.Error != "validation" || e.OS == "" {
+		t.Errorf("event = %+v", e)
+	}
+
+	t.Setenv(DoNotTrackEnv, "1")
+	tel.Record(ctx, Event{Command: "lint"})
+	if tel.Pending() != 0 {
+		t.Error("events recorded with DO_NOT_TRACK")
+	}
+}
+
+func TestRecordOffline(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tel := newTelemetry(t, "http://127.0.0.1:1", &now)
+	ctx := context.Background()
+	tel.Enable("")
+	tel.Record(ctx, Event{Command: "validate"})
+
+	now = now.Add(Interval)
+	if err := tel.Record(ctx, Event{Command: "lint"}); err == nil {
+		t.Error("no error sending to a closed port")
+	}
+	if tel.Pending() != 2 {
+		t.Errorf("pending = %d; failed events must stay in the spool", tel.Pending())
+	}
+	// The failed send is not retried for a day
+	if err := tel.Record(ctx, Event{Command: "fmt"}); err != nil {
+		t.Errorf("send retried: %v", err)
+	}
+}
+
+func TestDisable(t *testing.T) {
+	now := time.Now()
+	tel := newTelemetry(t, "", &now)
+	first, _ := tel.Enable("https://telemetry.example.com")
+	tel.Record(context.Background(), Event{Command: "validate"})
+	if err := tel.Disable(); err != nil {
+		t.Fatal(err)
+	}
+	config, err := tel.Load()
+	if err != nil || config.Enabled || config.ID != "" || tel.Pending() != 0 {
+		t.Errorf("after disable: %+v, %d pending, %v", config, tel.Pending(), err)
+	}
+	second, _ := tel.Enable("")
+	if second.ID == first.ID || second.URL() != DefaultEndpoint {
+		t.Errorf("enabled again: %+v", second)
+	}
+}
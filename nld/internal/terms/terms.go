@@ -0,0 +1,382 @@
+package terms
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/colemalphrus/nld/internal/readability"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Term is a definition of a document and where its term is used
+type Term struct {
+	Term string `json:"term"`
+	// Path is the JSON pointer of the definition
+	Path string `json:"path"`
+	Uses int    `json:"uses"`
+	// Sections are the IDs of the sections using the term
+	Sections []string `json:"sections,omitempty"`
+}
+
+// Undefined is a capitalized phrase used like a defined term that has no
+// definition
+type Undefined struct {
+	Term string `json:"term"`
+	Uses int    `json:"uses"`
+	// Paths are the JSON pointers of the texts using the phrase
+	Paths    []string `json:"paths"`
+	Sections []string `json:"sections"`
+}
+
+// Report is the defined-term usage of a document
+type Report struct {
+	Defined   []Term      `json:"defined"`
+	Undefined []Undefined `json:"undefined"`
+}
+
+// Unused returns the definitions whose term is never used
+func (r *Report) Unused() []Term {
+	var unused []Term
+	for _, t := range r.Defined {
+		if t.Uses == 0 {
+			unused = append(unused, t)
+		}
+	}
+	return unused
+}
+
+// text is a piece of section prose
+type text struct {
+	path    string
+	value   string
+	section string
+}
+
+// Analyze finds the uses of every defined term in the section content of
+// a document, and the capitalized phrases used like defined terms that
+// have no definition.
+//
+// Uses are counted by whole words, including plurals, in paragraphs, list
+// items and the text of other definitions, and by definitionRef blocks.
+// Undefined terms are found by convention: runs of capitalized words that
+// do not start a sentence, or any phrase in double quotes that is
+// capitalized. Entity names and roles, section titles, months and days,
+// and references such as Section 4 are not reported.
+func Analyze(doc *nld.Document) *Report {
+	texts := prose(doc)
+	refs := map[string][]string{}
+	var walk func(sections []nld.Section)
+	walk = func(sections []nld.Section) {
+		for _, s := range sections {
+			for _, b := range s.Blocks {
+				if b.Type == nld.BlockDefinitionRef {
+					refs[b.Term] = append(refs[b.Term], s.ID)
+				}
+			}
+			walk(s.Sections)
+		}
+	}
+	walk(doc.Structure.Sections)
+
+	report := &Report{Defined: []Term{}, Undefined: []Undefined{}}
+	var patterns []*regexp.Regexp
+	body := "/" + doc.BodyKey()
+	for i, def := range doc.Structure.Definitions {
+		t := Term{Term: def.Term, Path: fmt.Sprintf("%s/definitions/%d", body, i)}
+		re := termPattern(def.Term)
+		patterns = append(patterns, re)
+		seen := map[string]bool{}
+		use := func(section string) {
+			if section != "" && !seen[section] {
+				seen[section] = true
+				t.Sections = append(t.Sections, section)
+			}
+		}
+		for _, section := range refs[def.Term] {
+			t.Uses++
+			use(section)
+		}
+		if re != nil {
+			for _, tx := range texts {
+				n := len(re.FindAllStringIndex(tx.value, -1))
+				t.Uses += n
+				if n > 0 {
+					use(tx.section)
+				}
+			}
+			for j, other := range doc.Structure.Definitions {
+				if j != i {
+					t.Uses += len(re.FindAllStringIndex(other.Definition, -1))
+				}
+			}
+		}
+		report.Defined = append(report.Defined, t)
+	}
+
+	ignored := ignoredPhrases(doc)
+	byTerm := map[string]*Undefined{}
+	var order []string
+	for _, tx := range texts {
+		for _, phrase := range candidates(tx.value) {
+			if ignored[phrase] || defines(patterns, phrase) {
+				continue
+			}
+			u, ok := byTerm[phrase]
+			if !ok {
+				u = &Undefined{Term: phrase, Paths: []string{}, Sections: []string{}}
+				byTerm[phrase] = u
+				order = append(order, phrase)
+			}
+			u.Uses++
+			if !slices.Contains(u.Paths, tx.path) {
+				u.Paths = append(u.Paths, tx.path)
+			}
+			if !slices.Contains(u.Sections, tx.section) {
+				u.Sections = append(u.Sections, tx.section)
+			}
+		}
+	}
+	for _, phrase := range order {
+		report.Undefined = append(report.Undefined, *byTerm[phrase])
+	}
+	return report
+}
+
+// prose returns the paragraphs and list items of every section, with their
+// locations
+func prose(doc *nld.Document) []text {
+	var texts []text
+	var walk func(sections []nld.Section, path string)
+	walk = func(sections []nld.Section, path string) {
+		for i, s := range sections {
+			sp := fmt.Sprintf("%s/%d", path, i)
+			if s.Blocks == nil {
+				texts = append(texts, text{path: sp + "/content", value: s.Content, section: s.ID})
+			}
+			for j, b := range s.Blocks {
+				bp := fmt.Sprintf("%s/content/%d", sp, j)
+				switch b.Type {
+				case nld.BlockParagraph:
+					texts = append(texts, text{path: bp + "/text", value: b.Text, section: s.ID})
+				case nld.BlockList:
+					for k, item := range b.Items {
+						texts = append(texts, text{path: fmt.Sprintf("%s/items/%d", bp, k), value: item, section: s.ID})
+					}
+				}
+			}
+			walk(s.Sections, sp+"/sections")
+		}
+	}
+	walk(doc.Structure.Sections, "/"+doc.BodyKey()+"/sections")
+	return texts
+}
+
+// termPattern matches a defined term as whole words in the singular or the
+// plural, whichever form the definition uses
+func termPattern(term string) *regexp.Regexp {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return nil
+	}
+	switch {
+	case strings.HasSuffix(term, "ies"):
+		term = strings.TrimSuffix(term, "ies") + "y"
+	case strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss"):
+		term = strings.TrimSuffix(term, "s")
+	}
+	pattern := regexp.QuoteMeta(term) + `(s|es)?`
+	if strings.HasSuffix(term, "y") {
+		pattern = regexp.QuoteMeta(strings.TrimSuffix(term, "y")) + `(y|ies)`
+	}
+	re, err := regexp.Compile(`\b` + pattern + `\b`)
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// defines reports whether any defined term occurs in phrase
+func defines(patterns []*regexp.Regexp, phrase string) bool {
+	for _, re := range patterns {
+		if re != nil && re.MatchString(phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// Words that join the capitalized words of a term, as in Statement of Work
+var connectors = map[string]bool{"of": true, "for": true, "the": true}
+
+// Capitalized words that start a phrase without being part of the term
+var leading = map[string]bool{
+	"A": true, "An": true, "The": true, "This": true, "That": true, "These": true, "Those": true,
+	"Each": true, "Any": true, "All": true, "Such": true, "No": true, "Every": true,
+	"Our": true, "Your": true, "Its": true, "Their": true, "His": true, "Her": true,
+	"If": true, "In": true, "On": true, "At": true, "By": true, "For": true, "Upon": true,
+	"Under": true, "Unless": true, "Where": true, "When": true, "Within": true, "During": true,
+	"After": true, "Before": true, "Subject": true, "Notwithstanding": true, "Except": true,
+}
+
+// Words for references to parts of a document, as in Section 4
+var references = map[string]bool{
+	"Section": true, "Sections": true, "Article": true, "Articles": true, "Clause": true,
+	"Clauses": true, "Schedule": true, "Schedules": true, "Exhibit": true, "Exhibits": true,
+	"Appendix": true, "Annex": true, "Part": true, "Paragraph": true,
+}
+
+var calendar = map[string]bool{
+	"January": true, "February": true, "March": true, "April": true, "May": true, "June": true,
+	"July": true, "August": true, "September": true, "October": true, "November": true, "December": true,
+	"Monday": true, "Tuesday": true, "Wednesday": true, "Thursday": true, "Friday": true,
+	"Saturday": true, "Sunday": true,
+}
+
+var (
+	wordRe   = regexp.MustCompile(`[\p{L}\p{N}][\p{L}\p{N}'’-]*`)
+	quotedRe = regexp.MustCompile(`["“]([^"”]+)["”]`)
+)
+
+// candidates returns the phrases of text that are used like defined terms
+func candidates(value string) []string {
+	var phrases []string
+	for _, sentence := range readability.Split(value) {
+		for _, m := range quotedRe.FindAllStringSubmatch(sentence, -1) {
+			if phrase := strings.TrimSpace(m[1]); capitalizedPhrase(phrase) {
+				phrases = append(phrases, phrase)
+			}
+		}
+		// Quoted phrases are handled above
+		sentence = quotedRe.ReplaceAllString(sentence, ".")
+		phrases = append(phrases, runs(sentence)...)
+	}
+	return phrases
+}
+
+// runs returns the runs of capitalized words of a sentence that do not
+// just start it
+func runs(sentence string) []string {
+	locs := wordRe.FindAllStringIndex(sentence, -1)
+	var phrases []string
+	var run []string
+	first := true
+	startsSentence := false
+	flush := func() {
+		// Connectors only join capitalized words
+		for len(run) > 0 && connectors[run[len(run)-1]] {
+			run = run[:len(run)-1]
+		}
+		trimmed := false
+		for len(run) > 0 && leading[run[0]] {
+			run = run[1:]
+			trimmed = true
+		}
+		named := false
+		for _, w := range run {
+			named = named || capitalized(w)
+		}
+		if named && !(startsSentence && !trimmed && len(run) == 1) {
+			phrases = append(phrases, strings.Join(run, " "))
+		}
+		run = nil
+	}
+
+	possessive := false
+	for i, loc := range locs {
+		word := sentence[loc[0]:loc[1]]
+		adjacent := i > 0 && !possessive && strings.TrimSpace(sentence[locs[i-1][1]:loc[0]]) == ""
+		if len(run) > 0 && !adjacent {
+			flush()
+		}
+		// A possessive ends a phrase, as in the Supplier's Services
+		trimmed := strings.TrimSuffix(strings.TrimSuffix(word, "'s"), "’s")
+		possessive = trimmed != word
+		word = trimmed
+		switch {
+		case strings.HasPrefix(sentence[loc[1]:], ":"):
+			// A label, as in Serial: ABC123
+			run = nil
+		case (capitalized(word) || acronym(word)) && !calendar[word] && !references[word]:
+			if len(run) == 0 {
+				startsSentence = first
+			}
+			run = append(run, word)
+		case len(run) > 0 && connectors[word]:
+			run = append(run, word)
+		default:
+			if len(run) > 0 {
+				flush()
+			}
+		}
+		first = false
+	}
+	if len(run) > 0 {
+		flush()
+	}
+	return phrases
+}
+
+// capitalized reports whether word starts with an upper case letter and is
+// not an acronym or a single letter
+func capitalized(word string) bool {
+	runes := []rune(word)
+	if len(runes) < 2 || !unicode.IsUpper(runes[0]) {
+		return false
+	}
+	for _, r := range runes[1:] {
+		if unicode.IsLower(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// acronym reports whether word is in upper case, as in XYZ or ISO-8601,
+// which joins capitalized words but is not a term on its own
+func acronym(word string) bool {
+	runes := []rune(word)
+	return len(runes) >= 2 && unicode.IsUpper(runes[0]) && !capitalized(word)
+}
+
+// capitalizedPhrase reports whether every significant word of a quoted
+// phrase is capitalized
+func capitalizedPhrase(phrase string) bool {
+	words := wordRe.FindAllString(phrase, -1)
+	if len(words) == 0 || len(words) > 6 {
+		return false
+	}
+	for _, w := range words {
+		if !connectors[w] && !capitalized(w) {
+			return false
+		}
+	}
+	return true
+}
+
+// ignoredPhrases returns the capitalized phrases of a document that are
+// names rather than terms: entity names and roles, and section titles
+func ignoredPhrases(doc *nld.Document) map[string]bool {
+	ignored := map[string]bool{}
+	add := func(phrase string) {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			ignored[phrase] = true
+		}
+	}
+	for _, e := range doc.Metadata.Entities {
+		add(e.Name)
+		add(e.Role)
+	}
+	add(doc.Metadata.Title)
+	var walk func(sections []nld.Section)
+	walk = func(sections []nld.Section) {
+		for _, s := range sections {
+			add(s.Title)
+			walk(s.Sections)
+		}
+	}
+	walk(doc.Structure.Sections)
+	return ignored
+}
@@ -0,0 +1,95 @@
+package terms
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const testDoc = `{
+  "metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Services Agreement",
+    "entities": [{"id": "a", "name": "Acme Ltd", "role": "Provider"}, {"id": "b", "name": "Beta", "role": "Client"}]},
+  "content": {
+    "definitions": [
+      {"term": "Services", "definition": "The work described in Schedule 1, including the Deliverables."},
+      {"term": "Deliverable", "definition": "A report."},
+      {"term": "Fees", "definition": "The amounts payable."},
+      {"term": "Term", "definition": "One year."}
+    ],
+    "sections": [
+      {"id": "services", "title": "Scope of Work", "content": "Acme Ltd will perform the Services for the Client from 1 July. Each Service is described in Section 2.",
+       "sections": [{"id": "reports", "title": "Reports", "content": [
+         {"type": "list", "items": ["Monthly Usage Report", "the Change Request Log"]},
+         {"type": "table", "columns": [{"title": "Item"}], "rows": [["Quarterly Review"]]}]}]},
+      {"id": "term", "title": "Term", "content": [
+         {"type": "definitionRef", "term": "Term"},
+         {"type": "paragraph", "text": "The Provider's Monthly Usage Report is sent every Monday under the \"Quality Levels\"."}]}
+    ]
+  }
+}`
+
+func TestAnalyze(t *testing.T) {
+	doc, err := nld.Parse([]byte(testDoc))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	report := Analyze(doc)
+
+	defined := map[string]string{}
+	for _, d := range report.Defined {
+		defined[d.Term] = fmt.Sprintf("%s %d %v", d.Path, d.Uses, d.Sections)
+	}
+	expect := map[string]string{
+		"Services":    "/content/definitions/0 2 [services]",
+		"Deliverable": "/content/definitions/1 1 []",
+		"Fees":        "/content/definitions/2 0 []",
+		"Term":        "/content/definitions/3 1 [term]",
+	}
+	for term, want := range expect {
+		if defined[term] != want {
+			t.Errorf("Expected %s to be %q, got %q", term, want, defined[term])
+		}
+	}
+
+	if unused := report.Unused(); len(unused) != 1 || unused[0].Term != "Fees" {
+		t.Errorf("Expected Fees to be unused, got %v", unused)
+	}
+
+	var undefined []string
+	for _, u := range report.Undefined {
+		undefined = append(undefined, fmt.Sprintf("%s %d %v", u.Term, u.Uses, u.Paths))
+	}
+	want := []string{
+		"Monthly Usage Report 2 [/content/sections/0/sections/0/content/0/items/0 /content/sections/1/content/1/text]",
+		"Change Request Log 1 [/content/sections/0/sections/0/content/0/items/1]",
+		"Quality Levels 1 [/content/sections/1/content/1/text]",
+	}
+	if fmt.Sprint(undefined) != fmt.Sprint(want) {
+		t.Errorf("Expected undefined terms\n%v\ngot\n%v", want, undefined)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	testCases := []struct {
+		text   string
+		expect []string
+	}{
+		{text: "Payment is due in March.", expect: nil},
+		{text: "The Statement of Work applies.", expect: []string{"Statement of Work"}},
+		{text: "Notices go to the Registered Office and the Supplier.", expect: []string{"Registered Office", "Supplier"}},
+		{text: "See Clause 4 and ISO 8601.", expect: nil},
+		{text: "Fees are set out below. Invoice: 42.", expect: nil},
+		{text: `The "Effective Date" is 1 July.`, expect: []string{"Effective Date"}},
+		{text: `The "effective date" is 1 July.`, expect: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			got := candidates(tc.text)
+			if fmt.Sprint(got) != fmt.Sprint(tc.expect) {
+				t.Errorf("Expected %v, got %v", tc.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Package threshold evaluates the signature policies of documents, such as
+// two of three directors must sign. Only cryptographic signatures that
+// verify against the root hash of the document, made with a key registered
+// for the signer when it has any, count towards a policy; e-signatures and
+// stale signatures do not.
+package threshold
+
+import (
+	"fmt"
+
+	"github.com/colemalphrus/nld/internal/signing"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+// Result is the outcome of evaluating one signature policy
+type Result struct {
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	Required    int    `json:"required"`
+	// Signers are the entities that may sign for the policy
+	Signers []string `json:"signers"`
+	// Signed are the signers with a verified signature
+	Signed []string `json:"signed"`
+	// Missing are the signers without one
+	Missing   []string `json:"missing"`
+	Satisfied bool     `json:"satisfied"`
+}
+
+// Evaluate checks the signature policies of doc against its signatures
+// over root
+func Evaluate(doc *nld.Document, root string) []Result {
+	entities := map[string]nld.Entity{}
+	for _, e := range doc.Metadata.Entities {
+		entities[e.ID] = e
+	}
+	verified := map[string]bool{}
+	for _, sig := range doc.Verification.Signatures {
+		if e, ok := entities[sig.SignerID]; ok && signing.Verify(sig, root) == nil && e.HasKey(keyID(sig)) {
+			verified[sig.SignerID] = true
+		}
+	}
+
+	var results []Result
+	for _, p := range doc.Metadata.SignaturePolicies {
+		r := Result{ID: p.ID, Description: p.Description, Required: p.Required, Signers: doc.PolicySigners(p), Signed: []string{}, Missing: []string{}}
+		if r.Signers == nil {
+			r.Signers = []string{}
+		}
+		for _, id := range r.Signers {
+			if verified[id] {
+				r.Signed = append(r.Signed, id)
+			} else {
+				r.Missing = append(r.Missing, id)
+			}
+		}
+		r.Satisfied = p.Required > 0 && len(r.Signed) >= p.Required
+		results = append(results, r)
+	}
+	return results
+}
+
+// keyID returns the ID of the key of a verified signature, which is
+// worked out from its public key when the signature does not give it
+func keyID(sig nld.Signature) string {
+	if sig.KeyID != "" {
+		return sig.KeyID
+	}
+	pub, err := signing.PublicKey(sig)
+	if err != nil {
+		return ""
+	}
+	id, _ := signing.KeyID(pub)
+	return id
+}
+
+// Problem is a problem of the signature policy at Index in
+// metadata.signaturePolicies
+type Problem struct {
+	Index   int
+	Message string
+}
+
+// Check returns the problems of the signature policies of doc: policies
+// without an ID or signers, signers that are not entities, and numbers of
+// signatures that cannot be met
+func Check(doc *nld.Document) []Problem {
+	entities := map[string]bool{}
+	for _, e := range doc.Metadata.Entities {
+		entities[e.ID] = true
+	}
+	var problems []Problem
+	seen := map[string]bool{}
+	for i, p := range doc.Metadata.SignaturePolicies {
+		report := func(format string, args ...interface{}) {
+			problems = append(problems, Problem{Index: i, Message: fmt.Sprintf(format, args...)})
+		}
+		name := p.ID
+		switch {
+		case p.ID == "":
+			name = fmt.Sprint(i + 1)
+			report("signature policy %s has no ID", name)
+		case seen[p.ID]:
+			report("signature policy %s is defined twice", name)
+		}
+		seen[p.ID] = true
+		if len(p.Signers) > 0 && p.Role != "" {
+			report("signature policy %s has both signers and a role", name)
+		}
+		for _, id := range p.Signers {
+			if !entities[id] {
+				report("signature policy %s names %s, which is not an entity", name, id)
+			}
+		}
+		signers := doc.PolicySigners(p)
+		switch {
+		case len(signers) == 0:
+			report("signature policy %s has no signers", name)
+		case p.Required < 1 || p.Required > len(signers):
+			report("signature policy %s requires %d signature(s), expected between 1 and its %d signer(s)", name, p.Required, len(signers))
+		}
+	}
+	return problems
+}
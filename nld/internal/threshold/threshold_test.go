@@ -0,0 +1,116 @@
+package threshold
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/signing"
+	"github.com/colemalphrus/nld/pkg/nld"
+)
+
+const root = "sha256:0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+
+func TestEvaluate(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	sign := func(id, root string) nld.Signature {
+		sig, err := signing.Sign(key, root, id, "2025-03-01T10:00:00Z")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	doc := &nld.Document{Metadata: nld.Metadata{
+		Entities: []nld.Entity{{ID: "d1", Role: "Director"}, {ID: "d2", Role: "Director"}, {ID: "d3", Role: "Director"}, {ID: "client", Role: "Client"}},
+		SignaturePolicies: []nld.SignaturePolicy{
+			{ID: "board", Role: "Director", Required: 2},
+			{ID: "client", Signers: []string{"client"}, Required: 1},
+		},
+	}}
+	doc.Verification.Signatures = []nld.Signature{
+		sign("d1", root),
+		// A signature of an earlier version does not count
+		sign("d2", "sha256:00"),
+		{SignerID: "client", Date: "2025-03-01T10:00:00Z", Value: "docusign:env-1"},
+	}
+
+	results := Evaluate(doc, root)
+	if len(results) != 2 || results[0].Satisfied || len(results[0].Signed) != 1 || len(results[0].Missing) != 2 || results[1].Satisfied {
+		t.Fatalf("Evaluate = %+v", results)
+	}
+
+	doc.Verification.Signatures = append(doc.Verification.Signatures, sign("d3", root), sign("client", root))
+	for _, r := range Evaluate(doc, root) {
+		if !r.Satisfied {
+			t.Errorf("policy %s not satisfied: %+v", r.ID, r)
+		}
+	}
+}
+
+func TestEvaluateRegisteredKeys(t *testing.T) {
+	registered, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	id, _ := signing.KeyID(&registered.PublicKey)
+	doc := &nld.Document{Metadata: nld.Metadata{
+		Entities:          []nld.Entity{{ID: "d1", Role: "Director", KeyIDs: []string{id}}},
+		SignaturePolicies: []nld.SignaturePolicy{{ID: "board", Role: "Director", Required: 1}},
+	}}
+
+	testCases := []struct {
+		name   string
+		key    *ecdsa.PrivateKey
+		keyID  bool
+		counts bool
+	}{
+		{name: "Registered Key", key: registered, keyID: true, counts: true},
+		{name: "Registered Key Without ID", key: registered, counts: true},
+		{name: "Other Key", key: other, keyID: true},
+		{name: "Other Key Without ID", key: other},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sig, err := signing.Sign(tc.key, root, "d1", "2025-03-01T10:00:00Z")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.keyID {
+				sig.KeyID = ""
+			}
+			doc.Verification.Signatures = []nld.Signature{sig}
+			if r := Evaluate(doc, root); r[0].Satisfied != tc.counts {
+				t.Errorf("Evaluate = %+v, expected the signature to count: %v", r, tc.counts)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	doc := &nld.Document{Metadata: nld.Metadata{
+		Entities: []nld.Entity{{ID: "d1", Role: "Director"}, {ID: "d2", Role: "Director"}},
+		SignaturePolicies: []nld.SignaturePolicy{
+			{ID: "board", Role: "Director", Required: 2},
+			{ID: "board", Signers: []string{"d1"}, Role: "Director", Required: 1},
+			{Signers: []string{"d1", "d9"}, Required: 0},
+		},
+	}}
+	var got []string
+	for _, p := range Check(doc) {
+		got = append(got, p.Message)
+	}
+	expect := []string{
+		"signature policy board is defined twice",
+		"signature policy board has both signers and a role",
+		"signature policy 3 has no ID",
+		"signature policy 3 names d9, which is not an entity",
+		"signature policy 3 requires 0 signature(s), expected between 1 and its 2 signer(s)",
+	}
+	if len(got) != len(expect) {
+		t.Fatalf("Check = %q", got)
+	}
+	for i := range expect {
+		if got[i] != expect[i] {
+			t.Errorf("problem %d = %q, expected %q", i, got[i], expect[i])
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Environment variables of the OpenTelemetry SDK that turn tracing on.
+// The other OTEL_ variables, such as OTEL_EXPORTER_OTLP_HEADERS and
+// OTEL_TRACES_SAMPLER, configure the exporter and sampler as usual.
+const (
+	EndpointEnv       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	TracesEndpointEnv = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	ExporterEnv       = "OTEL_TRACES_EXPORTER"
+)
+
+// Enabled reports whether the environment asks for traces to be exported
+func Enabled() bool {
+	if strings.EqualFold(os.Getenv(ExporterEnv), "none") {
+		return false
+	}
+	return os.Getenv(EndpointEnv) != "" || os.Getenv(TracesEndpointEnv) != "" || strings.EqualFold(os.Getenv(ExporterEnv), "otlp")
+}
+
+// Start sets up the W3C trace context propagator and, when Enabled, a
+// tracer provider exporting spans over OTLP/HTTP. The returned function
+// flushes and stops the exporter. It lives apart from the tracing package
+// so that the WebAssembly build and the C library do not carry the SDK.
+func Start(ctx context.Context, version string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start trace exporter: %w", err)
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override these
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName("nld"), semconv.ServiceVersion(version)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
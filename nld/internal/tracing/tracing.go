@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name is the instrumentation name of the spans of nld. Spans go to the
+// global tracer provider, which records nothing until the otlp package
+// sets one up.
+const Name = "github.com/colemalphrus/nld"
+
+// TraceparentEnv holds the W3C trace context of a parent span, so that a
+// pipeline running nld can make its commands children of its own spans
+const TraceparentEnv = "TRACEPARENT"
+
+// FromEnv returns ctx with the parent span of TRACEPARENT, if set
+func FromEnv(ctx context.Context) context.Context {
+	parent := os.Getenv(TraceparentEnv)
+	if parent == "" {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{"traceparent": parent}
+	if state := os.Getenv("TRACESTATE"); state != "" {
+		carrier["tracestate"] = state
+	}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+// Span starts a span of nld named name. End it with End.
+func Span(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(Name).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err, if any, on span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
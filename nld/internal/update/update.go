@@ -0,0 +1,199 @@
+// Package update finds out whether a newer release of nld is available,
+// asking the release feed at most once a day and caching the answer
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DisableEnv turns the check off when set to anything but the empty
+	// string
+	DisableEnv = "NLD_NO_UPDATE_NOTIFIER"
+	// URLEnv replaces the release feed, such as with a mirror
+	URLEnv = "NLD_UPDATE_URL"
+	// DefaultURL is the latest release of nld on GitHub
+	DefaultURL = "https://api.github.com/repos/colemalphrus/nld/releases/latest"
+	// ReleasesPage is where users find the releases
+	ReleasesPage = "https://github.com/colemalphrus/nld/releases"
+	// Interval is how long an answer of the release feed is used
+	Interval = 24 * time.Hour
+)
+
+// Disabled reports whether the check is turned off by NLD_NO_UPDATE_NOTIFIER,
+// or as nld runs in CI, where nobody reads the notice
+func Disabled() bool {
+	return os.Getenv(DisableEnv) != "" || os.Getenv("CI") != ""
+}
+
+// state is the cache file: the latest release and when it was asked for
+type state struct {
+	Checked time.Time `json:"checked"`
+	Latest  string    `json:"latest"`
+}
+
+// Checker checks for releases newer than the version in use
+type Checker struct {
+	// URL is the release feed, which answers with a JSON object whose
+	// tag_name is the latest release
+	URL string
+	// Cache is the file holding the last answer
+	Cache  string
+	Client *http.Client
+	// now returns the current time
+	now func() time.Time
+}
+
+// New returns a checker of the release feed of URLEnv, or DefaultURL,
+// caching answers in the user cache directory
+func New() (*Checker, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the cache directory: %w", err)
+	}
+	url := os.Getenv(URLEnv)
+	if url == "" {
+		url = DefaultURL
+	}
+	return &Checker{
+		URL:    url,
+		Cache:  filepath.Join(dir, "nld", "update.json"),
+		Client: &http.Client{Timeout: 2 * time.Second},
+	}, nil
+}
+
+// Newer returns the latest release when it is newer than current, or "".
+// The release feed is asked when the cached answer is older than a day. A
+// failed request is not repeated for a day either, so that commands run
+// offline are not slowed down.
+func (c *Checker) Newer(ctx context.Context, current string) (string, error) {
+	now := time.Now()
+	if c.now != nil {
+		now = c.now()
+	}
+	s, err := c.load()
+	if err != nil || now.Sub(s.Checked) >= Interval || now.Before(s.Checked) {
+		latest, fetchErr := c.fetch(ctx)
+		s.Checked = now
+		if fetchErr == nil {
+			s.Latest = latest
+		}
+		if err := c.save(s); err != nil {
+			return "", err
+		}
+		if fetchErr != nil {
+			return "", fetchErr
+		}
+	}
+	if newer(s.Latest, current) {
+		return s.Latest, nil
+	}
+	return "", nil
+}
+
+// load reads the cache file
+func (c *Checker) load() (state, error) {
+	var s state
+	data, err := os.ReadFile(c.Cache)
+	if err != nil {
+		return s, err
+	}
+	return s, json.Unmarshal(data, &s)
+}
+
+// save writes the cache file
+func (c *Checker) save(s state) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Cache), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return os.WriteFile(c.Cache, data, 0644)
+}
+
+// fetch asks the release feed for the latest release
+func (c *Checker) fetch(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to check for updates: %s", resp.Status)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&release); err != nil {
+		return "", fmt.Errorf("failed to check for updates: %w", err)
+	}
+	if release.TagName == "" {
+		return "", errors.New("failed to check for updates: release has no tag")
+	}
+	return release.TagName, nil
+}
+
+// newer reports whether the version latest is newer than current. Versions
+// are compared by their dot-separated numbers, with or without a leading v;
+// pre-releases of a version are older than the version itself.
+func newer(latest, current string) bool {
+	l, lpre, ok := parse(latest)
+	if !ok {
+		return false
+	}
+	c, cpre, ok := parse(current)
+	if !ok {
+		return false
+	}
+	for i := 0; i < len(l) || i < len(c); i++ {
+		var a, b int
+		if i < len(l) {
+			a = l[i]
+		}
+		if i < len(c) {
+			b = c[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return lpre == "" && cpre != ""
+}
+
+// parse splits a version such as v1.2.3-rc.1 into its numbers and
+// pre-release
+func parse(version string) ([]int, string, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	version, _, _ = strings.Cut(version, "+")
+	version, pre, _ := strings.Cut(version, "-")
+	var numbers []int
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, "", false
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, pre, true
+}
@@ -0,0 +1,91 @@
+package update
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewer(t *testing.T) {
+	testCases := []struct {
+		latest, current string
+		expect          bool
+	}{
+		{"v0.2.0", "0.1.0", true},
+		{"v0.1.0", "0.1.0", false},
+		{"0.1.1", "v0.1.0", true},
+		{"v1.0", "0.9.9", true},
+		{"v0.1.0", "0.2.0", false},
+		{"v0.2.0", "0.2.0-rc.1", true},
+		{"v0.2.0-rc.1", "0.1.0", true},
+		{"v0.2.0-rc.1", "0.2.0", false},
+		{"nightly", "0.1.0", false},
+		{"v0.2.0", "(devel)", false},
+	}
+	for _, tc := range testCases {
+		if got := newer(tc.latest, tc.current); got != tc.expect {
+			t.Errorf("newer(%q, %q) = %v, expected %v", tc.latest, tc.current, got, tc.expect)
+		}
+	}
+}
+
+func TestChecker(t *testing.T) {
+	requests := 0
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+		w.Write([]byte(`{"tag_name": "v0.3.0"}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := &Checker{URL: server.URL, Cache: filepath.Join(t.TempDir(), "nld", "update.json"), now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	latest, err := c.Newer(ctx, "0.1.0")
+	if err != nil || latest != "v0.3.0" {
+		t.Fatalf("Newer = %q, %v; expected v0.3.0", latest, err)
+	}
+	// The cached answer is used for a day
+	now = now.Add(23 * time.Hour)
+	if latest, err := c.Newer(ctx, "0.3.0"); err != nil || latest != "" {
+		t.Errorf("Newer on the latest release = %q, %v", latest, err)
+	}
+	if requests != 1 {
+		t.Errorf("%d requests within a day, expected 1", requests)
+	}
+
+	// A failed request keeps the last answer and is not repeated for a day
+	now = now.Add(2 * time.Hour)
+	status = http.StatusInternalServerError
+	if _, err := c.Newer(ctx, "0.1.0"); err == nil {
+		t.Error("expected an error from a failing release feed")
+	}
+	if latest, err := c.Newer(ctx, "0.1.0"); err != nil || latest != "v0.3.0" {
+		t.Errorf("Newer after a failure = %q, %v; expected the cached v0.3.0", latest, err)
+	}
+	if requests != 2 {
+		t.Errorf("%d requests, expected 2", requests)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	t.Setenv("CI", "")
+	t.Setenv(DisableEnv, "")
+	if Disabled() {
+		t.Error("expected the check to be on")
+	}
+	t.Setenv(DisableEnv, "1")
+	if !Disabled() {
+		t.Errorf("expected %s to turn the check off", DisableEnv)
+	}
+	t.Setenv(DisableEnv, "")
+	t.Setenv("CI", "true")
+	if !Disabled() {
+		t.Error("expected the check to be off in CI")
+	}
+}
@@ -0,0 +1,329 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Fix is a change to a document that resolves a validation error, as a JSON
+// Patch (RFC 6902) operation with a description
+type Fix struct {
+	Op          string      `json:"op"`
+	Path        string      `json:"path"`
+	Value       interface{} `json:"value"`
+	Description string      `json:"description"`
+}
+
+// fixer suggests fixes for schema errors of a document
+type fixer struct {
+	schema *jsonschema.Schema
+	doc    interface{}
+}
+
+// fixes returns the fixes of an error: missing required properties are
+// added with an empty value, or their default, strings that are close to
+// one value of an enum are replaced by it, and values of the wrong type are
+// converted when nothing is lost, such as "12" to 12.
+func (f *fixer) fixes(ve *jsonschema.ValidationError) []Fix {
+	if f == nil {
+		return nil
+	}
+	at := strings.LastIndex(ve.KeywordLocation, "/")
+	if at < 0 {
+		return nil
+	}
+	s := schemaAt(f.schema, ve.KeywordLocation[:at])
+	value, ok := valueAt(f.doc, ve.InstanceLocation)
+	if s == nil || !ok {
+		return nil
+	}
+
+	switch ve.KeywordLocation[at+1:] {
+	case "required":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		var fixes []Fix
+		for _, name := range s.Required {
+			if _, ok := obj[name]; ok {
+				continue
+			}
+			empty, ok := emptyValue(memberSchemas(s, name))
+			if !ok {
+				continue
+			}
+			fixes = append(fixes, Fix{
+				Op:          "add",
+				Path:        ve.InstanceLocation + "/" + escape(name),
+				Value:       empty,
+				Description: fmt.Sprintf("add the missing property %s as %s", quote(name), display(empty)),
+			})
+		}
+		return fixes
+	case "enum":
+		str, ok := value.(string)
+		if !ok {
+			return nil
+		}
+		if match, ok := closestEnum(str, s.Enum); ok {
+			return []Fix{{
+				Op:          "replace",
+				Path:        ve.InstanceLocation,
+				Value:       match,
+				Description: fmt.Sprintf("replace %s with %s", display(str), display(match)),
+			}}
+		}
+	case "type":
+		for _, t := range s.Types {
+			if converted, ok := convert(value, t); ok {
+				return []Fix{{
+					Op:          "replace",
+					Path:        ve.InstanceLocation,
+					Value:       converted,
+					Description: fmt.Sprintf("replace %s with %s", display(value), display(converted)),
+				}}
+			}
+		}
+	}
+	return nil
+}
+
+// schemaAt returns the schema at a keyword location relative to s, such as
+// /properties/content/$ref, or nil when the location is not supported
+func schemaAt(s *jsonschema.Schema, location string) *jsonschema.Schema {
+	tokens := strings.Split(strings.TrimPrefix(location, "/"), "/")
+	if location == "" {
+		tokens = nil
+	}
+	for i := 0; i < len(tokens) && s != nil; i++ {
+		token := unescape(tokens[i])
+		// Keywords holding a schema by name or by index are followed by it
+		next := ""
+		if i+1 < len(tokens) {
+			next = unescape(tokens[i+1])
+		}
+		index, err := strconv.Atoi(next)
+		if err != nil {
+			index = -1
+		}
+		switch token {
+		case "$ref":
+			s = s.Ref
+		case "not":
+			s = s.Not
+		case "if":
+			s = s.If
+		case "then":
+			s = s.Then
+		case "else":
+			s = s.Else
+		case "propertyNames":
+			s = s.PropertyNames
+		case "contains":
+			s = s.Contains
+		case "additionalProperties":
+			s, _ = s.AdditionalProperties.(*jsonschema.Schema)
+		case "additionalItems":
+			s, _ = s.AdditionalItems.(*jsonschema.Schema)
+		case "items":
+			switch items := s.Items.(type) {
+			case *jsonschema.Schema:
+				s = items
+			case []*jsonschema.Schema:
+				s = nil
+				if index >= 0 && index < len(items) {
+					s = items[index]
+					i++
+				}
+			default:
+				s = s.Items2020
+			}
+		case "properties":
+			s = s.Properties[next]
+			i++
+		case "patternProperties":
+			var match *jsonschema.Schema
+			for re, p := range s.PatternProperties {
+				if re.String() == next {
+					match = p
+				}
+			}
+			s = match
+			i++
+		case "allOf", "anyOf", "oneOf":
+			list := map[string][]*jsonschema.Schema{"allOf": s.AllOf, "anyOf": s.AnyOf, "oneOf": s.OneOf}[token]
+			s = nil
+			if index >= 0 && index < len(list) {
+				s = list[index]
+			}
+			i++
+		default:
+			return nil
+		}
+	}
+	return s
+}
+
+// valueAt returns the value at a JSON pointer in doc
+func valueAt(doc interface{}, ptr string) (interface{}, bool) {
+	if ptr == "" {
+		return doc, true
+	}
+	for _, token := range strings.Split(ptr[1:], "/") {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if doc, ok = v[unescape(token)]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			doc = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// emptyValue returns the value a missing property of one of schemas is
+// added with: its default, or the empty value of its type. Properties with
+// an enum and no default have none, as there is no value to pick.
+func emptyValue(schemas []*jsonschema.Schema) (interface{}, bool) {
+	if len(schemas) == 0 {
+		return nil, false
+	}
+	s := schemas[0]
+	for s.Ref != nil && s.Default == nil {
+		s = s.Ref
+	}
+	switch {
+	case s.Default != nil:
+		return s.Default, true
+	case len(s.Constant) > 0:
+		return s.Constant[0], true
+	case len(s.Enum) > 0:
+		return nil, false
+	case len(s.Types) == 0 && len(s.OneOf) > 0:
+		return emptyValue(s.OneOf[:1])
+	case len(s.Types) == 0 && len(s.AnyOf) > 0:
+		return emptyValue(s.AnyOf[:1])
+	case len(s.Types) == 0:
+		return nil, false
+	}
+	switch s.Types[0] {
+	case "string":
+		return "", true
+	case "object":
+		return map[string]interface{}{}, true
+	case "array":
+		return []interface{}{}, true
+	case "number", "integer":
+		return json.Number("0"), true
+	case "boolean":
+		return false, true
+	}
+	return nil, true
+}
+
+// closestEnum returns the string of enum that s is an obvious typo of: the
+// same but for case, or the only one within two edits of it
+func closestEnum(s string, enum []interface{}) (string, bool) {
+	match, count := "", 0
+	for _, v := range enum {
+		if e, ok := v.(string); ok && strings.EqualFold(e, s) {
+			return e, true
+		}
+	}
+	if len([]rune(s)) < 4 {
+		return "", false
+	}
+	for _, v := range enum {
+		if e, ok := v.(string); ok && distance(strings.ToLower(e), strings.ToLower(s)) <= 2 {
+			match = e
+			count++
+		}
+	}
+	return match, count == 1
+}
+
+// distance returns the Levenshtein distance between two strings
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// numberRe matches a JSON number
+var numberRe = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// convert converts a value to a JSON type when nothing is lost, such as the
+// string "12" to the integer 12
+func convert(v interface{}, t string) (interface{}, bool) {
+	switch v := v.(type) {
+	case string:
+		s := strings.TrimSpace(v)
+		switch t {
+		case "number":
+			if numberRe.MatchString(s) {
+				return json.Number(s), true
+			}
+		case "integer":
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return json.Number(strconv.FormatInt(n, 10)), true
+			}
+		case "boolean":
+			if s == "true" || s == "false" {
+				return s == "true", true
+			}
+		}
+	case float64:
+		switch t {
+		case "string":
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		}
+	case bool:
+		if t == "string" {
+			return strconv.FormatBool(v), true
+		}
+	}
+	return nil, false
+}
+
+// display formats a value for the description of a fix
+func display(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// unescape decodes a JSON Pointer token
+func unescape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
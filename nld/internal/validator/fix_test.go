@@ -0,0 +1,102 @@
+package validator
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFixes(t *testing.T) {
+	v := New()
+	schema, err := v.LoadSchema(filepath.Join("..", "..", "schemas", "document-v1.json"))
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		metadata string
+		sections string
+		expected []Fix
+	}{
+		{
+			name:     "Missing Property",
+			metadata: `"type": "contract"`,
+			sections: `[{"id": "a", "content": "x"}]`,
+			expected: []Fix{{Op: "add", Path: "/content/sections/0/title", Value: "", Description: `add the missing property 'title' as ""`}},
+		},
+		{
+			name:     "Enum Typo",
+			metadata: `"type": "Contrct"`,
+			sections: `[]`,
+			expected: []Fix{{Op: "replace", Path: "/metadata/type", Value: "contract", Description: `replace "Contrct" with "contract"`}},
+		},
+		{
+			name:     "Enum Case",
+			metadata: `"type": "RECEIPT"`,
+			sections: `[]`,
+			expected: []Fix{{Op: "replace", Path: "/metadata/type", Value: "receipt", Description: `replace "RECEIPT" with "receipt"`}},
+		},
+		{
+			name:     "Wrong Type",
+			metadata: `"type": "contract"`,
+			sections: `[{"id": "a", "title": "A", "content": 5}]`,
+			expected: []Fix{{Op: "replace", Path: "/content/sections/0/content", Value: "5", Description: `replace 5 with "5"`}},
+		},
+		{
+			name:     "No Obvious Fix",
+			metadata: `"type": "lease"`,
+			sections: `[]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := []byte(`{
+				"metadata": {"version": "1.0.0", "created": "2025-06-27T12:00:00Z", "title": "Test", ` + tc.metadata + `},
+				"content": {"sections": ` + tc.sections + `}
+			}`)
+			result, err := v.ValidateBytes(doc, schema)
+			if err != nil {
+				t.Fatalf("Validation failed with error: %v", err)
+			}
+			if result.Valid {
+				t.Fatal("Expected the document to be invalid")
+			}
+			var fixes []Fix
+			for _, e := range result.Errors {
+				fixes = append(fixes, e.Fixes...)
+			}
+			if !reflect.DeepEqual(fixes, tc.expected) {
+				got, _ := json.Marshal(fixes)
+				t.Errorf("Expected %v, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		value    interface{}
+		to       string
+		expected interface{}
+		ok       bool
+	}{
+		{value: " 12 ", to: "integer", expected: json.Number("12"), ok: true},
+		{value: "12.50", to: "number", expected: json.Number("12.50"), ok: true},
+		{value: "12.5", to: "integer", ok: false},
+		{value: "true", to: "boolean", expected: true, ok: true},
+		{value: "yes", to: "boolean", ok: false},
+		{value: 10.5, to: "string", expected: "10.5", ok: true},
+		{value: false, to: "string", expected: "false", ok: true},
+		{value: "x", to: "array", ok: false},
+	}
+
+	for _, tc := range testCases {
+		got, ok := convert(tc.value, tc.to)
+		if ok != tc.ok || !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("convert(%#v, %s): expected %#v, %v, got %#v, %v", tc.value, tc.to, tc.expected, tc.ok, got, ok)
+		}
+	}
+}
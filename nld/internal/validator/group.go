@@ -0,0 +1,137 @@
+package validator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ways of grouping validation errors
+const (
+	// GroupByField groups errors with the same message at the same field of
+	// different list items, such as a missing title in many sections
+	GroupByField = "field"
+	// GroupByKeyword groups errors of the same schema keyword, whatever
+	// their message
+	GroupByKeyword = "keyword"
+)
+
+// ErrorGroup is a set of similar validation errors
+type ErrorGroup struct {
+	// Field is the field of the errors, with * for the list indexes in
+	// which they differ. Errors at different depths have the field of
+	// the list or object that holds them all.
+	Field string
+	// Message is the message of the first error
+	Message         string
+	KeywordLocation string `json:",omitempty"`
+	// Fields are the fields of the errors, in order
+	Fields []string
+}
+
+// GroupErrors groups similar errors, by field or by keyword, in the order
+// of the first error of each group. Errors that only group the errors
+// below them have no message of their own and are left out.
+func GroupErrors(errs []ValidationError, by string) ([]ErrorGroup, error) {
+	if by != GroupByField && by != GroupByKeyword {
+		return nil, fmt.Errorf("unsupported grouping: %s (supported: %s, %s)", by, GroupByField, GroupByKeyword)
+	}
+	var groups []ErrorGroup
+	index := map[string]int{}
+	for _, e := range errs {
+		if e.Message == "" {
+			continue
+		}
+		key := "field " + fieldPattern(e.Field) + " " + e.Message
+		if by == GroupByKeyword && e.KeywordLocation != "" {
+			key = "keyword " + e.KeywordLocation
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ErrorGroup{Field: e.Field, Message: e.Message, KeywordLocation: e.KeywordLocation})
+		}
+		g := &groups[i]
+		g.Fields = append(g.Fields, e.Field)
+		g.Field = mergePattern(g.Field, e.Field)
+	}
+	return groups, nil
+}
+
+// CountErrors returns the number of errors with a message of their own
+func CountErrors(errs []ValidationError) int {
+	n := 0
+	for _, e := range errs {
+		if e.Message != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// fieldPattern returns a JSON pointer with its list indexes replaced by *
+func fieldPattern(ptr string) string {
+	tokens := strings.Split(ptr, "/")
+	for i, token := range tokens {
+		if _, err := strconv.Atoi(token); err == nil {
+			tokens[i] = "*"
+		}
+	}
+	return strings.Join(tokens, "/")
+}
+
+// mergePattern returns pattern with * for the tokens in which ptr differs
+// from it, cut to the tokens they share when ptr is of another depth
+func mergePattern(pattern, ptr string) string {
+	a, b := strings.Split(pattern, "/"), strings.Split(ptr, "/")
+	if len(a) != len(b) {
+		n := 0
+		for ; n < len(a) && n < len(b); n++ {
+			if a[n] == b[n] {
+				continue
+			}
+			if fieldPattern(a[n]) != "*" || fieldPattern(b[n]) != "*" {
+				break
+			}
+			a[n] = "*"
+		}
+		return strings.Join(a[:n], "/")
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			a[i] = "*"
+		}
+	}
+	return strings.Join(a, "/")
+}
+
+// Location describes where the errors of a group are, such as
+// /content/sections/[3, 7, 9]/title, listing at most limit of them
+func (g ErrorGroup) Location(limit int) string {
+	indexed := strings.Count(g.Field, "*") == 1
+	for _, field := range g.Fields {
+		indexed = indexed && strings.Count(field, "/") == strings.Count(g.Field, "/")
+	}
+	if len(g.Fields) == 1 || !indexed {
+		list := g.Fields
+		if len(list) > limit {
+			list = append(list[:limit:limit], "...")
+		}
+		return strings.Join(list, ", ")
+	}
+	// The index of every error in the list whose items they are
+	at := strings.Index(g.Field, "*")
+	var indexes []string
+	for _, field := range g.Fields {
+		token := field[at:]
+		if end := strings.Index(token, "/"); end >= 0 {
+			token = token[:end]
+		}
+		indexes = append(indexes, token)
+	}
+	if len(indexes) > limit {
+		indexes = append(indexes[:limit:limit], "...")
+	}
+	return g.Field[:at] + "[" + strings.Join(indexes, ", ") + "]" + g.Field[at+1:]
+}
@@ -0,0 +1,60 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupErrors(t *testing.T) {
+	errs := []ValidationError{
+		{Field: "/content/sections/3", Message: "missing properties: 'title'", KeywordLocation: "/definitions/section/required"},
+		{Field: "/content/sections/5/content", Message: "expected string, but got number", KeywordLocation: "/definitions/section/properties/content/type"},
+		{Field: "/content/sections/7", Message: "missing properties: 'title'", KeywordLocation: "/definitions/section/required"},
+		{Field: "/content/sections/9", Message: "missing properties: 'id'", KeywordLocation: "/definitions/section/required"},
+		{Field: "/content/sections/12/sections/0", Message: "missing properties: 'title'", KeywordLocation: "/definitions/section/required"},
+	}
+
+	groups, err := GroupErrors(errs, GroupByField)
+	if err != nil {
+		t.Fatalf("GroupErrors failed: %v", err)
+	}
+	if len(groups) != 4 {
+		t.Fatalf("Expected 4 groups, got %+v", groups)
+	}
+	if want := []string{"/content/sections/3", "/content/sections/7"}; !reflect.DeepEqual(groups[0].Fields, want) {
+		t.Errorf("Expected %v, got %v", want, groups[0].Fields)
+	}
+	if groups[0].Field != "/content/sections/*" {
+		t.Errorf("Expected /content/sections/*, got %s", groups[0].Field)
+	}
+	if location := groups[0].Location(10); location != "/content/sections/[3, 7]" {
+		t.Errorf("Expected /content/sections/[3, 7], got %s", location)
+	}
+
+	groups, err = GroupErrors(errs, GroupByKeyword)
+	if err != nil {
+		t.Fatalf("GroupErrors failed: %v", err)
+	}
+	if len(groups) != 2 || len(groups[0].Fields) != 4 {
+		t.Fatalf("Expected the required errors in one group, got %+v", groups)
+	}
+	if groups[0].Field != "/content/sections/*" {
+		t.Errorf("Expected /content/sections/*, got %s", groups[0].Field)
+	}
+	if location := groups[0].Location(2); location != "/content/sections/3, /content/sections/7, ..." {
+		t.Errorf("Expected the first two fields, got %s", location)
+	}
+
+	// Errors without a message are neither grouped nor counted
+	errs = append(errs, ValidationError{Field: "/content/sections", KeywordLocation: "/properties/content/properties/sections"})
+	if groups, _ := GroupErrors(errs, GroupByKeyword); len(groups) != 2 {
+		t.Errorf("Expected the error without a message to be left out, got %+v", groups)
+	}
+	if n := CountErrors(errs); n != 5 {
+		t.Errorf("Expected 5 errors, got %d", n)
+	}
+
+	if _, err := GroupErrors(errs, "message"); err == nil {
+		t.Error("Expected an error for an unsupported grouping")
+	}
+}
@@ -0,0 +1,142 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules of the checks the schema cannot express. Schema errors have the
+// rule of their keyword, such as required or enum.
+const (
+	RuleJSON          = "json"
+	RuleSectionIDs    = "section-ids"
+	RuleTables        = "tables"
+	RuleDates         = "dates"
+	RuleRelationships = "relationships"
+	RuleReceipt       = "receipt"
+	RuleProfile       = "profile"
+	RuleLocales       = "locales"
+	RuleSigners       = "signers"
+)
+
+// Severity is how a failed rule is reported
+type Severity string
+
+const (
+	// SeverityError makes the document invalid, as rules do by default
+	SeverityError Severity = "error"
+	// SeverityWarning reports the error as a warning
+	SeverityWarning Severity = "warning"
+	// SeverityOff leaves the error out
+	SeverityOff Severity = "off"
+)
+
+// Severities sets the severity of rules by name. Rules it does not list
+// report errors.
+type Severities map[string]Severity
+
+// ParseSeverities parses severities by rule name, such as
+// {"required": "warning"}
+func ParseSeverities(settings map[string]string) (Severities, error) {
+	severities := Severities{}
+	for rule, level := range settings {
+		switch s := Severity(strings.ToLower(level)); s {
+		case SeverityError, SeverityWarning, SeverityOff:
+			severities[rule] = s
+		default:
+			return nil, fmt.Errorf("unsupported severity for %s: %s (supported: %s, %s, %s)", rule, level, SeverityError, SeverityWarning, SeverityOff)
+		}
+	}
+	return severities, nil
+}
+
+// Apply reports the errors of rules with warning severity as warnings and
+// leaves out those of rules that are off. The document is valid when no
+// error is left.
+func (s Severities) Apply(result *ValidationResult) {
+	if len(s) == 0 || result.Valid {
+		return
+	}
+	for _, e := range result.Findings() {
+		if s[e.Rule] == SeverityWarning {
+			result.Warnings = append(result.Warnings, ValidationWarning{Field: e.Field, Message: e.Message})
+		}
+	}
+	result.Filter(func(e ValidationError) bool {
+		severity, ok := s[e.Rule]
+		return !ok || severity == SeverityError
+	})
+}
+
+// Findings returns the errors of a result that are not caused by others:
+// the errors without causes in the tree, or those with a rule when there
+// is no tree
+func (r *ValidationResult) Findings() []ValidationError {
+	if len(r.Tree) > 0 {
+		return leaves(r.Tree)
+	}
+	var findings []ValidationError
+	for _, e := range r.Errors {
+		if e.Rule != "" {
+			findings = append(findings, e)
+		}
+	}
+	return findings
+}
+
+// leaves returns the errors of a tree that have no causes
+func leaves(tree []ValidationError) []ValidationError {
+	var list []ValidationError
+	for _, e := range tree {
+		if len(e.Causes) == 0 {
+			list = append(list, e)
+			continue
+		}
+		list = append(list, leaves(e.Causes)...)
+	}
+	return list
+}
+
+// Filter keeps the findings of a result for which keep returns true, and
+// the errors they were caused by. The result is valid when none is kept.
+func (r *ValidationResult) Filter(keep func(ValidationError) bool) {
+	if r.Valid {
+		return
+	}
+	if len(r.Tree) > 0 {
+		r.Tree = filterTree(r.Tree, keep)
+		r.Errors = flattenErrors(r.Tree)
+	} else {
+		var errs []ValidationError
+		found := false
+		for _, e := range r.Errors {
+			if e.Rule == "" || keep(e) {
+				errs = append(errs, e)
+				found = found || e.Rule != ""
+			}
+		}
+		r.Errors = nil
+		if found {
+			r.Errors = errs
+		}
+	}
+	r.Valid = len(r.Errors) == 0
+}
+
+// filterTree keeps the leaves of a tree for which keep returns true, and
+// the errors above them
+func filterTree(tree []ValidationError, keep func(ValidationError) bool) []ValidationError {
+	var kept []ValidationError
+	for _, e := range tree {
+		if len(e.Causes) == 0 {
+			if keep(e) {
+				kept = append(kept, e)
+			}
+			continue
+		}
+		if e.Causes = filterTree(e.Causes, keep); len(e.Causes) > 0 {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
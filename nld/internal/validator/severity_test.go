@@ -0,0 +1,78 @@
+package validator
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSeverities(t *testing.T) {
+	v := New()
+	schema, err := v.LoadSchema(filepath.Join("..", "..", "schemas", "document-v1.json"))
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+	doc := []byte(`{
+		"metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Test"},
+		"content": {"sections": [{"id": "a", "title": "A", "content": 5}, {"id": "b", "content": "B"}]}
+	}`)
+	validate := func() *ValidationResult {
+		result, err := v.ValidateBytes(doc, schema)
+		if err != nil {
+			t.Fatalf("Validation failed with error: %v", err)
+		}
+		return result
+	}
+
+	result := validate()
+	var rules []string
+	for _, e := range result.Findings() {
+		rules = append(rules, e.Rule)
+	}
+	if len(rules) != 3 || rules[0] != "type" || rules[1] != "type" || rules[2] != "required" {
+		t.Fatalf("Expected two type findings and a required one, got %v", rules)
+	}
+
+	severities, err := ParseSeverities(map[string]string{"required": "warning"})
+	if err != nil {
+		t.Fatalf("ParseSeverities failed: %v", err)
+	}
+	severities.Apply(result)
+	if result.Valid || len(result.Warnings) != 1 || result.Warnings[0].Field != "/content/sections/1" {
+		t.Fatalf("Expected the required error as a warning, got %+v", result)
+	}
+	for _, e := range result.Errors {
+		if e.Rule == "required" {
+			t.Errorf("Expected no required errors, got %+v", e)
+		}
+	}
+	if len(result.Tree) != 1 || len(result.Tree[0].Causes) != 1 {
+		t.Errorf("Expected the tree without the required error, got %+v", result.Tree)
+	}
+
+	result = validate()
+	severities, _ = ParseSeverities(map[string]string{"required": "off", "type": "off"})
+	severities.Apply(result)
+	if !result.Valid || len(result.Errors) != 0 || len(result.Tree) != 0 || len(result.Warnings) != 0 {
+		t.Errorf("Expected a valid result, got %+v", result)
+	}
+
+	if _, err := ParseSeverities(map[string]string{"required": "fatal"}); err == nil {
+		t.Error("Expected an error for an unsupported severity")
+	}
+}
+
+func TestFilterWithoutTree(t *testing.T) {
+	result := &ValidationResult{Errors: []ValidationError{
+		{Message: "doesn't validate with schema"},
+		{Field: "/content/sections/1/id", Message: "duplicate section id", Rule: RuleSectionIDs},
+		{Field: "/metadata/effectiveDate", Message: "invalid date", Rule: RuleDates},
+	}}
+	result.Filter(func(e ValidationError) bool { return e.Rule != RuleDates })
+	if result.Valid || len(result.Errors) != 2 {
+		t.Fatalf("Expected the summary and section id errors, got %+v", result.Errors)
+	}
+	result.Filter(func(e ValidationError) bool { return false })
+	if !result.Valid || len(result.Errors) != 0 {
+		t.Errorf("Expected a valid result, got %+v", result)
+	}
+}
@@ -0,0 +1,514 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Patterns of the JSON pointers that make up the skeleton of a document:
+// the body, its section lists, the sections and their block lists
+var (
+	sectionsPattern = `/(content|structure)/sections(/\d+/sections)*`
+	sectionPattern  = sectionsPattern + `/\d+`
+	skeletonRe      = regexp.MustCompile(`^(|/content|/structure|` + sectionsPattern + `|` + sectionPattern + `(/content)?)$`)
+	sectionIDRe     = regexp.MustCompile(`^` + sectionPattern + `/id$`)
+	blockRe         = regexp.MustCompile(`^` + sectionPattern + `/content/\d+$`)
+)
+
+// ValidateReader validates a JSON document read from r against a schema,
+// giving the same result as ValidateBytes without holding the whole
+// document in memory.
+//
+// The skeleton of the document (its body, sections and block lists) is
+// decoded token by token and checked against the matching parts of the
+// schema. Everything else, such as the metadata or a single block, is
+// decoded into memory and validated as a whole, so memory use is
+// proportional to the largest of those subtrees rather than to the
+// document. Parts of the skeleton whose schema cannot be checked
+// incrementally, for example because of anyOf or uniqueItems, are decoded
+// into memory too.
+func (v *Validator) ValidateReader(r io.Reader, schema *jsonschema.Schema) (*ValidationResult, error) {
+	st := &streamer{dec: json.NewDecoder(r), checks: &documentChecks{}}
+	err := st.value(schema, "")
+	if err == nil {
+		// Only whitespace may follow the document
+		if _, err = st.dec.Token(); err == io.EOF {
+			err = nil
+		} else if err == nil {
+			err = fmt.Errorf("invalid character after top-level value at offset %d", st.dec.InputOffset())
+		}
+	}
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if !errors.As(err, &syntaxErr) && !errors.Is(err, io.ErrUnexpectedEOF) && !strings.HasPrefix(err.Error(), "invalid character") {
+			return nil, fmt.Errorf("failed to read document: %w", err)
+		}
+		return &ValidationResult{
+			Valid: false,
+			Errors: []ValidationError{
+				{Message: fmt.Sprintf("Invalid JSON: %v", err), Rule: RuleJSON},
+			},
+		}, nil
+	}
+
+	if len(st.errs) > 0 {
+		errs := st.errs
+		if st.streamed {
+			// Match the error the schema reports for the whole document
+			errs = append([]ValidationError{{Message: fmt.Sprintf("doesn't validate with %s", schema.Location)}}, errs...)
+		}
+		return &ValidationResult{Valid: false, Errors: errs}, nil
+	}
+	if errs := st.checks.errors(); len(errs) > 0 {
+		return &ValidationResult{Valid: false, Errors: errs}, nil
+	}
+	return &ValidationResult{Valid: true}, nil
+}
+
+// streamer validates a document as it is decoded
+type streamer struct {
+	dec    *json.Decoder
+	checks *documentChecks
+	errs   []ValidationError
+	// streamed is set when the root of the document was decoded token by
+	// token
+	streamed bool
+}
+
+// value validates the next value of the document, at ptr, against s. A
+// nil schema accepts any value.
+func (st *streamer) value(s *jsonschema.Schema, ptr string) error {
+	if !skeletonRe.MatchString(ptr) {
+		var v interface{}
+		if err := st.dec.Decode(&v); err != nil {
+			return err
+		}
+		return st.validate(s, v, ptr)
+	}
+
+	tok, err := st.dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return st.validate(s, tok, ptr)
+	}
+	kind := "object"
+	if delim == '[' {
+		kind = "array"
+	}
+	if s != nil {
+		checked, ok := incremental(s, kind)
+		if !ok {
+			v, err := st.rest(delim)
+			if err != nil {
+				return err
+			}
+			return st.validate(s, v, ptr)
+		}
+		s = checked
+	}
+	if ptr == "" {
+		st.streamed = true
+	}
+	if s != nil && len(s.Types) > 0 && !hasType(s.Types, kind) {
+		st.report(ptr, "type", "expected %s, but got %s", strings.Join(s.Types, " or "), kind)
+		s = nil
+	}
+	if delim == '[' {
+		return st.array(s, ptr)
+	}
+	return st.object(s, ptr)
+}
+
+// object validates the members of an object whose opening brace has been
+// read
+func (st *streamer) object(s *jsonschema.Schema, ptr string) error {
+	count := 0
+	found := map[string]bool{}
+	var additional []string
+	for st.dec.More() {
+		tok, err := st.dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+		count++
+		location := ptr + "/" + escape(name)
+		if s == nil {
+			if err := st.value(nil, location); err != nil {
+				return err
+			}
+			continue
+		}
+
+		found[name] = true
+		if s.PropertyNames != nil {
+			if err := st.validate(s.PropertyNames, name, location); err != nil {
+				return err
+			}
+		}
+		schemas := memberSchemas(s, name)
+		if len(schemas) == 0 {
+			if allowed, ok := s.AdditionalProperties.(bool); ok && !allowed {
+				additional = append(additional, quote(name))
+			}
+		}
+		if len(schemas) <= 1 {
+			var member *jsonschema.Schema
+			if len(schemas) == 1 {
+				member = schemas[0]
+			}
+			if err := st.value(member, location); err != nil {
+				return err
+			}
+			continue
+		}
+		// A member matched by several schemas is checked against each
+		var v interface{}
+		if err := st.dec.Decode(&v); err != nil {
+			return err
+		}
+		for _, member := range schemas {
+			if err := st.validate(member, v, location); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := st.dec.Token(); err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+
+	if s.MinProperties != -1 && count < s.MinProperties {
+		st.report(ptr, "minProperties", "minimum %d properties allowed, but found %d properties", s.MinProperties, count)
+	}
+	if s.MaxProperties != -1 && count > s.MaxProperties {
+		st.report(ptr, "maxProperties", "maximum %d properties allowed, but found %d properties", s.MaxProperties, count)
+	}
+	var missing []string
+	for _, name := range s.Required {
+		if !found[name] {
+			missing = append(missing, quote(name))
+		}
+	}
+	if len(missing) > 0 {
+		st.report(ptr, "required", "missing properties: %s", strings.Join(missing, ", "))
+	}
+	if len(additional) > 0 {
+		st.report(ptr, "additionalProperties", "additionalProperties %s not allowed", strings.Join(additional, ", "))
+	}
+	return nil
+}
+
+// array validates the items of an array whose opening bracket has been read
+func (st *streamer) array(s *jsonschema.Schema, ptr string) error {
+	var items *jsonschema.Schema
+	if s != nil {
+		items, _ = s.Items.(*jsonschema.Schema)
+	}
+	count := 0
+	for st.dec.More() {
+		if err := st.value(items, ptr+"/"+strconv.Itoa(count)); err != nil {
+			return err
+		}
+		count++
+	}
+	if _, err := st.dec.Token(); err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+
+	if s.MinItems != -1 && count < s.MinItems {
+		st.report(ptr, "minItems", "minimum %d items required, but found %d items", s.MinItems, count)
+	}
+	if s.MaxItems != -1 && count > s.MaxItems {
+		st.report(ptr, "maxItems", "maximum %d items required, but found %d items", s.MaxItems, count)
+	}
+	return nil
+}
+
+// rest decodes the remainder of an object or array whose opening delimiter
+// has been read
+func (st *streamer) rest(delim json.Delim) (interface{}, error) {
+	if delim == '[' {
+		list := []interface{}{}
+		for st.dec.More() {
+			var v interface{}
+			if err := st.dec.Decode(&v); err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := st.dec.Token()
+		return list, err
+	}
+
+	obj := map[string]interface{}{}
+	for st.dec.More() {
+		tok, err := st.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if err := st.dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		obj[tok.(string)] = v
+	}
+	_, err := st.dec.Token()
+	return obj, err
+}
+
+// validate validates a decoded value at ptr against s and passes it on to
+// the document checks
+func (st *streamer) validate(s *jsonschema.Schema, v interface{}, ptr string) error {
+	st.checks.visit(v, ptr)
+	if s == nil {
+		return nil
+	}
+	err := s.Validate(v)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*jsonschema.ValidationError); !ok {
+		return err
+	}
+	errs := convertValidationErrors(err)
+	if ptr != "" {
+		// Within a document the schema of a value is not worth naming
+		errs = errs[1:]
+	}
+	for _, e := range errs {
+		e.Field = ptr + e.Field
+		st.errs = append(st.errs, e)
+	}
+	return nil
+}
+
+func (st *streamer) report(ptr, keyword, format string, args ...interface{}) {
+	st.errs = append(st.errs, ValidationError{Field: ptr, Message: fmt.Sprintf(format, args...), Rule: keyword})
+}
+
+// incremental returns the schema to check an object or array (kind)
+// against member by member, following references and choosing the oneOf
+// alternative that allows kind when no other does. It reports false when
+// the value must be validated as a whole.
+func incremental(s *jsonschema.Schema, kind string) (*jsonschema.Schema, bool) {
+	for s.Ref != nil {
+		if !bare(s) || len(s.OneOf) > 0 {
+			return nil, false
+		}
+		s = s.Ref
+	}
+	if len(s.OneOf) > 0 {
+		if !bare(s) {
+			return nil, false
+		}
+		var match *jsonschema.Schema
+		for _, alt := range s.OneOf {
+			for alt.Ref != nil && bare(alt) && len(alt.OneOf) == 0 {
+				alt = alt.Ref
+			}
+			if len(alt.Types) == 0 {
+				return nil, false
+			}
+			if hasType(alt.Types, kind) {
+				if match != nil {
+					return nil, false
+				}
+				match = alt
+			}
+		}
+		if match == nil {
+			return nil, false
+		}
+		return incremental(match, kind)
+	}
+	if !simple(s) {
+		return nil, false
+	}
+	if _, tuple := s.Items.([]*jsonschema.Schema); tuple {
+		return nil, false
+	}
+	return s, true
+}
+
+// simple reports whether s has only keywords that can be checked member by
+// member
+func simple(s *jsonschema.Schema) bool {
+	return s.Always == nil && s.Not == nil && len(s.AllOf) == 0 && len(s.AnyOf) == 0 &&
+		len(s.OneOf) == 0 && s.If == nil && len(s.Constant) == 0 && len(s.Enum) == 0 &&
+		s.Format == "" && s.RecursiveRef == nil && s.DynamicRef == nil &&
+		len(s.Dependencies) == 0 && len(s.DependentRequired) == 0 && len(s.DependentSchemas) == 0 &&
+		s.UnevaluatedProperties == nil && s.UnevaluatedItems == nil && !s.RegexProperties &&
+		!s.UniqueItems && s.Contains == nil && len(s.PrefixItems) == 0 && s.Items2020 == nil &&
+		s.AdditionalItems == nil && len(s.Extensions) == 0
+}
+
+// bare reports whether s has no keywords besides $ref and oneOf
+func bare(s *jsonschema.Schema) bool {
+	rest := *s
+	rest.OneOf = nil
+	return simple(&rest) && len(s.Types) == 0 && len(s.Required) == 0 &&
+		len(s.Properties) == 0 && len(s.PatternProperties) == 0 && s.PropertyNames == nil &&
+		s.AdditionalProperties == nil && s.Items == nil &&
+		s.MinProperties == -1 && s.MaxProperties == -1 && s.MinItems == -1 && s.MaxItems == -1 &&
+		s.MinLength == -1 && s.MaxLength == -1 && s.Pattern == nil &&
+		s.Minimum == nil && s.Maximum == nil && s.ExclusiveMinimum == nil &&
+		s.ExclusiveMaximum == nil && s.MultipleOf == nil
+}
+
+// memberSchemas returns the schemas that apply to the member name of an
+// object validated against s
+func memberSchemas(s *jsonschema.Schema, name string) []*jsonschema.Schema {
+	var schemas []*jsonschema.Schema
+	if p, ok := s.Properties[name]; ok {
+		schemas = append(schemas, p)
+	}
+	matched := len(schemas) > 0
+	for re, p := range s.PatternProperties {
+		if re.MatchString(name) {
+			schemas = append(schemas, p)
+			matched = true
+		}
+	}
+	if !matched {
+		if p, ok := s.AdditionalProperties.(*jsonschema.Schema); ok {
+			schemas = append(schemas, p)
+		}
+	}
+	return schemas
+}
+
+// documentChecks runs the checks ValidateBytes makes beyond the schema on
+// the parts of a document as they are decoded
+type documentChecks struct {
+	// ids holds the path of every section by id
+	ids           map[string][]string
+	tables        []located
+	dates         []ValidationError
+	relationships []ValidationError
+}
+
+// located is an error found in the block at path
+type located struct {
+	path string
+	err  ValidationError
+}
+
+// visit checks a decoded value at ptr, descending into it when it is part
+// of the document skeleton
+func (c *documentChecks) visit(v interface{}, ptr string) {
+	switch {
+	case ptr == "/metadata":
+		c.dates = append(c.dates, checkDates(map[string]interface{}{"metadata": v})...)
+	case ptr == "/relationships":
+		c.relationships = append(c.relationships, checkRelationships(map[string]interface{}{"relationships": v})...)
+	case sectionIDRe.MatchString(ptr):
+		if id, ok := v.(string); ok {
+			if c.ids == nil {
+				c.ids = map[string][]string{}
+			}
+			c.ids[id] = append(c.ids[id], strings.TrimSuffix(ptr, "/id"))
+		}
+	case blockRe.MatchString(ptr):
+		for _, e := range checkTable(v, ptr) {
+			c.tables = append(c.tables, located{path: ptr, err: e})
+		}
+	case skeletonRe.MatchString(ptr):
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for name, child := range v {
+				c.visit(child, ptr+"/"+escape(name))
+			}
+		case []interface{}:
+			for i, child := range v {
+				c.visit(child, ptr+"/"+strconv.Itoa(i))
+			}
+		}
+	}
+}
+
+// errors returns the errors found, in the order ValidateBytes reports them
+func (c *documentChecks) errors() []ValidationError {
+	type use struct{ id, path string }
+	var uses []use
+	for id, paths := range c.ids {
+		for _, path := range paths {
+			uses = append(uses, use{id, path})
+		}
+	}
+	sort.Slice(uses, func(i, j int) bool { return comparePointers(uses[i].path, uses[j].path) < 0 })
+
+	var errs []ValidationError
+	first := map[string]string{}
+	for _, u := range uses {
+		if path, dup := first[u.id]; dup {
+			errs = append(errs, duplicateSectionID(u.id, u.path, path))
+		} else {
+			first[u.id] = u.path
+		}
+	}
+
+	sort.SliceStable(c.tables, func(i, j int) bool { return comparePointers(c.tables[i].path, c.tables[j].path) < 0 })
+	for _, t := range c.tables {
+		errs = append(errs, t.err)
+	}
+	errs = append(errs, c.dates...)
+	return append(errs, c.relationships...)
+}
+
+// comparePointers orders JSON pointers depth first, comparing array
+// indexes as numbers, so that sections sort in document order
+func comparePointers(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+		if errX == nil && errY == nil {
+			return x - y
+		}
+		return strings.Compare(as[i], bs[i])
+	}
+	return len(as) - len(bs)
+}
+
+// hasType reports whether types contains t
+func hasType(types []string, t string) bool {
+	for _, item := range types {
+		if item == t {
+			return true
+		}
+	}
+	return false
+}
+
+// escape escapes a key for use in a JSON Pointer
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// quote quotes a property name the way schema errors do
+func quote(s string) string {
+	s = fmt.Sprintf("%q", s)
+	s = strings.ReplaceAll(s, `\"`, `"`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s[1:len(s)-1] + "'"
+}
@@ -0,0 +1,217 @@
+package validator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// loadDocumentSchema compiles the version 1 document schema
+func loadDocumentSchema(tb testing.TB) (*Validator, *jsonschema.Schema) {
+	tb.Helper()
+	v := New()
+	schema, err := v.LoadSchema(filepath.Join("..", "..", "schemas", "document-v1.json"))
+	if err != nil {
+		tb.Fatalf("Failed to load schema: %v", err)
+	}
+	return v, schema
+}
+
+// problems returns the located messages of a result, sorted, leaving out
+// the errors that only name the schema a value failed
+func problems(result *ValidationResult) []string {
+	var list []string
+	for _, e := range result.Errors {
+		if e.Message == "" || strings.HasPrefix(e.Message, "doesn't validate with") {
+			continue
+		}
+		list = append(list, e.Field+": "+e.Message)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func TestValidateReader(t *testing.T) {
+	v, schema := loadDocumentSchema(t)
+
+	const metadata = `"metadata": {"version": "1.0.0", "type": "receipt", "created": "2025-06-27T12:00:00Z", "title": "Receipt"}`
+	testCases := []struct {
+		name string
+		doc  string
+		// narrowed is set when only the oneOf alternative of the type of
+		// a value is reported
+		narrowed bool
+	}{
+		{name: "valid", doc: `{` + metadata + `, "content": {"sections": [{"id": "a", "title": "A", "content": "Text"}]}}`},
+		{name: "missing body", doc: `{` + metadata + `}`},
+		{name: "missing section fields", doc: `{` + metadata + `, "content": {"sections": [{"id": "a"}]}}`},
+		{name: "sections not an array", doc: `{` + metadata + `, "content": {"sections": {}}}`},
+		{name: "invalid block", doc: `{` + metadata + `, "content": {"sections": [{"id": "a", "title": "A", "content": [{"type": "paragraph"}]}]}}`, narrowed: true},
+		{name: "content of wrong type", doc: `{` + metadata + `, "content": {"sections": [{"id": "a", "title": "A", "content": 3}]}}`},
+		{name: "invalid metadata", doc: `{"metadata": {"version": "one", "type": "memo"}, "content": {"sections": []}}`},
+		{name: "duplicate ids", doc: `{` + metadata + `, "content": {"sections": [
+			{"sections": [{"id": "b", "title": "B", "content": "x"}], "id": "a", "title": "A", "content": "x"},
+			{"id": "b", "title": "B", "content": "x"},
+			{"id": "a", "title": "A", "content": "x"}]}}`},
+		{name: "table rows", doc: `{` + metadata + `, "content": {"sections": [{"id": "a", "title": "A", "content": [
+			{"type": "table", "columns": [{"title": "Item"}, {"title": "Price"}], "rows": [["Pen"], ["Ink", "2.00", "extra"]]}]}]}}`},
+		{name: "unordered dates", doc: `{"metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "C",
+			"effective": "2025-07-01", "expires": "2025-06-01"}, "content": {"sections": []}}`},
+		{name: "relationship cycles", doc: `{` + metadata + `, "content": {"sections": []}, "relationships": {
+			"dependencies": [{"source": "a", "target": "b", "type": "requires"}, {"source": "b", "target": "a", "type": "requires"}],
+			"conditions": [{"id": "x", "predicate": "late", "effect": "y"}, {"id": "y", "predicate": "due", "effect": "x"}]}}`},
+		{name: "not an object", doc: `[1, 2]`},
+		{name: "truncated", doc: `{` + metadata + `, "content": {"sections": [`},
+		{name: "trailing data", doc: `{` + metadata + `, "content": {"sections": []}} {}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			want, err := v.ValidateBytes([]byte(tc.doc), schema)
+			if err != nil {
+				t.Fatalf("ValidateBytes failed: %v", err)
+			}
+			got, err := v.ValidateReader(strings.NewReader(tc.doc), schema)
+			if err != nil {
+				t.Fatalf("ValidateReader failed: %v", err)
+			}
+			if got.Valid != want.Valid {
+				t.Fatalf("Expected valid=%v, got valid=%v: %v", want.Valid, got.Valid, got.Errors)
+			}
+			if strings.HasPrefix(tc.name, "truncated") || strings.HasPrefix(tc.name, "trailing") {
+				if len(got.Errors) != 1 || !strings.HasPrefix(got.Errors[0].Message, "Invalid JSON") {
+					t.Errorf("Expected an invalid JSON error, got %v", got.Errors)
+				}
+				return
+			}
+			w, g := problems(want), problems(got)
+			if tc.narrowed {
+				// The alternatives of other types are left out
+				reported := map[string]bool{}
+				for _, p := range w {
+					reported[p] = true
+				}
+				for _, p := range g {
+					if !reported[p] {
+						t.Errorf("Unexpected error %s", p)
+					}
+				}
+				if len(g) == 0 {
+					t.Error("Expected errors")
+				}
+				return
+			}
+			if fmt.Sprint(w) != fmt.Sprint(g) {
+				t.Errorf("Expected errors\n%s\ngot\n%s", strings.Join(w, "\n"), strings.Join(g, "\n"))
+			}
+			// Errors beyond the schema are reported in the same order
+			if !want.Valid && !strings.HasPrefix(want.Errors[0].Message, "doesn't validate with") {
+				if fmt.Sprint(want.Errors) != fmt.Sprint(got.Errors) {
+					t.Errorf("Expected %v, got %v", want.Errors, got.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateReaderExamples(t *testing.T) {
+	v, schema := loadDocumentSchema(t)
+	files, _ := filepath.Glob(filepath.Join("..", "..", "examples", "*.json"))
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("Failed to read example: %v", err)
+			}
+			want, _ := v.ValidateBytes(data, schema)
+			got, err := v.ValidateReader(bytes.NewReader(data), schema)
+			if err != nil {
+				t.Fatalf("ValidateReader failed: %v", err)
+			}
+			if got.Valid != want.Valid || fmt.Sprint(problems(want)) != fmt.Sprint(problems(got)) {
+				t.Errorf("Expected %v, got %v", want.Errors, got.Errors)
+			}
+		})
+	}
+}
+
+// largeReceipt returns a receipt with sections tables of rows line items
+// each
+func largeReceipt(sections, rows int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"metadata": {"version": "1.0.0", "type": "receipt", "created": "2025-06-27T12:00:00Z", "title": "Bulk order"},
+"content": {"sections": [`)
+	for s := 0; s < sections; s++ {
+		if s > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `{"id": "items-%d", "title": "Items %d", "content": [{"type": "table",
+"columns": [{"title": "Item"}, {"title": "Quantity", "key": "quantity"}, {"title": "Unit price", "key": "unitPrice"}, {"title": "Amount", "key": "amount"}],
+"rows": [`, s, s)
+		for r := 0; r < rows; r++ {
+			if r > 0 {
+				buf.WriteString(",")
+			}
+			fmt.Fprintf(&buf, `["Widget %d", "2", "1.25", "2.50"]`, r)
+		}
+		buf.WriteString("]}]}")
+	}
+	buf.WriteString("]}}")
+	return buf.Bytes()
+}
+
+func TestValidateReaderLarge(t *testing.T) {
+	v, schema := loadDocumentSchema(t)
+	doc := largeReceipt(50, 200)
+	want, _ := v.ValidateBytes(doc, schema)
+	got, err := v.ValidateReader(bytes.NewReader(doc), schema)
+	if err != nil {
+		t.Fatalf("ValidateReader failed: %v", err)
+	}
+	if !want.Valid || !got.Valid {
+		t.Errorf("Expected both to be valid, got %v and %v", want.Errors, got.Errors)
+	}
+}
+
+func benchmarkValidate(b *testing.B, stream bool) {
+	v, schema := loadDocumentSchema(b)
+	doc := largeReceipt(500, 200)
+	b.SetBytes(int64(len(doc)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var peak uint64
+	for i := 0; i < b.N; i++ {
+		var result *ValidationResult
+		var err error
+		if stream {
+			result, err = v.ValidateReader(bytes.NewReader(doc), schema)
+		} else {
+			result, err = v.ValidateBytes(doc, schema)
+		}
+		if err != nil || !result.Valid {
+			b.Fatalf("Validation failed: %v %v", err, result)
+		}
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		if m.HeapInuse > peak {
+			peak = m.HeapInuse
+		}
+	}
+	b.ReportMetric(float64(peak)/(1<<20), "heap-MB")
+}
+
+func BenchmarkValidateBytes(b *testing.B) {
+	benchmarkValidate(b, false)
+}
+
+func BenchmarkValidateReader(b *testing.B) {
+	benchmarkValidate(b, true)
+}
@@ -1,14 +1,24 @@
 package validator
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/fatih/color"
+	"github.com/colemalphrus/nld/internal/colors"
+	"github.com/colemalphrus/nld/internal/document"
+	"github.com/colemalphrus/nld/internal/metrics"
+	"github.com/colemalphrus/nld/internal/tracing"
+	"github.com/colemalphrus/nld/pkg/nld"
 	"github.com/santhosh-tekuri/jsonschema/v5"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Validator is responsible for validating NLD documents against schemas
@@ -22,6 +32,10 @@ type ValidationResult struct {
 	Valid    bool
 	Errors   []ValidationError
 	Warnings []ValidationWarning
+	// Tree holds the schema errors of Errors as a tree, each with the
+	// errors that caused it, such as the failed branches of a oneOf. It is
+	// set by ValidateBytes only.
+	Tree []ValidationError `json:",omitempty"`
 }
 
 // ValidationError represents a validation error with location information
@@ -30,6 +44,17 @@ type ValidationError struct {
 	Message string
 	Line    int
 	Column  int
+	// KeywordLocation is the JSON pointer of the schema keyword that
+	// failed, within its schema file
+	KeywordLocation string `json:",omitempty"`
+	// Rule names the check that failed: the schema keyword, such as
+	// required, or one of the Rule constants. Errors that only sum up
+	// the errors they were caused by have none.
+	Rule string `json:",omitempty"`
+	// Causes are the errors that caused this one, in Tree only
+	Causes []ValidationError `json:",omitempty"`
+	// Fixes are changes to the document suggested to resolve the error
+	Fixes []Fix `json:",omitempty"`
 }
 
 // ValidationWarning represents a validation warning
@@ -70,36 +95,294 @@ func (v *Validator) ValidateDocument(docPath, schemaPath string) (*ValidationRes
 
 // ValidateBytes validates a JSON document provided as bytes against a schema
 func (v *Validator) ValidateBytes(docBytes []byte, schema *jsonschema.Schema) (*ValidationResult, error) {
-	// Parse the document to get a Go value
+	return v.ValidateBytesContext(context.Background(), docBytes, schema)
+}
+
+// ValidateBytesContext is ValidateBytes traced as a child of the span of
+// ctx
+func (v *Validator) ValidateBytesContext(ctx context.Context, docBytes []byte, schema *jsonschema.Schema) (*ValidationResult, error) {
+	start := time.Now()
+	ctx, span := tracing.Span(ctx, "nld.validate", attribute.Int("nld.document.size", len(docBytes)))
 	var doc interface{}
-	err := json.Unmarshal(docBytes, &doc)
+	result, err := v.validateBytes(ctx, docBytes, &doc, schema)
+	
+	// Only known types are used as labels, to keep the number of series
+	// down
+	docType := "other"
+	if root, ok := doc.(map[string]interface{}); ok {
+		if meta, ok := root["metadata"].(map[string]interface{}); ok {
+			if t, ok := meta["type"].(string); ok && schemaFiles[strings.ToLower(t)] != "" {
+				docType = strings.ToLower(t)
+			}
+		}
+	}
+	switch {
+	case err != nil:
+		metrics.Validations.Inc(docType, metrics.Error)
+	case result.Valid:
+		metrics.Validations.Inc(docType, metrics.Valid)
+	default:
+		metrics.Validations.Inc(docType, metrics.Invalid)
+	}
+	metrics.ValidationDuration.Since(start, docType)
+	span.SetAttributes(attribute.String("nld.document.type", docType))
+	if result != nil {
+		span.SetAttributes(attribute.Bool("nld.valid", result.Valid), attribute.Int("nld.errors", len(result.Errors)))
+	}
+	tracing.End(span, err)
+	return result, err
+}
+
+// validateBytes validates a document, parsing it into doc
+func (v *Validator) validateBytes(ctx context.Context, docBytes []byte, docPtr *interface{}, schema *jsonschema.Schema) (*ValidationResult, error) {
+	// Parse the document to get a Go value
+	_, span := tracing.Span(ctx, "nld.validate.parse")
+	err := json.Unmarshal(docBytes, docPtr)
+	span.End()
+	doc := *docPtr
 	if err != nil {
-		return &ValidationResult{
-			Valid: false,
-			Errors: []ValidationError{
-				{
-					Field:   "",
-					Message: fmt.Sprintf("Invalid JSON: %v", err),
-					Line:    findErrorLine(err, string(docBytes)),
-					Column:  findErrorColumn(err),
-				},
-			},
-		}, nil
+		return invalidJSON(err, docBytes), nil
 	}
 
 	// Validate against the schema
+	_, span = tracing.Span(ctx, "nld.validate.schema")
 	err = schema.Validate(doc)
+	span.End()
 	if err != nil {
 		// Convert validation errors to our format
+		tree := errorTree(err, &fixer{schema: schema, doc: doc})
 		return &ValidationResult{
 			Valid:  false,
-			Errors: convertValidationErrors(err),
+			Errors: flattenErrors(tree),
+			Tree:   tree,
+		}, nil
+	}
+
+	// Section IDs must be unique across the whole section tree, table rows
+	// must match their columns, date ranges must be ordered, relationships
+	// must not be circular and signers must be entities, which the schema
+	// cannot express
+	_, span = tracing.Span(ctx, "nld.validate.rules")
+	errs := append(checkSectionIDs(doc), checkTables(doc)...)
+	errs = append(errs, checkDates(doc)...)
+	errs = append(errs, checkRelationships(doc)...)
+	errs = append(errs, checkSigners(doc)...)
+	span.End()
+	if len(errs) > 0 {
+		return &ValidationResult{
+			Valid:  false,
+			Errors: errs,
 		}, nil
 	}
 
 	return &ValidationResult{Valid: true}, nil
 }
 
+// CheckSyntax returns the result of a document that is not valid JSON,
+// with the line and column of the error, or nil when data is valid JSON.
+// It lets callers report syntax errors before anything else, such as the
+// schema of the document, is worked out from data.
+func CheckSyntax(data []byte) *ValidationResult {
+	// Valid documents are only scanned, not decoded, which keeps large
+	// documents from being held in memory
+	if json.Valid(data) {
+		return nil
+	}
+	var doc interface{}
+	return invalidJSON(json.Unmarshal(data, &doc), data)
+}
+
+// invalidJSON returns the result of a document that failed to decode
+func invalidJSON(err error, data []byte) *ValidationResult {
+	line, column := document.ErrorPosition(err, data)
+	return &ValidationResult{
+		Valid: false,
+		Errors: []ValidationError{
+			{
+				Field:   "",
+				Message: fmt.Sprintf("Invalid JSON: %v", err),
+				Line:    line,
+				Column:  column,
+				Rule:    RuleJSON,
+			},
+		},
+	}
+}
+
+// checkDates reports metadata dates and durations that do not parse or
+// form an unordered range
+func checkDates(doc interface{}) []ValidationError {
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	raw, ok := root["metadata"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var meta nld.Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil
+	}
+
+	var errs []ValidationError
+	for _, e := range meta.CheckDates() {
+		errs = append(errs, ValidationError{Field: "/metadata/" + e.Field, Message: e.Message, Rule: RuleDates})
+	}
+	return errs
+}
+
+// checkRelationships reports dependency cycles and circular or
+// contradictory conditions
+func checkRelationships(doc interface{}) []ValidationError {
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	raw, ok := root["relationships"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var rel nld.Relationships
+	if err := json.Unmarshal(data, &rel); err != nil {
+		return nil
+	}
+
+	var errs []ValidationError
+	for _, e := range rel.CheckRelationships() {
+		errs = append(errs, ValidationError{Field: "/relationships/" + e.Field, Message: e.Message, Rule: RuleRelationships})
+	}
+	return errs
+}
+
+// checkSigners reports signatures by someone other than an entity that
+// signs, entities signing twice and keys not registered for their entity
+func checkSigners(doc interface{}) []ValidationError {
+	root, ok := doc.(map[string]interface{})
+	if !ok || root["verification"] == nil {
+		return nil
+	}
+	data, err := json.Marshal(root)
+	if err != nil {
+		return nil
+	}
+	var d nld.Document
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil
+	}
+
+	var errs []ValidationError
+	for _, e := range d.CheckSigners() {
+		errs = append(errs, ValidationError{Field: "/verification/" + e.Field, Message: e.Message, Rule: RuleSigners})
+	}
+	return errs
+}
+
+// walkSections calls fn for every section of a document, including nested
+// subsections, with the JSON pointer of the section
+func walkSections(doc interface{}, fn func(section map[string]interface{}, path string)) {
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	var walk func(sections interface{}, location string)
+	walk = func(sections interface{}, location string) {
+		list, _ := sections.([]interface{})
+		for i, item := range list {
+			section, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			path := fmt.Sprintf("%s/%d", location, i)
+			fn(section, path)
+			walk(section["sections"], path+"/sections")
+		}
+	}
+
+	for _, key := range []string{"content", "structure"} {
+		if body, ok := root[key].(map[string]interface{}); ok {
+			walk(body["sections"], "/"+key+"/sections")
+		}
+	}
+}
+
+// checkSectionIDs reports section IDs, including those of nested
+// subsections, that are used more than once
+func checkSectionIDs(doc interface{}) []ValidationError {
+	var errs []ValidationError
+	seen := map[string]string{}
+	walkSections(doc, func(section map[string]interface{}, path string) {
+		id, ok := section["id"].(string)
+		if !ok {
+			return
+		}
+		if first, dup := seen[id]; dup {
+			errs = append(errs, duplicateSectionID(id, path, first))
+		} else {
+			seen[id] = path
+		}
+	})
+	return errs
+}
+
+// duplicateSectionID reports the section at path reusing the id of the
+// section at first
+func duplicateSectionID(id, path, first string) ValidationError {
+	return ValidationError{
+		Field:   path + "/id",
+		Message: fmt.Sprintf("duplicate section id %q (first used at %s)", id, first),
+		Rule:    RuleSectionIDs,
+	}
+}
+
+// checkTables reports table blocks whose rows do not match their columns
+func checkTables(doc interface{}) []ValidationError {
+	var errs []ValidationError
+	walkSections(doc, func(section map[string]interface{}, path string) {
+		blocks, _ := section["content"].([]interface{})
+		for i, raw := range blocks {
+			errs = append(errs, checkTable(raw, fmt.Sprintf("%s/content/%d", path, i))...)
+		}
+	})
+	return errs
+}
+
+// checkTable reports the rows of a table block at location that do not
+// match its columns. Blocks of other types are ignored.
+func checkTable(raw interface{}, location string) []ValidationError {
+	obj, ok := raw.(map[string]interface{})
+	if !ok || obj["type"] != nld.BlockTable {
+		return nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+	var block nld.Block
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil
+	}
+
+	var errs []ValidationError
+	for _, e := range block.CheckTable() {
+		field := fmt.Sprintf("%s/rows/%d/%d", location, e.Row, e.Column)
+		if e.Row < 0 {
+			field = fmt.Sprintf("%s/columns/%d", location, e.Column)
+		}
+		errs = append(errs, ValidationError{Field: field, Message: e.Message, Rule: RuleTables})
+	}
+	return errs
+}
+
 // ValidateString validates a JSON document provided as a string against a schema
 func (v *Validator) ValidateString(docString, schemaString string) (*ValidationResult, error) {
 	// Load the schema from string
@@ -153,98 +436,78 @@ func (v *Validator) loadSchemaFromString(schemaString string) (*jsonschema.Schem
 	return schema, nil
 }
 
-// convertValidationErrors converts jsonschema validation errors to our format
+// convertValidationErrors converts jsonschema validation errors to our
+// format, as a list in which each error is followed by its causes
 func convertValidationErrors(err error) []ValidationError {
-	var result []ValidationError
+	return flattenErrors(errorTree(err, nil))
+}
 
-	if ve, ok := err.(*jsonschema.ValidationError); ok {
-		// Process the basic error
-		result = append(result, ValidationError{
-			Field:   ve.InstanceLocation, // Use InstanceLocation instead of InstancePtr
-			Message: ve.Message,
-			Line:    0, // We don't have line information from the library
-			Column:  0,
-		})
-
-		// Process any sub-errors
-		for _, subErr := range ve.Causes {
-			result = append(result, convertValidationErrors(subErr)...)
-		}
+// errorTree converts jsonschema validation errors to our format, keeping
+// the causes of each error, with the fixes f suggests
+func errorTree(err error, f *fixer) []ValidationError {
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil
 	}
-
-	return result
+	return []ValidationError{convertValidationError(ve, f)}
 }
 
-// findErrorLine attempts to find the line number where a JSON parsing error occurred
-func findErrorLine(err error, content string) int {
-	errMsg := err.Error()
-	
-	// Try to extract line information from the error message
-	if strings.Contains(errMsg, "line") {
-		// This is a simplistic approach and might need refinement
-		parts := strings.Split(errMsg, "line")
-		if len(parts) > 1 {
-			var lineNum int
-			_, err := fmt.Sscanf(parts[1], " %d", &lineNum)
-			if err == nil {
-				return lineNum
-			}
-		}
+// convertValidationError converts a jsonschema validation error and its
+// causes. Causes are sorted by location, as the library reports those of
+// sibling properties in no particular order.
+func convertValidationError(ve *jsonschema.ValidationError, f *fixer) ValidationError {
+	e := ValidationError{
+		Field:           ve.InstanceLocation,
+		Message:         ve.Message,
+		KeywordLocation: ve.AbsoluteKeywordLocation,
+		Fixes:           f.fixes(ve),
 	}
-	
-	// If we can't extract from the error message, count lines up to the offset
-	if strings.Contains(errMsg, "offset") {
-		var offset int
-		_, err := fmt.Sscanf(errMsg, "invalid character '%c' at offset %d", new(rune), &offset)
-		if err == nil {
-			// Count newlines up to the offset
-			return strings.Count(content[:min(offset, len(content))], "\n") + 1
-		}
+	if i := strings.Index(e.KeywordLocation, "#"); i >= 0 {
+		e.KeywordLocation = e.KeywordLocation[i+1:]
 	}
-	
-	return 0
-}
-
-// findErrorColumn attempts to find the column number where a JSON parsing error occurred
-func findErrorColumn(err error) int {
-	errMsg := err.Error()
-	
-	// Try to extract column information from the error message
-	if strings.Contains(errMsg, "column") {
-		parts := strings.Split(errMsg, "column")
-		if len(parts) > 1 {
-			var colNum int
-			_, err := fmt.Sscanf(parts[1], " %d", &colNum)
-			if err == nil {
-				return colNum
-			}
-		}
+	e.Rule = e.KeywordLocation[strings.LastIndex(e.KeywordLocation, "/")+1:]
+	for _, cause := range ve.Causes {
+		e.Causes = append(e.Causes, convertValidationError(cause, f))
 	}
-	
-	// If we can't extract from the error message, try to get the offset
-	if strings.Contains(errMsg, "offset") {
-		var offset int
-		_, err := fmt.Sscanf(errMsg, "invalid character '%c' at offset %d", new(rune), &offset)
-		if err == nil {
-			// Find the last newline before the offset
-			content := err.Error()
-			lastNewline := strings.LastIndex(content[:min(offset, len(content))], "\n")
-			if lastNewline == -1 {
-				return offset + 1 // 1-based column indexing
-			}
-			return offset - lastNewline
+	sort.SliceStable(e.Causes, func(i, j int) bool {
+		a, b := e.Causes[i], e.Causes[j]
+		if a.Field != b.Field {
+			return lessPointer(a.Field, b.Field)
 		}
+		return lessPointer(a.KeywordLocation, b.KeywordLocation)
+	})
+	return e
+}
+
+// flattenErrors lists a tree of errors, each followed by its causes,
+// without their causes
+func flattenErrors(tree []ValidationError) []ValidationError {
+	var result []ValidationError
+	for _, e := range tree {
+		causes := e.Causes
+		e.Causes = nil
+		result = append(result, e)
+		result = append(result, flattenErrors(causes)...)
 	}
-	
-	return 0
+	return result
 }
 
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
+// lessPointer orders JSON pointers by their tokens, comparing array
+// indexes as numbers
+func lessPointer(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		if aerr == nil && berr == nil {
+			return an < bn
+		}
+		return as[i] < bs[i]
 	}
-	return b
+	return len(as) < len(bs)
 }
 
 // Validate validates an NLD document against its schema
@@ -259,17 +522,57 @@ func (v *Validator) LoadSchema(schemaPath string) (*jsonschema.Schema, error) {
 	return v.loadSchema(schemaPath)
 }
 
+// LoadSchemaBytes compiles a schema held in memory. Name identifies the
+// schema in error messages, such as schemas/document-v1.json.
+func (v *Validator) LoadSchemaBytes(name string, data []byte) (*jsonschema.Schema, error) {
+	url := "embedded:///" + strings.TrimPrefix(name, "/")
+	if err := v.compiler.AddResource(url, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to load schema: %w", err)
+	}
+	schema, err := v.compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+	return schema, nil
+}
+
+// SchemaFile returns the schema file of a document type, relative to the
+// executable. Types without a schema of their own use the default
+// document schema.
+func SchemaFile(docType string) string {
+	if file, ok := schemaFiles[strings.ToLower(docType)]; ok {
+		return file
+	}
+	return defaultSchemaFile
+}
+
+// defaultSchemaFile is the schema of document types without one of their
+// own
+const defaultSchemaFile = "schemas/document-v1.json"
+
+// schemaFiles maps document types to their schema files, relative to the
+// executable
+var schemaFiles = map[string]string{
+	"contract":  "schemas/document-v1.json",
+	"receipt":   "schemas/document-v1.json",
+	"agreement": "schemas/document-v1.json",
+	"nda":       "schemas/nda.schema.json",
+}
+
+// DocumentTypes returns the document types that have a schema, in sorted
+// order
+func DocumentTypes() []string {
+	var types []string
+	for t := range schemaFiles {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
 // GetSchemaForDocumentType returns the appropriate schema for a given document type
 func (v *Validator) GetSchemaForDocumentType(docType string) (string, error) {
-	// Map document types to schema files
-	schemaMap := map[string]string{
-		"contract":  "schemas/document-v1.json",
-		"receipt":   "schemas/document-v1.json",
-		"agreement": "schemas/document-v1.json",
-		"nda":       "schemas/nda.schema.json",
-	}
-	
-	schemaPath, ok := schemaMap[strings.ToLower(docType)]
+	schemaPath, ok := schemaFiles[strings.ToLower(docType)]
 	if !ok {
 		return "", fmt.Errorf("no schema available for document type: %s", docType)
 	}
@@ -287,13 +590,11 @@ func (v *Validator) GetSchemaForDocumentType(docType string) (string, error) {
 // FormatValidationResult formats a validation result as a human-readable string
 func FormatValidationResult(result *ValidationResult) string {
 	if result.Valid {
-		green := color.New(color.FgGreen).SprintFunc()
-		return green("Document is valid.")
+		return colors.Success("Document is valid.")
 	}
 	
 	var sb strings.Builder
-	red := color.New(color.FgRed).SprintFunc()
-	sb.WriteString(red("Document validation failed:\n"))
+	sb.WriteString(colors.Error("Document validation failed:\n"))
 	
 	for i, err := range result.Errors {
 		sb.WriteString(fmt.Sprintf("%d. %s", i+1, err.Message))
@@ -313,12 +614,11 @@ func FormatValidationResult(result *ValidationResult) string {
 	return sb.String()
 }
 
-// ColoredOutput returns colored output for validation results
+// ColoredOutput returns colored output for validation results, in the
+// colors of the theme when colors are on
 func ColoredOutput(valid bool, message string) string {
 	if valid {
-		green := color.New(color.FgGreen).SprintFunc()
-		return green(message)
+		return colors.Success(message)
 	}
-	red := color.New(color.FgRed).SprintFunc()
-	return red(message)
+	return colors.Error(message)
 }
\ No newline at end of file
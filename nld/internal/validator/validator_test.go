@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -192,4 +193,211 @@ func TestValidateBytes(t *testing.T) {
 // Helper function to check if a string contains a substring
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
-}
\ No newline at end of file
+}
+func TestValidateNestedSections(t *testing.T) {
+	v := New()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	schemaPath := filepath.Join(wd, "..", "..", "schemas", "document-v1.json")
+	schema, err := v.LoadSchema(schemaPath)
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		sections    string
+		expectValid bool
+		expectField string
+	}{
+		{
+			name:        "Nested Sections",
+			sections:    `[{"id": "a", "title": "A", "content": "", "sections": [{"id": "a1", "title": "A1", "content": "x", "sections": [{"id": "a1x", "title": "A1x", "content": "y"}]}]}]`,
+			expectValid: true,
+		},
+		{
+			name:        "Nested Section Missing Title",
+			sections:    `[{"id": "a", "title": "A", "content": "", "sections": [{"id": "a1", "content": "x"}]}]`,
+			expectValid: false,
+		},
+		{
+			name:        "Content Blocks",
+			sections:    `[{"id": "a", "title": "A", "content": [{"type": "paragraph", "text": "x"}, {"type": "list", "ordered": true, "items": ["one", "two"]}, {"type": "table", "header": ["Item", "Price"], "rows": [["Widget", "10"]]}, {"type": "definitionRef", "term": "Widget"}]}]`,
+			expectValid: true,
+		},
+		{
+			name:        "Unknown Block Type",
+			sections:    `[{"id": "a", "title": "A", "content": [{"type": "image", "src": "x.png"}]}]`,
+			expectValid: false,
+		},
+		{
+			name:        "List Block Without Items",
+			sections:    `[{"id": "a", "title": "A", "content": [{"type": "list"}]}]`,
+			expectValid: false,
+		},
+		{
+			name:        "Table Row Mismatch",
+			sections:    `[{"id": "a", "title": "A", "content": [{"type": "table", "columns": [{"title": "Item"}, {"title": "Price", "type": "number", "total": true}], "rows": [["Widget", "10.00"], ["Gadget"]]}]}]`,
+			expectValid: false,
+			expectField: "/content/sections/0/content/0/rows/1/1",
+		},
+		{
+			name:        "Table Cell Type",
+			sections:    `[{"id": "a", "title": "A", "content": [{"type": "table", "columns": [{"title": "Item"}, {"title": "Price", "type": "number"}], "rows": [["Widget", "ten"]]}]}]`,
+			expectValid: false,
+			expectField: "/content/sections/0/content/0/rows/0/1",
+		},
+		{
+			name:        "Duplicate Nested ID",
+			sections:    `[{"id": "a", "title": "A", "content": ""}, {"id": "b", "title": "B", "content": "", "sections": [{"id": "a", "title": "A again", "content": ""}]}]`,
+			expectValid: false,
+			expectField: "/content/sections/1/sections/0/id",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := []byte(`{
+				"metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Test"},
+				"content": {"sections": ` + tc.sections + `}
+			}`)
+
+			result, err := v.ValidateBytes(doc, schema)
+			if err != nil {
+				t.Fatalf("Validation failed with error: %v", err)
+			}
+			if result.Valid != tc.expectValid {
+				t.Fatalf("Expected valid=%v, got %v: %v", tc.expectValid, result.Valid, result.Errors)
+			}
+			if tc.expectField != "" && (len(result.Errors) == 0 || result.Errors[0].Field != tc.expectField) {
+				t.Errorf("Expected error at %s, got %v", tc.expectField, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateMoney(t *testing.T) {
+	v := New()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	schema, err := v.LoadSchema(filepath.Join(wd, "..", "..", "schemas", "document-v1.json"))
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		totals      string
+		expectValid bool
+	}{
+		{name: "Decimal Strings", totals: `{"currency": "USD", "subtotal": "10.00", "total": "10.00"}`, expectValid: true},
+		{name: "Money Objects", totals: `{"subtotal": {"amount": "10.00", "currency": "EUR"}, "total": {"amount": "10.00", "currency": "EUR"}}`, expectValid: true},
+		{name: "Float Amount", totals: `{"currency": "USD", "subtotal": 10.1, "total": "10.10"}`, expectValid: false},
+		{name: "Missing Currency", totals: `{"subtotal": {"amount": "10.00"}, "total": "10.00"}`, expectValid: false},
+		{name: "Lowercase Currency", totals: `{"currency": "usd", "subtotal": "10.00", "total": "10.00"}`, expectValid: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := []byte(`{
+				"metadata": {"version": "1.0.0", "type": "receipt", "created": "2025-06-27T12:00:00Z", "title": "Test"},
+				"content": {"sections": [], "totals": ` + tc.totals + `}
+			}`)
+
+			result, err := v.ValidateBytes(doc, schema)
+			if err != nil {
+				t.Fatalf("Validation failed with error: %v", err)
+			}
+			if result.Valid != tc.expectValid {
+				t.Errorf("Expected valid=%v, got %v: %v", tc.expectValid, result.Valid, result.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateBytesErrorPosition(t *testing.T) {
+	result, err := New().ValidateBytes([]byte("{\r\n  \"metadata\": {\r\n    \"title\": \"\u00e9t\u00e9\" \"x\"\r\n}"), nil)
+	if err != nil {
+		t.Fatalf("Validation failed with error: %v", err)
+	}
+	if result.Valid || result.Errors[0].Line != 3 || result.Errors[0].Column != 20 {
+		t.Errorf("Expected invalid JSON at 3:20, got %+v", result.Errors)
+	}
+}
+
+func TestValidateBytesErrorTree(t *testing.T) {
+	v := New()
+	schema, err := v.LoadSchema(filepath.Join("..", "..", "schemas", "document-v1.json"))
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	doc := []byte(`{
+		"metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Test"},
+		"content": {"sections": [{"id": "a", "title": "A", "content": 5}]}
+	}`)
+	result, err := v.ValidateBytes(doc, schema)
+	if err != nil {
+		t.Fatalf("Validation failed with error: %v", err)
+	}
+	if result.Valid || len(result.Tree) != 1 {
+		t.Fatalf("Expected an error tree, got %+v", result)
+	}
+
+	// The branches of the oneOf are the causes of its error
+	section := result.Tree[0].Causes[0]
+	if section.Field != "/content/sections/0" || len(section.Causes) != 1 {
+		t.Fatalf("Expected the section error, got %+v", section)
+	}
+	oneOf := section.Causes[0]
+	if oneOf.KeywordLocation != "/definitions/section/properties/content/oneOf" || len(oneOf.Causes) != 2 {
+		t.Fatalf("Expected the oneOf error, got %+v", oneOf)
+	}
+	for i, branch := range oneOf.Causes {
+		if want := fmt.Sprintf("%s/%d/type", oneOf.KeywordLocation, i); branch.KeywordLocation != want {
+			t.Errorf("Expected branch %d at %s, got %s", i, want, branch.KeywordLocation)
+		}
+	}
+
+	// Errors lists the tree flattened
+	if len(result.Errors) != 5 {
+		t.Fatalf("Expected 5 errors, got %+v", result.Errors)
+	}
+	for _, e := range result.Errors {
+		if len(e.Causes) > 0 {
+			t.Errorf("Expected no causes in Errors, got %+v", e)
+		}
+	}
+	if result.Errors[3].Message != oneOf.Causes[0].Message {
+		t.Errorf("Expected the first branch after the oneOf, got %+v", result.Errors[3])
+	}
+}
+
+func TestValidateSigners(t *testing.T) {
+	v := New()
+	wd, _ := os.Getwd()
+	schema, err := v.LoadSchema(filepath.Join(wd, "..", "..", "schemas", "document-v1.json"))
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	doc := []byte(`{
+  "metadata": {"version": "1.0.0", "type": "contract", "created": "2025-06-27T12:00:00Z", "title": "Test",
+    "entities": [{"id": "provider", "name": "ABC Corp", "role": "Service Provider"}]},
+  "content": {"sections": [{"id": "a", "title": "A", "content": "x"}]},
+  "verification": {"signatures": [{"signerId": "client", "date": "2025-06-28", "value": "sig"}]}
+}`)
+	result, err := v.ValidateBytes(doc, schema)
+	if err != nil {
+		t.Fatalf("Validation failed with error: %v", err)
+	}
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Rule != RuleSigners || result.Errors[0].Field != "/verification/signatures/0/signerId" {
+		t.Errorf("Expected an unknown signer error, got %+v", result.Errors)
+	}
+}
@@ -0,0 +1,319 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Event types
+const (
+	// Validated is sent when a document is validated, valid or not
+	Validated = "document.validated"
+	// Signed is sent when the digest of a document is recorded
+	Signed = "document.signed"
+	// Stored is sent when a document is stored, with its store.Info
+	Stored = "document.stored"
+	// Deleted is sent when a document is deleted from the store
+	Deleted = "document.deleted"
+	// Approval is sent when a stored document is approved, with the state
+	// of its workflow
+	Approval = "document.approval"
+	// Approved is sent when the last approval a stored document needs is
+	// given, with the state of its workflow
+	Approved = "document.approved"
+)
+
+// Headers of a delivery
+const (
+	// EventHeader holds the event type
+	EventHeader = "NLD-Event"
+	// DeliveryHeader holds the event ID, the same for every attempt
+	DeliveryHeader = "NLD-Delivery"
+	// TimestampHeader holds the Unix time the delivery was signed at
+	TimestampHeader = "NLD-Timestamp"
+	// SignatureHeader holds sha256= and the HMAC-SHA256 of the timestamp,
+	// a dot and the body, keyed with the secret of the webhook
+	SignatureHeader = "NLD-Signature"
+)
+
+// Events returns the event types
+func Events() []string {
+	return []string{Validated, Signed, Stored, Deleted, Approval, Approved}
+}
+
+// Hook is a URL notified of events
+type Hook struct {
+	URL string `yaml:"url"`
+	// Secret signs the deliveries; SecretEnv names an environment variable
+	// holding it instead, to keep it out of the config file
+	Secret    string `yaml:"secret"`
+	SecretEnv string `yaml:"secret-env"`
+	// Events are the event types sent, all of them when empty
+	Events []string `yaml:"events"`
+}
+
+// wants reports whether the hook is sent events of type typ
+func (h Hook) wants(typ string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if e == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is a webhooks config file:
+//
+//	webhooks:
+//	  - url: https://crm.example.com/hooks/nld
+//	    secret-env: CRM_WEBHOOK_SECRET
+//	    events: [document.signed, document.stored]
+type Config struct {
+	Webhooks []Hook `yaml:"webhooks"`
+}
+
+// Load reads a webhooks config file, resolving secrets from the
+// environment
+func Load(path string) ([]Hook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhooks config: %w", err)
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid webhooks config %s: %w", path, err)
+	}
+	known := map[string]bool{}
+	for _, e := range Events() {
+		known[e] = true
+	}
+	for i := range c.Webhooks {
+		h := &c.Webhooks[i]
+		if !strings.HasPrefix(h.URL, "http://") && !strings.HasPrefix(h.URL, "https://") {
+			return nil, fmt.Errorf("invalid webhooks config %s: webhook %d: invalid URL %q", path, i+1, h.URL)
+		}
+		for _, e := range h.Events {
+			if !known[e] {
+				return nil, fmt.Errorf("invalid webhooks config %s: webhook %d: unknown event %q (available: %s)", path, i+1, e, strings.Join(Events(), ", "))
+			}
+		}
+		if h.SecretEnv != "" {
+			if h.Secret = os.Getenv(h.SecretEnv); h.Secret == "" {
+				return nil, fmt.Errorf("invalid webhooks config %s: webhook %d: %s is not set", path, i+1, h.SecretEnv)
+			}
+		}
+	}
+	return c.Webhooks, nil
+}
+
+// Event is the payload of a delivery
+type Event struct {
+	ID   string      `json:"id"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Options configures a Dispatcher
+type Options struct {
+	// HTTPClient sends the deliveries; defaults to a client with a 10
+	// second timeout
+	HTTPClient *http.Client
+	// Retries is the number of times a failed delivery is retried;
+	// defaults to 5, and negative values disable retries
+	Retries int
+	// Backoff is the wait before the first retry, doubled for every
+	// further retry; defaults to 1 second
+	Backoff time.Duration
+	// Log receives failed deliveries; nil discards them
+	Log *slog.Logger
+}
+
+// Dispatcher delivers events to webhooks in the background. Deliveries
+// that fail with a network error or a status of 429 or 5xx are retried
+// with exponential backoff.
+type Dispatcher struct {
+	hooks []Hook
+	opts  Options
+	ctx   context.Context
+	stop  context.CancelFunc
+	wg    sync.WaitGroup
+}
+
+// New returns a dispatcher for hooks
+func New(hooks []Hook, opts Options) *Dispatcher {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.Retries == 0 {
+		opts.Retries = 5
+	} else if opts.Retries < 0 {
+		opts.Retries = 0
+	}
+	if opts.Backoff == 0 {
+		opts.Backoff = time.Second
+	}
+	if opts.Log == nil {
+		opts.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &Dispatcher{hooks: hooks, opts: opts, ctx: ctx, stop: stop}
+}
+
+// Send delivers an event of type typ to every hook that wants it, without
+// waiting for the deliveries
+func (d *Dispatcher) Send(typ string, data interface{}) {
+	if d == nil {
+		return
+	}
+	event := Event{ID: newID(), Type: typ, Time: time.Now().UTC(), Data: data}
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.opts.Log.Error("failed to encode webhook event", "type", typ, "error", err)
+		return
+	}
+	for _, h := range d.hooks {
+		if h.wants(typ) {
+			d.wg.Add(1)
+			go func(h Hook) {
+				defer d.wg.Done()
+				d.deliver(h, event, body)
+			}(h)
+		}
+	}
+}
+
+// Close waits for pending deliveries until ctx is done, then abandons the
+// rest
+func (d *Dispatcher) Close(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// deliver posts an event to a hook, retrying transient failures
+func (d *Dispatcher) deliver(h Hook, event Event, body []byte) {
+	wait := d.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		err := d.post(h, event, body)
+		if err == nil {
+			return
+		}
+		retry, ok := err.(*retryable)
+		if !ok || attempt >= d.opts.Retries {
+			d.opts.Log.Error("webhook delivery failed", "url", h.URL, "event", event.Type, "id", event.ID, "attempts", attempt+1, "error", err)
+			return
+		}
+		if retry.after > 0 {
+			wait = retry.after
+		}
+		d.opts.Log.Debug("retrying webhook delivery", "url", h.URL, "event", event.Type, "id", event.ID, "wait", wait, "error", err)
+		select {
+		case <-time.After(wait):
+		case <-d.ctx.Done():
+			d.opts.Log.Error("webhook delivery abandoned", "url", h.URL, "event", event.Type, "id", event.ID)
+			return
+		}
+		wait *= 2
+	}
+}
+
+// retryable is a delivery failure worth retrying
+type retryable struct {
+	err error
+	// after is the wait asked for by a Retry-After header
+	after time.Duration
+}
+
+func (r *retryable) Error() string { return r.err.Error() }
+
+// post makes one delivery attempt
+func (d *Dispatcher) post(h Hook, event Event, body []byte) error {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nld-webhook")
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(DeliveryHeader, event.ID)
+	req.Header.Set(TimestampHeader, timestamp)
+	if h.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(h.Secret, timestamp, body))
+	}
+
+	resp, err := d.opts.HTTPClient.Do(req)
+	if err != nil {
+		return &retryable{err: err}
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		r := &retryable{err: fmt.Errorf("status %d", resp.StatusCode)}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			r.after = time.Duration(seconds) * time.Second
+		}
+		return r
+	default:
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+}
+
+// Sign returns the signature header value of a delivery body sent at
+// timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of a delivery body
+// sent at timestamp, for receivers written in Go. Receivers should also
+// reject old timestamps to prevent replays.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// newID returns a random event ID
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
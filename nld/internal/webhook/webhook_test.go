@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcher(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	var events []Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		if !Verify("s3cret", r.Header.Get(TimestampHeader), body, r.Header.Get(SignatureHeader)) {
+			t.Errorf("delivery has a bad signature %q", r.Header.Get(SignatureHeader))
+		}
+		// The first attempt fails and is retried
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var e Event
+		json.Unmarshal(body, &e)
+		if r.Header.Get(EventHeader) != e.Type || r.Header.Get(DeliveryHeader) != e.ID {
+			t.Errorf("delivery headers = %v", r.Header)
+		}
+		events = append(events, e)
+	}))
+	defer server.Close()
+
+	d := New([]Hook{
+		{URL: server.URL, Secret: "s3cret", Events: []string{Stored}},
+	}, Options{Backoff: time.Millisecond})
+	d.Send(Validated, map[string]bool{"valid": true})
+	d.Send(Stored, map[string]string{"id": "lease"})
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if attempts != 2 || len(events) != 1 || events[0].Type != Stored {
+		t.Fatalf("attempts = %d, events = %+v", attempts, events)
+	}
+	if data, _ := events[0].Data.(map[string]interface{}); data["id"] != "lease" {
+		t.Errorf("event data = %v", events[0].Data)
+	}
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	d := New([]Hook{{URL: server.URL}}, Options{Backoff: time.Millisecond})
+	d.Send(Deleted, nil)
+	d.Close(context.Background())
+	if attempts != 1 {
+		t.Errorf("a rejected delivery was attempted %d times", attempts)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.yaml")
+	t.Setenv("CRM_SECRET", "from-env")
+	os.WriteFile(path, []byte("webhooks:\n  - url: https://crm.example.com/hook\n    secret-env: CRM_SECRET\n    events: [document.signed]\n"), 0644)
+	hooks, err := Load(path)
+	if err != nil || len(hooks) != 1 || hooks[0].Secret != "from-env" || hooks[0].wants(Stored) || !hooks[0].wants(Signed) {
+		t.Fatalf("Load() = %+v, %v", hooks, err)
+	}
+
+	for _, config := range []string{
+		"webhooks:\n  - url: ftp://example.com\n",
+		"webhooks:\n  - url: https://example.com\n    events: [document.printed]\n",
+		"webhooks:\n  - url: https://example.com\n    secret-env: NLD_UNSET_SECRET\n",
+	} {
+		os.WriteFile(path, []byte(config), 0644)
+		if _, err := Load(path); err == nil {
+			t.Errorf("Load() of %q succeeded", config)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package workflow implements the lifecycle of documents, recorded in
+// metadata.status: drafts are put up for review, approved, executed once
+// every entity has signed, and finally archived.
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+// Status is a state of the document lifecycle
+type Status string
+
+const (
+	Draft    Status = "draft"
+	Review   Status = "review"
+	Approved Status = "approved"
+	Executed Status = "executed"
+	Archived Status = "archived"
+)
+
+// transitions lists the statuses each status may move to. Documents in
+// review or approved may be sent back, and any document that is not yet
+// executed may be abandoned by archiving it.
+var transitions = map[Status][]Status{
+	Draft:    {Review, Archived},
+	Review:   {Draft, Approved, Archived},
+	Approved: {Review, Executed, Archived},
+	Executed: {Archived},
+	Archived: nil,
+}
+
+// Statuses returns the statuses in lifecycle order
+func Statuses() []Status {
+	return []Status{Draft, Review, Approved, Executed, Archived}
+}
+
+// Valid reports whether s is a status of the lifecycle
+func Valid(s Status) bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// Next returns the statuses a document in status s may move to
+func Next(s Status) []Status {
+	return transitions[s]
+}
+
+// CanTransition reports whether a document may move from one status to
+// another
+func CanTransition(from, to Status) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the status of a document. Documents without one are drafts.
+func Get(doc document.Document) Status {
+	meta := doc.Metadata()
+	if meta == nil || meta["status"] == nil {
+		return Draft
+	}
+	return Status(fmt.Sprint(meta["status"]))
+}
+
+// Final reports whether a document has been executed or archived, after
+// which its content only changes by amending it
+func Final(doc document.Document) bool {
+	status := Get(doc)
+	return status == Executed || status == Archived
+}
+
+// Unsigned returns the IDs of the entities of a document that have no
+// signature in verification.signatures
+func Unsigned(doc document.Document) []string {
+	signed := map[string]bool{}
+	if verification := doc.Verification(false); verification != nil {
+		list, _ := verification["signatures"].([]interface{})
+		for _, item := range list {
+			if obj, ok := item.(map[string]interface{}); ok && document.String(obj, "value") != "" {
+				signed[document.String(obj, "signerId")] = true
+			}
+		}
+	}
+
+	var unsigned []string
+	if meta := doc.Metadata(); meta != nil {
+		entities, _ := meta["entities"].([]interface{})
+		for _, e := range entities {
+			if obj, ok := e.(map[string]interface{}); ok && !signed[document.String(obj, "id")] {
+				unsigned = append(unsigned, document.String(obj, "id"))
+			}
+		}
+	}
+	return unsigned
+}
+
+// Set moves a document to status to, returning the status it was in. The
+// transition must be allowed from the current status, and documents are
+// only executed once every entity has signed; force skips both checks.
+func Set(doc document.Document, to Status, force bool) (Status, error) {
+	if !Valid(to) {
+		return "", fmt.Errorf("unknown status %q (expected one of: %s)", to, join(Statuses()))
+	}
+	from := Get(doc)
+	if !force {
+		switch {
+		case !Valid(from):
+			return from, fmt.Errorf("document has unknown status %q (use --force to set it anyway)", from)
+		case from == to:
+			return from, fmt.Errorf("document is already %s", to)
+		case !CanTransition(from, to):
+			next := "none"
+			if len(Next(from)) > 0 {
+				next = join(Next(from))
+			}
+			return from, fmt.Errorf("cannot move a document from %s to %s (allowed: %s; use --force to move anyway)", from, to, next)
+		}
+		if unsigned := Unsigned(doc); to == Executed && len(unsigned) > 0 {
+			return from, fmt.Errorf("cannot execute a document before every entity has signed (unsigned: %s; use --force to execute anyway)", strings.Join(unsigned, ", "))
+		}
+	}
+	doc.Object("metadata", true)["status"] = string(to)
+	return from, nil
+}
+
+// join lists statuses separated by commas
+func join(statuses []Status) string {
+	names := make([]string, len(statuses))
+	for i, s := range statuses {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ", ")
+}
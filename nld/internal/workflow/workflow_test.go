@@ -0,0 +1,89 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/colemalphrus/nld/internal/document"
+)
+
+const testDocument = `{
+  "metadata": {"type": "contract", "entities": [{"id": "provider", "name": "ABC Corp", "role": "Service Provider"}, {"id": "client", "name": "XYZ Ltd", "role": "Client"}]},
+  "structure": {"sections": []},
+  "verification": {"signatures": [{"signerId": "provider", "date": "2025-06-27T14:30:00Z", "value": "sig"}]}
+}`
+
+func parse(t *testing.T) document.Document {
+	t.Helper()
+	doc, err := document.Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+	return doc
+}
+
+func TestSet(t *testing.T) {
+	doc := parse(t)
+	if status := Get(doc); status != Draft {
+		t.Fatalf("Get of a document without status = %s", status)
+	}
+
+	for _, to := range []Status{Review, Draft, Review, Approved} {
+		if _, err := Set(doc, to, false); err != nil {
+			t.Fatalf("Set %s failed: %v", to, err)
+		}
+	}
+	if status := Get(doc); status != Approved || Final(doc) {
+		t.Errorf("Get = %s, expected approved", status)
+	}
+
+	testCases := []struct {
+		name  string
+		to    Status
+		force bool
+		ok    bool
+	}{
+		{"Unknown", "signed", false, false},
+		{"Unknown Forced", "signed", true, false},
+		{"Same", Approved, false, false},
+		{"Skipped", Draft, false, false},
+		{"Unsigned", Executed, false, false},
+		{"Unsigned Forced", Executed, true, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := parse(t)
+			doc.Metadata()["status"] = string(Approved)
+			from, err := Set(doc, tc.to, tc.force)
+			if (err == nil) != tc.ok {
+				t.Fatalf("Set %s error = %v", tc.to, err)
+			}
+			if tc.ok && (from != Approved || Get(doc) != tc.to) {
+				t.Errorf("Set %s moved from %s to %s", tc.to, from, Get(doc))
+			}
+			if !tc.ok && Get(doc) != Approved {
+				t.Errorf("failed Set %s changed the status to %s", tc.to, Get(doc))
+			}
+		})
+	}
+
+	// Archived documents are final
+	doc.Metadata()["status"] = string(Archived)
+	if !Final(doc) {
+		t.Error("archived document is not final")
+	}
+	if _, err := Set(doc, Draft, false); err == nil {
+		t.Error("Set of an archived document succeeded")
+	}
+}
+
+func TestUnsigned(t *testing.T) {
+	doc := parse(t)
+	if unsigned := Unsigned(doc); !reflect.DeepEqual(unsigned, []string{"client"}) {
+		t.Errorf("Unsigned = %v", unsigned)
+	}
+	document.Append(doc.Verification(true), "signatures", map[string]interface{}{"signerId": "client", "value": "docusign:env-1"})
+	if unsigned := Unsigned(doc); len(unsigned) != 0 {
+		t.Errorf("Unsigned of a fully signed document = %v", unsigned)
+	}
+}
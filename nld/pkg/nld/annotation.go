@@ -0,0 +1,38 @@
+package nld
+
+// Annotation is a comment on a section of a document, or on a range of its
+// text. Annotations are kept outside the hashed parts of the document, so
+// adding and resolving them leaves signatures valid.
+type Annotation struct {
+	ID string `json:"id"`
+	// Section is the ID of the annotated section
+	Section string `json:"section"`
+	// Range is the annotated text of the section's content, nil when the
+	// annotation is about the whole section
+	Range *TextRange `json:"range,omitempty"`
+	// Quote is the text covered by Range when the annotation was made
+	Quote    string `json:"quote,omitempty"`
+	Author   string `json:"author"`
+	Date     string `json:"date"`
+	Text     string `json:"text"`
+	Resolved bool   `json:"resolved,omitempty"`
+}
+
+// TextRange is a range of characters of a section's plain text content,
+// from Start up to but not including End
+type TextRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SectionAnnotations returns the annotations of the section with the given
+// ID, leaving out resolved ones unless resolved is set
+func (d *Document) SectionAnnotations(id string, resolved bool) []Annotation {
+	var out []Annotation
+	for _, a := range d.Annotations {
+		if a.Section == id && (resolved || !a.Resolved) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
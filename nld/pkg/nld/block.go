@@ -0,0 +1,103 @@
+package nld
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Block types for structured section content
+const (
+	BlockParagraph     = "paragraph"
+	BlockList          = "list"
+	BlockTable         = "table"
+	BlockDefinitionRef = "definitionRef"
+)
+
+// Block is one element of structured section content
+type Block struct {
+	Type string `json:"type"`
+	// Text is the text of a paragraph
+	Text string `json:"text,omitempty"`
+	// Ordered and Items describe a list
+	Ordered bool     `json:"ordered,omitempty"`
+	Items   []string `json:"items,omitempty"`
+	// Header, Columns and Rows describe a table. Columns supersede Header
+	// when cell types or totals are needed.
+	Header     []string   `json:"header,omitempty"`
+	Columns    []Column   `json:"columns,omitempty"`
+	Rows       [][]string `json:"rows,omitempty"`
+	TotalLabel string     `json:"totalLabel,omitempty"`
+	// Term names the definition a definitionRef block refers to
+	Term string `json:"term,omitempty"`
+}
+
+// Body returns the content of the section as blocks. Plain text content is
+// split into paragraphs on blank lines.
+func (s Section) Body() []Block {
+	if s.Blocks != nil {
+		return s.Blocks
+	}
+
+	var blocks []Block
+	for _, p := range strings.Split(strings.ReplaceAll(s.Content, "\r\n", "\n"), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			blocks = append(blocks, Block{Type: BlockParagraph, Text: p})
+		}
+	}
+	return blocks
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting content as either a
+// string or an array of blocks
+func (s *Section) UnmarshalJSON(data []byte) error {
+	type plain Section
+	var aux struct {
+		plain
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*s = Section(aux.plain)
+	if err := unmarshalContent(aux.Content, &s.Content, &s.Blocks); err != nil {
+		return fmt.Errorf("invalid content in section %s: %w", s.ID, err)
+	}
+	return nil
+}
+
+// unmarshalContent decodes section content given either as a string or as
+// an array of blocks
+func unmarshalContent(data json.RawMessage, text *string, blocks *[]Block) error {
+	content := strings.TrimSpace(string(data))
+	switch {
+	case content == "" || content == "null":
+	case content[0] == '[':
+		*blocks = []Block{}
+		if err := json.Unmarshal(data, blocks); err != nil {
+			return fmt.Errorf("invalid content blocks: %w", err)
+		}
+	default:
+		if err := json.Unmarshal(data, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing blocks as the content of
+// structured sections
+func (s Section) MarshalJSON() ([]byte, error) {
+	type plain Section
+	var aux struct {
+		plain
+		Content interface{} `json:"content"`
+	}
+	aux.plain = plain(s)
+	aux.Content = s.Content
+	if s.Blocks != nil {
+		aux.Content = s.Blocks
+	}
+	return json.Marshal(aux)
+}
@@ -0,0 +1,94 @@
+package nld
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"runtime"
+	"runtime/debug"
+	"sort"
+
+	"github.com/colemalphrus/nld/schemas"
+)
+
+// Build metadata, set when linking release builds:
+//
+//	go build -ldflags "-X github.com/colemalphrus/nld/pkg/nld.version=1.2.0 \
+//	  -X github.com/colemalphrus/nld/pkg/nld.commit=$(git rev-parse HEAD) \
+//	  -X github.com/colemalphrus/nld/pkg/nld.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/nld
+var (
+	version = "0.1.0"
+	commit  = ""
+	date    = ""
+)
+
+// Build describes the build of nld in use
+type Build struct {
+	Version string `json:"version"`
+	// Commit is the revision built from and Date when it was built or
+	// committed, when known
+	Commit string `json:"commit,omitempty"`
+	Date   string `json:"date,omitempty"`
+	// Modified reports that the working tree had uncommitted changes
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"goVersion"`
+	// Schemas are the schemas built into nld
+	Schemas []SchemaBuild `json:"schemas"`
+}
+
+// SchemaBuild identifies a schema built into nld
+type SchemaBuild struct {
+	File  string `json:"file"`
+	Title string `json:"title,omitempty"`
+	// SHA256 is the digest of the schema file, which changes with every
+	// change to the schema
+	SHA256 string `json:"sha256"`
+}
+
+// BuildInfo returns the build metadata of nld. The commit and date come
+// from the linker flags of release builds or, without them, from the
+// version control information Go records in binaries.
+func BuildInfo() Build {
+	b := Build{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok && commit == "" {
+		// go install of a tagged release records its version
+		if v := info.Main.Version; v != "" && v != "(devel)" {
+			b.Version = v
+		}
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				b.Commit = s.Value
+			case "vcs.time":
+				if b.Date == "" {
+					b.Date = s.Value
+				}
+			case "vcs.modified":
+				b.Modified = s.Value == "true"
+			}
+		}
+	}
+	b.Schemas = schemaBuilds()
+	return b
+}
+
+// schemaBuilds returns the schemas built into nld in file name order
+func schemaBuilds() []SchemaBuild {
+	names, _ := fs.Glob(schemas.FS, "*.json")
+	sort.Strings(names)
+	var builds []SchemaBuild
+	for _, name := range names {
+		data, err := schemas.FS.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		var schema struct {
+			Title string `json:"title"`
+		}
+		json.Unmarshal(data, &schema)
+		sum := sha256.Sum256(data)
+		builds = append(builds, SchemaBuild{File: name, Title: schema.Title, SHA256: hex.EncodeToString(sum[:])})
+	}
+	return builds
+}
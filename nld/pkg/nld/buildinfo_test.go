@@ -0,0 +1,31 @@
+package nld
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestBuildInfo(t *testing.T) {
+	b := BuildInfo()
+	if b.Version == "" {
+		t.Error("BuildInfo has no version")
+	}
+	if b.GoVersion != runtime.Version() {
+		t.Errorf("GoVersion = %q, want %q", b.GoVersion, runtime.Version())
+	}
+	found := false
+	for _, s := range b.Schemas {
+		if len(s.SHA256) != 64 {
+			t.Errorf("schema %s has digest %q", s.File, s.SHA256)
+		}
+		if s.File == "document-v1.json" {
+			found = true
+			if s.Title != "NLD Document Schema" {
+				t.Errorf("document-v1.json has title %q", s.Title)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("schemas %+v do not include document-v1.json", b.Schemas)
+	}
+}
@@ -0,0 +1,226 @@
+package nld
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of calendar dates in NLD documents
+const DateLayout = "2006-01-02"
+
+var durationPattern = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseDate parses a date (2025-07-01) or a datetime (RFC 3339). Dates and
+// datetimes without a UTC offset are interpreted in loc; a nil loc means
+// UTC. The returned flag reports whether the value included a time.
+func ParseDate(s string, loc *time.Location) (time.Time, bool, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if t, err := time.ParseInLocation(DateLayout, s, loc); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", s, loc); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid date %q (expected YYYY-MM-DD or an RFC 3339 datetime)", s)
+}
+
+// HasZone reports whether a datetime string carries a UTC offset. Plain
+// dates always do, since they are interpreted in the document time zone.
+func HasZone(s string) bool {
+	if !strings.Contains(s, "T") {
+		return true
+	}
+	_, err := time.Parse(time.RFC3339, s)
+	return err == nil
+}
+
+// Duration is an ISO 8601 duration such as P1Y6M or P30D. Calendar parts
+// are kept separate so that adding a duration follows the calendar.
+type Duration struct {
+	Years, Months, Weeks, Days int
+	Hours, Minutes, Seconds    int
+}
+
+// ParseDuration parses an ISO 8601 duration
+func ParseDuration(s string) (Duration, error) {
+	m := durationPattern.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return Duration{}, fmt.Errorf("invalid duration %q (expected ISO 8601, such as P1Y or P30D)", s)
+	}
+
+	parts := make([]int, 7)
+	for i, v := range m[1:] {
+		if v != "" {
+			parts[i], _ = strconv.Atoi(v)
+		}
+	}
+	return Duration{
+		Years: parts[0], Months: parts[1], Weeks: parts[2], Days: parts[3],
+		Hours: parts[4], Minutes: parts[5], Seconds: parts[6],
+	}, nil
+}
+
+// IsZero reports whether the duration is empty
+func (d Duration) IsZero() bool {
+	return d == Duration{}
+}
+
+// AddTo returns t advanced by the duration
+func (d Duration) AddTo(t time.Time) time.Time {
+	t = t.AddDate(d.Years, d.Months, d.Weeks*7+d.Days)
+	return t.Add(time.Duration(d.Hours)*time.Hour + time.Duration(d.Minutes)*time.Minute + time.Duration(d.Seconds)*time.Second)
+}
+
+// String formats the duration in ISO 8601
+func (d Duration) String() string {
+	if d.IsZero() {
+		return "P0D"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("P")
+	for _, p := range []struct {
+		n    int
+		unit string
+	}{{d.Years, "Y"}, {d.Months, "M"}, {d.Weeks, "W"}, {d.Days, "D"}} {
+		if p.n != 0 {
+			sb.WriteString(strconv.Itoa(p.n) + p.unit)
+		}
+	}
+	if d.Hours != 0 || d.Minutes != 0 || d.Seconds != 0 {
+		sb.WriteString("T")
+		for _, p := range []struct {
+			n    int
+			unit string
+		}{{d.Hours, "H"}, {d.Minutes, "M"}, {d.Seconds, "S"}} {
+			if p.n != 0 {
+				sb.WriteString(strconv.Itoa(p.n) + p.unit)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Location returns the time zone of the document, UTC when none is set
+func (m Metadata) Location() (*time.Location, error) {
+	if m.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(m.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", m.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Period returns the start and end of the period the document is in
+// force. The end is Expires, or Effective plus Term when no expiry is
+// given; it is the zero time for open-ended documents.
+func (m Metadata) Period() (start, end time.Time, err error) {
+	loc, err := m.Location()
+	if err != nil {
+		return start, end, err
+	}
+
+	if m.Effective != "" {
+		if start, _, err = ParseDate(m.Effective, loc); err != nil {
+			return start, end, fmt.Errorf("effective: %w", err)
+		}
+	}
+	if m.Expires != "" {
+		if end, _, err = ParseDate(m.Expires, loc); err != nil {
+			return start, end, fmt.Errorf("expires: %w", err)
+		}
+	} else if m.Term != "" && !start.IsZero() {
+		term, err := ParseDuration(m.Term)
+		if err != nil {
+			return start, end, fmt.Errorf("term: %w", err)
+		}
+		end = term.AddTo(start)
+	}
+	return start, end, nil
+}
+
+// FieldError is a problem with a metadata field
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error implements the error interface
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// CheckDates validates the dates and durations in the metadata: values
+// must parse, the time zone must exist, the document must expire after it
+// takes effect, and the notice period must be shorter than the term.
+func (m Metadata) CheckDates() []FieldError {
+	loc, err := m.Location()
+	if err != nil {
+		return []FieldError{{Field: "timezone", Message: err.Error()}}
+	}
+
+	var retainUntil string
+	if m.Retention != nil {
+		retainUntil = m.Retention.RetainUntil
+	}
+
+	var errs []FieldError
+	for _, f := range []struct{ name, value string }{{"created", m.Created}, {"effective", m.Effective}, {"expires", m.Expires}, {"retention.retainUntil", retainUntil}} {
+		if f.value == "" {
+			continue
+		}
+		if _, _, err := ParseDate(f.value, loc); err != nil {
+			errs = append(errs, FieldError{Field: f.name, Message: err.Error()})
+		}
+	}
+
+	var term, notice Duration
+	for _, f := range []struct {
+		name  string
+		value string
+		out   *Duration
+	}{{"term", m.Term, &term}, {"noticePeriod", m.NoticePeriod, &notice}} {
+		if f.value == "" {
+			continue
+		}
+		d, err := ParseDuration(f.value)
+		if err != nil {
+			errs = append(errs, FieldError{Field: f.name, Message: err.Error()})
+			continue
+		}
+		if d.IsZero() {
+			errs = append(errs, FieldError{Field: f.name, Message: "duration must not be zero"})
+		}
+		*f.out = d
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	start, end, _ := m.Period()
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		errs = append(errs, FieldError{Field: "expires", Message: fmt.Sprintf("%s is not after the effective date %s", m.Expires, m.Effective)})
+	}
+
+	// The notice period must end before the term does
+	if !notice.IsZero() && !term.IsZero() {
+		base := start
+		if base.IsZero() {
+			base = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+		}
+		if !notice.AddTo(base).Before(term.AddTo(base)) {
+			errs = append(errs, FieldError{Field: "noticePeriod", Message: fmt.Sprintf("%s is not shorter than the term %s", m.NoticePeriod, m.Term)})
+		}
+	}
+	return errs
+}
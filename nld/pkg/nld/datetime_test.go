@@ -0,0 +1,135 @@
+package nld
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseDuration(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expect      Duration
+		expectError bool
+	}{
+		{input: "P1Y", expect: Duration{Years: 1}},
+		{input: "P30D", expect: Duration{Days: 30}},
+		{input: "P1Y6M2W", expect: Duration{Years: 1, Months: 6, Weeks: 2}},
+		{input: "PT36H", expect: Duration{Hours: 36}},
+		{input: "P", expectError: true},
+		{input: "P1YT", expectError: true},
+		{input: "30 days", expectError: true},
+		{input: "P1.5Y", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParseDuration(tc.input)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDuration failed: %v", err)
+			}
+			if got != tc.expect {
+				t.Errorf("Expected %+v, got %+v", tc.expect, got)
+			}
+			if got.String() != tc.input {
+				t.Errorf("Expected %s to format as itself, got %s", tc.input, got)
+			}
+		})
+	}
+
+	// Calendar durations follow month lengths
+	start := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	if got := (Duration{Months: 1}).AddTo(start); got.Format(DateLayout) != "2025-03-03" {
+		t.Errorf("Expected 2025-03-03, got %s", got.Format(DateLayout))
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+
+	date, hasTime, err := ParseDate("2025-07-01", berlin)
+	if err != nil || hasTime {
+		t.Fatalf("Expected a plain date, got %v, %v", hasTime, err)
+	}
+	if date.UTC().Format(time.RFC3339) != "2025-06-30T22:00:00Z" {
+		t.Errorf("Expected the date to start at midnight in Berlin, got %s", date.UTC())
+	}
+
+	datetime, hasTime, err := ParseDate("2025-07-01T09:00:00-05:00", berlin)
+	if err != nil || !hasTime {
+		t.Fatalf("Expected a datetime, got %v, %v", hasTime, err)
+	}
+	if datetime.UTC().Hour() != 14 {
+		t.Errorf("Expected the offset to be honoured, got %s", datetime.UTC())
+	}
+
+	if _, _, err := ParseDate("07/01/2025", nil); err == nil {
+		t.Errorf("Expected slash dates to be rejected")
+	}
+	if HasZone("2025-07-01T09:00:00") || !HasZone("2025-07-01T09:00:00Z") || !HasZone("2025-07-01") {
+		t.Errorf("Unexpected HasZone results")
+	}
+}
+
+func TestCheckDates(t *testing.T) {
+	testCases := []struct {
+		name   string
+		meta   Metadata
+		expect []string
+	}{
+		{
+			name: "Ordered",
+			meta: Metadata{Effective: "2025-07-01", Expires: "2026-06-30", Term: "P1Y", NoticePeriod: "P30D", Timezone: "America/New_York"},
+		},
+		{
+			name:   "Expires Before Effective",
+			meta:   Metadata{Effective: "2025-07-01", Expires: "2025-06-30"},
+			expect: []string{"expires: 2025-06-30 is not after the effective date 2025-07-01"},
+		},
+		{
+			name: "Offsets Compared As Instants",
+			// 01:00 in Tokyo is still the previous day in UTC
+			meta:   Metadata{Effective: "2025-07-01T00:30:00Z", Expires: "2025-07-01T01:00:00+09:00"},
+			expect: []string{"expires: 2025-07-01T01:00:00+09:00 is not after the effective date"},
+		},
+		{
+			name:   "Notice Longer Than Term",
+			meta:   Metadata{Term: "P1M", NoticePeriod: "P6W"},
+			expect: []string{"noticePeriod: P6W is not shorter than the term P1M"},
+		},
+		{
+			name:   "Malformed Values",
+			meta:   Metadata{Effective: "1 July 2025", Term: "1 year", NoticePeriod: "P0D"},
+			expect: []string{"effective: invalid date", "term: invalid duration", "noticePeriod: duration must not be zero"},
+		},
+		{
+			name:   "Unknown Time Zone",
+			meta:   Metadata{Effective: "2025-07-01", Timezone: "Mars/Olympus"},
+			expect: []string{"timezone: invalid timezone"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := tc.meta.CheckDates()
+			if len(errs) != len(tc.expect) {
+				t.Fatalf("Expected %d errors, got %v", len(tc.expect), errs)
+			}
+			for i, err := range errs {
+				if !strings.Contains(err.Error(), tc.expect[i]) {
+					t.Errorf("Expected error containing %q, got %q", tc.expect[i], err.Error())
+				}
+			}
+		})
+	}
+}
@@ -1,118 +1,352 @@
 package nld
 
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/colemalphrus/nld/internal/digest"
+	"github.com/colemalphrus/nld/internal/document"
+)
+
 // Document represents an NLD document
 type Document struct {
-	// Add fields as needed
-	Metadata    Metadata
-	Structure   Structure
-	Relationships Relationships
-	Verification Verification
+	Metadata      Metadata      `json:"metadata"`
+	Structure     Structure     `json:"structure"`
+	Relationships Relationships `json:"relationships,omitempty"`
+	Verification  Verification  `json:"verification,omitempty"`
+	Revisions     []Revision    `json:"revisions,omitempty"`
+	Annotations   []Annotation  `json:"annotations,omitempty"`
+	Redline       []Change      `json:"redline,omitempty"`
+
+	// bodyKey records whether the body was read from "content" (version 1
+	// documents) or "structure" so that Marshal writes the same shape
+	bodyKey string
+	// hash is the root hash of the document as it was parsed
+	hash string
 }
 
 // Metadata contains document metadata
 type Metadata struct {
-	Type        string
-	Version     string
-	Created     string
-	Entities    []Entity
-	Jurisdiction string
+	Type         string   `json:"type"`
+	Version      string   `json:"version"`
+	Created      string   `json:"created"`
+	Title        string   `json:"title,omitempty"`
+	Author       string   `json:"author,omitempty"`
+	Entities     []Entity `json:"entities,omitempty"`
+	Jurisdiction string   `json:"jurisdiction,omitempty"`
+	Revision     int      `json:"revision,omitempty"`
+	// Status is the lifecycle state of the document: draft, review,
+	// approved, executed or archived
+	Status string `json:"status,omitempty"`
+	// Language is the locale of section titles and content, such as "en"
+	Language string `json:"language,omitempty"`
+
+	// Effective and Expires bound the period the document is in force. They
+	// are dates (2025-07-01) or datetimes with a UTC offset.
+	Effective string `json:"effective,omitempty"`
+	Expires   string `json:"expires,omitempty"`
+	// Term and NoticePeriod are ISO 8601 durations such as P1Y or P30D
+	Term         string `json:"term,omitempty"`
+	NoticePeriod string `json:"noticePeriod,omitempty"`
+	// Timezone is the IANA time zone in which dates without an offset are
+	// interpreted, UTC when empty
+	Timezone string `json:"timezone,omitempty"`
+	// SignaturePolicies are the groups of entities of which a number must
+	// sign, as nld verify checks
+	SignaturePolicies []SignaturePolicy `json:"signaturePolicies,omitempty"`
+	// Retention is how long the document must be kept, as nld retention
+	// sweep enforces
+	Retention *Retention `json:"retention,omitempty"`
 }
 
 // Entity represents an entity in the document
 type Entity struct {
-	ID   string
-	Name string
-	Role string
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Address string `json:"address,omitempty"`
+	// KeyIDs are the IDs of the signing keys registered for the entity;
+	// when set, its cryptographic signatures must be made with one of them
+	KeyIDs []string `json:"keyIds,omitempty"`
 }
 
 // Structure represents the document structure
 type Structure struct {
-	Sections    []Section
-	Items       []Item
-	Definitions []Definition
+	Sections    []Section    `json:"sections"`
+	Items       []Item       `json:"items,omitempty"`
+	Definitions []Definition `json:"definitions,omitempty"`
+	// Totals holds the amounts of receipts
+	Totals *Totals `json:"totals,omitempty"`
 }
 
-// Section represents a document section
+// Totals summarises the line items of a receipt. Amounts without their
+// own currency are in the receipt currency.
+type Totals struct {
+	Currency string `json:"currency,omitempty"`
+	Subtotal Money  `json:"subtotal"`
+	Taxes    []Tax  `json:"taxes,omitempty"`
+	Total    Money  `json:"total"`
+}
+
+// Tax is a tax charged on the subtotal of a receipt
+type Tax struct {
+	Name string `json:"name"`
+	// Rate is a percentage, such as "20" for 20%
+	Rate   string `json:"rate,omitempty"`
+	Amount Money  `json:"amount"`
+}
+
+// Section represents a document section. Its content is either plain text
+// in Content or, for structured sections, a list of Blocks.
 type Section struct {
-	ID    string
-	Title string
-	Content string
+	ID      string  `json:"id"`
+	Title   string  `json:"title"`
+	Content string  `json:"content"`
+	Blocks  []Block `json:"-"`
+	// Sections holds nested subsections, numbered beneath this section
+	Sections []Section `json:"sections,omitempty"`
+	// Translations holds the title and content in other locales, keyed by
+	// locale such as "fr" or "fr-CA"
+	Translations map[string]Translation `json:"translations,omitempty"`
 }
 
 // Item represents a document item
 type Item struct {
-	ID    string
-	Type  string
-	Value interface{}
+	ID    string      `json:"id"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value,omitempty"`
 }
 
 // Definition represents a document definition
 type Definition struct {
-	Term       string
-	Definition string
+	Term       string `json:"term"`
+	Definition string `json:"definition"`
 }
 
 // Relationships represents document relationships
 type Relationships struct {
-	Dependencies []Relationship
-	References   []Relationship
-	Conditions   []Condition
+	Dependencies []Relationship `json:"dependencies,omitempty"`
+	References   []Relationship `json:"references,omitempty"`
+	Conditions   []Condition    `json:"conditions,omitempty"`
 }
 
 // Relationship represents a relationship between document elements
 type Relationship struct {
-	Source string
-	Target string
-	Type   string
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
 }
 
 // Condition represents a condition in the document
 type Condition struct {
-	ID        string
-	Predicate string
-	Effect    string
+	ID        string `json:"id"`
+	Predicate string `json:"predicate"`
+	Effect    string `json:"effect"`
+	// Type is "obligation" for conditions that oblige Owner, the ID of an
+	// entity, to carry out Effect by Due when Predicate holds
+	Type  string `json:"type,omitempty"`
+	Owner string `json:"owner,omitempty"`
+	Due   string `json:"due,omitempty"`
 }
 
 // Verification represents document verification information
 type Verification struct {
-	Signatures   []Signature
-	Timestamps   []Timestamp
-	Attestations []Attestation
+	Signatures   []Signature   `json:"signatures,omitempty"`
+	Timestamps   []Timestamp   `json:"timestamps,omitempty"`
+	Attestations []Attestation `json:"attestations,omitempty"`
 }
 
 // Signature represents a document signature
 type Signature struct {
-	SignerID string
-	Date     string
-	Value    string
+	SignerID string `json:"signerId"`
+	Date     string `json:"date"`
+	Value    string `json:"value"`
+	// Algorithm, KeyID and PublicKey are set on cryptographic signatures,
+	// whose Value is the base64 signature of the root hash of the document
+	// made with the key
+	Algorithm string `json:"algorithm,omitempty"`
+	KeyID     string `json:"keyId,omitempty"`
+	PublicKey string `json:"publicKey,omitempty"`
+	// Certificates is the X.509 certificate chain of the key, leaf first,
+	// as base64 DER
+	Certificates []string `json:"certificates,omitempty"`
 }
 
 // Timestamp represents a document timestamp
 type Timestamp struct {
-	Date  string
-	Value string
+	Date  string `json:"date"`
+	Value string `json:"value"`
 }
 
 // Attestation represents a document attestation
 type Attestation struct {
-	AttesterID string
-	Date       string
-	Statement  string
+	AttesterID string `json:"attesterId"`
+	Date       string `json:"date"`
+	Statement  string `json:"statement"`
+	Type       string `json:"type,omitempty"`
+	// Role is the capacity a third party attests in, such as notary
+	Role string `json:"role,omitempty"`
+	// Value, Algorithm, KeyID, PublicKey and Certificates are set on
+	// attestations signed with the identity key of the attester, as on
+	// cryptographic signatures
+	Value        string   `json:"value,omitempty"`
+	Algorithm    string   `json:"algorithm,omitempty"`
+	KeyID        string   `json:"keyId,omitempty"`
+	PublicKey    string   `json:"publicKey,omitempty"`
+	Certificates []string `json:"certificates,omitempty"`
 }
 
 // New creates a new NLD document
 func New() *Document {
-	return &Document{}
+	return &Document{bodyKey: "content"}
 }
 
-// Parse parses an NLD document from bytes
+// Parse parses an NLD document from bytes. Both version 1 documents, which
+// keep their sections under "content", and typed documents using
+// "structure" are accepted.
 func Parse(data []byte) (*Document, error) {
-	// Placeholder for parsing logic
-	return &Document{}, nil
+	raw, err := document.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+
+	// The root hash is computed from the raw JSON so that it matches the
+	// hash produced by the CLI, including fields this model does not know
+	if raw.Metadata() != nil {
+		if rec, err := digest.Compute(raw); err == nil {
+			doc.hash = rec.Root
+		}
+	}
+
+	return &doc, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Document) UnmarshalJSON(data []byte) error {
+	type plain Document
+	var aux struct {
+		plain
+		Content   *Structure `json:"content"`
+		Structure *Structure `json:"structure"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*d = Document(aux.plain)
+	switch {
+	case aux.Structure != nil:
+		d.Structure = *aux.Structure
+		d.bodyKey = "structure"
+	case aux.Content != nil:
+		d.Structure = *aux.Content
+		d.bodyKey = "content"
+	default:
+		d.bodyKey = "content"
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing the body under the same
+// key it was read from
+func (d Document) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{
+		"metadata": d.Metadata,
+	}
+
+	key := d.bodyKey
+	if key == "" {
+		key = "content"
+	}
+	out[key] = d.Structure
+
+	if !isEmptyRelationships(d.Relationships) {
+		out["relationships"] = d.Relationships
+	}
+	if !isEmptyVerification(d.Verification) {
+		out["verification"] = d.Verification
+	}
+	if len(d.Revisions) > 0 {
+		out["revisions"] = d.Revisions
+	}
+	if len(d.Annotations) > 0 {
+		out["annotations"] = d.Annotations
+	}
+	if len(d.Redline) > 0 {
+		out["redline"] = d.Redline
+	}
+	return json.Marshal(out)
+}
+
+// BodyKey returns the key the document body is stored under: "content"
+// for version 1 documents or "structure" for typed documents
+func (d *Document) BodyKey() string {
+	if d.bodyKey == "" {
+		return "content"
+	}
+	return d.bodyKey
+}
+
+// Hash returns the root hash of the document. For parsed documents this is
+// the hash of the document as read; otherwise it is computed from the
+// current model.
+func (d *Document) Hash() (string, error) {
+	if d.hash != "" {
+		return d.hash, nil
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	raw, err := document.Parse(data)
+	if err != nil {
+		return "", err
+	}
+	rec, err := digest.Compute(raw)
+	if err != nil {
+		return "", err
+	}
+	return rec.Root, nil
+}
+
+// Section returns the section with the given ID, or nil
+func (d *Document) Section(id string) *Section {
+	for i := range d.Structure.Sections {
+		if d.Structure.Sections[i].ID == id {
+			return &d.Structure.Sections[i]
+		}
+	}
+	return nil
+}
+
+// Definition returns the definition of the given term, or nil
+func (d *Document) Definition(term string) *Definition {
+	for i := range d.Structure.Definitions {
+		if d.Structure.Definitions[i].Term == term {
+			return &d.Structure.Definitions[i]
+		}
+	}
+	return nil
 }
 
 // Validate validates the document
 func (d *Document) Validate() error {
 	// Placeholder for validation logic
 	return nil
-}
\ No newline at end of file
+}
+
+func isEmptyRelationships(r Relationships) bool {
+	return len(r.Dependencies) == 0 && len(r.References) == 0 && len(r.Conditions) == 0
+}
+
+func isEmptyVerification(v Verification) bool {
+	return len(v.Signatures) == 0 && len(v.Timestamps) == 0 && len(v.Attestations) == 0
+}
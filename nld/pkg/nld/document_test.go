@@ -0,0 +1,208 @@
+package nld
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readExample(t *testing.T, name string) []byte {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(wd, "..", "..", "examples", name))
+	if err != nil {
+		t.Fatalf("Failed to read example: %v", err)
+	}
+	return data
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name           string
+		file           string
+		expectType     string
+		expectSections int
+		expectBodyKey  string
+	}{
+		{
+			name:           "Version 1 Contract",
+			file:           "valid-contract.json",
+			expectType:     "contract",
+			expectSections: 2,
+			expectBodyKey:  "content",
+		},
+		{
+			name:           "NDA Structure",
+			file:           "nda.json",
+			expectType:     "NDA",
+			expectSections: 2,
+			expectBodyKey:  "structure",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, err := Parse(readExample(t, tc.file))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if doc.Metadata.Type != tc.expectType {
+				t.Errorf("Expected type %s, got %s", tc.expectType, doc.Metadata.Type)
+			}
+			if len(doc.Structure.Sections) != tc.expectSections {
+				t.Errorf("Expected %d sections, got %d", tc.expectSections, len(doc.Structure.Sections))
+			}
+
+			out, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			if !strings.Contains(string(out), `"`+tc.expectBodyKey+`":`) {
+				t.Errorf("Expected body under %s, got %s", tc.expectBodyKey, out)
+			}
+		})
+	}
+}
+
+func TestAmendAndChain(t *testing.T) {
+	original, err := Parse(readExample(t, "valid-contract.json"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	second, err := original.Amend("legal", "Extend scope", now)
+	if err != nil {
+		t.Fatalf("Amend failed: %v", err)
+	}
+	second.Section("scope").Content = "Extended scope"
+
+	third, err := second.Amend("legal", "Fix typo", now)
+	if err != nil {
+		t.Fatalf("Amend failed: %v", err)
+	}
+
+	if third.CurrentRevision() != 3 {
+		t.Errorf("Expected revision 3, got %d", third.CurrentRevision())
+	}
+	if len(third.History()) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(third.History()))
+	}
+	originalHash, _ := original.Hash()
+	if third.History()[0].Previous != originalHash {
+		t.Errorf("Expected first amendment to reference the original hash")
+	}
+
+	chain, err := Chain([]*Document{third, original, second})
+	if err != nil {
+		t.Fatalf("Chain failed: %v", err)
+	}
+	for i, doc := range chain {
+		if doc.CurrentRevision() != i+1 {
+			t.Errorf("Expected revision %d at position %d, got %d", i+1, i, doc.CurrentRevision())
+		}
+	}
+
+	// A revision whose predecessor was modified after amending breaks the chain
+	original.hash = ""
+	original.Section("introduction").Content = "Tampered"
+	if _, err := Chain([]*Document{original, second}); err == nil {
+		t.Errorf("Expected broken chain to be detected")
+	}
+}
+
+func TestOutline(t *testing.T) {
+	doc := New()
+	doc.Structure.Sections = []Section{
+		{ID: "definitions", Title: "Definitions"},
+		{ID: "services", Title: "Services", Sections: []Section{
+			{ID: "scope", Title: "Scope", Sections: []Section{
+				{ID: "included", Title: "Included"},
+				{ID: "excluded", Title: "Excluded", Sections: []Section{
+					{ID: "travel", Title: "Travel"},
+				}},
+			}},
+		}},
+	}
+
+	testCases := []struct {
+		name   string
+		style  string
+		expect []string
+	}{
+		{name: "Default", style: "default", expect: []string{"1", "2", "2.1", "2.1.a", "2.1.b", "2.1.b.i"}},
+		{name: "Decimal", style: "decimal", expect: []string{"1", "2", "2.1", "2.1.1", "2.1.2", "2.1.2.1"}},
+		{name: "UK", style: "uk", expect: []string{"1", "2", "2.1", "(a)", "(b)", "(i)"}},
+		{name: "US", style: "us", expect: []string{"Article I", "Article II", "Section 1", "(a)", "(b)", "(i)"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			style, err := LookupNumberingStyle(tc.style)
+			if err != nil {
+				t.Fatalf("LookupNumberingStyle failed: %v", err)
+			}
+
+			var got []string
+			var walk func([]OutlineEntry)
+			walk = func(entries []OutlineEntry) {
+				for _, e := range entries {
+					got = append(got, e.Number)
+					walk(e.Children)
+				}
+			}
+			walk(doc.Outline(style))
+
+			if strings.Join(got, " ") != strings.Join(tc.expect, " ") {
+				t.Errorf("Expected %v, got %v", tc.expect, got)
+			}
+		})
+	}
+
+	if _, err := LookupNumberingStyle("unknown"); err == nil {
+		t.Errorf("Expected unknown style to be rejected")
+	}
+	if got := NumberingStyleFor("US-CA").Name; got != "us" {
+		t.Errorf("Expected us style for US-CA, got %s", got)
+	}
+	if got := NumberingStyleFor("California, USA").Name; got != "us" {
+		t.Errorf("Expected us style for California, USA, got %s", got)
+	}
+	if got := NumberingStyleFor("").Name; got != "default" {
+		t.Errorf("Expected default style without a jurisdiction, got %s", got)
+	}
+}
+
+func TestSectionContentBlocks(t *testing.T) {
+	data := []byte(`{"metadata":{"type":"contract"},"content":{"sections":[
+		{"id":"a","title":"A","content":"One.\n\nTwo."},
+		{"id":"b","title":"B","content":[{"type":"list","items":["x","y"]},{"type":"definitionRef","term":"Services"}]}
+	]}}`)
+
+	doc, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if body := doc.Section("a").Body(); len(body) != 2 || body[1].Text != "Two." {
+		t.Errorf("Expected text content to split into two paragraphs, got %+v", body)
+	}
+	b := doc.Section("b")
+	if len(b.Blocks) != 2 || b.Blocks[0].Type != BlockList || b.Blocks[1].Term != "Services" {
+		t.Fatalf("Expected list and definitionRef blocks, got %+v", b.Blocks)
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(out), `"content":"One.\n\nTwo."`) || !strings.Contains(string(out), `"content":[{"type":"list","items":["x","y"]}`) {
+		t.Errorf("Expected content shapes to survive a roundtrip, got %s", out)
+	}
+}
@@ -0,0 +1,37 @@
+package nld
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// FuzzParse checks that Parse does not panic and that parsed documents
+// survive a round trip through JSON.
+// Run it with go test -fuzz FuzzParse ./pkg/nld; nld fuzz --corpus
+// writes near-valid seeds for it.
+func FuzzParse(f *testing.F) {
+	files, _ := filepath.Glob(filepath.Join("..", "..", "examples", "*.json"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatalf("Failed to read example: %v", err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := Parse(data)
+		if err != nil {
+			return
+		}
+		out, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if _, err := Parse(out); err != nil {
+			t.Fatalf("Parse of marshaled document failed: %v\n%s", err, out)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package nld
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Translation is the title and content of a section in another locale.
+// Like Section, its content is either plain text or a list of blocks.
+type Translation struct {
+	Title   string  `json:"title,omitempty"`
+	Content string  `json:"content,omitempty"`
+	Blocks  []Block `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting content as either a
+// string or an array of blocks
+func (t *Translation) UnmarshalJSON(data []byte) error {
+	type plain Translation
+	var aux struct {
+		plain
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*t = Translation(aux.plain)
+	return unmarshalContent(aux.Content, &t.Content, &t.Blocks)
+}
+
+// MarshalJSON implements json.Marshaler
+func (t Translation) MarshalJSON() ([]byte, error) {
+	type plain Translation
+	var aux struct {
+		plain
+		Content interface{} `json:"content,omitempty"`
+	}
+	aux.plain = plain(t)
+	if t.Blocks != nil {
+		aux.Content = t.Blocks
+	} else if t.Content != "" {
+		aux.Content = t.Content
+	}
+	return json.Marshal(aux)
+}
+
+// hasContent reports whether the translation provides content
+func (t Translation) hasContent() bool {
+	return t.Content != "" || t.Blocks != nil
+}
+
+// normalizeLocale returns the lower-case "lang-region" form of a locale
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// translation returns the section's translation for locale, matching
+// locales case-insensitively
+func (s Section) translation(locale string) (Translation, bool) {
+	if t, ok := s.Translations[locale]; ok {
+		return t, true
+	}
+	for l, t := range s.Translations {
+		if normalizeLocale(l) == normalizeLocale(locale) {
+			return t, true
+		}
+	}
+	return Translation{}, false
+}
+
+// Localize returns the section with its title and content, and those of
+// its subsections, in locale. Parts that are not translated are left in
+// the document language base.
+func (s Section) Localize(locale, base string) Section {
+	if normalizeLocale(locale) != normalizeLocale(base) {
+		if t, ok := s.translation(locale); ok {
+			if t.Title != "" {
+				s.Title = t.Title
+			}
+			if t.hasContent() {
+				s.Content, s.Blocks = t.Content, t.Blocks
+			}
+		}
+	}
+
+	if s.Sections != nil {
+		sections := make([]Section, len(s.Sections))
+		for i, sub := range s.Sections {
+			sections[i] = sub.Localize(locale, base)
+		}
+		s.Sections = sections
+	}
+	return s
+}
+
+// Localize returns a copy of the document with section titles and content
+// in locale, falling back to the document language where a section is not
+// translated
+func (d *Document) Localize(locale string) *Document {
+	localized := *d
+	localized.Structure.Sections = make([]Section, len(d.Structure.Sections))
+	for i, s := range d.Structure.Sections {
+		localized.Structure.Sections[i] = s.Localize(locale, d.Metadata.Language)
+	}
+	localized.Metadata.Language = locale
+	// The root hash belongs to the original content
+	localized.hash = ""
+	return &localized
+}
+
+// Locales returns the document language followed by the other locales
+// sections are translated into
+func (d *Document) Locales() []string {
+	seen := map[string]bool{}
+	var others []string
+	var walk func(sections []Section)
+	walk = func(sections []Section) {
+		for _, s := range sections {
+			for l := range s.Translations {
+				if n := normalizeLocale(l); !seen[n] && n != normalizeLocale(d.Metadata.Language) {
+					seen[n] = true
+					others = append(others, l)
+				}
+			}
+			walk(s.Sections)
+		}
+	}
+	walk(d.Structure.Sections)
+	sort.Strings(others)
+
+	if d.Metadata.Language == "" {
+		return others
+	}
+	return append([]string{d.Metadata.Language}, others...)
+}
+
+// LocaleError is a section that is incomplete in a required locale
+type LocaleError struct {
+	// Path is the JSON pointer of the section
+	Path    string
+	Locale  string
+	Message string
+}
+
+// CheckLocales reports sections whose title or content is missing in any
+// of the required locales. The document language counts as complete.
+func (d *Document) CheckLocales(required []string) []LocaleError {
+	var errs []LocaleError
+	base := normalizeLocale(d.Metadata.Language)
+	if base == "" {
+		errs = append(errs, LocaleError{Path: "/metadata/language", Message: "the language of the document is not declared"})
+	}
+
+	var walk func(sections []Section, path string)
+	walk = func(sections []Section, path string) {
+		for i, s := range sections {
+			sp := fmt.Sprintf("%s/%d", path, i)
+			for _, locale := range required {
+				if normalizeLocale(locale) == base {
+					continue
+				}
+				t, ok := s.translation(locale)
+				switch {
+				case !ok:
+					errs = append(errs, LocaleError{Path: sp, Locale: locale, Message: fmt.Sprintf("section %s has no %s translation", s.ID, locale)})
+				case t.Title == "":
+					errs = append(errs, LocaleError{Path: sp + "/translations/" + locale, Locale: locale, Message: fmt.Sprintf("section %s has no %s title", s.ID, locale)})
+				case !t.hasContent() && len(s.Body()) > 0:
+					errs = append(errs, LocaleError{Path: sp + "/translations/" + locale, Locale: locale, Message: fmt.Sprintf("section %s has no %s content", s.ID, locale)})
+				}
+			}
+			walk(s.Sections, sp+"/sections")
+		}
+	}
+	walk(d.Structure.Sections, "/"+d.BodyKey()+"/sections")
+	return errs
+}
@@ -0,0 +1,90 @@
+package nld
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const bilingualDocument = `{
+  "metadata": {"type": "contract", "language": "en"},
+  "content": {"sections": [
+    {"id": "scope", "title": "Scope", "content": "The Supplier provides services.",
+     "translations": {"fr": {"title": "Portée", "content": "Le Fournisseur fournit des services."}},
+     "sections": [
+       {"id": "fees", "title": "Fees", "content": [{"type": "paragraph", "text": "Fees are due monthly."}],
+        "translations": {"fr": {"title": "Honoraires"}, "de": {"title": "Gebühren", "content": [{"type": "paragraph", "text": "Monatlich fällig."}]}}}
+     ]}
+  ]}
+}`
+
+func TestLocalize(t *testing.T) {
+	doc, err := Parse([]byte(bilingualDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	if got := strings.Join(doc.Locales(), ","); got != "en,de,fr" {
+		t.Errorf("Expected locales en,de,fr, got %s", got)
+	}
+
+	fr := doc.Localize("FR")
+	scope := fr.Structure.Sections[0]
+	if scope.Title != "Portée" || scope.Content != "Le Fournisseur fournit des services." {
+		t.Errorf("Expected French scope, got %q: %q", scope.Title, scope.Content)
+	}
+	fees := scope.Sections[0]
+	if fees.Title != "Honoraires" || fees.Body()[0].Text != "Fees are due monthly." {
+		t.Errorf("Expected French title with English content, got %q: %+v", fees.Title, fees.Body())
+	}
+	if doc.Structure.Sections[0].Title != "Scope" || doc.Structure.Sections[0].Sections[0].Title != "Fees" {
+		t.Error("Localize modified the original document")
+	}
+
+	de := doc.Localize("de").Structure.Sections[0].Sections[0]
+	if de.Title != "Gebühren" || de.Body()[0].Text != "Monatlich fällig." {
+		t.Errorf("Expected German fees, got %q: %+v", de.Title, de.Body())
+	}
+
+	// Translations survive a round trip with blocks intact
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	again, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Failed to parse marshalled document: %v", err)
+	}
+	if got := again.Structure.Sections[0].Sections[0].Translations["de"].Blocks; len(got) != 1 || got[0].Text != "Monatlich fällig." {
+		t.Errorf("Expected German blocks after round trip, got %+v", got)
+	}
+}
+
+func TestCheckLocales(t *testing.T) {
+	doc, err := Parse([]byte(bilingualDocument))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		required []string
+		expect   []string
+	}{
+		{name: "Base Language", required: []string{"en"}},
+		{name: "Complete", required: []string{"en", "de"}, expect: []string{"/content/sections/0: section scope has no de translation"}},
+		{name: "Missing Content", required: []string{"fr"}, expect: []string{"/content/sections/0/sections/0/translations/fr: section fees has no fr content"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, e := range doc.CheckLocales(tc.required) {
+				got = append(got, e.Path+": "+e.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package nld
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// DefaultCurrencyPrecision is the number of decimal places used for
+// currencies without a known precision
+const DefaultCurrencyPrecision = 2
+
+// minorUnits lists ISO 4217 currencies whose minor unit is not 2 decimal
+// places
+var minorUnits = map[string]int{
+	"BHD": 3, "CLP": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+	"KWD": 3, "LYD": 3, "OMR": 3, "PYG": 0, "TND": 3, "UGX": 0, "VND": 0,
+	"XAF": 0, "XOF": 0,
+}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// CurrencyPrecision returns the number of decimal places of an ISO 4217
+// currency code
+func CurrencyPrecision(currency string) (int, error) {
+	code := strings.ToUpper(currency)
+	if !currencyPattern.MatchString(code) {
+		return 0, fmt.Errorf("invalid currency code %q (expected an ISO 4217 code such as USD)", currency)
+	}
+	if p, ok := minorUnits[code]; ok {
+		return p, nil
+	}
+	return DefaultCurrencyPrecision, nil
+}
+
+// Money is an exact amount of a currency. The amount is a decimal string
+// such as "12.50" so that no precision is lost to floating point. An empty
+// currency means the currency is given by the context, such as the
+// currency of a receipt.
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// NewMoney returns a validated amount of money
+func NewMoney(amount, currency string) (Money, error) {
+	m := Money{Amount: amount, Currency: strings.ToUpper(currency)}
+	return m, m.Validate()
+}
+
+// Validate checks that the amount is a decimal and the currency an ISO
+// 4217 code
+func (m Money) Validate() error {
+	if _, ok := parseDecimal(m.Amount); !ok || m.Amount == "" {
+		return fmt.Errorf("invalid amount %q (expected a decimal such as 12.50)", m.Amount)
+	}
+	if m.Currency != "" {
+		if _, err := CurrencyPrecision(m.Currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the amount followed by the currency, as in "12.50 USD"
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Amount
+	}
+	return m.Amount + " " + m.Currency
+}
+
+// Add returns m + o
+func (m Money) Add(o Money) (Money, error) {
+	return m.combine(o, func(a, b *big.Rat) *big.Rat { return new(big.Rat).Add(a, b) })
+}
+
+// Sub returns m - o
+func (m Money) Sub(o Money) (Money, error) {
+	return m.combine(o, func(a, b *big.Rat) *big.Rat { return new(big.Rat).Sub(a, b) })
+}
+
+// Mul returns m multiplied by a decimal factor, such as a quantity. The
+// result is exact; use Round to apply the currency precision.
+func (m Money) Mul(factor string) (Money, error) {
+	a, err := m.rat()
+	if err != nil {
+		return Money{}, err
+	}
+	f, ok := parseDecimal(factor)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid factor %q", factor)
+	}
+	return Money{
+		Amount:   new(big.Rat).Mul(a, f).FloatString(decimals(m.Amount) + decimals(factor)),
+		Currency: m.Currency,
+	}, nil
+}
+
+// Percent returns rate percent of m, exactly
+func (m Money) Percent(rate string) (Money, error) {
+	p, err := m.Mul(rate)
+	if err != nil {
+		return Money{}, err
+	}
+	r, _ := p.rat()
+	return Money{
+		Amount:   r.Quo(r, big.NewRat(100, 1)).FloatString(decimals(p.Amount) + 2),
+		Currency: m.Currency,
+	}, nil
+}
+
+// Round rounds m half away from zero to the given number of decimal places
+func (m Money) Round(places int) (Money, error) {
+	a, err := m.rat()
+	if err != nil {
+		return Money{}, err
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	scaled := new(big.Rat).Mul(a, new(big.Rat).SetInt(scale))
+	half := big.NewRat(1, 2)
+	if scaled.Sign() < 0 {
+		scaled.Sub(scaled, half)
+	} else {
+		scaled.Add(scaled, half)
+	}
+	// Quo truncates towards zero, completing the rounding
+	n := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+
+	return Money{
+		Amount:   new(big.Rat).SetFrac(n, scale).FloatString(places),
+		Currency: m.Currency,
+	}, nil
+}
+
+// Cmp compares m and o, returning -1, 0 or +1. Amounts in different
+// currencies cannot be compared.
+func (m Money) Cmp(o Money) (int, error) {
+	diff, err := m.Sub(o)
+	if err != nil {
+		return 0, err
+	}
+	d, _ := diff.rat()
+	return d.Sign(), nil
+}
+
+// Places returns the number of decimal places written in the amount
+func (m Money) Places() int {
+	return decimals(m.Amount)
+}
+
+// Sum adds amounts of money, which must share a currency
+func Sum(amounts ...Money) (Money, error) {
+	total := Money{Amount: "0"}
+	for _, m := range amounts {
+		var err error
+		if total, err = total.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Money is written either as an
+// object with amount and currency or as a decimal string whose currency is
+// given by the context. JSON numbers are rejected because they are read as
+// floating point.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	trimmed := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(trimmed, `"`):
+		var amount string
+		if err := json.Unmarshal(data, &amount); err != nil {
+			return err
+		}
+		*m = Money{Amount: amount}
+	case strings.HasPrefix(trimmed, "{"):
+		type plain Money
+		var p plain
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		*m = Money(p)
+	default:
+		return fmt.Errorf("invalid amount %s: amounts must be written as decimal strings, such as \"12.50\"", trimmed)
+	}
+	return m.Validate()
+}
+
+// MarshalJSON implements json.Marshaler, writing amounts without a
+// currency as plain decimal strings
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m.Currency == "" {
+		return json.Marshal(m.Amount)
+	}
+	type plain Money
+	return json.Marshal(plain(m))
+}
+
+// combine applies op to two amounts in the same currency, keeping the
+// larger number of decimal places
+func (m Money) combine(o Money, op func(a, b *big.Rat) *big.Rat) (Money, error) {
+	currency := m.Currency
+	switch {
+	case currency == "":
+		currency = o.Currency
+	case o.Currency != "" && o.Currency != currency:
+		return Money{}, fmt.Errorf("currency mismatch: %s and %s", m.Currency, o.Currency)
+	}
+
+	a, err := m.rat()
+	if err != nil {
+		return Money{}, err
+	}
+	b, err := o.rat()
+	if err != nil {
+		return Money{}, err
+	}
+
+	places := decimals(m.Amount)
+	if p := decimals(o.Amount); p > places {
+		places = p
+	}
+	return Money{Amount: op(a, b).FloatString(places), Currency: currency}, nil
+}
+
+// rat returns the exact value of the amount
+func (m Money) rat() (*big.Rat, error) {
+	r, ok := parseDecimal(m.Amount)
+	if !ok || m.Amount == "" {
+		return nil, fmt.Errorf("invalid amount %q", m.Amount)
+	}
+	return r, nil
+}
@@ -0,0 +1,119 @@
+package nld
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMoneyArithmetic(t *testing.T) {
+	usd := func(amount string) Money { return Money{Amount: amount, Currency: "USD"} }
+
+	testCases := []struct {
+		name   string
+		op     func() (Money, error)
+		expect string
+	}{
+		{name: "Add", op: func() (Money, error) { return usd("0.10").Add(usd("0.2")) }, expect: "0.30 USD"},
+		{name: "Add Contextual Currency", op: func() (Money, error) { return Money{Amount: "1"}.Add(usd("2.50")) }, expect: "3.50 USD"},
+		{name: "Sub", op: func() (Money, error) { return usd("1.00").Sub(usd("1.01")) }, expect: "-0.01 USD"},
+		{name: "Mul", op: func() (Money, error) { return usd("0.333").Mul("3") }, expect: "0.999 USD"},
+		{name: "Percent", op: func() (Money, error) { return usd("1339.98").Percent("8.25") }, expect: "110.548350 USD"},
+		{name: "Round Up", op: func() (Money, error) { return usd("110.54835").Round(2) }, expect: "110.55 USD"},
+		{name: "Round Half Away From Zero", op: func() (Money, error) { return usd("-2.125").Round(2) }, expect: "-2.13 USD"},
+		{name: "Round To Integer", op: func() (Money, error) { return Money{Amount: "499.5", Currency: "JPY"}.Round(0) }, expect: "500 JPY"},
+		{name: "Sum", op: func() (Money, error) { return Sum(usd("0.10"), usd("0.20"), usd("0.30")) }, expect: "0.60 USD"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.op()
+			if err != nil {
+				t.Fatalf("Operation failed: %v", err)
+			}
+			if got.String() != tc.expect {
+				t.Errorf("Expected %s, got %s", tc.expect, got)
+			}
+		})
+	}
+
+	if _, err := usd("1").Add(Money{Amount: "1", Currency: "EUR"}); err == nil {
+		t.Errorf("Expected currency mismatch to be rejected")
+	}
+	if cmp, err := usd("1.0").Cmp(usd("1.00")); err != nil || cmp != 0 {
+		t.Errorf("Expected 1.0 and 1.00 to compare equal, got %d, %v", cmp, err)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expect      Money
+		expectError bool
+	}{
+		{name: "Object", input: `{"amount": "12.50", "currency": "EUR"}`, expect: Money{Amount: "12.50", Currency: "EUR"}},
+		{name: "String", input: `"12.50"`, expect: Money{Amount: "12.50"}},
+		{name: "Number", input: `12.5`, expectError: true},
+		{name: "Bad Amount", input: `"12,50"`, expectError: true},
+		{name: "Bad Currency", input: `{"amount": "1", "currency": "euro"}`, expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var m Money
+			err := json.Unmarshal([]byte(tc.input), &m)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got %v", m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if m != tc.expect {
+				t.Errorf("Expected %+v, got %+v", tc.expect, m)
+			}
+
+			out, err := json.Marshal(m)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			var back Money
+			if err := json.Unmarshal(out, &back); err != nil || back != m {
+				t.Errorf("Roundtrip changed %+v to %+v (%v)", m, back, err)
+			}
+		})
+	}
+}
+
+func TestCurrencyPrecision(t *testing.T) {
+	testCases := []struct {
+		currency    string
+		expect      int
+		expectError bool
+	}{
+		{currency: "USD", expect: 2},
+		{currency: "jpy", expect: 0},
+		{currency: "KWD", expect: 3},
+		{currency: "dollars", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.currency, func(t *testing.T) {
+			got, err := CurrencyPrecision(tc.currency)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("Expected error, got success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CurrencyPrecision failed: %v", err)
+			}
+			if got != tc.expect {
+				t.Errorf("Expected %d, got %d", tc.expect, got)
+			}
+		})
+	}
+}
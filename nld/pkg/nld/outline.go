@@ -0,0 +1,214 @@
+package nld
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Counter formats used by numbering levels
+const (
+	Decimal    = "decimal"
+	LowerAlpha = "lower-alpha"
+	UpperAlpha = "upper-alpha"
+	LowerRoman = "lower-roman"
+	UpperRoman = "upper-roman"
+)
+
+// NumberingLevel describes how sections at one depth are numbered
+type NumberingLevel struct {
+	// Format is the counter format, such as Decimal or LowerAlpha
+	Format string `json:"format"`
+	// Inherit prefixes the number with the parent's number and a dot
+	// (1.1, 1.1.a); otherwise Prefix and Suffix wrap the counter alone
+	// ((a), (i))
+	Inherit bool   `json:"inherit,omitempty"`
+	Prefix  string `json:"prefix,omitempty"`
+	Suffix  string `json:"suffix,omitempty"`
+}
+
+// NumberingStyle is a named set of numbering levels. Sections nested deeper
+// than the defined levels reuse the last level.
+type NumberingStyle struct {
+	Name   string           `json:"name"`
+	Levels []NumberingLevel `json:"levels"`
+}
+
+// numberingStyles are the built-in numbering styles
+var numberingStyles = map[string]NumberingStyle{
+	// 1, 1.1, 1.1.a, 1.1.a.i
+	"default": {Name: "default", Levels: []NumberingLevel{
+		{Format: Decimal},
+		{Format: Decimal, Inherit: true},
+		{Format: LowerAlpha, Inherit: true},
+		{Format: LowerRoman, Inherit: true},
+	}},
+	// 1, 1.1, 1.1.1
+	"decimal": {Name: "decimal", Levels: []NumberingLevel{
+		{Format: Decimal},
+		{Format: Decimal, Inherit: true},
+	}},
+	// Clauses, sub-clauses, paragraphs and sub-paragraphs as used in
+	// England and Wales: 1, 1.1, (a), (i)
+	"uk": {Name: "uk", Levels: []NumberingLevel{
+		{Format: Decimal},
+		{Format: Decimal, Inherit: true},
+		{Format: LowerAlpha, Prefix: "(", Suffix: ")"},
+		{Format: LowerRoman, Prefix: "(", Suffix: ")"},
+	}},
+	// Articles and sections as common in US agreements: Article I,
+	// Section 1, (a), (i)
+	"us": {Name: "us", Levels: []NumberingLevel{
+		{Format: UpperRoman, Prefix: "Article "},
+		{Format: Decimal, Prefix: "Section "},
+		{Format: LowerAlpha, Prefix: "(", Suffix: ")"},
+		{Format: LowerRoman, Prefix: "(", Suffix: ")"},
+	}},
+}
+
+// jurisdictionStyles maps words found in a jurisdiction to its customary
+// style
+var jurisdictionStyles = map[string]string{
+	"gb":      "uk",
+	"uk":      "uk",
+	"england": "uk",
+	"wales":   "uk",
+	"us":      "us",
+	"usa":     "us",
+}
+
+// NumberingStyles returns the names of the built-in numbering styles
+func NumberingStyles() []string {
+	names := make([]string, 0, len(numberingStyles))
+	for name := range numberingStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupNumberingStyle returns the built-in numbering style with the given
+// name
+func LookupNumberingStyle(name string) (NumberingStyle, error) {
+	style, ok := numberingStyles[strings.ToLower(name)]
+	if !ok {
+		return NumberingStyle{}, fmt.Errorf("unknown numbering style %q (available: %s)", name, strings.Join(NumberingStyles(), ", "))
+	}
+	return style, nil
+}
+
+// NumberingStyleFor returns the customary numbering style for a
+// jurisdiction such as "US-CA", "GB" or "California, USA", falling back to
+// the default style
+func NumberingStyleFor(jurisdiction string) NumberingStyle {
+	words := strings.FieldsFunc(strings.ToLower(jurisdiction), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		if name, ok := jurisdictionStyles[word]; ok {
+			return numberingStyles[name]
+		}
+	}
+	return numberingStyles["default"]
+}
+
+// OutlineEntry is one numbered section in a document outline
+type OutlineEntry struct {
+	Number string `json:"number"`
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	// Depth is 0 for top-level sections
+	Depth    int            `json:"depth"`
+	Children []OutlineEntry `json:"children,omitempty"`
+}
+
+// Outline numbers the sections of the document using style. A style with
+// no levels uses the customary style for the document's jurisdiction.
+func (d *Document) Outline(style NumberingStyle) []OutlineEntry {
+	if len(style.Levels) == 0 {
+		style = NumberingStyleFor(d.Metadata.Jurisdiction)
+	}
+	return outline(d.Structure.Sections, style, "", 0)
+}
+
+// Number formats the counter n (starting at 1) for the given depth,
+// beneath a parent numbered parent
+func (s NumberingStyle) Number(parent string, depth, n int) string {
+	if len(s.Levels) == 0 {
+		return strconv.Itoa(n)
+	}
+	level := s.Levels[len(s.Levels)-1]
+	if depth < len(s.Levels) {
+		level = s.Levels[depth]
+	}
+
+	counter := formatCounter(level.Format, n)
+	if level.Inherit && parent != "" {
+		return parent + "." + counter
+	}
+	return level.Prefix + counter + level.Suffix
+}
+
+// outline numbers a list of sibling sections
+func outline(sections []Section, style NumberingStyle, parent string, depth int) []OutlineEntry {
+	if len(sections) == 0 {
+		return nil
+	}
+
+	entries := make([]OutlineEntry, 0, len(sections))
+	for i, s := range sections {
+		number := style.Number(parent, depth, i+1)
+		entries = append(entries, OutlineEntry{
+			Number:   number,
+			ID:       s.ID,
+			Title:    s.Title,
+			Depth:    depth,
+			Children: outline(s.Sections, style, number, depth+1),
+		})
+	}
+	return entries
+}
+
+// formatCounter renders n in the given counter format
+func formatCounter(format string, n int) string {
+	switch format {
+	case LowerAlpha:
+		return alpha(n)
+	case UpperAlpha:
+		return strings.ToUpper(alpha(n))
+	case LowerRoman:
+		return strings.ToLower(roman(n))
+	case UpperRoman:
+		return roman(n)
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+// alpha renders n as a, b, ..., z, aa, ab, ...
+func alpha(n int) string {
+	var b []byte
+	for n > 0 {
+		n--
+		b = append([]byte{byte('a' + n%26)}, b...)
+		n /= 26
+	}
+	return string(b)
+}
+
+// roman renders n as an upper case Roman numeral
+func roman(n int) string {
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+
+	var sb strings.Builder
+	for i, v := range values {
+		for n >= v {
+			sb.WriteString(symbols[i])
+			n -= v
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,33 @@
+package nld
+
+// SignaturePolicy requires Required of a group of entities to sign a
+// document, such as two of three directors. The group is the entities
+// listed in Signers, or else those with the given Role.
+type SignaturePolicy struct {
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	// Signers are the IDs of the entities of the group
+	Signers []string `json:"signers,omitempty"`
+	// Role selects the entities of the group by their role when Signers is
+	// empty
+	Role     string `json:"role,omitempty"`
+	Required int    `json:"required"`
+}
+
+// PolicySigners returns the IDs of the entities that may sign for a
+// signature policy
+func (d *Document) PolicySigners(p SignaturePolicy) []string {
+	if len(p.Signers) > 0 {
+		return p.Signers
+	}
+	var ids []string
+	if p.Role == "" {
+		return ids
+	}
+	for _, e := range d.Metadata.Entities {
+		if e.Role == p.Role {
+			ids = append(ids, e.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,29 @@
+package nld
+
+// Change is an edit proposed to the plain text content of a section, kept
+// in the redline of the document until it is accepted or rejected. It
+// replaces the text in Range, which was Deleted when the change was
+// proposed, with Inserted: an insertion has an empty range and a deletion
+// inserts nothing.
+type Change struct {
+	ID string `json:"id"`
+	// Section is the ID of the changed section
+	Section  string    `json:"section"`
+	Range    TextRange `json:"range"`
+	Deleted  string    `json:"deleted,omitempty"`
+	Inserted string    `json:"inserted,omitempty"`
+	Author   string    `json:"author"`
+	Date     string    `json:"date"`
+}
+
+// SectionChanges returns the proposed changes of the section with the
+// given ID
+func (d *Document) SectionChanges(id string) []Change {
+	var out []Change
+	for _, c := range d.Redline {
+		if c.Section == id {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,239 @@
+package nld
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CheckRelationships validates the relationship graph: dependencies must
+// not form a cycle, and conditions must not trigger each other in a circle
+// or both trigger and negate the same condition.
+//
+// A condition triggers the conditions whose IDs appear as whole words in
+// its effect. An ID preceded by "not", "no" or "!" negates that condition
+// instead, as in the effect "not waiver".
+//
+// Fields are relative to the relationships object, such as
+// dependencies/2.
+func (r Relationships) CheckRelationships() []FieldError {
+	errs := r.checkDependencyCycles()
+	return append(errs, r.checkConditionChains()...)
+}
+
+// checkDependencyCycles reports every cycle of the dependency graph once,
+// at the dependency that closes it
+func (r Relationships) checkDependencyCycles() []FieldError {
+	type edge struct {
+		target string
+		index  int
+	}
+	edges := map[string][]edge{}
+	var order []string
+	for i, d := range r.Dependencies {
+		if _, ok := edges[d.Source]; !ok {
+			order = append(order, d.Source)
+		}
+		edges[d.Source] = append(edges[d.Source], edge{d.Target, i})
+	}
+
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := map[string]int{}
+	var path []string
+	var errs []FieldError
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = onPath
+		path = append(path, id)
+		for _, e := range edges[id] {
+			switch state[e.target] {
+			case unvisited:
+				visit(e.target)
+			case onPath:
+				cycle := append(cyclePath(path, e.target), e.target)
+				errs = append(errs, FieldError{
+					Field:   fmt.Sprintf("dependencies/%d", e.index),
+					Message: "dependency cycle: " + strings.Join(cycle, " -> "),
+				})
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+	}
+	for _, id := range order {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return errs
+}
+
+// cyclePath returns the part of path starting at id
+func cyclePath(path []string, id string) []string {
+	for i, p := range path {
+		if p == id {
+			return append([]string(nil), path[i:]...)
+		}
+	}
+	return nil
+}
+
+// trigger is a condition named in the effect of another
+type trigger struct {
+	id      string
+	negated bool
+}
+
+var (
+	conditionIDRe = regexp.MustCompile(`[A-Za-z0-9_][A-Za-z0-9_.-]*[A-Za-z0-9_]|[A-Za-z0-9_]`)
+	negationRe    = regexp.MustCompile(`(?i)(\bno(t)?\s+|!\s*)$`)
+)
+
+// triggers returns the conditions named in the effect of c
+func (r Relationships) triggers(c Condition) []trigger {
+	ids := map[string]bool{}
+	for _, other := range r.Conditions {
+		ids[other.ID] = true
+	}
+	var list []trigger
+	for _, loc := range conditionIDRe.FindAllStringIndex(c.Effect, -1) {
+		id := c.Effect[loc[0]:loc[1]]
+		if ids[id] {
+			list = append(list, trigger{id: id, negated: negationRe.MatchString(c.Effect[:loc[0]])})
+		}
+	}
+	return list
+}
+
+// checkConditionChains reports circular and contradictory condition chains
+// at the condition that starts them. A condition that only inherits a
+// contradiction from a condition it triggers is not reported again.
+func (r Relationships) checkConditionChains() []FieldError {
+	index := map[string]int{}
+	triggers := map[string][]trigger{}
+	var ids []string
+	for i, c := range r.Conditions {
+		if _, dup := index[c.ID]; !dup {
+			index[c.ID] = i
+			ids = append(ids, c.ID)
+		}
+		triggers[c.ID] = append(triggers[c.ID], r.triggers(c)...)
+	}
+
+	var errs []FieldError
+	reported := map[string]bool{}
+	contradictions := map[string]string{}
+	reach := map[string]map[string][]trigger{}
+	for _, id := range ids {
+		reach[id] = conditionChains(id, triggers)
+	}
+
+	for _, id := range ids {
+		if reported[id] {
+			continue
+		}
+		if chain, ok := reach[id][id]; ok {
+			for _, t := range chain {
+				reported[t.id] = true
+			}
+			errs = append(errs, FieldError{
+				Field:   fmt.Sprintf("conditions/%d", index[id]),
+				Message: "condition cycle: " + formatChain(id, chain),
+			})
+			continue
+		}
+
+		// A condition contradicts the chain when it negates a condition
+		// the chain triggers, or the first condition itself
+		from := append([]string{id}, sortedKeys(reach[id])...)
+		for _, m := range from {
+			for _, t := range triggers[m] {
+				if !t.negated {
+					continue
+				}
+				negation := formatChain(id, append(append([]trigger(nil), reach[id][m]...), t))
+				if t.id == id {
+					contradictions[id] = "contradictory conditions: " + negation
+				} else if chain, ok := reach[id][t.id]; ok {
+					contradictions[id] = fmt.Sprintf("contradictory conditions: %s and %s", formatChain(id, chain), negation)
+				}
+			}
+			if _, found := contradictions[id]; found {
+				break
+			}
+		}
+	}
+
+	for _, id := range ids {
+		message, ok := contradictions[id]
+		if !ok || reported[id] {
+			continue
+		}
+		inherited := false
+		for other := range reach[id] {
+			if _, bad := contradictions[other]; bad && other != id {
+				inherited = true
+			}
+		}
+		if !inherited {
+			errs = append(errs, FieldError{Field: fmt.Sprintf("conditions/%d", index[id]), Message: message})
+		}
+	}
+	return errs
+}
+
+// conditionChains returns the shortest chain of triggers from id to every
+// condition it triggers, directly or through other conditions. Negated
+// conditions do not trigger anything.
+func conditionChains(id string, triggers map[string][]trigger) map[string][]trigger {
+	reach := map[string][]trigger{}
+	queue := []string{id}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, t := range triggers[from] {
+			if t.negated {
+				continue
+			}
+			if _, seen := reach[t.id]; seen {
+				continue
+			}
+			reach[t.id] = append(append([]trigger(nil), reach[from]...), t)
+			queue = append(queue, t.id)
+		}
+	}
+	return reach
+}
+
+// sortedKeys returns the conditions of a chain map, shortest chain first
+func sortedKeys(chains map[string][]trigger) []string {
+	keys := make([]string, 0, len(chains))
+	for k := range chains {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(chains[keys[i]]) != len(chains[keys[j]]) {
+			return len(chains[keys[i]]) < len(chains[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// formatChain writes a chain of triggers starting at id
+func formatChain(id string, chain []trigger) string {
+	names := []string{id}
+	for _, t := range chain {
+		if t.negated {
+			names = append(names, "not "+t.id)
+		} else {
+			names = append(names, t.id)
+		}
+	}
+	return strings.Join(names, " -> ")
+}
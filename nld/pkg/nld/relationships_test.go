@@ -0,0 +1,71 @@
+package nld
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckRelationships(t *testing.T) {
+	dep := func(source, target string) Relationship {
+		return Relationship{Source: source, Target: target, Type: "requires"}
+	}
+	cond := func(id, effect string) Condition {
+		return Condition{ID: id, Predicate: "payment is late", Effect: effect}
+	}
+
+	testCases := []struct {
+		name   string
+		rel    Relationships
+		expect []string
+	}{
+		{
+			name: "Acyclic",
+			rel: Relationships{
+				Dependencies: []Relationship{dep("a", "b"), dep("b", "c"), dep("a", "c")},
+				Conditions:   []Condition{cond("late", "interest applies and suspension applies"), cond("interest", "fees increase"), cond("suspension", "not waiver"), cond("waiver", "none")},
+			},
+		},
+		{
+			name:   "Dependency Cycle",
+			rel:    Relationships{Dependencies: []Relationship{dep("a", "b"), dep("b", "c"), dep("c", "a"), dep("d", "a")}},
+			expect: []string{"dependencies/2: dependency cycle: a -> b -> c -> a"},
+		},
+		{
+			name:   "Self Dependency",
+			rel:    Relationships{Dependencies: []Relationship{dep("a", "a")}},
+			expect: []string{"dependencies/0: dependency cycle: a -> a"},
+		},
+		{
+			name:   "Condition Cycle",
+			rel:    Relationships{Conditions: []Condition{cond("default", "triggers breach"), cond("breach", "triggers default"), cond("notice", "starts breach")}},
+			expect: []string{"conditions/0: condition cycle: default -> breach -> default"},
+		},
+		{
+			name: "Contradiction",
+			rel: Relationships{Conditions: []Condition{
+				cond("notice", "late applies"),
+				cond("late", "suspension and refund apply"),
+				cond("suspension", "no refund"),
+				cond("refund", "credit issued"),
+			}},
+			expect: []string{"conditions/1: contradictory conditions: late -> refund and late -> suspension -> not refund"},
+		},
+		{
+			name:   "Self Contradiction",
+			rel:    Relationships{Conditions: []Condition{cond("renewal", "extension applies"), cond("extension", "!renewal")}},
+			expect: []string{"conditions/0: contradictory conditions: renewal -> extension -> not renewal"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, e := range tc.rel.CheckRelationships() {
+				got = append(got, e.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected errors:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
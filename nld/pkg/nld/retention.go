@@ -0,0 +1,41 @@
+package nld
+
+import (
+	"fmt"
+	"time"
+)
+
+// Retention says how long a document must be kept. A document under legal
+// hold must be kept whatever its retention period, until the hold is
+// released.
+type Retention struct {
+	// RetainUntil is the date (2032-06-30) or datetime until which the
+	// document must be kept; documents without one are kept indefinitely
+	RetainUntil string `json:"retainUntil,omitempty"`
+	LegalHold   bool   `json:"legalHold,omitempty"`
+	// HoldReason records why the document is under legal hold, such as the
+	// matter it is held for
+	HoldReason string `json:"holdReason,omitempty"`
+}
+
+// RetainUntil returns the end of the retention period of the document, or
+// the zero time when it has none
+func (m Metadata) RetainUntil() (time.Time, error) {
+	if m.Retention == nil || m.Retention.RetainUntil == "" {
+		return time.Time{}, nil
+	}
+	loc, err := m.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, _, err := ParseDate(m.Retention.RetainUntil, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("retention.retainUntil: %w", err)
+	}
+	return t, nil
+}
+
+// LegalHold reports whether the document is under legal hold
+func (m Metadata) LegalHold() bool {
+	return m.Retention != nil && m.Retention.LegalHold
+}
@@ -0,0 +1,126 @@
+package nld
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/workflow"
+)
+
+// Revision records one amendment in a document's history
+type Revision struct {
+	// Number is the revision number this entry created, starting at 2 for
+	// the first amendment of an original document
+	Number int `json:"number"`
+	// Previous is the root hash of the revision that was amended
+	Previous string `json:"previous"`
+	Date     string `json:"date"`
+	Author   string `json:"author,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+}
+
+// CurrentRevision returns the revision number of the document. Documents
+// that have never been amended are revision 1.
+func (d *Document) CurrentRevision() int {
+	if d.Metadata.Revision > 0 {
+		return d.Metadata.Revision
+	}
+	return 1
+}
+
+// History returns the amendment history recorded in the document, oldest
+// first
+func (d *Document) History() []Revision {
+	return d.Revisions
+}
+
+// Amend creates the next revision of the document. The new revision
+// references the root hash of d, carries its full history forward, and has
+// no verification data since it must be signed again. A document with a
+// lifecycle status starts over as a draft.
+func (d *Document) Amend(author, summary string, now time.Time) (*Document, error) {
+	prev, err := d.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash document: %w", err)
+	}
+
+	// Deep copy the document so the new revision can be edited freely
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	var next Document
+	if err := json.Unmarshal(data, &next); err != nil {
+		return nil, err
+	}
+
+	next.Verification = Verification{}
+	next.Metadata.Revision = d.CurrentRevision() + 1
+	if next.Metadata.Status != "" {
+		next.Metadata.Status = string(workflow.Draft)
+	}
+	next.Revisions = append(append([]Revision(nil), d.Revisions...), Revision{
+		Number:   next.Metadata.Revision,
+		Previous: prev,
+		Date:     now.UTC().Format(time.RFC3339),
+		Author:   author,
+		Summary:  summary,
+	})
+
+	return &next, nil
+}
+
+// Chain orders a set of revisions of the same document from the original
+// to the latest amendment, checking that each revision references the hash
+// of the one before it.
+func Chain(docs []*Document) ([]*Document, error) {
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	byHash := make(map[string]*Document, len(docs))
+	for _, doc := range docs {
+		hash, err := doc.Hash()
+		if err != nil {
+			return nil, err
+		}
+		byHash[hash] = doc
+	}
+
+	// Link every amendment to the revision it references. The chain starts
+	// at the one document whose predecessor is not part of the set, which
+	// is normally the unamended original.
+	next := make(map[*Document]*Document, len(docs))
+	var first *Document
+	for _, doc := range docs {
+		var prev *Document
+		if len(doc.Revisions) > 0 {
+			prev = byHash[doc.Revisions[len(doc.Revisions)-1].Previous]
+		}
+		if prev == nil {
+			if first != nil {
+				return nil, fmt.Errorf("documents do not form a single amendment chain: revisions %d and %d both start a chain",
+					first.CurrentRevision(), doc.CurrentRevision())
+			}
+			first = doc
+			continue
+		}
+		if _, taken := next[prev]; taken {
+			return nil, fmt.Errorf("revision %d has more than one amendment", prev.CurrentRevision())
+		}
+		next[prev] = doc
+	}
+	if first == nil {
+		return nil, fmt.Errorf("documents do not form a single amendment chain: cycle detected")
+	}
+
+	chain := []*Document{first}
+	for cur := first; next[cur] != nil; cur = next[cur] {
+		chain = append(chain, next[cur])
+	}
+	if len(chain) != len(docs) {
+		return nil, fmt.Errorf("documents do not form a single amendment chain")
+	}
+	return chain, nil
+}
@@ -0,0 +1,65 @@
+package nld
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// nonSigningRoles are the entity roles that take part in a document
+// without signing it. Witnesses attest documents instead.
+var nonSigningRoles = []string{"Witness", "Observer", "Recipient"}
+
+// CanSign reports whether the role of an entity is one that signs
+func (e Entity) CanSign() bool {
+	for _, r := range nonSigningRoles {
+		if strings.EqualFold(e.Role, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasKey reports whether the entity may sign with the key keyID: one of
+// its registered keys, or any key when it has none registered
+func (e Entity) HasKey(keyID string) bool {
+	return len(e.KeyIDs) == 0 || slices.Contains(e.KeyIDs, keyID)
+}
+
+// CheckSigners validates the signatures against the entities: every
+// signer must be an entity with a role that signs, no entity signs twice,
+// and the cryptographic signatures of entities with registered keys must
+// use one of them. Fields are relative to verification.
+func (d *Document) CheckSigners() []FieldError {
+	entities := map[string]Entity{}
+	for _, e := range d.Metadata.Entities {
+		entities[e.ID] = e
+	}
+
+	var errs []FieldError
+	first := map[string]int{}
+	for i, sig := range d.Verification.Signatures {
+		field := fmt.Sprintf("signatures/%d", i)
+		e, ok := entities[sig.SignerID]
+		switch {
+		case !ok:
+			errs = append(errs, FieldError{Field: field + "/signerId", Message: fmt.Sprintf("signer %q is not an entity of the document", sig.SignerID)})
+			continue
+		case !e.CanSign():
+			errs = append(errs, FieldError{Field: field + "/signerId", Message: fmt.Sprintf("entity %s is a %s, which does not sign", e.ID, e.Role)})
+		}
+		// Placeholder signatures without a value are not signatures yet
+		if sig.Value == "" {
+			continue
+		}
+		if j, dup := first[sig.SignerID]; dup {
+			errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf("entity %s already signed (signatures/%d)", e.ID, j)})
+		} else {
+			first[sig.SignerID] = i
+		}
+		if sig.KeyID != "" && !e.HasKey(sig.KeyID) {
+			errs = append(errs, FieldError{Field: field + "/keyId", Message: fmt.Sprintf("key %s is not registered for entity %s", sig.KeyID, e.ID)})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,64 @@
+package nld
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckSigners(t *testing.T) {
+	const key1 = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	const key2 = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	entities := []Entity{
+		{ID: "provider", Role: "Service Provider", KeyIDs: []string{key1}},
+		{ID: "client", Role: "Client"},
+		{ID: "witness", Role: "witness"},
+	}
+
+	testCases := []struct {
+		name       string
+		signatures []Signature
+		expect     []string
+	}{
+		{
+			name: "Valid",
+			signatures: []Signature{
+				{SignerID: "provider", Value: "a", KeyID: key1},
+				{SignerID: "client", Value: "b", KeyID: key2},
+				{SignerID: "client", Value: ""},
+			},
+		},
+		{
+			name:       "Unknown Signer",
+			signatures: []Signature{{SignerID: "mallory", Value: "a"}},
+			expect:     []string{`signatures/0/signerId: signer "mallory" is not an entity of the document`},
+		},
+		{
+			name:       "Witness",
+			signatures: []Signature{{SignerID: "witness", Value: "a"}},
+			expect:     []string{`signatures/0/signerId: entity witness is a witness, which does not sign`},
+		},
+		{
+			name:       "Signed Twice",
+			signatures: []Signature{{SignerID: "client", Value: "a"}, {SignerID: "client", Value: "docusign:env-1"}},
+			expect:     []string{`signatures/1: entity client already signed (signatures/0)`},
+		},
+		{
+			name:       "Unregistered Key",
+			signatures: []Signature{{SignerID: "provider", Value: "a", KeyID: key2}},
+			expect:     []string{`signatures/0/keyId: key ` + key2 + ` is not registered for entity provider`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Document{Metadata: Metadata{Entities: entities}, Verification: Verification{Signatures: tc.signatures}}
+			var got []string
+			for _, e := range d.CheckSigners() {
+				got = append(got, e.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tc.expect, "\n") {
+				t.Errorf("Expected errors:\n%s\ngot:\n%s", strings.Join(tc.expect, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
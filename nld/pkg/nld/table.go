@@ -0,0 +1,200 @@
+package nld
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Column types for table blocks
+const (
+	ColumnText   = "text"
+	ColumnNumber = "number"
+	ColumnDate   = "date"
+)
+
+// decimalPattern matches the plain decimal numbers accepted in number
+// columns. Exponents and thousands separators are rejected so that the
+// written value is the exact value.
+var decimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// Column describes one column of a table block
+type Column struct {
+	Title string `json:"title"`
+	// Type is ColumnText (the default), ColumnNumber or ColumnDate
+	Type string `json:"type,omitempty"`
+	// Total adds the sum of the column to the table's totals row
+	Total bool `json:"total,omitempty"`
+	// Key is a machine readable name for the column. Receipt line item
+	// tables use the keys "quantity", "unitPrice" and "amount".
+	Key string `json:"key,omitempty"`
+}
+
+// TableError describes a problem with a table cell or column
+type TableError struct {
+	// Row and Column locate the problem; Row is -1 for column definitions
+	Row     int
+	Column  int
+	Message string
+}
+
+// Error implements the error interface
+func (e TableError) Error() string {
+	if e.Row < 0 {
+		return fmt.Sprintf("column %d: %s", e.Column+1, e.Message)
+	}
+	return fmt.Sprintf("row %d, column %d: %s", e.Row+1, e.Column+1, e.Message)
+}
+
+// TableColumns returns the columns of a table block. Tables that only have
+// a header, or neither, get text columns.
+func (b Block) TableColumns() []Column {
+	if len(b.Columns) > 0 {
+		return b.Columns
+	}
+
+	header := b.Header
+	if len(header) == 0 && len(b.Rows) > 0 {
+		header = make([]string, len(b.Rows[0]))
+	}
+	columns := make([]Column, len(header))
+	for i, title := range header {
+		columns[i] = Column{Title: title, Type: ColumnText}
+	}
+	return columns
+}
+
+// TableHeader returns the header row and body rows of a table block.
+// Tables without columns or a header use their first row as the header.
+func (b Block) TableHeader() ([]string, [][]string) {
+	if len(b.Columns) > 0 {
+		header := make([]string, len(b.Columns))
+		for i, c := range b.Columns {
+			header[i] = c.Title
+		}
+		return header, b.Rows
+	}
+	if len(b.Header) > 0 || len(b.Rows) == 0 {
+		return b.Header, b.Rows
+	}
+	return b.Rows[0], b.Rows[1:]
+}
+
+// CheckTable reports rows whose length differs from the number of columns,
+// cells that do not match their column type, and totals on non-numeric
+// columns
+func (b Block) CheckTable() []TableError {
+	columns := b.TableColumns()
+	var errs []TableError
+
+	for i, c := range columns {
+		switch c.Type {
+		case "", ColumnText, ColumnNumber, ColumnDate:
+		default:
+			errs = append(errs, TableError{Row: -1, Column: i, Message: fmt.Sprintf("unknown column type %q", c.Type)})
+		}
+		if c.Total && c.Type != ColumnNumber {
+			errs = append(errs, TableError{Row: -1, Column: i, Message: "totals require a number column"})
+		}
+	}
+
+	for r, row := range b.Rows {
+		if len(row) != len(columns) {
+			errs = append(errs, TableError{Row: r, Column: len(row), Message: fmt.Sprintf("expected %d cells, got %d", len(columns), len(row))})
+		}
+		for i, cell := range row {
+			if i >= len(columns) {
+				break
+			}
+			if msg := checkCell(columns[i].Type, cell); msg != "" {
+				errs = append(errs, TableError{Row: r, Column: i, Message: msg})
+			}
+		}
+	}
+	return errs
+}
+
+// TableTotals returns the totals row of a table block, or nil when no
+// column is totalled. The first column holds the total label unless it is
+// itself totalled.
+func (b Block) TableTotals() ([]string, error) {
+	columns := b.TableColumns()
+	totalled := false
+	for _, c := range columns {
+		totalled = totalled || c.Total
+	}
+	if !totalled {
+		return nil, nil
+	}
+
+	totals := make([]string, len(columns))
+	for i, c := range columns {
+		if !c.Total {
+			continue
+		}
+		sum := new(big.Rat)
+		scale := 0
+		for r, row := range b.Rows {
+			if i >= len(row) {
+				continue
+			}
+			v, ok := parseDecimal(row[i])
+			if !ok {
+				return nil, TableError{Row: r, Column: i, Message: fmt.Sprintf("%q is not a number", row[i])}
+			}
+			sum.Add(sum, v)
+			if d := decimals(row[i]); d > scale {
+				scale = d
+			}
+		}
+		totals[i] = sum.FloatString(scale)
+	}
+
+	if !columns[0].Total {
+		totals[0] = b.TotalLabel
+		if totals[0] == "" {
+			totals[0] = "Total"
+		}
+	}
+	return totals, nil
+}
+
+// checkCell returns a message when cell does not match the column type
+func checkCell(columnType, cell string) string {
+	if cell == "" {
+		return ""
+	}
+	switch columnType {
+	case ColumnNumber:
+		if _, ok := parseDecimal(cell); !ok {
+			return fmt.Sprintf("%q is not a number", cell)
+		}
+	case ColumnDate:
+		if _, err := time.Parse("2006-01-02", cell); err != nil {
+			return fmt.Sprintf("%q is not a date (YYYY-MM-DD)", cell)
+		}
+	}
+	return ""
+}
+
+// parseDecimal parses a plain decimal number exactly. Empty cells count as
+// zero.
+func parseDecimal(s string) (*big.Rat, bool) {
+	if s == "" {
+		return new(big.Rat), true
+	}
+	if !decimalPattern.MatchString(s) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// decimals returns the number of digits after the decimal point
+func decimals(s string) int {
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
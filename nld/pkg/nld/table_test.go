@@ -0,0 +1,93 @@
+package nld
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckTable(t *testing.T) {
+	columns := []Column{
+		{Title: "Item"},
+		{Title: "Quantity", Type: ColumnNumber},
+		{Title: "Delivered", Type: ColumnDate},
+		{Title: "Amount", Type: ColumnNumber, Total: true},
+	}
+
+	testCases := []struct {
+		name   string
+		block  Block
+		expect []string
+	}{
+		{
+			name:  "Consistent",
+			block: Block{Type: BlockTable, Columns: columns, Rows: [][]string{{"Widget", "2", "2025-07-01", "20.00"}, {"Gadget", "1", "", "5.5"}}},
+		},
+		{
+			name:   "Short Row",
+			block:  Block{Type: BlockTable, Columns: columns, Rows: [][]string{{"Widget", "2", "2025-07-01"}}},
+			expect: []string{"row 1, column 4: expected 4 cells, got 3"},
+		},
+		{
+			name:   "Bad Cells",
+			block:  Block{Type: BlockTable, Columns: columns, Rows: [][]string{{"Widget", "two", "01/07/2025", "1e3"}}},
+			expect: []string{"row 1, column 2", "row 1, column 3", "row 1, column 4"},
+		},
+		{
+			name:   "Total On Text Column",
+			block:  Block{Type: BlockTable, Columns: []Column{{Title: "Item", Total: true}}, Rows: [][]string{{"Widget"}}},
+			expect: []string{"column 1: totals require a number column"},
+		},
+		{
+			name:   "Header Only",
+			block:  Block{Type: BlockTable, Header: []string{"A", "B"}, Rows: [][]string{{"1", "2"}, {"3"}}},
+			expect: []string{"row 2, column 2: expected 2 cells, got 1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := tc.block.CheckTable()
+			if len(errs) != len(tc.expect) {
+				t.Fatalf("Expected %d errors, got %v", len(tc.expect), errs)
+			}
+			for i, e := range errs {
+				if !strings.Contains(e.Error(), tc.expect[i]) {
+					t.Errorf("Expected error containing %q, got %q", tc.expect[i], e.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestTableTotals(t *testing.T) {
+	block := Block{
+		Type: BlockTable,
+		Columns: []Column{
+			{Title: "Item"},
+			{Title: "Quantity", Type: ColumnNumber, Total: true},
+			{Title: "Amount", Type: ColumnNumber, Total: true},
+		},
+		Rows:       [][]string{{"Widget", "2", "0.10"}, {"Gadget", "1", "0.2"}, {"Service", "", "1000.005"}},
+		TotalLabel: "Subtotal",
+	}
+
+	totals, err := block.TableTotals()
+	if err != nil {
+		t.Fatalf("TableTotals failed: %v", err)
+	}
+	expected := []string{"Subtotal", "3", "1000.305"}
+	if strings.Join(totals, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected totals %v, got %v", expected, totals)
+	}
+
+	header, rows := block.TableHeader()
+	if strings.Join(header, ",") != "Item,Quantity,Amount" || len(rows) != 3 {
+		t.Errorf("Unexpected header %v or rows %v", header, rows)
+	}
+
+	block.Columns[1].Total = false
+	block.Columns[2].Total = false
+	if totals, _ := block.TableTotals(); totals != nil {
+		t.Errorf("Expected no totals row, got %v", totals)
+	}
+}
@@ -0,0 +1,22 @@
+package nldcli
+
+import (
+	"fmt"
+	"os"
+	// Embed the time zone database so document time zones resolve on
+	// systems without one
+	_ "time/tzdata"
+
+	"github.com/colemalphrus/nld/internal/cli"
+)
+
+// Main runs nld with the command line arguments of the process and exits.
+// Programs that compile in commands registered with package plugin call it
+// from their main function.
+func Main() {
+	c := cli.New()
+	if err := c.Execute(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cli.ExitCode(err))
+	}
+}
@@ -0,0 +1,243 @@
+package nldclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Options configures a client
+type Options struct {
+	// Token is sent as a bearer token with every request when set
+	Token string
+	// HTTPClient sends the requests; nil uses http.DefaultClient
+	HTTPClient *http.Client
+	// Retries is how many times a request is retried after a network
+	// error or a 429, 502, 503 or 504 response. Negative disables retries;
+	// zero retries 3 times.
+	Retries int
+	// Backoff is the wait before the first retry, doubled for each one
+	// after it; zero waits 200ms. A Retry-After header takes precedence.
+	Backoff time.Duration
+}
+
+// Client calls the HTTP API served by nld serve. Its methods are safe for
+// concurrent use.
+type Client struct {
+	base *url.URL
+	opts Options
+}
+
+// Problem is a validation error or warning
+type Problem struct {
+	// Field is the JSON pointer of the value with the problem
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// ValidationResult is the outcome of validating a document
+type ValidationResult struct {
+	Valid    bool      `json:"valid"`
+	Errors   []Problem `json:"errors"`
+	Warnings []Problem `json:"warnings"`
+}
+
+// RenderOptions controls how a document is rendered
+type RenderOptions struct {
+	// Format is markdown, html or text; empty renders html
+	Format     string   `json:"format,omitempty"`
+	Lang       string   `json:"lang,omitempty"`
+	Locales    []string `json:"locales,omitempty"`
+	Unnumbered bool     `json:"unnumbered,omitempty"`
+}
+
+// StoredDocument describes a document version held by the server
+type StoredDocument struct {
+	ID      string    `json:"id"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Size    int64     `json:"size"`
+	// Digest is the SHA-256 digest of the stored bytes
+	Digest string `json:"digest"`
+	Type   string `json:"type,omitempty"`
+	Title  string `json:"title,omitempty"`
+}
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("nld serve: %s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// New returns a client for the API at baseURL, such as
+// http://localhost:8080
+func New(baseURL string, opts Options) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.Retries == 0 {
+		opts.Retries = 3
+	}
+	if opts.Backoff == 0 {
+		opts.Backoff = 200 * time.Millisecond
+	}
+	return &Client{base: base, opts: opts}, nil
+}
+
+// Validate validates a document against the schema for its type. An
+// invalid document is not an error; see ValidationResult.Valid.
+func (c *Client) Validate(ctx context.Context, doc []byte) (*ValidationResult, error) {
+	var result ValidationResult
+	req := map[string]json.RawMessage{"document": doc}
+	if err := c.do(ctx, http.MethodPost, "/v1/validate", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Render renders a document and returns the rendering
+func (c *Client) Render(ctx context.Context, doc []byte, opts RenderOptions) (string, error) {
+	req := struct {
+		Document json.RawMessage `json:"document"`
+		RenderOptions
+	}{doc, opts}
+	var resp struct {
+		Output string `json:"output"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/render", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.Output, nil
+}
+
+// Sign records the digest of a document in verification.digest and returns
+// the signed document and its root hash
+func (c *Client) Sign(ctx context.Context, doc []byte) ([]byte, string, error) {
+	var resp struct {
+		Document json.RawMessage `json:"document"`
+		Root     string          `json:"root"`
+	}
+	req := map[string]json.RawMessage{"document": doc}
+	if err := c.do(ctx, http.MethodPost, "/v1/sign", req, &resp); err != nil {
+		return nil, "", err
+	}
+	return resp.Document, resp.Root, nil
+}
+
+// StoreDocument stores a document under id and returns the version stored
+func (c *Client) StoreDocument(ctx context.Context, id string, doc []byte) (*StoredDocument, error) {
+	var stored StoredDocument
+	if err := c.do(ctx, http.MethodPut, "/v1/documents/"+url.PathEscape(id), json.RawMessage(doc), &stored); err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// do sends a request with a JSON body and decodes the JSON response into
+// out, retrying transient failures
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	endpoint := c.base.String() + path
+
+	wait := c.opts.Backoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.send(ctx, method, endpoint, body, out)
+		if err == nil {
+			return nil
+		}
+		if retryAfter < 0 || attempt >= c.opts.Retries || ctx.Err() != nil {
+			return err
+		}
+		if retryAfter == 0 {
+			retryAfter = wait
+			wait *= 2
+		}
+		timer := time.NewTimer(retryAfter)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send sends a request once. The returned duration is negative when the
+// request must not be retried, and otherwise the wait the server asked
+// for, if any.
+func (c *Client) send(ctx context.Context, method, endpoint string, body []byte, out interface{}) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	}
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return -1, err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			apiErr.Message = e.Error
+		}
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return retryAfter(resp.Header.Get("Retry-After")), apiErr
+		}
+		return -1, apiErr
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return -1, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return 0, nil
+}
+
+// retryAfter returns the wait of a Retry-After header given in seconds,
+// or zero
+func retryAfter(header string) time.Duration {
+	var seconds int
+	if _, err := fmt.Sscanf(header, "%d", &seconds); err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
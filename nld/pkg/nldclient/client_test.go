@@ -0,0 +1,105 @@
+package nldclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/colemalphrus/nld/internal/server"
+	"github.com/colemalphrus/nld/internal/store"
+)
+
+func readExample(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "examples", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestClient(t *testing.T) {
+	ts := httptest.NewServer(server.New(server.Options{Store: store.NewFS(t.TempDir())}))
+	defer ts.Close()
+	c, err := New(ts.URL, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	result, err := c.Validate(ctx, readExample(t, "invalid-missing-fields.json"))
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if result.Valid || len(result.Errors) != 3 {
+		t.Errorf("Validate() = %+v, want 3 errors", result)
+	}
+
+	out, err := c.Render(ctx, readExample(t, "valid-contract.json"), RenderOptions{Format: "text"})
+	if err != nil || out == "" {
+		t.Errorf("Render() = %q, %v", out, err)
+	}
+
+	signed, root, err := c.Sign(ctx, readExample(t, "valid-contract.json"))
+	if err != nil || !strings.HasPrefix(root, "sha256:") || !strings.Contains(string(signed), root) {
+		t.Errorf("Sign() = %s, %q, %v", signed, root, err)
+	}
+
+	stored, err := c.StoreDocument(ctx, "lease", readExample(t, "valid-contract.json"))
+	if err != nil || stored.ID != "lease" || stored.Version != 1 || stored.Type != "contract" {
+		t.Errorf("StoreDocument() = %+v, %v", stored, err)
+	}
+
+	_, err = c.Render(ctx, []byte(`{}`), RenderOptions{Format: "pdf"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Render() with an unknown format error = %v", err)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	var calls atomic.Int32
+	var token string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("Authorization")
+		if calls.Add(1) < 3 {
+			http.Error(w, `{"error": "busy"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"valid": true, "errors": [], "warnings": []}`))
+	}))
+	defer ts.Close()
+
+	c, _ := New(ts.URL, Options{Token: "secret", Backoff: time.Millisecond})
+	result, err := c.Validate(context.Background(), []byte(`{}`))
+	if err != nil || !result.Valid {
+		t.Fatalf("Validate() = %+v, %v", result, err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 3", calls.Load())
+	}
+	if token != "Bearer secret" {
+		t.Errorf("Authorization = %q", token)
+	}
+
+	calls.Store(0)
+	c, _ = New(ts.URL, Options{Retries: -1})
+	var apiErr *Error
+	if _, err := c.Validate(context.Background(), []byte(`{}`)); !errors.As(err, &apiErr) || apiErr.Message != "busy" {
+		t.Errorf("Validate() without retries error = %v", err)
+	}
+
+	calls.Store(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Validate(ctx, []byte(`{}`)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Validate() with a cancelled context error = %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package plugin
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+// Command is a command compiled into nld. Programs register commands in an
+// init function and run nld with nldcli.Main:
+//
+//	func init() {
+//		plugin.Register(plugin.Command{
+//			Name:  "crm-sync",
+//			Short: "Push signed contracts to the CRM",
+//			Run:   syncContracts,
+//		})
+//	}
+//
+//	func main() { nldcli.Main() }
+type Command struct {
+	// Name is the command name, such as crm-sync
+	Name    string
+	Short   string
+	Long    string
+	Example string
+	// Run runs the command. Args are the command line arguments after the
+	// command name, without the global flags of nld; the command parses
+	// its own flags.
+	Run func(ctx *Context, args []string) error
+}
+
+// Context is what nld passes to a command: the global flags and where to
+// read and write
+type Context struct {
+	OutputFormat string
+	Quiet        bool
+	Verbose      bool
+	// Lang is the language selected with --lang, empty for the default
+	Lang   string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Logger receives diagnostic messages as configured by the --log-*
+	// flags
+	Logger *slog.Logger
+}
+
+var (
+	mu       sync.Mutex
+	commands = map[string]Command{}
+)
+
+// Register adds a command to nld. It panics if the command has no name or
+// Run function, or if a command of the same name is already registered.
+func Register(cmd Command) {
+	mu.Lock()
+	defer mu.Unlock()
+	if cmd.Name == "" || cmd.Run == nil {
+		panic("plugin: Register called with a command without a name or Run function")
+	}
+	if _, dup := commands[cmd.Name]; dup {
+		panic(fmt.Sprintf("plugin: Register called twice for command %q", cmd.Name))
+	}
+	commands[cmd.Name] = cmd
+}
+
+// Commands returns the registered commands sorted by name
+func Commands() []Command {
+	mu.Lock()
+	defer mu.Unlock()
+	list := make([]Command, 0, len(commands))
+	for _, cmd := range commands {
+		list = append(list, cmd)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
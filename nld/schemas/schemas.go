@@ -0,0 +1,9 @@
+package schemas
+
+import "embed"
+
+// FS holds the schemas shipped with nld, so that they can be used without
+// a schemas directory beside the executable, as in the browser
+//
+//go:embed *.json
+var FS embed.FS